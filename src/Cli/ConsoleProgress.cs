@@ -0,0 +1,33 @@
+using Core.Services;
+
+namespace ModelEditorCli
+{
+    /// <summary>
+    /// Writes operation progress to stderr on a single, overwritten line
+    /// </summary>
+    internal class ConsoleProgress : IProgress<OperationProgress>
+    {
+        private int width;
+
+        /// <summary>
+        /// A progress sink for interactive terminals, or null when stderr is redirected
+        /// </summary>
+        public static IProgress<OperationProgress>? Create() => Console.IsErrorRedirected ? null : new ConsoleProgress();
+
+        public void Report(OperationProgress value)
+        {
+            string line = value.Fraction.HasValue ? $"{value} ({value.Fraction.Value:P0})" : value.ToString();
+            Console.Error.Write("\r" + line.PadRight(width));
+            width = line.Length;
+        }
+
+        /// <summary>
+        /// Ends the progress line so later output starts on a fresh line
+        /// </summary>
+        public static void Finish(IProgress<OperationProgress>? progress)
+        {
+            if (progress is ConsoleProgress { width: > 0 })
+                Console.Error.WriteLine();
+        }
+    }
+}
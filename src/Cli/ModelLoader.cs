@@ -0,0 +1,80 @@
+using Core;
+using Core.Parsing;
+using Core.Services;
+
+namespace ModelEditorCli
+{
+    /// <summary>
+    /// Parses and expands model and data files from the command line without solving
+    /// </summary>
+    internal class ModelLoader
+    {
+        public ModelManager Manager { get; }
+        public List<string> Errors { get; }
+
+        private ModelLoader(ModelManager manager, List<string> errors)
+        {
+            Manager = manager;
+            Errors = errors;
+        }
+
+        /// <summary>
+        /// Loads files by extension: .dat files are data, .case files are overlays applied on top of
+        /// the data in the order given, everything else is model text, with the files it imports. With deferRules the forall
+        /// rules are kept unexpanded until the constraints are read. Data is read in the locale of the
+        /// .datalocale.json nearest to the first data file.
+        /// </summary>
+        public static ModelLoader Load(IEnumerable<string> files, bool deferRules = false)
+        {
+            var progress = ConsoleProgress.Create();
+            var loader = Load(files, Program.Cancellation, progress, deferRules);
+            ConsoleProgress.Finish(progress);
+            return loader;
+        }
+
+        public static ModelLoader Load(IEnumerable<string> files, CancellationToken cancellationToken, IProgress<OperationProgress>? progress,
+            bool deferRules = false)
+        {
+            var modelTexts = new List<string>();
+            var dataTexts = new List<string>();
+            var overlays = new List<CaseOverlay>();
+            var errors = new List<string>();
+            DataLocale? locale = null;
+
+            foreach (var file in files)
+            {
+                if (!File.Exists(file))
+                    throw new InvalidOperationException($"File not found: {file}");
+
+                if (string.Equals(Path.GetExtension(file), ".dat", StringComparison.OrdinalIgnoreCase))
+                {
+                    locale ??= DataLocale.Find(Path.GetDirectoryName(Path.GetFullPath(file))!);
+                    dataTexts.Add(File.ReadAllText(file));
+                }
+                else if (string.Equals(Path.GetExtension(file), CaseOverlay.Extension, StringComparison.OrdinalIgnoreCase))
+                    overlays.Add(CaseOverlay.Load(file));
+                else if (ModelComposer.HasImports(File.ReadAllText(file)))
+                {
+                    // A model importing other files is parsed as its units, imports first
+                    var composer = ModelComposer.Compose(file);
+                    errors.AddRange(composer.Errors);
+                    modelTexts.AddRange(composer.Units.Select(u => u.Text));
+                }
+                else
+                    modelTexts.Add(File.ReadAllText(file));
+            }
+
+            var manager = new ModelManager { DeferRuleExpansion = deferRules };
+            var dataParser = new DataFileParser(manager) { Locale = locale ?? DataLocale.Invariant };
+            var service = new ModelParsingService(manager, new EquationParser(manager), dataParser)
+            {
+                SolveAfterParse = false
+            };
+            service.Overlays.AddRange(overlays);
+
+            var result = service.ParseModel(modelTexts, dataTexts, cancellationToken, progress);
+            errors.AddRange(result.Errors);
+            return new ModelLoader(manager, errors);
+        }
+    }
+}
@@ -0,0 +1,1422 @@
+using System.Globalization;
+using Core;
+using Core.Analysis;
+using Core.Decomposition;
+using Core.Export;
+using Core.Formatting;
+using Core.Generation;
+using Core.Import;
+using Core.Parsing;
+using Core.Services;
+using Core.Solving;
+using Core.Storage;
+using Core.Visualization;
+using ModelEditorCli.Tui;
+
+namespace ModelEditorCli
+{
+    internal static class Program
+    {
+        private static readonly CancellationTokenSource cancellation = new CancellationTokenSource();
+
+        /// <summary>
+        /// Cancelled by Ctrl+C, so long operations stop cleanly instead of the process being killed
+        /// </summary>
+        public static CancellationToken Cancellation => cancellation.Token;
+
+        /// <summary>
+        /// Entry point: modeledit &lt;command&gt; [arguments]
+        /// </summary>
+        static int Main(string[] args)
+        {
+            Console.CancelKeyPress += (_, e) =>
+            {
+                // First Ctrl+C cancels the running operation, a second one ends the process
+                e.Cancel = !cancellation.IsCancellationRequested;
+                cancellation.Cancel();
+            };
+
+            if (args.Length == 0 || args[0] is "-h" or "--help" or "help")
+            {
+                PrintUsage();
+                return args.Length == 0 ? 1 : 0;
+            }
+
+            try
+            {
+                switch (args[0])
+                {
+                    case "tui":
+                        return RunTui(args.Skip(1).ToArray());
+                    case "import":
+                        return RunImport(args.Skip(1).ToArray());
+                    case "export-mof":
+                        return RunExportMof(args.Skip(1).ToArray());
+                    case "export":
+                        return RunExport(args.Skip(1).ToArray());
+                    case "skeleton":
+                        return RunSkeleton(args.Skip(1).ToArray());
+                    case "bigm":
+                        return RunBigM(args.Skip(1).ToArray());
+                    case "rule":
+                        return RunRule(args.Skip(1).ToArray());
+                    case "linearize":
+                        return RunLinearize(args.Skip(1).ToArray());
+                    case "classify":
+                        return RunClassify(args.Skip(1).ToArray());
+                    case "report":
+                        return RunReport(args.Skip(1).ToArray());
+                    case "blocks":
+                        return RunBlocks(args.Skip(1).ToArray());
+                    case "graph":
+                        return RunGraph(args.Skip(1).ToArray());
+                    case "piecewise":
+                        return RunPiecewise(args.Skip(1).ToArray());
+                    case "fmt":
+                        return RunFmt(args.Skip(1).ToArray());
+                    case "tags":
+                        return RunTags(args.Skip(1).ToArray());
+                    case "search":
+                        return RunSearch(args.Skip(1).ToArray());
+                    case "copy":
+                        return RunCopy(args.Skip(1).ToArray());
+                    case "paste":
+                        return RunPaste(args.Skip(1).ToArray());
+                    case "orphans":
+                        return RunOrphans(args.Skip(1).ToArray());
+                    case "pack":
+                        return RunPack(args.Skip(1).ToArray());
+                    case "unpack":
+                        return RunUnpack(args.Skip(1).ToArray());
+                    case "patch":
+                        return RunPatch(args.Skip(1).ToArray());
+                    case "migrate":
+                        return RunMigrate(args.Skip(1).ToArray());
+                    case "upgrade":
+                        return RunUpgrade(args.Skip(1).ToArray());
+                    case "refs":
+                        return RunRefs(args.Skip(1).ToArray());
+                    case "cases":
+                        return RunCases(args.Skip(1).ToArray());
+                    case "lint":
+                        return RunLint(args.Skip(1).ToArray());
+                    case "bundle":
+                        return RunBundleCommand(args.Skip(1).ToArray());
+                    case "unbundle":
+                        return RunUnbundle(args.Skip(1).ToArray());
+                    case "gen":
+                        return RunGen(args.Skip(1).ToArray());
+                    case "fuzz":
+                        return RunFuzz(args.Skip(1).ToArray());
+                    default:
+                        Console.Error.WriteLine($"Unknown command '{args[0]}'");
+                        PrintUsage();
+                        return 1;
+                }
+            }
+            catch (OperationCanceledException)
+            {
+                Console.Error.WriteLine();
+                Console.Error.WriteLine("Cancelled");
+                return 130;
+            }
+            catch (Exception ex)
+            {
+                Console.Error.WriteLine($"Error: {ex.Message}");
+                return 1;
+            }
+        }
+
+        private static int RunTui(string[] files)
+        {
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit tui <model.mod> [data.dat ...]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            foreach (var error in model.Errors)
+                Console.Error.WriteLine(error);
+
+            new ModelBrowserApp(model.Manager, Path.GetFileName(files[0])).Run();
+            return 0;
+        }
+
+        private static int RunImport(string[] args)
+        {
+            if (args.Length != 1 && !(args.Length == 3 && args[1] is "-o" or "--output" or "--into"))
+            {
+                Console.Error.WriteLine("Usage: modeledit import <model.lp|model.mof.json> [-o model.mod | --into model.mod]");
+                return 1;
+            }
+
+            string input = args[0];
+            string text = File.ReadAllText(input);
+            var model = input.EndsWith(".json", StringComparison.OrdinalIgnoreCase)
+                ? new MofImporter().Import(text)
+                : new LpImporter().Import(text);
+
+            if (string.IsNullOrEmpty(model.Name))
+                model.Name = Path.GetFileName(input);
+
+            foreach (var warning in model.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            if (args.Length == 3 && args[1] == "--into")
+            {
+                // Replace what an earlier import of the same file generated, keep everything else
+                string fileName = Path.GetFileName(input);
+                var source = ModelSource.Parse(File.Exists(args[2]) ? File.ReadAllText(args[2]) : "");
+                var changes = ModelProvenance.Regenerate(source, $"import:{fileName}", model.ToStatements(), fileName, DateTime.UtcNow);
+                changes.Apply(source);
+                File.WriteAllText(args[2], source.ToString());
+
+                int removed = changes.Edits.Count(e => e.IsRemoval);
+                Console.WriteLine($"Regenerated {changes.Edits.Count - removed} declarations ({removed} removed) in {args[2]}");
+                return 0;
+            }
+
+            string modelText = model.ToModelText();
+            if (args.Length == 3)
+            {
+                File.WriteAllText(args[2], modelText);
+                Console.WriteLine($"Imported {model.Variables.Count} variables and {model.Constraints.Count} constraints to {args[2]}");
+            }
+            else
+            {
+                Console.Write(modelText);
+            }
+            return 0;
+        }
+
+        private static int RunExportMof(string[] args)
+        {
+            string? output = null, order = null;
+            var files = new List<string>();
+            bool valid = true;
+            for (int i = 0; i < args.Length; i++)
+            {
+                string arg = args[i];
+                if (arg is "-o" or "--output" or "--order")
+                {
+                    if (i + 1 >= args.Length)
+                    {
+                        valid = false;
+                        break;
+                    }
+
+                    if (arg == "--order")
+                        order = args[++i];
+                    else
+                        output = args[++i];
+                }
+                else
+                {
+                    files.Add(arg);
+                }
+            }
+
+            var ordering = ExportOrdering.SortedColumns;
+            if (files.Count == 0 || !valid || (order != null && !TryParseOrdering(order, out ordering)))
+            {
+                Console.Error.WriteLine("Usage: modeledit export-mof <model.mod> [data.dat ...] [-o model.mof.json] [--order columns|name|creation]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var exporter = new MofExporter(model.Manager) { Ordering = ordering };
+            var progress = ConsoleProgress.Create();
+            string json = exporter.Export(Path.GetFileNameWithoutExtension(files[0]), Cancellation, progress);
+            ConsoleProgress.Finish(progress);
+            foreach (var warning in exporter.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            if (output != null)
+                File.WriteAllText(output, json);
+            else
+                Console.WriteLine(json);
+            return 0;
+        }
+
+        private static int RunExport(string[] args)
+        {
+            string? profileName = null, output = null;
+            var files = new List<string>();
+            bool valid = true;
+            for (int i = 0; i < args.Length; i++)
+            {
+                string arg = args[i];
+                if (arg is "--profile" or "-o" or "--output")
+                {
+                    if (i + 1 >= args.Length)
+                    {
+                        valid = false;
+                        break;
+                    }
+
+                    if (arg == "--profile")
+                        profileName = args[++i];
+                    else
+                        output = args[++i];
+                }
+                else
+                {
+                    files.Add(arg);
+                }
+            }
+
+            if (files.Count == 0 || !valid)
+            {
+                Console.Error.WriteLine("Usage: modeledit export <model.mod> [data.dat ...] [--profile name] [-o file]");
+                return 1;
+            }
+
+            // Model files are read as their units (imports first); profiles are declared in the first one
+            var modelTexts = new List<string>();
+            var dataTexts = new List<string>();
+            foreach (string file in files)
+            {
+                if (string.Equals(Path.GetExtension(file), ".dat", StringComparison.OrdinalIgnoreCase))
+                    dataTexts.Add(File.ReadAllText(file));
+                else if (ModelComposer.HasImports(File.ReadAllText(file)))
+                    modelTexts.AddRange(ModelComposer.Compose(file).Units.Select(u => u.Text));
+                else
+                    modelTexts.Add(File.ReadAllText(file));
+            }
+
+            var profiles = ExportProfiles.Parse(File.ReadAllText(files[0]));
+            foreach (var warning in profiles.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            if (profileName == null)
+            {
+                if (profiles.Profiles.Count == 0)
+                    Console.WriteLine("No export profiles; declare them with // @export <name> format=mps|mof|fzn ...");
+                foreach (var p in profiles.Profiles)
+                    Console.WriteLine(p);
+                return 0;
+            }
+
+            var profile = profiles.Find(profileName);
+            if (profile == null)
+            {
+                Console.Error.WriteLine($"No export profile '{profileName}' in {files[0]}");
+                return 1;
+            }
+
+            // The profile's case is the .case file of that name next to the model
+            CaseOverlay? overlay = profile.Case != null
+                ? CaseOverlay.Load(Path.Combine(Path.GetDirectoryName(Path.GetFullPath(files[0]))!, profile.Case + CaseOverlay.Extension))
+                : null;
+
+            var result = profile.Export(modelTexts, dataTexts, overlay, Cancellation);
+            foreach (var warning in result.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            if (output != null)
+            {
+                File.WriteAllText(output, result.Text);
+                Console.WriteLine($"Exported with profile '{profile.Name}' to {output}");
+            }
+            else
+            {
+                Console.Write(result.Text);
+            }
+            return 0;
+        }
+
+        private static int RunSkeleton(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            if (args.Length is not (1 or 3) || (args.Length == 3 && output != 1))
+            {
+                Console.Error.WriteLine("Usage: modeledit skeleton <model.mod> [-o structure.mod]");
+                return 1;
+            }
+
+            var exporter = new SkeletonExporter();
+            string text = exporter.Export(File.ReadAllText(args[0]));
+            foreach (string warning in exporter.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            if (output > 0)
+                File.WriteAllText(args[2], text);
+            else
+                Console.Write(text);
+            return 0;
+        }
+
+        private static int RunBigM(string[] files)
+        {
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit bigm <model.mod> [data.dat ...]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var findings = new BigMAnalyzer(model.Manager).Analyze();
+            foreach (var finding in findings)
+                Console.WriteLine(finding);
+
+            Console.WriteLine($"{findings.Count} big-M coefficients, {findings.Count(f => f.CanTighten)} can be tightened, " +
+                              $"{findings.Count(f => f.IsNumericallyRisky)} numerically risky");
+            return 0;
+        }
+
+        private static int RunRule(string[] args)
+        {
+            var bindings = args.Where(a => a.Contains('=')).ToList();
+            var rest = args.Where(a => !a.Contains('=')).ToList();
+            if (rest.Count < 2)
+            {
+                Console.Error.WriteLine("Usage: modeledit rule <model.mod> [data.dat ...] <rule> [iterator=value ...]");
+                return 1;
+            }
+
+            string name = rest[^1];
+            var model = ModelLoader.Load(rest.Take(rest.Count - 1), deferRules: true);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var values = bindings
+                .Select(b => b.Split('=', 2))
+                .ToDictionary(parts => parts[0].Trim(), parts => parts[1].Trim().Trim('"'));
+            var instances = model.Manager.InstantiateRule(name, values);
+
+            Console.WriteLine(model.Manager.FindRule(name)?.Source ?? name);
+            foreach (var instance in instances)
+                Console.WriteLine($"  {instance}");
+
+            string filter = bindings.Count == 0 ? "" : $" with {string.Join(", ", bindings)}";
+            Console.WriteLine($"{instances.Count} instance{(instances.Count == 1 ? "" : "s")} of '{name}'{filter}");
+            return 0;
+        }
+
+        private static int RunOrphans(string[] args)
+        {
+            bool fix = args.Contains("--fix");
+            var files = args.Where(a => a != "--fix").ToArray();
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit orphans <model.mod> [data.dat ...] [--fix]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            string modelText = File.ReadAllText(files[0]);
+            var report = OrphanReport.Analyze(modelText, model.Manager);
+            Console.Write(report.ToReport());
+
+            if (fix)
+            {
+                var cleanup = report.CreateCleanup();
+                File.WriteAllText(files[0], cleanup.Apply(modelText));
+                Console.WriteLine(cleanup);
+            }
+            return 0;
+        }
+
+        private static int RunLinearize(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            int bigM = Array.IndexOf(args, "--big-m");
+            if (args.Length == 0 || args[0].StartsWith("-") || output == args.Length - 1 || bigM == args.Length - 1)
+            {
+                Console.Error.WriteLine("Usage: modeledit linearize <model.mod> [--big-m <M>] [-o model.mod]");
+                return 1;
+            }
+
+            var linearizer = new ModelLinearizer();
+            if (bigM > 0)
+                linearizer.BigM = double.Parse(args[bigM + 1], CultureInfo.InvariantCulture);
+
+            var progress = ConsoleProgress.Create();
+            var result = linearizer.Linearize(File.ReadAllText(args[0]), null, Cancellation, progress);
+            ConsoleProgress.Finish(progress);
+            foreach (var warning in result.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+            foreach (var term in result.Terms)
+                Console.Error.WriteLine(term);
+
+            if (output > 0)
+                File.WriteAllText(args[output + 1], result.ModelText);
+            else
+                Console.Write(result.ModelText);
+            return 0;
+        }
+
+        private static int RunClassify(string[] files)
+        {
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit classify <model.mod> [data.dat ...]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var classification = model.Manager.Classify(new ISolverDriver[] { new ModelSolver(), new CpSatDriver() });
+            foreach (var entity in classification.Entities)
+                Console.WriteLine(entity);
+            Console.WriteLine(classification);
+            return 0;
+        }
+
+        private static int RunReport(string[] args)
+        {
+            var files = args.Where(a => a != "--csv").ToArray();
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit report <model.mod> [data.dat ...] [--csv]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+            if (model.Manager.Report.Kpis.Count == 0)
+            {
+                Console.Error.WriteLine("The model defines no KPIs (// @kpi <name> = <expression>)");
+                return 1;
+            }
+
+            ISolverDriver driver = new ModelSolver();
+            var result = driver.Solve(model.Manager, Cancellation);
+            if (result.Status is not (SolveStatus.Optimal or SolveStatus.Feasible))
+            {
+                Console.Error.WriteLine($"{result.Status}: {result.StatusMessage}");
+                return 1;
+            }
+
+            var report = model.Manager.Report.Evaluate(model.Manager, result);
+            Console.Write(args.Contains("--csv") ? report.ToCsv() : report.ToTable());
+            foreach (var alert in report.Alerts)
+                Console.Error.WriteLine($"{files[0]}({alert.LineNumber}): {alert}");
+            return report.Values.Any(v => v.Error != null) ? 1 : report.Alerts.Count > 0 ? 2 : 0;
+        }
+
+        private static int RunBlocks(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string? maxBlocks = Option("--max-blocks");
+            string? spy = Option("--spy");
+            var files = args
+                .Where((a, i) => !a.StartsWith("-") && (i == 0 || args[i - 1] is not ("--max-blocks" or "--spy")))
+                .ToArray();
+
+            if (files.Length == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit blocks <model.mod> [data.dat ...] [--max-blocks k] [--spy arranged.svg]");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var detector = new BlockStructureDetector();
+            if (maxBlocks != null)
+                detector.MaxBlocks = int.Parse(maxBlocks, CultureInfo.InvariantCulture);
+
+            var matrix = ConstraintMatrix.Build(model.Manager);
+            var analysis = detector.Detect(matrix);
+            Console.Write(analysis.ToReport());
+
+            if (spy != null)
+                File.WriteAllText(spy, new MatrixRenderer().RenderSpySvg(analysis.Arrange(matrix)));
+            return 0;
+        }
+
+        private static int RunGraph(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string kind = Option("--kind") ?? "variables";
+            string format = Option("--format") ?? "dot";
+            string? output = Option("-o");
+            var files = args
+                .Where((a, i) => !a.StartsWith("-") && (i == 0 || args[i - 1] is not ("--kind" or "--format" or "-o")))
+                .ToArray();
+
+            if (files.Length == 0 || kind is not ("variables" or "blocks" or "sets") || format is not ("dot" or "graphml"))
+            {
+                Console.Error.WriteLine("Usage: modeledit graph <model.mod> [data.dat ...] [--kind variables|blocks|sets] [--format dot|graphml] [--aggregate] [-o file]");
+                return 1;
+            }
+
+            string text;
+            if (kind == "variables")
+            {
+                var model = ModelLoader.Load(files);
+                if (model.Errors.Count > 0)
+                {
+                    foreach (var error in model.Errors)
+                        Console.Error.WriteLine(error);
+                    return 1;
+                }
+
+                var exporter = new ModelGraphExporter(ConstraintMatrix.Build(model.Manager)) { AggregateFamilies = args.Contains("--aggregate") };
+                text = format == "dot" ? exporter.ExportVariableConstraintDot() : exporter.ExportVariableConstraintGraphMl();
+            }
+            else
+            {
+                string modelText = File.ReadAllText(files[0]);
+                text = (kind, format) switch
+                {
+                    ("blocks", "dot") => ModelGraphExporter.ExportBlockHierarchyDot(modelText),
+                    ("blocks", _) => ModelGraphExporter.ExportBlockHierarchyGraphMl(modelText),
+                    (_, "dot") => ModelGraphExporter.ExportSetDependencyDot(modelText),
+                    _ => ModelGraphExporter.ExportSetDependencyGraphMl(modelText)
+                };
+            }
+
+            if (output != null)
+                File.WriteAllText(output, text);
+            else
+                Console.Write(text);
+            return 0;
+        }
+
+        private static int RunPiecewise(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string? strategy = Option("--strategy");
+            string? segments = Option("--segments");
+            string? tolerance = Option("--tolerance");
+            string? name = Option("--name");
+            string? output = Option("-o");
+
+            var linearizer = new PiecewiseLinearizer();
+            bool valid = args.Length >= 2 && !args[0].StartsWith("-") && !args[1].StartsWith("-");
+            switch (strategy)
+            {
+                case null or "uniform":
+                    break;
+                case "adaptive":
+                    linearizer.Strategy = BreakpointStrategy.CurvatureAdaptive;
+                    break;
+                case "error":
+                    linearizer.Strategy = BreakpointStrategy.ErrorBounded;
+                    break;
+                default:
+                    valid = false;
+                    break;
+            }
+            if (!valid)
+            {
+                Console.Error.WriteLine("Usage: modeledit piecewise <model.mod> <expression> [--strategy uniform|adaptive|error] [--segments n] [--tolerance e] [--name aux] [-o model.mod]");
+                return 1;
+            }
+
+            if (segments != null)
+                linearizer.Segments = int.Parse(segments, CultureInfo.InvariantCulture);
+            if (tolerance != null)
+                linearizer.Tolerance = double.Parse(tolerance, CultureInfo.InvariantCulture);
+
+            var progress = ConsoleProgress.Create();
+            var result = linearizer.Apply(File.ReadAllText(args[0]), args[1], name, Cancellation, progress);
+            ConsoleProgress.Finish(progress);
+            foreach (var warning in result.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+            Console.Error.WriteLine(result.Approximation);
+
+            if (output != null)
+                File.WriteAllText(output, result.ModelText);
+            else
+                Console.Write(result.ModelText);
+            return 0;
+        }
+
+        private static int RunTags(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string? select = Option("--select");
+            string? relax = Option("--relax");
+            string? output = Option("-o") ?? Option("--output");
+            string? penalty = Option("--penalty");
+
+            if (args.Length == 0 || args[0].StartsWith("-") || (select != null && relax != null))
+            {
+                Console.Error.WriteLine("Usage: modeledit tags <model.mod> [--select <tag> | --relax <tag> [--penalty <cost>]] [-o model.mod]");
+                return 1;
+            }
+
+            var tags = ModelTags.Parse(File.ReadAllText(args[0]));
+            foreach (var warning in tags.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+
+            string? text = null;
+            if (select != null)
+            {
+                text = tags.ExtractSubset(select);
+            }
+            else if (relax != null)
+            {
+                var result = tags.Relax(relax, penalty != null ? double.Parse(penalty, System.Globalization.CultureInfo.InvariantCulture) : ConstraintRelaxer.DefaultPenalty);
+                foreach (var warning in result.Warnings)
+                    Console.Error.WriteLine($"Warning: {warning}");
+                Console.Error.WriteLine($"Relaxed {result.Slacks.Count} constraints");
+                text = result.ModelText;
+            }
+
+            if (text == null)
+            {
+                foreach (var statistic in tags.GetStatistics())
+                    Console.WriteLine(statistic);
+                return 0;
+            }
+
+            if (output != null)
+                File.WriteAllText(output, text);
+            else
+                Console.Write(text);
+            return 0;
+        }
+
+        private static int RunSearch(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string? limit = Option("--limit");
+            var files = args.Skip(1).Where((a, i) => !a.StartsWith("-") && (i == 0 || args[i] != "--limit")).ToList();
+
+            if (args.Length < 2 || args[0].StartsWith("-") || files.Count == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit search <query> <model.mod> ... [--limit n]");
+                return 1;
+            }
+
+            var workspace = new ModelWorkspace();
+            foreach (string file in files)
+                workspace.AddDocument(Path.GetFileName(file), File.ReadAllText(file));
+
+            var results = workspace.Search(args[0], limit != null ? int.Parse(limit, CultureInfo.InvariantCulture) : 50);
+            foreach (var result in results)
+            {
+                Console.WriteLine($"{result.Document}:{result.LineNumber}  {result.Key}  {result.Score:0.##}");
+                foreach (var match in result.Matches)
+                    Console.WriteLine($"    {match.LineNumber}:{match.Column}  {match.Field.ToString().ToLowerInvariant()}{(match.Annotation != null ? " @" + match.Annotation : "")}  {match.Text}");
+            }
+            return results.Count > 0 ? 0 : 2;
+        }
+
+        private static int RunCopy(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            var selection = args.Skip(1).Where((a, i) => i + 1 != output && i + 1 != output + 1).ToList();
+            if (args.Length < 2 || args[0].StartsWith("-") || selection.Count == 0 || output == 0 || output == args.Length - 1)
+            {
+                Console.Error.WriteLine("Usage: modeledit copy <model.mod> <key|name|block:path> ... [-o fragment.json]");
+                return 1;
+            }
+
+            var fragment = ModelClipboard.CopySelection(File.ReadAllText(args[0]), selection, Path.GetFileName(args[0]));
+            Console.Error.WriteLine($"Copied {fragment.Entries.Count(e => !e.Dependency)} declaration(s) with {fragment.Entries.Count(e => e.Dependency)} dependencies");
+            if (output > 0)
+                File.WriteAllText(args[output + 1], fragment.ToJson());
+            else
+                Console.Write(fragment.ToJson());
+            return 0;
+        }
+
+        private static int RunPaste(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            if (args.Length < 2 || args[0].StartsWith("-") || args[1].StartsWith("-") || (output >= 0 && output != args.Length - 2))
+            {
+                Console.Error.WriteLine("Usage: modeledit paste <fragment.json> <model.mod> [-o model.mod]");
+                return 1;
+            }
+
+            var result = ModelClipboard.PasteSelection(File.ReadAllText(args[1]), ModelFragment.FromJson(File.ReadAllText(args[0])));
+            foreach (string key in result.Reused)
+                Console.Error.WriteLine($"Reused: {key}");
+            foreach (var (name, renamed) in result.Renamed)
+                Console.Error.WriteLine($"Renamed: {name} -> {renamed}");
+            Console.Error.WriteLine($"Pasted {result.Added.Count} declaration(s)");
+
+            if (output >= 0)
+                File.WriteAllText(args[output + 1], result.ModelText);
+            else
+                Console.Write(result.ModelText);
+            return 0;
+        }
+
+        private static int RunPack(string[] args)
+        {
+            // Older editors read only older format versions, so a shared package can be written down-level
+            int? formatVersion = null;
+            int versionOption = Array.IndexOf(args, "--format-version");
+            if (versionOption >= 0 && versionOption + 1 < args.Length && int.TryParse(args[versionOption + 1], out int version))
+            {
+                formatVersion = version;
+                args = args.Where((_, i) => i != versionOption && i != versionOption + 1).ToArray();
+            }
+
+            int output = Array.IndexOf(args, "-o");
+            if (args.Length is < 3 or > 4 || output != args.Length - 2 || output == 0)
+            {
+                Console.Error.WriteLine("Usage: modeledit pack <model.mod> [data.dat] -o <package-dir> [--format-version n]");
+                Console.Error.WriteLine("Format versions:");
+                foreach (var known in FormatVersion.All)
+                    Console.Error.WriteLine($"  {known}");
+                return 1;
+            }
+
+            string dataText = output == 2 ? File.ReadAllText(args[1]) : "";
+            var progress = ConsoleProgress.Create();
+            var result = ModelPackage.Save(args[^1], Path.GetFileNameWithoutExtension(args[0]), File.ReadAllText(args[0]), dataText,
+                new ModelPackageOptions { TargetVersion = formatVersion }, Cancellation, progress);
+            ConsoleProgress.Finish(progress);
+            foreach (string warning in result.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+            int removed = ModelPackage.CollectGarbage(args[^1]);
+
+            Console.WriteLine($"{result} ({removed} unused chunks removed)");
+            return 0;
+        }
+
+        private static int RunUnpack(string[] args)
+        {
+            string? Option(string name)
+            {
+                int index = Array.IndexOf(args, name);
+                return index >= 0 && index + 1 < args.Length ? args[index + 1] : null;
+            }
+
+            string? output = Option("-o") ?? Option("--output");
+            string? data = Option("--data");
+            if (args.Length == 0 || args[0].StartsWith("-"))
+            {
+                Console.Error.WriteLine("Usage: modeledit unpack <package-dir> [-o model.mod] [--data data.dat]");
+                return 1;
+            }
+
+            var (_, modelText, dataText) = ModelPackage.Load(args[0]);
+            if (output != null)
+                File.WriteAllText(output, modelText);
+            else
+                Console.Write(modelText);
+            if (data != null)
+                File.WriteAllText(data, dataText);
+            return 0;
+        }
+
+        private static int RunPatch(string[] args)
+        {
+            if (args.Length < 2)
+            {
+                Console.Error.WriteLine("Usage: modeledit patch <package-dir> <statement> [--data <statement>] ...");
+                return 1;
+            }
+
+            // Only the sections named by the statements are read; everything else stays on disk.
+            // The package keeps its format version, so editors that could open it still can.
+            var document = ModelPackage.Open(args[0]);
+            for (int i = 1; i < args.Length; i++)
+            {
+                bool replaced = args[i] == "--data" && i + 1 < args.Length
+                    ? document.UpsertData(args[++i])
+                    : document.Upsert(args[i]);
+                Console.Error.WriteLine(replaced ? $"Replaced: {args[i]}" : $"Added: {args[i]}");
+            }
+
+            var result = document.Save(new ModelPackageOptions { TargetVersion = Math.Min(document.StoredVersion, FormatVersion.Current.Number) }, Cancellation);
+            foreach (string warning in result.Warnings)
+                Console.Error.WriteLine($"Warning: {warning}");
+            Console.WriteLine(result);
+            return 0;
+        }
+
+        private static int RunMigrate(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            int inverse = Array.IndexOf(args, "--inverse");
+            var patches = args.Skip(1).Where((a, i) => i + 1 != output && i + 1 != inverse && i != output && i != inverse).ToList();
+            if (args.Length < 2 || patches.Count == 0 || output == 0 || inverse == 0 ||
+                (output > 0 && output == args.Length - 1) || (inverse > 0 && inverse == args.Length - 1))
+            {
+                Console.Error.WriteLine("Usage: modeledit migrate <model.mod> <change.patch|change.json> ... [-o model.mod] [--inverse undo.patch]");
+                return 1;
+            }
+
+            // Each patch is applied to the result of the previous one; the undo runs them backwards
+            string text = File.ReadAllText(args[0]);
+            var undo = new ModelPatch { Title = $"Revert {string.Join(", ", patches.Select(Path.GetFileNameWithoutExtension))}" };
+            foreach (string file in patches)
+            {
+                var patch = ModelPatch.Load(file);
+                text = patch.Apply(text, out var inverted);
+                undo.Operations.InsertRange(0, inverted.Operations);
+                Console.Error.WriteLine($"Applied {Path.GetFileName(file)}: {patch.Operations.Count} operation(s)");
+            }
+
+            if (inverse > 0)
+                File.WriteAllText(args[inverse + 1], undo.ToString());
+            if (output > 0)
+                File.WriteAllText(args[output + 1], text);
+            else
+                Console.Write(text);
+            return 0;
+        }
+
+        private static int RunFmt(string[] args)
+        {
+            bool write = args.Contains("-w");
+            bool check = args.Contains("--check");
+            var files = args.Where(a => a != "-w" && a != "--check").ToList();
+            if (files.Count == 0 || write && check)
+            {
+                Console.Error.WriteLine("Usage: modeledit fmt <model.mod|data.dat> ... [-w | --check]");
+                return 1;
+            }
+
+            var formatter = new ModelFormatter();
+            int changed = 0;
+            foreach (string file in files)
+            {
+                string text = File.ReadAllText(file);
+                string formatted = Path.GetExtension(file).Equals(".dat", StringComparison.OrdinalIgnoreCase)
+                    ? DataFormatter(file).FormatData(text)
+                    : formatter.Format(text);
+
+                if (!write && !check)
+                {
+                    Console.Write(formatted);
+                    continue;
+                }
+                if (formatted == text)
+                    continue;
+
+                changed++;
+                if (write)
+                    File.WriteAllText(file, formatted);
+                Console.WriteLine(write ? $"Formatted {file}" : file);
+            }
+            return check && changed > 0 ? 1 : 0;
+        }
+
+        /// <summary>
+        /// A formatter that keeps the decimal commas of a data file whose .datalocale.json asks for them
+        /// </summary>
+        private static ModelFormatter DataFormatter(string file)
+        {
+            var locale = DataLocale.Find(Path.GetDirectoryName(Path.GetFullPath(file))!);
+            return new ModelFormatter(new ModelFormatOptions { DecimalComma = locale.DecimalComma });
+        }
+
+        private static int RunUpgrade(string[] args)
+        {
+            bool check = args.Contains("--check");
+            var rest = args.Where(a => a != "--check").ToArray();
+            if (rest.Length < 2)
+            {
+                Console.Error.WriteLine("Usage: modeledit upgrade <migrations-dir> <model.mod> ... [--check]");
+                return 1;
+            }
+
+            var runner = new MigrationRunner().RegisterDirectory(rest[0]);
+            var files = rest.Skip(1).ToList();
+            if (check)
+            {
+                int outdated = 0;
+                foreach (string file in files)
+                {
+                    var pending = runner.Pending(File.ReadAllText(file));
+                    if (pending.Count > 0)
+                        outdated++;
+                    Console.WriteLine(pending.Count == 0 ? $"{file}: up to date" : $"{file}: pending {string.Join(", ", pending.Select(m => m.Id))}");
+                }
+                return outdated == 0 ? 0 : 1;
+            }
+
+            var results = runner.MigrateFiles(files);
+            foreach (var result in results)
+                Console.WriteLine(result);
+            return results.Any(r => r.Error != null) ? 1 : 0;
+        }
+
+        private static int RunRefs(string[] args)
+        {
+            int rename = Array.IndexOf(args, "--rename");
+            if (args.Length != 2 && !(args.Length == 4 && rename == 2))
+            {
+                Console.Error.WriteLine("Usage: modeledit refs <package-dir> <symbol> [--rename <name>]");
+                return 1;
+            }
+
+            var document = ModelPackage.Open(args[0]);
+            if (rename == 2)
+            {
+                int renamed = document.Rename(args[1], args[3]);
+                Console.WriteLine($"Renamed {renamed} occurrences of '{args[1]}' to '{args[3]}'");
+                Console.WriteLine(document.Save(new ModelPackageOptions { TargetVersion = Math.Min(document.StoredVersion, FormatVersion.Current.Number) }, Cancellation));
+                return 0;
+            }
+
+            var sites = document.References.FindReferences(args[1]);
+            foreach (var site in sites)
+                Console.WriteLine(site);
+            if (sites.Count == 0)
+                Console.Error.WriteLine($"'{args[1]}' is not used in the model");
+            return 0;
+        }
+
+        private static int RunCases(string[] args)
+        {
+            if (args.Length < 2 || (args[1] == "init" && args.Length < 3) || (args[1] == "add" && args.Length is < 3 or > 4))
+            {
+                Console.Error.WriteLine("Usage: modeledit cases <dir> init <model.mod> [data.dat ...]");
+                Console.Error.WriteLine("       modeledit cases <dir> add <name> [overlay.case]");
+                Console.Error.WriteLine("       modeledit cases <dir> list | run [name ...] [--parallel n] | compare [name ...] [--csv]");
+                return 1;
+            }
+
+            var cases = CaseManager.Open(args[0]);
+            string Relative(string file) => Path.GetRelativePath(args[0], Path.GetFullPath(file));
+            var rest = args.Skip(2).ToList();
+            switch (args[1])
+            {
+                case "init":
+                    cases.ModelFiles.Clear();
+                    cases.DataFiles.Clear();
+                    foreach (string file in rest)
+                    {
+                        if (!File.Exists(file))
+                            throw new InvalidOperationException($"File not found: {file}");
+                        (string.Equals(Path.GetExtension(file), ".dat", StringComparison.OrdinalIgnoreCase) ? cases.DataFiles : cases.ModelFiles).Add(Relative(file));
+                    }
+                    if (cases.Find("base") == null)
+                        cases.Register("base");
+                    cases.Save();
+                    Console.WriteLine($"{Path.Combine(args[0], CaseManager.FileName)}: {cases.ModelFiles.Count} model, {cases.DataFiles.Count} data files");
+                    return 0;
+
+                case "add":
+                    Console.WriteLine(cases.Register(rest[0], rest.Count > 1 ? Relative(rest[1]) : null));
+                    cases.Save();
+                    return 0;
+
+                case "list":
+                    foreach (var definition in cases.Cases)
+                        Console.WriteLine(definition);
+                    return 0;
+
+                case "run":
+                    int parallel = rest.IndexOf("--parallel");
+                    if (parallel >= 0)
+                    {
+                        if (parallel + 1 >= rest.Count || !int.TryParse(rest[parallel + 1], out int n) || n < 1)
+                        {
+                            Console.Error.WriteLine("--parallel needs a positive number");
+                            return 1;
+                        }
+                        cases.MaxParallelism = n;
+                        rest.RemoveRange(parallel, 2);
+                    }
+
+                    var progress = ConsoleProgress.Create();
+                    var runs = cases.RunAsync(new ModelSolver(), rest.Count > 0 ? rest : null, Cancellation, progress).GetAwaiter().GetResult();
+                    ConsoleProgress.Finish(progress);
+                    foreach (var run in runs)
+                    {
+                        Console.WriteLine($"{run} -> {run.ArchivePath}");
+                        if (run.Result.Status == SolveStatus.Error && run.Errors.Count == 0)
+                            Console.Error.WriteLine($"  {run.Result.StatusMessage}");
+                        foreach (string error in run.Errors)
+                            Console.Error.WriteLine($"  {error}");
+                    }
+                    Console.WriteLine();
+                    Console.Write(new CaseComparison(runs).ToTable());
+                    return runs.Any(r => r.Result.Status == SolveStatus.Error) ? 1 : 0;
+
+                case "compare":
+                    bool csv = rest.Remove("--csv");
+                    var comparison = cases.Compare(rest.Count > 0 ? rest : null);
+                    Console.Write(csv ? comparison.ToCsv() : comparison.ToTable());
+                    return 0;
+
+                default:
+                    Console.Error.WriteLine($"Unknown cases command '{args[1]}'");
+                    return 1;
+            }
+        }
+
+        private static int RunLint(string[] args)
+        {
+            string? profileName = null, config = null;
+            var files = new List<string>();
+            bool valid = true;
+            for (int i = 0; i < args.Length; i++)
+            {
+                string arg = args[i];
+                if (arg is "--profile" or "--config")
+                {
+                    if (i + 1 >= args.Length)
+                    {
+                        valid = false;
+                        break;
+                    }
+
+                    if (arg == "--profile")
+                        profileName = args[++i];
+                    else
+                        config = args[++i];
+                }
+                else
+                {
+                    files.Add(arg);
+                }
+            }
+
+            if (files.Count == 0 || !valid)
+            {
+                Console.Error.WriteLine("Usage: modeledit lint <model.mod> [data.dat ...] [--profile default|strict|<name>] [--config .modellint.json]");
+                return 1;
+            }
+
+            // Profiles come from the nearest .modellint.json next to or above the model
+            var configuration = config != null
+                ? LintConfiguration.Load(config)
+                : LintConfiguration.Find(Path.GetDirectoryName(Path.GetFullPath(files[0]))!);
+            var profile = configuration.Resolve(profileName ?? "default");
+
+            var model = ModelLoader.Load(files);
+            var linter = new ModelLinter(model.Manager)
+            {
+                ModelTexts = files
+                    .Where(f => !string.Equals(Path.GetExtension(f), ".dat", StringComparison.OrdinalIgnoreCase) &&
+                                !string.Equals(Path.GetExtension(f), CaseOverlay.Extension, StringComparison.OrdinalIgnoreCase))
+                    .Select(File.ReadAllText)
+                    .ToList()
+            };
+            var report = linter.Lint(profile, model.Errors);
+
+            foreach (var finding in report.Findings)
+            {
+                Console.WriteLine(finding);
+                foreach (var fix in finding.Fixes)
+                    Console.WriteLine($"  fix: {fix.Title}");
+            }
+            Console.WriteLine(configuration.Path != null ? $"{report} from {configuration.Path}" : report.ToString());
+            return report.HasErrors ? 1 : 0;
+        }
+
+        private static int RunBundleCommand(string[] args)
+        {
+            string? output = null, settings = null;
+            var files = new List<string>();
+            bool valid = true;
+            for (int i = 0; i < args.Length; i++)
+            {
+                string arg = args[i];
+                if (arg is "-o" or "--output" or "--settings")
+                {
+                    if (i + 1 >= args.Length)
+                    {
+                        valid = false;
+                        break;
+                    }
+
+                    if (arg == "--settings")
+                        settings = args[++i];
+                    else
+                        output = args[++i];
+                }
+                else
+                {
+                    files.Add(arg);
+                }
+            }
+
+            if (files.Count == 0 || output == null || !valid)
+            {
+                Console.Error.WriteLine("Usage: modeledit bundle <model.mod> [data.dat ...] [--settings file] -o run.zip");
+                return 1;
+            }
+
+            var model = ModelLoader.Load(files);
+            if (model.Errors.Count > 0)
+            {
+                foreach (var error in model.Errors)
+                    Console.Error.WriteLine(error);
+                return 1;
+            }
+
+            var bundle = new RunBundle
+            {
+                Name = Path.GetFileNameWithoutExtension(files[0]),
+                Fingerprint = ModelFingerprint.Compute(model.Manager).ModelHash
+            };
+            foreach (var file in files)
+            {
+                string role = Path.GetExtension(file).ToLowerInvariant() switch
+                {
+                    ".dat" => "data",
+                    CaseOverlay.Extension => "case",
+                    _ => "model"
+                };
+                bundle.AddFile(role, Path.GetFileName(file), File.ReadAllText(file));
+            }
+            if (settings != null)
+                bundle.AddFile("settings", Path.GetFileName(settings), File.ReadAllText(settings));
+
+            bundle.Export(output);
+            Console.WriteLine($"{output}: {bundle.Files.Count} files, package {bundle.PackageVersion}, fingerprint {bundle.Fingerprint}");
+            return 0;
+        }
+
+        private static int RunUnbundle(string[] args)
+        {
+            int output = Array.IndexOf(args, "-o");
+            if (args.Length != 3 || output != 1)
+            {
+                Console.Error.WriteLine("Usage: modeledit unbundle <run.zip> -o <dir>");
+                return 1;
+            }
+
+            var bundle = RunBundle.Import(args[0]);
+            foreach (var difference in bundle.CompareEnvironment())
+                Console.Error.WriteLine($"Warning: {difference}");
+
+            var configuration = bundle.Restore(args[2]);
+            foreach (var file in configuration.ModelFiles.Concat(configuration.DataFiles).Concat(configuration.OverlayFiles))
+                Console.WriteLine(file);
+            if (configuration.SettingsFile != null)
+                Console.WriteLine(configuration.SettingsFile);
+            if (bundle.Solver != null)
+                Console.WriteLine($"Solver: {bundle.Solver} {string.Join(" ", bundle.SolverOptions.Select(o => $"{o.Key}={o.Value}"))}".TrimEnd());
+            return 0;
+        }
+
+        private static int RunGen(string[] args)
+        {
+            const string usage = "Usage: modeledit gen [--rows n] [--columns n] [--density d] [--integer f] [--binary f] " +
+                                 "[--structure random|block-angular|staircase] [--blocks k] [--seed n] [-o model.mod]";
+            var values = new Dictionary<string, string>(StringComparer.Ordinal);
+            for (int i = 0; i < args.Length; i++)
+            {
+                if (!args[i].StartsWith("-") || i + 1 >= args.Length)
+                {
+                    Console.Error.WriteLine(usage);
+                    return 1;
+                }
+                values[args[i]] = args[++i];
+            }
+
+            var known = new[] { "--rows", "--columns", "--density", "--integer", "--binary", "--structure", "--blocks", "--seed", "-o", "--output" };
+            var unknown = values.Keys.FirstOrDefault(k => !known.Contains(k));
+            if (unknown != null)
+            {
+                Console.Error.WriteLine($"Unknown option '{unknown}'");
+                Console.Error.WriteLine(usage);
+                return 1;
+            }
+
+            int Int(string key, int fallback) => values.TryGetValue(key, out var v) ? int.Parse(v, CultureInfo.InvariantCulture) : fallback;
+            double Double(string key, double fallback) => values.TryGetValue(key, out var v) ? double.Parse(v, CultureInfo.InvariantCulture) : fallback;
+
+            var defaults = new GeneratorOptions();
+            var options = new GeneratorOptions
+            {
+                Rows = Int("--rows", defaults.Rows),
+                Columns = Int("--columns", defaults.Columns),
+                Density = Double("--density", defaults.Density),
+                IntegerFraction = Double("--integer", defaults.IntegerFraction),
+                BinaryFraction = Double("--binary", defaults.BinaryFraction),
+                Structure = values.TryGetValue("--structure", out var structure) ? GeneratorOptions.ParseStructure(structure) : defaults.Structure,
+                Blocks = Int("--blocks", defaults.Blocks),
+                Seed = Int("--seed", defaults.Seed)
+            };
+
+            var generated = new RandomModelGenerator(options).Generate();
+            string modelText = generated.Model.ToModelText();
+            string? output = values.GetValueOrDefault("-o") ?? values.GetValueOrDefault("--output");
+            if (output != null)
+            {
+                File.WriteAllText(output, modelText);
+                Console.WriteLine($"Generated {generated.Model.Variables.Count} variables and {generated.Model.Constraints.Count} constraints to {output}");
+            }
+            else
+            {
+                Console.Write(modelText);
+            }
+            return 0;
+        }
+
+        private static int RunFuzz(string[] args)
+        {
+            const string usage = "Usage: modeledit fuzz <lp|mof|model|data> [--iterations n] [--seed n] [seed files...]";
+            if (args.Length == 0 || !ParserFuzzer.Targets.Contains(args[0]))
+            {
+                Console.Error.WriteLine(usage);
+                return 1;
+            }
+
+            string target = args[0];
+            int iterations = 1000, seed = 0;
+            var corpus = ParserFuzzer.DefaultCorpus(target);
+            for (int i = 1; i < args.Length; i++)
+            {
+                if (args[i] is "--iterations" or "--seed" && i + 1 < args.Length)
+                {
+                    int value = int.Parse(args[i + 1], CultureInfo.InvariantCulture);
+                    if (args[i] == "--iterations")
+                        iterations = value;
+                    else
+                        seed = value;
+                    i++;
+                }
+                else if (File.Exists(args[i]))
+                {
+                    corpus.Add(File.ReadAllText(args[i]));
+                }
+                else
+                {
+                    Console.Error.WriteLine($"File not found: {args[i]}");
+                    Console.Error.WriteLine(usage);
+                    return 1;
+                }
+            }
+
+            var findings = new ParserFuzzer(seed) { Iterations = iterations }.Run(target, corpus);
+            foreach (var finding in findings)
+            {
+                Console.WriteLine(finding);
+                Console.WriteLine($"  input: {finding.Input.Replace("\n", "\\n")}");
+            }
+            Console.WriteLine($"{iterations} inputs, {findings.Count} findings");
+            return findings.Count == 0 ? 0 : 1;
+        }
+
+        private static bool TryParseOrdering(string text, out ExportOrdering ordering)
+        {
+            switch (text.ToLowerInvariant())
+            {
+                case "columns":
+                    ordering = ExportOrdering.SortedColumns;
+                    return true;
+                case "creation":
+                    ordering = ExportOrdering.CreationOrder;
+                    return true;
+                case "name":
+                    ordering = ExportOrdering.Name;
+                    return true;
+                default:
+                    ordering = ExportOrdering.SortedColumns;
+                    return false;
+            }
+        }
+
+        private static void PrintUsage()
+        {
+            Console.WriteLine("Usage: modeledit <command> [arguments]");
+            Console.WriteLine();
+            Console.WriteLine("Commands:");
+            Console.WriteLine("  tui <model.mod> [data.dat ...]   Browse a model in the terminal");
+            Console.WriteLine("  import <file> [-o model.mod]     Convert an LP (e.g. Pyomo) or MOF.json (JuMP) instance");
+            Console.WriteLine("  import <file> --into model.mod   Regenerate the declarations an earlier import of the file wrote");
+            Console.WriteLine("  skeleton <model.mod> [-o file]   Write the model structure without inline data, for review apart from the data");
+            Console.WriteLine("  bigm <model.mod> [data.dat ...]  Audit big-M coefficients on binaries");
+            Console.WriteLine("  rule <model.mod> [data.dat ...] <rule> [t=3 ...]   Show the instances of a constraint rule for the given iterator values, without expanding the rest");
+            Console.WriteLine("  linearize <model.mod> [--big-m M] [-o file]   Replace binary products, abs, min and max by auxiliaries with linear constraints");
+            Console.WriteLine("  piecewise <model.mod> <expression> [--strategy uniform|adaptive|error] [--segments n] [--tolerance e] [-o file]   Replace a function of one bounded variable by a piecewise-linear approximation");
+            Console.WriteLine("  classify <model.mod> [data.dat ...]   Problem class (LP, MILP, QP, ... MINLP), convexity and the solvers that handle it");
+            Console.WriteLine("  report <model.mod> [data.dat ...] [--csv]   Solve and print the KPIs the model defines with // @kpi <name> = <expression>; exits 2 if a // @alert rule fires");
+            Console.WriteLine("  blocks <model.mod> [data.dat ...] [--max-blocks k] [--spy file.svg]   Detect block-angular, staircase or Benders structure and propose block assignments");
+            Console.WriteLine("  graph <model.mod> [data.dat ...] [--kind variables|blocks|sets] [--format dot|graphml] [--aggregate] [-o file]   Export the variable–constraint graph, block hierarchy or set dependencies");
+            Console.WriteLine("  search <query> <model.mod> ... [--limit n]   Find declarations by name, docstring, tag or annotation value, best matches first");
+            Console.WriteLine("  copy <model.mod> <key|name|block:path> ... [-o fragment.json]   Copy declarations with everything they reference into a portable fragment");
+            Console.WriteLine("  paste <fragment.json> <model.mod> [-o file]   Paste a fragment, reusing identical declarations and renaming colliding ones");
+            Console.WriteLine("  orphans <model.mod> [data.dat ...] [--fix]   Report unused declarations, empty sets and disabled blocks; --fix removes them");
+            Console.WriteLine("  fmt <file> ... [-w | --check]      Format models and .dat files canonically; -w rewrites them, --check lists files that would change");
+            Console.WriteLine("  tags <model.mod> [--select <tag> | --relax <tag>] [-o file]   Tag statistics, tagged subset or soft constraints");
+            Console.WriteLine("  pack <model.mod> [data.dat] -o <dir> [--format-version n]   Save in the chunked package format (writes only changed chunks)");
+            Console.WriteLine("  unpack <dir> [-o model.mod] [--data data.dat]   Read a package back into text files");
+            Console.WriteLine("  patch <dir> <statement> [--data <statement>]   Replace statements in a package without loading the rest");
+            Console.WriteLine("  migrate <model.mod> <file.patch> ... [-o file] [--inverse undo.patch]   Apply scripted edits (add, remove, replace, set, coef) atomically, writing their inverse");
+            Console.WriteLine("  upgrade <migrations-dir> <model.mod> ... [--check]   Apply the migration patches a model has not had yet, recording them in the model");
+            Console.WriteLine("  refs <dir> <symbol> [--rename <name>]   List the references to a symbol from the saved index, or rename it");
+            Console.WriteLine("  cases <dir> init|add|list|run|compare ...   Register named cases (overlays), run them in parallel and compare their archived results");
+            Console.WriteLine("  lint <model.mod> [data.dat ...] [--profile name] [--config file]   Check the model against a lint profile; exits 1 on errors");
+            Console.WriteLine("  bundle <model.mod> [data.dat ...] [--settings file] -o run.zip   Archive a run with its environment for reproduction");
+            Console.WriteLine("  unbundle <run.zip> -o <dir>      Restore the files of a run bundle and report environment differences");
+            Console.WriteLine("  fuzz <lp|mof|model|data> [--iterations n] [--seed n] [seed files...]   Fuzz a parser with mutated inputs");
+            Console.WriteLine("  gen [--rows n] [--columns n] [--structure random|block-angular|staircase] [--seed n] [-o file]   Generate a random feasible LP/MIP");
+            Console.WriteLine("  export-mof <model.mod> [data.dat ...] [-o file] [--order columns|name|creation]   Write MathOptFormat (MOF.json)");
+            Console.WriteLine("  export <model.mod> [data.dat ...] [--profile name] [-o file]   Write the model with one of its // @export profiles; lists them without --profile");
+            Console.WriteLine();
+            Console.WriteLine("Data files may be followed by .case files: parameter values, bounds (x.ub = 10;) and set selections");
+            Console.WriteLine("(select S = {...};) applied on top of the data, so one model runs many cases.");
+            Console.WriteLine("Model files may import others under a prefix (import \"network.mod\" as net;) and use their");
+            Console.WriteLine("declarations as net.Name; data files set them as net__Name. Selected declarations keep their");
+            Console.WriteLine("names (import Nodes, Arcs from \"network.mod\";); data files set them as network__Nodes.");
+            Console.WriteLine("Data files are read in the locale of the nearest .datalocale.json: its culture (\"de-DE\" for");
+            Console.WriteLine("3,14), date format (\"dd.MM.yyyy\") and per-column overrides; without one, in the invariant culture.");
+        }
+    }
+}
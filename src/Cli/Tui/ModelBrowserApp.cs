@@ -0,0 +1,360 @@
+using Core;
+using Core.Analysis;
+
+namespace ModelEditorCli.Tui
+{
+    /// <summary>
+    /// Full-screen terminal browser over an EntityCatalog: group tree on the left,
+    /// entity list in the middle and the selected entity's details and references on the right.
+    /// </summary>
+    internal class ModelBrowserApp
+    {
+        private enum Pane
+        {
+            Tree,
+            List,
+            Detail
+        }
+
+        private readonly EntityCatalog catalog;
+        private readonly string title;
+        private readonly List<string> groups;
+        private readonly Stack<string> history = new Stack<string>();
+
+        private Pane focus = Pane.Tree;
+        private int groupIndex;
+        private int entryIndex;
+        private int referenceIndex;
+        private string? searchQuery;
+        private List<CatalogEntry> visibleEntries = new List<CatalogEntry>();
+        private string status = "";
+
+        public ModelBrowserApp(ModelManager manager, string title)
+        {
+            catalog = EntityCatalog.Build(manager);
+            groups = catalog.GetGroups().ToList();
+            this.title = title;
+            RefreshEntries();
+        }
+
+        public void Run()
+        {
+            if (Console.IsOutputRedirected || Console.IsInputRedirected)
+                throw new InvalidOperationException("The model browser needs an interactive terminal");
+
+            Console.CursorVisible = false;
+            try
+            {
+                while (true)
+                {
+                    Draw();
+                    var key = Console.ReadKey(intercept: true);
+                    if (!HandleKey(key))
+                        break;
+                }
+            }
+            finally
+            {
+                Console.ResetColor();
+                Console.Clear();
+                Console.CursorVisible = true;
+            }
+        }
+
+        private CatalogEntry? SelectedEntry =>
+            entryIndex >= 0 && entryIndex < visibleEntries.Count ? visibleEntries[entryIndex] : null;
+
+        private List<string> SelectedReferences
+        {
+            get
+            {
+                var entry = SelectedEntry;
+                return entry == null
+                    ? new List<string>()
+                    : entry.References.Concat(entry.ReferencedBy).ToList();
+            }
+        }
+
+        private bool HandleKey(ConsoleKeyInfo key)
+        {
+            status = "";
+
+            switch (key.Key)
+            {
+                case ConsoleKey.Q:
+                    return false;
+                case ConsoleKey.Tab:
+                    focus = (Pane)(((int)focus + ((key.Modifiers & ConsoleModifiers.Shift) != 0 ? 2 : 1)) % 3);
+                    break;
+                case ConsoleKey.UpArrow:
+                    Move(-1);
+                    break;
+                case ConsoleKey.DownArrow:
+                    Move(1);
+                    break;
+                case ConsoleKey.PageUp:
+                    Move(-10);
+                    break;
+                case ConsoleKey.PageDown:
+                    Move(10);
+                    break;
+                case ConsoleKey.RightArrow:
+                case ConsoleKey.Enter:
+                    Activate();
+                    break;
+                case ConsoleKey.LeftArrow:
+                    if (focus != Pane.Tree)
+                        focus--;
+                    break;
+                case ConsoleKey.Backspace:
+                    GoBack();
+                    break;
+                case ConsoleKey.Escape:
+                    if (searchQuery != null)
+                    {
+                        searchQuery = null;
+                        RefreshEntries();
+                    }
+                    break;
+                default:
+                    if (key.KeyChar == '/')
+                        PromptSearch();
+                    break;
+            }
+
+            return true;
+        }
+
+        private void Move(int delta)
+        {
+            switch (focus)
+            {
+                case Pane.Tree:
+                    groupIndex = Math.Clamp(groupIndex + delta, 0, Math.Max(0, groups.Count - 1));
+                    searchQuery = null;
+                    RefreshEntries();
+                    break;
+                case Pane.List:
+                    entryIndex = Math.Clamp(entryIndex + delta, 0, Math.Max(0, visibleEntries.Count - 1));
+                    referenceIndex = 0;
+                    break;
+                case Pane.Detail:
+                    referenceIndex = Math.Clamp(referenceIndex + delta, 0, Math.Max(0, SelectedReferences.Count - 1));
+                    break;
+            }
+        }
+
+        private void Activate()
+        {
+            if (focus == Pane.Tree)
+            {
+                focus = Pane.List;
+                return;
+            }
+
+            if (focus == Pane.List)
+            {
+                focus = Pane.Detail;
+                referenceIndex = 0;
+                return;
+            }
+
+            var references = SelectedReferences;
+            if (SelectedEntry == null || referenceIndex >= references.Count)
+                return;
+
+            history.Push(SelectedEntry.Key);
+            JumpTo(references[referenceIndex]);
+        }
+
+        private void JumpTo(string key)
+        {
+            var target = catalog.Get(key);
+            if (target == null)
+            {
+                status = $"'{key}' is not in the catalog";
+                return;
+            }
+
+            searchQuery = null;
+            groupIndex = Math.Max(0, groups.IndexOf(target.Group));
+            RefreshEntries();
+            entryIndex = Math.Max(0, visibleEntries.IndexOf(target));
+            referenceIndex = 0;
+            focus = Pane.Detail;
+        }
+
+        private void GoBack()
+        {
+            if (history.Count == 0)
+            {
+                status = "No previous entity";
+                return;
+            }
+
+            JumpTo(history.Pop());
+        }
+
+        private void PromptSearch()
+        {
+            int height = Console.WindowHeight;
+            Console.SetCursorPosition(0, height - 1);
+            Console.Write("/".PadRight(Console.WindowWidth - 1));
+            Console.SetCursorPosition(1, height - 1);
+            Console.CursorVisible = true;
+            string? query = Console.ReadLine();
+            Console.CursorVisible = false;
+
+            if (string.IsNullOrWhiteSpace(query))
+                return;
+
+            searchQuery = query.Trim();
+            RefreshEntries();
+            focus = Pane.List;
+            status = $"{visibleEntries.Count} match(es) for '{searchQuery}'";
+        }
+
+        private void RefreshEntries()
+        {
+            visibleEntries = searchQuery != null
+                ? catalog.Search(searchQuery).ToList()
+                : groups.Count > 0 ? catalog.GetGroup(groups[groupIndex]).ToList() : new List<CatalogEntry>();
+            entryIndex = 0;
+            referenceIndex = 0;
+        }
+
+        private void Draw()
+        {
+            int width = Math.Max(40, Console.WindowWidth);
+            int height = Math.Max(10, Console.WindowHeight);
+            int treeWidth = Math.Min(28, width / 5);
+            int listWidth = Math.Min(40, width / 3);
+            int detailWidth = width - treeWidth - listWidth - 2;
+            int rows = height - 3;
+
+            var tree = groups.Select(g => (Text: $"{g} ({catalog.GetGroup(g).Count()})", Selected: false)).ToList();
+            if (tree.Count > 0 && searchQuery == null)
+                tree[groupIndex] = (tree[groupIndex].Text, true);
+
+            var list = visibleEntries.Select(e => (Text: searchQuery != null ? $"{e.Name}  [{e.Kind}]" : e.Name, Selected: false)).ToList();
+            if (list.Count > 0)
+                list[entryIndex] = (list[entryIndex].Text, true);
+
+            var detail = BuildDetail(detailWidth);
+
+            Console.SetCursorPosition(0, 0);
+            WriteLine(Header(), width, inverse: true);
+
+            int treeOffset = ScrollOffset(groupIndex, rows);
+            int listOffset = ScrollOffset(entryIndex, rows);
+            int detailOffset = ScrollOffset(detail.FindIndex(l => l.Selected), rows);
+
+            for (int row = 0; row < rows; row++)
+            {
+                Console.SetCursorPosition(0, row + 1);
+                WriteCell(tree, row + treeOffset, treeWidth, focus == Pane.Tree);
+                Console.Write("│");
+                WriteCell(list, row + listOffset, listWidth, focus == Pane.List);
+                Console.Write("│");
+                WriteCell(detail, row + detailOffset, detailWidth, focus == Pane.Detail);
+            }
+
+            Console.SetCursorPosition(0, height - 2);
+            WriteLine(status, width, inverse: false);
+            WriteLine("↑↓ move  Tab pane  Enter open/jump  Backspace back  / search  Esc clear  q quit", width, inverse: true);
+        }
+
+        private string Header()
+        {
+            string location = searchQuery != null
+                ? $"search: {searchQuery}"
+                : groups.Count > 0 ? groups[groupIndex] : "(empty model)";
+            return $" {title} — {location}";
+        }
+
+        private List<(string Text, bool Selected)> BuildDetail(int width)
+        {
+            var lines = new List<(string Text, bool Selected)>();
+            var entry = SelectedEntry;
+            if (entry == null)
+                return lines;
+
+            lines.Add(($"{entry.Kind} {entry.Name}", false));
+            lines.Add(("", false));
+            foreach (var detail in entry.Details)
+            {
+                foreach (var wrapped in Wrap(detail, width - 1))
+                    lines.Add((wrapped, false));
+            }
+
+            int index = 0;
+            AddReferenceSection(lines, "Uses", entry.References, ref index);
+            AddReferenceSection(lines, "Used by", entry.ReferencedBy, ref index);
+            return lines;
+        }
+
+        private void AddReferenceSection(List<(string Text, bool Selected)> lines, string heading, IEnumerable<string> keys, ref int index)
+        {
+            var list = keys.ToList();
+            lines.Add(("", false));
+            lines.Add(($"{heading} ({list.Count}):", false));
+
+            foreach (var key in list)
+            {
+                bool selected = focus == Pane.Detail && index == referenceIndex;
+                lines.Add(($"  → {key}", selected));
+                index++;
+            }
+        }
+
+        private static IEnumerable<string> Wrap(string text, int width)
+        {
+            if (width <= 0)
+                yield break;
+
+            for (int i = 0; i < text.Length; i += width)
+                yield return text.Substring(i, Math.Min(width, text.Length - i));
+        }
+
+        private static int ScrollOffset(int selected, int rows)
+        {
+            return selected < rows ? 0 : selected - rows + 1;
+        }
+
+        private static void WriteCell(List<(string Text, bool Selected)> lines, int index, int width, bool focused)
+        {
+            string text = index < lines.Count ? lines[index].Text : "";
+            bool selected = index < lines.Count && lines[index].Selected;
+
+            if (selected)
+            {
+                Console.BackgroundColor = focused ? ConsoleColor.DarkCyan : ConsoleColor.DarkGray;
+                Console.ForegroundColor = ConsoleColor.White;
+            }
+
+            Console.Write(Fit(text, width));
+            Console.ResetColor();
+        }
+
+        private static void WriteLine(string text, int width, bool inverse)
+        {
+            if (inverse)
+            {
+                Console.BackgroundColor = ConsoleColor.Gray;
+                Console.ForegroundColor = ConsoleColor.Black;
+            }
+
+            Console.Write(Fit(text, width - 1));
+            Console.ResetColor();
+            Console.WriteLine();
+        }
+
+        private static string Fit(string text, int width)
+        {
+            if (width <= 0)
+                return "";
+
+            return text.Length > width ? text.Substring(0, width - 1) + "…" : text.PadRight(width);
+        }
+    }
+}
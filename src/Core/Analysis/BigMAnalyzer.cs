@@ -0,0 +1,222 @@
+using System.Globalization;
+using Core.Export;
+using Core.Models;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// A large coefficient on a binary variable in one constraint row
+    /// </summary>
+    public class BigMFinding
+    {
+        public string Constraint { get; init; } = "";
+        public string Variable { get; init; } = "";
+
+        /// <summary>
+        /// Coefficient of the binary in the row as written (for &gt;= rows, after negating to &lt;= form)
+        /// </summary>
+        public double Coefficient { get; init; }
+
+        /// <summary>
+        /// Smallest valid magnitude implied by the bounds of the other variables in the row,
+        /// or null if one of them is unbounded in the relevant direction
+        /// </summary>
+        public double? ImpliedM { get; init; }
+
+        public double M => Math.Abs(Coefficient);
+
+        public bool CanTighten => ImpliedM.HasValue && ImpliedM.Value < M * (1 - 1e-9);
+
+        /// <summary>
+        /// M is large enough that integrality tolerances let the binary switch the row partially
+        /// </summary>
+        public bool IsNumericallyRisky { get; init; }
+
+        internal LinearEquation Equation { get; init; } = null!;
+        internal bool Negated { get; init; }
+
+        public override string ToString()
+        {
+            string implied = ImpliedM.HasValue
+                ? CanTighten ? $"bounds imply M = {Format(ImpliedM.Value)}" : "M is tight"
+                : "no implied M (unbounded variables in row)";
+            string risk = IsNumericallyRisky ? "; numerically risky" : "";
+            return $"{Constraint}: M = {Format(M)} on {Variable} ({implied}{risk})";
+        }
+
+        private static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+    }
+
+    /// <summary>
+    /// Finds big-M style coefficients (large constants multiplying binaries) in an expanded model,
+    /// derives the tightest M implied by the variable bounds of the rest of the row, and flags
+    /// values large enough to cause numerical trouble.
+    ///
+    /// For a row written as rest + c·y &lt;= b with y binary and U the maximum activity of rest:
+    /// if c &lt; 0 the row is inactive at y = 1 whenever M = -c ≥ U - b, so U - b is the implied M;
+    /// if c &gt; 0 the row is inactive at y = 0 when U &lt; b, and c and b can both be lowered by b - U.
+    /// </summary>
+    public class BigMAnalyzer
+    {
+        private readonly ModelManager modelManager;
+
+        public BigMAnalyzer(ModelManager manager)
+        {
+            modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
+        }
+
+        /// <summary>
+        /// Minimum magnitude for a binary coefficient to count as a big-M
+        /// </summary>
+        public double MinMagnitude { get; set; } = 100;
+
+        /// <summary>
+        /// The binary coefficient must also be this many times larger than every other coefficient in the row
+        /// </summary>
+        public double MinRatio { get; set; } = 10;
+
+        /// <summary>
+        /// Integrality tolerance of the solver; the binary can take this value without being "on"
+        /// </summary>
+        public double IntegralityTolerance { get; set; } = 1e-5;
+
+        /// <summary>
+        /// Flag M as risky when M · IntegralityTolerance exceeds this (the row can be violated by that much)
+        /// </summary>
+        public double LeakageTolerance { get; set; } = 1e-1;
+
+        public List<BigMFinding> Analyze()
+        {
+            if (modelManager.IndexedEquationTemplates.Count > 0 || modelManager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot analyze: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            var findings = new List<BigMFinding>();
+            int row = 0;
+
+            foreach (var equation in modelManager.MaterializeRows())
+            {
+                row++;
+                if (equation.Operator == RelationalOperator.Equal)
+                    continue;
+
+                string name = equation.Label ?? (string.IsNullOrEmpty(equation.GetDescription()) ? $"c{row}" : equation.GetDescription());
+                var (coefficients, rhs) = equation.Evaluate(modelManager);
+
+                // Normalize to <= form
+                bool negate = equation.Operator is RelationalOperator.GreaterThan or RelationalOperator.GreaterThanOrEqual;
+                if (negate)
+                {
+                    coefficients = coefficients.ToDictionary(c => c.Key, c => -c.Value);
+                    rhs = -rhs;
+                }
+
+                foreach (var (variable, coefficient) in coefficients)
+                {
+                    double magnitude = Math.Abs(coefficient);
+                    if (magnitude < MinMagnitude || !IsBinary(variable))
+                        continue;
+
+                    double largestOther = coefficients.Where(c => c.Key != variable).Select(c => Math.Abs(c.Value)).DefaultIfEmpty(0).Max();
+                    if (largestOther > 0 && magnitude < MinRatio * largestOther)
+                        continue;
+
+                    double? maxActivity = MaxActivity(coefficients, variable);
+                    double? implied = null;
+                    if (maxActivity.HasValue)
+                    {
+                        implied = coefficient < 0
+                            ? Math.Max(maxActivity.Value - rhs, 0)
+                            : maxActivity.Value < rhs ? coefficient - (rhs - maxActivity.Value) : magnitude;
+                        implied = Math.Max(implied.Value, 0);
+                    }
+
+                    findings.Add(new BigMFinding
+                    {
+                        Constraint = name,
+                        Variable = variable,
+                        Coefficient = coefficient,
+                        ImpliedM = implied,
+                        IsNumericallyRisky = magnitude * IntegralityTolerance > LeakageTolerance,
+                        Equation = equation,
+                        Negated = negate
+                    });
+                }
+            }
+
+            return findings;
+        }
+
+        /// <summary>
+        /// Replaces every tightenable M by its implied value in the expanded equations (the model
+        /// text is not changed). Returns the number of coefficients changed.
+        /// </summary>
+        public int Tighten(IEnumerable<BigMFinding> findings)
+        {
+            int changed = 0;
+
+            foreach (var finding in findings.Where(f => f.CanTighten))
+            {
+                double sign = finding.Negated ? -1 : 1;
+
+                if (finding.Coefficient < 0)
+                {
+                    finding.Equation.Coefficients[finding.Variable] = new ConstantExpression(sign * -finding.ImpliedM!.Value);
+                }
+                else
+                {
+                    // Lower coefficient and right-hand side by the same amount d = c - M'
+                    double d = finding.Coefficient - finding.ImpliedM!.Value;
+                    double rhs = finding.Equation.Constant.Evaluate(modelManager);
+                    finding.Equation.Coefficients[finding.Variable] = new ConstantExpression(sign * finding.ImpliedM.Value);
+                    finding.Equation.Constant = new ConstantExpression(rhs - sign * d);
+                }
+
+                changed++;
+            }
+
+            return changed;
+        }
+
+        /// <summary>
+        /// Maximum of the row activity without the given variable, or null if unbounded
+        /// </summary>
+        private double? MaxActivity(Dictionary<string, double> coefficients, string excluded)
+        {
+            double total = 0;
+            foreach (var (variable, coefficient) in coefficients)
+            {
+                if (variable == excluded || coefficient == 0)
+                    continue;
+
+                var (lower, upper) = GetBounds(variable);
+                double? bound = coefficient > 0 ? upper : lower;
+                if (!bound.HasValue)
+                    return null;
+
+                total += coefficient * bound.Value;
+            }
+            return total;
+        }
+
+        private (double? Lower, double? Upper) GetBounds(string variable)
+        {
+            var info = IntegerModel.FindVariableInfo(modelManager, variable);
+            if (info == null)
+                return (0, null);
+            if (info.Type == VariableType.Boolean)
+                return (0, 1);
+            return info.GetBounds(variable);
+        }
+
+        private bool IsBinary(string variable)
+        {
+            var info = IntegerModel.FindVariableInfo(modelManager, variable);
+            return info != null && (info.Type == VariableType.Boolean ||
+                (info.Type == VariableType.Integer && info.GetBounds(variable) == (0, 1)));
+        }
+    }
+}
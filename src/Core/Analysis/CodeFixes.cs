@@ -0,0 +1,124 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// A fix offered for a diagnostic, shaped like an LSP code action: the editor shows the
+    /// title next to the diagnostic and applies the change set when it is picked
+    /// </summary>
+    public class CodeAction
+    {
+        public string Title => Edit.Title;
+
+        /// <summary>
+        /// LSP code action kind
+        /// </summary>
+        public string Kind { get; init; } = CodeFixes.QuickFix;
+
+        /// <summary>
+        /// Message of the diagnostic the action fixes
+        /// </summary>
+        public string Diagnostic { get; init; } = "";
+
+        /// <summary>
+        /// Lint rule id, or CodeFixes.UnresolvedReference for parse errors with a suggestion
+        /// </summary>
+        public string RuleId { get; init; } = "";
+
+        /// <summary>
+        /// Line of the diagnostic (0 if it is about the model as a whole)
+        /// </summary>
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// The fix an editor applies on "fix all" or a single keystroke
+        /// </summary>
+        public bool IsPreferred { get; init; }
+
+        public ChangeSet Edit { get; init; } = new ChangeSet();
+
+        public override string ToString() => LineNumber > 0 ? $"line {LineNumber}: {Title}" : Title;
+    }
+
+    /// <summary>
+    /// Turns symbol suggestions and lint findings into code actions against a model source
+    /// </summary>
+    public static class CodeFixes
+    {
+        public const string QuickFix = "quickfix";
+        public const string UnresolvedReference = "unresolved-reference";
+
+        /// <summary>
+        /// "Replace with" for each candidate (the closest one preferred), plus declaring the name
+        /// as a scalar parameter defaulting to 0 where a parameter would resolve the reference
+        /// </summary>
+        public static List<CodeAction> For(SymbolSuggestion suggestion, ModelSource source)
+        {
+            var actions = new List<CodeAction>();
+            string diagnostic = suggestion.ToString();
+            var statement = source.FindAt(suggestion.LineNumber);
+            var reference = new Regex($@"(?<![\w.]){Regex.Escape(suggestion.Name)}\b");
+
+            if (statement != null && reference.IsMatch(statement.Code))
+            {
+                foreach (var candidate in suggestion.Candidates)
+                {
+                    string code = reference.Replace(statement.Code, candidate);
+                    actions.Add(new CodeAction
+                    {
+                        Diagnostic = diagnostic,
+                        RuleId = UnresolvedReference,
+                        LineNumber = suggestion.LineNumber,
+                        IsPreferred = actions.Count == 0,
+                        Edit = new ChangeSet($"Replace '{suggestion.Name}' with '{candidate}'", ModelEdit.Rewrite(statement, code))
+                    });
+                }
+            }
+
+            if (suggestion.Kind.HasFlag(SymbolKind.Parameter) && (suggestion.Arity ?? 0) == 0 &&
+                source.Find(EntityCatalog.KeyOf(EntityKind.Parameter, suggestion.Name)) == null)
+            {
+                actions.Add(new CodeAction
+                {
+                    Diagnostic = diagnostic,
+                    RuleId = UnresolvedReference,
+                    LineNumber = suggestion.LineNumber,
+                    Edit = new ChangeSet($"Declare parameter '{suggestion.Name}' = 0", ModelEdit.Upsert($"float {suggestion.Name} = 0;"))
+                });
+            }
+
+            return actions;
+        }
+
+        /// <summary>
+        /// The fixes the linter attached to a finding, at the line of the entity's declaration
+        /// </summary>
+        public static List<CodeAction> For(LintFinding finding, ModelSource source)
+        {
+            return finding.Fixes
+                .Where(f => f.CanApply(source))
+                .Select((fix, i) => new CodeAction
+                {
+                    Diagnostic = finding.ToString(),
+                    RuleId = finding.RuleId,
+                    LineNumber = fix.Keys.Select(k => source.Find(k)?.LineNumber ?? 0).FirstOrDefault(),
+                    IsPreferred = i == 0,
+                    Edit = fix
+                })
+                .ToList();
+        }
+
+        /// <summary>
+        /// All code actions for a parse and lint of the source, by line
+        /// </summary>
+        public static List<CodeAction> Collect(ModelSource source, IEnumerable<SymbolSuggestion> suggestions, LintReport? report = null)
+        {
+            var actions = suggestions.SelectMany(s => For(s, source)).ToList();
+            if (report != null)
+                actions.AddRange(report.Findings.SelectMany(f => For(f, source)));
+
+            return actions.OrderBy(a => a.LineNumber).ToList();
+        }
+    }
+}
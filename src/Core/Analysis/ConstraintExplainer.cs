@@ -0,0 +1,292 @@
+using System.Globalization;
+using System.Text;
+using Core.Formatting;
+using Core.Models;
+using Core.Solving;
+
+namespace Core.Analysis
+{
+    public enum ConstraintState
+    {
+        /// <summary>No solution values were given</summary>
+        Unknown,
+        Satisfied,
+        Binding,
+        Violated
+    }
+
+    /// <summary>
+    /// One variable term of an explained constraint
+    /// </summary>
+    public class ExplainedTerm
+    {
+        public string Variable { get; init; } = "";
+
+        /// <summary>
+        /// Coefficient as written, e.g. "cap" or "2"
+        /// </summary>
+        public string Formula { get; init; } = "";
+
+        public double Coefficient { get; init; }
+
+        /// <summary>
+        /// Value of the variable in the solution, null if the solution does not have it
+        /// </summary>
+        public double? Value { get; init; }
+
+        public double? Contribution => Value.HasValue ? Coefficient * Value.Value : null;
+
+        public string? CoefficientUnit { get; init; }
+        public string? VariableUnit { get; init; }
+
+        /// <summary>
+        /// Catalog keys of the variable and the parameters in the coefficient
+        /// </summary>
+        public List<string> References { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// A constraint rendered for end users: as written, with parameter values substituted and,
+    /// given a solution, each term evaluated and the row's state
+    /// </summary>
+    public class ConstraintExplanation
+    {
+        public string Constraint { get; init; } = "";
+
+        /// <summary>
+        /// Catalog key of the constraint (see EntityCatalog)
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        public string Formula { get; init; } = "";
+        public string Substituted { get; init; } = "";
+        public RelationalOperator Operator { get; init; }
+        public double Rhs { get; init; }
+        public List<ExplainedTerm> Terms { get; } = new List<ExplainedTerm>();
+
+        /// <summary>
+        /// Left-hand side at the solution, null without one
+        /// </summary>
+        public double? Activity { get; init; }
+
+        /// <summary>
+        /// Distance to the bound, positive when satisfied (for = rows, minus the deviation)
+        /// </summary>
+        public double? Slack { get; init; }
+
+        public ConstraintState State { get; init; }
+
+        /// <summary>
+        /// One sentence answering why the constraint is binding, violated or slack
+        /// </summary>
+        public string Summary
+        {
+            get
+            {
+                string symbol = new ExpressionFormatter(ExpressionStyle.Unicode).RelationSymbol(Operator);
+                if (State == ConstraintState.Unknown)
+                    return $"{Constraint} has no solution to evaluate against";
+
+                var largest = Terms.Where(t => t.Contribution.HasValue && t.Contribution.Value != 0)
+                    .OrderByDescending(t => Math.Abs(t.Contribution!.Value))
+                    .Take(3)
+                    .Select(t => $"{t.Variable} ({Format(t.Contribution!.Value)})")
+                    .ToList();
+                string drivers = largest.Count > 0 ? $"; largest terms: {string.Join(", ", largest)}" : "";
+                string state = State switch
+                {
+                    ConstraintState.Binding => "binding",
+                    ConstraintState.Violated => $"violated by {Format(-Slack!.Value)}",
+                    _ => $"satisfied with slack {Format(Slack!.Value)}"
+                };
+                return $"{Constraint} is {state}: left side {Format(Activity!.Value)} {symbol} {Format(Rhs)}{drivers}";
+            }
+        }
+
+        public string ToText()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine($"{Constraint}: {Formula}");
+            sb.AppendLine($"  = {Substituted}");
+            foreach (var term in Terms)
+            {
+                string coefficient = WithUnit(Format(term.Coefficient), term.CoefficientUnit);
+                string value = term.Value.HasValue ? $" × {WithUnit(Format(term.Value.Value), term.VariableUnit)} = {Format(term.Contribution!.Value)}" : "";
+                sb.AppendLine($"  {term.Variable}: {term.Formula} = {coefficient}{value}");
+            }
+            sb.AppendLine(Summary);
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Markdown with entity references as links to their catalog keys ("#parameter:cap")
+        /// </summary>
+        public string ToMarkdown()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine($"### [{Constraint}](#{Key})");
+            sb.AppendLine();
+            sb.AppendLine($"`{Formula}`");
+            sb.AppendLine();
+            sb.AppendLine($"`{Substituted}`");
+            sb.AppendLine();
+            sb.AppendLine("| Variable | Coefficient | Value | Contribution | Uses |");
+            sb.AppendLine("|---|---|---|---|---|");
+            foreach (var term in Terms)
+            {
+                string variable = term.References.Count > 0 ? $"[{term.Variable}](#{term.References[0]})" : term.Variable;
+                string uses = string.Join(", ", term.References.Skip(1).Select(r => $"[{r.Substring(r.IndexOf(':') + 1)}](#{r})"));
+                sb.AppendLine($"| {variable} | {term.Formula} = {WithUnit(Format(term.Coefficient), term.CoefficientUnit)} | " +
+                              $"{(term.Value.HasValue ? WithUnit(Format(term.Value.Value), term.VariableUnit) : "")} | " +
+                              $"{(term.Contribution.HasValue ? Format(term.Contribution.Value) : "")} | {uses} |");
+            }
+            sb.AppendLine();
+            sb.AppendLine(Summary);
+            return sb.ToString();
+        }
+
+        public override string ToString() => ToText();
+
+        internal static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+
+        private static string WithUnit(string value, string? unit) => string.IsNullOrEmpty(unit) ? value : $"{value} {unit}";
+    }
+
+    /// <summary>
+    /// Answers "why is this constraint binding / violated" for an expanded model: renders the
+    /// row with parameter values substituted and evaluates each term at a solution
+    /// </summary>
+    public class ConstraintExplainer
+    {
+        private readonly ModelManager manager;
+        private readonly ExpressionFormatter formatter = new ExpressionFormatter(ExpressionStyle.Unicode);
+
+        public ConstraintExplainer(ModelManager manager)
+        {
+            this.manager = manager;
+        }
+
+        public ComparisonTolerances Tolerances { get; init; } = ComparisonTolerances.Default;
+
+        /// <summary>
+        /// Explains the constraint with the given label or description (e.g. "cap_2"),
+        /// evaluating its terms at the variable values if given
+        /// </summary>
+        public ConstraintExplanation Explain(string constraintId, IReadOnlyDictionary<string, double>? values = null)
+        {
+            var equation = Find(constraintId)
+                           ?? throw new InvalidOperationException($"Constraint '{constraintId}' not found");
+            string name = equation.Label ?? equation.GetDescription();
+
+            var terms = new List<ExplainedTerm>();
+            foreach (var (variable, coefficient) in equation.Coefficients.OrderBy(c => c.Key, StringComparer.Ordinal))
+            {
+                var declaration = FindVariable(variable);
+                var parameters = new List<Parameter>();
+                CollectParameters(coefficient, parameters);
+
+                var term = new ExplainedTerm
+                {
+                    Variable = variable,
+                    Formula = formatter.Format(coefficient),
+                    Coefficient = coefficient.Evaluate(manager),
+                    Value = values != null && values.TryGetValue(variable, out var value) ? value : null,
+                    CoefficientUnit = parameters.Count == 1 ? parameters[0].Unit : null,
+                    VariableUnit = declaration?.Unit
+                };
+                term.References.Add(EntityCatalog.KeyOf(EntityKind.Variable, declaration?.BaseName ?? variable));
+                term.References.AddRange(parameters.Select(p => EntityCatalog.KeyOf(EntityKind.Parameter, p.Name)).Distinct());
+                terms.Add(term);
+            }
+
+            double rhs = equation.Constant.Evaluate(manager);
+            double? activity = values != null && terms.All(t => t.Value.HasValue) ? terms.Sum(t => t.Contribution!.Value) : null;
+            double? slack = activity.HasValue ? Slack(equation.Operator, activity.Value, rhs) : null;
+
+            var explanation = new ConstraintExplanation
+            {
+                Constraint = name,
+                Key = EntityCatalog.KeyOf(EntityKind.Constraint, name),
+                Formula = formatter.Format(equation, includeLabel: false),
+                Substituted = Substitute(terms, equation.Operator, rhs),
+                Operator = equation.Operator,
+                Rhs = rhs,
+                Activity = activity,
+                Slack = slack,
+                State = slack switch
+                {
+                    null => ConstraintState.Unknown,
+                    double s when Tolerances.IsBinding(s) => ConstraintState.Binding,
+                    double s when s < 0 => ConstraintState.Violated,
+                    _ => ConstraintState.Satisfied
+                }
+            };
+            explanation.Terms.AddRange(terms);
+            return explanation;
+        }
+
+        private LinearEquation? Find(string constraintId)
+        {
+            if (manager.LabeledEquations.TryGetValue(constraintId, out var labeled))
+                return labeled;
+
+            // Accept the bracketed form of expanded labels: "cap[2]" for "cap_2"
+            string underscored = constraintId.Replace("[", "_").Replace("]", "").Replace(",", "_");
+            return manager.MaterializeRows().FirstOrDefault(e => e.Label == constraintId || e.Label == underscored || e.GetDescription() == constraintId);
+        }
+
+        private IndexedVariable? FindVariable(string expandedName)
+        {
+            return manager.IndexedVariables.Values
+                .Where(v => v.BaseName == expandedName || (!v.IsScalar && expandedName.StartsWith(v.BaseName, StringComparison.Ordinal)))
+                .OrderByDescending(v => v.BaseName.Length)
+                .FirstOrDefault();
+        }
+
+        private void CollectParameters(Expression expression, List<Parameter> parameters)
+        {
+            switch (expression)
+            {
+                case ParameterExpression p when manager.Parameters.TryGetValue(p.ParameterName, out var parameter):
+                    parameters.Add(parameter);
+                    break;
+                case IndexedParameterExpression ip when manager.Parameters.TryGetValue(ip.ParameterName, out var parameter):
+                    parameters.Add(parameter);
+                    break;
+                case BinaryExpression b:
+                    CollectParameters(b.Left, parameters);
+                    CollectParameters(b.Right, parameters);
+                    break;
+                case UnaryExpression u:
+                    CollectParameters(u.Operand, parameters);
+                    break;
+            }
+        }
+
+        private string Substitute(List<ExplainedTerm> terms, RelationalOperator op, double rhs)
+        {
+            var sb = new StringBuilder();
+            foreach (var term in terms)
+            {
+                double magnitude = Math.Abs(term.Coefficient);
+                string factor = magnitude == 1 ? "" : $"{ConstraintExplanation.Format(magnitude)}·";
+                if (sb.Length == 0)
+                    sb.Append(term.Coefficient < 0 ? "-" : "");
+                else
+                    sb.Append(term.Coefficient < 0 ? " - " : " + ");
+                sb.Append(factor).Append(term.Variable);
+            }
+            if (sb.Length == 0)
+                sb.Append('0');
+
+            return $"{sb} {formatter.RelationSymbol(op)} {ConstraintExplanation.Format(rhs)}";
+        }
+
+        private static double Slack(RelationalOperator op, double activity, double rhs) => op switch
+        {
+            RelationalOperator.LessThanOrEqual or RelationalOperator.LessThan => rhs - activity,
+            RelationalOperator.GreaterThanOrEqual or RelationalOperator.GreaterThan => activity - rhs,
+            _ => -Math.Abs(activity - rhs)
+        };
+    }
+}
@@ -0,0 +1,211 @@
+using System.Text.RegularExpressions;
+using Core.Import;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// A model text with soft constraints and the slack variables that were added for them
+    /// </summary>
+    public class RelaxationResult
+    {
+        public string ModelText { get; init; } = "";
+
+        /// <summary>
+        /// Slack variable names per relaxed constraint name (two for equalities: excess, then shortfall)
+        /// </summary>
+        public Dictionary<string, List<string>> Slacks { get; } = new Dictionary<string, List<string>>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Constraints that were left hard, with the reason
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Rewrites hard constraints of a model text into soft constraints: each relaxed row gets a
+    /// nonnegative slack variable (indexed like the constraint family) and the objective is
+    /// charged the penalty per unit of violation
+    /// </summary>
+    public class ConstraintRelaxer
+    {
+        public const double DefaultPenalty = 1000;
+        public const string DefaultSlackSuffix = "_slack";
+
+        /// <summary>
+        /// Objective cost per unit of slack, unless overridden per constraint
+        /// </summary>
+        public double Penalty { get; set; } = DefaultPenalty;
+
+        /// <summary>
+        /// Penalties of individual constraints by name
+        /// </summary>
+        public Dictionary<string, double> Penalties { get; } = new Dictionary<string, double>(StringComparer.Ordinal);
+
+        public string SlackSuffix { get; set; } = DefaultSlackSuffix;
+
+        /// <summary>
+        /// Relaxes the constraints with the given keys ("constraint:cap") and leaves the rest of the text intact
+        /// </summary>
+        public RelaxationResult Relax(string modelText, IEnumerable<string> constraintKeys)
+        {
+            var source = ModelSource.Parse(modelText);
+            var used = source.Statements.Where(s => s.Key != null).Select(s => s.Key!.Substring(s.Key.IndexOf(':') + 1)).ToHashSet(StringComparer.Ordinal);
+            var penaltyTerms = new List<string>();
+            var warnings = new List<string>();
+            var slacks = new Dictionary<string, List<string>>(StringComparer.Ordinal);
+
+            foreach (string key in constraintKeys.Distinct())
+            {
+                var statement = source.Find(key);
+                var definition = statement != null ? EntityDefinition.FromStatement(statement.Text) : null;
+                if (definition == null || definition.Kind != EntityKind.Constraint)
+                {
+                    warnings.Add($"{key}: not a constraint declaration");
+                    continue;
+                }
+
+                var split = SplitRelation(definition.Body!);
+                if (split == null)
+                {
+                    warnings.Add($"{definition.Name}: only <=, >= and == constraints can be relaxed");
+                    continue;
+                }
+
+                var iterators = ParseIterators(definition.Forall);
+                if (iterators == null)
+                {
+                    warnings.Add($"{definition.Name}: iterators must have the form 'i in Set' to index slack variables");
+                    continue;
+                }
+
+                var (lhs, op, rhs) = split.Value;
+                string index = iterators.Count > 0 ? $"[{string.Join(",", iterators.Select(i => i.Name))}]" : "";
+                var names = op == "=="
+                    ? new List<string> { Unique($"{definition.Name}{SlackSuffix}_pos", used), Unique($"{definition.Name}{SlackSuffix}_neg", used) }
+                    : new List<string> { Unique($"{definition.Name}{SlackSuffix}", used) };
+
+                foreach (string name in names)
+                {
+                    source.Upsert(new EntityDefinition
+                    {
+                        Kind = EntityKind.Variable,
+                        Type = "float+",
+                        Name = name,
+                        IndexSets = iterators.Select(i => i.Set).ToList()
+                    }.ToStatement());
+                }
+
+                // The slack absorbs the violation: lhs - s <= rhs, lhs + s >= rhs, lhs - s+ + s- == rhs
+                string relaxed = op switch
+                {
+                    "<=" => $"{lhs} - {names[0]}{index}",
+                    ">=" => $"{lhs} + {names[0]}{index}",
+                    _ => $"{lhs} - {names[0]}{index} + {names[1]}{index}"
+                };
+                definition.Body = $"{relaxed} {op} {rhs}";
+                source.Upsert(definition.ToStatement());
+
+                string penalty = LinearModel.FormatNumber(Penalties.TryGetValue(definition.Name, out double p) ? p : Penalty);
+                string sums = string.Concat(iterators.Select(i => $"sum({i.Name} in {i.Set}) "));
+                penaltyTerms.AddRange(names.Select(n => $"{sums}{penalty} * {n}{index}"));
+
+                slacks[definition.Name] = names;
+            }
+
+            if (penaltyTerms.Count > 0)
+                AddPenalty(source, penaltyTerms);
+
+            var result = new RelaxationResult { ModelText = source.ToString() };
+            result.Warnings.AddRange(warnings);
+            foreach (var (name, names) in slacks)
+                result.Slacks[name] = names;
+            return result;
+        }
+
+        private static void AddPenalty(ModelSource source, List<string> terms)
+        {
+            var objective = source.Find(EntityCatalog.KeyOf(EntityKind.Objective, ""));
+            var definition = objective != null ? EntityDefinition.FromStatement(objective.Text) : null;
+
+            if (definition == null)
+            {
+                source.Upsert($"minimize {string.Join(" + ", terms)};");
+                return;
+            }
+
+            string sign = definition.Sense == ObjectiveSense.Minimize ? " + " : " - ";
+            definition.Body += string.Concat(terms.Select(t => sign + t));
+            source.Upsert(definition.ToStatement());
+        }
+
+        /// <summary>
+        /// Splits a relation at its top-level comparison operator, or returns null if it has none
+        /// (or is a logical constraint)
+        /// </summary>
+        internal static (string Lhs, string Op, string Rhs)? SplitRelation(string body)
+        {
+            int depth = 0;
+            (int Position, string Op)? found = null;
+
+            for (int i = 0; i < body.Length - 1; i++)
+            {
+                char c = body[i];
+                if (c is '(' or '[' or '{')
+                    depth++;
+                else if (c is ')' or ']' or '}')
+                    depth--;
+                else if (depth == 0 && (c is '<' or '>' or '=' or '!') && body[i + 1] == '=')
+                {
+                    if (found != null || c == '!')
+                        return null;
+                    found = (i, body.Substring(i, 2));
+                    i++;
+                }
+                else if (depth == 0 && ((c == '=' && body[i + 1] == '>') || c == '&' || c == '|'))
+                {
+                    return null;
+                }
+            }
+
+            if (found == null)
+                return null;
+
+            var (position, op) = found.Value;
+            return (body.Substring(0, position).Trim(), op, body.Substring(position + 2).Trim());
+        }
+
+        internal static List<(string Name, string Set)>? ParseIterators(string? forall)
+        {
+            var iterators = new List<(string, string)>();
+            if (forall == null)
+                return iterators;
+
+            // Drop a filter ("i in I: i > 1"); the slack of filtered-out rows stays at zero
+            int colon = forall.IndexOf(':');
+            string header = colon >= 0 ? forall.Substring(0, colon) : forall;
+
+            foreach (string part in header.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries))
+            {
+                var m = Regex.Match(part, @"^(\w+)\s+in\s+(\w+)$");
+                if (!m.Success)
+                    return null;
+                iterators.Add((m.Groups[1].Value, m.Groups[2].Value));
+            }
+
+            return iterators;
+        }
+
+        internal static string Unique(string name, HashSet<string> used)
+        {
+            string candidate = name;
+            for (int i = 2; used.Contains(candidate); i++)
+                candidate = $"{name}{i}";
+
+            used.Add(candidate);
+            return candidate;
+        }
+    }
+}
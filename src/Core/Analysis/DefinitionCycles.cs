@@ -0,0 +1,150 @@
+using Core.Models;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Finds dependency cycles between definitions: decision expressions, parameter formulas,
+    /// computed sets (their iterated sets and filters) and ranges. A definition that depends on
+    /// itself cannot be evaluated, so each cycle is reported with its path,
+    /// "dexpr cost -> dexpr total -> dexpr cost", before anything is expanded.
+    /// </summary>
+    public static class DefinitionCycles
+    {
+        /// <summary>
+        /// Every cycle once, as the path of definitions with the first repeated at the end
+        /// </summary>
+        public static List<List<string>> Find(ModelManager manager)
+        {
+            var graph = Build(manager);
+            var cycles = new List<List<string>>();
+            var done = new HashSet<string>(StringComparer.Ordinal);
+
+            foreach (string definition in graph.Keys.OrderBy(k => k, StringComparer.Ordinal))
+                Visit(definition, graph, new List<string>(), done, cycles);
+
+            return cycles;
+        }
+
+        /// <summary>
+        /// Diagnostics for the cycles of a model, empty if there are none
+        /// </summary>
+        public static IEnumerable<string> Describe(ModelManager manager) => Find(manager).Select(DefinitionGuard.Describe);
+
+        private static void Visit(string definition, Dictionary<string, SortedSet<string>> graph, List<string> path,
+            HashSet<string> done, List<List<string>> cycles)
+        {
+            int start = path.IndexOf(definition);
+            if (start >= 0)
+            {
+                cycles.Add(path.Skip(start).Append(definition).ToList());
+                return;
+            }
+            if (done.Contains(definition))
+                return;
+
+            path.Add(definition);
+            foreach (string dependency in graph[definition].Where(graph.ContainsKey))
+                Visit(dependency, graph, path, done, cycles);
+            path.RemoveAt(path.Count - 1);
+            done.Add(definition);
+        }
+
+        /// <summary>
+        /// Definitions and the definitions they refer to
+        /// </summary>
+        private static Dictionary<string, SortedSet<string>> Build(ModelManager manager)
+        {
+            var graph = new Dictionary<string, SortedSet<string>>(StringComparer.Ordinal);
+
+            foreach (var dexpr in manager.DecisionExpressions.Values)
+                AddReferences(Node(graph, $"dexpr {dexpr.Name}"), dexpr.Expression);
+
+            foreach (var param in manager.Parameters.Values.Where(p => p.ComputeExpression != null))
+                AddReferences(Node(graph, $"parameter {param.Name}"), param.ComputeExpression);
+
+            foreach (var set in manager.ComputedSets.Values)
+            {
+                var references = Node(graph, $"set {set.Name}");
+                foreach (var iterator in set.Iterators)
+                    references.Add($"set {iterator.SetName}");
+                AddReferences(references, set.Condition);
+            }
+
+            foreach (var range in manager.Ranges.Values)
+            {
+                var references = Node(graph, $"set {range.Name}");
+                AddReferences(references, range.StartExpression);
+                AddReferences(references, range.EndExpression);
+            }
+
+            return graph;
+        }
+
+        private static SortedSet<string> Node(Dictionary<string, SortedSet<string>> graph, string definition)
+        {
+            if (!graph.TryGetValue(definition, out var references))
+                graph[definition] = references = new SortedSet<string>(StringComparer.Ordinal);
+            return references;
+        }
+
+        private static void AddReferences(SortedSet<string> references, Expression? expression)
+        {
+            switch (expression)
+            {
+                case null:
+                    return;
+                case ParameterExpression p:
+                    references.Add($"parameter {p.ParameterName}");
+                    break;
+                case IndexedParameterExpression ip:
+                    references.Add($"parameter {ip.ParameterName}");
+                    ip.Indices.ForEach(i => AddReferences(references, i));
+                    break;
+                case DecisionExpressionExpression d:
+                    references.Add($"dexpr {d.Name}");
+                    AddReferences(references, d.IndexExpression);
+                    break;
+                case BinaryExpression b:
+                    AddReferences(references, b.Left);
+                    AddReferences(references, b.Right);
+                    break;
+                case ComparisonExpression c:
+                    AddReferences(references, c.Left);
+                    AddReferences(references, c.Right);
+                    break;
+                case LogicalAndExpression l:
+                    AddReferences(references, l.Left);
+                    AddReferences(references, l.Right);
+                    break;
+                case UnaryExpression u:
+                    AddReferences(references, u.Operand);
+                    break;
+                case ConditionalExpression q:
+                    AddReferences(references, q.Condition);
+                    AddReferences(references, q.TrueValue);
+                    AddReferences(references, q.FalseValue);
+                    break;
+                case SummationExpression s:
+                    references.Add($"set {Windows.SetName(s.SetName)}");
+                    AddReferences(references, s.Body);
+                    break;
+                case FilteredSummationExpression f:
+                    foreach (var (_, setName) in f.Iterators)
+                        references.Add($"set {Windows.SetName(setName)}");
+                    AddReferences(references, f.Filter);
+                    AddReferences(references, f.Body);
+                    break;
+                case AggregationExpression a:
+                    foreach (var (_, setName) in a.Iterators)
+                        references.Add($"set {Windows.SetName(setName)}");
+                    AddReferences(references, a.Filter);
+                    AddReferences(references, a.Body);
+                    break;
+                case MathFunctionExpression m:
+                    foreach (var argument in m.Arguments)
+                        AddReferences(references, argument);
+                    break;
+            }
+        }
+    }
+}
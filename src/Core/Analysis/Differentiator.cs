@@ -0,0 +1,433 @@
+using Core.Models;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Symbolic differentiation of model expressions, for nonlinear solver drivers (gradients and
+    /// Jacobian/Hessian structure) and for linearizing an expression around a point.
+    /// Expressions are first normalized against the model: summations are expanded, parameters
+    /// replaced by their values, scalar decision expressions inlined and indexed variables named as
+    /// in the generated constraints ("x[2]" becomes "x2"). What remains is built from constants,
+    /// variables, + - * / ^, negation and the smooth math functions.
+    /// </summary>
+    public static class Differentiator
+    {
+        /// <summary>
+        /// The expression over concrete variables only; throws for parts that cannot be differentiated
+        /// (comparisons, modulo of variables, tuple summations, indexed decision expressions)
+        /// </summary>
+        public static Expression Normalize(Expression expression, ModelManager modelManager)
+        {
+            switch (expression)
+            {
+                case ConstantExpression:
+                case VariableExpression:
+                    return expression;
+
+                case IndexedVariableExpression indexed:
+                    var index1 = Normalize(indexed.Index1, modelManager);
+                    var index2 = indexed.Index2 == null ? null : Normalize(indexed.Index2, modelManager);
+                    if (index1 is not ConstantExpression || (index2 != null && index2 is not ConstantExpression))
+                        throw new InvalidOperationException($"Index of '{indexed}' is not fixed");
+                    return new VariableExpression(new IndexedVariableExpression(indexed.BaseName, index1, index2).GetFullName(modelManager));
+
+                case BinaryExpression binary when binary.Operator is BinaryOperator.Add or BinaryOperator.Subtract
+                    or BinaryOperator.Multiply or BinaryOperator.Divide or BinaryOperator.Power:
+                    return Combine(binary.Operator, Normalize(binary.Left, modelManager), Normalize(binary.Right, modelManager));
+
+                case UnaryExpression { Operator: UnaryOperator.Negate } unary:
+                    return Negate(Normalize(unary.Operand, modelManager));
+
+                case MathFunctionExpression function:
+                    var arguments = function.Arguments.Select(a => Normalize(a, modelManager)).ToArray();
+                    if (arguments.All(a => a is ConstantExpression))
+                        return new ConstantExpression(new MathFunctionExpression { Function = function.Function, Arguments = arguments }.Evaluate(modelManager));
+                    return new MathFunctionExpression { Function = function.Function, Arguments = arguments };
+
+                case SummationExpression summation:
+                    var terms = summation.ExpandTerms(modelManager);
+                    if (terms.Count == 1 && ReferenceEquals(terms[0], summation))
+                        throw new InvalidOperationException($"Cannot expand '{summation}' over a tuple set");
+                    return terms.Select(t => Normalize(t, modelManager)).Aggregate((Expression)new ConstantExpression(0), Add);
+
+                case DecisionExpressionExpression reference
+                    when modelManager.DecisionExpressions.TryGetValue(reference.Name, out var dexpr) && !dexpr.IsIndexed:
+                    return Normalize(dexpr.Expression, modelManager);
+
+                default:
+                    // Anything free of variables (parameters, aggregations over data) is folded to its value
+                    try
+                    {
+                        return new ConstantExpression(expression.Evaluate(modelManager));
+                    }
+                    catch (InvalidOperationException ex)
+                    {
+                        throw new InvalidOperationException($"Cannot differentiate '{expression}': {ex.Message}", ex);
+                    }
+            }
+        }
+
+        /// <summary>
+        /// Variables of a normalized expression, in order of first appearance
+        /// </summary>
+        public static IReadOnlyList<string> Variables(Expression normalized)
+        {
+            var variables = new List<string>();
+            void Visit(Expression e)
+            {
+                switch (e)
+                {
+                    case VariableExpression v when !variables.Contains(v.VariableName):
+                        variables.Add(v.VariableName);
+                        break;
+                    case BinaryExpression b:
+                        Visit(b.Left);
+                        Visit(b.Right);
+                        break;
+                    case UnaryExpression u:
+                        Visit(u.Operand);
+                        break;
+                    case MathFunctionExpression f:
+                        foreach (var argument in f.Arguments)
+                            Visit(argument);
+                        break;
+                }
+            }
+            Visit(normalized);
+            return variables;
+        }
+
+        /// <summary>
+        /// Partial derivative with respect to a variable ("x", or "flow2" for flow[2])
+        /// </summary>
+        public static Expression Differentiate(Expression expression, string variable, ModelManager modelManager)
+        {
+            return Derivative(Normalize(expression, modelManager), variable);
+        }
+
+        /// <summary>
+        /// Partial derivatives with respect to every variable the expression depends on
+        /// </summary>
+        public static Dictionary<string, Expression> Gradient(Expression expression, ModelManager modelManager)
+        {
+            var normalized = Normalize(expression, modelManager);
+            return Variables(normalized).ToDictionary(v => v, v => Derivative(normalized, v));
+        }
+
+        /// <summary>
+        /// Derivative of an expression that is already normalized
+        /// </summary>
+        public static Expression Derivative(Expression normalized, string variable)
+        {
+            Expression D(Expression e) => Derivative(e, variable);
+
+            switch (normalized)
+            {
+                case ConstantExpression:
+                    return Zero;
+
+                case VariableExpression v:
+                    return v.VariableName == variable ? One : Zero;
+
+                case UnaryExpression { Operator: UnaryOperator.Negate } unary:
+                    return Negate(D(unary.Operand));
+
+                case BinaryExpression b:
+                    var (u, w) = (b.Left, b.Right);
+                    switch (b.Operator)
+                    {
+                        case BinaryOperator.Add:
+                            return Add(D(u), D(w));
+                        case BinaryOperator.Subtract:
+                            return Subtract(D(u), D(w));
+                        case BinaryOperator.Multiply:
+                            return Add(Multiply(D(u), w), Multiply(u, D(w)));
+                        case BinaryOperator.Divide:
+                            return Divide(Subtract(Multiply(D(u), w), Multiply(u, D(w))), Power(w, new ConstantExpression(2)));
+                        case BinaryOperator.Power:
+                            return PowerDerivative(normalized, u, w, D);
+                    }
+                    break;
+
+                case MathFunctionExpression f:
+                    var x = f.Arguments[0];
+                    switch (f.Function)
+                    {
+                        case MathFunction.Pow:
+                            return PowerDerivative(normalized, x, f.Arguments[1], D);
+                        case MathFunction.Sqrt:
+                            return Divide(D(x), Multiply(new ConstantExpression(2), normalized));
+                        case MathFunction.Log:
+                            return Divide(D(x), x);
+                        case MathFunction.Exp:
+                            return Multiply(normalized, D(x));
+                        case MathFunction.Sin:
+                            return Multiply(Function(MathFunction.Cos, x), D(x));
+                        case MathFunction.Cos:
+                            return Negate(Multiply(Function(MathFunction.Sin, x), D(x)));
+                        case MathFunction.Tan:
+                            return Divide(D(x), Power(Function(MathFunction.Cos, x), new ConstantExpression(2)));
+                        case MathFunction.Abs:
+                            // Sign of the argument; undefined at 0, where solvers see a kink anyway
+                            return Multiply(Divide(x, normalized), D(x));
+                    }
+
+                    if (f.Arguments.All(a => Variables(a).Count == 0) || f.Arguments.All(a => D(a) is ConstantExpression { Value: 0 }))
+                        return Zero;
+                    throw new InvalidOperationException($"'{f}' is not differentiable; reformulate it with auxiliary variables");
+            }
+
+            throw new InvalidOperationException($"Cannot differentiate '{normalized}'; normalize it first");
+        }
+
+        /// <summary>
+        /// Value of a normalized expression at a point; variables missing from the point count as 0
+        /// </summary>
+        public static double Evaluate(Expression normalized, IReadOnlyDictionary<string, double> point)
+        {
+            double E(Expression e) => Evaluate(e, point);
+
+            return normalized switch
+            {
+                ConstantExpression c => c.Value,
+                VariableExpression v => point.TryGetValue(v.VariableName, out double value) ? value : 0,
+                UnaryExpression { Operator: UnaryOperator.Negate } u => -E(u.Operand),
+                BinaryExpression b => b.Operator switch
+                {
+                    BinaryOperator.Add => E(b.Left) + E(b.Right),
+                    BinaryOperator.Subtract => E(b.Left) - E(b.Right),
+                    BinaryOperator.Multiply => E(b.Left) * E(b.Right),
+                    BinaryOperator.Divide => E(b.Left) / E(b.Right),
+                    BinaryOperator.Power => Math.Pow(E(b.Left), E(b.Right)),
+                    _ => throw new InvalidOperationException($"Cannot evaluate '{b}' at a point")
+                },
+                MathFunctionExpression f => new MathFunctionExpression
+                {
+                    Function = f.Function,
+                    Arguments = f.Arguments.Select(a => (Expression)new ConstantExpression(E(a))).ToArray()
+                }.Evaluate(null!),
+                _ => throw new InvalidOperationException($"Cannot evaluate '{normalized}' at a point; normalize it first")
+            };
+        }
+
+        /// <summary>
+        /// First-order Taylor expansion around a point: f(x0) + grad f(x0) * (x - x0), as
+        /// coefficients of the variables and a constant term
+        /// </summary>
+        public static (Dictionary<string, double> Coefficients, double Constant) Linearize(
+            Expression expression, IReadOnlyDictionary<string, double> point, ModelManager modelManager)
+        {
+            var normalized = Normalize(expression, modelManager);
+            double constant = Evaluate(normalized, point);
+            var coefficients = new Dictionary<string, double>();
+
+            foreach (string variable in Variables(normalized))
+            {
+                double slope = Evaluate(Derivative(normalized, variable), point);
+                if (slope == 0)
+                    continue;
+                coefficients[variable] = slope;
+                constant -= slope * (point.TryGetValue(variable, out double at) ? at : 0);
+            }
+
+            return (coefficients, constant);
+        }
+
+        /// <summary>
+        /// The linear constraint approximating "expression op rhs" around a point
+        /// </summary>
+        public static LinearEquation Linearize(Expression expression, RelationalOperator op, double rhs,
+            IReadOnlyDictionary<string, double> point, ModelManager modelManager, string? label = null)
+        {
+            var (coefficients, constant) = Linearize(expression, point, modelManager);
+            return new LinearEquation(
+                coefficients.ToDictionary(c => c.Key, c => (Expression)new ConstantExpression(c.Value)),
+                new ConstantExpression(rhs - constant),
+                op,
+                label);
+        }
+
+        private static readonly ConstantExpression Zero = new ConstantExpression(0);
+        private static readonly ConstantExpression One = new ConstantExpression(1);
+
+        private static Expression PowerDerivative(Expression power, Expression u, Expression w, Func<Expression, Expression> d)
+        {
+            if (Variables(w).Count == 0)
+                return Multiply(Multiply(w, Power(u, Subtract(w, One))), d(u));
+
+            // u^w = exp(w log u)
+            return Multiply(power, Add(Multiply(d(w), Function(MathFunction.Log, u)), Divide(Multiply(w, d(u)), u)));
+        }
+
+        private static Expression Function(MathFunction function, Expression argument)
+        {
+            return new MathFunctionExpression { Function = function, Arguments = new[] { argument } };
+        }
+
+        // The builders below fold constants and the identities of 0 and 1, so derivatives stay readable
+
+        private static Expression Combine(BinaryOperator op, Expression left, Expression right) => op switch
+        {
+            BinaryOperator.Add => Add(left, right),
+            BinaryOperator.Subtract => Subtract(left, right),
+            BinaryOperator.Multiply => Multiply(left, right),
+            BinaryOperator.Divide => Divide(left, right),
+            _ => Power(left, right)
+        };
+
+        private static Expression Add(Expression left, Expression right)
+        {
+            if (left is ConstantExpression a && right is ConstantExpression b)
+                return new ConstantExpression(a.Value + b.Value);
+            if (left is ConstantExpression { Value: 0 })
+                return right;
+            if (right is ConstantExpression { Value: 0 })
+                return left;
+            if (right is UnaryExpression { Operator: UnaryOperator.Negate } negated)
+                return Subtract(left, negated.Operand);
+            return new BinaryExpression(left, BinaryOperator.Add, right);
+        }
+
+        private static Expression Subtract(Expression left, Expression right)
+        {
+            if (left is ConstantExpression a && right is ConstantExpression b)
+                return new ConstantExpression(a.Value - b.Value);
+            if (right is ConstantExpression { Value: 0 })
+                return left;
+            if (left is ConstantExpression { Value: 0 })
+                return Negate(right);
+            return new BinaryExpression(left, BinaryOperator.Subtract, right);
+        }
+
+        private static Expression Multiply(Expression left, Expression right)
+        {
+            if (left is ConstantExpression a && right is ConstantExpression b)
+                return new ConstantExpression(a.Value * b.Value);
+            if (left is ConstantExpression { Value: 0 } || right is ConstantExpression { Value: 0 })
+                return Zero;
+            if (left is ConstantExpression { Value: 1 })
+                return right;
+            if (right is ConstantExpression { Value: 1 })
+                return left;
+            if (left is ConstantExpression { Value: -1 })
+                return Negate(right);
+            if (right is ConstantExpression { Value: -1 })
+                return Negate(left);
+            // Constants go first: "2 * x" rather than "x * 2"
+            if (right is ConstantExpression)
+                return new BinaryExpression(right, BinaryOperator.Multiply, left);
+            return new BinaryExpression(left, BinaryOperator.Multiply, right);
+        }
+
+        private static Expression Divide(Expression left, Expression right)
+        {
+            if (left is ConstantExpression a && right is ConstantExpression b)
+                return new ConstantExpression(a.Value / b.Value);
+            if (left is ConstantExpression { Value: 0 })
+                return Zero;
+            if (right is ConstantExpression { Value: 1 })
+                return left;
+            return new BinaryExpression(left, BinaryOperator.Divide, right);
+        }
+
+        private static Expression Power(Expression left, Expression right)
+        {
+            if (left is ConstantExpression a && right is ConstantExpression b)
+                return new ConstantExpression(Math.Pow(a.Value, b.Value));
+            if (right is ConstantExpression { Value: 0 })
+                return One;
+            if (right is ConstantExpression { Value: 1 })
+                return left;
+            return new BinaryExpression(left, BinaryOperator.Power, right);
+        }
+
+        private static Expression Negate(Expression operand)
+        {
+            return operand switch
+            {
+                ConstantExpression c => new ConstantExpression(-c.Value),
+                UnaryExpression { Operator: UnaryOperator.Negate } inner => inner.Operand,
+                _ => new UnaryExpression(UnaryOperator.Negate, operand)
+            };
+        }
+    }
+
+    /// <summary>
+    /// Sparsity of the first and second derivatives of a nonlinear program, as solvers such as
+    /// Ipopt ask for it before the first iteration. Entries are structural: a derivative is listed
+    /// unless it is identically zero, whatever its value at a particular point.
+    /// </summary>
+    public class DerivativeStructure
+    {
+        /// <summary>
+        /// Variables of the objective and the constraints, in order of first appearance
+        /// </summary>
+        public List<string> Variables { get; } = new List<string>();
+
+        /// <summary>
+        /// Nonzeros of the constraint Jacobian: constraint row and variable, row by row
+        /// </summary>
+        public List<(int Row, string Variable)> Jacobian { get; } = new List<(int, string)>();
+
+        /// <summary>
+        /// Nonzeros of the lower triangle of the Hessian of the Lagrangian (objective plus
+        /// constraints), with Row at or after Column in Variables order
+        /// </summary>
+        public List<(string Row, string Column)> Hessian { get; } = new List<(string, string)>();
+
+        public int Rows { get; private set; }
+
+        public static DerivativeStructure Analyze(Expression? objective, IReadOnlyList<Expression> constraints, ModelManager modelManager)
+        {
+            var structure = new DerivativeStructure { Rows = constraints.Count };
+            var functions = new List<Expression>();
+            if (objective != null)
+                functions.Add(Differentiator.Normalize(objective, modelManager));
+            var rows = constraints.Select(c => Differentiator.Normalize(c, modelManager)).ToList();
+            functions.AddRange(rows);
+
+            foreach (var function in functions)
+            {
+                foreach (string variable in Differentiator.Variables(function))
+                {
+                    if (!structure.Variables.Contains(variable))
+                        structure.Variables.Add(variable);
+                }
+            }
+
+            for (int row = 0; row < rows.Count; row++)
+            {
+                foreach (string variable in Differentiator.Variables(rows[row]))
+                    structure.Jacobian.Add((row, variable));
+            }
+
+            var hessian = new HashSet<(string, string)>();
+            foreach (var function in functions)
+            {
+                var variables = Differentiator.Variables(function);
+                foreach (string first in variables)
+                {
+                    var gradient = Differentiator.Derivative(function, first);
+                    foreach (string second in Differentiator.Variables(gradient))
+                    {
+                        bool lower = structure.Variables.IndexOf(first) >= structure.Variables.IndexOf(second);
+                        hessian.Add(lower ? (first, second) : (second, first));
+                    }
+                }
+            }
+
+            structure.Hessian.AddRange(hessian
+                .OrderBy(e => structure.Variables.IndexOf(e.Item1))
+                .ThenBy(e => structure.Variables.IndexOf(e.Item2)));
+            return structure;
+        }
+
+        /// <summary>
+        /// True if no second derivative is left, i.e. the objective and every constraint are linear
+        /// </summary>
+        public bool IsLinear => Hessian.Count == 0;
+
+        public override string ToString() =>
+            $"{Rows} constraint(s), {Variables.Count} variable(s), {Jacobian.Count} Jacobian and {Hessian.Count} Hessian nonzero(s)";
+    }
+}
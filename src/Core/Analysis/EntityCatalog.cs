@@ -0,0 +1,359 @@
+using System.Text.RegularExpressions;
+using Core.Formatting;
+using Core.Models;
+
+namespace Core.Analysis
+{
+    public enum EntityKind
+    {
+        Set,
+        Parameter,
+        Variable,
+        DecisionExpression,
+        Constraint,
+        Objective
+    }
+
+    /// <summary>
+    /// One browsable model entity with its detail text and outgoing references
+    /// </summary>
+    public class CatalogEntry
+    {
+        /// <summary>
+        /// Unique key, e.g. "parameter:cost" or "constraint:cap[2]"
+        /// </summary>
+        public string Key { get; init; } = "";
+        public string Name { get; init; } = "";
+        public EntityKind Kind { get; init; }
+
+        /// <summary>
+        /// Grouping node in the browser tree (entity kind, or constraint family)
+        /// </summary>
+        public string Group { get; init; } = "";
+
+        public List<string> Details { get; } = new List<string>();
+
+        /// <summary>
+        /// Keys of entities this entity uses
+        /// </summary>
+        public SortedSet<string> References { get; } = new SortedSet<string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Keys of entities that use this entity
+        /// </summary>
+        public SortedSet<string> ReferencedBy { get; } = new SortedSet<string>(StringComparer.Ordinal);
+
+        public override string ToString() => Key;
+    }
+
+    /// <summary>
+    /// Flat, searchable index of the entities in a model with references in both directions,
+    /// used by the model browsers
+    /// </summary>
+    public class EntityCatalog
+    {
+        private readonly Dictionary<string, CatalogEntry> entries = new Dictionary<string, CatalogEntry>(StringComparer.Ordinal);
+
+        public IReadOnlyCollection<CatalogEntry> Entries => entries.Values;
+
+        public static EntityCatalog Build(ModelManager manager)
+        {
+            var catalog = new EntityCatalog();
+            var formatter = new ExpressionFormatter(ExpressionStyle.Unicode);
+
+            foreach (var range in manager.Ranges.Values)
+            {
+                var entry = catalog.Add(EntityKind.Set, range.Name, "Sets");
+                entry.Details.Add($"range {range.Name} = {formatter.Format(range.StartExpression)}..{formatter.Format(range.EndExpression)}");
+                AddDescription(entry, range.Description, null);
+                catalog.AddExpressionReferences(entry, range.StartExpression);
+                catalog.AddExpressionReferences(entry, range.EndExpression);
+            }
+
+            foreach (var set in manager.IndexSets.Values.Where(s => !manager.Ranges.ContainsKey(s.Name)))
+            {
+                var entry = catalog.Add(EntityKind.Set, set.Name, "Sets");
+                entry.Details.Add($"{set.Name} = {set.StartIndex}..{set.EndIndex} ({set.Count} elements)");
+                AddDescription(entry, set.Description, null);
+            }
+
+            foreach (var set in manager.PrimitiveSets.Values)
+            {
+                var entry = catalog.Add(EntityKind.Set, set.Name, "Sets");
+                entry.Details.Add($"{{{set.Type.ToString().ToLowerInvariant()}}} {set.Name} ({set.Count} elements)");
+                AddDescription(entry, set.Description, null);
+            }
+
+            foreach (var set in manager.TupleSets.Values)
+            {
+                var entry = catalog.Add(EntityKind.Set, set.Name, "Sets");
+                entry.Details.Add($"{{{set.SchemaName}}} {set.Name} ({set.Count} tuples)");
+                AddDescription(entry, set.Description, null);
+            }
+
+            foreach (var param in manager.Parameters.Values)
+            {
+                var entry = catalog.Add(EntityKind.Parameter, param.Name, "Parameters");
+                string indices = param.IsIndexed ? $"[{string.Join(",", param.IndexSetNames!)}]" : "";
+                string value = param.IsExternal ? " = ..." : param.IsScalar && param.Value != null ? $" = {param.Value}" : "";
+                entry.Details.Add($"{param.Type.ToString().ToLowerInvariant()} {param.Name}{indices}{value}");
+                AddDescription(entry, param.Description, param.Unit);
+
+                foreach (var setName in param.IndexSetNames ?? new List<string>())
+                    entry.References.Add(KeyOf(EntityKind.Set, Windows.SetName(setName)));
+
+                if (param.ComputeExpression != null)
+                    catalog.AddExpressionReferences(entry, param.ComputeExpression);
+            }
+
+            foreach (var variable in manager.IndexedVariables.Values)
+            {
+                var entry = catalog.Add(EntityKind.Variable, variable.BaseName, "Variables");
+                var sets = new List<string>();
+                if (!variable.IsScalar) sets.Add(variable.IndexSetName);
+                if (variable.SecondIndexSetName != null) sets.Add(variable.SecondIndexSetName);
+                if (variable.AdditionalIndexSets != null) sets.AddRange(variable.AdditionalIndexSets);
+
+                entry.Details.Add($"dvar {variable.Type.ToString().ToLowerInvariant()} {variable.BaseName}" +
+                                  (sets.Count > 0 ? $"[{string.Join(",", sets)}]" : "") +
+                                  $" in {variable.LowerBound?.ToString() ?? "-∞"}..{variable.UpperBound?.ToString() ?? "∞"}");
+                AddDescription(entry, variable.Description, variable.Unit);
+
+                foreach (var setName in sets)
+                    entry.References.Add(KeyOf(EntityKind.Set, Windows.SetName(setName)));
+            }
+
+            foreach (var dexpr in manager.DecisionExpressions.Values)
+            {
+                var entry = catalog.Add(EntityKind.DecisionExpression, dexpr.Name, "Decision expressions");
+                entry.Details.Add($"dexpr {dexpr.Name} = {formatter.Format(dexpr.Expression)}");
+                AddDescription(entry, manager.Documentation.GetValueOrDefault(entry.Key), null);
+                catalog.AddExpressionReferences(entry, dexpr.Expression);
+            }
+
+            // Rules whose constraints are not generated yet are listed as one entry each
+            foreach (var forall in manager.ForallStatements.Concat(manager.PendingRules))
+            {
+                if (forall.ConstraintTemplate == null)
+                    continue;
+
+                string name = manager.ForallStatements.Contains(forall)
+                    ? forall.Label ?? $"forall{manager.ForallStatements.IndexOf(forall) + 1}"
+                    : manager.RuleName(forall);
+                var entry = catalog.Add(EntityKind.Constraint, name, "Constraints");
+                var template = forall.ConstraintTemplate;
+                string iterators = string.Join(", ", forall.Iterators.Select(i => $"{i.VariableName} in {i.Range.SetName ?? "range"}"));
+                entry.Details.Add($"forall({iterators}) {formatter.FormatRelation(template.LeftSide, template.Operator, template.RightSide)}");
+                AddDescription(entry, manager.Documentation.GetValueOrDefault(entry.Key), null);
+                catalog.AddExpressionReferences(entry, template.LeftSide);
+                catalog.AddExpressionReferences(entry, template.RightSide);
+
+                foreach (var iterator in forall.Iterators.Where(i => i.Range.SetName != null))
+                    entry.References.Add(KeyOf(EntityKind.Set, iterator.Range.SetName!));
+            }
+
+            var equations = manager.Equations;
+            for (int i = 0; i < equations.Count; i++)
+            {
+                var equation = equations[i];
+                string family = equation.BaseName ?? equation.Label ?? "constraints";
+                string name = equation.Label ?? (string.IsNullOrEmpty(equation.GetDescription()) ? $"c{i + 1}" : equation.GetDescription());
+                var entry = catalog.Add(EntityKind.Constraint, name, $"Constraints/{family}");
+                entry.Details.Add(formatter.Format(equation, includeLabel: false));
+                AddDescription(entry, manager.Documentation.GetValueOrDefault(KeyOf(EntityKind.Constraint, family)), null);
+
+                foreach (var kvp in equation.Coefficients)
+                {
+                    catalog.AddVariableReference(entry, manager, kvp.Key);
+                    catalog.AddExpressionReferences(entry, kvp.Value);
+                }
+                catalog.AddExpressionReferences(entry, equation.Constant);
+            }
+
+            if (manager.Objective != null)
+            {
+                var entry = catalog.Add(EntityKind.Objective, manager.Objective.Name ?? "objective", "Objective");
+                entry.Details.Add(formatter.Format(manager.Objective));
+                AddDescription(entry, manager.Documentation.GetValueOrDefault(KeyOf(EntityKind.Objective, "")), null);
+
+                foreach (var kvp in manager.Objective.Coefficients)
+                {
+                    catalog.AddVariableReference(entry, manager, kvp.Key);
+                    catalog.AddExpressionReferences(entry, kvp.Value);
+                }
+            }
+
+            catalog.LinkReverseReferences();
+            return catalog;
+        }
+
+        public CatalogEntry? Get(string key)
+        {
+            return entries.TryGetValue(key, out var entry) ? entry : null;
+        }
+
+        /// <summary>
+        /// Group names in display order (Sets, Parameters, Variables, ..., Constraints/family)
+        /// </summary>
+        public IEnumerable<string> GetGroups()
+        {
+            return entries.Values
+                .Select(e => e.Group)
+                .Distinct()
+                .OrderBy(g => GroupOrder(g))
+                .ThenBy(g => g, StringComparer.Ordinal);
+        }
+
+        public IEnumerable<CatalogEntry> GetGroup(string group)
+        {
+            return entries.Values.Where(e => e.Group == group).OrderBy(e => e.Name, StringComparer.Ordinal);
+        }
+
+        /// <summary>
+        /// Case-insensitive search in names; a query containing '*' or '?' is treated as a wildcard pattern
+        /// </summary>
+        public IEnumerable<CatalogEntry> Search(string query)
+        {
+            if (string.IsNullOrWhiteSpace(query))
+                return Enumerable.Empty<CatalogEntry>();
+
+            Func<string, bool> matches;
+            if (query.IndexOfAny(new[] { '*', '?' }) >= 0)
+            {
+                var regex = new Regex("^" + Regex.Escape(query).Replace("\\*", ".*").Replace("\\?", ".") + "$", RegexOptions.IgnoreCase);
+                matches = regex.IsMatch;
+            }
+            else
+            {
+                matches = name => name.Contains(query, StringComparison.OrdinalIgnoreCase);
+            }
+
+            return entries.Values
+                .Where(e => matches(e.Name))
+                .OrderBy(e => GroupOrder(e.Group))
+                .ThenBy(e => e.Name, StringComparer.Ordinal);
+        }
+
+        public static string KeyOf(EntityKind kind, string name) => kind switch
+        {
+            EntityKind.Set => $"set:{name}",
+            EntityKind.Parameter => $"parameter:{name}",
+            EntityKind.Variable => $"variable:{name}",
+            EntityKind.DecisionExpression => $"dexpr:{name}",
+            EntityKind.Constraint => $"constraint:{name}",
+            _ => "objective"
+        };
+
+        private CatalogEntry Add(EntityKind kind, string name, string group)
+        {
+            string key = KeyOf(kind, name);
+            string uniqueKey = key;
+            int counter = 2;
+
+            while (entries.ContainsKey(uniqueKey))
+            {
+                uniqueKey = $"{key}#{counter++}";
+            }
+
+            var entry = new CatalogEntry { Key = uniqueKey, Name = name, Kind = kind, Group = group };
+            entries[uniqueKey] = entry;
+            return entry;
+        }
+
+        private void AddVariableReference(CatalogEntry entry, ModelManager manager, string expandedName)
+        {
+            var variable = manager.IndexedVariables.Values
+                .Where(v => v.BaseName == expandedName || (!v.IsScalar && expandedName.StartsWith(v.BaseName, StringComparison.Ordinal)))
+                .OrderByDescending(v => v.BaseName.Length)
+                .FirstOrDefault();
+
+            entry.References.Add(KeyOf(EntityKind.Variable, variable?.BaseName ?? expandedName));
+        }
+
+        private void AddExpressionReferences(CatalogEntry entry, Expression? expression)
+        {
+            switch (expression)
+            {
+                case null:
+                    return;
+                case ParameterExpression p:
+                    entry.References.Add(KeyOf(EntityKind.Parameter, p.ParameterName));
+                    break;
+                case IndexedParameterExpression ip:
+                    entry.References.Add(KeyOf(EntityKind.Parameter, ip.ParameterName));
+                    ip.Indices.ForEach(i => AddExpressionReferences(entry, i));
+                    break;
+                case VariableExpression v:
+                    entry.References.Add(KeyOf(EntityKind.Variable, v.VariableName));
+                    break;
+                case IndexedVariableExpression iv:
+                    entry.References.Add(KeyOf(EntityKind.Variable, iv.BaseName));
+                    break;
+                case DecisionExpressionExpression d:
+                    entry.References.Add(KeyOf(EntityKind.DecisionExpression, d.Name));
+                    break;
+                case BinaryExpression b:
+                    AddExpressionReferences(entry, b.Left);
+                    AddExpressionReferences(entry, b.Right);
+                    break;
+                case ComparisonExpression c:
+                    AddExpressionReferences(entry, c.Left);
+                    AddExpressionReferences(entry, c.Right);
+                    break;
+                case UnaryExpression u:
+                    AddExpressionReferences(entry, u.Operand);
+                    break;
+                case SummationExpression s:
+                    entry.References.Add(KeyOf(EntityKind.Set, Windows.SetName(s.SetName)));
+                    AddExpressionReferences(entry, s.Body);
+                    break;
+                case AggregationExpression a:
+                    foreach (var (_, setName) in a.Iterators)
+                        entry.References.Add(KeyOf(EntityKind.Set, Windows.SetName(setName)));
+                    AddExpressionReferences(entry, a.Filter);
+                    AddExpressionReferences(entry, a.Body);
+                    break;
+                case MathFunctionExpression f:
+                    foreach (var argument in f.Arguments)
+                        AddExpressionReferences(entry, argument);
+                    break;
+            }
+        }
+
+        /// <summary>
+        /// Drops references to unknown entities (iterator variables look like parameters) and fills ReferencedBy
+        /// </summary>
+        private void LinkReverseReferences()
+        {
+            foreach (var entry in entries.Values)
+            {
+                entry.References.RemoveWhere(r => r == entry.Key || !entries.ContainsKey(r));
+                foreach (var reference in entry.References)
+                    entries[reference].ReferencedBy.Add(entry.Key);
+            }
+        }
+
+        private static void AddDescription(CatalogEntry entry, string? description, string? unit)
+        {
+            if (!string.IsNullOrEmpty(unit))
+                entry.Details.Add($"Unit: {unit}");
+            if (!string.IsNullOrEmpty(description))
+                entry.Details.Add(description);
+        }
+
+        private static int GroupOrder(string group)
+        {
+            if (group.StartsWith("Constraints", StringComparison.Ordinal))
+                return 4;
+
+            return group switch
+            {
+                "Sets" => 0,
+                "Parameters" => 1,
+                "Variables" => 2,
+                "Decision expressions" => 3,
+                _ => 5
+            };
+        }
+    }
+}
@@ -0,0 +1,147 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Immutable ids of the declarations of a model text. Every declaration carries a line
+    /// comment with an id that never changes once assigned:
+    /// <code>
+    /// // @id 7d8c4b0e2f9a4c61b35e0d2a9f6e1c47
+    /// dvar float+ production[Plants];
+    /// </code>
+    /// The id lives in the leading comments of the statement, so it is saved and loaded with the
+    /// text by every storage and package format, and kept when the entity is renamed (see Rename)
+    /// or its declaration is rewritten. Test baselines, dashboards and annotations kept outside
+    /// the model refer to entities by id instead of by their names.
+    /// </summary>
+    public class EntityIds
+    {
+        public const string Annotation = "// @id";
+
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@id[ \t]+([0-9a-f]{32})[ \t]*$", RegexOptions.Multiline);
+
+        private readonly Dictionary<string, string> idsByKey = new Dictionary<string, string>(StringComparer.Ordinal);
+        private readonly Dictionary<string, string> keysById = new Dictionary<string, string>(StringComparer.Ordinal);
+
+        private EntityIds()
+        {
+        }
+
+        /// <summary>
+        /// Keys of the declarations that have an id, in source order
+        /// </summary>
+        public IReadOnlyCollection<string> Keys => idsByKey.Keys;
+
+        /// <summary>
+        /// Keys of declarations that carry the id of an earlier declaration, e.g. a declaration
+        /// copied with its comments; Assign gives them new ids
+        /// </summary>
+        public List<string> Duplicates { get; } = new List<string>();
+
+        public static EntityIds Parse(string modelText) => Parse(ModelSource.Parse(modelText));
+
+        public static EntityIds Parse(ModelSource source)
+        {
+            var ids = new EntityIds();
+
+            foreach (var statement in source.Statements.Where(s => s.Key != null))
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                var m = annotationPattern.Matches(trivia).LastOrDefault();
+                if (m == null || ids.idsByKey.ContainsKey(statement.Key!))
+                    continue;
+
+                string id = m.Groups[1].Value;
+                if (!ids.keysById.TryAdd(id, statement.Key!))
+                {
+                    ids.Duplicates.Add(statement.Key!);
+                    continue;
+                }
+                ids.idsByKey[statement.Key!] = id;
+            }
+
+            return ids;
+        }
+
+        /// <summary>
+        /// Id of the declaration of <paramref name="key"/>, or null if it has none
+        /// </summary>
+        public string? Find(string key) => idsByKey.GetValueOrDefault(key);
+
+        /// <summary>
+        /// Key the entity with <paramref name="id"/> is declared under now, or null if it is gone
+        /// </summary>
+        public string? FindKey(string id) => keysById.GetValueOrDefault(id);
+
+        public static string NewId() => Guid.NewGuid().ToString("N");
+
+        /// <summary>
+        /// Gives an id to every declaration without one and a new id to every duplicate.
+        /// Existing ids are never changed. Returns the number of ids assigned.
+        /// </summary>
+        public static int Assign(ModelSource source)
+        {
+            var ids = Parse(source);
+            int assigned = 0;
+
+            foreach (var key in source.Statements.Where(s => s.Key != null).Select(s => s.Key!).Distinct().ToList())
+            {
+                if (ids.Find(key) != null && !ids.Duplicates.Contains(key))
+                    continue;
+                source.Annotate(key, $"{Annotation} {NewId()}");
+                assigned++;
+            }
+            return assigned;
+        }
+
+        /// <summary>
+        /// The model text with an id on every declaration
+        /// </summary>
+        public static string Assign(string modelText)
+        {
+            var source = ModelSource.Parse(modelText);
+            Assign(source);
+            return source.ToString();
+        }
+
+        /// <summary>
+        /// Renames an entity in its declaration and everywhere its name is used, outside strings,
+        /// comments and tuple field accesses. The leading comments of every statement are kept,
+        /// so the entity keeps its id. Returns the number of sites renamed.
+        /// </summary>
+        public static int Rename(ModelSource source, string key, string newName)
+        {
+            if (!Regex.IsMatch(newName, @"^[A-Za-z_]\w*$"))
+                throw new InvalidOperationException($"'{newName}' is not a valid name");
+
+            if (source.Find(key) == null)
+                throw new InvalidOperationException($"'{key}' is not declared");
+            int colon = key.IndexOf(':');
+            if (colon < 0)
+                throw new InvalidOperationException($"'{key}' has no name to rename");
+            string name = key.Substring(colon + 1);
+            if (source.Statements.Any(s => s.Key != null && s.Key.Substring(s.Key.IndexOf(':') + 1) == newName))
+                throw new InvalidOperationException($"Cannot rename '{name}': '{newName}' is already declared");
+
+            var sites = new Regex($@"""(?:[^""\\]|\\.)*""|//[^\r\n]*|/\*.*?\*/|(?<![\w.]){Regex.Escape(name)}(?!\w)", RegexOptions.Singleline);
+            int renamed = 0;
+
+            foreach (var statement in source.Statements.ToList())
+            {
+                string code = sites.Replace(statement.Code, m =>
+                {
+                    if (m.Value != name)
+                        return m.Value;
+                    renamed++;
+                    return newName;
+                });
+                if (code != statement.Code)
+                    source.Replace(statement, code);
+            }
+            return renamed;
+        }
+
+        public override string ToString() => $"{idsByKey.Count} entities with ids";
+    }
+}
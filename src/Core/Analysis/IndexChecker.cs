@@ -0,0 +1,257 @@
+using Core.Models;
+using Core.Parsing;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Static check of the indexed references in a model text: every x[...] of a declared
+    /// variable or indexed parameter must have one index per index set of its declaration, and
+    /// literals and iterators must have the element type of the set at their position (x[p,t]
+    /// for x over Plants × Periods, not x[p] or x[t,p] with p a plant name). The diagnostics
+    /// point at the reference, so a wrong arity is reported where it is written rather than as
+    /// a failed expansion or export. Indices with tuple literals (cost[&lt;1,2&gt;]) and sets
+    /// whose element type is only known after evaluation are counted but not type-checked.
+    /// Iterators are also checked against the element type of their set: arithmetic (t-1) is
+    /// only defined on int and float elements, and a comparison needs an operand of the same
+    /// type (a timestamp compares with a timestamp string). Lead and lag over string and
+    /// timestamp sets go by position in the ordered set (PrimitiveSet.Shift), not by arithmetic.
+    /// </summary>
+    public static class IndexChecker
+    {
+        public static List<SyntaxDiagnostic> Check(ModelManager manager, string modelText)
+        {
+            var diagnostics = new List<SyntaxDiagnostic>();
+            var tokens = ModelLexer.Classify(modelText)
+                .Where(t => t.Kind is not (SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring or SyntaxTokenKind.Annotation))
+                .ToList();
+
+            int offset = 0;
+            int first = 0;
+            foreach (var statement in ModelSource.Parse(modelText).Statements)
+            {
+                offset += statement.Text.Length;
+                var statementTokens = new List<SyntaxToken>();
+                while (first < tokens.Count && tokens[first].Offset < offset)
+                    statementTokens.Add(tokens[first++]);
+
+                // Script blocks index JavaScript arrays, not model entities
+                string code = statement.Code;
+                if (code.StartsWith("execute", StringComparison.Ordinal) || code.StartsWith("main", StringComparison.Ordinal))
+                    continue;
+
+                CheckStatement(manager, modelText, statementTokens, diagnostics);
+            }
+
+            return diagnostics;
+        }
+
+        private static void CheckStatement(ModelManager manager, string modelText, List<SyntaxToken> tokens, List<SyntaxDiagnostic> diagnostics)
+        {
+            // Iterators of the statement and the sets they run over: "p in Plants"
+            var iterators = new Dictionary<string, string>(StringComparer.Ordinal);
+            for (int i = 0; i + 2 < tokens.Count; i++)
+            {
+                if (tokens[i].Kind != SyntaxTokenKind.Identifier || tokens[i + 1].Text != "in" || tokens[i + 2].Kind != SyntaxTokenKind.Identifier)
+                    continue;
+
+                // A window runs over its set: "s in window(T, t, 4)"
+                int set = tokens[i + 2].Text == "window" && i + 4 < tokens.Count && tokens[i + 3].Text == "(" ? i + 4 : i + 2;
+                iterators.TryAdd(tokens[i].Text, tokens[set].Text);
+            }
+
+            for (int i = 0; i + 1 < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                if (token.Kind != SyntaxTokenKind.Identifier || token.IsDeclaration || token.IsMember
+                    || tokens[i + 1].Text != "[" || iterators.ContainsKey(token.Text))
+                    continue;
+
+                var sets = IndexSets(manager, token.Text);
+                if (sets == null)
+                    continue;
+
+                // x[p][t] and x[p,t] are the same reference
+                var indices = new List<List<SyntaxToken>>();
+                int next = i + 1;
+                while (next < tokens.Count && tokens[next].Text == "[")
+                {
+                    int close = ReadIndices(tokens, next, indices);
+                    if (close < 0)
+                        return; // Unbalanced brackets are reported as syntax errors
+                    next = close + 1;
+                }
+
+                if (indices.Any(index => index.Count == 0 || index.Any(t => t.Text == "<")))
+                    continue;
+
+                var end = tokens[next - 1];
+                string reference = modelText.Substring(token.Offset, end.Offset + end.Length - token.Offset);
+                if (indices.Count != sets.Count)
+                {
+                    string declared = sets.Count == 0 ? "is not indexed" : $"is declared over {string.Join(" × ", sets)}";
+                    diagnostics.Add(Diagnostic(token,
+                        $"'{token.Text}' {declared} but {reference} has {indices.Count} {(indices.Count == 1 ? "index" : "indices")}"));
+                    continue;
+                }
+
+                for (int k = 0; k < sets.Count; k++)
+                {
+                    string? expected = ElementType(manager, sets[k]);
+                    string? actual = TypeOf(manager, indices[k], iterators);
+                    if (expected != null && actual != null && !Compatible(expected, actual, indices[k][0]))
+                    {
+                        diagnostics.Add(Diagnostic(indices[k][0],
+                            $"index {k + 1} of {reference} is {actual} but '{token.Text}' is indexed over {sets[k]} ({expected})"));
+                    }
+                }
+            }
+
+            CheckElementOperations(manager, tokens, iterators, diagnostics);
+        }
+
+        /// <summary>
+        /// Arithmetic and comparisons applied to the iterators of a statement
+        /// </summary>
+        private static void CheckElementOperations(ModelManager manager, List<SyntaxToken> tokens,
+            Dictionary<string, string> iterators, List<SyntaxDiagnostic> diagnostics)
+        {
+            for (int i = 0; i < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                if (token.Kind != SyntaxTokenKind.Identifier || token.IsMember || !iterators.TryGetValue(token.Text, out var set))
+                    continue;
+
+                // The binding itself (p in Plants) and tuple fields (a.cost) are not element operations
+                string after = i + 1 < tokens.Count ? tokens[i + 1].Text : "";
+                string before = i > 0 ? tokens[i - 1].Text : "";
+                if (after is "in" or ".")
+                    continue;
+
+                string? type = ElementType(manager, set);
+                if (type == null || type is "int" or "float")
+                    continue;
+
+                if (after is "+" or "-" or "*" or "/" or "%" || before is "+" or "-" or "*" or "/" or "%")
+                {
+                    diagnostics.Add(Diagnostic(token,
+                        $"arithmetic on '{token.Text}' is not defined: it is a {type} element of {set}"));
+                    continue;
+                }
+
+                // Only the operand on the far side of the operator: p == "North", t >= "2024-01-01"
+                if (after is "==" or "!=" or "<" or "<=" or ">" or ">=" && i + 2 < tokens.Count)
+                    CheckComparison(manager, token, type, set, tokens, i + 2, +1, iterators, diagnostics);
+                else if (before is "==" or "!=" or "<" or "<=" or ">" or ">=" && i >= 2)
+                    CheckComparison(manager, token, type, set, tokens, i - 2, -1, iterators, diagnostics);
+            }
+        }
+
+        private static void CheckComparison(ModelManager manager, SyntaxToken iterator, string type, string set,
+            List<SyntaxToken> tokens, int other, int direction, Dictionary<string, string> iterators, List<SyntaxDiagnostic> diagnostics)
+        {
+            // An operand longer than one token (cost[p], a.name) is not typed here
+            if (direction > 0 && other + 1 < tokens.Count && tokens[other + 1].Text is "[" or "." or "(")
+                return;
+
+            var operand = tokens[other];
+            string? actual = TypeOf(manager, new List<SyntaxToken> { operand }, iterators);
+            if (actual == null || Compatible(type, actual, operand) || Compatible(actual, type, operand))
+                return;
+
+            diagnostics.Add(Diagnostic(iterator,
+                $"'{iterator.Text}' is a {type} element of {set} and cannot be compared with {operand.Text} ({actual})"));
+        }
+
+        /// <summary>
+        /// Reads the comma-separated indices between the bracket at <paramref name="open"/> and its
+        /// closing bracket, whose position is returned; -1 if it is not closed
+        /// </summary>
+        private static int ReadIndices(List<SyntaxToken> tokens, int open, List<List<SyntaxToken>> indices)
+        {
+            var current = new List<SyntaxToken>();
+            int depth = 0;
+            for (int i = open + 1; i < tokens.Count; i++)
+            {
+                string text = tokens[i].Text;
+                if (depth == 0 && text == "]")
+                {
+                    indices.Add(current);
+                    return i;
+                }
+                if (depth == 0 && text == ",")
+                {
+                    indices.Add(current);
+                    current = new List<SyntaxToken>();
+                    continue;
+                }
+
+                if (text is "(" or "[" or "{")
+                    depth++;
+                else if (text is ")" or "]" or "}")
+                    depth--;
+                current.Add(tokens[i]);
+            }
+            return -1;
+        }
+
+        /// <summary>
+        /// Index sets of a variable or indexed parameter, or null for anything else. Indexed
+        /// dexprs keep only their first iterator, so their arity is not known here.
+        /// </summary>
+        private static IReadOnlyList<string>? IndexSets(ModelManager manager, string name)
+        {
+            if (manager.IndexedVariables.TryGetValue(name, out var variable))
+                return variable.IndexSetNames;
+
+            // Arrays filled by scripts are registered as scalars; only declared indices are checked
+            if (manager.Parameters.TryGetValue(name, out var parameter) && parameter.IsIndexed)
+                return parameter.IndexSetNames!;
+
+            return null;
+        }
+
+        /// <summary>
+        /// Element type of a set ("int", "string", "tuple Arc"), or null if it is not known before evaluation
+        /// </summary>
+        private static string? ElementType(ModelManager manager, string set)
+        {
+            if (manager.Ranges.ContainsKey(set) || manager.IndexSets.ContainsKey(set))
+                return "int";
+            if (manager.PrimitiveSets.TryGetValue(set, out var primitiveSet))
+                return primitiveSet.Type.ToString().ToLowerInvariant();
+            if (manager.TupleSets.TryGetValue(set, out var tupleSet))
+                return $"tuple {tupleSet.SchemaName}";
+            return null;
+        }
+
+        /// <summary>
+        /// Type of a single-token index: a literal, or an iterator of the statement
+        /// </summary>
+        private static string? TypeOf(ModelManager manager, List<SyntaxToken> index, Dictionary<string, string> iterators)
+        {
+            if (index.Count != 1)
+                return null;
+
+            var token = index[0];
+            return token.Kind switch
+            {
+                SyntaxTokenKind.Number => token.Text.IndexOfAny(new[] { '.', 'e', 'E' }) >= 0 ? "float" : "int",
+                SyntaxTokenKind.String => "string",
+                SyntaxTokenKind.Identifier when iterators.TryGetValue(token.Text, out var set) => ElementType(manager, set),
+                _ => null
+            };
+        }
+
+        /// <summary>
+        /// True if a value of type <paramref name="actual"/> can stand for an element of type
+        /// <paramref name="expected"/>; string literals stand for timestamps they can be read as
+        /// </summary>
+        private static bool Compatible(string expected, string actual, SyntaxToken token) =>
+            expected == actual || (expected == "float" && actual == "int")
+            || (expected == "timestamp" && actual == "string" && token.Kind == SyntaxTokenKind.String
+                && PrimitiveSet.TryParseTimestamp(token.Text, out _));
+
+        private static SyntaxDiagnostic Diagnostic(SyntaxToken token, string message) =>
+            new SyntaxDiagnostic { LineNumber = token.LineNumber, Column = token.Column, Message = message };
+    }
+}
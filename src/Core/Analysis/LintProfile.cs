@@ -0,0 +1,174 @@
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// A named set of per-rule severities. Rules a profile does not mention keep the severity of
+    /// the profile it extends, and in the end their default severity.
+    /// </summary>
+    public class LintProfile
+    {
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Profile this one starts from ("default", "strict" or another profile in the same file)
+        /// </summary>
+        public string? Extends { get; init; }
+
+        /// <summary>
+        /// Severity overrides by rule id
+        /// </summary>
+        public Dictionary<string, LintSeverity> Rules { get; init; } = new Dictionary<string, LintSeverity>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Every rule at its default severity
+        /// </summary>
+        public static readonly LintProfile Default = new LintProfile { Name = "default" };
+
+        /// <summary>
+        /// Every rule on, one level above its default: off and info become warnings, warnings become errors
+        /// </summary>
+        public static readonly LintProfile Strict = new LintProfile
+        {
+            Name = "strict",
+            Rules = ModelLinter.Rules.ToDictionary(r => r.Id, r => r.DefaultSeverity switch
+            {
+                LintSeverity.Off or LintSeverity.Info => LintSeverity.Warning,
+                _ => LintSeverity.Error
+            }, StringComparer.Ordinal)
+        };
+
+        public static IReadOnlyList<LintProfile> BuiltIn { get; } = new[] { Default, Strict };
+
+        public LintSeverity SeverityOf(string ruleId)
+        {
+            if (Rules.TryGetValue(ruleId, out var severity))
+                return severity;
+            return ModelLinter.GetRule(ruleId)?.DefaultSeverity ?? LintSeverity.Off;
+        }
+
+        public override string ToString() => Name;
+    }
+
+    /// <summary>
+    /// Lint profiles from a .modellint.json file checked into the model repository:
+    /// <code>
+    /// {
+    ///   "profiles": {
+    ///     "company": { "extends": "strict", "rules": { "unused-set": "off", "free-variable": "error" } }
+    ///   }
+    /// }
+    /// </code>
+    /// Profiles in the file may extend each other or a built-in profile, and may replace a
+    /// built-in profile by using its name.
+    /// </summary>
+    public class LintConfiguration
+    {
+        public const string FileName = ".modellint.json";
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            Converters = { new JsonStringEnumConverter(JsonNamingPolicy.CamelCase) },
+            ReadCommentHandling = JsonCommentHandling.Skip,
+            AllowTrailingCommas = true,
+            WriteIndented = true
+        };
+
+        public Dictionary<string, LintProfile> Profiles { get; init; } = new Dictionary<string, LintProfile>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Path the configuration was read from, or null for the built-in configuration
+        /// </summary>
+        [JsonIgnore]
+        public string? Path { get; private set; }
+
+        public static LintConfiguration Parse(string json)
+        {
+            LintConfiguration? configuration;
+            try
+            {
+                configuration = JsonSerializer.Deserialize<LintConfiguration>(json, jsonOptions);
+            }
+            catch (JsonException ex)
+            {
+                throw new InvalidOperationException($"Invalid lint configuration: {ex.Message}", ex);
+            }
+
+            configuration ??= new LintConfiguration();
+            foreach (var (name, profile) in configuration.Profiles)
+            {
+                foreach (var ruleId in profile.Rules.Keys)
+                {
+                    if (ModelLinter.GetRule(ruleId) == null)
+                        throw new InvalidOperationException($"Lint profile '{name}' configures unknown rule '{ruleId}'");
+                }
+            }
+            return configuration;
+        }
+
+        public static LintConfiguration Load(string path)
+        {
+            var configuration = Parse(File.ReadAllText(path));
+            configuration.Path = path;
+            return configuration;
+        }
+
+        /// <summary>
+        /// Loads the nearest .modellint.json in the directory or one of its parents; the built-in
+        /// profiles only if there is none
+        /// </summary>
+        public static LintConfiguration Find(string directory)
+        {
+            for (var current = new DirectoryInfo(directory); current != null; current = current.Parent)
+            {
+                string path = System.IO.Path.Combine(current.FullName, FileName);
+                if (File.Exists(path))
+                    return Load(path);
+            }
+            return new LintConfiguration();
+        }
+
+        /// <summary>
+        /// Names of all profiles available: built-in first, then those from the file
+        /// </summary>
+        public IEnumerable<string> ProfileNames =>
+            LintProfile.BuiltIn.Select(p => p.Name).Concat(Profiles.Keys.OrderBy(n => n, StringComparer.Ordinal)).Distinct();
+
+        /// <summary>
+        /// Flattens a profile and everything it extends into one profile with an explicit
+        /// severity for every rule
+        /// </summary>
+        public LintProfile Resolve(string name)
+        {
+            var chain = new List<LintProfile>();
+            string? current = name;
+            while (current != null)
+            {
+                if (chain.Any(p => p.Name == current))
+                    throw new InvalidOperationException($"Lint profile '{name}' extends itself via '{current}'");
+
+                var profile = Profiles.TryGetValue(current, out var defined)
+                    ? new LintProfile { Name = current, Extends = defined.Extends, Rules = defined.Rules }
+                    : LintProfile.BuiltIn.FirstOrDefault(p => p.Name == current)
+                      ?? throw new InvalidOperationException(
+                          $"Unknown lint profile '{current}' (available: {string.Join(", ", ProfileNames)})");
+
+                chain.Add(profile);
+                current = profile.Extends;
+            }
+
+            var rules = new Dictionary<string, LintSeverity>(StringComparer.Ordinal);
+            foreach (var rule in ModelLinter.Rules)
+            {
+                // The nearest profile in the chain that mentions the rule wins
+                var owner = chain.FirstOrDefault(p => p.Rules.ContainsKey(rule.Id));
+                rules[rule.Id] = owner?.Rules[rule.Id] ?? rule.DefaultSeverity;
+            }
+
+            return new LintProfile { Name = name, Rules = rules };
+        }
+    }
+}
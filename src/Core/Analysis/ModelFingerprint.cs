@@ -0,0 +1,268 @@
+using System.Globalization;
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Models;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Stable content hashes for a parsed model. Each entity is hashed from a canonical
+    /// representation (sorted coefficients, invariant number formatting), and the model hash
+    /// is computed over the sorted entity hashes, so declaration order and source formatting
+    /// do not affect the result.
+    /// </summary>
+    public class ModelFingerprint
+    {
+        private const string StampPrefix = "* FINGERPRINT ";
+
+        /// <summary>
+        /// Hash of the whole model (lowercase hex SHA-256)
+        /// </summary>
+        public string ModelHash { get; }
+
+        /// <summary>
+        /// Entity key (e.g. "parameter:cost", "constraint:limit_1") → hash
+        /// </summary>
+        public IReadOnlyDictionary<string, string> EntityHashes { get; }
+
+        private ModelFingerprint(string modelHash, Dictionary<string, string> entityHashes)
+        {
+            ModelHash = modelHash;
+            EntityHashes = entityHashes;
+        }
+
+        public static ModelFingerprint Compute(ModelManager manager)
+        {
+            var entities = new Dictionary<string, List<string>>();
+
+            foreach (var param in manager.Parameters.Values)
+                Add(entities, $"parameter:{param.Name}", CanonicalParameter(param));
+
+            foreach (var set in manager.IndexSets.Values)
+                Add(entities, $"set:{set.Name}", $"{set.StartIndex}..{set.EndIndex}");
+
+            foreach (var range in manager.Ranges.Values)
+                Add(entities, $"range:{range.Name}", $"{Canonical(range.StartExpression)}..{Canonical(range.EndExpression)}");
+
+            foreach (var set in manager.PrimitiveSets.Values)
+                Add(entities, $"set:{set.Name}", $"{set.Type}|{string.Join(",", set.GetAllValues().Select(FormatValue))}");
+
+            foreach (var schema in manager.TupleSchemas.Values)
+                Add(entities, $"tuple:{schema.Name}",
+                    string.Join(",", schema.Fields.Select(f => $"{(schema.KeyFields.Contains(f.Key) ? "key " : "")}{f.Value} {f.Key}")));
+
+            foreach (var tupleSet in manager.TupleSets.Values)
+                Add(entities, $"tupleset:{tupleSet.Name}", $"{tupleSet.SchemaName}|{string.Join(";", tupleSet.Instances.Select(CanonicalTuple))}");
+
+            foreach (var variable in manager.IndexedVariables.Values)
+                Add(entities, $"variable:{variable.BaseName}", CanonicalVariable(variable));
+
+            foreach (var dexpr in manager.DecisionExpressions.Values)
+                Add(entities, $"dexpr:{dexpr.Name}", $"{dexpr.Type}|{dexpr.IndexSetName}|{Canonical(dexpr.Expression)}");
+
+            foreach (var template in manager.IndexedEquationTemplates.Values)
+                Add(entities, $"template:{template.BaseName}", template.ToString());
+
+            foreach (var forall in manager.ForallStatements)
+            {
+                string body = CanonicalForall(forall);
+                Add(entities, $"forall:{forall.Label ?? Hash(body)[..12]}", body);
+            }
+
+            foreach (var equation in manager.MaterializeRows())
+            {
+                string body = CanonicalEquation(equation);
+                string name = equation.Label ?? equation.GetDescription();
+                Add(entities, $"constraint:{(string.IsNullOrEmpty(name) ? Hash(body)[..12] : name)}", body);
+            }
+
+            if (manager.Objective != null)
+                Add(entities, "objective", CanonicalObjective(manager.Objective));
+
+            var hashes = Number(entities);
+            var combined = string.Join("\n", hashes.OrderBy(e => e.Key, StringComparer.Ordinal).Select(e => $"{e.Key}={e.Value}"));
+            return new ModelFingerprint(Hash(combined), hashes);
+        }
+
+        /// <summary>
+        /// True if both fingerprints describe the same model content
+        /// </summary>
+        public bool Matches(ModelFingerprint other) => other != null && ModelHash == other.ModelHash;
+
+        /// <summary>
+        /// Entity keys that were added, removed or changed relative to another fingerprint
+        /// </summary>
+        public IEnumerable<string> GetChangedEntities(ModelFingerprint other)
+        {
+            var keys = new SortedSet<string>(EntityHashes.Keys, StringComparer.Ordinal);
+            keys.UnionWith(other.EntityHashes.Keys);
+
+            foreach (var key in keys)
+            {
+                EntityHashes.TryGetValue(key, out var mine);
+                other.EntityHashes.TryGetValue(key, out var theirs);
+                if (mine != theirs)
+                    yield return key;
+            }
+        }
+
+        /// <summary>
+        /// Comment line embedded in exported files (MPS/LP comments start with '*' or '\')
+        /// </summary>
+        public string ToStampLine() => StampPrefix + ModelHash;
+
+        /// <summary>
+        /// Reads the fingerprint stamp from an exported file, if present
+        /// </summary>
+        public static bool TryReadStamp(string exportedText, out string modelHash)
+        {
+            var match = Regex.Match(exportedText, @"^[*\\] FINGERPRINT ([0-9a-f]{64})\s*$", RegexOptions.Multiline);
+            modelHash = match.Success ? match.Groups[1].Value : string.Empty;
+            return match.Success;
+        }
+
+        /// <summary>
+        /// Checks that an exported file was produced from a model with this fingerprint
+        /// </summary>
+        public bool VerifyExport(string exportedText)
+        {
+            return TryReadStamp(exportedText, out var hash) && hash == ModelHash;
+        }
+
+        public override string ToString() => ModelHash;
+
+        private static void Add(Dictionary<string, List<string>> entities, string key, string canonical)
+        {
+            if (!entities.TryGetValue(key, out var hashes))
+                entities[key] = hashes = new List<string>();
+            hashes.Add(Hash(canonical));
+        }
+
+        /// <summary>
+        /// Gives every entity its own key. Identical unlabeled constraints are legal, so every copy
+        /// is kept (key, key#2, ...); copies are numbered in order of their hashes rather than their
+        /// declarations, so reordering entities that share a label leaves the model hash unchanged.
+        /// </summary>
+        private static Dictionary<string, string> Number(Dictionary<string, List<string>> entities)
+        {
+            var numbered = new Dictionary<string, string>();
+            foreach (var (key, hashes) in entities)
+            {
+                hashes.Sort(StringComparer.Ordinal);
+                for (int i = 0; i < hashes.Count; i++)
+                    numbered[i == 0 ? key : $"{key}#{i + 1}"] = hashes[i];
+            }
+            return numbered;
+        }
+
+        private static string Hash(string text)
+        {
+            var bytes = SHA256.HashData(Encoding.UTF8.GetBytes(text));
+            return Convert.ToHexString(bytes).ToLowerInvariant();
+        }
+
+        private static string CanonicalParameter(Parameter param)
+        {
+            var sb = new StringBuilder();
+            sb.Append(param.Type).Append('|');
+            sb.Append(param.IsExternal ? "external" : "inline").Append('|');
+            sb.Append(string.Join(",", param.IndexSetNames ?? new List<string>())).Append('|');
+
+            if (param.IsScalar)
+            {
+                sb.Append(FormatValue(param.Value));
+            }
+            else
+            {
+                var entries = param.GetIndexedEntries()
+                    .OrderBy(e => e.Key, StringComparer.Ordinal)
+                    .Select(e => $"{e.Key}={FormatValue(e.Value)}");
+                sb.Append(string.Join(";", entries));
+            }
+
+            if (param.ComputeExpression != null)
+                sb.Append("|=").Append(Canonical(param.ComputeExpression));
+
+            return sb.ToString();
+        }
+
+        private static string CanonicalVariable(IndexedVariable variable)
+        {
+            var dims = new List<string?> { variable.IndexSetName, variable.SecondIndexSetName };
+            if (variable.AdditionalIndexSets != null)
+                dims.AddRange(variable.AdditionalIndexSets);
+
+            string sc = variable.SemiContinuousRanges == null
+                ? ""
+                : string.Join(",", variable.SemiContinuousRanges.Select(r => $"{FormatValue(r.Lo)}..{FormatValue(r.Hi)}"));
+
+            string elements = string.Join(";", variable.ElementBounds
+                .OrderBy(e => e.Key, StringComparer.Ordinal)
+                .Select(e => $"{e.Key}={FormatValue(e.Value.Lower)}..{FormatValue(e.Value.Upper)}"));
+
+            return $"{variable.Type}|{string.Join(",", dims.Where(d => !string.IsNullOrEmpty(d)))}|" +
+                   $"{FormatValue(variable.LowerBound)}..{FormatValue(variable.UpperBound)}|{sc}|{elements}|{variable.ExistsCondition}";
+        }
+
+        private static string CanonicalEquation(LinearEquation equation)
+        {
+            var terms = equation.Coefficients
+                .OrderBy(c => c.Key, StringComparer.Ordinal)
+                .Select(c => $"{Canonical(c.Value)}*{c.Key}");
+            return $"{string.Join("+", terms)} {equation.GetOperatorSymbol()} {Canonical(equation.Constant)}";
+        }
+
+        private static string CanonicalObjective(Objective objective)
+        {
+            var terms = objective.Coefficients
+                .OrderBy(c => c.Key, StringComparer.Ordinal)
+                .Select(c => $"{Canonical(c.Value)}*{c.Key}");
+            return $"{objective.Sense}|{objective.Name}|{string.Join("+", terms)}+{Canonical(objective.Constant)}";
+        }
+
+        private static string CanonicalForall(ForallStatement forall)
+        {
+            var iterators = forall.Iterators.Select(i =>
+                $"{i.VariableName} in {i.Range.SetName ?? $"{Canonical(i.Range.Start)}..{Canonical(i.Range.End)}"}" +
+                (i.Filter != null ? $": {Canonical(i.Filter)}" : ""));
+            string template = forall.ConstraintTemplate == null
+                ? ""
+                : $"{Canonical(forall.ConstraintTemplate.LeftSide)} {forall.ConstraintTemplate.Operator} {Canonical(forall.ConstraintTemplate.RightSide)}";
+            return $"{string.Join(",", iterators)}|{Canonical(forall.Condition)}|{template}|{forall.ExistsCondition}";
+        }
+
+        private static string CanonicalTuple(TupleInstance tuple)
+        {
+            return string.Join(",", tuple.Fields.OrderBy(f => f.Key, StringComparer.Ordinal).Select(f => $"{f.Key}={FormatValue(f.Value)}"));
+        }
+
+        /// <summary>
+        /// Culture-invariant rendering of an expression tree
+        /// </summary>
+        private static string Canonical(Expression? expression)
+        {
+            return expression switch
+            {
+                null => "",
+                ConstantExpression c => FormatValue(c.Value),
+                BinaryExpression b => $"({Canonical(b.Left)} {b.Operator} {Canonical(b.Right)})",
+                UnaryExpression u => $"{u.Operator}({Canonical(u.Operand)})",
+                ComparisonExpression cmp => $"({Canonical(cmp.Left)} {cmp.Operator} {Canonical(cmp.Right)})",
+                _ => expression.ToString()
+            };
+        }
+
+        private static string FormatValue(object? value)
+        {
+            return value switch
+            {
+                null => "null",
+                double d => d.ToString("R", CultureInfo.InvariantCulture),
+                float f => ((double)f).ToString("R", CultureInfo.InvariantCulture),
+                IFormattable formattable => formattable.ToString(null, CultureInfo.InvariantCulture),
+                _ => value.ToString() ?? ""
+            };
+        }
+    }
+}
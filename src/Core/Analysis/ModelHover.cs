@@ -0,0 +1,91 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Hover for a name in a model text, shaped like an LSP hover: markdown contents with the
+    /// declaration and its docstring, and the range of the hovered name
+    /// </summary>
+    public class HoverInfo
+    {
+        public const int MaxDeclarationLength = 160;
+
+        /// <summary>
+        /// Key of the declared entity ("parameter:capacity", "objective")
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        /// <summary>
+        /// Declaration code on one line, shortened to MaxDeclarationLength
+        /// </summary>
+        public string Declaration { get; init; } = "";
+
+        public string? Documentation { get; init; }
+
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// 1-based column of the first character of the hovered name
+        /// </summary>
+        public int StartColumn { get; init; }
+
+        /// <summary>
+        /// 1-based column just past the hovered name
+        /// </summary>
+        public int EndColumn { get; init; }
+
+        /// <summary>
+        /// Markdown shown by the editor
+        /// </summary>
+        public string Contents => $"```\n{Declaration}\n```" + (Documentation != null ? $"\n\n{Documentation}" : "");
+
+        public override string ToString() => Documentation != null ? $"{Key}: {Documentation}" : Key;
+    }
+
+    /// <summary>
+    /// Resolves the name under the cursor to its declaration
+    /// </summary>
+    public static class ModelHover
+    {
+        private static readonly Regex identifier = new Regex(@"[A-Za-z_]\w*");
+        private static readonly Regex whitespace = new Regex(@"\s+");
+
+        /// <summary>
+        /// Hover for the name at a 1-based line and column, or null if there is no declared entity there.
+        /// The objective's hover is shown on its minimize or maximize keyword.
+        /// </summary>
+        public static HoverInfo? At(string modelText, int lineNumber, int column)
+        {
+            var lines = modelText.Split('\n');
+            if (lineNumber < 1 || lineNumber > lines.Length)
+                return null;
+
+            var word = identifier.Matches(lines[lineNumber - 1])
+                .FirstOrDefault(m => m.Index < column && column <= m.Index + m.Length + 1);
+            if (word == null)
+                return null;
+
+            var source = ModelSource.Parse(modelText);
+            var statement = word.Value is "minimize" or "maximize"
+                ? source.Find(EntityCatalog.KeyOf(EntityKind.Objective, ""))
+                : source.Statements.FirstOrDefault(s => s.Key != null && s.Key.EndsWith(":" + word.Value, StringComparison.Ordinal));
+            if (statement?.Key == null)
+                return null;
+
+            string declaration = whitespace.Replace(statement.Code.TrimEnd(';', ' ', '\r', '\n'), " ");
+            if (declaration.Length > HoverInfo.MaxDeclarationLength)
+                declaration = declaration.Substring(0, HoverInfo.MaxDeclarationLength - 3) + "...";
+
+            return new HoverInfo
+            {
+                Key = statement.Key,
+                Declaration = declaration,
+                Documentation = Docstrings.Extract(modelText).GetValueOrDefault(statement.Key),
+                LineNumber = lineNumber,
+                StartColumn = word.Index + 1,
+                EndColumn = word.Index + word.Length + 1
+            };
+        }
+    }
+}
@@ -0,0 +1,672 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+using Core.Services;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// One nonlinear term replaced by an auxiliary variable
+    /// </summary>
+    public class LinearizedTerm
+    {
+        /// <summary>
+        /// Constraint or objective the term was in ("constraint:cap", "objective:")
+        /// </summary>
+        public string Owner { get; init; } = "";
+
+        public string Term { get; init; } = "";
+        public string Auxiliary { get; init; } = "";
+
+        /// <summary>
+        /// "McCormick", "epigraph", "hypograph" or "big-M"
+        /// </summary>
+        public string Formulation { get; init; } = "";
+
+        public override string ToString() => $"{Owner}: {Term} -> {Auxiliary} ({Formulation})";
+    }
+
+    /// <summary>
+    /// A model text with its nonlinear terms replaced, and what was replaced
+    /// </summary>
+    public class LinearizationResult
+    {
+        public string ModelText { get; init; } = "";
+
+        /// <summary>
+        /// The edits that turn the original text into ModelText
+        /// </summary>
+        public ChangeSet Changes { get; init; } = new ChangeSet();
+
+        public List<LinearizedTerm> Terms { get; } = new List<LinearizedTerm>();
+
+        /// <summary>
+        /// Nonlinear terms that were left as they are, with the reason
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Rewrites common nonlinear constructs of a model text into MIP-compatible formulations:
+    /// <list type="bullet">
+    /// <item>a binary times a bounded variable, or two binaries, becomes an auxiliary with the
+    /// exact McCormick inequalities;</item>
+    /// <item>abs, max and min become an auxiliary bounded by every argument (epigraph or
+    /// hypograph) where the objective or the constraint pushes the term in the direction that
+    /// makes this exact, e.g. minimizing abs(x - y), and otherwise an auxiliary selected by
+    /// binaries with a big-M, if one is given;</item>
+    /// <item>max and min over an index domain (max(i in I) x[i]) the same way, with one bound
+    /// and one selector per element of the domain, and count of non-binary variables
+    /// (count(i in I) x[i]) a sum of indicators where it is pushed down. Their big-M defaults to
+    /// the spread of the bounds of the variable.</item>
+    /// </list>
+    /// Auxiliaries are indexed like the constraint family they come from and carry a
+    /// "// @generated linearize from constraint:..." annotation, so they can be told apart from
+    /// hand-written declarations and regenerated.
+    /// </summary>
+    public class ModelLinearizer
+    {
+        public const string Generator = "linearize";
+
+        private const string Reference = @"[A-Za-z_]\w*(?:\[[^\]]*\])?";
+
+        private static readonly Regex productPattern = new Regex($@"(?<![\w\].])(?<a>{Reference})\s*\*\s*(?<b>{Reference})(?![\w\[(])");
+        private static readonly Regex functionPattern = new Regex(@"(?<![\w.])(abs|max|min)\s*\(");
+        private static readonly Regex identifierPattern = new Regex(@"(?<![\w.])[A-Za-z_]\w*(?!\w)(?!\s*\()");
+        private static readonly Regex coefficientPattern = new Regex(@"^\d+(?:\.\d+)?\s*\*\s*");
+        private static readonly Regex iteratorPattern = new Regex(@"^\s*[A-Za-z_]\w*\s+in\s");
+
+        /// <summary>
+        /// Bound on the terms of abs, max and min that are not in a convex position; without it they are left alone
+        /// </summary>
+        public double? BigM { get; set; }
+
+        /// <summary>
+        /// Only aggregations over index domains are rewritten, as when a model is parsed
+        /// </summary>
+        public bool AggregationsOnly { get; set; }
+
+        /// <summary>
+        /// Reformulates the max, min and count of decision variables over index domains of a
+        /// model text so that it parses as a MIP. The rewritten constraints keep their lines and
+        /// the generated declarations and constraints are appended, so the line numbers of the
+        /// text stay valid. Terms that cannot be reformulated are left for the parser to report.
+        /// </summary>
+        public static string ReformulateAggregations(string modelText)
+        {
+            var linearization = new ModelLinearizer { AggregationsOnly = true }.Linearize(modelText);
+            if (linearization.Terms.Count == 0)
+                return modelText;
+
+            var source = ModelSource.Parse(modelText);
+            var rewritten = linearization.Changes.Edits
+                .Where(e => e.Statement != null && source.Find(e.Key!) != null)
+                .ToDictionary(e => e.Key!, e => e.Statement!.TrimEnd(), StringComparer.Ordinal);
+
+            var text = new StringBuilder();
+            foreach (var statement in source.Statements)
+            {
+                if (statement.Key == null || !rewritten.TryGetValue(statement.Key, out string? code))
+                {
+                    text.Append(statement.Text);
+                    continue;
+                }
+                int lines = statement.Code.Count(c => c == '\n') - code.Count(c => c == '\n');
+                text.Append(statement.Text, 0, statement.Text.Length - statement.Code.Length)
+                    .Append(code)
+                    .Append('\n', Math.Max(0, lines));
+            }
+            text.Append(source.Trailer);
+
+            foreach (var edit in linearization.Changes.Edits.Where(e => e.Statement != null && !rewritten.ContainsKey(e.Key!)))
+                text.Append('\n').Append(edit.Annotation).Append('\n').Append(edit.Statement!.TrimEnd());
+            return text.ToString();
+        }
+
+        /// <summary>
+        /// Linearizes the constraints and objective with the given keys, or all of them
+        /// </summary>
+        public LinearizationResult Linearize(string modelText, IEnumerable<string>? keys = null) =>
+            Linearize(modelText, keys, CancellationToken.None);
+
+        /// <summary>
+        /// Linearizes with cancellation, reporting progress per statement and checking the token
+        /// before each term is reformulated
+        /// </summary>
+        public LinearizationResult Linearize(
+            string modelText,
+            IEnumerable<string>? keys,
+            CancellationToken cancellationToken,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("linearize", progress, cancellationToken);
+            tracker.Stage("parse");
+            var source = ModelSource.Parse(modelText);
+            var selected = keys?.ToHashSet(StringComparer.Ordinal);
+            var used = source.Statements.Where(s => s.Key != null).Select(s => s.Key!.Substring(s.Key.IndexOf(':') + 1)).ToHashSet(StringComparer.Ordinal);
+            var variables = source.Statements
+                .Select(s => EntityDefinition.FromStatement(s.Text))
+                .Where(d => d != null && d.Kind == EntityKind.Variable)
+                .ToDictionary(d => d!.Name, d => d!, StringComparer.Ordinal);
+
+            var changes = new ChangeSet { Title = "Linearize" };
+            var terms = new List<LinearizedTerm>();
+            var warnings = new List<string>();
+
+            var statements = source.Statements.ToList();
+            tracker.Stage("statements", statements.Count);
+            for (int i = 0; i < statements.Count; i++)
+            {
+                var statement = statements[i];
+                tracker.Tick(i, statements.Count);
+                if (statement.Key == null || (selected != null && !selected.Contains(statement.Key)))
+                    continue;
+                var definition = EntityDefinition.FromStatement(statement.Text);
+                if (definition == null || definition.Kind is not (EntityKind.Constraint or EntityKind.Objective) || definition.Body == null)
+                    continue;
+
+                var context = new Context
+                {
+                    Definition = definition,
+                    Owner = definition.Kind == EntityKind.Objective ? "objective" : definition.Name,
+                    Iterators = ConstraintRelaxer.ParseIterators(definition.Forall),
+                    Variables = variables,
+                    Used = used,
+                    Annotation = new EntityProvenance { Generator = Generator, Source = definition.Key }.ToAnnotation()
+                };
+
+                string body = definition.Body;
+                var skipped = new HashSet<string>(StringComparer.Ordinal);
+                while (FindNext(body, context, skipped) is { } next)
+                {
+                    cancellationToken.ThrowIfCancellationRequested();
+                    var (index, length, kind, arguments, aggregation) = next;
+                    string term = body.Substring(index, length);
+                    var domain = aggregation == null ? new List<(string Name, string Set)>() : ConstraintRelaxer.ParseIterators(aggregation.Iterators);
+                    string? reason = context.Iterators == null || domain == null
+                        ? "iterators must have the form 'i in Set' to index auxiliaries"
+                        : UnboundName(term, context, domain);
+                    string? replacement = null;
+                    if (reason == null)
+                    {
+                        int pressure = Pressure(body, index, length, definition);
+                        replacement = aggregation != null
+                            ? Aggregate(aggregation, domain!, pressure, context, out reason)
+                            : kind == "prod"
+                                ? Product(arguments[0], arguments[1], term, context, out reason)
+                                : Function(kind, arguments, term, pressure, context, out reason);
+                    }
+
+                    if (replacement == null)
+                    {
+                        if (reason != null)
+                            warnings.Add($"{context.Owner}: {term} left as is, {reason}");
+                        skipped.Add(term);
+                        continue;
+                    }
+
+                    body = body.Remove(index, length).Insert(index, replacement);
+                }
+
+                if (context.Terms.Count == 0)
+                    continue;
+
+                definition.Body = body;
+                changes.Edits.Add(ModelEdit.Upsert(definition.ToStatement()));
+                changes.Edits.AddRange(context.Edits);
+                terms.AddRange(context.Terms);
+            }
+
+            tracker.Tick(statements.Count, statements.Count);
+            changes.Apply(source);
+            var result = new LinearizationResult { ModelText = source.ToString(), Changes = changes };
+            result.Terms.AddRange(terms);
+            result.Warnings.AddRange(warnings);
+            return result;
+        }
+
+        private class Context
+        {
+            public EntityDefinition Definition { get; init; } = null!;
+            public string Owner { get; init; } = "";
+            public List<(string Name, string Set)>? Iterators { get; init; }
+            public Dictionary<string, EntityDefinition> Variables { get; init; } = null!;
+            public HashSet<string> Used { get; init; } = null!;
+            public string Annotation { get; init; } = "";
+            public List<ModelEdit> Edits { get; } = new List<ModelEdit>();
+            public List<LinearizedTerm> Terms { get; } = new List<LinearizedTerm>();
+            private readonly Dictionary<string, int> definitions = new Dictionary<string, int>(StringComparer.Ordinal);
+
+            public string Index => Iterators is { Count: > 0 } ? $"[{string.Join(",", Iterators.Select(i => i.Name))}]" : "";
+
+            /// <summary>
+            /// Declares an auxiliary indexed like the constraint family, and over the iterators of
+            /// an aggregation if it has one per element
+            /// </summary>
+            public void Declare(string name, string type, string? lower = null, string? upper = null, List<(string Name, string Set)>? domain = null)
+            {
+                Edits.Add(ModelEdit.Upsert(new EntityDefinition
+                {
+                    Kind = EntityKind.Variable,
+                    Type = type,
+                    Name = name,
+                    IndexSets = Iterators!.Concat(domain ?? new List<(string Name, string Set)>()).Select(i => i.Set).ToList(),
+                    LowerBound = lower,
+                    UpperBound = upper
+                }.ToStatement(), Annotation));
+            }
+
+            /// <summary>
+            /// Adds the defining constraints of an auxiliary, over the iterators of the constraint
+            /// family or over <paramref name="forall"/>
+            /// </summary>
+            public void Constrain(string auxiliary, IEnumerable<string> relations, string? forall = null)
+            {
+                int n = definitions.GetValueOrDefault(auxiliary);
+                foreach (string relation in relations)
+                {
+                    Edits.Add(ModelEdit.Upsert(new EntityDefinition
+                    {
+                        Kind = EntityKind.Constraint,
+                        Forall = forall ?? Definition.Forall,
+                        Name = ConstraintRelaxer.Unique($"{auxiliary}_def{++n}", Used),
+                        Body = relation
+                    }.ToStatement(), Annotation));
+                }
+                definitions[auxiliary] = n;
+            }
+
+            public void Record(string term, string auxiliary, string formulation)
+            {
+                Terms.Add(new LinearizedTerm { Owner = Definition.Key, Term = term, Auxiliary = auxiliary, Formulation = formulation });
+            }
+        }
+
+        /// <summary>
+        /// Next max/min/count of variables over an index domain, else the next product of two
+        /// variables, or else the next abs/max/min call with no such call in its arguments, that
+        /// has not been skipped
+        /// </summary>
+        private (int Index, int Length, string Kind, List<string> Arguments, AggregationCall? Aggregation)? FindNext(string body, Context context, HashSet<string> skipped)
+        {
+            // avg of variables and count of binaries are linear, and the rest is data the parser evaluates
+            foreach (var call in Aggregations.Find(body))
+            {
+                if (call.Function is "max" or "min" or "count" && !skipped.Contains(call.Text) &&
+                    identifierPattern.Matches(call.Body).Any(i => context.Variables.ContainsKey(i.Value)) &&
+                    !(call.Function == "count" && IsVariable(call.Body.Trim(), context) && IsBinary(context.Variables[BaseName(call.Body.Trim())])))
+                    return (call.Index, call.Length, call.Function, new List<string>(), call);
+            }
+
+            if (AggregationsOnly)
+                return null;
+
+            for (var m = productPattern.Match(body); m.Success; m = productPattern.Match(body, m.Index + 1))
+            {
+                string a = m.Groups["a"].Value, b = m.Groups["b"].Value;
+                if (IsVariable(a, context) && IsVariable(b, context) && !skipped.Contains(m.Value))
+                    return (m.Index, m.Length, "prod", new List<string> { a, b }, null);
+            }
+
+            foreach (Match m in functionPattern.Matches(body))
+            {
+                int open = m.Index + m.Length - 1;
+                int close = MatchingParen(body, open);
+                if (close < 0)
+                    continue;
+
+                string inner = body.Substring(open + 1, close - open - 1);
+                string term = body.Substring(m.Index, close - m.Index + 1);
+                if (functionPattern.IsMatch(inner) || skipped.Contains(term) || iteratorPattern.IsMatch(inner))
+                    continue;
+
+                // abs(), max() and min() of data are evaluated by the parser as they are
+                if (!identifierPattern.Matches(inner).Any(i => context.Variables.ContainsKey(i.Value)))
+                    continue;
+
+                return (m.Index, term.Length, m.Groups[1].Value, SplitArguments(inner), null);
+            }
+
+            return null;
+        }
+
+        private string? Product(string a, string b, string term, Context context, out string? reason)
+        {
+            reason = null;
+            var (first, second) = (context.Variables[BaseName(a)], context.Variables[BaseName(b)]);
+            string index = context.Index;
+
+            if (IsBinary(first) && IsBinary(second))
+            {
+                string w = ConstraintRelaxer.Unique($"{context.Owner}_prod", context.Used);
+                context.Declare(w, "float", "0", "1");
+                context.Constrain(w, new[] { $"{w}{index} <= {a}", $"{w}{index} <= {b}", $"{w}{index} >= {a} + {b} - 1" });
+                context.Record(term, w, "McCormick");
+                return w + index;
+            }
+
+            var (binary, bounded, boundedName) = IsBinary(first) ? (a, second, b) : IsBinary(second) ? (b, first, a) : (null, null, null);
+            if (binary == null)
+            {
+                reason = "neither factor is binary";
+                return null;
+            }
+
+            var (lower, upper) = Bounds(bounded!);
+            if (lower == null || upper == null)
+            {
+                reason = $"'{bounded!.Name}' needs finite bounds (dvar ... in lo..hi)";
+                return null;
+            }
+
+            // w = binary * x: 0 when the binary is 0, x when it is 1
+            string aux = ConstraintRelaxer.Unique($"{context.Owner}_prod", context.Used);
+            string wi = aux + index;
+            context.Declare(aux, "float");
+            context.Constrain(aux, new[]
+            {
+                $"{wi} <= {Scale(upper, binary)}",
+                $"{wi} >= {Scale(lower, binary)}",
+                lower == "0" ? $"{wi} <= {boundedName}" : $"{wi} <= {boundedName} - {Wrap(lower)} + {Scale(lower, binary)}",
+                $"{wi} >= {boundedName} - {Wrap(upper)} + {Scale(upper, binary)}"
+            });
+            context.Record(term, aux, "McCormick");
+            return wi;
+        }
+
+        private string? Function(string kind, List<string> arguments, string term, int pressure, Context context, out string? reason)
+        {
+            reason = null;
+            if (arguments.Count == 0 || (kind == "abs" && arguments.Count != 1) || (kind != "abs" && arguments.Count < 2))
+                return null;
+
+            string aux = ConstraintRelaxer.Unique($"{context.Owner}_{kind}", context.Used);
+            string t = aux + context.Index;
+            var pieces = kind == "abs"
+                ? new List<string> { Wrap(arguments[0]), $"-{Wrap(arguments[0])}" }
+                : arguments.Select(Wrap).ToList();
+
+            // Convex terms pushed down and concave terms pushed up are exact without binaries
+            bool convex = kind != "min";
+            if ((convex && pressure > 0) || (!convex && pressure < 0))
+            {
+                context.Declare(aux, kind == "abs" ? "float+" : "float");
+                context.Constrain(aux, pieces.Select(p => convex ? $"{t} >= {p}" : $"{t} <= {p}"));
+                context.Record(term, aux, convex ? "epigraph" : "hypograph");
+                return t;
+            }
+
+            if (BigM == null)
+            {
+                context.Used.Remove(aux);
+                reason = "it is not in a position where an epigraph is exact; give a big-M to linearize it with binaries";
+                return null;
+            }
+
+            // One binary per piece selects the piece the auxiliary equals
+            string m = BigM.Value.ToString("R", CultureInfo.InvariantCulture);
+            var selectors = pieces.Select((_, k) => ConstraintRelaxer.Unique($"{aux}_sel{k + 1}", context.Used)).ToList();
+            context.Declare(aux, kind == "abs" ? "float+" : "float");
+            foreach (string selector in selectors)
+                context.Declare(selector, "bool");
+
+            var relations = new List<string> { $"{string.Join(" + ", selectors.Select(s => s + context.Index))} == 1" };
+            for (int k = 0; k < pieces.Count; k++)
+            {
+                string z = selectors[k] + context.Index;
+                relations.Add(convex ? $"{t} >= {pieces[k]}" : $"{t} <= {pieces[k]}");
+                relations.Add(convex ? $"{t} <= {pieces[k]} + {m} - {m} * {z}" : $"{t} >= {pieces[k]} - {m} + {m} * {z}");
+            }
+            context.Constrain(aux, relations);
+            context.Record(term, aux, "big-M");
+            return t;
+        }
+
+        /// <summary>
+        /// max, min and count over an index domain, with one bound, selector or indicator per
+        /// element of the domain in each instance of the constraint
+        /// </summary>
+        private string? Aggregate(AggregationCall call, List<(string Name, string Set)> domain, int pressure, Context context, out string? reason)
+        {
+            reason = null;
+            var filters = new[] { Filter(context.Definition.Forall), call.Filter }.Where(f => f != null).ToList();
+            string forall = string.Join(", ", context.Iterators!.Concat(domain).Select(i => $"{i.Name} in {i.Set}")) +
+                            (filters.Count > 0 ? ": " + string.Join(" && ", filters) : "");
+            if (forall.Contains(')'))
+            {
+                reason = "the filter of its domain has parentheses, which a forall header cannot hold";
+                return null;
+            }
+
+            string kind = call.Function;
+            string body = Wrap(call.Body);
+            string element = $"[{string.Join(",", context.Iterators!.Concat(domain).Select(i => i.Name))}]";
+            string aux = ConstraintRelaxer.Unique($"{context.Owner}_{kind}", context.Used);
+
+            if (kind == "count")
+            {
+                // An indicator that must be 1 where the body is nonzero; pushed down, it is 0 elsewhere
+                double? bound = BigM ?? Spread(call.Body, context, magnitude: true);
+                if (pressure <= 0 || bound == null)
+                {
+                    context.Used.Remove(aux);
+                    reason = pressure <= 0
+                        ? "count of non-binary variables is only reformulated where it is bounded from above"
+                        : "its variable needs finite bounds (dvar ... in lo..hi), or give a big-M";
+                    return null;
+                }
+
+                string big = bound.Value.ToString("R", CultureInfo.InvariantCulture);
+                string z = aux + element;
+                context.Declare(aux, "bool", domain: domain);
+                context.Constrain(aux, new[] { $"{body} <= {big} * {z}", $"{body} >= -{big} * {z}" }, forall);
+                context.Record(call.Text, aux, "indicator");
+                return $"sum({call.Header}) {z}";
+            }
+
+            string t = aux + context.Index;
+            bool convex = kind == "max";
+            if ((convex && pressure > 0) || (!convex && pressure < 0))
+            {
+                context.Declare(aux, "float");
+                context.Constrain(aux, new[] { convex ? $"{t} >= {body}" : $"{t} <= {body}" }, forall);
+                context.Record(call.Text, aux, convex ? "epigraph" : "hypograph");
+                return t;
+            }
+
+            double? m = BigM ?? Spread(call.Body, context, magnitude: false);
+            if (m == null)
+            {
+                context.Used.Remove(aux);
+                reason = "it is not in a position where an epigraph is exact; give its variable finite bounds or a big-M to linearize it with binaries";
+                return null;
+            }
+
+            // One selector per element picks the element the auxiliary equals
+            string mm = m.Value.ToString("R", CultureInfo.InvariantCulture);
+            string selector = ConstraintRelaxer.Unique($"{aux}_sel", context.Used);
+            string s = selector + element;
+            context.Declare(aux, "float");
+            context.Declare(selector, "bool", domain: domain);
+            context.Constrain(aux, new[] { $"sum({call.Header}) {s} == 1" });
+            context.Constrain(aux, new[]
+            {
+                convex ? $"{t} >= {body}" : $"{t} <= {body}",
+                convex ? $"{t} <= {body} + {mm} - {mm} * {s}" : $"{t} >= {body} - {mm} + {mm} * {s}"
+            }, forall);
+            context.Record(call.Text, aux, "big-M");
+            return t;
+        }
+
+        /// <summary>
+        /// Filter of a forall header, or null
+        /// </summary>
+        private static string? Filter(string? forall)
+        {
+            int colon = forall?.IndexOf(':') ?? -1;
+            return colon < 0 ? null : forall!.Substring(colon + 1).Trim();
+        }
+
+        /// <summary>
+        /// For a body that is a single variable reference with numeric bounds, the spread of the
+        /// bounds, or with <paramref name="magnitude"/> the largest absolute value it can take
+        /// </summary>
+        private static double? Spread(string body, Context context, bool magnitude)
+        {
+            string reference = body.Trim();
+            if (!Regex.IsMatch(reference, $"^{Reference}$") || !IsVariable(reference, context))
+                return null;
+
+            var (lower, upper) = Bounds(context.Variables[BaseName(reference)]);
+            if (!double.TryParse(lower, NumberStyles.Float, CultureInfo.InvariantCulture, out double lo) ||
+                !double.TryParse(upper, NumberStyles.Float, CultureInfo.InvariantCulture, out double hi))
+                return null;
+            return magnitude ? Math.Max(Math.Abs(lo), Math.Abs(hi)) : hi - lo;
+        }
+
+        /// <summary>
+        /// +1 if the objective or the relation pushes the term down (minimized, or on the smaller
+        /// side of an inequality with a positive sign), -1 if it pushes it up, 0 if neither or unknown
+        /// </summary>
+        private static int Pressure(string body, int index, int length, EntityDefinition definition)
+        {
+            string side;
+            int offset, direction;
+            if (definition.Kind == EntityKind.Objective)
+            {
+                (side, offset, direction) = (body, index, definition.Sense == ObjectiveSense.Minimize ? 1 : -1);
+            }
+            else
+            {
+                var split = ConstraintRelaxer.SplitRelation(body);
+                if (split == null || split.Value.Op == "==")
+                    return 0;
+
+                var (lhs, op, rhs) = split.Value;
+                bool left = index < lhs.Length;
+                (side, offset) = left ? (lhs, index) : (rhs, index - (body.Length - rhs.Length));
+                direction = (op == "<=") == left ? 1 : -1;
+            }
+
+            // The term must be a top-level summand, optionally with a positive numeric coefficient
+            int depth = 0, start = 0, sign = 1;
+            for (int i = 0; i <= side.Length; i++)
+            {
+                char c = i < side.Length ? side[i] : '+';
+                if (c is '(' or '[')
+                    depth++;
+                else if (c is ')' or ']')
+                    depth--;
+                else if (depth == 0 && c is '+' or '-' && (i == side.Length || !IsExponent(side, i)))
+                {
+                    if (offset >= start && offset < i)
+                    {
+                        string summand = coefficientPattern.Replace(side.Substring(start, i - start).Trim(), "");
+                        return summand.Length == length ? sign * direction : 0;
+                    }
+                    sign = c == '-' ? -1 : 1;
+                    start = i + 1;
+                }
+            }
+            return 0;
+        }
+
+        private static bool IsExponent(string text, int i)
+        {
+            return i >= 2 && (text[i - 1] is 'e' or 'E') && char.IsDigit(text[i - 2]);
+        }
+
+        /// <summary>
+        /// A name in the term that is neither declared nor an iterator of the constraint, i.e.
+        /// bound by an enclosing sum, so the auxiliary could not be indexed by it
+        /// </summary>
+        private static string? UnboundName(string term, Context context, List<(string Name, string Set)> domain)
+        {
+            foreach (Match m in identifierPattern.Matches(term))
+            {
+                string name = m.Value;
+                if (context.Used.Contains(name) || context.Iterators!.Concat(domain).Any(i => i.Name == name) || name is "in" or "abs" or "max" or "min" or "count")
+                    continue;
+                return $"it uses '{name}', which is not an iterator of the constraint (a sum iterator?)";
+            }
+            return null;
+        }
+
+        private static bool IsVariable(string reference, Context context) => context.Variables.ContainsKey(BaseName(reference));
+
+        private static string BaseName(string reference)
+        {
+            int bracket = reference.IndexOf('[');
+            return bracket < 0 ? reference : reference.Substring(0, bracket);
+        }
+
+        private static bool IsBinary(EntityDefinition variable)
+        {
+            return variable.Type == "bool" ||
+                   (variable.Type.StartsWith("int", StringComparison.Ordinal) && variable.LowerBound == "0" && variable.UpperBound == "1");
+        }
+
+        private static (string? Lower, string? Upper) Bounds(EntityDefinition variable)
+        {
+            string? lower = variable.LowerBound ?? (variable.Type.EndsWith("+", StringComparison.Ordinal) ? "0" : null);
+            string? upper = variable.UpperBound;
+            if (lower is "-infinity" or "-maxint")
+                lower = null;
+            if (upper is "infinity" or "maxint")
+                upper = null;
+            return (lower, upper);
+        }
+
+        private static string Scale(string factor, string term) => factor switch
+        {
+            "0" => "0",
+            "1" => term,
+            _ => $"{Wrap(factor)} * {term}"
+        };
+
+        /// <summary>
+        /// Parenthesizes an expression unless it is a single name, reference or number
+        /// </summary>
+        private static string Wrap(string expression)
+        {
+            string trimmed = expression.Trim();
+            return Regex.IsMatch(trimmed, $@"^(?:{Reference}|\d+(?:\.\d+)?)$") ? trimmed : $"({trimmed})";
+        }
+
+        private static int MatchingParen(string text, int open)
+        {
+            int depth = 0;
+            for (int i = open; i < text.Length; i++)
+            {
+                if (text[i] == '(')
+                    depth++;
+                else if (text[i] == ')' && --depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        private static List<string> SplitArguments(string inner)
+        {
+            var arguments = new List<string>();
+            int depth = 0, start = 0;
+            for (int i = 0; i < inner.Length; i++)
+            {
+                if (inner[i] is '(' or '[')
+                    depth++;
+                else if (inner[i] is ')' or ']')
+                    depth--;
+                else if (inner[i] == ',' && depth == 0)
+                {
+                    arguments.Add(inner.Substring(start, i - start).Trim());
+                    start = i + 1;
+                }
+            }
+            arguments.Add(inner.Substring(start).Trim());
+            return arguments;
+        }
+    }
+}
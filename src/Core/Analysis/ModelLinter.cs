@@ -0,0 +1,396 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Analysis
+{
+    public enum LintSeverity
+    {
+        Off,
+        Info,
+        Warning,
+        Error
+    }
+
+    /// <summary>
+    /// A check the linter can run, with the severity it has unless a profile overrides it
+    /// </summary>
+    public class LintRule
+    {
+        public string Id { get; init; } = "";
+        public string Description { get; init; } = "";
+        public LintSeverity DefaultSeverity { get; init; }
+
+        public override string ToString() => $"{Id} ({DefaultSeverity.ToString().ToLowerInvariant()}): {Description}";
+    }
+
+    public class LintFinding
+    {
+        public string RuleId { get; init; } = "";
+        public LintSeverity Severity { get; init; }
+
+        /// <summary>
+        /// The entity the finding is about (variable, parameter, constraint name), or "" for the model
+        /// </summary>
+        public string Subject { get; init; } = "";
+        public string Message { get; init; } = "";
+
+        /// <summary>
+        /// Machine-applicable fixes, preferred first (see CodeFixes)
+        /// </summary>
+        public List<ChangeSet> Fixes { get; init; } = new List<ChangeSet>();
+
+        public override string ToString()
+        {
+            string subject = Subject.Length > 0 ? $"{Subject}: " : "";
+            return $"{Severity.ToString().ToLowerInvariant()} [{RuleId}] {subject}{Message}";
+        }
+    }
+
+    public class LintReport
+    {
+        public string Profile { get; init; } = "";
+        public List<LintFinding> Findings { get; } = new List<LintFinding>();
+
+        public int ErrorCount => Findings.Count(f => f.Severity == LintSeverity.Error);
+        public int WarningCount => Findings.Count(f => f.Severity == LintSeverity.Warning);
+        public int InfoCount => Findings.Count(f => f.Severity == LintSeverity.Info);
+
+        /// <summary>
+        /// True if any finding is an error; CI gating fails the build on this
+        /// </summary>
+        public bool HasErrors => ErrorCount > 0;
+
+        public override string ToString() =>
+            $"{ErrorCount} errors, {WarningCount} warnings, {InfoCount} info (profile {Profile})";
+    }
+
+    /// <summary>
+    /// Runs the validation rules over a parsed model and reports findings at the severities of a
+    /// lint profile. Rules are identified by stable ids (e.g. "unused-variable") so profiles in
+    /// .modellint.json files can turn them off or change their severity.
+    /// </summary>
+    public class ModelLinter
+    {
+        public static readonly IReadOnlyList<LintRule> Rules = new[]
+        {
+            new LintRule { Id = "parse-error", DefaultSeverity = LintSeverity.Error, Description = "Statements that failed to parse" },
+            new LintRule { Id = "index-arity", DefaultSeverity = LintSeverity.Error, Description = "Indexed references with the wrong number or type of indices" },
+            new LintRule { Id = "missing-objective", DefaultSeverity = LintSeverity.Warning, Description = "Model has no objective" },
+            new LintRule { Id = "unused-variable", DefaultSeverity = LintSeverity.Warning, Description = "Variables not used in any constraint or the objective" },
+            new LintRule { Id = "empty-constraint", DefaultSeverity = LintSeverity.Warning, Description = "Constraints without variables" },
+            new LintRule { Id = "big-m", DefaultSeverity = LintSeverity.Warning, Description = "Big-M coefficients large enough to cause numerical trouble" },
+            new LintRule { Id = "nonconvex", DefaultSeverity = LintSeverity.Warning, Description = "Nonconvex objective or constraints, where local solvers may stop at a local optimum" },
+            new LintRule { Id = "problem-class", DefaultSeverity = LintSeverity.Info, Description = "Problem class and convexity of models that are not linear" },
+            new LintRule { Id = "unused-parameter", DefaultSeverity = LintSeverity.Info, Description = "Parameters nothing refers to" },
+            new LintRule { Id = "unused-set", DefaultSeverity = LintSeverity.Info, Description = "Sets and ranges nothing refers to" },
+            new LintRule { Id = "free-variable", DefaultSeverity = LintSeverity.Info, Description = "Continuous variables without bounds" },
+            new LintRule { Id = "loose-bound", DefaultSeverity = LintSeverity.Info, Description = "Variable bounds looser than single-variable constraints imply" },
+            new LintRule { Id = "missing-description", DefaultSeverity = LintSeverity.Off, Description = "Variables and parameters without a doc comment" },
+            new LintRule { Id = "unlabeled-constraint", DefaultSeverity = LintSeverity.Off, Description = "Constraints without a label" }
+        };
+
+        private static readonly Regex commentPattern = new Regex(@"//[^\n]*|/\*.*?\*/", RegexOptions.Singleline);
+        private static readonly Regex identifierPattern = new Regex(@"[A-Za-z_][A-Za-z0-9_]*");
+
+        private readonly ModelManager modelManager;
+        private ModelClassification? classification;
+        private IReadOnlyList<LinearEquation>? rows;
+
+        public ModelLinter(ModelManager manager)
+        {
+            modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
+        }
+
+        /// <summary>
+        /// Model source texts. Expansion replaces parameters by their values, so "unused-parameter"
+        /// and "unused-set" look for uses in the source, and "index-arity" checks the references
+        /// as written; they are skipped when it is not given.
+        /// </summary>
+        public IReadOnlyList<string> ModelTexts { get; set; } = Array.Empty<string>();
+
+        public static LintRule? GetRule(string id) => Rules.FirstOrDefault(r => r.Id == id);
+
+        /// <summary>
+        /// Lints with the built-in default profile
+        /// </summary>
+        public LintReport Lint(IEnumerable<string>? parseErrors = null) => Lint(LintProfile.Default, parseErrors);
+
+        /// <summary>
+        /// Runs every rule the profile does not turn off. Parse errors (e.g. ModelParsingService
+        /// errors) are reported under "parse-error" so a profile decides whether they fail the lint.
+        /// </summary>
+        public LintReport Lint(LintProfile profile, IEnumerable<string>? parseErrors = null)
+        {
+            var report = new LintReport { Profile = profile.Name };
+            var catalog = EntityCatalog.Build(modelManager);
+
+            foreach (var rule in Rules)
+            {
+                var severity = profile.SeverityOf(rule.Id);
+                if (severity == LintSeverity.Off)
+                    continue;
+
+                var findings = rule.Id == "parse-error"
+                    ? (parseErrors ?? Enumerable.Empty<string>()).Select(e => ("", e))
+                    : Check(rule.Id, catalog);
+
+                foreach (var (subject, message) in findings)
+                {
+                    report.Findings.Add(new LintFinding
+                    {
+                        RuleId = rule.Id,
+                        Severity = severity,
+                        Subject = subject,
+                        Message = message,
+                        Fixes = FixesFor(rule.Id, subject)
+                    });
+                }
+            }
+
+            report.Findings.Sort((a, b) => b.Severity != a.Severity
+                ? b.Severity.CompareTo(a.Severity)
+                : RuleOrder(a.RuleId).CompareTo(RuleOrder(b.RuleId)));
+            return report;
+        }
+
+        private IEnumerable<(string Subject, string Message)> Check(string ruleId, EntityCatalog catalog)
+        {
+            switch (ruleId)
+            {
+                case "missing-objective":
+                    if (modelManager.Objective == null && modelManager.MultiObjective == null)
+                        yield return ("", "no objective; solvers will only look for a feasible point");
+                    break;
+
+                case "index-arity":
+                    foreach (var text in ModelTexts)
+                    {
+                        foreach (var diagnostic in IndexChecker.Check(modelManager, text))
+                            yield return ("", diagnostic.ToString());
+                    }
+                    break;
+
+                case "unused-variable":
+                    foreach (var entry in Unreferenced(catalog, EntityKind.Variable))
+                        yield return (entry.Name, "declared but not used in any constraint or the objective");
+                    break;
+
+                case "unused-parameter":
+                    foreach (var name in UnusedInSource(catalog, EntityKind.Parameter))
+                        yield return (name, "declared but never used");
+                    break;
+
+                case "unused-set":
+                    foreach (var name in UnusedInSource(catalog, EntityKind.Set))
+                        yield return (name, "declared but never used");
+                    break;
+
+                case "empty-constraint":
+                    foreach (var (equation, row) in Rows.Select((e, i) => (e, i)).Where(r => r.e.VariableCount == 0))
+                        yield return (ConstraintName(equation, row), "has no variables; it is either always or never satisfied");
+                    break;
+
+                case "big-m":
+                    foreach (var finding in new BigMAnalyzer(modelManager).Analyze().Where(f => f.IsNumericallyRisky))
+                        yield return (finding.Constraint, $"M = {finding.M.ToString("G6", CultureInfo.InvariantCulture)} on {finding.Variable} is large enough to cause numerical trouble");
+                    break;
+
+                case "nonconvex":
+                    foreach (var entity in Classification().Entities.Where(e => e.Convexity == Convexity.Nonconvex))
+                        yield return (SubjectOf(entity), $"nonconvex ({entity.Reason}); local solvers may return a local optimum");
+                    break;
+
+                case "problem-class":
+                    if (!Classification().IsLinear)
+                        yield return ("", Classification().ToString());
+                    break;
+
+                case "free-variable":
+                    foreach (var variable in Variables().Where(v => v.Type == VariableType.Float && !v.HasBounds))
+                        yield return (variable.BaseName, "continuous variable without bounds");
+                    break;
+
+                case "loose-bound":
+                    foreach (var variable in Variables().Where(v => v.IsScalar && v.Type != VariableType.Boolean))
+                    {
+                        var (lower, upper) = ImpliedBounds(variable);
+                        if (modelManager.Tolerance.IsGreater(lower, variable.LowerBound ?? double.NegativeInfinity)
+                            || modelManager.Tolerance.IsLess(upper, variable.UpperBound ?? double.PositiveInfinity))
+                            yield return (variable.BaseName, $"constraints imply bounds {FormatBound(lower)}..{FormatBound(upper)}, tighter than declared");
+                    }
+                    break;
+
+                case "missing-description":
+                    foreach (var variable in Variables().Where(v => string.IsNullOrWhiteSpace(v.Description)))
+                        yield return (variable.BaseName, "variable has no description");
+                    foreach (var parameter in modelManager.Parameters.Values.OrderBy(p => p.Name, StringComparer.Ordinal).Where(p => string.IsNullOrWhiteSpace(p.Description)))
+                        yield return (parameter.Name, "parameter has no description");
+                    break;
+
+                case "unlabeled-constraint":
+                    foreach (var (equation, row) in Rows.Select((e, i) => (e, i)).Where(r => string.IsNullOrEmpty(r.e.Label) && string.IsNullOrEmpty(r.e.BaseName)))
+                        yield return (ConstraintName(equation, row), "constraint has no label");
+                    break;
+            }
+        }
+
+        private List<ChangeSet> FixesFor(string ruleId, string subject)
+        {
+            var fixes = new List<ChangeSet>();
+            switch (ruleId)
+            {
+                case "unused-variable":
+                    fixes.Add(new ChangeSet($"Remove unused variable '{subject}'", ModelEdit.Remove(EntityCatalog.KeyOf(EntityKind.Variable, subject))));
+                    break;
+
+                case "unused-parameter":
+                    fixes.Add(new ChangeSet($"Remove unused parameter '{subject}'", ModelEdit.Remove(EntityCatalog.KeyOf(EntityKind.Parameter, subject))));
+                    break;
+
+                case "unused-set":
+                    fixes.Add(new ChangeSet($"Remove unused set '{subject}'", ModelEdit.Remove(EntityCatalog.KeyOf(EntityKind.Set, subject))));
+                    break;
+
+                case "loose-bound":
+                    var tighten = TightenBounds(modelManager.IndexedVariables[subject]);
+                    if (tighten != null)
+                        fixes.Add(tighten);
+                    break;
+            }
+            return fixes;
+        }
+
+        /// <summary>
+        /// Bounds implied by constraints on the variable alone (a*x op b); integer bounds are rounded inwards
+        /// </summary>
+        private (double Lower, double Upper) ImpliedBounds(IndexedVariable variable)
+        {
+            double lower = variable.LowerBound ?? double.NegativeInfinity;
+            double upper = variable.UpperBound ?? double.PositiveInfinity;
+
+            foreach (var equation in Rows.Where(e => e.VariableCount == 1 && e.ContainsVariable(variable.BaseName)))
+            {
+                double a, b;
+                try
+                {
+                    var (coefficients, constant) = equation.Evaluate(modelManager);
+                    a = coefficients[variable.BaseName];
+                    b = constant / a;
+                }
+                catch (Exception)
+                {
+                    continue;
+                }
+                if (a == 0)
+                    continue;
+
+                bool isUpper = equation.Operator is RelationalOperator.LessThan or RelationalOperator.LessThanOrEqual;
+                if (a < 0)
+                    isUpper = !isUpper;
+
+                if (equation.Operator == RelationalOperator.Equal || isUpper)
+                    upper = Math.Min(upper, b);
+                if (equation.Operator == RelationalOperator.Equal || !isUpper)
+                    lower = Math.Max(lower, b);
+            }
+
+            if (variable.Type == VariableType.Integer)
+                return (modelManager.Tolerance.Ceiling(lower), modelManager.Tolerance.Floor(upper));
+            return (lower, upper);
+        }
+
+        /// <summary>
+        /// Rewrites the declaration with the implied bounds; null unless both are finite and
+        /// the declaration is in the model source
+        /// </summary>
+        private ChangeSet? TightenBounds(IndexedVariable variable)
+        {
+            var (lower, upper) = ImpliedBounds(variable);
+            if (double.IsInfinity(lower) || double.IsInfinity(upper) || modelManager.Tolerance.IsGreater(lower, upper))
+                return null;
+
+            string key = EntityCatalog.KeyOf(EntityKind.Variable, variable.BaseName);
+            var statement = ModelTexts.Select(t => ModelSource.Parse(t).Find(key)).FirstOrDefault(s => s != null);
+            var definition = statement != null ? EntityDefinition.FromStatement(statement.Text) : null;
+            if (definition == null || definition.IndexSets.Count > 0)
+                return null;
+
+            definition.LowerBound = lower.ToString("R", CultureInfo.InvariantCulture);
+            definition.UpperBound = upper.ToString("R", CultureInfo.InvariantCulture);
+            return new ChangeSet($"Tighten bounds of '{variable.BaseName}' to {definition.LowerBound}..{definition.UpperBound}",
+                ModelEdit.Upsert(definition.ToStatement()));
+        }
+
+        private static string FormatBound(double value) => double.IsInfinity(value)
+            ? (value > 0 ? "infinity" : "-infinity")
+            : value.ToString("G6", CultureInfo.InvariantCulture);
+
+        private static int RuleOrder(string id)
+        {
+            for (int i = 0; i < Rules.Count; i++)
+            {
+                if (Rules[i].Id == id)
+                    return i;
+            }
+            return Rules.Count;
+        }
+
+        private static IEnumerable<CatalogEntry> Unreferenced(EntityCatalog catalog, EntityKind kind)
+        {
+            return catalog.Entries
+                .Where(e => e.Kind == kind && e.ReferencedBy.Count == 0)
+                .OrderBy(e => e.Name, StringComparer.Ordinal);
+        }
+
+        /// <summary>
+        /// Entities whose name occurs only once (the declaration) in the comment-free source
+        /// </summary>
+        private IEnumerable<string> UnusedInSource(EntityCatalog catalog, EntityKind kind)
+        {
+            if (ModelTexts.Count == 0)
+                yield break;
+
+            var counts = new Dictionary<string, int>(StringComparer.Ordinal);
+            foreach (var text in ModelTexts)
+            {
+                foreach (Match match in identifierPattern.Matches(commentPattern.Replace(text, " ")))
+                    counts[match.Value] = counts.GetValueOrDefault(match.Value) + 1;
+            }
+
+            foreach (var name in catalog.Entries.Where(e => e.Kind == kind).Select(e => e.Name).Distinct().OrderBy(n => n, StringComparer.Ordinal))
+            {
+                if (counts.GetValueOrDefault(name) <= 1)
+                    yield return name;
+            }
+        }
+
+        private ModelClassification Classification()
+        {
+            return classification ??= ProblemClassifier.Classify(modelManager, modelTexts: ModelTexts.Count > 0 ? ModelTexts : null);
+        }
+
+        private static string SubjectOf(EntityClassification entity)
+        {
+            int colon = entity.Key.IndexOf(':');
+            return colon < 0 ? entity.Key : colon == entity.Key.Length - 1 ? entity.Key.Substring(0, colon) : entity.Key.Substring(colon + 1);
+        }
+
+        private IEnumerable<IndexedVariable> Variables()
+        {
+            return modelManager.IndexedVariables.Values.OrderBy(v => v.BaseName, StringComparer.Ordinal);
+        }
+
+        private static string ConstraintName(LinearEquation equation, int row)
+        {
+            return equation.Label ?? $"c{row + 1}";
+        }
+
+        /// <summary>
+        /// Rows of the model including those of pending rules, materialized once per linter
+        /// </summary>
+        private IReadOnlyList<LinearEquation> Rows => rows ??= modelManager.MaterializeRows();
+    }
+}
@@ -0,0 +1,153 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Where a generated declaration came from
+    /// </summary>
+    public class EntityProvenance
+    {
+        public string Key { get; init; } = "";
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// Template, macro, transform or import that wrote the declaration, e.g. "import:plant.lp";
+        /// regeneration replaces exactly the declarations of one generator
+        /// </summary>
+        public string Generator { get; init; } = "";
+
+        /// <summary>
+        /// Location the declaration was generated from (file, or file:line), if known
+        /// </summary>
+        public string? Source { get; init; }
+
+        public DateTime? GeneratedAt { get; init; }
+
+        /// <summary>
+        /// The annotation comment recording this provenance
+        /// </summary>
+        public string ToAnnotation()
+        {
+            string annotation = $"// @generated {Generator}";
+            if (Source != null)
+                annotation += $" from {Source}";
+            if (GeneratedAt != null)
+                annotation += $" at {GeneratedAt.Value.ToUniversalTime().ToString("yyyy-MM-ddTHH:mm:ssZ", CultureInfo.InvariantCulture)}";
+            return annotation;
+        }
+
+        public override string ToString() => $"{Key} <- {ToAnnotation().Substring("// @generated ".Length)}";
+    }
+
+    /// <summary>
+    /// Provenance annotations of a model text. Generated declarations carry a line comment naming
+    /// their generator, the location they were generated from and when:
+    /// <code>
+    /// // @generated import:plant.lp from plant.lp at 2026-10-16T09:30:00Z
+    /// dvar float+ x1;
+    /// </code>
+    /// Declarations without the annotation are hand-written and never touched by regeneration.
+    /// </summary>
+    public class ModelProvenance
+    {
+        private static readonly Regex annotationPattern = new Regex(
+            @"^[ \t]*//[ \t]*@generated[ \t]+(\S+)(?:[ \t]+from[ \t]+(.+?))?(?:[ \t]+at[ \t]+(\S+))?[ \t]*$", RegexOptions.Multiline);
+
+        private readonly List<EntityProvenance> entities = new List<EntityProvenance>();
+
+        private ModelProvenance()
+        {
+        }
+
+        /// <summary>
+        /// Generated declarations in source order
+        /// </summary>
+        public IReadOnlyList<EntityProvenance> Entities => entities;
+
+        public static ModelProvenance Parse(string modelText) => Parse(ModelSource.Parse(modelText));
+
+        public static ModelProvenance Parse(ModelSource source)
+        {
+            var provenance = new ModelProvenance();
+
+            foreach (var statement in source.Statements.Where(s => s.Key != null))
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                var m = annotationPattern.Matches(trivia).LastOrDefault();
+                if (m == null)
+                    continue;
+
+                provenance.entities.Add(new EntityProvenance
+                {
+                    Key = statement.Key!,
+                    LineNumber = statement.LineNumber,
+                    Generator = m.Groups[1].Value,
+                    Source = m.Groups[2].Success ? m.Groups[2].Value : null,
+                    GeneratedAt = m.Groups[3].Success && DateTime.TryParse(m.Groups[3].Value, CultureInfo.InvariantCulture,
+                        DateTimeStyles.AdjustToUniversal | DateTimeStyles.AssumeUniversal, out var at) ? at : null
+                });
+            }
+
+            return provenance;
+        }
+
+        public EntityProvenance? Find(string key)
+        {
+            return entities.FirstOrDefault(e => e.Key == key);
+        }
+
+        /// <summary>
+        /// Declarations written by the generator
+        /// </summary>
+        public IReadOnlyList<EntityProvenance> Select(string generator)
+        {
+            return entities.Where(e => e.Generator == generator).ToList();
+        }
+
+        public IReadOnlyList<string> Generators =>
+            entities.Select(e => e.Generator).Distinct().OrderBy(g => g, StringComparer.Ordinal).ToList();
+
+        /// <summary>
+        /// Change set that replaces everything the generator wrote before by its new output:
+        /// new and changed declarations are written with a fresh annotation, and previously
+        /// generated declarations it no longer produces are removed. Every statement must declare
+        /// a named entity. Throws if the output would overwrite a hand-written declaration or
+        /// one of another generator.
+        /// </summary>
+        public static ChangeSet Regenerate(ModelSource source, string generator, IEnumerable<string> statements,
+            string? sourceLocation = null, DateTime? generatedAt = null)
+        {
+            if (string.IsNullOrWhiteSpace(generator) || generator.Any(char.IsWhiteSpace))
+                throw new InvalidOperationException($"Invalid generator name '{generator}'");
+
+            var existing = Parse(source);
+            var previous = existing.Select(generator).Select(e => e.Key).ToHashSet(StringComparer.Ordinal);
+            var annotation = new EntityProvenance { Generator = generator, Source = sourceLocation, GeneratedAt = generatedAt }.ToAnnotation();
+
+            var changes = new ChangeSet { Title = $"Regenerate {generator}" };
+            var written = new HashSet<string>(StringComparer.Ordinal);
+
+            foreach (var statement in statements)
+            {
+                var edit = ModelEdit.Upsert(statement, annotation);
+                if (!written.Add(edit.Key!))
+                    throw new InvalidOperationException($"Generator '{generator}' declares '{edit.Key}' more than once");
+
+                if (!previous.Contains(edit.Key!) && source.Find(edit.Key!) != null)
+                {
+                    string owner = existing.Find(edit.Key!)?.Generator is string other ? $"generated by '{other}'" : "hand-written";
+                    throw new InvalidOperationException($"Generator '{generator}' would overwrite '{edit.Key}', which is {owner}");
+                }
+
+                changes.Edits.Add(edit);
+            }
+
+            foreach (var key in previous.Where(k => !written.Contains(k)).OrderBy(k => k, StringComparer.Ordinal))
+                changes.Edits.Add(ModelEdit.Remove(key));
+
+            return changes;
+        }
+    }
+}
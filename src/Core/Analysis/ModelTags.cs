@@ -0,0 +1,328 @@
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+using Core.Solving;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Helpers for hierarchical tags such as "hydro/ramping/up"
+    /// </summary>
+    public static class TagPath
+    {
+        /// <summary>
+        /// Trims the tag and its segments and drops empty segments ("/hydro//up " becomes "hydro/up")
+        /// </summary>
+        public static string Normalize(string tag)
+        {
+            return string.Join("/", tag.Split('/', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries));
+        }
+
+        /// <summary>
+        /// True if <paramref name="query"/> is the tag itself or one of its ancestors;
+        /// "hydro" and "hydro/ramping" both match "hydro/ramping/up"
+        /// </summary>
+        public static bool Matches(string tag, string query)
+        {
+            query = Normalize(query);
+            return tag.Equals(query, StringComparison.OrdinalIgnoreCase) ||
+                   tag.StartsWith(query + "/", StringComparison.OrdinalIgnoreCase);
+        }
+
+        /// <summary>
+        /// The tag and its ancestors, outermost first
+        /// </summary>
+        public static IEnumerable<string> Ancestors(string tag)
+        {
+            var segments = tag.Split('/');
+            for (int i = 1; i <= segments.Length; i++)
+                yield return string.Join("/", segments.Take(i));
+        }
+    }
+
+    /// <summary>
+    /// Tags of one declaration: its own plus those inherited from enclosing blocks
+    /// </summary>
+    public class TaggedEntity
+    {
+        public string Key { get; init; } = "";
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// Path of the innermost enclosing block ("hydro/units"), or null at top level
+        /// </summary>
+        public string? Block { get; init; }
+
+        public SortedSet<string> OwnTags { get; } = new SortedSet<string>(StringComparer.OrdinalIgnoreCase);
+
+        /// <summary>
+        /// Own tags, tags of every enclosing block and the block path itself
+        /// </summary>
+        public SortedSet<string> Tags { get; } = new SortedSet<string>(StringComparer.OrdinalIgnoreCase);
+
+        public bool HasTag(string query) => Tags.Any(t => TagPath.Matches(t, query));
+
+        /// <summary>
+        /// Switched off with the "disabled" tag, on the declaration or an enclosing block
+        /// </summary>
+        public bool IsDisabled => HasTag(ModelTags.DisabledTag);
+
+        public override string ToString() => $"{Key} [{string.Join(", ", Tags)}]";
+    }
+
+    /// <summary>
+    /// Usage of one tag (counting entities tagged with it or any descendant)
+    /// </summary>
+    public class TagStatistic
+    {
+        public string Tag { get; init; } = "";
+
+        /// <summary>
+        /// Entities tagged with exactly this tag
+        /// </summary>
+        public int Direct { get; set; }
+
+        public Dictionary<EntityKind, int> ByKind { get; } = new Dictionary<EntityKind, int>();
+
+        public int Total => ByKind.Values.Sum();
+
+        /// <summary>
+        /// Expanded constraint rows of the tagged constraint families (when computed against an expanded model)
+        /// </summary>
+        public int Rows { get; set; }
+
+        public override string ToString()
+        {
+            string kinds = string.Join(", ", ByKind.OrderBy(k => k.Key).Select(k => $"{k.Value} {k.Key.ToString().ToLowerInvariant()}"));
+            return $"{Tag}: {Total} entities ({kinds}){(Rows > 0 ? $", {Rows} rows" : "")}";
+        }
+    }
+
+    /// <summary>
+    /// Tag annotations of a model text. Tags are written in line comments before a declaration
+    /// and blocks group declarations whose members inherit the block's tags:
+    /// <code>
+    /// // @block hydro: water, reserve
+    /// // @tags hydro/ramping/up
+    /// forall(u in Units) rampUp: ...;
+    /// // @endblock
+    /// </code>
+    /// The block name is itself a tag; nested block names are joined into a path ("hydro/units").
+    /// </summary>
+    public class ModelTags
+    {
+        /// <summary>
+        /// Tag that marks a declaration, or every declaration of a block, as switched off
+        /// </summary>
+        public const string DisabledTag = "disabled";
+
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@(tags|block|endblock)\b[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+
+        private readonly ModelSource source;
+        private readonly List<TaggedEntity> entities = new List<TaggedEntity>();
+
+        private ModelTags(ModelSource source)
+        {
+            this.source = source;
+        }
+
+        public IReadOnlyList<TaggedEntity> Entities => entities;
+
+        /// <summary>
+        /// Unbalanced or empty block annotations
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        public static ModelTags Parse(string modelText)
+        {
+            var tags = new ModelTags(ModelSource.Parse(modelText));
+            var blocks = new Stack<(string Path, List<string> Tags)>();
+
+            foreach (var statement in tags.source.Statements)
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                var own = new List<string>();
+
+                foreach (Match m in annotationPattern.Matches(trivia))
+                {
+                    string argument = m.Groups[2].Value;
+                    switch (m.Groups[1].Value)
+                    {
+                        case "tags":
+                            own.AddRange(SplitTags(argument));
+                            break;
+
+                        case "block":
+                            int colon = argument.IndexOf(':');
+                            string name = TagPath.Normalize(colon >= 0 ? argument.Substring(0, colon) : argument);
+                            if (name.Length == 0)
+                            {
+                                tags.Warnings.Add($"Line {statement.LineNumber}: @block without a name");
+                                name = "block";
+                            }
+                            string path = blocks.Count > 0 ? $"{blocks.Peek().Path}/{name}" : name;
+                            blocks.Push((path, colon >= 0 ? SplitTags(argument.Substring(colon + 1)) : new List<string>()));
+                            break;
+
+                        default:
+                            if (blocks.Count == 0)
+                                tags.Warnings.Add($"Line {statement.LineNumber}: @endblock without a matching @block");
+                            else
+                                blocks.Pop();
+                            break;
+                    }
+                }
+
+                if (statement.Key == null)
+                    continue;
+
+                var entity = new TaggedEntity
+                {
+                    Key = statement.Key,
+                    LineNumber = statement.LineNumber,
+                    Block = blocks.Count > 0 ? blocks.Peek().Path : null
+                };
+
+                foreach (string tag in own)
+                {
+                    entity.OwnTags.Add(tag);
+                    entity.Tags.Add(tag);
+                }
+
+                foreach (var (path, blockTags) in blocks)
+                {
+                    entity.Tags.Add(path);
+                    entity.Tags.UnionWith(blockTags);
+                }
+
+                tags.entities.Add(entity);
+            }
+
+            foreach (var (path, _) in blocks)
+                tags.Warnings.Add($"Block '{path}' is not closed with @endblock");
+
+            return tags;
+        }
+
+        public TaggedEntity? Find(string key)
+        {
+            return entities.FirstOrDefault(e => e.Key == key);
+        }
+
+        /// <summary>
+        /// Entities carrying the tag or one of its descendants
+        /// </summary>
+        public IReadOnlyList<TaggedEntity> Select(string tag)
+        {
+            return entities.Where(e => e.HasTag(tag)).ToList();
+        }
+
+        /// <summary>
+        /// Every tag in use, including ancestors of hierarchical tags
+        /// </summary>
+        public IReadOnlyList<string> GetAllTags()
+        {
+            return entities
+                .SelectMany(e => e.Tags)
+                .SelectMany(TagPath.Ancestors)
+                .Distinct(StringComparer.OrdinalIgnoreCase)
+                .OrderBy(t => t, StringComparer.OrdinalIgnoreCase)
+                .ToList();
+        }
+
+        /// <summary>
+        /// Entity counts per tag. With an expanded model the rows generated by the tagged
+        /// constraint families are counted as well.
+        /// </summary>
+        public List<TagStatistic> GetStatistics(ModelManager? expanded = null)
+        {
+            Dictionary<string, int>? rowsByFamily = null;
+            if (expanded != null)
+            {
+                if (expanded.IndexedEquationTemplates.Count > 0 || expanded.ForallStatements.Count > 0)
+                {
+                    throw new InvalidOperationException(
+                        "Cannot count rows: Model has unexpanded templates. " +
+                        "Call ExpandAllTemplates() after loading external data.");
+                }
+
+                rowsByFamily = expanded.MaterializeRows()
+                    .GroupBy(SolutionComparison.GetFamily)
+                    .ToDictionary(g => g.Key, g => g.Count(), StringComparer.Ordinal);
+            }
+
+            var statistics = new List<TagStatistic>();
+            foreach (string tag in GetAllTags())
+            {
+                var statistic = new TagStatistic { Tag = tag };
+
+                foreach (var entity in Select(tag))
+                {
+                    var kind = KindOf(entity.Key);
+                    statistic.ByKind[kind] = statistic.ByKind.GetValueOrDefault(kind) + 1;
+
+                    if (entity.Tags.Contains(tag))
+                        statistic.Direct++;
+
+                    if (kind == EntityKind.Constraint && rowsByFamily != null)
+                        statistic.Rows += rowsByFamily.GetValueOrDefault(entity.Key.Substring("constraint:".Length));
+                }
+
+                statistics.Add(statistic);
+            }
+
+            return statistics;
+        }
+
+        /// <summary>
+        /// Model text with only the constraints carrying the tag; all other declarations are kept
+        /// so the subset still parses and can be exported
+        /// </summary>
+        public string ExtractSubset(string tag) => ExtractSubset(new[] { tag });
+
+        /// <summary>
+        /// Model text with only the constraints carrying one of the tags, as ExtractSubset(tag)
+        /// </summary>
+        public string ExtractSubset(IEnumerable<string> tags)
+        {
+            var subset = ModelSource.Parse(source.ToString());
+            var selected = tags.SelectMany(t => Select(t)).Select(e => e.Key).ToHashSet(StringComparer.Ordinal);
+
+            foreach (var entity in entities.Where(e => KindOf(e.Key) == EntityKind.Constraint && !selected.Contains(e.Key)))
+                subset.Remove(entity.Key);
+
+            return subset.ToString();
+        }
+
+        /// <summary>
+        /// Model text with every constraint carrying the tag turned into a soft constraint
+        /// </summary>
+        public RelaxationResult Relax(string tag, double penalty = ConstraintRelaxer.DefaultPenalty)
+        {
+            var relaxer = new ConstraintRelaxer { Penalty = penalty };
+            return relaxer.Relax(source.ToString(), Select(tag).Where(e => KindOf(e.Key) == EntityKind.Constraint).Select(e => e.Key));
+        }
+
+        private static List<string> SplitTags(string text)
+        {
+            return text
+                .Split(new[] { ',', ' ', '\t' }, StringSplitOptions.RemoveEmptyEntries)
+                .Select(TagPath.Normalize)
+                .Where(t => t.Length > 0)
+                .ToList();
+        }
+
+        private static EntityKind KindOf(string key)
+        {
+            return key.Substring(0, key.IndexOf(':') < 0 ? key.Length : key.IndexOf(':')) switch
+            {
+                "set" => EntityKind.Set,
+                "parameter" => EntityKind.Parameter,
+                "variable" => EntityKind.Variable,
+                "dexpr" => EntityKind.DecisionExpression,
+                "constraint" => EntityKind.Constraint,
+                _ => EntityKind.Objective
+            };
+        }
+    }
+}
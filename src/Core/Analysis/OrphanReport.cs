@@ -0,0 +1,192 @@
+using System.Text;
+using Core.Parsing;
+using Core.Storage;
+
+namespace Core.Analysis
+{
+    public enum OrphanKind
+    {
+        UnusedParameter,
+        EmptySet,
+        UnusedVariable,
+        DisabledBlock
+    }
+
+    /// <summary>
+    /// One piece of dead code: an unused declaration, an empty set or a block that is switched off
+    /// </summary>
+    public class OrphanFinding
+    {
+        public OrphanKind Kind { get; init; }
+
+        /// <summary>
+        /// Name of the declaration, or the path of the block
+        /// </summary>
+        public string Name { get; init; } = "";
+
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// Declarations the cleanup removes; empty if the finding is only reported
+        /// </summary>
+        public List<string> Keys { get; init; } = new List<string>();
+
+        public string Message { get; init; } = "";
+
+        public bool CanRemove => Keys.Count > 0;
+
+        public override string ToString() => $"line {LineNumber}: {Message}";
+    }
+
+    /// <summary>
+    /// Dead code of a model text: parameters never referenced, sets without members (with a model
+    /// loaded with its data), variables that appear in no active constraint and not in the objective,
+    /// directly or through decision expressions, and blocks whose declarations are all disabled
+    /// (see ModelTags.DisabledTag). Disabled constraints do not count as uses. Members of a
+    /// disabled block are reported with the block only.
+    /// </summary>
+    public class OrphanReport
+    {
+        public List<OrphanFinding> Findings { get; } = new List<OrphanFinding>();
+
+        public static OrphanReport Analyze(string modelText, ModelManager? manager = null)
+        {
+            var report = new OrphanReport();
+            var tags = ModelTags.Parse(modelText);
+            var references = ReferenceIndex.Build(modelText, "");
+
+            var disabled = tags.Entities.Where(e => e.IsDisabled).Select(e => e.Key).ToHashSet(StringComparer.Ordinal);
+            var disabledBlocks = FindDisabledBlocks(tags);
+            bool InDisabledBlock(TaggedEntity entity) =>
+                entity.Block != null && disabledBlocks.Any(b => TagPath.Matches(entity.Block, b));
+
+            // Statements without a key (unlabeled constraints) are always active
+            IEnumerable<string> UsedIn(string name) => references.FindReferences(name)
+                .Where(s => s.Kind == ReferenceKind.Use && s.Stream == "model")
+                .Select(s => s.Statement ?? "");
+
+            // Active constraints and the objective are live, and so is every decision expression they use
+            var live = new HashSet<string>(
+                tags.Entities.Where(e => !e.IsDisabled && (e.Key == "objective" || e.Key.StartsWith("constraint:", StringComparison.Ordinal))).Select(e => e.Key)
+                    .Append(""),
+                StringComparer.Ordinal);
+            bool grown = true;
+            while (grown)
+            {
+                grown = false;
+                foreach (var dexpr in tags.Entities.Where(e => e.Key.StartsWith("dexpr:", StringComparison.Ordinal) && !live.Contains(e.Key)))
+                {
+                    if (UsedIn(NameOf(dexpr.Key)).Any(live.Contains))
+                        grown = live.Add(dexpr.Key);
+                }
+            }
+
+            // A declaration is removed only if nothing mentions it; uses in disabled or dead code are reported instead
+            void AddUnused(OrphanKind kind, TaggedEntity entity, string message)
+            {
+                var users = UsedIn(NameOf(entity.Key)).Where(s => s != entity.Key).Distinct().ToList();
+                if (users.Count == 0)
+                    report.Add(kind, NameOf(entity.Key), entity, message, entity.Key);
+                else
+                    report.Add(kind, NameOf(entity.Key), entity, $"{message} (used only by {string.Join(", ", users.Select(Describe))})");
+            }
+
+            foreach (var entity in tags.Entities.Where(e => !InDisabledBlock(e)))
+            {
+                string name = NameOf(entity.Key);
+                if (entity.Key.StartsWith("parameter:", StringComparison.Ordinal) &&
+                    !UsedIn(name).Any(s => s != entity.Key && !disabled.Contains(s)))
+                {
+                    AddUnused(OrphanKind.UnusedParameter, entity, $"parameter '{name}' is never referenced");
+                }
+                else if (entity.Key.StartsWith("variable:", StringComparison.Ordinal) && !UsedIn(name).Any(live.Contains))
+                {
+                    AddUnused(OrphanKind.UnusedVariable, entity, $"variable '{name}' does not appear in any active constraint or the objective");
+                }
+            }
+
+            if (manager != null)
+            {
+                var empty = manager.IndexSets.Values.Where(s => s.Count <= 0).Select(s => s.Name)
+                    .Concat(manager.PrimitiveSets.Values.Where(s => s.Count == 0).Select(s => s.Name))
+                    .Concat(manager.TupleSets.Values.Where(s => s.Count == 0).Select(s => s.Name));
+                foreach (string name in empty.Distinct())
+                {
+                    var entity = tags.Find(EntityCatalog.KeyOf(EntityKind.Set, name));
+                    if (entity == null || !InDisabledBlock(entity))
+                        report.Add(OrphanKind.EmptySet, name, entity, $"set '{name}' has no members");
+                }
+            }
+
+            foreach (string block in disabledBlocks)
+            {
+                var members = tags.Entities.Where(e => e.Block != null && TagPath.Matches(e.Block, block)).ToList();
+                var memberKeys = members.Select(e => e.Key).ToHashSet(StringComparer.Ordinal);
+                var usedBy = members
+                    .SelectMany(e => UsedIn(NameOf(e.Key)))
+                    .Where(s => !memberKeys.Contains(s) && !disabled.Contains(s))
+                    .Distinct()
+                    .ToList();
+
+                string message = $"block '{block}' is entirely disabled ({members.Count} declaration{(members.Count == 1 ? "" : "s")})";
+                if (usedBy.Count > 0)
+                    message += $"; still used by {string.Join(", ", usedBy.Select(Describe))}";
+                report.Add(OrphanKind.DisabledBlock, block, members[0], message, usedBy.Count > 0 ? Array.Empty<string>() : memberKeys.ToArray());
+            }
+
+            report.Findings.Sort((a, b) => a.LineNumber.CompareTo(b.LineNumber));
+            return report;
+        }
+
+        /// <summary>
+        /// Change set removing the declarations of the given findings (by default all of them)
+        /// that can be removed, for review before it is applied
+        /// </summary>
+        public ChangeSet CreateCleanup(IEnumerable<OrphanFinding>? findings = null)
+        {
+            var keys = (findings ?? Findings).Where(f => f.CanRemove).SelectMany(f => f.Keys).Distinct().ToList();
+            return new ChangeSet(
+                $"Remove {keys.Count} orphaned declaration{(keys.Count == 1 ? "" : "s")}",
+                keys.Select(ModelEdit.Remove).ToArray());
+        }
+
+        public string ToReport()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine(Findings.Count == 0 ? "No orphans found" : $"{Findings.Count} orphans:");
+            foreach (var finding in Findings)
+                sb.AppendLine($"  {finding}");
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Outermost blocks whose declarations are all disabled
+        /// </summary>
+        private static List<string> FindDisabledBlocks(ModelTags tags)
+        {
+            var blocks = tags.Entities
+                .Where(e => e.Block != null)
+                .SelectMany(e => TagPath.Ancestors(e.Block!))
+                .Distinct(StringComparer.OrdinalIgnoreCase)
+                .Where(b => tags.Entities.Where(e => e.Block != null && TagPath.Matches(e.Block, b)).All(e => e.IsDisabled))
+                .ToList();
+            return blocks.Where(b => !blocks.Any(outer => outer != b && TagPath.Matches(b, outer))).ToList();
+        }
+
+        private static string Describe(string statement) => statement.Length == 0 ? "an unlabeled constraint" : statement;
+
+        private static string NameOf(string key) => key.Substring(key.IndexOf(':') + 1);
+
+        private void Add(OrphanKind kind, string name, TaggedEntity? entity, string message, params string[] keys)
+        {
+            Findings.Add(new OrphanFinding
+            {
+                Kind = kind,
+                Name = name,
+                LineNumber = entity?.LineNumber ?? 0,
+                Keys = keys.ToList(),
+                Message = message
+            });
+        }
+    }
+}
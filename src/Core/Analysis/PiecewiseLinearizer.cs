@@ -0,0 +1,621 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+using Core.Services;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// How the breakpoints of a piecewise-linear approximation are placed
+    /// </summary>
+    public enum BreakpointStrategy
+    {
+        /// <summary>Equally spaced</summary>
+        Uniform,
+
+        /// <summary>Denser where the function bends more (equal shares of the integral of sqrt|f''|)</summary>
+        CurvatureAdaptive,
+
+        /// <summary>As few as needed to keep the error within a tolerance</summary>
+        ErrorBounded
+    }
+
+    /// <summary>
+    /// Breakpoints of a piecewise-linear interpolation of a univariate function, and how far it is off
+    /// </summary>
+    public class PiecewiseApproximation
+    {
+        public string Function { get; init; } = "";
+
+        /// <summary>
+        /// The variable reference the function is of ("x", "flow[2]")
+        /// </summary>
+        public string Argument { get; init; } = "";
+
+        public BreakpointStrategy Strategy { get; init; }
+        public List<(double X, double Y)> Breakpoints { get; init; } = new List<(double, double)>();
+
+        /// <summary>
+        /// Largest |f(x) - approximation(x)| found by sampling every segment
+        /// </summary>
+        public double MaxError { get; init; }
+
+        /// <summary>
+        /// Where MaxError is attained
+        /// </summary>
+        public double MaxErrorAt { get; init; }
+
+        public int Segments => Breakpoints.Count - 1;
+
+        /// <summary>
+        /// The approximation at x, which must lie within the first and last breakpoint
+        /// </summary>
+        public double Value(double x)
+        {
+            int k = 1;
+            while (k < Breakpoints.Count - 1 && x > Breakpoints[k].X)
+                k++;
+            var (x0, y0) = Breakpoints[k - 1];
+            var (x1, y1) = Breakpoints[k];
+            return y0 + (y1 - y0) * (x - x0) / (x1 - x0);
+        }
+
+        public override string ToString() => string.Create(CultureInfo.InvariantCulture,
+            $"{Function} on [{Breakpoints[0].X:G6}, {Breakpoints[^1].X:G6}]: {Segments} segments ({Strategy}), " +
+            $"max error {MaxError:G4} at {Argument} = {MaxErrorAt:G6}");
+    }
+
+    /// <summary>
+    /// A model text with a nonlinear term replaced by its piecewise-linear approximation
+    /// </summary>
+    public class PiecewiseResult
+    {
+        public string ModelText { get; init; } = "";
+
+        /// <summary>
+        /// The edits that turn the original text into ModelText
+        /// </summary>
+        public ChangeSet Changes { get; init; } = new ChangeSet();
+
+        public PiecewiseApproximation Approximation { get; init; } = new PiecewiseApproximation();
+
+        /// <summary>
+        /// The variable that stands for the function's value
+        /// </summary>
+        public string Auxiliary { get; init; } = "";
+
+        /// <summary>
+        /// Constraints and objective the term was replaced in ("objective", "constraint:cap", "line 7")
+        /// </summary>
+        public List<string> Replaced { get; } = new List<string>();
+
+        public List<string> Warnings { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Approximates a univariate nonlinear function of a bounded variable by linear
+    /// interpolation between breakpoints, and writes the approximation into a model as the
+    /// convex-combination (lambda) formulation: a weight per breakpoint summing to 1, the
+    /// variable and the auxiliary equal to the weighted breakpoints, and one binary per segment
+    /// so that only the weights of two adjacent breakpoints are nonzero. The generated
+    /// declarations carry a "// @generated piecewise from f" annotation.
+    ///
+    /// Functions are written in the model language (exp, log, sqrt, abs, sin, cos, tan, pow,
+    /// min, max, ^) and may use scalar numeric parameters of the model.
+    /// </summary>
+    public class PiecewiseLinearizer
+    {
+        public const string Generator = "piecewise";
+
+        /// <summary>
+        /// Samples per segment when measuring the error
+        /// </summary>
+        private const int Samples = 64;
+
+        private const string Reference = @"(?<![\w.])[A-Za-z_]\w*(?:\[[^\]]*\])?(?![\w\[(])";
+
+        private static readonly Regex tokenPattern = new Regex(
+            @"\G\s*(?:(?<number>(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)|(?<name>[A-Za-z_]\w*(?:\[[^\]]*\])?)|(?<op>[-+*/^(),]))");
+
+        public BreakpointStrategy Strategy { get; set; } = BreakpointStrategy.Uniform;
+
+        /// <summary>
+        /// Number of segments for the uniform and curvature-adaptive strategies
+        /// </summary>
+        public int Segments { get; set; } = 8;
+
+        /// <summary>
+        /// Largest error the error-bounded strategy allows
+        /// </summary>
+        public double Tolerance { get; set; } = 1e-3;
+
+        /// <summary>
+        /// The error-bounded strategy stops splitting here even if the tolerance is not met
+        /// </summary>
+        public int MaxSegments { get; set; } = 256;
+
+        /// <summary>
+        /// Approximates a function given as a delegate; the curvature-adaptive strategy estimates f'' by finite differences
+        /// </summary>
+        public PiecewiseApproximation Approximate(Func<double, double> function, double lower, double upper, string name = "f(x)", string argument = "x")
+        {
+            return Approximate(function, null, lower, upper, name, argument, CancellationToken.None);
+        }
+
+        /// <summary>
+        /// Approximates a function written in the model language of the variable reference
+        /// <paramref name="argument"/>, with the given parameter values
+        /// </summary>
+        public PiecewiseApproximation Approximate(string function, string argument, double lower, double upper, IReadOnlyDictionary<string, double>? parameters = null) =>
+            Approximate(function, argument, lower, upper, parameters, CancellationToken.None);
+
+        private PiecewiseApproximation Approximate(string function, string argument, double lower, double upper, IReadOnlyDictionary<string, double>? parameters, CancellationToken cancellationToken)
+        {
+            var expression = new FunctionParser(function, argument, parameters ?? new Dictionary<string, double>()).Parse();
+            var point = new Dictionary<string, double>();
+            double F(double x)
+            {
+                point[FunctionParser.Variable] = x;
+                return Differentiator.Evaluate(expression, point);
+            }
+
+            Func<double, double>? curvature = null;
+            try
+            {
+                var second = Differentiator.Derivative(Differentiator.Derivative(expression, FunctionParser.Variable), FunctionParser.Variable);
+                curvature = x =>
+                {
+                    point[FunctionParser.Variable] = x;
+                    return Differentiator.Evaluate(second, point);
+                };
+            }
+            catch (InvalidOperationException)
+            {
+                // Not differentiable (abs, min, max): fall back to finite differences
+            }
+
+            return Approximate(F, curvature, lower, upper, function, argument, cancellationToken);
+        }
+
+        /// <summary>
+        /// Replaces every occurrence of <paramref name="function"/> in the constraints and the
+        /// objective of a model text by an auxiliary variable (<paramref name="name"/>, by default
+        /// derived from the function) and adds the piecewise-linear formulation that defines it.
+        /// The function's variable must have finite bounds.
+        /// </summary>
+        public PiecewiseResult Apply(string modelText, string function, string? name = null) =>
+            Apply(modelText, function, name, CancellationToken.None);
+
+        /// <summary>
+        /// Applies the approximation with cancellation, checking the token at every evaluation of
+        /// the function and reporting progress per statement rewritten
+        /// </summary>
+        public PiecewiseResult Apply(
+            string modelText,
+            string function,
+            string? name,
+            CancellationToken cancellationToken,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("piecewise", progress, cancellationToken);
+            tracker.Stage("parse");
+            var source = ModelSource.Parse(modelText);
+            var definitions = source.Statements
+                .Select(s => (Statement: s, Definition: EntityDefinition.FromStatement(s.Text)))
+                .ToList();
+            var parameters = new Dictionary<string, double>(StringComparer.Ordinal);
+            foreach (var definition in definitions.Select(d => d.Definition))
+            {
+                if (definition is { Kind: EntityKind.Parameter, IndexSets.Count: 0, Value: not null } &&
+                    double.TryParse(definition.Value, NumberStyles.Float, CultureInfo.InvariantCulture, out double value))
+                    parameters[definition.Name] = value;
+            }
+            var variables = definitions
+                .Where(d => d.Definition?.Kind == EntityKind.Variable)
+                .ToDictionary(d => d.Definition!.Name, d => d.Definition!, StringComparer.Ordinal);
+
+            var arguments = Regex.Matches(function, Reference).Select(m => m.Value)
+                .Where(r => variables.ContainsKey(BaseName(r)))
+                .Distinct(StringComparer.Ordinal)
+                .ToList();
+            if (arguments.Count != 1)
+                throw new InvalidOperationException($"'{function}' must be a function of one variable; it uses {(arguments.Count == 0 ? "none" : string.Join(", ", arguments))}");
+
+            string argument = arguments[0];
+            var variable = variables[BaseName(argument)];
+            double? Bound(string? text) => text == null ? null
+                : double.TryParse(text, NumberStyles.Float, CultureInfo.InvariantCulture, out double value) ? value
+                : parameters.TryGetValue(text, out value) ? value
+                : null;
+            double? lower = variable.Type == "bool" ? 0 : Bound(variable.LowerBound) ?? (variable.Type.EndsWith("+", StringComparison.Ordinal) ? 0 : null);
+            double? upper = variable.Type == "bool" ? 1 : Bound(variable.UpperBound);
+            if (lower == null || upper == null)
+                throw new InvalidOperationException($"'{variable.Name}' needs numeric bounds to approximate '{function}' (declare it 'in lo..hi')");
+
+            tracker.Stage("breakpoints", message: function);
+            var approximation = Approximate(function, argument, lower.Value, upper.Value, parameters, cancellationToken);
+
+            var used = source.Statements.Where(s => s.Key != null).Select(s => s.Key!.Substring(s.Key.IndexOf(':') + 1)).ToHashSet(StringComparer.Ordinal);
+            string auxiliary = name ?? ConstraintRelaxer.Unique(Regex.Replace(function, @"\W+", "_").Trim('_'), used);
+            if (name != null && !used.Add(name))
+                throw new InvalidOperationException($"'{name}' is already declared");
+
+            var changes = new ChangeSet { Title = $"Piecewise-linear {function}" };
+            var replaced = new List<string>();
+
+            // Matches the function however it is spaced
+            var tokens = tokenPattern.Matches(function).Select(m => Regex.Escape(m.Value.Trim()));
+            var pattern = new Regex(@"(?<![\w.\]])" + string.Join(@"\s*", tokens) + @"(?![\w\[])");
+            tracker.Stage("statements", definitions.Count);
+            for (int i = 0; i < definitions.Count; i++)
+            {
+                var (statement, definition) = definitions[i];
+                tracker.Tick(i, definitions.Count);
+                if (definition is { Kind: EntityKind.Constraint or EntityKind.Objective, Body: not null })
+                {
+                    if (!pattern.IsMatch(definition.Body))
+                        continue;
+                    definition.Body = pattern.Replace(definition.Body, auxiliary);
+                    changes.Edits.Add(ModelEdit.Upsert(definition.ToStatement()));
+                    replaced.Add(definition.Key);
+                }
+                else if (definition == null && statement.Key == null && pattern.IsMatch(statement.Code) && !statement.Code.TrimStart().StartsWith("//", StringComparison.Ordinal))
+                {
+                    changes.Edits.Add(ModelEdit.Rewrite(statement, pattern.Replace(statement.Code, auxiliary)));
+                    replaced.Add($"line {statement.LineNumber}");
+                }
+            }
+
+            tracker.Tick(definitions.Count, definitions.Count);
+            changes.Edits.AddRange(Formulation(approximation, auxiliary, used));
+            changes.Apply(source);
+            var result = new PiecewiseResult { ModelText = source.ToString(), Changes = changes, Approximation = approximation, Auxiliary = auxiliary };
+            result.Replaced.AddRange(replaced);
+            if (replaced.Count == 0)
+                result.Warnings.Add($"'{function}' does not occur in the objective or a constraint; only its approximation was added");
+            return result;
+        }
+
+        private PiecewiseApproximation Approximate(Func<double, double> function, Func<double, double>? curvature, double lower, double upper, string name, string argument, CancellationToken cancellationToken)
+        {
+            if (!double.IsFinite(lower) || !double.IsFinite(upper) || lower >= upper)
+                throw new InvalidOperationException(string.Create(CultureInfo.InvariantCulture, $"Cannot approximate '{name}' on [{lower}, {upper}]: the interval must be finite and nonempty"));
+            if (Strategy != BreakpointStrategy.ErrorBounded && Segments < 1)
+                throw new InvalidOperationException("A piecewise-linear approximation needs at least one segment");
+
+            // Every strategy samples the function, so a cancelled approximation stops at its next sample
+            double F(double x)
+            {
+                cancellationToken.ThrowIfCancellationRequested();
+                double y = function(x);
+                return double.IsFinite(y) ? y
+                    : throw new InvalidOperationException(string.Create(CultureInfo.InvariantCulture, $"'{name}' is not finite at {argument} = {x:G6}"));
+            }
+
+            var xs = Strategy switch
+            {
+                BreakpointStrategy.CurvatureAdaptive => CurvatureBreakpoints(F, curvature, lower, upper),
+                BreakpointStrategy.ErrorBounded => ErrorBoundedBreakpoints(F, lower, upper),
+                _ => Enumerable.Range(0, Segments + 1).Select(k => lower + (upper - lower) * k / Segments).ToList()
+            };
+            xs[^1] = upper;
+
+            var breakpoints = xs.Select(x => (x, F(x))).ToList();
+            double maxError = 0, maxErrorAt = lower;
+            for (int k = 1; k < breakpoints.Count; k++)
+            {
+                var (error, at) = SegmentError(F, breakpoints[k - 1], breakpoints[k]);
+                if (error > maxError)
+                    (maxError, maxErrorAt) = (error, at);
+            }
+
+            return new PiecewiseApproximation
+            {
+                Function = name,
+                Argument = argument,
+                Strategy = Strategy,
+                Breakpoints = breakpoints,
+                MaxError = maxError,
+                MaxErrorAt = maxErrorAt
+            };
+        }
+
+        /// <summary>
+        /// The error of linear interpolation is about h²|f''|/8 on a segment of width h, so it is
+        /// evened out by giving every segment the same share of the integral of sqrt|f''|
+        /// </summary>
+        private List<double> CurvatureBreakpoints(Func<double, double> function, Func<double, double>? curvature, double lower, double upper)
+        {
+            int cells = Samples * Segments;
+            double width = (upper - lower) / cells;
+            curvature ??= x => (function(x + width / 2) - 2 * function(x) + function(x - width / 2)) / (width * width / 4);
+
+            var cumulative = new double[cells + 1];
+            for (int i = 0; i < cells; i++)
+            {
+                double density = Math.Sqrt(Math.Abs(curvature(lower + (i + 0.5) * width)));
+                cumulative[i + 1] = cumulative[i] + (double.IsFinite(density) ? density : 0) * width;
+            }
+            double total = cumulative[cells];
+            if (total <= 0)
+                return Enumerable.Range(0, Segments + 1).Select(k => lower + (upper - lower) * k / Segments).ToList();
+
+            var xs = new List<double> { lower };
+            int cell = 0;
+            for (int k = 1; k < Segments; k++)
+            {
+                double target = total * k / Segments;
+                while (cumulative[cell + 1] < target)
+                    cell++;
+                double share = (target - cumulative[cell]) / (cumulative[cell + 1] - cumulative[cell]);
+                double x = lower + (cell + share) * width;
+                if (x > xs[^1])
+                    xs.Add(x);
+            }
+            xs.Add(upper);
+            return xs;
+        }
+
+        /// <summary>
+        /// Splits the segment with the largest error where that error occurs until every
+        /// segment is within Tolerance or MaxSegments is reached
+        /// </summary>
+        private List<double> ErrorBoundedBreakpoints(Func<double, double> function, double lower, double upper)
+        {
+            var points = new List<(double X, double Y)> { (lower, function(lower)), (upper, function(upper)) };
+            var errors = new List<(double Error, double At)> { SegmentError(function, points[0], points[1]) };
+
+            while (points.Count - 1 < MaxSegments)
+            {
+                int worst = 0;
+                for (int k = 1; k < errors.Count; k++)
+                {
+                    if (errors[k].Error > errors[worst].Error)
+                        worst = k;
+                }
+                if (errors[worst].Error <= Tolerance)
+                    break;
+
+                double x = errors[worst].At;
+                var split = (x, function(x));
+                points.Insert(worst + 1, split);
+                errors[worst] = SegmentError(function, points[worst], split);
+                errors.Insert(worst + 1, SegmentError(function, split, points[worst + 2]));
+            }
+            return points.Select(p => p.X).ToList();
+        }
+
+        private static (double Error, double At) SegmentError(Func<double, double> function, (double X, double Y) from, (double X, double Y) to)
+        {
+            double error = 0, at = from.X;
+            for (int j = 1; j < Samples; j++)
+            {
+                double x = from.X + (to.X - from.X) * j / Samples;
+                double interpolated = from.Y + (to.Y - from.Y) * j / Samples;
+                double e = Math.Abs(function(x) - interpolated);
+                if (e > error)
+                    (error, at) = (e, x);
+            }
+            return (error, at);
+        }
+
+        private static IEnumerable<ModelEdit> Formulation(PiecewiseApproximation approximation, string auxiliary, HashSet<string> used)
+        {
+            string annotation = new EntityProvenance { Generator = Generator, Source = approximation.Function }.ToAnnotation();
+            var points = approximation.Breakpoints;
+            int n = points.Count;
+            var weights = Enumerable.Range(1, n).Select(k => ConstraintRelaxer.Unique($"{auxiliary}_w{k}", used)).ToList();
+            var segments = Enumerable.Range(1, n - 1).Select(k => ConstraintRelaxer.Unique($"{auxiliary}_seg{k}", used)).ToList();
+
+            ModelEdit Declare(string name, string type, string? lower = null, string? upper = null) => ModelEdit.Upsert(new EntityDefinition
+            {
+                Kind = EntityKind.Variable,
+                Type = type,
+                Name = name,
+                LowerBound = lower,
+                UpperBound = upper
+            }.ToStatement(), annotation);
+
+            yield return Declare(auxiliary, "float", Format(points.Min(p => p.Y)), Format(points.Max(p => p.Y)));
+            foreach (var weight in weights)
+                yield return Declare(weight, "float", "0", "1");
+            foreach (var segment in segments)
+                yield return Declare(segment, "bool");
+
+            var relations = new List<string>
+            {
+                $"{string.Join(" + ", weights)} == 1",
+                $"{approximation.Argument} == {Combination(points.Select(p => p.X), weights)}",
+                $"{auxiliary} == {Combination(points.Select(p => p.Y), weights)}",
+                $"{string.Join(" + ", segments)} == 1"
+            };
+
+            // Only the weights at the ends of the selected segment may be nonzero
+            for (int k = 0; k < n; k++)
+            {
+                var adjacent = new[] { k - 1, k }.Where(s => s >= 0 && s < n - 1).Select(s => segments[s]);
+                relations.Add($"{weights[k]} <= {string.Join(" + ", adjacent)}");
+            }
+
+            int index = 1;
+            foreach (var relation in relations)
+            {
+                yield return ModelEdit.Upsert(new EntityDefinition
+                {
+                    Kind = EntityKind.Constraint,
+                    Name = ConstraintRelaxer.Unique($"{auxiliary}_def{index++}", used),
+                    Body = relation
+                }.ToStatement(), annotation);
+            }
+        }
+
+        private static string Combination(IEnumerable<double> coefficients, IReadOnlyList<string> weights)
+        {
+            var text = new StringBuilder();
+            foreach (var (coefficient, weight) in coefficients.Zip(weights))
+            {
+                if (coefficient == 0)
+                    continue;
+                string term = Math.Abs(coefficient) == 1 ? weight : $"{Format(Math.Abs(coefficient))} * {weight}";
+                text.Append(text.Length == 0 ? (coefficient < 0 ? "-" : "") + term : (coefficient < 0 ? " - " : " + ") + term);
+            }
+            return text.Length == 0 ? "0" : text.ToString();
+        }
+
+        private static string Format(double value) => value.ToString("G12", CultureInfo.InvariantCulture);
+
+        private static string BaseName(string reference)
+        {
+            int bracket = reference.IndexOf('[');
+            return bracket < 0 ? reference : reference.Substring(0, bracket);
+        }
+
+        /// <summary>
+        /// Parses a univariate function into an expression of the single variable
+        /// FunctionParser.Variable that Differentiator can evaluate and differentiate
+        /// </summary>
+        private class FunctionParser
+        {
+            public const string Variable = "x";
+
+            private readonly string text;
+            private readonly string argument;
+            private readonly IReadOnlyDictionary<string, double> parameters;
+            private readonly List<string> tokens = new List<string>();
+            private int position;
+
+            public FunctionParser(string text, string argument, IReadOnlyDictionary<string, double> parameters)
+            {
+                this.text = text;
+                this.argument = Regex.Replace(argument, @"\s+", "");
+                this.parameters = parameters;
+                int at = 0;
+                while (at < text.Length && !string.IsNullOrWhiteSpace(text.Substring(at)))
+                {
+                    var match = tokenPattern.Match(text, at);
+                    if (!match.Success)
+                        throw Error($"unexpected '{text.Substring(at).Trim()[0]}'");
+                    tokens.Add(match.Value.Trim());
+                    at += match.Length;
+                }
+            }
+
+            private string? Peek => position < tokens.Count ? tokens[position] : null;
+
+            public Expression Parse()
+            {
+                var expression = Sum();
+                if (Peek != null)
+                    throw Error($"unexpected '{Peek}'");
+                return expression;
+            }
+
+            private Expression Sum()
+            {
+                var expression = Product();
+                while (Peek is "+" or "-")
+                {
+                    var op = tokens[position++] == "+" ? BinaryOperator.Add : BinaryOperator.Subtract;
+                    expression = new BinaryExpression(expression, op, Product());
+                }
+                return expression;
+            }
+
+            private Expression Product()
+            {
+                var expression = Unary();
+                while (Peek is "*" or "/")
+                {
+                    var op = tokens[position++] == "*" ? BinaryOperator.Multiply : BinaryOperator.Divide;
+                    expression = new BinaryExpression(expression, op, Unary());
+                }
+                return expression;
+            }
+
+            private Expression Unary()
+            {
+                if (Peek == "-")
+                {
+                    position++;
+                    return new UnaryExpression(UnaryOperator.Negate, Unary());
+                }
+                if (Peek == "+")
+                {
+                    position++;
+                    return Unary();
+                }
+
+                var expression = Primary();
+                if (Peek == "^")
+                {
+                    position++;
+                    expression = new BinaryExpression(expression, BinaryOperator.Power, Unary());
+                }
+                return expression;
+            }
+
+            private Expression Primary()
+            {
+                string token = Peek ?? throw Error("unexpected end");
+                position++;
+
+                if (token == "(")
+                {
+                    var inner = Sum();
+                    Expect(")");
+                    return inner;
+                }
+                if (char.IsDigit(token[0]) || token[0] == '.')
+                    return new ConstantExpression(double.Parse(token, CultureInfo.InvariantCulture));
+                if (!char.IsLetter(token[0]) && token[0] != '_')
+                    throw Error($"unexpected '{token}'");
+
+                if (Peek == "(")
+                    return Function(token);
+                if (Regex.Replace(token, @"\s+", "") == argument)
+                    return new VariableExpression(Variable);
+                if (parameters.TryGetValue(token, out double value))
+                    return new ConstantExpression(value);
+                throw Error($"'{token}' is neither '{argument}' nor a scalar numeric parameter");
+            }
+
+            private Expression Function(string name)
+            {
+                string function = name.ToLowerInvariant() switch
+                {
+                    "ln" => nameof(MathFunction.Log),
+                    "maxl" => nameof(MathFunction.Max),
+                    "minl" => nameof(MathFunction.Min),
+                    var other => other
+                };
+                if (!Enum.TryParse<MathFunction>(function, ignoreCase: true, out var kind))
+                    throw Error($"unknown function '{name}'");
+
+                position++;
+                var arguments = new List<Expression> { Sum() };
+                while (Peek == ",")
+                {
+                    position++;
+                    arguments.Add(Sum());
+                }
+                Expect(")");
+
+                if (kind == MathFunction.Pow && arguments.Count == 2)
+                    return new BinaryExpression(arguments[0], BinaryOperator.Power, arguments[1]);
+                return new MathFunctionExpression { Function = kind, Arguments = arguments.ToArray() };
+            }
+
+            private void Expect(string token)
+            {
+                if (Peek != token)
+                    throw Error($"expected '{token}'");
+                position++;
+            }
+
+            private InvalidOperationException Error(string message) => new InvalidOperationException($"Cannot read function '{text}': {message}");
+        }
+    }
+}
@@ -0,0 +1,914 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+using Core.Solving;
+
+namespace Core.Analysis
+{
+    /// <summary>
+    /// Problem classes by the most general term in the objective and the constraints. QCP has
+    /// quadratic constraints (and a linear or quadratic objective); the MI variants have integer
+    /// or binary variables.
+    /// </summary>
+    public enum ProblemClass
+    {
+        LP,
+        MILP,
+        QP,
+        MIQP,
+        QCP,
+        MIQCP,
+        NLP,
+        MINLP
+    }
+
+    /// <summary>
+    /// Unknown when the analysis cannot decide, e.g. for coefficients of unknown sign
+    /// </summary>
+    public enum Convexity
+    {
+        Convex,
+        Nonconvex,
+        Unknown
+    }
+
+    public enum ExpressionForm
+    {
+        Linear,
+        Quadratic,
+        Nonlinear
+    }
+
+    /// <summary>
+    /// The form and convexity of the objective or one constraint statement
+    /// </summary>
+    public class EntityClassification
+    {
+        /// <summary>
+        /// Entity key ("objective", "constraint:cap"), or "line 12" for an unlabeled constraint
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        public ExpressionForm Form { get; init; }
+        public Convexity Convexity { get; init; }
+
+        /// <summary>
+        /// Why the entity is nonconvex or not known to be convex, e.g. "indefinite quadratic"; "" if convex
+        /// </summary>
+        public string Reason { get; init; } = "";
+
+        public override string ToString()
+        {
+            string reason = Reason.Length > 0 ? $" ({Reason})" : "";
+            return $"{Key}: {Form.ToString().ToLowerInvariant()}, {Convexity.ToString().ToLowerInvariant()}{reason}";
+        }
+    }
+
+    public class ModelClassification
+    {
+        public ProblemClass Class { get; init; }
+        public Convexity Objective { get; init; }
+        public Convexity Constraints { get; init; }
+
+        /// <summary>
+        /// Nonconvex if the objective or a constraint is, Unknown if one of them cannot be decided
+        /// </summary>
+        public Convexity Convexity => Objective == Convexity.Nonconvex || Constraints == Convexity.Nonconvex ? Convexity.Nonconvex
+            : Objective == Convexity.Unknown || Constraints == Convexity.Unknown ? Convexity.Unknown
+            : Convexity.Convex;
+
+        public bool IsLinear => Class is ProblemClass.LP or ProblemClass.MILP;
+
+        /// <summary>
+        /// The objective and the constraints that are not linear or not known to be convex
+        /// </summary>
+        public List<EntityClassification> Entities { get; } = new List<EntityClassification>();
+
+        /// <summary>
+        /// What a solver must support; Nonconvex only when the model is known to be nonconvex
+        /// </summary>
+        public SolverCapabilities Required { get; init; }
+
+        /// <summary>
+        /// Names of the given solvers that can handle the model, in the given order; null when no solvers were given
+        /// </summary>
+        public List<string>? Solvers { get; init; }
+
+        public override string ToString()
+        {
+            string text = $"{Class}, {Describe(Objective)} objective, {Describe(Constraints)} constraints";
+            if (Solvers != null)
+                text += Solvers.Count > 0 ? $"; solvers: {string.Join(", ", Solvers)}" : "; no configured solver handles it";
+            return text;
+        }
+
+        private static string Describe(Convexity convexity) => convexity switch
+        {
+            Convexity.Convex => "convex",
+            Convexity.Nonconvex => "nonconvex",
+            _ => "convexity unknown"
+        };
+    }
+
+    /// <summary>
+    /// Classifies a model as LP, MILP, QP, MIQP, QCP, MIQCP, NLP or MINLP and decides the
+    /// convexity of its objective and constraints where it can. Quadratic forms with numeric
+    /// coefficients are decided by the signs of their eigenvalues; other expressions by
+    /// composition rules: sums of convex terms and positive multiples stay convex, abs, max and
+    /// exp of convex arguments are convex, min, log and sqrt of concave arguments concave.
+    /// A minimized objective and the left minus the right side of a "&lt;=" constraint must be
+    /// convex, a maximized objective and "&gt;=" constraints concave, equalities linear.
+    /// For integer models the convexity is that of the continuous relaxation.
+    ///
+    /// The parser folds products of variables into coefficients, so the analysis reads the model
+    /// source (ModelManager.SourceTexts); without one, products left in the expanded
+    /// coefficients make the model quadratic of unknown convexity.
+    /// </summary>
+    public static class ProblemClassifier
+    {
+        /// <summary>
+        /// Largest quadratic form, in variables, whose eigenvalues are computed; larger ones are Unknown
+        /// </summary>
+        public const int MaxQuadraticSize = 200;
+
+        private static readonly Regex tokenPattern = new Regex(
+            @"\G\s*(?:(?<number>(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)|(?<name>[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)|(?<string>""[^""]*"")|(?<op><=|>=|==|!=|=>|&&|\|\||[-+*/^()\[\],:<>!?=]))");
+        private static readonly Regex relationPattern = new Regex(@"<=|>=|==");
+        private static readonly Regex declarationPattern = new Regex(@"^(?:(?:dvar|dexpr|float|int|bool|string|range|tuple|execute|main|using|subject|minimize|maximize|setof)\b|[{}])");
+        private static readonly Regex constantIndexPattern = new Regex(@"^(?:\[(?:\d+|""[^""]*"")(?:,(?:\d+|""[^""]*""))*\])+$");
+
+        /// <summary>
+        /// Classifies the model from its source texts (by default ModelManager.SourceTexts), with the
+        /// variable types and logical constraints of the manager. Lists the solvers, if given, that can handle it.
+        /// </summary>
+        public static ModelClassification Classify(ModelManager manager, IEnumerable<ISolverDriver>? solvers = null, IEnumerable<string>? modelTexts = null)
+        {
+            var texts = (modelTexts ?? manager.SourceTexts).ToList();
+            var analysis = new Analysis(manager);
+            foreach (var text in texts)
+                analysis.Read(text);
+            if (texts.Count == 0)
+                analysis.ReadExpanded();
+            return analysis.Result(solvers);
+        }
+
+        /// <summary>
+        /// Classifies a model text on its own; variable types come from its dvar declarations
+        /// </summary>
+        public static ModelClassification Classify(string modelText, IEnumerable<ISolverDriver>? solvers = null)
+        {
+            var analysis = new Analysis(null);
+            analysis.Read(modelText);
+            return analysis.Result(solvers);
+        }
+
+        private class Analysis
+        {
+            private readonly ModelManager? manager;
+            private readonly Dictionary<string, EntityDefinition> variables = new Dictionary<string, EntityDefinition>(StringComparer.Ordinal);
+            private readonly Dictionary<string, double> values = new Dictionary<string, double>(StringComparer.Ordinal);
+            private readonly Dictionary<string, (Form Form, bool Indexed)> expressions = new Dictionary<string, (Form, bool)>(StringComparer.Ordinal);
+            private readonly List<EntityClassification> entities = new List<EntityClassification>();
+            private EntityClassification? objective;
+            private bool logical;
+
+            public Analysis(ModelManager? manager)
+            {
+                this.manager = manager;
+            }
+
+            public void Read(string text)
+            {
+                foreach (var statement in ModelSource.Parse(text).Statements)
+                {
+                    var definition = EntityDefinition.FromStatement(statement.Text);
+                    switch (definition?.Kind)
+                    {
+                        case EntityKind.Variable:
+                            variables[definition.Name] = definition;
+                            break;
+
+                        case EntityKind.Parameter when definition.IndexSets.Count == 0 && definition.Value != null:
+                            if (double.TryParse(definition.Value, NumberStyles.Float, CultureInfo.InvariantCulture, out double value))
+                                values[definition.Name] = value;
+                            break;
+
+                        case EntityKind.DecisionExpression:
+                            try
+                            {
+                                expressions[definition.Name] = (new FormParser(this, definition.Value!).ParseExpression(), definition.IndexSets.Count > 0);
+                            }
+                            catch (FormatException)
+                            {
+                            }
+                            break;
+
+                        case EntityKind.Objective:
+                            objective = Classify(definition.Key, definition.Body!, definition.Sense);
+                            break;
+
+                        case EntityKind.Constraint:
+                            entities.Add(Classify(definition.Key, definition.Body!, null));
+                            break;
+
+                        case null:
+                            string code = statement.Code.Trim().TrimEnd(';').Trim();
+                            if (code.StartsWith("forall", StringComparison.Ordinal))
+                                code = StripForall(code);
+                            if (relationPattern.IsMatch(code) && !declarationPattern.IsMatch(code))
+                                entities.Add(Classify($"line {statement.LineNumber}", code, null));
+                            break;
+                    }
+                }
+            }
+
+            /// <summary>
+            /// Without a source, products of variables that remain in expanded coefficients
+            /// </summary>
+            public void ReadExpanded()
+            {
+                var unknown = Convexity.Unknown;
+                if (manager!.Objective?.Coefficients.Values.Any(ModelCharacteristics.ContainsVariable) ?? false)
+                    objective = new EntityClassification { Key = "objective", Form = ExpressionForm.Quadratic, Convexity = unknown, Reason = "no model source" };
+                var rows = manager.MaterializeRows();
+                for (int i = 0; i < rows.Count; i++)
+                {
+                    var equation = rows[i];
+                    if (equation.Coefficients.Values.Any(ModelCharacteristics.ContainsVariable))
+                        entities.Add(new EntityClassification { Key = equation.Label ?? $"c{i + 1}", Form = ExpressionForm.Quadratic, Convexity = unknown, Reason = "no model source" });
+                }
+            }
+
+            public ModelClassification Result(IEnumerable<ISolverDriver>? solvers)
+            {
+                bool integer, continuous;
+                if (manager != null && manager.IndexedVariables.Count > 0)
+                {
+                    integer = manager.IndexedVariables.Values.Any(v => v.Type is VariableType.Integer or VariableType.Boolean);
+                    continuous = manager.IndexedVariables.Values.Any(v => v.Type == VariableType.Float);
+                }
+                else
+                {
+                    integer = variables.Values.Any(v => v.Type is "bool" or "int" or "int+");
+                    continuous = variables.Values.Any(v => v.Type.StartsWith("float", StringComparison.Ordinal));
+                }
+
+                var objectiveForm = objective?.Form ?? ExpressionForm.Linear;
+                var constraintForm = entities.Select(e => e.Form).DefaultIfEmpty(ExpressionForm.Linear).Max();
+                var form = (ExpressionForm)Math.Max((int)objectiveForm, (int)constraintForm);
+                var @class = form == ExpressionForm.Nonlinear ? ProblemClass.NLP
+                    : constraintForm == ExpressionForm.Quadratic ? ProblemClass.QCP
+                    : objectiveForm == ExpressionForm.Quadratic ? ProblemClass.QP
+                    : ProblemClass.LP;
+                if (integer)
+                    @class++;
+
+                var objectiveConvexity = objective?.Convexity ?? Convexity.Convex;
+                var constraints = entities.Any(e => e.Convexity == Convexity.Nonconvex) ? Convexity.Nonconvex
+                    : entities.Any(e => e.Convexity == Convexity.Unknown) ? Convexity.Unknown
+                    : Convexity.Convex;
+
+                var required = SolverCapabilities.None;
+                if (continuous)
+                    required |= SolverCapabilities.Continuous;
+                if (integer)
+                    required |= SolverCapabilities.Integer;
+                if (logical || (manager?.LogicalConstraints.Count ?? 0) > 0)
+                    required |= SolverCapabilities.Logical;
+                if (form == ExpressionForm.Quadratic)
+                    required |= SolverCapabilities.Quadratic;
+                if (form == ExpressionForm.Nonlinear)
+                    required |= SolverCapabilities.Nonlinear;
+                if (objectiveConvexity == Convexity.Nonconvex || constraints == Convexity.Nonconvex)
+                    required |= SolverCapabilities.Nonconvex;
+
+                var classification = new ModelClassification
+                {
+                    Class = @class,
+                    Objective = objectiveConvexity,
+                    Constraints = constraints,
+                    Required = required,
+                    Solvers = solvers?.Where(s => (required & ~s.Capabilities) == SolverCapabilities.None).Select(s => s.Name).ToList()
+                };
+
+                if (objective != null && objective.Form != ExpressionForm.Linear)
+                    classification.Entities.Add(objective);
+                classification.Entities.AddRange(entities.Where(e => e.Form != ExpressionForm.Linear || e.Convexity != Convexity.Convex));
+                return classification;
+            }
+
+            public bool IsVariable(string name) => variables.ContainsKey(name) || (manager?.IndexedVariables.ContainsKey(name) ?? false);
+
+            public double? ValueOf(string name) => values.TryGetValue(name, out double value) ? value : null;
+
+            public (Form Form, bool Indexed)? ExpressionOf(string name) => expressions.TryGetValue(name, out var expression) ? expression : null;
+
+            private EntityClassification Classify(string key, string body, ObjectiveSense? sense)
+            {
+                try
+                {
+                    var parser = new FormParser(this, body);
+                    if (sense != null)
+                    {
+                        var form = parser.ParseExpression();
+                        var curvature = sense == ObjectiveSense.Minimize ? form.Curvature : Flip(form.Curvature);
+                        string wrong = sense == ObjectiveSense.Minimize ? "concave objective minimized" : "convex objective maximized";
+                        return Entity(key, form.Degree, curvature, wrong, form.Reason);
+                    }
+
+                    var sides = parser.ParseRelation();
+                    if (sides.Count < 2)
+                        return new EntityClassification { Key = key, Form = ExpressionForm.Linear, Convexity = Convexity.Convex };
+
+                    // A chained relation (lo <= f <= hi) is as nonconvex as its worst part
+                    var parts = new List<EntityClassification>();
+                    for (int i = 0; i + 1 < sides.Count; i++)
+                    {
+                        var (left, op) = sides[i];
+                        var difference = Form.Add(left, sides[i + 1].Form, -1);
+                        parts.Add(op switch
+                        {
+                            "<=" or "<" => Entity(key, difference.Degree, difference.Curvature, "left side minus right side is concave", difference.Reason),
+                            ">=" or ">" => Entity(key, difference.Degree, Flip(difference.Curvature), "left side minus right side is convex", difference.Reason),
+                            _ => difference.Degree <= 1
+                                ? Entity(key, difference.Degree, Curvature.Affine, "", null)
+                                : new EntityClassification { Key = key, Form = FormOf(difference.Degree), Convexity = Convexity.Nonconvex, Reason = "nonlinear equality" }
+                        });
+                    }
+                    var worst = parts.OrderBy(p => p.Convexity == Convexity.Nonconvex ? 0 : p.Convexity == Convexity.Unknown ? 1 : 2).First();
+                    return new EntityClassification { Key = key, Form = parts.Max(p => p.Form), Convexity = worst.Convexity, Reason = worst.Reason };
+                }
+                catch (LogicalExpressionException)
+                {
+                    logical = true;
+                    return new EntityClassification { Key = key, Form = ExpressionForm.Linear, Convexity = Convexity.Convex };
+                }
+                catch (FormatException ex)
+                {
+                    return new EntityClassification { Key = key, Form = ExpressionForm.Linear, Convexity = Convexity.Unknown, Reason = $"not analyzed: {ex.Message}" };
+                }
+            }
+
+            private static EntityClassification Entity(string key, int degree, Curvature curvature, string wrong, string? reason)
+            {
+                var (convexity, why) = curvature switch
+                {
+                    Curvature.Affine or Curvature.Convex => (Convexity.Convex, ""),
+                    Curvature.Concave => (Convexity.Nonconvex, wrong),
+                    Curvature.Indefinite => (Convexity.Nonconvex, "indefinite quadratic"),
+                    _ => (Convexity.Unknown, reason ?? "curvature unknown")
+                };
+                return new EntityClassification { Key = key, Form = FormOf(degree), Convexity = convexity, Reason = why };
+            }
+
+            private static ExpressionForm FormOf(int degree) =>
+                degree <= 1 ? ExpressionForm.Linear : degree == 2 ? ExpressionForm.Quadratic : ExpressionForm.Nonlinear;
+
+            private static string StripForall(string code)
+            {
+                int open = code.IndexOf('(');
+                int depth = 0;
+                for (int i = open; open >= 0 && i < code.Length; i++)
+                {
+                    if (code[i] == '(')
+                        depth++;
+                    else if (code[i] == ')' && --depth == 0)
+                        return code.Substring(i + 1).Trim();
+                }
+                return code;
+            }
+        }
+
+        private enum Curvature
+        {
+            Affine,
+            Convex,
+            Concave,
+            Indefinite,
+            Unknown
+        }
+
+        private static Curvature Flip(Curvature curvature) => curvature switch
+        {
+            Curvature.Convex => Curvature.Concave,
+            Curvature.Concave => Curvature.Convex,
+            _ => curvature
+        };
+
+        private static bool IsConvex(Curvature curvature) => curvature is Curvature.Affine or Curvature.Convex;
+        private static bool IsConcave(Curvature curvature) => curvature is Curvature.Affine or Curvature.Concave;
+
+        /// <summary>
+        /// An expression reduced to what classification needs: its polynomial degree, numeric
+        /// coefficients of the linear and quadratic terms where they are known, and a curvature
+        /// from composition rules where they are not
+        /// </summary>
+        private sealed class Form
+        {
+            /// <summary>
+            /// Degree of expressions that are not polynomials (exp, abs, division by a variable)
+            /// </summary>
+            public const int Nonpolynomial = 1000;
+
+            public int Degree { get; init; }
+
+            /// <summary>
+            /// Value of a constant form, null if it is not numeric
+            /// </summary>
+            public double? Value { get; init; }
+
+            /// <summary>
+            /// Coefficients by variable reference of a degree-1 form, null if one is not numeric
+            /// </summary>
+            public Dictionary<string, double>? Linear { get; init; }
+
+            /// <summary>
+            /// Coefficients of the products of a degree-2 form, keyed by ordered reference pairs;
+            /// null if one is not numeric or the references depend on sum iterators
+            /// </summary>
+            public Dictionary<(string, string), double>? Quadratic { get; init; }
+
+            /// <summary>
+            /// Curvature from composition rules, used when there are no numeric quadratic coefficients
+            /// </summary>
+            public Curvature Shape { get; init; }
+
+            /// <summary>
+            /// First construct that left the curvature unknown
+            /// </summary>
+            public string? Reason { get; init; }
+
+            public Curvature Curvature => Degree <= 1 ? Curvature.Affine
+                : Degree == 2 && Quadratic != null ? QuadraticCurvature(Quadratic)
+                : Shape;
+
+            public static Form Constant(double? value) => new Form
+            {
+                Value = value,
+                Linear = new Dictionary<string, double>(),
+                Quadratic = new Dictionary<(string, string), double>()
+            };
+
+            public static Form Variable(string reference) => new Form
+            {
+                Degree = 1,
+                Linear = new Dictionary<string, double> { [reference] = 1 },
+                Quadratic = new Dictionary<(string, string), double>()
+            };
+
+            public static Form Nonlinear(Curvature shape, string? reason, params Form[] parts) => new Form
+            {
+                Degree = Nonpolynomial,
+                Shape = shape,
+                Reason = shape == Curvature.Unknown ? parts.Select(p => p.Reason).FirstOrDefault(r => r != null) ?? reason : null
+            };
+
+            public static Form Add(Form a, Form b, int sign)
+            {
+                var left = a.Curvature;
+                var right = sign > 0 ? b.Curvature : Flip(b.Curvature);
+                var shape = left == Curvature.Affine ? right
+                    : right == Curvature.Affine ? left
+                    : left == right && left is Curvature.Convex or Curvature.Concave ? left
+                    : Curvature.Unknown;
+
+                return new Form
+                {
+                    Degree = Math.Max(a.Degree, b.Degree),
+                    Value = a.Value + sign * b.Value,
+                    Linear = Merge(a.Linear, b.Linear, sign),
+                    Quadratic = Merge(a.Quadratic, b.Quadratic, sign),
+                    Shape = shape,
+                    Reason = a.Reason ?? b.Reason ?? ((left, right) is (Curvature.Convex, Curvature.Concave) or (Curvature.Concave, Curvature.Convex) ? "sum of convex and concave terms" : null)
+                };
+            }
+
+            public static Form Scale(Form form, Form factor)
+            {
+                if (form.Degree == 0)
+                    return Constant(form.Value * factor.Value);
+                if (factor.Value is not { } value)
+                {
+                    bool affine = form.Curvature == Curvature.Affine;
+                    return new Form
+                    {
+                        Degree = form.Degree,
+                        Shape = affine ? Curvature.Affine : Curvature.Unknown,
+                        Reason = form.Reason ?? (affine ? null : "coefficient of unknown sign")
+                    };
+                }
+                if (value == 0)
+                    return Constant(0);
+
+                return new Form
+                {
+                    Degree = form.Degree,
+                    Linear = form.Linear?.ToDictionary(p => p.Key, p => p.Value * value),
+                    Quadratic = form.Quadratic?.ToDictionary(p => p.Key, p => p.Value * value),
+                    Shape = value > 0 ? form.Curvature : Flip(form.Curvature),
+                    Reason = form.Reason
+                };
+            }
+
+            public static Form Multiply(Form a, Form b)
+            {
+                if (a.Degree == 0)
+                    return Scale(b, a);
+                if (b.Degree == 0)
+                    return Scale(a, b);
+
+                int degree = Math.Min(a.Degree + b.Degree, Nonpolynomial);
+                if (degree == 2)
+                {
+                    Dictionary<(string, string), double>? quadratic = null;
+                    if (a.Linear != null && b.Linear != null)
+                    {
+                        quadratic = new Dictionary<(string, string), double>();
+                        foreach (var (u, cu) in a.Linear)
+                        {
+                            foreach (var (v, cv) in b.Linear)
+                            {
+                                var key = string.CompareOrdinal(u, v) <= 0 ? (u, v) : (v, u);
+                                quadratic[key] = quadratic.GetValueOrDefault(key) + cu * cv;
+                            }
+                        }
+                    }
+                    return new Form { Degree = 2, Quadratic = quadratic, Shape = Curvature.Unknown, Reason = quadratic == null ? "product of symbolic terms" : null };
+                }
+
+                return new Form
+                {
+                    Degree = degree,
+                    Shape = Curvature.Unknown,
+                    Reason = a.Reason ?? b.Reason ?? (degree < Nonpolynomial ? $"degree-{degree} product" : "product of nonlinear terms")
+                };
+            }
+
+            public static Form Divide(Form a, Form b)
+            {
+                if (b.Degree > 0)
+                    return Nonlinear(Curvature.Unknown, "division by a variable expression", a, b);
+                return Scale(a, Constant(b.Value is { } value && value != 0 ? 1 / value : null));
+            }
+
+            public static Form Power(Form a, Form b)
+            {
+                if (b.Degree > 0 || b.Value is not { } k)
+                    return Nonlinear(Curvature.Unknown, "variable exponent", a, b);
+                if (a.Degree == 0)
+                    return Constant(a.Value is { } value ? Math.Pow(value, k) : null);
+                if (k == 0)
+                    return Constant(1);
+                if (k == 1)
+                    return a;
+
+                bool affine = a.Curvature == Curvature.Affine;
+                bool even = k > 0 && k % 2 == 0;
+                if (k == 2)
+                {
+                    var square = Multiply(a, a);
+                    return affine && square.Quadratic == null ? new Form { Degree = square.Degree, Shape = Curvature.Convex } : square;
+                }
+                if (k > 0 && k == Math.Floor(k))
+                {
+                    return new Form
+                    {
+                        Degree = (int)Math.Min(a.Degree * k, Nonpolynomial),
+                        Shape = affine && even ? Curvature.Convex : Curvature.Unknown,
+                        Reason = affine && even ? null : a.Reason ?? $"power {k.ToString(CultureInfo.InvariantCulture)}"
+                    };
+                }
+                return Nonlinear(Curvature.Unknown, $"power {k.ToString(CultureInfo.InvariantCulture)}", a);
+            }
+
+            /// <summary>
+            /// The form of a sum over iterators, or of an indexed decision expression: the
+            /// references depend on the iterators, so only degree and curvature carry over
+            /// </summary>
+            public Form Generalize() => Degree == 0 ? Constant(null) : new Form
+            {
+                Degree = Degree,
+                Shape = Curvature == Curvature.Indefinite ? Curvature.Unknown : Curvature,
+                Reason = Reason ?? (Curvature == Curvature.Indefinite ? "indefinite quadratic terms summed over iterators" : null)
+            };
+
+            private static Dictionary<TKey, double>? Merge<TKey>(Dictionary<TKey, double>? a, Dictionary<TKey, double>? b, int sign) where TKey : notnull
+            {
+                if (a == null || b == null)
+                    return null;
+                var merged = new Dictionary<TKey, double>(a);
+                foreach (var (key, value) in b)
+                    merged[key] = merged.GetValueOrDefault(key) + sign * value;
+                return merged;
+            }
+        }
+
+        /// <summary>
+        /// Convex if the symmetric matrix of the form is positive semidefinite, concave if negative
+        /// semidefinite. Indefinite forms are only reported as such when no two references to the
+        /// same variable have symbolic indices, which might denote the same element.
+        /// </summary>
+        private static Curvature QuadraticCurvature(Dictionary<(string, string), double> quadratic)
+        {
+            var terms = quadratic.Where(p => p.Value != 0).ToList();
+            var references = terms.SelectMany(p => new[] { p.Key.Item1, p.Key.Item2 }).Distinct().OrderBy(r => r, StringComparer.Ordinal).ToList();
+            if (references.Count == 0)
+                return Curvature.Affine;
+            if (references.Count > MaxQuadraticSize)
+                return Curvature.Unknown;
+
+            int n = references.Count;
+            var index = references.Select((r, i) => (r, i)).ToDictionary(p => p.r, p => p.i, StringComparer.Ordinal);
+            var matrix = new double[n, n];
+            foreach (var ((u, v), value) in terms)
+            {
+                int i = index[u], j = index[v];
+                if (i == j)
+                {
+                    matrix[i, i] += value;
+                }
+                else
+                {
+                    matrix[i, j] += value / 2;
+                    matrix[j, i] += value / 2;
+                }
+            }
+
+            var eigenvalues = Eigenvalues(matrix);
+            double tolerance = 1e-9 * Math.Max(1, eigenvalues.Max(Math.Abs));
+            if (eigenvalues.All(e => e >= -tolerance))
+                return Curvature.Convex;
+            if (eigenvalues.All(e => e <= tolerance))
+                return Curvature.Concave;
+
+            static string BaseName(string reference) => reference.Contains('[') ? reference.Substring(0, reference.IndexOf('[')) : reference;
+            bool aliased = references
+                .Where(r => r.Contains('[') && !constantIndexPattern.IsMatch(r.Substring(r.IndexOf('['))))
+                .Any(r => references.Count(other => BaseName(other) == BaseName(r)) > 1);
+            return aliased ? Curvature.Unknown : Curvature.Indefinite;
+        }
+
+        /// <summary>
+        /// Eigenvalues of a symmetric matrix by cyclic Jacobi rotations
+        /// </summary>
+        private static double[] Eigenvalues(double[,] a)
+        {
+            int n = a.GetLength(0);
+            for (int sweep = 0; sweep < 100; sweep++)
+            {
+                double off = 0;
+                for (int p = 0; p < n; p++)
+                    for (int q = p + 1; q < n; q++)
+                        off += a[p, q] * a[p, q];
+                if (off < 1e-22)
+                    break;
+
+                for (int p = 0; p < n; p++)
+                {
+                    for (int q = p + 1; q < n; q++)
+                    {
+                        if (Math.Abs(a[p, q]) < 1e-300)
+                            continue;
+                        double theta = (a[q, q] - a[p, p]) / (2 * a[p, q]);
+                        double t = Math.Sign(theta == 0 ? 1 : theta) / (Math.Abs(theta) + Math.Sqrt(theta * theta + 1));
+                        double c = 1 / Math.Sqrt(t * t + 1), s = t * c;
+                        for (int k = 0; k < n; k++)
+                        {
+                            double akp = a[k, p], akq = a[k, q];
+                            a[k, p] = c * akp - s * akq;
+                            a[k, q] = s * akp + c * akq;
+                        }
+                        for (int k = 0; k < n; k++)
+                        {
+                            double apk = a[p, k], aqk = a[q, k];
+                            a[p, k] = c * apk - s * aqk;
+                            a[q, k] = s * apk + c * aqk;
+                        }
+                    }
+                }
+            }
+            return Enumerable.Range(0, n).Select(i => a[i, i]).ToArray();
+        }
+
+        private class LogicalExpressionException : FormatException
+        {
+            public LogicalExpressionException() : base("logical expression")
+            {
+            }
+        }
+
+        /// <summary>
+        /// Recursive descent over an expression or relation of the model language, producing Forms
+        /// </summary>
+        private class FormParser
+        {
+            private readonly Analysis analysis;
+            private readonly List<string> tokens = new List<string>();
+            private readonly HashSet<string> iterators = new HashSet<string>(StringComparer.Ordinal);
+            private int position;
+
+            public FormParser(Analysis analysis, string text)
+            {
+                this.analysis = analysis;
+                int at = 0;
+                while (at < text.Length)
+                {
+                    var match = tokenPattern.Match(text, at);
+                    if (!match.Success || match.Length == 0)
+                    {
+                        if (string.IsNullOrWhiteSpace(text.Substring(at)))
+                            break;
+                        throw new FormatException($"unexpected '{text.Substring(at).Trim()[0]}'");
+                    }
+                    tokens.Add(match.Value.Trim());
+                    at += match.Length;
+                }
+            }
+
+            private string? Peek => position < tokens.Count ? tokens[position] : null;
+
+            public Form ParseExpression()
+            {
+                var form = Expression();
+                End();
+                return form;
+            }
+
+            /// <summary>
+            /// The sides of a (possibly chained) relation, each with the operator that follows it
+            /// </summary>
+            public List<(Form Form, string Op)> ParseRelation()
+            {
+                var sides = new List<(Form, string)>();
+                var form = Expression();
+                while (Peek is "<=" or ">=" or "==" or "<" or ">")
+                {
+                    sides.Add((form, tokens[position++]));
+                    form = Expression();
+                }
+                sides.Add((form, ""));
+                End();
+                return sides;
+            }
+
+            private void End()
+            {
+                if (Peek is "&&" or "||" or "=>" or "!=" or "!" or "?")
+                    throw new LogicalExpressionException();
+                if (Peek != null)
+                    throw new FormatException($"unexpected '{Peek}'");
+            }
+
+            private Form Expression()
+            {
+                var form = Term();
+                while (Peek is "+" or "-")
+                {
+                    int sign = tokens[position++] == "+" ? 1 : -1;
+                    form = Form.Add(form, Term(), sign);
+                }
+                return form;
+            }
+
+            private Form Term()
+            {
+                var form = Unary();
+                while (Peek is "*" or "/")
+                {
+                    bool multiply = tokens[position++] == "*";
+                    var right = Unary();
+                    form = multiply ? Form.Multiply(form, right) : Form.Divide(form, right);
+                }
+                return form;
+            }
+
+            private Form Unary()
+            {
+                if (Peek == "-")
+                {
+                    position++;
+                    return Form.Scale(Unary(), Form.Constant(-1));
+                }
+                if (Peek == "+")
+                {
+                    position++;
+                    return Unary();
+                }
+                if (Peek == "!")
+                    throw new LogicalExpressionException();
+
+                var form = Primary();
+                if (Peek == "^")
+                {
+                    position++;
+                    form = Form.Power(form, Unary());
+                }
+                return form;
+            }
+
+            private Form Primary()
+            {
+                string token = Peek ?? throw new FormatException("unexpected end of expression");
+                position++;
+
+                if (token == "(")
+                {
+                    var inner = Expression();
+                    Expect(")");
+                    return inner;
+                }
+                if (char.IsDigit(token[0]) || token[0] == '.')
+                    return Form.Constant(double.Parse(token, CultureInfo.InvariantCulture));
+                if (!char.IsLetter(token[0]) && token[0] != '_')
+                    throw new FormatException($"unexpected '{token}'");
+
+                if (Peek == "(")
+                    return token is "sum" ? Sum() : Function(token.ToLowerInvariant());
+
+                string reference = token + Index();
+                if (iterators.Contains(token))
+                    return Form.Constant(null);
+                if (analysis.IsVariable(token))
+                    return Form.Variable(reference);
+                if (analysis.ExpressionOf(token) is { } expression)
+                    return expression.Indexed ? expression.Form.Generalize() : expression.Form;
+                return Form.Constant(reference == token ? analysis.ValueOf(token) : null);
+            }
+
+            private string Index()
+            {
+                string index = "";
+                while (Peek == "[")
+                {
+                    int depth = 0;
+                    do
+                    {
+                        string token = tokens[position++];
+                        depth += token == "[" ? 1 : token == "]" ? -1 : 0;
+                        index += token;
+                    }
+                    while (depth > 0 && position < tokens.Count);
+                }
+                return index;
+            }
+
+            private Form Sum()
+            {
+                // Iterator names ("i in I, j in J: i < j") are constants in the body
+                position++;
+                int depth = 1;
+                string? previous = null;
+                while (depth > 0)
+                {
+                    string token = Peek ?? throw new FormatException("unclosed sum");
+                    position++;
+                    depth += token == "(" ? 1 : token == ")" ? -1 : 0;
+                    if (token == "in" && previous != null)
+                        iterators.Add(previous);
+                    previous = token;
+                }
+                return Term().Generalize();
+            }
+
+            private Form Function(string name)
+            {
+                position++;
+                var arguments = new List<Form>();
+                if (Peek != ")")
+                {
+                    arguments.Add(Expression());
+                    while (Peek == ",")
+                    {
+                        position++;
+                        arguments.Add(Expression());
+                    }
+                }
+                Expect(")");
+
+                if (arguments.All(a => a.Degree == 0))
+                    return Form.Constant(null);
+                var curvatures = arguments.Select(a => a.Curvature).ToList();
+                var parts = arguments.ToArray();
+                return name switch
+                {
+                    "pow" when arguments.Count == 2 => Form.Power(arguments[0], arguments[1]),
+                    "abs" => Form.Nonlinear(curvatures[0] == Curvature.Affine ? Curvature.Convex : Curvature.Unknown, "abs of a nonlinear expression", parts),
+                    "max" or "maxl" => Form.Nonlinear(curvatures.All(IsConvex) ? Curvature.Convex : Curvature.Unknown, "max of nonconvex arguments", parts),
+                    "min" or "minl" => Form.Nonlinear(curvatures.All(IsConcave) ? Curvature.Concave : Curvature.Unknown, "min of nonconcave arguments", parts),
+                    "exp" => Form.Nonlinear(IsConvex(curvatures[0]) ? Curvature.Convex : Curvature.Unknown, "exp of a nonconvex argument", parts),
+                    "log" or "ln" or "sqrt" => Form.Nonlinear(IsConcave(curvatures[0]) ? Curvature.Concave : Curvature.Unknown, $"{name} of a nonconcave argument", parts),
+                    _ => Form.Nonlinear(Curvature.Unknown, name, parts)
+                };
+            }
+
+            private void Expect(string token)
+            {
+                if (Peek != token)
+                    throw new FormatException($"expected '{token}'");
+                position++;
+            }
+        }
+    }
+}
@@ -0,0 +1,330 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+
+namespace Core
+{
+    public enum OverlayEntryKind
+    {
+        /// <summary>
+        /// A parameter value in data file syntax ("cost = [1, 2];", "cost[2] = 5;")
+        /// </summary>
+        Parameter,
+
+        /// <summary>
+        /// Lower bound of a variable family ("x.lb = 0;")
+        /// </summary>
+        LowerBound,
+
+        /// <summary>
+        /// Upper bound of a variable family ("x.ub = 100;")
+        /// </summary>
+        UpperBound,
+
+        /// <summary>
+        /// Members of a set kept for the case ("select Scenarios = {"high"};")
+        /// </summary>
+        Selection
+    }
+
+    /// <summary>
+    /// One statement of an overlay
+    /// </summary>
+    public class OverlayEntry
+    {
+        public OverlayEntryKind Kind { get; init; }
+
+        /// <summary>
+        /// Name of the parameter, variable or set the entry changes
+        /// </summary>
+        public string Target { get; init; } = "";
+
+        /// <summary>
+        /// Statement text without the trailing ';'
+        /// </summary>
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// Value of a bound, or the members of a selection as written
+        /// </summary>
+        public string Value { get; init; } = "";
+
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// What the entry sets: "cost", "cost[2]", "x.ub" or "select Scenarios"; an overlay sets each key once
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        public override string ToString() => $"line {LineNumber}: {Key}";
+    }
+
+    /// <summary>
+    /// A case applied on top of a base model and its data at load time: parameter values, bounds
+    /// overrides and scenario selections, and nothing that changes the formulation. One model can
+    /// then be run for many cases, each a small file next to it:
+    /// <code>
+    /// demand = [30, 40, 50];                 // parameter values, as in a data file
+    /// x.ub = 100;                            // bounds of a variable family
+    /// select Scenarios = {"high", "peak"};   // keep only these members of a set
+    /// </code>
+    /// Every key must resolve against the loaded model; an overlay with unresolved keys is not applied.
+    /// </summary>
+    public class CaseOverlay
+    {
+        public const string Extension = ".case";
+
+        private static readonly Regex boundPattern = new Regex(@"^(?<name>[A-Za-z_]\w*)\.(?<bound>lb|ub)\s*=\s*(?<value>.+)$", RegexOptions.Singleline);
+        private static readonly Regex selectionPattern = new Regex(@"^select\s+(?<name>[A-Za-z_]\w*)\s*=\s*\{(?<value>.*)\}$", RegexOptions.Singleline);
+        private static readonly Regex assignmentPattern = new Regex(@"^(?<key>(?<name>[A-Za-z_]\w*)\s*(?:\[[^\]]*\]\s*)*)=", RegexOptions.Singleline);
+
+        public string Name { get; init; } = "";
+
+        public List<OverlayEntry> Entries { get; } = new List<OverlayEntry>();
+
+        /// <summary>
+        /// Statements that are none of the overlay forms
+        /// </summary>
+        public List<string> SyntaxErrors { get; } = new List<string>();
+
+        public static CaseOverlay Load(string path) =>
+            Parse(File.ReadAllText(path), Path.GetFileNameWithoutExtension(path));
+
+        public static CaseOverlay Parse(string text, string name = "case")
+        {
+            var overlay = new CaseOverlay { Name = name };
+            foreach (var (statement, line) in SplitStatements(text))
+            {
+                Match match;
+                if ((match = boundPattern.Match(statement)).Success)
+                {
+                    string bound = match.Groups["bound"].Value;
+                    overlay.Entries.Add(new OverlayEntry
+                    {
+                        Kind = bound == "lb" ? OverlayEntryKind.LowerBound : OverlayEntryKind.UpperBound,
+                        Target = match.Groups["name"].Value,
+                        Text = statement,
+                        Value = match.Groups["value"].Value.Trim(),
+                        LineNumber = line,
+                        Key = $"{match.Groups["name"].Value}.{bound}"
+                    });
+                }
+                else if ((match = selectionPattern.Match(statement)).Success)
+                {
+                    overlay.Entries.Add(new OverlayEntry
+                    {
+                        Kind = OverlayEntryKind.Selection,
+                        Target = match.Groups["name"].Value,
+                        Text = statement,
+                        Value = match.Groups["value"].Value.Trim(),
+                        LineNumber = line,
+                        Key = "select " + match.Groups["name"].Value
+                    });
+                }
+                else if ((match = assignmentPattern.Match(statement)).Success)
+                {
+                    overlay.Entries.Add(new OverlayEntry
+                    {
+                        Kind = OverlayEntryKind.Parameter,
+                        Target = match.Groups["name"].Value,
+                        Text = statement,
+                        LineNumber = line,
+                        Key = Regex.Replace(match.Groups["key"].Value, @"\s+", "")
+                    });
+                }
+                else
+                {
+                    overlay.SyntaxErrors.Add($"Line {line}: '{statement}' is not a parameter value, bound or selection");
+                }
+            }
+            return overlay;
+        }
+
+        /// <summary>
+        /// Checks that every key of the overlay resolves in the model: parameters, variable
+        /// families and set members must be declared, and bounds must be numbers. Returns one
+        /// message per problem, prefixed with the overlay name and line.
+        /// </summary>
+        public List<string> Validate(ModelManager manager)
+        {
+            var errors = SyntaxErrors.Select(e => $"Case '{Name}': {e}").ToList();
+            void Error(OverlayEntry entry, string message) => errors.Add($"Case '{Name}' line {entry.LineNumber}: {message}");
+
+            foreach (var duplicate in Entries.GroupBy(e => e.Key).Where(g => g.Count() > 1))
+                Error(duplicate.Last(), $"'{duplicate.Key}' is already set on line {duplicate.First().LineNumber}");
+
+            foreach (var entry in Entries)
+            {
+                switch (entry.Kind)
+                {
+                    case OverlayEntryKind.Parameter:
+                        if (manager.Parameters.TryGetValue(entry.Target, out var parameter) && parameter.IsComputed)
+                            Error(entry, $"parameter '{entry.Target}' is computed by the model and cannot be given a value");
+                        else if (parameter == null && !manager.TupleParameters.ContainsKey(entry.Target))
+                            Error(entry, Unresolved(manager, entry.Target, "a parameter", SymbolKind.Parameter) +
+                                (manager.PrimitiveSets.ContainsKey(entry.Target) ? $"; use 'select {entry.Target} = {{...}}' to choose members of a set" : ""));
+                        break;
+
+                    case OverlayEntryKind.LowerBound:
+                    case OverlayEntryKind.UpperBound:
+                        if (!manager.IndexedVariables.ContainsKey(entry.Target))
+                            Error(entry, Unresolved(manager, entry.Target, "a variable", SymbolKind.Variable));
+                        else if (ParseBound(entry.Value) == null)
+                            Error(entry, $"bound of '{entry.Target}' must be a number, not '{entry.Value}'");
+                        break;
+
+                    case OverlayEntryKind.Selection:
+                        if (!manager.PrimitiveSets.TryGetValue(entry.Target, out var set))
+                        {
+                            Error(entry, Unresolved(manager, entry.Target, "a set of values", SymbolKind.Set));
+                            break;
+                        }
+                        var members = SplitMembers(entry.Value);
+                        if (members.Count == 0)
+                            Error(entry, $"selection of '{entry.Target}' is empty");
+                        foreach (string member in members.Where(m => Find(set, m) == null))
+                            Error(entry, $"'{member.Trim('"')}' is not a member of set '{entry.Target}'");
+                        break;
+                }
+            }
+
+            // Bounds of a variable are checked together, against the declaration for the one not given
+            foreach (var variable in Entries.Where(e => e.Kind != OverlayEntryKind.Parameter && e.Kind != OverlayEntryKind.Selection)
+                         .GroupBy(e => e.Target)
+                         .Where(g => manager.IndexedVariables.ContainsKey(g.Key)))
+            {
+                var declared = manager.IndexedVariables[variable.Key];
+                double? lower = variable.Where(e => e.Kind == OverlayEntryKind.LowerBound).Select(e => ParseBound(e.Value)).FirstOrDefault() ?? declared.LowerBound;
+                double? upper = variable.Where(e => e.Kind == OverlayEntryKind.UpperBound).Select(e => ParseBound(e.Value)).FirstOrDefault() ?? declared.UpperBound;
+                if (lower > upper)
+                    Error(variable.First(), $"bounds of '{variable.Key}' are empty: {lower.Value.ToString(CultureInfo.InvariantCulture)}..{upper.Value.ToString(CultureInfo.InvariantCulture)}");
+            }
+
+            return errors;
+        }
+
+        /// <summary>
+        /// Validates the overlay and, if every key resolves, applies it to a model whose data is
+        /// loaded: parameter statements go through the data parser, scalars first, bounds replace
+        /// those of the declaration and selections remove the other members of their sets. Nothing
+        /// is applied if validation fails.
+        /// </summary>
+        public ParseSessionResult Apply(ModelManager manager, DataFileParser dataParser)
+        {
+            var result = new ParseSessionResult();
+            var errors = Validate(manager);
+            if (errors.Count > 0)
+            {
+                foreach (string error in errors)
+                    result.AddError(error, 0, Name);
+                return result;
+            }
+
+            // Scalars go first, so ranges sized by them ("range T = 1..nT;") follow the case before indexed values are read
+            var scalars = Entries.Where(e => e.Kind == OverlayEntryKind.Parameter && manager.Parameters.TryGetValue(e.Target, out var p) && p.IsScalar).ToList();
+            foreach (var entry in scalars.Concat(Entries.Except(scalars)))
+            {
+                switch (entry.Kind)
+                {
+                    case OverlayEntryKind.Parameter:
+                        var dataResult = dataParser.Parse(entry.Text + ";");
+                        foreach (var error in dataResult.Errors)
+                            result.AddError($"Case '{Name}' line {entry.LineNumber}: {error.Message}", entry.LineNumber, Name);
+                        if (!dataResult.HasErrors)
+                            result.IncrementSuccess();
+                        break;
+
+                    // The case's bound replaces a bound expression of the declaration for every element
+                    case OverlayEntryKind.LowerBound:
+                        manager.IndexedVariables[entry.Target].LowerBound = ParseBound(entry.Value);
+                        manager.IndexedVariables[entry.Target].LowerBoundExpression = null;
+                        result.IncrementSuccess();
+                        break;
+
+                    case OverlayEntryKind.UpperBound:
+                        manager.IndexedVariables[entry.Target].UpperBound = ParseBound(entry.Value);
+                        manager.IndexedVariables[entry.Target].UpperBoundExpression = null;
+                        result.IncrementSuccess();
+                        break;
+
+                    case OverlayEntryKind.Selection:
+                        var set = manager.PrimitiveSets[entry.Target];
+                        var selected = SplitMembers(entry.Value).Select(m => Find(set, m)!).ToList();
+                        var kept = set.GetAllValues().Where(selected.Contains).ToList();
+                        set.Clear();
+                        foreach (var value in kept)
+                            set.Add(value);
+                        result.IncrementSuccess();
+                        break;
+                }
+
+                if (entry == scalars.LastOrDefault())
+                    manager.ReevaluateRanges();
+            }
+            return result;
+        }
+
+        private static string Unresolved(ModelManager manager, string name, string what, SymbolKind kind)
+        {
+            string message = $"'{name}' is not {what} of the model";
+            var suggestion = SymbolSuggester.Suggest(manager, name, kind);
+            return suggestion == null ? message : $"{message}; {suggestion.Hint}";
+        }
+
+        private static double? ParseBound(string value) => value switch
+        {
+            "infinity" => double.PositiveInfinity,
+            "-infinity" => double.NegativeInfinity,
+            _ => double.TryParse(value, NumberStyles.Float, CultureInfo.InvariantCulture, out double number) ? number : null
+        };
+
+        private static List<string> SplitMembers(string value) =>
+            value.Split(',', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries).ToList();
+
+        /// <summary>
+        /// The member of the set written as the literal, or null
+        /// </summary>
+        private static object? Find(PrimitiveSet set, string literal)
+        {
+            string text = literal.Trim('"');
+            return set.GetAllValues().FirstOrDefault(v => Convert.ToString(v, CultureInfo.InvariantCulture) == text);
+        }
+
+        /// <summary>
+        /// Statements without comments, with the line each starts on
+        /// </summary>
+        private static IEnumerable<(string Statement, int Line)> SplitStatements(string text)
+        {
+            var lines = text.Replace("\r\n", "\n").Split('\n');
+            string current = "";
+            int start = 0;
+            for (int i = 0; i < lines.Length; i++)
+            {
+                string line = lines[i];
+                int comment = line.IndexOf("//", StringComparison.Ordinal);
+                if (comment >= 0)
+                    line = line.Substring(0, comment);
+
+                foreach (char c in line)
+                {
+                    if (c == ';')
+                    {
+                        if (current.Trim().Length > 0)
+                            yield return (current.Trim(), start);
+                        current = "";
+                    }
+                    else
+                    {
+                        if (current.Trim().Length == 0 && !char.IsWhiteSpace(c))
+                            start = i + 1;
+                        current += c;
+                    }
+                }
+                current += " ";
+            }
+            if (current.Trim().Length > 0)
+                yield return (current.Trim(), start);
+        }
+    }
+}
@@ -1,6 +1,7 @@
 using System.Globalization;
 using System.Text.RegularExpressions;
 using Core.Models;
+using Core.Parsing;
 
 namespace Core
 {
@@ -9,6 +10,11 @@ namespace Core
     /// </summary>
     public class DataFileParser
     {
+        /// <summary>
+        /// A line starting an assignment ("name =", "name[i] =")
+        /// </summary>
+        private static readonly Regex assignmentStartPattern = new Regex(@"^[a-zA-Z_]\w*(\[[^\]]*\])*\s*=(?!=)");
+
         private readonly ModelManager modelManager;
 
         public DataFileParser(ModelManager manager)
@@ -16,6 +22,11 @@ namespace Core
             modelManager = manager;
         }
 
+        /// <summary>
+        /// Number and date format of the data, with its per-column overrides
+        /// </summary>
+        public DataLocale Locale { get; set; } = DataLocale.Invariant;
+
         /// <summary>
         /// Parses the provided text and returns the result of the parsing session.
         /// </summary>
@@ -31,20 +42,28 @@ namespace Core
                 return result;
             }
 
+            if (!EquationParser.CheckLimit(() => modelManager.Limits.CheckFileSize(text, "Data text"), 0, result))
+                return result;
+
             // **Remove block comments FIRST**
             text = RemoveBlockComments(text);
 
-            var statements = BuildStatements(text);
+            var statements = BuildStatements(text, result);
 
             // Process each statement
             foreach (var (statement, lineNum) in statements)
             {
                 if (!string.IsNullOrWhiteSpace(statement))
                 {
-                    ProcessStatement(statement, lineNum, result);
+                    int firstError = result.Errors.Count;
+                    modelManager.PendingSuggestions.Clear();
+                    if (EquationParser.CheckLimit(() => modelManager.Limits.CheckNesting(statement), lineNum, result))
+                        ProcessStatement(statement, lineNum, result);
+                    result.AddSuggestions(modelManager.PendingSuggestions, firstError, lineNum);
                 }
             }
 
+            EquationParser.CheckLimit(() => modelManager.Limits.CheckMemory(modelManager), 0, result);
             return result;
         }
 
@@ -66,7 +85,7 @@ namespace Core
             }
         }
 
-        private List<(string content, int lineNumber)> BuildStatements(string text)
+        private List<(string content, int lineNumber)> BuildStatements(string text, ParseSessionResult? result = null)
         {
             string[] lines = text.Split(new[] { '\r', '\n' }, StringSplitOptions.RemoveEmptyEntries);
             var processedLines = new List<(string content, int lineNumber)>();
@@ -82,8 +101,19 @@ namespace Core
             var statements = new List<(string content, int lineNumber)>();
             string current = "";
             int startLine = 0;
+            int previousLine = 0;
             foreach (var (content, lineNum) in processedLines)
             {
+                // Error recovery: an assignment cannot continue with another one, so a missing ';'
+                // ends the statement at the line break instead of merging the two
+                if (current.Contains('=') && assignmentStartPattern.IsMatch(content))
+                {
+                    result?.AddError("Missing ';' at end of line", previousLine);
+                    statements.Add((current.Trim(), startLine));
+                    current = "";
+                }
+                previousLine = lineNum;
+
                 if (string.IsNullOrEmpty(current)) startLine = lineNum;
                 current += " " + content;
                 if (content.Contains(';'))
@@ -95,6 +125,12 @@ namespace Core
                     current = "";
                 }
             }
+
+            if (!string.IsNullOrWhiteSpace(current))
+            {
+                result?.AddError("Missing ';' at end of line", previousLine);
+                statements.Add((current.Trim(), startLine));
+            }
             return statements;
         }
 
@@ -241,7 +277,7 @@ namespace Core
             // Check if parameter exists
             if (!modelManager.Parameters.TryGetValue(paramName, out var param))
             {
-                error = $"Parameter '{paramName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Parameter '{paramName}' is not declared", paramName, SymbolKind.Parameter);
                 return false;
             }
 
@@ -273,7 +309,7 @@ namespace Core
                 return false;
             }
 
-            var values = ParseValueList(valuesStr, param.Type, out error);
+            var values = ParseValueList(valuesStr, param.Type, Locale.For(param.Name), out error);
             if (values == null) return false;
 
             var indices = ResolveIndices(param.IndexSetName!, out error);
@@ -308,7 +344,7 @@ namespace Core
                 int end = range.GetEnd(modelManager);
                 return Enumerable.Range(start, Math.Max(0, end - start + 1)).ToList();
             }
-            error = $"Index set '{name}' not found";
+            error = SymbolSuggester.Annotate(modelManager, $"Index set '{name}' not found", name, SymbolKind.Set | SymbolKind.TupleSet);
             return null;
         }
 
@@ -344,7 +380,7 @@ namespace Core
             // Validate and assign each row
             for (int i = 0; i < rows.Count; i++)
             {
-                var rowValues = ParseValueList(rows[i], param.Type, out error);
+                var rowValues = ParseValueList(rows[i], param.Type, Locale.For(param.Name), out error);
                 if (rowValues == null)
                 {
                     error = $"Error in row {i + 1}: {error}";
@@ -403,7 +439,7 @@ namespace Core
 
                 for (int j = 0; j < rows.Count; j++)
                 {
-                    var cellValues = ParseValueList(rows[j], param.Type, out error);
+                    var cellValues = ParseValueList(rows[j], param.Type, Locale.For(param.Name), out error);
                     if (cellValues == null)
                     {
                         error = $"Slice {i + 1}, row {j + 1}: {error}";
@@ -498,13 +534,13 @@ namespace Core
             return rows;
         }
 
-        private List<object>? ParseValueList(string valuesStr, ParameterType paramType, out string error)
+        private List<object>? ParseValueList(string valuesStr, ParameterType paramType, DataLocale locale, out string error)
         {
             error = string.Empty;
             var values = new List<object>();
 
             // Split by commas or whitespace, handling quoted strings
-            var parts = SplitByCommaOrWhitespace(valuesStr);
+            var parts = SplitByCommaOrWhitespace(valuesStr, locale);
 
             foreach (var part in parts)
             {
@@ -512,7 +548,7 @@ namespace Core
                 if (string.IsNullOrEmpty(trimmed))
                     continue;
 
-                object? value = ParseValueForType(trimmed, paramType, out error);
+                object? value = ParseValueForType(trimmed, paramType, locale, out error);
                 if (!string.IsNullOrEmpty(error))
                 {
                     return null;
@@ -528,7 +564,7 @@ namespace Core
         /// Splits a tuple instance body (inside &lt;...&gt;) into individual field values.
         /// Handles quoted strings, comma-separated, or space-separated fields.
         /// </summary>
-        private string[] SplitTupleFields(string input)
+        private string[] SplitTupleFields(string input, DataLocale locale)
         {
             var fields = new List<string>();
             var current = new System.Text.StringBuilder();
@@ -542,7 +578,7 @@ namespace Core
                     inQuotes = !inQuotes;
                     current.Append(c);
                 }
-                else if (!inQuotes && (c == ',' || c == ' ' || c == '\t'))
+                else if (!inQuotes && (c == ',' && SeparatesValues(input, i, locale) || c == ' ' || c == '\t'))
                 {
                     string token = current.ToString().Trim();
                     if (token.Length > 0)
@@ -561,7 +597,13 @@ namespace Core
             return fields.ToArray();
         }
 
-        private List<string> SplitByCommaOrWhitespace(string input)
+        /// <summary>
+        /// A comma separates values unless the locale writes decimal commas and a digit follows it
+        /// </summary>
+        private static bool SeparatesValues(string input, int index, DataLocale locale) =>
+            !locale.DecimalComma || index + 1 == input.Length || !char.IsDigit(input[index + 1]);
+
+        private List<string> SplitByCommaOrWhitespace(string input, DataLocale locale)
         {
             var result = new List<string>();
             var current = new System.Text.StringBuilder();
@@ -575,7 +617,7 @@ namespace Core
                 {
                     inQuotes = !inQuotes;
                 }
-                else if (input[i] == ',' && !inQuotes)
+                else if (input[i] == ',' && !inQuotes && SeparatesValues(input, i, locale))
                 {
                     hasComma = true;
                     break;
@@ -595,7 +637,7 @@ namespace Core
                         inQuotes = !inQuotes;
                         current.Append(c);
                     }
-                    else if (c == ',' && !inQuotes)
+                    else if (c == ',' && !inQuotes && SeparatesValues(input, i, locale))
                     {
                         result.Add(current.ToString());
                         current.Clear();
@@ -651,7 +693,7 @@ namespace Core
 
             if (!modelManager.Parameters.TryGetValue(paramName, out var param))
             {
-                error = $"Parameter '{paramName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Parameter '{paramName}' is not declared", paramName, SymbolKind.Parameter);
                 return false;
             }
 
@@ -667,7 +709,7 @@ namespace Core
                 int idx = dim == 0 ? index1 : dim == 1 ? index2 : index3;
                 if (!modelManager.IndexSets.TryGetValue(indexSetNames[dim], out var indexSet))
                 {
-                    error = $"Index set '{indexSetNames[dim]}' not found";
+                    error = SymbolSuggester.Annotate(modelManager, $"Index set '{indexSetNames[dim]}' not found", indexSetNames[dim], SymbolKind.Set);
                     return false;
                 }
                 if (!indexSet.Contains(idx))
@@ -677,7 +719,7 @@ namespace Core
                 }
             }
 
-            object? value = ParseValueForType(valueStr, param.Type, out error);
+            object? value = ParseValueForType(valueStr, param.Type, Locale.For(paramName), out error);
             if (!string.IsNullOrEmpty(error))
                 return false;
 
@@ -712,7 +754,7 @@ namespace Core
 
             if (!modelManager.Parameters.TryGetValue(paramName, out var param))
             {
-                error = $"Parameter '{paramName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Parameter '{paramName}' is not declared", paramName, SymbolKind.Parameter);
                 return false;
             }
 
@@ -739,7 +781,7 @@ namespace Core
             }
 
             // Parse and set value
-            object? value = ParseValueForType(valueStr, param.Type, out error);
+            object? value = ParseValueForType(valueStr, param.Type, Locale.For(paramName), out error);
             if (!string.IsNullOrEmpty(error))
                 return false;
 
@@ -773,7 +815,7 @@ namespace Core
 
             if (!modelManager.Parameters.TryGetValue(paramName, out var param))
             {
-                error = $"Parameter '{paramName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Parameter '{paramName}' is not declared", paramName, SymbolKind.Parameter);
                 return false;
             }
 
@@ -798,7 +840,7 @@ namespace Core
             }
 
             // Parse and set value
-            object? value = ParseValueForType(valueStr, param.Type, out error);
+            object? value = ParseValueForType(valueStr, param.Type, Locale.For(paramName), out error);
             if (!string.IsNullOrEmpty(error))
                 return false;
 
@@ -833,7 +875,7 @@ namespace Core
 
             if (!modelManager.Parameters.TryGetValue(paramName, out var param))
             {
-                error = $"Parameter '{paramName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Parameter '{paramName}' is not declared", paramName, SymbolKind.Parameter);
                 return false;
             }
 
@@ -862,7 +904,7 @@ namespace Core
             }
 
             // Parse and set value
-            object? value = ParseValueForType(valueStr, param.Type, out error);
+            object? value = ParseValueForType(valueStr, param.Type, Locale.For(paramName), out error);
             if (!string.IsNullOrEmpty(error))
                 return false;
 
@@ -889,7 +931,7 @@ namespace Core
             // Find the tuple set
             if (!modelManager.TupleSets.TryGetValue(setName, out var tupleSet))
             {
-                error = $"Tuple set '{setName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Tuple set '{setName}' is not declared", setName, SymbolKind.TupleSet);
                 return false;
             }
 
@@ -908,7 +950,7 @@ namespace Core
             {
                 string instanceData = tupleMatch.Groups[1].Value;
                 // Support both comma-separated and space-separated fields (OPL uses spaces)
-                var values = SplitTupleFields(instanceData);
+                var values = SplitTupleFields(instanceData, Locale);
 
                 // Accept tuples with extra trailing fields (data files often contain
                 // additional columns not declared in the schema — ignore them).
@@ -927,7 +969,7 @@ namespace Core
                     VariableType fieldType = field.Value;
                     string valueStr = values[fieldIndex++];
 
-                    object parsedValue = ParseTupleValue(valueStr, fieldType, out string parseError);
+                    object parsedValue = ParseTupleValue(valueStr, fieldType, Locale.For($"{setName}.{fieldName}"), out string parseError);
                     
                     if (!string.IsNullOrEmpty(parseError))
                     {
@@ -944,7 +986,7 @@ namespace Core
             return true;
         }
 
-        private object ParseTupleValue(string valueStr, VariableType type, out string error)
+        private object ParseTupleValue(string valueStr, VariableType type, DataLocale locale, out string error)
         {
             error = string.Empty;
 
@@ -952,9 +994,9 @@ namespace Core
             {
                 return type switch
                 {
-                    VariableType.String => valueStr.Trim('"'),
-                    VariableType.Integer => int.Parse(valueStr),
-                    VariableType.Float => double.Parse(valueStr, System.Globalization.CultureInfo.InvariantCulture),
+                    VariableType.String => locale.ReadString(valueStr.Trim('"')),
+                    VariableType.Integer => int.Parse(valueStr, NumberStyles.Integer, locale.Culture),
+                    VariableType.Float => double.Parse(valueStr, NumberStyles.Float, locale.Culture),
                     VariableType.Boolean => bool.Parse(valueStr),
                     _ => throw new InvalidOperationException($"Unknown type: {type}")
                 };
@@ -966,7 +1008,7 @@ namespace Core
             }
         }
 
-        private object? ParseValueForType(string valueStr, ParameterType type, out string error)
+        private object? ParseValueForType(string valueStr, ParameterType type, DataLocale locale, out string error)
         {
             error = string.Empty;
             valueStr = valueStr.Trim();
@@ -976,7 +1018,7 @@ namespace Core
                 switch (type)
                 {
                     case ParameterType.Integer:
-                        if (int.TryParse(valueStr, out int intValue))
+                        if (locale.TryParseInteger(valueStr, out int intValue))
                         {
                             return intValue;
                         }
@@ -987,13 +1029,13 @@ namespace Core
                         }
 
                     case ParameterType.Float:
-                        if (double.TryParse(valueStr, NumberStyles.Float, CultureInfo.InvariantCulture, out double floatValue))
+                        if (locale.TryParseNumber(valueStr, out double floatValue))
                         {
                             return floatValue;
                         }
                         else
                         {
-                            error = $"Cannot parse '{valueStr}' as float";
+                            error = $"Cannot parse '{valueStr}' as float" + (locale == DataLocale.Invariant ? "" : $" ({locale})");
                             return null;
                         }
 
@@ -1001,9 +1043,9 @@ namespace Core
                         // Remove quotes if present
                         if (valueStr.StartsWith("\"") && valueStr.EndsWith("\""))
                         {
-                            return valueStr.Substring(1, valueStr.Length - 2);
+                            return locale.ReadString(valueStr.Substring(1, valueStr.Length - 2));
                         }
-                        return valueStr;
+                        return locale.ReadString(valueStr);
 
                     default:
                         error = $"Unsupported parameter type: {type}";
@@ -1087,7 +1129,8 @@ namespace Core
             primitiveSet.Clear();
 
             // Parse and populate
-            var values = SplitByCommaOrWhitespace(data);
+            var locale = Locale.For(setName);
+            var values = SplitByCommaOrWhitespace(data, locale);
 
             foreach (var valueStr in values)
             {
@@ -1101,7 +1144,7 @@ namespace Core
                     switch (primitiveSet.Type)
                     {
                         case PrimitiveSetType.Int:
-                            if (int.TryParse(trimmed, out int intVal))
+                            if (locale.TryParseInteger(trimmed, out int intVal))
                             {
                                 primitiveSet.Add(intVal);
                             }
@@ -1113,13 +1156,12 @@ namespace Core
                             break;
 
                         case PrimitiveSetType.String:
-                            string strVal = trimmed.Trim('"');
+                            string strVal = locale.ReadString(trimmed.Trim('"'));
                             primitiveSet.Add(strVal);
                             break;
 
                         case PrimitiveSetType.Float:
-                            if (double.TryParse(trimmed, System.Globalization.NumberStyles.Float,
-                                System.Globalization.CultureInfo.InvariantCulture, out double floatVal))
+                            if (locale.TryParseNumber(trimmed, out double floatVal))
                             {
                                 primitiveSet.Add(floatVal);
                             }
@@ -1129,6 +1171,15 @@ namespace Core
                                 return false;
                             }
                             break;
+
+                        case PrimitiveSetType.Timestamp:
+                            if (!PrimitiveSet.TryParseTimestamp(trimmed, out _))
+                            {
+                                error = $"Invalid timestamp value for set '{setName}': '{trimmed}'";
+                                return false;
+                            }
+                            primitiveSet.Add(trimmed.Trim('"'));
+                            break;
                     }
                 }
                 catch (Exception ex)
@@ -1238,7 +1289,7 @@ private bool ParseSingleValue(Parameter param, string valueStr, out string error
         return false;
     }
 
-    object? parsedValue = ParseValueForType(valueStr, param.Type, out error);
+    object? parsedValue = ParseValueForType(valueStr, param.Type, Locale.For(param.Name), out error);
     
     if (!string.IsNullOrEmpty(error))
     {
@@ -0,0 +1,75 @@
+using Core.Models;
+
+namespace Core.Decomposition
+{
+    public enum BendersCutType
+    {
+        /// <summary>theta >= constant + sum(coeff * x) — bounds the recourse cost from below</summary>
+        Optimality,
+        /// <summary>0 >= constant + sum(coeff * x) — cuts off master solutions with an infeasible subproblem</summary>
+        Feasibility
+    }
+
+    /// <summary>
+    /// A cut generated by a subproblem, expressed over master variables
+    /// </summary>
+    public class BendersCut
+    {
+        public BendersCutType Type { get; }
+        public Dictionary<string, double> Coefficients { get; }
+        public double Constant { get; }
+
+        public BendersCut(BendersCutType type, Dictionary<string, double> coefficients, double constant)
+        {
+            Type = type;
+            Coefficients = coefficients;
+            Constant = constant;
+        }
+
+        /// <summary>
+        /// Evaluates the right-hand side of the cut at the given master solution
+        /// </summary>
+        public double Evaluate(IReadOnlyDictionary<string, double> masterValues)
+        {
+            double value = Constant;
+            foreach (var kvp in Coefficients)
+            {
+                value += kvp.Value * (masterValues.TryGetValue(kvp.Key, out var x) ? x : 0.0);
+            }
+            return value;
+        }
+
+        /// <summary>
+        /// Converts the cut into a master constraint.
+        /// Optimality: theta - sum(coeff * x) >= constant. Feasibility: -sum(coeff * x) >= constant.
+        /// </summary>
+        public LinearEquation ToEquation(string recourseVariable, string label)
+        {
+            var coefficients = new Dictionary<string, Expression>();
+            foreach (var kvp in Coefficients)
+            {
+                coefficients[kvp.Key] = new ConstantExpression(-kvp.Value);
+            }
+
+            if (Type == BendersCutType.Optimality)
+            {
+                double thetaCoeff = 1.0;
+                if (coefficients.TryGetValue(recourseVariable, out var existing) && existing is ConstantExpression c)
+                    thetaCoeff += c.Value;
+                coefficients[recourseVariable] = new ConstantExpression(thetaCoeff);
+            }
+
+            return new LinearEquation(coefficients, new ConstantExpression(Constant), RelationalOperator.GreaterThanOrEqual, label)
+            {
+                BaseName = label
+            };
+        }
+
+        public override string ToString()
+        {
+            var terms = Coefficients.OrderBy(k => k.Key).Select(k => $"{k.Value:G}*{k.Key}");
+            string rhs = string.Join(" + ", new[] { Constant.ToString("G") }.Concat(terms)).Replace("+ -", "- ");
+            return Type == BendersCutType.Optimality ? $"theta >= {rhs}" : $"0 >= {rhs}";
+        }
+    }
+}
@@ -0,0 +1,171 @@
+using System.Diagnostics;
+using Core.Models;
+using Core.Solving;
+
+namespace Core.Decomposition
+{
+    /// <summary>
+    /// Classic Benders loop: solve the master, solve all subproblems (in parallel) at the
+    /// master solution, add the returned cuts to the master and repeat until the bounds meet.
+    /// The master must be a minimization problem with one recourse variable per subproblem.
+    /// Cuts go into a copy of the master; the model passed in is left as it was and the cuts
+    /// come back in the result.
+    /// </summary>
+    public class BendersDecomposition
+    {
+        private readonly ModelManager master;
+        private readonly IReadOnlyList<IBendersSubproblem> subproblems;
+        private readonly ISolverDriver driver;
+
+        public int MaxIterations { get; set; } = 100;
+        public double GapTolerance { get; set; } = NumericTolerance.DefaultAbsolute;
+        public int MaxDegreeOfParallelism { get; set; } = Environment.ProcessorCount;
+
+        public BendersDecomposition(ModelManager master, IReadOnlyList<IBendersSubproblem> subproblems, ISolverDriver driver)
+        {
+            this.master = master ?? throw new ArgumentNullException(nameof(master));
+            this.subproblems = subproblems ?? throw new ArgumentNullException(nameof(subproblems));
+            this.driver = driver ?? throw new ArgumentNullException(nameof(driver));
+        }
+
+        public BendersResult Run(IProgress<BendersIteration>? progress = null)
+        {
+            if (master.Objective == null)
+                throw new InvalidOperationException("Benders master has no objective");
+            if (master.Objective.Sense != ObjectiveSense.Minimize)
+                throw new InvalidOperationException("Benders master must be a minimization problem");
+
+            var sw = Stopwatch.StartNew();
+            var model = master.Clone();
+            var cuts = new List<LinearEquation>();
+            var iterations = new List<BendersIteration>();
+            double lowerBound = double.NegativeInfinity;
+            double upperBound = double.PositiveInfinity;
+            var bestValues = new Dictionary<string, double>();
+            int cutCounter = 0;
+
+            for (int iteration = 1; iteration <= MaxIterations; iteration++)
+            {
+                var masterResult = driver.Solve(model);
+                if (masterResult.Status is not (SolveStatus.Optimal or SolveStatus.Feasible) ||
+                    !masterResult.ObjectiveValue.HasValue)
+                {
+                    return Finish(BendersStatus.MasterFailed, iterations, lowerBound, upperBound, bestValues, cuts,
+                        $"Master solve failed in iteration {iteration}: {masterResult.StatusMessage ?? masterResult.Status.ToString()}");
+                }
+
+                var masterValues = masterResult.VariableValues;
+                lowerBound = Math.Max(lowerBound, masterResult.ObjectiveValue.Value);
+
+                var subResults = SolveSubproblems(masterValues);
+                int failed = Array.FindIndex(subResults, r => r.Status is SolveStatus.Error or SolveStatus.Unbounded);
+                if (failed >= 0)
+                {
+                    return Finish(BendersStatus.SubproblemFailed, iterations, lowerBound, upperBound, bestValues, cuts,
+                        $"Subproblem '{subproblems[failed].Name}' returned {subResults[failed].Status} in iteration {iteration}" +
+                        (subResults[failed].StatusMessage != null ? $": {subResults[failed].StatusMessage}" : ""));
+                }
+
+                // First-stage cost plus true recourse cost is a valid upper bound when every subproblem is feasible
+                if (subResults.All(r => r.IsOptimal))
+                {
+                    double candidate = masterResult.ObjectiveValue.Value;
+                    for (int i = 0; i < subproblems.Count; i++)
+                    {
+                        candidate -= masterValues.TryGetValue(subproblems[i].RecourseVariable, out var theta) ? theta : 0.0;
+                        candidate += subResults[i].Objective;
+                    }
+
+                    if (candidate < upperBound)
+                    {
+                        upperBound = candidate;
+                        bestValues = new Dictionary<string, double>(masterValues);
+                    }
+                }
+
+                var record = new BendersIteration
+                {
+                    Iteration = iteration,
+                    LowerBound = lowerBound,
+                    UpperBound = upperBound,
+                    Elapsed = sw.Elapsed
+                };
+
+                if (record.Gap <= GapTolerance)
+                {
+                    iterations.Add(record);
+                    progress?.Report(record);
+                    return Finish(BendersStatus.Converged, iterations, lowerBound, upperBound, bestValues, cuts, null);
+                }
+
+                int cutsAdded = 0;
+                for (int i = 0; i < subproblems.Count; i++)
+                {
+                    foreach (var cut in subResults[i].Cuts)
+                    {
+                        cutCounter++;
+                        var row = cut.ToEquation(subproblems[i].RecourseVariable, $"benders_cut_{cutCounter}");
+                        model.AddEquation(row);
+                        cuts.Add(row);
+                        cutsAdded++;
+                    }
+                }
+
+                record = new BendersIteration
+                {
+                    Iteration = iteration,
+                    LowerBound = lowerBound,
+                    UpperBound = upperBound,
+                    CutsAdded = cutsAdded,
+                    Elapsed = sw.Elapsed
+                };
+                iterations.Add(record);
+                progress?.Report(record);
+
+                if (cutsAdded == 0)
+                {
+                    return Finish(BendersStatus.Stalled, iterations, lowerBound, upperBound, bestValues, cuts,
+                        "No cuts generated but the gap is still open");
+                }
+            }
+
+            return Finish(BendersStatus.IterationLimit, iterations, lowerBound, upperBound, bestValues, cuts,
+                $"Stopped after {MaxIterations} iterations");
+        }
+
+        private BendersSubproblemResult[] SolveSubproblems(IReadOnlyDictionary<string, double> masterValues)
+        {
+            var results = new BendersSubproblemResult[subproblems.Count];
+            var options = new ParallelOptions { MaxDegreeOfParallelism = Math.Max(1, MaxDegreeOfParallelism) };
+
+            Parallel.For(0, subproblems.Count, options, i =>
+            {
+                try
+                {
+                    results[i] = subproblems[i].Solve(masterValues, driver);
+                }
+                catch (Exception ex)
+                {
+                    results[i] = new BendersSubproblemResult { Status = SolveStatus.Error, StatusMessage = ex.Message };
+                }
+            });
+
+            return results;
+        }
+
+        private static BendersResult Finish(BendersStatus status, List<BendersIteration> iterations,
+            double lowerBound, double upperBound, Dictionary<string, double> bestValues, List<LinearEquation> cuts, string? message)
+        {
+            return new BendersResult
+            {
+                Status = status,
+                Iterations = iterations,
+                LowerBound = lowerBound,
+                UpperBound = upperBound,
+                MasterValues = bestValues,
+                Cuts = cuts,
+                StatusMessage = message
+            };
+        }
+    }
+}
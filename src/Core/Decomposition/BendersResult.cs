@@ -0,0 +1,60 @@
+using Core.Models;
+
+namespace Core.Decomposition
+{
+    public enum BendersStatus
+    {
+        Converged,
+        IterationLimit,
+        /// <summary>No subproblem produced a cut but the gap is still open</summary>
+        Stalled,
+        MasterFailed,
+        SubproblemFailed
+    }
+
+    /// <summary>
+    /// Bounds and cut count after one master/subproblem round
+    /// </summary>
+    public class BendersIteration
+    {
+        public int Iteration { get; init; }
+        public double LowerBound { get; init; }
+        public double UpperBound { get; init; }
+        public int CutsAdded { get; init; }
+        public TimeSpan Elapsed { get; init; }
+
+        /// <summary>
+        /// Relative gap (UB - LB) / max(1, |UB|)
+        /// </summary>
+        public double Gap => double.IsInfinity(UpperBound)
+            ? double.PositiveInfinity
+            : (UpperBound - LowerBound) / Math.Max(1.0, Math.Abs(UpperBound));
+
+        public override string ToString()
+        {
+            return $"Iteration {Iteration}: LB={LowerBound:G6} UB={UpperBound:G6} gap={Gap:P3} cuts={CutsAdded}";
+        }
+    }
+
+    public class BendersResult
+    {
+        public BendersStatus Status { get; init; }
+        public List<BendersIteration> Iterations { get; init; } = new();
+        public double LowerBound { get; init; }
+        public double UpperBound { get; init; }
+
+        /// <summary>
+        /// Master solution that produced the best upper bound
+        /// </summary>
+        public Dictionary<string, double> MasterValues { get; init; } = new();
+
+        /// <summary>
+        /// Cuts added to the copy of the master, in the order they were generated ("benders_cut_1", ...)
+        /// </summary>
+        public List<LinearEquation> Cuts { get; init; } = new();
+
+        public string? StatusMessage { get; init; }
+
+        public double Gap => Iterations.Count > 0 ? Iterations[^1].Gap : double.PositiveInfinity;
+    }
+}
@@ -0,0 +1,564 @@
+using System.Globalization;
+using System.Text;
+using Core.Visualization;
+
+namespace Core.Decomposition
+{
+    public enum BlockStructureKind
+    {
+        /// <summary>No usable block structure was found</summary>
+        None,
+
+        /// <summary>Independent blocks that share no rows or columns</summary>
+        Diagonal,
+
+        /// <summary>Blocks tied together by linking rows (Dantzig-Wolfe, Lagrangian relaxation)</summary>
+        BlockAngular,
+
+        /// <summary>Blocks tied together by linking columns (Benders)</summary>
+        DualBlockAngular,
+
+        /// <summary>Block k shares columns only with blocks k - 1 and k + 1 (multi-period models)</summary>
+        Staircase
+    }
+
+    /// <summary>
+    /// One proposed block: the rows and columns assigned to it
+    /// </summary>
+    public class DetectedBlock
+    {
+        /// <summary>
+        /// Block number, starting at 1
+        /// </summary>
+        public int Number { get; init; }
+
+        public List<string> Rows { get; init; } = new List<string>();
+        public List<string> Columns { get; init; } = new List<string>();
+
+        public override string ToString() => $"Block {Number}: {Rows.Count} rows, {Columns.Count} columns";
+    }
+
+    /// <summary>
+    /// Block structure proposed for a constraint matrix. Linking rows and columns belong to no
+    /// block, except in a staircase, where the columns shared by consecutive blocks are listed
+    /// as linking but stay in the earlier of the two blocks.
+    /// </summary>
+    public class BlockStructureAnalysis
+    {
+        public BlockStructureKind Kind { get; init; }
+        public List<DetectedBlock> Blocks { get; init; } = new List<DetectedBlock>();
+        public List<string> LinkingRows { get; init; } = new List<string>();
+        public List<string> LinkingColumns { get; init; } = new List<string>();
+
+        public int RowCount { get; init; }
+        public int ColumnCount { get; init; }
+
+        /// <summary>
+        /// Block numbers of the members of each row family; 0 stands for linking rows
+        /// </summary>
+        public Dictionary<string, SortedSet<int>> RowFamilyBlocks { get; init; } = new Dictionary<string, SortedSet<int>>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Block numbers of the members of each column family; 0 stands for linking columns
+        /// </summary>
+        public Dictionary<string, SortedSet<int>> ColumnFamilyBlocks { get; init; } = new Dictionary<string, SortedSet<int>>(StringComparer.Ordinal);
+
+        public int? GetRowBlock(string row) => Blocks.FirstOrDefault(b => b.Rows.Contains(row))?.Number;
+
+        public int? GetColumnBlock(string column) => Blocks.FirstOrDefault(b => b.Columns.Contains(column))?.Number;
+
+        /// <summary>
+        /// The matrix with rows and columns ordered by block, linking rows and columns last, and
+        /// the blocks as families ("block 1", ..., "linking"), for spy plots and heatmaps
+        /// </summary>
+        public ConstraintMatrix Arrange(ConstraintMatrix matrix)
+        {
+            var linkingRows = LinkingRows.ToHashSet(StringComparer.Ordinal);
+            var linkingColumns = LinkingColumns.ToHashSet(StringComparer.Ordinal);
+            var rowOrder = Order(matrix.RowNames, Blocks.Select(b => b.Rows), linkingRows);
+            var columnOrder = Order(matrix.ColumnNames, Blocks.Select(b => b.Columns), linkingColumns);
+
+            var arranged = new ConstraintMatrix();
+            var rowPosition = new int[matrix.RowCount];
+            foreach (var (index, family) in rowOrder)
+            {
+                rowPosition[index] = arranged.RowNames.Count;
+                arranged.RowNames.Add(matrix.RowNames[index]);
+                arranged.RowFamilies.Add(family);
+            }
+
+            var columnPosition = new int[matrix.ColumnCount];
+            foreach (var (index, family) in columnOrder)
+            {
+                columnPosition[index] = arranged.ColumnNames.Count;
+                arranged.ColumnNames.Add(matrix.ColumnNames[index]);
+                arranged.ColumnFamilies.Add(family);
+            }
+
+            arranged.Entries.AddRange(matrix.Entries
+                .Select(e => (rowPosition[e.Row], columnPosition[e.Column], e.Value))
+                .OrderBy(e => e.Item1)
+                .ThenBy(e => e.Item2));
+            return arranged;
+        }
+
+        public string ToReport()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine($"Structure: {Describe(Kind)}, {Blocks.Count} blocks, " +
+                          $"{LinkingRows.Count} linking rows ({Share(LinkingRows.Count, RowCount)}), " +
+                          $"{LinkingColumns.Count} linking columns ({Share(LinkingColumns.Count, ColumnCount)})");
+
+            string? suggestion = Kind switch
+            {
+                BlockStructureKind.Diagonal => "solve the blocks as separate models",
+                BlockStructureKind.BlockAngular => "Dantzig-Wolfe or Lagrangian relaxation of the linking rows",
+                BlockStructureKind.DualBlockAngular => "Benders with the linking columns in the master",
+                BlockStructureKind.Staircase => "nested Benders over the blocks in order",
+                _ => null
+            };
+            if (suggestion != null)
+                sb.AppendLine($"Suggested decomposition: {suggestion}");
+
+            foreach (var block in Blocks)
+                sb.AppendLine(block.ToString());
+
+            if (LinkingRows.Count > 0)
+                sb.AppendLine($"Linking rows: {FormatNames(LinkingRows)}");
+            if (LinkingColumns.Count > 0)
+                sb.AppendLine($"Linking columns: {FormatNames(LinkingColumns)}");
+
+            if (Kind != BlockStructureKind.None)
+            {
+                sb.AppendLine("Row families:");
+                foreach (var (family, blocks) in RowFamilyBlocks)
+                    sb.AppendLine($"  {family}: {FormatBlocks(blocks)}");
+                sb.AppendLine("Column families:");
+                foreach (var (family, blocks) in ColumnFamilyBlocks)
+                    sb.AppendLine($"  {family}: {FormatBlocks(blocks)}");
+            }
+
+            return sb.ToString();
+        }
+
+        public override string ToString() => $"{Describe(Kind)}, {Blocks.Count} blocks";
+
+        private static List<(int Index, string Family)> Order(List<string> names, IEnumerable<List<string>> blocks, HashSet<string> linking)
+        {
+            var index = names.Select((n, i) => (n, i)).GroupBy(x => x.n, StringComparer.Ordinal).ToDictionary(g => g.Key, g => g.First().i, StringComparer.Ordinal);
+            var order = new List<(int, string)>();
+            var placed = new HashSet<int>();
+
+            int number = 1;
+            foreach (var block in blocks)
+            {
+                foreach (string name in block)
+                {
+                    if (index.TryGetValue(name, out int i) && placed.Add(i))
+                        order.Add((i, $"block {number}"));
+                }
+                number++;
+            }
+
+            for (int i = 0; i < names.Count; i++)
+            {
+                if (placed.Add(i))
+                    order.Add((i, linking.Contains(names[i]) ? "linking" : "unassigned"));
+            }
+
+            return order;
+        }
+
+        private static string Describe(BlockStructureKind kind) => kind switch
+        {
+            BlockStructureKind.Diagonal => "block-diagonal",
+            BlockStructureKind.BlockAngular => "block-angular",
+            BlockStructureKind.DualBlockAngular => "dual block-angular",
+            BlockStructureKind.Staircase => "staircase",
+            _ => "no block structure"
+        };
+
+        private static string FormatBlocks(SortedSet<int> blocks) =>
+            string.Join(", ", blocks.Select(b => b == 0 ? "linking" : $"block {b}"));
+
+        private static string Share(int count, int total) =>
+            (total == 0 ? 0.0 : (double)count / total).ToString("0.#%", CultureInfo.InvariantCulture);
+
+        private static string FormatNames(List<string> names) =>
+            names.Count <= 10 ? string.Join(", ", names) : string.Join(", ", names.Take(10)) + $", ... ({names.Count - 10} more)";
+    }
+
+    /// <summary>
+    /// Detects block structure in a constraint matrix for decomposition, in this order:
+    /// independent blocks (connected components of the row-column graph); a staircase, from the
+    /// breadth-first levels of the row-column graph; blocks linked by rows, found by removing
+    /// whole row families or the densest rows until the rest falls apart; and blocks linked by
+    /// columns, the same search on the transposed matrix. A staircase comes before linking rows
+    /// because cutting out one of its stages also leaves linking rows, but loses the stage order.
+    /// Removed rows or columns that touch a single block are put back, so only the ones that
+    /// really link blocks remain.
+    /// </summary>
+    public class BlockStructureDetector
+    {
+        /// <summary>
+        /// Largest share of the rows (or columns) that may be linking
+        /// </summary>
+        public double MaxLinkingShare { get; set; } = 0.4;
+
+        /// <summary>
+        /// Smallest share of the rows a block must have; smaller pieces are merged into blocks
+        /// </summary>
+        public double MinBlockShare { get; set; } = 0.05;
+
+        public int MaxBlocks { get; set; } = 16;
+
+        /// <summary>
+        /// Upper bound on the linking sets tried per direction, to bound the time on large matrices
+        /// </summary>
+        public int MaxCandidates { get; set; } = 64;
+
+        public BlockStructureAnalysis Detect(ConstraintMatrix matrix)
+        {
+            if (MaxBlocks < 2)
+                throw new InvalidOperationException($"MaxBlocks must be at least 2, got {MaxBlocks}");
+
+            var rowColumns = Adjacency(matrix.RowCount, matrix.Entries.Select(e => (e.Row, e.Column)));
+            var columnRows = Adjacency(matrix.ColumnCount, matrix.Entries.Select(e => (e.Column, e.Row)));
+
+            var diagonal = Assign(rowColumns, matrix.ColumnCount, new bool[matrix.RowCount]);
+            if (diagonal != null)
+                return Result(matrix, BlockStructureKind.Diagonal, diagonal.Value.Rows, diagonal.Value.Columns);
+
+            var candidates = new List<(double Score, Func<BlockStructureAnalysis> Create)>();
+
+            var staircase = Staircase(rowColumns, columnRows);
+            if (staircase != null)
+            {
+                var (rows, columns, coupling, score) = staircase.Value;
+                candidates.Add((score, () => Result(matrix, BlockStructureKind.Staircase, rows, columns, coupling)));
+            }
+
+            var byRows = SplitByLinking(rowColumns, columnRows, matrix.RowFamilies);
+            if (byRows != null)
+            {
+                var (rows, columns, score) = byRows.Value;
+                candidates.Add((score, () => Result(matrix, BlockStructureKind.BlockAngular, rows, columns)));
+            }
+
+            var byColumns = SplitByLinking(columnRows, rowColumns, matrix.ColumnFamilies);
+            if (byColumns != null)
+            {
+                var (columns, rows, score) = byColumns.Value;
+                candidates.Add((score, () => Result(matrix, BlockStructureKind.DualBlockAngular, rows, columns)));
+            }
+
+            if (candidates.Count == 0)
+                return Result(matrix, BlockStructureKind.None, Enumerable.Repeat(-1, matrix.RowCount).ToArray(), Enumerable.Repeat(-1, matrix.ColumnCount).ToArray());
+
+            return candidates.OrderBy(c => c.Score).First().Create();
+        }
+
+        /// <summary>
+        /// Share of linking rows or columns per block gained, lower is better. The effective
+        /// number of blocks is the size of all blocks over the size of the largest, so a split
+        /// into one large block and a sliver gains little.
+        /// </summary>
+        private static double Score(int[] blocks, int linking, int total)
+        {
+            var sizes = blocks.Where(b => b >= 0).GroupBy(b => b).Select(g => g.Count()).ToList();
+            double gained = (double)sizes.Sum() / sizes.Max() - 1;
+            return (double)linking / total / gained;
+        }
+
+        /// <summary>
+        /// Blocks of the rows left after removing linking candidates: every row family, and the
+        /// densest rows down to each row length. Removing too many rows is harmless as the ones
+        /// within a block are restored; of the splits with few enough linking rows, the best
+        /// scoring wins. Returns block indexes for rows and columns, -1 for linking rows and for
+        /// columns used by linking rows only.
+        /// </summary>
+        private (int[] Rows, int[] Columns, double Score)? SplitByLinking(int[][] rowColumns, int[][] columnRows, List<string> families)
+        {
+            int rows = rowColumns.Length;
+            int maxLinking = (int)(MaxLinkingShare * rows);
+            var candidates = new List<int[]>();
+
+            // Denser families first, as linking rows tend to use more columns than block rows
+            var byFamily = Enumerable.Range(0, rows).GroupBy(r => families[r], StringComparer.Ordinal).ToList();
+            if (byFamily.Count > 1)
+            {
+                candidates.AddRange(byFamily
+                    .Where(g => g.Count() < rows)
+                    .OrderByDescending(g => g.Average(r => rowColumns[r].Length))
+                    .Take(MaxCandidates)
+                    .Select(g => g.ToArray()));
+            }
+
+            var densest = Enumerable.Range(0, rows).OrderByDescending(r => rowColumns[r].Length).ThenBy(r => r).ToArray();
+            var cuts = Enumerable.Range(1, rows - 1)
+                .Where(n => rowColumns[densest[n - 1]].Length != rowColumns[densest[n]].Length)
+                .ToList();
+            if (cuts.Count > MaxCandidates)
+                cuts = Enumerable.Range(0, MaxCandidates).Select(i => cuts[(int)((long)i * cuts.Count / MaxCandidates)]).Distinct().ToList();
+            candidates.AddRange(cuts.Select(n => densest.Take(n).ToArray()));
+
+            (int[] Rows, int[] Columns, double Score)? best = null;
+            double bestScore = double.PositiveInfinity;
+            foreach (var candidate in candidates)
+            {
+                var removed = new bool[rows];
+                foreach (int r in candidate)
+                    removed[r] = true;
+
+                var assignment = Assign(rowColumns, columnRows.Length, removed);
+                if (assignment == null)
+                    continue;
+
+                var (rowBlocks, columnBlocks) = assignment.Value;
+                Restore(rowColumns, candidate, rowBlocks, columnBlocks);
+
+                int linking = Enumerable.Range(0, rows).Count(r => rowBlocks[r] < 0 && rowColumns[r].Length > 0);
+                // Without linking rows the blocks are independent, which the caller has ruled out
+                if (linking == 0 || linking > maxLinking)
+                    continue;
+
+                double score = Score(rowBlocks, linking, rows);
+                if (score < bestScore)
+                {
+                    best = (rowBlocks, columnBlocks, score);
+                    bestScore = score;
+                }
+            }
+
+            return best;
+        }
+
+        /// <summary>
+        /// Puts removed rows back into a block when all their assigned columns are in that block,
+        /// sparsest first; columns used only by linking rows join the block of such a row
+        /// </summary>
+        private static void Restore(int[][] rowColumns, int[] removed, int[] rowBlocks, int[] columnBlocks)
+        {
+            foreach (int row in removed.OrderBy(r => rowColumns[r].Length).ThenBy(r => r))
+            {
+                var blocks = rowColumns[row].Select(c => columnBlocks[c]).Where(b => b >= 0).Distinct().ToList();
+                if (blocks.Count != 1)
+                    continue;
+
+                rowBlocks[row] = blocks[0];
+                foreach (int column in rowColumns[row])
+                    columnBlocks[column] = blocks[0];
+            }
+        }
+
+        /// <summary>
+        /// Connected components of the rows that are not removed, packed into at most MaxBlocks
+        /// blocks; null unless at least two components are large enough to be blocks of their own
+        /// </summary>
+        private (int[] Rows, int[] Columns)? Assign(int[][] rowColumns, int columns, bool[] removed)
+        {
+            var parent = Enumerable.Range(0, columns).ToArray();
+            int Find(int c)
+            {
+                while (parent[c] != c)
+                    c = parent[c] = parent[parent[c]];
+                return c;
+            }
+
+            for (int r = 0; r < rowColumns.Length; r++)
+            {
+                if (removed[r])
+                    continue;
+                foreach (int c in rowColumns[r].Skip(1))
+                    parent[Find(c)] = Find(rowColumns[r][0]);
+            }
+
+            var components = Enumerable.Range(0, rowColumns.Length)
+                .Where(r => !removed[r] && rowColumns[r].Length > 0)
+                .GroupBy(r => Find(rowColumns[r][0]))
+                .Select(g => (Root: g.Key, Rows: g.ToList()))
+                .ToList();
+
+            int active = components.Sum(c => c.Rows.Count);
+            int minRows = Math.Max(1, (int)Math.Ceiling(MinBlockShare * active));
+            int significant = components.Count(c => c.Rows.Count >= minRows);
+            if (significant < 2)
+                return null;
+
+            // Largest components first into the block with the fewest rows, then numbered by first row
+            var sizes = new int[Math.Min(MaxBlocks, significant)];
+            var bins = new Dictionary<int, int>();
+            foreach (var component in components.OrderByDescending(c => c.Rows.Count).ThenBy(c => c.Rows[0]))
+            {
+                int bin = Array.IndexOf(sizes, sizes.Min());
+                sizes[bin] += component.Rows.Count;
+                bins[component.Root] = bin;
+            }
+
+            var numbering = components
+                .GroupBy(c => bins[c.Root])
+                .OrderBy(g => g.Min(c => c.Rows[0]))
+                .Select((g, i) => (g.Key, i))
+                .ToDictionary(x => x.Key, x => x.i);
+
+            var rowBlocks = Enumerable.Repeat(-1, rowColumns.Length).ToArray();
+            var columnBlocks = Enumerable.Repeat(-1, columns).ToArray();
+            foreach (var component in components)
+            {
+                int block = numbering[bins[component.Root]];
+                foreach (int r in component.Rows)
+                {
+                    rowBlocks[r] = block;
+                    foreach (int c in rowColumns[r])
+                        columnBlocks[c] = block;
+                }
+            }
+
+            return (rowBlocks, columnBlocks);
+        }
+
+        /// <summary>
+        /// Breadth-first levels of the row-column graph from a far-away row: rows of level d use
+        /// only columns of levels d - 1 and d, so consecutive levels grouped into blocks form a
+        /// staircase. A column goes to the block of its level; columns used by two blocks couple
+        /// them and count as linking for the score. Null unless at least three blocks are large enough.
+        /// </summary>
+        private (int[] Rows, int[] Columns, bool[] Coupling, double Score)? Staircase(int[][] rowColumns, int[][] columnRows)
+        {
+            int start = Enumerable.Range(0, rowColumns.Length).FirstOrDefault(r => rowColumns[r].Length > 0, -1);
+            if (start < 0)
+                return null;
+
+            // The last row reached is as far from the start as any; searching again from it gives the deepest levels
+            var (rowLevels, columnLevels, last) = Levels(rowColumns, columnRows, start);
+            (rowLevels, columnLevels, _) = Levels(rowColumns, columnRows, last);
+
+            int depth = rowLevels.Max() + 1;
+            var levelSizes = new int[depth];
+            foreach (int level in rowLevels.Where(l => l >= 0))
+                levelSizes[level]++;
+
+            int active = levelSizes.Sum();
+            int minRows = Math.Max(1, (int)Math.Ceiling(MinBlockShare * active));
+            var groups = new List<(int First, int Last, int Rows)>();
+            for (int level = 0; level < depth; level++)
+            {
+                if (groups.Count > 0 && groups[^1].Rows < minRows)
+                    groups[^1] = (groups[^1].First, level, groups[^1].Rows + levelSizes[level]);
+                else
+                    groups.Add((level, level, levelSizes[level]));
+            }
+            if (groups.Count > 1 && groups[^1].Rows < minRows)
+            {
+                groups[^2] = (groups[^2].First, groups[^1].Last, groups[^2].Rows + groups[^1].Rows);
+                groups.RemoveAt(groups.Count - 1);
+            }
+            while (groups.Count > MaxBlocks)
+            {
+                int i = Enumerable.Range(0, groups.Count - 1).OrderBy(k => groups[k].Rows + groups[k + 1].Rows).First();
+                groups[i] = (groups[i].First, groups[i + 1].Last, groups[i].Rows + groups[i + 1].Rows);
+                groups.RemoveAt(i + 1);
+            }
+            if (groups.Count < 3)
+                return null;
+
+            var groupOfLevel = new int[depth];
+            for (int g = 0; g < groups.Count; g++)
+            {
+                for (int level = groups[g].First; level <= groups[g].Last; level++)
+                    groupOfLevel[level] = g;
+            }
+
+            // Rows and columns the search did not reach are in other components and fit in any block
+            var rowBlocks = rowLevels.Select((l, r) => l >= 0 ? groupOfLevel[l] : rowColumns[r].Length > 0 ? 0 : -1).ToArray();
+            var columnBlocks = columnLevels.Select((l, c) => l >= 0 ? groupOfLevel[l] : columnRows[c].Length > 0 ? rowBlocks[columnRows[c][0]] : -1).ToArray();
+            var coupling = columnRows.Select(rs => rs.Select(r => rowBlocks[r]).Distinct().Count() > 1).ToArray();
+            return (rowBlocks, columnBlocks, coupling, Score(rowBlocks, coupling.Count(c => c), columnRows.Length));
+        }
+
+        private static (int[] Rows, int[] Columns, int Last) Levels(int[][] rowColumns, int[][] columnRows, int start)
+        {
+            var rowLevels = Enumerable.Repeat(-1, rowColumns.Length).ToArray();
+            var columnLevels = Enumerable.Repeat(-1, columnRows.Length).ToArray();
+            var queue = new Queue<int>();
+            rowLevels[start] = 0;
+            queue.Enqueue(start);
+            int last = start;
+
+            while (queue.Count > 0)
+            {
+                int row = queue.Dequeue();
+                last = row;
+                foreach (int column in rowColumns[row].Where(c => columnLevels[c] < 0))
+                {
+                    columnLevels[column] = rowLevels[row];
+                    foreach (int next in columnRows[column].Where(r => rowLevels[r] < 0))
+                    {
+                        rowLevels[next] = rowLevels[row] + 1;
+                        queue.Enqueue(next);
+                    }
+                }
+            }
+
+            return (rowLevels, columnLevels, last);
+        }
+
+        private static BlockStructureAnalysis Result(ConstraintMatrix matrix, BlockStructureKind kind, int[] rowBlocks, int[] columnBlocks, bool[]? coupling = null)
+        {
+            int count = kind == BlockStructureKind.None ? 0 : rowBlocks.Concat(columnBlocks).DefaultIfEmpty(-1).Max() + 1;
+            var blocks = Enumerable.Range(1, count).Select(n => new DetectedBlock { Number = n }).ToList();
+            var analysis = new BlockStructureAnalysis
+            {
+                Kind = kind,
+                Blocks = blocks,
+                RowCount = matrix.RowCount,
+                ColumnCount = matrix.ColumnCount
+            };
+            if (kind == BlockStructureKind.None)
+                return analysis;
+
+            // Empty rows and columns belong to no block and link nothing
+            var usedRows = matrix.Entries.Select(e => e.Row).ToHashSet();
+            var usedColumns = matrix.Entries.Select(e => e.Column).ToHashSet();
+
+            for (int r = 0; r < matrix.RowCount; r++)
+            {
+                if (!usedRows.Contains(r))
+                    continue;
+                if (rowBlocks[r] >= 0)
+                    blocks[rowBlocks[r]].Rows.Add(matrix.RowNames[r]);
+                else
+                    analysis.LinkingRows.Add(matrix.RowNames[r]);
+                Add(analysis.RowFamilyBlocks, matrix.RowFamilies[r], rowBlocks[r] + 1);
+            }
+
+            for (int c = 0; c < matrix.ColumnCount; c++)
+            {
+                if (!usedColumns.Contains(c))
+                    continue;
+                if (columnBlocks[c] >= 0)
+                    blocks[columnBlocks[c]].Columns.Add(matrix.ColumnNames[c]);
+                if (columnBlocks[c] < 0 || coupling?[c] == true)
+                    analysis.LinkingColumns.Add(matrix.ColumnNames[c]);
+                Add(analysis.ColumnFamilyBlocks, matrix.ColumnFamilies[c], coupling?[c] == true ? 0 : columnBlocks[c] + 1);
+            }
+
+            return analysis;
+        }
+
+        private static void Add(Dictionary<string, SortedSet<int>> families, string family, int block)
+        {
+            if (!families.TryGetValue(family, out var blocks))
+                families[family] = blocks = new SortedSet<int>();
+            blocks.Add(block);
+        }
+
+        private static int[][] Adjacency(int count, IEnumerable<(int From, int To)> pairs)
+        {
+            var lists = Enumerable.Range(0, count).Select(_ => new List<int>()).ToArray();
+            foreach (var (from, to) in pairs)
+                lists[from].Add(to);
+            return lists.Select(l => l.Distinct().ToArray()).ToArray();
+        }
+    }
+}
@@ -0,0 +1,39 @@
+using Core.Solving;
+
+namespace Core.Decomposition
+{
+    /// <summary>
+    /// A Benders subproblem. Given a master solution it is solved independently of the
+    /// other subproblems (possibly in parallel) and returns its cost and any cuts.
+    /// </summary>
+    public interface IBendersSubproblem
+    {
+        string Name { get; }
+
+        /// <summary>
+        /// Name of the master variable (theta) that approximates this subproblem's cost
+        /// </summary>
+        string RecourseVariable { get; }
+
+        BendersSubproblemResult Solve(IReadOnlyDictionary<string, double> masterValues, ISolverDriver driver);
+    }
+
+    /// <summary>
+    /// Outcome of solving one subproblem for a given master solution
+    /// </summary>
+    public class BendersSubproblemResult
+    {
+        public SolveStatus Status { get; init; }
+
+        /// <summary>
+        /// Subproblem cost at the master solution; ignored unless Status is Optimal
+        /// </summary>
+        public double Objective { get; init; }
+
+        public List<BendersCut> Cuts { get; init; } = new();
+
+        public string? StatusMessage { get; init; }
+
+        public bool IsOptimal => Status == SolveStatus.Optimal;
+    }
+}
@@ -0,0 +1,56 @@
+using Core.Solving;
+
+namespace Core.Decomposition
+{
+    /// <summary>
+    /// Subproblem backed by its own ModelManager. Master values are pushed into the
+    /// subproblem as scalar parameters before each solve; cut construction is left to
+    /// a callback because it depends on the formulation (and usually on duals).
+    /// </summary>
+    public class ModelBendersSubproblem : IBendersSubproblem
+    {
+        private readonly Func<ModelManager, SolveResult, IReadOnlyDictionary<string, double>, IEnumerable<BendersCut>> cutGenerator;
+
+        public string Name { get; }
+        public string RecourseVariable { get; }
+        public ModelManager Model { get; }
+
+        /// <summary>
+        /// Master variable name → subproblem parameter name receiving its value
+        /// </summary>
+        public Dictionary<string, string> LinkedParameters { get; } = new Dictionary<string, string>();
+
+        public ModelBendersSubproblem(
+            string name,
+            ModelManager model,
+            string recourseVariable,
+            Func<ModelManager, SolveResult, IReadOnlyDictionary<string, double>, IEnumerable<BendersCut>> cutGenerator)
+        {
+            Name = name;
+            Model = model ?? throw new ArgumentNullException(nameof(model));
+            RecourseVariable = recourseVariable;
+            this.cutGenerator = cutGenerator ?? throw new ArgumentNullException(nameof(cutGenerator));
+        }
+
+        public BendersSubproblemResult Solve(IReadOnlyDictionary<string, double> masterValues, ISolverDriver driver)
+        {
+            foreach (var link in LinkedParameters)
+            {
+                Model.SetParameter(link.Value, masterValues.TryGetValue(link.Key, out var value) ? value : 0.0);
+            }
+
+            var result = driver.Solve(Model);
+            var cuts = result.Status is SolveStatus.Optimal or SolveStatus.Infeasible
+                ? cutGenerator(Model, result, masterValues).ToList()
+                : new List<BendersCut>();
+
+            return new BendersSubproblemResult
+            {
+                Status = result.Status,
+                Objective = result.ObjectiveValue ?? 0.0,
+                Cuts = cuts,
+                StatusMessage = result.StatusMessage
+            };
+        }
+    }
+}
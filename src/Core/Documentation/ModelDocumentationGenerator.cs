@@ -0,0 +1,419 @@
+using System.Globalization;
+using System.Text;
+using Core.Formatting;
+using Core.Models;
+
+namespace Core.Documentation
+{
+    public enum DocumentationFormat
+    {
+        /// <summary>Markdown with $$ math blocks</summary>
+        Markdown,
+        /// <summary>Standalone LaTeX document, ready for pdflatex</summary>
+        Latex
+    }
+
+    public class DocumentationOptions
+    {
+        public string Title { get; set; } = "Model Documentation";
+        public DocumentationFormat Format { get; set; } = DocumentationFormat.Markdown;
+        public bool IncludeStatistics { get; set; } = true;
+
+        /// <summary>
+        /// Expanded constraints are documented once per family; this many instances are shown
+        /// </summary>
+        public int InstancesPerFamily { get; set; } = 1;
+    }
+
+    /// <summary>
+    /// Summary counts for a model
+    /// </summary>
+    public class ModelStatistics
+    {
+        public int Sets { get; init; }
+        public int Parameters { get; init; }
+        public int VariableDeclarations { get; init; }
+        public int Variables { get; init; }
+        public int IntegerVariables { get; init; }
+        public int ConstraintTemplates { get; init; }
+        public int Constraints { get; init; }
+        public int LogicalConstraints { get; init; }
+        public int NonZeros { get; init; }
+
+        public static ModelStatistics Compute(ModelManager manager)
+        {
+            var rows = manager.MaterializeRows();
+            var variableNames = new HashSet<string>(rows.SelectMany(e => e.Coefficients.Keys));
+            if (manager.Objective != null)
+                variableNames.UnionWith(manager.Objective.Coefficients.Keys);
+
+            var discrete = manager.IndexedVariables.Values
+                .Where(v => v.Type == VariableType.Integer || v.Type == VariableType.Boolean)
+                .Select(v => v.BaseName)
+                .ToList();
+
+            return new ModelStatistics
+            {
+                Sets = manager.Ranges.Count + manager.IndexSets.Keys.Count(k => !manager.Ranges.ContainsKey(k)) +
+                       manager.PrimitiveSets.Count + manager.TupleSets.Count,
+                Parameters = manager.Parameters.Count,
+                VariableDeclarations = manager.IndexedVariables.Count,
+                Variables = variableNames.Count,
+                IntegerVariables = variableNames.Count(n => discrete.Any(d => n.StartsWith(d, StringComparison.Ordinal))),
+                ConstraintTemplates = manager.ForallStatements.Count + manager.PendingRules.Count,
+                Constraints = rows.Count,
+                LogicalConstraints = manager.LogicalConstraints.Count,
+                NonZeros = rows.Sum(e => e.Coefficients.Count)
+            };
+        }
+    }
+
+    /// <summary>
+    /// Generates human-readable model documentation: nomenclature tables for sets, parameters and
+    /// variables (with units and descriptions), the objective and constraint formulations in LaTeX
+    /// math, and model statistics. Works best on a model that has been parsed but not yet expanded,
+    /// so that forall constraints are documented in their indexed form.
+    /// </summary>
+    public class ModelDocumentationGenerator
+    {
+        private readonly ModelManager modelManager;
+        private readonly ExpressionFormatter latex = new ExpressionFormatter(ExpressionStyle.Latex);
+
+        public DocumentationOptions Options { get; }
+
+        private bool IsLatex => Options.Format == DocumentationFormat.Latex;
+
+        public ModelDocumentationGenerator(ModelManager manager, DocumentationOptions? options = null)
+        {
+            modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
+            Options = options ?? new DocumentationOptions();
+        }
+
+        public string Generate()
+        {
+            var sb = new StringBuilder();
+
+            if (IsLatex)
+            {
+                sb.AppendLine("\\documentclass{article}");
+                sb.AppendLine("\\usepackage{amsmath}");
+                sb.AppendLine("\\usepackage{booktabs}");
+                sb.AppendLine("\\usepackage{longtable}");
+                sb.AppendLine($"\\title{{{EscapeText(Options.Title)}}}");
+                sb.AppendLine("\\date{}");
+                sb.AppendLine("\\begin{document}");
+                sb.AppendLine("\\maketitle");
+            }
+            else
+            {
+                sb.AppendLine($"# {Options.Title}");
+            }
+
+            AppendSets(sb);
+            AppendParameters(sb);
+            AppendVariables(sb);
+            AppendObjective(sb);
+            AppendConstraints(sb);
+
+            if (Options.IncludeStatistics)
+                AppendStatistics(sb);
+
+            if (IsLatex)
+                sb.AppendLine("\\end{document}");
+
+            return sb.ToString();
+        }
+
+        public void Save(string filePath)
+        {
+            File.WriteAllText(filePath, Generate());
+        }
+
+        private void AppendSets(StringBuilder sb)
+        {
+            var rows = new List<string[]>();
+
+            foreach (var range in modelManager.Ranges.Values.OrderBy(r => r.Name))
+            {
+                string definition = $"{latex.Format(range.StartExpression)}..{latex.Format(range.EndExpression)}";
+                rows.Add(new[] { Symbol(range.Name), InlineMath(definition), SizeOf(range.Name), Text(range.Description) });
+            }
+
+            foreach (var set in modelManager.IndexSets.Values.Where(s => !modelManager.Ranges.ContainsKey(s.Name)).OrderBy(s => s.Name))
+            {
+                rows.Add(new[] { Symbol(set.Name), InlineMath($"{set.StartIndex}..{set.EndIndex}"), set.Count.ToString(), Text(set.Description) });
+            }
+
+            foreach (var set in modelManager.PrimitiveSets.Values.OrderBy(s => s.Name))
+            {
+                string definition = set.IsExternal ? "external" : $"{{{set.Type.ToString().ToLowerInvariant()}}}";
+                rows.Add(new[] { Symbol(set.Name), Text(definition), set.Count.ToString(), Text(set.Description) });
+            }
+
+            foreach (var set in modelManager.TupleSets.Values.OrderBy(s => s.Name))
+            {
+                rows.Add(new[] { Symbol(set.Name), Text($"tuples of {set.SchemaName}"), set.Count.ToString(), Text(set.Description) });
+            }
+
+            AppendSection(sb, "Sets", new[] { "Set", "Definition", "Size", "Description" }, rows);
+        }
+
+        private void AppendParameters(StringBuilder sb)
+        {
+            var rows = modelManager.Parameters.Values
+                .OrderBy(p => p.Name)
+                .Select(p => new[]
+                {
+                    Symbol(p.Name, p.IndexSetNames),
+                    Text(p.Type.ToString().ToLowerInvariant()),
+                    Text(p.Unit ?? p.Currency),
+                    Text(p.IsScalar && p.Value != null ? Convert.ToString(p.Value, CultureInfo.InvariantCulture) : p.IsExternal ? "external" : ""),
+                    Text(p.Description)
+                })
+                .ToList();
+
+            AppendSection(sb, "Parameters", new[] { "Parameter", "Type", "Unit", "Value", "Description" }, rows);
+        }
+
+        private void AppendVariables(StringBuilder sb)
+        {
+            var rows = modelManager.IndexedVariables.Values
+                .OrderBy(v => v.BaseName)
+                .Select(v =>
+                {
+                    var indices = new List<string>();
+                    if (!v.IsScalar) indices.Add(v.IndexSetName);
+                    if (v.SecondIndexSetName != null) indices.Add(v.SecondIndexSetName);
+                    if (v.AdditionalIndexSets != null) indices.AddRange(v.AdditionalIndexSets);
+
+                    return new[]
+                    {
+                        Symbol(v.BaseName, indices),
+                        Text(v.Type.ToString().ToLowerInvariant()),
+                        InlineMath(FormatBounds(v.LowerBound, v.UpperBound)),
+                        Text(v.Unit),
+                        Text(v.Description)
+                    };
+                })
+                .ToList();
+
+            AppendSection(sb, "Decision Variables", new[] { "Variable", "Type", "Domain", "Unit", "Description" }, rows);
+        }
+
+        private void AppendObjective(StringBuilder sb)
+        {
+            if (modelManager.Objective == null)
+                return;
+
+            AppendHeading(sb, "Objective");
+            AppendDisplayMath(sb, latex.Format(modelManager.Objective));
+            AppendDocumentation(sb, "objective");
+        }
+
+        private void AppendConstraints(StringBuilder sb)
+        {
+            var formulations = new List<(string Math, string? Key)>();
+
+            foreach (var forall in modelManager.ForallStatements)
+            {
+                if (forall.ConstraintTemplate == null)
+                    continue;
+
+                var template = forall.ConstraintTemplate;
+                string body = latex.FormatRelation(template.LeftSide, template.Operator, template.RightSide);
+                string iterators = string.Join(", ", forall.Iterators.Select(FormatIterator));
+                string condition = forall.Condition != null ? $" : {latex.Format(forall.Condition)}" : "";
+
+                formulations.Add(($"{Label(forall.Label)}{body} \\quad \\forall {iterators}{condition}", ConstraintKey(forall.Label)));
+            }
+
+            // Expanded or scalar constraints, and the rows of rules not expanded yet: document each family once
+            foreach (var family in modelManager.MaterializeRows().GroupBy(e => e.BaseName ?? e.Label ?? ""))
+            {
+                var instances = family.ToList();
+                var shown = instances.Take(Math.Max(1, Options.InstancesPerFamily)).ToList();
+                foreach (var equation in shown)
+                {
+                    string label = instances.Count > 1 ? equation.GetDescription() : equation.Label ?? equation.BaseName ?? "";
+                    bool last = equation == shown[^1] && instances.Count <= Options.InstancesPerFamily;
+                    formulations.Add(($"{Label(label)}{latex.Format(equation, includeLabel: false)}", last ? ConstraintKey(family.Key) : null));
+                }
+
+                if (instances.Count > Options.InstancesPerFamily)
+                    formulations.Add(($"\\text{{\\dots\\ {instances.Count} instances of {EscapeText(family.Key)} in total}}", ConstraintKey(family.Key)));
+            }
+
+            foreach (var logical in modelManager.LogicalConstraints)
+            {
+                string connective = logical.Type == LogicalConstraintType.Disjunctive ? "\\lor" : "\\Rightarrow";
+                formulations.Add(($"{Label(logical.Label)}\\left({latex.Format(logical.Left, false)}\\right) {connective} \\left({latex.Format(logical.Right, false)}\\right)", ConstraintKey(logical.Label)));
+            }
+
+            if (formulations.Count == 0)
+                return;
+
+            AppendHeading(sb, "Constraints");
+            foreach (var (math, key) in formulations)
+            {
+                AppendDisplayMath(sb, math);
+                if (key != null)
+                    AppendDocumentation(sb, key);
+            }
+        }
+
+        private static string? ConstraintKey(string? label) => string.IsNullOrEmpty(label) ? null : $"constraint:{label}";
+
+        /// <summary>
+        /// The docstring of an entity as a paragraph
+        /// </summary>
+        private void AppendDocumentation(StringBuilder sb, string key)
+        {
+            if (!modelManager.Documentation.TryGetValue(key, out var text))
+                return;
+
+            sb.AppendLine(IsLatex ? EscapeText(text) : text);
+            sb.AppendLine();
+        }
+
+        private void AppendStatistics(StringBuilder sb)
+        {
+            var stats = ModelStatistics.Compute(modelManager);
+            var rows = new List<string[]>
+            {
+                new[] { "Sets", stats.Sets.ToString() },
+                new[] { "Parameters", stats.Parameters.ToString() },
+                new[] { "Variable declarations", stats.VariableDeclarations.ToString() },
+                new[] { "Variables (expanded)", stats.Variables.ToString() },
+                new[] { "Integer/binary variables", stats.IntegerVariables.ToString() },
+                new[] { "Constraint templates", stats.ConstraintTemplates.ToString() },
+                new[] { "Constraints (expanded)", stats.Constraints.ToString() },
+                new[] { "Logical constraints", stats.LogicalConstraints.ToString() },
+                new[] { "Non-zeros", stats.NonZeros.ToString() }
+            };
+
+            AppendSection(sb, "Statistics", new[] { "Measure", "Count" }, rows);
+        }
+
+        private string FormatIterator(ForallIterator iterator)
+        {
+            string set = iterator.Range.SetName != null
+                ? SymbolName(iterator.Range.SetName)
+                : $"\\{{{latex.Format(iterator.Range.Start!)},\\dots,{latex.Format(iterator.Range.End!)}\\}}";
+            string filter = iterator.Filter != null ? $" : {latex.Format(iterator.Filter)}" : "";
+
+            return $"{iterator.VariableName} \\in {set}{filter}";
+        }
+
+        private static string FormatBounds(double? lower, double? upper)
+        {
+            string lo = lower.HasValue ? lower.Value.ToString(CultureInfo.InvariantCulture) : "-\\infty";
+            string hi = upper.HasValue ? upper.Value.ToString(CultureInfo.InvariantCulture) : "\\infty";
+            return $"[{lo}, {hi}]";
+        }
+
+        private string SizeOf(string setName)
+        {
+            return modelManager.IndexSets.TryGetValue(setName, out var set) ? set.Count.ToString() : "";
+        }
+
+        private void AppendHeading(StringBuilder sb, string title)
+        {
+            sb.AppendLine();
+            sb.AppendLine(IsLatex ? $"\\section*{{{title}}}" : $"## {title}");
+            sb.AppendLine();
+        }
+
+        private void AppendDisplayMath(StringBuilder sb, string math)
+        {
+            if (IsLatex)
+            {
+                sb.AppendLine("\\begin{equation*}");
+                sb.AppendLine(math);
+                sb.AppendLine("\\end{equation*}");
+            }
+            else
+            {
+                sb.AppendLine("$$");
+                sb.AppendLine(math);
+                sb.AppendLine("$$");
+                sb.AppendLine();
+            }
+        }
+
+        private void AppendSection(StringBuilder sb, string title, string[] headers, List<string[]> rows)
+        {
+            if (rows.Count == 0)
+                return;
+
+            AppendHeading(sb, title);
+
+            if (IsLatex)
+            {
+                sb.AppendLine($"\\begin{{longtable}}{{{new string('l', headers.Length)}}}");
+                sb.AppendLine("\\toprule");
+                sb.AppendLine(string.Join(" & ", headers) + " \\\\");
+                sb.AppendLine("\\midrule");
+                foreach (var row in rows)
+                    sb.AppendLine(string.Join(" & ", row) + " \\\\");
+                sb.AppendLine("\\bottomrule");
+                sb.AppendLine("\\end{longtable}");
+            }
+            else
+            {
+                sb.AppendLine($"| {string.Join(" | ", headers)} |");
+                sb.AppendLine($"|{string.Concat(headers.Select(_ => "---|"))}");
+                foreach (var row in rows)
+                    sb.AppendLine($"| {string.Join(" | ", row)} |");
+            }
+        }
+
+        private string Label(string? label)
+        {
+            return string.IsNullOrEmpty(label) ? "" : $"\\text{{{EscapeText(label)}:}}\\quad ";
+        }
+
+        /// <summary>
+        /// Symbol with its index sets, rendered as inline math (e.g. $c_{I,J}$)
+        /// </summary>
+        private string Symbol(string name, IEnumerable<string>? indexSets = null)
+        {
+            var indices = indexSets?.ToList() ?? new List<string>();
+            string symbol = SymbolName(name);
+            if (indices.Count > 0)
+                symbol += $"_{{{string.Join(",", indices.Select(SymbolName))}}}";
+            return InlineMath(symbol);
+        }
+
+        private static string SymbolName(string name)
+        {
+            return name.Length == 1 ? name : $"\\mathit{{{name.Replace("_", "\\_")}}}";
+        }
+
+        private static string InlineMath(string latexMath) => $"${latexMath}$";
+
+        private string Text(string? text)
+        {
+            if (string.IsNullOrEmpty(text))
+                return "";
+
+            return IsLatex ? EscapeText(text) : text.Replace("|", "\\|").Replace("\n", " ");
+        }
+
+        private static string EscapeText(string text)
+        {
+            var sb = new StringBuilder();
+            foreach (char c in text)
+            {
+                sb.Append(c switch
+                {
+                    '\\' => "\\textbackslash{}",
+                    '&' or '%' or '$' or '#' or '_' or '{' or '}' => $"\\{c}",
+                    '~' => "\\textasciitilde{}",
+                    '^' => "\\textasciicircum{}",
+                    _ => c.ToString()
+                });
+            }
+            return sb.ToString();
+        }
+    }
+}
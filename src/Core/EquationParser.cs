@@ -1,6 +1,8 @@
 using System.Text;
+using Core.Analysis;
 using Core.Models;
 using Core.Parsing;
+using Core.Services;
 using System.Text.RegularExpressions;
 
 namespace Core
@@ -86,6 +88,38 @@ namespace Core
                 return result;
             }
 
+            if (!CheckLimit(() => modelManager.Limits.CheckFileSize(text, "Model text"), 0, result))
+                return result;
+
+            NumericPrecision.ReadAnnotations(modelManager, text, result);
+            Docstrings.Read(modelManager, text, result);
+            UnitAnnotations.Read(modelManager, text, result);
+            modelManager.Report.Read(text, result);
+            if (ModelTables.IsUsed(text))
+                modelManager.Tables.Read(text, result);
+            text = Docstrings.Strip(text);
+
+            // Blocks of a "// @scoped" text have their own namespaces: local names are qualified before parsing
+            if (SymbolTable.IsScoped(text))
+            {
+                var symbols = SymbolTable.Build(text);
+                foreach (var (message, line) in symbols.Errors)
+                    result.AddError(message, line);
+                foreach (var warning in symbols.Warnings)
+                    result.AddWarning(warning);
+                text = symbols.Rewrite();
+            }
+
+            // Money in the objective is converted to the reporting currency before parsing
+            if (modelManager.Currencies.Money.Count > 0 || CurrencyTable.IsUsed(text))
+                text = modelManager.Currencies.Convert(text, result);
+
+            // max, min and count of variables over index domains become MIP terms with auxiliaries
+            if (Aggregations.IsUsed(text))
+                text = ModelLinearizer.ReformulateAggregations(text);
+
+            modelManager.SourceTexts.Add(text);
+
             // **Remove block comments FIRST**
             text = RemoveBlockComments(text);
 
@@ -99,7 +133,7 @@ namespace Core
             processedText = ExtractSubjectToBlocks(processedText, lineMapping, result);
 
             // Split into statements
-            var statements = SplitIntoStatements(processedText, lineMapping);
+            var statements = SplitIntoStatements(processedText, lineMapping, result);
 
             // Process each statement
             foreach (var (statement, lineNumber) in statements)
@@ -113,22 +147,77 @@ namespace Core
             // **REMOVED: Don't auto-expand here!**
             // Templates remain as templates until explicitly expanded
 
+            Docstrings.Apply(modelManager);
+            UnitAnnotations.Apply(modelManager);
+            modelManager.Currencies.Apply(modelManager);
+
+            CheckLimit(() => modelManager.Limits.CheckMemory(modelManager), 0, result);
             return result;
         }
 
+        /// <summary>
+        /// Runs a ModelLimits check, reporting a violation as an error at the line
+        /// </summary>
+        internal static bool CheckLimit(Action check, int lineNumber, ParseSessionResult result)
+        {
+            try
+            {
+                check();
+                return true;
+            }
+            catch (ModelLimitException ex)
+            {
+                result.AddError(ex.Message, lineNumber);
+                return false;
+            }
+        }
+
         /// <summary>
         /// Expands all constraint templates into concrete equations
         /// Call this AFTER external data has been loaded
         /// </summary>
-        public void ExpandAllTemplates(ParseSessionResult result)
-        {
+        public void ExpandAllTemplates(
+            ParseSessionResult result,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("transform", progress, cancellationToken);
+
+            // 0. Definitions that depend on themselves cannot be evaluated
+            var cycles = DefinitionCycles.Describe(modelManager).ToList();
+            foreach (var cycle in cycles)
+                result.AddError(cycle, 0);
+            if (cycles.Count > 0)
+                return;
+
+            // 0b. Scalar parameters computed by formulas see the loaded data
+            foreach (var parameter in modelManager.Parameters.Values.Where(p => p.IsScalar && p.IsComputed).ToList())
+            {
+                try
+                {
+                    parameter.Value = EvaluateComputedParameter(parameter);
+                }
+                catch (Exception ex)
+                {
+                    result.AddError($"Parameter '{parameter.Name}': {ex.Message}", 0);
+                }
+            }
+
             // 1. Expand indexed equation templates (simple forall, bracket notation)
+            tracker.Stage("templates", modelManager.IndexedEquationTemplates.Count);
             ExpandIndexedEquations(result);
 
             // 2. Expand forall statements (advanced forall with filters)
-            ExpandForallStatements(result);
+            ExpandForallStatements(result, tracker);
+
+            // 3. Evaluate bound expressions and existence conditions over the loaded data
+            BoundExpressions.Materialize(modelManager, result);
+            ExistenceConditions.Materialize(modelManager, result);
+
+            if (!CheckLimit(() => modelManager.Limits.CheckMemory(modelManager), 0, result))
+                return;
 
-            // 3. Evaluate assert statements — emit warnings for violations
+            // 4. Evaluate assert statements — emit warnings for violations
             var assertWarnings = modelManager.EvaluateAssertions();
             foreach (var warning in assertWarnings)
                 result.AddWarning(warning);
@@ -137,23 +226,25 @@ namespace Core
         /// <summary>
         /// Expands all forall statements into concrete equations
         /// </summary>
-        private void ExpandForallStatements(ParseSessionResult result)
+        private void ExpandForallStatements(ParseSessionResult result, ProgressTracker tracker)
         {
             if (modelManager.ForallStatements.Count == 0)
                 return;
 
             int beforeCount = modelManager.Equations.Count;
+            int total = modelManager.ForallStatements.Count;
+            tracker.Stage("forall", total);
 
-            foreach (var forall in modelManager.ForallStatements)
+            for (int i = 0; i < total; i++)
             {
+                var forall = modelManager.ForallStatements[i];
+                tracker.Tick(i, total, $"{modelManager.Equations.Count - beforeCount} constraints");
                 try
                 {
-                    var expandedConstraints = forall.Expand(modelManager);
-                    foreach (var constraint in expandedConstraints)
-                    {
-                        modelManager.AddEquation(constraint);
+                    // Kept as a rule; with DeferRuleExpansion its constraints are only generated by MaterializeRows
+                    int generated = modelManager.AddConstraintRule(forall);
+                    for (int n = 0; n < generated; n++)
                         result.IncrementSuccess();
-                    }
                 }
                 catch (Exception ex)
                 {
@@ -162,6 +253,7 @@ namespace Core
             }
 
             int expandedCount = modelManager.Equations.Count - beforeCount;
+            tracker.Tick(total, total, $"{expandedCount} constraints");
     
             // Clear the templates after expansion to prevent re-expansion
             modelManager.ForallStatements.Clear();
@@ -360,9 +452,22 @@ namespace Core
             return true;
         }
 
+        /// <summary>
+        /// Lines that can only begin a statement: declarations, the objective and blocks
+        /// </summary>
+        private static readonly Regex DeclarationStartPattern = new Regex(
+            @"^(dvar|dexpr|range|minimize|maximize|subject\s+to|tuple|execute|main|using)\b|^(float|int|bool|boolean|string)\+?\s+[a-zA-Z_]|^\{\w+\}\s*[a-zA-Z_]");
+
+        /// <summary>
+        /// Lines that begin a constraint: "forall(...)" or a label ("cap[i]:")
+        /// </summary>
+        private static readonly Regex ConstraintStartPattern = new Regex(
+            @"^forall\s*\(|^[a-zA-Z_]\w*(\[[^\]]*\])*\s*:(?!:)");
+
         private List<(string content, int lineNumber)> SplitIntoStatements(
             string text,
-            Dictionary<int, int> lineMapping)
+            Dictionary<int, int> lineMapping,
+            ParseSessionResult result)
         {
             // Split by line terminators, preserving empty lines for accurate line counting
             string[] lines = text.Split(new[] { "\r\n", "\n", "\r" }, StringSplitOptions.None);
@@ -386,10 +491,31 @@ namespace Core
             var statements = new List<(string content, int lineNumber)>();
             string currentStatement = "";
             int statementStartLine = 0;
+            int previousLine = 0;
             int braceDepth = 0;
 
             foreach (var (content, lineNumber) in processedLines)
             {
+                // Error recovery: a line that can only start a statement ends the unfinished one
+                // before it, so a missing ';' or '}' costs one statement, not the rest of the file
+                if (!string.IsNullOrWhiteSpace(currentStatement) && StartsStatement(content, currentStatement, braceDepth))
+                {
+                    if (braceDepth > 0)
+                    {
+                        // Unclosed tuple and execute blocks are already reported where they are extracted
+                        if (!result.Errors.Any(e => e.LineNumber == statementStartLine && e.Message.Contains("Missing closing brace")))
+                            result.AddError($"Missing closing '}}': statement skipped up to line {lineNumber}", statementStartLine);
+                    }
+                    else
+                    {
+                        result.AddError("Missing ';' at end of line", previousLine);
+                        statements.Add((currentStatement.Trim(), statementStartLine));
+                    }
+                    currentStatement = "";
+                    braceDepth = 0;
+                }
+                previousLine = lineNumber;
+
                 if (string.IsNullOrEmpty(currentStatement))
                 {
                     statementStartLine = lineNumber;
@@ -447,6 +573,33 @@ namespace Core
             return statements;
         }
 
+        /// <summary>
+        /// Whether a line begins a new statement although the current one has not ended. Only
+        /// declarations do inside braces; at depth 0 a constraint also does once the current
+        /// statement already has its relation outside brackets, since "forall(...)" (whose filter
+        /// may compare) is followed by its body.
+        /// </summary>
+        private static bool StartsStatement(string line, string currentStatement, int braceDepth)
+        {
+            if (DeclarationStartPattern.IsMatch(line))
+                return true;
+            if (braceDepth > 0 || !ConstraintStartPattern.IsMatch(line))
+                return false;
+
+            int depth = 0;
+            for (int i = 0; i + 1 < currentStatement.Length; i++)
+            {
+                char c = currentStatement[i];
+                if (c is '(' or '[')
+                    depth++;
+                else if (c is ')' or ']')
+                    depth--;
+                else if (depth == 0 && c is '<' or '>' or '=' && currentStatement[i + 1] == '=')
+                    return true;
+            }
+            return false;
+        }
+
         // Stores deferred execute blocks (JS code keyed by block number)
         private readonly Dictionary<int, string> deferredExecuteBlocks = new();
 
@@ -617,6 +770,10 @@ namespace Core
 
         private void ProcessStatement(string statement, int lineNumber, ParseSessionResult result)
         {
+            int firstError = result.Errors.Count;
+            modelManager.PendingSuggestions.Clear();
+            if (!CheckLimit(() => modelManager.Limits.CheckNesting(statement), lineNumber, result))
+                return;
             try
             {
                 ProcessStatementCore(statement, lineNumber, result);
@@ -625,6 +782,7 @@ namespace Core
             {
                 result.AddError($"\"{statement}\"\n  Error: {ex.Message}", lineNumber);
             }
+            result.AddSuggestions(modelManager.PendingSuggestions, firstError, lineNumber);
         }
 
         private void ProcessStatementCore(string statement, int lineNumber, ParseSessionResult result)
@@ -657,6 +815,14 @@ namespace Core
                 return;
             }
 
+            // 0.15. Variables and constraints with an existence condition: ... exists if has_storage[p]
+            if (TryParseExistenceCondition(statement, lineNumber, result))
+                return;
+
+            // 0.18. Scalar parameters computed by aggregations: float peak = max(t in T) demand[t]
+            if (TryParseAggregateParameter(statement, result))
+                return;
+
             // 0.2. Uninitialized multi-dimensional parameter declarations
             if (TryParseUninitializedArrayParameter(statement))
             {
@@ -1066,6 +1232,99 @@ namespace Core
                 RegexOptions.Singleline);
         }
 
+        /// <summary>
+        /// Parses a scalar parameter whose value is a formula with aggregations over index domains
+        /// (float peak = max(t in T) demand[t]). The formula is kept as the parameter's
+        /// ComputeExpression: it is evaluated now if its data is known, and again over the loaded
+        /// data when the templates are expanded.
+        /// </summary>
+        private bool TryParseAggregateParameter(string statement, ParseSessionResult result)
+        {
+            var match = Regex.Match(statement.Trim().TrimEnd(';'), @"^(int|float)\s+([a-zA-Z][a-zA-Z0-9_]*)\s*=\s*(.+)$", RegexOptions.Singleline);
+            if (!match.Success || !Aggregations.IsUsed(match.Groups[3].Value))
+                return false;
+
+            var parameter = new Parameter(match.Groups[2].Value, match.Groups[1].Value == "int" ? ParameterType.Integer : ParameterType.Float, 0)
+            {
+                ComputeExpression = ParseExpression(match.Groups[3].Value)
+            };
+            try
+            {
+                parameter.Value = EvaluateComputedParameter(parameter);
+            }
+            catch
+            {
+                // Evaluated again once the data is loaded
+                parameter.Value = null;
+            }
+
+            modelManager.AddParameter(parameter);
+            result.IncrementSuccess();
+            return true;
+        }
+
+        private object EvaluateComputedParameter(Parameter parameter)
+        {
+            double value = parameter.ComputeExpression!.Evaluate(modelManager);
+            return parameter.Type == ParameterType.Integer ? (int)Math.Round(value) : value;
+        }
+
+        /// <summary>
+        /// Parses a variable declaration or constraint followed by "exists if condition". The
+        /// condition is kept as written: variables are declared as usual and their absent elements
+        /// are found at expansion (ExistenceConditions); constraints become rules, with no
+        /// iterators for a single constraint, whose instances are generated only where it holds.
+        /// </summary>
+        private bool TryParseExistenceCondition(string statement, int lineNumber, ParseSessionResult result)
+        {
+            var match = ExistsPattern.Match(statement.Trim());
+            if (!match.Success)
+                return false;
+
+            string declaration = match.Groups[1].Value.Trim();
+            string condition = match.Groups[2].Value.Trim().TrimEnd(';').Trim();
+
+            var dvar = Regex.Match(declaration, @"^dvar\s+[a-zA-Z]+\+?\s+([a-zA-Z][a-zA-Z0-9_]*)");
+            if (dvar.Success)
+            {
+                int errors = result.Errors.Count;
+                ProcessStatementCore(declaration, lineNumber, result);
+                if (result.Errors.Count == errors && modelManager.IndexedVariables.TryGetValue(dvar.Groups[1].Value, out var variable))
+                    variable.ExistsCondition = condition;
+                return true;
+            }
+
+            ForallStatement? rule;
+            string error;
+            var forallMatch = ForallPattern.Match(declaration);
+            if (forallMatch.Success)
+            {
+                rule = ParseForallWithFilters(forallMatch.Groups[1].Value, forallMatch.Groups[2].Value.Trim(), forallMatch.Groups[3].Value.Trim(), out error);
+            }
+            else
+            {
+                var labeled = Regex.Match(declaration, @"^([a-zA-Z][a-zA-Z0-9_]*)\s*:\s*(.+)$", RegexOptions.Singleline);
+                var template = ParseConstraintTemplate(labeled.Success ? labeled.Groups[2].Value : declaration, out error);
+                rule = template == null ? null : new ForallStatement
+                {
+                    Label = labeled.Success ? labeled.Groups[1].Value : null,
+                    ConstraintTemplate = template
+                };
+            }
+
+            if (rule == null)
+            {
+                result.AddError($"\"{statement}\"\n  Error: {error}", lineNumber);
+                return true;
+            }
+
+            rule.ExistsCondition = condition;
+            rule.Source = statement.Trim();
+            modelManager.AddForallStatement(rule);
+            result.IncrementSuccess();
+            return true;
+        }
+
         /// <summary>
         /// Recognizes uninitialized multi-dimensional parameter declarations:
         ///   float Name[Set1][Set2]
@@ -1229,8 +1488,8 @@ namespace Core
             primitiveSet = null;
             error = string.Empty;
 
-            // Pattern: {int|string|float} setName = {values} or ...;
-            string pattern = @"^\s*\{(int|string|float)\}\s+([a-zA-Z][a-zA-Z0-9_]*)\s*=\s*(.+)$";
+            // Pattern: {int|string|float|timestamp} setName = {values} or ...;
+            string pattern = @"^\s*\{(int|string|float|timestamp)\}\s+([a-zA-Z][a-zA-Z0-9_]*)\s*=\s*(.+)$";
             var match = Regex.Match(statement.Trim(), pattern, RegexOptions.IgnoreCase);
 
             if (!match.Success)
@@ -1248,6 +1507,7 @@ namespace Core
                 "int" => PrimitiveSetType.Int,
                 "string" => PrimitiveSetType.String,
                 "float" => PrimitiveSetType.Float,
+                "timestamp" => PrimitiveSetType.Timestamp,
                 _ => PrimitiveSetType.Int
             };
 
@@ -1334,6 +1594,15 @@ namespace Core
                             }
 
                             break;
+
+                        case PrimitiveSetType.Timestamp:
+                            if (!PrimitiveSet.TryParseTimestamp(trimmed, out _))
+                            {
+                                error = $"Invalid timestamp value: '{trimmed}' (expected ISO 8601, e.g. \"2024-01-01T06:00\")";
+                                return false;
+                            }
+                            primitiveSet.Add(trimmed.Trim('"'));
+                            break;
                     }
                 }
                 catch (Exception ex)
@@ -1515,12 +1784,7 @@ namespace Core
         {
             error = string.Empty;
 
-            // Pattern: forall(iterators) [label:] constraint
-            // Handles all forall variants including filters, multi-dim, tuple sets
-            string forallPattern = 
-                @"^\s*forall\s*\(([^)]+)\)\s*(?:([a-zA-Z][a-zA-Z0-9_]*(?:\[[^\]]+\])*)\s*:\s*)?(.+)$";
-            var forallMatch = Regex.Match(statement.Trim(), forallPattern, 
-                RegexOptions.IgnoreCase | RegexOptions.Singleline);
+            var forallMatch = ForallPattern.Match(statement.Trim());
 
             if (forallMatch.Success)
             {
@@ -1528,7 +1792,14 @@ namespace Core
                 string labelPart = forallMatch.Groups[2].Value.Trim();
                 string constraintPart = forallMatch.Groups[3].Value.Trim();
 
-                return ParseForallWithFilters(iteratorsPart, labelPart, constraintPart, out error);
+                var forall = ParseForallWithFilters(iteratorsPart, labelPart, constraintPart, out error);
+                if (forall == null)
+                    return false;
+
+                forall.Source = statement.Trim();
+                // Store for later expansion
+                modelManager.AddForallStatement(forall);
+                return true;
             }
             
 
@@ -1569,13 +1840,13 @@ namespace Core
 
             if (!modelManager.IndexSets.ContainsKey(indexSetName1))
             {
-                error = $"First index set '{indexSetName1}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"First index set '{indexSetName1}' is not declared", indexSetName1, SymbolKind.Set);
                 return false;
             }
 
             if (!modelManager.IndexSets.ContainsKey(indexSetName2))
             {
-                error = $"Second index set '{indexSetName2}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Second index set '{indexSetName2}' is not declared", indexSetName2, SymbolKind.Set);
                 return false;
             }
 
@@ -1596,7 +1867,7 @@ namespace Core
 
             if (!modelManager.IndexSets.ContainsKey(indexSetName))
             {
-                error = $"Index set '{indexSetName}' is not declared";
+                error = SymbolSuggester.Annotate(modelManager, $"Index set '{indexSetName}' is not declared", indexSetName, SymbolKind.Set);
                 return false;
             }
 
@@ -1610,6 +1881,17 @@ namespace Core
         {
             foreach (var indexedEquation in modelManager.IndexedEquationTemplates.Values)
             {
+                long rows = IndexSetSize(indexedEquation.IndexSetName);
+                if (indexedEquation.IsTwoDimensional)
+                    rows *= IndexSetSize(indexedEquation.SecondIndexSetName!);
+                if (rows > modelManager.ExpansionLimit)
+                {
+                    result.AddError(
+                        $"Error expanding equation '{indexedEquation.BaseName}': would generate {rows} constraints, more than the limit of {modelManager.ExpansionLimit}",
+                        0);
+                    continue;
+                }
+
                 if (indexedEquation.IsTwoDimensional)
                 {
                     ExpandTwoDimensionalEquation(indexedEquation, result);
@@ -1621,6 +1903,13 @@ namespace Core
             }
         }
 
+        private long IndexSetSize(string name)
+        {
+            return modelManager.IndexSets.TryGetValue(name, out var indexSet)
+                ? Math.Max(0L, (long)indexSet.EndIndex - indexSet.StartIndex + 1)
+                : 0;
+        }
+
         private void ExpandTwoDimensionalEquation(IndexedEquation indexedEquation, ParseSessionResult result)
         {
             var indexSet1 = modelManager.IndexSets[indexedEquation.IndexSetName];
@@ -1664,7 +1953,29 @@ namespace Core
         
         
         
-        private bool ParseForallWithFilters(string iteratorsPart, string label, string constraintPart, 
+        /// <summary>
+        /// Edits a constraint rule: the forall statement replaces the rule with the given name
+        /// (its label, or "forall{n}") and its constraints are generated again
+        /// </summary>
+        public ForallStatement ReplaceRule(string name, string statement)
+        {
+            var rule = modelManager.FindRule(name)
+                ?? throw new InvalidOperationException($"Unknown constraint rule '{name}'");
+
+            string text = statement.Trim().TrimEnd(';').Trim();
+            var match = ForallPattern.Match(text);
+            if (!match.Success)
+                throw new InvalidOperationException($"'{text}' is not a forall statement");
+
+            var replacement = ParseForallWithFilters(match.Groups[1].Value, match.Groups[2].Value.Trim(), match.Groups[3].Value.Trim(), out string error)
+                ?? throw new InvalidOperationException($"Rule '{name}': {error}");
+            replacement.Source = text;
+
+            modelManager.ReplaceRule(rule, replacement);
+            return replacement;
+        }
+
+        private ForallStatement? ParseForallWithFilters(string iteratorsPart, string label, string constraintPart, 
     out string error)
 {
     error = string.Empty;
@@ -1679,26 +1990,22 @@ namespace Core
         // Format: "i in Set: filter, j in Set2: filter2, k in Set3"
         var iterators = ParseForallIteratorsWithFilters(iteratorsPart, out error);
         if (iterators == null)
-            return false;
+            return null;
 
         forall.Iterators = iterators;
 
         // Parse constraint template
         var template = ParseConstraintTemplate(constraintPart, out error);
         if (template == null)
-            return false;
+            return null;
 
         forall.ConstraintTemplate = template;
-
-        // Store for later expansion
-        modelManager.AddForallStatement(forall);
-        
-        return true;
+        return forall;
     }
     catch (Exception ex)
     {
         error = $"Error parsing forall: {ex.Message}";
-        return false;
+        return null;
     }
 }
 
@@ -2050,6 +2357,34 @@ private List<string> SplitByCommaTopLevel(string text)
                     return false;
                 }
 
+                string? leftResolved;
+                string? rightResolved;
+
+                // STEP 4.6: Replace window domains by their sets filtered to the elements of the
+                // window, before the sums over them are expanded; outside forall a window that
+                // does not fit has no instance to skip
+                if (Windows.IsUsed(leftSide) || Windows.IsUsed(rightSide))
+                {
+                    try
+                    {
+                        leftResolved = Windows.Resolve(modelManager, leftSide);
+                        rightResolved = Windows.Resolve(modelManager, rightSide);
+                    }
+                    catch (InvalidOperationException ex)
+                    {
+                        error = ex.Message;
+                        return false;
+                    }
+
+                    if (leftResolved == null || rightResolved == null)
+                    {
+                        error = "A window does not fit in its set; use clamp or wrap outside forall";
+                        return false;
+                    }
+                    leftSide = leftResolved;
+                    rightSide = rightResolved;
+                }
+
                 // STEP 5: Expand summations if present
                 leftSide = summationExpander.ExpandSummations(leftSide, out string sumError);
                 if (!string.IsNullOrEmpty(sumError))
@@ -2065,6 +2400,49 @@ private List<string> SplitByCommaTopLevel(string text)
                     return false;
                 }
 
+                // STEP 5b: Replace lead/lag calls by the elements they denote; outside forall
+                // there is no instance to skip, so a call past the end of its set is an error
+                try
+                {
+                    leftResolved = OrderedSets.Resolve(modelManager, leftSide);
+                    rightResolved = OrderedSets.Resolve(modelManager, rightSide);
+                }
+                catch (InvalidOperationException ex)
+                {
+                    error = ex.Message;
+                    return false;
+                }
+
+                if (leftResolved == null || rightResolved == null)
+                {
+                    error = "A lead/lag call is past the end of its set; use clamp, wrap or initial = ... outside forall";
+                    return false;
+                }
+                leftSide = leftResolved;
+                rightSide = rightResolved;
+
+                // STEP 5c: Replace min/max/prod/count/avg of data by their values, avg of variables by a
+                // scaled sum and count of binaries by a sum, expanded in turn; any other is not linear
+                if (Aggregations.IsUsed(leftSide) || Aggregations.IsUsed(rightSide))
+                {
+                    try
+                    {
+                        leftSide = summationExpander.ExpandSummations(Aggregations.Resolve(modelManager, leftSide), out sumError);
+                        if (string.IsNullOrEmpty(sumError))
+                            rightSide = summationExpander.ExpandSummations(Aggregations.Resolve(modelManager, rightSide), out sumError);
+                    }
+                    catch (InvalidOperationException ex)
+                    {
+                        sumError = ex.Message;
+                    }
+
+                    if (!string.IsNullOrEmpty(sumError))
+                    {
+                        error = sumError;
+                        return false;
+                    }
+                }
+
                 // STEP 6: Expand parentheses multiplication (e.g., 2*(x+y) -> 2*x+2*y)
                 leftSide = parenthesesExpander.ExpandParenthesesMultiplication(leftSide);
                 rightSide = parenthesesExpander.ExpandParenthesesMultiplication(rightSide);
@@ -2421,7 +2799,7 @@ private Dictionary<string, Expression> CombineCoefficients(
             }
 
             // Validate variables
-            if (!variableValidator.ValidateVariableDeclarations(coefficients.Keys.ToList(), out error))
+            if (!variableValidator.ValidateVariableDeclarations(coefficients.Keys.ToList(), cleaned, out error))
             {
                 return false;
             }
@@ -2680,13 +3058,18 @@ private Dictionary<string, Expression> CombineCoefficients(
                 return new TupleKeyExpression(inner);
             }
             
+            // Check for aggregations over index domains: max(i in Set) body, count(i in Set: filter) body
+            // (before math functions, whose min(a, b) and max(a, b) have the same names)
+            if (TryParseAggregation(exprStr, out var aggregationExpr))
+                return aggregationExpr!;
+
             // Check for math function calls: abs(...), sin(...), etc.
             if (TryParseMathFunction(exprStr, out var mathFuncExpr))
                 return mathFuncExpr!;
 
-            // Check for prod() aggregation: prod(i in Set) body
-            if (TryParseProdAggregation(exprStr, out var prodExpr))
-                return prodExpr!;
+            // Check for lead/lag and position operators: prev(T, t), ord(T, t), first(T)
+            if (OrderedSets.TryParse(exprStr, ParseExpression, out var orderedExpr, out _))
+                return orderedExpr!;
 
             // Check for item() function FIRST
             if (exprStr.StartsWith("item("))
@@ -2914,6 +3297,16 @@ private Dictionary<string, Expression> CombineCoefficients(
             return true;
         }
 
+        // Pattern: forall(iterators) [label:] constraint
+        // Handles all forall variants including filters, multi-dim, tuple sets
+        private static readonly Regex ExistsPattern = new Regex(
+            @"^(.+?)\s+exists\s+if\s+(.+)$",
+            RegexOptions.Singleline);
+
+        private static readonly Regex ForallPattern = new Regex(
+            @"^\s*forall\s*\(([^)]+)\)\s*(?:([a-zA-Z][a-zA-Z0-9_]*(?:\[[^\]]+\])*)\s*:\s*)?(.+)$",
+            RegexOptions.IgnoreCase | RegexOptions.Singleline);
+
         private static readonly Dictionary<string, MathFunction> MathFunctionNames = new(StringComparer.OrdinalIgnoreCase)
         {
             ["abs"]   = MathFunction.Abs,
@@ -2949,35 +3342,40 @@ private Dictionary<string, Expression> CombineCoefficients(
             return true;
         }
 
-        private bool TryParseProdAggregation(string exprStr, out Expression? result)
+        private static readonly Dictionary<string, AggregationExpression.AggregationType> AggregationNames = new()
         {
-            result = null;
-            var m = Regex.Match(exprStr, @"^\s*prod\s*\(([^)]+)\)\s*(.+)$", RegexOptions.IgnoreCase);
-            if (!m.Success) return false;
-
-            string iteratorsPart = m.Groups[1].Value;
-            string bodyStr = m.Groups[2].Value.Trim();
+            ["min"]   = AggregationExpression.AggregationType.Min,
+            ["max"]   = AggregationExpression.AggregationType.Max,
+            ["prod"]  = AggregationExpression.AggregationType.Product,
+            ["count"] = AggregationExpression.AggregationType.Count,
+            ["avg"]   = AggregationExpression.AggregationType.Average,
+        };
 
-            int colonIndex = FindTopLevelChar(iteratorsPart, ':');
-            string? filterPart = null;
-            if (colonIndex > 0)
-            {
-                filterPart = iteratorsPart.Substring(colonIndex + 1).Trim();
-                iteratorsPart = iteratorsPart.Substring(0, colonIndex).Trim();
-            }
+        /// <summary>
+        /// Parses a whole expression that is one aggregation: prod(i in Set) body,
+        /// max(i in I, j in J: i != j) body. The body is one term as for sum; an expression
+        /// that goes on after it ("max(i in I) c[i] + 1") is not one aggregation.
+        /// </summary>
+        private bool TryParseAggregation(string exprStr, out Expression? result)
+        {
+            result = null;
+            var calls = Aggregations.Find(exprStr);
+            if (calls.Count == 0 || calls[0].Index != 0 || calls[0].Length != exprStr.Length)
+                return false;
 
-            var iterators = ParseSummationIterators(iteratorsPart, out _);
+            var call = calls[0];
+            var iterators = ParseSummationIterators(call.Iterators, out _);
             if (iterators == null || iterators.Count == 0) return false;
 
-            Expression? filter = filterPart != null ? ParseExpression(filterPart) : null;
+            // A parenthesized body is parsed without its parentheses: max(i in I) (x[i] - y[i])
+            string bodyStr = call.Body;
+            if (bodyStr.StartsWith("(") && FindMatchingParen(bodyStr, 0) == bodyStr.Length - 1)
+                bodyStr = bodyStr.Substring(1, bodyStr.Length - 2).Trim();
+
+            Expression? filter = call.Filter != null ? ParseExpression(call.Filter) : null;
             Expression bodyExpr = ParseExpression(bodyStr);
 
-            // Build a product as a nested AggregationExpression if single iterator; fall back for multi
-            result = new AggregationExpression(
-                AggregationExpression.AggregationType.Product,
-                iterators[0].varName,
-                iterators[0].setName,
-                bodyExpr);
+            result = new AggregationExpression(AggregationNames[call.Function], iterators, filter, bodyExpr);
             return true;
         }
 
@@ -2994,7 +3392,8 @@ private Dictionary<string, Expression> CombineCoefficients(
     
             foreach (var part in parts)
             {
-                var match = Regex.Match(part.Trim(), @"^([a-zA-Z][a-zA-Z0-9_]*)\s+in\s+([a-zA-Z][a-zA-Z0-9_]*)$");
+                // The domain is a set, or a window over one (see Windows)
+                var match = Regex.Match(part.Trim(), @"^([a-zA-Z][a-zA-Z0-9_]*)\s+in\s+([a-zA-Z][a-zA-Z0-9_]*|window\s*\(.*\))$", RegexOptions.Singleline);
                 if (!match.Success)
                 {
                     error = $"Invalid iterator: {part}";
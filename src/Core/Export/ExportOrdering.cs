@@ -0,0 +1,85 @@
+namespace Core.Export
+{
+    /// <summary>
+    /// Canonical order of rows, columns and terms in exported files. Every mode depends only on
+    /// the model's content and declaration order, never on dictionary iteration order, so the same
+    /// model always produces byte-identical LP, MPS, MOF and FlatZinc output.
+    /// </summary>
+    public enum ExportOrdering
+    {
+        /// <summary>
+        /// Rows in the order the constraints were created; columns sorted by name (ordinal). The
+        /// default of every writer, and the layout files were written in before orderings existed.
+        /// </summary>
+        SortedColumns,
+
+        /// <summary>
+        /// Rows and columns sorted by name (ordinal); rows with equal names keep their creation order
+        /// </summary>
+        Name,
+
+        /// <summary>
+        /// Rows in the order the constraints were created; columns in order of first use (objective
+        /// first, then row by row, ties broken by name), e.g. to diff against the model text
+        /// </summary>
+        CreationOrder
+    }
+
+    /// <summary>
+    /// Applies an ExportOrdering; shared by all writers so they agree on one order
+    /// </summary>
+    internal static class ExportOrder
+    {
+        /// <summary>
+        /// Column order for the variables referenced by each row, rows given in creation order
+        /// </summary>
+        public static List<string> Columns(IEnumerable<IEnumerable<string>> rowVariables, ExportOrdering ordering)
+        {
+            var seen = new HashSet<string>(StringComparer.Ordinal);
+            var columns = new List<string>();
+
+            foreach (var row in rowVariables)
+            {
+                var added = row.Where(seen.Add).ToList();
+                added.Sort(StringComparer.Ordinal);
+                columns.AddRange(added);
+            }
+
+            if (ordering != ExportOrdering.CreationOrder)
+                columns.Sort(StringComparer.Ordinal);
+            return columns;
+        }
+
+        /// <summary>
+        /// Rows in the requested order; the sort is stable, so equal names keep creation order
+        /// </summary>
+        public static List<T> Rows<T>(IEnumerable<T> rows, Func<T, string> name, ExportOrdering ordering)
+        {
+            return ordering == ExportOrdering.Name
+                ? rows.OrderBy(name, StringComparer.Ordinal).ToList()
+                : rows.ToList();
+        }
+
+        /// <summary>
+        /// Position of each column, for ordering the terms of a row
+        /// </summary>
+        public static Dictionary<string, int> Index(IReadOnlyList<string> columns)
+        {
+            var index = new Dictionary<string, int>(columns.Count, StringComparer.Ordinal);
+            for (int i = 0; i < columns.Count; i++)
+                index[columns[i]] = i;
+            return index;
+        }
+
+        /// <summary>
+        /// Terms of a row in column order; names that are not columns go last, by name
+        /// </summary>
+        public static IEnumerable<KeyValuePair<string, TValue>> Terms<TValue>(
+            IEnumerable<KeyValuePair<string, TValue>> terms, IReadOnlyDictionary<string, int> columnIndex)
+        {
+            return terms
+                .OrderBy(t => columnIndex.TryGetValue(t.Key, out int i) ? i : int.MaxValue)
+                .ThenBy(t => t.Key, StringComparer.Ordinal);
+        }
+    }
+}
@@ -0,0 +1,416 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Import;
+using Core.Models;
+
+namespace Core.Export
+{
+    public enum ExportFormat
+    {
+        Mps,
+        Mof,
+        FlatZinc
+    }
+
+    public enum ExportNaming
+    {
+        /// <summary>
+        /// The model's own variable and constraint names
+        /// </summary>
+        Original,
+
+        /// <summary>
+        /// Variables x1, x2, ... and constraints c1, c2, ..., so the file reveals nothing but structure and numbers
+        /// </summary>
+        Anonymized
+    }
+
+    /// <summary>
+    /// Output of exporting with a profile
+    /// </summary>
+    public class ExportProfileResult
+    {
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// File extension of the format, with the dot (".mps", ".mof.json", ".fzn")
+        /// </summary>
+        public string Extension { get; init; } = "";
+
+        public List<string> Warnings { get; init; } = new List<string>();
+
+        /// <summary>
+        /// Names in the file mapped to the model's names, for names the profile changed; reads
+        /// solutions of an anonymized export back
+        /// </summary>
+        public Dictionary<string, string> Names { get; init; } = new Dictionary<string, string>(StringComparer.Ordinal);
+    }
+
+    /// <summary>
+    /// A named way of exporting a model, kept in the model text so the model carries its own
+    /// export targets:
+    /// <code>
+    /// // @export cplex-production format=mps precision=12 workarounds=no-objective-constant
+    /// // @export vendor-support format=mps names=anonymized blocks=hydro,thermal case=peak
+    /// </code>
+    /// Settings: format (mps, mof, fzn), names (original, anonymized), precision (significant
+    /// digits of every coefficient, bound and right-hand side), order (columns, the default: rows as created and columns by name; name; creation), blocks
+    /// (tags or blocks whose constraints are exported; the other constraints are left out), case
+    /// (a case overlay applied over the data) and workarounds for solvers' readers:
+    /// short-names (at most 8 characters, for fixed-format MPS readers) and no-objective-constant
+    /// (drops the objective's constant, which some readers reject).
+    /// </summary>
+    public class ExportProfile
+    {
+        public const string ShortNames = "short-names";
+        public const string NoObjectiveConstant = "no-objective-constant";
+        public const int MaxShortNameLength = 8;
+
+        public static readonly IReadOnlyList<string> KnownWorkarounds = new[] { ShortNames, NoObjectiveConstant };
+
+        public string Name { get; init; } = "";
+        public ExportFormat Format { get; init; } = ExportFormat.Mps;
+        public ExportNaming Naming { get; init; } = ExportNaming.Original;
+
+        /// <summary>
+        /// Significant digits of the written numbers, or null to write them exactly (see NumberWriter);
+        /// FlatZinc holds integers only and ignores it
+        /// </summary>
+        public int? Precision { get; init; }
+
+        public ExportOrdering Ordering { get; init; } = ExportOrdering.SortedColumns;
+
+        /// <summary>
+        /// Tags or block paths whose constraints are exported; empty exports all constraints
+        /// </summary>
+        public List<string> Blocks { get; init; } = new List<string>();
+
+        /// <summary>
+        /// Name of the case overlay applied over the data, or null for the base case
+        /// </summary>
+        public string? Case { get; init; }
+
+        public List<string> Workarounds { get; init; } = new List<string>();
+
+        public string Extension => Format switch
+        {
+            ExportFormat.Mof => ".mof.json",
+            ExportFormat.FlatZinc => ".fzn",
+            _ => ".mps"
+        };
+
+        /// <summary>
+        /// True if the profile writes the flat model rewritten (renamed, ...) rather than
+        /// straight from the expanded model
+        /// </summary>
+        private bool Rewrites => Naming != ExportNaming.Original || Workarounds.Count > 0;
+
+        /// <summary>
+        /// Parses, expands and writes the model. Model texts are the model's units (imports
+        /// first); the overlay is the profile's case, loaded by the caller. Throws
+        /// InvalidOperationException if the model does not expand without errors.
+        /// </summary>
+        public ExportProfileResult Export(IReadOnlyList<string> modelTexts, IReadOnlyList<string> dataTexts, CaseOverlay? overlay = null,
+            CancellationToken cancellationToken = default)
+        {
+            if (Case != null && overlay == null)
+                throw new InvalidOperationException($"Export profile '{Name}' needs case '{Case}'");
+
+            var texts = Blocks.Count == 0 ? modelTexts.ToList() : modelTexts.Select(t => ModelTags.Parse(t).ExtractSubset(Blocks)).ToList();
+            var manager = new ModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            if (overlay != null)
+                service.Overlays.Add(overlay);
+
+            var parsed = service.ParseModel(texts, dataTexts.ToList(), cancellationToken);
+            if (parsed.HasErrors)
+                throw new InvalidOperationException($"Cannot export with profile '{Name}': {string.Join("; ", parsed.Errors)}");
+
+            var warnings = new List<string>();
+            var names = new Dictionary<string, string>(StringComparer.Ordinal);
+            if (Rewrites)
+            {
+                var linear = Rewrite(LinearModel.FromModel(manager, Ordering, cancellationToken), names, warnings);
+                if (Format == ExportFormat.Mof)
+                {
+                    var numbers = new NumberWriter(Precision);
+                    string json = MofExporter.Write(linear, numbers);
+                    if (numbers.Warning != null)
+                        warnings.Add(numbers.Warning);
+                    return Result(json, warnings, names);
+                }
+
+                manager = new ModelManager();
+                var parser = new EquationParser(manager);
+                var reparsed = parser.Parse(linear.ToModelText());
+                if (reparsed.HasErrors)
+                    throw new InvalidOperationException($"Cannot export with profile '{Name}': {string.Join("; ", reparsed.Errors.Select(e => e.Message))}");
+                parser.ExpandAllTemplates(reparsed, cancellationToken);
+            }
+
+            string text;
+            switch (Format)
+            {
+                case ExportFormat.Mof:
+                    var mof = new MofExporter(manager) { Ordering = Ordering, Precision = Precision };
+                    text = mof.Export(Name, cancellationToken);
+                    warnings.AddRange(mof.Warnings);
+                    break;
+
+                case ExportFormat.FlatZinc:
+                    var flatZinc = new FlatZincExporter(manager) { Ordering = Ordering };
+                    text = flatZinc.Export();
+                    warnings.AddRange(flatZinc.Warnings);
+                    if (Precision != null)
+                        warnings.Add($"Precision {Precision} is ignored: FlatZinc is written in integers");
+                    break;
+
+                default:
+                    var mps = new MPSExporter(manager) { Ordering = Ordering, Precision = Precision };
+                    text = mps.Export(Naming == ExportNaming.Anonymized ? "PROBLEM" : Name, cancellationToken);
+                    warnings.AddRange(mps.Warnings);
+                    break;
+            }
+
+            return Result(text, warnings, names);
+        }
+
+        /// <summary>
+        /// The annotation line that declares this profile
+        /// </summary>
+        public override string ToString()
+        {
+            var settings = new List<string> { $"format={FormatName(Format)}" };
+            if (Naming != ExportNaming.Original)
+                settings.Add("names=anonymized");
+            if (Precision != null)
+                settings.Add($"precision={Precision.Value.ToString(CultureInfo.InvariantCulture)}");
+            if (Ordering != ExportOrdering.SortedColumns)
+                settings.Add(Ordering == ExportOrdering.Name ? "order=name" : "order=creation");
+            if (Blocks.Count > 0)
+                settings.Add($"blocks={string.Join(",", Blocks)}");
+            if (Case != null)
+                settings.Add($"case={Case}");
+            if (Workarounds.Count > 0)
+                settings.Add($"workarounds={string.Join(",", Workarounds)}");
+            return $"// @export {Name} {string.Join(" ", settings)}";
+        }
+
+        private ExportProfileResult Result(string text, List<string> warnings, Dictionary<string, string> names) =>
+            new ExportProfileResult { Text = text, Extension = Extension, Warnings = warnings, Names = names };
+
+        /// <summary>
+        /// Copy of the flat model with the profile's names and workarounds applied;
+        /// every renamed variable and constraint is recorded in <paramref name="names"/>
+        /// </summary>
+        private LinearModel Rewrite(LinearModel source, Dictionary<string, string> names, List<string> warnings)
+        {
+            var used = new HashSet<string>(StringComparer.Ordinal);
+            var variables = new Dictionary<string, string>(StringComparer.Ordinal);
+            int index = 0;
+            foreach (var variable in source.Variables)
+                variables[variable.Name] = Rename(variable.Name, "x", ++index, used, names);
+
+            var target = new LinearModel
+            {
+                Name = Naming == ExportNaming.Anonymized ? "" : source.Name,
+                ObjectiveSense = source.ObjectiveSense,
+                ObjectiveConstant = source.ObjectiveConstant
+            };
+
+            foreach (var variable in source.Variables)
+            {
+                target.Variables.Add(new LinearVariable
+                {
+                    Name = variables[variable.Name],
+                    Type = variable.Type,
+                    LowerBound = variable.LowerBound,
+                    UpperBound = variable.UpperBound
+                });
+            }
+
+            foreach (var (name, coefficient) in source.ObjectiveCoefficients)
+                target.ObjectiveCoefficients[variables[name]] = coefficient;
+
+            index = 0;
+            foreach (var constraint in source.Constraints)
+            {
+                target.Constraints.Add(new LinearConstraint
+                {
+                    Name = Rename(string.IsNullOrEmpty(constraint.Name) ? $"c{index + 1}" : constraint.Name, "c", ++index, used, names),
+                    Coefficients = constraint.Coefficients.ToDictionary(kv => variables[kv.Key], kv => kv.Value, StringComparer.Ordinal),
+                    Operator = constraint.Operator,
+                    Rhs = constraint.Rhs
+                });
+            }
+
+            if (Workarounds.Contains(NoObjectiveConstant) && target.ObjectiveConstant != 0)
+            {
+                warnings.Add($"Objective constant {NumberWriter.ToText(target.ObjectiveConstant)} left out ({NoObjectiveConstant})");
+                target.ObjectiveConstant = 0;
+            }
+
+            // Anonymized files must not leak the names through the importer's warnings either
+            if (Naming == ExportNaming.Original)
+                target.Warnings.AddRange(source.Warnings);
+            return target;
+        }
+
+        private string Rename(string name, string prefix, int index, HashSet<string> used, Dictionary<string, string> names)
+        {
+            string renamed = Naming == ExportNaming.Anonymized ? $"{prefix}{index}" : name;
+            if (Workarounds.Contains(ShortNames) && renamed.Length > MaxShortNameLength)
+            {
+                string suffix = index.ToString(CultureInfo.InvariantCulture);
+                renamed = renamed.Substring(0, Math.Max(1, MaxShortNameLength - suffix.Length)) + suffix;
+            }
+
+            // Shortened names may collide; numbered ones then take the next free number
+            for (int n = 1; used.Contains(renamed); n++)
+                renamed = $"{prefix}{index}_{n}";
+            used.Add(renamed);
+
+            if (renamed != name)
+                names[renamed] = name;
+            return renamed;
+        }
+
+        internal static string FormatName(ExportFormat format) => format switch
+        {
+            ExportFormat.Mof => "mof",
+            ExportFormat.FlatZinc => "fzn",
+            _ => "mps"
+        };
+    }
+
+    /// <summary>
+    /// The export profiles declared in a model text with "// @export" annotations (see ExportProfile)
+    /// </summary>
+    public class ExportProfiles
+    {
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@export[ \t]+(.*?)[ \t]*$", RegexOptions.Multiline);
+        private static readonly Regex settingPattern = new Regex(@"^(\w+)=(\S+)$");
+
+        private readonly List<ExportProfile> profiles = new List<ExportProfile>();
+
+        private ExportProfiles()
+        {
+        }
+
+        public IReadOnlyList<ExportProfile> Profiles => profiles;
+
+        /// <summary>
+        /// Unknown settings and values, and profiles declared twice (the first one counts)
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        public ExportProfile? Find(string name) =>
+            profiles.FirstOrDefault(p => string.Equals(p.Name, name, StringComparison.OrdinalIgnoreCase));
+
+        public static ExportProfiles Parse(string modelText)
+        {
+            var result = new ExportProfiles();
+            foreach (Match m in annotationPattern.Matches(modelText))
+            {
+                int lineNumber = modelText.Take(m.Index).Count(c => c == '\n') + 1;
+                var parts = m.Groups[1].Value.Split(new[] { ' ', '\t' }, StringSplitOptions.RemoveEmptyEntries);
+                if (parts.Length == 0 || parts[0].Contains('='))
+                {
+                    result.Warnings.Add($"Line {lineNumber}: @export without a profile name");
+                    continue;
+                }
+
+                var profile = result.ParseSettings(parts[0], parts.Skip(1), lineNumber);
+                if (result.Find(profile.Name) != null)
+                    result.Warnings.Add($"Line {lineNumber}: export profile '{profile.Name}' is declared again");
+                else
+                    result.profiles.Add(profile);
+            }
+            return result;
+        }
+
+        private ExportProfile ParseSettings(string name, IEnumerable<string> settings, int lineNumber)
+        {
+            var format = ExportFormat.Mps;
+            var naming = ExportNaming.Original;
+            int? precision = null;
+            var ordering = ExportOrdering.SortedColumns;
+            var blocks = new List<string>();
+            string? caseName = null;
+            var workarounds = new List<string>();
+
+            foreach (string setting in settings)
+            {
+                var m = settingPattern.Match(setting);
+                string key = m.Success ? m.Groups[1].Value.ToLowerInvariant() : setting;
+                string value = m.Success ? m.Groups[2].Value : "";
+                switch (key)
+                {
+                    case "format" when value.ToLowerInvariant() is "mps" or "mof" or "fzn" or "flatzinc":
+                        format = value.ToLowerInvariant() switch
+                        {
+                            "mof" => ExportFormat.Mof,
+                            "fzn" or "flatzinc" => ExportFormat.FlatZinc,
+                            _ => ExportFormat.Mps
+                        };
+                        break;
+
+                    case "names" when value.ToLowerInvariant() is "original" or "anonymized":
+                        naming = value.ToLowerInvariant() == "anonymized" ? ExportNaming.Anonymized : ExportNaming.Original;
+                        break;
+
+                    case "precision" when int.TryParse(value, NumberStyles.None, CultureInfo.InvariantCulture, out int digits) && digits is >= 1 and <= NumberWriter.MaxSignificantDigits:
+                        precision = digits;
+                        break;
+
+                    case "order" when value.ToLowerInvariant() is "columns" or "creation" or "name":
+                        ordering = value.ToLowerInvariant() switch
+                        {
+                            "creation" => ExportOrdering.CreationOrder,
+                            "name" => ExportOrdering.Name,
+                            _ => ExportOrdering.SortedColumns
+                        };
+                        break;
+
+                    case "blocks":
+                        blocks.AddRange(value.Split(',', StringSplitOptions.RemoveEmptyEntries).Select(TagPath.Normalize));
+                        break;
+
+                    case "case":
+                        caseName = value;
+                        break;
+
+                    case "workarounds":
+                        foreach (string workaround in value.ToLowerInvariant().Split(',', StringSplitOptions.RemoveEmptyEntries))
+                        {
+                            if (ExportProfile.KnownWorkarounds.Contains(workaround))
+                                workarounds.Add(workaround);
+                            else
+                                Warnings.Add($"Line {lineNumber}: unknown workaround '{workaround}' (expected {string.Join(", ", ExportProfile.KnownWorkarounds)})");
+                        }
+                        break;
+
+                    default:
+                        Warnings.Add($"Line {lineNumber}: export profile '{name}' has an invalid setting '{setting}'");
+                        break;
+                }
+            }
+
+            return new ExportProfile
+            {
+                Name = name,
+                Format = format,
+                Naming = naming,
+                Precision = precision,
+                Ordering = ordering,
+                Blocks = blocks,
+                Case = caseName,
+                Workarounds = workarounds
+            };
+        }
+    }
+}
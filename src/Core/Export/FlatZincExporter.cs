@@ -0,0 +1,193 @@
+using System.Text;
+using Core.Models;
+using Core.Services;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// Exports the discrete part of a model to FlatZinc, for CP solvers such as Chuffed or OR-Tools CP-SAT.
+    /// Linear constraints map to int_lin_le/int_lin_eq; disjunctions and implications are reified
+    /// with int_lin_*_reif and combined with bool_clause. The model language has no element or
+    /// alldifferent constraints, so none are emitted.
+    /// </summary>
+    public class FlatZincExporter
+    {
+        private static readonly HashSet<string> reservedWords = new HashSet<string>
+        {
+            "annotation", "any", "array", "bool", "case", "constraint", "diff", "div", "else", "elseif",
+            "endif", "enum", "false", "float", "function", "if", "in", "include", "int", "intersect",
+            "let", "list", "maximize", "minimize", "mod", "not", "of", "op", "output", "par", "predicate",
+            "record", "satisfy", "set", "solve", "string", "subset", "superset", "symdiff", "test",
+            "then", "true", "tuple", "type", "union", "var", "where", "xor"
+        };
+
+        private readonly ModelManager modelManager;
+        private readonly Dictionary<string, string> identifiers = new Dictionary<string, string>();
+
+        /// <summary>
+        /// Constraints and objective terms that were left out of the last export
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        /// <summary>
+        /// Order of variables, constraints and terms in the written file
+        /// </summary>
+        public ExportOrdering Ordering { get; set; } = ExportOrdering.SortedColumns;
+
+        public FlatZincExporter(ModelManager manager)
+        {
+            modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
+        }
+
+        /// <summary>
+        /// Exports the model to FlatZinc format
+        /// </summary>
+        public string Export()
+        {
+            using var phase = ModelTelemetry.StartPhase("export", ("format", "flatzinc"));
+            string text = Write();
+            ModelTelemetry.RecordExportSize("flatzinc", Encoding.UTF8.GetByteCount(text));
+            phase.Complete();
+            return text;
+        }
+
+        private string Write()
+        {
+            var model = IntegerModel.FromModel(modelManager, Ordering);
+            Warnings.Clear();
+            Warnings.AddRange(model.Warnings);
+            identifiers.Clear();
+
+            var sb = new StringBuilder();
+            foreach (var warning in model.Warnings)
+            {
+                sb.AppendLine($"% {warning}");
+            }
+
+            // All declarations must precede the constraints in FlatZinc
+            var constraints = new StringBuilder();
+            AppendVariables(sb, constraints, model);
+
+            int reified = 0;
+
+            foreach (var constraint in model.Constraints)
+            {
+                constraints.AppendLine($"constraint {FormatLinear(constraint, null)}; % {constraint.Name}");
+            }
+
+            foreach (var logical in model.LogicalConstraints)
+            {
+                string left = $"B_{++reified}";
+                string right = $"B_{++reified}";
+                sb.AppendLine($"var bool: {left} :: var_is_introduced :: is_defined_var;");
+                sb.AppendLine($"var bool: {right} :: var_is_introduced :: is_defined_var;");
+
+                constraints.AppendLine($"constraint {FormatLinear(logical.Left, left)} :: defines_var({left});");
+                constraints.AppendLine($"constraint {FormatLinear(logical.Right, right)} :: defines_var({right});");
+
+                // bool_clause(pos, neg) holds when any pos is true or any neg is false
+                string clause = logical.Type == LogicalConstraintType.Disjunctive
+                    ? $"bool_clause([{left}, {right}], [])"
+                    : $"bool_clause([{right}], [{left}])";
+                constraints.AppendLine($"constraint {clause}; % {logical.Name}");
+            }
+
+            string solve = "solve satisfy;";
+            if (model.ObjectiveCoefficients != null && model.ObjectiveCoefficients.Count > 0)
+            {
+                sb.AppendLine("var int: OBJECTIVE :: output_var;");
+
+                // OBJECTIVE = sum(c*x) + constant  <=>  sum(c*x) - OBJECTIVE = -constant
+                var coefficients = model.ObjectiveCoefficients.Values.Append(-1L);
+                var vars = model.ObjectiveCoefficients.Keys.Select(Identifier).Append("OBJECTIVE");
+                constraints.AppendLine(
+                    $"constraint int_lin_eq([{string.Join(", ", coefficients)}], [{string.Join(", ", vars)}], {-model.ObjectiveConstant}) :: defines_var(OBJECTIVE);");
+
+                string sense = model.ObjectiveSense == ObjectiveSense.Minimize ? "minimize" : "maximize";
+                solve = $"solve {sense} OBJECTIVE;";
+            }
+
+            sb.Append(constraints);
+            sb.AppendLine(solve);
+            return sb.ToString();
+        }
+
+        private void AppendVariables(StringBuilder sb, StringBuilder constraints, IntegerModel model)
+        {
+            foreach (var variable in model.Variables)
+            {
+                string domain = variable.IsBoolean
+                    ? "0..1"
+                    : variable.LowerBound.HasValue && variable.UpperBound.HasValue
+                        ? $"{variable.LowerBound}..{variable.UpperBound}"
+                        : "int";
+
+                sb.AppendLine($"var {domain}: {Identifier(variable.Name)} :: output_var;");
+
+                // Half-open bounds are not expressible as a domain
+                if (!variable.IsBoolean && variable.LowerBound.HasValue != variable.UpperBound.HasValue)
+                {
+                    if (variable.LowerBound.HasValue)
+                        constraints.AppendLine($"constraint int_le({variable.LowerBound}, {Identifier(variable.Name)});");
+                    else
+                        constraints.AppendLine($"constraint int_le({Identifier(variable.Name)}, {variable.UpperBound});");
+                }
+            }
+        }
+
+        /// <summary>
+        /// Formats a linear constraint, reified into the given boolean if one is passed
+        /// </summary>
+        private string FormatLinear(IntegerLinearConstraint constraint, string? reifiedBy)
+        {
+            var coefficients = constraint.Coefficients.Values.ToList();
+            long rhs = constraint.Rhs;
+
+            // FlatZinc only has <=, so >= is written with negated coefficients
+            if (constraint.Operator == RelationalOperator.GreaterThanOrEqual)
+            {
+                coefficients = coefficients.Select(c => -c).ToList();
+                rhs = -rhs;
+            }
+
+            string predicate = constraint.Operator == RelationalOperator.Equal ? "int_lin_eq" : "int_lin_le";
+            string vars = string.Join(", ", constraint.Coefficients.Keys.Select(Identifier));
+            string args = $"[{string.Join(", ", coefficients)}], [{vars}], {rhs}";
+
+            return reifiedBy == null
+                ? $"{predicate}({args})"
+                : $"{predicate}_reif({args}, {reifiedBy})";
+        }
+
+        private string Identifier(string name)
+        {
+            if (identifiers.TryGetValue(name, out var cached))
+                return cached;
+
+            var sb = new StringBuilder();
+            foreach (char c in name)
+            {
+                sb.Append(char.IsAsciiLetterOrDigit(c) || c == '_' ? c : '_');
+            }
+
+            string identifier = sb.ToString();
+
+            // B_n and OBJECTIVE are used for introduced variables
+            if (identifier.Length == 0 || !char.IsAsciiLetter(identifier[0]) || reservedWords.Contains(identifier) ||
+                identifier.StartsWith("B_") || identifier == "OBJECTIVE")
+            {
+                identifier = "X_" + identifier;
+            }
+
+            string unique = identifier;
+            int counter = 1;
+            while (identifiers.ContainsValue(unique))
+            {
+                unique = $"{identifier}_{counter++}";
+            }
+
+            identifiers[name] = unique;
+            return unique;
+        }
+    }
+}
@@ -0,0 +1,265 @@
+using Core.Models;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// Integer variable with finite or open bounds
+    /// </summary>
+    public class IntegerVariable
+    {
+        public string Name { get; init; } = "";
+        public long? LowerBound { get; init; }
+        public long? UpperBound { get; init; }
+        public bool IsBoolean { get; init; }
+    }
+
+    /// <summary>
+    /// Normalized linear constraint: sum(coefficients * vars) (&lt;= | &gt;= | ==) rhs, all integral
+    /// </summary>
+    public class IntegerLinearConstraint
+    {
+        public string Name { get; init; } = "";
+        public Dictionary<string, long> Coefficients { get; init; } = new Dictionary<string, long>();
+        public RelationalOperator Operator { get; init; }
+        public long Rhs { get; init; }
+    }
+
+    /// <summary>
+    /// Logical combination of two linear constraints (disjunction or implication)
+    /// </summary>
+    public class IntegerLogicalConstraint
+    {
+        public string Name { get; init; } = "";
+        public LogicalConstraintType Type { get; init; }
+        public IntegerLinearConstraint Left { get; init; } = new IntegerLinearConstraint();
+        public IntegerLinearConstraint Right { get; init; } = new IntegerLinearConstraint();
+    }
+
+    /// <summary>
+    /// The discrete part of an expanded model: integer and boolean variables, linear constraints
+    /// with integral coefficients, and logical constraints. Constraints that reference continuous
+    /// variables or have fractional data are left out and listed in Warnings.
+    /// Shared by the FlatZinc writer and the CP-SAT driver.
+    /// </summary>
+    public class IntegerModel
+    {
+        private static readonly NumericTolerance Integrality = new NumericTolerance { Absolute = 1e-9, Relative = 0 };
+
+        public List<IntegerVariable> Variables { get; } = new List<IntegerVariable>();
+        public List<IntegerLinearConstraint> Constraints { get; } = new List<IntegerLinearConstraint>();
+        public List<IntegerLogicalConstraint> LogicalConstraints { get; } = new List<IntegerLogicalConstraint>();
+
+        /// <summary>
+        /// Objective coefficients, or null when the objective is missing or not purely discrete
+        /// </summary>
+        public Dictionary<string, long>? ObjectiveCoefficients { get; private set; }
+        public long ObjectiveConstant { get; private set; }
+        public ObjectiveSense ObjectiveSense { get; private set; }
+
+        public List<string> Warnings { get; } = new List<string>();
+
+        /// <summary>
+        /// Column positions of the variables, for putting the terms of each row in canonical order
+        /// </summary>
+        private Dictionary<string, int> columnIndex = new Dictionary<string, int>();
+
+        public static IntegerModel FromModel(ModelManager manager) => FromModel(manager, ExportOrdering.SortedColumns);
+
+        /// <summary>
+        /// Extracts the discrete part with variables, constraints and terms in the given canonical order
+        /// </summary>
+        public static IntegerModel FromModel(ModelManager manager, ExportOrdering ordering)
+        {
+            if (manager.IndexedEquationTemplates.Count > 0 || manager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot export: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            var model = new IntegerModel();
+            var variables = new Dictionary<string, IntegerVariable>();
+
+            // Collect variables referenced anywhere, keeping only discrete ones
+            var equations = manager.MaterializeRows();
+            var rowVariables = new List<IEnumerable<string>>();
+            if (manager.Objective != null)
+                rowVariables.Add(manager.Objective.Coefficients.Keys);
+            rowVariables.AddRange(ConstraintTable.VariablesOf(equations));
+            rowVariables.AddRange(manager.LogicalConstraints.Select(l => l.Left.Coefficients.Keys.Concat(l.Right.Coefficients.Keys)));
+            var referenced = ExportOrder.Columns(rowVariables, ordering);
+            model.columnIndex = ExportOrder.Index(referenced);
+
+            foreach (var name in referenced)
+            {
+                var info = FindVariableInfo(manager, name);
+                if (info == null || (info.Type != VariableType.Integer && info.Type != VariableType.Boolean))
+                    continue;
+
+                var (lower, upper) = info.GetBounds(name);
+                var variable = info.Type == VariableType.Boolean
+                    ? new IntegerVariable { Name = name, LowerBound = 0, UpperBound = 1, IsBoolean = true }
+                    : new IntegerVariable
+                    {
+                        Name = name,
+                        LowerBound = lower.HasValue ? (long)Integrality.Ceiling(lower.Value) : null,
+                        UpperBound = upper.HasValue ? (long)Integrality.Floor(upper.Value) : null
+                    };
+
+                variables[name] = variable;
+                model.Variables.Add(variable);
+            }
+
+            int row = 0;
+            var constraints = new List<IntegerLinearConstraint>();
+            foreach (var equation in equations)
+            {
+                row++;
+                string name = equation.Label ?? (string.IsNullOrEmpty(equation.GetDescription()) ? $"c{row}" : equation.GetDescription());
+
+                if (model.TryConvert(manager, equation, name, variables, out var constraint))
+                    constraints.Add(constraint);
+            }
+            model.Constraints.AddRange(ExportOrder.Rows(constraints, c => c.Name, ordering));
+
+            int logicalRow = 0;
+            var logicalConstraints = new List<IntegerLogicalConstraint>();
+            foreach (var logical in manager.LogicalConstraints)
+            {
+                logicalRow++;
+                string name = logical.Label ?? $"logical{logicalRow}";
+
+                if (model.TryConvert(manager, logical.Left, name, variables, out var left) &&
+                    model.TryConvert(manager, logical.Right, name, variables, out var right))
+                {
+                    logicalConstraints.Add(new IntegerLogicalConstraint
+                    {
+                        Name = name,
+                        Type = logical.Type,
+                        Left = left,
+                        Right = right
+                    });
+                }
+            }
+            model.LogicalConstraints.AddRange(ExportOrder.Rows(logicalConstraints, c => c.Name, ordering));
+
+            if (manager.Objective != null)
+            {
+                model.ObjectiveSense = manager.Objective.Sense;
+                model.ConvertObjective(manager, manager.Objective, variables);
+            }
+
+            return model;
+        }
+
+        private bool TryConvert(ModelManager manager, LinearEquation equation, string name,
+            Dictionary<string, IntegerVariable> variables, out IntegerLinearConstraint constraint)
+        {
+            constraint = new IntegerLinearConstraint();
+            var (coefficients, constant) = equation.Evaluate(manager);
+
+            var continuous = coefficients.Keys.Where(v => !variables.ContainsKey(v)).OrderBy(v => v, StringComparer.Ordinal).FirstOrDefault();
+            if (continuous != null)
+            {
+                Warnings.Add($"Skipped '{name}': references non-discrete variable '{continuous}'");
+                return false;
+            }
+
+            if (!IsIntegral(constant) || coefficients.Values.Any(c => !IsIntegral(c)) || !IsExactlyIntegral(manager, equation))
+            {
+                Warnings.Add($"Skipped '{name}': has non-integral coefficients");
+                return false;
+            }
+
+            long rhs = (long)Math.Round(constant);
+            var op = equation.Operator;
+
+            // Strict inequalities are exact over the integers
+            if (op == RelationalOperator.LessThan)
+            {
+                op = RelationalOperator.LessThanOrEqual;
+                rhs--;
+            }
+            else if (op == RelationalOperator.GreaterThan)
+            {
+                op = RelationalOperator.GreaterThanOrEqual;
+                rhs++;
+            }
+
+            constraint = new IntegerLinearConstraint
+            {
+                Name = name,
+                Coefficients = ExportOrder.Terms(coefficients.Where(c => Math.Round(c.Value) != 0), columnIndex)
+                    .ToDictionary(c => c.Key, c => (long)Math.Round(c.Value)),
+                Operator = op,
+                Rhs = rhs
+            };
+            return true;
+        }
+
+        private void ConvertObjective(ModelManager manager, Objective objective, Dictionary<string, IntegerVariable> variables)
+        {
+            var coefficients = new Dictionary<string, long>();
+            var precision = NumericEvaluator.PrecisionOfObjective(manager);
+
+            foreach (var kvp in ExportOrder.Terms(objective.Coefficients, columnIndex))
+            {
+                double value = kvp.Value.Evaluate(manager);
+
+                if (!variables.ContainsKey(kvp.Key))
+                {
+                    Warnings.Add($"Objective dropped: references non-discrete variable '{kvp.Key}'");
+                    return;
+                }
+
+                if (!IsIntegral(value) || (precision.IsExact && !NumericEvaluator.Evaluate(kvp.Value, manager, precision).IsInteger))
+                {
+                    Warnings.Add($"Objective dropped: coefficient of '{kvp.Key}' is not integral");
+                    return;
+                }
+
+                if (Math.Round(value) != 0)
+                    coefficients[kvp.Key] = (long)Math.Round(value);
+            }
+
+            double constant = objective.Constant.Evaluate(manager);
+            if (!IsIntegral(constant) || (precision.IsExact && !NumericEvaluator.Evaluate(objective.Constant, manager, precision).IsInteger))
+            {
+                Warnings.Add("Objective dropped: constant is not integral");
+                return;
+            }
+
+            ObjectiveCoefficients = coefficients;
+            ObjectiveConstant = (long)Math.Round(constant);
+        }
+
+        /// <summary>
+        /// For rows of decimal or rational precision, integrality is decided on the exact values
+        /// rather than within the Integrality tolerance
+        /// </summary>
+        private static bool IsExactlyIntegral(ModelManager manager, LinearEquation equation)
+        {
+            if (!NumericEvaluator.PrecisionOf(manager, equation).IsExact)
+                return true;
+
+            var (coefficients, constant) = NumericEvaluator.Evaluate(equation, manager);
+            return constant.IsInteger && coefficients.Values.All(c => c.IsInteger);
+        }
+
+        private static bool IsIntegral(double value) => Integrality.IsIntegral(value);
+
+        /// <summary>
+        /// Finds the declaration of an expanded variable name (x3, flow1_2) by longest matching base name
+        /// </summary>
+        internal static IndexedVariable? FindVariableInfo(ModelManager manager, string expandedName)
+        {
+            if (manager.IndexedVariables.TryGetValue(expandedName, out var exact))
+                return exact;
+
+            return manager.IndexedVariables.Values
+                .Where(v => !v.IsScalar && expandedName.StartsWith(v.BaseName, StringComparison.Ordinal))
+                .OrderByDescending(v => v.BaseName.Length)
+                .FirstOrDefault();
+        }
+    }
+}
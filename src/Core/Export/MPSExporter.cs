@@ -1,5 +1,8 @@
+using System.Globalization;
 using System.Text;
+using Core.Analysis;
 using Core.Models;
+using Core.Services;
 
 namespace Core.Export
 {
@@ -16,15 +19,60 @@ namespace Core.Export
         {
             modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
         }
+
+        /// <summary>
+        /// When true, a "* FINGERPRINT" comment with the model content hash is written after the NAME line
+        /// </summary>
+        public bool IncludeFingerprint { get; set; }
+
+        /// <summary>
+        /// Order of rows and columns; either mode gives identical files for identical models
+        /// </summary>
+        public ExportOrdering Ordering { get; set; } = ExportOrdering.SortedColumns;
+
+        /// <summary>
+        /// Significant digits of the written values; null (the default) writes each value as the
+        /// shortest text that reads back exactly
+        /// </summary>
+        public int? Precision { get; set; }
+
+        /// <summary>
+        /// Precision-loss warnings of the last export: exact (decimal or rational) values that
+        /// MPS cannot hold and were written rounded, and values Precision changed
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        /// <summary>
+        /// Column names of the last export mapped to the model's variable names, for reading
+        /// back solutions that a solver reports under the MPS names
+        /// </summary>
+        public Dictionary<string, string> ColumnNames { get; } = new Dictionary<string, string>();
+
+        // Materialized rows of the model (a ConstraintTable is read through its row headers and
+        // term columns), then the positions of its rows and its columns in Ordering, of the export in progress
+        private IReadOnlyList<LinearEquation> materialized = Array.Empty<LinearEquation>();
+        private List<LinearEquation> equations = new List<LinearEquation>();
+        private List<int> rows = new List<int>();
+        private List<string> columns = new List<string>();
+        private NumberWriter numbers = new NumberWriter();
         
         /// <summary>
         /// Exports the model to MPS format
         /// </summary>
         /// <param name="problemName">Name of the problem (max 8 chars for compatibility)</param>
         /// <returns>MPS format string</returns>
-        public string Export(string problemName = "PROBLEM")
+        public string Export(string problemName = "PROBLEM") => Export(problemName, CancellationToken.None);
+
+        /// <summary>
+        /// Exports the model to MPS format, reporting each section and checking for cancellation between them
+        /// </summary>
+        public string Export(string problemName, CancellationToken cancellationToken, IProgress<OperationProgress>? progress = null)
         {
+            var tracker = new ProgressTracker("export", progress, cancellationToken);
+            using var phase = ModelTelemetry.StartPhase("export", ("format", "mps"));
             var sb = new StringBuilder();
+            Warnings.Clear();
+            numbers = new NumberWriter(Precision);
             
             // **Warn if templates exist but aren't expanded**
             if (modelManager.IndexedEquationTemplates.Count > 0 || 
@@ -45,27 +93,52 @@ namespace Core.Export
             problemName = SanitizeName(problemName, MAX_NAME_LENGTH);
             
             // Build unique row names BEFORE generating sections
+            materialized = modelManager.MaterializeRows();
+            equations = materialized is ConstraintTable table
+                ? Enumerable.Range(0, table.Count).Select(table.GetHeader).ToList()
+                : materialized.ToList();
             BuildUniqueRowNames();
+            rows = ExportOrder.Rows(Enumerable.Range(0, equations.Count), r => GetRowName(equations[r]), Ordering);
+            columns = GetColumns();
+            ColumnNames.Clear();
+            foreach (var varName in columns)
+                ColumnNames[SanitizeName(varName, MAX_NAME_LENGTH)] = varName;
             
             // NAME section
             sb.AppendLine($"NAME          {problemName}");
+
+            if (IncludeFingerprint)
+            {
+                sb.AppendLine(ModelFingerprint.Compute(modelManager).ToStampLine());
+            }
             
             // ROWS section
+            tracker.Stage("rows");
             AppendRowsSection(sb);
             
             // COLUMNS section
+            tracker.Stage("columns");
             AppendColumnsSection(sb);
             
             // RHS section
+            tracker.Stage("rhs");
             AppendRhsSection(sb);
             
             // BOUNDS section
+            tracker.Stage("bounds");
             AppendBoundsSection(sb);
             
             // ENDATA marker
+            tracker.Stage("done");
             sb.AppendLine("ENDATA");
-            
-            return sb.ToString();
+            if (numbers.Warning != null)
+                Warnings.Add(numbers.Warning);
+
+            string text = sb.ToString();
+            ModelTelemetry.RecordEntities("export", "constraints", equations.Count);
+            ModelTelemetry.RecordExportSize("mps", Encoding.UTF8.GetByteCount(text));
+            phase.Complete();
+            return text;
         }
         
         /// <summary>
@@ -93,7 +166,7 @@ namespace Core.Export
             rowNameCache.Clear();
             var usedNames = new HashSet<string>();
             
-            foreach (var equation in modelManager.Equations)
+            foreach (var equation in equations)
             {
                 string baseName = equation.Label ?? equation.BaseName ?? "R";
                 
@@ -137,8 +210,9 @@ namespace Core.Export
             sb.AppendLine($" N  {objName}");
             
             // Constraint rows
-            foreach (var equation in modelManager.Equations)
+            foreach (int row in rows)
             {
+                var equation = equations[row];
                 string rowType = equation.Operator switch
                 {
                     RelationalOperator.LessThanOrEqual => "L",
@@ -157,39 +231,39 @@ namespace Core.Export
         private void AppendColumnsSection(StringBuilder sb)
         {
             sb.AppendLine("COLUMNS");
+            var terms = GetTermsByColumn();
             
-            // Get all variables
-            var allVariables = GetAllVariableNames();
-            
-            foreach (var varName in allVariables.OrderBy(v => v))
+            foreach (var varName in columns)
             {
                 string colName = SanitizeName(varName, MAX_NAME_LENGTH);
 
                 // Objective coefficient
-                if (modelManager.Objective != null)
+                if (modelManager.Objective != null
+                    && modelManager.Objective.Coefficients.TryGetValue(varName, out var objExpr))
                 {
-                    double objCoeff = GetObjectiveCoefficient(varName);
-                    if (Math.Abs(objCoeff) > 1e-10)
+                    // Negate for maximization (MPS standard is minimization)
+                    string? mpsCoeff = FormatValue(objExpr, NumericEvaluator.PrecisionOfObjective(modelManager),
+                        modelManager.Objective.Sense != ObjectiveSense.Minimize, $"objective coefficient of '{varName}'");
+                    if (mpsCoeff != null)
                     {
                         string objName = SanitizeName(modelManager.Objective.Name ?? "OBJ", MAX_NAME_LENGTH);
-                        // Negate for minimization (MPS standard is minimization)
-                        double mpsCoeff = modelManager.Objective.Sense == ObjectiveSense.Minimize 
-                            ? objCoeff 
-                            : -objCoeff;
-                        sb.AppendLine($"    {colName,-10} {objName,-10} {mpsCoeff,12:G}");
+                        sb.AppendLine($"    {colName,-10} {objName,-10} {mpsCoeff}");
                     }
                 }
                 
                 // Constraint coefficients
-                for (int i = 0; i < modelManager.Equations.Count; i++)
+                if (!terms.TryGetValue(varName, out var entries))
+                    continue;
+
+                foreach (var (row, expr) in entries)
                 {
-                    var equation = modelManager.Equations[i];
-                    double coeff = GetConstraintCoefficient(equation, varName);
-                    
-                    if (Math.Abs(coeff) > 1e-10)
+                    var equation = equations[row];
+                    string rowName = GetRowName(equation);
+                    string? coeff = FormatValue(expr, NumericEvaluator.PrecisionOf(modelManager, equation),
+                        false, $"coefficient of '{varName}' in '{rowName}'");
+                    if (coeff != null)
                     {
-                        string rowName = GetRowName(equation);
-                        sb.AppendLine($"    {colName,-10} {rowName,-10} {coeff,12:G}");
+                        sb.AppendLine($"    {colName,-10} {rowName,-10} {coeff}");
                     }
                 }
             }
@@ -202,14 +276,16 @@ namespace Core.Export
             // Use a single RHS vector name
             string rhsName = "RHS1";
             
-            foreach (var equation in modelManager.Equations)
+            foreach (int row in rows)
             {
-                double rhsValue = equation.Constant.Evaluate(modelManager);
+                var equation = equations[row];
+                string rowName = GetRowName(equation);
+                string? rhsValue = FormatValue(equation.Constant, NumericEvaluator.PrecisionOf(modelManager, equation),
+                    false, $"right-hand side of '{rowName}'");
                 
-                if (Math.Abs(rhsValue) > 1e-10)
+                if (rhsValue != null)
                 {
-                    string rowName = GetRowName(equation);
-                    sb.AppendLine($"    {rhsName,-10} {rowName,-10} {rhsValue,12:G}");
+                    sb.AppendLine($"    {rhsName,-10} {rowName,-10} {rhsValue}");
                 }
             }
         }
@@ -219,9 +295,8 @@ namespace Core.Export
             sb.AppendLine("BOUNDS");
             
             string boundName = "BOUND1";
-            var allVariables = GetAllVariableNames();
             
-            foreach (var varName in allVariables.OrderBy(v => v))
+            foreach (var varName in columns)
             {
                 string colName = SanitizeName(varName, MAX_NAME_LENGTH);
                 var varInfo = GetVariableInfo(varName);
@@ -229,8 +304,9 @@ namespace Core.Export
                 if (varInfo == null)
                     continue;
                 
-                bool hasLower = varInfo.LowerBound.HasValue;
-                bool hasUpper = varInfo.UpperBound.HasValue;
+                var (lower, upper) = varInfo.GetBounds(varName);
+                bool hasLower = lower.HasValue;
+                bool hasUpper = upper.HasValue;
                 
                 if (!hasLower && !hasUpper)
                 {
@@ -239,7 +315,7 @@ namespace Core.Export
                 }
                 else if (hasLower && !hasUpper)
                 {
-                    if (varInfo.LowerBound == 0)
+                    if (lower == 0)
                     {
                         // Default lower bound is 0, so PL (positive, unbounded above)
                         sb.AppendLine($" PL {boundName,-10} {colName}");
@@ -247,29 +323,29 @@ namespace Core.Export
                     else
                     {
                         // Custom lower bound
-                        sb.AppendLine($" LO {boundName,-10} {colName,-10} {varInfo.LowerBound,12:G}");
+                        sb.AppendLine($" LO {boundName,-10} {colName,-10} {Bound(lower!.Value, "lower", varName),12}");
                     }
                 }
                 else if (!hasLower && hasUpper)
                 {
-                    if (varInfo.UpperBound == 0)
+                    if (upper == 0)
                     {
                         // Upper bound of 0
-                        sb.AppendLine($" UP {boundName,-10} {colName,-10} {0,12:G}");
+                        sb.AppendLine($" UP {boundName,-10} {colName,-10} {"0",12}");
                         sb.AppendLine($" MI {boundName,-10} {colName}");
                     }
                     else
                     {
                         // Upper bound only (implies lower = -inf)
                         sb.AppendLine($" MI {boundName,-10} {colName}");
-                        sb.AppendLine($" UP {boundName,-10} {colName,-10} {varInfo.UpperBound,12:G}");
+                        sb.AppendLine($" UP {boundName,-10} {colName,-10} {Bound(upper!.Value, "upper", varName),12}");
                     }
                 }
                 else
                 {
                     // Both bounds specified
-                    sb.AppendLine($" LO {boundName,-10} {colName,-10} {varInfo.LowerBound,12:G}");
-                    sb.AppendLine($" UP {boundName,-10} {colName,-10} {varInfo.UpperBound,12:G}");
+                    sb.AppendLine($" LO {boundName,-10} {colName,-10} {Bound(lower!.Value, "lower", varName),12}");
+                    sb.AppendLine($" UP {boundName,-10} {colName,-10} {Bound(upper!.Value, "upper", varName),12}");
                 }
                 
                 // Integer variables
@@ -284,80 +360,90 @@ namespace Core.Export
                     foreach (var (lo, hi) in varInfo.SemiContinuousRanges)
                     {
                         if (hi > 1e-10) // skip the 0..0 segment
-                            sb.AppendLine($" SC {boundName,-10} {colName,-10} {hi,12:G}");
+                            sb.AppendLine($" SC {boundName,-10} {colName,-10} {Bound(hi, "semi-continuous", varName),12}");
                     }
                 }
             }
         }
         
-        private HashSet<string> GetAllVariableNames()
+        /// <summary>
+        /// Variables of the objective and all rows, in Ordering
+        /// </summary>
+        private List<string> GetColumns()
         {
-            var variables = new HashSet<string>();
-            
-            // From objective
+            var rowVariables = new List<IEnumerable<string>>();
             if (modelManager.Objective != null)
+                rowVariables.Add(modelManager.Objective.Coefficients.Keys);
+            rowVariables.AddRange(ConstraintTable.VariablesOf(materialized));
+            return ExportOrder.Columns(rowVariables, Ordering);
+        }
+
+        /// <summary>
+        /// Constraint coefficients of each variable in row order, gathered in one pass over the rows
+        /// </summary>
+        private Dictionary<string, List<(int Row, Expression Coefficient)>> GetTermsByColumn()
+        {
+            var terms = new Dictionary<string, List<(int Row, Expression Coefficient)>>(StringComparer.Ordinal);
+
+            void Add(string varName, int row, Expression coefficient)
             {
-                foreach (var varName in modelManager.Objective.Coefficients.Keys)
-                {
-                    variables.Add(varName);
-                }
+                if (!terms.TryGetValue(varName, out var entries))
+                    terms[varName] = entries = new List<(int Row, Expression Coefficient)>();
+                entries.Add((row, coefficient));
             }
-            
-            // From constraints
-            foreach (var equation in modelManager.Equations)
+
+            var table = materialized as ConstraintTable;
+            foreach (int row in rows)
             {
-                foreach (var varName in equation.Coefficients.Keys)
+                if (table == null)
                 {
-                    variables.Add(varName);
+                    foreach (var (varName, expr) in equations[row].Coefficients)
+                        Add(varName, row, expr);
+                    continue;
                 }
+
+                var ids = table.GetTermIds(row);
+                for (int term = 0; term < ids.Length; term++)
+                    Add(table.GetName(ids[term]), row, table.GetCoefficient(row, term));
             }
-            
-            return variables;
-        }
-        
-        private double GetObjectiveCoefficient(string varName)
-        {
-            if (modelManager.Objective == null)
-                return 0.0;
-            
-            if (modelManager.Objective.Coefficients.TryGetValue(varName, out var expr))
-            {
-                return expr.Evaluate(modelManager);
-            }
-            
-            return 0.0;
+            return terms;
         }
         
-        private double GetConstraintCoefficient(LinearEquation equation, string varName)
+        /// <summary>
+        /// Value field of a COLUMNS or RHS entry, or null for a zero value. Values of exact
+        /// entities are written as exact decimals unless Precision is set; a rational without one
+        /// is rounded with a warning.
+        /// </summary>
+        private string? FormatValue(Expression expression, NumericPrecision precision, bool negate, string context)
         {
-            if (equation.Coefficients.TryGetValue(varName, out var expr))
+            if (!precision.IsExact)
             {
-                return expr.Evaluate(modelManager);
+                double value = expression.Evaluate(modelManager);
+                if (Math.Abs(value) <= 1e-10)
+                    return null;
+                return $"{numbers.Format(negate ? -value : value, context),12}";
             }
-            
-            return 0.0;
+
+            var exact = NumericEvaluator.Evaluate(expression, modelManager, precision);
+            if (negate)
+                exact = -exact;
+            if (exact.IsZero)
+                return null;
+            if (exact.IsTerminating && Precision == null)
+                return $"{exact,12}";
+
+            // With Precision the decimal is rounded like any other value and reported by numbers
+            double rounded = exact.IsTerminating ? exact.ToDouble() : NumericEvaluator.ToDouble(exact, context, Warnings);
+            return $"{numbers.Format(rounded, context),12}";
         }
+
+        private string Bound(double value, string kind, string varName) =>
+            numbers.Format(value, $"{kind} bound of '{varName}'");
         
         private IndexedVariable? GetVariableInfo(string expandedName)
         {
-            // Try to find the base variable
-            // expandedName could be "x1", "flow1_2", etc.
-            
-            foreach (var variable in modelManager.IndexedVariables.Values)
-            {
-                if (variable.IsScalar && variable.BaseName == expandedName)
-                {
-                    return variable;
-                }
-                
-                // Check if expandedName matches this variable's pattern
-                if (expandedName.StartsWith(variable.BaseName))
-                {
-                    return variable;
-                }
-            }
-            
-            return null;
+            // expandedName could be "x1", "flow1_2", etc.; the longest matching base name wins
+            return IntegerModel.FindVariableInfo(modelManager, expandedName);
         }
         
         private string GetRowName(LinearEquation equation)
@@ -368,7 +454,7 @@ namespace Core.Export
             }
             
             // Fallback (shouldn't happen if BuildUniqueRowNames was called)
-            string baseName = equation.Label ?? equation.BaseName ?? $"R{modelManager.Equations.IndexOf(equation)}";
+            string baseName = equation.Label ?? equation.BaseName ?? $"R{equations.IndexOf(equation)}";
             
             if (equation.Index.HasValue)
             {
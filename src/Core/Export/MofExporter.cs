@@ -0,0 +1,200 @@
+using System.Text;
+using System.Text.Json;
+using Core.Import;
+using Core.Models;
+using Core.Services;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// Exports expanded models to MathOptFormat (MOF.json v1.7), readable by JuMP/MathOptInterface.
+    /// Constraints become ScalarAffineFunction rows; bounds and integrality become Variable-in-set
+    /// constraints. Logical constraints have no MOF equivalent and are listed in Warnings.
+    /// </summary>
+    public class MofExporter
+    {
+        private readonly ModelManager modelManager;
+
+        /// <summary>
+        /// Parts of the model that were left out of the last export
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        /// <summary>
+        /// Order of variables, constraints and terms in the written file
+        /// </summary>
+        public ExportOrdering Ordering { get; set; } = ExportOrdering.SortedColumns;
+
+        /// <summary>
+        /// Significant digits of the written values; null (the default) writes them exactly
+        /// </summary>
+        public int? Precision { get; set; }
+
+        public MofExporter(ModelManager manager)
+        {
+            modelManager = manager ?? throw new ArgumentNullException(nameof(manager));
+        }
+
+        public string Export(string? name = null) => Export(name, CancellationToken.None);
+
+        public string Export(string? name, CancellationToken cancellationToken, IProgress<OperationProgress>? progress = null)
+        {
+            using var phase = ModelTelemetry.StartPhase("export", ("format", "mof"));
+            var model = LinearModel.FromModel(modelManager, Ordering, cancellationToken, progress);
+            cancellationToken.ThrowIfCancellationRequested();
+            if (name != null)
+                model.Name = name;
+
+            Warnings.Clear();
+            Warnings.AddRange(model.Warnings);
+            var numbers = new NumberWriter(Precision);
+            string text = Write(model, numbers);
+            if (numbers.Warning != null)
+                Warnings.Add(numbers.Warning);
+            ModelTelemetry.RecordEntities("export", "variables", model.Variables.Count);
+            ModelTelemetry.RecordEntities("export", "constraints", model.Constraints.Count);
+            ModelTelemetry.RecordExportSize("mof", Encoding.UTF8.GetByteCount(text));
+            phase.Complete();
+            return text;
+        }
+
+        /// <summary>
+        /// Writes a flat linear model as MOF.json
+        /// </summary>
+        public static string Write(LinearModel model) => Write(model, new NumberWriter());
+
+        /// <summary>
+        /// Writes a flat linear model as MOF.json with its values rounded by <paramref name="numbers"/>
+        /// </summary>
+        public static string Write(LinearModel model, NumberWriter numbers)
+        {
+            // Terms follow the order of the variables, whatever order their dictionaries hold
+            var columnIndex = ExportOrder.Index(model.Variables.Select(v => v.Name).ToList());
+
+            using var stream = new MemoryStream();
+            using (var writer = new Utf8JsonWriter(stream, new JsonWriterOptions { Indented = true }))
+            {
+                writer.WriteStartObject();
+                writer.WriteString("name", string.IsNullOrEmpty(model.Name) ? "MathOptFormat Model" : model.Name);
+                writer.WriteStartObject("version");
+                writer.WriteNumber("major", 1);
+                writer.WriteNumber("minor", 7);
+                writer.WriteEndObject();
+
+                writer.WriteStartArray("variables");
+                foreach (var variable in model.Variables)
+                {
+                    writer.WriteStartObject();
+                    writer.WriteString("name", variable.Name);
+                    writer.WriteEndObject();
+                }
+                writer.WriteEndArray();
+
+                writer.WriteStartObject("objective");
+                writer.WriteString("sense", model.ObjectiveSense == ObjectiveSense.Maximize ? "max" : "min");
+                writer.WritePropertyName("function");
+                WriteAffine(writer, model.ObjectiveCoefficients, model.ObjectiveConstant, columnIndex, numbers, "objective");
+                writer.WriteEndObject();
+
+                writer.WriteStartArray("constraints");
+                foreach (var constraint in model.Constraints)
+                {
+                    writer.WriteStartObject();
+                    writer.WriteString("name", constraint.Name);
+                    writer.WritePropertyName("function");
+                    WriteAffine(writer, constraint.Coefficients, 0, columnIndex, numbers, $"'{constraint.Name}'");
+                    writer.WritePropertyName("set");
+                    WriteSet(writer, constraint.Operator, numbers.Round(constraint.Rhs, $"right-hand side of '{constraint.Name}'"));
+                    writer.WriteEndObject();
+                }
+
+                foreach (var variable in model.Variables)
+                    WriteVariableSets(writer, variable, numbers);
+
+                writer.WriteEndArray();
+                writer.WriteEndObject();
+            }
+
+            return Encoding.UTF8.GetString(stream.ToArray());
+        }
+
+        private static void WriteAffine(Utf8JsonWriter writer, Dictionary<string, double> coefficients, double constant, Dictionary<string, int> columnIndex,
+            NumberWriter numbers, string row)
+        {
+            writer.WriteStartObject();
+            writer.WriteString("type", "ScalarAffineFunction");
+            writer.WriteStartArray("terms");
+            foreach (var (variable, coefficient) in ExportOrder.Terms(coefficients, columnIndex))
+            {
+                writer.WriteStartObject();
+                writer.WriteNumber("coefficient", numbers.Round(coefficient, $"coefficient of '{variable}' in {row}"));
+                writer.WriteString("variable", variable);
+                writer.WriteEndObject();
+            }
+            writer.WriteEndArray();
+            writer.WriteNumber("constant", numbers.Round(constant, $"constant of {row}"));
+            writer.WriteEndObject();
+        }
+
+        private static void WriteSet(Utf8JsonWriter writer, RelationalOperator op, double rhs)
+        {
+            writer.WriteStartObject();
+            switch (op)
+            {
+                case RelationalOperator.LessThan:
+                case RelationalOperator.LessThanOrEqual:
+                    writer.WriteString("type", "LessThan");
+                    writer.WriteNumber("upper", rhs);
+                    break;
+                case RelationalOperator.GreaterThan:
+                case RelationalOperator.GreaterThanOrEqual:
+                    writer.WriteString("type", "GreaterThan");
+                    writer.WriteNumber("lower", rhs);
+                    break;
+                default:
+                    writer.WriteString("type", "EqualTo");
+                    writer.WriteNumber("value", rhs);
+                    break;
+            }
+            writer.WriteEndObject();
+        }
+
+        private static void WriteVariableSets(Utf8JsonWriter writer, LinearVariable variable, NumberWriter numbers)
+        {
+            if (variable.Type == VariableType.Boolean)
+            {
+                WriteVariableSet(writer, variable.Name, "ZeroOne");
+                return;
+            }
+
+            if (variable.Type == VariableType.Integer)
+                WriteVariableSet(writer, variable.Name, "Integer");
+
+            double? lower = variable.LowerBound.HasValue ? numbers.Round(variable.LowerBound.Value, $"lower bound of '{variable.Name}'") : null;
+            double? upper = variable.UpperBound.HasValue ? numbers.Round(variable.UpperBound.Value, $"upper bound of '{variable.Name}'") : null;
+            if (lower.HasValue && upper.HasValue && lower == upper)
+                WriteVariableSet(writer, variable.Name, "EqualTo", ("value", lower.Value));
+            else if (lower.HasValue && upper.HasValue)
+                WriteVariableSet(writer, variable.Name, "Interval", ("lower", lower.Value), ("upper", upper.Value));
+            else if (lower.HasValue)
+                WriteVariableSet(writer, variable.Name, "GreaterThan", ("lower", lower.Value));
+            else if (upper.HasValue)
+                WriteVariableSet(writer, variable.Name, "LessThan", ("upper", upper.Value));
+        }
+
+        private static void WriteVariableSet(Utf8JsonWriter writer, string variable, string setType, params (string Name, double Value)[] values)
+        {
+            writer.WriteStartObject();
+            writer.WriteStartObject("function");
+            writer.WriteString("type", "Variable");
+            writer.WriteString("name", variable);
+            writer.WriteEndObject();
+            writer.WriteStartObject("set");
+            writer.WriteString("type", setType);
+            foreach (var (name, value) in values)
+                writer.WriteNumber(name, value);
+            writer.WriteEndObject();
+            writer.WriteEndObject();
+        }
+    }
+}
@@ -0,0 +1,107 @@
+using System.Globalization;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// Writes the numbers of an export. By default a value is written as the shortest text that
+    /// reads back as the same double (0.1 rather than 0.10000000000000001, 1E-30 rather than 0);
+    /// with SignificantDigits it is rounded to that many digits first. Values the rounding
+    /// changes are counted, and Warning reports the largest relative change of the export.
+    /// </summary>
+    public class NumberWriter
+    {
+        public const int MaxSignificantDigits = 17;
+
+        public NumberWriter(int? significantDigits = null)
+        {
+            if (significantDigits is < 1 or > MaxSignificantDigits)
+                throw new ArgumentOutOfRangeException(nameof(significantDigits), $"Precision must be 1 to {MaxSignificantDigits} significant digits");
+            SignificantDigits = significantDigits;
+        }
+
+        /// <summary>
+        /// Significant digits of the written values, or null to write every value exactly
+        /// </summary>
+        public int? SignificantDigits { get; }
+
+        /// <summary>
+        /// Number of values written so far, and how many of them the rounding changed
+        /// </summary>
+        public int Written { get; private set; }
+        public int Changed { get; private set; }
+
+        /// <summary>
+        /// Largest relative change of a written value, with what the value was and how it was written
+        /// </summary>
+        public double LargestChange { get; private set; }
+        public string? LargestChangeContext { get; private set; }
+
+        /// <summary>
+        /// Summary of the values the precision changed, or null if it lost nothing
+        /// </summary>
+        public string? Warning => Changed == 0 ? null :
+            $"Precision of {SignificantDigits} significant digits changed {Changed} of {Written} values; " +
+            $"the largest relative change is {LargestChange.ToString("G2", CultureInfo.InvariantCulture)}: {LargestChangeContext}";
+
+        /// <summary>
+        /// The value as it will be written, for writers that write doubles themselves (e.g. JSON)
+        /// </summary>
+        public double Round(double value, string context)
+        {
+            Written++;
+            if (SignificantDigits == null || value == 0 || double.IsInfinity(value) || double.IsNaN(value))
+                return value;
+
+            double rounded = double.Parse(value.ToString("G" + SignificantDigits.Value.ToString(CultureInfo.InvariantCulture), CultureInfo.InvariantCulture),
+                CultureInfo.InvariantCulture);
+            if (rounded != value)
+            {
+                Changed++;
+                double change = Math.Abs(rounded - value) / Math.Abs(value);
+                if (change > LargestChange)
+                {
+                    LargestChange = change;
+                    LargestChangeContext = $"{context} {ToText(value)} written as {ToText(rounded)}";
+                }
+            }
+            return rounded;
+        }
+
+        /// <summary>
+        /// The value as text in the invariant culture, exponent notation for very large or small values
+        /// </summary>
+        public string Format(double value, string context) => ToText(Round(value, context));
+
+        /// <summary>
+        /// Shortest round-trip text of a value in the invariant culture
+        /// </summary>
+        public static string ToText(double value) => value.ToString("R", CultureInfo.InvariantCulture);
+
+        /// <summary>
+        /// Shortest round-trip digits of a value written without an exponent, for formats
+        /// whose readers do not take one ("1E-07" becomes "0.0000001")
+        /// </summary>
+        public static string Positional(double value)
+        {
+            string text = ToText(value);
+            int e = text.IndexOf('E');
+            if (e < 0)
+                return text;
+
+            bool negative = text[0] == '-';
+            string mantissa = text.Substring(negative ? 1 : 0, e - (negative ? 1 : 0));
+            int exponent = int.Parse(text.Substring(e + 1), NumberStyles.AllowLeadingSign, CultureInfo.InvariantCulture);
+            int point = mantissa.IndexOf('.');
+            string digits = mantissa.Replace(".", "");
+
+            // Position of the decimal point within the digits
+            int position = (point < 0 ? mantissa.Length : point) + exponent;
+            string positional = position <= 0
+                ? "0." + new string('0', -position) + digits
+                : position >= digits.Length
+                    ? digits + new string('0', position - digits.Length)
+                    : digits.Substring(0, position) + "." + digits.Substring(position);
+            return negative ? "-" + positional : positional;
+        }
+    }
+}
@@ -0,0 +1,434 @@
+using System.Globalization;
+using System.IO.Compression;
+using System.Reflection;
+using System.Runtime.InteropServices;
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Analysis;
+using Core.Models;
+using Core.Solving;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// A file captured in a run bundle
+    /// </summary>
+    public class BundleFile
+    {
+        /// <summary>
+        /// "model", "data", "case" or "settings"
+        /// </summary>
+        public string Role { get; init; } = "";
+
+        /// <summary>
+        /// File name without directory, unique per role
+        /// </summary>
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// SHA-256 of the UTF-8 content
+        /// </summary>
+        public string Hash { get; init; } = "";
+
+        [JsonIgnore]
+        public string Text { get; init; } = "";
+
+        internal string EntryName => $"{Role}/{Name}";
+
+        public override string ToString() => EntryName;
+    }
+
+    /// <summary>
+    /// The machine and runtime a bundle was created on
+    /// </summary>
+    public class RunEnvironment
+    {
+        public string OperatingSystem { get; init; } = "";
+        public string Runtime { get; init; } = "";
+        public string Architecture { get; init; } = "";
+        public int ProcessorCount { get; init; }
+        public string Culture { get; init; } = "";
+
+        public static RunEnvironment Current() => new RunEnvironment
+        {
+            OperatingSystem = RuntimeInformation.OSDescription,
+            Runtime = RuntimeInformation.FrameworkDescription,
+            Architecture = RuntimeInformation.ProcessArchitecture.ToString(),
+            ProcessorCount = Environment.ProcessorCount,
+            Culture = CultureInfo.CurrentCulture.Name
+        };
+    }
+
+    /// <summary>
+    /// Outcome of the run a bundle was taken from, for checking a reproduction against
+    /// </summary>
+    public class RunBundleResult
+    {
+        public SolveStatus Status { get; init; }
+        public double? ObjectiveValue { get; init; }
+        public double? BestBound { get; init; }
+        public double? MipGap { get; init; }
+        public double SolveSeconds { get; init; }
+        public string? StatusMessage { get; init; }
+        public SortedDictionary<string, double> VariableValues { get; init; } = new SortedDictionary<string, double>(StringComparer.Ordinal);
+        public Dictionary<string, double> Kpis { get; init; } = new Dictionary<string, double>(StringComparer.Ordinal);
+
+        public static RunBundleResult From(SolveResult result) => new RunBundleResult
+        {
+            Status = result.Status,
+            ObjectiveValue = result.ObjectiveValue,
+            BestBound = result.BestBound,
+            MipGap = result.MipGap,
+            SolveSeconds = result.SolveTime.TotalSeconds,
+            StatusMessage = result.StatusMessage,
+            VariableValues = new SortedDictionary<string, double>(result.VariableValues, StringComparer.Ordinal),
+            Kpis = new Dictionary<string, double>(result.Kpis, StringComparer.Ordinal)
+        };
+    }
+
+    /// <summary>
+    /// Everything needed to reproduce a run: model and data files, the settings file, solver name
+    /// and options, the package version and the environment, plus the result that was obtained.
+    /// Export writes it as a single zip archive (bundle.json plus the files); Import reads one back,
+    /// verifying every file against its hash, and Restore writes the files out as a run configuration.
+    /// </summary>
+    public class RunBundle
+    {
+        public const string FormatName = "modeleditor-run-bundle";
+        public const string ManifestEntryName = "bundle.json";
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            Converters = { new JsonStringEnumConverter() },
+            WriteIndented = true
+        };
+
+        private static readonly Type[] optionTypes =
+        {
+            typeof(string), typeof(bool), typeof(int), typeof(long), typeof(double), typeof(TimeSpan)
+        };
+
+        public string Format { get; init; } = FormatName;
+        public int FormatVersion { get; init; } = 1;
+        public string Name { get; set; } = "";
+        public DateTime CreatedAt { get; init; } = DateTime.UtcNow;
+
+        /// <summary>
+        /// Version of the Core assembly that produced the run
+        /// </summary>
+        public string PackageVersion { get; init; } = CurrentPackageVersion;
+
+        public RunEnvironment Environment { get; init; } = RunEnvironment.Current();
+
+        /// <summary>
+        /// Name of the solver driver (ISolverDriver.Name), or null if the run was not solved
+        /// </summary>
+        public string? Solver { get; set; }
+
+        /// <summary>
+        /// Settable scalar properties of the solver driver (time limit, workers, ...) in invariant text form
+        /// </summary>
+        public SortedDictionary<string, string> SolverOptions { get; init; } = new SortedDictionary<string, string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Content hash of the expanded model (ModelFingerprint), if known
+        /// </summary>
+        public string? Fingerprint { get; set; }
+
+        public RunBundleResult? Result { get; set; }
+
+        public List<BundleFile> Files { get; init; } = new List<BundleFile>();
+
+        public static string CurrentPackageVersion
+        {
+            get
+            {
+                var assembly = typeof(RunBundle).Assembly;
+                return assembly.GetCustomAttribute<AssemblyInformationalVersionAttribute>()?.InformationalVersion
+                    ?? assembly.GetName().Version?.ToString()
+                    ?? "unknown";
+            }
+        }
+
+        [JsonIgnore]
+        public IEnumerable<BundleFile> ModelFiles => Files.Where(f => f.Role == "model");
+
+        [JsonIgnore]
+        public IEnumerable<BundleFile> DataFiles => Files.Where(f => f.Role == "data");
+
+        [JsonIgnore]
+        public IEnumerable<BundleFile> CaseFiles => Files.Where(f => f.Role == "case");
+
+        [JsonIgnore]
+        public BundleFile? SettingsFile => Files.FirstOrDefault(f => f.Role == "settings");
+
+        /// <summary>
+        /// Bundles the files of a run configuration (model, data, case overlay and settings files)
+        /// </summary>
+        public static RunBundle FromConfiguration(RunConfiguration configuration)
+        {
+            if (!configuration.ValidateFiles(out var missing))
+                throw new InvalidOperationException($"Missing files: {string.Join(", ", missing)}");
+
+            var bundle = new RunBundle { Name = configuration.Name };
+            foreach (var file in configuration.ModelFiles)
+                bundle.AddFile("model", Path.GetFileName(file), File.ReadAllText(file));
+            foreach (var file in configuration.DataFiles)
+                bundle.AddFile("data", Path.GetFileName(file), File.ReadAllText(file));
+            foreach (var file in configuration.OverlayFiles)
+                bundle.AddFile("case", Path.GetFileName(file), File.ReadAllText(file));
+            if (!string.IsNullOrEmpty(configuration.SettingsFile))
+                bundle.AddFile("settings", Path.GetFileName(configuration.SettingsFile), File.ReadAllText(configuration.SettingsFile));
+            return bundle;
+        }
+
+        public BundleFile AddFile(string role, string name, string text)
+        {
+            if (role is not ("model" or "data" or "case" or "settings"))
+                throw new ArgumentException($"Unknown bundle file role '{role}'", nameof(role));
+            if (string.IsNullOrEmpty(name) || name != Path.GetFileName(name))
+                throw new ArgumentException($"Invalid bundle file name '{name}'", nameof(name));
+            if (Files.Any(f => f.Role == role && f.Name == name))
+                throw new InvalidOperationException($"Bundle already contains {role}/{name}");
+
+            var file = new BundleFile { Role = role, Name = name, Text = text, Hash = Hash(text) };
+            Files.Add(file);
+            return file;
+        }
+
+        /// <summary>
+        /// Records the solver and its scalar settings; properties left at null are not recorded
+        /// </summary>
+        public void CaptureSolver(ISolverDriver driver)
+        {
+            Solver = driver.Name;
+            SolverOptions.Clear();
+            foreach (var (name, value) in ReadSolverOptions(driver))
+                SolverOptions[name] = value;
+        }
+
+        /// <summary>
+        /// The driver's scalar settings (public read/write properties of simple types) in invariant
+        /// text form, sorted by name; properties left at null are left out
+        /// </summary>
+        public static SortedDictionary<string, string> ReadSolverOptions(ISolverDriver driver)
+        {
+            var options = new SortedDictionary<string, string>(StringComparer.Ordinal);
+            foreach (var property in OptionProperties(driver))
+            {
+                object? value = property.GetValue(driver);
+                if (value != null)
+                    options[property.Name] = FormatOption(value);
+            }
+            return options;
+        }
+
+        /// <summary>
+        /// Sets the recorded options on a driver of the same solver. Returns the options the
+        /// driver does not have (e.g. after an upgrade removed a setting).
+        /// </summary>
+        public IReadOnlyList<string> ApplySolverOptions(ISolverDriver driver)
+        {
+            if (Solver != null && driver.Name != Solver)
+                throw new InvalidOperationException($"Bundle was solved with '{Solver}', not '{driver.Name}'");
+
+            var properties = OptionProperties(driver).ToDictionary(p => p.Name);
+            var unknown = new List<string>();
+            foreach (var (name, text) in SolverOptions)
+            {
+                if (!properties.TryGetValue(name, out var property))
+                {
+                    unknown.Add(name);
+                    continue;
+                }
+                property.SetValue(driver, ParseOption(text, property.PropertyType));
+            }
+            return unknown;
+        }
+
+        /// <summary>
+        /// Differences between the recorded environment and the current one, e.g.
+        /// "Package version: 1.2.0 (now 1.3.0)"; empty when they match
+        /// </summary>
+        public IReadOnlyList<string> CompareEnvironment()
+        {
+            var current = RunEnvironment.Current();
+            var differences = new List<string>();
+
+            void Compare(string label, string recorded, string now)
+            {
+                if (recorded != now)
+                    differences.Add($"{label}: {recorded} (now {now})");
+            }
+
+            Compare("Package version", PackageVersion, CurrentPackageVersion);
+            Compare("Operating system", Environment.OperatingSystem, current.OperatingSystem);
+            Compare("Runtime", Environment.Runtime, current.Runtime);
+            Compare("Architecture", Environment.Architecture, current.Architecture);
+            Compare("Processors", Environment.ProcessorCount.ToString(CultureInfo.InvariantCulture), current.ProcessorCount.ToString(CultureInfo.InvariantCulture));
+            Compare("Culture", Environment.Culture, current.Culture);
+            return differences;
+        }
+
+        public void Export(string path)
+        {
+            using var stream = File.Create(path);
+            Export(stream);
+        }
+
+        /// <summary>
+        /// Writes the bundle as a zip archive. Entries are written in a fixed order so the same
+        /// bundle always produces the same entry layout.
+        /// </summary>
+        public void Export(Stream stream)
+        {
+            using var archive = new ZipArchive(stream, ZipArchiveMode.Create, leaveOpen: true);
+
+            WriteEntry(archive, ManifestEntryName, JsonSerializer.SerializeToUtf8Bytes(this, jsonOptions));
+            foreach (var file in Files)
+                WriteEntry(archive, file.EntryName, Encoding.UTF8.GetBytes(file.Text));
+        }
+
+        public static RunBundle Import(string path)
+        {
+            using var stream = File.OpenRead(path);
+            return Import(stream);
+        }
+
+        /// <summary>
+        /// Reads a bundle archive. Throws if the format is unknown or any file does not match its hash.
+        /// </summary>
+        public static RunBundle Import(Stream stream)
+        {
+            using var archive = new ZipArchive(stream, ZipArchiveMode.Read, leaveOpen: true);
+
+            var manifestEntry = archive.GetEntry(ManifestEntryName)
+                ?? throw new InvalidOperationException($"Not a run bundle: {ManifestEntryName} is missing");
+            RunBundle manifest;
+            using (var manifestStream = manifestEntry.Open())
+            {
+                manifest = JsonSerializer.Deserialize<RunBundle>(manifestStream, jsonOptions)
+                    ?? throw new InvalidOperationException("Empty run bundle manifest");
+            }
+
+            if (manifest.Format != FormatName || manifest.FormatVersion != 1)
+                throw new InvalidOperationException($"Unsupported run bundle format '{manifest.Format}' version {manifest.FormatVersion}");
+
+            var files = new List<BundleFile>();
+            foreach (var file in manifest.Files)
+            {
+                var entry = archive.GetEntry(file.EntryName)
+                    ?? throw new InvalidOperationException($"Run bundle is missing {file.EntryName}");
+
+                string text;
+                using (var reader = new StreamReader(entry.Open(), Encoding.UTF8))
+                {
+                    text = reader.ReadToEnd();
+                }
+
+                if (Hash(text) != file.Hash)
+                    throw new InvalidOperationException($"Run bundle file {file.EntryName} does not match its hash");
+
+                files.Add(new BundleFile { Role = file.Role, Name = file.Name, Hash = file.Hash, Text = text });
+            }
+
+            manifest.Files.Clear();
+            manifest.Files.AddRange(files);
+            return manifest;
+        }
+
+        /// <summary>
+        /// Writes the bundled files to a directory (model/, data/, settings/) and returns a run
+        /// configuration for them. Solver and options are kept in the configuration's metadata
+        /// ("solver", "solver.TimeLimit", ...).
+        /// </summary>
+        public RunConfiguration Restore(string directory)
+        {
+            var configuration = new RunConfiguration
+            {
+                Name = Name,
+                Description = $"Restored from run bundle created {CreatedAt:u}",
+                WorkingDirectory = directory
+            };
+
+            foreach (var file in Files)
+            {
+                string path = Path.Combine(directory, file.Role, file.Name);
+                Directory.CreateDirectory(Path.GetDirectoryName(path)!);
+                File.WriteAllText(path, file.Text);
+
+                if (file.Role == "model")
+                    configuration.ModelFiles.Add(path);
+                else if (file.Role == "data")
+                    configuration.DataFiles.Add(path);
+                else if (file.Role == "case")
+                    configuration.OverlayFiles.Add(path);
+                else
+                    configuration.SettingsFile = path;
+            }
+
+            if (Solver != null)
+                configuration.Metadata["solver"] = Solver;
+            foreach (var (name, value) in SolverOptions)
+                configuration.Metadata[$"solver.{name}"] = value;
+            if (Fingerprint != null)
+                configuration.Metadata["fingerprint"] = Fingerprint;
+
+            return configuration;
+        }
+
+        /// <summary>
+        /// Checks that the model text, once parsed and expanded, still has the recorded fingerprint
+        /// </summary>
+        public bool VerifyFingerprint(ModelManager expanded)
+        {
+            return Fingerprint == null || ModelFingerprint.Compute(expanded).ModelHash == Fingerprint;
+        }
+
+        private static IEnumerable<PropertyInfo> OptionProperties(ISolverDriver driver)
+        {
+            return driver.GetType()
+                .GetProperties(BindingFlags.Public | BindingFlags.Instance)
+                .Where(p => p.GetGetMethod() != null && p.GetSetMethod() != null && p.GetIndexParameters().Length == 0)
+                .Where(p => optionTypes.Contains(Nullable.GetUnderlyingType(p.PropertyType) ?? p.PropertyType)
+                    || (Nullable.GetUnderlyingType(p.PropertyType) ?? p.PropertyType).IsEnum)
+                .OrderBy(p => p.Name, StringComparer.Ordinal);
+        }
+
+        private static string FormatOption(object value) => value switch
+        {
+            TimeSpan span => span.ToString("c", CultureInfo.InvariantCulture),
+            double number => number.ToString("R", CultureInfo.InvariantCulture),
+            bool flag => flag ? "true" : "false",
+            IFormattable formattable => formattable.ToString(null, CultureInfo.InvariantCulture),
+            _ => value.ToString() ?? ""
+        };
+
+        private static object ParseOption(string text, Type type)
+        {
+            var target = Nullable.GetUnderlyingType(type) ?? type;
+            if (target == typeof(string))
+                return text;
+            if (target == typeof(TimeSpan))
+                return TimeSpan.ParseExact(text, "c", CultureInfo.InvariantCulture);
+            if (target.IsEnum)
+                return Enum.Parse(target, text);
+            return Convert.ChangeType(text, target, CultureInfo.InvariantCulture);
+        }
+
+        private static void WriteEntry(ZipArchive archive, string name, byte[] content)
+        {
+            // A fixed timestamp keeps archives of identical bundles byte-identical
+            var entry = archive.CreateEntry(name, CompressionLevel.Optimal);
+            entry.LastWriteTime = new DateTimeOffset(2000, 1, 1, 0, 0, 0, TimeSpan.Zero);
+            using var stream = entry.Open();
+            stream.Write(content);
+        }
+
+        private static string Hash(string text) => Convert.ToHexString(SHA256.HashData(Encoding.UTF8.GetBytes(text))).ToLowerInvariant();
+    }
+}
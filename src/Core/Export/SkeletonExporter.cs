@@ -0,0 +1,76 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Export
+{
+    /// <summary>
+    /// Exports the structure of a model without its data: sets, parameter declarations, variables,
+    /// decision expressions and constraint families are written as in the source, with their
+    /// symbolic expressions, but values given inline are replaced by "..." so they have to come
+    /// from a data file. The formulation can then be reviewed and versioned apart from
+    /// confidential input data. Initializers computed from other declarations
+    /// ("float total = sum(i in I) cost[i];") are structure and are kept, as are range bounds.
+    /// </summary>
+    public class SkeletonExporter
+    {
+        private static readonly Regex initializerPattern = new Regex(
+            @"^(?<head>(?:\{[^}]*\}|[A-Za-z_]\w*\+?)\s+(?<name>[A-Za-z_]\w*)(?:\s*\[[^\]]*\])*\s*=)(?!=)\s*(?<value>.*?)\s*;?\s*$",
+            RegexOptions.Singleline);
+
+        private static readonly Regex identifierPattern = new Regex(@"\b[A-Za-z_]\w*");
+        private static readonly Regex stringPattern = new Regex(@"""(?:[^""\\]|\\.)*""");
+        private static readonly HashSet<string> literalWords = new HashSet<string>(StringComparer.Ordinal) { "true", "false", "infinity", "maxint" };
+
+        /// <summary>
+        /// Names of the declarations whose values were left out by the last export
+        /// </summary>
+        public List<string> Omitted { get; } = new List<string>();
+
+        /// <summary>
+        /// Statements kept as they are that may still hold data (script blocks)
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        public string Export(string modelText)
+        {
+            Omitted.Clear();
+            Warnings.Clear();
+            var source = ModelSource.Parse(modelText);
+
+            foreach (var statement in source.Statements.ToList())
+            {
+                string code = statement.Code;
+                if (Regex.IsMatch(code, @"^(execute|main)\b"))
+                {
+                    Warnings.Add($"Line {statement.LineNumber}: script block kept as is; check it for data");
+                    continue;
+                }
+
+                var match = initializerPattern.Match(code);
+                if (!match.Success || code.StartsWith("range", StringComparison.Ordinal) || !IsLiteral(match.Groups["value"].Value))
+                    continue;
+
+                source.Replace(statement, match.Groups["head"].Value + " ...;");
+                Omitted.Add(match.Groups["name"].Value);
+            }
+
+            string header = Omitted.Count == 0
+                ? "// Model structure; it has no inline data" + Environment.NewLine
+                : $"// Model structure; inline data of {string.Join(", ", Omitted)} omitted" + Environment.NewLine;
+            return header + source;
+        }
+
+        /// <summary>
+        /// True for a value made only of numbers, strings and literal brackets, false for "..."
+        /// (already external) and for expressions over other declarations
+        /// </summary>
+        private static bool IsLiteral(string value)
+        {
+            if (value == "...")
+                return false;
+
+            string withoutStrings = stringPattern.Replace(value, "\"\"");
+            return identifierPattern.Matches(withoutStrings).All(m => literalWords.Contains(m.Value));
+        }
+    }
+}
@@ -131,7 +131,7 @@ namespace Core
                     
                     if (left.IsSuccess && right.IsSuccess)
                     {
-                        return Math.Abs(left.Value - right.Value) < 1e-10;
+                        return NumericTolerance.Evaluation.AreEqual(left.Value, right.Value);
                     }
                 }
             }
@@ -145,7 +145,7 @@ namespace Core
                     
                     if (left.IsSuccess && right.IsSuccess)
                     {
-                        return Math.Abs(left.Value - right.Value) >= 1e-10;
+                        return !NumericTolerance.Evaluation.AreEqual(left.Value, right.Value);
                     }
                 }
             }
@@ -159,7 +159,7 @@ namespace Core
                     
                     if (left.IsSuccess && right.IsSuccess)
                     {
-                        return left.Value <= right.Value + 1e-10; // Add epsilon for floating point comparison
+                        return NumericTolerance.Evaluation.IsLessOrEqual(left.Value, right.Value);
                     }
                 }
             }
@@ -173,7 +173,7 @@ namespace Core
                     
                     if (left.IsSuccess && right.IsSuccess)
                     {
-                        return left.Value >= right.Value - 1e-10; // Subtract epsilon for floating point comparison
+                        return NumericTolerance.Evaluation.IsGreaterOrEqual(left.Value, right.Value);
                     }
                 }
             }
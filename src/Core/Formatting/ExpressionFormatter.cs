@@ -0,0 +1,590 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Models;
+
+namespace Core.Formatting
+{
+    /// <summary>
+    /// Output notation for formatted expressions
+    /// </summary>
+    public enum ExpressionStyle
+    {
+        /// <summary>Plain ASCII: sum(i in I) c[i]*x[i] &lt;= 10</summary>
+        Ascii,
+        /// <summary>Math-like Unicode: ∑(i∈I) c[i]·x[i] ≤ 10</summary>
+        Unicode,
+        /// <summary>LaTeX math mode: \sum_{i \in I} c_{i} x_{i} \leq 10</summary>
+        Latex,
+        /// <summary>Model language syntax that the parser accepts back</summary>
+        Opl
+    }
+
+    /// <summary>
+    /// Order of terms when formatting linear constraints and objectives
+    /// </summary>
+    public enum TermGrouping
+    {
+        /// <summary>Keep the order stored in the model</summary>
+        None,
+        /// <summary>Sort terms by variable name</summary>
+        Alphabetical,
+        /// <summary>Keep variables of the same family (x1, x2, ...) together in natural index order</summary>
+        ByFamily
+    }
+
+    public class ExpressionFormatOptions
+    {
+        public ExpressionStyle Style { get; set; } = ExpressionStyle.Ascii;
+
+        /// <summary>
+        /// Wrap sums longer than this many characters (0 = never wrap)
+        /// </summary>
+        public int MaxLineWidth { get; set; }
+
+        /// <summary>
+        /// Indentation of continuation lines
+        /// </summary>
+        public int IndentWidth { get; set; } = 4;
+
+        public TermGrouping Grouping { get; set; } = TermGrouping.None;
+
+        /// <summary>
+        /// Significant digits for numeric constants
+        /// </summary>
+        public int Precision { get; set; } = 15;
+    }
+
+    /// <summary>
+    /// Renders expressions, constraints and objectives in ASCII, Unicode, LaTeX or model syntax,
+    /// for documentation generation and UI display
+    /// </summary>
+    public class ExpressionFormatter
+    {
+        // Precedence levels, higher binds tighter
+        private const int OrPrecedence = 1;
+        private const int ComparisonPrecedence = 2;
+        private const int AdditivePrecedence = 3;
+        private const int MultiplicativePrecedence = 4;
+        private const int UnaryPrecedence = 5;
+        private const int PowerPrecedence = 6;
+        private const int AtomPrecedence = 7;
+
+        public ExpressionFormatOptions Options { get; }
+
+        private ExpressionStyle Style => Options.Style;
+
+        public ExpressionFormatter(ExpressionFormatOptions? options = null)
+        {
+            Options = options ?? new ExpressionFormatOptions();
+        }
+
+        public ExpressionFormatter(ExpressionStyle style)
+            : this(new ExpressionFormatOptions { Style = style })
+        {
+        }
+
+        /// <summary>
+        /// Formats an expression; top-level sums are wrapped according to MaxLineWidth
+        /// </summary>
+        public string Format(Expression expression)
+        {
+            var terms = new List<(bool Negative, Expression Term)>();
+            FlattenSum(expression, false, terms);
+
+            if (terms.Count <= 1)
+                return Render(expression, 0);
+
+            return JoinTerms(terms.Select(t => (t.Negative, Render(t.Term, AdditivePrecedence + 1))).ToList(), 0);
+        }
+
+        /// <summary>
+        /// Formats a linear constraint as "terms op constant", with optional label
+        /// </summary>
+        public string Format(LinearEquation equation, bool includeLabel = true)
+        {
+            string? label = equation.Label ?? (string.IsNullOrEmpty(equation.BaseName) ? null : equation.GetDescription());
+            bool showLabel = includeLabel && !string.IsNullOrEmpty(label);
+            string lhs = FormatLinear(equation.Coefficients, null, showLabel ? label!.Length + 2 : 0);
+            string text = $"{lhs} {RelationSymbol(equation.Operator)} {Render(equation.Constant, ComparisonPrecedence + 1)}";
+
+            if (!showLabel)
+                return text;
+
+            return Style == ExpressionStyle.Latex
+                ? $"\\text{{{EscapeLatexText(label)}:}}\\; {text}"
+                : $"{label}: {text}";
+        }
+
+        /// <summary>
+        /// Formats "left op right", e.g. a constraint template
+        /// </summary>
+        public string FormatRelation(Expression left, RelationalOperator op, Expression right)
+        {
+            return $"{Format(left)} {RelationSymbol(op)} {Render(right, ComparisonPrecedence + 1)}";
+        }
+
+        public string RelationSymbol(RelationalOperator op) => (Style, op) switch
+        {
+            (ExpressionStyle.Unicode, RelationalOperator.LessThanOrEqual) => "≤",
+            (ExpressionStyle.Unicode, RelationalOperator.GreaterThanOrEqual) => "≥",
+            (ExpressionStyle.Latex, RelationalOperator.LessThanOrEqual) => "\\leq",
+            (ExpressionStyle.Latex, RelationalOperator.GreaterThanOrEqual) => "\\geq",
+            (ExpressionStyle.Unicode or ExpressionStyle.Latex, RelationalOperator.Equal) => "=",
+            (_, RelationalOperator.LessThanOrEqual) => "<=",
+            (_, RelationalOperator.GreaterThanOrEqual) => ">=",
+            (_, RelationalOperator.Equal) => "==",
+            (_, RelationalOperator.LessThan) => "<",
+            _ => ">"
+        };
+
+        /// <summary>
+        /// Formats an objective as "maximize terms"
+        /// </summary>
+        public string Format(Objective objective)
+        {
+            string sense = (Style, objective.Sense) switch
+            {
+                (ExpressionStyle.Latex, ObjectiveSense.Maximize) => "\\max\\;",
+                (ExpressionStyle.Latex, _) => "\\min\\;",
+                (_, ObjectiveSense.Maximize) => "maximize",
+                _ => "minimize"
+            };
+            string body = FormatLinear(objective.Coefficients, objective.Constant, sense.Length + 1);
+
+            return $"{sense} {body}";
+        }
+
+        private string FormatLinear(Dictionary<string, Expression> coefficients, Expression? constant, int startColumn)
+        {
+            var terms = new List<(bool Negative, string Text)>();
+
+            foreach (var kvp in OrderTerms(coefficients))
+            {
+                terms.Add(FormatLinearTerm(kvp.Value, FormatVariableName(kvp.Key)));
+            }
+
+            if (constant != null && !(constant is ConstantExpression { Value: 0 }))
+            {
+                if (constant is ConstantExpression c && c.Value < 0)
+                    terms.Add((true, FormatNumber(-c.Value)));
+                else
+                    terms.Add((false, Render(constant, AdditivePrecedence + 1)));
+            }
+
+            if (terms.Count == 0)
+                return "0";
+
+            return JoinTerms(terms, startColumn);
+        }
+
+        private (bool Negative, string Text) FormatLinearTerm(Expression coefficient, string variable)
+        {
+            if (coefficient is ConstantExpression c)
+            {
+                bool negative = c.Value < 0;
+                double magnitude = Math.Abs(c.Value);
+
+                if (magnitude == 1.0)
+                    return (negative, variable);
+
+                return (negative, $"{FormatNumber(magnitude)}{MultiplySymbol(true)}{variable}");
+            }
+
+            return (false, $"{Render(coefficient, MultiplicativePrecedence)}{MultiplySymbol(false)}{variable}");
+        }
+
+        private IEnumerable<KeyValuePair<string, Expression>> OrderTerms(Dictionary<string, Expression> coefficients)
+        {
+            return Options.Grouping switch
+            {
+                TermGrouping.Alphabetical => coefficients.OrderBy(c => c.Key, StringComparer.Ordinal),
+                TermGrouping.ByFamily => coefficients
+                    .Select((c, position) => (Term: c, Position: position, Family: FamilyOf(c.Key)))
+                    .GroupBy(t => t.Family)
+                    .OrderBy(g => g.Min(t => t.Position))
+                    .SelectMany(g => g.OrderBy(t => NaturalKey(t.Term.Key), StringComparer.Ordinal))
+                    .Select(t => t.Term),
+                _ => coefficients
+            };
+        }
+
+        /// <summary>
+        /// Joins signed terms, wrapping before an operator when a line would exceed MaxLineWidth
+        /// </summary>
+        private string JoinTerms(List<(bool Negative, string Text)> terms, int startColumn)
+        {
+            var sb = new StringBuilder();
+            int lineStart = -startColumn;
+            string indent = new string(' ', Options.IndentWidth);
+
+            for (int i = 0; i < terms.Count; i++)
+            {
+                var (negative, text) = terms[i];
+                string piece = i == 0
+                    ? (negative ? $"{MinusSymbol()}{text}" : text)
+                    : $" {(negative ? MinusSymbol() : "+")} {text}";
+
+                if (i > 0 && Options.MaxLineWidth > 0 && sb.Length - lineStart + piece.Length > Options.MaxLineWidth)
+                {
+                    sb.Append(Style == ExpressionStyle.Latex ? " \\\\" : "").AppendLine();
+                    lineStart = sb.Length;
+                    sb.Append(indent);
+                    if (Style == ExpressionStyle.Latex)
+                        sb.Append("\\quad ");
+                    piece = piece.TrimStart();
+                }
+
+                sb.Append(piece);
+            }
+
+            return sb.ToString();
+        }
+
+        private static void FlattenSum(Expression expression, bool negative, List<(bool, Expression)> terms)
+        {
+            if (expression is BinaryExpression b && (b.Operator == BinaryOperator.Add || b.Operator == BinaryOperator.Subtract))
+            {
+                FlattenSum(b.Left, negative, terms);
+                FlattenSum(b.Right, b.Operator == BinaryOperator.Subtract ? !negative : negative, terms);
+            }
+            else
+            {
+                terms.Add((negative, expression));
+            }
+        }
+
+        /// <summary>
+        /// Renders an expression, parenthesizing it when it binds looser than the context requires
+        /// </summary>
+        private string Render(Expression? expression, int contextPrecedence)
+        {
+            if (expression == null)
+                return "";
+
+            var (text, precedence) = RenderWithPrecedence(expression);
+
+            if (precedence >= contextPrecedence)
+                return text;
+
+            return Style == ExpressionStyle.Latex ? $"\\left({text}\\right)" : $"({text})";
+        }
+
+        private (string Text, int Precedence) RenderWithPrecedence(Expression expression)
+        {
+            switch (expression)
+            {
+                case ConstantExpression c:
+                    return (FormatNumber(c.Value), c.Value < 0 ? UnaryPrecedence : AtomPrecedence);
+
+                case ParameterExpression p:
+                    return (FormatIdentifier(p.ParameterName), AtomPrecedence);
+
+                case VariableExpression v:
+                    return (FormatVariableName(v.VariableName), AtomPrecedence);
+
+                case IndexedVariableExpression iv:
+                    var indices = new List<Expression> { iv.Index1 };
+                    if (iv.Index2 != null)
+                        indices.Add(iv.Index2);
+                    return (FormatIndexed(iv.BaseName, indices), AtomPrecedence);
+
+                case IndexedParameterExpression ip:
+                    return (FormatIndexed(ip.ParameterName, ip.Indices), AtomPrecedence);
+
+                case BinaryExpression b:
+                    return RenderBinary(b.Left, b.Operator, b.Right);
+
+                case ComparisonExpression cmp:
+                    return RenderBinary(cmp.Left, cmp.Operator, cmp.Right);
+
+                case LogicalAndExpression and:
+                    string andSymbol = Style switch
+                    {
+                        ExpressionStyle.Unicode => " ∧ ",
+                        ExpressionStyle.Latex => " \\land ",
+                        _ => " && "
+                    };
+                    return ($"{Render(and.Left, OrPrecedence + 1)}{andSymbol}{Render(and.Right, OrPrecedence + 1)}", OrPrecedence);
+
+                case UnaryExpression u:
+                    string unary = u.Operator == UnaryOperator.Negate
+                        ? MinusSymbol()
+                        : Style switch
+                        {
+                            ExpressionStyle.Unicode => "¬",
+                            ExpressionStyle.Latex => "\\lnot ",
+                            _ => "!"
+                        };
+                    return ($"{unary}{Render(u.Operand, UnaryPrecedence)}", UnaryPrecedence);
+
+                case SummationExpression s:
+                    return (RenderIterated("sum", new[] { (s.IndexVariable, s.SetName) }, null, s.Body), AdditivePrecedence);
+
+                case FilteredSummationExpression fs:
+                    return (RenderIterated("sum", fs.Iterators, fs.Filter, fs.Body), AdditivePrecedence);
+
+                case AggregationExpression agg:
+                    return (RenderIterated(agg.FunctionName, agg.Iterators, agg.Filter, agg.Body), AdditivePrecedence);
+
+                case AggregationExpression.ConditionalExpression nested:
+                    return (RenderConditional(nested.Condition, nested.TrueValue, nested.FalseValue), OrPrecedence);
+
+                case ConditionalExpression cond:
+                    return (RenderConditional(cond.Condition, cond.TrueValue, cond.FalseValue), OrPrecedence);
+
+                case MathFunctionExpression f:
+                    return (RenderFunction(f), AtomPrecedence);
+
+                default:
+                    return (SafeToString(expression), AtomPrecedence);
+            }
+        }
+
+        private (string Text, int Precedence) RenderBinary(Expression left, BinaryOperator op, Expression right)
+        {
+            switch (op)
+            {
+                case BinaryOperator.Add:
+                case BinaryOperator.Subtract:
+                    string sign = op == BinaryOperator.Add ? "+" : MinusSymbol();
+                    return ($"{Render(left, AdditivePrecedence)} {sign} {Render(right, AdditivePrecedence + 1)}", AdditivePrecedence);
+
+                case BinaryOperator.Multiply:
+                    // "3x" only when the right side is a plain name
+                    bool numericLeft = left is ConstantExpression &&
+                        right is VariableExpression or IndexedVariableExpression or ParameterExpression or IndexedParameterExpression;
+                    return ($"{Render(left, MultiplicativePrecedence)}{MultiplySymbol(numericLeft)}{Render(right, MultiplicativePrecedence + 1)}", MultiplicativePrecedence);
+
+                case BinaryOperator.Divide when Style == ExpressionStyle.Latex:
+                    return ($"\\frac{{{Render(left, 0)}}}{{{Render(right, 0)}}}", AtomPrecedence);
+
+                case BinaryOperator.Divide:
+                    return ($"{Render(left, MultiplicativePrecedence)} / {Render(right, MultiplicativePrecedence + 1)}", MultiplicativePrecedence);
+
+                case BinaryOperator.Modulo:
+                case BinaryOperator.Div:
+                    string word = op == BinaryOperator.Modulo ? "mod" : "div";
+                    if (Style == ExpressionStyle.Latex)
+                        word = $"\\mathbin{{\\text{{{word}}}}}";
+                    else if (Style == ExpressionStyle.Ascii && op == BinaryOperator.Modulo)
+                        word = "%";
+                    return ($"{Render(left, MultiplicativePrecedence)} {word} {Render(right, MultiplicativePrecedence + 1)}", MultiplicativePrecedence);
+
+                case BinaryOperator.Power:
+                    string exponent = Style == ExpressionStyle.Latex
+                        ? $"^{{{Render(right, 0)}}}"
+                        : $"^{Render(right, PowerPrecedence)}";
+                    return ($"{Render(left, PowerPrecedence + 1)}{exponent}", PowerPrecedence);
+
+                case BinaryOperator.LogicalOr:
+                    string orSymbol = Style switch
+                    {
+                        ExpressionStyle.Unicode => "∨",
+                        ExpressionStyle.Latex => "\\lor",
+                        _ => "||"
+                    };
+                    return ($"{Render(left, OrPrecedence)} {orSymbol} {Render(right, OrPrecedence + 1)}", OrPrecedence);
+
+                default:
+                    return ($"{Render(left, ComparisonPrecedence + 1)} {ComparisonSymbol(op)} {Render(right, ComparisonPrecedence + 1)}", ComparisonPrecedence);
+            }
+        }
+
+        private string RenderIterated(string name, IEnumerable<(string Var, string Set)> iterators, Expression? filter, Expression body)
+        {
+            var its = iterators.ToList();
+            string bodyText = Render(body, MultiplicativePrecedence);
+
+            switch (Style)
+            {
+                case ExpressionStyle.Latex:
+                    string latexIterators = string.Join(", ", its.Select(i => $"{FormatIdentifier(i.Var)} \\in {FormatIdentifier(i.Set)}"));
+                    if (filter != null)
+                        latexIterators += $" : {Render(filter, 0)}";
+                    string op = name switch
+                    {
+                        "sum" => "\\sum",
+                        "prod" => "\\prod",
+                        "min" => "\\min",
+                        "max" => "\\max",
+                        _ => $"\\operatorname{{{name}}}"
+                    };
+                    return $"{op}_{{{latexIterators}}} {bodyText}";
+
+                case ExpressionStyle.Unicode:
+                    string unicodeIterators = string.Join(", ", its.Select(i => $"{i.Var}∈{i.Set}"));
+                    if (filter != null)
+                        unicodeIterators += $" : {Render(filter, 0)}";
+                    string symbol = name switch
+                    {
+                        "sum" => "∑",
+                        "prod" => "∏",
+                        _ => name
+                    };
+                    return $"{symbol}({unicodeIterators}) {bodyText}";
+
+                default:
+                    string asciiIterators = string.Join(", ", its.Select(i => $"{i.Var} in {i.Set}"));
+                    if (filter != null)
+                        asciiIterators += $" : {Render(filter, 0)}";
+                    return $"{name}({asciiIterators}) {bodyText}";
+            }
+        }
+
+        private string RenderConditional(Expression condition, Expression whenTrue, Expression whenFalse)
+        {
+            return Style == ExpressionStyle.Latex
+                ? $"\\begin{{cases}} {Render(whenTrue, 0)} & \\text{{if }} {Render(condition, 0)} \\\\ {Render(whenFalse, 0)} & \\text{{otherwise}} \\end{{cases}}"
+                : $"{Render(condition, OrPrecedence + 1)} ? {Render(whenTrue, OrPrecedence + 1)} : {Render(whenFalse, OrPrecedence + 1)}";
+        }
+
+        private string RenderFunction(MathFunctionExpression function)
+        {
+            var args = function.Arguments.Select(a => Render(a, 0)).ToArray();
+            string name = function.Function.ToString().ToLowerInvariant();
+
+            if (Style == ExpressionStyle.Latex)
+            {
+                return function.Function switch
+                {
+                    MathFunction.Abs => $"\\left|{args[0]}\\right|",
+                    MathFunction.Sqrt => $"\\sqrt{{{args[0]}}}",
+                    MathFunction.Floor => $"\\lfloor {args[0]} \\rfloor",
+                    MathFunction.Ceil => $"\\lceil {args[0]} \\rceil",
+                    MathFunction.Exp => $"e^{{{args[0]}}}",
+                    MathFunction.Pow => $"{{{args[0]}}}^{{{args[1]}}}",
+                    MathFunction.Log or MathFunction.Sin or MathFunction.Cos or MathFunction.Tan
+                        or MathFunction.Min or MathFunction.Max => $"\\{name}\\left({string.Join(", ", args)}\\right)",
+                    _ => $"\\operatorname{{{name}}}\\left({string.Join(", ", args)}\\right)"
+                };
+            }
+
+            if (Style == ExpressionStyle.Unicode)
+            {
+                switch (function.Function)
+                {
+                    case MathFunction.Abs: return $"|{args[0]}|";
+                    case MathFunction.Sqrt: return $"√({args[0]})";
+                    case MathFunction.Floor: return $"⌊{args[0]}⌋";
+                    case MathFunction.Ceil: return $"⌈{args[0]}⌉";
+                }
+            }
+
+            return $"{name}({string.Join(", ", args)})";
+        }
+
+        private string FormatIndexed(string name, IEnumerable<Expression> indices)
+        {
+            var parts = indices.Select(i => Render(i, 0)).ToList();
+
+            return Style switch
+            {
+                ExpressionStyle.Latex => $"{FormatIdentifier(name)}_{{{string.Join(",", parts)}}}",
+                ExpressionStyle.Opl => $"{name}{string.Concat(parts.Select(p => $"[{p}]"))}",
+                _ => $"{name}[{string.Join(",", parts)}]"
+            };
+        }
+
+        /// <summary>
+        /// Expanded variable names (x1, flow2_3) are shown as subscripted names in LaTeX
+        /// </summary>
+        private string FormatVariableName(string name)
+        {
+            if (Style != ExpressionStyle.Latex)
+                return name;
+
+            var match = Regex.Match(name, @"^([A-Za-z_]*[A-Za-z])(\d+(?:_\d+)*)$");
+            if (!match.Success)
+                return FormatIdentifier(name);
+
+            return $"{FormatIdentifier(match.Groups[1].Value)}_{{{match.Groups[2].Value.Replace('_', ',')}}}";
+        }
+
+        private string FormatIdentifier(string name)
+        {
+            if (Style != ExpressionStyle.Latex)
+                return name;
+
+            return name.Length == 1 ? name : $"\\mathit{{{name.Replace("_", "\\_")}}}";
+        }
+
+        private string FormatNumber(double value)
+        {
+            if (double.IsPositiveInfinity(value))
+                return Style switch { ExpressionStyle.Unicode => "∞", ExpressionStyle.Latex => "\\infty", _ => "infinity" };
+            if (double.IsNegativeInfinity(value))
+                return Style switch { ExpressionStyle.Unicode => "−∞", ExpressionStyle.Latex => "-\\infty", _ => "-infinity" };
+
+            string text = value.ToString("G" + Options.Precision, CultureInfo.InvariantCulture);
+
+            if (Style == ExpressionStyle.Latex && text.Contains('E'))
+            {
+                var parts = text.Split('E');
+                text = $"{parts[0]} \\times 10^{{{int.Parse(parts[1], CultureInfo.InvariantCulture)}}}";
+            }
+
+            return Style == ExpressionStyle.Unicode ? text.Replace('-', '−') : text;
+        }
+
+        private string MultiplySymbol(bool numericLeft) => Style switch
+        {
+            ExpressionStyle.Unicode => numericLeft ? "" : "·",
+            ExpressionStyle.Latex => numericLeft ? " " : " \\cdot ",
+            _ => "*"
+        };
+
+        private string MinusSymbol() => Style == ExpressionStyle.Unicode ? "−" : "-";
+
+        private string ComparisonSymbol(BinaryOperator op) => (Style, op) switch
+        {
+            (ExpressionStyle.Unicode, BinaryOperator.LessThanOrEqual) => "≤",
+            (ExpressionStyle.Unicode, BinaryOperator.GreaterThanOrEqual) => "≥",
+            (ExpressionStyle.Unicode, BinaryOperator.NotEqual) => "≠",
+            (ExpressionStyle.Unicode, BinaryOperator.Equal) => "=",
+            (ExpressionStyle.Latex, BinaryOperator.LessThanOrEqual) => "\\leq",
+            (ExpressionStyle.Latex, BinaryOperator.GreaterThanOrEqual) => "\\geq",
+            (ExpressionStyle.Latex, BinaryOperator.NotEqual) => "\\neq",
+            (ExpressionStyle.Latex, BinaryOperator.Equal) => "=",
+            (_, BinaryOperator.LessThanOrEqual) => "<=",
+            (_, BinaryOperator.GreaterThanOrEqual) => ">=",
+            (_, BinaryOperator.NotEqual) => "!=",
+            (_, BinaryOperator.Equal) => "==",
+            (_, BinaryOperator.LessThan) => "<",
+            (_, BinaryOperator.GreaterThan) => ">",
+            _ => op.ToString()
+        };
+
+        private static string EscapeLatexText(string text)
+        {
+            return text.Replace("\\", "\\textbackslash{}").Replace("_", "\\_").Replace("&", "\\&")
+                .Replace("%", "\\%").Replace("#", "\\#").Replace("{", "\\{").Replace("}", "\\}");
+        }
+
+        private static string SafeToString(Expression expression)
+        {
+            try
+            {
+                return expression.ToString() ?? expression.GetType().Name;
+            }
+            catch (NotImplementedException)
+            {
+                return expression.GetType().Name;
+            }
+        }
+
+        private static string FamilyOf(string name)
+        {
+            var match = Regex.Match(name, @"^(.*?[A-Za-z])[\d_]*$");
+            return match.Success ? match.Groups[1].Value : name;
+        }
+
+        /// <summary>
+        /// Sort key that orders embedded numbers numerically (x2 before x10)
+        /// </summary>
+        private static string NaturalKey(string name)
+        {
+            return Regex.Replace(name, @"\d+", m => m.Value.PadLeft(10, '0'));
+        }
+    }
+}
@@ -0,0 +1,845 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+
+namespace Core.Formatting
+{
+    public class ModelFormatOptions
+    {
+        /// <summary>
+        /// Spaces per indentation level
+        /// </summary>
+        public int IndentWidth { get; set; } = 4;
+
+        /// <summary>
+        /// Lines longer than this are wrapped before a + or - (0 = never wrap)
+        /// </summary>
+        public int MaxLineWidth { get; set; } = 100;
+
+        /// <summary>
+        /// Move declarations into sections: tuples, sets and parameters (in their own order),
+        /// variables, decision expressions, the objective, constraints. Execute blocks stay where
+        /// they are and no declaration moves ahead of a name it uses.
+        /// </summary>
+        public bool OrderSections { get; set; } = true;
+
+        /// <summary>
+        /// Align the '=' of consecutive one-line assignments in data files
+        /// </summary>
+        public bool AlignAssignments { get; set; } = true;
+
+        /// <summary>
+        /// Data files write decimal commas ("3,14"), so a comma between digits is part of the number
+        /// </summary>
+        public bool DecimalComma { get; set; }
+    }
+
+    /// <summary>
+    /// Canonical layout of model and data texts: one statement per line start, indentation from
+    /// blocks and brackets, one space around binary operators and after commas, at most one blank
+    /// line, long sums wrapped, and declarations grouped into sections. Comments and docstrings
+    /// are kept with the statement they precede, and formatting a formatted text changes nothing.
+    /// </summary>
+    public class ModelFormatter
+    {
+        private enum TokenKind { Word, Number, String, Operator, Open, Close, Comma, Semicolon, Colon, LineComment, BlockComment }
+
+        private class Token
+        {
+            public TokenKind Kind { get; init; }
+            public string Text { get; init; } = "";
+
+            /// <summary>
+            /// Line breaks in the whitespace before the token
+            /// </summary>
+            public int Newlines { get; init; }
+
+            public bool SpaceBefore { get; init; }
+
+            /// <summary>
+            /// A brace that opens or closes a block (subject to, forall, tuple) rather than a set literal
+            /// </summary>
+            public bool IsBlock { get; set; }
+
+            /// <summary>
+            /// A '&lt;' or '&gt;' delimiting a tuple rather than comparing
+            /// </summary>
+            public bool IsTuple { get; set; }
+
+            public bool Opens => Kind == TokenKind.Open && !IsBlock || IsTuple && Text == "<";
+            public bool Closes => Kind == TokenKind.Close && !IsBlock || IsTuple && Text == ">";
+
+            public override string ToString() => Text;
+        }
+
+        private class Piece
+        {
+            public string Text { get; init; } = "";
+            public bool SpaceBefore { get; init; }
+            public int Depth { get; init; }
+
+            /// <summary>
+            /// A binary + or - the line may be wrapped before
+            /// </summary>
+            public bool Breakable { get; init; }
+        }
+
+        private class Line
+        {
+            public int Level { get; init; }
+
+            /// <summary>
+            /// Block depth of the statement the line belongs to
+            /// </summary>
+            public int Blocks { get; init; }
+
+            public List<Piece> Pieces { get; } = new List<Piece>();
+            public int Blank { get; init; }
+
+            /// <summary>
+            /// Verbatim text (execute blocks, multi-line comments); pieces are ignored
+            /// </summary>
+            public string? Raw { get; init; }
+        }
+
+        /// <summary>
+        /// One top-level statement with its comments
+        /// </summary>
+        private class Unit
+        {
+            public int Index { get; init; }
+            public List<string> Leading { get; } = new List<string>();
+            public bool BlankBefore { get; set; }
+            public string Code { get; init; } = "";
+            public string? Trailing { get; set; }
+            public int Section { get; set; }
+            public string? Declares { get; init; }
+            public HashSet<string> Uses { get; } = new HashSet<string>(StringComparer.Ordinal);
+
+            /// <summary>
+            /// Statements inside a subject to block
+            /// </summary>
+            public List<Unit> Members { get; } = new List<Unit>();
+
+            public Unit? Closing { get; set; }
+        }
+
+        private const int PinnedSection = -1;
+
+        private static readonly string[] Operators = { "...", "..", "<=", ">=", "==", "!=", "=>", "&&", "||", "+", "-", "*", "/", "^", "<", ">", "=", "!", "?", "|", "%", "&", "." };
+
+        /// <summary>
+        /// Words after which an operator is unary and before which a bracket is not a call
+        /// </summary>
+        private static readonly HashSet<string> OperatorWords = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "in", "and", "or", "not", "mod", "div", "minimize", "maximize", "return", "to", "with", "union", "inter", "diff", "symdiff"
+        };
+
+        private static readonly Regex wordPattern = new Regex(@"\G[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*");
+        private static readonly Regex numberPattern = new Regex(@"\G(?:\d+(?:\.(?!\.)\d*)?|\.\d+)(?:[eE][+-]?\d+)?");
+        private static readonly Regex decimalCommaPattern = new Regex(@"\G\d+,\d+(?:[eE][+-]?\d+)?");
+        private static readonly Regex identifierPattern = new Regex(@"[A-Za-z_]\w*");
+        private static readonly Regex assignmentPattern = new Regex(@"^(\w+) = ");
+        private static readonly Regex annotationPattern = new Regex(@"//[ \t]*@(block|endblock|scoped)\b");
+
+        public ModelFormatOptions Options { get; }
+
+        public ModelFormatter(ModelFormatOptions? options = null)
+        {
+            Options = options ?? new ModelFormatOptions();
+        }
+
+        /// <summary>
+        /// Formats a model text with the default options
+        /// </summary>
+        public static string FormatModel(string modelText) => new ModelFormatter().Format(modelText);
+
+        public string Format(string modelText) => Format(modelText, data: false);
+
+        /// <summary>
+        /// Formats a data file: values stay space- or comma-separated as written and the
+        /// assignments of a run of one-line statements are aligned
+        /// </summary>
+        public string FormatData(string dataText) => Format(dataText, data: true);
+
+        private string Format(string text, bool data)
+        {
+            string newline = text.Contains("\r\n") ? "\r\n" : "\n";
+            text = text.Replace("\r\n", "\n");
+
+            var source = ModelSource.Parse(text);
+            var units = ReadUnits(source, data, out var trailer, out bool trailerBlank);
+
+            if (!data && Options.OrderSections && !annotationPattern.IsMatch(text))
+                units = Order(units);
+
+            var lines = new List<Line>();
+            for (int i = 0; i < units.Count; i++)
+            {
+                var unit = units[i];
+                bool blank = i > 0 && (unit.BlankBefore || (!data && Options.OrderSections && unit.Section != units[i - 1].Section));
+                Emit(lines, unit, 0, blank, data);
+            }
+
+            var output = Render(lines);
+            if (data && Options.AlignAssignments)
+                output = Align(output);
+
+            if (trailer.Count > 0)
+            {
+                if (output.Count > 0 && trailerBlank)
+                    output.Add("");
+                output.AddRange(trailer);
+            }
+
+            return output.Count == 0 ? "" : string.Join(newline, output) + newline;
+        }
+
+        private List<Unit> ReadUnits(ModelSource source, bool data, out List<string> trailer, out bool trailerBlank)
+        {
+            var all = new List<Unit>();
+            Unit? previous = null;
+
+            foreach (var statement in source.Statements)
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                trivia = TakeTrailing(previous, trivia);
+
+                var comments = new List<string>();
+                ReadTrivia(comments, trivia, out bool blank);
+
+                // Comments set off by a blank line stay in place; the rest move with the statement
+                int detached = comments.LastIndexOf("");
+                if (detached >= 0)
+                {
+                    var comment = new Unit { Index = all.Count, BlankBefore = blank, Section = previous?.Section ?? 0 };
+                    comment.Leading.AddRange(comments.Take(detached));
+                    all.Add(comment);
+                    comments.RemoveRange(0, detached + 1);
+                    blank = true;
+                }
+
+                var unit = new Unit
+                {
+                    Index = all.Count,
+                    Code = statement.Code,
+                    Declares = DeclaredName(statement),
+                    BlankBefore = blank,
+                    Section = data ? 0 : SectionOf(statement)
+                };
+                unit.Leading.AddRange(comments);
+                foreach (Match m in identifierPattern.Matches(Docstrings.Strip(statement.Code)))
+                    unit.Uses.Add(m.Value);
+
+                all.Add(unit);
+                previous = unit;
+            }
+
+            trailer = new List<string>();
+            ReadTrivia(trailer, TakeTrailing(previous, source.Trailer), out trailerBlank);
+
+            // The statements of a subject to block move and indent as one unit
+            var units = new List<Unit>();
+            Unit? block = null;
+            foreach (var unit in all)
+            {
+                if (block == null && Regex.IsMatch(unit.Code, @"^subject\s+to\b"))
+                {
+                    block = unit;
+                    units.Add(unit);
+                }
+                else if (block != null && unit.Code.StartsWith("}", StringComparison.Ordinal))
+                {
+                    block.Closing = unit;
+                    block = null;
+                }
+                else if (block != null)
+                {
+                    block.Members.Add(unit);
+                    block.Uses.UnionWith(unit.Uses);
+                }
+                else
+                {
+                    units.Add(unit);
+                }
+            }
+
+            return units;
+        }
+
+        /// <summary>
+        /// Moves a comment on the line of the previous statement to that statement and returns the rest
+        /// </summary>
+        private static string TakeTrailing(Unit? previous, string trivia)
+        {
+            if (previous == null)
+                return trivia;
+
+            int lineBreak = trivia.IndexOf('\n');
+            string sameLine = lineBreak < 0 ? trivia : trivia.Substring(0, lineBreak);
+            if (Opens(sameLine))
+                return trivia;
+
+            if (!string.IsNullOrWhiteSpace(sameLine))
+                previous.Trailing = sameLine.Trim();
+            return lineBreak < 0 ? "" : trivia.Substring(lineBreak + 1);
+        }
+
+        /// <summary>
+        /// True if the text leaves a block comment or docstring open
+        /// </summary>
+        private static bool Opens(string text)
+        {
+            string? open = null;
+            Scan(text, ref open);
+            return open != null;
+        }
+
+        /// <summary>
+        /// Follows block comments and docstrings through a line; <paramref name="open"/> is the
+        /// closing delimiter still expected, or null
+        /// </summary>
+        private static void Scan(string line, ref string? open)
+        {
+            for (int i = 0; i < line.Length; i++)
+            {
+                if (open != null)
+                {
+                    if (string.CompareOrdinal(line, i, open, 0, open.Length) == 0)
+                    {
+                        i += open.Length - 1;
+                        open = null;
+                    }
+                }
+                else if (string.CompareOrdinal(line, i, "//", 0, 2) == 0 || string.CompareOrdinal(line, i, "#:", 0, 2) == 0)
+                {
+                    return;
+                }
+                else if (string.CompareOrdinal(line, i, "/*", 0, 2) == 0)
+                {
+                    open = "*/";
+                    i++;
+                }
+                else if (string.CompareOrdinal(line, i, "\"\"\"", 0, 3) == 0)
+                {
+                    open = "\"\"\"";
+                    i += 2;
+                }
+            }
+        }
+
+        /// <summary>
+        /// Comment lines of a trivia run: trimmed, except inside multi-line comments and docstrings;
+        /// blank lines are collapsed and those before the first comment reported as <paramref name="blankBefore"/>
+        /// </summary>
+        private static void ReadTrivia(List<string> lines, string trivia, out bool blankBefore)
+        {
+            blankBefore = false;
+            string? open = null;
+            bool pendingBlank = false;
+            var raw = trivia.Split('\n');
+
+            for (int i = 0; i < raw.Length; i++)
+            {
+                bool inside = open != null;
+                string line = raw[i].Trim();
+
+                // Inner lines of a block comment are indented with the comment, starred lines one past it
+                if (inside && line.StartsWith('*'))
+                    line = " " + line;
+                Scan(raw[i], ref open);
+
+                if (line.Length == 0 && !inside)
+                {
+                    // The last piece is the indentation before the statement, not a line
+                    if (i < raw.Length - 1)
+                        pendingBlank = true;
+                    continue;
+                }
+
+                if (pendingBlank)
+                {
+                    if (lines.Count == 0)
+                        blankBefore = true;
+                    else
+                        lines.Add("");
+                    pendingBlank = false;
+                }
+                lines.Add(line);
+            }
+
+            if (pendingBlank && lines.Count == 0)
+                blankBefore = true;
+        }
+
+        private static string? DeclaredName(ModelStatement statement)
+        {
+            if (statement.Key != null)
+                return statement.Key.Contains(':') ? statement.Key.Substring(statement.Key.IndexOf(':') + 1) : null;
+
+            var tuple = Regex.Match(statement.Code, @"^tuple\s+(\w+)");
+            return tuple.Success ? tuple.Groups[1].Value : null;
+        }
+
+        private static int SectionOf(ModelStatement statement)
+        {
+            string head = Regex.Match(statement.Code, @"^\w+").Value;
+            if (head is "execute" or "main")
+                return PinnedSection;
+            if (head is "using" or "include")
+                return 0;
+            if (head == "tuple")
+                return 1;
+
+            string kind = statement.Key == null ? "" : statement.Key.Split(':')[0];
+            return kind switch
+            {
+                "set" or "parameter" => 1,
+                "variable" => 2,
+                "dexpr" => 3,
+                "objective" => 4,
+                "constraint" => 5,
+                // Parameters of a tuple type, unlabeled constraints; anything else stays in place
+                _ when Regex.IsMatch(statement.Code, @"^\w+\s+\w+\s*(\[[^\]]*\]\s*)*(=|;)") => 1,
+                _ when head is "forall" or "subject" || Regex.IsMatch(statement.Code, "<=|>=|==") => 5,
+                _ => PinnedSection
+            };
+        }
+
+        /// <summary>
+        /// Stable sort by section between execute blocks and statements of unknown kind; a statement is only placed once every
+        /// earlier statement declaring a name it uses has been placed
+        /// </summary>
+        private static List<Unit> Order(List<Unit> units)
+        {
+            var ordered = new List<Unit>();
+            int start = 0;
+
+            while (start < units.Count)
+            {
+                int end = start;
+                while (end < units.Count && units[end].Section != PinnedSection)
+                    end++;
+
+                var pending = units.GetRange(start, end - start);
+                while (pending.Count > 0)
+                {
+                    var next = pending
+                        .Where(u => pending.TakeWhile(p => p != u).All(p => p.Declares == null || !u.Uses.Contains(p.Declares)))
+                        .OrderBy(u => u.Section)
+                        .ThenBy(u => u.Index)
+                        .First();
+                    ordered.Add(next);
+                    pending.Remove(next);
+                }
+
+                if (end < units.Count)
+                    ordered.Add(units[end]);
+                start = end + 1;
+            }
+
+            return ordered;
+        }
+
+        private void Emit(List<Line> lines, Unit unit, int level, bool blank, bool data)
+        {
+            int first = lines.Count;
+            foreach (string comment in unit.Leading)
+                lines.Add(new Line { Level = comment.Length == 0 ? 0 : level, Raw = comment });
+
+            if (unit.Members.Count > 0 || unit.Closing != null)
+            {
+                lines.Add(new Line { Level = level, Raw = "subject to {" + Trailing(unit) });
+                for (int i = 0; i < unit.Members.Count; i++)
+                    Emit(lines, unit.Members[i], level + 1, i > 0 && unit.Members[i].BlankBefore, data);
+
+                if (unit.Closing != null)
+                {
+                    foreach (string comment in unit.Closing.Leading)
+                        lines.Add(new Line { Level = comment.Length == 0 ? 0 : level + 1, Raw = comment });
+                    lines.Add(new Line { Level = level, Raw = unit.Closing.Code.Trim() + Trailing(unit.Closing) });
+                }
+            }
+            else if (Regex.IsMatch(unit.Code, @"^(execute|main)\b"))
+            {
+                var code = unit.Code.Split('\n');
+                for (int i = 0; i < code.Length; i++)
+                {
+                    string text = (i == 0 ? code[i].Trim() : code[i].TrimEnd()) + (i == code.Length - 1 ? Trailing(unit) : "");
+                    lines.Add(new Line { Level = i == 0 ? level : 0, Raw = text });
+                }
+            }
+            else if (unit.Code.Length > 0)
+            {
+                int codeStart = lines.Count;
+                FormatCode(lines, Tokenize(unit.Code, data, data && Options.DecimalComma), level, data);
+                if (unit.Trailing != null && lines.Count > codeStart)
+                    lines[^1].Pieces.Add(new Piece { Text = unit.Trailing, SpaceBefore = true, Depth = int.MaxValue });
+            }
+
+            if (blank && lines.Count > first)
+                lines.Insert(first, new Line { Raw = "" });
+        }
+
+        private static string Trailing(Unit unit) => unit.Trailing != null ? " " + unit.Trailing : "";
+
+        private static List<Token> Tokenize(string code, bool data, bool decimalComma)
+        {
+            var tokens = new List<Token>();
+            int i = 0;
+
+            while (i < code.Length)
+            {
+                int newlines = 0;
+                int whitespaceStart = i;
+                while (i < code.Length && char.IsWhiteSpace(code[i]))
+                {
+                    if (code[i] == '\n')
+                        newlines++;
+                    i++;
+                }
+                if (i >= code.Length)
+                    break;
+
+                bool space = i > whitespaceStart;
+                int start = i;
+                TokenKind kind;
+                char c = code[i];
+
+                if (string.CompareOrdinal(code, i, "//", 0, 2) == 0 || string.CompareOrdinal(code, i, "#:", 0, 2) == 0)
+                {
+                    int end = code.IndexOf('\n', i);
+                    i = end < 0 ? code.Length : end;
+                    kind = TokenKind.LineComment;
+                }
+                else if (string.CompareOrdinal(code, i, "/*", 0, 2) == 0 || string.CompareOrdinal(code, i, "\"\"\"", 0, 3) == 0)
+                {
+                    string close = c == '/' ? "*/" : "\"\"\"";
+                    int end = code.IndexOf(close, i + 2, StringComparison.Ordinal);
+                    i = end < 0 ? code.Length : end + close.Length;
+                    kind = TokenKind.BlockComment;
+                }
+                else if (c == '"')
+                {
+                    i++;
+                    while (i < code.Length && code[i] != '"' && code[i] != '\n')
+                        i += code[i] == '\\' ? 2 : 1;
+                    i = Math.Min(code.Length, i + 1);
+                    kind = TokenKind.String;
+                }
+                else if (decimalComma && decimalCommaPattern.Match(code, i) is { Success: true } decimalNumber)
+                {
+                    i += decimalNumber.Length;
+                    kind = TokenKind.Number;
+                }
+                else if (numberPattern.Match(code, i) is { Success: true } number)
+                {
+                    i += number.Length;
+                    kind = TokenKind.Number;
+                }
+                else if (wordPattern.Match(code, i) is { Success: true } word)
+                {
+                    i += word.Length;
+                    // dvar float+ x
+                    if (word.Value is "float" or "int" && i + 1 < code.Length && code[i] == '+' && char.IsWhiteSpace(code[i + 1]))
+                        i++;
+                    kind = TokenKind.Word;
+                }
+                else if (c is '(' or '[' or '{')
+                {
+                    i++;
+                    kind = TokenKind.Open;
+                }
+                else if (c is ')' or ']' or '}')
+                {
+                    i++;
+                    kind = TokenKind.Close;
+                }
+                else if (c == ',')
+                {
+                    i++;
+                    kind = TokenKind.Comma;
+                }
+                else if (c == ';')
+                {
+                    i++;
+                    kind = TokenKind.Semicolon;
+                }
+                else if (c == ':')
+                {
+                    i++;
+                    kind = TokenKind.Colon;
+                }
+                else
+                {
+                    string op = Operators.FirstOrDefault(o => string.CompareOrdinal(code, i, o, 0, o.Length) == 0) ?? c.ToString();
+                    i += op.Length;
+                    kind = TokenKind.Operator;
+                }
+
+                tokens.Add(new Token { Kind = kind, Text = code.Substring(start, i - start).TrimEnd(), Newlines = newlines, SpaceBefore = space });
+            }
+
+            MarkBrackets(tokens, data);
+            return tokens;
+        }
+
+        /// <summary>
+        /// Braces after a word or a closing parenthesis open blocks, others are set literals;
+        /// a '&lt;' where an operand starts opens a tuple
+        /// </summary>
+        private static void MarkBrackets(List<Token> tokens, bool data)
+        {
+            var open = new Stack<Token>();
+            for (int i = 0; i < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                var previous = i > 0 ? tokens[i - 1] : null;
+
+                if (token.Text == "{")
+                {
+                    token.IsBlock = !data && previous != null &&
+                                    (previous.Kind == TokenKind.Word && !OperatorWords.Contains(previous.Text) || previous.Text == ")");
+                    open.Push(token);
+                }
+                else if (token.Text == "<" && (data || previous == null || previous.Opens || previous.Text is "=" or "|" or "in" ||
+                                               previous.Kind is TokenKind.Comma or TokenKind.Colon))
+                {
+                    token.IsTuple = true;
+                    open.Push(token);
+                }
+                else if (token.Text == ">" && open.Count > 0 && open.Peek().IsTuple)
+                {
+                    token.IsTuple = true;
+                    open.Pop();
+                }
+                else if (token.Kind == TokenKind.Open)
+                {
+                    open.Push(token);
+                }
+                else if (token.Kind == TokenKind.Close && open.Count > 0)
+                {
+                    token.IsBlock = open.Pop().IsBlock;
+                }
+            }
+        }
+
+        private void FormatCode(List<Line> lines, List<Token> tokens, int baseLevel, bool data)
+        {
+            // Level of the line each open block brace is on; the block's statements are one deeper
+            var blocks = new Stack<int>();
+            int depth = 0;
+            int questions = 0;
+            int closedLevel = baseLevel;
+            bool inStatement = false;
+            bool previousUnary = false;
+            Line? line = null;
+            Token? previous = null;
+
+            for (int i = 0; i < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                var next = i + 1 < tokens.Count ? tokens[i + 1] : null;
+
+                if (token.Closes)
+                    depth = Math.Max(0, depth - 1);
+                else if (token.IsBlock && token.Text == "}" && blocks.Count > 0)
+                    closedLevel = blocks.Pop();
+
+                int statementLevel = blocks.Count > 0 ? blocks.Peek() + 1 : baseLevel;
+                if (line == null || token.Newlines > 0 || previous?.Kind == TokenKind.LineComment)
+                {
+                    int level = token.IsBlock && token.Text == "{" && line != null ? line.Level
+                        : token.IsBlock || token.Text == "else" && previous?.IsBlock == true ? closedLevel
+                        : inStatement && token.Closes ? statementLevel + depth
+                        : inStatement ? statementLevel + Math.Max(1, depth)
+                        : statementLevel;
+                    line = new Line { Level = level, Blocks = statementLevel, Blank = line == null ? 0 : Math.Min(1, token.Newlines - 1) };
+                    lines.Add(line);
+                }
+
+                bool unary = token.Kind == TokenKind.Operator && token.Text is "-" or "+" or "!" && IsUnary(previous, token, next, data);
+                line.Pieces.Add(new Piece
+                {
+                    Text = token.Text,
+                    SpaceBefore = line.Pieces.Count > 0 && Space(previous!, token, previousUnary, unary, depth, questions),
+                    Depth = depth,
+                    Breakable = token.Text is "+" or "-" && !unary
+                });
+
+                if (token.Opens)
+                    depth++;
+                else if (token.IsBlock && token.Text == "{")
+                    blocks.Push(line.Level);
+                if (token.Text == "?")
+                    questions++;
+                else if (token.Kind == TokenKind.Colon && questions > 0)
+                    questions--;
+
+                if (token.Kind is not (TokenKind.LineComment or TokenKind.BlockComment))
+                    inStatement = !(token.Kind == TokenKind.Semicolon && depth == 0 || token.IsBlock);
+
+                previous = token;
+                previousUnary = unary;
+            }
+        }
+
+        private static bool IsUnary(Token? previous, Token token, Token? next, bool data)
+        {
+            if (previous == null || previous.Opens || previous.Kind is TokenKind.Comma or TokenKind.Semicolon or TokenKind.Colon)
+                return true;
+            if (previous.Kind == TokenKind.Operator && !previous.IsTuple)
+                return true;
+            if (previous.Kind == TokenKind.Word && OperatorWords.Contains(previous.Text))
+                return true;
+
+            // "[1 -2]": in data a sign written against its value after a space stays a sign
+            return data && token.SpaceBefore && next != null && !next.SpaceBefore;
+        }
+
+        private static bool Space(Token previous, Token token, bool previousUnary, bool unary, int depth, int questions)
+        {
+            if (token.Kind == TokenKind.LineComment)
+                return true;
+            if (token.Kind == TokenKind.BlockComment || previous.Kind == TokenKind.BlockComment)
+                return token.SpaceBefore;
+            if (previousUnary)
+                return false;
+            if (token.IsBlock || previous.IsBlock)
+                return true;
+
+            if (token.Closes || token.Kind is TokenKind.Comma or TokenKind.Semicolon)
+                return false;
+            if (previous.Kind is TokenKind.Comma or TokenKind.Semicolon)
+                return true;
+            if (previous.Opens)
+                return false;
+            if (token.Text is ".." or "^" or "." || previous.Text is ".." or "^" or ".")
+                return false;
+
+            if (token.Kind == TokenKind.Colon)
+                return depth > 0 || questions > 0;
+            if (previous.Kind == TokenKind.Colon)
+                return true;
+
+            // Calls and indexing: sum(...), x[i], p[i].cost, but minimize (a + b)
+            if (token.Opens && token.Text is "(" or "[")
+                return !(previous.Kind == TokenKind.Word && !OperatorWords.Contains(previous.Text) || previous.Closes);
+            if (token.Opens)
+                return !(previous.Kind == TokenKind.Word && !OperatorWords.Contains(previous.Text)) || token.SpaceBefore;
+
+            if (unary)
+                return previous.Kind is TokenKind.Operator or TokenKind.Word || token.SpaceBefore;
+
+            return true;
+        }
+
+        private List<string> Render(List<Line> lines)
+        {
+            var output = new List<string>();
+            foreach (var line in lines)
+            {
+                if (line.Blank > 0 && output.Count > 0 && output[^1].Length > 0)
+                    output.Add("");
+
+                if (line.Raw != null)
+                {
+                    if (line.Raw.Length == 0)
+                    {
+                        if (output.Count > 0 && output[^1].Length > 0)
+                            output.Add("");
+                    }
+                    else
+                    {
+                        output.Add(Indent(line.Level) + line.Raw);
+                    }
+                    continue;
+                }
+
+                foreach (var wrapped in Wrap(line))
+                    output.Add(wrapped.TrimEnd());
+            }
+            return output;
+        }
+
+        private string Indent(int level) => new string(' ', level * Options.IndentWidth);
+
+        /// <summary>
+        /// Splits a long line before the binary + and - outside the brackets opened on the line
+        /// </summary>
+        private IEnumerable<string> Wrap(Line line)
+        {
+            string indent = Indent(line.Level);
+            var pieces = line.Pieces;
+            string Join(int from, int to) => string.Concat(pieces.Skip(from).Take(to - from).Select((p, k) => (k > 0 && p.SpaceBefore ? " " : "") + p.Text));
+
+            string whole = Join(0, pieces.Count);
+            if (Options.MaxLineWidth <= 0 || indent.Length + whole.Length <= Options.MaxLineWidth)
+            {
+                yield return indent + whole;
+                yield break;
+            }
+
+            var candidates = pieces.Select((p, k) => (p, k)).Where(x => x.p.Breakable && x.k > 0).ToList();
+            if (candidates.Count == 0)
+            {
+                yield return indent + whole;
+                yield break;
+            }
+            int depth = pieces.Min(p => p.Depth);
+            var breaks = candidates.Where(x => x.p.Depth == depth).Select(x => x.k).ToList();
+
+            int start = 0;
+            string currentIndent = indent;
+            while (start < pieces.Count)
+            {
+                int end = pieces.Count;
+                if (currentIndent.Length + Join(start, end).Length > Options.MaxLineWidth)
+                {
+                    var fitting = breaks.Where(b => b > start && currentIndent.Length + Join(start, b).Length <= Options.MaxLineWidth).ToList();
+                    var later = breaks.Where(b => b > start).ToList();
+                    if (fitting.Count > 0)
+                        end = fitting.Max();
+                    else if (later.Count > 0)
+                        end = later.Min();
+                }
+
+                yield return currentIndent + Join(start, end);
+                start = end;
+
+                // Indented like a continuation line the formatter reads back
+                if (start < pieces.Count)
+                    currentIndent = Indent(line.Blocks + Math.Max(1, pieces[start].Depth));
+            }
+        }
+
+        /// <summary>
+        /// Pads the names of runs of one-line "name = value;" assignments so their '=' line up
+        /// </summary>
+        private static List<string> Align(List<string> output)
+        {
+            var result = new List<string>(output);
+            int i = 0;
+            while (i < result.Count)
+            {
+                int end = i;
+                while (end < result.Count && assignmentPattern.IsMatch(result[end]) && result[end].Contains(';'))
+                    end++;
+
+                if (end - i > 1)
+                {
+                    int width = Enumerable.Range(i, end - i).Max(k => assignmentPattern.Match(result[k]).Groups[1].Length);
+                    for (int k = i; k < end; k++)
+                    {
+                        string name = assignmentPattern.Match(result[k]).Groups[1].Value;
+                        result[k] = name.PadRight(width) + result[k].Substring(name.Length);
+                    }
+                }
+                i = Math.Max(end, i + 1);
+            }
+            return result;
+        }
+    }
+}
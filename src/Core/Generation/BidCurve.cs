@@ -0,0 +1,255 @@
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Networks;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Generation
+{
+    public enum CurveSide
+    {
+        /// <summary>
+        /// Bids to buy: the price falls as the quantity grows
+        /// </summary>
+        Demand,
+
+        /// <summary>
+        /// Offers to sell: the price rises as the quantity grows
+        /// </summary>
+        Supply
+    }
+
+    public enum CurveShape
+    {
+        /// <summary>
+        /// Every point ends a step of constant price that starts at the point before
+        /// </summary>
+        Stepwise,
+
+        /// <summary>
+        /// The price is interpolated linearly between the points
+        /// </summary>
+        Piecewise
+    }
+
+    /// <summary>
+    /// A demand or bid curve of a market-clearing model: quantity and price points, optionally
+    /// scaled per period by time series parameters of the model (the load profile of a demand,
+    /// the fuel price index of an offer). Generate splits the curve into segments of constant
+    /// price and writes, for the curve "d" over the periods T,
+    /// <code>
+    /// range d_segments = 1..4;
+    /// float d_width[d_segments] = [5, 5, 5, 5];
+    /// float d_price[d_segments] = [90, 70, 55, 45];
+    /// dvar float+ d_segment[T,d_segments];
+    /// dvar float+ d_quantity[T];
+    /// forall(t in T, k in d_segments) d_segmentLimit: d_segment[t,k] &lt;= d_width[k] * load[t];
+    /// forall(t in T) d_cleared: d_quantity[t] == sum(k in d_segments) d_segment[t,k];
+    /// dexpr float d_benefit = sum(t in T) sum(k in d_segments) d_price[k] * d_segment[t,k];
+    /// </code>
+    /// Maximizing the benefit of the demand minus the cost of the supply, subject to a balance
+    /// of the cleared quantities, clears the market. The segments fill in merit order without
+    /// binaries only if demand prices fall and supply prices rise, which Validate checks.
+    /// </summary>
+    public class BidCurve
+    {
+        public const string GeneratorPrefix = "curve:";
+
+        private const double Tolerance = 1e-9;
+
+        private static readonly Regex NamePattern = new Regex(@"^[A-Za-z_]\w*$");
+
+        public BidCurve(string name, CurveSide side, CurveShape shape = CurveShape.Stepwise)
+        {
+            if (!NamePattern.IsMatch(name))
+                throw new ArgumentException($"'{name}' is not a name", nameof(name));
+            Name = name;
+            Side = side;
+            Shape = shape;
+        }
+
+        public string Name { get; }
+        public CurveSide Side { get; }
+        public CurveShape Shape { get; }
+
+        /// <summary>
+        /// Points of the curve in increasing quantity; a stepwise curve starts at quantity 0
+        /// and a piecewise curve is flat up to its first point
+        /// </summary>
+        public List<(double Quantity, double Price)> Points { get; } = new List<(double, double)>();
+
+        /// <summary>
+        /// Ordered set of the periods the curve is cleared in
+        /// </summary>
+        public string Periods { get; set; } = "T";
+
+        /// <summary>
+        /// Parameter indexed by the periods that scales the quantities; null for the same
+        /// quantities in every period
+        /// </summary>
+        public string? QuantityProfile { get; set; }
+
+        /// <summary>
+        /// Parameter indexed by the periods that scales the prices; null for the same prices
+        /// in every period
+        /// </summary>
+        public string? PriceProfile { get; set; }
+
+        /// <summary>
+        /// Steps each piece of a piecewise curve is split into; each step is priced at the
+        /// middle of its piece, so the value of a filled step is exact
+        /// </summary>
+        public int Resolution { get; set; } = 4;
+
+        public BidCurve Add(double quantity, double price)
+        {
+            Points.Add((quantity, price));
+            return this;
+        }
+
+        /// <summary>
+        /// Checks the curve: points, quantities that do not increase, prices out of merit order,
+        /// profiles that are not names and the resolution
+        /// </summary>
+        public List<LintFinding> Validate()
+        {
+            var findings = new List<LintFinding>();
+
+            if (Points.Count == 0 || Shape == CurveShape.Piecewise && Points.Count < 2)
+                findings.Add(Finding("curve-points", $"needs at least {(Shape == CurveShape.Piecewise ? "two points" : "one point")}"));
+            if (Points.Any(p => p.Quantity < 0))
+                findings.Add(Finding("negative-quantity", "has a negative quantity"));
+            if (Points.Zip(Points.Skip(1)).Any(p => p.Second.Quantity <= p.First.Quantity))
+                findings.Add(Finding("curve-quantities", "has quantities that do not increase"));
+
+            var prices = Points.Select(p => p.Price).ToList();
+            bool ordered = Side == CurveSide.Demand
+                ? prices.Zip(prices.Skip(1)).All(p => p.Second <= p.First + Tolerance)
+                : prices.Zip(prices.Skip(1)).All(p => p.Second >= p.First - Tolerance);
+            if (!ordered)
+                findings.Add(Finding("merit-order", Side == CurveSide.Demand ? "has a price that rises, so its segments would not fill in order" : "has a price that falls, so its segments would not fill in order"));
+
+            foreach (var profile in new[] { QuantityProfile, PriceProfile }.Where(p => p != null && !NamePattern.IsMatch(p)))
+                findings.Add(Finding("curve-profile", $"'{profile}' is not the name of a parameter"));
+            if (Shape == CurveShape.Piecewise && Resolution < 1)
+                findings.Add(Finding("curve-resolution", "needs at least one step per piece"));
+
+            return findings;
+        }
+
+        /// <summary>
+        /// Segments of constant price in merit order: the steps of a stepwise curve, or the
+        /// pieces of a piecewise curve split into Resolution steps each; adjacent segments with
+        /// the same price are merged
+        /// </summary>
+        public List<(double Width, double Price)> Segments()
+        {
+            var segments = new List<(double Width, double Price)>();
+
+            void Add(double width, double price)
+            {
+                if (width <= Tolerance)
+                    return;
+                if (segments.Count > 0 && Math.Abs(segments[^1].Price - price) <= Tolerance)
+                    segments[^1] = (segments[^1].Width + width, price);
+                else
+                    segments.Add((width, price));
+            }
+
+            if (Shape == CurveShape.Stepwise)
+            {
+                double start = 0;
+                foreach (var (quantity, price) in Points)
+                {
+                    Add(quantity - start, price);
+                    start = quantity;
+                }
+                return segments;
+            }
+
+            Add(Points[0].Quantity, Points[0].Price);
+            foreach (var (from, to) in Points.Zip(Points.Skip(1)))
+            {
+                double width = (to.Quantity - from.Quantity) / Resolution;
+                for (int step = 0; step < Resolution; step++)
+                    Add(width, from.Price + (to.Price - from.Price) * (step + 0.5) / Resolution);
+            }
+            return segments;
+        }
+
+        /// <summary>
+        /// The documented entities of the curve
+        /// </summary>
+        public List<PatternEntity> Generate()
+        {
+            var errors = Validate();
+            if (errors.Count > 0)
+                throw new InvalidOperationException($"Curve {Name} is not valid: {string.Join("; ", errors)}");
+
+            var segments = Segments();
+            string set = $"{Name}_segments";
+            string side = Side == CurveSide.Demand ? "demand" : "supply";
+            string width = QuantityProfile != null ? $"{Name}_width[k] * {QuantityProfile}[t]" : $"{Name}_width[k]";
+            string price = PriceProfile != null ? $"{Name}_price[k] * {PriceProfile}[t]" : $"{Name}_price[k]";
+
+            return new List<PatternEntity>
+            {
+                Entity(new EntityDefinition { Kind = EntityKind.Set, Type = "range", Name = set, Value = $"1..{segments.Count}" },
+                    $"Segments of the {side} curve {Name}, in merit order"),
+                Entity(new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = $"{Name}_width", IndexSets = { set },
+                        Value = $"[{string.Join(", ", segments.Select(s => NetworkFormulation.Format(s.Width)))}]" },
+                    QuantityProfile != null ? $"Quantity of each segment of {Name}, scaled by {QuantityProfile} in every period" : $"Quantity of each segment of {Name}"),
+                Entity(new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = $"{Name}_price", IndexSets = { set },
+                        Value = $"[{string.Join(", ", segments.Select(s => NetworkFormulation.Format(s.Price)))}]" },
+                    PriceProfile != null ? $"Price of each segment of {Name}, scaled by {PriceProfile} in every period" : $"Price of each segment of {Name}"),
+                Entity(new EntityDefinition { Kind = EntityKind.Variable, Type = "float+", Name = $"{Name}_segment", IndexSets = { Periods, set } },
+                    $"Quantity cleared on each segment of {Name} in the period"),
+                Entity(new EntityDefinition { Kind = EntityKind.Variable, Type = "float+", Name = $"{Name}_quantity", IndexSets = { Periods } },
+                    $"Quantity of {Name} cleared in the period"),
+                Entity(new EntityDefinition { Kind = EntityKind.Constraint, Forall = $"t in {Periods}, k in {set}", Name = $"{Name}_segmentLimit",
+                        Body = $"{Name}_segment[t,k] <= {width}" },
+                    $"A segment of {Name} clears at most its quantity"),
+                Entity(new EntityDefinition { Kind = EntityKind.Constraint, Forall = $"t in {Periods}", Name = $"{Name}_cleared",
+                        Body = $"{Name}_quantity[t] == sum(k in {set}) {Name}_segment[t,k]" },
+                    $"The quantity of {Name} is what clears on its segments"),
+                Entity(new EntityDefinition { Kind = EntityKind.DecisionExpression, Type = "float", Name = Side == CurveSide.Demand ? $"{Name}_benefit" : $"{Name}_cost",
+                        Value = $"sum(t in {Periods}) sum(k in {set}) {price} * {Name}_segment[t,k]" },
+                    Side == CurveSide.Demand
+                        ? $"Gross benefit of the demand {Name}, the area under its curve up to the cleared quantity"
+                        : $"Cost of the supply {Name}, the area under its curve up to the cleared quantity")
+            };
+        }
+
+        /// <summary>
+        /// Change set that writes the entities of the curve into a model, tagged
+        /// "market/demand/name" or "market/supply/name", replacing what was generated for it
+        /// before (see ModelProvenance.Regenerate)
+        /// </summary>
+        public ChangeSet Regenerate(ModelSource source)
+        {
+            var entities = Generate().ToDictionary(e => e.Definition.Key, StringComparer.Ordinal);
+            var regenerated = ModelProvenance.Regenerate(source, GeneratorPrefix + Name, entities.Values.Select(e => e.Definition.ToStatement()));
+            var tags = new[] { $"market/{(Side == CurveSide.Demand ? "demand" : "supply")}/{Name}" };
+
+            return new ChangeSet
+            {
+                Title = $"Generate curve {Name}",
+                Edits = regenerated.Edits
+                    .Select(e => e.IsRemoval ? e : ModelEdit.Upsert(e.Statement!, e.Annotation, entities[e.Key!].Documentation, tags))
+                    .ToList()
+            };
+        }
+
+        private static PatternEntity Entity(EntityDefinition definition, string documentation)
+        {
+            return new PatternEntity { Definition = definition, Documentation = documentation };
+        }
+
+        private LintFinding Finding(string id, string message)
+        {
+            return new LintFinding { RuleId = id, Severity = LintSeverity.Error, Subject = Name, Message = message };
+        }
+
+        public override string ToString() => $"{Name} ({Side.ToString().ToLowerInvariant()}, {Points.Count} points)";
+    }
+}
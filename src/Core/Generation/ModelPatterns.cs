@@ -0,0 +1,354 @@
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Models;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Generation
+{
+    /// <summary>
+    /// One argument of a pattern: the name of a set, parameter or variable the generated
+    /// entities are built on
+    /// </summary>
+    public class PatternArgument
+    {
+        public string Name { get; init; } = "";
+        public string Description { get; init; } = "";
+
+        /// <summary>
+        /// Name used when the argument is not given
+        /// </summary>
+        public string Default { get; init; } = "";
+
+        /// <summary>
+        /// True if the argument names a set, which the model must declare
+        /// </summary>
+        public bool IsSet { get; init; }
+    }
+
+    /// <summary>
+    /// One declaration a pattern generates, with its docstring
+    /// </summary>
+    public class PatternEntity
+    {
+        public EntityDefinition Definition { get; init; } = new EntityDefinition();
+        public string Documentation { get; init; } = "";
+
+        public override string ToString() => Definition.ToStatement();
+    }
+
+    /// <summary>
+    /// A common MIP formulation, generated from the names of the sets, parameters and
+    /// variables it is written over
+    /// </summary>
+    public class ModelPattern
+    {
+        public string Name { get; init; } = "";
+        public string Description { get; init; } = "";
+        public IReadOnlyList<PatternArgument> Arguments { get; init; } = new List<PatternArgument>();
+
+        internal Func<Func<string, string>, List<PatternEntity>> Build { get; init; } = null!;
+
+        public override string ToString() => $"{Name}({string.Join(", ", Arguments.Select(a => a.Name))})";
+    }
+
+    /// <summary>
+    /// A model text with the entities of a pattern added
+    /// </summary>
+    public class PatternResult
+    {
+        public string ModelText { get; init; } = "";
+
+        /// <summary>
+        /// The edits that turn the original text into ModelText
+        /// </summary>
+        public ChangeSet Changes { get; init; } = new ChangeSet();
+
+        /// <summary>
+        /// Keys of parameters and variables the model already declared, which the pattern uses as they are
+        /// </summary>
+        public List<string> Reused { get; } = new List<string>();
+
+        public List<string> Warnings { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Library of common MIP modeling patterns: minimum up/down times, ramping limits, startup
+    /// logic and cost, inventory balance and network flow conservation. Each is a generator
+    /// parameterized by the names it is written over, so that
+    /// <code>
+    /// ModelPatterns.Apply(model, "min-up-down", new Dictionary&lt;string, string&gt; { ["units"] = "Plants" })
+    /// </code>
+    /// adds the documented parameters, variables and constraints of the formulation. Parameters
+    /// and variables the model already declares are used as they are; constraints and decision
+    /// expressions are written, or rewritten when the pattern is applied again. The generated
+    /// declarations carry a "// @generated pattern:name" annotation.
+    /// </summary>
+    public static class ModelPatterns
+    {
+        public const string GeneratorPrefix = "pattern:";
+
+        private static readonly Regex NamePattern = new Regex(@"^[A-Za-z_]\w*$");
+
+        private static PatternArgument Units => new PatternArgument { Name = "units", Default = "Units", IsSet = true, Description = "units (plants, machines)" };
+        private static PatternArgument Periods => new PatternArgument { Name = "periods", Default = "T", IsSet = true, Description = "time periods, in order" };
+        private static PatternArgument On => new PatternArgument { Name = "on", Default = "on", Description = "binary variable: the unit is on in the period" };
+        private static PatternArgument Start => new PatternArgument { Name = "start", Default = "start", Description = "binary variable: the unit starts up in the period" };
+        private static PatternArgument Stop => new PatternArgument { Name = "stop", Default = "stop", Description = "binary variable: the unit shuts down in the period" };
+
+        /// <summary>
+        /// The patterns of the library
+        /// </summary>
+        public static IReadOnlyList<ModelPattern> All { get; } = new List<ModelPattern>
+        {
+            new ModelPattern
+            {
+                Name = "min-up-down",
+                Description = "A unit that starts stays on for at least minUp periods, and one that stops stays off for at least minDown periods",
+                Arguments = new[]
+                {
+                    Units, Periods, On, Start, Stop,
+                    new PatternArgument { Name = "minUp", Default = "minUp", Description = "minimum number of periods a unit stays on" },
+                    new PatternArgument { Name = "minDown", Default = "minDown", Description = "minimum number of periods a unit stays off" }
+                },
+                Build = a => new List<PatternEntity>
+                {
+                    Parameter("int", a("minUp"), "Minimum number of periods a unit stays on once started", a("units")),
+                    Parameter("int", a("minDown"), "Minimum number of periods a unit stays off once stopped", a("units")),
+                    Variable("bool", a("on"), "1 if the unit is on in the period", a("units"), a("periods")),
+                    Variable("bool", a("start"), "1 if the unit starts up in the period", a("units"), a("periods")),
+                    Variable("bool", a("stop"), "1 if the unit shuts down in the period", a("units"), a("periods")),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("on")}_minUp",
+                        $"sum(s in window({a("periods")}, t, {a("minUp")}[u])) {a("start")}[u,s] <= {a("on")}[u,t]",
+                        $"A unit started in the last {a("minUp")} periods is on; the window is cut off at the start of the horizon"),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("on")}_minDown",
+                        $"sum(s in window({a("periods")}, t, {a("minDown")}[u])) {a("stop")}[u,s] <= 1 - {a("on")}[u,t]",
+                        $"A unit stopped in the last {a("minDown")} periods is off; the window is cut off at the start of the horizon")
+                }
+            },
+            new ModelPattern
+            {
+                Name = "startup-cost",
+                Description = "Startups and shutdowns follow the changes of the on/off state, and their cost is summed in a decision expression",
+                Arguments = new[]
+                {
+                    Units, Periods, On, Start, Stop,
+                    new PatternArgument { Name = "initial", Default = "on0", Description = "on/off state of each unit before the first period" },
+                    new PatternArgument { Name = "cost", Default = "startCost", Description = "cost of one startup" },
+                    new PatternArgument { Name = "total", Default = "startupCost", Description = "decision expression for the total startup cost" }
+                },
+                Build = a => new List<PatternEntity>
+                {
+                    Parameter("int", a("initial"), "1 if the unit is on before the first period", a("units")),
+                    Parameter("float", a("cost"), "Cost of one startup of the unit", a("units")),
+                    Variable("bool", a("on"), "1 if the unit is on in the period", a("units"), a("periods")),
+                    Variable("bool", a("start"), "1 if the unit starts up in the period", a("units"), a("periods")),
+                    Variable("bool", a("stop"), "1 if the unit shuts down in the period", a("units"), a("periods")),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("on")}_logic",
+                        $"{a("on")}[u,t] - {a("on")}[u,prev({a("periods")}, t, initial = {a("initial")}[u])] == {a("start")}[u,t] - {a("stop")}[u,t]",
+                        $"The change of state is a startup or a shutdown; the first period starts from {a("initial")}"),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("on")}_startOrStop",
+                        $"{a("start")}[u,t] + {a("stop")}[u,t] <= 1",
+                        "A unit does not start up and shut down in the same period"),
+                    Expression(a("total"),
+                        $"sum(u in {a("units")}) sum(t in {a("periods")}) {a("cost")}[u] * {a("start")}[u,t]",
+                        "Total startup cost; add it to the objective")
+                }
+            },
+            new ModelPattern
+            {
+                Name = "ramping",
+                Description = "Output changes from one period to the next by at most the ramp-up and ramp-down limits",
+                Arguments = new[]
+                {
+                    Units, Periods,
+                    new PatternArgument { Name = "output", Default = "gen", Description = "output of the unit in the period" },
+                    new PatternArgument { Name = "rampUp", Default = "rampUp", Description = "largest increase of output from one period to the next" },
+                    new PatternArgument { Name = "rampDown", Default = "rampDown", Description = "largest decrease of output from one period to the next" },
+                    new PatternArgument { Name = "initial", Default = "gen0", Description = "output of each unit before the first period" }
+                },
+                Build = a => new List<PatternEntity>
+                {
+                    Parameter("float", a("rampUp"), "Largest increase of output from one period to the next", a("units")),
+                    Parameter("float", a("rampDown"), "Largest decrease of output from one period to the next", a("units")),
+                    Parameter("float", a("initial"), "Output of the unit before the first period", a("units")),
+                    Variable("float+", a("output"), "Output of the unit in the period", a("units"), a("periods")),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("output")}_rampUp",
+                        $"{a("output")}[u,t] - {a("output")}[u,prev({a("periods")}, t, initial = {a("initial")}[u])] <= {a("rampUp")}[u]",
+                        $"Output rises by at most {a("rampUp")}; the first period ramps from {a("initial")}"),
+                    Constraint($"u in {a("units")}, t in {a("periods")}", $"{a("output")}_rampDown",
+                        $"{a("output")}[u,prev({a("periods")}, t, initial = {a("initial")}[u])] - {a("output")}[u,t] <= {a("rampDown")}[u]",
+                        $"Output falls by at most {a("rampDown")}; the first period ramps from {a("initial")}")
+                }
+            },
+            new ModelPattern
+            {
+                Name = "inventory-balance",
+                Description = "Stock at the end of a period is the stock before it plus production minus demand",
+                Arguments = new[]
+                {
+                    new PatternArgument { Name = "items", Default = "Items", IsSet = true, Description = "products or locations that hold stock" },
+                    Periods,
+                    new PatternArgument { Name = "stock", Default = "stock", Description = "stock at the end of the period" },
+                    new PatternArgument { Name = "production", Default = "produce", Description = "amount produced or received in the period" },
+                    new PatternArgument { Name = "demand", Default = "demand", Description = "amount delivered in the period" },
+                    new PatternArgument { Name = "initial", Default = "stock0", Description = "stock before the first period" }
+                },
+                Build = a => new List<PatternEntity>
+                {
+                    Parameter("float", a("demand"), "Amount delivered in the period", a("items"), a("periods")),
+                    Parameter("float", a("initial"), "Stock before the first period", a("items")),
+                    Variable("float+", a("stock"), "Stock at the end of the period", a("items"), a("periods")),
+                    Variable("float+", a("production"), "Amount produced or received in the period", a("items"), a("periods")),
+                    Constraint($"i in {a("items")}, t in {a("periods")}", $"{a("stock")}_balance",
+                        $"{a("stock")}[i,t] == {a("stock")}[i,prev({a("periods")}, t, initial = {a("initial")}[i])] + {a("production")}[i,t] - {a("demand")}[i,t]",
+                        $"Stock carried over plus {a("production")} minus {a("demand")}; the first period starts from {a("initial")}")
+                }
+            },
+            new ModelPattern
+            {
+                Name = "flow-conservation",
+                Description = "Flow out of a node minus flow into it equals its supply, on arcs with a capacity",
+                Arguments = new[]
+                {
+                    new PatternArgument { Name = "nodes", Default = "Nodes", IsSet = true, Description = "nodes of the network" },
+                    new PatternArgument { Name = "flow", Default = "flow", Description = "flow on the arc from one node to another" },
+                    new PatternArgument { Name = "supply", Default = "supply", Description = "supply of the node (negative for demand)" },
+                    new PatternArgument { Name = "capacity", Default = "capacity", Description = "capacity of the arc (0 where there is none)" }
+                },
+                Build = a => new List<PatternEntity>
+                {
+                    Parameter("float", a("supply"), "Supply of the node; negative for demand", a("nodes")),
+                    Parameter("float", a("capacity"), "Capacity of the arc from the first node to the second; 0 where there is no arc", a("nodes"), a("nodes")),
+                    Variable("float+", a("flow"), "Flow on the arc from the first node to the second", a("nodes"), a("nodes")),
+                    Constraint($"n in {a("nodes")}", $"{a("flow")}_conservation",
+                        $"sum(m in {a("nodes")}) {a("flow")}[n,m] == {a("supply")}[n] + sum(m in {a("nodes")}) {a("flow")}[m,n]",
+                        $"Flow out of the node equals its {a("supply")} plus the flow into it"),
+                    Constraint($"n in {a("nodes")}, m in {a("nodes")}", $"{a("flow")}_capacity",
+                        $"{a("flow")}[n,m] <= {a("capacity")}[n,m]",
+                        $"Flow on an arc is at most its {a("capacity")}")
+                }
+            }
+        };
+
+        /// <summary>
+        /// The pattern with the given name; throws ArgumentException if there is none
+        /// </summary>
+        public static ModelPattern Find(string name)
+        {
+            return All.FirstOrDefault(p => p.Name == name)
+                ?? throw new ArgumentException($"Unknown pattern '{name}'; the library has {string.Join(", ", All.Select(p => p.Name))}");
+        }
+
+        /// <summary>
+        /// The entities of a pattern over the given names; arguments that are not given take
+        /// their defaults. Throws ArgumentException for an argument the pattern does not have
+        /// or a name that is not an identifier.
+        /// </summary>
+        public static List<PatternEntity> Generate(string pattern, IReadOnlyDictionary<string, string>? arguments = null)
+        {
+            var definition = Find(pattern);
+            var names = new Dictionary<string, string>(StringComparer.Ordinal);
+            foreach (var argument in definition.Arguments)
+                names[argument.Name] = argument.Default;
+
+            foreach (var (name, value) in arguments ?? new Dictionary<string, string>())
+            {
+                if (!names.ContainsKey(name))
+                    throw new ArgumentException($"Pattern {definition} has no argument '{name}'");
+                if (!NamePattern.IsMatch(value.Trim()))
+                    throw new ArgumentException($"Argument '{name}' of {definition.Name} must be a name, not '{value}'");
+                names[name] = value.Trim();
+            }
+
+            return definition.Build(name => names[name]);
+        }
+
+        /// <summary>
+        /// Adds the entities of a pattern to a model text
+        /// </summary>
+        public static PatternResult Apply(string modelText, string pattern, IReadOnlyDictionary<string, string>? arguments = null)
+        {
+            var definition = Find(pattern);
+            var entities = Generate(pattern, arguments);
+            var source = ModelSource.Parse(modelText);
+            string annotation = new EntityProvenance { Generator = GeneratorPrefix + definition.Name }.ToAnnotation();
+            var provenance = ModelProvenance.Parse(source);
+            var changes = new ChangeSet { Title = $"Add pattern {definition.Name}" };
+            var reused = new List<string>();
+            var warnings = new List<string>();
+
+            foreach (var argument in definition.Arguments.Where(a => a.IsSet))
+            {
+                string name = arguments != null && arguments.TryGetValue(argument.Name, out var given) ? given.Trim() : argument.Default;
+                if (source.Find(EntityCatalog.KeyOf(EntityKind.Set, name)) == null)
+                    warnings.Add($"Set '{name}' ({argument.Description}) is not declared");
+            }
+
+            foreach (var entity in entities)
+            {
+                var existing = source.Find(entity.Definition.Key);
+                if (existing != null && entity.Definition.Kind is EntityKind.Parameter or EntityKind.Variable)
+                {
+                    reused.Add(entity.Definition.Key);
+                    continue;
+                }
+                if (existing != null && provenance.Find(entity.Definition.Key)?.Generator != GeneratorPrefix + definition.Name)
+                {
+                    warnings.Add($"{entity.Definition.Key} is already declared and was not generated by {definition.Name}; it is left as is");
+                    continue;
+                }
+                changes.Edits.Add(ModelEdit.Upsert(entity.Definition.ToStatement(), annotation, entity.Documentation));
+            }
+
+            changes.Apply(source);
+            var result = new PatternResult { ModelText = source.ToString(), Changes = changes };
+            result.Reused.AddRange(reused);
+            result.Warnings.AddRange(warnings);
+            return result;
+        }
+
+        private static PatternEntity Parameter(string type, string name, string documentation, params string[] sets)
+        {
+            return new PatternEntity
+            {
+                Definition = new EntityDefinition
+                {
+                    Kind = EntityKind.Parameter,
+                    Type = type,
+                    Name = name,
+                    IndexSets = sets.ToList(),
+                    Value = "..."
+                },
+                Documentation = documentation
+            };
+        }
+
+        private static PatternEntity Variable(string type, string name, string documentation, params string[] sets)
+        {
+            return new PatternEntity
+            {
+                Definition = new EntityDefinition { Kind = EntityKind.Variable, Type = type, Name = name, IndexSets = sets.ToList() },
+                Documentation = documentation
+            };
+        }
+
+        private static PatternEntity Constraint(string forall, string name, string body, string documentation)
+        {
+            return new PatternEntity
+            {
+                Definition = new EntityDefinition { Kind = EntityKind.Constraint, Forall = forall, Name = name, Body = body },
+                Documentation = documentation
+            };
+        }
+
+        private static PatternEntity Expression(string name, string value, string documentation)
+        {
+            return new PatternEntity
+            {
+                Definition = new EntityDefinition { Kind = EntityKind.DecisionExpression, Type = "float", Name = name, Value = value },
+                Documentation = documentation
+            };
+        }
+    }
+}
@@ -0,0 +1,236 @@
+using System.Text;
+using Core.Import;
+
+namespace Core.Generation
+{
+    /// <summary>
+    /// Input that broke a parser's contract: an exception other than the parser's error
+    /// channel, or no answer within the time limit
+    /// </summary>
+    public class FuzzFinding
+    {
+        public string Target { get; init; } = "";
+        public string Input { get; init; } = "";
+
+        /// <summary>
+        /// Exception type name, or "Timeout"
+        /// </summary>
+        public string Failure { get; init; } = "";
+        public string Message { get; init; } = "";
+
+        public override string ToString() => $"{Target}: {Failure}: {Message}";
+    }
+
+    /// <summary>
+    /// Mutation fuzzer for the parsers. Each iteration takes a corpus entry (seeded from test
+    /// models and generated models), applies a few structure-aware mutations and runs the
+    /// parser on the result. Malformed input must end in the parser's error channel:
+    /// InvalidOperationException or FormatException for the LP and MOF importers, reported
+    /// errors for the model and data parsers. Anything else is a finding. Runs are repeatable:
+    /// the same seed and corpus give the same inputs.
+    /// </summary>
+    public class ParserFuzzer
+    {
+        public static IReadOnlyList<string> Targets { get; } = new[] { "lp", "mof", "model", "data" };
+
+        private static readonly string[] Fragments =
+        {
+            ";", ":", ",", "{", "}", "[", "]", "(", ")", "<", ">", "..", "+", "-", "*", "/", "=", "==", "<=", ">=",
+            "\"", "'", "//", "/*", "*/", "\\", "\n", " ", "0", "-1", "1e308", "-1e308", "1e-400", "NaN", "Infinity",
+            "2147483648", "99999999999999999999", "x", "x[1]", "in", "forall", "sum", "range", "dvar", "float", "int",
+            "bool", "minimize", "maximize", "subject to", "tuple", "execute", "ST", "Bounds", "Generals", "End",
+            "{\"", "\":", "null", "true", "[]", "{}", "\"name\"", "\"variables\"", "\"terms\"", "\"type\""
+        };
+
+        private readonly int seed;
+
+        public ParserFuzzer(int seed = 0)
+        {
+            this.seed = seed;
+        }
+
+        public int Iterations { get; init; } = 1000;
+
+        /// <summary>
+        /// Longest a single parse may take before it counts as a hang
+        /// </summary>
+        public TimeSpan InputTimeout { get; init; } = TimeSpan.FromSeconds(5);
+
+        /// <summary>
+        /// Mutated inputs are cut to this length, so a run cannot grow its inputs without bound
+        /// </summary>
+        public int MaxInputLength { get; init; } = 64 * 1024;
+
+        /// <summary>
+        /// Built-in seeds for a target: generated models in the target's syntax and a few
+        /// hand-written snippets
+        /// </summary>
+        public static List<string> DefaultCorpus(string target)
+        {
+            var corpus = new List<string>();
+            foreach (var structure in new[] { ModelStructure.Random, ModelStructure.BlockAngular, ModelStructure.Staircase })
+            {
+                var generated = new RandomModelGenerator(new GeneratorOptions
+                {
+                    Rows = 4, Columns = 5, Structure = structure, Blocks = 2, IntegerFraction = 0.4, BinaryFraction = 0.2, Seed = 1
+                }).Generate().Model;
+
+                switch (target)
+                {
+                    case "model":
+                        corpus.Add(generated.ToModelText());
+                        break;
+                    case "mof":
+                        corpus.Add(ToMof(generated));
+                        break;
+                }
+            }
+
+            switch (target)
+            {
+                case "lp":
+                    corpus.Add("\\ Pyomo LP\nmax\nobj: 3 x1 + 2 x2 - 4\nst\nc1: x1 + x2 <= 4\nc2: -x1 + 3 x2 >= -2\nbounds\n0 <= x1 <= 3\nx2 free\ngenerals\nx1\nend\n");
+                    corpus.Add("Minimize\n obj: 2 a + [ a ^ 2 ] / 2\nSubject To\n r_1: a - b = 1\nBinary\n b\nEnd\n");
+                    break;
+                case "model":
+                    corpus.Add("range I = 1..3;\nfloat c[I] = [1, 2, 3];\ndvar float+ x[I];\nminimize sum(i in I) c[i]*x[i];\nsubject to {\n  forall(i in I) lo: x[i] >= 1;\n}\n");
+                    corpus.Add("tuple Arc { int from; int to; float cost; }\n{Arc} Arcs = {<1,2,3.5>, <2,3,1>};\ndvar float+ flow[Arcs];\nminimize sum(a in Arcs) a.cost * flow[a];\n");
+                    break;
+                case "data":
+                    corpus.Add("n = 3;\ncost = [1.5, 2, 3];\nsupply = [[1, 2], [3, 4]];\nArcs = {<1,2,3.5>, <2,3,1>};\n");
+                    break;
+            }
+
+            return corpus;
+        }
+
+        /// <summary>
+        /// Fuzzes one target, returning one finding per distinct failure (type and message)
+        /// </summary>
+        public List<FuzzFinding> Run(string target, IReadOnlyList<string> corpus, CancellationToken cancellationToken = default)
+        {
+            if (!Targets.Contains(target))
+                throw new InvalidOperationException($"Unknown fuzz target '{target}' (expected {string.Join(", ", Targets)})");
+            if (corpus.Count == 0)
+                throw new InvalidOperationException("Fuzzing needs at least one corpus entry");
+
+            var random = new Random(seed);
+            var findings = new List<FuzzFinding>();
+            var seen = new HashSet<string>(StringComparer.Ordinal);
+
+            for (int i = 0; i < Iterations; i++)
+            {
+                cancellationToken.ThrowIfCancellationRequested();
+
+                string input = corpus[random.Next(corpus.Count)];
+                int mutations = random.Next(1, 5);
+                for (int m = 0; m < mutations; m++)
+                    input = Mutate(input, corpus, random);
+                if (input.Length > MaxInputLength)
+                    input = input.Substring(0, MaxInputLength);
+
+                var finding = Check(target, input);
+                if (finding != null && seen.Add($"{finding.Failure}: {finding.Message}"))
+                    findings.Add(finding);
+            }
+
+            return findings;
+        }
+
+        /// <summary>
+        /// Runs the parser of the target on one input, returning null if it kept its contract
+        /// </summary>
+        public FuzzFinding? Check(string target, string input)
+        {
+            Exception? failure = null;
+            var parse = Task.Run(() =>
+            {
+                try
+                {
+                    Execute(target, input);
+                }
+                catch (Exception ex) when (ex is not (InvalidOperationException or FormatException) || target is "model" or "data")
+                {
+                    failure = ex;
+                }
+                catch (Exception)
+                {
+                    // The importer rejected the input with a message
+                }
+            });
+
+            if (!parse.Wait(InputTimeout))
+                return new FuzzFinding { Target = target, Input = input, Failure = "Timeout", Message = $"no result after {InputTimeout.TotalSeconds:0.#} s" };
+
+            return failure == null
+                ? null
+                : new FuzzFinding { Target = target, Input = input, Failure = failure.GetType().Name, Message = failure.Message };
+        }
+
+        /// <summary>
+        /// Parses the input with the target's parser
+        /// </summary>
+        public static void Execute(string target, string input)
+        {
+            switch (target)
+            {
+                case "lp":
+                    new LpImporter().Import(input);
+                    break;
+                case "mof":
+                    new MofImporter().Import(input);
+                    break;
+                case "model":
+                {
+                    var manager = new ModelManager();
+                    var parser = new EquationParser(manager);
+                    var result = parser.Parse(input);
+                    if (!result.HasErrors)
+                        parser.ExpandAllTemplates(result);
+                    break;
+                }
+                case "data":
+                    new DataFileParser(new ModelManager()).Parse(input);
+                    break;
+                default:
+                    throw new ArgumentException($"Unknown fuzz target '{target}'", nameof(target));
+            }
+        }
+
+        private static string Mutate(string input, IReadOnlyList<string> corpus, Random random)
+        {
+            int position = input.Length == 0 ? 0 : random.Next(input.Length + 1);
+            int length = input.Length == position ? 0 : random.Next(1, Math.Min(16, input.Length - position) + 1);
+
+            switch (random.Next(6))
+            {
+                case 0: // delete a span
+                    return input.Remove(position, length);
+                case 1: // duplicate a span
+                    return input.Insert(position, input.Substring(position, length));
+                case 2: // insert a syntax fragment
+                    return input.Insert(position, Fragments[random.Next(Fragments.Length)]);
+                case 3: // replace a character
+                    if (input.Length == 0)
+                        return input;
+                    var sb = new StringBuilder(input);
+                    sb[Math.Min(position, input.Length - 1)] = (char)random.Next(32, 127);
+                    return sb.ToString();
+                case 4: // splice with another corpus entry
+                    string other = corpus[random.Next(corpus.Count)];
+                    return input.Substring(0, position) + other.Substring(random.Next(other.Length + 1));
+                default: // truncate
+                    return input.Substring(0, position);
+            }
+        }
+
+        private static string ToMof(LinearModel model)
+        {
+            var manager = new ModelManager();
+            var parser = new EquationParser(manager);
+            var result = parser.Parse(model.ToModelText());
+            parser.ExpandAllTemplates(result);
+            return new Export.MofExporter(manager).Export(model.Name);
+        }
+    }
+}
@@ -0,0 +1,242 @@
+using System.Globalization;
+using Core.Import;
+using Core.Models;
+
+namespace Core.Generation
+{
+    public enum ModelStructure
+    {
+        /// <summary>
+        /// Every row may use every column
+        /// </summary>
+        Random,
+
+        /// <summary>
+        /// Independent blocks of rows and columns tied together by a few linking rows
+        /// </summary>
+        BlockAngular,
+
+        /// <summary>
+        /// Row block k uses the columns of blocks k and k + 1 (multi-period models)
+        /// </summary>
+        Staircase
+    }
+
+    /// <summary>
+    /// Size and shape of a generated model. The same options and seed always give the same model.
+    /// </summary>
+    public class GeneratorOptions
+    {
+        public int Rows { get; init; } = 10;
+        public int Columns { get; init; } = 10;
+
+        /// <summary>
+        /// Probability that a row uses a column it may use under the structure
+        /// </summary>
+        public double Density { get; init; } = 0.3;
+
+        /// <summary>
+        /// Fractions of the columns that are general integers and binaries; the rest are continuous
+        /// </summary>
+        public double IntegerFraction { get; init; }
+        public double BinaryFraction { get; init; }
+
+        /// <summary>
+        /// Fraction of the rows that are equalities
+        /// </summary>
+        public double EqualityFraction { get; init; } = 0.1;
+
+        public ModelStructure Structure { get; init; } = ModelStructure.Random;
+
+        /// <summary>
+        /// Number of blocks of a block-angular or staircase model
+        /// </summary>
+        public int Blocks { get; init; } = 2;
+
+        /// <summary>
+        /// Coefficients are nonzero integers in -MaxCoefficient..MaxCoefficient
+        /// </summary>
+        public int MaxCoefficient { get; init; } = 10;
+
+        public int Seed { get; init; }
+
+        public ObjectiveSense Sense { get; init; } = ObjectiveSense.Minimize;
+
+        /// <summary>
+        /// Parses "random", "block-angular" or "staircase"
+        /// </summary>
+        public static ModelStructure ParseStructure(string text)
+        {
+            return text.Trim().ToLowerInvariant() switch
+            {
+                "random" => ModelStructure.Random,
+                "block-angular" or "blockangular" => ModelStructure.BlockAngular,
+                "staircase" => ModelStructure.Staircase,
+                _ => throw new InvalidOperationException($"Unknown model structure '{text}' (expected random, block-angular or staircase)")
+            };
+        }
+
+        public void Validate()
+        {
+            if (Rows < 1 || Columns < 1)
+                throw new InvalidOperationException("A generated model needs at least one row and one column");
+            if (Density <= 0 || Density > 1)
+                throw new InvalidOperationException($"Density must be in (0, 1], got {Density.ToString(CultureInfo.InvariantCulture)}");
+            if (IntegerFraction < 0 || BinaryFraction < 0 || IntegerFraction + BinaryFraction > 1)
+                throw new InvalidOperationException("Integer and binary fractions must be non-negative and sum to at most 1");
+            if (EqualityFraction < 0 || EqualityFraction > 1)
+                throw new InvalidOperationException("Equality fraction must be in [0, 1]");
+            if (MaxCoefficient < 1)
+                throw new InvalidOperationException("MaxCoefficient must be at least 1");
+            if (Structure != ModelStructure.Random && (Blocks < 2 || Blocks > Columns || Blocks > Rows))
+                throw new InvalidOperationException($"A {Structure} model needs 2..min(rows, columns) blocks, got {Blocks}");
+        }
+    }
+
+    /// <summary>
+    /// A generated model with the point it was built around, which satisfies every row
+    /// </summary>
+    public class GeneratedModel
+    {
+        public LinearModel Model { get; init; } = new LinearModel();
+        public Dictionary<string, double> FeasiblePoint { get; init; } = new Dictionary<string, double>(StringComparer.Ordinal);
+    }
+
+    /// <summary>
+    /// Generates random feasible, bounded LPs and MIPs for benchmarking the writers and solver
+    /// drivers and for feeding the parsers structurally valid input. A feasible point is
+    /// drawn first (integral for integer columns, two decimals otherwise) and each right-hand
+    /// side is placed at or beyond the row's value at that point, so the model is feasible by
+    /// construction; all columns have finite bounds, so it is also bounded.
+    /// </summary>
+    public class RandomModelGenerator
+    {
+        private readonly GeneratorOptions options;
+
+        public RandomModelGenerator(GeneratorOptions options)
+        {
+            this.options = options ?? throw new ArgumentNullException(nameof(options));
+            options.Validate();
+        }
+
+        public GeneratedModel Generate()
+        {
+            var random = new Random(options.Seed);
+            var model = new LinearModel
+            {
+                Name = $"random-{options.Structure.ToString().ToLowerInvariant()}-{options.Seed}",
+                ObjectiveSense = options.Sense
+            };
+            var point = new Dictionary<string, double>(StringComparer.Ordinal);
+
+            int binaries = (int)Math.Round(options.Columns * options.BinaryFraction);
+            int integers = Math.Min(options.Columns - binaries, (int)Math.Round(options.Columns * options.IntegerFraction));
+            var types = Enumerable.Repeat(VariableType.Boolean, binaries)
+                .Concat(Enumerable.Repeat(VariableType.Integer, integers))
+                .Concat(Enumerable.Repeat(VariableType.Float, options.Columns - binaries - integers))
+                .OrderBy(_ => random.Next())
+                .ToList();
+
+            var columns = new List<string>(options.Columns);
+            for (int j = 0; j < options.Columns; j++)
+            {
+                string name = $"x{j + 1}";
+                var type = types[j];
+                double upper = type == VariableType.Boolean ? 1 : random.Next(1, 21);
+                double value = type == VariableType.Float
+                    ? Math.Round(random.NextDouble() * upper, 2)
+                    : random.Next(0, (int)upper + 1);
+
+                model.Variables.Add(new LinearVariable { Name = name, Type = type, LowerBound = 0, UpperBound = upper });
+                point[name] = value;
+                columns.Add(name);
+            }
+
+            var used = new HashSet<string>(StringComparer.Ordinal);
+            foreach (var (name, candidates) in RowPlan(columns))
+            {
+                var coefficients = new Dictionary<string, double>(StringComparer.Ordinal);
+                foreach (var column in candidates.Where(_ => random.NextDouble() < options.Density))
+                    coefficients[column] = Coefficient(random);
+
+                // Every row uses at least one column
+                if (coefficients.Count == 0)
+                    coefficients[candidates[random.Next(candidates.Count)]] = Coefficient(random);
+                used.UnionWith(coefficients.Keys);
+
+                // Values have two decimals and coefficients are integers, so rounding to two
+                // decimals recovers the exact activity
+                double activity = Math.Round(coefficients.Sum(c => c.Value * point[c.Key]), 2);
+                var op = random.NextDouble() < options.EqualityFraction
+                    ? RelationalOperator.Equal
+                    : random.Next(2) == 0 ? RelationalOperator.LessThanOrEqual : RelationalOperator.GreaterThanOrEqual;
+                double slack = op == RelationalOperator.Equal ? 0 : random.Next(0, 6);
+
+                model.Constraints.Add(new LinearConstraint
+                {
+                    Name = name,
+                    Coefficients = coefficients,
+                    Operator = op,
+                    Rhs = op == RelationalOperator.GreaterThanOrEqual ? activity - slack : activity + slack
+                });
+            }
+
+            // The objective uses every column, so none is left out of the model
+            foreach (var column in columns)
+                model.ObjectiveCoefficients[column] = Coefficient(random);
+
+            return new GeneratedModel { Model = model, FeasiblePoint = point };
+        }
+
+        /// <summary>
+        /// Names of the rows and the columns each may use, by structure
+        /// </summary>
+        private IEnumerable<(string Name, List<string> Columns)> RowPlan(List<string> columns)
+        {
+            if (options.Structure == ModelStructure.Random)
+            {
+                for (int i = 0; i < options.Rows; i++)
+                    yield return ($"c{i + 1}", columns);
+                yield break;
+            }
+
+            var columnBlocks = Split(columns, options.Blocks);
+            int linking = options.Structure == ModelStructure.BlockAngular
+                ? Math.Max(1, options.Rows / (options.Blocks + 1))
+                : 0;
+            var rowBlocks = Split(Enumerable.Range(1, options.Rows - linking).ToList(), options.Blocks);
+
+            for (int k = 0; k < options.Blocks; k++)
+            {
+                var blockColumns = options.Structure == ModelStructure.Staircase && k + 1 < options.Blocks
+                    ? columnBlocks[k].Concat(columnBlocks[k + 1]).ToList()
+                    : columnBlocks[k];
+                foreach (var row in rowBlocks[k])
+                    yield return ($"b{k + 1}_c{row}", blockColumns);
+            }
+
+            for (int i = 0; i < linking; i++)
+                yield return ($"link{i + 1}", columns);
+        }
+
+        private int Coefficient(Random random)
+        {
+            int magnitude = random.Next(1, options.MaxCoefficient + 1);
+            return random.Next(2) == 0 ? magnitude : -magnitude;
+        }
+
+        /// <summary>
+        /// Splits items into the given number of contiguous, nearly equal parts
+        /// </summary>
+        private static List<List<T>> Split<T>(List<T> items, int parts)
+        {
+            var result = new List<List<T>>(parts);
+            for (int k = 0; k < parts; k++)
+            {
+                int start = items.Count * k / parts, end = items.Count * (k + 1) / parts;
+                result.Add(items.GetRange(start, end - start));
+            }
+            return result;
+        }
+    }
+}
@@ -0,0 +1,457 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Export;
+using Core.Models;
+using Core.Parsing;
+using Core.Services;
+
+namespace Core.Import
+{
+    /// <summary>
+    /// Scalar variable of a flat linear model. Variables read from LinearModel.Variables are views
+    /// of the model's columns: setting Type or a bound changes the model.
+    /// </summary>
+    public class LinearVariable
+    {
+        private LinearVariableTable? table;
+        private int nameId;
+
+        // Values of a variable that is not (yet) part of a model
+        private string name = "";
+        private VariableType type = VariableType.Float;
+        private double? lowerBound;
+        private double? upperBound;
+
+        public LinearVariable()
+        {
+        }
+
+        internal LinearVariable(LinearVariableTable table, int nameId)
+        {
+            Bind(table, nameId);
+        }
+
+        public string Name
+        {
+            get => table != null ? table.GetName(nameId) : name;
+            init => name = value;
+        }
+
+        public VariableType Type
+        {
+            get => table != null ? table.GetVariableType(nameId) : type;
+            set
+            {
+                if (table != null)
+                    table.SetVariableType(nameId, value);
+                else
+                    type = value;
+            }
+        }
+
+        /// <summary>
+        /// Null means unbounded in that direction
+        /// </summary>
+        public double? LowerBound
+        {
+            get => table != null ? table.GetLowerBound(nameId) : lowerBound;
+            set
+            {
+                if (table != null)
+                    table.SetLowerBound(nameId, value);
+                else
+                    lowerBound = value;
+            }
+        }
+
+        public double? UpperBound
+        {
+            get => table != null ? table.GetUpperBound(nameId) : upperBound;
+            set
+            {
+                if (table != null)
+                    table.SetUpperBound(nameId, value);
+                else
+                    upperBound = value;
+            }
+        }
+
+        internal void Bind(LinearVariableTable table, int nameId)
+        {
+            this.table = table;
+            this.nameId = nameId;
+        }
+    }
+
+    /// <summary>
+    /// Linear constraint: sum(coefficients * vars) (&lt;= | &gt;= | ==) rhs. Constraints read from
+    /// LinearModel.Constraints are read-only views; Coefficients is a snapshot of the row, so
+    /// replace the constraint (Constraints[i] = ...) to change it.
+    /// </summary>
+    public class LinearConstraint
+    {
+        private readonly LinearConstraintTable? table;
+        private readonly int row;
+        private readonly int generation;
+
+        private readonly string name = "";
+        private Dictionary<string, double>? coefficients;
+        private readonly RelationalOperator op;
+        private readonly double rhs;
+
+        public LinearConstraint()
+        {
+        }
+
+        internal LinearConstraint(LinearConstraintTable table, int row, int generation)
+        {
+            this.table = table;
+            this.row = row;
+            this.generation = generation;
+        }
+
+        public string Name
+        {
+            get => table != null ? table.GetName(row, generation) : name;
+            init => name = value;
+        }
+
+        public Dictionary<string, double> Coefficients
+        {
+            get => coefficients ??= table != null ? table.GetCoefficients(row, generation) : new Dictionary<string, double>();
+            init => coefficients = value;
+        }
+
+        public RelationalOperator Operator
+        {
+            get => table != null ? table.GetOperator(row, generation) : op;
+            init => op = value;
+        }
+
+        public double Rhs
+        {
+            get => table != null ? table.GetRhs(row, generation) : rhs;
+            init => rhs = value;
+        }
+
+        internal int Row => row;
+
+        internal bool IsViewOf(LinearConstraintTable table, int generation) => this.table == table && this.generation == generation;
+    }
+
+    /// <summary>
+    /// Flat (scalar, non-indexed) linear or mixed-integer model as found in solver interchange
+    /// formats. Importers produce it; ToModelText turns it into editor model syntax.
+    /// </summary>
+    public class LinearModel
+    {
+        private static readonly HashSet<string> reservedWords = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "bool", "dexpr", "dvar", "else", "execute", "float", "forall", "if", "in", "int", "main",
+            "maximize", "minimize", "prod", "range", "string", "subject", "sum", "to", "tuple"
+        };
+
+        public string Name { get; set; } = "";
+        public LinearVariableTable Variables { get; }
+        public LinearConstraintTable Constraints { get; }
+
+        public ObjectiveSense ObjectiveSense { get; set; } = ObjectiveSense.Minimize;
+        public Dictionary<string, double> ObjectiveCoefficients { get; } = new Dictionary<string, double>();
+        public double ObjectiveConstant { get; set; }
+
+        /// <summary>
+        /// Parts of the source that could not be represented (e.g. quadratic or nonlinear terms)
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        /// <summary>
+        /// Flattens an expanded model: evaluates all coefficients and takes bounds and types from
+        /// the variable declarations. Logical constraints cannot be represented and are listed in Warnings.
+        /// </summary>
+        public static LinearModel FromModel(ModelManager manager) => FromModel(manager, CancellationToken.None);
+
+        /// <summary>
+        /// Flattens an expanded model, reporting progress per constraint row
+        /// </summary>
+        public static LinearModel FromModel(ModelManager manager, CancellationToken cancellationToken, IProgress<OperationProgress>? progress = null) =>
+            FromModel(manager, ExportOrdering.SortedColumns, cancellationToken, progress);
+
+        /// <summary>
+        /// Flattens an expanded model with variables, constraints and the terms of each row in the
+        /// given canonical order
+        /// </summary>
+        public static LinearModel FromModel(
+            ModelManager manager,
+            ExportOrdering ordering,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            if (manager.IndexedEquationTemplates.Count > 0 || manager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot export: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            var tracker = new ProgressTracker("export", progress, cancellationToken);
+            var model = new LinearModel();
+
+            tracker.Stage("variables");
+            var equations = manager.MaterializeRows();
+            var rowVariables = new List<IEnumerable<string>>();
+            if (manager.Objective != null)
+                rowVariables.Add(manager.Objective.Coefficients.Keys);
+            rowVariables.AddRange(ConstraintTable.VariablesOf(equations));
+            var columns = ExportOrder.Columns(rowVariables, ordering);
+            var columnIndex = ExportOrder.Index(columns);
+
+            foreach (var name in columns)
+            {
+                var info = IntegerModel.FindVariableInfo(manager, name);
+                if (info?.IsSemiContinuous == true)
+                    model.Warnings.Add($"Semi-continuous domain of '{name}' was not exported");
+
+                model.Variables.Add(new LinearVariable
+                {
+                    Name = name,
+                    Type = info?.Type ?? VariableType.Float,
+                    LowerBound = info?.Type == VariableType.Boolean ? 0 : info != null ? info.GetBounds(name).Lower : 0,
+                    UpperBound = info?.Type == VariableType.Boolean ? 1 : info?.GetBounds(name).Upper
+                });
+            }
+
+            // Entities with decimal or rational precision are evaluated exactly, then converted
+            bool exact = NumericEvaluator.HasExactEntities(manager);
+            var usedNames = new HashSet<string>(StringComparer.Ordinal);
+            var constraints = new List<LinearConstraint>(equations.Count);
+            int row = 0;
+            tracker.Stage("constraints", equations.Count);
+            foreach (var equation in equations)
+            {
+                tracker.Tick(row, equations.Count);
+                row++;
+                string name = equation.Label ?? (string.IsNullOrEmpty(equation.GetDescription()) ? $"c{row}" : equation.GetDescription());
+                string unique = name;
+                for (int i = 2; !usedNames.Add(unique); i++)
+                    unique = $"{name}_{i}";
+
+                var (coefficients, constant) = exact
+                    ? EvaluateExact(manager, equation, unique, model.Warnings)
+                    : equation.Evaluate(manager);
+                constraints.Add(new LinearConstraint
+                {
+                    Name = unique,
+                    Coefficients = ExportOrder.Terms(coefficients.Where(c => c.Value != 0), columnIndex).ToDictionary(c => c.Key, c => c.Value),
+                    // Strict inequalities are relaxed, as in the MPS export
+                    Operator = equation.Operator switch
+                    {
+                        RelationalOperator.LessThan => RelationalOperator.LessThanOrEqual,
+                        RelationalOperator.GreaterThan => RelationalOperator.GreaterThanOrEqual,
+                        var op => op
+                    },
+                    Rhs = constant
+                });
+            }
+
+            tracker.Tick(row, equations.Count);
+            foreach (var constraint in ExportOrder.Rows(constraints, c => c.Name, ordering))
+                model.Constraints.Add(constraint);
+
+            foreach (var logical in manager.LogicalConstraints)
+                model.Warnings.Add($"Logical constraint '{logical.Label ?? logical.Type.ToString()}' was not exported");
+
+            if (manager.Objective != null)
+            {
+                model.Name = manager.Objective.Name ?? "";
+                model.ObjectiveSense = manager.Objective.Sense;
+                var precision = NumericEvaluator.PrecisionOfObjective(manager);
+                foreach (var (name, expression) in ExportOrder.Terms(manager.Objective.Coefficients, columnIndex))
+                {
+                    double value = precision.IsExact
+                        ? NumericEvaluator.ToDouble(NumericEvaluator.Evaluate(expression, manager, precision), $"objective coefficient of '{name}'", model.Warnings)
+                        : expression.Evaluate(manager);
+                    if (value != 0)
+                        model.ObjectiveCoefficients[name] = value;
+                }
+                model.ObjectiveConstant = precision.IsExact
+                    ? NumericEvaluator.ToDouble(NumericEvaluator.Evaluate(manager.Objective.Constant, manager, precision), "objective constant", model.Warnings)
+                    : manager.Objective.Constant.Evaluate(manager);
+            }
+
+            return model;
+        }
+
+        private static (Dictionary<string, double> Coefficients, double Constant) EvaluateExact(
+            ModelManager manager, LinearEquation equation, string row, List<string> warnings)
+        {
+            var (coefficients, constant) = NumericEvaluator.Evaluate(equation, manager);
+            return (
+                coefficients.ToDictionary(c => c.Key, c => NumericEvaluator.ToDouble(c.Value, $"coefficient of '{c.Key}' in '{row}'", warnings)),
+                NumericEvaluator.ToDouble(constant, $"right-hand side of '{row}'", warnings));
+        }
+
+        public LinearModel()
+        {
+            Names = new NameTable();
+            Variables = new LinearVariableTable(Names);
+            Constraints = new LinearConstraintTable(Names);
+        }
+
+        /// <summary>
+        /// Interned variable and constraint names shared by the column tables
+        /// </summary>
+        public NameTable Names { get; }
+
+        public LinearVariable? FindVariable(string name) => Variables.Find(name);
+
+        /// <summary>
+        /// Returns the variable, declaring a continuous non-negative one if it does not exist yet
+        /// </summary>
+        public LinearVariable GetOrAddVariable(string name)
+        {
+            var variable = FindVariable(name);
+            if (variable == null)
+            {
+                variable = new LinearVariable { Name = name, LowerBound = 0 };
+                Variables.Add(variable);
+            }
+            return variable;
+        }
+
+        /// <summary>
+        /// Writes the model in editor syntax. Source names that are not valid identifiers are
+        /// rewritten (e.g. "x(1,2)" becomes "x_1_2"); the mapping is listed in a header comment.
+        /// </summary>
+        public string ToModelText()
+        {
+            var identifiers = new Dictionary<string, string>(StringComparer.Ordinal);
+            var used = new HashSet<string>(StringComparer.Ordinal);
+            var sb = new StringBuilder();
+
+            foreach (var variable in Variables)
+                identifiers[variable.Name] = MakeIdentifier(variable.Name, "x", used);
+            var columnIndex = ExportOrder.Index(Variables.Select(v => v.Name).ToList());
+
+            var constraintNames = Constraints.Select(c => MakeIdentifier(string.IsNullOrEmpty(c.Name) ? "c" : c.Name, "c", used)).ToList();
+
+            if (!string.IsNullOrEmpty(Name))
+                sb.AppendLine($"// Model: {Name}");
+            foreach (var warning in Warnings)
+                sb.AppendLine($"// Warning: {warning}");
+
+            var renamed = identifiers.Where(kv => kv.Key != kv.Value).ToList();
+            if (renamed.Count > 0)
+            {
+                sb.AppendLine("// Renamed variables:");
+                foreach (var (source, identifier) in renamed)
+                    sb.AppendLine($"//   {source} -> {identifier}");
+            }
+            if (sb.Length > 0)
+                sb.AppendLine();
+
+            foreach (var variable in Variables)
+                sb.AppendLine(FormatVariable(variable, identifiers[variable.Name]));
+            sb.AppendLine();
+
+            string objective = FormatLinear(ExportOrder.Terms(ObjectiveCoefficients, columnIndex), identifiers, ObjectiveConstant);
+            sb.AppendLine($"{(ObjectiveSense == ObjectiveSense.Minimize ? "minimize" : "maximize")} {objective};");
+            sb.AppendLine();
+
+            for (int i = 0; i < Constraints.Count; i++)
+            {
+                var constraint = Constraints[i];
+                sb.AppendLine($"{constraintNames[i]}: {FormatLinear(ExportOrder.Terms(constraint.Coefficients, columnIndex), identifiers, 0)} {FormatOperator(constraint.Operator)} {FormatNumber(constraint.Rhs)};");
+            }
+
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// The declarations of ToModelText one by one, without the header comments (the output
+        /// of an import regenerated into an existing model, see ModelProvenance.Regenerate)
+        /// </summary>
+        public List<string> ToStatements()
+        {
+            return ModelSource.Parse(ToModelText()).Statements.Select(s => s.Code).ToList();
+        }
+
+        private static string FormatVariable(LinearVariable variable, string identifier)
+        {
+            if (variable.Type == VariableType.Boolean)
+                return $"dvar bool {identifier};";
+
+            string type = variable.Type == VariableType.Integer ? "int" : "float";
+
+            if (variable.LowerBound == null && variable.UpperBound == null)
+                return $"dvar {type} {identifier};";
+            if (variable.LowerBound == 0 && variable.UpperBound == null)
+                return $"dvar {type}+ {identifier};";
+
+            string lower = variable.LowerBound.HasValue ? FormatNumber(variable.LowerBound.Value) : "";
+            string upper = variable.UpperBound.HasValue ? FormatNumber(variable.UpperBound.Value) : "";
+            return $"dvar {type} {identifier} in {lower}..{upper};";
+        }
+
+        private static string FormatLinear(IEnumerable<KeyValuePair<string, double>> coefficients, Dictionary<string, string> identifiers, double constant)
+        {
+            var sb = new StringBuilder();
+
+            foreach (var (name, coefficient) in coefficients)
+            {
+                if (coefficient == 0)
+                    continue;
+
+                string identifier = identifiers.TryGetValue(name, out var id) ? id : name;
+                double magnitude = Math.Abs(coefficient);
+
+                if (sb.Length == 0)
+                    sb.Append(coefficient < 0 ? "-" : "");
+                else
+                    sb.Append(coefficient < 0 ? " - " : " + ");
+
+                sb.Append(magnitude == 1 ? identifier : $"{FormatNumber(magnitude)}*{identifier}");
+            }
+
+            if (constant != 0 || sb.Length == 0)
+            {
+                if (sb.Length == 0)
+                    sb.Append(FormatNumber(constant));
+                else
+                    sb.Append(constant < 0 ? " - " : " + ").Append(FormatNumber(Math.Abs(constant)));
+            }
+
+            return sb.ToString();
+        }
+
+        private static string FormatOperator(RelationalOperator op) => op switch
+        {
+            RelationalOperator.LessThanOrEqual or RelationalOperator.LessThan => "<=",
+            RelationalOperator.GreaterThanOrEqual or RelationalOperator.GreaterThan => ">=",
+            _ => "=="
+        };
+
+        /// <summary>
+        /// Plain decimal notation; the model language has no exponent syntax. All digits of the
+        /// shortest round-trip form are kept, so the text reads back as the same value.
+        /// </summary>
+        internal static string FormatNumber(double value)
+        {
+            return NumberWriter.Positional(value);
+        }
+
+        private static string MakeIdentifier(string name, string prefix, HashSet<string> used)
+        {
+            string identifier = Regex.Replace(name, @"[^A-Za-z0-9_]+", "_").Trim('_');
+            if (identifier.Length == 0 || !char.IsLetter(identifier[0]))
+                identifier = prefix + "_" + identifier;
+            if (reservedWords.Contains(identifier))
+                identifier += "_";
+
+            string unique = identifier;
+            for (int i = 2; !used.Add(unique); i++)
+                unique = $"{identifier}_{i}";
+
+            return unique;
+        }
+    }
+}
@@ -0,0 +1,379 @@
+using System.Collections;
+using Core.Models;
+
+namespace Core.Import
+{
+    /// <summary>
+    /// Variables of a flat model stored column-wise: name id, type and bounds per row, with a
+    /// name-to-row index. Elements are handed out as LinearVariable views that read and write
+    /// the columns, so a million variables cost a few dozen bytes each instead of an object apiece.
+    /// </summary>
+    public class LinearVariableTable : IList<LinearVariable>
+    {
+        private readonly NameTable names;
+        private readonly List<int> nameIds = new List<int>();
+        private readonly List<byte> types = new List<byte>();
+
+        // NaN encodes "unbounded in that direction"
+        private readonly List<double> lowerBounds = new List<double>();
+        private readonly List<double> upperBounds = new List<double>();
+
+        /// <summary>
+        /// Row of the first variable with each name id, -1 if none
+        /// </summary>
+        private readonly List<int> rowByName = new List<int>();
+
+        internal LinearVariableTable(NameTable names)
+        {
+            this.names = names;
+        }
+
+        public int Count => nameIds.Count;
+
+        public bool IsReadOnly => false;
+
+        public LinearVariable this[int index]
+        {
+            get
+            {
+                CheckIndex(index);
+                return new LinearVariable(this, nameIds[index]);
+            }
+            set
+            {
+                CheckIndex(index);
+                int nameId = names.Intern(value.Name);
+                (var type, var lower, var upper) = (value.Type, value.LowerBound, value.UpperBound);
+
+                if (nameIds[index] != nameId)
+                {
+                    nameIds[index] = nameId;
+                    RebuildIndex();
+                }
+                Write(index, type, lower, upper);
+                value.Bind(this, nameId);
+            }
+        }
+
+        /// <summary>
+        /// Row of the variable with the given name, or -1
+        /// </summary>
+        public int IndexOf(string name)
+        {
+            return names.TryGetId(name, out int id) && id < rowByName.Count ? rowByName[id] : -1;
+        }
+
+        public LinearVariable? Find(string name)
+        {
+            return IndexOf(name) >= 0 ? new LinearVariable(this, names.Intern(name)) : null;
+        }
+
+        /// <summary>
+        /// Appends a copy of the variable; the instance passed in becomes a view of the new row
+        /// </summary>
+        public void Add(LinearVariable item) => Insert(Count, item);
+
+        public void Insert(int index, LinearVariable item)
+        {
+            if (index < 0 || index > Count)
+                throw new ArgumentOutOfRangeException(nameof(index));
+
+            int nameId = names.Intern(item.Name);
+            (var type, var lower, var upper) = (item.Type, item.LowerBound, item.UpperBound);
+
+            nameIds.Insert(index, nameId);
+            types.Insert(index, 0);
+            lowerBounds.Insert(index, double.NaN);
+            upperBounds.Insert(index, double.NaN);
+            Write(index, type, lower, upper);
+
+            if (index == Count - 1)
+            {
+                while (rowByName.Count <= nameId)
+                    rowByName.Add(-1);
+                if (rowByName[nameId] < 0)
+                    rowByName[nameId] = index;
+            }
+            else
+            {
+                RebuildIndex();
+            }
+
+            item.Bind(this, nameId);
+        }
+
+        public void RemoveAt(int index)
+        {
+            CheckIndex(index);
+            nameIds.RemoveAt(index);
+            types.RemoveAt(index);
+            lowerBounds.RemoveAt(index);
+            upperBounds.RemoveAt(index);
+            RebuildIndex();
+        }
+
+        /// <summary>
+        /// Removes the first variable with the same name
+        /// </summary>
+        public bool Remove(LinearVariable item)
+        {
+            int index = IndexOf(item.Name);
+            if (index < 0)
+                return false;
+
+            RemoveAt(index);
+            return true;
+        }
+
+        public void Clear()
+        {
+            nameIds.Clear();
+            types.Clear();
+            lowerBounds.Clear();
+            upperBounds.Clear();
+            rowByName.Clear();
+        }
+
+        public int IndexOf(LinearVariable item) => IndexOf(item.Name);
+
+        public bool Contains(LinearVariable item) => IndexOf(item.Name) >= 0;
+
+        public void CopyTo(LinearVariable[] array, int arrayIndex)
+        {
+            for (int i = 0; i < Count; i++)
+                array[arrayIndex + i] = this[i];
+        }
+
+        public IEnumerator<LinearVariable> GetEnumerator()
+        {
+            for (int i = 0; i < Count; i++)
+                yield return new LinearVariable(this, nameIds[i]);
+        }
+
+        IEnumerator IEnumerable.GetEnumerator() => GetEnumerator();
+
+        internal string GetName(int nameId) => names[nameId];
+
+        internal VariableType GetVariableType(int nameId) => (VariableType)types[RowOf(nameId)];
+
+        internal double? GetLowerBound(int nameId) => FromColumn(lowerBounds[RowOf(nameId)]);
+
+        internal double? GetUpperBound(int nameId) => FromColumn(upperBounds[RowOf(nameId)]);
+
+        internal void SetVariableType(int nameId, VariableType type) => types[RowOf(nameId)] = (byte)type;
+
+        internal void SetLowerBound(int nameId, double? value) => lowerBounds[RowOf(nameId)] = value ?? double.NaN;
+
+        internal void SetUpperBound(int nameId, double? value) => upperBounds[RowOf(nameId)] = value ?? double.NaN;
+
+        private int RowOf(int nameId)
+        {
+            int row = nameId < rowByName.Count ? rowByName[nameId] : -1;
+            if (row < 0)
+                throw new InvalidOperationException($"Variable '{names[nameId]}' has been removed from the model");
+            return row;
+        }
+
+        private void Write(int row, VariableType type, double? lower, double? upper)
+        {
+            types[row] = (byte)type;
+            lowerBounds[row] = lower ?? double.NaN;
+            upperBounds[row] = upper ?? double.NaN;
+        }
+
+        private void RebuildIndex()
+        {
+            rowByName.Clear();
+            rowByName.AddRange(Enumerable.Repeat(-1, names.Count));
+
+            for (int row = nameIds.Count - 1; row >= 0; row--)
+                rowByName[nameIds[row]] = row;
+        }
+
+        private void CheckIndex(int index)
+        {
+            if (index < 0 || index >= Count)
+                throw new ArgumentOutOfRangeException(nameof(index));
+        }
+
+        private static double? FromColumn(double value) => double.IsNaN(value) ? null : value;
+    }
+
+    /// <summary>
+    /// Constraints of a flat model stored column-wise: name id, operator and right-hand side per
+    /// row, and each row's terms as parallel arrays of variable name ids and coefficients.
+    /// Elements are handed out as read-only LinearConstraint views.
+    /// </summary>
+    public class LinearConstraintTable : IList<LinearConstraint>
+    {
+        private readonly NameTable names;
+        private readonly List<int> nameIds = new List<int>();
+        private readonly List<byte> operators = new List<byte>();
+        private readonly List<double> rhs = new List<double>();
+        private readonly List<int[]> termVariables = new List<int[]>();
+        private readonly List<double[]> termCoefficients = new List<double[]>();
+
+        /// <summary>
+        /// Incremented whenever rows shift, so views of moved rows can detect it
+        /// </summary>
+        private int generation;
+
+        internal LinearConstraintTable(NameTable names)
+        {
+            this.names = names;
+        }
+
+        public int Count => nameIds.Count;
+
+        public bool IsReadOnly => false;
+
+        public LinearConstraint this[int index]
+        {
+            get
+            {
+                CheckIndex(index);
+                return new LinearConstraint(this, index, generation);
+            }
+            set
+            {
+                CheckIndex(index);
+                var (nameId, op, right, variables, coefficients) = Encode(value);
+                nameIds[index] = nameId;
+                operators[index] = op;
+                rhs[index] = right;
+                termVariables[index] = variables;
+                termCoefficients[index] = coefficients;
+            }
+        }
+
+        /// <summary>
+        /// Appends a copy of the constraint; later changes to its Coefficients dictionary are not seen
+        /// </summary>
+        public void Add(LinearConstraint item) => Insert(Count, item);
+
+        public void Insert(int index, LinearConstraint item)
+        {
+            if (index < 0 || index > Count)
+                throw new ArgumentOutOfRangeException(nameof(index));
+
+            var (nameId, op, right, variables, coefficients) = Encode(item);
+            nameIds.Insert(index, nameId);
+            operators.Insert(index, op);
+            rhs.Insert(index, right);
+            termVariables.Insert(index, variables);
+            termCoefficients.Insert(index, coefficients);
+            if (index < Count - 1)
+                generation++;
+        }
+
+        public void RemoveAt(int index)
+        {
+            CheckIndex(index);
+            nameIds.RemoveAt(index);
+            operators.RemoveAt(index);
+            rhs.RemoveAt(index);
+            termVariables.RemoveAt(index);
+            termCoefficients.RemoveAt(index);
+            generation++;
+        }
+
+        /// <summary>
+        /// Removes the row a view refers to
+        /// </summary>
+        public bool Remove(LinearConstraint item)
+        {
+            int index = IndexOf(item);
+            if (index < 0)
+                return false;
+
+            RemoveAt(index);
+            return true;
+        }
+
+        public void Clear()
+        {
+            nameIds.Clear();
+            operators.Clear();
+            rhs.Clear();
+            termVariables.Clear();
+            termCoefficients.Clear();
+            generation++;
+        }
+
+        public int IndexOf(LinearConstraint item) => item.IsViewOf(this, generation) ? item.Row : -1;
+
+        public bool Contains(LinearConstraint item) => IndexOf(item) >= 0;
+
+        public void CopyTo(LinearConstraint[] array, int arrayIndex)
+        {
+            for (int i = 0; i < Count; i++)
+                array[arrayIndex + i] = this[i];
+        }
+
+        public IEnumerator<LinearConstraint> GetEnumerator()
+        {
+            int current = generation;
+            for (int i = 0; i < Count; i++)
+            {
+                if (generation != current)
+                    throw new InvalidOperationException("Constraints were modified during enumeration");
+                yield return new LinearConstraint(this, i, generation);
+            }
+        }
+
+        IEnumerator IEnumerable.GetEnumerator() => GetEnumerator();
+
+        /// <summary>
+        /// Number of terms of a row, without materializing them
+        /// </summary>
+        public int GetTermCount(int index) => termVariables[index].Length;
+
+        internal string GetName(int row, int viewGeneration) => names[nameIds[Check(row, viewGeneration)]];
+
+        internal RelationalOperator GetOperator(int row, int viewGeneration) => (RelationalOperator)operators[Check(row, viewGeneration)];
+
+        internal double GetRhs(int row, int viewGeneration) => rhs[Check(row, viewGeneration)];
+
+        internal Dictionary<string, double> GetCoefficients(int row, int viewGeneration)
+        {
+            Check(row, viewGeneration);
+            var variables = termVariables[row];
+            var coefficients = termCoefficients[row];
+
+            var result = new Dictionary<string, double>(variables.Length);
+            for (int i = 0; i < variables.Length; i++)
+                result[names[variables[i]]] = coefficients[i];
+            return result;
+        }
+
+        private (int NameId, byte Operator, double Rhs, int[] Variables, double[] Coefficients) Encode(LinearConstraint constraint)
+        {
+            var terms = constraint.Coefficients;
+            var variables = new int[terms.Count];
+            var coefficients = new double[terms.Count];
+
+            int i = 0;
+            foreach (var (name, coefficient) in terms)
+            {
+                variables[i] = names.Intern(name);
+                coefficients[i] = coefficient;
+                i++;
+            }
+
+            return (names.Intern(constraint.Name), (byte)constraint.Operator, constraint.Rhs, variables, coefficients);
+        }
+
+        private int Check(int row, int viewGeneration)
+        {
+            if (viewGeneration != generation || row >= Count)
+                throw new InvalidOperationException("Constraint view is out of date: rows were inserted or removed");
+            return row;
+        }
+
+        private void CheckIndex(int index)
+        {
+            if (index < 0 || index >= Count)
+                throw new ArgumentOutOfRangeException(nameof(index));
+        }
+    }
+}
@@ -0,0 +1,399 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Models;
+
+namespace Core.Import
+{
+    /// <summary>
+    /// Reads CPLEX LP files, the instance format written by Pyomo (model.write("x.lp")) and most
+    /// solver front ends. Pyomo artifacts are undone: the ONE_VAR_CONSTANT helper variable becomes
+    /// a constant, and the c_u_/c_l_/c_e_/r_l_/r_u_ row prefixes are stripped.
+    /// Quadratic terms, SOS and semi-continuous sections are skipped with a warning.
+    /// </summary>
+    public class LpImporter
+    {
+        private const string PyomoConstant = "ONE_VAR_CONSTANT";
+
+        private enum Section { None, Objective, Constraints, Bounds, General, Binary, Unsupported, End }
+
+        private static readonly Regex SectionHeader = new Regex(
+            @"^\s*(?<keyword>minimi[sz]e|minimum|min|maximi[sz]e|maximum|max|subject\s+to|such\s+that|s\.t\.|st\.?|bounds?|generals?|gen|integers?|binary|binaries|bin|semi-continuous|semis?|sos|end)(?=\s|$)(?<rest>.*)$",
+            RegexOptions.IgnoreCase);
+
+        private static readonly Regex Token = new Regex(
+            @"(?<number>(?:\d+\.?\d*|\.\d+)(?:[eE][+\-]?\d+)?)|(?<op><=|>=|=<|=>|<|>|=|\+|-|:|\*|\^|\[|\])|(?<name>[^\s+\-<>=:*^\[\]]+)");
+
+        public LinearModel Import(string text)
+        {
+            bool pyomo = text.Contains("Pyomo", StringComparison.Ordinal);
+            text = Regex.Replace(text, @"\\\*.*?\*\\", "", RegexOptions.Singleline);
+            text = Regex.Replace(text, @"\\[^\n]*", "");
+
+            var model = new LinearModel();
+            var sections = new Dictionary<Section, List<string>>();
+            var boundLines = new List<string>();
+            var section = Section.None;
+
+            foreach (var rawLine in text.Split('\n'))
+            {
+                string line = rawLine.Trim();
+                var header = SectionHeader.Match(line);
+                if (header.Success)
+                {
+                    section = ToSection(header.Groups["keyword"].Value, model);
+                    line = header.Groups["rest"].Value.Trim();
+                }
+
+                if (line.Length == 0 || section == Section.None || section == Section.Unsupported || section == Section.End)
+                    continue;
+
+                if (section == Section.Bounds)
+                {
+                    boundLines.Add(line);
+                    continue;
+                }
+
+                if (!sections.TryGetValue(section, out var tokens))
+                    sections[section] = tokens = new List<string>();
+                tokens.AddRange(Tokenize(line));
+            }
+
+            if (sections.TryGetValue(Section.Objective, out var objectiveTokens))
+                ReadObjective(model, objectiveTokens);
+            if (sections.TryGetValue(Section.Constraints, out var constraintTokens))
+                ReadConstraints(model, constraintTokens, pyomo);
+
+            foreach (var line in boundLines)
+                ReadBound(model, line);
+
+            foreach (var name in sections.GetValueOrDefault(Section.General) ?? new List<string>())
+                model.GetOrAddVariable(name).Type = VariableType.Integer;
+
+            foreach (var name in sections.GetValueOrDefault(Section.Binary) ?? new List<string>())
+            {
+                var variable = model.GetOrAddVariable(name);
+                variable.Type = VariableType.Boolean;
+                variable.LowerBound = 0;
+                variable.UpperBound = 1;
+            }
+
+            RemovePyomoConstant(model);
+            return model;
+        }
+
+        private static Section ToSection(string keyword, LinearModel model)
+        {
+            string k = Regex.Replace(keyword.ToLowerInvariant(), @"\s+", " ");
+
+            if (k.StartsWith("min"))
+            {
+                model.ObjectiveSense = ObjectiveSense.Minimize;
+                return Section.Objective;
+            }
+            if (k.StartsWith("max"))
+            {
+                model.ObjectiveSense = ObjectiveSense.Maximize;
+                return Section.Objective;
+            }
+
+            switch (k)
+            {
+                case "subject to":
+                case "such that":
+                case "s.t.":
+                case "st":
+                case "st.":
+                    return Section.Constraints;
+                case "bound":
+                case "bounds":
+                    return Section.Bounds;
+                case "general":
+                case "generals":
+                case "gen":
+                case "integer":
+                case "integers":
+                    return Section.General;
+                case "binary":
+                case "binaries":
+                case "bin":
+                    return Section.Binary;
+                case "end":
+                    return Section.End;
+                default:
+                    model.Warnings.Add($"Section '{keyword}' is not supported and was skipped");
+                    return Section.Unsupported;
+            }
+        }
+
+        private static List<string> Tokenize(string line)
+        {
+            return Token.Matches(line).Select(m => m.Value).ToList();
+        }
+
+        private static void ReadObjective(LinearModel model, List<string> tokens)
+        {
+            int position = 0;
+            if (tokens.Count > 1 && tokens[1] == ":")
+                position = 2;
+
+            var (coefficients, constant) = ReadTerms(model, tokens, ref position, "objective");
+            foreach (var (name, coefficient) in coefficients)
+                model.ObjectiveCoefficients[name] = coefficient;
+            model.ObjectiveConstant = constant;
+        }
+
+        private static void ReadConstraints(LinearModel model, List<string> tokens, bool pyomo)
+        {
+            int position = 0;
+            int index = 0;
+
+            while (position < tokens.Count)
+            {
+                index++;
+                string name = $"c{index}";
+                if (position + 1 < tokens.Count && tokens[position + 1] == ":")
+                {
+                    name = tokens[position];
+                    position += 2;
+                }
+
+                var (coefficients, constant) = ReadTerms(model, tokens, ref position, name);
+                if (position >= tokens.Count)
+                    throw new InvalidOperationException($"LP constraint '{name}' has no relational operator");
+
+                var op = tokens[position++] switch
+                {
+                    "<=" or "=<" or "<" => RelationalOperator.LessThanOrEqual,
+                    ">=" or "=>" or ">" => RelationalOperator.GreaterThanOrEqual,
+                    "=" => RelationalOperator.Equal,
+                    var other => throw new InvalidOperationException($"LP constraint '{name}': unexpected '{other}'")
+                };
+
+                double rhs = ReadSignedNumber(tokens, ref position, name);
+                model.Constraints.Add(new LinearConstraint
+                {
+                    Name = pyomo ? StripPyomoPrefix(name) : name,
+                    Coefficients = coefficients,
+                    Operator = op,
+                    Rhs = rhs - constant
+                });
+            }
+        }
+
+        /// <summary>
+        /// Reads "[+|-] [number] name" terms and bare constants up to a relational operator or
+        /// the end of the tokens. Quadratic brackets ("[ ... ]") are skipped with a warning.
+        /// </summary>
+        private static (Dictionary<string, double> Coefficients, double Constant) ReadTerms(LinearModel model, List<string> tokens, ref int position, string context)
+        {
+            var coefficients = new Dictionary<string, double>();
+            double constant = 0;
+
+            while (position < tokens.Count && !IsRelational(tokens[position]))
+            {
+                if (tokens[position] == "[")
+                {
+                    int end = tokens.IndexOf("]", position);
+                    position = end < 0 ? tokens.Count : end + 1;
+                    if (position < tokens.Count && tokens[position] == "/")
+                        position += 2;
+                    model.Warnings.Add($"Quadratic terms in '{context}' are not supported and were dropped");
+                    continue;
+                }
+
+                double sign = 1;
+                while (position < tokens.Count && (tokens[position] == "+" || tokens[position] == "-"))
+                {
+                    if (tokens[position] == "-")
+                        sign = -sign;
+                    position++;
+                }
+
+                double coefficient = 1;
+                bool hasNumber = false;
+                if (position < tokens.Count && TryParseNumber(tokens[position], out double number))
+                {
+                    coefficient = number;
+                    hasNumber = true;
+                    position++;
+                    if (position < tokens.Count && tokens[position] == "*")
+                        position++;
+                }
+
+                if (position < tokens.Count && IsName(tokens[position]))
+                {
+                    string variable = tokens[position++];
+                    model.GetOrAddVariable(variable);
+                    coefficients[variable] = coefficients.GetValueOrDefault(variable) + sign * coefficient;
+                }
+                else if (hasNumber)
+                {
+                    constant += sign * coefficient;
+                }
+                else if (position < tokens.Count && !IsRelational(tokens[position]))
+                {
+                    // A token that is neither a term nor an operator would never be consumed
+                    throw new InvalidOperationException($"LP '{context}': unexpected '{tokens[position]}'");
+                }
+            }
+
+            return (coefficients, constant);
+        }
+
+        private static void ReadBound(LinearModel model, string line)
+        {
+            var tokens = Tokenize(line);
+            int nameIndex = tokens.FindIndex(IsNameNotInfinity);
+            if (nameIndex < 0)
+                throw new InvalidOperationException($"Invalid LP bound: {line}");
+
+            var variable = model.GetOrAddVariable(tokens[nameIndex]);
+
+            if (tokens.Count == nameIndex + 2 && tokens[nameIndex + 1].Equals("free", StringComparison.OrdinalIgnoreCase))
+            {
+                variable.LowerBound = null;
+                variable.UpperBound = null;
+                return;
+            }
+
+            // Left side: "value <=" (or ">=") before the name
+            if (nameIndex > 0)
+            {
+                int position = 0;
+                double? value = ReadBoundValue(tokens, ref position, line);
+                ApplyBound(variable, Flip(tokens[position]), value, line);
+            }
+
+            // Right side: "op value" after the name
+            if (nameIndex + 1 < tokens.Count)
+            {
+                int position = nameIndex + 2;
+                ApplyBound(variable, tokens[nameIndex + 1], ReadBoundValue(tokens, ref position, line), line);
+            }
+        }
+
+        private static void ApplyBound(LinearVariable variable, string op, double? value, string line)
+        {
+            switch (op)
+            {
+                case "<=" or "=<" or "<":
+                    variable.UpperBound = value;
+                    break;
+                case ">=" or "=>" or ">":
+                    variable.LowerBound = value;
+                    break;
+                case "=":
+                    variable.LowerBound = variable.UpperBound = value;
+                    break;
+                default:
+                    throw new InvalidOperationException($"Invalid LP bound: {line}");
+            }
+        }
+
+        /// <summary>
+        /// Signed number or infinity (returned as null)
+        /// </summary>
+        private static double? ReadBoundValue(List<string> tokens, ref int position, string line)
+        {
+            double sign = 1;
+            while (position < tokens.Count && (tokens[position] == "+" || tokens[position] == "-"))
+            {
+                if (tokens[position] == "-")
+                    sign = -sign;
+                position++;
+            }
+
+            if (position >= tokens.Count)
+                throw new InvalidOperationException($"Invalid LP bound: {line}");
+
+            string token = tokens[position++];
+            if (IsInfinity(token))
+                return null;
+            if (TryParseNumber(token, out double number))
+                return sign * number;
+
+            throw new InvalidOperationException($"Invalid LP bound: {line}");
+        }
+
+        private static double ReadSignedNumber(List<string> tokens, ref int position, string context)
+        {
+            double sign = 1;
+            while (position < tokens.Count && (tokens[position] == "+" || tokens[position] == "-"))
+            {
+                if (tokens[position] == "-")
+                    sign = -sign;
+                position++;
+            }
+
+            if (position < tokens.Count && TryParseNumber(tokens[position], out double number))
+            {
+                position++;
+                return sign * number;
+            }
+
+            throw new InvalidOperationException($"LP constraint '{context}' has no numeric right-hand side");
+        }
+
+        /// <summary>
+        /// Pyomo writes objective constants as a coefficient on ONE_VAR_CONSTANT (fixed to 1 by an
+        /// extra equality row); fold it back into constants
+        /// </summary>
+        private static void RemovePyomoConstant(LinearModel model)
+        {
+            var helper = model.FindVariable(PyomoConstant);
+            if (helper == null)
+                return;
+
+            model.Variables.Remove(helper);
+            if (model.ObjectiveCoefficients.Remove(PyomoConstant, out double objectiveConstant))
+                model.ObjectiveConstant += objectiveConstant;
+
+            for (int i = model.Constraints.Count - 1; i >= 0; i--)
+            {
+                var constraint = model.Constraints[i];
+                if (!constraint.Coefficients.Remove(PyomoConstant, out double coefficient))
+                    continue;
+
+                if (constraint.Coefficients.Count == 0)
+                {
+                    model.Constraints.RemoveAt(i);
+                    continue;
+                }
+
+                model.Constraints[i] = new LinearConstraint
+                {
+                    Name = constraint.Name,
+                    Coefficients = constraint.Coefficients,
+                    Operator = constraint.Operator,
+                    Rhs = constraint.Rhs - coefficient
+                };
+            }
+        }
+
+        private static string StripPyomoPrefix(string name)
+        {
+            var match = Regex.Match(name, @"^(?:c_[elu]_|r_[lu]_)(.+?)_?$");
+            return match.Success ? match.Groups[1].Value : name;
+        }
+
+        private static string Flip(string op) => op switch
+        {
+            "<=" or "=<" or "<" => ">=",
+            ">=" or "=>" or ">" => "<=",
+            _ => op
+        };
+
+        private static bool IsRelational(string token) => token is "<=" or ">=" or "=<" or "=>" or "<" or ">" or "=";
+
+        private static bool IsName(string token) => token.Length > 0 && !TryParseNumber(token, out _) && Token.Match(token).Groups["name"].Success;
+
+        private static bool IsNameNotInfinity(string token) => IsName(token) && !IsInfinity(token);
+
+        private static bool IsInfinity(string token) => token.Equals("inf", StringComparison.OrdinalIgnoreCase) || token.Equals("infinity", StringComparison.OrdinalIgnoreCase);
+
+        private static bool TryParseNumber(string token, out double value)
+        {
+            return double.TryParse(token, NumberStyles.Float, CultureInfo.InvariantCulture, out value) && !IsInfinity(token);
+        }
+    }
+}
@@ -0,0 +1,215 @@
+using System.Text.Json;
+using Core.Models;
+
+namespace Core.Import
+{
+    /// <summary>
+    /// Reads MathOptFormat (MOF.json, as written by JuMP/MathOptInterface) into a LinearModel.
+    /// Supports scalar affine objectives and constraints, variable bounds, Integer and ZeroOne;
+    /// other functions and sets are skipped with a warning.
+    /// </summary>
+    public class MofImporter
+    {
+        public LinearModel Import(string json)
+        {
+            JsonDocument document;
+            try
+            {
+                document = JsonDocument.Parse(json);
+            }
+            catch (JsonException ex)
+            {
+                throw new InvalidOperationException($"Invalid MOF.json: {ex.Message}", ex);
+            }
+
+            using (document)
+            {
+                var root = document.RootElement;
+                if (root.ValueKind != JsonValueKind.Object || !root.TryGetProperty("variables", out var variables))
+                    throw new InvalidOperationException("Invalid MOF.json: missing 'variables'");
+
+                var model = new LinearModel { Name = GetString(root, "name") ?? "" };
+
+                foreach (var variable in variables.EnumerateArray())
+                {
+                    string name = GetString(variable, "name") ?? throw new InvalidOperationException("Invalid MOF.json: variable without name");
+                    model.Variables.Add(new LinearVariable { Name = name });
+                }
+
+                if (root.TryGetProperty("objective", out var objective))
+                    ReadObjective(model, objective);
+
+                if (root.TryGetProperty("constraints", out var constraints))
+                {
+                    int index = 0;
+                    foreach (var constraint in constraints.EnumerateArray())
+                        ReadConstraint(model, constraint, ++index);
+                }
+
+                return model;
+            }
+        }
+
+        private static void ReadObjective(LinearModel model, JsonElement objective)
+        {
+            string sense = GetString(objective, "sense") ?? "min";
+            model.ObjectiveSense = sense == "max" ? ObjectiveSense.Maximize : ObjectiveSense.Minimize;
+
+            if (sense == "feasibility" || !objective.TryGetProperty("function", out var function))
+                return;
+
+            if (!TryReadAffine(model, function, out var coefficients, out double constant))
+                model.Warnings.Add($"Objective function of type '{GetString(function, "type")}' is not supported; only its affine part was imported");
+
+            foreach (var (name, coefficient) in coefficients)
+                model.ObjectiveCoefficients[name] = coefficient;
+            model.ObjectiveConstant = constant;
+        }
+
+        private static void ReadConstraint(LinearModel model, JsonElement constraint, int index)
+        {
+            string name = GetString(constraint, "name") ?? $"c{index}";
+            var function = GetProperty(constraint, "function");
+            var set = GetProperty(constraint, "set");
+            string functionType = GetString(function, "type") ?? "";
+            string setType = GetString(set, "type") ?? "";
+
+            if (functionType == "Variable")
+            {
+                var variable = model.FindVariable(GetString(function, "name") ?? "")
+                    ?? throw new InvalidOperationException($"Constraint '{name}' references an unknown variable");
+                ApplyVariableSet(model, variable, set, setType, name);
+                return;
+            }
+
+            if (!TryReadAffine(model, function, out var coefficients, out double constant) || functionType != "ScalarAffineFunction")
+            {
+                model.Warnings.Add($"Constraint '{name}': function type '{functionType}' is not supported");
+                return;
+            }
+
+            switch (setType)
+            {
+                case "LessThan":
+                    Add(model, name, coefficients, RelationalOperator.LessThanOrEqual, GetNumber(set, "upper") - constant);
+                    break;
+                case "GreaterThan":
+                    Add(model, name, coefficients, RelationalOperator.GreaterThanOrEqual, GetNumber(set, "lower") - constant);
+                    break;
+                case "EqualTo":
+                    Add(model, name, coefficients, RelationalOperator.Equal, GetNumber(set, "value") - constant);
+                    break;
+                case "Interval":
+                    double lower = GetNumber(set, "lower") - constant;
+                    double upper = GetNumber(set, "upper") - constant;
+                    if (lower == upper)
+                    {
+                        Add(model, name, coefficients, RelationalOperator.Equal, lower);
+                    }
+                    else
+                    {
+                        Add(model, name + "_lower", coefficients, RelationalOperator.GreaterThanOrEqual, lower);
+                        Add(model, name + "_upper", coefficients, RelationalOperator.LessThanOrEqual, upper);
+                    }
+                    break;
+                default:
+                    model.Warnings.Add($"Constraint '{name}': set type '{setType}' is not supported");
+                    break;
+            }
+        }
+
+        private static void ApplyVariableSet(LinearModel model, LinearVariable variable, JsonElement set, string setType, string name)
+        {
+            switch (setType)
+            {
+                case "GreaterThan":
+                    variable.LowerBound = GetNumber(set, "lower");
+                    break;
+                case "LessThan":
+                    variable.UpperBound = GetNumber(set, "upper");
+                    break;
+                case "EqualTo":
+                    variable.LowerBound = variable.UpperBound = GetNumber(set, "value");
+                    break;
+                case "Interval":
+                    variable.LowerBound = GetNumber(set, "lower");
+                    variable.UpperBound = GetNumber(set, "upper");
+                    break;
+                case "Integer":
+                    variable.Type = VariableType.Integer;
+                    break;
+                case "ZeroOne":
+                    variable.Type = VariableType.Boolean;
+                    break;
+                default:
+                    model.Warnings.Add($"Constraint '{name}': variable set type '{setType}' is not supported");
+                    break;
+            }
+        }
+
+        /// <summary>
+        /// Reads a Variable or ScalarAffineFunction; for a ScalarQuadraticFunction the affine part is
+        /// returned and false signals that terms were dropped
+        /// </summary>
+        private static bool TryReadAffine(LinearModel model, JsonElement function, out Dictionary<string, double> coefficients, out double constant)
+        {
+            coefficients = new Dictionary<string, double>();
+            constant = 0;
+
+            switch (GetString(function, "type"))
+            {
+                case "Variable":
+                    coefficients[RequireVariable(model, GetString(function, "name"))] = 1;
+                    return true;
+
+                case "ScalarAffineFunction":
+                case "ScalarQuadraticFunction":
+                    var terms = function.TryGetProperty("affine_terms", out var affineTerms) ? affineTerms : GetProperty(function, "terms");
+                    foreach (var term in terms.EnumerateArray())
+                    {
+                        string variable = RequireVariable(model, GetString(term, "variable"));
+                        coefficients[variable] = coefficients.GetValueOrDefault(variable) + GetNumber(term, "coefficient");
+                    }
+                    constant = function.TryGetProperty("constant", out _) ? GetNumber(function, "constant") : 0;
+                    return GetString(function, "type") == "ScalarAffineFunction";
+
+                default:
+                    return false;
+            }
+        }
+
+        private static void Add(LinearModel model, string name, Dictionary<string, double> coefficients, RelationalOperator op, double rhs)
+        {
+            model.Constraints.Add(new LinearConstraint { Name = name, Coefficients = new Dictionary<string, double>(coefficients), Operator = op, Rhs = rhs });
+        }
+
+        private static string RequireVariable(LinearModel model, string? name)
+        {
+            if (name == null || model.FindVariable(name) == null)
+                throw new InvalidOperationException($"Invalid MOF.json: unknown variable '{name}'");
+            return name;
+        }
+
+        private static string? GetString(JsonElement element, string property)
+        {
+            return element.ValueKind == JsonValueKind.Object && element.TryGetProperty(property, out var value) && value.ValueKind == JsonValueKind.String
+                ? value.GetString()
+                : null;
+        }
+
+        private static JsonElement GetProperty(JsonElement element, string property)
+        {
+            if (element.ValueKind != JsonValueKind.Object || !element.TryGetProperty(property, out var value))
+                throw new InvalidOperationException($"Invalid MOF.json: missing '{property}'");
+            return value;
+        }
+
+        private static double GetNumber(JsonElement element, string property)
+        {
+            var value = GetProperty(element, property);
+            if (value.ValueKind != JsonValueKind.Number || !value.TryGetDouble(out double number))
+                throw new InvalidOperationException($"Invalid MOF.json: '{property}' is not a number");
+            return number;
+        }
+    }
+}
@@ -1,6 +1,11 @@
 ﻿using System.Text.RegularExpressions;
+using Core.Analysis;
 using Core.Models;
 using Core.Parsing;
+using Core.Services;
+using Core.Solving;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
 
 namespace Core
 {
@@ -19,6 +24,101 @@ namespace Core
 
         public Dictionary<string, TupleParameter> TupleParameters { get; } = new Dictionary<string, TupleParameter>();
 
+        /// <summary>
+        /// Arithmetic for coefficients of entities without their own precision (float64 by default)
+        /// </summary>
+        public NumericPrecision NumericPrecision { get; set; } = NumericPrecision.Float64;
+
+        /// <summary>
+        /// Per-entity precision from @numeric annotations, by entity key ("constraint:balance", "objective")
+        /// </summary>
+        public Dictionary<string, NumericPrecision> EntityPrecision { get; } = new Dictionary<string, NumericPrecision>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Currencies of money parameters, exchange rates and the reporting currency from @money and @rate annotations
+        /// </summary>
+        public CurrencyTable Currencies { get; private set; } = new CurrencyTable();
+
+        /// <summary>
+        /// KPIs from @kpi annotations, evaluated after each solve
+        /// </summary>
+        public ReportDefinition Report { get; private set; } = new ReportDefinition();
+
+        /// <summary>
+        /// Custom tables from @table comments, kept with the model for rules and reports
+        /// </summary>
+        public ModelTables Tables { get; private set; } = new ModelTables();
+
+        /// <summary>
+        /// Tolerance for checks on model data and solutions (implied bounds, integrality, slacks)
+        /// </summary>
+        public NumericTolerance Tolerance { get; set; } = NumericTolerance.Default;
+
+        /// <summary>
+        /// Equations added between memory estimates (estimating walks the whole model)
+        /// </summary>
+        private const int MemoryCheckInterval = 4096;
+
+        public const int DefaultExpansionLimit = 1_000_000;
+
+        /// <summary>
+        /// Most constraints a single forall, or terms a single expression's sums, may expand
+        /// into; larger expansions are reported as errors instead of being built
+        /// </summary>
+        public int ExpansionLimit { get; set; } = DefaultExpansionLimit;
+
+        /// <summary>
+        /// Latest solution of the model (set after a successful solve), used by Explain
+        /// </summary>
+        public SolveResult? Solution { get; set; }
+
+        /// <summary>
+        /// Size guardrails checked by the parsers and the Add methods (see ModelLimits.Shared for servers)
+        /// </summary>
+        public ModelLimits Limits { get; set; } = ModelLimits.Default;
+
+        /// <summary>
+        /// Optional audit log; when set, the mutating methods of this class (AddParameter, AddEquation,
+        /// SetObjective, ExpandForallStatements, Clear, ...) record to it. Changes made directly to
+        /// the public collections (Parameters, Equations, ...) bypass these methods and are not recorded.
+        /// </summary>
+        public AuditLog? AuditLog { get; set; }
+
+        /// <summary>
+        /// Logger for parse and expansion diagnostics; a no-op logger by default
+        /// </summary>
+        public ILogger Logger { get; set; } = NullLogger.Instance;
+
+        /// <summary>
+        /// The model texts parsed into this manager, for analyses that need the source
+        /// (the parser folds products of variables into coefficients)
+        /// </summary>
+        public List<string> SourceTexts { get; } = new List<string>();
+
+        /// <summary>
+        /// Docstrings of the declared entities, by entity key ("parameter:cost", "constraint:cap", "objective")
+        /// </summary>
+        public Dictionary<string, string> Documentation { get; } = new Dictionary<string, string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Units of measure from "// @unit" annotations, by entity key ("parameter:capacity", "variable:flow")
+        /// </summary>
+        public Dictionary<string, string> Units { get; } = new Dictionary<string, string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Suggestions for unresolved names found while parsing the current statement; the
+        /// parsers move them to the ParseSessionResult when the statement fails
+        /// </summary>
+        internal List<SymbolSuggestion> PendingSuggestions { get; } = new List<SymbolSuggestion>();
+
+        private int auditSuppression;
+
+        private void Audit(AuditOperation operation, string entity, string? details = null)
+        {
+            if (AuditLog != null && auditSuppression == 0)
+                AuditLog.Record(operation, entity, details);
+        }
+
         
         /// <summary>
         /// Gets a decision expression by name
@@ -34,7 +134,9 @@ namespace Core
 
         public void AddForallStatement(ForallStatement forall)
         {
+            Limits.CheckEntityCount(this);
             ForallStatements.Add(forall);
+            Audit(AuditOperation.Add, $"forall:{forall.Label ?? ForallStatements.Count.ToString()}");
         }
 
         /// <summary>
@@ -48,24 +150,260 @@ namespace Core
 
             // Track what we've already expanded
             var alreadyExpanded = new HashSet<ForallStatement>();
+            int equationsBefore = equations.Count;
 
-            foreach (var forall in ForallStatements)
+            auditSuppression++;
+            try
             {
-                if (alreadyExpanded.Contains(forall))
-                    continue;
-
-                var expandedConstraints = forall.Expand(this);
-                foreach (var constraint in expandedConstraints)
+                foreach (var forall in ForallStatements)
                 {
-                    AddEquation(constraint);
-                }
+                    if (alreadyExpanded.Contains(forall))
+                        continue;
 
-                alreadyExpanded.Add(forall);
+                    AddConstraintRule(forall);
+                    alreadyExpanded.Add(forall);
+                }
+            }
+            finally
+            {
+                auditSuppression--;
             }
 
+            Audit(AuditOperation.Expand, "forall", $"{equations.Count - equationsBefore} constraints generated");
+
             // Clear forall statements after expansion to avoid re-expansion
             ForallStatements.Clear();
         }
+
+        /// <summary>
+        /// Keeps a forall statement as a constraint rule and generates its constraints, or leaves
+        /// them pending when DeferRuleExpansion is set. Returns the number of constraints added.
+        /// </summary>
+        public int AddConstraintRule(ForallStatement rule)
+        {
+            if (DeferRuleExpansion)
+            {
+                ConstraintRules.Add(rule);
+                pendingRules.Add(rule);
+                Audit(AuditOperation.Add, $"rule:{RuleName(rule)}");
+                return 0;
+            }
+
+            var generated = GenerateRule(rule);
+            ConstraintRules.Add(rule);
+            return generated;
+        }
+
+        /// <summary>
+        /// Label of a rule, or "forall{n}" by its position for unlabeled rules
+        /// </summary>
+        public string RuleName(ForallStatement rule)
+        {
+            return rule.Label ?? $"forall{ConstraintRules.IndexOf(rule) + 1}";
+        }
+
+        public ForallStatement? FindRule(string name)
+        {
+            return ConstraintRules.FirstOrDefault(r => RuleName(r) == name);
+        }
+
+        /// <summary>
+        /// Generates the instances of a rule whose iterators have the bound values ("t" = "3")
+        /// for inspection, without adding them to the model or expanding the rest of the family
+        /// </summary>
+        public List<LinearEquation> InstantiateRule(string name, IReadOnlyDictionary<string, string> bindings)
+        {
+            var rule = FindRule(name) ?? ForallStatements.FirstOrDefault(r => r.Label == name)
+                ?? throw new InvalidOperationException($"Unknown constraint rule '{name}'");
+
+            foreach (var iterator in bindings.Keys.Where(k => rule.Iterators.All(i => i.VariableName != k)))
+                throw new InvalidOperationException(
+                    $"Rule '{name}' has no iterator '{iterator}'; its iterators are {string.Join(", ", rule.Iterators.Select(i => i.VariableName))}");
+
+            var instances = rule.Expand(this, bindings);
+            foreach (var instance in instances)
+                instance.Rule = rule;
+            return instances;
+        }
+
+        /// <summary>
+        /// Every row of the model: the stored constraints followed by the rows of the pending rules,
+        /// generated into a temporary ConstraintTable that is not kept by the model. Rules are
+        /// encoded one at a time, so only the largest family exists as objects at once. Throws
+        /// InvalidOperationException naming the rule when a rule cannot be expanded.
+        /// </summary>
+        public IReadOnlyList<LinearEquation> MaterializeRows()
+        {
+            if (pendingRules.Count == 0)
+                return equations;
+
+            var rows = new ConstraintTable();
+            rows.AddRange(equations);
+            foreach (var rule in pendingRules)
+            {
+                foreach (var row in ExpandRule(rule))
+                {
+                    row.Rule = rule;
+                    rows.Add(row);
+                }
+            }
+            return rows;
+        }
+
+        /// <summary>
+        /// Generates the rows of the pending rules into Equations for a solve that edits rows in
+        /// place; disposing the returned scope drops them and leaves those rules pending again
+        /// </summary>
+        public IDisposable MaterializeInPlace()
+        {
+            var scope = new MaterializedScope(this, pendingRules.ToList());
+            try
+            {
+                ExpandPendingRules();
+            }
+            catch
+            {
+                scope.Dispose();
+                throw;
+            }
+            return scope;
+        }
+
+        /// <summary>
+        /// Generates and keeps the constraints of the pending rules, as if they had been expanded at parse time
+        /// </summary>
+        public void ExpandPendingRules()
+        {
+            while (pendingRules.Count > 0)
+            {
+                var rule = pendingRules[0];
+                AddGenerated(rule, ExpandRule(rule));
+                pendingRules.RemoveAt(0);
+            }
+        }
+
+        private List<LinearEquation> ExpandRule(ForallStatement rule)
+        {
+            try
+            {
+                return rule.Expand(this);
+            }
+            catch (Exception ex) when (ex is not ModelLimitException)
+            {
+                throw new InvalidOperationException($"Error expanding rule '{RuleName(rule)}': {ex.Message}", ex);
+            }
+        }
+
+        private sealed class MaterializedScope : IDisposable
+        {
+            private readonly ModelManager manager;
+            private List<ForallStatement>? rules;
+
+            public MaterializedScope(ModelManager manager, List<ForallStatement> rules)
+            {
+                this.manager = manager;
+                this.rules = rules;
+            }
+
+            public void Dispose()
+            {
+                if (rules == null)
+                    return;
+
+                foreach (var rule in rules.Where(r => manager.ConstraintRules.Contains(r) && !manager.pendingRules.Contains(r)))
+                {
+                    manager.DropGenerated(rule);
+                    manager.pendingRules.Add(rule);
+                }
+                manager.pendingRules.Sort((a, b) => manager.ConstraintRules.IndexOf(a).CompareTo(manager.ConstraintRules.IndexOf(b)));
+                rules = null;
+            }
+        }
+
+        /// <summary>
+        /// Replaces a rule by an edited one: the constraints of the old rule are dropped and the
+        /// new rule generates its own, now or when the rows are materialized
+        /// </summary>
+        public void ReplaceRule(ForallStatement rule, ForallStatement replacement)
+        {
+            int index = ConstraintRules.IndexOf(rule);
+            if (index < 0)
+                throw new InvalidOperationException($"'{rule.Label ?? "forall"}' is not a constraint rule of the model");
+
+            string name = RuleName(rule);
+            bool pending = DeferRuleExpansion || pendingRules.Contains(rule);
+
+            // Expanded before anything is dropped, so a rule that fails leaves the model as it was
+            var generated = pending ? null : replacement.Expand(this);
+            DropGenerated(rule);
+            pendingRules.Remove(rule);
+
+            if (generated == null)
+                pendingRules.Add(replacement);
+            else
+                AddGenerated(replacement, generated);
+
+            ConstraintRules[index] = replacement;
+            Audit(AuditOperation.Update, $"rule:{name}");
+        }
+
+        public bool RemoveRule(ForallStatement rule)
+        {
+            string name = RuleName(rule);
+            if (!ConstraintRules.Remove(rule))
+                return false;
+
+            DropGenerated(rule);
+            pendingRules.Remove(rule);
+            Audit(AuditOperation.Remove, $"rule:{name}");
+            return true;
+        }
+
+        /// <summary>
+        /// Drops the generated constraints of every rule and makes the rules pending again, so a
+        /// model loaded with eager expansion holds only its rules. Returns the number of constraints dropped.
+        /// </summary>
+        public int CollapseRules()
+        {
+            int before = equations.Count;
+            foreach (var rule in ConstraintRules.Where(r => !pendingRules.Contains(r)))
+            {
+                DropGenerated(rule);
+                pendingRules.Add(rule);
+            }
+            return before - equations.Count;
+        }
+
+        private int GenerateRule(ForallStatement rule)
+        {
+            var generated = rule.Expand(this);
+            AddGenerated(rule, generated);
+            return generated.Count;
+        }
+
+        private void AddGenerated(ForallStatement rule, List<LinearEquation> generated)
+        {
+            auditSuppression++;
+            try
+            {
+                foreach (var constraint in generated)
+                {
+                    constraint.Rule = rule;
+                    AddEquation(constraint);
+                }
+            }
+            finally
+            {
+                auditSuppression--;
+            }
+        }
+
+        private void DropGenerated(ForallStatement rule)
+        {
+            equations.RemoveAll(e => e.Rule == rule);
+            foreach (var label in LabeledEquations.Where(kvp => kvp.Value.Rule == rule).Select(kvp => kvp.Key).ToList())
+                LabeledEquations.Remove(label);
+        }
         // Add to existing ModelManager class
 
         public Dictionary<string, List<int>> Sets { get; } = new Dictionary<string, List<int>>();
@@ -79,7 +417,33 @@ namespace Core
         {
             Sets[name] = Enumerable.Range(start, end - start + 1).ToList();
         }
-        public List<LinearEquation> Equations { get; } = new List<LinearEquation>();
+        private readonly List<LinearEquation> equations = new List<LinearEquation>();
+        private readonly List<ForallStatement> pendingRules = new List<ForallStatement>();
+
+        /// <summary>
+        /// Concrete constraints held by the model: the rows written directly and those of expanded
+        /// rules. Rows of pending rules are not included; exporters and solvers read MaterializeRows().
+        /// </summary>
+        public List<LinearEquation> Equations => equations;
+
+        /// <summary>
+        /// Generating rules of the model (forall statements), kept after their expansion so they
+        /// can be edited as one entity: "forall(t in T) storage[t] = storage[t-1] + inflow[t] - release[t]"
+        /// stands for all its rows.
+        /// </summary>
+        public List<ForallStatement> ConstraintRules { get; } = new List<ForallStatement>();
+
+        /// <summary>
+        /// Rules whose constraints have not been generated yet
+        /// </summary>
+        public IReadOnlyList<ForallStatement> PendingRules => pendingRules;
+
+        /// <summary>
+        /// When set, constraint rules are stored unexpanded and their rows are only generated by
+        /// MaterializeRows() during export and solve; by default rules are expanded into Equations
+        /// at parse time
+        /// </summary>
+        public bool DeferRuleExpansion { get; set; }
 
         public Objective? Objective { get; set; }
         public MultiObjective? MultiObjective { get; set; }
@@ -108,12 +472,14 @@ namespace Core
             {
                 // Update existing parameter
                 param.Value = value;
+                Audit(AuditOperation.Update, $"parameter:{name}");
             }
             else
             {
                 // Create new scalar parameter
                 var newParam = new Parameter(name, ParameterType.Float, value);
                 Parameters[name] = newParam;
+                Audit(AuditOperation.Add, $"parameter:{name}");
             }
         }
 
@@ -126,17 +492,20 @@ namespace Core
             {
                 // Update existing parameter
                 param.Value = value;
+                Audit(AuditOperation.Update, $"parameter:{name}");
             }
             else
             {
                 // Create new parameter
                 var newParam = new Parameter(name, type, value);
                 Parameters[name] = newParam;
+                Audit(AuditOperation.Add, $"parameter:{name}");
             }
         }
 
         public void AddParameter(Parameter parameter)
         {
+            Limits.CheckEntityCount(this);
             if (Parameters.ContainsKey(parameter.Name))
             {
                 throw new InvalidOperationException($"Parameter '{parameter.Name}' is already defined");
@@ -155,6 +524,7 @@ namespace Core
             }
 
             Parameters[parameter.Name] = parameter;
+            Audit(AuditOperation.Add, $"parameter:{parameter.Name}");
         }
 
         /// <summary>
@@ -174,52 +544,74 @@ namespace Core
 
         public void AddIndexSet(IndexSet indexSet)
         {
+            Limits.CheckEntityCount(this);
             IndexSets[indexSet.Name] = indexSet;
+            Audit(AuditOperation.Add, $"set:{indexSet.Name}");
         }
 
         public void AddIndexedVariable(IndexedVariable variable)
         {
+            Limits.CheckEntityCount(this);
             IndexedVariables[variable.BaseName] = variable;
+            Audit(AuditOperation.Add, $"variable:{variable.BaseName}");
         }
 
         public void AddTupleParameter(TupleParameter param)
         {
+            Limits.CheckEntityCount(this);
             TupleParameters[param.Name] = param;
+            Audit(AuditOperation.Add, $"parameter:{param.Name}");
         }
 
         public void AddIndexedEquationTemplate(IndexedEquation equation)
         {
+            Limits.CheckEntityCount(this);
             IndexedEquationTemplates[equation.BaseName] = equation;
+            Audit(AuditOperation.Add, $"template:{equation.BaseName}");
         }
 
         public void AddEquation(LinearEquation equation)
         {
-            Equations.Add(equation);
+            Limits.CheckEntityCount(this);
+            foreach (var (variable, coefficient) in equation.Coefficients)
+                Limits.CheckDepth(coefficient, $"Coefficient of '{variable}' in {equation.Label ?? "constraint"}");
+            Limits.CheckDepth(equation.Constant, $"Constant of {equation.Label ?? "constraint"}");
+
+            equations.Add(equation);
+            if (equations.Count % MemoryCheckInterval == 0)
+                Limits.CheckMemory(this);
             
             if (!string.IsNullOrEmpty(equation.Label))
             {
                 LabeledEquations[equation.Label] = equation;
             }
+
+            Audit(AuditOperation.Add, $"constraint:{equation.Label ?? equation.GetDescription()}");
         }
 
         public void SetObjective(Objective objective)
         {
+            Audit(Objective == null ? AuditOperation.Add : AuditOperation.Update, "objective");
             Objective = objective;
         }
 
         public void AddDecisionExpression(DecisionExpression dexpr)
         {
+            Limits.CheckEntityCount(this);
             if (DecisionExpressions.ContainsKey(dexpr.Name))
             {
                 throw new InvalidOperationException($"Decision expression '{dexpr.Name}' is already defined");
             }
             
             DecisionExpressions[dexpr.Name] = dexpr;
+            Audit(AuditOperation.Add, $"dexpr:{dexpr.Name}");
         }
 
         public void AddAssertion(AssertStatement assertion)
         {
+            Limits.CheckEntityCount(this);
             Assertions.Add(assertion);
+            Audit(AuditOperation.Add, "assertion");
         }
 
         public void ValidateAssertions()
@@ -254,8 +646,10 @@ namespace Core
             IndexSets.Clear();
             TupleSets.Clear();
             IndexedVariables.Clear();
-            Equations.Clear();
+            equations.Clear();
             LabeledEquations.Clear();
+            ConstraintRules.Clear();
+            pendingRules.Clear();
             IndexedEquationTemplates.Clear();
             Objective = null; 
             DecisionExpressions.Clear();
@@ -265,6 +659,89 @@ namespace Core
             TupleSets.Clear();
             TupleSchemas.Clear();
             TupleSets.Clear();
+            PendingSuggestions.Clear();
+            NumericPrecision = NumericPrecision.Float64;
+            EntityPrecision.Clear();
+            Currencies = new CurrencyTable();
+            Report = new ReportDefinition();
+            Tables = new ModelTables();
+            Solution = null;
+            SourceTexts.Clear();
+            Documentation.Clear();
+            Units.Clear();
+            Audit(AuditOperation.Clear, "model");
+        }
+
+        /// <summary>
+        /// Copy of the model with its own collections, for a solve that adds or removes rows,
+        /// rules or declarations (e.g. cuts) without changing this model. The entities in them
+        /// (parameters, variables, constraints) are shared, so edit those on the original only.
+        /// The copy has no audit log and no solution.
+        /// </summary>
+        public ModelManager Clone()
+        {
+            var clone = new ModelManager
+            {
+                NumericPrecision = NumericPrecision,
+                Tolerance = Tolerance,
+                ExpansionLimit = ExpansionLimit,
+                Limits = Limits,
+                Logger = Logger,
+                DeferRuleExpansion = DeferRuleExpansion,
+                Objective = Objective,
+                MultiObjective = MultiObjective,
+                Currencies = Currencies,
+                Report = Report,
+                Tables = Tables
+            };
+
+            CopyInto(Parameters, clone.Parameters);
+            CopyInto(IndexSets, clone.IndexSets);
+            CopyInto(IndexedVariables, clone.IndexedVariables);
+            CopyInto(IndexedEquationTemplates, clone.IndexedEquationTemplates);
+            CopyInto(LabeledEquations, clone.LabeledEquations);
+            CopyInto(DecisionExpressions, clone.DecisionExpressions);
+            CopyInto(TupleParameters, clone.TupleParameters);
+            CopyInto(EntityPrecision, clone.EntityPrecision);
+            CopyInto(Documentation, clone.Documentation);
+            CopyInto(Units, clone.Units);
+            CopyInto(Sets, clone.Sets);
+            CopyInto(TupleSchemas, clone.TupleSchemas);
+            CopyInto(TupleSets, clone.TupleSets);
+            CopyInto(PrimitiveSets, clone.PrimitiveSets);
+            CopyInto(Ranges, clone.Ranges);
+            CopyInto(ComputedSets, clone.ComputedSets);
+
+            clone.SourceTexts.AddRange(SourceTexts);
+            clone.ForallStatements.AddRange(ForallStatements);
+            clone.LogicalConstraints.AddRange(LogicalConstraints);
+            clone.ConstraintRules.AddRange(ConstraintRules);
+            clone.Assertions.AddRange(Assertions);
+            clone.equations.AddRange(equations);
+            clone.pendingRules.AddRange(pendingRules);
+            return clone;
+        }
+
+        private static void CopyInto<TKey, TValue>(Dictionary<TKey, TValue> source, Dictionary<TKey, TValue> target) where TKey : notnull
+        {
+            foreach (var (key, value) in source)
+                target[key] = value;
+        }
+
+        /// <summary>
+        /// Problem class and convexity of the model (see ProblemClassifier), with the given solvers that can handle it
+        /// </summary>
+        public ModelClassification Classify(IEnumerable<ISolverDriver>? solvers = null)
+        {
+            return ProblemClassifier.Classify(this, solvers);
+        }
+
+        /// <summary>
+        /// Explains a constraint (see ConstraintExplainer), evaluated at the current solution if there is one
+        /// </summary>
+        public ConstraintExplanation Explain(string constraintId)
+        {
+            return new ConstraintExplainer(this).Explain(constraintId, Solution?.VariableValues);
         }
 
         public string GenerateParseResultsReport()
@@ -345,6 +822,9 @@ namespace Core
             return Parameters.TryGetValue(name, out var parameter) ? parameter : null;
         }
 
+        /// <summary>
+        /// Stored constraint with the label; rows of pending rules are reached through MaterializeRows or InstantiateRule
+        /// </summary>
         public LinearEquation? GetEquationByLabel(string label)
         {
             return LabeledEquations.TryGetValue(label, out var equation) ? equation : null;
@@ -411,24 +891,29 @@ namespace Core
 
         public void AddTupleSchema(TupleSchema schema)
         {
+            Limits.CheckEntityCount(this);
             if (TupleSchemas.ContainsKey(schema.Name))
             {
                 throw new InvalidOperationException($"Tuple schema '{schema.Name}' is already defined");
             }
             TupleSchemas[schema.Name] = schema;
+            Audit(AuditOperation.Add, $"tuple:{schema.Name}");
         }
     
         public void AddTupleSet(TupleSet tupleSet)
         {
+            Limits.CheckEntityCount(this);
             if (TupleSets.ContainsKey(tupleSet.Name))
             {
                 throw new InvalidOperationException($"Tuple set '{tupleSet.Name}' already exists");
             }
             TupleSets[tupleSet.Name] = tupleSet;
+            Audit(AuditOperation.Add, $"tupleset:{tupleSet.Name}");
         }
 
         public void AddPrimitiveSet(PrimitiveSet primitiveSet)
         {
+            Limits.CheckEntityCount(this);
             if (primitiveSet == null)
                 throw new ArgumentNullException(nameof(primitiveSet));
     
@@ -436,6 +921,7 @@ namespace Core
                 throw new InvalidOperationException($"Primitive set '{primitiveSet.Name}' is already defined");
     
             PrimitiveSets[primitiveSet.Name] = primitiveSet;
+            Audit(AuditOperation.Add, $"set:{primitiveSet.Name}");
         }
 
         /// <summary>
@@ -448,68 +934,18 @@ namespace Core
         }
 
         /// <summary>
-        /// Validates that there are no circular dependencies in dexprs
+        /// Validates that there are no circular dependencies between dexprs, parameter formulas
+        /// and computed sets (see Analysis.DefinitionCycles)
         /// </summary>
         public bool ValidateDexprDependencies(out string error)
         {
-            error = string.Empty;
-        
-            // Build dependency graph
             foreach (var dexpr in DecisionExpressions.Values)
             {
                 dexpr.AnalyzeDependencies(this);
             }
-        
-            // Check for cycles using DFS
-            var visited = new HashSet<string>();
-            var recursionStack = new HashSet<string>();
-        
-            foreach (var dexprName in DecisionExpressions.Keys)
-            {
-                if (HasCycle(dexprName, visited, recursionStack, out var cycle))
-                {
-                    error = $"Circular dependency detected: {string.Join(" -> ", cycle)}";
-                    return false;
-                }
-            }
-        
-            return true;
-        }
-    
-        private bool HasCycle(string dexprName, HashSet<string> visited, 
-            HashSet<string> recursionStack, out List<string> cycle)
-        {
-            cycle = new List<string>();
-        
-            if (!visited.Contains(dexprName))
-            {
-                visited.Add(dexprName);
-                recursionStack.Add(dexprName);
-            
-                if (DecisionExpressions.TryGetValue(dexprName, out var dexpr))
-                {
-                    foreach (var dependency in dexpr.DependsOn)
-                    {
-                        if (!visited.Contains(dependency))
-                        {
-                            if (HasCycle(dependency, visited, recursionStack, out cycle))
-                            {
-                                cycle.Insert(0, dexprName);
-                                return true;
-                            }
-                        }
-                        else if (recursionStack.Contains(dependency))
-                        {
-                            cycle.Add(dexprName);
-                            cycle.Add(dependency);
-                            return true;
-                        }
-                    }
-                }
-            }
-        
-            recursionStack.Remove(dexprName);
-            return false;
+
+            error = string.Join("\n", Analysis.DefinitionCycles.Describe(this));
+            return error.Length == 0;
         }
 
         // Add this property to the ModelManager class
@@ -524,12 +960,14 @@ namespace Core
         /// </summary>
         public void AddRange(OplRange range)
         {
+            Limits.CheckEntityCount(this);
             if (Ranges.ContainsKey(range.Name))
             {
                 throw new InvalidOperationException($"Range '{range.Name}' is already defined");
             }
     
             Ranges[range.Name] = range;
+            Audit(AuditOperation.Add, $"range:{range.Name}");
         }
 
         /// <summary>
@@ -578,12 +1016,14 @@ namespace Core
 
         public void AddComputedSet(ComputedSet computedSet)
         {
+            Limits.CheckEntityCount(this);
             if (ComputedSets.ContainsKey(computedSet.Name))
             {
                 throw new InvalidOperationException($"Computed set '{computedSet.Name}' is already defined");
             }
     
             ComputedSets[computedSet.Name] = computedSet;
+            Audit(AuditOperation.Add, $"set:{computedSet.Name}");
         }
 
         /// <summary>
@@ -606,17 +1046,29 @@ namespace Core
             if (IndexedEquationTemplates.Count == 0)
                 return;
 
-            foreach (var template in IndexedEquationTemplates.Values)
+            int equationsBefore = Equations.Count;
+
+            auditSuppression++;
+            try
             {
-                if (template.IsTwoDimensional)
-                {
-                    ExpandTwoDimensionalEquationTemplate(template);
-                }
-                else
+                foreach (var template in IndexedEquationTemplates.Values)
                 {
-                    ExpandSingleDimensionalEquationTemplate(template);
+                    if (template.IsTwoDimensional)
+                    {
+                        ExpandTwoDimensionalEquationTemplate(template);
+                    }
+                    else
+                    {
+                        ExpandSingleDimensionalEquationTemplate(template);
+                    }
                 }
             }
+            finally
+            {
+                auditSuppression--;
+            }
+
+            Audit(AuditOperation.Expand, "templates", $"{Equations.Count - equationsBefore} constraints generated");
 
             // Clear templates after expansion
             IndexedEquationTemplates.Clear();
@@ -1,4 +1,6 @@
+using Core.Analysis;
 using Core.Models;
+using Core.Services;
 using Core.Solving;
 
 namespace Core
@@ -19,6 +21,21 @@ namespace Core
             this.dataParser = dataParser;
         }
 
+        /// <summary>
+        /// When false, ParseModel stops after expansion and leaves SolveResult unset
+        /// </summary>
+        public bool SolveAfterParse { get; set; } = true;
+
+        /// <summary>
+        /// Results of earlier solves; when set, parsing an unchanged model again does not re-solve it
+        /// </summary>
+        public SolveCache? Cache { get; set; }
+
+        /// <summary>
+        /// Cases applied in order on top of the data files, before the model is expanded
+        /// </summary>
+        public List<CaseOverlay> Overlays { get; } = new List<CaseOverlay>();
+
         /// <summary>
         /// Parses model and data files and returns a structured result
         /// </summary>
@@ -27,6 +44,23 @@ namespace Core
         /// <returns>ParseResult with success/error information</returns>
         public ParseResult ParseModel(List<string> modelTexts, List<string> dataTexts)
         {
+            return ParseModel(modelTexts, dataTexts, CancellationToken.None);
+        }
+
+        /// <summary>
+        /// Parses model and data files, reporting progress per file and stage. Cancellation is
+        /// checked between files and stages and throws OperationCanceledException.
+        /// </summary>
+        public ParseResult ParseModel(
+            List<string> modelTexts,
+            List<string> dataTexts,
+            CancellationToken cancellationToken,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("load", progress, cancellationToken);
+            using var phase = ModelTelemetry.StartPhase("parse")
+                .SetTag("modeleditor.model_files", modelTexts?.Count ?? 0)
+                .SetTag("modeleditor.data_files", dataTexts?.Count ?? 0);
             var result = new ParseResult();
             var allResults = new List<ParseSessionResult>();
 
@@ -41,17 +75,29 @@ namespace Core
                     result.Success = false;
                     result.Errors.Add("No model files provided");
                     result.SummaryMessage = "No model files to parse";
+                    phase.Complete("error", result.SummaryMessage);
                     return result;
                 }
 
                 // STEP 1: Parse model files (declarations and templates only)
-                foreach (var text in modelTexts)
+                tracker.Stage("model", modelTexts.Count);
+                for (int i = 0; i < modelTexts.Count; i++)
                 {
-                    if (!string.IsNullOrWhiteSpace(text))
+                    if (!string.IsNullOrWhiteSpace(modelTexts[i]))
                     {
-                        var parseResult = parser.Parse(text);
+                        var parseResult = parser.Parse(modelTexts[i]);
                         allResults.Add(parseResult);
                     }
+                    tracker.Tick(i + 1, modelTexts.Count);
+                }
+
+                // STEP 1b: Check the indices of every reference against the declarations of all files
+                foreach (var text in modelTexts.Where(t => !string.IsNullOrWhiteSpace(t)))
+                {
+                    var indexResult = new ParseSessionResult();
+                    foreach (var diagnostic in IndexChecker.Check(modelManager, text))
+                        indexResult.AddError(diagnostic.ToString(), diagnostic.LineNumber);
+                    allResults.Add(indexResult);
                 }
 
                 // STEP 2a: Pre-scan data files for scalar values so that range-defining
@@ -67,6 +113,8 @@ namespace Core
                 // STEP 2: Parse data files (populate all parameter values)
                 if (dataTexts != null)
                 {
+                    tracker.Stage("data", dataTexts.Count);
+                    int loaded = 0;
                     foreach (var text in dataTexts)
                     {
                         if (!string.IsNullOrWhiteSpace(text))
@@ -78,13 +126,19 @@ namespace Core
                             {
                                 sessionResult.Errors.Add(error);
                             }
+                            sessionResult.Suggestions.AddRange(dataResult.Suggestions);
 
                             allResults.Add(sessionResult);
                         }
+                        tracker.Tick(++loaded, dataTexts.Count);
                     }
                 }
 
-                // STEP 2b: Re-evaluate ranges once more in case data files themselves define
+                // STEP 2b: Apply case overlays over the data; an overlay whose keys do not all resolve is skipped
+                foreach (var overlay in Overlays)
+                    allResults.Add(overlay.Apply(modelManager, dataParser));
+
+                // STEP 2c: Re-evaluate ranges once more in case data files themselves define
                 // parameters that influence other ranges.
                 modelManager.ReevaluateRanges();
 
@@ -126,7 +180,8 @@ namespace Core
                 if (!missingParams.Any())
                 {
                     var expansionResult = new ParseSessionResult();
-                    parser.ExpandAllTemplates(expansionResult);
+                    tracker.Stage("expand");
+                    parser.ExpandAllTemplates(expansionResult, cancellationToken, progress);
                     allResults.Add(expansionResult);
 
                     if (expansionResult.HasErrors)
@@ -149,6 +204,7 @@ namespace Core
                     {
                         result.Errors.Add(error.Message);
                     }
+                    result.Suggestions.AddRange(parseResult.Suggestions);
                 }
 
                 // Set success flag and summary
@@ -159,29 +215,49 @@ namespace Core
                         ? $"Parsed with errors: {result.TotalSuccess} statements, {result.TotalErrors} errors"
                         : $"Parse failed: {result.TotalErrors} errors";
 
+                ModelTelemetry.RecordEntities("parse", "statements", result.TotalSuccess);
+                ModelTelemetry.RecordEntities("parse", "constraints", modelManager.Equations.Count);
+                phase.SetTag("modeleditor.error_count", result.TotalErrors);
+                phase.Complete(result.TotalErrors == 0 ? "ok" : "error", result.TotalErrors == 0 ? null : result.SummaryMessage);
+                phase.Dispose(); // the solve below is its own phase
+
                 // STEP 5: Solve (only if no parse errors and an objective is defined)
-                if (result.TotalErrors == 0 && modelManager.Objective != null)
+                if (SolveAfterParse && result.TotalErrors == 0 && modelManager.Objective != null)
                 {
+                    tracker.Stage("solve");
+                    ISolverDriver driver = new ModelSolver();
+                    using var solvePhase = ModelTelemetry.StartPhase("solve", ("solver", driver.Name));
                     try
                     {
-                        result.SolveResult = new ModelSolver().Solve(modelManager);
+                        result.SolveResult = Cache != null
+                            ? Cache.GetOrSolve(modelManager, driver, cancellationToken)
+                            : driver.Solve(modelManager, cancellationToken);
+                        ModelTelemetry.RecordSolve(driver.Name, result.SolveResult.Status.ToString());
+                        solvePhase.Complete(result.SolveResult.Status is SolveStatus.Optimal or SolveStatus.Feasible ? "ok" : "error", result.SolveResult.StatusMessage);
                         if (result.SolveResult.Status is SolveStatus.Optimal or SolveStatus.Feasible)
+                        {
+                            modelManager.Solution = result.SolveResult;
+                            modelManager.Report.Evaluate(modelManager, result.SolveResult);
+                            result.Warnings.AddRange(result.SolveResult.Alerts.Select(a => a.ToString()));
                             result.SummaryMessage += $" | Objective: {result.SolveResult.ObjectiveValue:G}";
+                        }
                     }
-                    catch (Exception ex)
+                    catch (Exception ex) when (ex is not OperationCanceledException)
                     {
+                        solvePhase.Complete("error", ex.Message);
                         result.Warnings.Add($"Solver error: {ex.Message}");
                     }
                 }
 
                 return result;
             }
-            catch (Exception ex)
+            catch (Exception ex) when (ex is not OperationCanceledException)
             {
                 result.Success = false;
                 result.TotalErrors++;
                 result.Errors.Add($"Critical error during parsing: {ex.Message}");
                 result.SummaryMessage = "Critical error during parsing";
+                phase.Complete("error", ex.Message);
                 return result;
             }
         }
@@ -0,0 +1,204 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// One aggregation over an index domain as written in a text: max(p in P, t in T: t > 1) x[p,t]
+    /// </summary>
+    public class AggregationCall
+    {
+        public int Index { get; init; }
+        public int Length { get; init; }
+
+        /// <summary>
+        /// min, max, prod, count or avg
+        /// </summary>
+        public string Function { get; init; } = "";
+
+        /// <summary>
+        /// Iterators as written, without the filter ("p in P, t in T")
+        /// </summary>
+        public string Iterators { get; init; } = "";
+
+        public string? Filter { get; init; }
+        public string Body { get; init; } = "";
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// The parenthesized part of the call, filter included
+        /// </summary>
+        public string Header => Filter == null ? Iterators : $"{Iterators}: {Filter}";
+    }
+
+    /// <summary>
+    /// Aggregations other than sum in constraints (see AggregationExpression). Constraints are
+    /// expanded as text, so the calls are resolved there, after the sums have bound the names
+    /// they use: an aggregation of data becomes its value, avg of variables a sum scaled by the
+    /// size of its domain, and count of binaries a sum. min, max and count of other variables
+    /// are not linear; they are reformulated with auxiliary variables before the model is
+    /// parsed (ModelLinearizer), and one left in a constraint is an error.
+    /// </summary>
+    public static class Aggregations
+    {
+        private static readonly Regex CallPattern = new Regex(@"(?<![\w.])(min|max|prod|count|avg)\s*\(\s*[A-Za-z_]\w*\s+in\s");
+
+        private static readonly Regex NamePattern = new Regex(@"(?<![\w.""])[A-Za-z_]\w*(?!\w)");
+
+        private static readonly Regex ReferencePattern = new Regex(@"^[A-Za-z_]\w*(?:\[[^\]]*\])*$");
+
+        /// <summary>
+        /// True if the text has an aggregation over an index domain other than sum
+        /// </summary>
+        public static bool IsUsed(string text) => CallPattern.IsMatch(text);
+
+        /// <summary>
+        /// The aggregation calls of a text from left to right; the body of each is one term as
+        /// for sum, so "max(i in I) x[i] + 1" adds 1 once
+        /// </summary>
+        public static List<AggregationCall> Find(string text)
+        {
+            var calls = new List<AggregationCall>();
+            foreach (Match match in CallPattern.Matches(text))
+            {
+                int open = text.IndexOf('(', match.Index);
+                int close = Close(text, open);
+                if (close < 0)
+                    continue;
+
+                string header = text.Substring(open + 1, close - open - 1);
+                int colon = TopLevelColon(header);
+                string body = SummationExpander.ExtractSumBody(text, close + 1, out int length);
+                if (body.Length == 0)
+                    continue;
+
+                // The extracted body is trimmed; the call ends where its last character is
+                int end = close + 1 + length;
+                while (end > close + 1 && char.IsWhiteSpace(text[end - 1]))
+                    end--;
+
+                calls.Add(new AggregationCall
+                {
+                    Index = match.Index,
+                    Length = end - match.Index,
+                    Function = match.Groups[1].Value,
+                    Iterators = (colon < 0 ? header : header.Substring(0, colon)).Trim(),
+                    Filter = colon < 0 ? null : header.Substring(colon + 1).Trim(),
+                    Body = body,
+                    Text = text.Substring(match.Index, end - match.Index)
+                });
+            }
+            return calls;
+        }
+
+        /// <summary>
+        /// Replaces the aggregations of one side of an expanded constraint by linear terms; throws
+        /// InvalidOperationException for one that is not linear
+        /// </summary>
+        public static string Resolve(ModelManager manager, string text)
+        {
+            // Innermost first: a call in the body of another comes after it
+            int limit = text.Length;
+            while (Find(text).LastOrDefault(c => c.Index < limit) is { } call)
+            {
+                string replacement = Linear(manager, call)
+                    ?? throw new InvalidOperationException(NotLinear(call));
+                text = text.Substring(0, call.Index) + replacement + text.Substring(call.Index + call.Length);
+                limit = call.Index;
+            }
+            return text;
+        }
+
+        /// <summary>
+        /// The call as a linear term, or null if it is not linear
+        /// </summary>
+        private static string? Linear(ModelManager manager, AggregationCall call)
+        {
+            var parser = new EquationParser(manager);
+            if (!UsesVariables(manager, call.Header) && !UsesVariables(manager, call.Body))
+            {
+                double value = parser.ParseExpression(call.Text).Evaluate(manager);
+                string number = value.ToString("R", CultureInfo.InvariantCulture);
+                return value < 0 ? $"({number})" : number;
+            }
+
+            switch (call.Function)
+            {
+                case "avg":
+                    double count = parser.ParseExpression($"count({call.Header}) 1").Evaluate(manager);
+                    if (count == 0)
+                        throw new InvalidOperationException($"{call.Text} is over an empty set");
+                    string factor = (1.0 / count).ToString("R", CultureInfo.InvariantCulture);
+                    string body = call.Body.StartsWith('-') ? $"({call.Body})" : call.Body;
+                    return $"sum({call.Header}) {factor}*{body}";
+
+                case "count" when IsBinary(manager, call.Body):
+                    return $"sum({call.Header}) {call.Body}";
+
+                default:
+                    return null;
+            }
+        }
+
+        private static string NotLinear(AggregationCall call)
+        {
+            if (call.Function == "prod")
+                return $"'{call.Text}' is a product of decision variables, which is not linear";
+            return $"'{call.Text}' is not linear in the decision variables and was not reformulated: " +
+                   (call.Function == "count"
+                       ? "count of non-binary variables needs finite bounds and must be bounded from above"
+                       : "give its variables finite bounds, or bound it from the convex side (max from above, min from below)");
+        }
+
+        private static bool UsesVariables(ModelManager manager, string text)
+        {
+            return NamePattern.Matches(text).Any(m =>
+                manager.IndexedVariables.ContainsKey(m.Value) || manager.DecisionExpressions.ContainsKey(m.Value));
+        }
+
+        /// <summary>
+        /// True if the body is a single reference to a bool variable or an int variable in 0..1
+        /// </summary>
+        private static bool IsBinary(ModelManager manager, string body)
+        {
+            string reference = body.Trim();
+            if (!ReferencePattern.IsMatch(reference))
+                return false;
+
+            int bracket = reference.IndexOf('[');
+            string name = bracket < 0 ? reference : reference.Substring(0, bracket);
+            return manager.IndexedVariables.TryGetValue(name, out var variable) &&
+                   (variable.Type == VariableType.Boolean ||
+                    (variable.Type == VariableType.Integer && variable.LowerBound == 0 && variable.UpperBound == 1));
+        }
+
+        private static int TopLevelColon(string header)
+        {
+            int depth = 0;
+            for (int i = 0; i < header.Length; i++)
+            {
+                if (header[i] is '(' or '[')
+                    depth++;
+                else if (header[i] is ')' or ']')
+                    depth--;
+                else if (header[i] == ':' && depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        private static int Close(string text, int open)
+        {
+            int depth = 0;
+            for (int i = open; i < text.Length; i++)
+            {
+                if (text[i] == '(')
+                    depth++;
+                else if (text[i] == ')' && --depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+    }
+}
@@ -0,0 +1,142 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Evaluates the bound expressions of variable declarations over the loaded data:
+    /// <code>
+    /// dvar float+ x[p in P, t in T] in 0..capacity[p] * availability[p,t];
+    /// dvar float+ reserve in 0..maxReserve;
+    /// </code>
+    /// An expression without the declaration's iterators is the bound of the whole family; one
+    /// using them is evaluated per element into IndexedVariable.ElementBounds. The expressions
+    /// stay in the declaration, so loading other data and expanding again updates every bound
+    /// without rewriting the model. Right-hand sides of constraints are expressions already and
+    /// are evaluated the same way when the model is written or solved.
+    /// </summary>
+    public static class BoundExpressions
+    {
+        /// <summary>
+        /// Evaluates every bound expression; expressions that do not evaluate to a number are errors
+        /// </summary>
+        public static void Materialize(ModelManager manager, ParseSessionResult result)
+        {
+            foreach (var variable in manager.IndexedVariables.Values.Where(v => v.HasBoundExpressions))
+            {
+                variable.ElementBounds.Clear();
+                var iterators = variable.IndexIterators ?? new List<string>();
+                bool perElement = iterators.Any(i => Uses(variable.LowerBoundExpression, i) || Uses(variable.UpperBoundExpression, i));
+
+                if (!perElement)
+                {
+                    if (variable.LowerBoundExpression != null && TryEvaluate(manager, variable, variable.LowerBoundExpression, result, out double lower))
+                        variable.LowerBound = lower;
+                    if (variable.UpperBoundExpression != null && TryEvaluate(manager, variable, variable.UpperBoundExpression, result, out double upper))
+                        variable.UpperBound = upper;
+                    continue;
+                }
+
+                var domains = new List<List<int>>();
+                foreach (string set in variable.IndexSetNames)
+                {
+                    var elements = Elements(manager, set);
+                    if (elements == null)
+                    {
+                        result.AddError($"Bounds of '{variable.BaseName}' use its iterators, which needs a range or index set; '{set}' is neither", 0);
+                        break;
+                    }
+                    domains.Add(elements);
+                }
+
+                if (domains.Count == iterators.Count)
+                    MaterializeElements(manager, variable, iterators, domains, new List<int>(), result);
+            }
+        }
+
+        /// <summary>
+        /// Evaluates the bounds of every element, stopping at the first failing one
+        /// </summary>
+        private static bool MaterializeElements(ModelManager manager, IndexedVariable variable, List<string> iterators,
+            List<List<int>> domains, List<int> indices, ParseSessionResult result)
+        {
+            if (indices.Count < domains.Count)
+            {
+                foreach (int index in domains[indices.Count])
+                {
+                    indices.Add(index);
+                    bool evaluated = MaterializeElements(manager, variable, iterators, domains, indices, result);
+                    indices.RemoveAt(indices.Count - 1);
+                    if (!evaluated)
+                        return false;
+                }
+                return true;
+            }
+
+            double? lower = variable.LowerBound, upper = variable.UpperBound;
+            if (variable.LowerBoundExpression != null)
+            {
+                if (!TryEvaluate(manager, variable, Substitute(variable.LowerBoundExpression, iterators, indices), result, out double value))
+                    return false;
+                lower = value;
+            }
+            if (variable.UpperBoundExpression != null)
+            {
+                if (!TryEvaluate(manager, variable, Substitute(variable.UpperBoundExpression, iterators, indices), result, out double value))
+                    return false;
+                upper = value;
+            }
+
+            variable.ElementBounds[variable.BaseName + string.Join("_", indices)] = (lower, upper);
+            return true;
+        }
+
+        private static bool TryEvaluate(ModelManager manager, IndexedVariable variable, string expression, ParseSessionResult result, out double value)
+        {
+            value = 0;
+            var parser = new ExpressionParser(manager);
+            if (!parser.TryParseExpression(expression, out var coefficients, out var constant, out string error))
+            {
+                result.AddError($"Bound of '{variable.BaseName}' cannot be evaluated: {error}", 0);
+                return false;
+            }
+            if (coefficients.Count > 0)
+            {
+                result.AddError($"Bound of '{variable.BaseName}' refers to decision variable '{coefficients.Keys.First()}' in '{expression}'", 0);
+                return false;
+            }
+
+            try
+            {
+                value = constant.Evaluate(manager);
+                return true;
+            }
+            catch (Exception ex)
+            {
+                result.AddError($"Bound of '{variable.BaseName}' cannot be evaluated in '{expression}': {ex.Message}", 0);
+                return false;
+            }
+        }
+
+        internal static bool Uses(string? expression, string iterator) =>
+            expression != null && Regex.IsMatch(expression, $@"\b{Regex.Escape(iterator)}\b");
+
+        internal static string Substitute(string expression, List<string> iterators, List<int> indices)
+        {
+            for (int i = 0; i < iterators.Count; i++)
+                expression = Regex.Replace(expression, $@"\b{Regex.Escape(iterators[i])}\b", indices[i].ToString(System.Globalization.CultureInfo.InvariantCulture));
+
+            // Indexers are read without spaces: availability[1, 2] as availability[1,2]
+            return Regex.Replace(expression, @"\s*,\s*", ",");
+        }
+
+        internal static List<int>? Elements(ModelManager manager, string set)
+        {
+            if (manager.IndexSets.TryGetValue(set, out var indexSet))
+                return indexSet.GetIndices().ToList();
+            if (manager.Ranges.TryGetValue(set, out var range))
+                return range.GetValues(manager).ToList();
+            return null;
+        }
+    }
+}
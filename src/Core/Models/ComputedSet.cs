@@ -29,15 +29,24 @@ namespace Core.Models
             IsProjection = isProjection;
         }
 
+        public ComputedSet Clone()
+        {
+            var iterators = Iterators.Select(i => new SetIterator(i.VariableName, i.SetName)).ToList();
+            return new ComputedSet(Name, ElementType, iterators, OutputExpression, Condition, IsProjection);
+        }
+
         public object Evaluate(ModelManager manager)
         {
             var results = new List<object>();
 
-            // Get all iterator ranges
-            var iteratorRanges = GetIteratorRanges(manager);
+            using (DefinitionGuard.Enter($"set {Name}"))
+            {
+                // Get all iterator ranges
+                var iteratorRanges = GetIteratorRanges(manager);
 
-            // Generate all combinations of iterator values
-            EvaluateRecursive(manager, 0, new Dictionary<string, object>(), iteratorRanges, results);
+                // Generate all combinations of iterator values
+                EvaluateRecursive(manager, 0, new Dictionary<string, object>(), iteratorRanges, results);
+            }
 
             // Return appropriate type
             if (IsProjection)
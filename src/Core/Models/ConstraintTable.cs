@@ -0,0 +1,197 @@
+using System.Collections;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Constraint rows stored column-wise: label, base name, indices, operator and right-hand side
+    /// per row, and each row's terms as parallel arrays of variable name ids and coefficients.
+    /// Names are interned, so the variables repeated across the rows of a family are stored once.
+    /// Coefficients and right-hand sides that are not plain numbers are kept as expressions beside
+    /// the columns. Elements are handed out as LinearEquation copies: editing one does not change the table.
+    /// </summary>
+    public class ConstraintTable : IReadOnlyList<LinearEquation>
+    {
+        private const int NoIndex = int.MinValue;
+
+        private readonly NameTable names = new NameTable();
+        private readonly List<ForallStatement> rules = new List<ForallStatement>();
+
+        // Name ids, -1 for none
+        private readonly List<int> labels = new List<int>();
+        private readonly List<int> baseNames = new List<int>();
+        private readonly List<int> ruleIds = new List<int>();
+
+        private readonly List<int> indices = new List<int>();
+        private readonly List<int> secondIndices = new List<int>();
+        private readonly List<byte> operators = new List<byte>();
+        private readonly List<int[]> termVariables = new List<int[]>();
+
+        // NaN marks a value held as an expression in the maps below
+        private readonly List<double> constants = new List<double>();
+        private readonly List<double[]> termCoefficients = new List<double[]>();
+        private readonly Dictionary<int, Expression> expressionConstants = new Dictionary<int, Expression>();
+        private readonly Dictionary<(int Row, int Term), Expression> expressionCoefficients = new Dictionary<(int Row, int Term), Expression>();
+
+        public int Count => labels.Count;
+
+        /// <summary>
+        /// Interned labels, base names and variable names of the rows
+        /// </summary>
+        public int NameCount => names.Count;
+
+        public LinearEquation this[int index]
+        {
+            get
+            {
+                if (index < 0 || index >= Count)
+                    throw new ArgumentOutOfRangeException(nameof(index));
+                return Decode(index, true);
+            }
+        }
+
+        /// <summary>
+        /// Appends a copy of the row; later changes to the equation are not seen
+        /// </summary>
+        public void Add(LinearEquation equation)
+        {
+            int row = Count;
+            labels.Add(Intern(equation.Label));
+            baseNames.Add(Intern(equation.BaseName));
+            ruleIds.Add(RuleId(equation.Rule));
+            indices.Add(equation.Index ?? NoIndex);
+            secondIndices.Add(equation.SecondIndex ?? NoIndex);
+            operators.Add((byte)equation.Operator);
+            constants.Add(Encode(equation.Constant, out var constant));
+            if (constant != null)
+                expressionConstants[row] = constant;
+
+            var variables = new int[equation.Coefficients.Count];
+            var coefficients = new double[equation.Coefficients.Count];
+            int term = 0;
+            foreach (var (name, expression) in equation.Coefficients)
+            {
+                variables[term] = names.Intern(name);
+                coefficients[term] = Encode(expression, out var coefficient);
+                if (coefficient != null)
+                    expressionCoefficients[(row, term)] = coefficient;
+                term++;
+            }
+            termVariables.Add(variables);
+            termCoefficients.Add(coefficients);
+        }
+
+        public void AddRange(IEnumerable<LinearEquation> equations)
+        {
+            foreach (var equation in equations)
+                Add(equation);
+        }
+
+        /// <summary>
+        /// Number of terms of a row, without materializing them
+        /// </summary>
+        public int GetTermCount(int index) => termVariables[index].Length;
+
+        /// <summary>
+        /// Variable name ids of a row's terms, in term order; GetName turns an id into the name
+        /// </summary>
+        public ReadOnlySpan<int> GetTermIds(int index) => termVariables[index];
+
+        public string GetName(int id) => names[id];
+
+        /// <summary>
+        /// Coefficient of a row's term; only coefficients held as expressions are not created anew
+        /// </summary>
+        public Expression GetCoefficient(int index, int term)
+        {
+            double value = termCoefficients[index][term];
+            return double.IsNaN(value) ? expressionCoefficients[(index, term)] : new ConstantExpression(value);
+        }
+
+        /// <summary>
+        /// Value of a row's term coefficient; only coefficients held as expressions are evaluated
+        /// </summary>
+        public double EvaluateCoefficient(int index, int term, ModelManager manager)
+        {
+            double value = termCoefficients[index][term];
+            return double.IsNaN(value) ? expressionCoefficients[(index, term)].Evaluate(manager) : value;
+        }
+
+        /// <summary>
+        /// Row without its terms, for readers that take the terms column-wise
+        /// </summary>
+        public LinearEquation GetHeader(int index) => Decode(index, false);
+
+        /// <summary>
+        /// Variable names of each row's terms. A ConstraintTable is read column-wise instead of
+        /// decoding its rows; any other list is read from its equations.
+        /// </summary>
+        public static IEnumerable<IEnumerable<string>> VariablesOf(IReadOnlyList<LinearEquation> rows)
+        {
+            if (rows is not ConstraintTable table)
+                return rows.Select(e => e.Coefficients.Keys);
+            return table.termVariables.Select(ids => ids.Select(id => table.names[id]));
+        }
+
+        public IEnumerator<LinearEquation> GetEnumerator()
+        {
+            for (int i = 0; i < Count; i++)
+                yield return Decode(i, true);
+        }
+
+        IEnumerator IEnumerable.GetEnumerator() => GetEnumerator();
+
+        private LinearEquation Decode(int row, bool withTerms)
+        {
+            var variables = termVariables[row];
+            var values = termCoefficients[row];
+            var coefficients = new Dictionary<string, Expression>(withTerms ? variables.Length : 0);
+            for (int term = 0; withTerms && term < variables.Length; term++)
+            {
+                coefficients[names[variables[term]]] = double.IsNaN(values[term])
+                    ? expressionCoefficients[(row, term)]
+                    : new ConstantExpression(values[term]);
+            }
+
+            return new LinearEquation(
+                coefficients,
+                double.IsNaN(constants[row]) ? expressionConstants[row] : new ConstantExpression(constants[row]),
+                (RelationalOperator)operators[row],
+                labels[row] < 0 ? null : names[labels[row]])
+            {
+                BaseName = baseNames[row] < 0 ? null : names[baseNames[row]],
+                Index = indices[row] == NoIndex ? null : indices[row],
+                SecondIndex = secondIndices[row] == NoIndex ? null : secondIndices[row],
+                Rule = ruleIds[row] < 0 ? null : rules[ruleIds[row]]
+            };
+        }
+
+        private int Intern(string? name) => name == null ? -1 : names.Intern(name);
+
+        private int RuleId(ForallStatement? rule)
+        {
+            if (rule == null)
+                return -1;
+
+            // Rows arrive family by family, so the rule is nearly always the last one seen
+            int id = rules.Count > 0 && rules[^1] == rule ? rules.Count - 1 : rules.IndexOf(rule);
+            if (id < 0)
+            {
+                id = rules.Count;
+                rules.Add(rule);
+            }
+            return id;
+        }
+
+        private static double Encode(Expression value, out Expression? expression)
+        {
+            if (value.GetType() == typeof(ConstantExpression) && !double.IsNaN(((ConstantExpression)value).Value))
+            {
+                expression = null;
+                return ((ConstantExpression)value).Value;
+            }
+
+            expression = value;
+            return double.NaN;
+        }
+    }
+}
@@ -44,7 +44,8 @@ namespace Core.Models
                 throw new InvalidOperationException($"Decision expression '{Name}' is indexed. Use Evaluate(modelManager, index) instead.");
             }
             
-            return Expression.Evaluate(modelManager);
+            using (DefinitionGuard.Enter($"dexpr {Name}"))
+                return Expression.Evaluate(modelManager);
         }
 
         /// <summary>
@@ -106,7 +107,8 @@ namespace Core.Models
                 modelManager.SetParameter(IndexSetName!, index);
                 
                 // Evaluate the expression
-                return Expression.Evaluate(modelManager);
+                using (DefinitionGuard.Enter($"dexpr {Name}[{index}]"))
+                    return Expression.Evaluate(modelManager);
             }
             finally
             {
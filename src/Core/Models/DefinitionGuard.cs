@@ -0,0 +1,48 @@
+namespace Core.Models
+{
+    /// <summary>
+    /// Tracks the definitions (dexprs, computed sets) being evaluated on the current thread, so
+    /// one that refers back to itself fails with the cycle path instead of overflowing the stack:
+    /// "Circular definition: dexpr cost -> dexpr total -> dexpr cost". Models are checked for
+    /// cycles before expansion (see Analysis.DefinitionCycles); this covers definitions edited
+    /// into a model afterwards.
+    /// </summary>
+    public static class DefinitionGuard
+    {
+        [ThreadStatic]
+        private static List<string>? evaluating;
+
+        /// <summary>
+        /// Marks a definition ("dexpr cost", "set Active") as being evaluated until the returned
+        /// scope is disposed; throws InvalidOperationException if it already is
+        /// </summary>
+        public static IDisposable Enter(string definition)
+        {
+            evaluating ??= new List<string>();
+            int start = evaluating.IndexOf(definition);
+            if (start >= 0)
+                throw new InvalidOperationException(Describe(evaluating.Skip(start).Append(definition)));
+
+            evaluating.Add(definition);
+            return new Scope();
+        }
+
+        /// <summary>
+        /// Diagnostic for a cycle given as its path, first definition repeated at the end
+        /// </summary>
+        public static string Describe(IEnumerable<string> cycle) => $"Circular definition: {string.Join(" -> ", cycle)}";
+
+        private sealed class Scope : IDisposable
+        {
+            private bool disposed;
+
+            public void Dispose()
+            {
+                if (disposed)
+                    return;
+                disposed = true;
+                evaluating!.RemoveAt(evaluating.Count - 1);
+            }
+        }
+    }
+}
@@ -0,0 +1,231 @@
+using System.Globalization;
+using System.Numerics;
+using System.Text.RegularExpressions;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Exact rational number (numerator over a positive denominator, in lowest terms) used for
+    /// coefficients of entities with decimal or rational precision. A double converts to the
+    /// shortest decimal that round-trips it, so the literal 0.1 becomes exactly 1/10.
+    /// </summary>
+    public readonly struct ExactNumber : IEquatable<ExactNumber>, IComparable<ExactNumber>
+    {
+        private static readonly Regex decimalPattern = new Regex(@"^([+-]?)(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$");
+        private static readonly Regex fractionPattern = new Regex(@"^([+-]?\d+)\s*/\s*(\d+)$");
+
+        /// <summary>
+        /// Largest decimal exponent accepted when parsing (beyond the range of double)
+        /// </summary>
+        private const int MaxExponent = 400;
+
+        private readonly BigInteger numerator;
+        private readonly BigInteger denominator;
+
+        public ExactNumber(BigInteger numerator, BigInteger denominator)
+        {
+            if (denominator.IsZero)
+                throw new DivideByZeroException("Exact number with zero denominator");
+
+            if (denominator.Sign < 0)
+            {
+                numerator = -numerator;
+                denominator = -denominator;
+            }
+
+            var gcd = BigInteger.GreatestCommonDivisor(numerator, denominator);
+            if (!gcd.IsOne && !gcd.IsZero)
+            {
+                numerator /= gcd;
+                denominator /= gcd;
+            }
+
+            this.numerator = numerator;
+            this.denominator = numerator.IsZero ? BigInteger.One : denominator;
+        }
+
+        public static ExactNumber Zero => new ExactNumber(BigInteger.Zero, BigInteger.One);
+        public static ExactNumber One => new ExactNumber(BigInteger.One, BigInteger.One);
+
+        public BigInteger Numerator => numerator;
+
+        /// <summary>
+        /// Denominator (1 for default(ExactNumber), which is zero)
+        /// </summary>
+        public BigInteger Denominator => denominator.IsZero ? BigInteger.One : denominator;
+
+        public bool IsZero => numerator.IsZero;
+        public bool IsInteger => Denominator.IsOne;
+        public int Sign => numerator.Sign;
+
+        /// <summary>
+        /// True if the value has a finite decimal expansion (the denominator has no prime
+        /// factors other than 2 and 5)
+        /// </summary>
+        public bool IsTerminating
+        {
+            get
+            {
+                var d = Denominator;
+                while (d % 2 == 0)
+                    d /= 2;
+                while (d % 5 == 0)
+                    d /= 5;
+                return d.IsOne;
+            }
+        }
+
+        /// <summary>
+        /// True if converting to double and back gives the same value, i.e. writing the
+        /// number to a binary64 format loses nothing
+        /// </summary>
+        public bool IsExactDouble
+        {
+            get
+            {
+                double value = ToDouble();
+                return !double.IsInfinity(value) && FromDouble(value) == this;
+            }
+        }
+
+        public static ExactNumber FromInteger(BigInteger value) => new ExactNumber(value, BigInteger.One);
+
+        /// <summary>
+        /// The shortest decimal that round-trips the double
+        /// </summary>
+        public static ExactNumber FromDouble(double value)
+        {
+            if (double.IsNaN(value) || double.IsInfinity(value))
+                throw new InvalidOperationException($"{value.ToString(CultureInfo.InvariantCulture)} has no exact value");
+            return Parse(value.ToString("R", CultureInfo.InvariantCulture));
+        }
+
+        /// <summary>
+        /// Parses a decimal literal ("19.99", "-1.5e-3") or a fraction ("1/3") without rounding
+        /// </summary>
+        public static ExactNumber Parse(string text)
+        {
+            return TryParse(text, out var value)
+                ? value
+                : throw new FormatException($"'{text}' is not a decimal number or fraction");
+        }
+
+        public static bool TryParse(string text, out ExactNumber value)
+        {
+            value = Zero;
+            text = text.Trim();
+
+            var fraction = fractionPattern.Match(text);
+            if (fraction.Success)
+            {
+                var den = BigInteger.Parse(fraction.Groups[2].Value, CultureInfo.InvariantCulture);
+                if (den.IsZero)
+                    return false;
+                value = new ExactNumber(BigInteger.Parse(fraction.Groups[1].Value, CultureInfo.InvariantCulture), den);
+                return true;
+            }
+
+            var m = decimalPattern.Match(text);
+            string integerDigits = m.Groups[2].Value, fractionDigits = m.Groups[3].Value;
+            if (!m.Success || integerDigits.Length + fractionDigits.Length == 0)
+                return false;
+
+            int exponent = 0;
+            if (m.Groups[4].Success && (!int.TryParse(m.Groups[4].Value, NumberStyles.AllowLeadingSign, CultureInfo.InvariantCulture, out exponent) || Math.Abs(exponent) > MaxExponent))
+                return false;
+
+            var digits = BigInteger.Parse("0" + integerDigits + fractionDigits, CultureInfo.InvariantCulture);
+            if (m.Groups[1].Value == "-")
+                digits = -digits;
+
+            exponent -= fractionDigits.Length;
+            value = exponent >= 0
+                ? new ExactNumber(digits * BigInteger.Pow(10, exponent), BigInteger.One)
+                : new ExactNumber(digits, BigInteger.Pow(10, -exponent));
+            return true;
+        }
+
+        /// <summary>
+        /// Nearest double (correctly rounded for terminating values)
+        /// </summary>
+        public double ToDouble()
+        {
+            if (IsTerminating)
+                return double.Parse(ToString(), CultureInfo.InvariantCulture);
+
+            // Enough decimals for 20 significant digits, more than double can distinguish
+            int scale = 20 + Math.Max(0, Digits(Denominator) - Digits(BigInteger.Abs(numerator)));
+            return double.Parse(Round(scale).ToString(), CultureInfo.InvariantCulture);
+        }
+
+        /// <summary>
+        /// Rounds to a number of decimal places, halves to even (as System.Decimal does)
+        /// </summary>
+        public ExactNumber Round(int scale)
+        {
+            if (scale < 0)
+                throw new ArgumentOutOfRangeException(nameof(scale));
+
+            var factor = BigInteger.Pow(10, scale);
+            var quotient = BigInteger.DivRem(numerator * factor, Denominator, out var remainder);
+            int half = (BigInteger.Abs(remainder) * 2).CompareTo(Denominator);
+            if (half > 0 || (half == 0 && !quotient.IsEven))
+                quotient += numerator.Sign;
+
+            return new ExactNumber(quotient, factor);
+        }
+
+        /// <summary>
+        /// Exact decimal for terminating values ("0.3", "-12.125"), otherwise "numerator/denominator"
+        /// </summary>
+        public override string ToString()
+        {
+            if (!IsTerminating)
+                return $"{numerator.ToString(CultureInfo.InvariantCulture)}/{Denominator.ToString(CultureInfo.InvariantCulture)}";
+
+            int places = 0;
+            var power = BigInteger.One;
+            while (!(power % Denominator).IsZero)
+            {
+                power *= 10;
+                places++;
+            }
+
+            var digits = BigInteger.Abs(numerator * (power / Denominator)).ToString(CultureInfo.InvariantCulture);
+            string sign = numerator.Sign < 0 ? "-" : "";
+            if (places == 0)
+                return sign + digits;
+
+            digits = digits.PadLeft(places + 1, '0');
+            return $"{sign}{digits.Substring(0, digits.Length - places)}.{digits.Substring(digits.Length - places)}";
+        }
+
+        public static ExactNumber operator +(ExactNumber a, ExactNumber b) =>
+            new ExactNumber(a.numerator * b.Denominator + b.numerator * a.Denominator, a.Denominator * b.Denominator);
+
+        public static ExactNumber operator -(ExactNumber a, ExactNumber b) =>
+            new ExactNumber(a.numerator * b.Denominator - b.numerator * a.Denominator, a.Denominator * b.Denominator);
+
+        public static ExactNumber operator *(ExactNumber a, ExactNumber b) =>
+            new ExactNumber(a.numerator * b.numerator, a.Denominator * b.Denominator);
+
+        public static ExactNumber operator /(ExactNumber a, ExactNumber b) =>
+            b.IsZero ? throw new DivideByZeroException("Division by zero in exact arithmetic")
+                     : new ExactNumber(a.numerator * b.Denominator, a.Denominator * b.numerator);
+
+        public static ExactNumber operator -(ExactNumber a) => new ExactNumber(-a.numerator, a.Denominator);
+
+        public static bool operator ==(ExactNumber a, ExactNumber b) => a.Equals(b);
+        public static bool operator !=(ExactNumber a, ExactNumber b) => !a.Equals(b);
+        public static bool operator <(ExactNumber a, ExactNumber b) => a.CompareTo(b) < 0;
+        public static bool operator >(ExactNumber a, ExactNumber b) => a.CompareTo(b) > 0;
+
+        public bool Equals(ExactNumber other) => numerator == other.numerator && Denominator == other.Denominator;
+        public override bool Equals(object? obj) => obj is ExactNumber other && Equals(other);
+        public override int GetHashCode() => HashCode.Combine(numerator, Denominator);
+
+        public int CompareTo(ExactNumber other) => (numerator * other.Denominator).CompareTo(other.numerator * Denominator);
+
+        private static int Digits(BigInteger value) => value.IsZero ? 1 : value.ToString(CultureInfo.InvariantCulture).Length;
+    }
+}
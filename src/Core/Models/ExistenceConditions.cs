@@ -0,0 +1,128 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Existence conditions of variables and constraints, evaluated over the loaded data:
+    /// <code>
+    /// dvar float+ storage[p in P] in 0..capacity[p] exists if has_storage[p];
+    /// forall(p in P) fill: storage[p] <= level[p] exists if has_storage[p];
+    /// reserveLimit: reserve <= 10 exists if useReserve;
+    /// </code>
+    /// A condition is a numeric expression (true when nonzero) or one comparison of two. Variable
+    /// elements for which it does not hold are recorded in IndexedVariable.AbsentElements and held
+    /// at zero, so terms referring to them drop out; constraints whose condition does not hold are
+    /// not generated (see ForallStatement.ExistsCondition). Loading other data and expanding again
+    /// changes which entities exist without rewriting the model.
+    /// </summary>
+    public static class ExistenceConditions
+    {
+        private static readonly Regex ComparisonPattern = new Regex(@"^(.+?)\s*(==|!=|<=|>=|<|>)\s*(.+)$", RegexOptions.Singleline);
+
+        /// <summary>
+        /// Evaluates the existence conditions of all variables; runs after the bound expressions,
+        /// whose per-element bounds the absent elements override
+        /// </summary>
+        public static void Materialize(ModelManager manager, ParseSessionResult result)
+        {
+            foreach (var variable in manager.IndexedVariables.Values.Where(v => v.ExistsCondition != null))
+            {
+                if (!variable.HasBoundExpressions)
+                    variable.ElementBounds.Clear();
+                variable.AbsentElements.Clear();
+
+                try
+                {
+                    foreach (string element in Absent(manager, variable))
+                    {
+                        variable.AbsentElements.Add(element);
+                        variable.ElementBounds[element] = (0, 0);
+                    }
+                }
+                catch (InvalidOperationException ex)
+                {
+                    result.AddError($"Existence condition of '{variable.BaseName}' cannot be evaluated: {ex.Message}", 0);
+                }
+            }
+        }
+
+        /// <summary>
+        /// True if the condition holds; throws InvalidOperationException if it cannot be evaluated
+        /// </summary>
+        public static bool Holds(ModelManager manager, string condition)
+        {
+            var comparison = ComparisonPattern.Match(condition.Trim());
+            if (!comparison.Success)
+                return Evaluate(manager, condition) != 0;
+
+            double left = Evaluate(manager, comparison.Groups[1].Value);
+            double right = Evaluate(manager, comparison.Groups[3].Value);
+            return comparison.Groups[2].Value switch
+            {
+                "==" => Math.Abs(left - right) < 1e-10,
+                "!=" => Math.Abs(left - right) >= 1e-10,
+                "<=" => left <= right,
+                ">=" => left >= right,
+                "<" => left < right,
+                _ => left > right
+            };
+        }
+
+        private static IEnumerable<string> Absent(ModelManager manager, IndexedVariable variable)
+        {
+            string condition = variable.ExistsCondition!;
+            var sets = variable.IndexSetNames;
+            if (sets.Count == 0)
+                return Holds(manager, condition) ? Enumerable.Empty<string>() : new[] { variable.BaseName };
+
+            var domains = new List<List<int>>();
+            foreach (string set in sets)
+            {
+                domains.Add(BoundExpressions.Elements(manager, set)
+                    ?? throw new InvalidOperationException($"'{set}' is not a range or index set"));
+            }
+
+            var iterators = variable.IndexIterators;
+            bool perElement = iterators != null && iterators.Any(i => BoundExpressions.Uses(condition, i));
+            bool familyHolds = perElement || Holds(manager, condition);
+
+            var absent = new List<string>();
+            foreach (var indices in Combinations(domains))
+            {
+                bool exists = perElement
+                    ? Holds(manager, BoundExpressions.Substitute(condition, iterators!, indices))
+                    : familyHolds;
+                if (!exists)
+                    absent.Add(variable.BaseName + string.Join("_", indices));
+            }
+            return absent;
+        }
+
+        private static IEnumerable<List<int>> Combinations(List<List<int>> domains)
+        {
+            IEnumerable<List<int>> combinations = new[] { new List<int>() };
+            foreach (var domain in domains)
+                combinations = combinations.SelectMany(prefix => domain.Select(value => new List<int>(prefix) { value }));
+            return combinations;
+        }
+
+        private static double Evaluate(ModelManager manager, string expression)
+        {
+            var parser = new ExpressionParser(manager);
+            if (!parser.TryParseExpression(expression.Trim(), out var coefficients, out var constant, out string error))
+                throw new InvalidOperationException(error);
+            if (coefficients.Count > 0)
+                throw new InvalidOperationException($"'{expression.Trim()}' refers to decision variable '{coefficients.Keys.First()}'");
+
+            try
+            {
+                return constant.Evaluate(manager);
+            }
+            catch (Exception ex) when (ex is not InvalidOperationException)
+            {
+                throw new InvalidOperationException(ex.Message, ex);
+            }
+        }
+    }
+}
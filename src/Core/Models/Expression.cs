@@ -1,3 +1,5 @@
+using Core.Parsing;
+
 namespace Core.Models
 {
     /// <summary>
@@ -70,7 +72,8 @@ namespace Core.Models
         {
             if (!modelManager.Parameters.TryGetValue(ParameterName, out var param))
             {
-                throw new InvalidOperationException($"Parameter '{ParameterName}' not found");
+                throw new InvalidOperationException(
+                    SymbolSuggester.Annotate(modelManager, $"Parameter '{ParameterName}' not found", ParameterName, SymbolKind.Parameter, 0));
             }
 
             if (!param.IsScalar)
@@ -665,12 +668,12 @@ namespace Core.Models
 
             bool result = Operator switch
             {
-                BinaryOperator.Equal => Math.Abs(leftValue - rightValue) < 1e-10,
-                BinaryOperator.NotEqual => Math.Abs(leftValue - rightValue) >= 1e-10,
-                BinaryOperator.LessThan => leftValue < rightValue,
-                BinaryOperator.LessThanOrEqual => leftValue <= rightValue,
-                BinaryOperator.GreaterThan => leftValue > rightValue,
-                BinaryOperator.GreaterThanOrEqual => leftValue >= rightValue,
+                BinaryOperator.Equal => NumericTolerance.Evaluation.AreEqual(leftValue, rightValue),
+                BinaryOperator.NotEqual => !NumericTolerance.Evaluation.AreEqual(leftValue, rightValue),
+                BinaryOperator.LessThan => NumericTolerance.Evaluation.IsLess(leftValue, rightValue),
+                BinaryOperator.LessThanOrEqual => NumericTolerance.Evaluation.IsLessOrEqual(leftValue, rightValue),
+                BinaryOperator.GreaterThan => NumericTolerance.Evaluation.IsGreater(leftValue, rightValue),
+                BinaryOperator.GreaterThanOrEqual => NumericTolerance.Evaluation.IsGreaterOrEqual(leftValue, rightValue),
                 _ => throw new InvalidOperationException($"Invalid comparison operator: {Operator}")
             };
 
@@ -954,7 +957,11 @@ namespace Core.Models
                         ? new ConstantExpression(value)
                         : paramExpr,
 
-                VariableExpression varExpr => varExpr,
+                // A bare iterator can be parsed as a variable reference when the body is read before the sum
+                VariableExpression varExpr =>
+                    context.TryGetIndex(varExpr.VariableName, out int iteratorValue)
+                        ? new ConstantExpression(iteratorValue)
+                        : varExpr,
 
                 IndexedVariableExpression idxVarExpr => new IndexedVariableExpression(
                     idxVarExpr.BaseName,
@@ -973,6 +980,12 @@ namespace Core.Models
                     SubstituteIndex(unaryExpr.Operand, context)
                 ),
 
+                MathFunctionExpression funcExpr => new MathFunctionExpression
+                {
+                    Function = funcExpr.Function,
+                    Arguments = funcExpr.Arguments.Select(a => SubstituteIndex(a, context)).ToArray()
+                },
+
                 SummationExpression sumExpr => sumExpr, // Keep nested summations as-is for now
 
                 _ => expr
@@ -1173,8 +1186,9 @@ namespace Core.Models
                     // For scalar dexpr, we could inline it
                     if (!dexpr.IsIndexed && Index == null && IndexExpression == null)
                     {
-                        // Return the underlying expression (inlining)
-                        return dexpr.Expression.Simplify(modelManager);
+                        // Return the underlying expression (inlining); a circular definition stays a reference
+                        using (DefinitionGuard.Enter($"dexpr {Name}"))
+                            return dexpr.Expression.Simplify(modelManager);
                     }
                 }
                 catch
@@ -1188,7 +1202,11 @@ namespace Core.Models
     }
     
     /// <summary>
-    /// Represents aggregation functions: min, max, prod, card
+    /// Represents aggregation functions over index domains: min, max, prod, card, count, avg.
+    /// Like sum they take several iterators and a filter:
+    ///   max(t in T) demand[t], count(i in I: cost[i] > 10) open[i], avg(p in P, t in T) price[p,t]
+    /// count is the number of combinations whose body is nonzero; min, max and avg of an empty
+    /// domain are errors.
     /// </summary>
     public class AggregationExpression : Expression
     {
@@ -1198,130 +1216,107 @@ namespace Core.Models
             Max,
             Product,
             Cardinality,
-            Average
+            Average,
+            Count
         }
         
         public AggregationType Type { get; set; }
-        public string IndexVariable { get; set; }
-        public string SetName { get; set; }
+        public List<(string varName, string setName)> Iterators { get; set; }
+        public Expression? Filter { get; set; }
         public Expression Body { get; set; }
+
+        /// <summary>
+        /// First iterator, for aggregations over a single set
+        /// </summary>
+        public string IndexVariable => Iterators[0].varName;
+        public string SetName => Iterators[0].setName;
         
         public AggregationExpression(AggregationType type, string indexVar, string setName, Expression body)
+            : this(type, new List<(string, string)> { (indexVar, setName) }, null, body)
+        {
+        }
+
+        public AggregationExpression(AggregationType type, List<(string, string)> iterators, Expression? filter, Expression body)
         {
             Type = type;
-            IndexVariable = indexVar;
-            SetName = setName;
+            Iterators = iterators;
+            Filter = filter;
             Body = body;
         }
         
         public override double Evaluate(ModelManager modelManager)
         {
-            var range = GetRange(modelManager);
-            
-            // Store original parameter
-            bool hadOriginal = modelManager.Parameters.TryGetValue(IndexVariable, out var originalParam);
-            double originalValue = hadOriginal ? Convert.ToDouble(originalParam!.Value) : 0;
-            
-            try
-            {
-                double result = Type switch
-                {
-                    AggregationType.Min => EvaluateMin(range, modelManager),
-                    AggregationType.Max => EvaluateMax(range, modelManager),
-                    AggregationType.Product => EvaluateProduct(range, modelManager),
-                    AggregationType.Cardinality => range.Count(),
-                    AggregationType.Average => EvaluateAverage(range, modelManager),
-                    _ => throw new InvalidOperationException($"Unknown aggregation type: {Type}")
-                };
-                
-                return result;
-            }
-            finally
+            var values = new List<double>();
+            Collect(modelManager, 0, values);
+
+            if (values.Count == 0 && Type is AggregationType.Min or AggregationType.Max or AggregationType.Average)
+                throw new InvalidOperationException($"{this} is over an empty set");
+
+            return Type switch
             {
-                // Restore
-                if (hadOriginal)
-                {
-                    modelManager.SetParameter(IndexVariable, originalValue);
-                }
-                else
-                {
-                    modelManager.Parameters.Remove(IndexVariable);
-                }
-            }
+                AggregationType.Min => values.Min(),
+                AggregationType.Max => values.Max(),
+                AggregationType.Product => values.Aggregate(1.0, (a, b) => a * b),
+                AggregationType.Cardinality => values.Count,
+                AggregationType.Count => values.Count(v => v != 0),
+                AggregationType.Average => values.Average(),
+                _ => throw new InvalidOperationException($"Unknown aggregation type: {Type}")
+            };
         }
-        
-        private double EvaluateMin(IEnumerable<int> range, ModelManager modelManager)
+
+        /// <summary>
+        /// Values of the body for every combination of the iterators that passes the filter
+        /// </summary>
+        private void Collect(ModelManager modelManager, int iteratorIndex, List<double> values)
         {
-            double min = double.MaxValue;
-            foreach (int value in range)
+            if (iteratorIndex == Iterators.Count)
             {
-                modelManager.SetParameter(IndexVariable, value);
-                double current = Body.Evaluate(modelManager);
-                if (current < min) min = current;
+                if (Filter == null || Filter.Evaluate(modelManager) != 0)
+                    values.Add(Body.Evaluate(modelManager));
+                return;
             }
-            return min;
-        }
-        
-        private double EvaluateMax(IEnumerable<int> range, ModelManager modelManager)
-        {
-            double max = double.MinValue;
-            foreach (int value in range)
+
+            var (varName, setName) = Iterators[iteratorIndex];
+            bool hadOriginal = modelManager.Parameters.TryGetValue(varName, out var original);
+            try
             {
-                modelManager.SetParameter(IndexVariable, value);
-                double current = Body.Evaluate(modelManager);
-                if (current > max) max = current;
+                foreach (var element in OrderedSets.Elements(modelManager, setName))
+                {
+                    modelManager.Parameters[varName] = element is string text
+                        ? new Parameter(varName, ParameterType.String, text)
+                        : new Parameter(varName, element is double ? ParameterType.Float : ParameterType.Integer, element);
+                    Collect(modelManager, iteratorIndex + 1, values);
+                }
             }
-            return max;
-        }
-        
-        private double EvaluateProduct(IEnumerable<int> range, ModelManager modelManager)
-        {
-            double product = 1.0;
-            foreach (int value in range)
+            finally
             {
-                modelManager.SetParameter(IndexVariable, value);
-                product *= Body.Evaluate(modelManager);
+                if (hadOriginal)
+                    modelManager.Parameters[varName] = original!;
+                else
+                    modelManager.Parameters.Remove(varName);
             }
-            return product;
         }
-        
-        private double EvaluateAverage(IEnumerable<int> range, ModelManager modelManager)
+
+        /// <summary>
+        /// Name of the aggregation in the model language
+        /// </summary>
+        public string FunctionName => Type switch
+        {
+            AggregationType.Min => "min",
+            AggregationType.Max => "max",
+            AggregationType.Product => "prod",
+            AggregationType.Cardinality => "card",
+            AggregationType.Count => "count",
+            _ => "avg"
+        };
+
+        public override string ToString()
         {
-            double sum = 0.0;
-            int count = 0;
-            foreach (int value in range)
-            {
-                modelManager.SetParameter(IndexVariable, value);
-                sum += Body.Evaluate(modelManager);
-                count++;
-            }
-            return count > 0 ? sum / count : 0.0;
+            var iterators = string.Join(", ", Iterators.Select(i => $"{i.varName} in {i.setName}"));
+            var filter = Filter != null ? $": {Filter}" : "";
+            return $"{FunctionName}({iterators}{filter}) {Body}";
         }
-        
-        private IEnumerable<int> GetRange(ModelManager modelManager)
-        {
-            // Try OplRange first
-            if (modelManager.Ranges.TryGetValue(SetName, out var range))
-            {
-                return range.GetValues(modelManager);
-            }
-            
-            // Try to get from Sets dictionary
-            if (modelManager.Sets.TryGetValue(SetName, out var set))
-            {
-                return set;
-            }
 
-            // Try to get from IndexSets
-            if (modelManager.IndexSets.TryGetValue(SetName, out var indexSet))
-            {
-                return indexSet.GetIndices();
-            }
-            
-            throw new InvalidOperationException($"Set or IndexSet '{SetName}' not found");
-        }
-        
-        public override string ToString() => $"{Type}({IndexVariable} in {SetName}) {Body}";
         public override bool IsConstant => false;
         public override Expression Simplify(ModelManager? modelManager = null) => this;
 
@@ -238,7 +238,7 @@ namespace Core.Models
             if (double.TryParse(str1, out double d1) && 
                 double.TryParse(str2, out double d2))
             {
-                return Math.Abs(d1 - d2) < 1e-10;
+                return NumericTolerance.Evaluation.AreEqual(d1, d2);
             }
 
             return false;
@@ -0,0 +1,140 @@
+using System.Globalization;
+
+namespace Core.Models
+{
+    public enum OrderedSetFunction
+    {
+        Prev,
+        Next,
+        Ord,
+        First,
+        Last
+    }
+
+    /// <summary>
+    /// What prev and next give past the ends of their set
+    /// </summary>
+    public enum BoundaryPolicy
+    {
+        /// <summary>
+        /// The constraint instance is left out (the default)
+        /// </summary>
+        Skip,
+
+        /// <summary>
+        /// Continue at the other end of the set (OPL prevc/nextc)
+        /// </summary>
+        Wrap,
+
+        /// <summary>
+        /// Stay at the first or last element
+        /// </summary>
+        Clamp,
+
+        /// <summary>
+        /// The reference the call indexes is replaced by an initial-condition expression
+        /// </summary>
+        Initial
+    }
+
+    /// <summary>
+    /// Lead/lag and position operators over an ordered set, by position rather than by index
+    /// arithmetic, so they also hold for non-contiguous and non-numeric sets:
+    ///   prev(T, t), next(T, t, 2), prevc(T, t), ord(T, t), first(T), last(T)
+    ///   prev(T, t, clamp), next(T, t, wrap), prev(T, t, initial = level0[p])
+    /// In constraints the calls are resolved to elements when the constraint is expanded (see
+    /// OrderedSets.Resolve); elsewhere they evaluate to the element (int and float sets) or
+    /// its position (ord, counted from 0 as in OPL).
+    /// </summary>
+    public class OrderedSetExpression : Expression
+    {
+        public OrderedSetFunction Function { get; set; }
+        public string SetName { get; set; } = "";
+
+        /// <summary>
+        /// Element the operator starts from; null for first and last
+        /// </summary>
+        public Expression? Element { get; set; }
+
+        /// <summary>
+        /// Number of positions prev and next move
+        /// </summary>
+        public int Offset { get; set; } = 1;
+
+        public BoundaryPolicy Policy { get; set; } = BoundaryPolicy.Skip;
+
+        /// <summary>
+        /// Initial-condition expression of the Initial policy, as written
+        /// </summary>
+        public string? Initial { get; set; }
+
+        public override double Evaluate(ModelManager modelManager)
+        {
+            object value = Value(modelManager);
+            if (value is string)
+                throw new InvalidOperationException($"{this} is the element \"{value}\" of {SetName}, which has no numeric value; use ord({SetName}, ...) for its position");
+            return Convert.ToDouble(value, CultureInfo.InvariantCulture);
+        }
+
+        /// <summary>
+        /// The element the operator gives, or for ord the position of the element
+        /// </summary>
+        public object Value(ModelManager modelManager)
+        {
+            return Resolve(modelManager)
+                ?? throw new InvalidOperationException($"{this} is outside {SetName}");
+        }
+
+        /// <summary>
+        /// Like Value, but null past the ends of the set under the skip and initial policies
+        /// </summary>
+        public object? Resolve(ModelManager modelManager)
+        {
+            var elements = OrderedSets.Elements(modelManager, SetName);
+            if (Function == OrderedSetFunction.First || Function == OrderedSetFunction.Last)
+            {
+                if (elements.Count == 0)
+                    throw new InvalidOperationException($"{this}: {SetName} is empty");
+                return Function == OrderedSetFunction.First ? elements[0] : elements[^1];
+            }
+
+            object element = OrderedSets.ElementValue(modelManager, Element!);
+            int position = OrderedSets.IndexOf(elements, element);
+            if (position < 0)
+                throw new InvalidOperationException($"{this}: {OrderedSets.Format(element)} is not an element of {SetName}");
+            if (Function == OrderedSetFunction.Ord)
+                return position;
+
+            return OrderedSets.Shift(elements, position, Function == OrderedSetFunction.Prev ? -Offset : Offset, Policy);
+        }
+
+        public override bool IsConstant => false;
+
+        public override string ToString()
+        {
+            string name = Function.ToString().ToLowerInvariant();
+            if (Element == null)
+                return $"{name}({SetName})";
+
+            var arguments = new List<string> { SetName, Element.ToString() };
+            if (Function == OrderedSetFunction.Ord)
+                return $"{name}({string.Join(", ", arguments)})";
+
+            if (Offset != 1)
+                arguments.Add(Offset.ToString(CultureInfo.InvariantCulture));
+            switch (Policy)
+            {
+                case BoundaryPolicy.Wrap:
+                    arguments.Add("wrap");
+                    break;
+                case BoundaryPolicy.Clamp:
+                    arguments.Add("clamp");
+                    break;
+                case BoundaryPolicy.Initial:
+                    arguments.Add($"initial = {Initial}");
+                    break;
+            }
+            return $"{name}({string.Join(", ", arguments)})";
+        }
+    }
+}
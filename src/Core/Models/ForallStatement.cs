@@ -18,27 +18,62 @@ namespace Core.Models
 
         public string? Label { get; set; }
 
+        /// <summary>
+        /// Existence condition over parameters ("has_storage[p]"), evaluated per instance with the
+        /// iterators substituted; instances for which it does not hold are not generated. A
+        /// constraint without iterators is kept as a rule with no iterators so its condition can
+        /// be evaluated once the data is loaded.
+        /// </summary>
+        public string? ExistsCondition { get; set; }
+
+        /// <summary>
+        /// The statement as written in the model, for showing and editing the rule
+        /// </summary>
+        public string? Source { get; set; }
 
         /// <summary>
         /// Expands the forall into concrete constraints
         /// </summary>
         public List<LinearEquation> Expand(ModelManager manager)
         {
+            return Expand(manager, null);
+        }
+
+        /// <summary>
+        /// Expands only the instances whose iterators have the bound values (iterator name to
+        /// value as written, "t" = "3"), so one time step or one plant of a large family can be
+        /// inspected without generating the rest. Unbound iterators run over their whole set.
+        /// </summary>
+        public List<LinearEquation> Expand(ModelManager manager, IReadOnlyDictionary<string, string>? bindings)
+        {
+            long combinations = 1;
+            foreach (var iterator in Iterators)
+            {
+                if (bindings != null && bindings.ContainsKey(iterator.VariableName))
+                    continue;
+
+                combinations *= GetIteratorSize(manager, iterator);
+                if (combinations > manager.ExpansionLimit)
+                    throw new InvalidOperationException(
+                        $"forall over {string.Join(", ", Iterators.Select(i => i.Range.SetName))} would generate more than {manager.ExpansionLimit} constraints");
+            }
+
             var constraints = new List<LinearEquation>();
 
             // Generate all combinations of iterator values
-            ExpandRecursive(manager, 0, new Dictionary<string, object>(), constraints);
+            ExpandRecursive(manager, 0, new Dictionary<string, object>(), constraints, bindings);
 
             return constraints;
         }
 
         private void ExpandRecursive(ModelManager manager, int iteratorIndex, 
-            Dictionary<string, object> context, List<LinearEquation> constraints)
+            Dictionary<string, object> context, List<LinearEquation> constraints,
+            IReadOnlyDictionary<string, string>? bindings)
         {
             if (iteratorIndex >= Iterators.Count)
             {
                 // All iterators bound - check global condition and generate constraint
-                if (EvaluateGlobalCondition(manager, context))
+                if (EvaluateGlobalCondition(manager, context) && EvaluateExistsCondition(manager, context))
                 {
                     var constraint = GenerateConstraint(manager, context);
                     if (constraint != null)
@@ -51,6 +86,8 @@ namespace Core.Models
 
             var iterator = Iterators[iteratorIndex];
             var range = GetIteratorRange(manager, iterator);
+            if (bindings != null && bindings.TryGetValue(iterator.VariableName, out var bound))
+                range = range.Where(value => FormatValue(value) == bound).ToList();
 
             foreach (var value in range)
             {
@@ -71,7 +108,7 @@ namespace Core.Models
                     }
 
                     // Recurse to next iterator
-                    ExpandRecursive(manager, iteratorIndex + 1, context, constraints);
+                    ExpandRecursive(manager, iteratorIndex + 1, context, constraints, bindings);
                 }
                 finally
                 {
@@ -89,6 +126,25 @@ namespace Core.Models
             return EvaluateFilter(manager, Condition, context);
         }
 
+        /// <summary>
+        /// Unlike filters, a condition that cannot be evaluated (missing data) is an error
+        /// rather than a skipped instance
+        /// </summary>
+        private bool EvaluateExistsCondition(ModelManager manager, Dictionary<string, object> context)
+        {
+            if (ExistsCondition == null)
+                return true;
+
+            try
+            {
+                return ExistenceConditions.Holds(manager, SubstituteIterators(ExistsCondition, context, manager));
+            }
+            catch (InvalidOperationException ex)
+            {
+                throw new InvalidOperationException($"exists if {ExistsCondition}: {ex.Message}", ex);
+            }
+        }
+
         private bool EvaluateFilter(ModelManager manager, Expression filter, Dictionary<string, object> context)
         {
             try
@@ -192,6 +248,17 @@ namespace Core.Models
             {
                 return strConst.Value;
             }
+            else if (expr is OrderedSetExpression ordered)
+            {
+                try
+                {
+                    return ordered.Value(manager);
+                }
+                catch (InvalidOperationException)
+                {
+                    return null;
+                }
+            }
 
             try
             {
@@ -239,6 +306,19 @@ namespace Core.Models
             string leftExpr = SubstituteIterators(ConstraintTemplate.LeftSide.ToString(), context, manager);
             string rightExpr = SubstituteIterators(ConstraintTemplate.RightSide.ToString(), context, manager);
 
+            // Lead/lag calls past the end of their set and windows that do not fit under the
+            // skip policy leave the instance out
+            string? leftResolved = Windows.Resolve(manager, leftExpr);
+            string? rightResolved = Windows.Resolve(manager, rightExpr);
+            if (leftResolved == null || rightResolved == null)
+                return null;
+            leftResolved = OrderedSets.Resolve(manager, leftResolved);
+            rightResolved = OrderedSets.Resolve(manager, rightResolved);
+            if (leftResolved == null || rightResolved == null)
+                return null;
+            leftExpr = leftResolved;
+            rightExpr = rightResolved;
+
             // Parse as equation
             var parser = new EquationParser(manager);
             string equationStr = $"{leftExpr} {OperatorToString(ConstraintTemplate.Operator)} {rightExpr}";
@@ -329,6 +409,14 @@ namespace Core.Models
             };
         }
 
+        /// <summary>
+        /// An iterator value as written in a binding: numbers invariant, strings without quotes
+        /// </summary>
+        public static string FormatValue(object value)
+        {
+            return Convert.ToString(value, System.Globalization.CultureInfo.InvariantCulture) ?? "";
+        }
+
         private List<object> GetIteratorRange(ModelManager manager, ForallIterator iterator)
         {
             var range = new List<object>();
@@ -362,6 +450,22 @@ namespace Core.Models
             return range;
         }
 
+        /// <summary>
+        /// Number of values of an iterator, without enumerating ranges
+        /// </summary>
+        private long GetIteratorSize(ModelManager manager, ForallIterator iterator)
+        {
+            string setName = iterator.Range.SetName ?? "";
+            if (!manager.TupleSets.ContainsKey(setName) && !manager.ComputedSets.ContainsKey(setName))
+            {
+                if (manager.IndexSets.TryGetValue(setName, out var indexSet))
+                    return Math.Max(0L, (long)indexSet.EndIndex - indexSet.StartIndex + 1);
+                if (manager.Ranges.TryGetValue(setName, out var oplRange))
+                    return Math.Max(0L, (long)oplRange.GetEnd(manager) - oplRange.GetStart(manager) + 1);
+            }
+            return GetIteratorRange(manager, iterator).Count;
+        }
+
         private void SetTemporaryParameter(ModelManager manager, string name, object value)
         {
             ParameterType type = value switch
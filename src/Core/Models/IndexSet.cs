@@ -9,6 +9,11 @@
         public int StartIndex { get; set; }
         public int EndIndex { get; set; }
 
+        /// <summary>
+        /// Docstring of the set declaration
+        /// </summary>
+        public string? Description { get; set; }
+
         
 
         public IndexSet(string name, int startIndex, int endIndex)
@@ -60,6 +65,11 @@
             return StartIndex + position;
         }
 
+        public IndexSet Clone()
+        {
+            return new IndexSet(Name, StartIndex, EndIndex) { Description = Description };
+        }
+
         public override string ToString()
         {
             return $"{Name} = {StartIndex}..{EndIndex}";
@@ -12,6 +12,47 @@
         public double? LowerBound { get; set; }
         public double? UpperBound { get; set; }
 
+        /// <summary>
+        /// Bounds written as expressions over parameters ("0..capacity[p] * availability[p,t]"),
+        /// kept as written and evaluated when the model is expanded (see BoundExpressions)
+        /// </summary>
+        public string? LowerBoundExpression { get; set; }
+        public string? UpperBoundExpression { get; set; }
+
+        /// <summary>
+        /// Iterator names of the declaration's index sets ("p", "t" of x[p in P, t in T]), or
+        /// null if the declaration names only the sets
+        /// </summary>
+        public List<string>? IndexIterators { get; set; }
+
+        /// <summary>
+        /// Bounds of single elements by expanded name (x1_2), from bound expressions over the
+        /// iterators; elements not listed have the family's bounds
+        /// </summary>
+        public Dictionary<string, (double? Lower, double? Upper)> ElementBounds { get; } =
+            new Dictionary<string, (double? Lower, double? Upper)>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Existence condition over parameters ("has_storage[p]"); elements for which it does
+        /// not hold are absent from the model (see ExistenceConditions)
+        /// </summary>
+        public string? ExistsCondition { get; set; }
+
+        /// <summary>
+        /// Expanded names of the elements whose existence condition does not hold
+        /// </summary>
+        public HashSet<string> AbsentElements { get; } = new HashSet<string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Docstring of the dvar declaration
+        /// </summary>
+        public string? Description { get; set; }
+
+        /// <summary>
+        /// Unit of measure from a "// @unit" annotation, e.g. "MW" or "EUR/MWh"
+        /// </summary>
+        public string? Unit { get; set; }
+
         /// <summary>
         /// Additional index sets for 3D+ variables (beyond the first two dimensions)
         /// </summary>
@@ -42,6 +83,39 @@
         /// </summary>
         public bool HasBounds => LowerBound.HasValue || UpperBound.HasValue;
 
+        public bool HasBoundExpressions => LowerBoundExpression != null || UpperBoundExpression != null;
+
+        /// <summary>
+        /// Index sets in declaration order
+        /// </summary>
+        public IReadOnlyList<string> IndexSetNames
+        {
+            get
+            {
+                var sets = new List<string>();
+                if (!IsScalar)
+                    sets.Add(IndexSetName);
+                if (IsTwoDimensional)
+                    sets.Add(SecondIndexSetName!);
+                if (AdditionalIndexSets != null)
+                    sets.AddRange(AdditionalIndexSets);
+                return sets;
+            }
+        }
+
+        /// <summary>
+        /// Bounds of one element of the family (expanded name, e.g. x1_2)
+        /// </summary>
+        public (double? Lower, double? Upper) GetBounds(string expandedName)
+        {
+            return ElementBounds.TryGetValue(expandedName, out var bounds) ? bounds : (LowerBound, UpperBound);
+        }
+
+        /// <summary>
+        /// False for elements whose existence condition does not hold
+        /// </summary>
+        public bool Exists(string expandedName) => !AbsentElements.Contains(expandedName);
+
         /// <summary>
         /// Non-null for semi-continuous variables: the variable is either 0 or within one of these ranges.
         /// OPL: dvar float+ x in 0..0 | 10..20;
@@ -74,13 +148,16 @@
             else
                 varDecl = $"{Type} {BaseName}[{IndexSetName}]";
 
-            if (HasBounds)
+            if (HasBounds || HasBoundExpressions)
             {
-                string lower = LowerBound?.ToString() ?? "-∞";
-                string upper = UpperBound?.ToString() ?? "∞";
+                string lower = LowerBoundExpression ?? LowerBound?.ToString() ?? "-∞";
+                string upper = UpperBoundExpression ?? UpperBound?.ToString() ?? "∞";
                 varDecl += $" in {lower}..{upper}";
             }
 
+            if (ExistsCondition != null)
+                varDecl += $" exists if {ExistsCondition}";
+
             return varDecl;
         }
     }
@@ -42,6 +42,11 @@
         /// </summary>
         public int? SecondIndex { get; set; }
 
+        /// <summary>
+        /// The constraint rule (forall statement) that generated this equation, if any
+        /// </summary>
+        public ForallStatement? Rule { get; set; }
+
         public LinearEquation()
         {
             Coefficients = new Dictionary<string, Expression>();
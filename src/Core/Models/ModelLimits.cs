@@ -0,0 +1,185 @@
+using System.Globalization;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Thrown when a model grows beyond one of the manager's ModelLimits
+    /// </summary>
+    public class ModelLimitException : InvalidOperationException
+    {
+        public ModelLimitException(string limit, long maximum, long actual, string message)
+            : base(message)
+        {
+            Limit = limit;
+            Maximum = maximum;
+            Actual = actual;
+        }
+
+        /// <summary>
+        /// Name of the exceeded limit (MaxEntities, MaxExpressionDepth, MaxFileSize, MaxMemoryBytes)
+        /// </summary>
+        public string Limit { get; }
+        public long Maximum { get; }
+        public long Actual { get; }
+    }
+
+    /// <summary>
+    /// Guardrails on the size of a model, checked while parsing and when entities are added
+    /// programmatically. Default only limits expression depth (deeper trees overflow the stack
+    /// of the recursive evaluators and formatters); Shared suits a server where one broken or
+    /// hostile model must not take the process down.
+    /// </summary>
+    public class ModelLimits
+    {
+        /// <summary>
+        /// Most declared entities (parameters, sets, variable families, constraints, ...) in a model
+        /// </summary>
+        public int MaxEntities { get; init; } = int.MaxValue;
+
+        /// <summary>
+        /// Deepest nesting of an expression: brackets in model text, operators in a built expression tree
+        /// </summary>
+        public int MaxExpressionDepth { get; init; } = 500;
+
+        /// <summary>
+        /// Longest model or data text, in characters
+        /// </summary>
+        public long MaxFileSize { get; init; } = long.MaxValue;
+
+        /// <summary>
+        /// Largest estimated memory footprint of the model (see EstimateMemory)
+        /// </summary>
+        public long MaxMemoryBytes { get; init; } = long.MaxValue;
+
+        public static ModelLimits Default { get; } = new ModelLimits();
+
+        public static ModelLimits Shared { get; } = new ModelLimits
+        {
+            MaxEntities = 2_000_000,
+            MaxExpressionDepth = 200,
+            MaxFileSize = 32L * 1024 * 1024,
+            MaxMemoryBytes = 1024L * 1024 * 1024
+        };
+
+        public void CheckFileSize(string text, string what)
+        {
+            if (text.Length > MaxFileSize)
+                throw new ModelLimitException(nameof(MaxFileSize), MaxFileSize, text.Length,
+                    $"{what} is {FormatBytes(text.Length)}, more than the limit of {FormatBytes(MaxFileSize)}");
+        }
+
+        /// <summary>
+        /// Throws if the bracket nesting of a statement's text is deeper than MaxExpressionDepth
+        /// </summary>
+        public void CheckNesting(string text)
+        {
+            int depth = 0, deepest = 0;
+            foreach (char c in text)
+            {
+                if (c is '(' or '[' or '{')
+                    deepest = Math.Max(deepest, ++depth);
+                else if (c is ')' or ']' or '}')
+                    depth--;
+            }
+
+            if (deepest > MaxExpressionDepth)
+                throw new ModelLimitException(nameof(MaxExpressionDepth), MaxExpressionDepth, deepest,
+                    $"Expression nests {deepest} levels deep, more than the limit of {MaxExpressionDepth}");
+        }
+
+        /// <summary>
+        /// Throws if the expression tree is deeper than MaxExpressionDepth
+        /// </summary>
+        public void CheckDepth(Expression expression, string context)
+        {
+            int depth = Depth(expression, MaxExpressionDepth + 1);
+            if (depth > MaxExpressionDepth)
+                throw new ModelLimitException(nameof(MaxExpressionDepth), MaxExpressionDepth, depth,
+                    $"{context} nests more than {MaxExpressionDepth} levels deep");
+        }
+
+        /// <summary>
+        /// Throws if adding one more entity would exceed MaxEntities
+        /// </summary>
+        public void CheckEntityCount(ModelManager manager)
+        {
+            long count = CountEntities(manager);
+            if (count >= MaxEntities)
+                throw new ModelLimitException(nameof(MaxEntities), MaxEntities, count + 1,
+                    $"Model would have more than {MaxEntities} entities");
+        }
+
+        public void CheckMemory(ModelManager manager)
+        {
+            if (MaxMemoryBytes == long.MaxValue)
+                return;
+
+            long estimate = EstimateMemory(manager);
+            if (estimate > MaxMemoryBytes)
+                throw new ModelLimitException(nameof(MaxMemoryBytes), MaxMemoryBytes, estimate,
+                    $"Model needs about {FormatBytes(estimate)}, more than the limit of {FormatBytes(MaxMemoryBytes)}");
+        }
+
+        public static long CountEntities(ModelManager manager)
+        {
+            return (long)manager.Parameters.Count + manager.IndexSets.Count + manager.Ranges.Count
+                   + manager.TupleSchemas.Count + manager.TupleSets.Count + manager.PrimitiveSets.Count
+                   + manager.ComputedSets.Count + manager.TupleParameters.Count + manager.IndexedVariables.Count
+                   + manager.DecisionExpressions.Count + manager.IndexedEquationTemplates.Count
+                   + manager.ForallStatements.Count + manager.PendingRules.Count + manager.Equations.Count + manager.Assertions.Count;
+        }
+
+        /// <summary>
+        /// Rough footprint of the model's data in bytes: a fixed cost per entity plus the
+        /// stored values, tuples and constraint terms. Meant for admission control, not accounting.
+        /// </summary>
+        public static long EstimateMemory(ModelManager manager)
+        {
+            const long entityBytes = 256, valueBytes = 64, termBytes = 96;
+
+            long bytes = CountEntities(manager) * entityBytes;
+            bytes += manager.Parameters.Values.Sum(p => (long)p.ValueCount) * valueBytes;
+            bytes += manager.TupleSets.Values.Sum(s => (long)s.Count) * entityBytes;
+            bytes += manager.Equations.Sum(e => (long)e.Coefficients.Count) * termBytes;
+            return bytes;
+        }
+
+        /// <summary>
+        /// Depth of the tree, counted without recursion and up to the cap
+        /// </summary>
+        private static int Depth(Expression expression, int cap)
+        {
+            int deepest = 0;
+            var pending = new Stack<(Expression Node, int Depth)>();
+            pending.Push((expression, 1));
+            while (pending.Count > 0)
+            {
+                var (node, depth) = pending.Pop();
+                deepest = Math.Max(deepest, depth);
+                if (deepest >= cap)
+                    return deepest;
+
+                switch (node)
+                {
+                    case BinaryExpression binary:
+                        pending.Push((binary.Left, depth + 1));
+                        pending.Push((binary.Right, depth + 1));
+                        break;
+                    case UnaryExpression unary:
+                        pending.Push((unary.Operand, depth + 1));
+                        break;
+                }
+            }
+            return deepest;
+        }
+
+        private static string FormatBytes(long bytes)
+        {
+            if (bytes >= 1024 * 1024)
+                return (bytes / (1024.0 * 1024)).ToString("0.#", CultureInfo.InvariantCulture) + " MB";
+            if (bytes >= 1024)
+                return (bytes / 1024.0).ToString("0.#", CultureInfo.InvariantCulture) + " KB";
+            return bytes.ToString(CultureInfo.InvariantCulture) + " bytes";
+        }
+    }
+}
@@ -0,0 +1,30 @@
+namespace Core.Models
+{
+    /// <summary>
+    /// Interned strings addressed by dense integer ids. Variable names and the variable
+    /// references in constraint rows share one table, so each name is stored once.
+    /// Used by the column tables of LinearModel and by ConstraintTable.
+    /// </summary>
+    public class NameTable
+    {
+        private readonly List<string> names = new List<string>();
+        private readonly Dictionary<string, int> ids = new Dictionary<string, int>(StringComparer.Ordinal);
+
+        public int Count => names.Count;
+
+        public string this[int id] => names[id];
+
+        public int Intern(string name)
+        {
+            if (!ids.TryGetValue(name, out int id))
+            {
+                id = names.Count;
+                names.Add(name);
+                ids.Add(name, id);
+            }
+            return id;
+        }
+
+        public bool TryGetId(string name, out int id) => ids.TryGetValue(name, out id);
+    }
+}
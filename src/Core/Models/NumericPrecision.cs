@@ -0,0 +1,208 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Solving;
+
+namespace Core.Models
+{
+    public enum NumericType
+    {
+        /// <summary>
+        /// IEEE binary64, the solvers' native type (default)
+        /// </summary>
+        Float64,
+
+        /// <summary>
+        /// Fixed-point decimal: exact arithmetic with every intermediate result rounded to Scale places
+        /// </summary>
+        Decimal,
+
+        /// <summary>
+        /// Exact rational arithmetic
+        /// </summary>
+        Rational
+    }
+
+    /// <summary>
+    /// Arithmetic used to evaluate the coefficients of a constraint family or the objective.
+    /// Set per model (ModelManager.NumericPrecision) or per entity with an annotation:
+    /// <code>
+    /// // @numeric default decimal(4)
+    /// // @numeric rational
+    /// forall(a in Accounts) balance: ...;
+    /// </code>
+    /// The first line sets the model default, the second applies to the declaration it precedes.
+    /// </summary>
+    public class NumericPrecision
+    {
+        public const int DefaultScale = 10;
+
+        private static readonly Regex specPattern = new Regex(@"^(float64|float|double|decimal|rational)\s*(?:\(\s*(\d+)\s*\))?$", RegexOptions.IgnoreCase);
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@numeric[ \t]+(default[ \t]+)?(.*?)[ \t]*$", RegexOptions.Multiline);
+
+        public NumericType Type { get; init; }
+
+        /// <summary>
+        /// Decimal places of a fixed-point decimal
+        /// </summary>
+        public int Scale { get; init; } = DefaultScale;
+
+        public static readonly NumericPrecision Float64 = new NumericPrecision { Type = NumericType.Float64 };
+
+        public bool IsExact => Type != NumericType.Float64;
+
+        /// <summary>
+        /// Parses "float64", "decimal", "decimal(4)" or "rational"
+        /// </summary>
+        public static NumericPrecision Parse(string text)
+        {
+            var m = specPattern.Match(text.Trim());
+            if (!m.Success)
+                throw new InvalidOperationException($"Unknown numeric type '{text.Trim()}' (expected float64, decimal(scale) or rational)");
+
+            var type = m.Groups[1].Value.ToLowerInvariant() switch
+            {
+                "decimal" => NumericType.Decimal,
+                "rational" => NumericType.Rational,
+                _ => NumericType.Float64
+            };
+            if (m.Groups[2].Success && type != NumericType.Decimal)
+                throw new InvalidOperationException($"Only decimal takes a scale: '{text.Trim()}'");
+
+            return new NumericPrecision
+            {
+                Type = type,
+                Scale = m.Groups[2].Success ? int.Parse(m.Groups[2].Value, CultureInfo.InvariantCulture) : DefaultScale
+            };
+        }
+
+        /// <summary>
+        /// Applies the precision to an intermediate result (rounds fixed-point decimals)
+        /// </summary>
+        public ExactNumber Normalize(ExactNumber value) => Type == NumericType.Decimal ? value.Round(Scale) : value;
+
+        /// <summary>
+        /// Reads the @numeric annotations of a model text into the manager, reporting those
+        /// that do not parse as errors
+        /// </summary>
+        public static void ReadAnnotations(ModelManager manager, string modelText, ParseSessionResult result)
+        {
+            if (!modelText.Contains("@numeric"))
+                return;
+
+            foreach (var statement in ModelSource.Parse(modelText).Statements)
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                foreach (Match m in annotationPattern.Matches(trivia))
+                {
+                    NumericPrecision precision;
+                    try
+                    {
+                        precision = Parse(m.Groups[2].Value);
+                    }
+                    catch (InvalidOperationException ex)
+                    {
+                        result.AddError(ex.Message, statement.LineNumber);
+                        continue;
+                    }
+
+                    if (m.Groups[1].Success)
+                        manager.NumericPrecision = precision;
+                    else if (statement.Key != null)
+                        manager.EntityPrecision[statement.Key] = precision;
+                    else
+                        result.AddError("@numeric must precede a named declaration", statement.LineNumber);
+                }
+            }
+        }
+
+        public override string ToString() => Type switch
+        {
+            NumericType.Decimal => $"decimal({Scale})",
+            NumericType.Rational => "rational",
+            _ => "float64"
+        };
+    }
+
+    /// <summary>
+    /// Evaluates coefficients in the precision of their entity. Arithmetic (+ - * /) on
+    /// constants and parameters is done exactly; every other expression is evaluated as a
+    /// double and taken at its shortest decimal value.
+    /// </summary>
+    public static class NumericEvaluator
+    {
+        /// <summary>
+        /// Precision of an expanded constraint row: that of its family, else the model's
+        /// </summary>
+        public static NumericPrecision PrecisionOf(ModelManager manager, LinearEquation equation)
+        {
+            string key = EntityCatalog.KeyOf(EntityKind.Constraint, SolutionComparison.GetFamily(equation));
+            return manager.EntityPrecision.TryGetValue(key, out var precision) ? precision : manager.NumericPrecision;
+        }
+
+        public static NumericPrecision PrecisionOfObjective(ModelManager manager)
+        {
+            return manager.EntityPrecision.TryGetValue(EntityCatalog.KeyOf(EntityKind.Objective, ""), out var precision)
+                ? precision
+                : manager.NumericPrecision;
+        }
+
+        /// <summary>
+        /// True if any entity is evaluated in exact arithmetic
+        /// </summary>
+        public static bool HasExactEntities(ModelManager manager) =>
+            manager.NumericPrecision.IsExact || manager.EntityPrecision.Values.Any(p => p.IsExact);
+
+        public static ExactNumber Evaluate(Expression expression, ModelManager manager, NumericPrecision precision)
+        {
+            if (!precision.IsExact)
+                return ExactNumber.FromDouble(expression.Evaluate(manager));
+            return precision.Normalize(Exact(expression, manager, precision));
+        }
+
+        public static (Dictionary<string, ExactNumber> Coefficients, ExactNumber Constant) Evaluate(LinearEquation equation, ModelManager manager)
+        {
+            var precision = PrecisionOf(manager, equation);
+            var coefficients = equation.Coefficients.ToDictionary(c => c.Key, c => Evaluate(c.Value, manager, precision));
+            return (coefficients, Evaluate(equation.Constant, manager, precision));
+        }
+
+        /// <summary>
+        /// Converts to double for a binary64 target, warning if the value changes
+        /// </summary>
+        public static double ToDouble(ExactNumber value, string context, ICollection<string> warnings)
+        {
+            double result = value.ToDouble();
+            if (!value.IsExactDouble)
+                warnings.Add($"Precision loss: {context} is {value}, written as {result.ToString("R", CultureInfo.InvariantCulture)}");
+            return result;
+        }
+
+        private static ExactNumber Exact(Expression expression, ModelManager manager, NumericPrecision precision)
+        {
+            switch (expression)
+            {
+                case BinaryExpression binary when binary.Operator is BinaryOperator.Add or BinaryOperator.Subtract
+                                                  or BinaryOperator.Multiply or BinaryOperator.Divide:
+                    var left = Exact(binary.Left, manager, precision);
+                    var right = Exact(binary.Right, manager, precision);
+                    return precision.Normalize(binary.Operator switch
+                    {
+                        BinaryOperator.Add => left + right,
+                        BinaryOperator.Subtract => left - right,
+                        BinaryOperator.Multiply => left * right,
+                        _ => right.IsZero
+                            ? throw new InvalidOperationException($"Division by zero in {binary}")
+                            : left / right
+                    });
+
+                case UnaryExpression { Operator: UnaryOperator.Negate } unary:
+                    return -Exact(unary.Operand, manager, precision);
+
+                default:
+                    return ExactNumber.FromDouble(expression.Evaluate(manager));
+            }
+        }
+    }
+}
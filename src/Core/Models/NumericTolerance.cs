@@ -0,0 +1,81 @@
+using System.Globalization;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Absolute and relative tolerance for comparing computed values. Two finite values are
+    /// equal when they differ by at most Absolute, or by at most Relative times the larger
+    /// magnitude; infinities are only equal to themselves and NaN to nothing.
+    /// Default is used for solutions (validation, checking, diffing); Evaluation for the
+    /// comparisons inside model expressions (==, &lt;=, filters).
+    /// </summary>
+    public class NumericTolerance
+    {
+        public const double DefaultAbsolute = 1e-6;
+        public const double DefaultRelative = 1e-9;
+
+        public double Absolute { get; init; } = DefaultAbsolute;
+        public double Relative { get; init; } = DefaultRelative;
+
+        public static NumericTolerance Default { get; } = new NumericTolerance();
+
+        /// <summary>
+        /// Tolerance of comparisons in model expressions: only absorbs rounding error
+        /// </summary>
+        public static NumericTolerance Evaluation { get; } = new NumericTolerance { Absolute = 1e-10, Relative = 0 };
+
+        public bool AreEqual(double a, double b)
+        {
+            if (a == b)
+                return true;
+            if (double.IsNaN(a) || double.IsNaN(b) || double.IsInfinity(a) || double.IsInfinity(b))
+                return false;
+
+            double diff = Math.Abs(a - b);
+            return diff <= Absolute || diff <= Relative * Math.Max(Math.Abs(a), Math.Abs(b));
+        }
+
+        public bool IsZero(double value) => Math.Abs(value) <= Absolute;
+
+        /// <summary>
+        /// 0 if the values are equal within the tolerance, otherwise the sign of a - b
+        /// </summary>
+        public int Compare(double a, double b) => AreEqual(a, b) ? 0 : a.CompareTo(b);
+
+        public bool IsLess(double a, double b) => Compare(a, b) < 0;
+        public bool IsGreater(double a, double b) => Compare(a, b) > 0;
+        public bool IsLessOrEqual(double a, double b) => Compare(a, b) <= 0;
+        public bool IsGreaterOrEqual(double a, double b) => Compare(a, b) >= 0;
+
+        /// <summary>
+        /// True if the value is within Absolute of an integer (the relative part is not used,
+        /// or every large value would count as integral)
+        /// </summary>
+        public bool IsIntegral(double value) =>
+            !double.IsNaN(value) && !double.IsInfinity(value) && Math.Abs(value - Math.Round(value)) <= Absolute;
+
+        /// <summary>
+        /// Smallest integer not below the value, treating values within Absolute of an integer as that integer
+        /// </summary>
+        public double Ceiling(double value) => IsIntegral(value) ? Math.Round(value) : Math.Ceiling(value);
+
+        public double Floor(double value) => IsIntegral(value) ? Math.Round(value) : Math.Floor(value);
+
+        /// <summary>
+        /// Sorted values with those equal (within the tolerance) to a smaller kept value removed
+        /// </summary>
+        public List<double> Distinct(IEnumerable<double> values)
+        {
+            var result = new List<double>();
+            foreach (var value in values.Where(v => !double.IsNaN(v)).OrderBy(v => v))
+            {
+                if (result.Count == 0 || !AreEqual(result[^1], value))
+                    result.Add(value);
+            }
+            return result;
+        }
+
+        public override string ToString() =>
+            $"abs {Absolute.ToString("G", CultureInfo.InvariantCulture)}, rel {Relative.ToString("G", CultureInfo.InvariantCulture)}";
+    }
+}
@@ -12,6 +12,11 @@ namespace Core.Models
         public string Name { get; set; }
         public Expression StartExpression { get; set; }
         public Expression EndExpression { get; set; }
+
+        /// <summary>
+        /// Docstring of the range declaration
+        /// </summary>
+        public string? Description { get; set; }
         
         // Cached evaluated values
         private int? cachedStart;
@@ -95,6 +100,14 @@ namespace Core.Models
             cachedEnd = null;
         }
         
+        /// <summary>
+        /// Copy of the declaration without the cached bounds, which belong to the model that evaluated them
+        /// </summary>
+        public OplRange Clone()
+        {
+            return new OplRange(Name, StartExpression, EndExpression) { Description = Description };
+        }
+        
         public override string ToString()
         {
             return $"range {Name} = {StartExpression}..{EndExpression}";
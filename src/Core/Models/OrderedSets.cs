@@ -0,0 +1,369 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// Elements of ordered sets and the lead/lag calls over them (see OrderedSetExpression).
+    /// Time coupling written with the operators follows the order of the set:
+    /// <code>
+    /// {int} T = {1, 2, 5, 10};
+    /// forall(t in T) balance: level[t] == level[prev(T, t, initial = level0)] + inflow[t];
+    /// forall(t in T) ramp: x[next(T, t)] - x[t] &lt;= 5;
+    /// </code>
+    /// Constraints are expanded as text, so the calls are resolved there: each becomes the
+    /// element it denotes (prev(T, 5) is 2, not 4). Past the ends of the set the policy decides:
+    /// skip leaves the constraint instance out, wrap and clamp give another element, and initial
+    /// replaces the whole reference (level[prev(T, 1)]) by its initial-condition expression.
+    /// </summary>
+    public static class OrderedSets
+    {
+        private static readonly Regex CallPattern = new Regex(@"\b(prevc|nextc|prev|next|ord|first|last)\s*\(");
+
+        private static readonly Regex IdentifierPattern = new Regex(@"^[a-zA-Z_][a-zA-Z0-9_]*$");
+
+        /// <summary>
+        /// Elements of a range, index set, primitive set or computed set, in order, or of a
+        /// window over one (empty where the skip policy leaves it out)
+        /// </summary>
+        public static List<object> Elements(ModelManager manager, string setName)
+        {
+            if (Windows.TryParse(setName, out var window, out string error))
+                return window != null ? Windows.Elements(manager, window) ?? new List<object>() : throw new InvalidOperationException(error);
+            if (manager.Ranges.TryGetValue(setName, out var range))
+                return range.GetValues(manager).Cast<object>().ToList();
+            if (manager.IndexSets.TryGetValue(setName, out var indexSet))
+                return indexSet.GetIndices().Cast<object>().ToList();
+            if (manager.PrimitiveSets.TryGetValue(setName, out var primitiveSet))
+                return primitiveSet.GetAllValues().ToList();
+            if (manager.ComputedSets.TryGetValue(setName, out var computedSet) && computedSet.Evaluate(manager) is IEnumerable<object> computed)
+                return computed.ToList();
+            if (manager.TupleSets.ContainsKey(setName))
+                throw new InvalidOperationException($"'{setName}' is a tuple set; lead/lag operators need a set of values");
+
+            throw new InvalidOperationException($"'{setName}' is not a declared set");
+        }
+
+        /// <summary>
+        /// Position of an element (0-based), comparing numbers by value and timestamps in any
+        /// ISO 8601 spelling; -1 if it is not a member
+        /// </summary>
+        public static int IndexOf(List<object> elements, object element)
+        {
+            for (int i = 0; i < elements.Count; i++)
+            {
+                if (Same(elements[i], element))
+                    return i;
+            }
+            return -1;
+        }
+
+        private static bool Same(object member, object element)
+        {
+            if (member is string text)
+            {
+                if (element is not string other)
+                    return false;
+                return text == other
+                    || (PrimitiveSet.TryParseTimestamp(other, out string timestamp) && text == timestamp);
+            }
+            return element is not string && Convert.ToDouble(member, CultureInfo.InvariantCulture) == Convert.ToDouble(element, CultureInfo.InvariantCulture);
+        }
+
+        /// <summary>
+        /// The element <paramref name="offset"/> positions from <paramref name="position"/>, or
+        /// null past the ends of the set under the skip and initial policies
+        /// </summary>
+        public static object? Shift(List<object> elements, int position, int offset, BoundaryPolicy policy)
+        {
+            int target = position + offset;
+            if (target >= 0 && target < elements.Count)
+                return elements[target];
+
+            return policy switch
+            {
+                BoundaryPolicy.Wrap => elements[((target % elements.Count) + elements.Count) % elements.Count],
+                BoundaryPolicy.Clamp => elements[target < 0 ? 0 : elements.Count - 1],
+                _ => null
+            };
+        }
+
+        /// <summary>
+        /// Value of the element argument of a call: a string iterator or literal, otherwise a number
+        /// </summary>
+        public static object ElementValue(ModelManager manager, Expression element)
+        {
+            switch (element)
+            {
+                case StringConstantExpression text:
+                    return text.Value;
+                case ParameterExpression parameter when manager.Parameters.TryGetValue(parameter.ParameterName, out var value) && value.Value is string name:
+                    return name;
+                case OrderedSetExpression call:
+                    return call.Value(manager);
+                default:
+                    return element.Evaluate(manager);
+            }
+        }
+
+        /// <summary>
+        /// An element as written in a model: numbers invariant, strings quoted
+        /// </summary>
+        public static string Format(object element)
+        {
+            return element switch
+            {
+                string text => $"\"{text}\"",
+                double number => number.ToString("R", CultureInfo.InvariantCulture),
+                _ => Convert.ToString(element, CultureInfo.InvariantCulture) ?? ""
+            };
+        }
+
+        /// <summary>
+        /// Parses a whole expression that is one lead/lag or position call; false if it is not
+        /// one, with <paramref name="error"/> set if it is one but malformed
+        /// </summary>
+        public static bool TryParse(string text, Func<string, Expression> parseElement, out OrderedSetExpression? call, out string error)
+        {
+            call = null;
+            error = string.Empty;
+            text = text.Trim();
+
+            var match = CallPattern.Match(text);
+            if (!match.Success || match.Index != 0 || Close(text, match.Length - 1) != text.Length - 1)
+                return false;
+
+            string name = match.Groups[1].Value;
+            var arguments = SplitArguments(text.Substring(match.Length, text.Length - match.Length - 1));
+            if (arguments.Count == 0 || !IdentifierPattern.IsMatch(arguments[0]))
+            {
+                error = $"{name}() expects a set name as its first argument";
+                return false;
+            }
+
+            var result = new OrderedSetExpression { SetName = arguments[0] };
+            switch (name)
+            {
+                case "first":
+                case "last":
+                    if (arguments.Count != 1)
+                    {
+                        error = $"{name}() expects 1 argument ({name}(Set)), got {arguments.Count}";
+                        return false;
+                    }
+                    result.Function = name == "first" ? OrderedSetFunction.First : OrderedSetFunction.Last;
+                    call = result;
+                    return true;
+
+                case "ord":
+                    if (arguments.Count != 2)
+                    {
+                        error = $"ord() expects 2 arguments (ord(Set, element)), got {arguments.Count}";
+                        return false;
+                    }
+                    result.Function = OrderedSetFunction.Ord;
+                    result.Element = ParseElement(arguments[1], parseElement);
+                    call = result;
+                    return true;
+            }
+
+            if (arguments.Count < 2)
+            {
+                error = $"{name}() expects the set and an element ({name}(Set, element))";
+                return false;
+            }
+
+            result.Function = name.StartsWith("prev", StringComparison.Ordinal) ? OrderedSetFunction.Prev : OrderedSetFunction.Next;
+            result.Element = ParseElement(arguments[1], parseElement);
+            bool circular = name.EndsWith("c", StringComparison.Ordinal);
+            if (circular)
+                result.Policy = BoundaryPolicy.Wrap;
+
+            foreach (string argument in arguments.Skip(2))
+            {
+                if (int.TryParse(argument, NumberStyles.Integer, CultureInfo.InvariantCulture, out int offset) && offset > 0)
+                {
+                    result.Offset = offset;
+                }
+                else if (!circular && argument is "skip" or "wrap" or "clamp")
+                {
+                    result.Policy = argument == "skip" ? BoundaryPolicy.Skip : argument == "wrap" ? BoundaryPolicy.Wrap : BoundaryPolicy.Clamp;
+                }
+                else if (!circular && Regex.Match(argument, @"^initial\s*=\s*(.+)$", RegexOptions.Singleline) is { Success: true } initial)
+                {
+                    result.Policy = BoundaryPolicy.Initial;
+                    result.Initial = initial.Groups[1].Value.Trim();
+                }
+                else
+                {
+                    error = $"{name}(): '{argument}' is neither a positive offset nor a boundary policy (skip, wrap, clamp, initial = expression)";
+                    return false;
+                }
+            }
+
+            call = result;
+            return true;
+        }
+
+        /// <summary>
+        /// Replaces the lead/lag and position calls of an expanded constraint by the elements
+        /// they denote. Calls over iterators that are not bound yet (those of a sum) are left for
+        /// a later pass. Returns null if the instance is skipped; throws InvalidOperationException
+        /// if a call cannot be resolved.
+        /// </summary>
+        public static string? Resolve(ModelManager manager, string text)
+        {
+            int limit = text.Length;
+            while (true)
+            {
+                var match = CallPattern.Matches(text).LastOrDefault(m => m.Index < limit);
+                if (match == null)
+                    return text;
+
+                int close = Close(text, match.Index + match.Length - 1);
+                if (close < 0)
+                    throw new InvalidOperationException($"{match.Groups[1].Value}( is not closed");
+
+                string callText = text.Substring(match.Index, close - match.Index + 1);
+                if (!TryParse(callText, argument => ParseResolved(manager, argument), out var call, out string error))
+                    throw new InvalidOperationException(error);
+
+                if (call!.Element is ParameterExpression unbound && !manager.Parameters.ContainsKey(unbound.ParameterName))
+                {
+                    limit = match.Index;
+                    continue;
+                }
+
+                object? value = call.Resolve(manager);
+                if (value != null)
+                {
+                    text = text.Substring(0, match.Index) + Format(value) + text.Substring(close + 1);
+                    limit = match.Index;
+                }
+                else if (call.Policy == BoundaryPolicy.Initial)
+                {
+                    (int start, int end) = EnclosingReference(text, match.Index, callText);
+                    string initial = IdentifierPattern.IsMatch(call.Initial!) || Regex.IsMatch(call.Initial!, @"^[\w.]+(\[[^\]]*\])+$")
+                        ? call.Initial!
+                        : $"({call.Initial})";
+                    text = text.Substring(0, start) + initial + text.Substring(end + 1);
+                    limit = start;
+                }
+                else
+                {
+                    return null;
+                }
+            }
+        }
+
+        internal static Expression ParseResolved(ModelManager manager, string argument)
+        {
+            string text = argument.Trim();
+            if (text.Length >= 2 && text.StartsWith('"') && text.EndsWith('"'))
+                return new StringConstantExpression(text.Substring(1, text.Length - 2));
+            return new EquationParser(manager).ParseExpression(text);
+        }
+
+        private static Expression ParseElement(string argument, Func<string, Expression> parseElement)
+        {
+            string text = argument.Trim();
+            if (text.Length >= 2 && text.StartsWith('"') && text.EndsWith('"'))
+                return new StringConstantExpression(text.Substring(1, text.Length - 2));
+            return parseElement(text);
+        }
+
+        /// <summary>
+        /// Span of the reference whose index holds a call: level[prev(T, 1)], x[p][prev(T, 1)]
+        /// </summary>
+        private static (int Start, int End) EnclosingReference(string text, int callStart, string callText)
+        {
+            int open = -1;
+            int depth = 0;
+            for (int i = callStart - 1; i >= 0 && open < 0; i--)
+            {
+                if (text[i] == ']')
+                    depth++;
+                else if (text[i] == '[' && depth-- == 0)
+                    open = i;
+            }
+            if (open < 0)
+                throw new InvalidOperationException($"{callText}: an initial condition replaces the reference the call indexes, but it indexes none");
+
+            int start = open;
+            while (start > 0 && text[start - 1] == ']')
+                start = Open(text, start - 1);
+            while (start > 0 && (char.IsLetterOrDigit(text[start - 1]) || text[start - 1] == '_'))
+                start--;
+
+            int end = Close(text, open);
+            while (end + 1 < text.Length && text[end + 1] == '[')
+                end = Close(text, end + 1);
+            return (start, end);
+        }
+
+        /// <summary>
+        /// Position of the bracket closing the one at <paramref name="open"/>, or -1
+        /// </summary>
+        private static int Close(string text, int open)
+        {
+            char opening = text[open];
+            char closing = opening == '(' ? ')' : ']';
+            int depth = 0;
+            bool inQuotes = false;
+            for (int i = open; i < text.Length; i++)
+            {
+                char c = text[i];
+                if (c == '"')
+                    inQuotes = !inQuotes;
+                else if (inQuotes)
+                    continue;
+                else if (c == opening)
+                    depth++;
+                else if (c == closing && --depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        private static int Open(string text, int close)
+        {
+            int depth = 0;
+            for (int i = close; i >= 0; i--)
+            {
+                if (text[i] == ']')
+                    depth++;
+                else if (text[i] == '[' && --depth == 0)
+                    return i;
+            }
+            return 0;
+        }
+
+        private static List<string> SplitArguments(string text)
+        {
+            var arguments = new List<string>();
+            int depth = 0;
+            int start = 0;
+            bool inQuotes = false;
+            for (int i = 0; i < text.Length; i++)
+            {
+                char c = text[i];
+                if (c == '"')
+                    inQuotes = !inQuotes;
+                else if (inQuotes)
+                    continue;
+                else if (c is '(' or '[' or '<')
+                    depth++;
+                else if (c is ')' or ']' or '>')
+                    depth--;
+                else if (c == ',' && depth == 0)
+                {
+                    arguments.Add(text.Substring(start, i - start).Trim());
+                    start = i + 1;
+                }
+            }
+            if (text.Trim().Length > 0)
+                arguments.Add(text.Substring(start).Trim());
+            return arguments;
+        }
+    }
+}
@@ -10,6 +10,21 @@ namespace Core.Models
         public ParameterType Type { get; set; }
         public object? Value { get; set; }
         public bool IsExternal { get; set; }
+
+        /// <summary>
+        /// Docstring of the declaration
+        /// </summary>
+        public string? Description { get; set; }
+
+        /// <summary>
+        /// Unit of measure from a "// @unit" annotation, e.g. "MW" or "EUR/MWh"
+        /// </summary>
+        public string? Unit { get; set; }
+
+        /// <summary>
+        /// Currency code of a money parameter (from a // @money annotation), or null
+        /// </summary>
+        public string? Currency { get; set; }
         
         // UNIFIED: Always use IndexSetNames internally
         public List<string>? IndexSetNames { get; set; }
@@ -258,6 +273,41 @@ namespace Core.Models
             return GetMultiDimValue(indices.ToArray());
         }
 
+        /// <summary>
+        /// Number of stored indexed values
+        /// </summary>
+        public int ValueCount => (indexedValues?.Count ?? 0) + (multiDimValues?.Count ?? 0);
+
+        /// <summary>
+        /// Gets all stored indexed values keyed by their comma-separated indices (e.g. "1" or "2,3")
+        /// </summary>
+        public IEnumerable<KeyValuePair<string, object>> GetIndexedEntries()
+        {
+            if (indexedValues != null)
+            {
+                foreach (var kvp in indexedValues)
+                    yield return new KeyValuePair<string, object>(kvp.Key.ToString(), kvp.Value);
+            }
+
+            if (multiDimValues != null)
+            {
+                foreach (var kvp in multiDimValues)
+                    yield return kvp;
+            }
+        }
+
+        /// <summary>
+        /// Copy of the parameter with its own index set list and stored values
+        /// </summary>
+        public Parameter Clone()
+        {
+            var clone = (Parameter)MemberwiseClone();
+            clone.IndexSetNames = IndexSetNames != null ? new List<string>(IndexSetNames) : null;
+            clone.indexedValues = indexedValues != null ? new Dictionary<int, object>(indexedValues) : null;
+            clone.multiDimValues = multiDimValues != null ? new Dictionary<string, object>(multiDimValues) : null;
+            return clone;
+        }
+
         // Evaluate computed parameter
         public object? EvaluateComputed(ModelManager manager, int[] indices)
         {
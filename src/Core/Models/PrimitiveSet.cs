@@ -1,18 +1,27 @@
 namespace Core.Models
 {
     /// <summary>
-    /// Represents a set of primitive values (int, string, float) compatible with OPL syntax
+    /// Represents a set of primitive values (int, string, float, timestamp) compatible with OPL syntax
     /// Examples: {int} nodes = {1, 2, 3}; or {string} cities = ...;
+    /// Sets are ordered: elements keep the order they were added in, except timestamps, which
+    /// are kept in chronological order. Ord and Shift give the lead/lag of an element by position.
     /// </summary>
     public class PrimitiveSet
     {
         public string Name { get; }
         public PrimitiveSetType Type { get; }
         public bool IsExternal { get; }
+
+        /// <summary>
+        /// Docstring of the set declaration
+        /// </summary>
+        public string? Description { get; set; }
         
         private readonly HashSet<int> intValues = new HashSet<int>();
         private readonly HashSet<string> stringValues = new HashSet<string>();
         private readonly HashSet<double> floatValues = new HashSet<double>();
+        private readonly HashSet<string> timestampValues = new HashSet<string>(StringComparer.Ordinal);
+        private readonly List<object> ordered = new List<object>();
         
         /// <summary>
         /// Creates a primitive set
@@ -32,6 +41,7 @@ namespace Core.Models
             PrimitiveSetType.Int => intValues.Count,
             PrimitiveSetType.String => stringValues.Count,
             PrimitiveSetType.Float => floatValues.Count,
+            PrimitiveSetType.Timestamp => timestampValues.Count,
             _ => 0
         };
         
@@ -43,16 +53,47 @@ namespace Core.Models
             switch (Type)
             {
                 case PrimitiveSetType.Int:
-                    intValues.Add(Convert.ToInt32(value));
+                    int intVal = Convert.ToInt32(value);
+                    if (intValues.Add(intVal))
+                        ordered.Add(intVal);
                     break;
                 case PrimitiveSetType.String:
-                    stringValues.Add(value.ToString()!);
+                    string strVal = value.ToString()!;
+                    if (stringValues.Add(strVal))
+                        ordered.Add(strVal);
                     break;
                 case PrimitiveSetType.Float:
-                    floatValues.Add(Convert.ToDouble(value));
+                    double floatVal = Convert.ToDouble(value);
+                    if (floatValues.Add(floatVal))
+                        ordered.Add(floatVal);
+                    break;
+                case PrimitiveSetType.Timestamp:
+                    if (!TryParseTimestamp(value.ToString()!, out string timestamp))
+                        throw new FormatException($"'{value}' is not a timestamp (expected e.g. 2024-01-01T06:00)");
+                    if (timestampValues.Add(timestamp))
+                    {
+                        // Normalized timestamps sort chronologically as strings
+                        int position = ordered.FindIndex(v => string.CompareOrdinal((string)v, timestamp) > 0);
+                        ordered.Insert(position < 0 ? ordered.Count : position, timestamp);
+                    }
                     break;
             }
         }
+
+        /// <summary>
+        /// Reads an ISO 8601 date or date and time ("2024-01-01", "2024-01-01T06:00") as the
+        /// normalized element of a timestamp set ("2024-01-01T06:00:00")
+        /// </summary>
+        public static bool TryParseTimestamp(string text, out string timestamp)
+        {
+            timestamp = string.Empty;
+            if (!DateTime.TryParse(text.Trim().Trim('"'), System.Globalization.CultureInfo.InvariantCulture,
+                    System.Globalization.DateTimeStyles.AllowWhiteSpaces, out var value))
+                return false;
+
+            timestamp = value.ToString("s", System.Globalization.CultureInfo.InvariantCulture);
+            return true;
+        }
         
         /// <summary>
         /// Checks if a value exists in the set
@@ -64,9 +105,34 @@ namespace Core.Models
                 PrimitiveSetType.Int when value is int intVal => intValues.Contains(intVal),
                 PrimitiveSetType.String when value is string strVal => stringValues.Contains(strVal),
                 PrimitiveSetType.Float when value is double floatVal => floatValues.Contains(floatVal),
+                PrimitiveSetType.Timestamp when value is string text => TryParseTimestamp(text, out string timestamp) && timestampValues.Contains(timestamp),
                 _ => false
             };
         }
+
+        /// <summary>
+        /// Position of an element in the set (1-based, unlike ord() in models), or 0 if it is not a member
+        /// </summary>
+        public int Ord(object value)
+        {
+            if (Type == PrimitiveSetType.Timestamp && value is string text && TryParseTimestamp(text, out string timestamp))
+                value = timestamp;
+            return ordered.IndexOf(value) + 1;
+        }
+
+        /// <summary>
+        /// The element <paramref name="offset"/> positions after <paramref name="value"/> (before it
+        /// if negative), or null if that is outside the set or the value is not a member. Lead and
+        /// lag follow the order of the set, so they also hold for non-contiguous and non-numeric sets.
+        /// </summary>
+        public object? Shift(object value, int offset)
+        {
+            int ord = Ord(value);
+            if (ord == 0)
+                return null;
+            int position = ord - 1 + offset;
+            return position >= 0 && position < ordered.Count ? ordered[position] : null;
+        }
         
         /// <summary>
         /// Gets all integer values (only valid for Int type)
@@ -103,13 +169,7 @@ namespace Core.Models
         /// </summary>
         public IEnumerable<object> GetAllValues()
         {
-            return Type switch
-            {
-                PrimitiveSetType.Int => intValues.Cast<object>(),
-                PrimitiveSetType.String => stringValues.Cast<object>(),
-                PrimitiveSetType.Float => floatValues.Cast<object>(),
-                _ => Enumerable.Empty<object>()
-            };
+            return ordered;
         }
         
         /// <summary>
@@ -124,13 +184,7 @@ namespace Core.Models
             
             int zeroBasedIndex = index - 1;
             
-            return Type switch
-            {
-                PrimitiveSetType.Int when zeroBasedIndex < intValues.Count => intValues.ElementAt(zeroBasedIndex),
-                PrimitiveSetType.String when zeroBasedIndex < stringValues.Count => stringValues.ElementAt(zeroBasedIndex),
-                PrimitiveSetType.Float when zeroBasedIndex < floatValues.Count => floatValues.ElementAt(zeroBasedIndex),
-                _ => null
-            };
+            return zeroBasedIndex < ordered.Count ? ordered[zeroBasedIndex] : null;
         }
         
         /// <summary>
@@ -141,6 +195,19 @@ namespace Core.Models
             intValues.Clear();
             stringValues.Clear();
             floatValues.Clear();
+            timestampValues.Clear();
+            ordered.Clear();
+        }
+        
+        /// <summary>
+        /// Copy of the set with its elements in the same order
+        /// </summary>
+        public PrimitiveSet Clone()
+        {
+            var clone = new PrimitiveSet(Name, Type, IsExternal) { Description = Description };
+            foreach (var value in ordered)
+                clone.Add(value);
+            return clone;
         }
         
         public override string ToString()
@@ -160,6 +227,7 @@ namespace Core.Models
             {
                 PrimitiveSetType.Int => string.Join(", ", intValues.OrderBy(v => v)),
                 PrimitiveSetType.String => string.Join(", ", stringValues.Select(s => $"\"{s}\"")),
+                PrimitiveSetType.Timestamp => string.Join(", ", ordered.Select(s => $"\"{s}\"")),
                 PrimitiveSetType.Float => string.Join(", ", floatValues.OrderBy(v => v)
                     .Select(v => v.ToString(System.Globalization.CultureInfo.InvariantCulture))),
                 _ => ""
@@ -184,6 +252,11 @@ namespace Core.Models
     {
         Int,
         String,
-        Float
+        Float,
+
+        /// <summary>
+        /// Points in time, written as ISO 8601 strings and ordered chronologically
+        /// </summary>
+        Timestamp
     }
 }
\ No newline at end of file
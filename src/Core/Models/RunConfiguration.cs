@@ -34,6 +34,11 @@ namespace Core.Models
         /// </summary>
         public List<string> DataFiles { get; set; } = new List<string>();
 
+        /// <summary>
+        /// Case overlay paths (.case), applied in order on top of the data files
+        /// </summary>
+        public List<string> OverlayFiles { get; set; } = new List<string>();
+
         /// <summary>
         /// Settings file path (optional)
         /// </summary>
@@ -87,6 +92,14 @@ namespace Core.Models
                 }
             }
 
+            foreach (var file in OverlayFiles)
+            {
+                if (!File.Exists(file))
+                {
+                    missingFiles.Add(file);
+                }
+            }
+
             if (!string.IsNullOrEmpty(SettingsFile) && !File.Exists(SettingsFile))
             {
                 missingFiles.Add(SettingsFile);
@@ -107,6 +120,7 @@ namespace Core.Models
                 Description = Description,
                 ModelFiles = new List<string>(ModelFiles),
                 DataFiles = new List<string>(DataFiles),
+                OverlayFiles = new List<string>(OverlayFiles),
                 SettingsFile = SettingsFile,
                 WorkingDirectory = WorkingDirectory,
                 Metadata = new Dictionary<string, string>(Metadata)
@@ -49,6 +49,15 @@ namespace Core.Models
             return KeyFields.Select(kf => Fields[kf]);
         }
         
+        public TupleSchema Clone()
+        {
+            var clone = new TupleSchema(Name);
+            foreach (var field in Fields)
+                clone.Fields[field.Key] = field.Value;
+            clone.KeyFields.AddRange(KeyFields);
+            return clone;
+        }
+        
         public override string ToString()
         {
             var fieldStrings = Fields.Select(f =>
@@ -9,6 +9,11 @@ namespace Core.Models
         public string SchemaName { get; }
         public bool IsExternal { get; }
         public List<TupleInstance> Instances { get; }
+
+        /// <summary>
+        /// Docstring of the tuple set declaration
+        /// </summary>
+        public string? Description { get; set; }
         
         public string? IndexSetName { get; set; }  // Optional: which index set indexes this
         public bool IsIndexed => !string.IsNullOrEmpty(IndexSetName);
@@ -108,6 +113,22 @@ namespace Core.Models
             Instances.Clear();
         }
         
+        /// <summary>
+        /// Copy of the set with copies of its instances
+        /// </summary>
+        public TupleSet Clone()
+        {
+            var clone = new TupleSet(Name, SchemaName, IsExternal) { Description = Description, IndexSetName = IndexSetName };
+            foreach (var instance in Instances)
+            {
+                var copy = new TupleInstance(instance.SchemaName);
+                foreach (var field in instance.Fields)
+                    copy.Fields[field.Key] = field.Value;
+                clone.Instances.Add(copy);
+            }
+            return clone;
+        }
+        
         public override string ToString()
         {
             if (IsExternal && Count == 0)
@@ -0,0 +1,322 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Models
+{
+    /// <summary>
+    /// A sliding window over an ordered set as written in an iterator domain: window(T, t, 4)
+    /// </summary>
+    public class SetWindow
+    {
+        public string SetName { get; init; } = "";
+
+        /// <summary>
+        /// Element the window moves with, as written
+        /// </summary>
+        public string Element { get; init; } = "";
+
+        /// <summary>
+        /// First and last position of the window relative to the element (negative before it)
+        /// </summary>
+        public int From { get; init; }
+        public int To { get; init; }
+
+        /// <summary>
+        /// Length given by an expression of the data (window(T, t, minUp[u])), evaluated when
+        /// the window is resolved; null if the span is a number or an offset range
+        /// </summary>
+        public string? Length { get; init; }
+
+        public BoundaryPolicy Policy { get; init; } = BoundaryPolicy.Clamp;
+    }
+
+    /// <summary>
+    /// Sliding windows over ordered sets, as an iterator domain of sum and the other
+    /// aggregations, for minimum up/down times and ramping envelopes:
+    /// <code>
+    /// forall(p in P, t in T) minUp: sum(s in window(T, t, 4)) start[p,s] &lt;= on[p,t];
+    /// forall(p in P, t in T) ramp: sum(s in window(T, t, 0..2)) x[p,s] &lt;= 3 * cap[p];
+    /// </code>
+    /// window(T, t, n) is the n elements of T up to and including t, and window(T, t, a..b) the
+    /// elements a to b positions from t; n may be an expression of the data, minUp[u]. Like prev and next the window follows the order of the
+    /// set. Past the ends of the set the policy decides: clamp (the default) keeps the part of
+    /// the window inside the set, wrap continues at the other end, and skip leaves the
+    /// constraint instance out unless the whole window fits. Constraints are expanded as text,
+    /// so a window is resolved there once its element is bound: the iterator runs over the set
+    /// itself, filtered to the elements of the window.
+    /// </summary>
+    public static class Windows
+    {
+        private static readonly Regex CallPattern = new Regex(@"(?<![\w.])window\s*\(");
+
+        private static readonly Regex SpanPattern = new Regex(@"^(-?\d+)\s*\.\.\s*(-?\d+)$");
+
+        private static readonly Regex NumberPattern = new Regex(@"^[-+]?[\d.]+$");
+
+        private static readonly Regex IteratorPattern = new Regex(@"([A-Za-z_]\w*)\s+in\s*$");
+
+        private static readonly Regex IdentifierPattern = new Regex(@"^[A-Za-z_]\w*$");
+
+        private static readonly Regex FunctionPattern = new Regex(@"([A-Za-z_]\w*)\s*$");
+
+        /// <summary>
+        /// True if the text has a window domain
+        /// </summary>
+        public static bool IsUsed(string text) => CallPattern.IsMatch(text);
+
+        /// <summary>
+        /// The set a domain iterates over: T for both "T" and "window(T, t, 4)"
+        /// </summary>
+        public static string SetName(string domain)
+        {
+            return TryParse(domain, out var window, out _) ? window!.SetName : domain;
+        }
+
+        /// <summary>
+        /// Parses a whole domain that is one window; false if it is not one, with
+        /// <paramref name="error"/> set if it is one but malformed
+        /// </summary>
+        public static bool TryParse(string text, out SetWindow? window, out string error)
+        {
+            window = null;
+            error = string.Empty;
+            text = text.Trim();
+
+            var match = CallPattern.Match(text);
+            if (!match.Success || match.Index != 0 || Close(text, match.Length - 1) != text.Length - 1)
+                return false;
+
+            var arguments = SplitArguments(text.Substring(match.Length, text.Length - match.Length - 1));
+            if (arguments.Count is < 3 or > 4)
+            {
+                error = $"{text}: a window has a set, an element, a length or an offset range a..b, and optionally clamp, wrap or skip";
+                return true;
+            }
+
+            int from = 0, to = 0;
+            string? lengthExpression = null;
+            var span = SpanPattern.Match(arguments[2]);
+            if (span.Success)
+            {
+                from = int.Parse(span.Groups[1].Value, CultureInfo.InvariantCulture);
+                to = int.Parse(span.Groups[2].Value, CultureInfo.InvariantCulture);
+            }
+            else if (int.TryParse(arguments[2], NumberStyles.None, CultureInfo.InvariantCulture, out int length) && length > 0)
+            {
+                (from, to) = (1 - length, 0);
+            }
+            else if (!NumberPattern.IsMatch(arguments[2]) && arguments[2].Length > 0)
+            {
+                lengthExpression = arguments[2];
+            }
+            else
+            {
+                error = $"{text}: '{arguments[2]}' is neither a positive length nor an offset range a..b";
+                return true;
+            }
+
+            if (from > to)
+            {
+                error = $"{text}: the offset range {from}..{to} is empty";
+                return true;
+            }
+
+            var policy = BoundaryPolicy.Clamp;
+            if (arguments.Count == 4)
+            {
+                switch (arguments[3])
+                {
+                    case "clamp":
+                        break;
+                    case "wrap":
+                        policy = BoundaryPolicy.Wrap;
+                        break;
+                    case "skip":
+                        policy = BoundaryPolicy.Skip;
+                        break;
+                    default:
+                        error = $"{text}: '{arguments[3]}' is not a boundary policy; use clamp, wrap or skip";
+                        return true;
+                }
+            }
+
+            window = new SetWindow { SetName = arguments[0], Element = arguments[1], From = from, To = to, Length = lengthExpression, Policy = policy };
+            return true;
+        }
+
+        /// <summary>
+        /// Elements of the window in the order of the set, or null if the skip policy leaves
+        /// the instance out
+        /// </summary>
+        public static List<object>? Elements(ModelManager manager, SetWindow window)
+        {
+            var elements = OrderedSets.Elements(manager, window.SetName);
+            object element = OrderedSets.ElementValue(manager, OrderedSets.ParseResolved(manager, window.Element));
+            int position = OrderedSets.IndexOf(elements, element);
+            if (position < 0)
+                throw new InvalidOperationException($"window({window.SetName}, ...): {OrderedSets.Format(element)} is not an element of {window.SetName}");
+
+            int from = window.From;
+            if (window.Length != null)
+            {
+                double length = OrderedSets.ParseResolved(manager, window.Length).Evaluate(manager);
+                if (length < 0 || length != Math.Floor(length))
+                    throw new InvalidOperationException($"window({window.SetName}, ...): the length {window.Length} is {length.ToString(CultureInfo.InvariantCulture)}, not a whole number of elements");
+                from = 1 - (int)length;
+            }
+
+            var members = new List<object>();
+            for (int offset = from; offset <= window.To; offset++)
+            {
+                object? member = OrderedSets.Shift(elements, position, offset, window.Policy == BoundaryPolicy.Wrap ? BoundaryPolicy.Wrap : BoundaryPolicy.Skip);
+                if (member == null && window.Policy == BoundaryPolicy.Skip)
+                    return null;
+                if (member != null && OrderedSets.IndexOf(members, member) < 0)
+                    members.Add(member);
+            }
+            return members;
+        }
+
+        /// <summary>
+        /// Replaces the window domains of an expanded constraint by their sets, filtered to the
+        /// elements of each window; an aggregation over an empty window becomes 0 (sum, count)
+        /// or is an error. Returns null if the instance is skipped; throws
+        /// InvalidOperationException if a window cannot be resolved.
+        /// </summary>
+        public static string? Resolve(ModelManager manager, string text)
+        {
+            // Right to left, so the positions of the windows before stay valid
+            int limit = text.Length;
+            while (CallPattern.Matches(text).LastOrDefault(m => m.Index < limit) is { } match)
+            {
+                int close = Close(text, match.Index + match.Length - 1);
+                if (close < 0)
+                    throw new InvalidOperationException("window( is not closed");
+
+                string domain = text.Substring(match.Index, close - match.Index + 1);
+                if (!TryParse(domain, out var window, out string error))
+                    throw new InvalidOperationException($"{domain} is not a window");
+                if (window == null)
+                    throw new InvalidOperationException(error);
+
+                var iterator = IteratorPattern.Match(text.Substring(0, match.Index));
+                int open = Open(text, match.Index);
+                if (!iterator.Success || open < 0)
+                    throw new InvalidOperationException($"{domain} is only a domain of sum, min, max, prod, count or avg: sum(s in {domain}) ...");
+
+                if (IdentifierPattern.IsMatch(window.Element) && !manager.Parameters.ContainsKey(window.Element))
+                    throw new InvalidOperationException($"{domain}: '{window.Element}' is not bound; a window moves with an iterator of the constraint");
+
+                var elements = Elements(manager, window);
+                if (elements == null)
+                    return null;
+
+                int end = Close(text, open);
+                string header = text.Substring(open + 1, end - open - 1);
+                if (elements.Count == 0)
+                {
+                    var function = FunctionPattern.Match(text.Substring(0, open));
+                    string name = function.Success ? function.Groups[1].Value : "";
+                    if (name is not ("sum" or "count"))
+                        throw new InvalidOperationException($"{name}({header}) ... is over an empty window");
+
+                    SummationExpander.ExtractSumBody(text, end + 1, out int length);
+                    text = text.Substring(0, function.Index) + "0" + text.Substring(end + 1 + length);
+                    limit = function.Index;
+                    continue;
+                }
+
+                // s in window(T, 5, 3) becomes s in T: (s == 3 || s == 4 || s == 5)
+                string variable = iterator.Groups[1].Value;
+                string membership = string.Join(" || ", elements.Select(e => $"{variable} == {OrderedSets.Format(e)}"));
+                int colon = TopLevelColon(header);
+                string iterators = header.Substring(0, match.Index - open - 1) + window.SetName + header.Substring(close - open, (colon < 0 ? header.Length : colon) - (close - open));
+                string filter = colon < 0 ? $"({membership})" : $"({membership}) && ({header.Substring(colon + 1).Trim()})";
+                text = text.Substring(0, open + 1) + $"{iterators}: {filter}" + text.Substring(end);
+                limit = open;
+            }
+            return text;
+        }
+
+        private static int TopLevelColon(string header)
+        {
+            int depth = 0;
+            for (int i = 0; i < header.Length; i++)
+            {
+                if (header[i] is '(' or '[')
+                    depth++;
+                else if (header[i] is ')' or ']')
+                    depth--;
+                else if (header[i] == ':' && depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        /// <summary>
+        /// Position of the parenthesis closing the one at <paramref name="open"/>, or -1
+        /// </summary>
+        private static int Close(string text, int open)
+        {
+            int depth = 0;
+            bool inQuotes = false;
+            for (int i = open; i < text.Length; i++)
+            {
+                char c = text[i];
+                if (c == '"')
+                    inQuotes = !inQuotes;
+                else if (inQuotes)
+                    continue;
+                else if (c == '(')
+                    depth++;
+                else if (c == ')' && --depth == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        /// <summary>
+        /// Position of the unclosed parenthesis before <paramref name="index"/>, or -1
+        /// </summary>
+        private static int Open(string text, int index)
+        {
+            int depth = 0;
+            for (int i = index - 1; i >= 0; i--)
+            {
+                if (text[i] == ')')
+                    depth++;
+                else if (text[i] == '(' && depth-- == 0)
+                    return i;
+            }
+            return -1;
+        }
+
+        private static List<string> SplitArguments(string text)
+        {
+            var arguments = new List<string>();
+            int depth = 0;
+            int start = 0;
+            bool inQuotes = false;
+            for (int i = 0; i < text.Length; i++)
+            {
+                char c = text[i];
+                if (c == '"')
+                    inQuotes = !inQuotes;
+                else if (inQuotes)
+                    continue;
+                else if (c is '(' or '[')
+                    depth++;
+                else if (c is ')' or ']')
+                    depth--;
+                else if (c == ',' && depth == 0)
+                {
+                    arguments.Add(text.Substring(start, i - start).Trim());
+                    start = i + 1;
+                }
+            }
+            arguments.Add(text.Substring(start).Trim());
+            return arguments;
+        }
+    }
+}
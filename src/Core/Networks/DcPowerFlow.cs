@@ -0,0 +1,404 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Networks
+{
+    public enum DcPowerFlowMethod
+    {
+        /// <summary>Bus voltage angles as variables, with a flow constraint per line</summary>
+        Angle,
+
+        /// <summary>Line flows as linear functions of the injections, through the PTDF matrix</summary>
+        Ptdf
+    }
+
+    /// <summary>
+    /// How the DC power flow of one network is written
+    /// </summary>
+    public class DcPowerFlowOptions
+    {
+        public DcPowerFlowMethod Method { get; set; } = DcPowerFlowMethod.Angle;
+
+        /// <summary>
+        /// Prefix of the generated names (dc_flow_a_b, dc_balance_a)
+        /// </summary>
+        public string Prefix { get; set; } = "dc";
+
+        /// <summary>
+        /// Reference bus, where the angle is 0 and which balances the PTDF injections; the first
+        /// node if not given
+        /// </summary>
+        public string? SlackNode { get; set; }
+
+        /// <summary>
+        /// Net injection of a node as an expression of the model, with {node} standing for the
+        /// node: "gen[{node}] - load[{node}]". The supplies of the nodes are used if not given.
+        /// </summary>
+        public string? Injection { get; set; }
+
+        /// <summary>
+        /// True to limit the flow of lines with a capacity in both directions
+        /// </summary>
+        public bool LineLimits { get; set; } = true;
+
+        /// <summary>
+        /// Lines whose outage is checked (as "a -> b"), each against the capacities of the other lines
+        /// </summary>
+        public List<string> Contingencies { get; set; } = new List<string>();
+
+        /// <summary>
+        /// True to check the outage of every line (N-1)
+        /// </summary>
+        public bool AllContingencies { get; set; }
+
+        /// <summary>
+        /// Factor on the capacities after an outage (emergency ratings)
+        /// </summary>
+        public double ContingencyRating { get; set; } = 1;
+    }
+
+    public class DcPowerFlowResult
+    {
+        public List<string> Statements { get; } = new List<string>();
+
+        /// <summary>
+        /// Outages that were left out because they split the network
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+    }
+
+    /// <summary>
+    /// DC load flow over a network whose arcs are lines with a reactance: the linearized power
+    /// flow of transmission grids, where the flow on a line is its susceptance times the angle
+    /// difference across it. The angle formulation keeps the angles as variables; the PTDF
+    /// formulation writes each flow directly as a combination of the nodal injections. Line
+    /// limits bound the flows by the capacities, and contingencies add the limits after the
+    /// outage of a line, through the line outage distribution factors. Each network is
+    /// formulated with its own options under the generator "dcflow:name", so the blocks of a
+    /// model with several grids are regenerated independently.
+    /// </summary>
+    public static class DcPowerFlow
+    {
+        public const string GeneratorPrefix = "dcflow:";
+
+        private const double Tolerance = 1e-9;
+
+        public static DcPowerFlowResult Generate(Network network, DcPowerFlowOptions? options = null)
+        {
+            options ??= new DcPowerFlowOptions();
+            if (!Regex.IsMatch(options.Prefix, @"^[A-Za-z_]\w*$"))
+                throw new ArgumentException($"'{options.Prefix}' is not a name", nameof(options));
+            int slack = Check(network, options);
+
+            string p = options.Prefix;
+            var used = new HashSet<string>(StringComparer.Ordinal);
+            var lines = network.Arcs.Select(a => ConstraintRelaxer.Unique($"{NetworkFormulation.Identifier(a.From)}_{NetworkFormulation.Identifier(a.To)}", used)).ToList();
+            var flows = lines.Select(l => $"{p}_flow_{l}").ToList();
+            var nodes = network.Nodes.Select(n => NetworkFormulation.Identifier(n.Name)).ToList();
+            var result = new DcPowerFlowResult();
+
+            foreach (var flow in flows)
+                result.Statements.Add(Variable(flow));
+
+            if (options.Method == DcPowerFlowMethod.Angle)
+            {
+                foreach (var node in nodes)
+                    result.Statements.Add(Variable($"{p}_angle_{node}"));
+                result.Statements.Add(Constraint($"{p}_slack", $"{p}_angle_{nodes[slack]} == 0"));
+
+                for (int l = 0; l < network.Arcs.Count; l++)
+                {
+                    var arc = network.Arcs[l];
+                    string b = NetworkFormulation.Format(1 / arc.Reactance!.Value);
+                    result.Statements.Add(Constraint($"{p}_ohm_{lines[l]}",
+                        $"{flows[l]} == {b} * {p}_angle_{NetworkFormulation.Identifier(arc.From)} - {b} * {p}_angle_{NetworkFormulation.Identifier(arc.To)}"));
+                }
+
+                for (int n = 0; n < network.Nodes.Count; n++)
+                {
+                    var node = network.Nodes[n];
+                    var body = new StringBuilder();
+                    for (int l = 0; l < network.Arcs.Count; l++)
+                    {
+                        if (network.Arcs[l].From == node.Name)
+                            body.Append(body.Length == 0 ? "" : " + ").Append(flows[l]);
+                        if (network.Arcs[l].To == node.Name)
+                            body.Append(body.Length == 0 ? "-" : " - ").Append(flows[l]);
+                    }
+                    result.Statements.Add(Constraint($"{p}_balance_{nodes[n]}", $"{body} == {Injection(node, options)}"));
+                }
+            }
+            else
+            {
+                var ptdf = Ptdf(network, network.Nodes[slack].Name);
+                for (int l = 0; l < network.Arcs.Count; l++)
+                {
+                    // Fixed supplies give the flow itself
+                    if (options.Injection == null)
+                    {
+                        double flow = Enumerable.Range(0, network.Nodes.Count).Sum(n => ptdf[l, n] * network.Nodes[n].Supply);
+                        result.Statements.Add(Constraint($"{p}_ptdf_{lines[l]}", $"{flows[l]} == {NetworkFormulation.Format(flow)}"));
+                        continue;
+                    }
+
+                    var terms = new StringBuilder();
+                    for (int n = 0; n < network.Nodes.Count; n++)
+                    {
+                        if (Math.Abs(ptdf[l, n]) < Tolerance)
+                            continue;
+                        terms.Append(terms.Length == 0 ? (ptdf[l, n] < 0 ? "-" : "") : (ptdf[l, n] < 0 ? " - " : " + "))
+                            .Append(NetworkFormulation.Format(Math.Abs(ptdf[l, n])))
+                            .Append($" * ({Injection(network.Nodes[n], options)})");
+                    }
+                    result.Statements.Add(Constraint($"{p}_ptdf_{lines[l]}", $"{flows[l]} == {(terms.Length > 0 ? terms.ToString() : "0")}"));
+                }
+
+                // The injections add up to zero; with fixed supplies Check has made sure of it
+                if (options.Injection != null)
+                {
+                    string total = string.Join(" + ", network.Nodes.Select(n => $"({Injection(n, options)})"));
+                    result.Statements.Add(Constraint($"{p}_balance", $"{total} == 0"));
+                }
+            }
+
+            if (options.LineLimits)
+            {
+                for (int l = 0; l < network.Arcs.Count; l++)
+                {
+                    if (network.Arcs[l].Capacity is not double capacity)
+                        continue;
+                    result.Statements.Add(Constraint($"{p}_limit_{lines[l]}", $"{flows[l]} <= {NetworkFormulation.Format(capacity)}"));
+                    result.Statements.Add(Constraint($"{p}_limitReverse_{lines[l]}", $"{flows[l]} >= {NetworkFormulation.Format(-capacity)}"));
+                }
+            }
+
+            var outages = Outages(network, options);
+            if (outages.Count > 0)
+            {
+                var lodf = Lodf(network, Ptdf(network, network.Nodes[slack].Name));
+                foreach (int k in outages)
+                {
+                    if (lodf[k] == null)
+                    {
+                        result.Warnings.Add($"The outage of {network.Arcs[k]} splits the network; it is left out");
+                        continue;
+                    }
+
+                    // After the outage of k, line l carries its flow plus its share of the flow of k
+                    for (int l = 0; l < network.Arcs.Count; l++)
+                    {
+                        if (l == k || network.Arcs[l].Capacity is not double capacity || Math.Abs(lodf[k]![l]) < Tolerance)
+                            continue;
+
+                        double factor = lodf[k]![l];
+                        string flow = $"{flows[l]} {(factor < 0 ? "-" : "+")} {NetworkFormulation.Format(Math.Abs(factor))} * {flows[k]}";
+                        double limit = capacity * options.ContingencyRating;
+                        result.Statements.Add(Constraint($"{p}_outage_{lines[k]}_{lines[l]}", $"{flow} <= {NetworkFormulation.Format(limit)}"));
+                        result.Statements.Add(Constraint($"{p}_outageReverse_{lines[k]}_{lines[l]}", $"{flow} >= {NetworkFormulation.Format(-limit)}"));
+                    }
+                }
+            }
+
+            return result;
+        }
+
+        /// <summary>
+        /// Change set that writes the DC power flow of the network into a model, replacing what
+        /// was generated for it before (see ModelProvenance.Regenerate)
+        /// </summary>
+        public static ChangeSet Regenerate(ModelSource source, Network network, DcPowerFlowOptions? options = null)
+        {
+            return ModelProvenance.Regenerate(source, GeneratorPrefix + NetworkFormulation.Identifier(network.Name), Generate(network, options).Statements);
+        }
+
+        /// <summary>
+        /// Power transfer distribution factors: the change of the flow on each line (rows, in arc
+        /// order) per unit injected at each node (columns, in node order) and withdrawn at the slack
+        /// </summary>
+        public static double[,] Ptdf(Network network, string slackNode)
+        {
+            int nodes = network.Nodes.Count;
+            int slack = IndexOf(network, slackNode);
+            var index = network.Nodes.Select((n, i) => (n.Name, i)).ToDictionary(x => x.Name, x => x.i, StringComparer.Ordinal);
+
+            // Susceptance matrix without the row and column of the slack
+            var reduced = new int[nodes];
+            for (int n = 0, r = 0; n < nodes; n++)
+                reduced[n] = n == slack ? -1 : r++;
+
+            var b = new double[nodes - 1, nodes - 1];
+            foreach (var arc in network.Arcs)
+            {
+                double susceptance = 1 / arc.Reactance!.Value;
+                int from = reduced[index[arc.From]], to = reduced[index[arc.To]];
+                if (from >= 0)
+                    b[from, from] += susceptance;
+                if (to >= 0)
+                    b[to, to] += susceptance;
+                if (from >= 0 && to >= 0)
+                {
+                    b[from, to] -= susceptance;
+                    b[to, from] -= susceptance;
+                }
+            }
+
+            var x = Invert(b);
+            var ptdf = new double[network.Arcs.Count, nodes];
+            for (int l = 0; l < network.Arcs.Count; l++)
+            {
+                var arc = network.Arcs[l];
+                int from = reduced[index[arc.From]], to = reduced[index[arc.To]];
+                for (int n = 0; n < nodes; n++)
+                {
+                    if (reduced[n] < 0)
+                        continue;
+                    double angleFrom = from >= 0 ? x[from, reduced[n]] : 0;
+                    double angleTo = to >= 0 ? x[to, reduced[n]] : 0;
+                    ptdf[l, n] = (angleFrom - angleTo) / arc.Reactance!.Value;
+                }
+            }
+            return ptdf;
+        }
+
+        /// <summary>
+        /// Line outage distribution factors by outage: the share of the flow of the line that
+        /// fails which moves to each line; null for an outage that splits the network
+        /// </summary>
+        private static double[]?[] Lodf(Network network, double[,] ptdf)
+        {
+            int lines = network.Arcs.Count;
+            var index = network.Nodes.Select((n, i) => (n.Name, i)).ToDictionary(x => x.Name, x => x.i, StringComparer.Ordinal);
+            var lodf = new double[]?[lines];
+
+            for (int k = 0; k < lines; k++)
+            {
+                int from = index[network.Arcs[k].From], to = index[network.Arcs[k].To];
+                double self = ptdf[k, from] - ptdf[k, to];
+                if (Math.Abs(1 - self) < Tolerance)
+                    continue;
+
+                lodf[k] = new double[lines];
+                for (int l = 0; l < lines; l++)
+                    lodf[k]![l] = l == k ? -1 : (ptdf[l, from] - ptdf[l, to]) / (1 - self);
+            }
+            return lodf;
+        }
+
+        private static List<int> Outages(Network network, DcPowerFlowOptions options)
+        {
+            if (options.AllContingencies)
+                return Enumerable.Range(0, network.Arcs.Count).ToList();
+
+            var outages = new List<int>();
+            foreach (var line in options.Contingencies)
+            {
+                string normalized = Regex.Replace(line.Trim(), @"\s*->\s*", " -> ");
+                int k = network.Arcs.ToList().FindIndex(a => a.ToString() == normalized);
+                if (k < 0)
+                    throw new InvalidOperationException($"Contingency '{line}' is not a line of {network.Name}");
+                outages.Add(k);
+            }
+            return outages;
+        }
+
+        /// <summary>
+        /// Checks that the network can carry a DC power flow and returns the position of the slack
+        /// </summary>
+        private static int Check(Network network, DcPowerFlowOptions options)
+        {
+            // Fixed supplies must balance; injections of the model are balanced by a constraint
+            var problems = network.Validate()
+                .Where(f => f.Severity == LintSeverity.Error
+                    || f.RuleId is "disconnected" or "isolated-node" or "self-loop"
+                    || (f.RuleId == "unbalanced-supply" && options.Injection == null))
+                .Select(f => f.ToString())
+                .ToList();
+            problems.AddRange(network.Arcs.Where(a => !(a.Reactance > 0)).Select(a => $"{a}: a line needs a positive reactance"));
+            if (network.Nodes.Count < 2)
+                problems.Add("a DC power flow needs at least two nodes");
+            if (problems.Count > 0)
+                throw new InvalidOperationException($"Network {network.Name} cannot carry a DC power flow: {string.Join("; ", problems)}");
+
+            return options.SlackNode != null ? IndexOf(network, options.SlackNode) : 0;
+        }
+
+        private static int IndexOf(Network network, string node)
+        {
+            int index = network.Nodes.ToList().FindIndex(n => n.Name == node);
+            return index >= 0 ? index : throw new InvalidOperationException($"'{node}' is not a node of {network.Name}");
+        }
+
+        private static string Injection(NetworkNode node, DcPowerFlowOptions options)
+        {
+            if (options.Injection == null)
+                return NetworkFormulation.Format(node.Supply);
+
+            string element = double.TryParse(node.Name, NumberStyles.Float, CultureInfo.InvariantCulture, out _) ? node.Name : $"\"{node.Name}\"";
+            return options.Injection.Replace("{node}", element);
+        }
+
+        /// <summary>
+        /// Inverse of a nonsingular matrix by Gauss-Jordan elimination with partial pivoting
+        /// </summary>
+        private static double[,] Invert(double[,] matrix)
+        {
+            int n = matrix.GetLength(0);
+            var a = (double[,])matrix.Clone();
+            var inverse = new double[n, n];
+            for (int i = 0; i < n; i++)
+                inverse[i, i] = 1;
+
+            for (int column = 0; column < n; column++)
+            {
+                int pivot = column;
+                for (int row = column + 1; row < n; row++)
+                {
+                    if (Math.Abs(a[row, column]) > Math.Abs(a[pivot, column]))
+                        pivot = row;
+                }
+                if (Math.Abs(a[pivot, column]) < Tolerance)
+                    throw new InvalidOperationException("The susceptance matrix is singular; the network is not connected");
+
+                for (int j = 0; j < n; j++)
+                {
+                    (a[column, j], a[pivot, j]) = (a[pivot, j], a[column, j]);
+                    (inverse[column, j], inverse[pivot, j]) = (inverse[pivot, j], inverse[column, j]);
+                }
+
+                double scale = a[column, column];
+                for (int j = 0; j < n; j++)
+                {
+                    a[column, j] /= scale;
+                    inverse[column, j] /= scale;
+                }
+
+                for (int row = 0; row < n; row++)
+                {
+                    double factor = a[row, column];
+                    if (row == column || factor == 0)
+                        continue;
+                    for (int j = 0; j < n; j++)
+                    {
+                        a[row, j] -= factor * a[column, j];
+                        inverse[row, j] -= factor * inverse[column, j];
+                    }
+                }
+            }
+            return inverse;
+        }
+
+        private static string Variable(string name)
+        {
+            return new EntityDefinition { Kind = EntityKind.Variable, Type = "float", Name = name }.ToStatement();
+        }
+
+        private static string Constraint(string name, string body)
+        {
+            return new EntityDefinition { Kind = EntityKind.Constraint, Name = name, Body = body }.ToStatement();
+        }
+    }
+}
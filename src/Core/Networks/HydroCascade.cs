@@ -0,0 +1,373 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Networks
+{
+    /// <summary>
+    /// Production of a station as a function of its discharge, at one reservoir volume
+    /// </summary>
+    public class HydroCurve
+    {
+        public double Volume { get; init; }
+
+        /// <summary>
+        /// Breakpoints in increasing discharge
+        /// </summary>
+        public List<(double Discharge, double Power)> Points { get; init; } = new List<(double, double)>();
+    }
+
+    /// <summary>
+    /// A reservoir with its power station. Water leaves it by discharge through the turbines, by
+    /// spill and, if the station has one, by a bypass gate; each goes to a station further down
+    /// the cascade, after a delay in periods, or leaves the cascade (null).
+    /// </summary>
+    public class HydroStation
+    {
+        public string Name { get; init; } = "";
+
+        public string? Downstream { get; set; }
+        public int Delay { get; set; }
+
+        public string? SpillTo { get; set; }
+        public int SpillDelay { get; set; }
+
+        /// <summary>
+        /// Capacity of the bypass gate; null if the station has none
+        /// </summary>
+        public double? MaxBypass { get; set; }
+        public string? BypassTo { get; set; }
+        public int BypassDelay { get; set; }
+
+        /// <summary>
+        /// Volumes in units of discharge times one period
+        /// </summary>
+        public double MinVolume { get; set; }
+        public double MaxVolume { get; set; }
+        public double InitialVolume { get; set; }
+
+        /// <summary>
+        /// Largest discharge; the last breakpoint of the curves if not given, unbounded if there are none
+        /// </summary>
+        public double? MaxDischarge { get; set; }
+
+        /// <summary>
+        /// Parameter of the model with the local inflow per period; a parameter Name_inflow is
+        /// declared for the data if not given
+        /// </summary>
+        public string? Inflow { get; set; }
+
+        /// <summary>
+        /// Production curves at one or more volumes, since the head rises with the volume; none if
+        /// the station produces nothing (a pure reservoir)
+        /// </summary>
+        public List<HydroCurve> Curves { get; set; } = new List<HydroCurve>();
+
+        public override string ToString() => Name;
+    }
+
+    /// <summary>
+    /// One river system of reservoirs and stations, and the generator of its model entities:
+    /// volume, discharge, spill and bypass variables over the periods, a volume balance per
+    /// reservoir that receives the water of the stations upstream after their delays, and the
+    /// production of each station bounded by planes through its production curves. With curves
+    /// at several volumes the planes interpolate between them, so production depends on the head;
+    /// the curves are taken to be concave, as they are in practice, otherwise the planes
+    /// overestimate the production. The entities are documented and tagged
+    /// "hydro/cascade/station", and written under the generator "hydro:cascade", so every
+    /// cascade of a model has its own periods and settings and is regenerated on its own.
+    /// </summary>
+    public class HydroCascade
+    {
+        public const string GeneratorPrefix = "hydro:";
+
+        private const double Tolerance = 1e-9;
+
+        private static readonly Regex NamePattern = new Regex(@"^[A-Za-z_]\w*$");
+
+        public HydroCascade(string name)
+        {
+            if (!NamePattern.IsMatch(name))
+                throw new ArgumentException($"'{name}' is not a name", nameof(name));
+            Name = name;
+        }
+
+        public string Name { get; }
+
+        /// <summary>
+        /// Ordered set of the periods the entities are indexed over
+        /// </summary>
+        public string Periods { get; set; } = "T";
+
+        public List<HydroStation> Stations { get; } = new List<HydroStation>();
+
+        public HydroStation AddStation(string name)
+        {
+            var station = new HydroStation { Name = name };
+            Stations.Add(station);
+            return station;
+        }
+
+        public HydroStation? FindStation(string name) => Stations.FirstOrDefault(s => s.Name == name);
+
+        /// <summary>
+        /// The cascade as a network of its stations, with an arc for every way water flows
+        /// between them (see Network.Validate)
+        /// </summary>
+        public Network ToNetwork()
+        {
+            var network = new Network(Name);
+            foreach (var station in Stations.Where(s => network.FindNode(s.Name) == null))
+                network.AddNode(station.Name);
+            foreach (var station in Stations)
+            {
+                foreach (var (target, _, _) in Routes(station).Where(r => r.Target != null))
+                    network.AddArc(station.Name, target!);
+            }
+            return network;
+        }
+
+        /// <summary>
+        /// Checks the cascade: station names, routes to stations that are not in it, water that
+        /// flows back up, volume limits and production curves
+        /// </summary>
+        public List<LintFinding> Validate()
+        {
+            var findings = new List<LintFinding>();
+
+            foreach (var group in Stations.GroupBy(s => s.Name).Where(g => g.Count() > 1))
+                findings.Add(Finding("duplicate-station", group.Key, "is declared more than once"));
+
+            foreach (var station in Stations)
+            {
+                if (!NamePattern.IsMatch(station.Name))
+                    findings.Add(Finding("station-name", station.Name, "is not a name"));
+
+                foreach (var (target, delay, route) in Routes(station))
+                {
+                    if (target != null && FindStation(target) == null)
+                        findings.Add(Finding("dangling-route", station.Name, $"sends its {route} to '{target}', which is not a station of {Name}"));
+                    if (delay < 0)
+                        findings.Add(Finding("negative-delay", station.Name, $"has a negative {route} delay"));
+                }
+
+                if (station.MaxVolume < station.MinVolume)
+                    findings.Add(Finding("volume-limits", station.Name, "has a largest volume below its smallest"));
+                else if (station.InitialVolume < station.MinVolume || station.InitialVolume > station.MaxVolume)
+                    findings.Add(Finding("initial-volume", station.Name, "starts outside its volume limits"));
+
+                var curves = station.Curves.OrderBy(c => c.Volume).ToList();
+                if (curves.Any(c => c.Points.Count < 2 || c.Points.Zip(c.Points.Skip(1)).Any(p => p.Second.Discharge <= p.First.Discharge)))
+                    findings.Add(Finding("production-curve", station.Name, "has a curve with fewer than two breakpoints or discharges that do not increase"));
+                else if (curves.Any(c => !c.Points.Select(p => p.Discharge).SequenceEqual(curves[0].Points.Select(p => p.Discharge))))
+                    findings.Add(Finding("production-curve", station.Name, "has curves with different discharge breakpoints"));
+                else if (curves.Zip(curves.Skip(1)).Any(c => c.Second.Volume - c.First.Volume < Tolerance))
+                    findings.Add(Finding("production-curve", station.Name, "has two curves at the same volume"));
+            }
+
+            // Water flows down: following the routes never leads back to a station
+            foreach (var station in Stations)
+            {
+                var seen = new HashSet<string>(StringComparer.Ordinal);
+                var pending = new Stack<string>(Downstream(station));
+                while (pending.Count > 0)
+                {
+                    string next = pending.Pop();
+                    if (next == station.Name)
+                    {
+                        findings.Add(Finding("cycle", station.Name, "receives its own water back"));
+                        break;
+                    }
+                    if (seen.Add(next) && FindStation(next) is { } below)
+                    {
+                        foreach (var target in Downstream(below))
+                            pending.Push(target);
+                    }
+                }
+            }
+
+            return findings;
+        }
+
+        /// <summary>
+        /// Statements of the cascade with their docstrings and tags
+        /// </summary>
+        public List<(string Statement, string Documentation, IReadOnlyList<string> Tags)> Generate()
+        {
+            var problems = Validate();
+            if (problems.Count > 0)
+                throw new InvalidOperationException($"Cascade {Name} is not valid: {string.Join("; ", problems)}");
+
+            string t = Periods;
+            var entities = new List<(string, string, IReadOnlyList<string>)>();
+
+            foreach (var station in Stations)
+            {
+                string s = station.Name;
+                var tags = new[] { $"hydro/{Name}/{s}" };
+                void Add(EntityDefinition definition, string documentation) => entities.Add((definition.ToStatement(), documentation, tags));
+
+                string inflow = station.Inflow ?? $"{s}_inflow";
+                if (station.Inflow == null)
+                    Add(new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = inflow, IndexSets = { t }, Value = "..." }, $"Local inflow to {s} per period");
+
+                Add(Variable(s, "volume", t, station.MinVolume, station.MaxVolume), $"Volume of the reservoir of {s} at the end of the period");
+
+                double? maxDischarge = station.MaxDischarge ?? (station.Curves.Count > 0 ? station.Curves.Max(c => c.Points[^1].Discharge) : null);
+                Add(Variable(s, "discharge", t, maxDischarge != null ? 0 : null, maxDischarge), $"Discharge through the turbines of {s}");
+                Add(Variable(s, "spill", t, null, null), $"Water spilled at {s}");
+                if (station.MaxBypass != null)
+                    Add(Variable(s, "bypass", t, 0, station.MaxBypass), $"Water let past the turbines of {s} by the bypass gate");
+                if (station.Curves.Count > 0)
+                    Add(Variable(s, "power", t, null, null), $"Production of {s}");
+
+                Add(new EntityDefinition { Kind = EntityKind.Constraint, Forall = $"t in {t}", Name = $"{s}_balance", Body = Balance(station, inflow) },
+                    $"The volume of {s} carries over, plus its inflow and the water of the stations upstream, minus what leaves it");
+
+                var planes = Planes(station);
+                for (int k = 0; k < planes.Count; k++)
+                {
+                    Add(new EntityDefinition { Kind = EntityKind.Constraint, Forall = $"t in {t}", Name = $"{s}_production{k + 1}", Body = Production(s, planes[k]) },
+                        station.Curves.Count > 1
+                            ? $"Production of {s} is at most plane {k + 1} through its curves at volumes {string.Join(", ", station.Curves.Select(c => NetworkFormulation.Format(c.Volume)))}"
+                            : $"Production of {s} is at most segment {k + 1} of its production curve");
+                }
+            }
+
+            return entities;
+        }
+
+        /// <summary>
+        /// Change set that writes the entities of the cascade into a model, replacing what was
+        /// generated for it before (see ModelProvenance.Regenerate)
+        /// </summary>
+        public ChangeSet Regenerate(ModelSource source)
+        {
+            var entities = Generate();
+            var regenerated = ModelProvenance.Regenerate(source, GeneratorPrefix + Name, entities.Select(e => e.Statement));
+            var byKey = entities.ToDictionary(e => ModelSource.GetKey(e.Statement)!, StringComparer.Ordinal);
+
+            return new ChangeSet
+            {
+                Title = $"Generate cascade {Name}",
+                Edits = regenerated.Edits
+                    .Select(e => e.IsRemoval ? e : ModelEdit.Upsert(e.Statement!, e.Annotation, byKey[e.Key!].Documentation, byKey[e.Key!].Tags))
+                    .ToList()
+            };
+        }
+
+        private string Balance(HydroStation station, string inflow)
+        {
+            string s = station.Name;
+            var body = new StringBuilder($"{s}_volume[t] == {s}_volume[prev({Periods}, t, initial = {NetworkFormulation.Format(station.InitialVolume)})]");
+            body.Append($" + {inflow}[t] - {s}_discharge[t] - {s}_spill[t]");
+            if (station.MaxBypass != null)
+                body.Append($" - {s}_bypass[t]");
+
+            // Water from upstream arrives after the delay of its route; before the horizon there was none
+            foreach (var upstream in Stations)
+            {
+                foreach (var (target, delay, route) in Routes(upstream).Where(r => r.Target == s))
+                {
+                    string period = delay == 0 ? "t" : $"prev({Periods}, t, {delay}, initial = 0)";
+                    body.Append($" + {upstream.Name}_{route}[{period}]");
+                }
+            }
+            return body.ToString();
+        }
+
+        private static string Production(string station, (double Discharge, double Volume, double Constant) plane)
+        {
+            var body = new StringBuilder($"{station}_power[t] <= {NetworkFormulation.Format(plane.Discharge)} * {station}_discharge[t]");
+            if (Math.Abs(plane.Volume) > Tolerance)
+                body.Append($" {(plane.Volume < 0 ? "-" : "+")} {NetworkFormulation.Format(Math.Abs(plane.Volume))} * {station}_volume[t]");
+            if (Math.Abs(plane.Constant) > Tolerance)
+                body.Append($" {(plane.Constant < 0 ? "-" : "+")} {NetworkFormulation.Format(Math.Abs(plane.Constant))}");
+            return body.ToString();
+        }
+
+        /// <summary>
+        /// Planes power = a * discharge + b * volume + c bounding the production: one per segment
+        /// of a single curve, or two per segment between curves at adjacent volumes, each through
+        /// three corners of the cell
+        /// </summary>
+        private static List<(double Discharge, double Volume, double Constant)> Planes(HydroStation station)
+        {
+            var curves = station.Curves.OrderBy(c => c.Volume).ToList();
+            var planes = new List<(double, double, double)>();
+
+            void Add(double discharge, double volume, double constant)
+            {
+                if (!planes.Any(p => Math.Abs(p.Item1 - discharge) < Tolerance && Math.Abs(p.Item2 - volume) < Tolerance && Math.Abs(p.Item3 - constant) < Tolerance))
+                    planes.Add((discharge, volume, constant));
+            }
+
+            if (curves.Count == 1)
+            {
+                var points = curves[0].Points;
+                for (int k = 0; k + 1 < points.Count; k++)
+                {
+                    double slope = (points[k + 1].Power - points[k].Power) / (points[k + 1].Discharge - points[k].Discharge);
+                    Add(slope, 0, points[k].Power - slope * points[k].Discharge);
+                }
+                return planes;
+            }
+
+            for (int j = 0; j + 1 < curves.Count; j++)
+            {
+                var (low, high) = (curves[j], curves[j + 1]);
+                double dv = high.Volume - low.Volume;
+                for (int k = 0; k + 1 < low.Points.Count; k++)
+                {
+                    double q0 = low.Points[k].Discharge, dq = low.Points[k + 1].Discharge - q0;
+
+                    // Through both ends of the segment at the lower volume and its far end at the higher one
+                    double a = (low.Points[k + 1].Power - low.Points[k].Power) / dq;
+                    double b = (high.Points[k + 1].Power - low.Points[k + 1].Power) / dv;
+                    Add(a, b, low.Points[k].Power - a * q0 - b * low.Volume);
+
+                    // Through its near end at both volumes and its far end at the higher one
+                    a = (high.Points[k + 1].Power - high.Points[k].Power) / dq;
+                    b = (high.Points[k].Power - low.Points[k].Power) / dv;
+                    Add(a, b, low.Points[k].Power - a * q0 - b * low.Volume);
+                }
+            }
+            return planes;
+        }
+
+        private static IEnumerable<(string? Target, int Delay, string Route)> Routes(HydroStation station)
+        {
+            yield return (station.Downstream, station.Delay, "discharge");
+            yield return (station.SpillTo, station.SpillDelay, "spill");
+            if (station.MaxBypass != null)
+                yield return (station.BypassTo, station.BypassDelay, "bypass");
+        }
+
+        private static IEnumerable<string> Downstream(HydroStation station)
+        {
+            return Routes(station).Where(r => r.Target != null).Select(r => r.Target!).Distinct();
+        }
+
+        private static EntityDefinition Variable(string station, string quantity, string periods, double? lower, double? upper)
+        {
+            return new EntityDefinition
+            {
+                Kind = EntityKind.Variable,
+                Type = lower == null && upper == null ? "float+" : "float",
+                Name = $"{station}_{quantity}",
+                IndexSets = { periods },
+                LowerBound = lower != null ? NetworkFormulation.Format(lower.Value) : upper != null ? "0" : null,
+                UpperBound = upper != null ? NetworkFormulation.Format(upper.Value) : null
+            };
+        }
+
+        private static LintFinding Finding(string id, string subject, string message)
+        {
+            return new LintFinding { RuleId = id, Severity = LintSeverity.Error, Subject = subject, Message = message };
+        }
+
+        public override string ToString() => $"{Name}: {Stations.Count} stations";
+    }
+}
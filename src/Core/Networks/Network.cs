@@ -0,0 +1,192 @@
+using System.Globalization;
+using Core.Analysis;
+using Core.Models;
+
+namespace Core.Networks
+{
+    public class NetworkNode
+    {
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Net amount the node puts into the network: positive at sources, negative at sinks
+        /// </summary>
+        public double Supply { get; set; }
+
+        public override string ToString() => Supply == 0 ? Name : $"{Name} ({Supply.ToString(CultureInfo.InvariantCulture)})";
+    }
+
+    public class NetworkArc
+    {
+        public string From { get; init; } = "";
+        public string To { get; init; } = "";
+
+        /// <summary>
+        /// Largest flow on the arc; null if it is unbounded
+        /// </summary>
+        public double? Capacity { get; set; }
+
+        /// <summary>
+        /// Cost per unit of flow
+        /// </summary>
+        public double Cost { get; set; }
+
+        /// <summary>
+        /// Reactance of a line, for DC power flow; null if the arc is not a line
+        /// </summary>
+        public double? Reactance { get; set; }
+
+        public override string ToString() => $"{From} -> {To}";
+    }
+
+    /// <summary>
+    /// A directed network of nodes and arcs with capacities and costs: the grid, pipeline or
+    /// transport network of a power or logistics model. Build one in code or read it from the
+    /// sets of a parsed model with FromModel; Validate finds dangling arcs and disconnected parts
+    /// before NetworkFormulation writes its flow variables and conservation constraints.
+    /// </summary>
+    public class Network
+    {
+        private readonly List<NetworkNode> nodes = new List<NetworkNode>();
+        private readonly List<NetworkArc> arcs = new List<NetworkArc>();
+
+        public Network(string name)
+        {
+            if (string.IsNullOrWhiteSpace(name))
+                throw new ArgumentException("A network needs a name", nameof(name));
+            Name = name;
+        }
+
+        public string Name { get; }
+
+        public IReadOnlyList<NetworkNode> Nodes => nodes;
+        public IReadOnlyList<NetworkArc> Arcs => arcs;
+
+        public NetworkNode AddNode(string name, double supply = 0)
+        {
+            if (FindNode(name) != null)
+                throw new InvalidOperationException($"Network {Name} already has a node '{name}'");
+
+            var node = new NetworkNode { Name = name, Supply = supply };
+            nodes.Add(node);
+            return node;
+        }
+
+        public NetworkArc AddArc(string from, string to, double? capacity = null, double cost = 0)
+        {
+            var arc = new NetworkArc { From = from, To = to, Capacity = capacity, Cost = cost };
+            arcs.Add(arc);
+            return arc;
+        }
+
+        public NetworkNode? FindNode(string name) => nodes.FirstOrDefault(n => n.Name == name);
+
+        public IEnumerable<NetworkArc> Outgoing(string node) => arcs.Where(a => a.From == node);
+        public IEnumerable<NetworkArc> Incoming(string node) => arcs.Where(a => a.To == node);
+
+        /// <summary>
+        /// Reads a network from a parsed model: the nodes are the elements of a set, the arcs the
+        /// tuples of a tuple set with "from" and "to" fields and, if the schema has them,
+        /// "capacity", "cost" and "reactance" fields. Supplies are read from a parameter
+        /// indexed by the nodes.
+        /// </summary>
+        public static Network FromModel(ModelManager manager, string nodeSet, string arcSet, string? supply = null)
+        {
+            if (!manager.TupleSets.TryGetValue(arcSet, out var tuples))
+                throw new InvalidOperationException($"'{arcSet}' is not a tuple set of arcs");
+
+            var network = new Network(arcSet);
+            foreach (var element in OrderedSets.Elements(manager, nodeSet))
+            {
+                double value = supply == null
+                    ? 0
+                    : OrderedSets.ParseResolved(manager, $"{supply}[{OrderedSets.Format(element)}]").Evaluate(manager);
+                network.AddNode(ElementName(element), value);
+            }
+
+            foreach (var tuple in tuples.Instances)
+            {
+                object from = tuple.GetValue("from") ?? throw new InvalidOperationException($"Arcs of {arcSet} need a 'from' field");
+                object to = tuple.GetValue("to") ?? throw new InvalidOperationException($"Arcs of {arcSet} need a 'to' field");
+                object? capacity = tuple.GetValue("capacity");
+                object? cost = tuple.GetValue("cost");
+                object? reactance = tuple.GetValue("reactance");
+                var arc = network.AddArc(ElementName(from), ElementName(to),
+                    capacity != null ? Convert.ToDouble(capacity, CultureInfo.InvariantCulture) : null,
+                    cost != null ? Convert.ToDouble(cost, CultureInfo.InvariantCulture) : 0);
+                if (reactance != null)
+                    arc.Reactance = Convert.ToDouble(reactance, CultureInfo.InvariantCulture);
+            }
+            return network;
+        }
+
+        /// <summary>
+        /// Checks the network: arcs to or from nodes it does not have, arcs from a node to itself,
+        /// negative capacities, parts not connected to the rest, nodes without arcs, and supplies
+        /// that do not add up to zero
+        /// </summary>
+        public List<LintFinding> Validate()
+        {
+            var findings = new List<LintFinding>();
+            var names = nodes.Select(n => n.Name).ToHashSet(StringComparer.Ordinal);
+
+            foreach (var arc in arcs)
+            {
+                var missing = new[] { arc.From, arc.To }.Where(n => !names.Contains(n)).Distinct().ToList();
+                if (missing.Count > 0)
+                    findings.Add(Finding("dangling-arc", LintSeverity.Error, arc.ToString(), $"ends at {string.Join(" and ", missing.Select(n => $"'{n}'"))}, which is not a node"));
+                else if (arc.From == arc.To)
+                    findings.Add(Finding("self-loop", LintSeverity.Warning, arc.ToString(), "starts and ends at the same node"));
+
+                if (arc.Capacity < 0)
+                    findings.Add(Finding("negative-capacity", LintSeverity.Error, arc.ToString(), $"capacity {arc.Capacity.Value.ToString(CultureInfo.InvariantCulture)} is negative"));
+            }
+
+            foreach (var node in nodes.Where(n => !arcs.Any(a => a.From == n.Name || a.To == n.Name)))
+                findings.Add(Finding("isolated-node", node.Supply != 0 ? LintSeverity.Error : LintSeverity.Warning, node.Name, "has no arcs"));
+
+            var components = Components().Where(c => c.Count > 1).ToList();
+            if (components.Count > 1)
+            {
+                findings.Add(Finding("disconnected", LintSeverity.Warning, Name,
+                    $"has {components.Count} parts with no arcs between them: " +
+                    string.Join("; ", components.Select(c => string.Join(", ", c)))));
+            }
+
+            double total = nodes.Sum(n => n.Supply);
+            if (Math.Abs(total) > 1e-9)
+                findings.Add(Finding("unbalanced-supply", LintSeverity.Warning, Name, $"supplies add up to {total.ToString(CultureInfo.InvariantCulture)}, so flow conservation is infeasible"));
+
+            return findings;
+        }
+
+        /// <summary>
+        /// Nodes grouped into the parts connected by arcs in either direction, in node order
+        /// </summary>
+        public List<List<string>> Components()
+        {
+            var parent = nodes.ToDictionary(n => n.Name, n => n.Name, StringComparer.Ordinal);
+
+            string Root(string name)
+            {
+                while (parent[name] != name)
+                    name = parent[name] = parent[parent[name]];
+                return name;
+            }
+
+            foreach (var arc in arcs.Where(a => parent.ContainsKey(a.From) && parent.ContainsKey(a.To)))
+                parent[Root(arc.From)] = Root(arc.To);
+
+            return nodes.GroupBy(n => Root(n.Name)).Select(g => g.Select(n => n.Name).ToList()).ToList();
+        }
+
+        public override string ToString() => $"{Name}: {nodes.Count} nodes, {arcs.Count} arcs";
+
+        private static string ElementName(object element) => Convert.ToString(element, CultureInfo.InvariantCulture) ?? "";
+
+        private static LintFinding Finding(string id, LintSeverity severity, string subject, string message)
+        {
+            return new LintFinding { RuleId = id, Severity = severity, Subject = subject, Message = message };
+        }
+    }
+}
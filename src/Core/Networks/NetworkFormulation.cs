@@ -0,0 +1,113 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Server;
+
+namespace Core.Networks
+{
+    /// <summary>
+    /// Writes the flow formulation of a network as model declarations: one flow variable per arc,
+    /// bounded by its capacity, one conservation constraint per node and a decision expression
+    /// for the cost of the flow:
+    /// <code>
+    /// dvar float flow_a_b in 0..40;
+    /// flow_balance_a: flow_a_b + flow_a_c - flow_c_a == 10;
+    /// dexpr float flowCost = 2 * flow_a_b + 3 * flow_a_c;
+    /// </code>
+    /// The declarations carry the "// @generated network:name" annotation, so writing the
+    /// formulation again after the network changed replaces them and removes those of arcs and
+    /// nodes that are gone.
+    /// </summary>
+    public static class NetworkFormulation
+    {
+        public const string GeneratorPrefix = "network:";
+
+        /// <summary>
+        /// Statements of the formulation; <paramref name="flow"/> prefixes the names of the flow
+        /// variables, the conservation constraints (flow_balance_a) and the cost (flowCost)
+        /// </summary>
+        public static List<string> Generate(Network network, string flow = "flow")
+        {
+            if (!Regex.IsMatch(flow, @"^[A-Za-z_]\w*$"))
+                throw new ArgumentException($"'{flow}' is not a name", nameof(flow));
+
+            var errors = network.Validate().Where(f => f.Severity == LintSeverity.Error).ToList();
+            if (errors.Count > 0)
+                throw new InvalidOperationException($"Network {network.Name} is not valid: {string.Join("; ", errors)}");
+
+            var used = new HashSet<string>(StringComparer.Ordinal);
+            var variables = new Dictionary<NetworkArc, string>();
+            var statements = new List<string>();
+
+            foreach (var arc in network.Arcs)
+            {
+                string name = ConstraintRelaxer.Unique($"{flow}_{Identifier(arc.From)}_{Identifier(arc.To)}", used);
+                variables[arc] = name;
+                statements.Add(new EntityDefinition
+                {
+                    Kind = EntityKind.Variable,
+                    Type = arc.Capacity != null ? "float" : "float+",
+                    Name = name,
+                    LowerBound = arc.Capacity != null ? "0" : null,
+                    UpperBound = arc.Capacity != null ? Format(arc.Capacity.Value) : null
+                }.ToStatement());
+            }
+
+            foreach (var node in network.Nodes)
+            {
+                var outgoing = network.Outgoing(node.Name).ToList();
+                var incoming = network.Incoming(node.Name).ToList();
+                if (outgoing.Count + incoming.Count == 0)
+                    continue;
+
+                // Flow out minus flow in is the supply of the node
+                var body = new StringBuilder();
+                foreach (var arc in outgoing)
+                    body.Append(body.Length == 0 ? "" : " + ").Append(variables[arc]);
+                foreach (var arc in incoming)
+                    body.Append(body.Length == 0 ? "-" : " - ").Append(variables[arc]);
+                body.Append(" == ").Append(Format(node.Supply));
+
+                statements.Add(new EntityDefinition
+                {
+                    Kind = EntityKind.Constraint,
+                    Name = ConstraintRelaxer.Unique($"{flow}_balance_{Identifier(node.Name)}", used),
+                    Body = body.ToString()
+                }.ToStatement());
+            }
+
+            var costs = network.Arcs.Where(a => a.Cost != 0).Select(a => $"{Format(a.Cost)} * {variables[a]}").ToList();
+            if (costs.Count > 0)
+            {
+                statements.Add(new EntityDefinition
+                {
+                    Kind = EntityKind.DecisionExpression,
+                    Type = "float",
+                    Name = ConstraintRelaxer.Unique($"{flow}Cost", used),
+                    Value = string.Join(" + ", costs)
+                }.ToStatement());
+            }
+
+            return statements;
+        }
+
+        /// <summary>
+        /// Change set that writes the formulation of the network into a model, replacing what
+        /// was generated for it before (see ModelProvenance.Regenerate)
+        /// </summary>
+        public static ChangeSet Regenerate(ModelSource source, Network network, string flow = "flow")
+        {
+            return ModelProvenance.Regenerate(source, GeneratorPrefix + Identifier(network.Name), Generate(network, flow));
+        }
+
+        internal static string Identifier(string name)
+        {
+            string identifier = Regex.Replace(name, @"\W+", "_").Trim('_');
+            return identifier.Length > 0 ? identifier : "_";
+        }
+
+        internal static string Format(double value) => value.ToString("G12", CultureInfo.InvariantCulture);
+    }
+}
@@ -1,3 +1,4 @@
+using Core.Parsing;
 using Core.Solving;
 
 namespace Core
@@ -12,6 +13,11 @@ namespace Core
         public int TotalErrors { get; set; }
         public List<string> Errors { get; set; } = new List<string>();
         public List<string> Warnings { get; set; } = new List<string>();
+
+        /// <summary>
+        /// Suggested names for unresolved references in the model and data files
+        /// </summary>
+        public List<SymbolSuggestion> Suggestions { get; set; } = new List<SymbolSuggestion>();
         public string SummaryMessage { get; set; } = string.Empty;
         public SolveResult? SolveResult { get; set; }
 
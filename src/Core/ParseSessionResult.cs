@@ -1,5 +1,6 @@
 using System.Collections.Generic;
 using System.Linq;
+using Core.Parsing;
 
 namespace Core
 {
@@ -12,6 +13,11 @@ namespace Core
         public List<string> Warnings { get; private set; } = new List<string>();
         public int SuccessCount { get; private set; } = 0;
 
+        /// <summary>
+        /// Closest declared names for unresolved references, one per error that has any
+        /// </summary>
+        public List<SymbolSuggestion> Suggestions { get; } = new List<SymbolSuggestion>();
+
         public void AddError(string error, int lineNumber, string? filePath = null)
         {
             Errors.Add((error, lineNumber, filePath));
@@ -41,5 +47,33 @@ namespace Core
         {
             return Errors.Where(e => e.LineNumber == lineNumber).Select(e => e.Message);
         }
+
+        public IEnumerable<SymbolSuggestion> GetSuggestionsForLine(int lineNumber)
+        {
+            return Suggestions.Where(s => s.LineNumber == lineNumber);
+        }
+
+        /// <summary>
+        /// Keeps the pending suggestions whose name is quoted in an error added since firstError;
+        /// suggestions from parse attempts that did not end up reported are dropped
+        /// </summary>
+        internal void AddSuggestions(List<SymbolSuggestion> pending, int firstError, int lineNumber)
+        {
+            foreach (var suggestion in pending)
+            {
+                for (int i = firstError; i < Errors.Count; i++)
+                {
+                    if (!Errors[i].Message.Contains($"'{suggestion.Name}'"))
+                        continue;
+
+                    suggestion.LineNumber = lineNumber;
+                    suggestion.FilePath = Errors[i].FilePath;
+                    if (!Suggestions.Any(s => s.LineNumber == lineNumber && s.Name == suggestion.Name))
+                        Suggestions.Add(suggestion);
+                    break;
+                }
+            }
+            pending.Clear();
+        }
     }
 }
\ No newline at end of file
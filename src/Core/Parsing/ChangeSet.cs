@@ -0,0 +1,157 @@
+namespace Core.Parsing
+{
+    /// <summary>
+    /// One statement-level edit of a model source: set or remove the declaration of an entity,
+    /// or rewrite a statement that declares none (an objective body, an unlabeled constraint)
+    /// </summary>
+    public class ModelEdit
+    {
+        /// <summary>
+        /// Entity key of the declaration to set or remove, or null for a rewrite
+        /// </summary>
+        public string? Key { get; init; }
+
+        /// <summary>
+        /// Code of the statement a rewrite replaces, exactly as it was when the edit was made
+        /// </summary>
+        public string? Target { get; init; }
+
+        /// <summary>
+        /// New statement code, or null to remove the declaration
+        /// </summary>
+        public string? Statement { get; init; }
+
+        /// <summary>
+        /// "// @name ..." comment to set on the declaration after it is written (see ModelSource.Annotate)
+        /// </summary>
+        public string? Annotation { get; init; }
+
+        /// <summary>
+        /// Docstring to set on the declaration after it is written (see ModelSource.Document)
+        /// </summary>
+        public string? Documentation { get; init; }
+
+        /// <summary>
+        /// Tags to set on the declaration after it is written, as a "// @tags" annotation (see ModelTags)
+        /// </summary>
+        public IReadOnlyList<string>? Tags { get; init; }
+
+        public bool IsRemoval => Statement == null;
+
+        /// <summary>
+        /// Adds or replaces the declaration made by the statement, optionally annotating, documenting and tagging it
+        /// </summary>
+        public static ModelEdit Upsert(string statement, string? annotation = null, string? documentation = null,
+            IReadOnlyList<string>? tags = null)
+        {
+            string key = ModelSource.GetKey(statement.Trim())
+                ?? throw new InvalidOperationException($"Statement does not declare a named entity: {statement.Trim()}");
+            return new ModelEdit { Key = key, Statement = statement.Trim(), Annotation = annotation, Documentation = documentation, Tags = tags };
+        }
+
+        public static ModelEdit Remove(string key) => new ModelEdit { Key = key };
+
+        /// <summary>
+        /// Replaces the code of an existing statement, by key if it declares an entity
+        /// </summary>
+        public static ModelEdit Rewrite(ModelStatement statement, string code)
+        {
+            return statement.Key != null
+                ? new ModelEdit { Key = statement.Key, Statement = code.Trim() }
+                : new ModelEdit { Target = statement.Code, Statement = code.Trim() };
+        }
+
+        /// <summary>
+        /// False if the statement the edit was made against is gone: a removed declaration
+        /// that no longer exists, or a rewrite target whose code has changed since
+        /// </summary>
+        public bool CanApply(ModelSource source)
+        {
+            if (Target != null)
+                return FindTarget(source) != null;
+            return !IsRemoval || source.Find(Key!) != null;
+        }
+
+        internal void Apply(ModelSource source)
+        {
+            if (Target != null)
+                source.Replace(FindTarget(source)!, Statement!);
+            else if (IsRemoval)
+                source.Remove(Key!);
+            else
+                source.Upsert(Statement!);
+
+            if (Documentation != null && Key != null && !IsRemoval)
+                source.Document(Key, Documentation);
+            if (Tags is { Count: > 0 } && Key != null && !IsRemoval)
+                source.Annotate(Key, $"// @tags {string.Join(", ", Tags)}");
+            if (Annotation != null && Key != null && !IsRemoval)
+                source.Annotate(Key, Annotation);
+        }
+
+        private ModelStatement? FindTarget(ModelSource source)
+        {
+            return source.Statements.FirstOrDefault(s => s.Key == null && s.Code == Target);
+        }
+
+        public override string ToString()
+        {
+            if (Target != null)
+                return $"rewrite '{Target}' as '{Statement}'";
+            return IsRemoval ? $"remove {Key}" : $"set {Key}: {Statement}";
+        }
+    }
+
+    /// <summary>
+    /// Edits that are applied together or not at all, e.g. the machine-applicable fix of a
+    /// diagnostic. Applied through ModelHost.ApplyChangeSet by the server, or directly to a text.
+    /// </summary>
+    public class ChangeSet
+    {
+        public string Title { get; init; } = "";
+        public List<ModelEdit> Edits { get; init; } = new List<ModelEdit>();
+
+        public ChangeSet()
+        {
+        }
+
+        public ChangeSet(string title, params ModelEdit[] edits)
+        {
+            Title = title;
+            Edits = edits.ToList();
+        }
+
+        /// <summary>
+        /// Keys of the declarations the change set touches
+        /// </summary>
+        public IEnumerable<string> Keys => Edits.Where(e => e.Key != null).Select(e => e.Key!).Distinct();
+
+        public bool CanApply(ModelSource source) => Edits.All(e => e.CanApply(source));
+
+        /// <summary>
+        /// Applies every edit in order. Throws, leaving the source unchanged, if any of them
+        /// no longer applies because the model changed since the change set was made.
+        /// </summary>
+        public void Apply(ModelSource source)
+        {
+            var stale = Edits.FirstOrDefault(e => !e.CanApply(source));
+            if (stale != null)
+                throw new InvalidOperationException($"Change '{Title}' no longer applies: cannot {stale}");
+
+            foreach (var edit in Edits)
+                edit.Apply(source);
+        }
+
+        /// <summary>
+        /// Applies the change set to a model text and returns the edited text
+        /// </summary>
+        public string Apply(string modelText)
+        {
+            var source = ModelSource.Parse(modelText);
+            Apply(source);
+            return source.ToString();
+        }
+
+        public override string ToString() => Title;
+    }
+}
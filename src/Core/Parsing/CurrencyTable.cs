@@ -0,0 +1,441 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// A parameter holding the price of one unit of From in To, possibly indexed (by period)
+    /// </summary>
+    public class ExchangeRate
+    {
+        public string From { get; init; } = "";
+        public string To { get; init; } = "";
+        public string Parameter { get; init; } = "";
+
+        public override string ToString() => $"{Parameter} ({From} to {To})";
+    }
+
+    /// <summary>
+    /// Money parameters and exchange rates of a model, from annotations before the declarations:
+    /// <code>
+    /// // @money reporting EUR
+    /// // @money USD
+    /// float shipCost[Routes] = ...;
+    /// // @rate USD EUR
+    /// float usdEur[Periods] = ...;
+    /// </code>
+    /// The first line sets the currency the objective is reported in, the second marks a cost
+    /// parameter (or decision expression) as an amount in USD and the third a table of exchange
+    /// rates, here one per period. Before parsing, money in the objective that is not in the
+    /// reporting currency is multiplied by its rate (or divided by the inverse rate), taking the
+    /// rate's indices from the money's own. Adding or comparing amounts in different currencies
+    /// without a rate is an error anywhere in the model; a term multiplied by a rate is in the
+    /// rate's target currency, and a decision expression takes the currency of its terms.
+    /// </summary>
+    public class CurrencyTable
+    {
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@(money|rate)\b[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+        private static readonly Regex codePattern = new Regex(@"^[A-Z]{3}$");
+        private static readonly Regex declarationPattern = new Regex(@"^(?:dexpr\s+)?(?:float|int)\+?\s+(\w+)\s*((?:\[[^\]]*\]\s*)*)");
+
+        private static readonly HashSet<string> separators = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "+", "-", "<=", ">=", "==", "!=", "<", ">", "="
+        };
+
+        /// <summary>
+        /// Index sets of the money parameters, decision expressions and rates, by name
+        /// </summary>
+        private readonly Dictionary<string, List<string>> indexSets = new Dictionary<string, List<string>>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Names of the decision expressions among the money
+        /// </summary>
+        private readonly HashSet<string> expressions = new HashSet<string>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Currency the objective is expressed in, or null to leave it in the currency of its terms
+        /// </summary>
+        public string? ReportingCurrency { get; set; }
+
+        /// <summary>
+        /// Currency of each money parameter and decision expression
+        /// </summary>
+        public Dictionary<string, string> Money { get; } = new Dictionary<string, string>(StringComparer.Ordinal);
+
+        public List<ExchangeRate> Rates { get; } = new List<ExchangeRate>();
+
+        public static bool IsUsed(string modelText) => modelText.Contains("@money") || modelText.Contains("@rate");
+
+        /// <summary>
+        /// Reads the annotations of a model text, checks its statements for mixed currencies and
+        /// returns the text with the objective converted to the reporting currency
+        /// </summary>
+        public string Convert(string modelText, ParseSessionResult result)
+        {
+            var source = ModelSource.Parse(modelText);
+            foreach (var statement in source.Statements)
+                ReadAnnotations(statement, result);
+
+            foreach (var statement in source.Statements.ToList())
+            {
+                string code = statement.Code;
+                if (statement.Key == "objective" && ReportingCurrency != null)
+                {
+                    int errors = result.Errors.Count;
+                    string converted = Convert(code, ReportingCurrency, source, statement.LineNumber, result);
+                    if (result.Errors.Count > errors)
+                        continue;
+                    if (converted != code)
+                    {
+                        source.Replace(statement, converted);
+                        code = converted;
+                    }
+                }
+
+                string? currency = Check(code, statement.LineNumber, result);
+                if (statement.Key?.StartsWith("dexpr:", StringComparison.Ordinal) == true)
+                {
+                    string name = statement.Key.Substring("dexpr:".Length);
+                    expressions.Add(name);
+                    if (currency != null && Money.TryAdd(name, currency))
+                        indexSets[name] = IndexSetsOf(code);
+                }
+            }
+
+            return source.ToString();
+        }
+
+        /// <summary>
+        /// Sets the Currency of the parsed money parameters
+        /// </summary>
+        public void Apply(ModelManager manager)
+        {
+            foreach (var (name, currency) in Money)
+            {
+                if (manager.Parameters.TryGetValue(name, out var parameter))
+                    parameter.Currency = currency;
+            }
+        }
+
+        /// <summary>
+        /// The rate converting one currency into another, or null
+        /// </summary>
+        public ExchangeRate? FindRate(string from, string to) => Rates.FirstOrDefault(r => r.From == from && r.To == to);
+
+        private void ReadAnnotations(ModelStatement statement, ParseSessionResult result)
+        {
+            string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+            foreach (Match m in annotationPattern.Matches(trivia))
+            {
+                var words = m.Groups[2].Value.Split((char[]?)null, StringSplitOptions.RemoveEmptyEntries);
+                string? invalid = words.Skip(m.Groups[1].Value == "money" && words.FirstOrDefault() == "reporting" ? 1 : 0)
+                    .FirstOrDefault(w => !codePattern.IsMatch(w));
+                if (invalid != null)
+                {
+                    result.AddError($"Invalid currency code '{invalid}' (expected three capital letters such as EUR)", statement.LineNumber);
+                    continue;
+                }
+
+                string? name = statement.Key?.Substring(statement.Key.IndexOf(':') + 1);
+                switch (m.Groups[1].Value)
+                {
+                    case "money" when words.Length == 2 && words[0] == "reporting":
+                        if (ReportingCurrency != null && ReportingCurrency != words[1])
+                            result.AddError($"Reporting currency is already {ReportingCurrency}", statement.LineNumber);
+                        else
+                            ReportingCurrency = words[1];
+                        break;
+
+                    case "money" when words.Length == 1:
+                        if (statement.Key?.StartsWith("parameter:", StringComparison.Ordinal) != true &&
+                            statement.Key?.StartsWith("dexpr:", StringComparison.Ordinal) != true)
+                        {
+                            result.AddError("@money must precede a parameter or decision expression declaration", statement.LineNumber);
+                            break;
+                        }
+                        Money[name!] = words[0];
+                        indexSets[name!] = IndexSetsOf(statement.Code);
+                        break;
+
+                    case "money":
+                        result.AddError("Expected '@money <currency>' or '@money reporting <currency>'", statement.LineNumber);
+                        break;
+
+                    case "rate" when words.Length == 2 && words[0] != words[1]:
+                        if (statement.Key?.StartsWith("parameter:", StringComparison.Ordinal) != true)
+                        {
+                            result.AddError("@rate must precede a parameter declaration", statement.LineNumber);
+                            break;
+                        }
+                        if (FindRate(words[0], words[1]) is ExchangeRate existing)
+                        {
+                            result.AddError($"Rate from {words[0]} to {words[1]} is already '{existing.Parameter}'", statement.LineNumber);
+                            break;
+                        }
+                        Rates.Add(new ExchangeRate { From = words[0], To = words[1], Parameter = name! });
+                        indexSets[name!] = IndexSetsOf(statement.Code);
+                        break;
+
+                    default:
+                        result.AddError("Expected '@rate <from> <to>' with two different currencies", statement.LineNumber);
+                        break;
+                }
+            }
+        }
+
+        /// <summary>
+        /// Reports a statement adding or comparing different currencies and returns the currency
+        /// of its terms, or null if it has no money or mixes currencies
+        /// </summary>
+        private string? Check(string code, int lineNumber, ParseSessionResult result)
+        {
+            var currencies = new SortedSet<string>(StringComparer.Ordinal);
+            foreach (var term in Terms(code))
+            {
+                var termCurrencies = CurrenciesOf(term);
+                if (termCurrencies.Count > 1)
+                {
+                    result.AddError($"Term combines amounts in {string.Join(" and ", termCurrencies)} without a conversion", lineNumber);
+                    return null;
+                }
+                currencies.UnionWith(termCurrencies);
+            }
+
+            if (currencies.Count > 1)
+            {
+                result.AddError($"Statement mixes amounts in {string.Join(" and ", currencies)} without a conversion " +
+                                "(multiply by an exchange rate declared with // @rate)", lineNumber);
+                return null;
+            }
+            return currencies.FirstOrDefault();
+        }
+
+        /// <summary>
+        /// Multiplies the money of each term not in the target currency by the rate to it. A
+        /// decision expression is replaced by a converted copy, declared after it as "name__EUR".
+        /// </summary>
+        private string Convert(string code, string target, ModelSource source, int lineNumber, ParseSessionResult result)
+        {
+            var replacements = new List<(int Start, int End, string Text)>();
+            foreach (var term in Terms(code))
+            {
+                var converted = term.Select(Rate).OfType<ExchangeRate>().Select(r => r.From).ToHashSet();
+                foreach (var reference in term)
+                {
+                    if (!Money.TryGetValue(reference.Name, out var currency) || currency == target || converted.Contains(currency))
+                        continue;
+
+                    if (expressions.Contains(reference.Name))
+                    {
+                        if (ConvertExpression(reference.Name, target, source, lineNumber, result) is string name)
+                            replacements.Add((reference.Start, reference.Start + reference.Name.Length, name));
+                        continue;
+                    }
+
+                    var rate = FindRate(currency, target);
+                    var inverse = rate == null ? FindRate(target, currency) : null;
+                    if (rate == null && inverse == null)
+                    {
+                        result.AddError($"No exchange rate from {currency} to {target} for '{reference.Name}' in the objective", lineNumber);
+                        continue;
+                    }
+
+                    string? rateReference = RateReference(rate ?? inverse!, reference, lineNumber, result);
+                    if (rateReference == null)
+                        continue;
+
+                    // A product reads left to right, so only a divisor or a base needs parentheses
+                    string amount = $"{code.Substring(reference.Start, reference.End - reference.Start)} {(rate != null ? "*" : "/")} {rateReference}";
+                    bool grouped = code.Substring(0, reference.Start).TrimEnd().EndsWith('/') || code.Substring(reference.End).TrimStart().StartsWith('^');
+                    replacements.Add((reference.Start, reference.End, grouped ? $"({amount})" : amount));
+                }
+            }
+
+            foreach (var (start, end, text) in replacements.OrderByDescending(r => r.Start))
+                code = code.Substring(0, start) + text + code.Substring(end);
+            return code;
+        }
+
+        /// <summary>
+        /// Declares a copy of a decision expression converted to the target currency on the line
+        /// of its declaration (so line numbers do not move) and returns its name
+        /// </summary>
+        private string? ConvertExpression(string name, string target, ModelSource source, int lineNumber, ParseSessionResult result)
+        {
+            string converted = $"{name}__{target}";
+            if (Money.ContainsKey(converted))
+                return converted;
+
+            var declaration = source.Find("dexpr:" + name);
+            var head = declaration == null ? Match.Empty : Regex.Match(declaration.Code, $@"^dexpr\s+\w+\+?\s+{Regex.Escape(name)}\b");
+            if (declaration == null || !head.Success)
+            {
+                result.AddError($"Decision expression '{name}' is in {Money[name]} and not declared in this file, so it cannot be converted to {target}", lineNumber);
+                return null;
+            }
+
+            Money[converted] = target;
+            indexSets[converted] = indexSets.GetValueOrDefault(name) ?? new List<string>();
+            expressions.Add(converted);
+
+            string code = declaration.Code;
+            string copy = Convert(code.Substring(0, head.Length - name.Length) + converted + code.Substring(head.Length),
+                target, source, declaration.LineNumber, result);
+            source.Replace(source.Find("dexpr:" + name)!, code + " " + copy);
+            return converted;
+        }
+
+        /// <summary>
+        /// The rate for a money reference: "usdEur[t]" for a rate indexed by a set the money is also
+        /// indexed by, where t is the money's index for that set
+        /// </summary>
+        private string? RateReference(ExchangeRate rate, Reference money, int lineNumber, ParseSessionResult result)
+        {
+            var rateSets = indexSets.GetValueOrDefault(rate.Parameter) ?? new List<string>();
+            if (rateSets.Count == 0)
+                return rate.Parameter;
+
+            var moneySets = indexSets.GetValueOrDefault(money.Name) ?? new List<string>();
+            var indices = new List<string>();
+            foreach (string set in rateSets)
+            {
+                int position = moneySets.IndexOf(set);
+                if (position < 0 || position >= money.Indices.Count)
+                {
+                    result.AddError($"Exchange rate '{rate.Parameter}' is indexed by {set} but '{money.Name}' is not", lineNumber);
+                    return null;
+                }
+                indices.Add(money.Indices[position]);
+            }
+            return $"{rate.Parameter}[{string.Join("][", indices)}]";
+        }
+
+        private ExchangeRate? Rate(Reference reference) => Rates.FirstOrDefault(r => r.Parameter == reference.Name);
+
+        /// <summary>
+        /// Currencies of the money in a term, after the conversions by the rates it is multiplied by
+        /// </summary>
+        private SortedSet<string> CurrenciesOf(List<Reference> term)
+        {
+            var currencies = new SortedSet<string>(term.Where(r => Money.ContainsKey(r.Name)).Select(r => Money[r.Name]), StringComparer.Ordinal);
+            foreach (var rate in term.Select(Rate).OfType<ExchangeRate>())
+            {
+                if (currencies.Remove(rate.From))
+                    currencies.Add(rate.To);
+            }
+            return currencies;
+        }
+
+        private class Reference
+        {
+            public string Name { get; init; } = "";
+            public int Start { get; init; }
+            public int End { get; init; }
+
+            /// <summary>
+            /// Index expressions of the subscripts ("p", "t" for "cost[p][t]" or "cost[p, t]")
+            /// </summary>
+            public List<string> Indices { get; } = new List<string>();
+        }
+
+        /// <summary>
+        /// References to money and rates in the additive terms of a statement: its parts between
+        /// '+', '-' and relations outside brackets
+        /// </summary>
+        private List<List<Reference>> Terms(string code)
+        {
+            var tokens = ModelLexer.Tokenize(code);
+            var terms = new List<List<Reference>> { new List<Reference>() };
+            int depth = 0;
+
+            for (int i = 0; i < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                if (token.Text is "(" or "[" or "{")
+                    depth++;
+                else if (token.Text is ")" or "]" or "}")
+                    depth--;
+                else if (depth == 0 && token.Kind == SyntaxTokenKind.Operator && separators.Contains(token.Text))
+                    terms.Add(new List<Reference>());
+                else if (token.Kind == SyntaxTokenKind.Identifier && (i == 0 || tokens[i - 1].Text != ".") &&
+                         (Money.ContainsKey(token.Text) || Rates.Any(r => r.Parameter == token.Text)))
+                {
+                    var reference = Subscripts(code, tokens, ref i);
+                    terms[^1].Add(reference);
+                }
+            }
+            return terms;
+        }
+
+        /// <summary>
+        /// Reads the subscripts after the identifier at <paramref name="index"/>, leaving the
+        /// index at the last token of the reference
+        /// </summary>
+        private static Reference Subscripts(string code, List<SyntaxToken> tokens, ref int index)
+        {
+            var name = tokens[index];
+            int end = name.Offset + name.Length;
+            var indices = new List<string>();
+
+            while (index + 1 < tokens.Count && tokens[index + 1].Text == "[")
+            {
+                int open = index + 1;
+                int depth = 0;
+                int close = open;
+                for (; close < tokens.Count; close++)
+                {
+                    if (tokens[close].Text is "(" or "[" or "{")
+                        depth++;
+                    else if (tokens[close].Text is ")" or "]" or "}" && --depth == 0)
+                        break;
+                }
+                if (close == tokens.Count)
+                    break;
+
+                int start = tokens[open].Offset + 1;
+                indices.AddRange(SplitTopLevel(code.Substring(start, tokens[close].Offset - start)));
+                end = tokens[close].Offset + 1;
+                index = close;
+            }
+
+            var reference = new Reference { Name = name.Text, Start = name.Offset, End = end };
+            reference.Indices.AddRange(indices);
+            return reference;
+        }
+
+        /// <summary>
+        /// Index sets of a declaration: "[p in Plants][T]" and "[Plants, T]" give Plants, T
+        /// </summary>
+        private static List<string> IndexSetsOf(string code)
+        {
+            var match = declarationPattern.Match(code);
+            if (!match.Success || match.Groups[2].Length == 0)
+                return new List<string>();
+
+            return match.Groups[2].Value.Split(new[] { '[', ']' }, StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries)
+                .SelectMany(SplitTopLevel)
+                .Select(part => Regex.Match(part, @"^\w+\s+in\s+(.+)$") is { Success: true } m ? m.Groups[1].Value.Trim() : part)
+                .ToList();
+        }
+
+        private static IEnumerable<string> SplitTopLevel(string text)
+        {
+            int depth = 0;
+            int start = 0;
+            for (int i = 0; i < text.Length; i++)
+            {
+                if (text[i] is '(' or '[' or '{' or '<')
+                    depth++;
+                else if (text[i] is ')' or ']' or '}' or '>')
+                    depth--;
+                else if (text[i] == ',' && depth == 0)
+                {
+                    yield return text.Substring(start, i - start).Trim();
+                    start = i + 1;
+                }
+            }
+            if (text.Substring(start).Trim() is { Length: > 0 } last)
+                yield return last;
+        }
+    }
+}
@@ -0,0 +1,202 @@
+using System.Globalization;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// How numbers and dates are written in data files. The default is the invariant culture:
+    /// "3.14" and ISO dates. With a decimal-comma culture such as de-DE "3,14" is one number, and a
+    /// comma followed by a digit is a decimal comma rather than a separator of list elements or
+    /// tuple fields ("[3,14 2,5]" or "[3,14, 2,5]"). Group separators are never accepted, so
+    /// "3,14" is either 3.14 or an error, never 314.
+    /// <para>
+    /// With a date format, string values written in it are stored as ISO dates (yyyy-MM-dd), so a
+    /// model compares and sorts them the same whatever the data file's locale. Columns (a
+    /// parameter, a set or a tuple field as "set.field") can override the culture and date format.
+    /// The settings are read from a .datalocale.json file:
+    /// <code>
+    /// {
+    ///   "culture": "de-DE",
+    ///   "dateFormat": "dd.MM.yyyy",
+    ///   "columns": {
+    ///     "exchangeRate": { "culture": "invariant" },
+    ///     "Orders.due": { "dateFormat": "yyyy-MM-dd" }
+    ///   }
+    /// }
+    /// </code>
+    /// </para>
+    /// </summary>
+    public class DataLocale
+    {
+        public const string FileName = ".datalocale.json";
+
+        public const string IsoDateFormat = "yyyy-MM-dd";
+
+        public static DataLocale Invariant { get; } = new DataLocale();
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            ReadCommentHandling = JsonCommentHandling.Skip,
+            AllowTrailingCommas = true
+        };
+
+        public CultureInfo Culture { get; init; } = CultureInfo.InvariantCulture;
+
+        /// <summary>
+        /// .NET format of dates in string values ("dd.MM.yyyy"), or null to read strings as they are
+        /// </summary>
+        public string? DateFormat { get; init; }
+
+        /// <summary>
+        /// Overrides by column: a parameter or set name, or "tupleSet.field"
+        /// </summary>
+        public IReadOnlyDictionary<string, DataLocale> Columns { get; init; } = new Dictionary<string, DataLocale>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Path the settings were read from, or null
+        /// </summary>
+        public string? Path { get; private set; }
+
+        public bool DecimalComma => Culture.NumberFormat.NumberDecimalSeparator == ",";
+
+        /// <summary>
+        /// The locale of one column: its override, or this locale
+        /// </summary>
+        public DataLocale For(string column) => Columns.TryGetValue(column, out var locale) ? locale : this;
+
+        /// <summary>
+        /// A locale for a culture name ("de-DE", "invariant") and an optional date format
+        /// </summary>
+        public static DataLocale Create(string culture, string? dateFormat = null) => new DataLocale
+        {
+            Culture = CultureOf(culture),
+            DateFormat = dateFormat
+        };
+
+        /// <summary>
+        /// A number in the locale's format: digits, its decimal separator, a sign and an exponent
+        /// </summary>
+        public bool TryParseNumber(string text, out double value) =>
+            double.TryParse(text, NumberStyles.Float, Culture, out value);
+
+        public bool TryParseInteger(string text, out int value) =>
+            int.TryParse(text, NumberStyles.Integer, Culture, out value);
+
+        /// <summary>
+        /// A date in the locale's date format or in ISO form
+        /// </summary>
+        public bool TryParseDate(string text, out DateTime date)
+        {
+            if (DateFormat != null && DateTime.TryParseExact(text, DateFormat, Culture, DateTimeStyles.None, out date))
+                return true;
+            return DateTime.TryParseExact(text, IsoDateFormat, CultureInfo.InvariantCulture, DateTimeStyles.None, out date);
+        }
+
+        /// <summary>
+        /// A string value as stored: an ISO date if the locale has a date format and the value is
+        /// written in it, else the value itself
+        /// </summary>
+        public string ReadString(string text) =>
+            DateFormat != null && TryParseDate(text, out var date) ? date.ToString(IsoDateFormat, CultureInfo.InvariantCulture) : text;
+
+        /// <summary>
+        /// A value written in the locale so that reading it back gives the same value: numbers in
+        /// round-trip form, ISO dates in the date format, strings quoted
+        /// </summary>
+        public string Format(object value) => value switch
+        {
+            double number => number.ToString("R", Culture),
+            float number => ((double)number).ToString("R", Culture),
+            int number => number.ToString(Culture),
+            bool flag => flag ? "true" : "false",
+            DateTime date => Quote(FormatDate(date)),
+            string text when DateFormat != null &&
+                DateTime.TryParseExact(text, IsoDateFormat, CultureInfo.InvariantCulture, DateTimeStyles.None, out var date) => Quote(FormatDate(date)),
+            _ => Quote(Convert.ToString(value, CultureInfo.InvariantCulture) ?? "")
+        };
+
+        public string FormatDate(DateTime date) => date.ToString(DateFormat ?? IsoDateFormat, DateFormat == null ? CultureInfo.InvariantCulture : Culture);
+
+        public static DataLocale Parse(string json)
+        {
+            Settings? settings;
+            try
+            {
+                settings = JsonSerializer.Deserialize<Settings>(json, jsonOptions);
+            }
+            catch (JsonException ex)
+            {
+                throw new InvalidOperationException($"Invalid data locale: {ex.Message}", ex);
+            }
+
+            settings ??= new Settings();
+            var culture = settings.Culture == null ? CultureInfo.InvariantCulture : CultureOf(settings.Culture);
+            var columns = new Dictionary<string, DataLocale>(StringComparer.Ordinal);
+
+            // A column override keeps whatever it does not set from the file's settings
+            foreach (var (column, columnSettings) in settings.Columns)
+            {
+                columns[column] = new DataLocale
+                {
+                    Culture = columnSettings.Culture == null ? culture : CultureOf(columnSettings.Culture),
+                    DateFormat = columnSettings.DateFormat ?? settings.DateFormat
+                };
+            }
+            return new DataLocale { Culture = culture, DateFormat = settings.DateFormat, Columns = columns };
+        }
+
+        public static DataLocale Load(string path)
+        {
+            var locale = Parse(File.ReadAllText(path));
+            locale.Path = path;
+            return locale;
+        }
+
+        /// <summary>
+        /// Loads the nearest .datalocale.json in the directory or one of its parents; the invariant
+        /// locale if there is none
+        /// </summary>
+        public static DataLocale Find(string directory)
+        {
+            for (var current = new DirectoryInfo(directory); current != null; current = current.Parent)
+            {
+                string path = System.IO.Path.Combine(current.FullName, FileName);
+                if (File.Exists(path))
+                    return Load(path);
+            }
+            return Invariant;
+        }
+
+        public override string ToString()
+        {
+            string name = Culture.Name.Length == 0 ? "invariant" : Culture.Name;
+            return DateFormat == null ? name : $"{name}, dates {DateFormat}";
+        }
+
+        private static CultureInfo CultureOf(string name)
+        {
+            if (name.Length == 0 || name.Equals("invariant", StringComparison.OrdinalIgnoreCase))
+                return CultureInfo.InvariantCulture;
+            try
+            {
+                return CultureInfo.GetCultureInfo(name, predefinedOnly: true);
+            }
+            catch (CultureNotFoundException)
+            {
+                throw new InvalidOperationException($"Unknown culture '{name}'");
+            }
+        }
+
+        private static string Quote(string text) => "\"" + text + "\"";
+
+        private class Settings
+        {
+            public string? Culture { get; init; }
+            public string? DateFormat { get; init; }
+            public Dictionary<string, Settings> Columns { get; init; } = new Dictionary<string, Settings>(StringComparer.Ordinal);
+        }
+    }
+}
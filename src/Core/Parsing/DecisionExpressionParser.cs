@@ -226,7 +226,7 @@ namespace Core.Parsing
                         !modelManager.Ranges.ContainsKey(setName) &&
                         !modelManager.Sets.ContainsKey(setName))
                     {
-                        error = $"Set '{setName}' not found for summation";
+                        error = SymbolSuggester.Annotate(modelManager, $"Set '{setName}' not found for summation", setName, SymbolKind.Set);
                         return new ConstantExpression(0);
                     }
                     
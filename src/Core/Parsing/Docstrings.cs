@@ -0,0 +1,270 @@
+using System.Text;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// Documentation strings of declared entities, written before the declaration as a
+    /// triple-quoted block or as "#:" line comments, or as a "#:" comment after it on the same line:
+    /// <code>
+    /// """Capacity of each plant,
+    ///    in tonnes per day"""
+    /// float capacity[Plants] = ...;
+    /// #: Amount shipped from a plant to a market
+    /// dvar float+ ship[Plants][Markets];
+    /// range Days = 1..7; #: Planning horizon
+    /// </code>
+    /// Docstrings are trivia to the parser. They are read into ModelManager.Documentation and the
+    /// Description of sets, parameters and variables, and shown in hovers and generated documentation.
+    /// </summary>
+    public static class Docstrings
+    {
+        private const string Quotes = "\"\"\"";
+        private const string LinePrefix = "#:";
+
+        /// <summary>
+        /// Docstrings of a model text by entity key ("parameter:capacity", "constraint:cap", "objective").
+        /// Docstrings that do not belong to a named declaration are reported as warnings and
+        /// unterminated triple-quoted blocks as errors.
+        /// </summary>
+        public static Dictionary<string, string> Extract(string modelText, ParseSessionResult? result = null)
+        {
+            var documentation = new Dictionary<string, string>(StringComparer.Ordinal);
+            if (!modelText.Contains(LinePrefix) && !modelText.Contains(Quotes))
+                return documentation;
+
+            var source = ModelSource.Parse(modelText);
+            ModelStatement? previous = null;
+
+            void Add(ModelStatement? owner, int lineNumber, string content, bool closed)
+            {
+                if (!closed)
+                {
+                    result?.AddError("Unterminated docstring (missing closing \"\"\")", lineNumber);
+                    return;
+                }
+
+                if (owner?.Key == null)
+                {
+                    result?.AddWarning($"Line {lineNumber}: docstring does not document a named declaration");
+                    return;
+                }
+
+                documentation[owner.Key] = documentation.TryGetValue(owner.Key, out var existing)
+                    ? existing + "\n" + content
+                    : content;
+            }
+
+            foreach (var statement in source.Statements)
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                int firstLineBreak = trivia.IndexOf('\n');
+                int triviaLines = trivia.Count(c => c == '\n');
+
+                foreach (var (offset, content, closed) in Find(trivia))
+                {
+                    int lineNumber = statement.LineNumber - triviaLines + trivia.Take(offset).Count(c => c == '\n');
+                    bool trailing = previous != null && (firstLineBreak < 0 || offset < firstLineBreak) && !content.Contains('\n');
+                    Add(trailing ? previous : statement, lineNumber, content, closed);
+                }
+
+                previous = statement;
+            }
+
+            if (previous != null)
+            {
+                string trailer = source.Trailer;
+                int firstLineBreak = trailer.IndexOf('\n');
+                int line = previous.LineNumber + previous.Code.Count(c => c == '\n');
+
+                foreach (var (offset, content, closed) in Find(trailer))
+                {
+                    bool trailing = firstLineBreak < 0 || offset < firstLineBreak;
+                    Add(trailing ? previous : null, line + trailer.Take(offset).Count(c => c == '\n'), content, closed);
+                }
+            }
+
+            return documentation;
+        }
+
+        /// <summary>
+        /// Reads the docstrings of a model text into the manager's documentation
+        /// </summary>
+        public static void Read(ModelManager manager, string modelText, ParseSessionResult result)
+        {
+            foreach (var (key, text) in Extract(modelText, result))
+                manager.Documentation[key] = text;
+        }
+
+        /// <summary>
+        /// Copies the documentation into the Description of the parsed sets, parameters and variables
+        /// </summary>
+        public static void Apply(ModelManager manager)
+        {
+            foreach (var (key, text) in manager.Documentation)
+            {
+                int colon = key.IndexOf(':');
+                if (colon < 0)
+                    continue;
+
+                string name = key.Substring(colon + 1);
+                switch (key.Substring(0, colon))
+                {
+                    case "set":
+                        if (manager.Ranges.TryGetValue(name, out var range)) range.Description = text;
+                        if (manager.IndexSets.TryGetValue(name, out var indexSet)) indexSet.Description = text;
+                        if (manager.PrimitiveSets.TryGetValue(name, out var primitiveSet)) primitiveSet.Description = text;
+                        if (manager.TupleSets.TryGetValue(name, out var tupleSet)) tupleSet.Description = text;
+                        break;
+
+                    case "parameter":
+                        if (manager.Parameters.TryGetValue(name, out var parameter)) parameter.Description = text;
+                        break;
+
+                    case "variable":
+                        if (manager.IndexedVariables.TryGetValue(name, out var variable)) variable.Description = text;
+                        break;
+                }
+            }
+        }
+
+        /// <summary>
+        /// The model text with every docstring blanked out; line breaks are kept so line numbers still match
+        /// </summary>
+        public static string Strip(string text)
+        {
+            if (!text.Contains(LinePrefix) && !text.Contains(Quotes))
+                return text;
+
+            var sb = new StringBuilder(text.Length);
+            int i = 0;
+            while (i < text.Length)
+            {
+                int length = Length(text, i);
+                if (length > 0)
+                {
+                    sb.Append('\n', text.Substring(i, length).Count(c => c == '\n'));
+                    i += length;
+                    continue;
+                }
+
+                int skip = 1;
+                if (text[i] == '"')
+                {
+                    int close = text.IndexOfAny(new[] { '"', '\n' }, i + 1);
+                    skip = (close < 0 ? text.Length : close + 1) - i;
+                }
+                else if (string.CompareOrdinal(text, i, "//", 0, 2) == 0)
+                {
+                    int end = text.IndexOf('\n', i);
+                    skip = (end < 0 ? text.Length : end) - i;
+                }
+                else if (string.CompareOrdinal(text, i, "/*", 0, 2) == 0)
+                {
+                    int end = text.IndexOf("*/", i + 2, StringComparison.Ordinal);
+                    skip = (end < 0 ? text.Length : end + 2) - i;
+                }
+
+                sb.Append(text, i, skip);
+                i += skip;
+            }
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Length of the docstring starting at <paramref name="start"/>, or 0 if none starts there
+        /// </summary>
+        internal static int Length(string text, int start)
+        {
+            if (string.CompareOrdinal(text, start, LinePrefix, 0, LinePrefix.Length) == 0)
+            {
+                int end = text.IndexOf('\n', start);
+                return (end < 0 ? text.Length : end) - start;
+            }
+
+            if (string.CompareOrdinal(text, start, Quotes, 0, Quotes.Length) == 0)
+            {
+                int close = text.IndexOf(Quotes, start + Quotes.Length, StringComparison.Ordinal);
+                return (close < 0 ? text.Length : close + Quotes.Length) - start;
+            }
+
+            return 0;
+        }
+
+        /// <summary>
+        /// Docstrings in a run of trivia: offset, text and whether it is terminated.
+        /// Consecutive "#:" lines form one docstring; a blank line or a comment ends it.
+        /// </summary>
+        private static IEnumerable<(int Offset, string Content, bool Closed)> Find(string trivia)
+        {
+            var lines = new List<string>();
+            int linesOffset = 0;
+            int linesEnd = 0;
+            int i = 0;
+
+            while (i < trivia.Length)
+            {
+                int length = Length(trivia, i);
+                if (length == 0)
+                {
+                    i += char.IsWhiteSpace(trivia[i]) ? 1 : CommentLength(trivia, i);
+                    continue;
+                }
+
+                if (lines.Count > 0 && (trivia[i] != '#' || trivia.Substring(linesEnd, i - linesEnd).Count(c => c == '\n') > 1 ||
+                                        !string.IsNullOrWhiteSpace(trivia.Substring(linesEnd, i - linesEnd))))
+                {
+                    yield return (linesOffset, string.Join("\n", lines), true);
+                    lines.Clear();
+                }
+
+                if (trivia[i] == '#')
+                {
+                    if (lines.Count == 0)
+                        linesOffset = i;
+                    string line = trivia.Substring(i + LinePrefix.Length, length - LinePrefix.Length).TrimEnd();
+                    lines.Add(line.StartsWith(' ') ? line.Substring(1) : line);
+                    linesEnd = i + length;
+                }
+                else
+                {
+                    bool closed = length >= 2 * Quotes.Length && string.CompareOrdinal(trivia, i + length - Quotes.Length, Quotes, 0, Quotes.Length) == 0;
+                    string body = trivia.Substring(i + Quotes.Length, length - Quotes.Length - (closed ? Quotes.Length : 0));
+                    yield return (i, Dedent(body), closed);
+                }
+
+                i += length;
+            }
+
+            if (lines.Count > 0)
+                yield return (linesOffset, string.Join("\n", lines), true);
+        }
+
+        private static int CommentLength(string trivia, int start)
+        {
+            if (string.CompareOrdinal(trivia, start, "//", 0, 2) == 0)
+            {
+                int end = trivia.IndexOf('\n', start);
+                return (end < 0 ? trivia.Length : end) - start;
+            }
+            if (string.CompareOrdinal(trivia, start, "/*", 0, 2) == 0)
+            {
+                int end = trivia.IndexOf("*/", start + 2, StringComparison.Ordinal);
+                return (end < 0 ? trivia.Length : end + 2) - start;
+            }
+            return 1;
+        }
+
+        /// <summary>
+        /// Trims each line and drops leading and trailing blank lines of a triple-quoted block
+        /// </summary>
+        private static string Dedent(string body)
+        {
+            var lines = body.Replace("\r\n", "\n").Split('\n').Select(l => l.Trim()).ToList();
+            while (lines.Count > 0 && lines[0].Length == 0)
+                lines.RemoveAt(0);
+            while (lines.Count > 0 && lines[^1].Length == 0)
+                lines.RemoveAt(lines.Count - 1);
+            return string.Join("\n", lines);
+        }
+    }
+}
@@ -64,6 +64,7 @@ namespace Core.Parsing
             ParseSignConstraint(signConstraint, out double? lowerBound, out double? upperBound);
 
             List<(double Lo, double Hi)>? semiContinuousRanges = null;
+            string? lowerExpression = null, upperExpression = null;
             if (!string.IsNullOrEmpty(boundsExpr))
             {
                 if (boundsExpr.Contains('|'))
@@ -77,7 +78,8 @@ namespace Core.Parsing
                 }
                 else
                 {
-                    if (!ParseBounds(boundsExpr, out var lb, out var ub, out error))
+                    var iterators = string.IsNullOrEmpty(indexingPart) ? null : ParseIndexIterators(indexingPart);
+                    if (!ParseBounds(boundsExpr, iterators, out var lb, out var ub, out lowerExpression, out upperExpression, out error))
                         return false;
                     if (lb.HasValue) lowerBound = lb.Value;
                     if (ub.HasValue) upperBound = ub.Value;
@@ -90,6 +92,8 @@ namespace Core.Parsing
                 {
                     LowerBound = lowerBound,
                     UpperBound = upperBound,
+                    LowerBoundExpression = lowerExpression,
+                    UpperBoundExpression = upperExpression,
                     SemiContinuousRanges = semiContinuousRanges
                 };
                 return true;
@@ -107,6 +111,9 @@ namespace Core.Parsing
 
             variable = CreateVariable(varName, varType, indexSets, lowerBound, upperBound);
             if (semiContinuousRanges != null) variable.SemiContinuousRanges = semiContinuousRanges;
+            variable.LowerBoundExpression = lowerExpression;
+            variable.UpperBoundExpression = upperExpression;
+            variable.IndexIterators = ParseIndexIterators(indexingPart);
             return true;
         }
 
@@ -124,10 +131,11 @@ namespace Core.Parsing
             VariableType varType = ParseVariableType(typeStr);
             ParseSignConstraint(signConstraint, out double? lowerBound, out double? upperBound);
 
+            string? lowerExpression = null, upperExpression = null;
             if (!string.IsNullOrEmpty(boundsExpr))
             {
                 // Bounds may contain complex expressions (tuple field access) — be lenient
-                if (ParseBounds(boundsExpr, out var lb, out var ub, out _))
+                if (ParseBounds(boundsExpr, null, out var lb, out var ub, out lowerExpression, out upperExpression, out _))
                 {
                     if (lb.HasValue) lowerBound = lb.Value;
                     if (ub.HasValue) upperBound = ub.Value;
@@ -136,14 +144,16 @@ namespace Core.Parsing
 
             var bracketMatches = Regex.Matches(bracketsStr, @"\[([^\]]+)\]");
             var indexSets = new List<string>();
+            var iterators = new List<string>();
 
             foreach (Match bm in bracketMatches)
             {
                 string content = bm.Groups[1].Value.Trim();
-                var iterMatch = Regex.Match(content, @"^[a-zA-Z][a-zA-Z0-9_]*\s+in\s+([a-zA-Z][a-zA-Z0-9_]*)$");
+                var iterMatch = Regex.Match(content, @"^([a-zA-Z][a-zA-Z0-9_]*)\s+in\s+([a-zA-Z][a-zA-Z0-9_]*)$");
                 if (iterMatch.Success)
                 {
-                    indexSets.Add(iterMatch.Groups[1].Value.Trim());
+                    iterators.Add(iterMatch.Groups[1].Value);
+                    indexSets.Add(iterMatch.Groups[2].Value.Trim());
                 }
                 else if (Regex.IsMatch(content, @"^[a-zA-Z][a-zA-Z0-9_]*$"))
                 {
@@ -160,6 +170,9 @@ namespace Core.Parsing
                 return false;
 
             variable = CreateVariable(varName, varType, indexSets, lowerBound, upperBound);
+            variable.LowerBoundExpression = lowerExpression;
+            variable.UpperBoundExpression = upperExpression;
+            variable.IndexIterators = iterators.Count == indexSets.Count ? iterators : null;
             return true;
         }
 
@@ -191,7 +204,7 @@ namespace Core.Parsing
                     !modelManager.TupleSchemas.ContainsKey(setName) &&
                     !modelManager.ComputedSets.ContainsKey(setName))
                 {
-                    error = $"Index set '{setName}' not found";
+                    error = SymbolSuggester.Annotate(modelManager, $"Index set '{setName}' not found", setName, SymbolKind.Set | SymbolKind.TupleSet);
                     return false;
                 }
             }
@@ -259,8 +272,23 @@ namespace Core.Parsing
         }
 
         /// <summary>
-        /// Parses bounds expression: "0..100", "minVal..maxVal", "..100", "0.."
+        /// Iterator names of an OPL-style indexing part ("p in P, t in T"), or null if any set is
+        /// named without one
         /// </summary>
+        private static List<string>? ParseIndexIterators(string indexingPart)
+        {
+            var parts = indexingPart.Split(',').Select(p => p.Trim()).Where(p => p.Length > 0).ToList();
+            var iterators = new List<string>();
+            foreach (var part in parts)
+            {
+                var match = Regex.Match(part, @"^([a-zA-Z][a-zA-Z0-9_]*)\s+in\s+[a-zA-Z][a-zA-Z0-9_]*$");
+                if (!match.Success)
+                    return null;
+                iterators.Add(match.Groups[1].Value);
+            }
+            return iterators;
+        }
+
         /// <summary>
         /// Parses semi-continuous bound alternation: lo1..hi1 | lo2..hi2 ...
         /// Returns the list of range segments if alternation is present.
@@ -274,17 +302,31 @@ namespace Core.Parsing
             var result = new List<(double Lo, double Hi)>();
             foreach (var seg in segments)
             {
-                if (!ParseBounds(seg, out double? lo, out double? hi, out error))
+                if (!ParseBounds(seg, null, out double? lo, out double? hi, out var loExpression, out var hiExpression, out error))
                     return null;
+                if (loExpression != null || hiExpression != null)
+                {
+                    error = $"Semi-continuous ranges must be numbers: '{seg}'";
+                    return null;
+                }
                 result.Add((lo ?? 0, hi ?? double.PositiveInfinity));
             }
             return result;
         }
 
-        private bool ParseBounds(string boundsExpr, out double? lowerBound, out double? upperBound, out string error)
+        /// <summary>
+        /// Parses bounds expression: "0..100", "minVal..maxVal", "..100", "0..", or expressions
+        /// over parameters ("0..capacity[p] * availability[p,t]"). A side that is not a number is
+        /// returned as its expression text to be evaluated with the data; a scalar parameter
+        /// that already has a value gives that value as well.
+        /// </summary>
+        private bool ParseBounds(string boundsExpr, List<string>? iterators, out double? lowerBound, out double? upperBound,
+            out string? lowerExpression, out string? upperExpression, out string error)
         {
             lowerBound = null;
             upperBound = null;
+            lowerExpression = null;
+            upperExpression = null;
             error = string.Empty;
 
             boundsExpr = boundsExpr.Trim();
@@ -303,55 +345,49 @@ namespace Core.Parsing
                 return false;
             }
 
-            string lowerStr = parts[0].Trim();
-            string upperStr = parts[1].Trim();
+            // Either side may be empty ("..upper", "lower..")
+            if (!ParseBound(parts[0].Trim(), "lower", iterators, out lowerBound, out lowerExpression, out error) ||
+                !ParseBound(parts[1].Trim(), "upper", iterators, out upperBound, out upperExpression, out error))
+                return false;
+
+            return true;
+        }
+
+        private bool ParseBound(string text, string side, List<string>? iterators, out double? value, out string? expression, out string error)
+        {
+            value = null;
+            expression = null;
+            error = string.Empty;
+            if (string.IsNullOrEmpty(text))
+                return true;
 
-            // Parse lower bound (may be empty for "..upper")
-            if (!string.IsNullOrEmpty(lowerStr))
+            if (double.TryParse(text, System.Globalization.NumberStyles.Float,
+                System.Globalization.CultureInfo.InvariantCulture, out double number))
             {
-                if (double.TryParse(lowerStr, System.Globalization.NumberStyles.Float,
-                    System.Globalization.CultureInfo.InvariantCulture, out double lower))
-                {
-                    lowerBound = lower;
-                }
-                else if (modelManager.Parameters.TryGetValue(lowerStr, out var lowerParam))
-                {
-                    // It's a parameter
-                    if (lowerParam.Value != null)
-                    {
-                        lowerBound = Convert.ToDouble(lowerParam.Value);
-                    }
-                }
-                else
-                {
-                    error = $"Invalid lower bound: '{lowerStr}'";
-                    return false;
-                }
+                value = number;
+                return true;
             }
 
-            // Parse upper bound (may be empty for "lower..")
-            if (!string.IsNullOrEmpty(upperStr))
+            if (!Regex.IsMatch(text, @"^[\w\s\[\],.+\-*/()]+$") || (!char.IsLetterOrDigit(text[0]) && text[0] is not ('(' or '-')))
             {
-                if (double.TryParse(upperStr, System.Globalization.NumberStyles.Float,
-                    System.Globalization.CultureInfo.InvariantCulture, out double upper))
-                {
-                    upperBound = upper;
-                }
-                else if (modelManager.Parameters.TryGetValue(upperStr, out var upperParam))
-                {
-                    // It's a parameter
-                    if (upperParam.Value != null)
-                    {
-                        upperBound = Convert.ToDouble(upperParam.Value);
-                    }
-                }
-                else
+                error = $"Invalid {side} bound: '{text}'";
+                return false;
+            }
+
+            // Names other than the iterators, field names (t.cap) and functions must be parameters
+            foreach (Match name in Regex.Matches(text, @"(?<![\w.])([a-zA-Z][a-zA-Z0-9_]*)(?!\s*\()"))
+            {
+                string symbol = name.Groups[1].Value;
+                if (iterators?.Contains(symbol) != true && !modelManager.Parameters.ContainsKey(symbol) && !modelManager.TupleParameters.ContainsKey(symbol))
                 {
-                    error = $"Invalid upper bound: '{upperStr}'";
+                    error = SymbolSuggester.Annotate(modelManager, $"Invalid {side} bound: '{symbol}' is not a declared parameter", symbol, SymbolKind.Parameter);
                     return false;
                 }
             }
 
+            expression = text;
+            if (modelManager.Parameters.TryGetValue(text, out var parameter) && parameter.Value != null)
+                value = Convert.ToDouble(parameter.Value);
             return true;
         }
     }
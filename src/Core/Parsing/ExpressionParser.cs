@@ -203,7 +203,8 @@ namespace Core.Parsing
                 }
                 else
                 {
-                    error = $"Undefined identifier '{coeffStr}' used as coefficient for variable '{variable}'";
+                    error = SymbolSuggester.Annotate(modelManager, $"Undefined identifier '{coeffStr}' used as coefficient for variable '{variable}'",
+                        coeffStr, SymbolKind.Parameter | SymbolKind.DecisionExpression, 0);
                     return false;
                 }
             }
@@ -281,11 +282,13 @@ namespace Core.Parsing
         {
             Expression constant = new ConstantExpression(0);
 
-            // Pattern to find standalone numbers
-            string constantPattern = @"(?:^|(?<=[+\-]))(\d+\.\d+|\d+(?!\.\d))(?![a-zA-Z_*])";
+            // Pattern to find standalone numbers; the lookahead also excludes digits and '.' so the
+            // match cannot stop inside a longer coefficient (e.g. "100" of "1000*y")
+            string constantPattern = @"(?:^|(?<=[+\-]))(\d+\.\d+|\d+)(?![\d.a-zA-Z_*])";
             var constantMatches = Regex.Matches(expression, constantPattern);
 
-            var constantTerms = new List<Expression>();
+            double sum = 0;
+            bool hasConstant = false;
             
             foreach (Match match in constantMatches)
             {
@@ -310,21 +313,15 @@ namespace Core.Parsing
                 string numStr = match.Groups[1].Value;
                 if (double.TryParse(numStr, NumberStyles.Float, CultureInfo.InvariantCulture, out double constValue))
                 {
-                    constantTerms.Add(new ConstantExpression(constValue));
+                    // The sign before the number belongs to it ("x - 3", "<= -3")
+                    bool negative = match.Index > 0 && expression[match.Index - 1] == '-';
+                    sum += negative ? -constValue : constValue;
+                    hasConstant = true;
                 }
             }
 
-            // Combine constant terms
-            if (constantTerms.Count > 0)
-            {
-                constant = constantTerms[0];
-                for (int i = 1; i < constantTerms.Count; i++)
-                {
-                    constant = new BinaryExpression(constant, BinaryOperator.Add, constantTerms[i]);
-                }
-            }
-
-            return constant.Simplify(modelManager);
+            // Folded here rather than as a chain of additions, whose depth grows with the term count
+            return hasConstant ? new ConstantExpression(sum) : constant;
         }
 
         private Expression? ParseTokenizedExpression(string expr, TokenManager tokenManager, out string error)
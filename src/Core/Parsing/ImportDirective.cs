@@ -0,0 +1,77 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// An import statement of a model text. Composed files and workspace documents share one grammar:
+    /// <code>
+    /// import "network.mod" as net;                // all declarations, reached as net.Name
+    /// import Nodes, capacity from "network.mod";  // selected declarations, reached by their own names
+    /// </code>
+    /// ModelComposer resolves the source as a file path, ModelWorkspace as a document name (a file
+    /// name's extension is dropped). Quotes may be left out for a plain name: import Nodes from network;
+    /// </summary>
+    public class ImportDirective
+    {
+        private static readonly Regex pattern = new Regex(
+            @"^[ \t]*import[ \t]+(?:""(?<source>[^""]+)""[ \t]+as[ \t]+(?<alias>[A-Za-z_]\w*)" +
+            @"|(?<names>[A-Za-z_]\w*(?:[ \t]*,[ \t]*[A-Za-z_]\w*)*)[ \t]+from[ \t]+(?:""(?<source>[^""]+)""|(?<source>[\w.\-]+)))[ \t]*;",
+            RegexOptions.Multiline | RegexOptions.Compiled);
+
+        /// <summary>
+        /// File path or document name imported from
+        /// </summary>
+        public string Source { get; init; } = "";
+
+        /// <summary>
+        /// Prefix of an "import ... as alias" directive, null for a selective import
+        /// </summary>
+        public string? Alias { get; init; }
+
+        /// <summary>
+        /// Names of a selective "import A, B from ..." directive, empty for a prefixed import
+        /// </summary>
+        public IReadOnlyList<string> Names { get; init; } = Array.Empty<string>();
+
+        public int LineNumber { get; init; }
+
+        public bool IsSelective => Alias == null;
+
+        public static bool IsUsed(string modelText)
+        {
+            return pattern.IsMatch(modelText);
+        }
+
+        /// <summary>
+        /// Import directives of a model text in the order they appear
+        /// </summary>
+        public static List<ImportDirective> Parse(string modelText)
+        {
+            var directives = new List<ImportDirective>();
+            foreach (Match match in pattern.Matches(modelText))
+            {
+                directives.Add(new ImportDirective
+                {
+                    Source = match.Groups["source"].Value,
+                    Alias = match.Groups["alias"].Success ? match.Groups["alias"].Value : null,
+                    Names = match.Groups["names"].Value.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries),
+                    LineNumber = modelText.Take(match.Index).Count(c => c == '\n') + 1
+                });
+            }
+            return directives;
+        }
+
+        /// <summary>
+        /// Blanks out import directives so line numbers in parse errors stay correct
+        /// </summary>
+        public static string Blank(string modelText)
+        {
+            return pattern.Replace(modelText, m => new string(' ', m.Length));
+        }
+
+        public override string ToString()
+        {
+            return IsSelective ? $"import {string.Join(", ", Names)} from \"{Source}\";" : $"import \"{Source}\" as {Alias};";
+        }
+    }
+}
@@ -20,7 +20,7 @@ namespace Core.Parsing
             error = string.Empty;
 
             // Pattern: range Name = a..b
-            string rangePattern = @"^\s*range\s+([a-zA-Z][a-zA-Z0-9]*)\s*=\s*([a-zA-Z0-9]+)\.\.([a-zA-Z0-9]+)$";
+            string rangePattern = @"^\s*range\s+([a-zA-Z][a-zA-Z0-9_]*)\s*=\s*([a-zA-Z0-9_]+)\.\.([a-zA-Z0-9_]+)$";
             var rangeMatch = Regex.Match(statement.Trim(), rangePattern);
             if (rangeMatch.Success)
             {
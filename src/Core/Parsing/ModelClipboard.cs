@@ -0,0 +1,256 @@
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Analysis;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// One declaration of a copied fragment
+    /// </summary>
+    public class FragmentEntry
+    {
+        public string Key { get; init; } = "";
+
+        /// <summary>
+        /// Statement code without leading comments
+        /// </summary>
+        public string Code { get; init; } = "";
+
+        /// <summary>
+        /// True if the declaration was not selected but is referenced by the selection
+        /// </summary>
+        public bool Dependency { get; init; }
+
+        public override string ToString() => Dependency ? $"{Key} (dependency)" : Key;
+    }
+
+    /// <summary>
+    /// A self-contained selection of declarations, as it travels between models: the selected
+    /// statements and every set, parameter, variable and decision expression they reference,
+    /// in the order of the model they were copied from
+    /// </summary>
+    public class ModelFragment
+    {
+        public const string Format = "modeledit-fragment";
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingDefault,
+            WriteIndented = true
+        };
+
+        [JsonPropertyName("format")]
+        public string FormatName { get; init; } = Format;
+
+        /// <summary>
+        /// Name of the model the fragment was copied from, for display only
+        /// </summary>
+        public string Source { get; init; } = "";
+
+        public List<FragmentEntry> Entries { get; init; } = new List<FragmentEntry>();
+
+        public IEnumerable<string> Keys => Entries.Select(e => e.Key);
+
+        public string ToJson() => JsonSerializer.Serialize(this, jsonOptions);
+
+        public static ModelFragment FromJson(string json)
+        {
+            var fragment = JsonSerializer.Deserialize<ModelFragment>(json, jsonOptions)
+                ?? throw new InvalidOperationException("Fragment is empty");
+            if (fragment.FormatName != Format)
+                throw new InvalidOperationException($"Not a model fragment (format '{fragment.FormatName}')");
+            return fragment;
+        }
+
+        /// <summary>
+        /// The statements as model text
+        /// </summary>
+        public override string ToString() => string.Join(Environment.NewLine, Entries.Select(e => e.Code)) + Environment.NewLine;
+    }
+
+    /// <summary>
+    /// Outcome of pasting a fragment into a model
+    /// </summary>
+    public class PasteResult
+    {
+        public string ModelText { get; internal set; } = "";
+
+        /// <summary>
+        /// The edits that turn the target model into ModelText, for hosts that apply them themselves
+        /// </summary>
+        public ChangeSet Changes { get; init; } = new ChangeSet();
+
+        /// <summary>
+        /// Keys of the declarations the paste added, as named in the target
+        /// </summary>
+        public List<string> Added { get; } = new List<string>();
+
+        /// <summary>
+        /// Dependencies the target already declares identically; references are bound to them
+        /// </summary>
+        public List<string> Reused { get; } = new List<string>();
+
+        /// <summary>
+        /// Fragment names that collided with a name of the target, to the name they were pasted under
+        /// </summary>
+        public Dictionary<string, string> Renamed { get; } = new Dictionary<string, string>(StringComparer.Ordinal);
+    }
+
+    /// <summary>
+    /// Copy and paste of entity subtrees between models. A selection is a list of entity keys
+    /// ("constraint:balance"), plain names ("balance") or blocks ("block:hydro", which takes
+    /// every declaration of the @block and its nested blocks). Copying adds everything the
+    /// selected statements reference, so the fragment parses on its own. Pasting reuses
+    /// dependencies the target declares with the same code, renames every other colliding
+    /// name to the first free "name_2", "name_3", ... and rewrites the pasted statements to
+    /// the new names. Statements that declare no entity (unlabeled constraints) are not copied.
+    /// </summary>
+    public static class ModelClipboard
+    {
+        public const string BlockPrefix = "block:";
+
+        public static ModelFragment CopySelection(string modelText, IEnumerable<string> selection, string sourceName = "")
+        {
+            var source = ModelSource.Parse(modelText);
+            var declared = source.Statements.Where(s => s.Key != null && s.Key.Contains(':')).ToList();
+            var byName = declared
+                .GroupBy(s => NameOf(s.Key!), StringComparer.Ordinal)
+                .ToDictionary(g => g.Key, g => g.ToList(), StringComparer.Ordinal);
+
+            var selected = new HashSet<string>(StringComparer.Ordinal);
+            ModelTags? tags = null;
+            foreach (string item in selection)
+            {
+                if (item.StartsWith(BlockPrefix, StringComparison.Ordinal))
+                {
+                    tags ??= ModelTags.Parse(modelText);
+                    string block = TagPath.Normalize(item.Substring(BlockPrefix.Length));
+                    var members = tags.Entities.Where(e => e.Block != null && TagPath.Matches(e.Block, block)).ToList();
+                    if (members.Count == 0)
+                        throw new InvalidOperationException($"Block '{block}' has no declarations");
+                    selected.UnionWith(members.Select(e => e.Key));
+                }
+                else if (item.Contains(':') || item == "objective")
+                {
+                    if (source.Find(item) == null)
+                        throw new InvalidOperationException($"'{item}' is not declared");
+                    selected.Add(item);
+                }
+                else
+                {
+                    if (!byName.TryGetValue(item, out var matches))
+                        throw new InvalidOperationException($"'{item}' is not declared");
+                    if (matches.Count > 1)
+                        throw new InvalidOperationException($"'{item}' is ambiguous: {string.Join(", ", matches.Select(m => m.Key))}");
+                    selected.Add(matches[0].Key!);
+                }
+            }
+
+            // Dependencies are followed transitively; constraints and the objective are never pulled in
+            var included = new HashSet<string>(selected, StringComparer.Ordinal);
+            var pending = new Queue<string>(selected);
+            while (pending.Count > 0)
+            {
+                var statement = source.Find(pending.Dequeue())!;
+                foreach (string name in ReferencedNames(statement.Code))
+                {
+                    if (!byName.TryGetValue(name, out var matches))
+                        continue;
+                    foreach (var dependency in matches.Where(m => IsDependencyKind(m.Key!)))
+                    {
+                        if (included.Add(dependency.Key!))
+                            pending.Enqueue(dependency.Key!);
+                    }
+                }
+            }
+
+            var fragment = new ModelFragment { Source = sourceName };
+            foreach (var statement in source.Statements.Where(s => s.Key != null && included.Contains(s.Key)))
+                fragment.Entries.Add(new FragmentEntry { Key = statement.Key!, Code = statement.Code.Trim(), Dependency = !selected.Contains(statement.Key!) });
+            return fragment;
+        }
+
+        /// <summary>
+        /// Pastes a fragment into a model text. Throws if the fragment carries an objective and
+        /// the target already has a different one, since objectives cannot be renamed.
+        /// </summary>
+        public static PasteResult PasteSelection(string modelText, ModelFragment fragment)
+        {
+            var source = ModelSource.Parse(modelText);
+            var taken = new HashSet<string>(
+                source.Statements.Where(s => s.Key != null && s.Key.Contains(':')).Select(s => NameOf(s.Key!)),
+                StringComparer.Ordinal);
+            var pastedNames = fragment.Entries.Where(e => e.Key.Contains(':')).Select(e => NameOf(e.Key)).ToHashSet(StringComparer.Ordinal);
+
+            var changes = new ChangeSet { Title = $"Paste {fragment.Entries.Count} declaration(s){(fragment.Source.Length > 0 ? " from " + fragment.Source : "")}" };
+            var result = new PasteResult { Changes = changes };
+            var renames = new Dictionary<string, string>(StringComparer.Ordinal);
+
+            // Entries come in source order, so the names a statement references are settled before it is rebound
+            foreach (var entry in fragment.Entries)
+            {
+                string code = SymbolTable.ReplaceNames(entry.Code, n => renames.TryGetValue(n, out var renamed) ? renamed : null);
+                var existing = source.Find(entry.Key);
+
+                if (!entry.Key.Contains(':'))
+                {
+                    if (existing != null && existing.Code.Trim() != code)
+                        throw new InvalidOperationException("The target model already has an objective; remove it or leave the objective out of the selection");
+                    if (existing == null)
+                    {
+                        changes.Edits.Add(ModelEdit.Upsert(code));
+                        result.Added.Add(entry.Key);
+                    }
+                    continue;
+                }
+
+                string name = NameOf(entry.Key);
+                if (entry.Dependency && existing != null && existing.Code.Trim() == code)
+                {
+                    result.Reused.Add(entry.Key);
+                    continue;
+                }
+
+                if (taken.Contains(name))
+                {
+                    string free = name;
+                    for (int n = 2; taken.Contains(free) || pastedNames.Contains(free); n++)
+                        free = $"{name}_{n}";
+
+                    renames[name] = free;
+                    result.Renamed[name] = free;
+                    code = SymbolTable.ReplaceNames(entry.Code, n => renames.TryGetValue(n, out var renamed) ? renamed : null);
+                    name = free;
+                }
+
+                taken.Add(name);
+                var edit = ModelEdit.Upsert(code);
+                changes.Edits.Add(edit);
+                result.Added.Add(edit.Key!);
+            }
+
+            changes.Apply(source);
+            result.ModelText = source.ToString();
+            return result;
+        }
+
+        private static HashSet<string> ReferencedNames(string code)
+        {
+            var names = new HashSet<string>(StringComparer.Ordinal);
+            SymbolTable.ReplaceNames(code, n =>
+            {
+                names.Add(n);
+                return null;
+            });
+            return names;
+        }
+
+        private static bool IsDependencyKind(string key)
+        {
+            return !key.StartsWith("constraint:", StringComparison.Ordinal) && key != "objective";
+        }
+
+        private static string NameOf(string key) => key.Substring(key.IndexOf(':') + 1);
+    }
+}
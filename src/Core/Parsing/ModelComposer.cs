@@ -0,0 +1,264 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// One model file of a composition, rewritten so its names carry the prefix it was imported under
+    /// </summary>
+    public class ComposedUnit
+    {
+        public string FilePath { get; init; } = "";
+
+        /// <summary>
+        /// Import prefix of the unit ("net", "plant__net"), empty for the root file
+        /// </summary>
+        public string Prefix { get; init; } = "";
+
+        /// <summary>
+        /// Model text to parse; import and export statements are blanked so line numbers still match the file
+        /// </summary>
+        public string Text { get; internal set; } = "";
+
+        /// <summary>
+        /// Names visible through the prefix (its declarations and re-exports) to their qualified names
+        /// </summary>
+        public Dictionary<string, string> Exports { get; } = new Dictionary<string, string>(StringComparer.Ordinal);
+
+        public override string ToString() => Prefix.Length == 0 ? Path.GetFileName(FilePath) : $"{Path.GetFileName(FilePath)} as {Prefix}";
+    }
+
+    /// <summary>
+    /// Composes a model from files that import each other under a prefix:
+    /// <code>
+    /// import "network.mod" as net;
+    /// import Periods from "calendar.mod";
+    /// export net.Nodes;
+    /// dvar float+ flow[net.Arcs][Periods];
+    /// </code>
+    /// The top-level declarations of an imported file are reached as "net.Name" and parsed as
+    /// "net__Name", so two imports of the same sub-model stay apart. A selective import reaches the
+    /// named declarations by their own names, through the file's prefix or one named after the
+    /// file ("calendar").
+    /// Names a file imports are its own unless it re-exports them with "export alias.Name;". Import
+    /// paths are resolved against the importing file's directory and then the search paths, in
+    /// order; the first match wins.
+    /// Units come out dependencies first, the root last, ready for ModelParsingService.
+    /// </summary>
+    public class ModelComposer
+    {
+        private static readonly Regex exportPattern = new Regex(
+            @"^[ \t]*export[ \t]+(?<names>[A-Za-z_]\w*[ \t]*\.[ \t]*[A-Za-z_]\w*(?:[ \t]*,[ \t]*[A-Za-z_]\w*[ \t]*\.[ \t]*[A-Za-z_]\w*)*)[ \t]*;",
+            RegexOptions.Multiline);
+
+        private static readonly Regex scopedPattern = new Regex(@"^[ \t]*//[ \t]*@scoped\b", RegexOptions.Multiline);
+
+        private readonly List<ComposedUnit> units = new List<ComposedUnit>();
+        private readonly List<string> stack = new List<string>();
+
+        /// <summary>
+        /// Directories searched, in order, for imports not found next to the importing file
+        /// </summary>
+        public List<string> SearchPaths { get; } = new List<string>();
+
+        public IReadOnlyList<ComposedUnit> Units => units;
+
+        /// <summary>
+        /// Missing files, cycles, unknown aliases and names not exported, as "file line n: message"
+        /// </summary>
+        public List<string> Errors { get; } = new List<string>();
+
+        public static bool HasImports(string modelText)
+        {
+            return ImportDirective.IsUsed(modelText);
+        }
+
+        /// <summary>
+        /// Reads a root model file and everything it imports
+        /// </summary>
+        public static ModelComposer Compose(string rootPath, IEnumerable<string>? searchPaths = null)
+        {
+            var composer = new ModelComposer();
+            if (searchPaths != null)
+                composer.SearchPaths.AddRange(searchPaths);
+            composer.Add(Path.GetFullPath(rootPath), "");
+            return composer;
+        }
+
+        private ComposedUnit? Add(string path, string prefix)
+        {
+            if (stack.Contains(path, StringComparer.Ordinal))
+            {
+                Errors.Add($"Circular import: {string.Join(" -> ", stack.Skip(stack.IndexOf(path)).Append(path).Select(Path.GetFileName))}");
+                return null;
+            }
+
+            string name = Path.GetFileName(path);
+            string text = File.ReadAllText(path);
+            stack.Add(path);
+
+            var directives = ImportDirective.Parse(text);
+            var imports = new Dictionary<string, (ComposedUnit? Unit, int LineNumber)>(StringComparer.Ordinal);
+            var fileAliases = new Dictionary<string, string>(StringComparer.Ordinal);
+            foreach (var directive in directives.Where(d => !d.IsSelective))
+            {
+                int line = directive.LineNumber;
+                string alias = directive.Alias!;
+                if (imports.TryGetValue(alias, out var existing))
+                {
+                    Errors.Add($"{name} line {line}: prefix '{alias}' is already used on line {existing.LineNumber}");
+                    continue;
+                }
+
+                string? resolved = Resolve(directive.Source, Path.GetDirectoryName(path)!);
+                if (resolved == null)
+                {
+                    Errors.Add($"{name} line {line}: imported file '{directive.Source}' not found");
+                    imports[alias] = (null, line);
+                    continue;
+                }
+
+                imports[alias] = (Add(resolved, prefix.Length == 0 ? alias : prefix + SymbolTable.Separator + alias), line);
+                fileAliases.TryAdd(resolved, alias);
+            }
+
+            // Selectively imported names go through the file's prefix, or one named after the file
+            var selected = new Dictionary<string, (string Alias, int LineNumber)>(StringComparer.Ordinal);
+            foreach (var directive in directives.Where(d => d.IsSelective))
+            {
+                int line = directive.LineNumber;
+                string? resolved = Resolve(directive.Source, Path.GetDirectoryName(path)!);
+                if (resolved == null)
+                {
+                    Errors.Add($"{name} line {line}: imported file '{directive.Source}' not found");
+                    continue;
+                }
+
+                if (!fileAliases.TryGetValue(resolved, out var alias))
+                {
+                    alias = FileAlias(resolved);
+                    for (int n = 2; imports.ContainsKey(alias); n++)
+                        alias = FileAlias(resolved) + n;
+
+                    imports[alias] = (Add(resolved, prefix.Length == 0 ? alias : prefix + SymbolTable.Separator + alias), line);
+                    fileAliases[resolved] = alias;
+                }
+
+                var importedUnit = imports[alias].Unit;
+                foreach (string imported in directive.Names)
+                {
+                    if (selected.TryGetValue(imported, out var existing))
+                        Errors.Add($"{name} line {line}: '{imported}' is already imported on line {existing.LineNumber}");
+                    else if (importedUnit != null && !importedUnit.Exports.ContainsKey(imported))
+                        Errors.Add($"{name} line {line}: '{imported}' is not exported by {Path.GetFileName(resolved)}");
+                    else
+                        selected[imported] = (alias, line);
+                }
+            }
+
+            stack.RemoveAt(stack.Count - 1);
+
+            var unit = new ComposedUnit { FilePath = path, Prefix = prefix };
+            string Qualify(string declared) => prefix.Length == 0 ? declared : prefix + SymbolTable.Separator + declared;
+
+            // A scoped file is flattened first; its block-local names get the prefix too but are not exported
+            string flat = text;
+            SymbolTable? table = null;
+            if (scopedPattern.IsMatch(text))
+            {
+                table = SymbolTable.Build(text);
+                flat = scopedPattern.Replace(table.Rewrite(), m => new string(' ', m.Length));
+            }
+
+            var own = new HashSet<string>(ModelSource.Parse(flat).Statements
+                .Select(s => s.Key)
+                .Where(k => k != null && !k.EndsWith(":", StringComparison.Ordinal))
+                .Select(k => k!.Substring(k.IndexOf(':') + 1)), StringComparer.Ordinal);
+
+            foreach (string declared in table?.Root.Symbols.Keys ?? (IEnumerable<string>)own)
+                unit.Exports[declared] = Qualify(declared);
+
+            foreach (var (imported, entry) in selected.Where(s => own.Contains(s.Key)).ToList())
+            {
+                Errors.Add($"{name} line {entry.LineNumber}: '{imported}' is imported and also declared in this file");
+                selected.Remove(imported);
+            }
+
+            string? Member(string alias, string member, int line)
+            {
+                if (!imports.TryGetValue(alias, out var import) || own.Contains(alias))
+                    return null;
+                if (import.Unit == null)
+                    return alias + SymbolTable.Separator + member;
+                if (import.Unit.Exports.TryGetValue(member, out var qualified))
+                    return qualified;
+
+                Errors.Add($"{name} line {line}: '{member}' is not exported by {Path.GetFileName(import.Unit.FilePath)} (imported as {alias})");
+                return alias + SymbolTable.Separator + member;
+            }
+
+            foreach (Match match in exportPattern.Matches(text))
+            {
+                int line = LineOf(text, match.Index);
+                foreach (string entry in match.Groups["names"].Value.Split(',', StringSplitOptions.TrimEntries))
+                {
+                    var parts = entry.Split('.', StringSplitOptions.TrimEntries);
+                    if (!imports.ContainsKey(parts[0]))
+                    {
+                        Errors.Add($"{name} line {line}: '{parts[0]}' is not an import prefix");
+                        continue;
+                    }
+                    if (unit.Exports.ContainsKey(parts[1]))
+                    {
+                        Errors.Add($"{name} line {line}: re-export of '{parts[1]}' collides with a name this file already exports");
+                        continue;
+                    }
+                    unit.Exports[parts[1]] = Member(parts[0], parts[1], line)!;
+                }
+            }
+
+            // Directives are blanked rather than removed so line numbers in parse errors stay correct
+            flat = ImportDirective.Blank(flat);
+            flat = exportPattern.Replace(flat, m => new string(' ', m.Length));
+
+            var source = ModelSource.Parse(flat);
+            foreach (var statement in source.Statements.ToList())
+            {
+                int line = statement.LineNumber;
+                string code = SymbolTable.ReplaceNames(statement.Code,
+                    n => own.Contains(n) ? Qualify(n) : selected.TryGetValue(n, out var selection) ? Member(selection.Alias, n, line) : null,
+                    (alias, member) => Member(alias, member, line));
+                if (code != statement.Code)
+                    source.Replace(statement, code);
+            }
+
+            unit.Text = source.ToString();
+            units.Add(unit);
+            return unit;
+        }
+
+        private string? Resolve(string importPath, string directory)
+        {
+            foreach (string root in SearchPaths.Prepend(directory))
+            {
+                string candidate = Path.GetFullPath(Path.Combine(root, importPath));
+                if (File.Exists(candidate))
+                    return candidate;
+            }
+            return null;
+        }
+
+        /// <summary>
+        /// Prefix of a selectively imported file: its name without extension, as an identifier
+        /// </summary>
+        private static string FileAlias(string path)
+        {
+            string alias = Regex.Replace(Path.GetFileNameWithoutExtension(path), @"\W", "_");
+            return char.IsDigit(alias[0]) ? "_" + alias : alias;
+        }
+
+        private static int LineOf(string text, int index)
+        {
+            return text.Take(index).Count(c => c == '\n') + 1;
+        }
+    }
+}
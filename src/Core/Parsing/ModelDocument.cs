@@ -0,0 +1,316 @@
+namespace Core.Parsing
+{
+    /// <summary>
+    /// Replacement of <see cref="Length"/> characters at <see cref="Offset"/> by <see cref="Text"/>
+    /// </summary>
+    public class TextChange
+    {
+        public int Offset { get; init; }
+        public int Length { get; init; }
+        public string Text { get; init; } = "";
+
+        public override string ToString() => $"[{Offset}, {Offset + Length}) -> \"{Text}\"";
+    }
+
+    /// <summary>
+    /// A syntax error found in one statement: an unclosed bracket or string, a missing ';'
+    /// </summary>
+    public class SyntaxDiagnostic
+    {
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// 1-based column
+        /// </summary>
+        public int Column { get; init; }
+
+        public string Message { get; init; } = "";
+
+        public override string ToString() => $"Line {LineNumber}, column {Column}: {Message}";
+    }
+
+    /// <summary>
+    /// The statements an edit replaced: Removed statements from index First on became Added ones
+    /// </summary>
+    public class ReparseResult
+    {
+        public int First { get; init; }
+        public int Removed { get; init; }
+        public int Added { get; init; }
+
+        public override string ToString() => $"statements {First}..{First + Removed} replaced by {Added}";
+    }
+
+    /// <summary>
+    /// A model text kept parsed into statements while it is edited, for editors and the LSP.
+    /// An edit reparses from the statement before the edited one until a statement boundary of
+    /// the old text is reached again past the edit; the statements after it are kept and only
+    /// shifted. Syntax diagnostics are computed per statement, so they are updated the same way.
+    /// </summary>
+    public class ModelDocument
+    {
+        private readonly List<ModelStatement> statements = new List<ModelStatement>();
+
+        /// <summary>
+        /// Offset of each statement's text, leading trivia included
+        /// </summary>
+        private readonly List<int> offsets = new List<int>();
+
+        /// <summary>
+        /// Syntax errors of each statement as (offset in the statement text, message)
+        /// </summary>
+        private readonly List<List<(int Offset, string Message)>> errors = new List<List<(int, string)>>();
+
+        private string trailer = "";
+
+        public ModelDocument(string text)
+        {
+            Text = text;
+            Read(0, 1, 0, null);
+        }
+
+        public string Text { get; private set; }
+
+        public IReadOnlyList<ModelStatement> Statements => statements;
+
+        /// <summary>
+        /// Syntax diagnostics of all statements, in text order
+        /// </summary>
+        public List<SyntaxDiagnostic> Diagnostics
+        {
+            get
+            {
+                var diagnostics = new List<SyntaxDiagnostic>();
+                for (int i = 0; i < statements.Count; i++)
+                {
+                    foreach (var (offset, message) in errors[i])
+                    {
+                        string statementText = statements[i].Text;
+                        int position = offsets[i] + offset;
+                        diagnostics.Add(new SyntaxDiagnostic
+                        {
+                            LineNumber = StartLine(i) + Count(statementText, 0, offset),
+                            Column = position - (position == 0 ? 0 : Text.LastIndexOf('\n', position - 1) + 1) + 1,
+                            Message = message
+                        });
+                    }
+                }
+                return diagnostics;
+            }
+        }
+
+        /// <summary>
+        /// A copy of the statements as a ModelSource, for statement-level edits
+        /// </summary>
+        public ModelSource ToSource() => new ModelSource(new List<ModelStatement>(statements), trailer);
+
+        /// <summary>
+        /// Applies an edit between 1-based line and column positions, as sent by an LSP client
+        /// </summary>
+        public ReparseResult Apply(int startLine, int startColumn, int endLine, int endColumn, string text)
+        {
+            int start = OffsetOf(startLine, startColumn);
+            return Apply(new TextChange { Offset = start, Length = OffsetOf(endLine, endColumn) - start, Text = text });
+        }
+
+        public ReparseResult Apply(TextChange change)
+        {
+            if (change.Offset < 0 || change.Length < 0 || change.Offset + change.Length > Text.Length)
+                throw new InvalidOperationException($"Change {change} is outside the text (length {Text.Length})");
+
+            Text = string.Concat(Text.AsSpan(0, change.Offset), change.Text, Text.AsSpan(change.Offset + change.Length));
+
+            // A statement's end can depend on the text after it (the optional ';' after a
+            // block's '}'), so the statement before the edited one is read again as well
+            int first = Math.Max(0, StatementAt(change.Offset) - 1);
+            int start = first < statements.Count ? offsets[first] : 0;
+            int line = first < statements.Count ? StartLine(first) : 1;
+
+            return Read(start, line, first, change);
+        }
+
+        /// <summary>
+        /// Reads statements of the text from <paramref name="start"/> and splices
+        /// them in at index <paramref name="first"/>, in place of the old statements up to the first
+        /// one that starts at the same place past the change (all of them if there is no change)
+        /// </summary>
+        private ReparseResult Read(int start, int line, int first, TextChange? change)
+        {
+            string text = Text;
+            int delta = change == null ? 0 : change.Text.Length - change.Length;
+            int changeEnd = change == null ? int.MaxValue : change.Offset + change.Length;
+            int changedEnd = change == null ? int.MaxValue : change.Offset + change.Text.Length;
+
+            var added = new List<ModelStatement>();
+            var addedOffsets = new List<int>();
+            int position = start;
+            int next = first;
+            bool synced = false;
+
+            while (true)
+            {
+                while (next < statements.Count && (offsets[next] < changeEnd || offsets[next] + delta < position))
+                    next++;
+                if (position >= changedEnd && next < statements.Count && offsets[next] + delta == position && added.Count > 0)
+                {
+                    synced = true;
+                    break;
+                }
+
+                int statementStart = position;
+                if (ModelSource.ReadStatement(text, ref position, ref line) is not ModelStatement statement)
+                    break;
+
+                added.Add(statement);
+                addedOffsets.Add(statementStart);
+            }
+
+            if (!synced)
+            {
+                next = statements.Count;
+                trailer = text.Substring(position);
+            }
+
+            int lineDelta = synced ? line - StartLine(next) : 0;
+            int removed = next - first;
+
+            statements.RemoveRange(first, removed);
+            offsets.RemoveRange(first, removed);
+            errors.RemoveRange(first, removed);
+            statements.InsertRange(first, added);
+            offsets.InsertRange(first, addedOffsets);
+            errors.InsertRange(first, added.Select(Check));
+
+            for (int i = first + added.Count; i < statements.Count; i++)
+            {
+                offsets[i] += delta;
+                if (lineDelta != 0)
+                {
+                    var statement = statements[i];
+                    statements[i] = new ModelStatement { Key = statement.Key, Text = statement.Text, LineNumber = statement.LineNumber + lineDelta };
+                }
+            }
+
+            return new ReparseResult { First = first, Removed = removed, Added = added.Count };
+        }
+
+        /// <summary>
+        /// Index of the statement whose text (leading trivia included) contains the offset,
+        /// or Statements.Count for the trailer
+        /// </summary>
+        private int StatementAt(int offset)
+        {
+            if (statements.Count == 0)
+                return 0;
+
+            int index = offsets.BinarySearch(offset);
+            if (index < 0)
+                index = ~index - 1;
+            var last = statements[^1];
+            return index == statements.Count - 1 && offset >= offsets[index] + last.Text.Length ? statements.Count : Math.Max(0, index);
+        }
+
+        /// <summary>
+        /// Line the statement's leading trivia starts on
+        /// </summary>
+        private int StartLine(int index)
+        {
+            var statement = statements[index];
+            return statement.LineNumber - Count(statement.Text, 0, statement.Text.Length - statement.Code.Length);
+        }
+
+        private int OffsetOf(int lineNumber, int column)
+        {
+            int offset = 0;
+            for (int line = 1; line < lineNumber; line++)
+            {
+                int end = Text.IndexOf('\n', offset);
+                if (end < 0)
+                    throw new InvalidOperationException($"Line {lineNumber} is past the end of the text");
+                offset = end + 1;
+            }
+
+            int lineEnd = Text.IndexOf('\n', offset);
+            return Math.Min(offset + column - 1, lineEnd < 0 ? Text.Length : lineEnd);
+        }
+
+        /// <summary>
+        /// Unterminated strings, comments and docstrings, unbalanced brackets and a missing ';'
+        /// </summary>
+        private static List<(int Offset, string Message)> Check(ModelStatement statement)
+        {
+            var found = new List<(int, string)>();
+            var open = new Stack<SyntaxToken>();
+            var tokens = ModelLexer.Tokenize(statement.Text);
+            string code = statement.Code.TrimEnd();
+
+            // The braces of "subject to {" and its closing "}" are separate statements
+            bool header = code.StartsWith("subject", StringComparison.Ordinal) && code.EndsWith('{');
+            bool closing = code.StartsWith('}');
+
+            foreach (var token in tokens)
+            {
+                switch (token.Kind)
+                {
+                    case SyntaxTokenKind.String when token.Length < 2 || !token.Text.EndsWith('"'):
+                        found.Add((token.Offset, "Unterminated string"));
+                        break;
+
+                    case SyntaxTokenKind.Comment when token.Text.StartsWith("/*") && (token.Length < 4 || !token.Text.EndsWith("*/")):
+                        found.Add((token.Offset, "Unterminated comment (missing closing */)"));
+                        break;
+
+                    case SyntaxTokenKind.Docstring when token.Text.StartsWith("\"\"\"") && (token.Length < 6 || !token.Text.EndsWith("\"\"\"")):
+                        found.Add((token.Offset, "Unterminated docstring (missing closing \"\"\")"));
+                        break;
+
+                    case SyntaxTokenKind.Punctuation or SyntaxTokenKind.SetLiteral when token.Text is "(" or "[" or "{":
+                        open.Push(token);
+                        break;
+
+                    case SyntaxTokenKind.Punctuation or SyntaxTokenKind.SetLiteral when token.Text is ")" or "]" or "}":
+                        if (open.Count == 0)
+                        {
+                            if (!closing || token.Offset != tokens.First(t => t.Kind is not (SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring)).Offset)
+                                found.Add((token.Offset, $"Unmatched '{token.Text}'"));
+                            break;
+                        }
+
+                        string expected = Closer(open.Pop().Text);
+                        if (expected != token.Text)
+                            found.Add((token.Offset, $"Expected '{expected}' but found '{token.Text}'"));
+                        break;
+                }
+            }
+
+            if (header && open.Count > 0 && open.Peek().Text == "{")
+                open.Pop();
+
+            foreach (var token in open)
+                found.Add((token.Offset, $"Unclosed '{token.Text}'"));
+
+            if (open.Count == 0 && !header && !code.EndsWith(';') && !code.EndsWith('}'))
+                found.Add((statement.Text.TrimEnd().Length, "Missing ';' at end of statement"));
+
+            return found.OrderBy(e => e.Item1).ToList();
+        }
+
+        private static string Closer(string opener) => opener switch
+        {
+            "(" => ")",
+            "[" => "]",
+            _ => "}"
+        };
+
+        private static int Count(string text, int from, int to)
+        {
+            int count = 0;
+            for (int i = from; i < to; i++)
+            {
+                if (text[i] == '\n')
+                    count++;
+            }
+            return count;
+        }
+    }
+}
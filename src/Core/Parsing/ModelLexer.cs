@@ -0,0 +1,349 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    public enum SyntaxTokenKind
+    {
+        Keyword,
+        Type,
+        Function,
+        Identifier,
+        Number,
+        String,
+        Operator,
+        Punctuation,
+
+        /// <summary>
+        /// Brace of a set literal or set type ("{1, 2}", "{string}"), as opposed to a block brace
+        /// </summary>
+        SetLiteral,
+
+        Comment,
+        Docstring,
+
+        /// <summary>
+        /// "@block", "@tag" and the other annotations
+        /// </summary>
+        Annotation
+    }
+
+    /// <summary>
+    /// A classified token of a model or data text
+    /// </summary>
+    public class SyntaxToken
+    {
+        public SyntaxTokenKind Kind { get; init; }
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// 0-based offset in the text
+        /// </summary>
+        public int Offset { get; init; }
+
+        public int Length => Text.Length;
+
+        /// <summary>
+        /// 1-based line and column of the first character
+        /// </summary>
+        public int LineNumber { get; init; }
+        public int Column { get; init; }
+
+        /// <summary>
+        /// Key of the entity an identifier names ("parameter:capacity", "tuple:Arc"), set by ModelLexer.Classify
+        /// </summary>
+        public string? Symbol { get; internal set; }
+
+        /// <summary>
+        /// The identifier is the name in the entity's declaration
+        /// </summary>
+        public bool IsDeclaration { get; internal set; }
+
+        /// <summary>
+        /// The identifier follows a '.' (a tuple field or a property such as "ub")
+        /// </summary>
+        public bool IsMember { get; internal set; }
+
+        public override string ToString() => $"{LineNumber}:{Column} {Kind} {Text}";
+    }
+
+    /// <summary>
+    /// The lexer editors use to highlight model and data files. Tokenize classifies the words,
+    /// literals and comments of a text; Classify also resolves identifiers to the entities the
+    /// model declares. SemanticTokens encodes the result for an LSP client.
+    /// </summary>
+    public static class ModelLexer
+    {
+        public static readonly IReadOnlySet<string> Keywords = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "and", "constraint", "constraints", "dexpr", "diff", "div", "dvar", "else", "execute", "exists", "false", "forall",
+            "if", "in", "infinity", "inter", "key", "main", "maximize", "maxint", "minimize", "mod", "not", "or",
+            "range", "return", "setof", "subject", "symdiff", "to", "true", "tuple", "union", "using", "with"
+        };
+
+        public static readonly IReadOnlySet<string> Types = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "bool", "boolean", "float", "int", "string", "timestamp"
+        };
+
+        public static readonly IReadOnlySet<string> Functions = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "abs", "card", "ceil", "cos", "exp", "first", "floor", "item", "last", "log", "max", "maxl", "min",
+            "minl", "next", "nextc", "ord", "pow", "prev", "prevc", "prod", "round", "sin", "sqrt", "sum", "tan", "trunc", "window"
+        };
+
+        private static readonly string[] operators =
+        {
+            "...", "..", "==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "^", "%", "=", "<", ">", "!", "?", ":", ".", "|"
+        };
+
+        private static readonly Regex tuplePattern = new Regex(@"^tuple\s+(\w+)");
+
+        /// <summary>
+        /// Words after which a brace opens a set literal, not a block
+        /// </summary>
+        private static readonly HashSet<string> literalWords = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "in", "and", "or", "not", "union", "inter", "diff", "symdiff", "return", "setof"
+        };
+
+        /// <summary>
+        /// Tokens of a text without whitespace. Unterminated strings and comments run to the end
+        /// of their line or of the text; a character no token starts with is a Punctuation token.
+        /// </summary>
+        public static List<SyntaxToken> Tokenize(string text)
+        {
+            var tokens = new List<SyntaxToken>();
+            var braces = new Stack<bool>();
+            int line = 1;
+            int lineStart = 0;
+            int i = 0;
+
+            while (i < text.Length)
+            {
+                char c = text[i];
+                if (c == '\n')
+                {
+                    line++;
+                    lineStart = ++i;
+                    continue;
+                }
+                if (char.IsWhiteSpace(c))
+                {
+                    i++;
+                    continue;
+                }
+
+                var (kind, length) = Scan(text, i);
+                if (kind == SyntaxTokenKind.Punctuation && c is '{' or '}')
+                {
+                    bool literal = c == '{' ? OpensLiteral(tokens) : braces.Count > 0 && braces.Peek();
+                    if (c == '{')
+                        braces.Push(literal);
+                    else if (braces.Count > 0)
+                        braces.Pop();
+                    if (literal)
+                        kind = SyntaxTokenKind.SetLiteral;
+                }
+
+                tokens.Add(new SyntaxToken
+                {
+                    Kind = kind,
+                    Text = text.Substring(i, length),
+                    Offset = i,
+                    LineNumber = line,
+                    Column = i - lineStart + 1
+                });
+
+                for (int j = i; j < i + length; j++)
+                {
+                    if (text[j] == '\n')
+                    {
+                        line++;
+                        lineStart = j + 1;
+                    }
+                }
+                i += length;
+            }
+
+            return tokens;
+        }
+
+        /// <summary>
+        /// Tokens of a model text with identifiers resolved: the names of declared sets, parameters,
+        /// variables, decision expressions, constraints and tuple types get their entity key as
+        /// Symbol, and the name in each declaration is marked as such
+        /// </summary>
+        public static List<SyntaxToken> Classify(string modelText)
+        {
+            var tokens = Tokenize(modelText);
+            var source = ModelSource.Parse(modelText);
+
+            var symbols = new Dictionary<string, string>(StringComparer.Ordinal);
+            int offset = 0;
+            int first = 0;
+            foreach (var statement in source.Statements)
+            {
+                int codeOffset = offset + statement.Text.Length - statement.Code.Length;
+                offset += statement.Text.Length;
+                while (first < tokens.Count && tokens[first].Offset < codeOffset)
+                    first++;
+
+                string? key = statement.Key;
+                var tuple = tuplePattern.Match(statement.Code);
+                if (tuple.Success)
+                    key = "tuple:" + tuple.Groups[1].Value;
+                if (key == null || key.EndsWith(':') || !key.Contains(':'))
+                    continue;
+
+                string name = key.Substring(key.IndexOf(':') + 1);
+                symbols.TryAdd(name, key);
+
+                for (int i = first; i < tokens.Count && tokens[i].Offset < offset; i++)
+                {
+                    if (tokens[i].Kind == SyntaxTokenKind.Identifier && tokens[i].Text == name)
+                    {
+                        tokens[i].IsDeclaration = true;
+                        break;
+                    }
+                }
+            }
+
+            for (int i = 0; i < tokens.Count; i++)
+            {
+                var token = tokens[i];
+                if (token.Kind != SyntaxTokenKind.Identifier)
+                    continue;
+
+                if (i > 0 && tokens[i - 1].Text == ".")
+                {
+                    token.IsMember = true;
+                    continue;
+                }
+
+                if (symbols.TryGetValue(token.Text, out var key))
+                    token.Symbol = key;
+            }
+
+            return tokens;
+        }
+
+        private static (SyntaxTokenKind Kind, int Length) Scan(string text, int start)
+        {
+            char c = text[start];
+
+            int docstring = Docstrings.Length(text, start);
+            if (docstring > 0)
+                return (SyntaxTokenKind.Docstring, docstring);
+
+            if (string.CompareOrdinal(text, start, "//", 0, 2) == 0)
+                return (SyntaxTokenKind.Comment, LineEnd(text, start) - start);
+
+            if (string.CompareOrdinal(text, start, "/*", 0, 2) == 0)
+            {
+                int end = text.IndexOf("*/", start + 2, StringComparison.Ordinal);
+                return (SyntaxTokenKind.Comment, (end < 0 ? text.Length : end + 2) - start);
+            }
+
+            if (c == '"')
+            {
+                int i = start + 1;
+                while (i < text.Length && text[i] != '"' && text[i] != '\n')
+                    i += text[i] == '\\' && i + 1 < text.Length && text[i + 1] != '\n' ? 2 : 1;
+                return (SyntaxTokenKind.String, (i < text.Length && text[i] == '"' ? i + 1 : i) - start);
+            }
+
+            if (c == '@' && start + 1 < text.Length && char.IsLetter(text[start + 1]))
+                return (SyntaxTokenKind.Annotation, WordEnd(text, start + 1) - start);
+
+            if (char.IsDigit(c) || c == '.' && start + 1 < text.Length && char.IsDigit(text[start + 1]) && !PreviousIsDot(text, start))
+                return (SyntaxTokenKind.Number, NumberEnd(text, start) - start);
+
+            if (char.IsLetter(c) || c == '_')
+            {
+                int end = WordEnd(text, start);
+                string word = text.Substring(start, end - start);
+                if (Types.Contains(word))
+                {
+                    // "float+" and "int+" are one type
+                    bool signed = word is "float" or "int" && end < text.Length && text[end] is '+' or '-' &&
+                                  (end + 1 == text.Length || !char.IsLetterOrDigit(text[end + 1]) && text[end + 1] != '(');
+                    return (SyntaxTokenKind.Type, end - start + (signed ? 1 : 0));
+                }
+                if (Keywords.Contains(word))
+                    return (SyntaxTokenKind.Keyword, end - start);
+                if (Functions.Contains(word))
+                    return (SyntaxTokenKind.Function, end - start);
+                return (SyntaxTokenKind.Identifier, end - start);
+            }
+
+            foreach (string op in operators)
+            {
+                if (string.CompareOrdinal(text, start, op, 0, op.Length) == 0)
+                    return (SyntaxTokenKind.Operator, op.Length);
+            }
+
+            return (SyntaxTokenKind.Punctuation, 1);
+        }
+
+        /// <summary>
+        /// A brace opens a block after a word that is not an operator ("subject to {", "tuple Arc {")
+        /// or after ')' ("forall(...) {"); anywhere else it opens a set
+        /// </summary>
+        private static bool OpensLiteral(List<SyntaxToken> tokens)
+        {
+            var previous = tokens.LastOrDefault(t => t.Kind is not (SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring));
+            if (previous == null)
+                return true;
+            if (previous.Text == ")")
+                return false;
+            if (previous.Kind is SyntaxTokenKind.Identifier or SyntaxTokenKind.Keyword)
+                return literalWords.Contains(previous.Text);
+            return true;
+        }
+
+        private static int LineEnd(string text, int start)
+        {
+            int end = text.IndexOf('\n', start);
+            return end < 0 ? text.Length : end;
+        }
+
+        private static int WordEnd(string text, int start)
+        {
+            int i = start;
+            while (i < text.Length && (char.IsLetterOrDigit(text[i]) || text[i] == '_'))
+                i++;
+            return i;
+        }
+
+        private static bool PreviousIsDot(string text, int start) => start > 0 && text[start - 1] == '.';
+
+        private static int NumberEnd(string text, int start)
+        {
+            int i = start;
+            while (i < text.Length && char.IsDigit(text[i]))
+                i++;
+
+            // "1..3" is a range, not a decimal point
+            if (i + 1 < text.Length && text[i] == '.' && text[i + 1] != '.')
+            {
+                i++;
+                while (i < text.Length && char.IsDigit(text[i]))
+                    i++;
+            }
+
+            if (i < text.Length && text[i] is 'e' or 'E')
+            {
+                int exponent = i + 1;
+                if (exponent < text.Length && text[exponent] is '+' or '-')
+                    exponent++;
+                if (exponent < text.Length && char.IsDigit(text[exponent]))
+                {
+                    i = exponent;
+                    while (i < text.Length && char.IsDigit(text[i]))
+                        i++;
+                }
+            }
+            return i;
+        }
+    }
+}
@@ -0,0 +1,143 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// Outcome of a three-way merge of model texts
+    /// </summary>
+    public class ModelMergeResult
+    {
+        public string ModelText { get; init; } = "";
+
+        /// <summary>
+        /// Statements taken from "mine": entity keys, or the first line of an unnamed statement
+        /// </summary>
+        public List<string> Changed { get; init; } = new List<string>();
+
+        /// <summary>
+        /// Statements both sides changed differently, named like Changed
+        /// </summary>
+        public List<string> Conflicts { get; init; } = new List<string>();
+
+        public bool HasConflicts => Conflicts.Count > 0;
+
+        public override string ToString() =>
+            $"{Changed.Count} changed, {Conflicts.Count} conflicts{(HasConflicts ? ": " + string.Join(", ", Conflicts) : "")}";
+    }
+
+    /// <summary>
+    /// Three-way merge of model texts at statement level: a statement changed (added, edited or
+    /// removed) on one side only takes that side's version, one changed identically on both is
+    /// taken once, and one changed differently on both is a conflict. Declarations are matched by
+    /// entity key, unnamed statements (e.g. forall constraints without a label) by their code.
+    /// Statements compare with their leading comments, so docstring and annotation edits merge
+    /// too. The result keeps the order of "theirs"; statements only "mine" has are inserted after
+    /// the statement they follow in "mine".
+    /// </summary>
+    public static class ModelMerge
+    {
+        /// <summary>
+        /// Merges the changes from baseText to mine into theirs. Conflicts keep theirs, or mine
+        /// when <paramref name="preferMine"/> is set; they are reported either way.
+        /// </summary>
+        public static ModelMergeResult Merge(string baseText, string mine, string theirs, bool preferMine = false)
+        {
+            var baseSource = ModelSource.Parse(baseText);
+            var mineSource = ModelSource.Parse(mine);
+            var theirSource = ModelSource.Parse(theirs);
+            var baseSide = Index(baseSource);
+            var mineSide = Index(mineSource);
+            var theirSide = Index(theirSource);
+
+            var changed = new List<string>();
+            var conflicts = new List<string>();
+            var chosen = new Dictionary<string, ModelStatement?>(StringComparer.Ordinal);
+
+            foreach (string id in baseSide.Order.Concat(mineSide.Order).Concat(theirSide.Order).Distinct())
+            {
+                var b = baseSide.Statements.GetValueOrDefault(id);
+                var m = mineSide.Statements.GetValueOrDefault(id);
+                var t = theirSide.Statements.GetValueOrDefault(id);
+
+                if (Same(m, b) || Same(m, t))
+                {
+                    chosen[id] = t;
+                }
+                else if (Same(t, b))
+                {
+                    chosen[id] = m;
+                    changed.Add(Describe(m ?? b!));
+                }
+                else
+                {
+                    chosen[id] = preferMine ? m : t;
+                    conflicts.Add(Describe(m ?? t ?? b!));
+                }
+            }
+
+            // Theirs' order, then mine's additions after their predecessor in mine
+            var merged = theirSide.Order.Where(id => chosen[id] != null).ToList();
+            string? previous = null;
+            foreach (string id in mineSide.Order)
+            {
+                if (chosen[id] != null && !merged.Contains(id))
+                    merged.Insert(previous == null ? 0 : merged.IndexOf(previous) + 1, id);
+                if (merged.Contains(id))
+                    previous = id;
+            }
+
+            var statements = new List<ModelStatement>();
+            foreach (string id in merged)
+            {
+                // A statement that was first in its text needs a line of its own elsewhere
+                var statement = chosen[id]!;
+                if (statements.Count > 0 && !char.IsWhiteSpace(statement.Text[0]))
+                    statement = new ModelStatement { Key = statement.Key, Text = Environment.NewLine + statement.Text, LineNumber = statement.LineNumber };
+                statements.Add(statement);
+            }
+
+            string trailer = MergeTrailer(baseSource.Trailer, mineSource.Trailer, theirSource.Trailer, preferMine);
+            return new ModelMergeResult
+            {
+                ModelText = new ModelSource(statements, trailer).ToString(),
+                Changed = changed,
+                Conflicts = conflicts
+            };
+        }
+
+        private static bool Same(ModelStatement? a, ModelStatement? b) =>
+            a == null ? b == null : b != null && a.Text.Trim() == b.Text.Trim();
+
+        private static string MergeTrailer(string baseTrailer, string mine, string theirs, bool preferMine)
+        {
+            if (mine.Trim() == baseTrailer.Trim() || mine.Trim() == theirs.Trim())
+                return theirs;
+            return theirs.Trim() == baseTrailer.Trim() || preferMine ? mine : theirs;
+        }
+
+        private static string Describe(ModelStatement statement) =>
+            statement.Key ?? statement.Code.Split('\n')[0].Trim();
+
+        /// <summary>
+        /// Statements by identity, in text order: the key, or the normalized code of an unnamed
+        /// statement numbered by occurrence
+        /// </summary>
+        private static (List<string> Order, Dictionary<string, ModelStatement> Statements) Index(ModelSource source)
+        {
+            var order = new List<string>();
+            var statements = new Dictionary<string, ModelStatement>(StringComparer.Ordinal);
+            var occurrences = new Dictionary<string, int>(StringComparer.Ordinal);
+            foreach (var statement in source.Statements)
+            {
+                string id = statement.Key ?? "code:" + Regex.Replace(statement.Code, @"\s+", " ");
+                occurrences[id] = occurrences.GetValueOrDefault(id) + 1;
+                if (occurrences[id] > 1)
+                    id += "#" + occurrences[id];
+
+                order.Add(id);
+                statements[id] = statement;
+            }
+            return (order, statements);
+        }
+    }
+}
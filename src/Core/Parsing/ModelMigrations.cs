@@ -0,0 +1,214 @@
+using System.Text.RegularExpressions;
+using Core.Storage;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// One upgrade step of the model conventions: a patch, or code for what a patch cannot express
+    /// </summary>
+    public class ModelMigration
+    {
+        private static readonly Regex idPattern = new Regex(@"^[A-Za-z0-9][\w.\-]*$");
+
+        public ModelMigration(string id, ModelPatch patch)
+            : this(id, patch.Title, text => patch.Apply(text, out _))
+        {
+        }
+
+        public ModelMigration(string id, string description, Func<string, string> transform)
+        {
+            if (!idPattern.IsMatch(id))
+                throw new InvalidOperationException($"Invalid migration id '{id}'; use letters, digits, '.', '-' and '_'");
+            Id = id;
+            Description = description;
+            Transform = transform;
+        }
+
+        public string Id { get; }
+        public string Description { get; }
+
+        /// <summary>
+        /// Model text before the migration to model text after it; throws if the model cannot be migrated
+        /// </summary>
+        public Func<string, string> Transform { get; }
+
+        public override string ToString() => Description.Length == 0 ? Id : $"{Id} ({Description})";
+    }
+
+    /// <summary>
+    /// Outcome of migrating one model document
+    /// </summary>
+    public class MigrationResult
+    {
+        /// <summary>
+        /// File path or stored model id
+        /// </summary>
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Migrated text, or the original text if a migration failed
+        /// </summary>
+        public string Text { get; init; } = "";
+
+        public List<string> Applied { get; } = new List<string>();
+
+        /// <summary>
+        /// Recorded migrations this runner does not know, e.g. from a newer version of the conventions
+        /// </summary>
+        public List<string> Unknown { get; } = new List<string>();
+
+        public string? Error { get; init; }
+
+        public bool Changed => Error == null && Applied.Count > 0;
+
+        public override string ToString()
+        {
+            if (Error != null)
+                return $"{Name}: failed, {Error}";
+            string line = Applied.Count == 0 ? $"{Name}: up to date" : $"{Name}: applied {string.Join(", ", Applied)}";
+            return Unknown.Count == 0 ? line : $"{line} (unknown migrations recorded: {string.Join(", ", Unknown)})";
+        }
+    }
+
+    /// <summary>
+    /// Applies registered migrations, in registration order, to model documents that have not had
+    /// them yet. Each document records what it has had in a header comment:
+    /// <code>
+    /// // @migrations 0001-tag-taxonomy, 0002-split-hydro
+    /// </code>
+    /// so running the same migrations again is a no-op. A document is migrated all the way or not
+    /// at all: if one of its pending migrations fails, it is left as it was and the others go on.
+    /// </summary>
+    public class MigrationRunner
+    {
+        private static readonly Regex headerPattern = new Regex(@"^[ \t]*//[ \t]*@migrations\b[ \t]*(?<ids>[^\r\n]*?)[ \t]*\r?$\n?", RegexOptions.Multiline);
+
+        private readonly List<ModelMigration> migrations = new List<ModelMigration>();
+
+        public IReadOnlyList<ModelMigration> Migrations => migrations;
+
+        public MigrationRunner Register(ModelMigration migration)
+        {
+            if (migrations.Any(m => m.Id == migration.Id))
+                throw new InvalidOperationException($"Migration '{migration.Id}' is already registered");
+            migrations.Add(migration);
+            return this;
+        }
+
+        public MigrationRunner Register(string id, string description, Func<string, string> transform)
+        {
+            return Register(new ModelMigration(id, description, transform));
+        }
+
+        /// <summary>
+        /// Registers the patch files (.patch, .json) of a directory in file name order, each with
+        /// its file name as id
+        /// </summary>
+        public MigrationRunner RegisterDirectory(string directory)
+        {
+            var files = Directory.GetFiles(directory)
+                .Where(f => Path.GetExtension(f) is ModelPatch.Extension or ".json")
+                .OrderBy(f => Path.GetFileName(f), StringComparer.Ordinal);
+            foreach (string file in files)
+                Register(new ModelMigration(Path.GetFileNameWithoutExtension(file), ModelPatch.Load(file)));
+            return this;
+        }
+
+        /// <summary>
+        /// Ids recorded in the document's @migrations header, in the order they were applied
+        /// </summary>
+        public static IReadOnlyList<string> AppliedMigrations(string modelText)
+        {
+            var match = headerPattern.Match(modelText);
+            return match.Success
+                ? match.Groups["ids"].Value.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries)
+                : Array.Empty<string>();
+        }
+
+        public IReadOnlyList<ModelMigration> Pending(string modelText)
+        {
+            var applied = AppliedMigrations(modelText).ToHashSet(StringComparer.Ordinal);
+            return migrations.Where(m => !applied.Contains(m.Id)).ToList();
+        }
+
+        public MigrationResult Migrate(string modelText, string name = "")
+        {
+            var recorded = AppliedMigrations(modelText).ToList();
+            var pending = Pending(modelText);
+            string text = headerPattern.Replace(modelText, "", 1);
+            var applied = new List<string>();
+
+            foreach (var migration in pending)
+            {
+                try
+                {
+                    text = migration.Transform(text);
+                }
+                catch (Exception ex) when (ex is InvalidOperationException or FormatException)
+                {
+                    var failed = new MigrationResult { Name = name, Text = modelText, Error = $"migration {migration.Id}: {ex.Message}" };
+                    failed.Applied.AddRange(applied);
+                    return failed;
+                }
+                applied.Add(migration.Id);
+            }
+
+            var result = new MigrationResult { Name = name, Text = applied.Count == 0 ? modelText : WithHeader(text, recorded.Concat(applied)) };
+            result.Applied.AddRange(applied);
+            result.Unknown.AddRange(recorded.Where(id => migrations.All(m => m.Id != id)));
+            return result;
+        }
+
+        /// <summary>
+        /// Migrates model files in place; files that fail or are up to date are not written
+        /// </summary>
+        public List<MigrationResult> MigrateFiles(IEnumerable<string> paths)
+        {
+            var results = new List<MigrationResult>();
+            foreach (string path in paths)
+            {
+                var result = Migrate(File.ReadAllText(path), path);
+                if (result.Changed)
+                    File.WriteAllText(path, result.Text);
+                results.Add(result);
+            }
+            return results;
+        }
+
+        /// <summary>
+        /// Migrates the latest version of every stored model, saving a migrated model as a new version
+        /// </summary>
+        public async Task<List<MigrationResult>> MigrateStorageAsync(IModelStorage storage, CancellationToken cancellationToken = default)
+        {
+            var results = new List<MigrationResult>();
+            foreach (var info in await storage.ListAsync(cancellationToken))
+            {
+                var model = await storage.OpenAsync(info.Id, null, cancellationToken);
+                if (model == null)
+                    continue;
+
+                var result = Migrate(model.ModelText, model.Id);
+                if (result.Changed)
+                {
+                    await storage.SaveAsync(new StoredModel
+                    {
+                        Id = model.Id,
+                        Name = model.Name,
+                        Version = model.Version + 1,
+                        SavedAt = DateTime.UtcNow,
+                        Owner = model.Owner,
+                        ModelText = result.Text,
+                        DataText = model.DataText
+                    }, cancellationToken);
+                }
+                results.Add(result);
+            }
+            return results;
+        }
+
+        private static string WithHeader(string text, IEnumerable<string> ids)
+        {
+            return $"// @migrations {string.Join(", ", ids)}\n{text}";
+        }
+    }
+}
@@ -0,0 +1,442 @@
+using System.Globalization;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    public enum PatchOperationKind
+    {
+        /// <summary>
+        /// Declares a new entity; fails if it exists
+        /// </summary>
+        Add,
+
+        /// <summary>
+        /// Removes a declaration
+        /// </summary>
+        Remove,
+
+        /// <summary>
+        /// Replaces a whole declaration; fails if it does not exist
+        /// </summary>
+        Replace,
+
+        /// <summary>
+        /// Sets one attribute of a declaration: "value" of a parameter, "lb" or "ub" of a variable
+        /// </summary>
+        Set,
+
+        /// <summary>
+        /// Sets the coefficient of a variable on the left-hand side of a constraint; 0 removes the term
+        /// </summary>
+        Coefficient
+    }
+
+    /// <summary>
+    /// One operation of a model patch
+    /// </summary>
+    public class PatchOperation
+    {
+        public PatchOperationKind Op { get; init; }
+
+        /// <summary>
+        /// Entity key ("parameter:cap") or plain name of the declaration, for all but Add and Replace
+        /// </summary>
+        public string? Target { get; init; }
+
+        /// <summary>
+        /// Statement to add or to replace with
+        /// </summary>
+        public string? Statement { get; init; }
+
+        public string? Attribute { get; init; }
+        public string? Variable { get; init; }
+        public string? Value { get; init; }
+
+        [JsonIgnore]
+        public int LineNumber { get; init; }
+
+        public static PatchOperation Add(string statement) => new PatchOperation { Op = PatchOperationKind.Add, Statement = statement.Trim() };
+        public static PatchOperation Remove(string target) => new PatchOperation { Op = PatchOperationKind.Remove, Target = target };
+        public static PatchOperation Replace(string statement) => new PatchOperation { Op = PatchOperationKind.Replace, Statement = statement.Trim() };
+
+        public static PatchOperation Set(string target, string attribute, string value) =>
+            new PatchOperation { Op = PatchOperationKind.Set, Target = target, Attribute = attribute, Value = value };
+
+        public static PatchOperation Coefficient(string target, string variable, string value) =>
+            new PatchOperation { Op = PatchOperationKind.Coefficient, Target = target, Variable = variable, Value = value };
+
+        /// <summary>
+        /// The operation as a line of the text format
+        /// </summary>
+        public override string ToString() => Op switch
+        {
+            PatchOperationKind.Add => $"add {Statement}",
+            PatchOperationKind.Remove => $"remove {Target}",
+            PatchOperationKind.Replace => $"replace {Statement}",
+            PatchOperationKind.Set => $"set {Target} {Attribute} = {Value}",
+            _ => $"coef {Target} {Variable} = {Value}"
+        };
+    }
+
+    /// <summary>
+    /// Scripted edits of a model, written by tools and read by reviewers, one operation per line:
+    /// <code>
+    /// # Raise hydro capacity and let release enter the balance at full weight
+    /// add float reserve = 10;
+    /// set cap value = 120
+    /// set variable:release ub = 80
+    /// coef balance release = -1
+    /// remove parameter:legacyCap
+    /// replace dvar float+ spill[T] in 0..5;
+    /// </code>
+    /// or the same operations as JSON. A patch applies as a whole or not at all and yields its
+    /// inverse, which restores every touched declaration, so a series of patches works like
+    /// database migrations: applied in order, and rolled back by applying the inverses backwards.
+    /// </summary>
+    public class ModelPatch
+    {
+        public const string Extension = ".patch";
+
+        private static readonly Regex linePattern = new Regex(
+            @"^(?<op>set|coef)\s+(?<target>[\w:]+)\s+(?<name>\w+)\s*=\s*(?<value>.+?)\s*;?\s*$");
+
+        private static readonly Regex boundsPattern = new Regex(@"\s+in\s+(?<lo>[^;]+?)\s*\.\.\s*(?<hi>[^;]+?)\s*(?=;|$)");
+        private static readonly Regex initializerPattern = new Regex(@"^(?<head>[^=]*?)\s*(?:=(?!=)\s*(?<value>.*?))?\s*;?\s*$", RegexOptions.Singleline);
+        private static readonly Regex relationPattern = new Regex(@"==|<=|>=|=|<|>");
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            Converters = { new JsonStringEnumConverter(JsonNamingPolicy.CamelCase) },
+            WriteIndented = true
+        };
+
+        public string Title { get; set; } = "";
+        public List<PatchOperation> Operations { get; init; } = new List<PatchOperation>();
+
+        public static ModelPatch Load(string path)
+        {
+            string text = File.ReadAllText(path);
+            return string.Equals(Path.GetExtension(path), ".json", StringComparison.OrdinalIgnoreCase)
+                ? FromJson(text)
+                : Parse(text, Path.GetFileNameWithoutExtension(path));
+        }
+
+        /// <summary>
+        /// Reads the text format. A leading "#" comment becomes the title when none is given;
+        /// other "#" lines and blank lines are skipped.
+        /// </summary>
+        public static ModelPatch Parse(string text, string? title = null)
+        {
+            var patch = new ModelPatch { Title = title ?? "" };
+            var lines = text.Replace("\r\n", "\n").Split('\n');
+
+            for (int i = 0; i < lines.Length; i++)
+            {
+                string line = lines[i].Trim();
+                int number = i + 1;
+                if (line.StartsWith("#", StringComparison.Ordinal))
+                {
+                    if (patch.Operations.Count == 0 && title == null && patch.Title.Length == 0)
+                        patch.Title = line.TrimStart('#').Trim();
+                    continue;
+                }
+                if (line.Length == 0)
+                    continue;
+
+                int space = line.IndexOfAny(new[] { ' ', '\t' });
+                string op = space < 0 ? line : line.Substring(0, space);
+                string rest = space < 0 ? "" : line.Substring(space + 1).Trim();
+
+                // A statement may run over several lines up to its ';'
+                if (op is "add" or "replace")
+                {
+                    while (!rest.EndsWith(";", StringComparison.Ordinal) && !rest.EndsWith("}", StringComparison.Ordinal) && i + 1 < lines.Length)
+                        rest += "\n" + lines[++i];
+                }
+
+                var match = linePattern.Match(line);
+                patch.Operations.Add(op switch
+                {
+                    "add" when rest.Length > 0 => new PatchOperation { Op = PatchOperationKind.Add, Statement = rest.Trim(), LineNumber = number },
+                    "replace" when rest.Length > 0 => new PatchOperation { Op = PatchOperationKind.Replace, Statement = rest.Trim(), LineNumber = number },
+                    "remove" when rest.Length > 0 => new PatchOperation { Op = PatchOperationKind.Remove, Target = rest.TrimEnd(';').Trim(), LineNumber = number },
+                    "set" when match.Success => new PatchOperation
+                    {
+                        Op = PatchOperationKind.Set, Target = match.Groups["target"].Value, Attribute = match.Groups["name"].Value,
+                        Value = match.Groups["value"].Value, LineNumber = number
+                    },
+                    "coef" when match.Success => new PatchOperation
+                    {
+                        Op = PatchOperationKind.Coefficient, Target = match.Groups["target"].Value, Variable = match.Groups["name"].Value,
+                        Value = match.Groups["value"].Value, LineNumber = number
+                    },
+                    _ => throw new InvalidOperationException(
+                        $"Patch line {number}: '{line}' is not an operation; use add, remove, replace, set <target> <attribute> = <value> or coef <constraint> <variable> = <value>")
+                });
+            }
+
+            return patch;
+        }
+
+        public static ModelPatch FromJson(string json)
+        {
+            return JsonSerializer.Deserialize<ModelPatch>(json, jsonOptions)
+                ?? throw new InvalidOperationException("Patch file is empty");
+        }
+
+        public string ToJson() => JsonSerializer.Serialize(this, jsonOptions);
+
+        /// <summary>
+        /// The text format
+        /// </summary>
+        public override string ToString()
+        {
+            var sb = new StringBuilder();
+            if (Title.Length > 0)
+                sb.AppendLine($"# {Title}");
+            foreach (var operation in Operations)
+                sb.AppendLine(operation.ToString());
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Turns the operations into statement-level edits against the source, checking each
+        /// against the declarations as the earlier ones leave them. The source is not changed.
+        /// The inverse patch replaces, removes or re-adds every touched declaration as it was;
+        /// re-added declarations go to the end of the model.
+        /// </summary>
+        public ChangeSet Resolve(ModelSource source, out ModelPatch inverse)
+        {
+            var working = ModelSource.Parse(source.ToString());
+            var changes = new ChangeSet { Title = Title };
+            var undo = new List<PatchOperation>();
+
+            foreach (var operation in Operations)
+            {
+                string where = operation.LineNumber > 0 ? $"Patch '{Title}' line {operation.LineNumber}" : $"Patch '{Title}'";
+                ModelEdit edit;
+                string key;
+                try
+                {
+                    (edit, key) = ResolveOperation(working, operation);
+                }
+                catch (InvalidOperationException ex)
+                {
+                    throw new InvalidOperationException($"{where}: {ex.Message}", ex);
+                }
+
+                string? before = working.Find(key)?.Code;
+                changes.Edits.Add(edit);
+                new ChangeSet(Title, edit).Apply(working);
+                undo.Add(before == null ? PatchOperation.Remove(key)
+                    : working.Find(key) == null ? PatchOperation.Add(before)
+                    : PatchOperation.Replace(before));
+            }
+
+            undo.Reverse();
+            inverse = new ModelPatch { Title = $"Revert {Title}".TrimEnd(), Operations = undo };
+            return changes;
+        }
+
+        /// <summary>
+        /// Applies the patch to a model text, atomically: if an operation does not apply or the
+        /// result has parse errors the text did not have, nothing is applied and the reason is thrown
+        /// </summary>
+        public string Apply(string modelText, out ModelPatch inverse)
+        {
+            var source = ModelSource.Parse(modelText);
+            var changes = Resolve(source, out inverse);
+            changes.Apply(source);
+            string patched = source.ToString();
+
+            var newErrors = ParseErrors(patched).Except(ParseErrors(modelText)).ToList();
+            if (newErrors.Count > 0)
+                throw new InvalidOperationException($"Patch '{Title}' would break the model: {string.Join("; ", newErrors)}");
+
+            return patched;
+        }
+
+        private static List<string> ParseErrors(string modelText)
+        {
+            if (string.IsNullOrWhiteSpace(modelText))
+                return new List<string>();
+
+            var result = new EquationParser(new ModelManager()).Parse(modelText);
+            return result.Errors.Select(e => e.Message).ToList();
+        }
+
+        private static (ModelEdit Edit, string Key) ResolveOperation(ModelSource source, PatchOperation operation)
+        {
+            switch (operation.Op)
+            {
+                case PatchOperationKind.Add:
+                case PatchOperationKind.Replace:
+                {
+                    var edit = ModelEdit.Upsert(operation.Statement ?? throw new InvalidOperationException($"{operation.Op} needs a statement"));
+                    bool exists = source.Find(edit.Key!) != null;
+                    if (operation.Op == PatchOperationKind.Add && exists)
+                        throw new InvalidOperationException($"'{edit.Key}' is already declared; use replace to change it");
+                    if (operation.Op == PatchOperationKind.Replace && !exists)
+                        throw new InvalidOperationException($"'{edit.Key}' is not declared; use add to declare it");
+                    return (edit, edit.Key!);
+                }
+
+                case PatchOperationKind.Remove:
+                {
+                    var statement = Find(source, operation.Target);
+                    return (ModelEdit.Remove(statement.Key!), statement.Key!);
+                }
+
+                case PatchOperationKind.Set:
+                {
+                    var statement = Find(source, operation.Target);
+                    string value = operation.Value ?? throw new InvalidOperationException("set needs a value");
+                    string code = statement.Key!.StartsWith("variable:", StringComparison.Ordinal)
+                        ? SetBound(statement.Code, operation.Attribute, value)
+                        : statement.Key.StartsWith("parameter:", StringComparison.Ordinal) && operation.Attribute == "value"
+                            ? SetValue(statement.Code, value)
+                            : throw new InvalidOperationException(
+                                $"'{operation.Attribute}' cannot be set on {statement.Key}; parameters have value, variables lb and ub");
+                    return (ModelEdit.Upsert(code), statement.Key);
+                }
+
+                default:
+                {
+                    var statement = Find(source, operation.Target);
+                    if (!statement.Key!.StartsWith("constraint:", StringComparison.Ordinal))
+                        throw new InvalidOperationException($"{statement.Key} is not a constraint");
+                    string code = SetCoefficient(statement.Code, operation.Variable ?? "", operation.Value ?? "");
+                    return (ModelEdit.Upsert(code), statement.Key);
+                }
+            }
+        }
+
+        /// <summary>
+        /// The declaration with the key, or the only one with the name
+        /// </summary>
+        private static ModelStatement Find(ModelSource source, string? target)
+        {
+            if (string.IsNullOrEmpty(target))
+                throw new InvalidOperationException("operation needs a target");
+
+            if (target.Contains(':'))
+                return source.Find(target) ?? throw new InvalidOperationException($"'{target}' is not declared");
+
+            var matches = source.Statements.Where(s => s.Key != null && s.Key.EndsWith(":" + target, StringComparison.Ordinal)).ToList();
+            return matches.Count switch
+            {
+                1 => matches[0],
+                0 => throw new InvalidOperationException($"'{target}' is not declared"),
+                _ => throw new InvalidOperationException($"'{target}' is ambiguous: {string.Join(", ", matches.Select(m => m.Key))}")
+            };
+        }
+
+        private static string SetValue(string code, string value)
+        {
+            var match = initializerPattern.Match(code);
+            return $"{match.Groups["head"].Value} = {value};";
+        }
+
+        private static string SetBound(string code, string? attribute, string value)
+        {
+            if (attribute is not ("lb" or "ub"))
+                throw new InvalidOperationException($"'{attribute}' cannot be set on a variable; use lb or ub");
+
+            string body = code.TrimEnd().TrimEnd(';');
+            var match = boundsPattern.Match(body);
+            string lo = match.Success ? match.Groups["lo"].Value : Regex.IsMatch(body, @"^dvar\s+\w+\+") ? "0" : "-infinity";
+            string hi = match.Success ? match.Groups["hi"].Value : "infinity";
+            if (attribute == "lb")
+                lo = value;
+            else
+                hi = value;
+
+            string bounds = $" in {lo}..{hi}";
+            return (match.Success ? body.Remove(match.Index, match.Length).Insert(match.Index, bounds) : body + bounds) + ";";
+        }
+
+        /// <summary>
+        /// Rewrites the term of the variable on the left-hand side: "2*x[i]" becomes "3*x[i]", a
+        /// missing term is appended, and a coefficient of 0 removes the term
+        /// </summary>
+        private static string SetCoefficient(string code, string variable, string value)
+        {
+            string body = code.TrimEnd().TrimEnd(';');
+            int colon = FindLabelColon(body);
+            var relation = relationPattern.Match(body, colon + 1);
+            if (!relation.Success)
+                throw new InvalidOperationException("constraint has no relational operator");
+
+            string left = body.Substring(colon + 1, relation.Index - colon - 1);
+            var termPattern = new Regex(
+                @"(?<sign>[+-]?)\s*(?:(?<coef>\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|\w+(?:\[[^\]]*\])*)\s*\*\s*)?(?<var>\b" + Regex.Escape(variable) + @"\b(?:\s*\[[^\]]*\])*)(?=\s*(?:[+-]|$))");
+            var terms = termPattern.Matches(left);
+            if (terms.Count > 1)
+                throw new InvalidOperationException($"'{variable}' appears {terms.Count} times on the left-hand side");
+            if (terms.Count == 0 && Regex.IsMatch(body.Substring(relation.Index), @"\b" + Regex.Escape(variable) + @"\b"))
+                throw new InvalidOperationException($"'{variable}' is on the right-hand side; move it left to set its coefficient");
+
+            bool numeric = double.TryParse(value, NumberStyles.Float, CultureInfo.InvariantCulture, out double number);
+            string newLeft;
+            if (terms.Count == 0)
+            {
+                if (numeric && number == 0)
+                    return code;
+                newLeft = left.TrimEnd() + " " + Term(variable, value, numeric, number, first: false) + " ";
+            }
+            else
+            {
+                var term = terms[0];
+                bool first = left.Substring(0, term.Index).Trim().Length == 0;
+                string replacement = numeric && number == 0 ? "" : Term(term.Groups["var"].Value, value, numeric, number, first);
+                string head = left.Substring(0, term.Index);
+                newLeft = (first ? head + replacement : head.TrimEnd() + (replacement.Length == 0 ? "" : " " + replacement))
+                    + left.Substring(term.Index + term.Length);
+                newLeft = Regex.Replace(newLeft, @"^(\s*)\+\s*", "$1");
+                if (newLeft.Trim().Length == 0)
+                    newLeft = " 0 ";
+            }
+
+            return body.Substring(0, colon + 1) + newLeft + body.Substring(relation.Index) + ";";
+        }
+
+        private static string Term(string variable, string value, bool numeric, double number, bool first)
+        {
+            if (!numeric)
+                return (first ? "" : "+ ") + $"({value})*{variable}";
+
+            string magnitude = Math.Abs(number) == 1 ? "" : Math.Abs(number).ToString(CultureInfo.InvariantCulture) + "*";
+            string sign = number < 0 ? (first ? "-" : "- ") : (first ? "" : "+ ");
+            return sign + magnitude + variable;
+        }
+
+        /// <summary>
+        /// Index of the colon after the constraint label (after the forall header if there is one), or -1
+        /// </summary>
+        private static int FindLabelColon(string body)
+        {
+            int start = 0;
+            if (body.StartsWith("forall", StringComparison.Ordinal))
+            {
+                int depth = 0;
+                for (int i = body.IndexOf('('); i >= 0 && i < body.Length; i++)
+                {
+                    if (body[i] == '(') depth++;
+                    else if (body[i] == ')' && --depth == 0)
+                    {
+                        start = i + 1;
+                        break;
+                    }
+                }
+            }
+
+            var label = new Regex(@"\G\s*\w+(?:\[[^\]]*\])?\s*:(?!=)").Match(body, start);
+            return label.Success ? label.Index + label.Length - 1 : start - 1;
+        }
+    }
+}
@@ -0,0 +1,459 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// One top-level statement of a model text, including the whitespace and comments before it
+    /// </summary>
+    public class ModelStatement
+    {
+        /// <summary>
+        /// Entity key in EntityCatalog form ("parameter:cost", "constraint:cap", "objective"),
+        /// or null for statements that do not declare a named entity
+        /// </summary>
+        public string? Key { get; init; }
+
+        /// <summary>
+        /// Full text of the statement, with leading trivia, exactly as in the source
+        /// </summary>
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// Statement text without leading whitespace and comments
+        /// </summary>
+        public string Code => Text.Substring(TriviaLength(Text));
+
+        public int LineNumber { get; init; }
+
+        public override string ToString() => Key ?? Code;
+
+        /// <summary>
+        /// Length of the whitespace, comments and docstrings at <paramref name="start"/>
+        /// </summary>
+        internal static int TriviaLength(string text, int start = 0)
+        {
+            int i = start;
+            while (i < text.Length)
+            {
+                if (char.IsWhiteSpace(text[i]))
+                {
+                    i++;
+                }
+                else if (i + 1 < text.Length && text[i] == '/' && text[i + 1] == '/')
+                {
+                    int end = text.IndexOf('\n', i);
+                    i = end < 0 ? text.Length : end + 1;
+                }
+                else if (i + 1 < text.Length && text[i] == '/' && text[i + 1] == '*')
+                {
+                    int end = text.IndexOf("*/", i + 2, StringComparison.Ordinal);
+                    i = end < 0 ? text.Length : end + 2;
+                }
+                else if (Docstrings.Length(text, i) is int docstring and > 0)
+                {
+                    i += docstring;
+                }
+                else
+                {
+                    break;
+                }
+            }
+            return i - start;
+        }
+    }
+
+    /// <summary>
+    /// Statement-level view of a model text that supports replacing, inserting and removing
+    /// the declaration of a single entity while leaving the rest of the text byte-for-byte intact
+    /// </summary>
+    public class ModelSource
+    {
+        private static readonly string[] BlockKeywords = { "execute", "tuple", "forall", "main" };
+
+        private static readonly Regex subjectToPattern = new Regex(@"\Gsubject\s+to\s*\{");
+        private static readonly Regex headPattern = new Regex(@"\G\w+");
+
+        private static readonly Regex blockAnnotationPattern = new Regex(@"^[ \t]*//[ \t]*@(block|endblock)\b[^\n]*\n?", RegexOptions.Multiline);
+        private static readonly Regex docstringLinePattern = new Regex(@"^[ \t]*#:[^\n]*\n?", RegexOptions.Multiline);
+
+        private static readonly (Regex Pattern, EntityKind Kind)[] DeclarationPatterns =
+        {
+            (new Regex(@"^range\s+(\w+)"), EntityKind.Set),
+            (new Regex(@"^\{[^}]*\}\s*(\w+)"), EntityKind.Set),
+            (new Regex(@"^dvar\s+\w+\+?\s+(\w+)"), EntityKind.Variable),
+            (new Regex(@"^dexpr\s+\w+\s+(\w+)"), EntityKind.DecisionExpression),
+            (new Regex(@"^(?:float|int|bool|string)\+?\s+(\w+)"), EntityKind.Parameter),
+            (new Regex(@"^forall\s*\(.*?\)\s*(\w+)\s*:(?!:)", RegexOptions.Singleline), EntityKind.Constraint),
+            (new Regex(@"^(?!forall\b|subject\b|minimize\b|maximize\b)(\w+)\s*:(?!:)"), EntityKind.Constraint)
+        };
+
+        private readonly List<ModelStatement> statements;
+
+        /// <summary>
+        /// Whitespace and comments after the last statement
+        /// </summary>
+        private string trailer;
+
+        internal ModelSource(List<ModelStatement> statements, string trailer)
+        {
+            this.statements = statements;
+            this.trailer = trailer;
+        }
+
+        public IReadOnlyList<ModelStatement> Statements => statements;
+
+        /// <summary>
+        /// Whitespace and comments after the last statement
+        /// </summary>
+        internal string Trailer => trailer;
+
+        public static ModelSource Parse(string text)
+        {
+            var statements = new List<ModelStatement>();
+            int start = 0;
+            int line = 1;
+
+            while (ReadStatement(text, ref start, ref line) is ModelStatement statement)
+                statements.Add(statement);
+
+            return new ModelSource(statements, text.Substring(start));
+        }
+
+        /// <summary>
+        /// Reads the statement at <paramref name="start"/>, a position between statements of line
+        /// <paramref name="line"/>, and moves both past it. Returns null if only trivia is left.
+        /// </summary>
+        internal static ModelStatement? ReadStatement(string text, ref int start, ref int line)
+        {
+            int codeStart = start + ModelStatement.TriviaLength(text, start);
+            if (codeStart >= text.Length)
+                return null;
+
+            int end = FindStatementEnd(text, codeStart);
+            var statement = new ModelStatement
+            {
+                Key = GetKey(text.Substring(codeStart, end - codeStart)),
+                Text = text.Substring(start, end - start),
+                LineNumber = line + CountLines(text, start, codeStart)
+            };
+
+            line += CountLines(text, start, end);
+            start = end;
+            return statement;
+        }
+
+        /// <summary>
+        /// Entity key declared by a single statement, or null
+        /// </summary>
+        public static string? GetKey(string statement)
+        {
+            string code = statement.Substring(ModelStatement.TriviaLength(statement));
+
+            if (Regex.IsMatch(code, @"^(minimize|maximize)\b"))
+                return EntityCatalog.KeyOf(EntityKind.Objective, "");
+
+            foreach (var (pattern, kind) in DeclarationPatterns)
+            {
+                var match = pattern.Match(code);
+                if (match.Success)
+                    return EntityCatalog.KeyOf(kind, match.Groups[1].Value);
+            }
+
+            return null;
+        }
+
+        public ModelStatement? Find(string key)
+        {
+            return statements.FirstOrDefault(s => s.Key == key);
+        }
+
+        /// <summary>
+        /// Replaces the statement declaring the same entity as <paramref name="statement"/>,
+        /// or appends it. Returns true if an existing statement was replaced.
+        /// </summary>
+        public bool Upsert(string statement)
+        {
+            string code = statement.Trim();
+            if (!code.EndsWith(";") && !code.EndsWith("}"))
+                code += ";";
+
+            string? key = GetKey(code)
+                ?? throw new InvalidOperationException($"Statement does not declare a named entity: {code}");
+
+            int index = statements.FindIndex(s => s.Key == key);
+            if (index >= 0)
+            {
+                var existing = statements[index];
+                string trivia = existing.Text.Substring(0, ModelStatement.TriviaLength(existing.Text));
+                statements[index] = new ModelStatement { Key = key, Text = trivia + code, LineNumber = existing.LineNumber };
+                return true;
+            }
+
+            int insertAt = FindInsertPosition(key);
+            var previous = insertAt > 0 ? statements[insertAt - 1] : null;
+            statements.Insert(insertAt, new ModelStatement
+            {
+                Key = key,
+                Text = (statements.Count > 0 || trailer.Length > 0 ? Environment.NewLine : "") + code,
+                LineNumber = previous?.LineNumber + 1 ?? 1
+            });
+            return false;
+        }
+
+        /// <summary>
+        /// The statement whose code spans the given line, or null
+        /// </summary>
+        public ModelStatement? FindAt(int lineNumber)
+        {
+            return statements.LastOrDefault(s => s.LineNumber <= lineNumber
+                && lineNumber <= s.LineNumber + s.Code.Count(c => c == '\n'));
+        }
+
+        /// <summary>
+        /// Replaces the code of one statement, keyed or not, keeping its leading trivia.
+        /// Returns false if the statement is no longer part of the source.
+        /// </summary>
+        public bool Replace(ModelStatement statement, string code)
+        {
+            int index = statements.IndexOf(statement);
+            if (index < 0)
+                return false;
+
+            code = code.Trim();
+            if (!code.EndsWith(";") && !code.EndsWith("}"))
+                code += ";";
+
+            string trivia = statement.Text.Substring(0, ModelStatement.TriviaLength(statement.Text));
+            statements[index] = new ModelStatement { Key = GetKey(code), Text = trivia + code, LineNumber = statement.LineNumber };
+            return true;
+        }
+
+        /// <summary>
+        /// Sets a "// @name ..." annotation line in the leading comments of a declaration,
+        /// replacing an existing annotation with the same name or adding it right above the code.
+        /// Returns false if the entity is not declared.
+        /// </summary>
+        public bool Annotate(string key, string annotation)
+        {
+            int index = statements.FindIndex(s => s.Key == key);
+            if (index < 0)
+                return false;
+
+            annotation = annotation.Trim();
+            var name = Regex.Match(annotation, @"^//\s*@(\w+)");
+            if (!name.Success)
+                throw new InvalidOperationException($"Not an annotation comment: {annotation}");
+
+            var statement = statements[index];
+            string trivia = statement.Text.Substring(0, ModelStatement.TriviaLength(statement.Text));
+            int lineStart = trivia.LastIndexOf('\n') + 1;
+            string indent = trivia.Substring(lineStart);
+
+            var existing = new Regex($@"^([ \t]*)//[ \t]*@{name.Groups[1].Value}\b[^\r\n]*", RegexOptions.Multiline);
+            trivia = existing.IsMatch(trivia)
+                ? existing.Replace(trivia, m => m.Groups[1].Value + annotation, 1)
+                : trivia.Substring(0, lineStart) + indent + annotation + Environment.NewLine + indent;
+
+            statements[index] = new ModelStatement { Key = key, Text = trivia + statement.Code, LineNumber = statement.LineNumber };
+            return true;
+        }
+
+        /// <summary>
+        /// Sets the docstring of a declaration as "#:" lines right above the code, replacing the
+        /// "#:" lines it has there. Returns false if the entity is not declared.
+        /// </summary>
+        public bool Document(string key, string documentation)
+        {
+            int index = statements.FindIndex(s => s.Key == key);
+            if (index < 0)
+                return false;
+
+            var statement = statements[index];
+            string trivia = statement.Text.Substring(0, ModelStatement.TriviaLength(statement.Text));
+
+            // The first line of the trivia may hold the trailing docstring of the statement before
+            int ownStart = index > 0 ? trivia.IndexOf('\n') + 1 : 0;
+            if (index > 0 && ownStart == 0)
+            {
+                trivia += Environment.NewLine;
+                ownStart = trivia.Length;
+            }
+
+            int lineStart = trivia.LastIndexOf('\n') + 1;
+            string indent = trivia.Substring(lineStart);
+            string own = docstringLinePattern.Replace(trivia.Substring(ownStart, lineStart - ownStart), "");
+            string lines = string.Concat(documentation.Trim().Split('\n')
+                .Select(line => $"{indent}#: {line.Trim()}{Environment.NewLine}"));
+
+            trivia = trivia.Substring(0, ownStart) + own + lines + indent;
+            statements[index] = new ModelStatement { Key = key, Text = trivia + statement.Code, LineNumber = statement.LineNumber };
+            return true;
+        }
+
+        /// <summary>
+        /// Removes a declaration with its leading comments. @block and @endblock annotations among
+        /// them belong to the block, not the statement, so they move on to the next statement; a
+        /// block that is left without declarations is removed with its annotations.
+        /// </summary>
+        public bool Remove(string key)
+        {
+            int index = statements.FindIndex(s => s.Key == key);
+            if (index < 0)
+                return false;
+
+            var removed = statements[index];
+            statements.RemoveAt(index);
+
+            var carried = blockAnnotationPattern.Matches(removed.Text.Substring(0, removed.Text.Length - removed.Code.Length))
+                .Select(m => m.Value.EndsWith("\n") ? m.Value : m.Value + "\n")
+                .ToList();
+            if (carried.Count > 0)
+            {
+                var next = index < statements.Count ? statements[index] : null;
+                string text = CarryAnnotations(carried, next?.Text ?? trailer, index > 0);
+                if (next != null)
+                    statements[index] = new ModelStatement { Key = next.Key, Text = text, LineNumber = next.LineNumber };
+                else
+                    trailer = text;
+            }
+            return true;
+        }
+
+        public override string ToString()
+        {
+            var sb = new StringBuilder();
+            foreach (var statement in statements)
+                sb.Append(statement.Text);
+            sb.Append(trailer);
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Inserts block annotations at the start of the leading comments of <paramref name="text"/>,
+        /// dropping an opening annotation that meets the closing one of the same block
+        /// </summary>
+        private static string CarryAnnotations(List<string> carried, string text, bool afterCode)
+        {
+            int trivia = ModelStatement.TriviaLength(text);
+            var first = blockAnnotationPattern.Match(text.Substring(0, trivia));
+            while (carried.Count > 0 && first.Success && first.Groups[1].Value == "endblock" &&
+                   blockAnnotationPattern.Match(carried[^1]).Groups[1].Value == "block")
+            {
+                carried.RemoveAt(carried.Count - 1);
+                text = text.Remove(first.Index, first.Length);
+                trivia -= first.Length;
+                first = blockAnnotationPattern.Match(text.Substring(0, trivia));
+            }
+
+            // Annotations must start on a line of their own
+            int newline = text.IndexOf('\n', 0, trivia);
+            if (newline >= 0)
+                return text.Insert(newline + 1, string.Concat(carried));
+            return afterCode && carried.Count > 0 ? "\n" + string.Concat(carried) + text : string.Concat(carried) + text;
+        }
+
+        /// <summary>
+        /// New declarations go after the last statement of the same kind (so sets stay before
+        /// parameters that use them); constraints and objectives go at the end
+        /// </summary>
+        private int FindInsertPosition(string key)
+        {
+            string prefix = key.Contains(':') ? key.Substring(0, key.IndexOf(':') + 1) : key;
+            int last = statements.FindLastIndex(s => s.Key != null && s.Key.StartsWith(prefix, StringComparison.Ordinal));
+
+            if (last >= 0 && !prefix.StartsWith("constraint", StringComparison.Ordinal))
+                return last + 1;
+
+            // Do not append after the closing brace of a "subject to" block
+            int close = statements.FindLastIndex(s => s.Code.StartsWith("}"));
+            int open = statements.FindLastIndex(s => Regex.IsMatch(s.Code, @"^subject\s+to\b"));
+            if (prefix.StartsWith("constraint", StringComparison.Ordinal) && open >= 0 && close > open)
+                return close;
+
+            return statements.Count;
+        }
+
+        /// <summary>
+        /// Index just past the end of the statement starting at <paramref name="start"/>: the ';' at
+        /// nesting depth 0, the '}' closing a block statement (execute, tuple, forall {...}), or the
+        /// opening/closing brace of a "subject to" block
+        /// </summary>
+        private static int FindStatementEnd(string text, int start)
+        {
+            var subjectTo = subjectToPattern.Match(text, start);
+            if (subjectTo.Success)
+                return start + subjectTo.Length;
+
+            if (text[start] == '}')
+                return SkipOptionalSemicolon(text, start + 1);
+
+            string head = headPattern.Match(text, start).Value;
+            bool isBlock = BlockKeywords.Contains(head);
+            int depth = 0;
+            int i = start;
+
+            while (i < text.Length)
+            {
+                char c = text[i];
+
+                if (c == '/' && i + 1 < text.Length && (text[i + 1] == '/' || text[i + 1] == '*'))
+                {
+                    i += ModelStatement.TriviaLength(text, i);
+                    continue;
+                }
+
+                if (c == '"')
+                {
+                    int close = text.IndexOf('"', i + 1);
+                    i = close < 0 ? text.Length : close + 1;
+                    continue;
+                }
+
+                if (c is '(' or '[' or '{')
+                {
+                    depth++;
+                }
+                else if (c is ')' or ']' or '}')
+                {
+                    // An unmatched closer ends the statement (e.g. a missing ';' before a block's '}')
+                    if (depth == 0)
+                        return i > start ? i : i + 1;
+
+                    depth--;
+                    if (depth == 0 && c == '}' && isBlock)
+                        return SkipOptionalSemicolon(text, i + 1);
+                }
+                else if (c == ';' && depth == 0)
+                {
+                    return i + 1;
+                }
+
+                i++;
+            }
+
+            return text.Length;
+        }
+
+        private static int SkipOptionalSemicolon(string text, int index)
+        {
+            int i = index;
+            while (i < text.Length && (text[i] == ' ' || text[i] == '\t'))
+                i++;
+
+            return i < text.Length && text[i] == ';' ? i + 1 : index;
+        }
+
+        private static int CountLines(string text, int from, int to)
+        {
+            int count = 0;
+            for (int i = from; i < to; i++)
+            {
+                if (text[i] == '\n')
+                    count++;
+            }
+            return count;
+        }
+    }
+}
@@ -0,0 +1,361 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    public enum TableColumnType
+    {
+        Int,
+        Float,
+        Bool,
+        String,
+
+        /// <summary>
+        /// ISO date (2026-03-01); counts as its day number in arithmetic, so end - start is a number of days
+        /// </summary>
+        Date
+    }
+
+    public class TableColumn
+    {
+        public string Name { get; init; } = "";
+        public TableColumnType Type { get; init; }
+
+        /// <summary>
+        /// The column may be left empty in a row
+        /// </summary>
+        public bool Optional { get; init; }
+
+        /// <summary>
+        /// The column is part of the row key, which must be unique in the table
+        /// </summary>
+        public bool Key { get; init; }
+
+        public override string ToString() =>
+            $"{Name}: {Type.ToString().ToLowerInvariant()}{(Optional ? "?" : "")}{(Key ? " key" : "")}";
+    }
+
+    /// <summary>
+    /// One custom table of a model: its schema and the rows, validated against it
+    /// </summary>
+    public class ModelDataTable
+    {
+        private static readonly Regex conditionPattern = new Regex(@"^\s*(?<column>[A-Za-z_]\w*)\s*(?<op>==|!=|<=|>=|<|>)\s*(?<value>.*?)\s*$");
+
+        public string Name { get; init; } = "";
+        public List<TableColumn> Columns { get; init; } = new List<TableColumn>();
+
+        /// <summary>
+        /// Values in column order: int, double, bool, string or DateOnly, null for an empty optional value
+        /// </summary>
+        public List<object?[]> Rows { get; } = new List<object?[]>();
+
+        public int LineNumber { get; init; }
+
+        public int ColumnIndex(string column)
+        {
+            int index = Columns.FindIndex(c => c.Name == column);
+            return index >= 0 ? index : throw new InvalidOperationException($"Table '{Name}' has no column '{column}'");
+        }
+
+        public object? this[int row, string column] => Rows[row][ColumnIndex(column)];
+
+        /// <summary>
+        /// A value as a number: dates as their day number, booleans as 0 or 1
+        /// </summary>
+        public double Number(int row, string column)
+        {
+            return Rows[row][ColumnIndex(column)] switch
+            {
+                int i => i,
+                double d => d,
+                bool b => b ? 1 : 0,
+                DateOnly date => date.DayNumber,
+                null => throw new InvalidOperationException($"Table '{Name}' row {row + 1} has no {column}"),
+                _ => throw new InvalidOperationException($"Column '{column}' of table '{Name}' is not numeric")
+            };
+        }
+
+        /// <summary>
+        /// The row with the given key values, in key column order, or null
+        /// </summary>
+        public object?[]? Find(params object[] key)
+        {
+            var keys = Columns.Select((c, i) => (c, i)).Where(p => p.c.Key).Select(p => p.i).ToList();
+            if (keys.Count != key.Length)
+                throw new InvalidOperationException($"Table '{Name}' has {keys.Count} key column(s)");
+
+            return Rows.FirstOrDefault(r => keys.Select((k, i) => Equals(r[k], Convert(Columns[k], key[i]))).All(m => m));
+        }
+
+        /// <summary>
+        /// The rows matching every condition of a filter such as "unit == U1 and start >= 2026-03-01",
+        /// as column-to-value maps; a null or empty filter selects all rows
+        /// </summary>
+        public List<IReadOnlyDictionary<string, object?>> Select(string? where = null)
+        {
+            var conditions = new List<Func<object?[], bool>>();
+            foreach (string part in string.IsNullOrWhiteSpace(where) ? Array.Empty<string>() : Regex.Split(where, @"\s+and\s+"))
+            {
+                var match = conditionPattern.Match(part);
+                if (!match.Success)
+                    throw new InvalidOperationException($"Invalid condition '{part.Trim()}'; expected <column> <op> <value>");
+
+                int index = ColumnIndex(match.Groups["column"].Value);
+                string op = match.Groups["op"].Value;
+                object? value = Parse(Columns[index], Unquote(match.Groups["value"].Value), out string? error);
+                if (error != null)
+                    throw new InvalidOperationException(error);
+
+                conditions.Add(row =>
+                {
+                    int order = Compare(row[index], value);
+                    return op switch
+                    {
+                        "==" => order == 0,
+                        "!=" => order != 0,
+                        _ when row[index] == null || value == null => false,
+                        "<" => order < 0,
+                        "<=" => order <= 0,
+                        ">" => order > 0,
+                        _ => order >= 0
+                    };
+                });
+            }
+
+            return Rows
+                .Where(r => conditions.All(c => c(r)))
+                .Select(r => (IReadOnlyDictionary<string, object?>)Columns.Select((c, i) => (c.Name, Value: r[i]))
+                    .ToDictionary(p => p.Name, p => p.Value, StringComparer.Ordinal))
+                .ToList();
+        }
+
+        /// <summary>
+        /// The table in the annotation format it is read from
+        /// </summary>
+        public override string ToString()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine($"/* @table {Name}({string.Join(", ", Columns)})");
+            foreach (var row in Rows)
+                sb.AppendLine(string.Join(", ", row.Select(Format)));
+            sb.Append("*/");
+            return sb.ToString();
+        }
+
+        internal static object? Parse(TableColumn column, string text, out string? error)
+        {
+            error = null;
+            if (text.Length == 0)
+            {
+                if (!column.Optional)
+                    error = $"Column '{column.Name}' needs a value";
+                return null;
+            }
+
+            switch (column.Type)
+            {
+                case TableColumnType.Int when int.TryParse(text, NumberStyles.Integer, CultureInfo.InvariantCulture, out int i):
+                    return i;
+                case TableColumnType.Float when double.TryParse(text, NumberStyles.Float, CultureInfo.InvariantCulture, out double d):
+                    return d;
+                case TableColumnType.Bool when bool.TryParse(text, out bool b):
+                    return b;
+                case TableColumnType.Date when DateOnly.TryParseExact(text, "yyyy-MM-dd", CultureInfo.InvariantCulture, DateTimeStyles.None, out var date):
+                    return date;
+                case TableColumnType.String:
+                    return text;
+                default:
+                    error = $"Column '{column.Name}': '{text}' is not a valid {column.Type.ToString().ToLowerInvariant()}";
+                    return null;
+            }
+        }
+
+        private static object? Convert(TableColumn column, object value)
+        {
+            if (value is string text)
+                return Parse(column, text, out string? error) ?? throw new InvalidOperationException(error ?? $"Key column '{column.Name}' needs a value");
+            return column.Type == TableColumnType.Float && value is int i ? (double)i : value;
+        }
+
+        private static int Compare(object? a, object? b)
+        {
+            if (a == null || b == null)
+                return a == null && b == null ? 0 : a == null ? -1 : 1;
+            return a is string s ? string.CompareOrdinal(s, (string)b) : ((IComparable)a).CompareTo(b);
+        }
+
+        internal static string Unquote(string text)
+        {
+            return text.Length >= 2 && text[0] == '"' && text[^1] == '"' ? text.Substring(1, text.Length - 2).Replace("\"\"", "\"") : text;
+        }
+
+        private static string Format(object? value) => value switch
+        {
+            null => "",
+            double d => d.ToString("R", CultureInfo.InvariantCulture),
+            bool b => b ? "true" : "false",
+            DateOnly date => date.ToString("yyyy-MM-dd", CultureInfo.InvariantCulture),
+            string s when s.IndexOfAny(new[] { ',', '"' }) >= 0 || s != s.Trim() => "\"" + s.Replace("\"", "\"\"") + "\"",
+            _ => System.Convert.ToString(value, CultureInfo.InvariantCulture) ?? ""
+        };
+    }
+
+    /// <summary>
+    /// Auxiliary tables kept in the model text itself, so they are stored, packaged, patched and
+    /// versioned with the model instead of living in side files:
+    /// <code>
+    /// /* @table maintenance(unit: string key, start: date key, end: date, hours: float, note: string?)
+    /// U1, 2026-03-01, 2026-03-14, 40, overhaul
+    /// U2, 2026-05-10, 2026-05-12, 8,
+    /// */
+    /// </code>
+    /// Column types are int, float, bool, string and date (yyyy-MM-dd); "?" allows empty values
+    /// and "key" columns together identify a row. Values are separated by commas; a string with a
+    /// comma is written in double quotes. Rows that do not match the schema are parse errors at
+    /// their line. KPI and alert expressions reach a table as a set of row numbers and its values
+    /// as "table.column[row]", e.g. sum(r in maintenance) maintenance.hours[r]; code queries it
+    /// through ModelDataTable.Select and Find.
+    /// </summary>
+    public class ModelTables
+    {
+        private static readonly Regex tablePattern = new Regex(@"/\*[ \t]*@table\b(?<body>.*?)\*/", RegexOptions.Singleline);
+        private static readonly Regex headerPattern = new Regex(@"^[ \t]*(?<name>[A-Za-z_]\w*)[ \t]*\((?<columns>[^)]*)\)[ \t]*$");
+        private static readonly Regex columnPattern = new Regex(@"^(?<name>[A-Za-z_]\w*)\s*:\s*(?<type>int|float|bool|string|date)(?<optional>\?)?(?:\s+(?<key>key))?$");
+
+        private readonly Dictionary<string, ModelDataTable> tables = new Dictionary<string, ModelDataTable>(StringComparer.Ordinal);
+
+        public IEnumerable<ModelDataTable> Tables => tables.Values;
+
+        public static bool IsUsed(string modelText) => modelText.Contains("@table");
+
+        public ModelDataTable? Find(string name) => tables.TryGetValue(name, out var table) ? table : null;
+
+        /// <summary>
+        /// Reads the @table comments of a model text; a table with schema errors is left out,
+        /// a row with invalid values is reported and skipped
+        /// </summary>
+        public void Read(string modelText, ParseSessionResult result)
+        {
+            foreach (Match m in tablePattern.Matches(modelText))
+            {
+                int lineNumber = 1 + modelText.Take(m.Index).Count(c => c == '\n');
+                var lines = m.Groups["body"].Value.Replace("\r\n", "\n").Split('\n');
+
+                var header = headerPattern.Match(lines[0]);
+                if (!header.Success)
+                {
+                    result.AddError("Expected '@table <name>(<column>: <type>, ...)'", lineNumber);
+                    continue;
+                }
+
+                string name = header.Groups["name"].Value;
+                if (tables.TryGetValue(name, out var existing))
+                {
+                    result.AddError($"Table '{name}' is already defined on line {existing.LineNumber}", lineNumber);
+                    continue;
+                }
+
+                var columns = new List<TableColumn>();
+                foreach (string part in header.Groups["columns"].Value.Split(',', StringSplitOptions.TrimEntries))
+                {
+                    var column = columnPattern.Match(part);
+                    if (!column.Success)
+                    {
+                        result.AddError($"Table '{name}': invalid column '{part}'; expected <name>: int|float|bool|string|date, with ? for optional and key for key columns", lineNumber);
+                        columns = null;
+                        break;
+                    }
+                    if (columns.Any(c => c.Name == column.Groups["name"].Value))
+                    {
+                        result.AddError($"Table '{name}': column '{column.Groups["name"].Value}' is declared twice", lineNumber);
+                        columns = null;
+                        break;
+                    }
+                    columns.Add(new TableColumn
+                    {
+                        Name = column.Groups["name"].Value,
+                        Type = Enum.Parse<TableColumnType>(column.Groups["type"].Value, ignoreCase: true),
+                        Optional = column.Groups["optional"].Success,
+                        Key = column.Groups["key"].Success
+                    });
+                }
+                if (columns == null)
+                    continue;
+                if (columns.Any(c => c.Key && c.Optional))
+                {
+                    result.AddError($"Table '{name}': key columns cannot be optional", lineNumber);
+                    continue;
+                }
+
+                var table = new ModelDataTable { Name = name, Columns = columns, LineNumber = lineNumber };
+                var keys = new Dictionary<string, int>(StringComparer.Ordinal);
+                for (int i = 1; i < lines.Length; i++)
+                {
+                    if (lines[i].Trim().Length == 0)
+                        continue;
+
+                    int line = lineNumber + i;
+                    var values = SplitRow(lines[i]);
+                    if (values.Count != columns.Count)
+                    {
+                        result.AddError($"Table '{name}': row has {values.Count} value(s), the schema {columns.Count}", line);
+                        continue;
+                    }
+
+                    var row = new object?[columns.Count];
+                    string? error = null;
+                    for (int c = 0; c < columns.Count && error == null; c++)
+                        row[c] = ModelDataTable.Parse(columns[c], values[c], out error);
+                    if (error != null)
+                    {
+                        result.AddError($"Table '{name}': {error}", line);
+                        continue;
+                    }
+
+                    if (columns.Any(c => c.Key))
+                    {
+                        string key = string.Join("\u001f", columns.Select((c, k) => c.Key ? values[k] : null).Where(v => v != null));
+                        if (keys.TryGetValue(key, out int first))
+                        {
+                            result.AddError($"Table '{name}': duplicate key, first used on line {first}", line);
+                            continue;
+                        }
+                        keys[key] = line;
+                    }
+
+                    table.Rows.Add(row);
+                }
+
+                tables[name] = table;
+            }
+        }
+
+        /// <summary>
+        /// Splits a row at commas outside double quotes; values are trimmed and unquoted
+        /// </summary>
+        private static List<string> SplitRow(string line)
+        {
+            var values = new List<string>();
+            var current = new StringBuilder();
+            bool quoted = false;
+            foreach (char c in line)
+            {
+                if (c == '"')
+                    quoted = !quoted;
+                if (c == ',' && !quoted)
+                {
+                    values.Add(ModelDataTable.Unquote(current.ToString().Trim()));
+                    current.Clear();
+                }
+                else
+                {
+                    current.Append(c);
+                }
+            }
+            values.Add(ModelDataTable.Unquote(current.ToString().Trim()));
+            return values;
+        }
+    }
+}
@@ -5,6 +5,7 @@ using System.Text;
 
 using System.Text.RegularExpressions;
 using Core.Models;
+using Core.Services;
 
 namespace Core.Parsing
 {
@@ -85,7 +86,7 @@ namespace Core.Parsing
                 if (start > end)
                 {
                     // This is a warning, but we'll allow it (empty range)
-                    System.Diagnostics.Debug.WriteLine($"Warning: Range '{rangeName}' has start ({start}) greater than end ({end})");
+                    modelManager.Logger.LogRangeInverted(rangeName, start, end);
                 }
             }
             catch (Exception ex)
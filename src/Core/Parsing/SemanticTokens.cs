@@ -0,0 +1,119 @@
+namespace Core.Parsing
+{
+    /// <summary>
+    /// LSP semantic tokens for a model text. The legend is fixed: a client registers TokenTypes
+    /// and TokenModifiers and reads Encode's output as (deltaLine, deltaStart, length, type,
+    /// modifiers) groups, with 0-based UTF-16 positions. Tokens spanning lines are split per line.
+    /// </summary>
+    public static class SemanticTokens
+    {
+        public static readonly IReadOnlyList<string> TokenTypes = new[]
+        {
+            "keyword", "type", "function", "number", "string", "comment", "operator",
+            "enum", "struct", "parameter", "variable", "macro", "label", "property", "decorator"
+        };
+
+        public static readonly IReadOnlyList<string> TokenModifiers = new[]
+        {
+            "declaration", "readonly", "documentation", "defaultLibrary"
+        };
+
+        /// <summary>
+        /// Semantic type and modifiers of a token, or null for punctuation and identifiers that
+        /// name no declared entity (iterators, fields in a tuple declaration).
+        /// Sets and set literals are enums, tuple types structs, decision expressions macros
+        /// and constraint names labels.
+        /// </summary>
+        public static (string Type, IReadOnlyList<string> Modifiers)? Classify(SyntaxToken token)
+        {
+            var modifiers = new List<string>();
+            if (token.IsDeclaration)
+                modifiers.Add("declaration");
+
+            string? type = token.Kind switch
+            {
+                SyntaxTokenKind.Keyword => "keyword",
+                SyntaxTokenKind.Type => "type",
+                SyntaxTokenKind.Function => "function",
+                SyntaxTokenKind.Number => "number",
+                SyntaxTokenKind.String => "string",
+                SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring => "comment",
+                SyntaxTokenKind.Operator => "operator",
+                SyntaxTokenKind.SetLiteral => "enum",
+                SyntaxTokenKind.Annotation => "decorator",
+                SyntaxTokenKind.Identifier when token.IsMember => "property",
+                SyntaxTokenKind.Identifier => token.Symbol?.Substring(0, token.Symbol.IndexOf(':')) switch
+                {
+                    "set" => "enum",
+                    "tuple" => "struct",
+                    "parameter" => "parameter",
+                    "variable" => "variable",
+                    "dexpr" => "macro",
+                    "constraint" => "label",
+                    _ => null
+                },
+                _ => null
+            };
+
+            if (token.Kind == SyntaxTokenKind.Function)
+                modifiers.Add("defaultLibrary");
+            else if (token.Kind == SyntaxTokenKind.Docstring)
+                modifiers.Add("documentation");
+            else if (type is "parameter" or "enum" && token.Kind == SyntaxTokenKind.Identifier)
+                modifiers.Add("readonly");
+
+            return type == null ? null : (type, modifiers);
+        }
+
+        /// <summary>
+        /// Encoded semantic tokens of a model text
+        /// </summary>
+        public static int[] Encode(string modelText) => Encode(ModelLexer.Classify(modelText));
+
+        public static int[] Encode(IEnumerable<SyntaxToken> tokens)
+        {
+            var data = new List<int>();
+            int previousLine = 0;
+            int previousStart = 0;
+
+            foreach (var token in tokens)
+            {
+                if (Classify(token) is not var (type, modifiers))
+                    continue;
+
+                int typeIndex = IndexOf(TokenTypes, type);
+                int modifierBits = modifiers.Aggregate(0, (bits, m) => bits | 1 << IndexOf(TokenModifiers, m));
+
+                var lines = token.Text.Split('\n');
+                for (int i = 0; i < lines.Length; i++)
+                {
+                    int length = lines[i].TrimEnd('\r').Length;
+                    if (length == 0)
+                        continue;
+
+                    int line = token.LineNumber - 1 + i;
+                    int start = i == 0 ? token.Column - 1 : 0;
+                    data.Add(line - previousLine);
+                    data.Add(line == previousLine ? start - previousStart : start);
+                    data.Add(length);
+                    data.Add(typeIndex);
+                    data.Add(modifierBits);
+                    previousLine = line;
+                    previousStart = start;
+                }
+            }
+
+            return data.ToArray();
+        }
+
+        private static int IndexOf(IReadOnlyList<string> legend, string name)
+        {
+            for (int i = 0; i < legend.Count; i++)
+            {
+                if (legend[i] == name)
+                    return i;
+            }
+            throw new InvalidOperationException($"'{name}' is not in the semantic token legend");
+        }
+    }
+}
@@ -91,7 +91,7 @@ namespace Core.Parsing
                 !modelManager.TupleSets.ContainsKey(indexSet) &&
                 !modelManager.ComputedSets.ContainsKey(indexSet))
             {
-                error = $"Index set '{indexSet}' not found";
+                error = SymbolSuggester.Annotate(modelManager, $"Index set '{indexSet}' not found", indexSet, SymbolKind.Set);
                 return false;
             }
 
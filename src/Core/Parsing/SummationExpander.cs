@@ -34,6 +34,7 @@ namespace Core.Parsing
             }
 
             // Process sums from left to right
+            long generatedTerms = 0;
             while (true)
             {
                 // Match any sum( — then use depth tracking to extract arg and body
@@ -95,17 +96,27 @@ namespace Core.Parsing
 
                 // Get indices
                 IEnumerable<int> indices;
+                long size;
                 if (modelManager.IndexSets.TryGetValue(setName, out var indexSet))
                 {
                     indices = indexSet.GetIndices();
+                    size = (long)indexSet.EndIndex - indexSet.StartIndex + 1;
                 }
                 else if (modelManager.Ranges != null && modelManager.Ranges.TryGetValue(setName, out var range))
                 {
                     indices = range.GetValues(modelManager);
+                    size = (long)range.GetEnd(modelManager) - range.GetStart(modelManager) + 1;
                 }
                 else
                 {
-                    error = $"Set or range '{setName}' not found";
+                    error = SymbolSuggester.Annotate(modelManager, $"Set or range '{setName}' not found", setName, SymbolKind.Set);
+                    return expression;
+                }
+
+                generatedTerms += Math.Max(0, size);
+                if (generatedTerms > modelManager.ExpansionLimit)
+                {
+                    error = $"Sums over '{setName}' would generate more than {modelManager.ExpansionLimit} terms";
                     return expression;
                 }
 
@@ -234,19 +245,19 @@ namespace Core.Parsing
                         double right = EvalArith(filter.Substring(opIdx + op.Length).Trim());
                         return op switch
                         {
-                            ">=" => left >= right,
-                            "<=" => left <= right,
-                            "==" => Math.Abs(left - right) < 1e-10,
-                            "!=" => Math.Abs(left - right) >= 1e-10,
-                            ">" => left > right,
-                            "<" => left < right,
+                            ">=" => NumericTolerance.Evaluation.IsGreaterOrEqual(left, right),
+                            "<=" => NumericTolerance.Evaluation.IsLessOrEqual(left, right),
+                            "==" => NumericTolerance.Evaluation.AreEqual(left, right),
+                            "!=" => !NumericTolerance.Evaluation.AreEqual(left, right),
+                            ">" => NumericTolerance.Evaluation.IsGreater(left, right),
+                            "<" => NumericTolerance.Evaluation.IsLess(left, right),
                             _ => false
                         };
                     }
                 }
 
                 // Scalar: non-zero = true
-                return Math.Abs(EvalArith(filter)) > 1e-10;
+                return !NumericTolerance.Evaluation.IsZero(EvalArith(filter));
             }
             catch
             {
@@ -327,7 +338,7 @@ namespace Core.Parsing
         /// 3. Continues past ) or ] if followed by *, /, or [
         /// 4. Handles unary +/- at start or after operators
         /// </summary>
-        private string ExtractSumBody(string expression, int startIndex, out int length)
+        internal static string ExtractSumBody(string expression, int startIndex, out int length)
         {
             length = 0;
             
@@ -464,7 +475,7 @@ namespace Core.Parsing
         /// <summary>
         /// Checks if a + or - at position i is in unary position
         /// </summary>
-        private bool IsUnaryPosition(StringBuilder body, int startIndex, int currentPos, string fullExpression)
+        private static bool IsUnaryPosition(StringBuilder body, int startIndex, int currentPos, string fullExpression)
         {
             // If body is empty, it's unary
             if (body.Length == 0)
@@ -494,7 +505,7 @@ namespace Core.Parsing
         /// <summary>
         /// Checks if a character should stop sum body extraction at depth 0
         /// </summary>
-        private bool IsStoppingCharacter(char c)
+        private static bool IsStoppingCharacter(char c)
         {
             return c == '+' || c == '-' || c == '<' || c == '>' || 
                    c == '=' || c == '!' || c == ';' || c == ')' || c == ',';
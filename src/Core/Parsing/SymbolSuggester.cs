@@ -0,0 +1,184 @@
+namespace Core.Parsing
+{
+    /// <summary>
+    /// Kinds of declared names a reference can resolve to
+    /// </summary>
+    [Flags]
+    public enum SymbolKind
+    {
+        Variable = 1,
+        Parameter = 2,
+        Set = 4,
+        DecisionExpression = 8,
+        TupleSet = 16,
+        Any = Variable | Parameter | Set | DecisionExpression | TupleSet
+    }
+
+    /// <summary>
+    /// Declared names close to an unresolved reference, attached to the parse error it caused
+    /// so an editor can offer "replace with" quick fixes
+    /// </summary>
+    public class SymbolSuggestion
+    {
+        /// <summary>
+        /// The name as written in the model
+        /// </summary>
+        public string Name { get; init; } = "";
+
+        public SymbolKind Kind { get; init; }
+
+        /// <summary>
+        /// Number of indices the reference was written with, if known
+        /// </summary>
+        public int? Arity { get; init; }
+
+        /// <summary>
+        /// Closest declared names, best first
+        /// </summary>
+        public IReadOnlyList<string> Candidates { get; init; } = Array.Empty<string>();
+
+        /// <summary>
+        /// Line of the statement with the error (0 if not known)
+        /// </summary>
+        public int LineNumber { get; internal set; }
+
+        public string? FilePath { get; internal set; }
+
+        public string Hint => Candidates.Count == 1
+            ? $"did you mean '{Candidates[0]}'?"
+            : $"did you mean one of {string.Join(", ", Candidates.Select(c => $"'{c}'"))}?";
+
+        public override string ToString() => $"'{Name}': {Hint}";
+    }
+
+    /// <summary>
+    /// Suggests declared names for unresolved references by case-insensitive edit distance
+    /// (adjacent transpositions count as one edit). Names declared with a different number of
+    /// indices than the reference rank below those with a matching one.
+    /// </summary>
+    public static class SymbolSuggester
+    {
+        public const int MaxCandidates = 3;
+
+        public static SymbolSuggestion? Suggest(ModelManager manager, string name, SymbolKind kind, int? arity = null)
+        {
+            var candidates = Rank(name, Declared(manager, kind), arity);
+            if (candidates.Count == 0)
+                return null;
+
+            return new SymbolSuggestion { Name = name, Kind = kind, Arity = arity, Candidates = candidates };
+        }
+
+        /// <summary>
+        /// Appends the suggestion for an unresolved name to an error message, and queues it for the
+        /// parse session result of the current statement (see ParseSessionResult.Suggestions)
+        /// </summary>
+        public static string Annotate(ModelManager manager, string error, string name, SymbolKind kind, int? arity = null)
+        {
+            var suggestion = Suggest(manager, name, kind, arity);
+            if (suggestion == null)
+                return error;
+
+            manager.PendingSuggestions.Add(suggestion);
+            return $"{error}; {suggestion.Hint}";
+        }
+
+        /// <summary>
+        /// Candidates within the edit budget for the name's length, best first: fewest edits
+        /// (plus one for an arity mismatch), then names that differ only in case, then ordinal
+        /// </summary>
+        public static List<string> Rank(string name, IEnumerable<(string Name, int? Arity)> declared, int? arity = null, int max = MaxCandidates)
+        {
+            int budget = MaxDistance(name);
+
+            return declared
+                .Where(d => d.Name != name)
+                .GroupBy(d => d.Name, StringComparer.Ordinal)
+                .Select(g => g.First())
+                .Select(d => (d.Name, Distance: Distance(name, d.Name), Mismatch: arity.HasValue && d.Arity.HasValue && d.Arity != arity))
+                .Where(d => d.Distance <= budget)
+                .OrderBy(d => d.Distance + (d.Mismatch ? 1 : 0))
+                .ThenBy(d => d.Mismatch)
+                .ThenBy(d => string.Equals(d.Name, name, StringComparison.OrdinalIgnoreCase) ? 0 : 1)
+                .ThenBy(d => d.Name, StringComparer.Ordinal)
+                .Take(max)
+                .Select(d => d.Name)
+                .ToList();
+        }
+
+        /// <summary>
+        /// Case-insensitive optimal string alignment distance
+        /// </summary>
+        public static int Distance(string a, string b)
+        {
+            a = a.ToLowerInvariant();
+            b = b.ToLowerInvariant();
+
+            var d = new int[a.Length + 1, b.Length + 1];
+            for (int i = 0; i <= a.Length; i++)
+                d[i, 0] = i;
+            for (int j = 0; j <= b.Length; j++)
+                d[0, j] = j;
+
+            for (int i = 1; i <= a.Length; i++)
+            {
+                for (int j = 1; j <= b.Length; j++)
+                {
+                    int cost = a[i - 1] == b[j - 1] ? 0 : 1;
+                    d[i, j] = Math.Min(Math.Min(d[i - 1, j] + 1, d[i, j - 1] + 1), d[i - 1, j - 1] + cost);
+                    if (i > 1 && j > 1 && a[i - 1] == b[j - 2] && a[i - 2] == b[j - 1])
+                        d[i, j] = Math.Min(d[i, j], d[i - 2, j - 2] + 1);
+                }
+            }
+
+            return d[a.Length, b.Length];
+        }
+
+        /// <summary>
+        /// Short names only match by case, so "x" does not suggest every other one-letter name
+        /// </summary>
+        private static int MaxDistance(string name) => name.Length switch
+        {
+            <= 2 => 0,
+            <= 5 => 1,
+            <= 8 => 2,
+            _ => 3
+        };
+
+        private static IEnumerable<(string Name, int? Arity)> Declared(ModelManager manager, SymbolKind kind)
+        {
+            if (kind.HasFlag(SymbolKind.Variable))
+            {
+                foreach (var variable in manager.IndexedVariables.Values)
+                    yield return (variable.BaseName, variable.Dimensionality);
+            }
+
+            if (kind.HasFlag(SymbolKind.Parameter))
+            {
+                foreach (var parameter in manager.Parameters.Values)
+                    yield return (parameter.Name, parameter.Dimensionality);
+                foreach (var name in manager.TupleParameters.Keys)
+                    yield return (name, 0);
+            }
+
+            if (kind.HasFlag(SymbolKind.DecisionExpression))
+            {
+                foreach (var dexpr in manager.DecisionExpressions.Values)
+                    yield return (dexpr.Name, dexpr.IsIndexed ? null : 0);
+            }
+
+            if (kind.HasFlag(SymbolKind.Set))
+            {
+                foreach (var name in manager.IndexSets.Keys.Concat(manager.Ranges.Keys).Concat(manager.Sets.Keys)
+                             .Concat(manager.PrimitiveSets.Keys).Concat(manager.ComputedSets.Keys))
+                    yield return (name, null);
+            }
+
+            if (kind.HasFlag(SymbolKind.TupleSet))
+            {
+                foreach (var name in manager.TupleSets.Keys)
+                    yield return (name, null);
+            }
+        }
+    }
+}
@@ -0,0 +1,375 @@
+using System.Text;
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// A name declared in a model text, with the scope it is visible in
+    /// </summary>
+    public class ScopedSymbol
+    {
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Entity key of the declaration ("parameter:cap", "constraint:balance")
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// Block the declaration is written in
+        /// </summary>
+        public Scope DeclaringScope { get; init; } = null!;
+
+        /// <summary>
+        /// Scope the name is visible in: the declaring block, or the enclosing one once exported
+        /// </summary>
+        public Scope Scope { get; internal set; } = null!;
+
+        public bool Exported => Scope != DeclaringScope;
+
+        /// <summary>
+        /// Name the model is parsed with: the name itself at top level, "hydro__cap" in block hydro
+        /// </summary>
+        public string QualifiedName => Scope.Qualify(Name);
+
+        public override string ToString() => $"{QualifiedName} (line {LineNumber})";
+    }
+
+    /// <summary>
+    /// The top level of a model text or one @block in it
+    /// </summary>
+    public class Scope
+    {
+        private static readonly Regex nonIdentifierPattern = new Regex(@"\W");
+
+        internal Scope(string name, Scope? parent)
+        {
+            Name = name;
+            Parent = parent;
+            Path = parent?.Parent == null ? name : $"{parent.Path}/{name}";
+            parent?.Children.Add(this);
+        }
+
+        public string Name { get; }
+
+        /// <summary>
+        /// Block path as in tags ("hydro/units"), empty at top level
+        /// </summary>
+        public string Path { get; }
+
+        public Scope? Parent { get; }
+        public List<Scope> Children { get; } = new List<Scope>();
+        public Dictionary<string, ScopedSymbol> Symbols { get; } = new Dictionary<string, ScopedSymbol>(StringComparer.Ordinal);
+
+        internal List<(string Name, int LineNumber)> Exports { get; } = new List<(string, int)>();
+
+        internal int Depth => Parent == null ? 0 : Parent.Depth + 1;
+
+        public override string ToString() => Parent == null ? "top level" : $"block '{Path}'";
+
+        public string Qualify(string name)
+        {
+            if (Parent == null)
+                return name;
+
+            return string.Join(SymbolTable.Separator, Path.Split('/').Select(p => nonIdentifierPattern.Replace(p, "_"))) + SymbolTable.Separator + name;
+        }
+
+        /// <summary>
+        /// The symbol a name written in this scope refers to: its own declarations first, then
+        /// those of the enclosing scopes
+        /// </summary>
+        public ScopedSymbol? Lookup(string name)
+        {
+            for (var scope = this; scope != null; scope = scope.Parent)
+            {
+                if (scope.Symbols.TryGetValue(name, out var symbol))
+                    return symbol;
+            }
+            return null;
+        }
+    }
+
+    /// <summary>
+    /// Lexical scopes of a model text whose blocks have their own namespaces. A text opts in with a
+    /// "// @scoped" line; its @block annotations then open scopes, names declared in a block are
+    /// local to it and its nested blocks unless exported to the enclosing scope:
+    /// <code>
+    /// // @scoped
+    /// // @block hydro
+    /// // @export release
+    /// float cap = 120;
+    /// dvar float+ release[T] in 0..cap;
+    /// // @endblock
+    /// </code>
+    /// Two blocks may then both declare "cap" without a collision. The text is parsed after
+    /// Rewrite, where every local name reads as its qualified name ("hydro__cap"); data files set
+    /// external local parameters by that name.
+    /// </summary>
+    public class SymbolTable
+    {
+        public const string Separator = "__";
+
+        private static readonly Regex scopedPattern = new Regex(@"^[ \t]*//[ \t]*@scoped\b", RegexOptions.Multiline);
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@(block|endblock|export)\b[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+        private static readonly Regex identifierPattern = new Regex(@"\G[A-Za-z_]\w*");
+        private static readonly Regex numberPattern = new Regex(@"\G\w+");
+        private static readonly Regex memberPattern = new Regex(@"\G[ \t]*\.[ \t]*([A-Za-z_]\w*)");
+
+        private readonly ModelSource source;
+        private readonly List<(ModelStatement Statement, Scope Scope)> placements = new List<(ModelStatement, Scope)>();
+
+        private SymbolTable(ModelSource source)
+        {
+            this.source = source;
+        }
+
+        public Scope Root { get; } = new Scope("", null);
+
+        /// <summary>
+        /// Names declared twice in one scope and exports of undeclared names, with their lines
+        /// </summary>
+        public List<(string Message, int LineNumber)> Errors { get; } = new List<(string, int)>();
+
+        /// <summary>
+        /// Unbalanced or empty block annotations
+        /// </summary>
+        public List<string> Warnings { get; } = new List<string>();
+
+        public static bool IsScoped(string modelText)
+        {
+            return scopedPattern.IsMatch(modelText);
+        }
+
+        public static SymbolTable Build(string modelText)
+        {
+            var table = new SymbolTable(ModelSource.Parse(modelText));
+            var declarations = new List<ScopedSymbol>();
+            var current = table.Root;
+
+            void ReadAnnotations(string trivia, int lineNumber)
+            {
+                foreach (Match m in annotationPattern.Matches(trivia))
+                {
+                    string argument = m.Groups[2].Value;
+                    switch (m.Groups[1].Value)
+                    {
+                        case "block":
+                            int colon = argument.IndexOf(':');
+                            string name = (colon >= 0 ? argument.Substring(0, colon) : argument).Trim();
+                            if (name.Length == 0)
+                            {
+                                table.Warnings.Add($"Line {lineNumber}: @block without a name");
+                                name = "block";
+                            }
+                            current = new Scope(name, current);
+                            break;
+
+                        case "endblock":
+                            if (current.Parent == null)
+                                table.Warnings.Add($"Line {lineNumber}: @endblock without a matching @block");
+                            else
+                                current = current.Parent;
+                            break;
+
+                        default:
+                            if (current.Parent == null)
+                            {
+                                table.Errors.Add(("@export outside a block", lineNumber));
+                                break;
+                            }
+                            foreach (string exported in argument.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries))
+                                current.Exports.Add((exported, lineNumber));
+                            break;
+                    }
+                }
+            }
+
+            foreach (var statement in table.source.Statements)
+            {
+                ReadAnnotations(statement.Text.Substring(0, statement.Text.Length - statement.Code.Length), statement.LineNumber);
+                table.placements.Add((statement, current));
+
+                int separator = statement.Key?.IndexOf(':') ?? -1;
+                if (separator < 0 || separator == statement.Key!.Length - 1)
+                    continue;
+
+                declarations.Add(new ScopedSymbol
+                {
+                    Name = statement.Key.Substring(separator + 1),
+                    Key = statement.Key,
+                    LineNumber = statement.LineNumber,
+                    DeclaringScope = current,
+                    Scope = current
+                });
+            }
+
+            // Closing annotations after the last statement
+            int length = table.source.Statements.Sum(st => st.Text.Length);
+            ReadAnnotations(modelText.Substring(Math.Min(length, modelText.Length)), modelText.Count(c => c == '\n') + 1);
+
+            for (var open = current; open.Parent != null; open = open.Parent)
+                table.Warnings.Add($"Block '{open.Path}' is not closed with @endblock");
+
+            // Exports lift a name one scope up; inner blocks first, so a block can pass on a name it received
+            var scopes = table.AllScopes().OrderByDescending(s => s.Depth).ToList();
+            foreach (var scope in scopes)
+            {
+                foreach (var (name, line) in scope.Exports)
+                {
+                    var symbols = declarations.Where(d => d.Scope == scope && d.Name == name).ToList();
+                    if (symbols.Count == 0)
+                        table.Errors.Add(($"Block '{scope.Path}' exports '{name}', which it does not declare", line));
+                    foreach (var symbol in symbols)
+                        symbol.Scope = scope.Parent!;
+                }
+            }
+
+            foreach (var symbol in declarations)
+            {
+                if (symbol.Scope.Symbols.TryGetValue(symbol.Name, out var existing))
+                {
+                    // Collisions at top level of plain declarations are reported by the parser as before
+                    if (symbol.Scope.Parent != null || symbol.Exported || existing.Exported)
+                        table.Errors.Add(($"'{symbol.Name}' is already declared {(symbol.Scope.Parent == null ? "at top level" : $"in block '{symbol.Scope.Path}'")} on line {existing.LineNumber}", symbol.LineNumber));
+                    continue;
+                }
+                symbol.Scope.Symbols[symbol.Name] = symbol;
+            }
+
+            return table;
+        }
+
+        public IEnumerable<Scope> AllScopes()
+        {
+            var pending = new Stack<Scope>();
+            pending.Push(Root);
+            while (pending.Count > 0)
+            {
+                var scope = pending.Pop();
+                yield return scope;
+                for (int i = scope.Children.Count - 1; i >= 0; i--)
+                    pending.Push(scope.Children[i]);
+            }
+        }
+
+        /// <summary>
+        /// Scope of the statement at or before a line
+        /// </summary>
+        public Scope ScopeAt(int lineNumber)
+        {
+            var scope = Root;
+            foreach (var (statement, placed) in placements)
+            {
+                if (statement.LineNumber > lineNumber)
+                    break;
+                scope = placed;
+            }
+            return scope;
+        }
+
+        /// <summary>
+        /// The symbol a name written at a line refers to, or null for names declared nowhere in
+        /// reach (iterators, names of other model files)
+        /// </summary>
+        public ScopedSymbol? Resolve(string name, int lineNumber)
+        {
+            return ScopeAt(lineNumber).Lookup(name);
+        }
+
+        /// <summary>
+        /// The model text with every name that resolves to a block-local symbol replaced by its
+        /// qualified name; line structure, comments and strings are left as they are
+        /// </summary>
+        public string Rewrite()
+        {
+            var rewritten = ModelSource.Parse(source.ToString());
+            for (int i = 0; i < placements.Count; i++)
+            {
+                var (statement, scope) = placements[i];
+                string code = ReplaceNames(statement.Code, name => scope.Lookup(name)?.QualifiedName);
+                if (code != statement.Code)
+                    rewritten.Replace(rewritten.Statements[i], code);
+            }
+            return rewritten.ToString();
+        }
+
+        /// <summary>
+        /// Replaces the names in a statement outside strings and comments. Tuple fields ("p.cost")
+        /// are left alone; "a.b" is first offered to <paramref name="member"/> as a whole.
+        /// A callback returning null keeps the name.
+        /// </summary>
+        internal static string ReplaceNames(string code, Func<string, string?> replace, Func<string, string, string?>? member = null)
+        {
+            var sb = new StringBuilder(code.Length);
+            int i = 0;
+            while (i < code.Length)
+            {
+                char c = code[i];
+                int skip = 0;
+                if (c == '"')
+                {
+                    int end = i + 1;
+                    while (end < code.Length && code[end] != '"')
+                        end += code[end] == '\\' ? 2 : 1;
+                    skip = Math.Min(end + 1, code.Length) - i;
+                }
+                else if (c == '/' && i + 1 < code.Length && (code[i + 1] == '/' || code[i + 1] == '*'))
+                {
+                    int end = code[i + 1] == '/' ? code.IndexOf('\n', i) : code.IndexOf("*/", i + 2, StringComparison.Ordinal) + 2;
+                    skip = (end <= 1 ? code.Length : end) - i;
+                }
+                else if (char.IsLetter(c) || c == '_')
+                {
+                    string name = identifierPattern.Match(code, i).Value;
+                    if (IsFieldAccess(code, i))
+                    {
+                        sb.Append(name);
+                        i += name.Length;
+                        continue;
+                    }
+
+                    var access = memberPattern.Match(code, i + name.Length);
+                    string? qualified = member != null && access.Success ? member(name, access.Groups[1].Value) : null;
+                    if (qualified != null)
+                    {
+                        sb.Append(qualified);
+                        i += name.Length + access.Length;
+                        continue;
+                    }
+
+                    sb.Append(replace(name) ?? name);
+                    i += name.Length;
+                    continue;
+                }
+                else if (char.IsDigit(c))
+                {
+                    // Keeps the exponent of "1e5" from being read as an identifier
+                    skip = numberPattern.Match(code, i).Length;
+                }
+
+                if (skip > 0)
+                {
+                    sb.Append(code, i, skip);
+                    i += skip;
+                }
+                else
+                {
+                    sb.Append(c);
+                    i++;
+                }
+            }
+            return sb.ToString();
+        }
+
+        private static bool IsFieldAccess(string code, int index)
+        {
+            int i = index - 1;
+            while (i >= 0 && char.IsWhiteSpace(code[i]))
+                i--;
+            return i >= 0 && code[i] == '.' && (i == 0 || code[i - 1] != '.');
+        }
+    }
+}
@@ -0,0 +1,90 @@
+using System.Text.RegularExpressions;
+
+namespace Core.Parsing
+{
+    /// <summary>
+    /// Units of measure of parameters and variables, from an annotation before the declaration:
+    /// <code>
+    /// // @unit MW
+    /// float capacity[Plants] = ...;
+    /// // @unit EUR/MWh
+    /// float price[Periods] = ...;
+    /// </code>
+    /// Like docstrings, units are read into the manager (ModelManager.Units) and copied into the
+    /// Unit of the parsed parameters and variables, which generated documentation, the entity
+    /// catalog and constraint explanations show.
+    /// </summary>
+    public static class UnitAnnotations
+    {
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@unit\b[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+
+        public static bool IsUsed(string modelText) => modelText.Contains("@unit");
+
+        /// <summary>
+        /// Units of a model text by entity key ("parameter:capacity", "variable:flow"). Annotations
+        /// without a unit or before other statements are reported as errors.
+        /// </summary>
+        public static Dictionary<string, string> Extract(string modelText, ParseSessionResult? result = null)
+        {
+            var units = new Dictionary<string, string>(StringComparer.Ordinal);
+            if (!IsUsed(modelText))
+                return units;
+
+            foreach (var statement in ModelSource.Parse(modelText).Statements)
+            {
+                string trivia = statement.Text.Substring(0, statement.Text.Length - statement.Code.Length);
+                foreach (Match m in annotationPattern.Matches(trivia))
+                {
+                    string unit = m.Groups[1].Value;
+                    if (unit.Length == 0)
+                    {
+                        result?.AddError("@unit needs a unit of measure, e.g. // @unit MW", statement.LineNumber);
+                        continue;
+                    }
+
+                    if (statement.Key?.StartsWith("parameter:", StringComparison.Ordinal) != true &&
+                        statement.Key?.StartsWith("variable:", StringComparison.Ordinal) != true)
+                    {
+                        result?.AddError("@unit must precede a parameter or variable declaration", statement.LineNumber);
+                        continue;
+                    }
+
+                    units[statement.Key] = unit;
+                }
+            }
+
+            return units;
+        }
+
+        /// <summary>
+        /// Reads the unit annotations of a model text into the manager
+        /// </summary>
+        public static void Read(ModelManager manager, string modelText, ParseSessionResult result)
+        {
+            foreach (var (key, unit) in Extract(modelText, result))
+                manager.Units[key] = unit;
+        }
+
+        /// <summary>
+        /// Copies the units into the Unit of the parsed parameters and variables
+        /// </summary>
+        public static void Apply(ModelManager manager)
+        {
+            foreach (var (key, unit) in manager.Units)
+            {
+                int colon = key.IndexOf(':');
+                string name = key.Substring(colon + 1);
+                switch (key.Substring(0, colon))
+                {
+                    case "parameter":
+                        if (manager.Parameters.TryGetValue(name, out var parameter)) parameter.Unit = unit;
+                        break;
+
+                    case "variable":
+                        if (manager.IndexedVariables.TryGetValue(name, out var variable)) variable.Unit = unit;
+                        break;
+                }
+            }
+        }
+    }
+}
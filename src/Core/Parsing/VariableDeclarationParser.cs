@@ -52,13 +52,13 @@ namespace Core.Parsing
                 // Validate index sets
                 if (!modelManager.IndexSets.ContainsKey(indexSetName1))
                 {
-                    error = $"First index set '{indexSetName1}' is not declared";
+                    error = SymbolSuggester.Annotate(modelManager, $"First index set '{indexSetName1}' is not declared", indexSetName1, SymbolKind.Set);
                     return false;
                 }
 
                 if (!modelManager.IndexSets.ContainsKey(indexSetName2))
                 {
-                    error = $"Second index set '{indexSetName2}' is not declared";
+                    error = SymbolSuggester.Annotate(modelManager, $"Second index set '{indexSetName2}' is not declared", indexSetName2, SymbolKind.Set);
                     return false;
                 }
 
@@ -114,7 +114,7 @@ namespace Core.Parsing
 
                 if (!modelManager.IndexSets.ContainsKey(indexSetName))
                 {
-                    error = $"Index set '{indexSetName}' is not declared";
+                    error = SymbolSuggester.Annotate(modelManager, $"Index set '{indexSetName}' is not declared", indexSetName, SymbolKind.Set);
                     return false;
                 }
 
@@ -8,6 +8,8 @@ namespace Core.Parsing
     /// </summary>
     public class VariableValidator
     {
+        private const SymbolKind ReferenceKinds = SymbolKind.Variable | SymbolKind.Parameter | SymbolKind.DecisionExpression;
+
         private readonly ModelManager modelManager;
 
         public VariableValidator(ModelManager manager)
@@ -16,6 +18,15 @@ namespace Core.Parsing
         }
 
         public bool ValidateVariableDeclarations(List<string> coefficients, out string error)
+        {
+            return ValidateVariableDeclarations(coefficients, null, out error);
+        }
+
+        /// <summary>
+        /// Validates the coefficients of a parsed expression; the expression text, if given, tells
+        /// how many indices an undeclared name was written with so suggestions prefer names of that shape
+        /// </summary>
+        public bool ValidateVariableDeclarations(List<string> coefficients, string? expressionText, out string error)
         {
             error = string.Empty;
             var undeclaredVariables = new List<string>();
@@ -39,11 +50,22 @@ namespace Core.Parsing
 
                 if (uniqueUndeclared.Count == 1)
                 {
-                    error = $"Variable '{uniqueUndeclared[0]}' is used but not declared. Use 'var {uniqueUndeclared[0]}' or 'var {uniqueUndeclared[0]}[IndexSet]' to declare it";
+                    error = SymbolSuggester.Annotate(modelManager,
+                        $"Variable '{uniqueUndeclared[0]}' is used but not declared. Use 'var {uniqueUndeclared[0]}' or 'var {uniqueUndeclared[0]}[IndexSet]' to declare it",
+                        uniqueUndeclared[0], ReferenceKinds, WrittenArity(uniqueUndeclared[0], expressionText));
                 }
                 else
                 {
                     error = $"Variables {string.Join(", ", uniqueUndeclared.Select(v => $"'{v}'"))} are used but not declared. Variables must be declared before use";
+                    foreach (var name in uniqueUndeclared)
+                    {
+                        var suggestion = SymbolSuggester.Suggest(modelManager, name, ReferenceKinds, WrittenArity(name, expressionText));
+                        if (suggestion != null)
+                        {
+                            modelManager.PendingSuggestions.Add(suggestion);
+                            error += $"; {suggestion}";
+                        }
+                    }
                 }
 
                 return false;
@@ -52,6 +74,17 @@ namespace Core.Parsing
             return true;
         }
 
+        private static int? WrittenArity(string name, string? expressionText)
+        {
+            if (expressionText == null)
+                return null;
+
+            var match = Regex.Match(expressionText, $@"(?<![A-Za-z0-9_]){Regex.Escape(name)}(?![A-Za-z0-9_])\s*(\[(?<indices>[^\]]*)\])?");
+            if (!match.Success)
+                return null;
+            return match.Groups["indices"].Success ? match.Groups["indices"].Value.Split(',').Length : 0;
+        }
+
         private string ExtractBaseVariableName(string variableName)
         {
             // Check if exact name exists
@@ -87,7 +120,7 @@ namespace Core.Parsing
             }
 
             // Pattern for 1D numeric index: x1
-            match = Regex.Match(variableName, @"^([a-zA-Z]+)(\d+)$");
+            match = Regex.Match(variableName, @"^([a-zA-Z][a-zA-Z0-9_]*?)(\d+)$");
             if (match.Success)
             {
                 string baseName = match.Groups[1].Value;
@@ -0,0 +1,377 @@
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Services;
+
+namespace Core.Server
+{
+    [Flags]
+    public enum Permission
+    {
+        None = 0,
+        Read = 1,
+        Edit = 2,
+        Solve = 4,
+        Admin = 8,
+        All = Read | Edit | Solve | Admin
+    }
+
+    /// <summary>
+    /// An authenticated caller: subject id from the identity provider plus role claims
+    /// </summary>
+    public class UserPrincipal
+    {
+        public string Subject { get; init; } = "";
+        public string DisplayName { get; init; } = "";
+        public HashSet<string> Roles { get; init; } = new HashSet<string>(StringComparer.OrdinalIgnoreCase);
+
+        public override string ToString() => string.IsNullOrEmpty(DisplayName) ? Subject : DisplayName;
+    }
+
+    /// <summary>
+    /// Result of validating a bearer token
+    /// </summary>
+    public class TokenValidationResult
+    {
+        public bool Success => Principal != null;
+        public UserPrincipal? Principal { get; init; }
+        public string? Error { get; init; }
+
+        public static TokenValidationResult Valid(UserPrincipal principal) => new TokenValidationResult { Principal = principal };
+        public static TokenValidationResult Invalid(string error) => new TokenValidationResult { Error = error };
+    }
+
+    /// <summary>
+    /// Pluggable authentication: turns a bearer token (e.g. an OIDC ID or access token) into a principal
+    /// </summary>
+    public interface ITokenValidator
+    {
+        Task<TokenValidationResult> ValidateAsync(string token, CancellationToken cancellationToken = default);
+    }
+
+    /// <summary>
+    /// Fixed token-to-principal table, for development and tests
+    /// </summary>
+    public class StaticTokenValidator : ITokenValidator
+    {
+        private readonly Dictionary<string, UserPrincipal> tokens = new Dictionary<string, UserPrincipal>(StringComparer.Ordinal);
+
+        public StaticTokenValidator Add(string token, UserPrincipal principal)
+        {
+            tokens[token] = principal;
+            return this;
+        }
+
+        public Task<TokenValidationResult> ValidateAsync(string token, CancellationToken cancellationToken = default)
+        {
+            return Task.FromResult(tokens.TryGetValue(token, out var principal)
+                ? TokenValidationResult.Valid(principal)
+                : TokenValidationResult.Invalid("Unknown token"));
+        }
+    }
+
+    /// <summary>
+    /// Grants permissions on a model (or all models) and optionally only on a block of it
+    /// </summary>
+    public class AccessGrant
+    {
+        /// <summary>
+        /// "user:&lt;subject&gt;", "role:&lt;role&gt;" or "*" for every authenticated user
+        /// </summary>
+        public string Subject { get; init; } = "";
+
+        /// <summary>
+        /// Model id, or "*" for all models
+        /// </summary>
+        public string ModelId { get; init; } = "*";
+
+        /// <summary>
+        /// Entity key pattern the grant is limited to (e.g. "parameter:price*"), or "*" for the whole model
+        /// </summary>
+        public string Block { get; init; } = "*";
+
+        public Permission Permissions { get; init; }
+
+        public override string ToString() => $"{Subject} {Permissions} on {ModelId}/{Block}";
+    }
+
+    /// <summary>
+    /// Limits a permission on some entities of a model (or all models) to callers with a role,
+    /// on top of the grants: e.g. only "market" may edit the parameters tagged "prices", while
+    /// editors keep editing everything else. Block names count as tags (see ModelTags), so
+    /// Tag = "hydro" covers the declarations of the hydro block and its nested blocks.
+    /// </summary>
+    public class EntityRestriction
+    {
+        /// <summary>
+        /// Model id, or "*" for all models
+        /// </summary>
+        public string ModelId { get; init; } = "*";
+
+        /// <summary>
+        /// Entity key pattern as in AccessGrant.Block (e.g. "parameter:price*"), or "*" for any entity
+        /// </summary>
+        public string Entities { get; init; } = "*";
+
+        /// <summary>
+        /// Tag or block path the entity must carry (or a descendant of it), or null for any entity
+        /// </summary>
+        public string? Tag { get; init; }
+
+        /// <summary>
+        /// Role claim a caller needs for the restricted permissions on matching entities
+        /// </summary>
+        public string Role { get; init; } = "";
+
+        public Permission Permissions { get; init; } = Permission.Edit;
+
+        public override string ToString() =>
+            $"{Permissions} on {ModelId}/{Entities}{(Tag != null ? $" tagged {Tag}" : "")} requires role {Role}";
+    }
+
+    public class AccessDeniedException : InvalidOperationException
+    {
+        public Permission Required { get; }
+        public string ModelId { get; }
+        public string? EntityKey { get; }
+
+        /// <summary>
+        /// Roles of which the caller needs one, when an entity restriction denied the operation
+        /// </summary>
+        public IReadOnlyList<string> RequiredRoles { get; }
+
+        public AccessDeniedException(UserPrincipal? principal, Permission required, string modelId, string? entityKey)
+            : this(principal, required, modelId, entityKey, Array.Empty<string>())
+        {
+        }
+
+        public AccessDeniedException(UserPrincipal? principal, Permission required, string modelId, string? entityKey, IReadOnlyList<string> requiredRoles)
+            : base($"{(principal == null ? "Anonymous caller" : $"User '{principal}'")} lacks {required} permission on " +
+                   $"model '{modelId}'{(entityKey != null ? $" entity '{entityKey}'" : "")}" +
+                   (requiredRoles.Count > 0 ? $"; requires role {string.Join(" and ", requiredRoles.Select(r => $"'{r}'"))}" : ""))
+        {
+            Required = required;
+            ModelId = modelId;
+            EntityKey = entityKey;
+            RequiredRoles = requiredRoles;
+        }
+    }
+
+    /// <summary>
+    /// Ambient caller of the current operation (set per request by the server front ends)
+    /// </summary>
+    public static class AccessContext
+    {
+        private static readonly AsyncLocal<UserPrincipal?> currentPrincipal = new AsyncLocal<UserPrincipal?>();
+
+        public static UserPrincipal? Current => currentPrincipal.Value;
+
+        /// <summary>
+        /// Sets the caller until the returned scope is disposed; the caller also becomes the audit author
+        /// </summary>
+        public static IDisposable BeginScope(UserPrincipal principal)
+        {
+            var previous = currentPrincipal.Value;
+            currentPrincipal.Value = principal;
+            var auditScope = AuditContext.BeginScope(principal.Subject);
+
+            return new Scope(() =>
+            {
+                auditScope.Dispose();
+                currentPrincipal.Value = previous;
+            });
+        }
+
+        private sealed class Scope : IDisposable
+        {
+            private Action? restore;
+            public Scope(Action restore) { this.restore = restore; }
+
+            public void Dispose()
+            {
+                restore?.Invoke();
+                restore = null;
+            }
+        }
+    }
+
+    /// <summary>
+    /// Role-based access policy for hosted models. Permissions of all grants matching the
+    /// caller, model and entity are combined; Admin implies every other permission. Entity
+    /// restrictions then narrow what the grants allow on matching entities to callers with
+    /// the restriction's role; admins of the model are exempt.
+    /// </summary>
+    public class AccessPolicy
+    {
+        private readonly List<AccessGrant> grants = new List<AccessGrant>();
+        private readonly List<EntityRestriction> restrictions = new List<EntityRestriction>();
+        private readonly object syncRoot = new object();
+
+        /// <summary>
+        /// Built-in roles that can be assigned on a model
+        /// </summary>
+        public static readonly IReadOnlyDictionary<string, Permission> Roles = new Dictionary<string, Permission>(StringComparer.OrdinalIgnoreCase)
+        {
+            ["viewer"] = Permission.Read,
+            ["editor"] = Permission.Read | Permission.Edit,
+            ["operator"] = Permission.Read | Permission.Solve,
+            ["planner"] = Permission.Read | Permission.Edit | Permission.Solve,
+            ["admin"] = Permission.All
+        };
+
+        public void Grant(AccessGrant grant)
+        {
+            lock (syncRoot)
+            {
+                grants.RemoveAll(g => g.Subject == grant.Subject && g.ModelId == grant.ModelId && g.Block == grant.Block);
+                if (grant.Permissions != Permission.None)
+                    grants.Add(grant);
+            }
+        }
+
+        public bool Revoke(string subject, string modelId, string block = "*")
+        {
+            lock (syncRoot)
+            {
+                return grants.RemoveAll(g => g.Subject == subject && g.ModelId == modelId && g.Block == block) > 0;
+            }
+        }
+
+        /// <summary>
+        /// Removes all grants and restrictions specific to a model (used when the model is deleted)
+        /// </summary>
+        public void RevokeAll(string modelId)
+        {
+            lock (syncRoot)
+            {
+                grants.RemoveAll(g => g.ModelId == modelId);
+                restrictions.RemoveAll(r => r.ModelId == modelId);
+            }
+        }
+
+        /// <summary>
+        /// Adds a restriction, replacing one for the same model, entities, tag and role
+        /// (Permissions None only removes it)
+        /// </summary>
+        public void Restrict(EntityRestriction restriction)
+        {
+            lock (syncRoot)
+            {
+                restrictions.RemoveAll(r => SameSelector(r, restriction));
+                if (restriction.Permissions != Permission.None)
+                    restrictions.Add(restriction);
+            }
+        }
+
+        public IReadOnlyList<EntityRestriction> GetRestrictions(string modelId)
+        {
+            lock (syncRoot)
+            {
+                return restrictions.Where(r => r.ModelId == modelId || r.ModelId == "*").ToList();
+            }
+        }
+
+        /// <summary>
+        /// True if restrictions apply to the model, i.e. Demand needs the tags of the entities
+        /// </summary>
+        public bool HasRestrictions(string modelId)
+        {
+            lock (syncRoot)
+            {
+                return restrictions.Any(r => r.ModelId == modelId || r.ModelId == "*");
+            }
+        }
+
+        public IReadOnlyList<AccessGrant> GetGrants(string modelId)
+        {
+            lock (syncRoot)
+            {
+                return grants.Where(g => g.ModelId == modelId || g.ModelId == "*").ToList();
+            }
+        }
+
+        /// <summary>
+        /// Combined permissions of the caller on a model, or on one entity of it when a key is given.
+        /// Block-limited grants only apply to operations on a matching entity.
+        /// </summary>
+        public Permission GetPermissions(UserPrincipal? principal, string modelId, string? entityKey = null)
+        {
+            if (principal == null)
+                return Permission.None;
+
+            var result = Permission.None;
+            lock (syncRoot)
+            {
+                foreach (var grant in grants)
+                {
+                    if ((grant.ModelId == "*" || grant.ModelId == modelId) &&
+                        MatchesSubject(grant.Subject, principal) &&
+                        MatchesBlock(grant.Block, entityKey))
+                    {
+                        result |= grant.Permissions;
+                    }
+                }
+            }
+
+            return result.HasFlag(Permission.Admin) ? Permission.All : result;
+        }
+
+        public bool IsAllowed(UserPrincipal? principal, string modelId, Permission required, string? entityKey = null)
+        {
+            return (GetPermissions(principal, modelId, entityKey) & required) == required;
+        }
+
+        /// <summary>
+        /// Throws unless the grants allow the operation and, for an entity, the caller has a role
+        /// of every restriction it falls under. <paramref name="entityTags"/> are the entity's tags
+        /// including its block paths (TaggedEntity.Tags); without them only restrictions by key apply.
+        /// </summary>
+        public void Demand(UserPrincipal? principal, string modelId, Permission required, string? entityKey = null, IEnumerable<string>? entityTags = null)
+        {
+            if (!IsAllowed(principal, modelId, required, entityKey))
+                throw new AccessDeniedException(principal, required, modelId, entityKey);
+            if (entityKey == null || GetPermissions(principal, modelId).HasFlag(Permission.Admin))
+                return;
+
+            var tags = entityTags?.ToList() ?? new List<string>();
+            List<EntityRestriction> matching;
+            lock (syncRoot)
+            {
+                matching = restrictions
+                    .Where(r => (r.ModelId == "*" || r.ModelId == modelId) &&
+                                (r.Permissions & required) != Permission.None &&
+                                MatchesBlock(r.Entities, entityKey) &&
+                                (r.Tag == null || tags.Any(t => TagPath.Matches(t, r.Tag))))
+                    .ToList();
+            }
+
+            var missing = matching.Where(r => !principal!.Roles.Contains(r.Role)).Select(r => r.Role).Distinct(StringComparer.OrdinalIgnoreCase).ToList();
+            if (missing.Count > 0)
+                throw new AccessDeniedException(principal, required, modelId, entityKey, missing);
+        }
+
+        private static bool SameSelector(EntityRestriction a, EntityRestriction b) =>
+            a.ModelId == b.ModelId && a.Entities == b.Entities && a.Role.Equals(b.Role, StringComparison.OrdinalIgnoreCase) &&
+            string.Equals(a.Tag, b.Tag, StringComparison.OrdinalIgnoreCase);
+
+        private static bool MatchesSubject(string subject, UserPrincipal principal)
+        {
+            if (subject == "*")
+                return true;
+            if (subject.StartsWith("user:", StringComparison.Ordinal))
+                return subject.Substring(5) == principal.Subject;
+            if (subject.StartsWith("role:", StringComparison.Ordinal))
+                return principal.Roles.Contains(subject.Substring(5));
+            return false;
+        }
+
+        private static bool MatchesBlock(string block, string? entityKey)
+        {
+            if (block == "*")
+                return true;
+            if (entityKey == null)
+                return false;
+
+            return Regex.IsMatch(entityKey, "^" + Regex.Escape(block).Replace("\\*", ".*").Replace("\\?", ".") + "$");
+        }
+    }
+}
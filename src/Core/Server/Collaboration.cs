@@ -0,0 +1,234 @@
+using Core.Parsing;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// Write or removal of one entity declaration, stamped for last-writer-wins merging
+    /// </summary>
+    public class EntityOperation
+    {
+        public string Key { get; init; } = "";
+
+        /// <summary>
+        /// Declaration text, or null for a removal
+        /// </summary>
+        public string? Statement { get; init; }
+
+        /// <summary>
+        /// Lamport timestamp assigned by the replica that made the edit
+        /// </summary>
+        public long Timestamp { get; init; }
+
+        public string ReplicaId { get; init; } = "";
+
+        /// <summary>
+        /// Position in the server log; 0 for operations not yet accepted by the server
+        /// </summary>
+        public long Sequence { get; init; }
+
+        public bool IsRemoval => Statement == null;
+
+        /// <summary>
+        /// Total order on concurrent edits: the later timestamp wins, ties go to the greater replica id
+        /// </summary>
+        public bool Supersedes(EntityOperation other)
+        {
+            return Timestamp != other.Timestamp
+                ? Timestamp > other.Timestamp
+                : string.CompareOrdinal(ReplicaId, other.ReplicaId) > 0;
+        }
+
+        public override string ToString() => $"{ReplicaId}@{Timestamp} {(IsRemoval ? "remove" : "set")} {Key}";
+    }
+
+    /// <summary>
+    /// Outcome of one synchronization round
+    /// </summary>
+    public class SyncResult
+    {
+        /// <summary>
+        /// Operations accepted by the server after the client's last sequence, including its own
+        /// </summary>
+        public List<EntityOperation> Operations { get; init; } = new List<EntityOperation>();
+
+        /// <summary>
+        /// Sequence to send on the next sync
+        /// </summary>
+        public long Sequence { get; init; }
+        public int Version { get; init; }
+        public List<string> Errors { get; init; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Last-writer-wins map from entity key to the operation that currently defines it
+    /// </summary>
+    internal class EntityRegisterMap
+    {
+        private readonly Dictionary<string, EntityOperation> registers = new Dictionary<string, EntityOperation>();
+
+        public long Clock { get; private set; }
+
+        public IEnumerable<EntityOperation> Values => registers.Values;
+
+        public EntityOperation? Get(string key) => registers.TryGetValue(key, out var op) ? op : null;
+
+        /// <summary>
+        /// Stores the operation if it supersedes the current one for its key; returns whether it did
+        /// </summary>
+        public bool Merge(EntityOperation operation)
+        {
+            Clock = Math.Max(Clock, operation.Timestamp);
+
+            if (registers.TryGetValue(operation.Key, out var current) && !operation.Supersedes(current))
+                return false;
+
+            registers[operation.Key] = operation;
+            return true;
+        }
+
+        public long Tick() => ++Clock;
+    }
+
+    /// <summary>
+    /// Server-side log of a hosted model's entity operations. Only operations that win against
+    /// the current register are appended, so replaying the log from any sequence converges.
+    /// </summary>
+    public class OperationLog
+    {
+        public const string ServerReplicaId = "server";
+
+        private readonly List<EntityOperation> operations = new List<EntityOperation>();
+        private readonly EntityRegisterMap registers = new EntityRegisterMap();
+
+        /// <summary>
+        /// Sequence of the last accepted operation
+        /// </summary>
+        public long Sequence => operations.Count;
+
+        public long Clock => registers.Clock;
+
+        /// <summary>
+        /// Merges an operation from a replica; returns the logged copy if it won, otherwise null
+        /// </summary>
+        public EntityOperation? Append(EntityOperation operation)
+        {
+            var logged = new EntityOperation
+            {
+                Key = operation.Key,
+                Statement = operation.Statement,
+                Timestamp = operation.Timestamp,
+                ReplicaId = operation.ReplicaId,
+                Sequence = operations.Count + 1
+            };
+
+            if (!registers.Merge(logged))
+                return null;
+
+            operations.Add(logged);
+            return logged;
+        }
+
+        /// <summary>
+        /// Logs an edit made directly on the server (not through sync)
+        /// </summary>
+        public EntityOperation Record(string key, string? statement)
+        {
+            return Append(new EntityOperation { Key = key, Statement = statement, Timestamp = registers.Tick(), ReplicaId = ServerReplicaId })!;
+        }
+
+        public IReadOnlyList<EntityOperation> GetSince(long sequence)
+        {
+            return operations.Skip((int)Math.Clamp(sequence, 0, operations.Count)).ToList();
+        }
+    }
+
+    /// <summary>
+    /// Client-side replica of a hosted model for collaborative editing. Starts from the model text
+    /// at a known log sequence; local edits are queued as operations and exchanged with the server
+    /// through ModelHost.Sync. Replicas that have seen the same operations hold the same entities.
+    /// </summary>
+    public class EntityReplica
+    {
+        private readonly string baseText;
+        private readonly EntityRegisterMap registers = new EntityRegisterMap();
+        private readonly List<EntityOperation> pending = new List<EntityOperation>();
+
+        public EntityReplica(string replicaId, string baseText, long sequence)
+        {
+            ReplicaId = replicaId;
+            this.baseText = baseText;
+            Sequence = sequence;
+        }
+
+        public string ReplicaId { get; }
+
+        /// <summary>
+        /// Last server sequence merged into this replica
+        /// </summary>
+        public long Sequence { get; private set; }
+
+        /// <summary>
+        /// Local operations not yet sent to the server
+        /// </summary>
+        public IReadOnlyList<EntityOperation> Pending => pending;
+
+        public EntityOperation Upsert(string statement)
+        {
+            string key = ModelSource.GetKey(statement)
+                ?? throw new InvalidOperationException($"Statement does not declare a named entity: {statement.Trim()}");
+            return Local(key, statement.Trim());
+        }
+
+        public EntityOperation Remove(string key) => Local(key, null);
+
+        /// <summary>
+        /// Merges a remote operation; returns whether it changed the replica
+        /// </summary>
+        public bool Apply(EntityOperation operation) => registers.Merge(operation);
+
+        /// <summary>
+        /// Merges the server's answer to a sync that sent the pending operations
+        /// </summary>
+        public void Merge(SyncResult result)
+        {
+            foreach (var operation in result.Operations)
+                registers.Merge(operation);
+
+            pending.Clear();
+            Sequence = Math.Max(Sequence, result.Sequence);
+        }
+
+        /// <summary>
+        /// Current declaration of an entity, or null if it does not exist
+        /// </summary>
+        public string? GetStatement(string key)
+        {
+            var operation = registers.Get(key);
+            return operation != null ? operation.Statement : ModelSource.Parse(baseText).Find(key)?.Code;
+        }
+
+        /// <summary>
+        /// Model text: the base text with the winning operations applied in key order
+        /// </summary>
+        public string ToText()
+        {
+            var source = ModelSource.Parse(baseText);
+            foreach (var operation in registers.Values.OrderBy(o => o.Key, StringComparer.Ordinal))
+            {
+                if (operation.IsRemoval)
+                    source.Remove(operation.Key);
+                else
+                    source.Upsert(operation.Statement!);
+            }
+            return source.ToString();
+        }
+
+        private EntityOperation Local(string key, string? statement)
+        {
+            var operation = new EntityOperation { Key = key, Statement = statement, Timestamp = registers.Tick(), ReplicaId = ReplicaId };
+            registers.Merge(operation);
+            pending.Add(operation);
+            return operation;
+        }
+    }
+}
@@ -0,0 +1,179 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Models;
+using Core.Parsing;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// Structured, protocol-neutral form of a single model declaration as exchanged over the server API.
+    /// Converts to and from the model statement it represents.
+    /// </summary>
+    public class EntityDefinition
+    {
+        public EntityKind Kind { get; set; }
+        public string Name { get; set; } = "";
+
+        /// <summary>
+        /// Declared type: "float", "int", "bool", "string" (optionally with '+'), "range" for ranges,
+        /// or the element type of a set
+        /// </summary>
+        public string Type { get; set; } = "";
+
+        public List<string> IndexSets { get; set; } = new List<string>();
+
+        /// <summary>
+        /// Value expression of a set, parameter or decision expression ("..." for external data)
+        /// </summary>
+        public string? Value { get; set; }
+
+        public string? LowerBound { get; set; }
+        public string? UpperBound { get; set; }
+
+        /// <summary>
+        /// Iterator header of a constraint family, e.g. "n in Nodes"
+        /// </summary>
+        public string? Forall { get; set; }
+
+        /// <summary>
+        /// Relation of a constraint, or the expression of an objective
+        /// </summary>
+        public string? Body { get; set; }
+
+        public ObjectiveSense Sense { get; set; }
+
+        /// <summary>
+        /// Server revision of the entity when it was read (0 if it does not exist); not part of the statement
+        /// </summary>
+        public int Revision { get; set; }
+
+        public string Key => EntityCatalog.KeyOf(Kind, Name);
+
+        public string ToStatement()
+        {
+            string indices = IndexSets.Count > 0 ? $"[{string.Join(",", IndexSets)}]" : "";
+
+            switch (Kind)
+            {
+                case EntityKind.Set:
+                    return Type == "range"
+                        ? $"range {Name} = {Require(Value, "value")};"
+                        : $"{{{Type}}} {Name} = {Require(Value, "value")};";
+
+                case EntityKind.Parameter:
+                    return Value != null
+                        ? $"{Type} {Name}{indices} = {Value};"
+                        : $"{Type} {Name}{indices};";
+
+                case EntityKind.Variable:
+                    var sb = new StringBuilder($"dvar {Type} {Name}{indices}");
+                    if (LowerBound != null || UpperBound != null)
+                        sb.Append($" in {Require(LowerBound, "lower bound")}..{Require(UpperBound, "upper bound")}");
+                    return sb.Append(';').ToString();
+
+                case EntityKind.DecisionExpression:
+                    return $"dexpr {Type} {Name}{indices} = {Require(Value, "value")};";
+
+                case EntityKind.Constraint:
+                    string header = Forall != null ? $"forall({Forall}) " : "";
+                    return $"{header}{Name}: {Require(Body, "body")};";
+
+                default:
+                    return $"{(Sense == ObjectiveSense.Minimize ? "minimize" : "maximize")} {Require(Body, "body")};";
+            }
+        }
+
+        /// <summary>
+        /// Reads a declaration statement back into its structured form, or returns null
+        /// when the statement is not a single named declaration
+        /// </summary>
+        public static EntityDefinition? FromStatement(string statement)
+        {
+            string code = statement.Substring(ModelStatement.TriviaLength(statement)).Trim();
+            if (code.EndsWith(";"))
+                code = code.Substring(0, code.Length - 1).TrimEnd();
+
+            Match m;
+
+            if ((m = Regex.Match(code, @"^(minimize|maximize)\s+(.+)$", RegexOptions.Singleline)).Success)
+            {
+                return new EntityDefinition
+                {
+                    Kind = EntityKind.Objective,
+                    Sense = m.Groups[1].Value == "minimize" ? ObjectiveSense.Minimize : ObjectiveSense.Maximize,
+                    Body = m.Groups[2].Value.Trim()
+                };
+            }
+
+            if ((m = Regex.Match(code, @"^range\s+(\w+)\s*=\s*(.+)$", RegexOptions.Singleline)).Success)
+                return new EntityDefinition { Kind = EntityKind.Set, Type = "range", Name = m.Groups[1].Value, Value = m.Groups[2].Value.Trim() };
+
+            if ((m = Regex.Match(code, @"^\{\s*(\w+)\s*\}\s*(\w+)\s*=\s*(.+)$", RegexOptions.Singleline)).Success)
+                return new EntityDefinition { Kind = EntityKind.Set, Type = m.Groups[1].Value, Name = m.Groups[2].Value, Value = m.Groups[3].Value.Trim() };
+
+            if ((m = Regex.Match(code, @"^dvar\s+(\w+\+?)\s+(\w+)\s*(?:\[([^\]]*)\])?\s*(?:in\s+(.+?)\s*\.\.\s*(.+))?$", RegexOptions.Singleline)).Success)
+            {
+                return new EntityDefinition
+                {
+                    Kind = EntityKind.Variable,
+                    Type = m.Groups[1].Value,
+                    Name = m.Groups[2].Value,
+                    IndexSets = SplitIndices(m.Groups[3].Value),
+                    LowerBound = m.Groups[4].Success ? m.Groups[4].Value.Trim() : null,
+                    UpperBound = m.Groups[5].Success ? m.Groups[5].Value.Trim() : null
+                };
+            }
+
+            if ((m = Regex.Match(code, @"^dexpr\s+(\w+)\s+(\w+)\s*(?:\[([^\]]*)\])?\s*=\s*(.+)$", RegexOptions.Singleline)).Success)
+            {
+                return new EntityDefinition
+                {
+                    Kind = EntityKind.DecisionExpression,
+                    Type = m.Groups[1].Value,
+                    Name = m.Groups[2].Value,
+                    IndexSets = SplitIndices(m.Groups[3].Value),
+                    Value = m.Groups[4].Value.Trim()
+                };
+            }
+
+            if ((m = Regex.Match(code, @"^((?:float|int|bool|string)\+?)\s+(\w+)\s*(?:\[([^\]]*)\])?\s*(?:=\s*(.+))?$", RegexOptions.Singleline)).Success)
+            {
+                return new EntityDefinition
+                {
+                    Kind = EntityKind.Parameter,
+                    Type = m.Groups[1].Value,
+                    Name = m.Groups[2].Value,
+                    IndexSets = SplitIndices(m.Groups[3].Value),
+                    Value = m.Groups[4].Success ? m.Groups[4].Value.Trim() : null
+                };
+            }
+
+            if ((m = Regex.Match(code, @"^(?:forall\s*\((.*?)\)\s*)?(\w+)\s*:(?!:)\s*(.+)$", RegexOptions.Singleline)).Success &&
+                !Regex.IsMatch(m.Groups[2].Value, @"^(forall|subject|minimize|maximize)$"))
+            {
+                return new EntityDefinition
+                {
+                    Kind = EntityKind.Constraint,
+                    Forall = m.Groups[1].Success ? m.Groups[1].Value.Trim() : null,
+                    Name = m.Groups[2].Value,
+                    Body = m.Groups[3].Value.Trim()
+                };
+            }
+
+            return null;
+        }
+
+        private string Require(string? value, string field)
+        {
+            return string.IsNullOrWhiteSpace(value)
+                ? throw new InvalidOperationException($"{Kind} '{Name}' has no {field}")
+                : value;
+        }
+
+        private static List<string> SplitIndices(string indices)
+        {
+            return indices.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries).ToList();
+        }
+    }
+}
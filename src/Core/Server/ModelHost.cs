@@ -0,0 +1,1815 @@
+using System.Diagnostics;
+using Core.Analysis;
+using Core.Export;
+using Core.Models;
+using Core.Parsing;
+using Core.Services;
+using Core.Solving;
+using Core.Storage;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// A model held in memory by the server: its source statements plus data text
+    /// </summary>
+    public class HostedModel
+    {
+        public string Id { get; }
+        public string Name { get; set; }
+        public ModelSource Source { get; internal set; }
+        public string DataText { get; internal set; }
+
+        /// <summary>
+        /// Incremented on every change to the model or data text
+        /// </summary>
+        public int Version { get; internal set; } = 1;
+        public DateTime LastModified { get; internal set; }
+
+        /// <summary>
+        /// Parse errors of the current text
+        /// </summary>
+        public List<string> Errors { get; internal set; } = new List<string>();
+
+        /// <summary>
+        /// Subject of the user who created the model, if it was created by an authenticated caller
+        /// </summary>
+        public string? Owner { get; internal set; }
+
+        /// <summary>
+        /// Error of the most recent save to the host's storage, or null if it succeeded
+        /// </summary>
+        public string? LastSaveError { get; internal set; }
+
+        /// <summary>
+        /// Estimated footprint in bytes of the model's last expansion (see ModelLimits.EstimateMemory);
+        /// 0 until it is first expanded or solved
+        /// </summary>
+        public long MemoryEstimate { get; internal set; }
+
+        /// <summary>
+        /// Version last written to the host's storage; 0 if it never was (e.g. without storage)
+        /// </summary>
+        public int SavedVersion { get; internal set; }
+
+        /// <summary>
+        /// True while the current version exists only in memory (and the recovery location)
+        /// </summary>
+        public bool HasUnsavedChanges => Version > SavedVersion;
+
+        /// <summary>
+        /// Version last written to the host's recovery location; 0 if none is there
+        /// </summary>
+        internal int CheckpointVersion { get; set; }
+
+        /// <summary>
+        /// Id of the shared model this is a user's working copy of; null for shared models
+        /// </summary>
+        public string? BranchOf { get; internal set; }
+
+        /// <summary>
+        /// Version of the shared model the working copy was branched from or last merged with
+        /// </summary>
+        public int BaseVersion { get; internal set; }
+
+        /// <summary>
+        /// Model and data text of the shared model at BaseVersion, the base of three-way merges
+        /// </summary>
+        internal string BaseText { get; set; } = "";
+        internal string BaseData { get; set; } = "";
+
+        /// <summary>
+        /// Saves run one after another per model; a queued save writes the state current when it starts
+        /// </summary>
+        internal Task PendingSave { get; set; } = Task.CompletedTask;
+        internal bool SaveQueued { get; set; }
+
+        internal object SyncRoot { get; } = new object();
+
+        /// <summary>
+        /// Accepted entity operations, for synchronizing collaborative replicas
+        /// </summary>
+        internal OperationLog Log { get; } = new OperationLog();
+
+        /// <summary>
+        /// Per-entity change counters. Kept after an entity is removed so that a re-added
+        /// entity never reuses a revision a client may still hold.
+        /// </summary>
+        private readonly Dictionary<string, int> revisions = new Dictionary<string, int>();
+
+        internal HostedModel(string id, string name, string modelText, string dataText, DateTime created)
+        {
+            Id = id;
+            Name = name;
+            Source = ModelSource.Parse(modelText);
+            DataText = dataText;
+            LastModified = created;
+
+            foreach (var statement in Source.Statements.Where(s => s.Key != null))
+                revisions[statement.Key!] = 1;
+        }
+
+        public string ModelText => Source.ToString();
+
+        /// <summary>
+        /// Sequence of the last entity operation; a replica created from ModelText starts here
+        /// </summary>
+        public long Sequence
+        {
+            get
+            {
+                lock (SyncRoot)
+                {
+                    return Log.Sequence;
+                }
+            }
+        }
+
+        /// <summary>
+        /// Current revision of an entity, or 0 if the model does not declare it
+        /// </summary>
+        public int GetRevision(string key)
+        {
+            lock (SyncRoot)
+            {
+                return Source.Find(key) != null && revisions.TryGetValue(key, out int revision) ? revision : 0;
+            }
+        }
+
+        internal void BumpRevision(string key)
+        {
+            revisions[key] = revisions.TryGetValue(key, out int revision) ? revision + 1 : 1;
+        }
+    }
+
+    /// <summary>
+    /// Outcome of adding, replacing or removing one entity
+    /// </summary>
+    public class EntityUpdateResult
+    {
+        public string Key { get; init; } = "";
+        public bool Success { get; init; }
+
+        /// <summary>
+        /// True if an existing declaration was replaced rather than a new one added
+        /// </summary>
+        public bool Replaced { get; init; }
+        public int Version { get; init; }
+
+        /// <summary>
+        /// Revision of the entity after the operation, or its current revision if the operation failed
+        /// </summary>
+        public int Revision { get; init; }
+
+        /// <summary>
+        /// True if the expected revision did not match; Current then holds the server's version of the entity
+        /// </summary>
+        public bool Conflict { get; init; }
+
+        /// <summary>
+        /// Server state of the entity on a conflict (null if it no longer exists)
+        /// </summary>
+        public EntityDefinition? Current { get; init; }
+
+        public List<string> Errors { get; init; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Outcome of publishing a working copy or updating it from the shared model
+    /// </summary>
+    public class WorkingCopyResult
+    {
+        public bool Success { get; init; }
+
+        /// <summary>
+        /// Version of the model that was written (the shared one for Publish, the copy for Update)
+        /// </summary>
+        public int Version { get; init; }
+
+        /// <summary>
+        /// Statements the working copy changed (see ModelMergeResult)
+        /// </summary>
+        public List<string> Changed { get; init; } = new List<string>();
+
+        /// <summary>
+        /// Statements changed differently on both sides; "data" if the data texts were
+        /// </summary>
+        public List<string> Conflicts { get; init; } = new List<string>();
+
+        public List<string> Errors { get; init; } = new List<string>();
+    }
+
+    /// <summary>
+    /// Protocol-independent core of the editor server: holds models in memory, applies
+    /// entity-level edits and runs solves with progress reporting. Front ends (gRPC, HTTP)
+    /// translate their messages to calls on this class.
+    /// </summary>
+    public class ModelHost
+    {
+        private readonly Dictionary<string, HostedModel> models = new Dictionary<string, HostedModel>();
+        private readonly List<Task> pendingDeletes = new List<Task>();
+        private readonly List<RunningSolve> running = new List<RunningSolve>();
+        private readonly object syncRoot = new object();
+        private readonly Func<DateTime> clock;
+
+        public ModelHost(Func<DateTime>? clock = null)
+        {
+            this.clock = clock ?? (() => DateTime.UtcNow);
+        }
+
+        /// <summary>
+        /// Interval between heartbeat progress events while the solver runs
+        /// </summary>
+        public TimeSpan ProgressInterval { get; set; } = TimeSpan.FromSeconds(1);
+
+        /// <summary>
+        /// Results of earlier solves; when set, solving an unchanged model with the same solver
+        /// settings returns the stored result
+        /// </summary>
+        public SolveCache? Cache { get; set; }
+
+        /// <summary>
+        /// Access policy checked against AccessContext.Current on every operation; null disables checks
+        /// </summary>
+        public AccessPolicy? Policy { get; set; }
+
+        /// <summary>
+        /// Reject entity edits that do not state the revision they were based on
+        /// </summary>
+        public bool RequireRevisions { get; set; }
+
+        /// <summary>
+        /// Persistence backend; when set every change is saved in the background as a new version
+        /// </summary>
+        public IModelStorage? Storage { get; set; }
+
+        /// <summary>
+        /// Size guardrails for hosted models: texts beyond MaxFileSize are refused, the rest
+        /// is enforced on every manager the host parses into
+        /// </summary>
+        public ModelLimits Limits { get; set; } = ModelLimits.Shared;
+
+        /// <summary>
+        /// Receives the host's structured events (see ModelLog); a no-op logger by default
+        /// </summary>
+        public ILogger Logger { get; set; } = NullLogger.Instance;
+
+        /// <summary>
+        /// Registered webhooks told about finished, failed and progressing solves; null sends none
+        /// </summary>
+        public WebhookNotifier? Webhooks { get; set; }
+
+        /// <summary>
+        /// Where CheckpointAsync keeps models with unsaved changes until they reach Storage;
+        /// null disables autosave and recovery
+        /// </summary>
+        public ModelRecovery? Recovery { get; set; }
+
+        /// <summary>
+        /// Published, read-only versions of the hosted models; kept in memory unless the server
+        /// gives the store a directory
+        /// </summary>
+        public PublishedModelStore Publications { get; set; } = new PublishedModelStore();
+
+        /// <summary>
+        /// Set by ShutdownAsync; new solves are refused from then on
+        /// </summary>
+        public bool IsShuttingDown { get; private set; }
+
+        /// <summary>
+        /// Throws AccessDeniedException unless the current caller holds the permission, on an
+        /// entity also unless they have the roles of the restrictions its tags fall under
+        /// </summary>
+        public void Demand(string id, Permission permission, string? entityKey = null)
+        {
+            Policy?.Demand(AccessContext.Current, id, permission, entityKey);
+            if (entityKey == null || Policy == null || !Policy.HasRestrictions(id))
+                return;
+
+            var model = Find(id);
+            if (model == null)
+                return;
+
+            string modelText;
+            lock (model.SyncRoot)
+                modelText = model.ModelText;
+            DemandRestricted(id, modelText, new[] { entityKey }, permission);
+        }
+
+        /// <summary>
+        /// Creates a model; under an access policy the caller must be authenticated and becomes its admin
+        /// </summary>
+        public HostedModel Create(string name, string modelText, string dataText = "")
+        {
+            var creator = AccessContext.Current;
+            if (Policy != null && creator == null)
+                throw new AccessDeniedException(null, Permission.Edit, "*", null);
+
+            Limits.CheckFileSize(modelText, "Model text");
+            Limits.CheckFileSize(dataText, "Data text");
+
+            var model = new HostedModel(Guid.NewGuid().ToString("N"), name, modelText, dataText, clock());
+            model.Errors = ParseErrors(model.ModelText);
+            model.Owner = creator?.Subject;
+
+            lock (syncRoot)
+            {
+                models[model.Id] = model;
+            }
+
+            int entityCount;
+            lock (model.SyncRoot)
+            {
+                entityCount = CountEntities(model);
+                Persist(model);
+            }
+
+            if (Policy != null && creator != null)
+                Policy.Grant(new AccessGrant { Subject = $"user:{creator.Subject}", ModelId = model.Id, Permissions = Permission.Admin });
+
+            Logger.LogModelCreated(model.Id, model.Name, entityCount);
+            return model;
+        }
+
+        public HostedModel? Find(string id)
+        {
+            lock (syncRoot)
+            {
+                return models.TryGetValue(id, out var model) ? model : null;
+            }
+        }
+
+        public HostedModel Get(string id)
+        {
+            return Find(id) ?? throw new InvalidOperationException($"Model '{id}' not found");
+        }
+
+        /// <summary>
+        /// Models the current caller may read
+        /// </summary>
+        public IReadOnlyList<HostedModel> List()
+        {
+            lock (syncRoot)
+            {
+                return models.Values
+                    .Where(m => Policy == null || Policy.IsAllowed(AccessContext.Current, m.Id, Permission.Read))
+                    .OrderBy(m => m.Name, StringComparer.Ordinal)
+                    .ToList();
+            }
+        }
+
+        /// <summary>
+        /// Every hosted model whatever the caller may read, for metrics
+        /// </summary>
+        internal IReadOnlyList<HostedModel> AllModels()
+        {
+            lock (syncRoot)
+            {
+                return models.Values.ToList();
+            }
+        }
+
+        public bool Delete(string id)
+        {
+            var model = Find(id);
+            if (model == null)
+                return false;
+
+            Demand(id, Permission.Admin);
+            Policy?.RevokeAll(id);
+
+            lock (syncRoot)
+            {
+                if (!models.Remove(id))
+                    return false;
+
+                // Delete after any save still in flight, so the model is not written back
+                var storage = Storage;
+                if (storage != null)
+                    pendingDeletes.Add(model.PendingSave.ContinueWith(_ => storage.DeleteAsync(id), TaskScheduler.Default).Unwrap());
+            }
+
+            Recovery?.Remove(id);
+            Logger.LogModelDeleted(id);
+            return true;
+        }
+
+        /// <summary>
+        /// Loads the latest version of every model in Storage that is not already hosted.
+        /// Owners are granted admin again, since grants themselves are not persisted.
+        /// </summary>
+        public async Task<int> LoadAsync(CancellationToken cancellationToken = default)
+        {
+            var storage = Storage ?? throw new InvalidOperationException("No storage is configured on this host");
+            var sw = Stopwatch.StartNew();
+            int loaded = 0;
+
+            foreach (var info in await storage.ListAsync(cancellationToken))
+            {
+                if (Find(info.Id) != null)
+                    continue;
+
+                var stored = await storage.OpenAsync(info.Id, null, cancellationToken);
+                if (stored == null)
+                    continue;
+
+                var model = new HostedModel(stored.Id, stored.Name, stored.ModelText, stored.DataText, stored.SavedAt)
+                {
+                    Version = stored.Version,
+                    SavedVersion = stored.Version,
+                    Owner = stored.Owner
+                };
+                model.Errors = ParseErrors(model.ModelText);
+
+                lock (syncRoot)
+                {
+                    models.TryAdd(model.Id, model);
+                }
+
+                if (Policy != null && model.Owner != null)
+                    Policy.Grant(new AccessGrant { Subject = $"user:{model.Owner}", ModelId = model.Id, Permissions = Permission.Admin });
+                loaded++;
+            }
+
+            Logger.LogModelsRestored(loaded, storage.GetType().Name, sw.Elapsed);
+            return loaded;
+        }
+
+        /// <summary>
+        /// Writes every model with unsaved changes or a running solve to the recovery location,
+        /// with the incumbents of its running solves, and removes the checkpoints of models saved
+        /// since. Models already checkpointed at their current version are skipped unless solves
+        /// run on them or this is the shutdown checkpoint. Returns the number written.
+        /// </summary>
+        public async Task<int> CheckpointAsync(bool shutdown = false, CancellationToken cancellationToken = default)
+        {
+            var recovery = Recovery ?? throw new InvalidOperationException("No recovery location is configured on this host");
+            var sw = Stopwatch.StartNew();
+            List<HostedModel> hosted;
+            List<RunningSolve> solves;
+            lock (syncRoot)
+            {
+                hosted = models.Values.ToList();
+                solves = running.ToList();
+            }
+
+            int written = 0;
+            foreach (var model in hosted)
+            {
+                var modelSolves = solves.Where(s => s.Model == model).Select(s => s.ToCheckpoint(clock())).ToList();
+                RecoveryCheckpoint? checkpoint = null;
+                bool saved;
+                lock (model.SyncRoot)
+                {
+                    saved = !model.HasUnsavedChanges && modelSolves.Count == 0;
+                    if (!saved && (shutdown || modelSolves.Count > 0 || model.CheckpointVersion != model.Version))
+                        checkpoint = CheckpointOf(model, modelSolves, shutdown);
+                }
+
+                if (checkpoint != null)
+                {
+                    await recovery.WriteAsync(checkpoint, cancellationToken);
+                    lock (model.SyncRoot)
+                        model.CheckpointVersion = checkpoint.Version;
+                    written++;
+                }
+                else if (saved && model.CheckpointVersion != 0)
+                {
+                    recovery.Remove(model.Id);
+                    lock (model.SyncRoot)
+                        model.CheckpointVersion = 0;
+                }
+            }
+
+            if (written > 0)
+                Logger.LogModelsCheckpointed(written, solves.Count, sw.Elapsed);
+            return written;
+        }
+
+        /// <summary>
+        /// Drains the host: refuses new solves, gives running ones the grace period to finish,
+        /// waits for queued saves, then checkpoints whatever is still unsaved or running and
+        /// delivers pending webhooks. A checkpoint that cannot be written is logged, not thrown,
+        /// so the rest of the shutdown proceeds.
+        /// </summary>
+        public async Task ShutdownAsync(TimeSpan grace, CancellationToken cancellationToken = default)
+        {
+            IsShuttingDown = true;
+
+            Task[] solves;
+            lock (syncRoot)
+            {
+                solves = running.Select(r => r.Finished.Task).ToArray();
+            }
+            if (solves.Length > 0)
+                await Task.WhenAny(Task.WhenAll(solves), Task.Delay(grace, cancellationToken));
+
+            await FlushAsync();
+
+            var recovery = Recovery;
+            if (recovery != null)
+            {
+                try
+                {
+                    await CheckpointAsync(shutdown: true, cancellationToken);
+                }
+                catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+                {
+                    Logger.LogCheckpointFailed(recovery.Directory, ex);
+                }
+            }
+
+            if (Webhooks != null)
+                await Webhooks.FlushAsync();
+        }
+
+        /// <summary>
+        /// Checkpoints with changes this host does not have, typically left by a crash or by a
+        /// drained host without storage, that the caller may see: a newer version than the hosted
+        /// one (or a model the host does not know), or solves that were interrupted. Checkpoints
+        /// the host has caught up with are removed. Offer them to the user, then RestoreAsync or
+        /// Discard each.
+        /// </summary>
+        public async Task<IReadOnlyList<RecoveryCheckpoint>> RecoverAsync(CancellationToken cancellationToken = default)
+        {
+            var recovery = Recovery ?? throw new InvalidOperationException("No recovery location is configured on this host");
+            var offers = new List<RecoveryCheckpoint>();
+            foreach (var checkpoint in await recovery.ReadAllAsync(cancellationToken))
+            {
+                var model = Find(checkpoint.Id);
+
+                // This process's own autosave of a model it still holds is not a recovery
+                if (model != null && model.CheckpointVersion != 0)
+                    continue;
+
+                if (model != null && model.Version >= checkpoint.Version && checkpoint.RunningSolves.Count == 0)
+                {
+                    recovery.Remove(checkpoint.Id);
+                    continue;
+                }
+
+                if (MayRecover(checkpoint, Permission.Read))
+                    offers.Add(checkpoint);
+            }
+            return offers;
+        }
+
+        /// <summary>
+        /// Brings a recovered checkpoint back: replaces the text of the hosted model (as a new
+        /// version) or hosts the model again if it is gone. The restored version is checkpointed
+        /// again right away and saved to Storage like any edit.
+        /// </summary>
+        public async Task<HostedModel> RestoreAsync(string id, CancellationToken cancellationToken = default)
+        {
+            var recovery = Recovery ?? throw new InvalidOperationException("No recovery location is configured on this host");
+            var checkpoint = await recovery.ReadAsync(id, cancellationToken)
+                ?? throw new InvalidOperationException($"No recovered changes for model '{id}'");
+
+            var model = Find(id);
+            if (model != null)
+            {
+                Demand(id, Permission.Edit);
+                lock (model.SyncRoot)
+                {
+                    model.Version = Math.Max(model.Version, checkpoint.Version);
+                    ReplaceText(model, checkpoint.ModelText, checkpoint.DataText);
+                }
+            }
+            else
+            {
+                if (!MayRecover(checkpoint, Permission.Admin))
+                    throw new AccessDeniedException(AccessContext.Current, Permission.Admin, id, null);
+
+                model = new HostedModel(checkpoint.Id, checkpoint.Name, checkpoint.ModelText, checkpoint.DataText, clock())
+                {
+                    Version = checkpoint.Version,
+                    Owner = checkpoint.Owner,
+                    BranchOf = checkpoint.BranchOf,
+                    BaseVersion = checkpoint.BaseVersion,
+                    BaseText = checkpoint.BaseText ?? "",
+                    BaseData = checkpoint.BaseData ?? ""
+                };
+                model.Errors = ParseErrors(model.ModelText);
+                lock (syncRoot)
+                {
+                    models[model.Id] = model;
+                }
+                lock (model.SyncRoot)
+                {
+                    Persist(model);
+                }
+                if (Policy != null && model.Owner != null)
+                    Policy.Grant(new AccessGrant { Subject = $"user:{model.Owner}", ModelId = model.Id, Permissions = Permission.Admin });
+            }
+
+            RecoveryCheckpoint restored;
+            lock (model.SyncRoot)
+            {
+                restored = CheckpointOf(model, new List<SolveCheckpoint>(), shutdown: false);
+            }
+            await recovery.WriteAsync(restored, cancellationToken);
+            lock (model.SyncRoot)
+                model.CheckpointVersion = restored.Version;
+            return model;
+        }
+
+        /// <summary>
+        /// Drops a recovered checkpoint without restoring it. Like RestoreAsync, it needs Edit on a
+        /// hosted model, and otherwise the checkpoint's ownership or Admin.
+        /// </summary>
+        public async Task<bool> DiscardAsync(string id, CancellationToken cancellationToken = default)
+        {
+            var recovery = Recovery ?? throw new InvalidOperationException("No recovery location is configured on this host");
+            var model = Find(id);
+            if (model != null)
+            {
+                Demand(id, Permission.Edit);
+            }
+            else
+            {
+                var checkpoint = await recovery.ReadAsync(id, cancellationToken);
+                if (checkpoint == null)
+                    return false;
+                if (!MayRecover(checkpoint, Permission.Admin))
+                    throw new AccessDeniedException(AccessContext.Current, Permission.Admin, id, null);
+            }
+            return recovery.Remove(id);
+        }
+
+        /// <summary>
+        /// Waits until every queued save and delete has reached the storage
+        /// </summary>
+        public Task FlushAsync()
+        {
+            lock (syncRoot)
+            {
+                return Task.WhenAll(models.Values.Select(m => m.PendingSave).Concat(pendingDeletes));
+            }
+        }
+
+        /// <summary>
+        /// Saved versions of a model, oldest first
+        /// </summary>
+        public Task<IReadOnlyList<StoredModelInfo>> GetVersionsAsync(string id, CancellationToken cancellationToken = default)
+        {
+            Get(id);
+            Demand(id, Permission.Read);
+            var storage = Storage ?? throw new InvalidOperationException("No storage is configured on this host");
+            return storage.VersionsAsync(id, cancellationToken);
+        }
+
+        /// <summary>
+        /// Sets the permissions of a subject on a model or one block of it (None removes the grant).
+        /// Requires Admin on the model.
+        /// </summary>
+        public void Grant(string id, string subject, Permission permissions, string block = "*")
+        {
+            Get(id);
+            if (Policy == null)
+                throw new InvalidOperationException("Access control is not enabled on this host");
+
+            Demand(id, Permission.Admin);
+            Policy.Grant(new AccessGrant { Subject = subject, ModelId = id, Block = block, Permissions = permissions });
+        }
+
+        /// <summary>
+        /// Limits a permission on the entities matching a key pattern and/or tag (or block) of a
+        /// model to callers with a role (see EntityRestriction); None removes the restriction.
+        /// Requires Admin on the model.
+        /// </summary>
+        public void Restrict(string id, string role, string entities = "*", string? tag = null, Permission permissions = Permission.Edit)
+        {
+            Get(id);
+            if (Policy == null)
+                throw new InvalidOperationException("Access control is not enabled on this host");
+
+            Demand(id, Permission.Admin);
+            Policy.Restrict(new EntityRestriction { ModelId = id, Role = role, Entities = entities, Tag = tag, Permissions = permissions });
+        }
+
+        /// <summary>
+        /// Freezes the model's current version as a published model: the model and data text are
+        /// parsed and expanded, and only a version without errors is published. Publishing an
+        /// unchanged version again returns the existing artifact. Refused for working copies,
+        /// which publish into their shared model instead, and if the model is no longer at
+        /// <paramref name="expectedVersion"/>.
+        /// </summary>
+        public async Task<PublishedModel> PublishVersionAsync(string id, int? expectedVersion = null, CancellationToken cancellationToken = default)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Edit);
+            if (model.BranchOf != null)
+                throw new InvalidOperationException($"Model '{id}' is a working copy; publish it to '{model.BranchOf}' first");
+
+            string modelText, dataText;
+            int version;
+            lock (model.SyncRoot)
+            {
+                if (expectedVersion != null && expectedVersion != model.Version)
+                    throw new InvalidOperationException($"Model is at version {model.Version}, expected {expectedVersion}");
+                (modelText, dataText, version) = (model.ModelText, model.DataText, model.Version);
+            }
+
+            string publicationId = PublishedModel.ContentId(id, modelText, dataText);
+            var existing = Publications.Find(publicationId);
+            if (existing != null)
+                return existing;
+
+            ParseResult parseResult = null!;
+            var manager = await Task.Run(() => ExpandText(modelText, dataText, out parseResult, cancellationToken), cancellationToken);
+            if (parseResult.HasErrors)
+                throw new InvalidOperationException($"Model '{id}' version {version} has errors: {string.Join("; ", parseResult.Errors)}");
+
+            var published = await Publications.AddAsync(new PublishedModel
+            {
+                Id = publicationId,
+                ModelId = id,
+                Name = model.Name,
+                Version = version,
+                PublishedAt = clock(),
+                PublishedBy = AccessContext.Current?.Subject,
+                ModelText = modelText,
+                DataText = dataText,
+                Fingerprint = ModelFingerprint.Compute(manager).ModelHash
+            }, cancellationToken);
+            Logger.LogModelPublished(id, version, published.Id);
+            return published;
+        }
+
+        /// <summary>
+        /// Published versions of a model, oldest first
+        /// </summary>
+        public IReadOnlyList<PublishedModel> GetPublished(string id)
+        {
+            Demand(id, Permission.Read);
+            return Publications.List(id);
+        }
+
+        /// <summary>
+        /// A published model; reading it needs Read permission on the model it came from
+        /// </summary>
+        public PublishedModel GetPublication(string publicationId)
+        {
+            var published = Publications.Find(publicationId)
+                ?? throw new InvalidOperationException($"Published model '{publicationId}' not found");
+            Demand(published.ModelId, Permission.Read);
+            return published;
+        }
+
+        /// <summary>
+        /// Export profiles declared in the model text ("// @export" annotations)
+        /// </summary>
+        public ExportProfiles GetExportProfiles(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            return ExportProfiles.Parse(model.ModelText);
+        }
+
+        /// <summary>
+        /// Writes the current version of the model with one of its export profiles. Profiles
+        /// applying a case cannot be used here: the host keeps no case files.
+        /// </summary>
+        public ExportProfileResult Export(string id, string profileName, CancellationToken cancellationToken = default)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            string modelText, dataText;
+            lock (model.SyncRoot)
+            {
+                modelText = model.ModelText;
+                dataText = model.DataText;
+            }
+
+            var profile = ExportProfiles.Parse(modelText).Find(profileName)
+                ?? throw new InvalidOperationException($"Model '{id}' has no export profile '{profileName}'");
+            if (profile.Case != null)
+                throw new InvalidOperationException($"Export profile '{profile.Name}' applies case '{profile.Case}', which the server does not have");
+
+            return profile.Export(new[] { modelText }, new[] { dataText }, cancellationToken: cancellationToken);
+        }
+
+        /// <summary>
+        /// Entities declared in the model text, in source order
+        /// </summary>
+        public IReadOnlyList<EntityDefinition> GetEntities(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            lock (model.SyncRoot)
+            {
+                return model.Source.Statements
+                    .Where(s => s.Key != null)
+                    .Select(s => ReadEntity(model, s))
+                    .Where(d => d != null)
+                    .Select(d => d!)
+                    .ToList();
+            }
+        }
+
+        /// <summary>
+        /// Adds or replaces the declaration of an entity. With validation the model is re-parsed
+        /// and the edit is rolled back if it introduces parse errors; without validation the edit
+        /// is staged (used for bulk uploads, followed by one Validate call).
+        /// An expected revision makes the edit conditional (0 = the entity must not exist yet);
+        /// on a mismatch nothing changes and the result carries the current server state.
+        /// </summary>
+        public EntityUpdateResult ApplyEntity(string id, EntityDefinition definition, bool validate = true, int? expectedRevision = null)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Edit, definition.Key);
+            string statement;
+
+            try
+            {
+                statement = definition.ToStatement();
+            }
+            catch (InvalidOperationException ex)
+            {
+                return Failure(definition.Key, model.Version, ex.Message);
+            }
+
+            lock (model.SyncRoot)
+            {
+                var precondition = CheckRevision(model, definition.Key, expectedRevision);
+                if (precondition != null)
+                    return precondition;
+
+                string previousText = model.ModelText;
+                bool replaced = model.Source.Upsert(statement);
+                try
+                {
+                    DemandRestricted(id, model.ModelText, new[] { definition.Key }, Permission.Edit);
+                }
+                catch (AccessDeniedException)
+                {
+                    model.Source = ModelSource.Parse(previousText);
+                    throw;
+                }
+
+                if (validate)
+                {
+                    var errors = ParseErrors(model.ModelText);
+                    var newErrors = errors.Except(model.Errors).ToArray();
+                    if (newErrors.Length > 0)
+                    {
+                        model.Source = ModelSource.Parse(previousText);
+                        return Failure(definition.Key, model.Version, newErrors);
+                    }
+                    model.Errors = errors;
+                }
+
+                model.BumpRevision(definition.Key);
+                model.Log.Record(definition.Key, model.Source.Find(definition.Key)!.Code);
+                Touch(model);
+                return new EntityUpdateResult
+                {
+                    Key = definition.Key,
+                    Success = true,
+                    Replaced = replaced,
+                    Version = model.Version,
+                    Revision = model.GetRevision(definition.Key)
+                };
+            }
+        }
+
+        /// <summary>
+        /// Removes the declaration of an entity, conditionally on its revision if one is given
+        /// </summary>
+        public EntityUpdateResult RemoveEntity(string id, string key, int? expectedRevision = null)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Edit, key);
+
+            lock (model.SyncRoot)
+            {
+                var precondition = CheckRevision(model, key, expectedRevision);
+                if (precondition != null)
+                    return precondition;
+
+                if (!model.Source.Remove(key))
+                    return Failure(key, model.Version, $"Entity '{key}' not found");
+
+                model.BumpRevision(key);
+                model.Log.Record(key, null);
+                model.Errors = ParseErrors(model.ModelText);
+                Touch(model);
+                return new EntityUpdateResult { Key = key, Success = true, Version = model.Version };
+            }
+        }
+
+        /// <summary>
+        /// Quick fixes for the model's current diagnostics: replacements for misspelled names and
+        /// the fixes of lint findings (default profile), ready for ApplyChangeSet
+        /// </summary>
+        public List<CodeAction> GetCodeActions(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+
+            string modelText, dataText;
+            ModelSource source;
+            lock (model.SyncRoot)
+            {
+                modelText = model.ModelText;
+                dataText = model.DataText;
+                source = ModelSource.Parse(modelText);
+            }
+
+            var manager = new ModelManager { Limits = Limits };
+            var parser = new EquationParser(manager);
+            var result = parser.Parse(modelText);
+            if (!string.IsNullOrWhiteSpace(dataText))
+                new DataFileParser(manager).Parse(dataText);
+            if (!manager.Parameters.Values.Any(p => p.IsExternal && !p.HasValue))
+                parser.ExpandAllTemplates(new ParseSessionResult());
+
+            var report = new ModelLinter(manager) { ModelTexts = new[] { modelText } }.Lint();
+            return CodeFixes.Collect(source, result.Suggestions, report);
+        }
+
+        /// <summary>
+        /// Declaration and docstring of the entity named at a 1-based line and column, or null
+        /// </summary>
+        public HoverInfo? GetHover(string id, int lineNumber, int column)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+
+            string modelText;
+            lock (model.SyncRoot)
+                modelText = model.ModelText;
+
+            return ModelHover.At(modelText, lineNumber, column);
+        }
+
+        /// <summary>
+        /// Classified tokens of the model text, for highlighting
+        /// </summary>
+        public List<SyntaxToken> GetTokens(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+
+            string modelText;
+            lock (model.SyncRoot)
+                modelText = model.ModelText;
+
+            return ModelLexer.Classify(modelText);
+        }
+
+        /// <summary>
+        /// Applies a change set (e.g. a code action's edit) as one edit: validated and rolled back
+        /// like ApplyEntity, and refused if the model version is no longer the expected one or an
+        /// edit no longer applies. Keyed edits are recorded in the sync log.
+        /// </summary>
+        public EntityUpdateResult ApplyChangeSet(string id, ChangeSet changes, int? expectedVersion = null)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Edit);
+            foreach (var key in changes.Keys)
+                Demand(id, Permission.Edit, key);
+
+            lock (model.SyncRoot)
+            {
+                if (expectedVersion != null && expectedVersion != model.Version)
+                {
+                    return new EntityUpdateResult
+                    {
+                        Success = false,
+                        Conflict = true,
+                        Version = model.Version,
+                        Errors = { $"Model is at version {model.Version}, expected {expectedVersion}" }
+                    };
+                }
+
+                string previousText = model.ModelText;
+                try
+                {
+                    changes.Apply(model.Source);
+                }
+                catch (InvalidOperationException ex)
+                {
+                    model.Source = ModelSource.Parse(previousText);
+                    return Failure("", model.Version, ex.Message);
+                }
+
+                try
+                {
+                    DemandRestricted(id, model.ModelText, changes.Keys, Permission.Edit);
+                }
+                catch (AccessDeniedException)
+                {
+                    model.Source = ModelSource.Parse(previousText);
+                    throw;
+                }
+
+                var errors = ParseErrors(model.ModelText);
+                var newErrors = errors.Except(model.Errors).ToArray();
+                if (newErrors.Length > 0)
+                {
+                    model.Source = ModelSource.Parse(previousText);
+                    return Failure("", model.Version, newErrors);
+                }
+                model.Errors = errors;
+
+                foreach (var key in changes.Keys)
+                {
+                    model.BumpRevision(key);
+                    model.Log.Record(key, model.Source.Find(key)?.Code);
+                }
+                Touch(model);
+                return new EntityUpdateResult { Success = true, Version = model.Version };
+            }
+        }
+
+        /// <summary>
+        /// The caller's working copy of a shared model, created on first use: a private model
+        /// (only its owner and admins may access it) whose edits and solves leave the shared
+        /// model alone until they are published
+        /// </summary>
+        public HostedModel Branch(string id)
+        {
+            var shared = Get(id);
+            Demand(id, Permission.Read);
+            if (shared.BranchOf != null)
+                throw new InvalidOperationException($"Model '{id}' is a working copy of '{shared.BranchOf}'");
+
+            var caller = AccessContext.Current;
+            if (Policy != null && caller == null)
+                throw new AccessDeniedException(null, Permission.Read, id, null);
+
+            HostedModel copy;
+            lock (shared.SyncRoot)
+            {
+                copy = new HostedModel(Guid.NewGuid().ToString("N"), shared.Name, shared.ModelText, shared.DataText, clock())
+                {
+                    Owner = caller?.Subject,
+                    Errors = shared.Errors.ToList(),
+                    BranchOf = id,
+                    BaseVersion = shared.Version,
+                    BaseText = shared.ModelText,
+                    BaseData = shared.DataText
+                };
+            }
+
+            lock (syncRoot)
+            {
+                var existing = models.Values.FirstOrDefault(m => m.BranchOf == id && m.Owner == copy.Owner);
+                if (existing != null)
+                    return existing;
+                models[copy.Id] = copy;
+            }
+
+            if (Policy != null)
+                Policy.Grant(new AccessGrant { Subject = $"user:{caller!.Subject}", ModelId = copy.Id, Permissions = Permission.Admin });
+
+            Logger.LogModelCreated(copy.Id, copy.Name, CountEntities(copy));
+            return copy;
+        }
+
+        /// <summary>
+        /// Working copies of a shared model the caller may read
+        /// </summary>
+        public IReadOnlyList<HostedModel> GetWorkingCopies(string id)
+        {
+            return List().Where(m => m.BranchOf == id).ToList();
+        }
+
+        /// <summary>
+        /// Merges a working copy's changes since it was branched (or last merged) into the shared
+        /// model as one new version (see ModelMerge). Refused when both changed a statement
+        /// differently, unless <paramref name="overwrite"/> lets the copy win those conflicts, and
+        /// like ApplyChangeSet when the result has new parse errors or the shared model is no
+        /// longer at <paramref name="expectedVersion"/>. Afterwards the copy holds the published text.
+        /// </summary>
+        public WorkingCopyResult Publish(string copyId, bool overwrite = false, int? expectedVersion = null)
+        {
+            var (copy, shared) = GetBranch(copyId);
+            Demand(shared.Id, Permission.Edit);
+
+            string mineText, mineData, baseText, baseData;
+            int copyVersion;
+            lock (copy.SyncRoot)
+            {
+                (mineText, mineData, baseText, baseData, copyVersion) = (copy.ModelText, copy.DataText, copy.BaseText, copy.BaseData, copy.Version);
+            }
+
+            string publishedText, publishedData;
+            int version;
+            ModelMergeResult merge;
+            lock (shared.SyncRoot)
+            {
+                if (expectedVersion != null && expectedVersion != shared.Version)
+                {
+                    return new WorkingCopyResult
+                    {
+                        Success = false,
+                        Version = shared.Version,
+                        Errors = { $"Model is at version {shared.Version}, expected {expectedVersion}" }
+                    };
+                }
+
+                merge = ModelMerge.Merge(baseText, mineText, shared.ModelText, preferMine: overwrite);
+                var (mergedData, dataConflict) = MergeData(baseData, mineData, shared.DataText, overwrite);
+                if (dataConflict)
+                    merge.Conflicts.Add("data");
+                if (merge.HasConflicts && !overwrite)
+                    return new WorkingCopyResult { Success = false, Version = shared.Version, Changed = merge.Changed, Conflicts = merge.Conflicts };
+
+                var changedKeys = ChangedKeys(shared.Source, ModelSource.Parse(merge.ModelText));
+                foreach (string key in changedKeys)
+                    Demand(shared.Id, Permission.Edit, key);
+                DemandRestricted(shared.Id, merge.ModelText, changedKeys, Permission.Edit);
+
+                var errors = ParseErrors(merge.ModelText);
+                var newErrors = errors.Except(shared.Errors).ToList();
+                if (newErrors.Count > 0)
+                    return new WorkingCopyResult { Success = false, Version = shared.Version, Changed = merge.Changed, Conflicts = merge.Conflicts, Errors = newErrors };
+
+                if (merge.ModelText != shared.ModelText || mergedData != shared.DataText)
+                    ReplaceText(shared, merge.ModelText, mergedData);
+                (publishedText, publishedData, version) = (shared.ModelText, shared.DataText, shared.Version);
+            }
+
+            lock (copy.SyncRoot)
+            {
+                (copy.BaseText, copy.BaseData, copy.BaseVersion) = (publishedText, publishedData, version);
+
+                // Edits made to the copy while publishing stay, to be published next time
+                if (copy.Version == copyVersion && (copy.ModelText != publishedText || copy.DataText != publishedData))
+                    ReplaceText(copy, publishedText, publishedData);
+            }
+
+            return new WorkingCopyResult { Success = true, Version = version, Changed = merge.Changed, Conflicts = merge.Conflicts };
+        }
+
+        /// <summary>
+        /// Merges what others published to the shared model since the copy was branched (or last
+        /// merged) into the working copy. Statements both changed keep the copy's version and are
+        /// reported as conflicts; publishing afterwards overwrites them in the shared model.
+        /// </summary>
+        public WorkingCopyResult Update(string copyId)
+        {
+            var (copy, shared) = GetBranch(copyId);
+            Demand(copy.Id, Permission.Edit);
+            Demand(shared.Id, Permission.Read);
+
+            string theirText, theirData;
+            int sharedVersion;
+            lock (shared.SyncRoot)
+            {
+                (theirText, theirData, sharedVersion) = (shared.ModelText, shared.DataText, shared.Version);
+            }
+
+            lock (copy.SyncRoot)
+            {
+                var merge = ModelMerge.Merge(copy.BaseText, copy.ModelText, theirText, preferMine: true);
+                var (mergedData, dataConflict) = MergeData(copy.BaseData, copy.DataText, theirData, preferMine: true);
+                if (dataConflict)
+                    merge.Conflicts.Add("data");
+
+                (copy.BaseText, copy.BaseData, copy.BaseVersion) = (theirText, theirData, sharedVersion);
+                if (merge.ModelText != copy.ModelText || mergedData != copy.DataText)
+                    ReplaceText(copy, merge.ModelText, mergedData);
+                return new WorkingCopyResult { Success = true, Version = copy.Version, Changed = merge.Changed, Conflicts = merge.Conflicts };
+            }
+        }
+
+        /// <summary>
+        /// Generated declarations of the model and where they came from
+        /// </summary>
+        public ModelProvenance GetProvenance(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            lock (model.SyncRoot)
+            {
+                return ModelProvenance.Parse(model.Source);
+            }
+        }
+
+        /// <summary>
+        /// Replaces the declarations previously written by a generator with its new output,
+        /// stamped with the host clock (see ModelProvenance.Regenerate), as one change set
+        /// </summary>
+        public EntityUpdateResult Regenerate(string id, string generator, IEnumerable<string> statements, string? sourceLocation = null, int? expectedVersion = null)
+        {
+            var model = Get(id);
+            ChangeSet changes;
+            try
+            {
+                lock (model.SyncRoot)
+                {
+                    changes = ModelProvenance.Regenerate(model.Source, generator, statements, sourceLocation, clock());
+                }
+            }
+            catch (InvalidOperationException ex)
+            {
+                return Failure("", model.Version, ex.Message);
+            }
+
+            return ApplyChangeSet(id, changes, expectedVersion);
+        }
+
+        /// <summary>
+        /// Collaborative sync round: merges the replica's operations (last writer wins per entity)
+        /// and returns every accepted operation after <paramref name="sinceSequence"/>. Merged edits are
+        /// never rolled back for parse errors, since all replicas must converge; errors are reported instead.
+        /// </summary>
+        public SyncResult Sync(string id, long sinceSequence, IEnumerable<EntityOperation> operations)
+        {
+            var model = Get(id);
+            var incoming = operations.ToList();
+            Demand(id, Permission.Read);
+            foreach (var operation in incoming)
+            {
+                Demand(id, Permission.Edit, operation.Key);
+                if (!operation.IsRemoval && ModelSource.GetKey(operation.Statement!) != operation.Key)
+                    throw new InvalidOperationException($"Operation statement does not declare '{operation.Key}'");
+            }
+
+            lock (model.SyncRoot)
+            {
+                bool changed = false;
+                foreach (var operation in incoming)
+                {
+                    var accepted = model.Log.Append(operation);
+                    if (accepted == null)
+                        continue;
+
+                    if (accepted.IsRemoval)
+                        model.Source.Remove(accepted.Key);
+                    else
+                        model.Source.Upsert(accepted.Statement!);
+
+                    model.BumpRevision(accepted.Key);
+                    changed = true;
+                }
+
+                if (changed)
+                {
+                    model.Errors = ParseErrors(model.ModelText);
+                    Touch(model);
+                }
+
+                return new SyncResult
+                {
+                    Operations = model.Log.GetSince(sinceSequence).ToList(),
+                    Sequence = model.Log.Sequence,
+                    Version = model.Version,
+                    Errors = model.Errors.ToList()
+                };
+            }
+        }
+
+        public void SetData(string id, string dataText)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Edit);
+            Limits.CheckFileSize(dataText, "Data text");
+            lock (model.SyncRoot)
+            {
+                model.DataText = dataText;
+                Touch(model);
+            }
+        }
+
+        /// <summary>
+        /// Re-parses the model text and returns (and stores) its parse errors
+        /// </summary>
+        public List<string> Validate(string id)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            lock (model.SyncRoot)
+            {
+                using var phase = ModelTelemetry.StartPhase("validate").SetTag("modeleditor.model_id", id);
+                var sw = Stopwatch.StartNew();
+                model.Errors = ParseErrors(model.ModelText);
+                if (model.Errors.Count > 0)
+                    ModelTelemetry.RecordFailure("parse", "errors");
+                Logger.LogModelParsed(id, CountEntities(model), model.Errors.Count, sw.Elapsed);
+                phase.SetTag("modeleditor.error_count", model.Errors.Count).Complete(model.Errors.Count == 0 ? "ok" : "error");
+                return model.Errors;
+            }
+        }
+
+        /// <summary>
+        /// Parses model and data, expands and solves on a worker thread. Progress events are
+        /// reported for each phase and every ProgressInterval while the solver runs.
+        /// Cancellation stops parsing and expansion and is passed on to the driver; drivers that
+        /// cannot stop early are abandoned. The monitor receives the driver's incumbents and
+        /// bounds, which are also reported as progress; stopping it ends the solve with the
+        /// incumbent. Registered webhooks hear about milestones and the outcome (see WebhookNotifier).
+        /// </summary>
+        public async Task<SolveResult> SolveAsync(
+            string id,
+            ISolverDriver driver,
+            IProgress<SolveProgress>? progress = null,
+            SolveMonitor? monitor = null,
+            CancellationToken cancellationToken = default)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Solve);
+            return await SolveHostedAsync(model, driver, progress, monitor, cancellationToken);
+        }
+
+        /// <summary>
+        /// Solves a published model (see PublishVersionAsync) like SolveAsync, independent of
+        /// later edits to the model it was published from. Needs Solve permission on that model.
+        /// </summary>
+        public async Task<SolveResult> SolvePublishedAsync(
+            string publicationId,
+            ISolverDriver driver,
+            IProgress<SolveProgress>? progress = null,
+            SolveMonitor? monitor = null,
+            CancellationToken cancellationToken = default)
+        {
+            var published = Publications.Find(publicationId)
+                ?? throw new InvalidOperationException($"Published model '{publicationId}' not found");
+            Demand(published.ModelId, Permission.Solve);
+
+            // Not hosted, so neither edited nor checkpointed; only the solve itself is tracked
+            var model = new HostedModel(published.ModelId, published.Name, published.ModelText, published.DataText, published.PublishedAt)
+            {
+                Version = published.Version
+            };
+            return await SolveHostedAsync(model, driver, progress, monitor, cancellationToken);
+        }
+
+        private async Task<SolveResult> SolveHostedAsync(
+            HostedModel model,
+            ISolverDriver driver,
+            IProgress<SolveProgress>? progress,
+            SolveMonitor? monitor,
+            CancellationToken cancellationToken)
+        {
+            string id = model.Id;
+            if (IsShuttingDown)
+                throw new InvalidOperationException("The server is shutting down and accepts no new solves");
+            var sw = Stopwatch.StartNew();
+            using var phase = ModelTelemetry.StartPhase("solve", ("solver", driver.Name)).SetTag("modeleditor.model_id", id);
+            Logger.LogSolveStarted(id, driver.Name);
+            monitor ??= new SolveMonitor();
+            using var milestones = Webhooks?.Track(model, driver.Name, monitor);
+            var run = new RunningSolve(model, driver.Name, monitor, clock());
+            lock (syncRoot)
+            {
+                running.Add(run);
+            }
+            ModelTelemetry.RecordActiveSolve(driver.Name, 1);
+            try
+            {
+                var result = await SolveModelAsync(model, driver, progress, monitor, sw, cancellationToken);
+                ModelTelemetry.RecordSolve(driver.Name, result.Status.ToString());
+                if (result.Status is not (SolveStatus.Optimal or SolveStatus.Feasible))
+                    ModelTelemetry.RecordFailure("solve", result.Status.ToString());
+                Webhooks?.NotifyFinished(model, driver.Name, result, sw.Elapsed);
+                phase.SetTag("modeleditor.status", result.Status.ToString());
+                phase.Complete(result.Status is SolveStatus.Optimal or SolveStatus.Feasible ? "ok" : "error", result.StatusMessage);
+                return result;
+            }
+            catch (OperationCanceledException)
+            {
+                Logger.LogSolveCancelled(id, driver.Name, sw.Elapsed);
+                phase.Complete("cancelled");
+                throw;
+            }
+            finally
+            {
+                ModelTelemetry.RecordActiveSolve(driver.Name, -1);
+                lock (syncRoot)
+                {
+                    running.Remove(run);
+                }
+                run.Finished.TrySetResult();
+            }
+        }
+
+        private async Task<SolveResult> SolveModelAsync(
+            HostedModel model,
+            ISolverDriver driver,
+            IProgress<SolveProgress>? progress,
+            SolveMonitor monitor,
+            Stopwatch sw,
+            CancellationToken cancellationToken)
+        {
+            progress?.Report(new SolveProgress { Phase = SolvePhase.Parsing, Elapsed = sw.Elapsed, Message = $"Parsing {model.Name}" });
+
+            ParseResult parseResult = null!;
+            var manager = await Task.Run(() => ExpandModel(model, out parseResult, cancellationToken), cancellationToken);
+
+            if (parseResult.HasErrors)
+            {
+                var failed = new SolveResult
+                {
+                    Status = SolveStatus.Error,
+                    StatusMessage = string.Join("; ", parseResult.Errors),
+                    SolveTime = sw.Elapsed
+                };
+                progress?.Report(new SolveProgress { Phase = SolvePhase.Failed, Elapsed = sw.Elapsed, Status = failed.Status, Message = failed.StatusMessage });
+                Logger.LogSolveCompleted(model.Id, driver.Name, failed.Status.ToString(), sw.Elapsed, 0);
+                return failed;
+            }
+
+            cancellationToken.ThrowIfCancellationRequested();
+
+            // The rows of the constraint rules exist only for this solve
+            using var rows = manager.MaterializeInPlace();
+            string? cacheKey = Cache != null ? SolveCache.KeyOf(manager, driver) : null;
+            if (cacheKey != null && Cache!.TryGet(cacheKey, out var cached))
+            {
+                progress?.Report(new SolveProgress
+                {
+                    Phase = cached.Status is SolveStatus.Optimal or SolveStatus.Feasible ? SolvePhase.Completed : SolvePhase.Failed,
+                    Elapsed = sw.Elapsed,
+                    Status = cached.Status,
+                    Message = $"{cached.StatusMessage ?? cached.Status.ToString()} (cached)",
+                    ObjectiveValue = cached.ObjectiveValue,
+                    BestBound = cached.BestBound,
+                    MipGap = cached.MipGap
+                });
+                Logger.LogSolveCompleted(model.Id, driver.Name, cached.Status.ToString(), sw.Elapsed, manager.Equations.Count);
+                return cached;
+            }
+
+            progress?.Report(new SolveProgress
+            {
+                Phase = SolvePhase.Solving,
+                Elapsed = sw.Elapsed,
+                Message = $"{driver.Name}: {manager.IndexedVariables.Count} variable families, {manager.Equations.Count} constraints"
+            });
+
+            void ReportEvent(SolverEvent solverEvent) => progress?.Report(new SolveProgress
+            {
+                Phase = SolvePhase.Solving,
+                Elapsed = sw.Elapsed,
+                Message = $"{driver.Name}: {solverEvent}",
+                ObjectiveValue = monitor.Incumbent?.ObjectiveValue,
+                BestBound = monitor.BestBound,
+                MipGap = monitor.MipGap
+            });
+
+            monitor.EventReported += ReportEvent;
+            SolveResult result;
+            try
+            {
+                var solveTask = Task.Run(() => driver.Solve(manager, monitor, cancellationToken));
+                while (!solveTask.IsCompleted)
+                {
+                    var finished = await Task.WhenAny(solveTask, Task.Delay(ProgressInterval, cancellationToken));
+                    cancellationToken.ThrowIfCancellationRequested();
+
+                    if (finished != solveTask)
+                    {
+                        progress?.Report(new SolveProgress
+                        {
+                            Phase = SolvePhase.Solving,
+                            Elapsed = sw.Elapsed,
+                            Message = $"{driver.Name} running",
+                            ObjectiveValue = monitor.Incumbent?.ObjectiveValue,
+                            BestBound = monitor.BestBound,
+                            MipGap = monitor.MipGap
+                        });
+                    }
+                }
+
+                result = await solveTask;
+            }
+            finally
+            {
+                monitor.EventReported -= ReportEvent;
+            }
+
+            // A run stopped early by the monitor is not what the same settings would produce
+            if (cacheKey != null && !monitor.IsStopRequested)
+                Cache!.Put(cacheKey, result);
+
+            bool solved = result.Status is SolveStatus.Optimal or SolveStatus.Feasible;
+            if (solved)
+                manager.Report.Evaluate(manager, result);
+
+            progress?.Report(new SolveProgress
+            {
+                Phase = solved ? SolvePhase.Completed : SolvePhase.Failed,
+                Elapsed = sw.Elapsed,
+                Status = result.Status,
+                Message = result.StatusMessage ?? result.Status.ToString(),
+                ObjectiveValue = result.ObjectiveValue,
+                BestBound = result.BestBound,
+                MipGap = result.MipGap
+            });
+
+            ModelTelemetry.RecordEntities("solve", "constraints", manager.Equations.Count);
+            Logger.LogSolveCompleted(model.Id, driver.Name, result.Status.ToString(), sw.Elapsed, manager.Equations.Count);
+            return result;
+        }
+
+        /// <summary>
+        /// Parses model and data into a fresh manager and expands all templates, without solving
+        /// </summary>
+        public ModelManager Expand(string id, out ParseResult parseResult)
+        {
+            var model = Get(id);
+            Demand(id, Permission.Read);
+            return ExpandModel(model, out parseResult);
+        }
+
+        private ModelManager ExpandModel(HostedModel model, out ParseResult parseResult, CancellationToken cancellationToken = default)
+        {
+            string modelText, dataText;
+            lock (model.SyncRoot)
+            {
+                modelText = model.ModelText;
+                dataText = model.DataText;
+            }
+
+            var manager = ExpandText(modelText, dataText, out parseResult, cancellationToken);
+            model.MemoryEstimate = ModelLimits.EstimateMemory(manager);
+            return manager;
+        }
+
+        private ModelManager ExpandText(string modelText, string dataText, out ParseResult parseResult, CancellationToken cancellationToken = default)
+        {
+            var manager = new ModelManager { Limits = Limits };
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            parseResult = service.ParseModel(new List<string> { modelText }, new List<string> { dataText }, cancellationToken);
+            return manager;
+        }
+
+        private List<string> ParseErrors(string modelText)
+        {
+            if (string.IsNullOrWhiteSpace(modelText))
+                return new List<string>();
+
+            var manager = new ModelManager { Limits = Limits };
+            var result = new EquationParser(manager).Parse(modelText);
+            return result.Errors.Select(e => e.Message).ToList();
+        }
+
+        /// <summary>
+        /// Null if the edit may proceed, otherwise the failed (precondition or conflict) result
+        /// </summary>
+        private EntityUpdateResult? CheckRevision(HostedModel model, string key, int? expectedRevision)
+        {
+            if (expectedRevision == null)
+            {
+                return RequireRevisions
+                    ? Failure(key, model.Version, $"An expected revision is required to modify '{key}'")
+                    : null;
+            }
+
+            int current = model.GetRevision(key);
+            if (current == expectedRevision)
+                return null;
+
+            var statement = model.Source.Find(key);
+            return new EntityUpdateResult
+            {
+                Key = key,
+                Success = false,
+                Conflict = true,
+                Version = model.Version,
+                Revision = current,
+                Current = statement != null ? ReadEntity(model, statement) : null,
+                Errors = { current == 0
+                    ? $"Entity '{key}' does not exist (expected revision {expectedRevision})"
+                    : $"Entity '{key}' is at revision {current}, expected {expectedRevision}" }
+            };
+        }
+
+        private static int CountEntities(HostedModel model) => model.Source.Statements.Count(s => s.Key != null);
+
+        private static EntityDefinition? ReadEntity(HostedModel model, ModelStatement statement)
+        {
+            var definition = EntityDefinition.FromStatement(statement.Text);
+            if (definition != null)
+                definition.Revision = model.GetRevision(statement.Key!);
+            return definition;
+        }
+
+        private void Touch(HostedModel model)
+        {
+            model.Version++;
+            model.LastModified = clock();
+            Persist(model);
+        }
+
+        /// <summary>
+        /// Queues a background save unless one is already waiting (called under the model's lock).
+        /// Working copies are session state and are not saved; publishing keeps their changes.
+        /// </summary>
+        private void Persist(HostedModel model)
+        {
+            var storage = Storage;
+            if (storage == null || model.SaveQueued || model.BranchOf != null)
+                return;
+
+            model.SaveQueued = true;
+            model.PendingSave = model.PendingSave.ContinueWith(_ => SaveAsync(storage, model, Logger), TaskScheduler.Default).Unwrap();
+        }
+
+        /// <summary>
+        /// Saves the model's current state. Stored versions are never replaced: when another host
+        /// sharing the storage saved the same version first, an identical version counts as saved
+        /// and a different one moves the model past the latest stored version before saving again.
+        /// </summary>
+        private static async Task SaveAsync(IModelStorage storage, HostedModel model, ILogger logger)
+        {
+            const int MaxConflicts = 3;
+            for (int conflicts = 0; ; conflicts++)
+            {
+                StoredModel snapshot;
+                lock (model.SyncRoot)
+                {
+                    model.SaveQueued = false;
+                    snapshot = new StoredModel
+                    {
+                        Id = model.Id,
+                        Name = model.Name,
+                        Version = model.Version,
+                        SavedAt = model.LastModified,
+                        Owner = model.Owner,
+                        ModelText = model.ModelText,
+                        DataText = model.DataText
+                    };
+                }
+
+                var sw = Stopwatch.StartNew();
+                try
+                {
+                    try
+                    {
+                        await storage.SaveAsync(snapshot);
+                    }
+                    catch (ModelVersionConflictException) when (conflicts < MaxConflicts)
+                    {
+                        var stored = await storage.OpenAsync(snapshot.Id, snapshot.Version);
+                        if (stored == null || stored.ModelText != snapshot.ModelText || stored.DataText != snapshot.DataText)
+                        {
+                            var versions = await storage.VersionsAsync(snapshot.Id);
+                            int next = Math.Max(snapshot.Version, versions.Count > 0 ? versions[^1].Version : 0) + 1;
+                            lock (model.SyncRoot)
+                                model.Version = Math.Max(model.Version, next);
+                            logger.LogModelVersionConflict(snapshot.Id, snapshot.Version, next);
+                            continue;
+                        }
+                    }
+                    model.LastSaveError = null;
+                    lock (model.SyncRoot)
+                        model.SavedVersion = Math.Max(model.SavedVersion, snapshot.Version);
+                    logger.LogModelSaved(snapshot.Id, snapshot.Version, sw.Elapsed);
+                }
+                catch (Exception ex)
+                {
+                    model.LastSaveError = ex.Message;
+                    ModelTelemetry.RecordFailure("save", ex.GetType().Name);
+                    logger.LogModelSaveFailed(snapshot.Id, snapshot.Version, ex);
+                }
+                return;
+            }
+        }
+
+        /// <summary>
+        /// Checks the entity restrictions of the model against the entities' tags in a model text:
+        /// the current one before an edit, the edited one after it, so an edit can neither change
+        /// a restricted declaration nor move one into a restricted block or tag
+        /// </summary>
+        private void DemandRestricted(string id, string modelText, IEnumerable<string> keys, Permission permission)
+        {
+            if (Policy == null || !Policy.HasRestrictions(id))
+                return;
+
+            var tags = new Dictionary<string, SortedSet<string>>(StringComparer.Ordinal);
+            foreach (var entity in ModelTags.Parse(modelText).Entities)
+                tags.TryAdd(entity.Key, entity.Tags);
+            foreach (string key in keys)
+                Policy.Demand(AccessContext.Current, id, permission, key, tags.GetValueOrDefault(key));
+        }
+
+        private (HostedModel Copy, HostedModel Shared) GetBranch(string copyId)
+        {
+            var copy = Get(copyId);
+            if (copy.BranchOf == null)
+                throw new InvalidOperationException($"Model '{copyId}' is not a working copy");
+            var shared = Find(copy.BranchOf) ?? throw new InvalidOperationException($"Shared model '{copy.BranchOf}' of working copy '{copyId}' no longer exists");
+            return (copy, shared);
+        }
+
+        /// <summary>
+        /// Three-way merge of data texts as a whole; true if both sides changed them differently
+        /// </summary>
+        private static (string Text, bool Conflict) MergeData(string baseData, string mine, string theirs, bool preferMine)
+        {
+            if (mine == baseData || mine == theirs)
+                return (theirs, false);
+            if (theirs == baseData)
+                return (mine, false);
+            return (preferMine ? mine : theirs, true);
+        }
+
+        /// <summary>
+        /// Replaces model and data text as one edit (called under the model's lock): entities
+        /// whose code changed get a new revision and a sync log entry, so stale clients conflict
+        /// </summary>
+        private void ReplaceText(HostedModel model, string modelText, string dataText)
+        {
+            var previous = model.Source;
+            model.Source = ModelSource.Parse(modelText);
+            model.DataText = dataText;
+            model.Errors = ParseErrors(model.ModelText);
+
+            foreach (string key in ChangedKeys(previous, model.Source))
+            {
+                model.BumpRevision(key);
+                model.Log.Record(key, model.Source.Find(key)?.Code);
+            }
+            Touch(model);
+        }
+
+        private static List<string> ChangedKeys(ModelSource previous, ModelSource next) =>
+            previous.Statements.Concat(next.Statements).Select(st => st.Key).OfType<string>().Distinct()
+                .Where(k => previous.Find(k)?.Code != next.Find(k)?.Code)
+                .ToList();
+
+        /// <summary>
+        /// Checkpoint of the model's current state (called under the model's lock)
+        /// </summary>
+        private static RecoveryCheckpoint CheckpointOf(HostedModel model, List<SolveCheckpoint> solves, bool shutdown) => new RecoveryCheckpoint
+        {
+            Id = model.Id,
+            Name = model.Name,
+            Version = model.Version,
+            SavedAt = model.LastModified,
+            Owner = model.Owner,
+            ModelText = model.ModelText,
+            DataText = model.DataText,
+            SavedVersion = model.SavedVersion,
+            Shutdown = shutdown,
+            RunningSolves = solves,
+            BranchOf = model.BranchOf,
+            BaseVersion = model.BaseVersion,
+            BaseText = model.BranchOf != null ? model.BaseText : null,
+            BaseData = model.BranchOf != null ? model.BaseData : null
+        };
+
+        /// <summary>
+        /// Without a policy anyone may; otherwise the checkpoint's owner or a caller holding the permission
+        /// </summary>
+        private bool MayRecover(RecoveryCheckpoint checkpoint, Permission permission)
+        {
+            var caller = AccessContext.Current;
+            return Policy == null
+                || caller != null && caller.Subject == checkpoint.Owner
+                || Policy.IsAllowed(caller, checkpoint.Id, permission);
+        }
+
+        private static EntityUpdateResult Failure(string key, int version, params string[] errors) => new EntityUpdateResult
+        {
+            Key = key,
+            Success = false,
+            Version = version,
+            Errors = errors.ToList()
+        };
+
+        /// <summary>
+        /// A solve in progress, for the shutdown path and checkpoints
+        /// </summary>
+        private sealed class RunningSolve
+        {
+            public RunningSolve(HostedModel model, string solver, SolveMonitor monitor, DateTime startedAt)
+            {
+                Model = model;
+                Solver = solver;
+                Monitor = monitor;
+                StartedAt = startedAt;
+            }
+
+            public HostedModel Model { get; }
+            public string Solver { get; }
+            public SolveMonitor Monitor { get; }
+            public DateTime StartedAt { get; }
+            public TaskCompletionSource Finished { get; } = new TaskCompletionSource(TaskCreationOptions.RunContinuationsAsynchronously);
+
+            public SolveCheckpoint ToCheckpoint(DateTime now)
+            {
+                var incumbent = Monitor.Incumbent;
+                return new SolveCheckpoint
+                {
+                    Solver = Solver,
+                    StartedAt = StartedAt,
+                    ElapsedSeconds = (now - StartedAt).TotalSeconds,
+                    ObjectiveValue = incumbent?.ObjectiveValue,
+                    BestBound = Monitor.BestBound,
+                    MipGap = Monitor.MipGap,
+                    Values = incumbent?.VariableValues.ToDictionary(v => v.Key, v => v.Value) ?? new Dictionary<string, double>()
+                };
+            }
+        }
+    }
+}
@@ -0,0 +1,124 @@
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Storage;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// A solve that was still running when its model was checkpointed, with the best solution it had
+    /// </summary>
+    public class SolveCheckpoint
+    {
+        public string Solver { get; init; } = "";
+        public DateTime StartedAt { get; init; }
+        public double ElapsedSeconds { get; init; }
+        public double? ObjectiveValue { get; init; }
+        public double? BestBound { get; init; }
+        public double? MipGap { get; init; }
+
+        /// <summary>
+        /// Variable values of the incumbent, e.g. to warm-start the solve again after a restart
+        /// </summary>
+        public Dictionary<string, double> Values { get; init; } = new Dictionary<string, double>();
+
+        public override string ToString() => ObjectiveValue.HasValue
+            ? $"{Solver} after {ElapsedSeconds:0.#} s, incumbent {ObjectiveValue}"
+            : $"{Solver} after {ElapsedSeconds:0.#} s, no incumbent";
+    }
+
+    /// <summary>
+    /// A model's state as written to the recovery location: the text of a version the host's
+    /// storage does not have yet, and the solves that were running on it
+    /// </summary>
+    public class RecoveryCheckpoint : StoredModel
+    {
+        /// <summary>
+        /// Version last saved to the host's storage (0 if never, e.g. without storage)
+        /// </summary>
+        public int SavedVersion { get; init; }
+
+        /// <summary>
+        /// True if written by the shutdown path, false if by the periodic autosave (a crash)
+        /// </summary>
+        public bool Shutdown { get; init; }
+
+        public List<SolveCheckpoint> RunningSolves { get; init; } = new List<SolveCheckpoint>();
+
+        /// <summary>
+        /// For a working copy: the shared model, and its version and text the copy is based on
+        /// </summary>
+        public string? BranchOf { get; init; }
+        public int BaseVersion { get; init; }
+        public string? BaseText { get; init; }
+        public string? BaseData { get; init; }
+
+        public override string ToString() =>
+            $"{Id} v{Version} ({Name}), saved v{SavedVersion}, {RunningSolves.Count} running solves";
+    }
+
+    /// <summary>
+    /// The recovery location: one checkpoint document per model with unsaved changes,
+    /// &lt;root&gt;/&lt;id&gt;.json. Documents are written then renamed, so a crash during an autosave
+    /// leaves the previous checkpoint intact.
+    /// </summary>
+    public class ModelRecovery
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            WriteIndented = true
+        };
+
+        public ModelRecovery(string directory)
+        {
+            Directory = directory;
+            System.IO.Directory.CreateDirectory(directory);
+        }
+
+        public string Directory { get; }
+
+        public async Task WriteAsync(RecoveryCheckpoint checkpoint, CancellationToken cancellationToken = default)
+        {
+            string path = PathOf(checkpoint.Id);
+            string temporary = path + ".tmp";
+            await File.WriteAllTextAsync(temporary, JsonSerializer.Serialize(checkpoint, jsonOptions), cancellationToken);
+            File.Move(temporary, path, overwrite: true);
+        }
+
+        public async Task<RecoveryCheckpoint?> ReadAsync(string id, CancellationToken cancellationToken = default)
+        {
+            string path = PathOf(id);
+            if (!File.Exists(path))
+                return null;
+            return JsonSerializer.Deserialize<RecoveryCheckpoint>(await File.ReadAllTextAsync(path, cancellationToken), jsonOptions);
+        }
+
+        /// <summary>
+        /// Every checkpoint in the location, oldest change first. Leftover temporary files of an
+        /// interrupted write are ignored.
+        /// </summary>
+        public async Task<IReadOnlyList<RecoveryCheckpoint>> ReadAllAsync(CancellationToken cancellationToken = default)
+        {
+            var result = new List<RecoveryCheckpoint>();
+            foreach (string path in System.IO.Directory.GetFiles(Directory, "*.json"))
+            {
+                var checkpoint = await ReadAsync(Path.GetFileNameWithoutExtension(path), cancellationToken);
+                if (checkpoint != null)
+                    result.Add(checkpoint);
+            }
+            return result.OrderBy(c => c.SavedAt).ThenBy(c => c.Id, StringComparer.Ordinal).ToList();
+        }
+
+        public bool Remove(string id)
+        {
+            string path = PathOf(id);
+            if (!File.Exists(path))
+                return false;
+            File.Delete(path);
+            return true;
+        }
+
+        private string PathOf(string id) => Path.Combine(Directory, StoredModelFormat.ValidateId(id) + ".json");
+    }
+}
@@ -0,0 +1,165 @@
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// A frozen, validated version of a model for production use. The id is a hash of the
+    /// model id, model text and data text, so the same content always has the same id and an
+    /// artifact can be checked against it.
+    /// </summary>
+    public class PublishedModel
+    {
+        /// <summary>
+        /// Content address: lowercase hex SHA-256 (see ContentId)
+        /// </summary>
+        public string Id { get; init; } = "";
+
+        public string ModelId { get; init; } = "";
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Version of the model that was published
+        /// </summary>
+        public int Version { get; init; }
+
+        public DateTime PublishedAt { get; init; }
+
+        /// <summary>
+        /// Subject of the user who published it, if known
+        /// </summary>
+        public string? PublishedBy { get; init; }
+
+        public string ModelText { get; init; } = "";
+        public string DataText { get; init; } = "";
+
+        /// <summary>
+        /// ModelFingerprint hash of the expanded model, equal for versions that differ in formatting only
+        /// </summary>
+        public string Fingerprint { get; init; } = "";
+
+        public static string ContentId(string modelId, string modelText, string dataText)
+        {
+            var content = Encoding.UTF8.GetBytes($"{modelId}\0{modelText}\0{dataText}");
+            return Convert.ToHexString(SHA256.HashData(content)).ToLowerInvariant();
+        }
+
+        public override string ToString() => $"{Id[..Math.Min(12, Id.Length)]} ({ModelId} v{Version})";
+    }
+
+    /// <summary>
+    /// Write-once store of published models: publishing content that is already there returns the
+    /// existing artifact, and nothing is ever replaced. With a directory every artifact is also
+    /// kept as &lt;root&gt;/&lt;id&gt;.json and found again after a restart; artifacts read back are
+    /// checked against their content address.
+    /// </summary>
+    public class PublishedModelStore
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            WriteIndented = true
+        };
+
+        private readonly Dictionary<string, PublishedModel> published = new Dictionary<string, PublishedModel>(StringComparer.Ordinal);
+        private readonly object syncRoot = new object();
+
+        /// <summary>
+        /// Keeps artifacts in memory only, for tests and servers without a publication path
+        /// </summary>
+        public PublishedModelStore()
+        {
+        }
+
+        public PublishedModelStore(string directory)
+        {
+            Directory = directory;
+            System.IO.Directory.CreateDirectory(directory);
+            foreach (string path in System.IO.Directory.GetFiles(directory, "*.json"))
+            {
+                var artifact = Read(path);
+                published[artifact.Id] = artifact;
+            }
+        }
+
+        public string? Directory { get; }
+
+        /// <summary>
+        /// Stores the artifact unless one with its id exists; returns the stored one
+        /// </summary>
+        public async Task<PublishedModel> AddAsync(PublishedModel artifact, CancellationToken cancellationToken = default)
+        {
+            if (artifact.Id != PublishedModel.ContentId(artifact.ModelId, artifact.ModelText, artifact.DataText))
+                throw new InvalidOperationException($"Published model '{artifact.Id}' does not match its content");
+
+            lock (syncRoot)
+            {
+                if (published.TryGetValue(artifact.Id, out var existing))
+                    return existing;
+            }
+
+            if (Directory != null)
+            {
+                string path = PathOf(artifact.Id);
+                string temporary = path + "." + Guid.NewGuid().ToString("N") + ".tmp";
+                await File.WriteAllTextAsync(temporary, JsonSerializer.Serialize(artifact, jsonOptions), cancellationToken);
+                try
+                {
+                    File.Move(temporary, path, overwrite: false);
+                }
+                catch (IOException) when (File.Exists(path))
+                {
+                    // Published concurrently; the content is the same
+                    File.Delete(temporary);
+                }
+            }
+
+            lock (syncRoot)
+            {
+                if (!published.TryAdd(artifact.Id, artifact))
+                    return published[artifact.Id];
+                return artifact;
+            }
+        }
+
+        public PublishedModel? Find(string id)
+        {
+            lock (syncRoot)
+            {
+                return published.GetValueOrDefault(id);
+            }
+        }
+
+        /// <summary>
+        /// Artifacts published from a model, oldest version first
+        /// </summary>
+        public IReadOnlyList<PublishedModel> List(string modelId)
+        {
+            lock (syncRoot)
+            {
+                return published.Values.Where(p => p.ModelId == modelId)
+                    .OrderBy(p => p.Version).ThenBy(p => p.PublishedAt).ToList();
+            }
+        }
+
+        private static PublishedModel Read(string path)
+        {
+            var artifact = JsonSerializer.Deserialize<PublishedModel>(File.ReadAllText(path), jsonOptions)
+                ?? throw new InvalidOperationException($"Published model document '{path}' is empty");
+            if (artifact.Id != Path.GetFileNameWithoutExtension(path) ||
+                artifact.Id != PublishedModel.ContentId(artifact.ModelId, artifact.ModelText, artifact.DataText))
+                throw new InvalidOperationException($"Published model document '{path}' does not match its content address");
+            return artifact;
+        }
+
+        private string PathOf(string id)
+        {
+            if (id.Length != 64 || !id.All(Uri.IsHexDigit))
+                throw new InvalidOperationException($"Invalid published model id '{id}'");
+            return Path.Combine(Directory!, id + ".json");
+        }
+    }
+}
@@ -0,0 +1,254 @@
+using System.Diagnostics.Metrics;
+using System.Globalization;
+using System.Text;
+using Core.Services;
+
+namespace Core.Server
+{
+    /// <summary>
+    /// Prometheus text exposition of a running server: the ModelTelemetry instruments and ASP.NET
+    /// Core's request durations, aggregated in process by a MeterListener, plus gauges read from
+    /// the host at scrape time (open models, memory per model). Instrument names are converted to
+    /// Prometheus conventions: dots become underscores, durations are in seconds, sizes in bytes
+    /// and counters end in _total, e.g. modeleditor.phase.duration (ms) becomes
+    /// modeleditor_phase_duration_seconds.
+    /// </summary>
+    public sealed class ServerMetrics : IDisposable
+    {
+        /// <summary>
+        /// Meter and histogram of ASP.NET Core's own request timing (method, route, status code)
+        /// </summary>
+        public const string HostingMeter = "Microsoft.AspNetCore.Hosting";
+        public const string RequestDuration = "http.server.request.duration";
+
+        public const string ContentType = "text/plain; version=0.0.4; charset=utf-8";
+
+        private static readonly double[] secondBuckets = { 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600 };
+        private static readonly double[] sizeBuckets = { 10, 100, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9 };
+
+        private readonly ModelHost host;
+        private readonly MeterListener listener;
+        private readonly Dictionary<Instrument, Family> families = new Dictionary<Instrument, Family>();
+        private readonly object syncRoot = new object();
+
+        public ServerMetrics(ModelHost host)
+        {
+            this.host = host;
+            listener = new MeterListener
+            {
+                InstrumentPublished = (instrument, l) =>
+                {
+                    if (instrument.Meter.Name == ModelTelemetry.Name ||
+                        instrument.Meter.Name == HostingMeter && instrument.Name == RequestDuration)
+                        l.EnableMeasurementEvents(instrument);
+                }
+            };
+            listener.SetMeasurementEventCallback<long>((instrument, value, tags, _) => Record(instrument, value, tags));
+            listener.SetMeasurementEventCallback<double>((instrument, value, tags, _) => Record(instrument, value, tags));
+            listener.Start();
+        }
+
+        /// <summary>
+        /// The current values in the Prometheus text format, families sorted by name
+        /// </summary>
+        public string Scrape()
+        {
+            var text = new StringBuilder();
+
+            var models = host.AllModels().OrderBy(m => m.Id, StringComparer.Ordinal).ToList();
+            WriteHeader(text, "modeleditor_models_open", "gauge", "Models held by the server");
+            text.Append("modeleditor_models_open ").Append(models.Count).Append('\n');
+
+            WriteHeader(text, "modeleditor_model_memory_bytes", "gauge", "Model and data text held in memory per model");
+            foreach (var model in models)
+            {
+                long chars;
+                lock (model.SyncRoot)
+                    chars = model.ModelText.Length + model.DataText.Length;
+                WriteSample(text, "modeleditor_model_memory_bytes", Label("model_id", model.Id), chars * sizeof(char));
+            }
+
+            WriteHeader(text, "modeleditor_model_expanded_bytes", "gauge", "Estimated footprint of the last expansion per model (see ModelLimits.EstimateMemory)");
+            foreach (var model in models.Where(m => m.MemoryEstimate > 0))
+                WriteSample(text, "modeleditor_model_expanded_bytes", Label("model_id", model.Id), model.MemoryEstimate);
+
+            List<Family> snapshot;
+            lock (syncRoot)
+                snapshot = families.Values.OrderBy(f => f.Name, StringComparer.Ordinal).ToList();
+            foreach (var family in snapshot)
+                family.Write(text);
+
+            return text.ToString();
+        }
+
+        public void Dispose() => listener.Dispose();
+
+        private void Record(Instrument instrument, double value, ReadOnlySpan<KeyValuePair<string, object?>> tags)
+        {
+            Family? family;
+            lock (syncRoot)
+            {
+                if (!families.TryGetValue(instrument, out family))
+                    families[instrument] = family = Family.Of(instrument);
+            }
+
+            // Label order must not depend on the order the caller passed the tags in
+            var labels = new List<string>(tags.Length);
+            foreach (var tag in tags)
+                labels.Add(Label(tag.Key, Convert.ToString(tag.Value, CultureInfo.InvariantCulture) ?? ""));
+            labels.Sort(StringComparer.Ordinal);
+
+            family.Record(string.Join(",", labels), value * family.Scale);
+        }
+
+        private static void WriteHeader(StringBuilder text, string name, string type, string help)
+        {
+            text.Append("# HELP ").Append(name).Append(' ').Append(help.Replace("\\", "\\\\").Replace("\n", "\\n")).Append('\n');
+            text.Append("# TYPE ").Append(name).Append(' ').Append(type).Append('\n');
+        }
+
+        private static void WriteSample(StringBuilder text, string name, string labels, double value)
+        {
+            text.Append(name);
+            if (labels.Length > 0)
+                text.Append('{').Append(labels).Append('}');
+            text.Append(' ').Append(FormatValue(value)).Append('\n');
+        }
+
+        private static string Label(string key, string value)
+        {
+            var escaped = value.Replace("\\", "\\\\").Replace("\"", "\\\"").Replace("\n", "\\n");
+            return $"{SanitizeName(key)}=\"{escaped}\"";
+        }
+
+        private static string SanitizeName(string name)
+        {
+            var chars = name.Select(c => char.IsAsciiLetterOrDigit(c) || c == '_' ? c : '_').ToArray();
+            return new string(chars);
+        }
+
+        private static string FormatValue(double value)
+        {
+            if (double.IsPositiveInfinity(value))
+                return "+Inf";
+            if (double.IsNegativeInfinity(value))
+                return "-Inf";
+            return double.IsNaN(value) ? "NaN" : value.ToString("R", CultureInfo.InvariantCulture);
+        }
+
+        /// <summary>
+        /// One instrument's series, keyed by their rendered label set
+        /// </summary>
+        private sealed class Family
+        {
+            private readonly Dictionary<string, Series> series = new Dictionary<string, Series>(StringComparer.Ordinal);
+
+            public string Name { get; private init; } = "";
+            public string Type { get; private init; } = "";
+            public string Help { get; private init; } = "";
+
+            /// <summary>
+            /// Factor from the instrument's unit to the exposed one (ms to seconds)
+            /// </summary>
+            public double Scale { get; private init; } = 1;
+
+            public double[] Buckets { get; private init; } = Array.Empty<double>();
+
+            public static Family Of(Instrument instrument)
+            {
+                string name = SanitizeName(instrument.Name);
+                string unit = instrument.Unit ?? "";
+                double scale = 1;
+                if (unit == "ms")
+                {
+                    unit = "s";
+                    scale = 0.001;
+                }
+
+                string type = instrument switch
+                {
+                    Counter<long> or Counter<double> => "counter",
+                    Histogram<long> or Histogram<double> => "histogram",
+                    _ => "gauge"
+                };
+
+                if (unit == "s")
+                    name += "_seconds";
+                else if (unit == "By")
+                    name += "_bytes";
+                if (type == "counter")
+                    name = (name.EndsWith("_count", StringComparison.Ordinal) ? name[..^"_count".Length] : name) + "_total";
+
+                return new Family
+                {
+                    Name = name,
+                    Type = type,
+                    Help = instrument.Description ?? instrument.Name,
+                    Scale = scale,
+                    Buckets = type != "histogram" ? Array.Empty<double>() : unit == "s" ? secondBuckets : sizeBuckets
+                };
+            }
+
+            public void Record(string labels, double value)
+            {
+                lock (series)
+                {
+                    if (!series.TryGetValue(labels, out var current))
+                        series[labels] = current = new Series(Buckets.Length);
+
+                    if (Type == "histogram")
+                    {
+                        current.Count++;
+                        current.Sum += value;
+                        int bucket = Array.FindIndex(Buckets, b => value <= b);
+                        if (bucket >= 0)
+                            current.BucketCounts[bucket]++;
+                    }
+                    else
+                    {
+                        current.Sum += value;
+                    }
+                }
+            }
+
+            public void Write(StringBuilder text)
+            {
+                WriteHeader(text, Name, Type, Help);
+                lock (series)
+                {
+                    foreach (var (labels, current) in series.OrderBy(s => s.Key, StringComparer.Ordinal))
+                    {
+                        if (Type != "histogram")
+                        {
+                            WriteSample(text, Name, labels, current.Sum);
+                            continue;
+                        }
+
+                        string prefix = labels.Length > 0 ? labels + "," : "";
+                        long cumulative = 0;
+                        for (int i = 0; i < Buckets.Length; i++)
+                        {
+                            cumulative += current.BucketCounts[i];
+                            WriteSample(text, Name + "_bucket", prefix + $"le=\"{FormatValue(Buckets[i])}\"", cumulative);
+                        }
+                        WriteSample(text, Name + "_bucket", prefix + "le=\"+Inf\"", current.Count);
+                        WriteSample(text, Name + "_sum", labels, current.Sum);
+                        WriteSample(text, Name + "_count", labels, current.Count);
+                    }
+                }
+            }
+        }
+
+        private sealed class Series
+        {
+            public Series(int buckets)
+            {
+                BucketCounts = new long[buckets];
+            }
+
+            public double Sum { get; set; }
+            public long Count { get; set; }
+            public long[] BucketCounts { get; }
+        }
+    }
+}
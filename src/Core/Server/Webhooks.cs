@@ -0,0 +1,459 @@
+using System.Globalization;
+using System.Net;
+using System.Net.Sockets;
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Services;
+using Core.Solving;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+
+namespace Core.Server
+{
+    [Flags]
+    public enum WebhookEvents
+    {
+        None = 0,
+
+        /// <summary>A solve ended with an optimal or feasible solution</summary>
+        SolveCompleted = 1,
+
+        /// <summary>A solve ended without a solution: parse errors, infeasible, solver errors</summary>
+        SolveFailed = 2,
+
+        /// <summary>A running solve found its first incumbent or closed the gap below a milestone</summary>
+        Milestone = 4,
+
+        All = SolveCompleted | SolveFailed | Milestone
+    }
+
+    /// <summary>
+    /// A registered webhook: where to post, which events, and the secret the posts are signed with
+    /// </summary>
+    public class WebhookSubscription
+    {
+        public string Id { get; init; } = Guid.NewGuid().ToString("N");
+        public Uri Url { get; init; } = null!;
+        public WebhookEvents Events { get; init; } = WebhookEvents.All;
+
+        /// <summary>
+        /// Key of the HMAC-SHA256 signature in the X-ModelEditor-Signature header; empty sends unsigned posts
+        /// </summary>
+        [JsonIgnore]
+        public string Secret { get; init; } = "";
+
+        /// <summary>
+        /// Model the webhook watches, or "*" for every model
+        /// </summary>
+        public string ModelId { get; init; } = "*";
+
+        /// <summary>
+        /// Subject of the user who registered it
+        /// </summary>
+        public string? Owner { get; init; }
+
+        public bool Matches(string modelId, WebhookEvents kind) =>
+            (Events & kind) != 0 && (ModelId == "*" || ModelId == modelId);
+
+        public override string ToString() => $"{Id} {Url} ({Events})";
+    }
+
+    /// <summary>
+    /// The JSON body of a webhook post
+    /// </summary>
+    public class WebhookPayload
+    {
+        /// <summary>
+        /// "solve.completed", "solve.failed" or "solve.milestone"
+        /// </summary>
+        public string Event { get; init; } = "";
+
+        public string ModelId { get; init; } = "";
+        public string ModelName { get; init; } = "";
+        public string Solver { get; init; } = "";
+        public DateTime Timestamp { get; internal set; }
+        public double ElapsedSeconds { get; init; }
+        public string? Status { get; init; }
+        public string? Message { get; init; }
+
+        /// <summary>
+        /// "first-incumbent" or "gap:0.01" for milestones
+        /// </summary>
+        public string? Milestone { get; init; }
+
+        public double? ObjectiveValue { get; init; }
+        public double? BestBound { get; init; }
+        public double? MipGap { get; init; }
+    }
+
+    /// <summary>
+    /// Outcome of one delivery, kept in WebhookNotifier.History
+    /// </summary>
+    public class WebhookDelivery
+    {
+        public string SubscriptionId { get; init; } = "";
+        public string Event { get; init; } = "";
+        public string Delivery { get; init; } = "";
+        public int Attempts { get; set; }
+        public HttpStatusCode? StatusCode { get; set; }
+        public bool Delivered { get; set; }
+        public string? Error { get; set; }
+
+        public override string ToString() =>
+            $"{Event} to {SubscriptionId}: {(Delivered ? "delivered" : "failed")} after {Attempts} attempt(s)" + (Error != null ? $" ({Error})" : "");
+    }
+
+    /// <summary>
+    /// Posts solve events to registered webhooks, so orchestration systems learn about finished,
+    /// failed and progressing solves without polling. Each post carries the event in
+    /// X-ModelEditor-Event, a delivery id in X-ModelEditor-Delivery and, when the subscription has
+    /// a secret, "sha256=&lt;hex&gt;" of the HMAC-SHA256 of the body in X-ModelEditor-Signature.
+    /// Connection failures, timeouts, 408, 429 and 5xx responses are retried with exponential
+    /// backoff; other responses end the delivery. Deliveries run in the background and never
+    /// delay the solve. Since anyone allowed to register a webhook makes the server post to a URL
+    /// of their choice, URLs must be on AllowedHosts when it is set, and loopback, link-local and
+    /// private addresses are refused unless AllowPrivateNetworks is set: at registration for
+    /// literal addresses, and for host names by the default client after they are resolved,
+    /// right before it connects.
+    /// </summary>
+    public class WebhookNotifier
+    {
+        public const string EventHeader = "X-ModelEditor-Event";
+        public const string DeliveryHeader = "X-ModelEditor-Delivery";
+        public const string SignatureHeader = "X-ModelEditor-Signature";
+
+        private const int HistoryLimit = 200;
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull
+        };
+
+        private readonly HttpClient http;
+        private readonly Func<DateTime> clock;
+        private readonly Dictionary<string, WebhookSubscription> subscriptions = new Dictionary<string, WebhookSubscription>(StringComparer.Ordinal);
+        private readonly List<Task> pending = new List<Task>();
+        private readonly LinkedList<WebhookDelivery> history = new LinkedList<WebhookDelivery>();
+        private readonly object syncRoot = new object();
+
+        public WebhookNotifier(HttpClient? http = null, Func<DateTime>? clock = null)
+        {
+            this.http = http ?? new HttpClient(new SocketsHttpHandler { ConnectCallback = ConnectAsync }) { Timeout = TimeSpan.FromSeconds(10) };
+            this.clock = clock ?? (() => DateTime.UtcNow);
+        }
+
+        /// <summary>
+        /// Attempts per delivery, including the first
+        /// </summary>
+        public int MaxAttempts { get; set; } = 5;
+
+        /// <summary>
+        /// Delay before the first retry; doubled for each further one
+        /// </summary>
+        public TimeSpan RetryDelay { get; set; } = TimeSpan.FromSeconds(1);
+
+        /// <summary>
+        /// Relative MIP gaps that raise a milestone the first time a running solve closes below them
+        /// </summary>
+        public List<double> GapMilestones { get; set; } = new List<double> { 0.1, 0.01 };
+
+        /// <summary>
+        /// Host names webhooks may post to, exactly or as "*.example.com" for its subdomains;
+        /// empty allows any host
+        /// </summary>
+        public List<string> AllowedHosts { get; set; } = new List<string>();
+
+        /// <summary>
+        /// Allows posts to loopback, link-local and private addresses, for receivers on the
+        /// server's own network
+        /// </summary>
+        public bool AllowPrivateNetworks { get; set; }
+
+        public ILogger Logger { get; set; } = NullLogger.Instance;
+
+        public IReadOnlyList<WebhookSubscription> Subscriptions
+        {
+            get { lock (syncRoot) return subscriptions.Values.ToList(); }
+        }
+
+        /// <summary>
+        /// The most recent deliveries, newest first
+        /// </summary>
+        public IReadOnlyList<WebhookDelivery> History
+        {
+            get { lock (syncRoot) return history.ToList(); }
+        }
+
+        public WebhookSubscription Register(WebhookSubscription subscription)
+        {
+            if (subscription.Url == null || !subscription.Url.IsAbsoluteUri || subscription.Url.Scheme is not ("http" or "https"))
+                throw new ArgumentException("A webhook needs an absolute http or https URL");
+            if (subscription.Events == WebhookEvents.None)
+                throw new ArgumentException("A webhook needs at least one event");
+
+            string host = subscription.Url.IdnHost.Trim('[', ']').TrimEnd('.').ToLowerInvariant();
+            if (AllowedHosts.Count > 0 && !AllowedHosts.Any(allowed => HostMatches(allowed, host)))
+                throw new ArgumentException($"Webhook host '{host}' is not one of the allowed hosts");
+            if (!AllowPrivateNetworks && (host == "localhost" || host.EndsWith(".localhost", StringComparison.Ordinal) ||
+                IPAddress.TryParse(host, out var address) && !IsPublic(address)))
+            {
+                throw new ArgumentException($"Webhook host '{host}' is a loopback, link-local or private address");
+            }
+
+            lock (syncRoot)
+                subscriptions[subscription.Id] = subscription;
+            return subscription;
+        }
+
+        public bool Unregister(string id)
+        {
+            lock (syncRoot)
+                return subscriptions.Remove(id);
+        }
+
+        /// <summary>
+        /// Raises SolveCompleted or SolveFailed for a finished solve
+        /// </summary>
+        public void NotifyFinished(HostedModel model, string solver, SolveResult result, TimeSpan elapsed)
+        {
+            bool solved = result.Status is SolveStatus.Optimal or SolveStatus.Feasible;
+            Notify(model, solved ? WebhookEvents.SolveCompleted : WebhookEvents.SolveFailed, new WebhookPayload
+            {
+                Event = solved ? "solve.completed" : "solve.failed",
+                ModelId = model.Id,
+                ModelName = model.Name,
+                Solver = solver,
+                ElapsedSeconds = elapsed.TotalSeconds,
+                Status = result.Status.ToString(),
+                Message = result.StatusMessage,
+                ObjectiveValue = result.ObjectiveValue,
+                BestBound = result.BestBound,
+                MipGap = result.MipGap
+            });
+        }
+
+        /// <summary>
+        /// Raises milestones from the events of a running solve until the returned handle is disposed
+        /// </summary>
+        public IDisposable Track(HostedModel model, string solver, SolveMonitor monitor)
+        {
+            bool incumbent = false;
+            var reached = new HashSet<double>();
+            var milestones = GapMilestones.OrderByDescending(g => g).ToList();
+
+            void OnEvent(SolverEvent solverEvent)
+            {
+                var raised = new List<string>();
+                lock (reached)
+                {
+                    if (solverEvent.Kind == SolverEventKind.Incumbent && !incumbent)
+                    {
+                        incumbent = true;
+                        raised.Add("first-incumbent");
+                    }
+
+                    // Only the tightest newly reached gap is reported when one event passes several
+                    double? gap = monitor.MipGap;
+                    var passed = milestones.Where(m => gap.HasValue && gap.Value <= m && reached.Add(m)).ToList();
+                    if (passed.Count > 0)
+                        raised.Add("gap:" + passed.Min().ToString(CultureInfo.InvariantCulture));
+                }
+
+                foreach (string milestone in raised)
+                {
+                    Notify(model, WebhookEvents.Milestone, new WebhookPayload
+                    {
+                        Event = "solve.milestone",
+                        ModelId = model.Id,
+                        ModelName = model.Name,
+                        Solver = solver,
+                        ElapsedSeconds = solverEvent.Elapsed.TotalSeconds,
+                        Milestone = milestone,
+                        ObjectiveValue = monitor.Incumbent?.ObjectiveValue,
+                        BestBound = monitor.BestBound,
+                        MipGap = monitor.MipGap
+                    });
+                }
+            }
+
+            monitor.EventReported += OnEvent;
+            return new Subscription(() => monitor.EventReported -= OnEvent);
+        }
+
+        /// <summary>
+        /// Waits for the deliveries in flight, e.g. before the server shuts down
+        /// </summary>
+        public Task FlushAsync()
+        {
+            lock (syncRoot)
+                return Task.WhenAll(pending.ToList());
+        }
+
+        /// <summary>
+        /// HMAC-SHA256 of a body, as sent in the signature header; receivers compare it with their own
+        /// </summary>
+        public static string Sign(string secret, string body)
+        {
+            using var hmac = new HMACSHA256(Encoding.UTF8.GetBytes(secret));
+            return "sha256=" + Convert.ToHexString(hmac.ComputeHash(Encoding.UTF8.GetBytes(body))).ToLowerInvariant();
+        }
+
+        /// <summary>
+        /// False for addresses that are not reachable from the internet: unspecified, loopback,
+        /// link-local (including cloud metadata at 169.254.169.254), private, shared, multicast
+        /// and reserved ranges
+        /// </summary>
+        public static bool IsPublic(IPAddress address)
+        {
+            if (address.IsIPv4MappedToIPv6)
+                address = address.MapToIPv4();
+
+            if (address.AddressFamily == AddressFamily.InterNetworkV6)
+            {
+                byte first = address.GetAddressBytes()[0];
+                return !address.Equals(IPAddress.IPv6Any) && !IPAddress.IsLoopback(address) &&
+                    !address.IsIPv6LinkLocal && !address.IsIPv6SiteLocal && !address.IsIPv6Multicast &&
+                    (first & 0xFE) != 0xFC;
+            }
+
+            byte[] b = address.GetAddressBytes();
+            return !(b[0] == 0 || b[0] == 10 || b[0] == 127 || b[0] >= 224 ||
+                b[0] == 100 && (b[1] & 0xC0) == 64 ||
+                b[0] == 169 && b[1] == 254 ||
+                b[0] == 172 && (b[1] & 0xF0) == 16 ||
+                b[0] == 192 && b[1] == 0 && b[2] == 0 ||
+                b[0] == 192 && b[1] == 168 ||
+                b[0] == 198 && (b[1] & 0xFE) == 18);
+        }
+
+        private static bool HostMatches(string allowed, string host)
+        {
+            allowed = allowed.Trim().TrimEnd('.').ToLowerInvariant();
+            return allowed.StartsWith("*.", StringComparison.Ordinal)
+                ? host.EndsWith(allowed.Substring(1), StringComparison.Ordinal)
+                : host == allowed;
+        }
+
+        /// <summary>
+        /// Connects the default client only to the addresses of the host that pass IsPublic, so a
+        /// name that resolves (or is rebound) to an internal address is never reached
+        /// </summary>
+        private async ValueTask<Stream> ConnectAsync(SocketsHttpConnectionContext context, CancellationToken cancellationToken)
+        {
+            var addresses = await Dns.GetHostAddressesAsync(context.DnsEndPoint.Host, cancellationToken);
+            var allowed = addresses.Where(a => AllowPrivateNetworks || IsPublic(a)).ToArray();
+            if (allowed.Length == 0)
+                throw new HttpRequestException($"Webhook host '{context.DnsEndPoint.Host}' resolves to no public address");
+
+            var socket = new Socket(SocketType.Stream, ProtocolType.Tcp) { NoDelay = true };
+            try
+            {
+                await socket.ConnectAsync(allowed, context.DnsEndPoint.Port, cancellationToken);
+                return new NetworkStream(socket, ownsSocket: true);
+            }
+            catch
+            {
+                socket.Dispose();
+                throw;
+            }
+        }
+
+        private void Notify(HostedModel model, WebhookEvents kind, WebhookPayload payload)
+        {
+            List<WebhookSubscription> targets;
+            lock (syncRoot)
+                targets = subscriptions.Values.Where(s => s.Matches(model.Id, kind)).ToList();
+
+            // Every subscription gets its own delivery id, so receivers can drop retried duplicates
+            payload.Timestamp = clock();
+            foreach (var subscription in targets)
+            {
+                string delivery = Guid.NewGuid().ToString("N");
+                lock (syncRoot)
+                {
+                    pending.RemoveAll(t => t.IsCompleted);
+                    pending.Add(Task.Run(() => DeliverAsync(subscription, payload, delivery)));
+                }
+            }
+        }
+
+        private async Task DeliverAsync(WebhookSubscription subscription, WebhookPayload payload, string id)
+        {
+            string json = JsonSerializer.Serialize(payload, jsonOptions);
+            var delivery = new WebhookDelivery { SubscriptionId = subscription.Id, Event = payload.Event, Delivery = id };
+
+            while (delivery.Attempts < MaxAttempts)
+            {
+                if (delivery.Attempts > 0)
+                    await Task.Delay(TimeSpan.FromTicks(RetryDelay.Ticks * (1L << Math.Min(delivery.Attempts - 1, 16))));
+                delivery.Attempts++;
+
+                using var request = new HttpRequestMessage(HttpMethod.Post, subscription.Url)
+                {
+                    Content = new StringContent(json, Encoding.UTF8, "application/json")
+                };
+                request.Headers.TryAddWithoutValidation(EventHeader, payload.Event);
+                request.Headers.TryAddWithoutValidation(DeliveryHeader, id);
+                if (subscription.Secret.Length > 0)
+                    request.Headers.TryAddWithoutValidation(SignatureHeader, Sign(subscription.Secret, json));
+
+                bool transient;
+                try
+                {
+                    using var response = await http.SendAsync(request);
+                    delivery.StatusCode = response.StatusCode;
+                    if (response.IsSuccessStatusCode)
+                    {
+                        delivery.Delivered = true;
+                        delivery.Error = null;
+                        break;
+                    }
+
+                    int code = (int)response.StatusCode;
+                    transient = code is 408 or 429 or >= 500;
+                    delivery.Error = $"HTTP {code}";
+                }
+                catch (Exception ex) when (ex is HttpRequestException or TaskCanceledException)
+                {
+                    transient = true;
+                    delivery.Error = ex.Message;
+                }
+
+                if (!transient)
+                    break;
+            }
+
+            if (!delivery.Delivered)
+            {
+                ModelTelemetry.RecordFailure("webhook", delivery.StatusCode?.ToString() ?? "unreachable");
+                Logger.LogWebhookFailed(subscription.Id, payload.Event, delivery.Attempts, delivery.Error ?? "");
+            }
+
+            lock (syncRoot)
+            {
+                history.AddFirst(delivery);
+                if (history.Count > HistoryLimit)
+                    history.RemoveLast();
+            }
+        }
+
+        private sealed class Subscription : IDisposable
+        {
+            private Action? dispose;
+
+            public Subscription(Action dispose)
+            {
+                this.dispose = dispose;
+            }
+
+            public void Dispose()
+            {
+                dispose?.Invoke();
+                dispose = null;
+            }
+        }
+    }
+}
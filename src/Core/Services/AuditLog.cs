@@ -0,0 +1,189 @@
+using System;
+using System.Collections.Generic;
+using System.IO;
+using System.Linq;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using System.Threading;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// Kinds of model mutations recorded in the audit log
+    /// </summary>
+    public enum AuditOperation
+    {
+        Add,
+        Update,
+        Remove,
+        Expand,
+        Clear
+    }
+
+    /// <summary>
+    /// One immutable audit log record
+    /// </summary>
+    public class AuditEntry
+    {
+        public long Sequence { get; init; }
+        public DateTime Timestamp { get; init; }
+        public string Author { get; init; } = "";
+        public AuditOperation Operation { get; init; }
+        public List<string> Entities { get; init; } = new List<string>();
+        public string? Details { get; init; }
+
+        public override string ToString()
+        {
+            return $"{Timestamp:yyyy-MM-dd HH:mm:ss} {Author} {Operation} {string.Join(", ", Entities)}" +
+                   (Details != null ? $" ({Details})" : "");
+        }
+    }
+
+    /// <summary>
+    /// Ambient author for audit records. Flows with async calls, so a request handler or
+    /// UI command can set it once and every mutation underneath is attributed to it.
+    /// </summary>
+    public static class AuditContext
+    {
+        private static readonly AsyncLocal<string?> currentAuthor = new AsyncLocal<string?>();
+
+        /// <summary>
+        /// Author of the current scope, or the OS user name when none is set
+        /// </summary>
+        public static string CurrentAuthor => currentAuthor.Value ?? Environment.UserName;
+
+        /// <summary>
+        /// Sets the author until the returned scope is disposed
+        /// </summary>
+        public static IDisposable BeginScope(string author)
+        {
+            var previous = currentAuthor.Value;
+            currentAuthor.Value = author;
+            return new Scope(() => currentAuthor.Value = previous);
+        }
+
+        private sealed class Scope : IDisposable
+        {
+            private Action? restore;
+            public Scope(Action restore) { this.restore = restore; }
+
+            public void Dispose()
+            {
+                restore?.Invoke();
+                restore = null;
+            }
+        }
+    }
+
+    /// <summary>
+    /// Append-only log of model mutations. When a file path is given every entry is
+    /// appended to it immediately as one JSON line; existing entries are never rewritten.
+    /// Only mutations made through ModelManager's methods are recorded, not direct edits
+    /// of its collections.
+    /// </summary>
+    public class AuditLog
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            Converters = { new JsonStringEnumConverter() }
+        };
+
+        private readonly List<AuditEntry> entries = new List<AuditEntry>();
+        private readonly object sync = new object();
+        private readonly Func<DateTime> clock;
+
+        /// <summary>
+        /// File the log is persisted to, or null for an in-memory log
+        /// </summary>
+        public string? FilePath { get; }
+
+        public IReadOnlyList<AuditEntry> Entries
+        {
+            get { lock (sync) return entries.ToList(); }
+        }
+
+        public AuditLog(string? filePath = null, Func<DateTime>? clock = null)
+        {
+            FilePath = filePath;
+            this.clock = clock ?? (() => DateTime.UtcNow);
+
+            if (filePath != null && File.Exists(filePath))
+            {
+                foreach (var line in File.ReadLines(filePath))
+                {
+                    if (string.IsNullOrWhiteSpace(line))
+                        continue;
+
+                    var entry = JsonSerializer.Deserialize<AuditEntry>(line, jsonOptions)
+                        ?? throw new InvalidOperationException($"Invalid audit log entry in '{filePath}'");
+                    entries.Add(entry);
+                }
+            }
+        }
+
+        /// <summary>
+        /// Opens the audit log stored next to a model file (model.mod → model.mod.audit.jsonl)
+        /// </summary>
+        public static AuditLog ForModel(string modelFilePath)
+        {
+            return new AuditLog(GetPathForModel(modelFilePath));
+        }
+
+        public static string GetPathForModel(string modelFilePath) => modelFilePath + ".audit.jsonl";
+
+        /// <summary>
+        /// Records a mutation attributed to the current AuditContext author
+        /// </summary>
+        public AuditEntry Record(AuditOperation operation, IEnumerable<string> entities, string? details = null)
+        {
+            lock (sync)
+            {
+                var entry = new AuditEntry
+                {
+                    Sequence = entries.Count == 0 ? 1 : entries[^1].Sequence + 1,
+                    Timestamp = clock(),
+                    Author = AuditContext.CurrentAuthor,
+                    Operation = operation,
+                    Entities = entities.ToList(),
+                    Details = details
+                };
+
+                if (FilePath != null)
+                {
+                    File.AppendAllText(FilePath, JsonSerializer.Serialize(entry, jsonOptions) + Environment.NewLine);
+                }
+
+                entries.Add(entry);
+                return entry;
+            }
+        }
+
+        public AuditEntry Record(AuditOperation operation, string entity, string? details = null)
+        {
+            return Record(operation, new[] { entity }, details);
+        }
+
+        /// <summary>
+        /// Returns entries matching all given criteria, oldest first
+        /// </summary>
+        public IEnumerable<AuditEntry> Query(
+            string? author = null,
+            AuditOperation? operation = null,
+            string? entity = null,
+            DateTime? from = null,
+            DateTime? to = null)
+        {
+            return Entries.Where(e =>
+                (author == null || e.Author == author) &&
+                (operation == null || e.Operation == operation) &&
+                (entity == null || e.Entities.Contains(entity)) &&
+                (from == null || e.Timestamp >= from) &&
+                (to == null || e.Timestamp <= to));
+        }
+
+        /// <summary>
+        /// Full history of a single entity (e.g. "parameter:cost")
+        /// </summary>
+        public IEnumerable<AuditEntry> GetHistory(string entity) => Query(entity: entity);
+    }
+}
@@ -0,0 +1,355 @@
+using System.Globalization;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using System.Text.RegularExpressions;
+using Core.Solving;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// A named case: the base model and data with an overlay on top (see CaseOverlay)
+    /// </summary>
+    public class CaseDefinition
+    {
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Overlay file relative to the case directory, or null for the base case
+        /// </summary>
+        public string? Overlay { get; init; }
+
+        public string? Description { get; init; }
+
+        public override string ToString() => Overlay == null ? $"{Name} (base)" : $"{Name} ({Overlay})";
+    }
+
+    /// <summary>
+    /// The outcome of running one case, as archived
+    /// </summary>
+    public class CaseRun
+    {
+        public string Case { get; init; } = "";
+        public DateTime StartedAt { get; init; }
+        public string Solver { get; init; } = "";
+        public SolveResult Result { get; init; } = new SolveResult();
+
+        /// <summary>
+        /// Load errors (unresolved overlay keys, data errors); the case was not solved if there are any
+        /// </summary>
+        public List<string> Errors { get; init; } = new List<string>();
+
+        /// <summary>
+        /// File the run was archived to, relative to the case directory
+        /// </summary>
+        [JsonIgnore]
+        public string? ArchivePath { get; internal set; }
+
+        public override string ToString() =>
+            $"{Case}: {Result.Status}" + (Result.ObjectiveValue.HasValue ? $" {Result.ObjectiveValue.Value.ToString("G6", CultureInfo.InvariantCulture)}" : "");
+    }
+
+    /// <summary>
+    /// Runs of several cases side by side: objective, status, the model's KPIs and the totals of
+    /// the variable families (or chosen variables) per case, with the objective change against the
+    /// first case
+    /// </summary>
+    public class CaseComparison
+    {
+        public List<CaseRun> Runs { get; } = new List<CaseRun>();
+
+        /// <summary>
+        /// Variables shown as columns; when empty, one column per variable family with its total
+        /// </summary>
+        public List<string> Variables { get; } = new List<string>();
+
+        public CaseComparison(IEnumerable<CaseRun> runs)
+        {
+            Runs.AddRange(runs);
+        }
+
+        public string ToTable()
+        {
+            var rows = Rows();
+            var widths = rows[0].Select((_, i) => rows.Max(r => r[i].Length)).ToArray();
+            var sb = new StringBuilder();
+            foreach (var row in rows)
+            {
+                // Case and status left-aligned, numbers right-aligned
+                var cells = row.Select((cell, i) => i < 2 ? cell.PadRight(widths[i]) : cell.PadLeft(widths[i]));
+                sb.AppendLine(string.Join("  ", cells).TrimEnd());
+            }
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// The table as comma-separated values, for spreadsheets
+        /// </summary>
+        public string ToCsv()
+        {
+            var sb = new StringBuilder();
+            foreach (var row in Rows())
+                sb.AppendLine(string.Join(",", row.Select(c => c.Contains(',') || c.Contains('"') ? $"\"{c.Replace("\"", "\"\"")}\"" : c)));
+            return sb.ToString();
+        }
+
+        private List<string[]> Rows()
+        {
+            var columns = Variables.Count > 0
+                ? Variables.ToList()
+                : Runs.SelectMany(r => r.Result.VariableValues.Keys).Select(SolutionComparison.GetFamily).Distinct().OrderBy(f => f, StringComparer.Ordinal).ToList();
+
+            var kpis = Runs.SelectMany(r => r.Result.Kpis.Keys).Distinct().ToList();
+            double? reference = Runs.FirstOrDefault()?.Result.ObjectiveValue;
+            var rows = new List<string[]>
+            {
+                new[] { "Case", "Status", "Objective", "Change", "Time (s)" }.Concat(kpis).Concat(columns).ToArray()
+            };
+            foreach (var run in Runs)
+            {
+                var result = run.Result;
+                double? objective = result.ObjectiveValue;
+                var values = columns.Select(c => Variables.Count > 0
+                    ? result.VariableValues.TryGetValue(c, out double v) ? Format(v) : ""
+                    : result.VariableValues.Any(p => SolutionComparison.GetFamily(p.Key) == c)
+                        ? Format(result.VariableValues.Where(p => SolutionComparison.GetFamily(p.Key) == c).Sum(p => p.Value))
+                        : "");
+                rows.Add(new[]
+                {
+                    run.Case,
+                    result.Status.ToString(),
+                    objective.HasValue ? Format(objective.Value) : "",
+                    objective.HasValue && reference.HasValue && run != Runs[0] ? Change(objective.Value, reference.Value) : "",
+                    result.SolveTime.TotalSeconds.ToString("0.00", CultureInfo.InvariantCulture)
+                }.Concat(kpis.Select(k => result.Kpis.TryGetValue(k, out double v) ? Format(v) : "")).Concat(values).ToArray());
+            }
+            return rows;
+        }
+
+        private static string Change(double value, double reference)
+        {
+            string delta = (value - reference).ToString("+0.######;-0.######;0", CultureInfo.InvariantCulture);
+            return Math.Abs(reference) < 1e-12 ? delta : $"{delta} ({(value - reference) / Math.Abs(reference):+0.0%;-0.0%;0.0%})";
+        }
+
+        private static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+    }
+
+    /// <summary>
+    /// Named cases of one model, kept in a case directory: the registry (cases.json) lists the base
+    /// model and data files and the overlay of each case, paths relative to the directory. Cases
+    /// run in parallel, each on its own ModelManager, and every run is archived under
+    /// archive/&lt;case&gt;/ so earlier results stay available for comparison.
+    /// </summary>
+    public class CaseManager
+    {
+        public const string FileName = "cases.json";
+        public const string ArchiveDirectory = "archive";
+
+        private static readonly Regex namePattern = new Regex(@"^[A-Za-z0-9_][A-Za-z0-9_.-]*$");
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            WriteIndented = true,
+            Converters = { new JsonStringEnumConverter() }
+        };
+
+        private class Registry
+        {
+            public List<string> ModelFiles { get; init; } = new List<string>();
+            public List<string> DataFiles { get; init; } = new List<string>();
+            public List<CaseDefinition> Cases { get; init; } = new List<CaseDefinition>();
+        }
+
+        private readonly List<CaseDefinition> cases = new List<CaseDefinition>();
+
+        public string Root { get; }
+
+        public List<string> ModelFiles { get; } = new List<string>();
+        public List<string> DataFiles { get; } = new List<string>();
+
+        public IReadOnlyList<CaseDefinition> Cases => cases;
+
+        /// <summary>
+        /// Cases solved at the same time; each holds a model in memory
+        /// </summary>
+        public int MaxParallelism { get; set; } = Environment.ProcessorCount;
+
+        private CaseManager(string directory)
+        {
+            Root = directory;
+        }
+
+        /// <summary>
+        /// Opens the case directory, reading its registry if there is one
+        /// </summary>
+        public static CaseManager Open(string directory)
+        {
+            var manager = new CaseManager(directory);
+            string path = Path.Combine(directory, FileName);
+            if (File.Exists(path))
+            {
+                var registry = JsonSerializer.Deserialize<Registry>(File.ReadAllBytes(path), jsonOptions)
+                    ?? throw new InvalidOperationException($"{path} is empty");
+                manager.ModelFiles.AddRange(registry.ModelFiles);
+                manager.DataFiles.AddRange(registry.DataFiles);
+                manager.cases.AddRange(registry.Cases);
+            }
+            return manager;
+        }
+
+        public void Save()
+        {
+            Directory.CreateDirectory(Root);
+            var registry = new Registry { ModelFiles = ModelFiles, DataFiles = DataFiles, Cases = cases };
+            File.WriteAllBytes(Path.Combine(Root, FileName), JsonSerializer.SerializeToUtf8Bytes(registry, jsonOptions));
+        }
+
+        /// <summary>
+        /// Adds a case; without an overlay it is the base model and data as they are
+        /// </summary>
+        public CaseDefinition Register(string name, string? overlay = null, string? description = null)
+        {
+            if (!namePattern.IsMatch(name))
+                throw new InvalidOperationException($"Case name '{name}' may only contain letters, digits, '_', '-' and '.'");
+            if (Find(name) != null)
+                throw new InvalidOperationException($"Case '{name}' is already registered");
+            if (overlay != null && !File.Exists(Path.Combine(Root, overlay)))
+                throw new InvalidOperationException($"Overlay file not found: {overlay}");
+
+            var definition = new CaseDefinition { Name = name, Overlay = overlay, Description = description };
+            cases.Add(definition);
+            return definition;
+        }
+
+        public bool Remove(string name) => cases.RemoveAll(c => c.Name == name) > 0;
+
+        public CaseDefinition? Find(string name) => cases.FirstOrDefault(c => c.Name == name);
+
+        /// <summary>
+        /// Loads and solves the named cases (all by default) with the driver, at most MaxParallelism
+        /// at a time, and archives each run. A case whose overlay or data do not load is reported
+        /// with its errors and not solved. Runs are returned in registry order.
+        /// </summary>
+        public async Task<List<CaseRun>> RunAsync(
+            ISolverDriver driver,
+            IEnumerable<string>? names = null,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var selected = names == null
+                ? cases.ToList()
+                : names.Select(n => Find(n) ?? throw new InvalidOperationException($"Unknown case '{n}'")).Distinct().ToList();
+            if (ModelFiles.Count == 0)
+                throw new InvalidOperationException("The case directory has no model files");
+
+            var modelTexts = ModelFiles.Select(f => File.ReadAllText(Path.Combine(Root, f))).ToList();
+            var dataTexts = DataFiles.Select(f => File.ReadAllText(Path.Combine(Root, f))).ToList();
+
+            var tracker = new ProgressTracker("cases", progress, cancellationToken);
+            tracker.Stage("solve", selected.Count);
+            int completed = 0;
+            var runs = new CaseRun[selected.Count];
+            var options = new ParallelOptions { MaxDegreeOfParallelism = Math.Max(1, MaxParallelism), CancellationToken = cancellationToken };
+            await Parallel.ForEachAsync(Enumerable.Range(0, selected.Count), options, (i, ct) =>
+            {
+                runs[i] = Run(selected[i], driver, modelTexts, dataTexts, ct);
+                Archive(runs[i]);
+                lock (tracker)
+                    tracker.Tick(++completed, selected.Count, selected[i].Name);
+                return ValueTask.CompletedTask;
+            });
+            return runs.ToList();
+        }
+
+        /// <summary>
+        /// Archived runs of a case, oldest first
+        /// </summary>
+        public List<CaseRun> History(string name)
+        {
+            string directory = Path.Combine(Root, ArchiveDirectory, name);
+            if (!Directory.Exists(directory))
+                return new List<CaseRun>();
+
+            return Directory.GetFiles(directory, "*.json")
+                .OrderBy(f => f, StringComparer.Ordinal)
+                .Select(f =>
+                {
+                    var run = JsonSerializer.Deserialize<CaseRun>(File.ReadAllBytes(f), jsonOptions)!;
+                    run.ArchivePath = Path.GetRelativePath(Root, f);
+                    return run;
+                })
+                .ToList();
+        }
+
+        /// <summary>
+        /// Comparison of the latest archived run of each case (all by default) in registry order
+        /// </summary>
+        public CaseComparison Compare(IEnumerable<string>? names = null)
+        {
+            var selected = names?.ToHashSet(StringComparer.Ordinal);
+            return new CaseComparison(cases
+                .Where(c => selected == null || selected.Contains(c.Name))
+                .Select(c => History(c.Name).LastOrDefault())
+                .OfType<CaseRun>());
+        }
+
+        private CaseRun Run(CaseDefinition definition, ISolverDriver driver, List<string> modelTexts, List<string> dataTexts, CancellationToken cancellationToken)
+        {
+            var startedAt = DateTime.UtcNow;
+            var manager = new ModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            if (definition.Overlay != null)
+            {
+                string path = Path.Combine(Root, definition.Overlay);
+                service.Overlays.Add(CaseOverlay.Parse(File.ReadAllText(path), definition.Name));
+            }
+
+            var parsed = service.ParseModel(modelTexts, dataTexts, cancellationToken);
+            if (parsed.Errors.Count > 0)
+            {
+                return new CaseRun
+                {
+                    Case = definition.Name,
+                    StartedAt = startedAt,
+                    Solver = driver.Name,
+                    Errors = parsed.Errors,
+                    Result = new SolveResult { Status = SolveStatus.Error, StatusMessage = $"{parsed.Errors.Count} load errors" }
+                };
+            }
+
+            SolveResult result;
+            try
+            {
+                result = driver.Solve(manager, cancellationToken);
+                if (result.Status is SolveStatus.Optimal or SolveStatus.Feasible)
+                    manager.Report.Evaluate(manager, result);
+            }
+            catch (Exception ex) when (ex is not OperationCanceledException)
+            {
+                result = new SolveResult { Status = SolveStatus.Error, StatusMessage = ex.Message };
+            }
+            return new CaseRun { Case = definition.Name, StartedAt = startedAt, Solver = driver.Name, Result = result };
+        }
+
+        private void Archive(CaseRun run)
+        {
+            string directory = Path.Combine(Root, ArchiveDirectory, run.Case);
+            Directory.CreateDirectory(directory);
+
+            // Timestamps sort in run order; a run in the same millisecond gets a counter that sorts after it
+            string stamp = run.StartedAt.ToString("yyyyMMdd'T'HHmmssfff'Z'", CultureInfo.InvariantCulture);
+            string path = Path.Combine(directory, stamp + ".json");
+            for (int n = 2; File.Exists(path); n++)
+                path = Path.Combine(directory, $"{stamp}_{n}.json");
+
+            File.WriteAllBytes(path, JsonSerializer.SerializeToUtf8Bytes(run, jsonOptions));
+            run.ArchivePath = Path.GetRelativePath(Root, path);
+        }
+    }
+}
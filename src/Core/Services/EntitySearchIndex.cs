@@ -0,0 +1,300 @@
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// Where in a declaration a search term was found
+    /// </summary>
+    public enum SearchField
+    {
+        Name,
+        Tag,
+        Annotation,
+        Docstring
+    }
+
+    /// <summary>
+    /// One occurrence of a search term, for highlighting
+    /// </summary>
+    public class SearchMatch
+    {
+        public SearchField Field { get; init; }
+
+        /// <summary>
+        /// The word as written in the model text
+        /// </summary>
+        public string Text { get; init; } = "";
+
+        /// <summary>
+        /// Annotation name ("unit", "owner") for a match in an annotation value
+        /// </summary>
+        public string? Annotation { get; init; }
+
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// 1-based column of the first character
+        /// </summary>
+        public int Column { get; init; }
+
+        public int Length { get; init; }
+
+        public override string ToString() => $"{Field.ToString().ToLowerInvariant()} '{Text}' at {LineNumber}:{Column}";
+    }
+
+    /// <summary>
+    /// A declaration matching every term of a search, best results first
+    /// </summary>
+    public class SearchResult
+    {
+        public string Document { get; init; } = "";
+
+        /// <summary>
+        /// Entity key, e.g. "parameter:rampRate" or "constraint:cap"
+        /// </summary>
+        public string Key { get; init; } = "";
+
+        public string Name { get; init; } = "";
+
+        /// <summary>
+        /// Line of the declaration
+        /// </summary>
+        public int LineNumber { get; init; }
+
+        public double Score { get; init; }
+
+        public List<SearchMatch> Matches { get; } = new List<SearchMatch>();
+
+        public override string ToString() => $"{Document}:{LineNumber} {Key} ({Score:0.##})";
+    }
+
+    /// <summary>
+    /// Inverted index over the declarations of one model text: the words of entity names,
+    /// docstrings, "// @tags" and the values of other "// @name value" annotations, with their
+    /// positions. Words are lowercased and identifiers are also split at camel case and
+    /// underscores, so "rampRate_StationA" is found by "ramp", "rate", "stationa" and the whole
+    /// name. A query word matches a word equal to it or, with half the weight, a word starting
+    /// with it; names weigh most, then tags, annotations and docstrings.
+    /// </summary>
+    public class EntitySearchIndex
+    {
+        private static readonly Regex wordPattern = new Regex(@"[A-Za-z0-9]+");
+        private static readonly Regex partPattern = new Regex(@"[A-Z]+(?![a-z])|[A-Z]?[a-z]+|[0-9]+");
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@(\w+)[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+
+        private const double PrefixWeight = 0.5;
+
+        private readonly string document;
+        private readonly Dictionary<string, List<Posting>> postings = new Dictionary<string, List<Posting>>(StringComparer.Ordinal);
+        private readonly Dictionary<string, (string Name, int LineNumber)> declarations = new Dictionary<string, (string, int)>(StringComparer.Ordinal);
+        private string[] terms = Array.Empty<string>();
+        private int[] lineStarts = Array.Empty<int>();
+
+        private EntitySearchIndex(string document)
+        {
+            this.document = document;
+        }
+
+        /// <summary>
+        /// Number of indexed words
+        /// </summary>
+        public int TermCount => terms.Length;
+
+        public static EntitySearchIndex Build(string documentName, string modelText)
+        {
+            var index = new EntitySearchIndex(documentName);
+            index.lineStarts = new[] { 0 }.Concat(modelText.Select((c, i) => (c, i)).Where(p => p.c == '\n').Select(p => p.i + 1)).ToArray();
+
+            var source = ModelSource.Parse(modelText);
+            ModelStatement? previous = null;
+            int offset = 0;
+
+            foreach (var statement in source.Statements)
+            {
+                int triviaLength = statement.Text.Length - statement.Code.Length;
+                index.AddTrivia(modelText, offset, triviaLength, statement.Key, previous?.Key);
+
+                if (statement.Key != null)
+                {
+                    int codeStart = offset + triviaLength;
+                    string name = NameOf(statement.Key);
+                    var declared = Regex.Match(statement.Code, $@"(?<!\w){Regex.Escape(name)}(?!\w)");
+                    int nameStart = declared.Success ? codeStart + declared.Index : codeStart;
+
+                    index.declarations.TryAdd(statement.Key, (name, index.LineOf(codeStart)));
+                    if (declared.Success)
+                        index.AddWords(modelText, nameStart, name.Length, statement.Key, SearchField.Name, null);
+                }
+
+                offset += statement.Text.Length;
+                previous = statement;
+            }
+
+            index.AddTrivia(modelText, offset, modelText.Length - offset, null, previous?.Key);
+            index.terms = index.postings.Keys.OrderBy(t => t, StringComparer.Ordinal).ToArray();
+            return index;
+        }
+
+        /// <summary>
+        /// Declarations matching every word of the query, unordered; the score of a declaration
+        /// adds up the best match of each word
+        /// </summary>
+        public IEnumerable<SearchResult> Search(string query)
+        {
+            var words = wordPattern.Matches(query).Select(m => m.Value.ToLowerInvariant()).Distinct().ToList();
+            if (words.Count == 0)
+                yield break;
+
+            Dictionary<string, (double Score, List<(Posting Posting, double Weight)> Hits)>? found = null;
+            foreach (string word in words)
+            {
+                var byEntity = new Dictionary<string, (double Score, List<(Posting, double)> Hits)>(StringComparer.Ordinal);
+                foreach (var (term, weight) in Lookup(word))
+                {
+                    foreach (var posting in postings[term])
+                    {
+                        double score = weight * FieldWeight(posting.Field);
+                        if (!byEntity.TryGetValue(posting.Key, out var current))
+                            current = (0, new List<(Posting, double)>());
+                        current.Hits.Add((posting, weight));
+                        byEntity[posting.Key] = (Math.Max(current.Score, score), current.Hits);
+                    }
+                }
+
+                found = found == null
+                    ? byEntity
+                    : found.Where(f => byEntity.ContainsKey(f.Key))
+                        .ToDictionary(f => f.Key, f => (f.Value.Score + byEntity[f.Key].Score, f.Value.Hits.Concat(byEntity[f.Key].Hits).ToList()), StringComparer.Ordinal);
+            }
+
+            foreach (var (key, (score, hits)) in found!)
+            {
+                var (name, line) = declarations[key];
+                var result = new SearchResult { Document = document, Key = key, Name = name, LineNumber = line, Score = score };
+                // Where a word and one of its parts both match, highlight the better match
+                var best = hits
+                    .GroupBy(h => (h.Posting.LineNumber, h.Posting.Column))
+                    .Select(g => g.OrderByDescending(h => h.Weight).ThenBy(h => h.Posting.Text.Length).First().Posting)
+                    .Distinct();
+                foreach (var hit in best.OrderBy(h => h.LineNumber).ThenBy(h => h.Column))
+                {
+                    result.Matches.Add(new SearchMatch
+                    {
+                        Field = hit.Field,
+                        Text = hit.Text,
+                        Annotation = hit.Annotation,
+                        LineNumber = hit.LineNumber,
+                        Column = hit.Column,
+                        Length = hit.Text.Length
+                    });
+                }
+                yield return result;
+            }
+        }
+
+        private static IEnumerable<(string Term, int Offset, int Length)> Terms(string word)
+        {
+            yield return (word.ToLowerInvariant(), 0, word.Length);
+
+            var parts = partPattern.Matches(word);
+            if (parts.Count < 2)
+                yield break;
+
+            foreach (Match part in parts)
+                yield return (part.Value.ToLowerInvariant(), part.Index, part.Length);
+        }
+
+        private IEnumerable<(string Term, double Weight)> Lookup(string word)
+        {
+            int first = Array.BinarySearch(terms, word, StringComparer.Ordinal);
+            if (first >= 0)
+                yield return (word, 1);
+
+            for (int i = first >= 0 ? first + 1 : ~first; i < terms.Length && terms[i].StartsWith(word, StringComparison.Ordinal); i++)
+                yield return (terms[i], PrefixWeight);
+        }
+
+        private static double FieldWeight(SearchField field) => field switch
+        {
+            SearchField.Name => 4,
+            SearchField.Tag => 2,
+            SearchField.Annotation => 1.5,
+            _ => 1
+        };
+
+        /// <summary>
+        /// Indexes the docstrings and annotations in the trivia before a statement. A one-line
+        /// docstring on the first line belongs to the previous statement, as in Docstrings.
+        /// </summary>
+        private void AddTrivia(string text, int start, int length, string? key, string? previousKey)
+        {
+            string trivia = text.Substring(start, length);
+            int firstLineBreak = trivia.IndexOf('\n');
+
+            for (int i = 0; i < trivia.Length; i++)
+            {
+                int docstring = Docstrings.Length(trivia, i);
+                if (docstring > 0)
+                {
+                    bool trailing = previousKey != null && (firstLineBreak < 0 || i < firstLineBreak) && trivia.IndexOf('\n', i, docstring) < 0;
+                    string? owner = trailing ? previousKey : key;
+                    if (owner != null)
+                        AddWords(text, start + i, docstring, owner, SearchField.Docstring, null);
+                    i += docstring - 1;
+                }
+                else if (string.CompareOrdinal(trivia, i, "//", 0, 2) == 0)
+                {
+                    int end = trivia.IndexOf('\n', i);
+                    i = (end < 0 ? trivia.Length : end) - 1;
+                }
+            }
+
+            if (key == null)
+                return;
+
+            foreach (Match m in annotationPattern.Matches(trivia))
+            {
+                string annotation = m.Groups[1].Value;
+                if (annotation is "block" or "endblock")
+                    continue;
+
+                var value = m.Groups[2];
+                AddWords(text, start + value.Index, value.Length, key,
+                    annotation == "tags" ? SearchField.Tag : SearchField.Annotation,
+                    annotation == "tags" ? null : annotation);
+            }
+        }
+
+        private void AddWords(string text, int start, int length, string key, SearchField field, string? annotation)
+        {
+            foreach (Match word in wordPattern.Matches(text.Substring(start, length)))
+            {
+                foreach (var (term, offset, termLength) in Terms(word.Value))
+                {
+                    int position = start + word.Index + offset;
+                    int line = LineOf(position);
+                    var posting = new Posting(key, field, annotation, word.Value.Substring(offset, termLength), line, position - lineStarts[line - 1] + 1);
+
+                    if (!postings.TryGetValue(term, out var list))
+                        postings[term] = list = new List<Posting>();
+                    list.Add(posting);
+                }
+            }
+        }
+
+        private int LineOf(int position)
+        {
+            int line = Array.BinarySearch(lineStarts, position);
+            return line >= 0 ? line + 1 : ~line;
+        }
+
+        private static string NameOf(string key)
+        {
+            int colon = key.IndexOf(':');
+            return colon >= 0 ? key.Substring(colon + 1) : key;
+        }
+
+        private record Posting(string Key, SearchField Field, string? Annotation, string Text, int LineNumber, int Column);
+    }
+}
@@ -0,0 +1,156 @@
+using Microsoft.Extensions.Logging;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// Structured log events of the editor and server. Event names and field names
+    /// (model_id, entity_count, duration_ms, ...) are stable so dashboards and alerts can rely
+    /// on them; change them only together with the observability configuration.
+    /// </summary>
+    public static class ModelLog
+    {
+        public static readonly EventId ModelCreated = new EventId(1000, "model_created");
+        public static readonly EventId ModelDeleted = new EventId(1001, "model_deleted");
+        public static readonly EventId ModelParsed = new EventId(1002, "model_parsed");
+        public static readonly EventId ModelSaved = new EventId(1010, "model_saved");
+        public static readonly EventId ModelSaveFailed = new EventId(1011, "model_save_failed");
+        public static readonly EventId ModelsRestored = new EventId(1012, "models_restored");
+        public static readonly EventId ModelsCheckpointed = new EventId(1013, "models_checkpointed");
+        public static readonly EventId CheckpointFailed = new EventId(1014, "checkpoint_failed");
+        public static readonly EventId ModelPublished = new EventId(1015, "model_published");
+        public static readonly EventId ModelVersionConflict = new EventId(1016, "model_version_conflict");
+        public static readonly EventId EntitiesUploaded = new EventId(1020, "entities_uploaded");
+        public static readonly EventId SolveStarted = new EventId(1030, "solve_started");
+        public static readonly EventId SolveCompleted = new EventId(1031, "solve_completed");
+        public static readonly EventId SolveCancelled = new EventId(1032, "solve_cancelled");
+        public static readonly EventId DocumentParsed = new EventId(1040, "document_parsed");
+        public static readonly EventId ConfigurationLoadFailed = new EventId(1050, "configuration_load_failed");
+        public static readonly EventId RangeInverted = new EventId(1060, "range_inverted");
+        public static readonly EventId WebhookFailed = new EventId(1070, "webhook_failed");
+
+        private static readonly Action<ILogger, string, string, int, Exception?> modelCreated =
+            LoggerMessage.Define<string, string, int>(LogLevel.Information, ModelCreated,
+                "Created model {model_id} ({model_name}) with {entity_count} entities");
+
+        private static readonly Action<ILogger, string, Exception?> modelDeleted =
+            LoggerMessage.Define<string>(LogLevel.Information, ModelDeleted, "Deleted model {model_id}");
+
+        private static readonly Action<ILogger, string, int, int, double, Exception?> modelParsed =
+            LoggerMessage.Define<string, int, int, double>(LogLevel.Debug, ModelParsed,
+                "Parsed model {model_id}: {entity_count} entities, {error_count} errors in {duration_ms} ms");
+
+        private static readonly Action<ILogger, string, int, double, Exception?> modelSaved =
+            LoggerMessage.Define<string, int, double>(LogLevel.Debug, ModelSaved,
+                "Saved model {model_id} version {version} in {duration_ms} ms");
+
+        private static readonly Action<ILogger, string, int, Exception?> modelSaveFailed =
+            LoggerMessage.Define<string, int>(LogLevel.Error, ModelSaveFailed,
+                "Saving model {model_id} version {version} failed");
+
+        private static readonly Action<ILogger, int, string, double, Exception?> modelsRestored =
+            LoggerMessage.Define<int, string, double>(LogLevel.Information, ModelsRestored,
+                "Restored {count} models from {storage} in {duration_ms} ms");
+
+        private static readonly Action<ILogger, int, int, double, Exception?> modelsCheckpointed =
+            LoggerMessage.Define<int, int, double>(LogLevel.Information, ModelsCheckpointed,
+                "Checkpointed {count} unsaved models ({solve_count} running solves) in {duration_ms} ms");
+
+        private static readonly Action<ILogger, string, Exception?> checkpointFailed =
+            LoggerMessage.Define<string>(LogLevel.Error, CheckpointFailed, "Checkpointing to {location} failed");
+
+        private static readonly Action<ILogger, string, int, string, Exception?> modelPublished =
+            LoggerMessage.Define<string, int, string>(LogLevel.Information, ModelPublished,
+                "Published model {model_id} version {version} as {publication_id}");
+
+        private static readonly Action<ILogger, string, int, int, Exception?> modelVersionConflict =
+            LoggerMessage.Define<string, int, int>(LogLevel.Warning, ModelVersionConflict,
+                "Model {model_id} version {version} is already stored, saving as version {next_version}");
+
+        private static readonly Action<ILogger, int, string, int, Exception?> entitiesUploaded =
+            LoggerMessage.Define<int, string, int>(LogLevel.Information, EntitiesUploaded,
+                "Uploaded {entity_count} entities to model {model_id} ({error_count} parse errors)");
+
+        private static readonly Action<ILogger, string, string, Exception?> solveStarted =
+            LoggerMessage.Define<string, string>(LogLevel.Information, SolveStarted,
+                "Solving model {model_id} with {solver}");
+
+        private static readonly Action<ILogger, string, string, string, double, int, Exception?> solveCompleted =
+            LoggerMessage.Define<string, string, string, double, int>(LogLevel.Information, SolveCompleted,
+                "Solved model {model_id} with {solver}: {status} in {duration_ms} ms ({entity_count} constraints)");
+
+        private static readonly Action<ILogger, string, string, double, Exception?> solveCancelled =
+            LoggerMessage.Define<string, string, double>(LogLevel.Information, SolveCancelled,
+                "Solve of model {model_id} with {solver} cancelled after {duration_ms} ms");
+
+        private static readonly Action<ILogger, string, int, int, double, Exception?> documentParsed =
+            LoggerMessage.Define<string, int, int, double>(LogLevel.Debug, DocumentParsed,
+                "Parsed document {document}: {entity_count} statements, {error_count} errors in {duration_ms} ms");
+
+        private static readonly Action<ILogger, string, Exception?> configurationLoadFailed =
+            LoggerMessage.Define<string>(LogLevel.Warning, ConfigurationLoadFailed,
+                "Loading run configuration from {file} failed");
+
+        private static readonly Action<ILogger, string, int, int, Exception?> rangeInverted =
+            LoggerMessage.Define<string, int, int>(LogLevel.Warning, RangeInverted,
+                "Range {range} has start {start} greater than end {end}; it is empty");
+
+        private static readonly Action<ILogger, string, string, int, string, Exception?> webhookFailed =
+            LoggerMessage.Define<string, string, int, string>(LogLevel.Warning, WebhookFailed,
+                "Webhook {webhook_id} gave up on {event} after {attempts} attempts: {error}");
+
+        public static void LogModelCreated(this ILogger logger, string modelId, string name, int entityCount) =>
+            modelCreated(logger, modelId, name, entityCount, null);
+
+        public static void LogModelDeleted(this ILogger logger, string modelId) => modelDeleted(logger, modelId, null);
+
+        public static void LogModelParsed(this ILogger logger, string modelId, int entityCount, int errorCount, TimeSpan duration) =>
+            modelParsed(logger, modelId, entityCount, errorCount, Milliseconds(duration), null);
+
+        public static void LogModelSaved(this ILogger logger, string modelId, int version, TimeSpan duration) =>
+            modelSaved(logger, modelId, version, Milliseconds(duration), null);
+
+        public static void LogModelSaveFailed(this ILogger logger, string modelId, int version, Exception exception) =>
+            modelSaveFailed(logger, modelId, version, exception);
+
+        public static void LogModelsRestored(this ILogger logger, int count, string storage, TimeSpan duration) =>
+            modelsRestored(logger, count, storage, Milliseconds(duration), null);
+
+        public static void LogModelsCheckpointed(this ILogger logger, int count, int solveCount, TimeSpan duration) =>
+            modelsCheckpointed(logger, count, solveCount, Milliseconds(duration), null);
+
+        public static void LogCheckpointFailed(this ILogger logger, string location, Exception exception) =>
+            checkpointFailed(logger, location, exception);
+
+        public static void LogModelPublished(this ILogger logger, string modelId, int version, string publicationId) =>
+            modelPublished(logger, modelId, version, publicationId, null);
+
+        public static void LogModelVersionConflict(this ILogger logger, string modelId, int version, int nextVersion) =>
+            modelVersionConflict(logger, modelId, version, nextVersion, null);
+
+        public static void LogEntitiesUploaded(this ILogger logger, string modelId, int entityCount, int errorCount) =>
+            entitiesUploaded(logger, entityCount, modelId, errorCount, null);
+
+        public static void LogSolveStarted(this ILogger logger, string modelId, string solver) =>
+            solveStarted(logger, modelId, solver, null);
+
+        public static void LogSolveCompleted(this ILogger logger, string modelId, string solver, string status, TimeSpan duration, int constraintCount) =>
+            solveCompleted(logger, modelId, solver, status, Milliseconds(duration), constraintCount, null);
+
+        public static void LogSolveCancelled(this ILogger logger, string modelId, string solver, TimeSpan duration) =>
+            solveCancelled(logger, modelId, solver, Milliseconds(duration), null);
+
+        public static void LogDocumentParsed(this ILogger logger, string document, int entityCount, int errorCount, TimeSpan duration) =>
+            documentParsed(logger, document, entityCount, errorCount, Milliseconds(duration), null);
+
+        public static void LogConfigurationLoadFailed(this ILogger logger, string file, Exception exception) =>
+            configurationLoadFailed(logger, file, exception);
+
+        public static void LogRangeInverted(this ILogger logger, string range, int start, int end) =>
+            rangeInverted(logger, range, start, end, null);
+
+        public static void LogWebhookFailed(this ILogger logger, string webhookId, string eventName, int attempts, string error) =>
+            webhookFailed(logger, webhookId, eventName, attempts, error, null);
+
+        private static double Milliseconds(TimeSpan duration) => Math.Round(duration.TotalMilliseconds, 1);
+    }
+}
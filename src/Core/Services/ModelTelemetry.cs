@@ -0,0 +1,176 @@
+using System.Diagnostics;
+using System.Diagnostics.Metrics;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// OpenTelemetry-compatible tracing and metrics for the parse, validate, export and solve
+    /// phases. Spans come from an ActivitySource and measurements from a Meter, both named
+    /// "ModelEditor"; nothing is collected unless a listener (e.g. the OpenTelemetry SDK with
+    /// AddSource/AddMeter("ModelEditor")) subscribes, so the default costs next to nothing.
+    /// </summary>
+    public static class ModelTelemetry
+    {
+        public const string Name = "ModelEditor";
+
+        public static readonly ActivitySource Source = new ActivitySource(Name);
+        public static readonly Meter Meter = new Meter(Name);
+
+        /// <summary>
+        /// Wall-clock duration of a phase, tagged with phase and outcome (plus format or solver)
+        /// </summary>
+        public static readonly Histogram<double> PhaseDuration =
+            Meter.CreateHistogram<double>("modeleditor.phase.duration", "ms", "Duration of parse, validate, export and solve phases");
+
+        /// <summary>
+        /// Model size seen by a phase, tagged with phase and kind (statements, variables, constraints)
+        /// </summary>
+        public static readonly Histogram<long> ModelEntities =
+            Meter.CreateHistogram<long>("modeleditor.model.entities", "{entity}", "Number of statements, variables or constraints processed by a phase");
+
+        /// <summary>
+        /// Size of exported files, tagged with format
+        /// </summary>
+        public static readonly Histogram<long> ExportSize =
+            Meter.CreateHistogram<long>("modeleditor.export.size", "By", "Size of exported model files");
+
+        /// <summary>
+        /// Finished solves, tagged with solver and status
+        /// </summary>
+        public static readonly Counter<long> Solves =
+            Meter.CreateCounter<long>("modeleditor.solve.count", "{solve}", "Solves by solver and result status");
+
+        /// <summary>
+        /// Solves currently running, tagged with solver
+        /// </summary>
+        public static readonly UpDownCounter<long> ActiveSolves =
+            Meter.CreateUpDownCounter<long>("modeleditor.solve.active", "{solve}", "Solves currently running");
+
+        /// <summary>
+        /// Failed operations, tagged with operation (parse, solve, save, webhook) and reason
+        /// </summary>
+        public static readonly Counter<long> Failures =
+            Meter.CreateCounter<long>("modeleditor.failure.count", "{failure}", "Failed parses, solves, saves and webhook deliveries");
+
+        /// <summary>
+        /// Starts a span and duration measurement for one phase. Dimensions (e.g. ("format", "mps"))
+        /// are low-cardinality tags applied to both the span and the metrics.
+        /// </summary>
+        public static TelemetryPhase StartPhase(string phase, params (string Key, string Value)[] dimensions)
+        {
+            return new TelemetryPhase(phase, dimensions);
+        }
+
+        public static void RecordEntities(string phase, string kind, long count)
+        {
+            if (ModelEntities.Enabled)
+                ModelEntities.Record(count, new KeyValuePair<string, object?>("phase", phase), new KeyValuePair<string, object?>("kind", kind));
+        }
+
+        public static void RecordExportSize(string format, long bytes)
+        {
+            if (ExportSize.Enabled)
+                ExportSize.Record(bytes, new KeyValuePair<string, object?>("format", format));
+        }
+
+        public static void RecordSolve(string solver, string status)
+        {
+            if (Solves.Enabled)
+                Solves.Add(1, new KeyValuePair<string, object?>("solver", solver), new KeyValuePair<string, object?>("status", status));
+        }
+
+        /// <summary>
+        /// +1 when a solve starts, -1 when it ends
+        /// </summary>
+        public static void RecordActiveSolve(string solver, int delta)
+        {
+            if (ActiveSolves.Enabled)
+                ActiveSolves.Add(delta, new KeyValuePair<string, object?>("solver", solver));
+        }
+
+        public static void RecordFailure(string operation, string reason)
+        {
+            if (Failures.Enabled)
+                Failures.Add(1, new KeyValuePair<string, object?>("operation", operation), new KeyValuePair<string, object?>("reason", reason));
+        }
+    }
+
+    /// <summary>
+    /// One instrumented phase: an optional span plus a duration measurement recorded on Dispose.
+    /// The outcome is "aborted" unless Complete is called, so exceptions and cancellations show
+    /// up as such without a catch block at every call site.
+    /// </summary>
+    public sealed class TelemetryPhase : IDisposable
+    {
+        private readonly string phase;
+        private readonly (string Key, string Value)[] dimensions;
+        private readonly Activity? activity;
+        private readonly long started = Stopwatch.GetTimestamp();
+        private string outcome = "aborted";
+        private bool disposed;
+
+        internal TelemetryPhase(string phase, (string Key, string Value)[] dimensions)
+        {
+            this.phase = phase;
+            this.dimensions = dimensions;
+            activity = ModelTelemetry.Source.StartActivity($"modeleditor.{phase}");
+            if (activity != null)
+            {
+                foreach (var (key, value) in dimensions)
+                    activity.SetTag(key, value);
+            }
+        }
+
+        /// <summary>
+        /// The span, or null when no listener is tracing
+        /// </summary>
+        public Activity? Activity => activity;
+
+        public string Outcome => outcome;
+
+        /// <summary>
+        /// Adds a span attribute; unlike dimensions these may be high-cardinality (model ids, counts)
+        /// </summary>
+        public TelemetryPhase SetTag(string key, object? value)
+        {
+            activity?.SetTag(key, value);
+            return this;
+        }
+
+        /// <summary>
+        /// Marks the phase finished with "ok" or another outcome ("error" for parse or solve
+        /// failures, "cancelled"); only "error" marks the span as failed
+        /// </summary>
+        public void Complete(string outcome = "ok", string? description = null)
+        {
+            this.outcome = outcome;
+            if (outcome == "ok")
+                activity?.SetStatus(ActivityStatusCode.Ok);
+            else if (outcome == "error")
+                activity?.SetStatus(ActivityStatusCode.Error, description);
+        }
+
+        public void Dispose()
+        {
+            if (disposed)
+                return;
+            disposed = true;
+
+            if (ModelTelemetry.PhaseDuration.Enabled)
+            {
+                var tags = new TagList { { "phase", phase }, { "outcome", outcome } };
+                foreach (var (key, value) in dimensions)
+                    tags.Add(key, value);
+                ModelTelemetry.PhaseDuration.Record(Stopwatch.GetElapsedTime(started).TotalMilliseconds, tags);
+            }
+
+            if (activity != null)
+            {
+                activity.SetTag("modeleditor.outcome", outcome);
+                if (outcome == "aborted")
+                    activity.SetStatus(ActivityStatusCode.Error, "aborted");
+                activity.Dispose();
+            }
+        }
+    }
+}
@@ -0,0 +1,477 @@
+using System;
+using System.Collections.Generic;
+using System.Diagnostics;
+using System.IO;
+using System.Linq;
+using System.Threading;
+using Core.Analysis;
+using Core.Parsing;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// A model document in a workspace
+    /// </summary>
+    public class WorkspaceDocument
+    {
+        public string Name { get; }
+        public string? FilePath { get; set; }
+        public string Text { get; private set; }
+
+        /// <summary>
+        /// Incremented on every text change
+        /// </summary>
+        public int Version { get; private set; } = 1;
+
+        /// <summary>
+        /// Version of the text that Manager was last built from (0 = never parsed)
+        /// </summary>
+        public int ParsedVersion { get; internal set; }
+
+        public ModelManager Manager { get; internal set; } = new ModelManager();
+        public ParseSessionResult? LastParseResult { get; internal set; }
+        public ModelFingerprint? Fingerprint { get; internal set; }
+        public List<WorkspaceLink> Imports { get; } = new List<WorkspaceLink>();
+
+        public bool IsParsed => ParsedVersion == Version;
+
+        public WorkspaceDocument(string name, string text)
+        {
+            Name = name;
+            Text = text;
+        }
+
+        public void SetText(string text)
+        {
+            if (text == Text)
+                return;
+
+            Text = text;
+            Version++;
+        }
+    }
+
+    /// <summary>
+    /// An import of one entity from another document
+    /// (declared in the model text as: import Nodes, capacity from "network";)
+    /// </summary>
+    public class WorkspaceLink
+    {
+        public string SourceDocument { get; init; } = "";
+        public string EntityName { get; init; } = "";
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// Entity hash of the source entity at the time the link was last resolved
+        /// </summary>
+        public string? ResolvedHash { get; internal set; }
+
+        public override string ToString() => $"{SourceDocument}.{EntityName}";
+    }
+
+    /// <summary>
+    /// Result of a workspace-wide validation pass
+    /// </summary>
+    public class WorkspaceValidationResult
+    {
+        public Dictionary<string, List<string>> Errors { get; } = new Dictionary<string, List<string>>();
+        public List<string> ParseOrder { get; } = new List<string>();
+
+        public bool IsValid => Errors.Values.All(e => e.Count == 0);
+
+        public void AddError(string document, string message)
+        {
+            if (!Errors.TryGetValue(document, out var list))
+            {
+                list = new List<string>();
+                Errors[document] = list;
+            }
+            list.Add(message);
+        }
+    }
+
+    /// <summary>
+    /// A set of model documents where one model can import sets, ranges and parameters
+    /// declared in another (e.g. a shared network topology model). Documents use the selective
+    /// imports of composed model files (ImportDirective); the imported declarations are copied,
+    /// so each document evaluates and changes its own.
+    /// </summary>
+    public class ModelWorkspace
+    {
+        private readonly Dictionary<string, WorkspaceDocument> documents = new Dictionary<string, WorkspaceDocument>();
+        private readonly Dictionary<string, (int Version, EntitySearchIndex Index)> searchIndexes = new Dictionary<string, (int, EntitySearchIndex)>();
+
+        public IEnumerable<WorkspaceDocument> Documents => documents.Values.OrderBy(d => d.Name);
+
+        /// <summary>
+        /// Receives a document_parsed event per document parse; a no-op logger by default
+        /// </summary>
+        public ILogger Logger { get; set; } = NullLogger.Instance;
+
+        public WorkspaceDocument AddDocument(string name, string text)
+        {
+            if (documents.ContainsKey(name))
+            {
+                throw new InvalidOperationException($"Document '{name}' is already in the workspace");
+            }
+
+            var document = new WorkspaceDocument(name, text);
+            documents[name] = document;
+            return document;
+        }
+
+        /// <summary>
+        /// Adds a model file; the document name is the file name without extension
+        /// </summary>
+        public WorkspaceDocument AddFile(string filePath)
+        {
+            var document = AddDocument(Path.GetFileNameWithoutExtension(filePath), File.ReadAllText(filePath));
+            document.FilePath = filePath;
+            return document;
+        }
+
+        public WorkspaceDocument? GetDocument(string name)
+        {
+            return documents.TryGetValue(name, out var document) ? document : null;
+        }
+
+        public bool RemoveDocument(string name)
+        {
+            searchIndexes.Remove(name);
+            return documents.Remove(name);
+        }
+
+        /// <summary>
+        /// Declarations of all documents whose names, docstrings, tags or annotation values match
+        /// every word of the query, best first. Indexes are rebuilt for documents whose text changed.
+        /// </summary>
+        public List<SearchResult> Search(string query, int limit = 50)
+        {
+            var results = new List<SearchResult>();
+            foreach (var document in Documents)
+            {
+                if (!searchIndexes.TryGetValue(document.Name, out var cached) || cached.Version != document.Version)
+                {
+                    cached = (document.Version, EntitySearchIndex.Build(document.Name, document.Text));
+                    searchIndexes[document.Name] = cached;
+                }
+
+                results.AddRange(cached.Index.Search(query));
+            }
+
+            return results
+                .OrderByDescending(r => r.Score)
+                .ThenBy(r => r.Document, StringComparer.Ordinal)
+                .ThenBy(r => r.LineNumber)
+                .Take(limit)
+                .ToList();
+        }
+
+        /// <summary>
+        /// Parses a document after parsing (and importing from) its dependencies
+        /// </summary>
+        public ParseSessionResult Parse(string name)
+        {
+            var result = new WorkspaceValidationResult();
+            var order = GetParseOrder(name, result);
+            ParseInOrder(order, result);
+
+            return documents[name].LastParseResult ?? new ParseSessionResult();
+        }
+
+        /// <summary>
+        /// Parses every document in dependency order and checks all links
+        /// </summary>
+        public WorkspaceValidationResult ValidateAll() => ValidateAll(CancellationToken.None);
+
+        /// <summary>
+        /// Parses and checks every document, reporting progress per document
+        /// </summary>
+        public WorkspaceValidationResult ValidateAll(CancellationToken cancellationToken, IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("validate", progress, cancellationToken);
+            using var phase = ModelTelemetry.StartPhase("validate").SetTag("modeleditor.documents", documents.Count);
+            var result = new WorkspaceValidationResult();
+            var order = new List<string>();
+            var visited = new HashSet<string>();
+
+            foreach (var name in documents.Keys.OrderBy(n => n))
+            {
+                Visit(name, visited, new HashSet<string>(), order, result);
+            }
+
+            ParseInOrder(order, result, tracker);
+            ModelTelemetry.RecordEntities("validate", "documents", order.Count);
+            phase.SetTag("modeleditor.error_count", result.Errors.Values.Sum(e => e.Count));
+            phase.Complete(result.IsValid ? "ok" : "error");
+            return result;
+        }
+
+        /// <summary>
+        /// True if the document must be re-parsed: its own text changed, an imported entity
+        /// changed since the link was resolved, or a document it imports from is stale
+        /// </summary>
+        public bool IsStale(string name)
+        {
+            return IsStale(name, new HashSet<string>());
+        }
+
+        /// <summary>
+        /// Links whose source entity changed since they were resolved
+        /// </summary>
+        public IEnumerable<(WorkspaceDocument Document, WorkspaceLink Link)> GetStaleLinks()
+        {
+            foreach (var document in Documents)
+            {
+                foreach (var link in document.Imports)
+                {
+                    if (IsLinkStale(link))
+                        yield return (document, link);
+                }
+            }
+        }
+
+        /// <summary>
+        /// Documents that import (directly or transitively) from the given document
+        /// </summary>
+        public IEnumerable<string> GetDependents(string name)
+        {
+            var result = new SortedSet<string>();
+            var queue = new Queue<string>();
+            queue.Enqueue(name);
+
+            while (queue.Count > 0)
+            {
+                var current = queue.Dequeue();
+                foreach (var document in documents.Values)
+                {
+                    if (ReadImports(document).Any(l => l.SourceDocument == current) && result.Add(document.Name))
+                        queue.Enqueue(document.Name);
+                }
+            }
+
+            return result;
+        }
+
+        private bool IsStale(string name, HashSet<string> visiting)
+        {
+            if (!documents.TryGetValue(name, out var document))
+                return true;
+
+            if (!document.IsParsed)
+                return true;
+
+            if (!visiting.Add(name))
+                return false;
+
+            foreach (var link in document.Imports)
+            {
+                if (IsStale(link.SourceDocument, visiting) || IsLinkStale(link))
+                    return true;
+            }
+
+            return false;
+        }
+
+        private bool IsLinkStale(WorkspaceLink link)
+        {
+            if (!documents.TryGetValue(link.SourceDocument, out var source))
+                return true;
+
+            if (!source.IsParsed)
+                return true;
+
+            return FindEntityHash(source, link.EntityName) != link.ResolvedHash;
+        }
+
+        private List<string> GetParseOrder(string name, WorkspaceValidationResult result)
+        {
+            if (!documents.ContainsKey(name))
+            {
+                throw new InvalidOperationException($"Document '{name}' is not in the workspace");
+            }
+
+            var order = new List<string>();
+            Visit(name, new HashSet<string>(), new HashSet<string>(), order, result);
+            return order;
+        }
+
+        /// <summary>
+        /// Depth-first topological sort over import links, reporting cycles and missing documents
+        /// </summary>
+        private void Visit(string name, HashSet<string> visited, HashSet<string> onStack,
+            List<string> order, WorkspaceValidationResult result)
+        {
+            if (visited.Contains(name))
+                return;
+
+            visited.Add(name);
+            onStack.Add(name);
+
+            foreach (var link in ReadImports(documents[name]))
+            {
+                if (!documents.ContainsKey(link.SourceDocument))
+                {
+                    result.AddError(name, $"Line {link.LineNumber}: imported document '{link.SourceDocument}' is not in the workspace");
+                    continue;
+                }
+
+                if (onStack.Contains(link.SourceDocument))
+                {
+                    result.AddError(name, $"Line {link.LineNumber}: circular import between '{name}' and '{link.SourceDocument}'");
+                    continue;
+                }
+
+                Visit(link.SourceDocument, visited, onStack, order, result);
+            }
+
+            onStack.Remove(name);
+            order.Add(name);
+        }
+
+        private void ParseInOrder(List<string> order, WorkspaceValidationResult result, ProgressTracker? tracker = null)
+        {
+            tracker?.Stage("documents", order.Count);
+            foreach (var name in order)
+            {
+                tracker?.Tick(result.ParseOrder.Count, order.Count, name);
+                var document = documents[name];
+                var sw = Stopwatch.StartNew();
+                var manager = new ModelManager { Logger = Logger };
+                var parser = new EquationParser(manager);
+
+                document.Imports.Clear();
+                document.Imports.AddRange(ReadImports(document));
+
+                foreach (var link in document.Imports)
+                {
+                    if (!documents.TryGetValue(link.SourceDocument, out var source) || !source.IsParsed)
+                        continue;
+
+                    if (!TryImportEntity(source.Manager, manager, link.EntityName))
+                    {
+                        result.AddError(name, $"Line {link.LineNumber}: '{link.EntityName}' is not declared in '{link.SourceDocument}'");
+                        continue;
+                    }
+
+                    link.ResolvedHash = FindEntityHash(source, link.EntityName);
+                }
+
+                var parseResult = parser.Parse(ImportDirective.Blank(document.Text));
+                foreach (var directive in ImportDirective.Parse(document.Text).Where(d => !d.IsSelective))
+                {
+                    parseResult.AddError($"prefixed imports (as {directive.Alias}) are only resolved when composing model files; " +
+                        $"import the names instead: import Name, ... from \"{directive.Source}\";", directive.LineNumber);
+                }
+
+                if (!parseResult.HasErrors)
+                {
+                    parser.ExpandAllTemplates(parseResult, tracker?.CancellationToken ?? default);
+                }
+
+                foreach (var error in parseResult.Errors)
+                {
+                    result.AddError(name, $"Line {error.LineNumber}: {error.Message}");
+                }
+
+                document.Manager = manager;
+                document.LastParseResult = parseResult;
+                document.Fingerprint = ModelFingerprint.Compute(manager);
+                document.ParsedVersion = document.Version;
+                result.ParseOrder.Add(name);
+                Logger.LogDocumentParsed(name, parseResult.SuccessCount, parseResult.Errors.Count, sw.Elapsed);
+            }
+            tracker?.Tick(order.Count, order.Count);
+        }
+
+        /// <summary>
+        /// Copies a named declaration (set, range, tuple set or parameter) into the target model;
+        /// the target gets its own copy, so evaluating or changing it leaves the source alone
+        /// </summary>
+        private static bool TryImportEntity(ModelManager source, ModelManager target, string name)
+        {
+            bool found = false;
+
+            if (source.Ranges.TryGetValue(name, out var range))
+            {
+                target.Ranges[name] = range.Clone();
+                found = true;
+            }
+
+            if (source.IndexSets.TryGetValue(name, out var indexSet))
+            {
+                target.IndexSets[name] = indexSet.Clone();
+                found = true;
+            }
+
+            if (source.PrimitiveSets.TryGetValue(name, out var primitiveSet))
+            {
+                target.PrimitiveSets[name] = primitiveSet.Clone();
+                found = true;
+            }
+
+            if (source.ComputedSets.TryGetValue(name, out var computedSet))
+            {
+                target.ComputedSets[name] = computedSet.Clone();
+                found = true;
+            }
+
+            if (source.TupleSets.TryGetValue(name, out var tupleSet))
+            {
+                target.TupleSets[name] = tupleSet.Clone();
+                if (source.TupleSchemas.TryGetValue(tupleSet.SchemaName, out var schema))
+                    target.TupleSchemas[schema.Name] = schema.Clone();
+                found = true;
+            }
+
+            if (source.Parameters.TryGetValue(name, out var parameter))
+            {
+                target.Parameters[name] = parameter.Clone();
+                found = true;
+            }
+
+            return found;
+        }
+
+        private static string? FindEntityHash(WorkspaceDocument document, string entityName)
+        {
+            if (document.Fingerprint == null)
+                return null;
+
+            var hashes = document.Fingerprint.EntityHashes
+                .Where(e => e.Key.EndsWith(":" + entityName, StringComparison.Ordinal))
+                .OrderBy(e => e.Key, StringComparer.Ordinal)
+                .Select(e => e.Value);
+
+            var combined = string.Join(",", hashes);
+            return combined.Length > 0 ? combined : null;
+        }
+
+        private List<WorkspaceLink> ReadImports(WorkspaceDocument document)
+        {
+            var links = new List<WorkspaceLink>();
+
+            foreach (var directive in ImportDirective.Parse(document.Text).Where(d => d.IsSelective))
+            {
+                // A file name ("network.mod") names the document added from that file
+                string source = directive.Source;
+                if (!documents.ContainsKey(source) && documents.ContainsKey(Path.GetFileNameWithoutExtension(source)))
+                    source = Path.GetFileNameWithoutExtension(source);
+
+                foreach (var entity in directive.Names)
+                {
+                    links.Add(new WorkspaceLink
+                    {
+                        SourceDocument = source,
+                        EntityName = entity,
+                        LineNumber = directive.LineNumber
+                    });
+                }
+            }
+
+            return links;
+        }
+    }
+}
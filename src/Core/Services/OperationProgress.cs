@@ -0,0 +1,106 @@
+using System.Diagnostics;
+
+namespace Core.Services
+{
+    /// <summary>
+    /// Progress of a long-running operation (load, save, validate, export, transform, solve).
+    /// Total is null when the amount of work is not known in advance.
+    /// </summary>
+    public class OperationProgress
+    {
+        /// <summary>
+        /// Operation name, e.g. "load" or "export"
+        /// </summary>
+        public string Operation { get; init; } = "";
+
+        /// <summary>
+        /// Current step within the operation, e.g. "data" or "columns"
+        /// </summary>
+        public string Stage { get; init; } = "";
+
+        public long Completed { get; init; }
+        public long? Total { get; init; }
+        public TimeSpan Elapsed { get; init; }
+        public string Message { get; init; } = "";
+
+        /// <summary>
+        /// Completed / Total in [0, 1], or null if the total is unknown
+        /// </summary>
+        public double? Fraction => Total is > 0 ? Math.Min(1.0, (double)Completed / Total.Value) : null;
+
+        public override string ToString()
+        {
+            string amount = Total.HasValue ? $"{Completed}/{Total}" : Completed.ToString();
+            string text = $"[{Elapsed.TotalSeconds:F1}s] {Operation}/{Stage} {amount}";
+            return string.IsNullOrEmpty(Message) ? text : $"{text}: {Message}";
+        }
+    }
+
+    /// <summary>
+    /// Reports progress of one operation and checks for cancellation at each step. Item-level
+    /// ticks are throttled to ReportInterval so tight loops do not flood the UI; stage changes
+    /// are always reported.
+    /// </summary>
+    public class ProgressTracker
+    {
+        public static readonly TimeSpan ReportInterval = TimeSpan.FromMilliseconds(100);
+
+        private readonly string operation;
+        private readonly IProgress<OperationProgress>? progress;
+        private readonly CancellationToken cancellationToken;
+        private readonly Stopwatch stopwatch = Stopwatch.StartNew();
+        private TimeSpan lastReport = TimeSpan.Zero;
+        private string stage = "";
+
+        public ProgressTracker(string operation, IProgress<OperationProgress>? progress, CancellationToken cancellationToken)
+        {
+            this.operation = operation;
+            this.progress = progress;
+            this.cancellationToken = cancellationToken;
+        }
+
+        public CancellationToken CancellationToken => cancellationToken;
+
+        /// <summary>
+        /// Starts a new stage and reports it
+        /// </summary>
+        public void Stage(string name, long? total = null, string message = "")
+        {
+            cancellationToken.ThrowIfCancellationRequested();
+            stage = name;
+            Report(0, total, message);
+        }
+
+        /// <summary>
+        /// Reports that <paramref name="completed"/> items of the current stage are done
+        /// (throttled) and throws if cancellation was requested
+        /// </summary>
+        public void Tick(long completed, long? total = null, string message = "")
+        {
+            cancellationToken.ThrowIfCancellationRequested();
+            if (progress == null)
+                return;
+
+            bool finished = total.HasValue && completed >= total.Value;
+            if (finished || stopwatch.Elapsed - lastReport >= ReportInterval)
+                Report(completed, total, message);
+        }
+
+        private void Report(long completed, long? total, string message)
+        {
+            if (progress == null)
+                return;
+
+            lastReport = stopwatch.Elapsed;
+            progress.Report(new OperationProgress
+            {
+                Operation = operation,
+                Stage = stage,
+                Completed = completed,
+                Total = total,
+                Elapsed = stopwatch.Elapsed,
+                Message = message
+            });
+        }
+    }
+}
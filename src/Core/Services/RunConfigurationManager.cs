@@ -4,6 +4,8 @@ using System.IO;
 using System.Linq;
 using System.Text.Json;
 using Core.Models;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
 
 namespace Core.Services
 {
@@ -14,6 +16,11 @@ namespace Core.Services
     {
         private readonly string configurationsDirectory;
         private readonly Dictionary<string, RunConfiguration> configurations;
+
+        /// <summary>
+        /// Receives warnings about configuration files that could not be loaded
+        /// </summary>
+        public ILogger Logger { get; set; } = NullLogger.Instance;
         
         public RunConfigurationManager(string? baseDirectory = null)
         {
@@ -109,7 +116,7 @@ namespace Core.Services
                 catch (Exception ex)
                 {
                     // Log error but continue loading other configs
-                    Console.WriteLine($"Error loading configuration from {file}: {ex.Message}");
+                    Logger.LogConfigurationLoadFailed(file, ex);
                 }
             }
         }
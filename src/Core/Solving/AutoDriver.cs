@@ -0,0 +1,285 @@
+using System.Globalization;
+using Core.Analysis;
+using Core.Documentation;
+using Core.Export;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Properties of an expanded model that decide which backend suits it
+    /// </summary>
+    public class ModelCharacteristics
+    {
+        public int Variables { get; init; }
+        public int IntegerVariables { get; init; }
+        public int Constraints { get; init; }
+        public int NonZeros { get; init; }
+        public int LogicalConstraints { get; init; }
+
+        /// <summary>
+        /// A constraint or the objective multiplies variables
+        /// </summary>
+        public bool HasQuadraticTerms { get; init; }
+
+        /// <summary>
+        /// All variables are discrete and all data integral, so integer-only backends can take it
+        /// </summary>
+        public bool IsPureInteger { get; init; }
+
+        /// <summary>
+        /// Problem class and convexity from the model source (see ProblemClassifier)
+        /// </summary>
+        public ModelClassification? Classification { get; init; }
+
+        /// <summary>
+        /// The objective or a constraint has terms beyond products of variables (exp, powers, division by variables)
+        /// </summary>
+        public bool HasNonlinearTerms => Classification?.Required.HasFlag(SolverCapabilities.Nonlinear) ?? false;
+
+        public double IntegralityShare => Variables == 0 ? 0 : (double)IntegerVariables / Variables;
+        public bool IsLinearProgram => IntegerVariables == 0 && LogicalConstraints == 0 && !HasQuadraticTerms && !HasNonlinearTerms;
+
+        public SolverCapabilities Required
+        {
+            get
+            {
+                var required = SolverCapabilities.None;
+                if (IntegerVariables < Variables)
+                    required |= SolverCapabilities.Continuous;
+                if (IntegerVariables > 0)
+                    required |= SolverCapabilities.Integer;
+                if (LogicalConstraints > 0)
+                    required |= SolverCapabilities.Logical;
+                if (HasQuadraticTerms)
+                    required |= SolverCapabilities.Quadratic;
+                if (Classification != null)
+                    required |= Classification.Required & (SolverCapabilities.Nonlinear | SolverCapabilities.Nonconvex);
+                return required;
+            }
+        }
+
+        public static ModelCharacteristics Compute(ModelManager manager)
+        {
+            // Statistics, classification and the integer check all read the rows; generate them once
+            using var rows = manager.MaterializeInPlace();
+            var statistics = ModelStatistics.Compute(manager);
+            var classification = ProblemClassifier.Classify(manager);
+            bool quadratic = manager.Equations.Any(e => e.Coefficients.Values.Any(ContainsVariable)) ||
+                             (manager.Objective?.Coefficients.Values.Any(ContainsVariable) ?? false) ||
+                             classification.Required.HasFlag(SolverCapabilities.Quadratic);
+            bool pureInteger = statistics.IntegerVariables == statistics.Variables &&
+                               IntegerModel.FromModel(manager).Warnings.Count == 0;
+
+            return new ModelCharacteristics
+            {
+                Variables = statistics.Variables,
+                IntegerVariables = statistics.IntegerVariables,
+                Constraints = statistics.Constraints,
+                NonZeros = statistics.NonZeros,
+                LogicalConstraints = statistics.LogicalConstraints,
+                HasQuadraticTerms = quadratic,
+                IsPureInteger = pureInteger,
+                Classification = classification
+            };
+        }
+
+        internal static bool ContainsVariable(Expression expression) => expression switch
+        {
+            VariableExpression or IndexedVariableExpression or DecisionExpressionExpression => true,
+            BinaryExpression b => ContainsVariable(b.Left) || ContainsVariable(b.Right),
+            UnaryExpression u => ContainsVariable(u.Operand),
+            _ => false
+        };
+    }
+
+    /// <summary>
+    /// The backend AutoDriver picked for a model and why
+    /// </summary>
+    public class SolverSelection
+    {
+        /// <summary>
+        /// Null when no configured backend can solve the model
+        /// </summary>
+        public ISolverDriver? Driver { get; init; }
+
+        public ModelCharacteristics Characteristics { get; init; } = new ModelCharacteristics();
+
+        /// <summary>
+        /// The decision steps in order, e.g. "CP-SAT: cannot solve continuous variables"
+        /// </summary>
+        public List<string> Trace { get; init; } = new List<string>();
+
+        public override string ToString() => string.Join(Environment.NewLine, Trace);
+    }
+
+    /// <summary>
+    /// Picks one of the configured backends from the model's characteristics, so users need
+    /// not know the solvers' trade-offs. The decision, in order:
+    /// 1. drop backends that lack a capability the model needs (continuous or integer
+    ///    variables, logical constraints, quadratic or other nonlinear terms, global
+    ///    optimization of a model the ProblemClassifier finds nonconvex);
+    /// 2. if Performance has races of this very model, take the driver that won most;
+    /// 3. pure integer models up to MaxConstraintProgrammingVariables go to an integer-only
+    ///    backend (constraint programming, e.g. CP-SAT), everything else to a backend that also
+    ///    handles continuous variables (LP/MIP, e.g. CPLEX);
+    /// 4. otherwise the first remaining backend in configured order.
+    /// Every step is written to the selection's Trace.
+    /// </summary>
+    public class AutoDriver : ISolverDriver
+    {
+        private readonly List<ISolverDriver> drivers;
+
+        public AutoDriver(IEnumerable<ISolverDriver> drivers)
+        {
+            this.drivers = drivers.ToList();
+            if (this.drivers.Count == 0)
+                throw new ArgumentException("Automatic selection needs at least one driver", nameof(drivers));
+        }
+
+        public string Name => "Auto";
+
+        public SolverCapabilities Capabilities => drivers.Aggregate(SolverCapabilities.None, (all, d) => all | d.Capabilities);
+
+        /// <summary>
+        /// Largest pure integer model (in variables) sent to an integer-only backend
+        /// </summary>
+        public int MaxConstraintProgrammingVariables { get; set; } = 50_000;
+
+        /// <summary>
+        /// Race history used to prefer past winners on the same model
+        /// </summary>
+        public SolverPerformance? Performance { get; set; }
+
+        /// <summary>
+        /// Problem class and convexity of the model, with the configured backends that can solve it
+        /// </summary>
+        public ModelClassification Classify(ModelManager manager) => ProblemClassifier.Classify(manager, drivers);
+
+        public SolverSelection Select(ModelManager manager)
+        {
+            var characteristics = ModelCharacteristics.Compute(manager);
+            var trace = new List<string> { Describe(characteristics) };
+            var required = characteristics.Required;
+
+            var capable = new List<ISolverDriver>();
+            foreach (var driver in drivers)
+            {
+                var missing = required & ~driver.Capabilities;
+                if (missing == SolverCapabilities.None)
+                    capable.Add(driver);
+                else
+                    trace.Add($"{driver.Name}: cannot solve {DescribeCapabilities(missing)}");
+            }
+
+            if (capable.Count == 0)
+            {
+                trace.Add($"No configured solver handles {DescribeCapabilities(required)}");
+                return new SolverSelection { Characteristics = characteristics, Trace = trace };
+            }
+
+            ISolverDriver Chosen(ISolverDriver driver, string reason)
+            {
+                trace.Add($"Chose {driver.Name}: {reason}");
+                return driver;
+            }
+
+            if (capable.Count == 1)
+                return Selection(Chosen(capable[0], "the only configured solver that can solve this model"));
+
+            string modelHash = ModelFingerprint.Compute(manager).ModelHash;
+            if (Performance != null && Performance.Statistics(modelHash).Count > 0)
+            {
+                var winner = Performance.Choose(capable, modelHash);
+                if (winner != null)
+                    return Selection(Chosen(winner, "won most races on this model"));
+            }
+
+            var integerOnly = capable.FirstOrDefault(d => !d.Capabilities.HasFlag(SolverCapabilities.Continuous));
+            var general = capable.FirstOrDefault(d => d.Capabilities.HasFlag(SolverCapabilities.Continuous));
+            if (characteristics.IsPureInteger && integerOnly != null)
+            {
+                if (characteristics.Variables <= MaxConstraintProgrammingVariables)
+                    return Selection(Chosen(integerOnly, "pure integer model, suited to constraint programming"));
+                trace.Add($"{integerOnly.Name}: more than {MaxConstraintProgrammingVariables} variables for constraint programming");
+            }
+
+            if (general != null)
+            {
+                string reason = characteristics.IsLinearProgram ? "linear program" : "mixed-integer program";
+                return Selection(Chosen(general, reason));
+            }
+
+            return Selection(Chosen(capable[0], "first configured solver"));
+
+            SolverSelection Selection(ISolverDriver driver) =>
+                new SolverSelection { Driver = driver, Characteristics = characteristics, Trace = trace };
+        }
+
+        public SolveResult Solve(ModelManager manager) => Solve(manager, CancellationToken.None);
+
+        public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken)
+        {
+            var selection = Select(manager);
+            if (selection.Driver == null)
+                return Unsolvable(selection);
+            return Annotate(selection.Driver.Solve(manager, cancellationToken), selection.Driver);
+        }
+
+        public SolveResult Solve(ModelManager manager, SolveMonitor monitor, CancellationToken cancellationToken)
+        {
+            var selection = Select(manager);
+            if (selection.Driver == null)
+                return Unsolvable(selection);
+            return Annotate(selection.Driver.Solve(manager, monitor, cancellationToken), selection.Driver);
+        }
+
+        private static SolveResult Unsolvable(SolverSelection selection) => new SolveResult
+        {
+            Status = SolveStatus.Error,
+            StatusMessage = selection.Trace.Last()
+        };
+
+        private static SolveResult Annotate(SolveResult result, ISolverDriver driver) => new SolveResult
+        {
+            Status = result.Status,
+            ObjectiveValue = result.ObjectiveValue,
+            VariableValues = result.VariableValues,
+            ConstraintSlacks = result.ConstraintSlacks,
+            MipGap = result.MipGap,
+            BestBound = result.BestBound,
+            SolveTime = result.SolveTime,
+            StatusMessage = $"{driver.Name}: {result.StatusMessage ?? result.Status.ToString()}"
+        };
+
+        private static string Describe(ModelCharacteristics c)
+        {
+            string kind = c.Classification is { IsLinear: false } classification ? $"{classification.Class} model ({classification.Convexity.ToString().ToLowerInvariant()})"
+                : c.HasQuadraticTerms ? "quadratic model"
+                : c.IsLinearProgram ? "linear program"
+                : c.IsPureInteger ? "pure integer model"
+                : "mixed-integer model";
+            return string.Create(CultureInfo.InvariantCulture,
+                $"Model: {kind}, {c.Variables} variables ({c.IntegralityShare * 100:0}% integer), {c.Constraints} constraints, " +
+                $"{c.NonZeros} nonzeros, {c.LogicalConstraints} logical constraints");
+        }
+
+        private static string DescribeCapabilities(SolverCapabilities capabilities)
+        {
+            var names = new List<string>();
+            if (capabilities.HasFlag(SolverCapabilities.Continuous))
+                names.Add("continuous variables");
+            if (capabilities.HasFlag(SolverCapabilities.Integer))
+                names.Add("integer variables");
+            if (capabilities.HasFlag(SolverCapabilities.Logical))
+                names.Add("logical constraints");
+            if (capabilities.HasFlag(SolverCapabilities.Quadratic))
+                names.Add("quadratic terms");
+            if (capabilities.HasFlag(SolverCapabilities.Nonlinear))
+                names.Add("nonlinear terms");
+            if (capabilities.HasFlag(SolverCapabilities.Nonconvex))
+                names.Add("nonconvex models");
+            return string.Join(", ", names);
+        }
+    }
+}
@@ -0,0 +1,211 @@
+using System.Globalization;
+using System.Text;
+using Core.Analysis;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Outcome of solving one block of a model with its boundary held at a reference solution
+    /// </summary>
+    public class BlockSolveResult
+    {
+        public string Block { get; init; } = "";
+
+        /// <summary>
+        /// The block's values laid over the reference solution, so every variable has a value
+        /// </summary>
+        public SolveResult Solution { get; init; } = new SolveResult();
+
+        public SolveStatus Status => Solution.Status;
+
+        /// <summary>
+        /// Objective of the reference solution, for comparing what-if runs with the full model
+        /// </summary>
+        public double? ReferenceObjective { get; init; }
+
+        /// <summary>
+        /// Rows of the block (without the rows that fix the boundary)
+        /// </summary>
+        public int Rows { get; init; }
+
+        /// <summary>
+        /// Variables that appear only in the block's rows and were optimized
+        /// </summary>
+        public List<string> FreeVariables { get; } = new List<string>();
+
+        /// <summary>
+        /// Block variables that also appear in rows outside the block, held at their reference values
+        /// </summary>
+        public List<string> BoundaryVariables { get; } = new List<string>();
+
+        /// <summary>
+        /// Free variables whose value moved away from the reference
+        /// </summary>
+        public Dictionary<string, (double Reference, double Value)> Changes { get; } = new Dictionary<string, (double, double)>();
+
+        public string ToReport()
+        {
+            var sb = new StringBuilder();
+            sb.Append($"Block {Block}: {Status}");
+            if (Solution.ObjectiveValue.HasValue)
+                sb.Append($", objective {Format(Solution.ObjectiveValue.Value)}");
+            if (ReferenceObjective.HasValue)
+                sb.Append($" (full model {Format(ReferenceObjective.Value)})");
+            sb.AppendLine();
+            sb.AppendLine($"  {Rows} rows, {FreeVariables.Count} free variables, {BoundaryVariables.Count} boundary variables fixed");
+
+            if (Changes.Count > 0)
+            {
+                sb.AppendLine("  Changed:");
+                foreach (var (name, (before, after)) in Changes.OrderBy(c => c.Key, StringComparer.Ordinal))
+                    sb.AppendLine($"    {name}: {Format(before)} -> {Format(after)}");
+            }
+
+            return sb.ToString();
+        }
+
+        private static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+    }
+
+    /// <summary>
+    /// Solves one block (or any slice of rows) of an expanded model in isolation, for quick
+    /// what-if iterations on one part of a large system. Variables of the block's rows that also
+    /// appear in other rows are the boundary: they are fixed at the reference solution (by default
+    /// the manager's last full solution), as are the variables of other blocks in the objective.
+    /// The remaining block variables are optimized against the block's rows only. Logical
+    /// constraints are kept; their variables outside the block are fixed too.
+    /// The model is modified only for the duration of the solve.
+    /// </summary>
+    public class BlockSolver
+    {
+        private const string FixPrefix = "fix_";
+
+        private readonly ISolverDriver driver;
+
+        public BlockSolver(ISolverDriver driver)
+        {
+            this.driver = driver ?? throw new ArgumentNullException(nameof(driver));
+        }
+
+        /// <summary>
+        /// Smallest change of a free variable reported in Changes
+        /// </summary>
+        public double Tolerance { get; set; } = NumericTolerance.DefaultAbsolute;
+
+        /// <summary>
+        /// Solves the constraints carrying a block tag (e.g. "plants/north", see ModelTags)
+        /// </summary>
+        public BlockSolveResult Solve(ModelManager manager, ModelTags tags, string block, SolveResult? reference = null)
+        {
+            var families = tags.Select(block)
+                .Where(e => e.Key.StartsWith("constraint:", StringComparison.Ordinal))
+                .Select(e => e.Key.Substring("constraint:".Length))
+                .ToHashSet(StringComparer.Ordinal);
+
+            return Solve(manager, TagPath.Normalize(block), equation =>
+                families.Contains(equation.BaseName ?? equation.Label ?? "") ||
+                families.Contains(SolutionComparison.GetFamily(equation)), reference);
+        }
+
+        /// <summary>
+        /// Solves the rows selected by <paramref name="slice"/>, e.g. one index of a constraint family
+        /// </summary>
+        public BlockSolveResult Solve(ModelManager manager, string name, Func<LinearEquation, bool> slice, SolveResult? reference = null)
+        {
+            if (manager.IndexedEquationTemplates.Count > 0 || manager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot solve a block: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            reference ??= manager.Solution
+                ?? throw new InvalidOperationException($"Cannot solve block '{name}': solve the full model first to fix its boundary");
+            if (reference.Status is not (SolveStatus.Optimal or SolveStatus.Feasible))
+                throw new InvalidOperationException($"Cannot solve block '{name}': the reference solution is {reference.Status}");
+
+            // The block is cut out of the rows in place, so the rule rows are held for the solve
+            using var rows = manager.MaterializeInPlace();
+            var inside = manager.Equations.Where(slice).ToList();
+            if (inside.Count == 0)
+                throw new InvalidOperationException($"Block '{name}' has no constraints");
+
+            var insideSet = inside.ToHashSet();
+            var outsideVariables = manager.Equations
+                .Where(e => !insideSet.Contains(e))
+                .SelectMany(e => e.Coefficients.Keys)
+                .ToHashSet(StringComparer.Ordinal);
+            var blockVariables = inside.SelectMany(e => e.Coefficients.Keys).Distinct().ToList();
+
+            var boundary = blockVariables.Where(outsideVariables.Contains).ToList();
+            var free = blockVariables.Where(v => !outsideVariables.Contains(v)).ToHashSet(StringComparer.Ordinal);
+            var fixedVariables = boundary
+                .Concat(manager.Objective?.Coefficients.Keys ?? Enumerable.Empty<string>())
+                .Concat(manager.LogicalConstraints.SelectMany(l => l.Left.Coefficients.Keys.Concat(l.Right.Coefficients.Keys)))
+                .Where(v => !free.Contains(v))
+                .Distinct()
+                .ToList();
+
+            var original = manager.Equations.ToList();
+            SolveResult solved;
+            try
+            {
+                manager.Equations.Clear();
+                manager.Equations.AddRange(inside);
+                foreach (string variable in fixedVariables)
+                {
+                    manager.Equations.Add(new LinearEquation(
+                        new Dictionary<string, Expression> { [variable] = new ConstantExpression(1) },
+                        new ConstantExpression(reference.VariableValues.GetValueOrDefault(variable)),
+                        RelationalOperator.Equal,
+                        FixPrefix + variable));
+                }
+
+                solved = driver.Solve(manager);
+            }
+            finally
+            {
+                manager.Equations.Clear();
+                manager.Equations.AddRange(original);
+            }
+
+            bool hasSolution = solved.Status is SolveStatus.Optimal or SolveStatus.Feasible;
+            var values = new Dictionary<string, double>(reference.VariableValues);
+            var changes = new Dictionary<string, (double, double)>();
+            if (hasSolution)
+            {
+                foreach (string variable in free)
+                {
+                    double before = reference.VariableValues.GetValueOrDefault(variable);
+                    double after = solved.VariableValues.GetValueOrDefault(variable);
+                    values[variable] = after;
+                    if (Math.Abs(after - before) > Tolerance)
+                        changes[variable] = (before, after);
+                }
+            }
+
+            var result = new BlockSolveResult
+            {
+                Block = name,
+                Rows = inside.Count,
+                ReferenceObjective = reference.ObjectiveValue,
+                Solution = new SolveResult
+                {
+                    Status = solved.Status,
+                    ObjectiveValue = hasSolution ? solved.ObjectiveValue : null,
+                    VariableValues = hasSolution ? values : new Dictionary<string, double>(),
+                    MipGap = solved.MipGap,
+                    BestBound = solved.BestBound,
+                    SolveTime = solved.SolveTime,
+                    StatusMessage = $"Block {name}: {solved.StatusMessage ?? solved.Status.ToString()}"
+                }
+            };
+            result.FreeVariables.AddRange(blockVariables.Where(free.Contains));
+            result.BoundaryVariables.AddRange(boundary);
+            foreach (var change in changes)
+                result.Changes.Add(change.Key, change.Value);
+            return result;
+        }
+    }
+}
@@ -0,0 +1,295 @@
+using System.Diagnostics;
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Export;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Solves bounded-integer models with OR-Tools CP-SAT through its proto interface:
+    /// the model is written as a CpModelProto text file, solved with the sat_runner
+    /// executable and the CpSolverResponse is mapped back to variable names.
+    /// </summary>
+    public class CpSatDriver : ISolverDriver
+    {
+        public string Name => "CP-SAT";
+
+        /// <summary>
+        /// Pure integer models only: continuous variables and fractional data are rejected
+        /// </summary>
+        public SolverCapabilities Capabilities => SolverCapabilities.Integer | SolverCapabilities.Logical;
+
+        /// <summary>
+        /// Path to the OR-Tools sat_runner executable
+        /// </summary>
+        public string SolverPath { get; set; } = "sat_runner";
+
+        public TimeSpan? TimeLimit { get; set; }
+
+        /// <summary>
+        /// Number of search workers, or null for the solver default
+        /// </summary>
+        public int? NumWorkers { get; set; }
+
+        /// <summary>
+        /// Initial solution hint keyed by expanded variable name
+        /// </summary>
+        public IReadOnlyDictionary<string, double>? Hints { get; set; }
+
+        /// <summary>
+        /// Domain used for integer variables declared without bounds
+        /// </summary>
+        public long DefaultBound { get; set; } = 1_000_000_000;
+
+        public SolveResult Solve(ModelManager manager) => Solve(manager, CancellationToken.None);
+
+        /// <summary>
+        /// Cancellation ends the solver process and throws OperationCanceledException
+        /// </summary>
+        public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken) => Run(manager, null, cancellationToken);
+
+        /// <summary>
+        /// Follows the solver's search log and reports incumbents (objective only, CP-SAT does
+        /// not log the values) and bounds. Stopping the monitor ends the solver process.
+        /// </summary>
+        public SolveResult Solve(ModelManager manager, SolveMonitor monitor, CancellationToken cancellationToken) =>
+            Run(manager, monitor, cancellationToken);
+
+        private SolveResult Run(ModelManager manager, SolveMonitor? monitor, CancellationToken cancellationToken)
+        {
+            cancellationToken.ThrowIfCancellationRequested();
+            var sw = Stopwatch.StartNew();
+            string workDir = Path.Combine(Path.GetTempPath(), $"cpsat_{Guid.NewGuid():N}");
+
+            try
+            {
+                var model = IntegerModel.FromModel(manager);
+                if (model.Warnings.Count > 0)
+                {
+                    return Error($"Model is not a bounded-integer model: {string.Join("; ", model.Warnings)}", sw.Elapsed);
+                }
+
+                var builder = new CpSatModelBuilder(model) { DefaultBound = DefaultBound };
+                Directory.CreateDirectory(workDir);
+
+                string modelFile = Path.Combine(workDir, "model.pb.txt");
+                string responseFile = Path.Combine(workDir, "response.pb.txt");
+                File.WriteAllText(modelFile, builder.Build(Hints));
+
+                var startInfo = new ProcessStartInfo(SolverPath)
+                {
+                    RedirectStandardOutput = true,
+                    RedirectStandardError = true,
+                    UseShellExecute = false
+                };
+                startInfo.ArgumentList.Add($"--input={modelFile}");
+                startInfo.ArgumentList.Add($"--output={responseFile}");
+                startInfo.ArgumentList.Add($"--params={BuildParameters(logSearchProgress: monitor != null)}");
+
+                using var process = Process.Start(startInfo)
+                    ?? throw new InvalidOperationException($"Could not start '{SolverPath}'");
+
+                // Drain stdout so the solver never blocks on a full pipe
+                if (monitor != null)
+                {
+                    process.OutputDataReceived += (_, e) =>
+                    {
+                        if (e.Data != null && ParseLogLine(e.Data) is { } solverEvent)
+                            monitor.Report(solverEvent);
+                    };
+                    process.BeginOutputReadLine();
+                }
+                else
+                {
+                    _ = process.StandardOutput.ReadToEndAsync();
+                }
+                var stderr = process.StandardError.ReadToEndAsync();
+
+                using var stopping = CancellationTokenSource.CreateLinkedTokenSource(cancellationToken, monitor?.StopToken ?? CancellationToken.None);
+                using var registration = stopping.Token.Register(() =>
+                {
+                    try
+                    {
+                        process.Kill(entireProcessTree: true);
+                    }
+                    catch (InvalidOperationException)
+                    {
+                        // Already exited
+                    }
+                });
+
+                var grace = TimeSpan.FromSeconds(30);
+                if (TimeLimit.HasValue && !process.WaitForExit(TimeLimit.Value + grace))
+                {
+                    process.Kill(entireProcessTree: true);
+                    return Error("CP-SAT did not stop within its time limit", sw.Elapsed);
+                }
+
+                process.WaitForExit();
+                cancellationToken.ThrowIfCancellationRequested();
+
+                if (!File.Exists(responseFile))
+                {
+                    if (monitor != null && monitor.IsStopRequested)
+                        return monitor.StoppedResult(sw.Elapsed);
+                    return Error($"CP-SAT exited with code {process.ExitCode}: {stderr.Result.Trim()}", sw.Elapsed);
+                }
+
+                sw.Stop();
+                var result = ParseResponse(File.ReadAllText(responseFile), builder, sw.Elapsed);
+                return monitor != null ? monitor.Complete(result) : result;
+            }
+            catch (Exception ex) when (ex is not OperationCanceledException)
+            {
+                return Error(ex.Message, sw.Elapsed);
+            }
+            finally
+            {
+                if (Directory.Exists(workDir))
+                    Directory.Delete(workDir, recursive: true);
+            }
+        }
+
+        /// <summary>
+        /// Maps a CpSolverResponse (protobuf text format) back to a SolveResult
+        /// </summary>
+        public static SolveResult ParseResponse(string responseText, CpSatModelBuilder builder, TimeSpan elapsed = default)
+        {
+            var fields = ReadTopLevelFields(responseText);
+
+            string cpStatus = fields.TryGetValue("status", out var statusValues) ? statusValues[0] : "UNKNOWN";
+            var status = cpStatus switch
+            {
+                "OPTIMAL" => SolveStatus.Optimal,
+                "FEASIBLE" => SolveStatus.Feasible,
+                "INFEASIBLE" => SolveStatus.Infeasible,
+                _ => SolveStatus.Error
+            };
+
+            var values = new Dictionary<string, double>();
+            if (fields.TryGetValue("solution", out var solution))
+            {
+                for (int i = 0; i < Math.Min(solution.Count, builder.ModelVariableCount); i++)
+                {
+                    values[builder.GetVariableName(i)] = long.Parse(solution[i], CultureInfo.InvariantCulture);
+                }
+            }
+
+            double? objective = ReadDouble(fields, "objective_value");
+            double? bound = ReadDouble(fields, "best_objective_bound");
+            bool hasSolution = status == SolveStatus.Optimal || status == SolveStatus.Feasible;
+
+            return new SolveResult
+            {
+                Status = status,
+                ObjectiveValue = hasSolution ? objective : null,
+                BestBound = hasSolution ? bound : null,
+                MipGap = hasSolution ? SolveMonitor.Gap(objective, bound) : null,
+                VariableValues = values,
+                SolveTime = elapsed,
+                StatusMessage = $"CP-SAT status {cpStatus}"
+            };
+        }
+
+        /// <summary>
+        /// Maps a line of CP-SAT's search log to an event: "#3 0.12s best:8 next:[5,7] ..." is a
+        /// new incumbent, "#Bound 0.20s best:8 next:[6,7]" an improved bound. The bound is the
+        /// end of the "next" interval away from the incumbent (empty once optimality is proven).
+        /// </summary>
+        public static SolverEvent? ParseLogLine(string line)
+        {
+            var match = Regex.Match(line, @"^#(\d+|Bound)\s+([\d.]+)s\s+best:(\S+)\s+next:\[([^\]]*)\]");
+            if (!match.Success ||
+                !double.TryParse(match.Groups[2].Value, NumberStyles.Float, CultureInfo.InvariantCulture, out var seconds) ||
+                !double.TryParse(match.Groups[3].Value, NumberStyles.Float, CultureInfo.InvariantCulture, out var best) ||
+                !double.IsFinite(best))
+                return null;
+
+            double bound = best;
+            var next = match.Groups[4].Value.Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries);
+            if (next.Length == 2 &&
+                double.TryParse(next[0], NumberStyles.Float, CultureInfo.InvariantCulture, out var low) &&
+                double.TryParse(next[1], NumberStyles.Float, CultureInfo.InvariantCulture, out var high))
+            {
+                // Minimizing, the interval lies below the incumbent; maximizing, above it
+                bound = low > best ? high : low;
+            }
+
+            bool isBound = match.Groups[1].Value == "Bound";
+            return new SolverEvent
+            {
+                Kind = isBound ? SolverEventKind.Bound : SolverEventKind.Incumbent,
+                Elapsed = TimeSpan.FromSeconds(seconds),
+                ObjectiveValue = isBound ? null : best,
+                BestBound = bound
+            };
+        }
+
+        private string BuildParameters(bool logSearchProgress)
+        {
+            var parameters = new List<string>();
+
+            if (logSearchProgress)
+                parameters.Add("log_search_progress:true");
+
+            if (TimeLimit.HasValue)
+                parameters.Add($"max_time_in_seconds:{TimeLimit.Value.TotalSeconds.ToString(CultureInfo.InvariantCulture)}");
+
+            if (NumWorkers.HasValue)
+                parameters.Add($"num_workers:{NumWorkers.Value}");
+
+            return string.Join(",", parameters);
+        }
+
+        /// <summary>
+        /// Reads scalar and repeated top-level fields ("key: value" or "key: [a, b]"), skipping nested messages
+        /// </summary>
+        private static Dictionary<string, List<string>> ReadTopLevelFields(string text)
+        {
+            var fields = new Dictionary<string, List<string>>();
+            int depth = 0;
+
+            foreach (var rawLine in text.Split('\n'))
+            {
+                string line = rawLine.Trim();
+                var match = Regex.Match(line, @"^(\w+)\s*:\s*(.+)$");
+
+                if (depth == 0 && match.Success)
+                {
+                    string key = match.Groups[1].Value;
+                    string value = match.Groups[2].Value.Trim();
+
+                    if (!fields.TryGetValue(key, out var list))
+                    {
+                        list = new List<string>();
+                        fields[key] = list;
+                    }
+
+                    if (value.StartsWith("["))
+                        list.AddRange(value.Trim('[', ']').Split(',', StringSplitOptions.TrimEntries | StringSplitOptions.RemoveEmptyEntries));
+                    else
+                        list.Add(value.Trim('"'));
+                }
+
+                depth += line.Count(c => c == '{') - line.Count(c => c == '}');
+            }
+
+            return fields;
+        }
+
+        private static double? ReadDouble(Dictionary<string, List<string>> fields, string key)
+        {
+            return fields.TryGetValue(key, out var values) &&
+                   double.TryParse(values[0], NumberStyles.Float, CultureInfo.InvariantCulture, out var value)
+                ? value
+                : null;
+        }
+
+        private static SolveResult Error(string message, TimeSpan elapsed) => new SolveResult
+        {
+            Status = SolveStatus.Error,
+            StatusMessage = message,
+            SolveTime = elapsed
+        };
+    }
+}
@@ -0,0 +1,200 @@
+using System.Globalization;
+using System.Text;
+using Core.Export;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Translates the discrete part of a model into a CP-SAT CpModelProto (protobuf text format).
+    /// Linear constraints become linear constraints with a domain; indicator, implication and
+    /// disjunctive constraints become enforcement literals combined with bool_or. The model
+    /// language has no interval variables, so optional activities are expressed the same way
+    /// (an indicator that enforces the activity's constraints).
+    /// </summary>
+    public class CpSatModelBuilder
+    {
+        private const long Min = long.MinValue;
+        private const long Max = long.MaxValue;
+
+        private readonly IntegerModel model;
+        private readonly Dictionary<string, int> variableIndex = new Dictionary<string, int>();
+        private readonly List<string> variableNames = new List<string>();
+        private readonly List<(long Lo, long Hi)> domains = new List<(long, long)>();
+        private readonly StringBuilder constraints = new StringBuilder();
+
+        /// <summary>
+        /// Domain used for integer variables declared without bounds (CP-SAT requires finite domains)
+        /// </summary>
+        public long DefaultBound { get; set; } = 1_000_000_000;
+
+        public CpSatModelBuilder(IntegerModel model)
+        {
+            this.model = model ?? throw new ArgumentNullException(nameof(model));
+        }
+
+        /// <summary>
+        /// Number of model variables (introduced literals come after these)
+        /// </summary>
+        public int ModelVariableCount => model.Variables.Count;
+
+        public string GetVariableName(int index) => variableNames[index];
+
+        public bool TryGetVariableIndex(string name, out int index) => variableIndex.TryGetValue(name, out index);
+
+        /// <summary>
+        /// Builds the CpModelProto text, optionally with a solution hint
+        /// </summary>
+        public string Build(IReadOnlyDictionary<string, double>? hints = null)
+        {
+            variableIndex.Clear();
+            variableNames.Clear();
+            domains.Clear();
+            constraints.Clear();
+
+            foreach (var variable in model.Variables)
+            {
+                AddVariable(variable.Name,
+                    variable.LowerBound ?? -DefaultBound,
+                    variable.UpperBound ?? DefaultBound);
+            }
+
+            foreach (var constraint in model.Constraints)
+            {
+                AppendLinear(constraint, DomainOf(constraint), null);
+            }
+
+            foreach (var logical in model.LogicalConstraints)
+            {
+                AppendLogical(logical);
+            }
+
+            var sb = new StringBuilder();
+            sb.AppendLine("name: \"model\"");
+
+            for (int i = 0; i < variableNames.Count; i++)
+            {
+                sb.AppendLine($"variables {{ name: \"{Escape(variableNames[i])}\" domain: {domains[i].Lo} domain: {domains[i].Hi} }}");
+            }
+
+            sb.Append(constraints);
+            AppendObjective(sb);
+            AppendHints(sb, hints);
+
+            return sb.ToString();
+        }
+
+        private int AddVariable(string name, long lo, long hi)
+        {
+            int index = variableNames.Count;
+            variableNames.Add(name);
+            domains.Add((lo, hi));
+            variableIndex[name] = index;
+            return index;
+        }
+
+        private int AddLiteral(string name) => AddVariable(name, 0, 1);
+
+        private void AppendLinear(IntegerLinearConstraint constraint, long[] domain, int? enforcementLiteral)
+        {
+            constraints.Append("constraints { ");
+            constraints.Append($"name: \"{Escape(constraint.Name)}\" ");
+
+            if (enforcementLiteral.HasValue)
+                constraints.Append($"enforcement_literal: {enforcementLiteral.Value} ");
+
+            constraints.Append("linear { ");
+            foreach (var name in constraint.Coefficients.Keys)
+                constraints.Append($"vars: {variableIndex[name]} ");
+            foreach (var coefficient in constraint.Coefficients.Values)
+                constraints.Append($"coeffs: {coefficient} ");
+            foreach (var bound in domain)
+                constraints.Append($"domain: {bound} ");
+            constraints.AppendLine("} }");
+        }
+
+        /// <summary>
+        /// Disjunction: r1 => left, r2 => right, r1 or r2.
+        /// Implication/indicator: (not l) => (not left), r => right, (not l) or r.
+        /// </summary>
+        private void AppendLogical(IntegerLogicalConstraint logical)
+        {
+            int right = AddLiteral($"{logical.Name}_rhs");
+            AppendLinear(logical.Right, DomainOf(logical.Right), right);
+
+            if (logical.Type == LogicalConstraintType.Disjunctive)
+            {
+                int left = AddLiteral($"{logical.Name}_lhs");
+                AppendLinear(logical.Left, DomainOf(logical.Left), left);
+                constraints.AppendLine($"constraints {{ name: \"{Escape(logical.Name)}\" bool_or {{ literals: {left} literals: {right} }} }}");
+            }
+            else
+            {
+                int condition = AddLiteral($"{logical.Name}_cond");
+                AppendLinear(logical.Left, ComplementOf(logical.Left), Negated(condition));
+                constraints.AppendLine($"constraints {{ name: \"{Escape(logical.Name)}\" bool_or {{ literals: {Negated(condition)} literals: {right} }} }}");
+            }
+        }
+
+        private void AppendObjective(StringBuilder sb)
+        {
+            if (model.ObjectiveCoefficients == null || model.ObjectiveCoefficients.Count == 0)
+                return;
+
+            // CP-SAT minimizes scaling_factor * (sum + offset); maximize by negating and scaling by -1
+            bool maximize = model.ObjectiveSense == ObjectiveSense.Maximize;
+            long sign = maximize ? -1 : 1;
+
+            sb.Append("objective { ");
+            foreach (var name in model.ObjectiveCoefficients.Keys)
+                sb.Append($"vars: {variableIndex[name]} ");
+            foreach (var coefficient in model.ObjectiveCoefficients.Values)
+                sb.Append($"coeffs: {sign * coefficient} ");
+            sb.Append($"offset: {(sign * model.ObjectiveConstant).ToString(CultureInfo.InvariantCulture)} ");
+            sb.Append($"scaling_factor: {sign} ");
+            sb.AppendLine("}");
+        }
+
+        private void AppendHints(StringBuilder sb, IReadOnlyDictionary<string, double>? hints)
+        {
+            if (hints == null)
+                return;
+
+            var known = hints
+                .Where(h => variableIndex.ContainsKey(h.Key) && variableIndex[h.Key] < ModelVariableCount)
+                .OrderBy(h => variableIndex[h.Key])
+                .ToList();
+
+            if (known.Count == 0)
+                return;
+
+            sb.Append("solution_hint { ");
+            foreach (var hint in known)
+                sb.Append($"vars: {variableIndex[hint.Key]} ");
+            foreach (var hint in known)
+                sb.Append($"values: {(long)Math.Round(hint.Value)} ");
+            sb.AppendLine("}");
+        }
+
+        private static long[] DomainOf(IntegerLinearConstraint constraint) => constraint.Operator switch
+        {
+            RelationalOperator.LessThanOrEqual => new[] { Min, constraint.Rhs },
+            RelationalOperator.GreaterThanOrEqual => new[] { constraint.Rhs, Max },
+            _ => new[] { constraint.Rhs, constraint.Rhs }
+        };
+
+        private static long[] ComplementOf(IntegerLinearConstraint constraint) => constraint.Operator switch
+        {
+            RelationalOperator.LessThanOrEqual => new[] { constraint.Rhs + 1, Max },
+            RelationalOperator.GreaterThanOrEqual => new[] { Min, constraint.Rhs - 1 },
+            _ => new[] { Min, constraint.Rhs - 1, constraint.Rhs + 1, Max }
+        };
+
+        /// <summary>
+        /// CP-SAT encodes the negation of literal i as -i-1
+        /// </summary>
+        private static int Negated(int literal) => -literal - 1;
+
+        private static string Escape(string value) => value.Replace("\\", "\\\\").Replace("\"", "\\\"");
+    }
+}
@@ -0,0 +1,314 @@
+using System.Globalization;
+using System.Text;
+using Core.Analysis;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Constraint families that may be violated, and the cost per unit of violation
+    /// </summary>
+    public class ElasticGroup
+    {
+        public string Name { get; init; } = "";
+        public double Weight { get; set; } = 1;
+
+        /// <summary>
+        /// Constraint families (constraint names in the model text) belonging to the group
+        /// </summary>
+        public HashSet<string> Families { get; } = new HashSet<string>(StringComparer.Ordinal);
+
+        public override string ToString() => $"{Name} (weight {Weight.ToString(CultureInfo.InvariantCulture)})";
+    }
+
+    /// <summary>
+    /// How far the right-hand side of one row had to move to make the model feasible
+    /// </summary>
+    public class ConstraintRelaxation
+    {
+        public string Constraint { get; init; } = "";
+        public string Group { get; init; } = "";
+
+        /// <summary>
+        /// Change of the right-hand side: positive loosens a &lt;= row, negative loosens a &gt;= row
+        /// </summary>
+        public double Amount { get; init; }
+
+        public double Weight { get; init; }
+
+        public override string ToString() =>
+            $"{Constraint}: rhs {(Amount >= 0 ? "+" : "")}{Amount.ToString("G6", CultureInfo.InvariantCulture)} (group {Group})";
+    }
+
+    public class ElasticResult
+    {
+        /// <summary>
+        /// The model was feasible as stated, so nothing was relaxed
+        /// </summary>
+        public bool WasFeasible { get; init; }
+
+        /// <summary>
+        /// Status of the final solve (the original model, or the relaxation)
+        /// </summary>
+        public SolveStatus Status { get; init; }
+
+        /// <summary>
+        /// Minimal weighted violation found by the relaxation
+        /// </summary>
+        public double? TotalPenalty { get; init; }
+
+        public List<ConstraintRelaxation> Relaxations { get; } = new List<ConstraintRelaxation>();
+
+        /// <summary>
+        /// Solution of the final solve, without the elastic variables
+        /// </summary>
+        public SolveResult? Solution { get; init; }
+
+        public string? StatusMessage { get; init; }
+
+        public string ToReport()
+        {
+            var sb = new StringBuilder();
+            if (WasFeasible)
+                return "Model is feasible; no relaxation needed" + Environment.NewLine;
+
+            sb.AppendLine($"Feasibility relaxation: {Status}" + (StatusMessage != null ? $" ({StatusMessage})" : ""));
+            if (TotalPenalty.HasValue)
+                sb.AppendLine($"Total weighted violation: {TotalPenalty.Value.ToString("G6", CultureInfo.InvariantCulture)}");
+
+            foreach (var group in Relaxations.GroupBy(r => r.Group))
+            {
+                sb.AppendLine($"  {group.Key}:");
+                foreach (var relaxation in group)
+                    sb.AppendLine($"    {relaxation}");
+            }
+
+            return sb.ToString();
+        }
+    }
+
+    /// <summary>
+    /// Feasibility relaxation (elastic programming) over any solver driver, in the spirit of CPLEX
+    /// feasopt: rows of the selected groups get nonnegative elastic variables, the weighted sum of
+    /// violations is minimized, and the rows that had to move are reported. Optionally a second solve
+    /// optimizes the original objective among the minimally relaxed solutions.
+    /// The model is modified only for the duration of the solves.
+    /// </summary>
+    public class FeasibilityRelaxation
+    {
+        private const string ElasticPrefix = "elastic_";
+
+        private readonly ISolverDriver driver;
+
+        public FeasibilityRelaxation(ISolverDriver driver)
+        {
+            this.driver = driver ?? throw new ArgumentNullException(nameof(driver));
+        }
+
+        /// <summary>
+        /// Groups that may be relaxed; when empty every constraint is elastic with weight 1
+        /// </summary>
+        public List<ElasticGroup> Groups { get; } = new List<ElasticGroup>();
+
+        /// <summary>
+        /// Solve the model as stated first and only relax it when it is infeasible
+        /// </summary>
+        public bool CheckFeasibilityFirst { get; set; } = true;
+
+        /// <summary>
+        /// After finding the minimal violation, optimize the original objective subject to it
+        /// </summary>
+        public bool OptimizeOriginalObjective { get; set; }
+
+        /// <summary>
+        /// Relative slack on the minimal violation in the second phase, and the threshold below
+        /// which an elastic value is reported as zero
+        /// </summary>
+        public double Tolerance { get; set; } = NumericTolerance.DefaultAbsolute;
+
+        public ElasticGroup AddGroup(string name, double weight, params string[] families)
+        {
+            var group = new ElasticGroup { Name = name, Weight = weight };
+            group.Families.UnionWith(families);
+            Groups.Add(group);
+            return group;
+        }
+
+        /// <summary>
+        /// Adds a group holding the constraints carrying a tag (or one of its descendants)
+        /// </summary>
+        public ElasticGroup AddTagGroup(ModelTags tags, string tag, double weight = 1)
+        {
+            var families = tags.Select(tag)
+                .Where(e => e.Key.StartsWith("constraint:", StringComparison.Ordinal))
+                .Select(e => e.Key.Substring("constraint:".Length))
+                .ToArray();
+            return AddGroup(tag, weight, families);
+        }
+
+        public ElasticResult Run(ModelManager manager)
+        {
+            if (manager.IndexedEquationTemplates.Count > 0 || manager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot relax: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            // Elastic variables are added to the rows in place, so the rule rows are held for the run
+            using var rows = manager.MaterializeInPlace();
+
+            if (CheckFeasibilityFirst)
+            {
+                var original = driver.Solve(manager);
+                if (original.Status is SolveStatus.Optimal or SolveStatus.Feasible or SolveStatus.Unbounded)
+                    return new ElasticResult { WasFeasible = true, Status = original.Status, Solution = original, StatusMessage = original.StatusMessage };
+                if (original.Status == SolveStatus.Error)
+                    return new ElasticResult { Status = original.Status, Solution = original, StatusMessage = original.StatusMessage };
+            }
+
+            var elastics = new List<(LinearEquation Equation, string Row, ElasticGroup Group, string? Up, string? Down)>();
+            var originalObjective = manager.Objective;
+            LinearEquation? violationCap = null;
+
+            try
+            {
+                AddElasticVariables(manager, elastics);
+
+                var penalty = new Dictionary<string, Expression>();
+                foreach (var elastic in elastics)
+                {
+                    foreach (string? name in new[] { elastic.Up, elastic.Down })
+                    {
+                        if (name != null)
+                            penalty[name] = new ConstantExpression(elastic.Group.Weight);
+                    }
+                }
+
+                manager.Objective = new Objective(ObjectiveSense.Minimize, penalty, new ConstantExpression(0), "elastic_penalty");
+                var relaxed = driver.Solve(manager);
+
+                if (relaxed.Status is not (SolveStatus.Optimal or SolveStatus.Feasible))
+                {
+                    return new ElasticResult
+                    {
+                        Status = relaxed.Status,
+                        StatusMessage = relaxed.StatusMessage ?? "The relaxed model could not be solved"
+                    };
+                }
+
+                double total = penalty.Sum(p => p.Value.Evaluate(manager) * relaxed.VariableValues.GetValueOrDefault(p.Key));
+                var final = relaxed;
+
+                if (OptimizeOriginalObjective && originalObjective != null)
+                {
+                    violationCap = new LinearEquation(
+                        new Dictionary<string, Expression>(penalty),
+                        new ConstantExpression(total * (1 + Tolerance) + Tolerance),
+                        RelationalOperator.LessThanOrEqual,
+                        "elastic_penalty_cap");
+                    manager.Equations.Add(violationCap);
+                    manager.Objective = originalObjective;
+
+                    var optimized = driver.Solve(manager);
+                    if (optimized.Status is SolveStatus.Optimal or SolveStatus.Feasible)
+                        final = optimized;
+                }
+
+                var result = new ElasticResult
+                {
+                    Status = final.Status,
+                    TotalPenalty = total,
+                    Solution = WithoutElastics(final, penalty.Keys.ToHashSet()),
+                    StatusMessage = final.StatusMessage
+                };
+
+                foreach (var (_, row, group, up, down) in elastics)
+                {
+                    double amount = (up != null ? final.VariableValues.GetValueOrDefault(up) : 0) -
+                                    (down != null ? final.VariableValues.GetValueOrDefault(down) : 0);
+                    if (Math.Abs(amount) > Tolerance)
+                        result.Relaxations.Add(new ConstraintRelaxation { Constraint = row, Group = group.Name, Amount = amount, Weight = group.Weight });
+                }
+
+                return result;
+            }
+            finally
+            {
+                manager.Objective = originalObjective;
+                if (violationCap != null)
+                    manager.Equations.Remove(violationCap);
+
+                foreach (var (equation, _, _, up, down) in elastics)
+                {
+                    foreach (string? name in new[] { up, down })
+                    {
+                        if (name == null)
+                            continue;
+                        equation.Coefficients.Remove(name);
+                        manager.IndexedVariables.Remove(name);
+                    }
+                }
+            }
+        }
+
+        /// <summary>
+        /// Adds -up to rows that may be loosened upwards (&lt;=, ==) and +down to rows that may be
+        /// loosened downwards (&gt;=, ==), so the right-hand side effectively moves by up - down
+        /// </summary>
+        private void AddElasticVariables(ModelManager manager, List<(LinearEquation, string, ElasticGroup, string?, string?)> elastics)
+        {
+            var all = new ElasticGroup { Name = "all" };
+            int row = 0;
+
+            foreach (var equation in manager.Equations)
+            {
+                row++;
+                string family = SolutionComparison.GetFamily(equation);
+                var group = Groups.Count == 0 ? all : Groups.FirstOrDefault(g => g.Families.Contains(family));
+                if (group == null)
+                    continue;
+
+                bool up = equation.Operator is RelationalOperator.LessThanOrEqual or RelationalOperator.LessThan or RelationalOperator.Equal;
+                bool down = equation.Operator is RelationalOperator.GreaterThanOrEqual or RelationalOperator.GreaterThan or RelationalOperator.Equal;
+                string? upName = up ? AddVariable(manager, $"{ElasticPrefix}up{row}") : null;
+                string? downName = down ? AddVariable(manager, $"{ElasticPrefix}down{row}") : null;
+
+                if (upName != null)
+                    equation.Coefficients[upName] = new ConstantExpression(-1);
+                if (downName != null)
+                    equation.Coefficients[downName] = new ConstantExpression(1);
+
+                string name = equation.Label ?? (string.IsNullOrEmpty(equation.GetDescription()) ? $"c{row}" : equation.GetDescription());
+                elastics.Add((equation, name, group, upName, downName));
+            }
+        }
+
+        private static string AddVariable(ModelManager manager, string name)
+        {
+            string unique = name;
+            for (int i = 2; manager.IndexedVariables.ContainsKey(unique); i++)
+                unique = $"{name}_{i}";
+
+            manager.IndexedVariables[unique] = new IndexedVariable(unique, "", VariableType.Float, lowerBound: 0);
+            return unique;
+        }
+
+        private static SolveResult WithoutElastics(SolveResult result, HashSet<string> elasticNames)
+        {
+            return new SolveResult
+            {
+                Status = result.Status,
+                ObjectiveValue = result.ObjectiveValue,
+                VariableValues = result.VariableValues
+                    .Where(v => !elasticNames.Contains(v.Key))
+                    .ToDictionary(v => v.Key, v => v.Value),
+                ConstraintSlacks = result.ConstraintSlacks,
+                MipGap = result.MipGap,
+                BestBound = result.BestBound,
+                SolveTime = result.SolveTime,
+                StatusMessage = result.StatusMessage
+            };
+        }
+    }
+}
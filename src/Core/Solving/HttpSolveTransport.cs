@@ -0,0 +1,133 @@
+using System.Globalization;
+using System.Net;
+using System.Text;
+using System.Text.Json;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Talks to a solve server over HTTP:
+    /// HEAD /jobs/{id}/model answers with the bytes received in Upload-Offset (404 for a new job);
+    /// PATCH /jobs/{id}/model appends a chunk at Upload-Offset of a model of Upload-Length bytes;
+    /// POST /jobs/{id}/start takes {"solver": ..., "options": {...}};
+    /// GET /jobs/{id}/log?offset=n returns the log from byte n, with the job state in Job-State
+    /// (running, completed, failed), the next offset in Log-Offset and failures in Job-Message;
+    /// GET /jobs/{id}/result returns the SolveResult as JSON; DELETE /jobs/{id} cancels.
+    /// Connection failures, timeouts, 408, 429 and 5xx responses are transient.
+    /// </summary>
+    public class HttpSolveTransport : IRemoteSolveTransport
+    {
+        private readonly Uri baseAddress;
+        private readonly HttpClient http;
+
+        public HttpSolveTransport(Uri baseAddress, HttpClient? http = null)
+        {
+            this.baseAddress = new Uri(baseAddress.ToString().TrimEnd('/') + "/");
+            this.http = http ?? new HttpClient();
+        }
+
+        public string Endpoint => baseAddress.ToString().TrimEnd('/');
+
+        /// <summary>
+        /// Called on every request before it is sent, e.g. to add a bearer token or sign it
+        /// </summary>
+        public Func<HttpRequestMessage, CancellationToken, Task>? Authenticate { get; set; }
+
+        public async Task<long> GetUploadedLengthAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendAsync(HttpMethod.Head, $"jobs/{jobId}/model", null, cancellationToken);
+            if (response.StatusCode == HttpStatusCode.NotFound)
+                return 0;
+
+            await EnsureSuccessAsync(response, cancellationToken);
+            return ReadLong(response, "Upload-Offset") ?? 0;
+        }
+
+        public async Task UploadAsync(string jobId, long offset, long totalLength, ReadOnlyMemory<byte> chunk, CancellationToken cancellationToken)
+        {
+            var content = new ReadOnlyMemoryContent(chunk);
+            content.Headers.ContentType = new System.Net.Http.Headers.MediaTypeHeaderValue("application/offset+octet-stream");
+            using var response = await SendAsync(HttpMethod.Patch, $"jobs/{jobId}/model", content, cancellationToken, request =>
+            {
+                request.Headers.TryAddWithoutValidation("Upload-Offset", offset.ToString(CultureInfo.InvariantCulture));
+                request.Headers.TryAddWithoutValidation("Upload-Length", totalLength.ToString(CultureInfo.InvariantCulture));
+            });
+            await EnsureSuccessAsync(response, cancellationToken);
+        }
+
+        public async Task StartAsync(string jobId, string solver, IReadOnlyDictionary<string, string> options, CancellationToken cancellationToken)
+        {
+            string body = JsonSerializer.Serialize(new { solver, options }, RemoteSolverDriver.JsonOptions);
+            using var response = await SendAsync(HttpMethod.Post, $"jobs/{jobId}/start",
+                new StringContent(body, Encoding.UTF8, "application/json"), cancellationToken);
+            await EnsureSuccessAsync(response, cancellationToken);
+        }
+
+        public async Task<RemoteJobStatus> PollAsync(string jobId, long logOffset, CancellationToken cancellationToken)
+        {
+            using var response = await SendAsync(HttpMethod.Get, $"jobs/{jobId}/log?offset={logOffset}", null, cancellationToken);
+            await EnsureSuccessAsync(response, cancellationToken);
+
+            byte[] log = await response.Content.ReadAsByteArrayAsync(cancellationToken);
+            string state = ReadHeader(response, "Job-State") ?? "running";
+            return new RemoteJobStatus
+            {
+                State = state switch
+                {
+                    "completed" => RemoteJobState.Completed,
+                    "failed" => RemoteJobState.Failed,
+                    _ => RemoteJobState.Running
+                },
+                Log = Encoding.UTF8.GetString(log),
+                LogOffset = ReadLong(response, "Log-Offset") ?? logOffset + log.Length,
+                Message = ReadHeader(response, "Job-Message")
+            };
+        }
+
+        public async Task<SolveResult> GetResultAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendAsync(HttpMethod.Get, $"jobs/{jobId}/result", null, cancellationToken);
+            await EnsureSuccessAsync(response, cancellationToken);
+            return JsonSerializer.Deserialize<SolveResult>(await response.Content.ReadAsStringAsync(cancellationToken), RemoteSolverDriver.JsonOptions)
+                   ?? throw new RemoteSolveException($"{Endpoint} returned an empty result", false);
+        }
+
+        public async Task CancelAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendAsync(HttpMethod.Delete, $"jobs/{jobId}", null, cancellationToken);
+            if (response.StatusCode != HttpStatusCode.NotFound)
+                await EnsureSuccessAsync(response, cancellationToken);
+        }
+
+        private async Task<HttpResponseMessage> SendAsync(HttpMethod method, string path, HttpContent? content,
+            CancellationToken cancellationToken, Action<HttpRequestMessage>? configure = null)
+        {
+            var request = new HttpRequestMessage(method, new Uri(baseAddress, path)) { Content = content };
+            configure?.Invoke(request);
+            if (Authenticate != null)
+                await Authenticate(request, cancellationToken);
+
+            try
+            {
+                return await http.SendAsync(request, cancellationToken);
+            }
+            catch (HttpRequestException ex)
+            {
+                throw new RemoteSolveException($"{method} {path} on {Endpoint} failed: {ex.Message}", true, ex);
+            }
+            catch (TaskCanceledException ex) when (!cancellationToken.IsCancellationRequested)
+            {
+                throw new RemoteSolveException($"{method} {path} on {Endpoint} timed out", true, ex);
+            }
+        }
+
+        private Task EnsureSuccessAsync(HttpResponseMessage response, CancellationToken cancellationToken) =>
+            RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+
+        private static string? ReadHeader(HttpResponseMessage response, string name) =>
+            response.Headers.TryGetValues(name, out var values) ? values.FirstOrDefault() : null;
+
+        private static long? ReadLong(HttpResponseMessage response, string name) =>
+            long.TryParse(ReadHeader(response, name), NumberStyles.Integer, CultureInfo.InvariantCulture, out var value) ? value : null;
+    }
+}
@@ -0,0 +1,80 @@
+namespace Core.Solving
+{
+    /// <summary>
+    /// Kinds of model a backend can solve, for automatic solver selection
+    /// </summary>
+    [Flags]
+    public enum SolverCapabilities
+    {
+        None = 0,
+
+        /// <summary>Continuous variables (LP and the continuous part of MIP)</summary>
+        Continuous = 1,
+
+        /// <summary>Integer and binary variables</summary>
+        Integer = 2,
+
+        /// <summary>Logical constraints (disjunctions, implications)</summary>
+        Logical = 4,
+
+        /// <summary>Products of variables in constraints or objective</summary>
+        Quadratic = 8,
+
+        /// <summary>Other nonlinear terms (exp, log, powers, division by variables)</summary>
+        Nonlinear = 16,
+
+        /// <summary>Global optima of nonconvex models (spatial branch-and-bound)</summary>
+        Nonconvex = 32
+    }
+
+    /// <summary>
+    /// A backend that can solve a fully-expanded ModelManager.
+    /// Implementations must be safe to call concurrently on different ModelManager instances.
+    /// </summary>
+    public interface ISolverDriver
+    {
+        /// <summary>
+        /// Short name of the backend (e.g. "CPLEX"), used in reports and logs
+        /// </summary>
+        string Name { get; }
+
+        /// <summary>
+        /// What the backend can solve; by default linear models with continuous and integer variables
+        /// </summary>
+        SolverCapabilities Capabilities => SolverCapabilities.Continuous | SolverCapabilities.Integer;
+
+        SolveResult Solve(ModelManager manager);
+
+        /// <summary>
+        /// Solves with cancellation. The default checks the token before and after the solve;
+        /// drivers that can stop their backend early (e.g. by ending a solver process) override it.
+        /// Throws OperationCanceledException when cancelled.
+        /// </summary>
+        SolveResult Solve(ModelManager manager, CancellationToken cancellationToken)
+        {
+            cancellationToken.ThrowIfCancellationRequested();
+            var result = Solve(manager);
+            cancellationToken.ThrowIfCancellationRequested();
+            return result;
+        }
+
+        /// <summary>
+        /// Solves while reporting incumbents, bounds and node counts to the monitor. Stopping the
+        /// monitor ends the solve with its incumbent instead of throwing. The default reports the
+        /// final solution only; drivers that see the search as it runs override it.
+        /// </summary>
+        SolveResult Solve(ModelManager manager, SolveMonitor monitor, CancellationToken cancellationToken)
+        {
+            var started = DateTime.UtcNow;
+            using var linked = CancellationTokenSource.CreateLinkedTokenSource(cancellationToken, monitor.StopToken);
+            try
+            {
+                return monitor.Complete(Solve(manager, linked.Token));
+            }
+            catch (OperationCanceledException) when (monitor.IsStopRequested && !cancellationToken.IsCancellationRequested)
+            {
+                return monitor.StoppedResult(DateTime.UtcNow - started);
+            }
+        }
+    }
+}
@@ -0,0 +1,629 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Parsing;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// A named key figure of a solution, from a "// @kpi name = expression" line of the model
+    /// </summary>
+    public class KpiDefinition
+    {
+        public string Name { get; init; } = "";
+        public string Expression { get; init; } = "";
+        public int LineNumber { get; init; }
+
+        public override string ToString() => $"{Name} = {Expression}";
+    }
+
+    /// <summary>
+    /// The value of a KPI in one solution, or why it could not be evaluated
+    /// </summary>
+    public class KpiValue
+    {
+        public string Name { get; init; } = "";
+        public double? Value { get; init; }
+        public string? Error { get; init; }
+
+        public override string ToString() =>
+            $"{Name} = " + (Value.HasValue ? Value.Value.ToString("G6", CultureInfo.InvariantCulture) : $"({Error})");
+    }
+
+    /// <summary>
+    /// The KPI values of one solve, as a table, and the alerts they raised
+    /// </summary>
+    public class KpiReport
+    {
+        public List<KpiValue> Values { get; } = new List<KpiValue>();
+        public List<ReportAlert> Alerts { get; } = new List<ReportAlert>();
+
+        /// <summary>
+        /// The report of a solve result's stored KPI values, e.g. of an archived run
+        /// </summary>
+        public static KpiReport From(SolveResult result)
+        {
+            var report = new KpiReport();
+            report.Values.AddRange(result.Kpis.Select(k => new KpiValue { Name = k.Key, Value = k.Value }));
+            report.Alerts.AddRange(result.Alerts);
+            return report;
+        }
+
+        public string ToTable()
+        {
+            var rows = Rows();
+            var widths = rows[0].Select((_, i) => rows.Max(r => r[i].Length)).ToArray();
+            var sb = new StringBuilder();
+            foreach (var row in rows)
+            {
+                // Names left-aligned, values right-aligned
+                sb.AppendLine($"{row[0].PadRight(widths[0])}  {row[1].PadLeft(widths[1])}".TrimEnd());
+            }
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// The table as comma-separated values, for spreadsheets; values are written in full precision
+        /// </summary>
+        public string ToCsv()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine("KPI,Value");
+            foreach (var value in Values)
+                sb.AppendLine($"{value.Name},{(value.Value.HasValue ? value.Value.Value.ToString("R", CultureInfo.InvariantCulture) : "")}");
+            return sb.ToString();
+        }
+
+        private List<string[]> Rows()
+        {
+            var rows = new List<string[]> { new[] { "KPI", "Value" } };
+            rows.AddRange(Values.Select(v => new[]
+            {
+                v.Name,
+                v.Value.HasValue ? v.Value.Value.ToString("G6", CultureInfo.InvariantCulture) : $"error: {v.Error}"
+            }));
+            return rows;
+        }
+    }
+
+    /// <summary>
+    /// The KPIs a model defines, so reporting lives with the model rather than in scripts that
+    /// read its results:
+    /// <code>
+    /// // @kpi generation = sum(u in Units, t in T) gen[u][t]
+    /// // @kpi spill = sum(t in T) spill[t]
+    /// // @kpi averagePrice = sum(t in T) price[t] * sold[t] / sum(t in T) sold[t]
+    /// </code>
+    /// An expression may use numbers, + - * / ^, parentheses, parameters, variables (at their
+    /// solution value), scalar and indexed decision expressions, KPIs defined before it, the
+    /// aggregates sum, prod, min, max and avg over sets or ranges ("t in T", "t in 1..n") and the
+    /// functions abs, sqrt, exp, log, floor, ceil, round, pow, min and max. Custom tables (see
+    /// ModelTables) act as sets of row numbers whose columns are read as "table.column[row]".
+    /// Evaluate computes the KPIs of a solution and checks the alert rules (see AlertRule), storing
+    /// both in the SolveResult so they are archived with the run.
+    /// </summary>
+    public class ReportDefinition
+    {
+        private static readonly Regex annotationPattern = new Regex(@"^[ \t]*//[ \t]*@(kpi|alert)\b[ \t]*(.*?)[ \t]*$", RegexOptions.Multiline);
+        private static readonly Regex definitionPattern = new Regex(@"^([A-Za-z_]\w*)\s*=\s*(.+)$");
+        private static readonly Regex dexprPattern = new Regex(@"^dexpr\s+\w+\+?\s+(\w+)\s*((?:\[[^\]]*\]\s*)*)=\s*(.+?);?\s*$", RegexOptions.Singleline);
+
+        private readonly Dictionary<string, Formula> formulas = new Dictionary<string, Formula>(StringComparer.Ordinal);
+
+        public List<KpiDefinition> Kpis { get; } = new List<KpiDefinition>();
+
+        public List<AlertRule> Alerts { get; } = new List<AlertRule>();
+
+        /// <summary>
+        /// Absolute tolerance of soft constraint violations and utilization bounds
+        /// </summary>
+        public NumericTolerance Tolerance { get; set; } = NumericTolerance.Default;
+
+        public KpiDefinition? Find(string name) => Kpis.FirstOrDefault(k => k.Name == name);
+
+        /// <summary>
+        /// Reads the @kpi and @alert annotations of a model text, reporting malformed ones at
+        /// their line
+        /// </summary>
+        public void Read(string modelText, ParseSessionResult result)
+        {
+            var alerts = new List<AlertRule>();
+            foreach (Match m in annotationPattern.Matches(modelText))
+            {
+                int lineNumber = 1 + modelText.Take(m.Index).Count(c => c == '\n');
+                if (m.Groups[1].Value == "alert")
+                {
+                    try
+                    {
+                        alerts.Add(AlertRule.Parse(m.Groups[2].Value, lineNumber));
+                    }
+                    catch (InvalidOperationException ex)
+                    {
+                        result.AddError(ex.Message, lineNumber);
+                    }
+                    continue;
+                }
+
+                var definition = definitionPattern.Match(m.Groups[2].Value);
+                if (!definition.Success)
+                {
+                    result.AddError("Expected '@kpi <name> = <expression>'", lineNumber);
+                    continue;
+                }
+
+                string name = definition.Groups[1].Value;
+                if (Find(name) is KpiDefinition existing)
+                {
+                    result.AddError($"KPI '{name}' is already defined on line {existing.LineNumber}", lineNumber);
+                    continue;
+                }
+
+                try
+                {
+                    formulas[name] = Formula.Parse(definition.Groups[2].Value);
+                }
+                catch (InvalidOperationException ex)
+                {
+                    result.AddError($"KPI '{name}': {ex.Message}", lineNumber);
+                    continue;
+                }
+                Kpis.Add(new KpiDefinition { Name = name, Expression = definition.Groups[2].Value, LineNumber = lineNumber });
+            }
+
+            // A rule may come before the KPI it watches
+            foreach (var alert in alerts)
+            {
+                if (alert.Kpi != null && Find(alert.Kpi) == null)
+                    result.AddError($"Alert on unknown KPI '{alert.Kpi}'", alert.LineNumber);
+                else
+                    Alerts.Add(alert);
+            }
+        }
+
+        /// <summary>
+        /// Evaluates the KPIs at a solution in definition order and stores their values in its
+        /// Kpis, then its alerts in Alerts. A KPI that cannot be evaluated (a missing value, an
+        /// unknown name) is reported in the returned report and left out of the stored values.
+        /// </summary>
+        public KpiReport Evaluate(ModelManager manager, SolveResult solution)
+        {
+            var report = new KpiReport();
+            var context = new Context(manager, solution.VariableValues, this);
+            solution.Kpis.Clear();
+
+            foreach (var kpi in Kpis)
+            {
+                try
+                {
+                    double value = formulas[kpi.Name].Evaluate(context);
+                    solution.Kpis[kpi.Name] = value;
+                    report.Values.Add(new KpiValue { Name = kpi.Name, Value = value });
+                }
+                catch (InvalidOperationException ex)
+                {
+                    report.Values.Add(new KpiValue { Name = kpi.Name, Error = ex.Message });
+                }
+                context.Evaluated.Add(kpi.Name);
+            }
+
+            solution.Alerts.Clear();
+            foreach (var rule in Alerts)
+                solution.Alerts.AddRange(rule.Check(manager, solution, Tolerance));
+            report.Alerts.AddRange(solution.Alerts);
+            return report;
+        }
+
+        /// <summary>
+        /// The body and index names of a decision expression, read from the model source
+        /// </summary>
+        private (Formula Body, List<string> Indices)? ReadExpression(ModelManager manager, string name)
+        {
+            foreach (string text in manager.SourceTexts)
+            {
+                var statement = ModelSource.Parse(text).Find("dexpr:" + name);
+                var match = statement == null ? Match.Empty : dexprPattern.Match(statement.Code);
+                if (!match.Success)
+                    continue;
+
+                var indices = match.Groups[2].Value.Split(new[] { '[', ']', ',' }, StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries)
+                    .Select(part => part.Split(' ', StringSplitOptions.RemoveEmptyEntries)[0])
+                    .ToList();
+                return (Formula.Parse(match.Groups[3].Value), indices);
+            }
+            return null;
+        }
+
+        /// <summary>
+        /// What a formula is evaluated against: the model, the solution and the bound indices
+        /// </summary>
+        private class Context
+        {
+            private readonly ReportDefinition report;
+            private readonly Dictionary<string, (Formula Body, List<string> Indices)?> expressions =
+                new Dictionary<string, (Formula, List<string>)?>(StringComparer.Ordinal);
+
+            public Context(ModelManager manager, IReadOnlyDictionary<string, double> values, ReportDefinition report)
+            {
+                Manager = manager;
+                Values = values;
+                this.report = report;
+            }
+
+            public ModelManager Manager { get; }
+            public IReadOnlyDictionary<string, double> Values { get; }
+            public Dictionary<string, int> Indices { get; private set; } = new Dictionary<string, int>(StringComparer.Ordinal);
+
+            /// <summary>
+            /// KPIs already evaluated (successfully or not), in order
+            /// </summary>
+            public HashSet<string> Evaluated { get; } = new HashSet<string>(StringComparer.Ordinal);
+
+            public double Resolve(string name, List<int> subscripts)
+            {
+                if (subscripts.Count == 0 && Indices.TryGetValue(name, out int index))
+                    return index;
+
+                if (report.Find(name) != null)
+                {
+                    if (!Evaluated.Contains(name))
+                        throw new InvalidOperationException($"KPI '{name}' is not defined before this one");
+                    return report.formulas[name].Evaluate(this);
+                }
+
+                if (Manager.Parameters.TryGetValue(name, out var parameter))
+                    return ParameterValue(parameter, subscripts);
+
+                int dot = name.IndexOf('.');
+                if (dot > 0 && Manager.Tables.Find(name.Substring(0, dot)) is ModelDataTable table)
+                {
+                    if (subscripts.Count != 1 || subscripts[0] < 1 || subscripts[0] > table.Rows.Count)
+                        throw new InvalidOperationException($"'{name}' takes one row number from 1 to {table.Rows.Count}");
+                    return table.Number(subscripts[0] - 1, name.Substring(dot + 1));
+                }
+
+                if (Manager.IndexedVariables.ContainsKey(name))
+                {
+                    string variable = subscripts.Count == 0 ? name : name + string.Join("_", subscripts);
+                    return Values.TryGetValue(variable, out double value)
+                        ? value
+                        : throw new InvalidOperationException($"The solution has no value for '{variable}'");
+                }
+
+                if (Manager.DecisionExpressions.ContainsKey(name))
+                {
+                    if (!expressions.TryGetValue(name, out var expression))
+                        expressions[name] = expression = report.ReadExpression(Manager, name);
+                    if (expression == null)
+                        throw new InvalidOperationException($"Decision expression '{name}' is not in the model source");
+                    if (expression.Value.Indices.Count != subscripts.Count)
+                        throw new InvalidOperationException($"Decision expression '{name}' takes {expression.Value.Indices.Count} indices");
+
+                    var outer = Indices;
+                    Indices = new Dictionary<string, int>(StringComparer.Ordinal);
+                    for (int i = 0; i < subscripts.Count; i++)
+                        Indices[expression.Value.Indices[i]] = subscripts[i];
+                    try
+                    {
+                        return expression.Value.Body.Evaluate(this);
+                    }
+                    finally
+                    {
+                        Indices = outer;
+                    }
+                }
+
+                throw new InvalidOperationException($"Unknown name '{name}'");
+            }
+
+            public IEnumerable<int> Members(string set)
+            {
+                if (Manager.Ranges.TryGetValue(set, out var range))
+                    return range.GetValues(Manager);
+                if (Manager.Sets.TryGetValue(set, out var members))
+                    return members;
+                if (Manager.IndexSets.TryGetValue(set, out var indexSet))
+                    return indexSet.GetIndices();
+                if (Manager.Tables.Find(set) is ModelDataTable table)
+                    return Enumerable.Range(1, table.Rows.Count);
+                throw new InvalidOperationException($"Set, range or table '{set}' not found");
+            }
+
+            private double ParameterValue(Parameter parameter, List<int> subscripts)
+            {
+                if (subscripts.Count != parameter.Dimensionality)
+                    throw new InvalidOperationException($"Parameter '{parameter.Name}' takes {parameter.Dimensionality} indices");
+
+                object? value = parameter.IsComputed ? parameter.EvaluateComputed(Manager, subscripts.ToArray())
+                    : subscripts.Count switch
+                    {
+                        0 => parameter.Value,
+                        1 => parameter.GetIndexedValue(subscripts[0]),
+                        2 => parameter.GetIndexedValue(subscripts[0], subscripts[1]),
+                        _ => parameter.GetMultiDimValue(subscripts.ToArray())
+                    };
+
+                return value switch
+                {
+                    null => throw new InvalidOperationException($"Parameter '{parameter.Name}' has no value" +
+                                                                (subscripts.Count > 0 ? $" at [{string.Join(", ", subscripts)}]" : "")),
+                    bool flag => flag ? 1 : 0,
+                    IConvertible number when value is not string => number.ToDouble(CultureInfo.InvariantCulture),
+                    _ => throw new InvalidOperationException($"Parameter '{parameter.Name}' is not numeric")
+                };
+            }
+
+            /// <summary>
+            /// Evaluates the body once per combination of the bindings' members
+            /// </summary>
+            public IEnumerable<double> Expand(List<(string Index, string? Set, Formula? From, Formula? To)> bindings, Formula body)
+            {
+                return Expand(bindings, 0, body);
+            }
+
+            private IEnumerable<double> Expand(List<(string Index, string? Set, Formula? From, Formula? To)> bindings, int position, Formula body)
+            {
+                if (position == bindings.Count)
+                {
+                    yield return body.Evaluate(this);
+                    yield break;
+                }
+
+                var (index, set, from, to) = bindings[position];
+                IEnumerable<int> members;
+                if (set != null)
+                {
+                    members = Members(set);
+                }
+                else
+                {
+                    int first = (int)Math.Round(from!.Evaluate(this));
+                    members = Enumerable.Range(first, Math.Max(0, (int)Math.Round(to!.Evaluate(this)) - first + 1));
+                }
+
+                bool shadowed = Indices.TryGetValue(index, out int outer);
+                foreach (int member in members.ToList())
+                {
+                    Indices[index] = member;
+                    foreach (double value in Expand(bindings, position + 1, body))
+                        yield return value;
+                }
+                if (shadowed)
+                    Indices[index] = outer;
+                else
+                    Indices.Remove(index);
+            }
+        }
+
+        /// <summary>
+        /// A parsed KPI expression. Names are resolved when it is evaluated, so a formula can be
+        /// read before the data is.
+        /// </summary>
+        private class Formula
+        {
+            private static readonly HashSet<string> aggregates = new HashSet<string>(StringComparer.Ordinal) { "sum", "prod", "min", "max", "avg" };
+
+            private readonly Func<Context, double> evaluate;
+
+            private Formula(Func<Context, double> evaluate)
+            {
+                this.evaluate = evaluate;
+            }
+
+            public double Evaluate(Context context) => evaluate(context);
+
+            public static Formula Parse(string text)
+            {
+                var tokens = ModelLexer.Tokenize(text).Where(t => t.Kind is not (SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring)).ToList();
+                var reader = new Reader(tokens);
+                var formula = reader.Sum();
+                if (reader.Current != null)
+                    throw new InvalidOperationException($"Unexpected '{reader.Current.Text}' at column {reader.Current.Column}");
+                return formula;
+            }
+
+            /// <summary>
+            /// Recursive descent over the tokens: sums of products of powers of unary terms. An
+            /// aggregate's body is a product, so "sum(t in T) a[t] * x[t] + 1" adds 1 once.
+            /// </summary>
+            private class Reader
+            {
+                private readonly List<SyntaxToken> tokens;
+                private int position;
+
+                public Reader(List<SyntaxToken> tokens)
+                {
+                    this.tokens = tokens;
+                }
+
+                public SyntaxToken? Current => position < tokens.Count ? tokens[position] : null;
+
+                public Formula Sum()
+                {
+                    var left = Product();
+                    while (Current?.Text is "+" or "-")
+                    {
+                        bool add = Next().Text == "+";
+                        var (a, b) = (left, Product());
+                        left = new Formula(c => add ? a.Evaluate(c) + b.Evaluate(c) : a.Evaluate(c) - b.Evaluate(c));
+                    }
+                    return left;
+                }
+
+                private Formula Product()
+                {
+                    var left = Unary();
+                    while (Current?.Text is "*" or "/")
+                    {
+                        bool multiply = Next().Text == "*";
+                        var (a, b) = (left, Unary());
+                        left = new Formula(c =>
+                        {
+                            if (multiply)
+                                return a.Evaluate(c) * b.Evaluate(c);
+                            double divisor = b.Evaluate(c);
+                            return divisor == 0 ? throw new InvalidOperationException("Division by zero") : a.Evaluate(c) / divisor;
+                        });
+                    }
+                    return left;
+                }
+
+                private Formula Unary()
+                {
+                    if (Current?.Text == "-")
+                    {
+                        Next();
+                        var operand = Unary();
+                        return new Formula(c => -operand.Evaluate(c));
+                    }
+                    if (Current?.Text == "+")
+                        Next();
+
+                    var power = Primary();
+                    if (Current?.Text == "^")
+                    {
+                        Next();
+                        var (a, b) = (power, Unary());
+                        power = new Formula(c => Math.Pow(a.Evaluate(c), b.Evaluate(c)));
+                    }
+                    return power;
+                }
+
+                private Formula Primary()
+                {
+                    var token = Current ?? throw new InvalidOperationException("Unexpected end of expression");
+
+                    if (token.Kind == SyntaxTokenKind.Number)
+                    {
+                        Next();
+                        if (!double.TryParse(token.Text, NumberStyles.Float, CultureInfo.InvariantCulture, out double number))
+                            throw new InvalidOperationException($"Invalid number '{token.Text}'");
+                        return new Formula(_ => number);
+                    }
+
+                    if (token.Text == "(")
+                    {
+                        Next();
+                        var inner = Sum();
+                        Expect(")");
+                        return inner;
+                    }
+
+                    if (token.Kind is not (SyntaxTokenKind.Identifier or SyntaxTokenKind.Function))
+                        throw new InvalidOperationException($"Unexpected '{token.Text}' at column {token.Column}");
+
+                    Next();
+                    if (Current?.Text == "(")
+                        return aggregates.Contains(token.Text) && IsBinding(position + 1) ? Aggregate(token.Text) : Function(token.Text);
+
+                    // A column of a custom table: "maintenance.hours[r]"
+                    string name = token.Text;
+                    if (Current?.Text == "." && position + 1 < tokens.Count && tokens[position + 1].Kind is SyntaxTokenKind.Identifier or SyntaxTokenKind.Keyword or SyntaxTokenKind.Function or SyntaxTokenKind.Type)
+                    {
+                        Next();
+                        name += "." + Next().Text;
+                    }
+
+                    var subscripts = new List<Formula>();
+                    while (Current?.Text == "[")
+                    {
+                        Next();
+                        subscripts.Add(Sum());
+                        while (Current?.Text == ",")
+                        {
+                            Next();
+                            subscripts.Add(Sum());
+                        }
+                        Expect("]");
+                    }
+
+                    return new Formula(c => c.Resolve(name, subscripts.Select(s => (int)Math.Round(s.Evaluate(c))).ToList()));
+                }
+
+                private bool IsBinding(int at) =>
+                    at + 1 < tokens.Count && tokens[at].Kind == SyntaxTokenKind.Identifier && tokens[at + 1].Text == "in";
+
+                private Formula Aggregate(string name)
+                {
+                    Expect("(");
+                    var bindings = new List<(string Index, string? Set, Formula? From, Formula? To)>();
+                    do
+                    {
+                        if (bindings.Count > 0)
+                            Next();
+                        if (!IsBinding(position))
+                            throw new InvalidOperationException($"Expected '<index> in <set>' in {name}(...)");
+
+                        string index = Next().Text;
+                        Next();
+                        if (Current?.Kind == SyntaxTokenKind.Identifier && position + 1 < tokens.Count && tokens[position + 1].Text is "," or ")")
+                        {
+                            bindings.Add((index, Next().Text, null, null));
+                            continue;
+                        }
+
+                        var from = Sum();
+                        Expect("..");
+                        bindings.Add((index, null, from, Sum()));
+                    }
+                    while (Current?.Text == ",");
+                    Expect(")");
+
+                    var body = Product();
+                    return new Formula(c =>
+                    {
+                        var values = c.Expand(bindings, body).ToList();
+                        return name switch
+                        {
+                            "sum" => values.Sum(),
+                            "prod" => values.Aggregate(1.0, (a, b) => a * b),
+                            _ when values.Count == 0 => throw new InvalidOperationException($"{name}(...) over an empty set"),
+                            "min" => values.Min(),
+                            "max" => values.Max(),
+                            _ => values.Average()
+                        };
+                    });
+                }
+
+                private Formula Function(string name)
+                {
+                    Expect("(");
+                    var arguments = new List<Formula> { Sum() };
+                    while (Current?.Text == ",")
+                    {
+                        Next();
+                        arguments.Add(Sum());
+                    }
+                    Expect(")");
+
+                    Func<double[], double> function = (name, arguments.Count) switch
+                    {
+                        ("abs", 1) => a => Math.Abs(a[0]),
+                        ("sqrt", 1) => a => Math.Sqrt(a[0]),
+                        ("exp", 1) => a => Math.Exp(a[0]),
+                        ("log", 1) => a => Math.Log(a[0]),
+                        ("floor", 1) => a => Math.Floor(a[0]),
+                        ("ceil", 1) => a => Math.Ceiling(a[0]),
+                        ("round", 1) => a => Math.Round(a[0], MidpointRounding.AwayFromZero),
+                        ("pow", 2) => a => Math.Pow(a[0], a[1]),
+                        ("min", > 1) => a => a.Min(),
+                        ("max", > 1) => a => a.Max(),
+                        _ => throw new InvalidOperationException($"Unknown function {name} with {arguments.Count} arguments")
+                    };
+                    return new Formula(c => function(arguments.Select(a => a.Evaluate(c)).ToArray()));
+                }
+
+                private SyntaxToken Next() => tokens[position++];
+
+                private void Expect(string text)
+                {
+                    if (Current?.Text != text)
+                        throw new InvalidOperationException(Current == null
+                            ? $"Expected '{text}' at end of expression"
+                            : $"Expected '{text}' but found '{Current.Text}' at column {Current.Column}");
+                    Next();
+                }
+            }
+        }
+    }
+}
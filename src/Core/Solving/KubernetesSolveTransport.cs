@@ -0,0 +1,448 @@
+using System.Collections.Concurrent;
+using System.Net;
+using System.Net.Http.Headers;
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Nodes;
+using Core.Storage;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Shape of the Kubernetes Job that runs one solve. The pod gets an emptyDir volume at
+    /// /work; an init container downloads the model there from a presigned object storage URL,
+    /// then the solver container runs "&lt;Command&gt; --solver &lt;solver&gt; [--option value ...]
+    /// /work/model.mof.json /work/result.json" and uploads result.json to the presigned
+    /// RESULT_URL with curl, so the solver image needs sh and curl.
+    /// </summary>
+    public class KubernetesJobTemplate
+    {
+        public string Image { get; set; } = "";
+
+        /// <summary>
+        /// Solver entry point inside the image
+        /// </summary>
+        public List<string> Command { get; } = new List<string> { "modeledit-solve" };
+
+        /// <summary>
+        /// Image of the init container that fetches the model; it must provide curl
+        /// </summary>
+        public string TransferImage { get; set; } = "curlimages/curl:8.10.1";
+
+        /// <summary>
+        /// Resource quantities in Kubernetes notation, e.g. "16" CPUs or "128Gi" memory
+        /// </summary>
+        public string? CpuRequest { get; set; }
+        public string? MemoryRequest { get; set; }
+        public string? CpuLimit { get; set; }
+        public string? MemoryLimit { get; set; }
+
+        /// <summary>
+        /// Size limit of the /work volume, e.g. "50Gi" for very large models
+        /// </summary>
+        public string? VolumeSizeLimit { get; set; }
+
+        public string? ServiceAccountName { get; set; }
+        public Dictionary<string, string> NodeSelector { get; } = new Dictionary<string, string>();
+        public Dictionary<string, string> Labels { get; } = new Dictionary<string, string>();
+
+        /// <summary>
+        /// Kubernetes stops the job after this long; the driver's TimeLimit only reaches the solver
+        /// </summary>
+        public TimeSpan? ActiveDeadline { get; set; }
+
+        /// <summary>
+        /// Finished jobs (and their pods) are removed by Kubernetes after this long
+        /// </summary>
+        public TimeSpan TimeToLiveAfterFinished { get; set; } = TimeSpan.FromHours(1);
+
+        /// <summary>
+        /// Builds the Job manifest; override to add tolerations, secrets or sidecars
+        /// </summary>
+        public virtual JsonObject Build(string name, Uri modelUrl, Uri resultUrl, string solver, IReadOnlyDictionary<string, string> options)
+        {
+            if (string.IsNullOrWhiteSpace(Image))
+                throw new InvalidOperationException("Kubernetes job template requires a solver image");
+
+            var labels = new JsonObject { ["app.kubernetes.io/managed-by"] = "modeleditor" };
+            foreach (var (key, value) in Labels)
+                labels[key] = value;
+
+            var arguments = new JsonArray("sh", "-c",
+                "\"$@\" /work/model.mof.json /work/result.json && curl -sSf -T /work/result.json \"$RESULT_URL\"", "solve");
+            foreach (string part in Command)
+                arguments.Add(part);
+            arguments.Add("--solver");
+            arguments.Add(solver);
+            foreach (var (option, value) in options.OrderBy(o => o.Key, StringComparer.Ordinal))
+            {
+                arguments.Add("--" + option);
+                arguments.Add(value);
+            }
+
+            var workVolume = new JsonObject { ["name"] = "work", ["emptyDir"] = new JsonObject() };
+            if (VolumeSizeLimit != null)
+                workVolume["emptyDir"]!["sizeLimit"] = VolumeSizeLimit;
+
+            var podSpec = new JsonObject
+            {
+                ["restartPolicy"] = "Never",
+                ["volumes"] = new JsonArray(workVolume),
+                ["initContainers"] = new JsonArray(new JsonObject
+                {
+                    ["name"] = "fetch-model",
+                    ["image"] = TransferImage,
+                    ["command"] = new JsonArray("curl", "-sSf", "-o", "/work/model.mof.json", "$(MODEL_URL)"),
+                    ["env"] = new JsonArray(new JsonObject { ["name"] = "MODEL_URL", ["value"] = modelUrl.AbsoluteUri }),
+                    ["volumeMounts"] = WorkMount()
+                }),
+                ["containers"] = new JsonArray(new JsonObject
+                {
+                    ["name"] = "solver",
+                    ["image"] = Image,
+                    ["command"] = arguments,
+                    ["env"] = new JsonArray(new JsonObject { ["name"] = "RESULT_URL", ["value"] = resultUrl.AbsoluteUri }),
+                    ["resources"] = new JsonObject
+                    {
+                        ["requests"] = Quantities(CpuRequest, MemoryRequest),
+                        ["limits"] = Quantities(CpuLimit, MemoryLimit)
+                    },
+                    ["volumeMounts"] = WorkMount()
+                })
+            };
+            if (ServiceAccountName != null)
+                podSpec["serviceAccountName"] = ServiceAccountName;
+            if (NodeSelector.Count > 0)
+                podSpec["nodeSelector"] = new JsonObject(NodeSelector.Select(n => KeyValuePair.Create(n.Key, (JsonNode?)n.Value)));
+
+            var jobSpec = new JsonObject
+            {
+                ["backoffLimit"] = 0,
+                ["ttlSecondsAfterFinished"] = (int)TimeToLiveAfterFinished.TotalSeconds,
+                ["template"] = new JsonObject
+                {
+                    ["metadata"] = new JsonObject { ["labels"] = labels.DeepClone() },
+                    ["spec"] = podSpec
+                }
+            };
+            if (ActiveDeadline.HasValue)
+                jobSpec["activeDeadlineSeconds"] = (long)ActiveDeadline.Value.TotalSeconds;
+
+            return new JsonObject
+            {
+                ["apiVersion"] = "batch/v1",
+                ["kind"] = "Job",
+                ["metadata"] = new JsonObject { ["name"] = name, ["labels"] = labels },
+                ["spec"] = jobSpec
+            };
+
+            static JsonArray WorkMount() => new JsonArray(new JsonObject { ["name"] = "work", ["mountPath"] = "/work" });
+        }
+
+        private static JsonObject Quantities(string? cpu, string? memory)
+        {
+            var quantities = new JsonObject();
+            if (cpu != null)
+                quantities["cpu"] = cpu;
+            if (memory != null)
+                quantities["memory"] = memory;
+            return quantities;
+        }
+    }
+
+    /// <summary>
+    /// Runs solves as Kubernetes Jobs for cloud deployments. The model goes to object storage
+    /// under &lt;prefix&gt;&lt;job id&gt;/model.mof.json (staged locally until the last chunk, then
+    /// uploaded in one PUT, so a model already stored for the same job id is not sent again);
+    /// the Job built from Template fetches it and writes result.json next to it through
+    /// presigned URLs, so pods need no storage credentials. Progress comes from the Job and its
+    /// pod: the solver container's log is streamed back and pod state changes (Pending,
+    /// Unschedulable, ImagePullBackOff, Running, ...) are raised through StatusChanged.
+    /// </summary>
+    public class KubernetesSolveTransport : IRemoteSolveTransport
+    {
+        private const string ServiceAccountDirectory = "/var/run/secrets/kubernetes.io/serviceaccount";
+
+        private readonly Uri apiServer;
+        private readonly string @namespace;
+        private readonly S3StorageOptions storage;
+        private readonly HttpClient http;
+        private readonly Func<DateTime> clock;
+        private readonly ConcurrentDictionary<string, string> jobNames = new ConcurrentDictionary<string, string>();
+        private readonly ConcurrentDictionary<string, string> lastStatus = new ConcurrentDictionary<string, string>();
+
+        /// <param name="http">Used for the API server and object storage; its handler must trust the cluster's CA</param>
+        public KubernetesSolveTransport(Uri apiServer, string @namespace, KubernetesJobTemplate template, S3StorageOptions storage,
+                                        HttpClient? http = null, Func<DateTime>? clock = null)
+        {
+            if (string.IsNullOrWhiteSpace(storage.Endpoint) || string.IsNullOrWhiteSpace(storage.Bucket))
+                throw new InvalidOperationException("Kubernetes solves require object storage with an endpoint and a bucket");
+
+            this.apiServer = new Uri(apiServer.ToString().TrimEnd('/') + "/");
+            this.@namespace = @namespace;
+            this.storage = storage;
+            this.http = http ?? new HttpClient();
+            this.clock = clock ?? (() => DateTime.UtcNow);
+            Template = template;
+        }
+
+        /// <summary>
+        /// Transport for a process running in a pod: API server, namespace and token come from
+        /// the pod's service account
+        /// </summary>
+        public static KubernetesSolveTransport InCluster(KubernetesJobTemplate template, S3StorageOptions storage, HttpClient? http = null)
+        {
+            string host = Environment.GetEnvironmentVariable("KUBERNETES_SERVICE_HOST")
+                          ?? throw new InvalidOperationException("Not running in a Kubernetes pod (KUBERNETES_SERVICE_HOST is not set)");
+            string port = Environment.GetEnvironmentVariable("KUBERNETES_SERVICE_PORT") ?? "443";
+            string namespaceFile = Path.Combine(ServiceAccountDirectory, "namespace");
+            string @namespace = File.Exists(namespaceFile) ? File.ReadAllText(namespaceFile).Trim() : "default";
+
+            return new KubernetesSolveTransport(new Uri($"https://{host}:{port}"), @namespace, template, storage, http)
+            {
+                Token = File.ReadAllText(Path.Combine(ServiceAccountDirectory, "token")).Trim()
+            };
+        }
+
+        public KubernetesJobTemplate Template { get; }
+
+        /// <summary>
+        /// Bearer token for the API server
+        /// </summary>
+        public string? Token { get; set; }
+
+        /// <summary>
+        /// How long the presigned model and result URLs stay valid; must cover queueing and solving
+        /// </summary>
+        public TimeSpan UrlLifetime { get; set; } = TimeSpan.FromDays(1);
+
+        /// <summary>
+        /// Raised with "&lt;pod&gt;: &lt;state&gt;" whenever the job's pod changes state
+        /// </summary>
+        public event Action<string>? StatusChanged;
+
+        public string Endpoint => $"kubernetes {apiServer.Authority}/{@namespace}";
+
+        public async Task<long> GetUploadedLengthAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendStorageAsync(HttpMethod.Head, ObjectKey(jobId, "model.mof.json"), null, cancellationToken);
+            if (response.StatusCode != HttpStatusCode.NotFound)
+            {
+                await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+                return response.Content.Headers.ContentLength ?? 0;
+            }
+
+            var staged = new FileInfo(StagingPath(jobId));
+            return staged.Exists ? staged.Length : 0;
+        }
+
+        public async Task UploadAsync(string jobId, long offset, long totalLength, ReadOnlyMemory<byte> chunk, CancellationToken cancellationToken)
+        {
+            string staging = StagingPath(jobId);
+            await using (var file = new FileStream(staging, FileMode.OpenOrCreate, FileAccess.Write, FileShare.None))
+            {
+                file.Position = offset;
+                await file.WriteAsync(chunk, cancellationToken);
+                file.SetLength(offset + chunk.Length);
+            }
+            if (offset + chunk.Length < totalLength)
+                return;
+
+            try
+            {
+                await using var model = new FileStream(staging, FileMode.Open, FileAccess.Read, FileShare.Read);
+                using var response = await SendStorageAsync(HttpMethod.Put, ObjectKey(jobId, "model.mof.json"), model, cancellationToken);
+                await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+            }
+            catch (Exception)
+            {
+                // Drop the last chunk so the retry sends it again and repeats the PUT
+                await using (var file = new FileStream(staging, FileMode.Open, FileAccess.Write, FileShare.None))
+                    file.SetLength(offset);
+                throw;
+            }
+            File.Delete(staging);
+        }
+
+        public async Task StartAsync(string jobId, string solver, IReadOnlyDictionary<string, string> options, CancellationToken cancellationToken)
+        {
+            // Names must be unique while an earlier job for the same model waits for its TTL
+            string name = $"solve-{jobId.Substring(0, Math.Min(12, jobId.Length)).ToLowerInvariant()}-{Guid.NewGuid():N}".Substring(0, 30);
+            var manifest = Template.Build(name,
+                Presign(HttpMethod.Get, ObjectKey(jobId, "model.mof.json")),
+                Presign(HttpMethod.Put, ObjectKey(jobId, "result.json")),
+                solver, options);
+
+            using var response = await SendApiAsync(HttpMethod.Post, $"apis/batch/v1/namespaces/{@namespace}/jobs",
+                new StringContent(manifest.ToJsonString(), Encoding.UTF8, "application/json"), cancellationToken);
+            await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+
+            jobNames[jobId] = name;
+            lastStatus.TryRemove(jobId, out _);
+            StatusChanged?.Invoke($"Job {name} created");
+        }
+
+        public async Task<RemoteJobStatus> PollAsync(string jobId, long logOffset, CancellationToken cancellationToken)
+        {
+            string name = JobName(jobId);
+            var job = await GetJsonAsync($"apis/batch/v1/namespaces/{@namespace}/jobs/{name}", cancellationToken);
+            var pods = await GetJsonAsync($"api/v1/namespaces/{@namespace}/pods?labelSelector={Uri.EscapeDataString("job-name=" + name)}", cancellationToken);
+            var pod = pods["items"]?.AsArray().FirstOrDefault();
+
+            string log = "";
+            if (pod != null)
+            {
+                string podName = (string)pod["metadata"]!["name"]!;
+                string state = DescribePod(pod);
+                if (!lastStatus.TryGetValue(jobId, out var previous) || previous != state)
+                {
+                    lastStatus[jobId] = state;
+                    StatusChanged?.Invoke($"{podName}: {state}");
+                }
+                if ((string?)pod["status"]?["phase"] != "Pending")
+                    log = await ReadLogAsync(podName, logOffset, cancellationToken);
+            }
+
+            var status = job["status"];
+            bool succeeded = ((int?)status?["succeeded"] ?? 0) > 0;
+            var failedCondition = Condition(status, "Failed");
+            bool failed = ((int?)status?["failed"] ?? 0) > 0 || (string?)failedCondition?["status"] == "True";
+            return new RemoteJobStatus
+            {
+                State = succeeded ? RemoteJobState.Completed : failed ? RemoteJobState.Failed : RemoteJobState.Running,
+                Log = log,
+                LogOffset = logOffset + Encoding.UTF8.GetByteCount(log),
+                Message = failed ? (string?)failedCondition?["message"] ?? $"Job {name} failed" : null
+            };
+        }
+
+        public async Task<SolveResult> GetResultAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendStorageAsync(HttpMethod.Get, ObjectKey(jobId, "result.json"), null, cancellationToken);
+            if (response.StatusCode == HttpStatusCode.NotFound)
+                throw new RemoteSolveException($"Job {JobName(jobId)} finished without uploading a result", false);
+            await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+
+            try
+            {
+                return JsonSerializer.Deserialize<SolveResult>(await response.Content.ReadAsStringAsync(cancellationToken), RemoteSolverDriver.JsonOptions)
+                       ?? throw new RemoteSolveException($"Job {JobName(jobId)} uploaded an empty result", false);
+            }
+            catch (JsonException ex)
+            {
+                throw new RemoteSolveException($"Result of job {JobName(jobId)} is not valid JSON: {ex.Message}", false, ex);
+            }
+        }
+
+        public async Task CancelAsync(string jobId, CancellationToken cancellationToken)
+        {
+            using var response = await SendApiAsync(HttpMethod.Delete,
+                $"apis/batch/v1/namespaces/{@namespace}/jobs/{JobName(jobId)}?propagationPolicy=Background", null, cancellationToken);
+            if (response.StatusCode != HttpStatusCode.NotFound)
+                await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+        }
+
+        /// <summary>
+        /// The solver container's log from a byte offset. The log API has no offset, so the
+        /// whole log is read and the part already seen is skipped.
+        /// </summary>
+        private async Task<string> ReadLogAsync(string podName, long logOffset, CancellationToken cancellationToken)
+        {
+            using var response = await SendApiAsync(HttpMethod.Get,
+                $"api/v1/namespaces/{@namespace}/pods/{podName}/log?container=solver", null, cancellationToken);
+            if (response.StatusCode == HttpStatusCode.BadRequest)
+                return ""; // container not started yet
+            await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+
+            byte[] log = await response.Content.ReadAsByteArrayAsync(cancellationToken);
+            return logOffset >= log.Length ? "" : Encoding.UTF8.GetString(log, (int)logOffset, log.Length - (int)logOffset);
+        }
+
+        /// <summary>
+        /// Pod phase with the reason a container waits or an unschedulable pod's message
+        /// </summary>
+        private static string DescribePod(JsonNode pod)
+        {
+            var status = pod["status"];
+            string phase = (string?)status?["phase"] ?? "Unknown";
+
+            var waiting = (status?["initContainerStatuses"]?.AsArray() ?? new JsonArray())
+                .Concat(status?["containerStatuses"]?.AsArray() ?? new JsonArray())
+                .Select(c => c?["state"]?["waiting"])
+                .FirstOrDefault(w => w != null && (string?)w["reason"] is not (null or "PodInitializing" or "ContainerCreating"));
+            if (waiting != null)
+                return Reason(phase, waiting);
+
+            var scheduled = Condition(status, "PodScheduled");
+            return (string?)scheduled?["status"] == "False" ? Reason(phase, scheduled!) : phase;
+
+            static string Reason(string phase, JsonNode node) =>
+                (string?)node["message"] is { Length: > 0 } message ? $"{phase} ({node["reason"]}: {message})" : $"{phase} ({node["reason"]})";
+        }
+
+        private static JsonNode? Condition(JsonNode? status, string type) =>
+            status?["conditions"]?.AsArray().FirstOrDefault(c => (string?)c?["type"] == type);
+
+        private async Task<JsonNode> GetJsonAsync(string path, CancellationToken cancellationToken)
+        {
+            using var response = await SendApiAsync(HttpMethod.Get, path, null, cancellationToken);
+            await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+            return JsonNode.Parse(await response.Content.ReadAsStringAsync(cancellationToken))
+                   ?? throw new RemoteSolveException($"Empty response for {path} from {Endpoint}", false);
+        }
+
+        private Task<HttpResponseMessage> SendApiAsync(HttpMethod method, string path, HttpContent? content, CancellationToken cancellationToken)
+        {
+            var request = new HttpRequestMessage(method, new Uri(apiServer, path)) { Content = content };
+            if (Token != null)
+                request.Headers.Authorization = new AuthenticationHeaderValue("Bearer", Token);
+            return SendAsync(request, cancellationToken);
+        }
+
+        private async Task<HttpResponseMessage> SendStorageAsync(HttpMethod method, string key, Stream? body, CancellationToken cancellationToken)
+        {
+            var request = new HttpRequestMessage(method, ObjectUri(key));
+            string payloadHash = AwsSignatureV4.Hex(SHA256.HashData(Array.Empty<byte>()));
+            if (body != null)
+            {
+                payloadHash = AwsSignatureV4.Hex(await SHA256.HashDataAsync(body, cancellationToken));
+                body.Position = 0;
+                request.Content = new StreamContent(body);
+            }
+
+            AwsSignatureV4.Sign(request, payloadHash, storage.Region, "s3", storage.AccessKey, storage.SecretKey, clock());
+            return await SendAsync(request, cancellationToken);
+        }
+
+        private async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken cancellationToken)
+        {
+            try
+            {
+                return await http.SendAsync(request, cancellationToken);
+            }
+            catch (HttpRequestException ex)
+            {
+                throw new RemoteSolveException($"{request.Method} {request.RequestUri?.AbsolutePath} on {Endpoint} failed: {ex.Message}", true, ex);
+            }
+            catch (TaskCanceledException ex) when (!cancellationToken.IsCancellationRequested)
+            {
+                throw new RemoteSolveException($"{request.Method} {request.RequestUri?.AbsolutePath} on {Endpoint} timed out", true, ex);
+            }
+        }
+
+        private Uri Presign(HttpMethod method, string key) =>
+            AwsSignatureV4.Presign(method, ObjectUri(key), storage.Region, "s3", storage.AccessKey, storage.SecretKey, clock(), UrlLifetime);
+
+        private Uri ObjectUri(string key) =>
+            new Uri($"{storage.Endpoint.TrimEnd('/')}/{AwsSignatureV4.EncodePath(storage.Bucket)}/{AwsSignatureV4.EncodePath(key)}");
+
+        private string ObjectKey(string jobId, string file) => $"{storage.Prefix}{jobId}/{file}";
+
+        private string JobName(string jobId) =>
+            jobNames.TryGetValue(jobId, out var name) ? name : throw new RemoteSolveException($"No Kubernetes job was started for {jobId}", false);
+
+        private static string StagingPath(string jobId) =>
+            Path.Combine(Path.GetTempPath(), $"k8s_{jobId}.upload");
+    }
+}
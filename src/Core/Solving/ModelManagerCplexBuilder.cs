@@ -31,15 +31,29 @@ namespace Core.Solving
             if (objective == null)
                 return null;
 
-            var equations = _manager.Equations;
+            var equations = _manager.MaterializeRows();
+            var table = equations as ConstraintTable;
 
             // Collect all variable names from objective + all constraints (sorted for determinism)
             var varSet = new HashSet<string>(objective.Coefficients.Keys);
-            foreach (var eq in equations)
-                foreach (var k in eq.Coefficients.Keys)
-                    varSet.Add(k);
+            foreach (var variables in ConstraintTable.VariablesOf(equations))
+                varSet.UnionWith(variables);
 
             _colToVarName = varSet.OrderBy(x => x).ToList();
+            var colIndex = _colToVarName.Select((name, c) => (name, c)).ToDictionary(x => x.name, x => x.c);
+
+            // Non-zero constraint coefficients per column, gathered while the rows are read once
+            var colRows = _colToVarName.Select(_ => new List<int>()).ToList();
+            var colValues = _colToVarName.Select(_ => new List<double>()).ToList();
+
+            void AddEntry(string varName, int row, double v)
+            {
+                if (Math.Abs(v) > 1e-12)
+                {
+                    colRows[colIndex[varName]].Add(row);
+                    colValues[colIndex[varName]].Add(v);
+                }
+            }
 
             var model = new CplexModel();
             model.OptSense = objective.Sense == ObjectiveSense.Maximize
@@ -50,7 +64,8 @@ namespace Core.Solving
             _rowToName = new List<string>(equations.Count);
             for (int r = 0; r < equations.Count; r++)
             {
-                var eq = equations[r];
+                // A stored row is read without building its terms; they come from the columns below
+                var eq = table?.GetHeader(r) ?? equations[r];
                 char sense = eq.Operator switch
                 {
                     RelationalOperator.LessThanOrEqual => 'L',
@@ -63,6 +78,18 @@ namespace Core.Solving
                 string name = eq.Label ?? eq.BaseName ?? $"c{r}";
                 _rowToName.Add(name);
                 model.Data.AddRow(sense, rhs, name);
+
+                if (table != null)
+                {
+                    var ids = table.GetTermIds(r);
+                    for (int t = 0; t < ids.Length; t++)
+                        AddEntry(table.GetName(ids[t]), r, table.EvaluateCoefficient(r, t, _manager));
+                }
+                else
+                {
+                    foreach (var (varName, expr) in eq.Coefficients)
+                        AddEntry(varName, r, expr.Evaluate(_manager));
+                }
             }
 
             // Add columns — CSC format: BeginColumn → AddColumn(rowIndices, values) → AddVariable
@@ -71,27 +98,11 @@ namespace Core.Solving
                 string varName = _colToVarName[c];
 
                 int col = model.BeginColumn();
-
-                // Gather non-zero constraint coefficients for this variable
-                var rowIndices = new List<int>();
-                var rowValues = new List<double>();
-                for (int r = 0; r < equations.Count; r++)
-                {
-                    if (equations[r].Coefficients.TryGetValue(varName, out var expr))
-                    {
-                        double v = expr.Evaluate(_manager);
-                        if (Math.Abs(v) > 1e-12)
-                        {
-                            rowIndices.Add(r);
-                            rowValues.Add(v);
-                        }
-                    }
-                }
-                model.AddColumn(rowIndices, rowValues);
+                model.AddColumn(colRows[c], colValues[c]);
 
                 var info = FindVariableInfo(varName);
-                double lb = info?.LowerBound ?? 0.0;
-                double ub = info?.UpperBound ?? CplexInfinity;
+                double lb = info?.GetBounds(varName).Lower ?? 0.0;
+                double ub = info?.GetBounds(varName).Upper ?? CplexInfinity;
                 double objCoeff = objective.Coefficients.TryGetValue(varName, out var objExpr)
                     ? objExpr.Evaluate(_manager)
                     : 0.0;
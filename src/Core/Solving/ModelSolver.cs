@@ -7,10 +7,25 @@ namespace Core.Solving
     /// <summary>
     /// Orchestrates solving a fully-expanded ModelManager using the CPLEX solver.
     /// </summary>
-    public class ModelSolver
+    public class ModelSolver : ISolverDriver
     {
-        public SolveResult Solve(ModelManager manager)
+        public string Name => "CPLEX";
+
+        /// <summary>
+        /// Linear rows only; logical constraints are not passed to CPLEX
+        /// </summary>
+        public SolverCapabilities Capabilities => SolverCapabilities.Continuous | SolverCapabilities.Integer;
+
+        public SolveResult Solve(ModelManager manager) => Solve(manager, CancellationToken.None);
+
+        /// <summary>
+        /// Solves with cancellation. The CplexSolver wrapper offers no call that interrupts a running
+        /// solve, so CPLEX goes on until it returns or reaches MaxSolutionTime; a solve cancelled in
+        /// the meantime then throws OperationCanceledException instead of reporting its status.
+        /// </summary>
+        public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken)
         {
+            cancellationToken.ThrowIfCancellationRequested();
             var sw = Stopwatch.StartNew();
 
             var builder = new ModelManagerCplexBuilder(manager);
@@ -30,8 +45,10 @@ namespace Core.Solving
                 solver.Setup();
                 solver.Solve();
             }
-            catch (Exception ex)
+            catch (Exception ex) when (ex is not OperationCanceledException)
             {
+                // A solve cancelled while it ran is reported as the cancellation, whatever its outcome
+                cancellationToken.ThrowIfCancellationRequested();
                 sw.Stop();
                 return new SolveResult
                 {
@@ -42,6 +59,8 @@ namespace Core.Solving
             }
 
             sw.Stop();
+            // The status and values of a cancelled solve are not reported as a normal result
+            cancellationToken.ThrowIfCancellationRequested();
             return BuildResult(extractor, builder, sw.Elapsed);
         }
 
@@ -0,0 +1,301 @@
+using System.Collections.Concurrent;
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using System.Xml.Linq;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Submits models to the NEOS Server (neos-server.org) through its XML-RPC API, for users
+    /// without local solver licenses. Models go as MPS; the category is "milp" when the model
+    /// has integer columns and "lp" otherwise, and the driver's RemoteSolver must be one of the
+    /// MPS solvers NEOS lists in that category (e.g. "Cbc", "HiGHS"). NEOS requires an e-mail
+    /// address with every job. Solver options are not passed on. The job's output is streamed
+    /// as the log and read by ResultParser, which by default understands CBC's solution listing.
+    /// </summary>
+    public class NeosSolveTransport : IRemoteSolveTransport
+    {
+        public static readonly Uri DefaultServer = new Uri("https://neos-server.org:3333");
+
+        private readonly Uri server;
+        private readonly HttpClient http;
+        private readonly ConcurrentDictionary<string, MemoryStream> staged = new ConcurrentDictionary<string, MemoryStream>();
+        private readonly ConcurrentDictionary<string, (int Number, string Password)> jobs = new ConcurrentDictionary<string, (int, string)>();
+
+        public NeosSolveTransport(string email, Uri? server = null, HttpClient? http = null)
+        {
+            if (string.IsNullOrWhiteSpace(email))
+                throw new InvalidOperationException("NEOS requires an e-mail address with every job");
+
+            Email = email;
+            this.server = server ?? DefaultServer;
+            this.http = http ?? new HttpClient();
+        }
+
+        public string Email { get; }
+
+        /// <summary>
+        /// Reads a SolveResult from the job's final output; variable names are the MPS column names
+        /// </summary>
+        public Func<string, SolveResult> ResultParser { get; set; } = ParseCbcSolution;
+
+        public string Endpoint => $"NEOS {server.Host}";
+
+        public RemoteModelFormat ModelFormat => RemoteModelFormat.Mps;
+
+        /// <summary>
+        /// NEOS takes the model in the submission itself, so chunks are staged in memory until the job starts
+        /// </summary>
+        public Task<long> GetUploadedLengthAsync(string jobId, CancellationToken cancellationToken) =>
+            Task.FromResult(staged.TryGetValue(jobId, out var model) ? model.Length : 0L);
+
+        public Task UploadAsync(string jobId, long offset, long totalLength, ReadOnlyMemory<byte> chunk, CancellationToken cancellationToken)
+        {
+            var model = staged.GetOrAdd(jobId, _ => new MemoryStream());
+            model.SetLength(offset);
+            model.Position = offset;
+            model.Write(chunk.Span);
+            return Task.CompletedTask;
+        }
+
+        /// <summary>
+        /// Lists the MPS solvers NEOS offers in a category, e.g. "milp"
+        /// </summary>
+        public async Task<IReadOnlyList<string>> ListSolversAsync(string category, CancellationToken cancellationToken = default)
+        {
+            var entries = (object[])(await CallAsync("listSolversInCategory", cancellationToken, category))!;
+            return entries.Cast<string>()
+                .Select(e => e.Split(':'))
+                .Where(e => e.Length == 2 && e[1].Equals("MPS", StringComparison.OrdinalIgnoreCase))
+                .Select(e => e[0])
+                .ToList();
+        }
+
+        public async Task StartAsync(string jobId, string solver, IReadOnlyDictionary<string, string> options, CancellationToken cancellationToken)
+        {
+            if (!staged.TryGetValue(jobId, out var model))
+                throw new RemoteSolveException($"No model was uploaded for {jobId}", false);
+
+            string mps = Encoding.UTF8.GetString(model.GetBuffer(), 0, (int)model.Length);
+            string category = Regex.IsMatch(mps, @"^\s*(LI|UI|BV)\s|'MARKER'", RegexOptions.Multiline) ? "milp" : "lp";
+            var available = await ListSolversAsync(category, cancellationToken);
+            string neosSolver = available.FirstOrDefault(s => s.Equals(solver, StringComparison.OrdinalIgnoreCase))
+                ?? throw new RemoteSolveException(
+                    $"NEOS has no MPS solver '{solver}' for {category}; available: {string.Join(", ", available)}", false);
+
+            var document = new XElement("document",
+                new XElement("category", category),
+                new XElement("solver", neosSolver),
+                new XElement("inputMethod", "MPS"),
+                new XElement("email", Email),
+                new XElement("MPS", new XCData(mps)));
+
+            var submitted = (object[])(await CallAsync("submitJob", cancellationToken, document.ToString()))!;
+            int number = (int)submitted[0];
+            string password = (string)submitted[1];
+            if (number == 0)
+                throw new RemoteSolveException($"NEOS rejected the job: {password}", false);
+
+            jobs[jobId] = (number, password);
+            staged.TryRemove(jobId, out _);
+        }
+
+        public async Task<RemoteJobStatus> PollAsync(string jobId, long logOffset, CancellationToken cancellationToken)
+        {
+            var (number, password) = Job(jobId);
+            string state = (string)(await CallAsync("getJobStatus", cancellationToken, number, password))!;
+
+            string log = "";
+            long nextOffset = logOffset;
+            if (state == "Running")
+            {
+                var intermediate = (object[])(await CallAsync("getIntermediateResultsNonBlocking", cancellationToken, number, password, (int)logOffset))!;
+                log = Text(intermediate[0]);
+                nextOffset = (int)intermediate[1];
+            }
+
+            return new RemoteJobStatus
+            {
+                State = state switch
+                {
+                    "Done" => RemoteJobState.Completed,
+                    "Running" or "Waiting" => RemoteJobState.Running,
+                    _ => RemoteJobState.Failed
+                },
+                Log = log,
+                LogOffset = nextOffset,
+                Message = state is "Done" or "Running" or "Waiting" ? null : $"NEOS job {number}: {state}"
+            };
+        }
+
+        public async Task<SolveResult> GetResultAsync(string jobId, CancellationToken cancellationToken)
+        {
+            var (number, password) = Job(jobId);
+            return ResultParser(Text(await CallAsync("getFinalResults", cancellationToken, number, password)));
+        }
+
+        public async Task CancelAsync(string jobId, CancellationToken cancellationToken)
+        {
+            var (number, password) = Job(jobId);
+            await CallAsync("killJob", cancellationToken, number, password, "Cancelled by user");
+        }
+
+        private static readonly Regex CbcStatus = new Regex(
+            @"^(?<status>Optimal|Infeasible|Integer infeasible|Unbounded|Stopped on [\w ]+?) - objective value (?<objective>\S+)",
+            RegexOptions.Compiled | RegexOptions.Multiline);
+
+        private static readonly Regex CbcColumn = new Regex(@"^\s*(\*\*)?\s*\d+\s+(?<name>\S+)\s+(?<value>\S+)", RegexOptions.Compiled);
+
+        /// <summary>
+        /// Reads CBC's solution listing: a status line ("Optimal - objective value 5") followed
+        /// by one "index name value reduced-cost" line per nonzero column
+        /// </summary>
+        public static SolveResult ParseCbcSolution(string output)
+        {
+            var status = CbcStatus.Match(output);
+            if (!status.Success)
+            {
+                string lastLine = output.Split('\n').Select(l => l.Trim()).LastOrDefault(l => l.Length > 0) ?? "no output";
+                return new SolveResult { Status = SolveStatus.Error, StatusMessage = $"No solution in NEOS output: {lastLine}" };
+            }
+
+            var values = new Dictionary<string, double>();
+            foreach (string line in output.Substring(status.Index + status.Length).Split('\n').Skip(1))
+            {
+                var column = CbcColumn.Match(line);
+                if (!column.Success)
+                {
+                    if (line.Trim().Length == 0)
+                        continue;
+                    break;
+                }
+                if (double.TryParse(column.Groups["value"].Value, NumberStyles.Float, CultureInfo.InvariantCulture, out double value))
+                    values[column.Groups["name"].Value] = value;
+            }
+
+            string text = status.Groups["status"].Value;
+            var solveStatus = text switch
+            {
+                "Optimal" => SolveStatus.Optimal,
+                "Infeasible" or "Integer infeasible" => SolveStatus.Infeasible,
+                "Unbounded" => SolveStatus.Unbounded,
+                _ => values.Count > 0 ? SolveStatus.Feasible : SolveStatus.Error
+            };
+            bool hasSolution = solveStatus is SolveStatus.Optimal or SolveStatus.Feasible;
+
+            return new SolveResult
+            {
+                Status = solveStatus,
+                ObjectiveValue = hasSolution && double.TryParse(status.Groups["objective"].Value, NumberStyles.Float, CultureInfo.InvariantCulture, out double objective)
+                    ? objective
+                    : null,
+                VariableValues = hasSolution ? values : new Dictionary<string, double>(),
+                StatusMessage = text
+            };
+        }
+
+        /// <summary>
+        /// NEOS sends output as base64, or as an empty string when there is none
+        /// </summary>
+        private static string Text(object? output) => output is byte[] bytes ? Encoding.UTF8.GetString(bytes) : output as string ?? "";
+
+        private (int Number, string Password) Job(string jobId) =>
+            jobs.TryGetValue(jobId, out var job) ? job : throw new RemoteSolveException($"No NEOS job was submitted for {jobId}", false);
+
+        private async Task<object?> CallAsync(string method, CancellationToken cancellationToken, params object[] parameters)
+        {
+            var content = new StringContent(XmlRpc.Call(method, parameters), Encoding.UTF8, "text/xml");
+            HttpResponseMessage response;
+            try
+            {
+                response = await http.PostAsync(server, content, cancellationToken);
+            }
+            catch (HttpRequestException ex)
+            {
+                throw new RemoteSolveException($"{method} on {Endpoint} failed: {ex.Message}", true, ex);
+            }
+            catch (TaskCanceledException ex) when (!cancellationToken.IsCancellationRequested)
+            {
+                throw new RemoteSolveException($"{method} on {Endpoint} timed out", true, ex);
+            }
+
+            using (response)
+            {
+                await RemoteSolveException.ThrowIfFailedAsync(response, Endpoint, cancellationToken);
+                return XmlRpc.ParseResponse(await response.Content.ReadAsStringAsync(cancellationToken), method, Endpoint);
+            }
+        }
+    }
+
+    /// <summary>
+    /// The parts of XML-RPC that NEOS uses: string, int, boolean, double, base64, array and struct values
+    /// </summary>
+    internal static class XmlRpc
+    {
+        public static string Call(string method, IEnumerable<object> parameters)
+        {
+            var call = new XElement("methodCall",
+                new XElement("methodName", method),
+                new XElement("params", parameters.Select(p => new XElement("param", Value(p)))));
+            return "<?xml version=\"1.0\"?>" + call.ToString(SaveOptions.DisableFormatting);
+        }
+
+        /// <summary>
+        /// The returned value; a fault becomes a (not transient) RemoteSolveException
+        /// </summary>
+        public static object? ParseResponse(string xml, string method, string endpoint)
+        {
+            XElement root;
+            try
+            {
+                root = XElement.Parse(xml);
+            }
+            catch (System.Xml.XmlException ex)
+            {
+                throw new RemoteSolveException($"{method} on {endpoint} returned invalid XML-RPC: {ex.Message}", false, ex);
+            }
+
+            var fault = root.Element("fault");
+            if (fault != null)
+            {
+                var details = (Dictionary<string, object?>)Read(fault.Element("value")!)!;
+                throw new RemoteSolveException($"{method} on {endpoint} failed: {details.GetValueOrDefault("faultString")}", false);
+            }
+
+            var value = root.Element("params")?.Element("param")?.Element("value");
+            return value == null ? null : Read(value);
+        }
+
+        private static XElement Value(object value) => new XElement("value", value switch
+        {
+            string s => new XElement("string", s),
+            int i => new XElement("int", i.ToString(CultureInfo.InvariantCulture)),
+            bool b => new XElement("boolean", b ? "1" : "0"),
+            double d => new XElement("double", d.ToString("R", CultureInfo.InvariantCulture)),
+            byte[] bytes => new XElement("base64", Convert.ToBase64String(bytes)),
+            IEnumerable<object> items => new XElement("array", new XElement("data", items.Select(Value))),
+            _ => throw new ArgumentException($"XML-RPC cannot encode {value.GetType().Name}", nameof(value))
+        });
+
+        private static object? Read(XElement value)
+        {
+            var typed = value.Elements().FirstOrDefault();
+            if (typed == null)
+                return value.Value;
+
+            return typed.Name.LocalName switch
+            {
+                "string" => typed.Value,
+                "int" or "i4" => int.Parse(typed.Value, CultureInfo.InvariantCulture),
+                "boolean" => typed.Value.Trim() == "1",
+                "double" => double.Parse(typed.Value, CultureInfo.InvariantCulture),
+                "base64" => Convert.FromBase64String(typed.Value.Trim()),
+                "array" => typed.Element("data")?.Elements("value").Select(Read).ToArray() ?? Array.Empty<object?>(),
+                "struct" => typed.Elements("member").ToDictionary(m => m.Element("name")!.Value, m => Read(m.Element("value")!)),
+                "nil" => null,
+                _ => typed.Value
+            };
+        }
+    }
+}
@@ -0,0 +1,337 @@
+using System.Diagnostics;
+using System.Globalization;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Export;
+
+namespace Core.Solving
+{
+    public enum RemoteJobState
+    {
+        Running,
+        Completed,
+        Failed
+    }
+
+    /// <summary>
+    /// File format a solve server takes the model in
+    /// </summary>
+    public enum RemoteModelFormat
+    {
+        /// <summary>MathOptFormat JSON, which keeps the model's variable and constraint names</summary>
+        Mof,
+
+        /// <summary>Free MPS; solutions come back under the exporter's column names and are mapped back</summary>
+        Mps
+    }
+
+    /// <summary>
+    /// State of a remote job and the log written since the requested offset
+    /// </summary>
+    public class RemoteJobStatus
+    {
+        public RemoteJobState State { get; init; }
+        public string Log { get; init; } = "";
+
+        /// <summary>
+        /// Log offset (in bytes) to ask for next time
+        /// </summary>
+        public long LogOffset { get; init; }
+
+        public string? Message { get; init; }
+    }
+
+    /// <summary>
+    /// A remote call failed; transient failures (connection loss, server overload) are retried
+    /// </summary>
+    public class RemoteSolveException : InvalidOperationException
+    {
+        public RemoteSolveException(string message, bool transient, Exception? inner = null)
+            : base(message, inner)
+        {
+            IsTransient = transient;
+        }
+
+        public bool IsTransient { get; }
+
+        /// <summary>
+        /// Throws for an unsuccessful HTTP response; 408, 429 and 5xx are transient
+        /// </summary>
+        internal static async Task ThrowIfFailedAsync(HttpResponseMessage response, string endpoint, CancellationToken cancellationToken)
+        {
+            if (response.IsSuccessStatusCode)
+                return;
+
+            int code = (int)response.StatusCode;
+            string detail = await response.Content.ReadAsStringAsync(cancellationToken);
+            throw new RemoteSolveException(
+                $"{response.RequestMessage?.Method} {response.RequestMessage?.RequestUri?.AbsolutePath} on {endpoint} failed: " +
+                $"{code} {response.ReasonPhrase} {detail}".TrimEnd(), code is 408 or 429 or >= 500);
+        }
+    }
+
+    /// <summary>
+    /// How RemoteSolverDriver reaches a solve server. A job is identified by the driver; the
+    /// model is uploaded in chunks at increasing offsets, so an interrupted upload resumes from
+    /// what the server already has.
+    /// </summary>
+    public interface IRemoteSolveTransport
+    {
+        /// <summary>
+        /// Server address for messages, e.g. "https://solve.example.com" or "ssh big-box"
+        /// </summary>
+        string Endpoint { get; }
+
+        RemoteModelFormat ModelFormat => RemoteModelFormat.Mof;
+
+        /// <summary>
+        /// Bytes of the job's model the server has, 0 for an unknown job
+        /// </summary>
+        Task<long> GetUploadedLengthAsync(string jobId, CancellationToken cancellationToken);
+
+        Task UploadAsync(string jobId, long offset, long totalLength, ReadOnlyMemory<byte> chunk, CancellationToken cancellationToken);
+
+        Task StartAsync(string jobId, string solver, IReadOnlyDictionary<string, string> options, CancellationToken cancellationToken);
+
+        Task<RemoteJobStatus> PollAsync(string jobId, long logOffset, CancellationToken cancellationToken);
+
+        Task<SolveResult> GetResultAsync(string jobId, CancellationToken cancellationToken);
+
+        Task CancelAsync(string jobId, CancellationToken cancellationToken);
+    }
+
+    /// <summary>
+    /// Solves on a remote solve server: exports the expanded model in the transport's format
+    /// (MOF unless it asks for MPS), uploads it in resumable chunks, starts the job, streams
+    /// its log back through LogReceived and downloads the solution. Transient failures are
+    /// retried with exponential backoff. The job id is the model's cache key (fingerprint,
+    /// solver and options), so re-running after a broken connection reuses the upload.
+    /// </summary>
+    public class RemoteSolverDriver : ISolverDriver
+    {
+        internal static readonly JsonSerializerOptions JsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            Converters = { new JsonStringEnumConverter() }
+        };
+
+        private readonly IRemoteSolveTransport transport;
+
+        public RemoteSolverDriver(IRemoteSolveTransport transport)
+        {
+            this.transport = transport;
+        }
+
+        public string Name => $"Remote {RemoteSolver}";
+
+        /// <summary>
+        /// Solver the server should run, e.g. "cplex"
+        /// </summary>
+        public string RemoteSolver { get; set; } = "cplex";
+
+        public TimeSpan? TimeLimit { get; set; }
+
+        public int ChunkSize { get; set; } = 8 * 1024 * 1024;
+
+        /// <summary>
+        /// Attempts after the first for each remote call that fails transiently
+        /// </summary>
+        public int MaxRetries { get; set; } = 5;
+
+        /// <summary>
+        /// Delay before the first retry; doubled for each further one
+        /// </summary>
+        public TimeSpan RetryDelay { get; set; } = TimeSpan.FromSeconds(1);
+
+        public TimeSpan PollInterval { get; set; } = TimeSpan.FromSeconds(2);
+
+        /// <summary>
+        /// Raised for every line of the remote solver's log
+        /// </summary>
+        public event Action<string>? LogReceived;
+
+        public SolveResult Solve(ModelManager manager) => Solve(manager, CancellationToken.None);
+
+        public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken) =>
+            SolveAsync(manager, cancellationToken).GetAwaiter().GetResult();
+
+        /// <summary>
+        /// Cancellation cancels the remote job (best effort) and throws OperationCanceledException
+        /// </summary>
+        public async Task<SolveResult> SolveAsync(ModelManager manager, CancellationToken cancellationToken = default)
+        {
+            var sw = Stopwatch.StartNew();
+            string jobId = SolveCache.KeyOf(manager, this);
+            bool started = false;
+            var format = transport.ModelFormat;
+            string modelFile = Path.Combine(Path.GetTempPath(), $"remote_{Guid.NewGuid():N}{(format == RemoteModelFormat.Mps ? ".mps" : ".mof.json")}");
+            Dictionary<string, string>? columnNames = null;
+
+            try
+            {
+                if (format == RemoteModelFormat.Mps)
+                {
+                    var exporter = new MPSExporter(manager);
+                    File.WriteAllText(modelFile, exporter.Export("PROBLEM", cancellationToken));
+                    columnNames = exporter.ColumnNames;
+                }
+                else
+                {
+                    File.WriteAllText(modelFile, new MofExporter(manager).Export(null, cancellationToken));
+                }
+                await UploadAsync(jobId, modelFile, cancellationToken);
+
+                var options = new Dictionary<string, string>();
+                if (TimeLimit.HasValue)
+                    options["timeLimitSeconds"] = TimeLimit.Value.TotalSeconds.ToString(CultureInfo.InvariantCulture);
+                await RetryAsync(() => transport.StartAsync(jobId, RemoteSolver, options, cancellationToken), cancellationToken);
+                started = true;
+
+                var status = await FollowLogAsync(jobId, cancellationToken);
+                if (status.State == RemoteJobState.Failed)
+                    return Error($"Remote job failed on {transport.Endpoint}: {status.Message ?? "no message"}", sw.Elapsed);
+
+                var result = await RetryAsync(() => transport.GetResultAsync(jobId, cancellationToken), cancellationToken);
+                return new SolveResult
+                {
+                    Status = result.Status,
+                    ObjectiveValue = result.ObjectiveValue,
+                    VariableValues = columnNames == null
+                        ? result.VariableValues
+                        : result.VariableValues.ToDictionary(v => columnNames.TryGetValue(v.Key, out var name) ? name : v.Key, v => v.Value),
+                    ConstraintSlacks = result.ConstraintSlacks,
+                    MipGap = result.MipGap,
+                    BestBound = result.BestBound,
+                    SolveTime = sw.Elapsed,
+                    StatusMessage = $"{transport.Endpoint}: {result.StatusMessage ?? result.Status.ToString()}"
+                };
+            }
+            catch (OperationCanceledException) when (cancellationToken.IsCancellationRequested)
+            {
+                if (started)
+                {
+                    try
+                    {
+                        await transport.CancelAsync(jobId, CancellationToken.None);
+                    }
+                    catch (Exception)
+                    {
+                        // The job ends on its own; nothing more to do from here
+                    }
+                }
+                throw;
+            }
+            catch (Exception ex) when (ex is not OperationCanceledException)
+            {
+                return Error(ex.Message, sw.Elapsed);
+            }
+            finally
+            {
+                if (File.Exists(modelFile))
+                    File.Delete(modelFile);
+            }
+        }
+
+        /// <summary>
+        /// Uploads the file from the offset the server reports, so a connection lost halfway
+        /// through a large model resumes instead of starting over
+        /// </summary>
+        private async Task UploadAsync(string jobId, string path, CancellationToken cancellationToken)
+        {
+            await using var file = new FileStream(path, FileMode.Open, FileAccess.Read, FileShare.Read);
+            long total = file.Length;
+            var buffer = new byte[(int)Math.Min(ChunkSize, Math.Max(1, total))];
+
+            long offset = await RetryAsync(() => transport.GetUploadedLengthAsync(jobId, cancellationToken), cancellationToken);
+            int failures = 0;
+            while (offset < total)
+            {
+                int length = (int)Math.Min(buffer.Length, total - offset);
+                file.Position = offset;
+                await file.ReadExactlyAsync(buffer.AsMemory(0, length), cancellationToken);
+                try
+                {
+                    await transport.UploadAsync(jobId, offset, total, buffer.AsMemory(0, length), cancellationToken);
+                    offset += length;
+                    failures = 0;
+                }
+                catch (Exception ex) when (IsTransient(ex) && failures < MaxRetries)
+                {
+                    // Resume from whatever part of the chunk arrived
+                    await Task.Delay(Backoff(failures++), cancellationToken);
+                    offset = await RetryAsync(() => transport.GetUploadedLengthAsync(jobId, cancellationToken), cancellationToken);
+                }
+            }
+        }
+
+        private async Task<RemoteJobStatus> FollowLogAsync(string jobId, CancellationToken cancellationToken)
+        {
+            long logOffset = 0;
+            var partial = new StringBuilder();
+            while (true)
+            {
+                var status = await RetryAsync(() => transport.PollAsync(jobId, logOffset, cancellationToken), cancellationToken);
+                logOffset = status.LogOffset;
+
+                partial.Append(status.Log);
+                string text = partial.ToString();
+                int end = text.LastIndexOf('\n');
+                if (end >= 0)
+                {
+                    foreach (string line in text.Substring(0, end).Split('\n'))
+                        LogReceived?.Invoke(line.TrimEnd('\r'));
+                    partial.Remove(0, end + 1);
+                }
+
+                if (status.State != RemoteJobState.Running)
+                {
+                    if (partial.Length > 0)
+                        LogReceived?.Invoke(partial.ToString());
+                    return status;
+                }
+
+                await Task.Delay(PollInterval, cancellationToken);
+            }
+        }
+
+        private async Task<T> RetryAsync<T>(Func<Task<T>> call, CancellationToken cancellationToken)
+        {
+            for (int attempt = 0; ; attempt++)
+            {
+                try
+                {
+                    return await call();
+                }
+                catch (Exception ex) when (IsTransient(ex) && attempt < MaxRetries)
+                {
+                    await Task.Delay(Backoff(attempt), cancellationToken);
+                }
+                catch (Exception ex) when (IsTransient(ex))
+                {
+                    throw new RemoteSolveException($"{transport.Endpoint} did not respond after {MaxRetries + 1} attempts: {ex.Message}", false, ex);
+                }
+            }
+        }
+
+        private Task RetryAsync(Func<Task> call, CancellationToken cancellationToken) =>
+            RetryAsync(async () =>
+            {
+                await call();
+                return true;
+            }, cancellationToken);
+
+        private TimeSpan Backoff(int attempt) => TimeSpan.FromTicks(RetryDelay.Ticks * (1L << Math.Min(attempt, 16)));
+
+        private static bool IsTransient(Exception ex) =>
+            ex is RemoteSolveException { IsTransient: true } or HttpRequestException or IOException;
+
+        private static SolveResult Error(string message, TimeSpan elapsed) => new SolveResult
+        {
+            Status = SolveStatus.Error,
+            StatusMessage = message,
+            SolveTime = elapsed
+        };
+    }
+}
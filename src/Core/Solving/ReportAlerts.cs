@@ -0,0 +1,206 @@
+using System.Globalization;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Models;
+
+namespace Core.Solving
+{
+    public enum AlertSeverity
+    {
+        Warning,
+        Critical
+    }
+
+    /// <summary>
+    /// An alert rule that fired for a solution, for operational monitoring
+    /// </summary>
+    public class ReportAlert
+    {
+        public AlertSeverity Severity { get; init; }
+
+        /// <summary>
+        /// The rule as written ("spill > 50", "utilization(cap*) > 95%")
+        /// </summary>
+        public string Rule { get; init; } = "";
+
+        /// <summary>
+        /// The KPI, constraint row or soft constraint the alert is about
+        /// </summary>
+        public string Subject { get; init; } = "";
+
+        public double Value { get; init; }
+        public double? Threshold { get; init; }
+
+        /// <summary>
+        /// Line of the rule in the model
+        /// </summary>
+        public int LineNumber { get; init; }
+
+        public string Message { get; init; } = "";
+
+        public override string ToString() => $"{Severity.ToString().ToLowerInvariant()}: {Message}";
+    }
+
+    /// <summary>
+    /// A "// @alert" rule of a model, checked after each solve:
+    /// <code>
+    /// // @alert spill > 50                     a KPI above (or below) a threshold
+    /// // @alert critical utilization(cap*) > 95%   a constraint row using more of its bound
+    /// // @alert violated(hard-ish)             any violated soft constraint of a weight class
+    /// </code>
+    /// Utilization is the activity of a &lt;= row over its (positive) right-hand side; the
+    /// pattern matches row or constraint names with '*' and '?'. A soft constraint is one relaxed
+    /// with slack variables (see ConstraintRelaxer), its weight class a tag on it ("// @tags
+    /// hard-ish"), and it is violated when its slacks add up to more than the tolerance.
+    /// Thresholds may be written as percentages. Rules are warnings unless marked critical.
+    /// </summary>
+    public class AlertRule
+    {
+        private static readonly Regex thresholdPattern = new Regex(@"^(?:(warning|critical)\s+)?(\w+|utilization\(\s*[^)]*?\s*\))\s*(>=|<=|>|<)\s*([-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?)(%?)$");
+        private static readonly Regex violatedPattern = new Regex(@"^(?:(warning|critical)\s+)?violated\(\s*([^)]+?)\s*\)$");
+        private static readonly Regex utilizationPattern = new Regex(@"^utilization\(\s*([^)]*?)\s*\)$");
+
+        public AlertSeverity Severity { get; init; }
+        public string Text { get; init; } = "";
+        public int LineNumber { get; init; }
+
+        /// <summary>
+        /// KPI name for a threshold on a KPI
+        /// </summary>
+        public string? Kpi { get; init; }
+
+        /// <summary>
+        /// Name pattern of the rows for a utilization rule ("*" for all)
+        /// </summary>
+        public string? Rows { get; init; }
+
+        /// <summary>
+        /// Weight class (tag) of the soft constraints for a violation rule
+        /// </summary>
+        public string? WeightClass { get; init; }
+
+        public string? Operator { get; init; }
+        public double Threshold { get; init; }
+
+        /// <summary>
+        /// Reads the text after "@alert"; throws for a malformed rule
+        /// </summary>
+        public static AlertRule Parse(string text, int lineNumber)
+        {
+            var violated = violatedPattern.Match(text);
+            if (violated.Success)
+            {
+                return new AlertRule
+                {
+                    Severity = SeverityOf(violated.Groups[1].Value),
+                    Text = text,
+                    LineNumber = lineNumber,
+                    WeightClass = TagPath.Normalize(violated.Groups[2].Value)
+                };
+            }
+
+            var match = thresholdPattern.Match(text);
+            if (!match.Success)
+                throw new InvalidOperationException("Expected '@alert [critical] <kpi> > <threshold>', 'utilization(<rows>) > <percent>' or 'violated(<weight class>)'");
+
+            double threshold = double.Parse(match.Groups[4].Value, NumberStyles.Float, CultureInfo.InvariantCulture);
+            var utilization = utilizationPattern.Match(match.Groups[2].Value);
+            return new AlertRule
+            {
+                Severity = SeverityOf(match.Groups[1].Value),
+                Text = text,
+                LineNumber = lineNumber,
+                Kpi = utilization.Success ? null : match.Groups[2].Value,
+                Rows = utilization.Success ? (utilization.Groups[1].Length > 0 ? utilization.Groups[1].Value : "*") : null,
+                Operator = match.Groups[3].Value,
+                Threshold = match.Groups[5].Length > 0 ? threshold / 100 : threshold
+            };
+        }
+
+        /// <summary>
+        /// The alerts of this rule for a solution whose KPIs have been evaluated
+        /// </summary>
+        public IEnumerable<ReportAlert> Check(ModelManager manager, SolveResult solution, NumericTolerance tolerance)
+        {
+            if (Kpi != null)
+            {
+                if (solution.Kpis.TryGetValue(Kpi, out double value) && Exceeds(value))
+                    yield return Alert(Kpi, value, $"KPI {Kpi} = {Format(value)} is {Describe()} {Format(Threshold)}");
+                yield break;
+            }
+
+            if (Rows != null)
+            {
+                var pattern = new Regex("^" + Regex.Escape(Rows).Replace("\\*", ".*").Replace("\\?", ".") + "$");
+                foreach (var equation in manager.MaterializeRows().Where(e => e.Operator is RelationalOperator.LessThanOrEqual or RelationalOperator.LessThan))
+                {
+                    string name = equation.Label ?? equation.GetDescription();
+                    if (!pattern.IsMatch(name) && !pattern.IsMatch(SolutionComparison.GetFamily(equation)))
+                        continue;
+
+                    var (coefficients, rhs) = equation.Evaluate(manager);
+                    if (rhs <= tolerance.Absolute || coefficients.Keys.Any(v => !solution.VariableValues.ContainsKey(v)))
+                        continue;
+
+                    double utilization = coefficients.Sum(c => c.Value * solution.VariableValues[c.Key]) / rhs;
+                    if (Exceeds(utilization))
+                        yield return Alert(name, utilization, $"Constraint {name} is at {Percent(utilization)} of its bound ({Describe()} {Percent(Threshold)})");
+                }
+                yield break;
+            }
+
+            foreach (var source in manager.SourceTexts)
+            {
+                foreach (var entity in ModelTags.Parse(source).Select(WeightClass!).Where(e => e.Key.StartsWith("constraint:", StringComparison.Ordinal)))
+                {
+                    string constraint = entity.Key.Substring("constraint:".Length);
+                    var slacks = new[] { "", "_pos", "_neg" }
+                        .Select(suffix => constraint + ConstraintRelaxer.DefaultSlackSuffix + suffix)
+                        .Where(manager.IndexedVariables.ContainsKey)
+                        .ToHashSet(StringComparer.Ordinal);
+                    if (slacks.Count == 0)
+                        continue;
+
+                    double violation = solution.VariableValues.Where(v => slacks.Contains(SolutionComparison.GetFamily(v.Key)) || slacks.Contains(v.Key)).Sum(v => v.Value);
+                    if (violation > tolerance.Absolute)
+                        yield return Alert(constraint, violation, $"Soft constraint {constraint} ({WeightClass}) is violated by {Format(violation)}");
+                }
+            }
+        }
+
+        public override string ToString() => Text;
+
+        private bool Exceeds(double value) => Operator switch
+        {
+            ">" => value > Threshold,
+            ">=" => value >= Threshold,
+            "<" => value < Threshold,
+            _ => value <= Threshold
+        };
+
+        private string Describe() => Operator switch
+        {
+            ">" => "above",
+            ">=" => "at or above",
+            "<" => "below",
+            _ => "at or below"
+        };
+
+        private ReportAlert Alert(string subject, double value, string message) => new ReportAlert
+        {
+            Severity = Severity,
+            Rule = Text,
+            Subject = subject,
+            Value = value,
+            Threshold = WeightClass == null ? Threshold : null,
+            LineNumber = LineNumber,
+            Message = message
+        };
+
+        private static AlertSeverity SeverityOf(string word) => word == "critical" ? AlertSeverity.Critical : AlertSeverity.Warning;
+
+        private static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+
+        private static string Percent(double value) => value.ToString("0.#%", CultureInfo.InvariantCulture);
+    }
+}
@@ -0,0 +1,248 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Export;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Tolerances used when deciding whether a value changed or a constraint is binding
+    /// </summary>
+    public class ComparisonTolerances : NumericTolerance
+    {
+        /// <summary>
+        /// A constraint is binding when |slack| is at most this value
+        /// </summary>
+        public double BindingSlack { get; init; } = DefaultAbsolute;
+
+        public static new ComparisonTolerances Default => new ComparisonTolerances();
+
+        public bool IsBinding(double slack) => Math.Abs(slack) <= BindingSlack;
+    }
+
+    public class VariableChange
+    {
+        public string Name { get; init; } = "";
+        public double? Before { get; init; }
+        public double? After { get; init; }
+        public double Delta => (After ?? 0.0) - (Before ?? 0.0);
+
+        public override string ToString() =>
+            $"{Name}: {Before?.ToString("G6") ?? "-"} -> {After?.ToString("G6") ?? "-"} ({Delta:+0.######;-0.######;0})";
+    }
+
+    public class ConstraintStatusChange
+    {
+        public string Name { get; init; } = "";
+        public double? SlackBefore { get; init; }
+        public double? SlackAfter { get; init; }
+        public bool WasBinding { get; init; }
+        public bool IsBinding { get; init; }
+
+        public override string ToString() =>
+            $"{Name}: {(WasBinding ? "binding" : "non-binding")} -> {(IsBinding ? "binding" : "non-binding")}";
+    }
+
+    /// <summary>
+    /// Differences between two solutions of (versions of) the same model
+    /// </summary>
+    public class SolutionDelta
+    {
+        public List<VariableChange> VariableChanges { get; } = new List<VariableChange>();
+        public List<ConstraintStatusChange> ConstraintChanges { get; } = new List<ConstraintStatusChange>();
+        public double? ObjectiveBefore { get; init; }
+        public double? ObjectiveAfter { get; init; }
+
+        /// <summary>
+        /// True if both objectives are known and differ beyond the comparison tolerance
+        /// </summary>
+        public bool ObjectiveChanged { get; init; }
+
+        public double? ObjectiveDelta => ObjectiveBefore.HasValue && ObjectiveAfter.HasValue
+            ? ObjectiveAfter.Value - ObjectiveBefore.Value
+            : null;
+
+        public bool HasChanges => VariableChanges.Count > 0 || ConstraintChanges.Count > 0 ||
+                                  ObjectiveChanged;
+
+        public string ToReport()
+        {
+            var sb = new StringBuilder();
+            sb.AppendLine("=== Solution Comparison ===");
+            sb.AppendLine($"Objective: {ObjectiveBefore?.ToString("G") ?? "-"} -> {ObjectiveAfter?.ToString("G") ?? "-"}" +
+                          (ObjectiveDelta.HasValue ? $" (delta {ObjectiveDelta.Value:G})" : ""));
+
+            sb.AppendLine($"\nChanged variables: {VariableChanges.Count}");
+            foreach (var change in VariableChanges.OrderByDescending(c => Math.Abs(c.Delta)))
+                sb.AppendLine($"  - {change}");
+
+            sb.AppendLine($"\nConstraints changing binding status: {ConstraintChanges.Count}");
+            foreach (var change in ConstraintChanges)
+                sb.AppendLine($"  - {change}");
+
+            return sb.ToString();
+        }
+    }
+
+    /// <summary>
+    /// Which declarations of the model a comparison covers. A name is compared when its
+    /// declaration passes every criterion given; null or empty criteria do not restrict.
+    /// </summary>
+    public class ComparisonScope
+    {
+        /// <summary>
+        /// Declared variables and constraint families ("flow", "cap")
+        /// </summary>
+        public IReadOnlyCollection<string>? Families { get; init; }
+
+        /// <summary>
+        /// Tags of the declarations, own or inherited from a block; a tag also selects its descendants
+        /// </summary>
+        public IReadOnlyCollection<string>? Tags { get; init; }
+
+        /// <summary>
+        /// Blocks enclosing the declarations; a block also selects the blocks nested in it
+        /// </summary>
+        public IReadOnlyCollection<string>? Blocks { get; init; }
+    }
+
+    /// <summary>
+    /// Compares two solve results variable by variable and constraint by constraint
+    /// </summary>
+    public static class SolutionComparison
+    {
+        /// <summary>
+        /// Compares solution a (before) with solution b (after).
+        /// The optional filter receives variable and constraint names and decides what to include.
+        /// </summary>
+        public static SolutionDelta Compare(SolveResult a, SolveResult b,
+            ComparisonTolerances? tolerances = null, Func<string, bool>? filter = null)
+        {
+            filter ??= _ => true;
+            return Compare(a, b, tolerances, filter, filter);
+        }
+
+        /// <summary>
+        /// Compares the variables and constraints of the model within the scope. Each name is traced
+        /// to its declaration (dvar flow for flow2_1, constraint family cap for the row cap_3), whose
+        /// tags and block come from the annotations of the model source (see ModelTags).
+        /// </summary>
+        public static SolutionDelta Compare(SolveResult a, SolveResult b, ModelManager model, ComparisonScope scope,
+            ComparisonTolerances? tolerances = null)
+        {
+            var tagged = new Dictionary<string, TaggedEntity>(StringComparer.Ordinal);
+            foreach (var source in model.SourceTexts)
+            {
+                foreach (var entity in ModelTags.Parse(source).Entities)
+                    tagged.TryAdd(entity.Key, entity);
+            }
+
+            var rowFamilies = new Dictionary<string, string>(StringComparer.Ordinal);
+            foreach (var equation in model.MaterializeRows())
+            {
+                if (!string.IsNullOrEmpty(equation.Label))
+                    rowFamilies.TryAdd(equation.Label, GetFamily(equation));
+            }
+
+            bool InScope(EntityKind kind, string family)
+            {
+                if (scope.Families is { Count: > 0 } && !scope.Families.Contains(family))
+                    return false;
+                if (scope.Tags is not { Count: > 0 } && scope.Blocks is not { Count: > 0 })
+                    return true;
+
+                if (!tagged.TryGetValue(EntityCatalog.KeyOf(kind, family), out var entity))
+                    return false;
+                return (scope.Tags is not { Count: > 0 } || scope.Tags.Any(entity.HasTag)) &&
+                       (scope.Blocks is not { Count: > 0 } || (entity.Block != null && scope.Blocks.Any(b => TagPath.Matches(entity.Block, b))));
+            }
+
+            return Compare(a, b, tolerances,
+                name => InScope(EntityKind.Variable, IntegerModel.FindVariableInfo(model, name)?.BaseName ?? GetFamily(name)),
+                name => InScope(EntityKind.Constraint, rowFamilies.GetValueOrDefault(name) ?? GetFamily(name)));
+        }
+
+        private static SolutionDelta Compare(SolveResult a, SolveResult b, ComparisonTolerances? tolerances,
+            Func<string, bool> variableFilter, Func<string, bool> constraintFilter)
+        {
+            tolerances ??= ComparisonTolerances.Default;
+
+            var delta = new SolutionDelta
+            {
+                ObjectiveBefore = a.ObjectiveValue,
+                ObjectiveAfter = b.ObjectiveValue,
+                ObjectiveChanged = a.ObjectiveValue.HasValue && b.ObjectiveValue.HasValue
+                                   && !tolerances.AreEqual(a.ObjectiveValue.Value, b.ObjectiveValue.Value)
+            };
+
+            foreach (var name in a.VariableValues.Keys.Union(b.VariableValues.Keys).Where(variableFilter).OrderBy(n => n, StringComparer.Ordinal))
+            {
+                bool hasBefore = a.VariableValues.TryGetValue(name, out var before);
+                bool hasAfter = b.VariableValues.TryGetValue(name, out var after);
+
+                if (hasBefore && hasAfter && tolerances.AreEqual(before, after))
+                    continue;
+
+                delta.VariableChanges.Add(new VariableChange
+                {
+                    Name = name,
+                    Before = hasBefore ? before : null,
+                    After = hasAfter ? after : null
+                });
+            }
+
+            foreach (var name in a.ConstraintSlacks.Keys.Union(b.ConstraintSlacks.Keys).Where(constraintFilter).OrderBy(n => n, StringComparer.Ordinal))
+            {
+                bool hasBefore = a.ConstraintSlacks.TryGetValue(name, out var slackBefore);
+                bool hasAfter = b.ConstraintSlacks.TryGetValue(name, out var slackAfter);
+
+                bool wasBinding = hasBefore && tolerances.IsBinding(slackBefore);
+                bool isBinding = hasAfter && tolerances.IsBinding(slackAfter);
+
+                if (wasBinding == isBinding && hasBefore == hasAfter)
+                    continue;
+
+                delta.ConstraintChanges.Add(new ConstraintStatusChange
+                {
+                    Name = name,
+                    SlackBefore = hasBefore ? slackBefore : null,
+                    SlackAfter = hasAfter ? slackAfter : null,
+                    WasBinding = wasBinding,
+                    IsBinding = isBinding
+                });
+            }
+
+            return delta;
+        }
+
+        /// <summary>
+        /// Compares only the given variable/constraint families (e.g. "x" matches x1, x2_3; "limit" matches limit_1)
+        /// </summary>
+        public static SolutionDelta Compare(SolveResult a, SolveResult b, ComparisonTolerances? tolerances, IEnumerable<string> families)
+        {
+            var familySet = new HashSet<string>(families);
+            return Compare(a, b, tolerances, name => familySet.Contains(GetFamily(name)));
+        }
+
+        /// <summary>
+        /// Gets the family (base name) of an expanded name by stripping the trailing index part
+        /// </summary>
+        public static string GetFamily(string name)
+        {
+            string family = Regex.Replace(name, @"[0-9_]+$", "");
+            return family.Length > 0 ? family : name;
+        }
+
+        /// <summary>
+        /// Gets the family of an expanded constraint: the name of the constraint it was generated from
+        /// </summary>
+        public static string GetFamily(LinearEquation equation)
+        {
+            if (!string.IsNullOrEmpty(equation.BaseName))
+                return equation.BaseName;
+
+            return string.IsNullOrEmpty(equation.Label) ? "constraints" : GetFamily(equation.Label);
+        }
+    }
+}
@@ -0,0 +1,165 @@
+using System.Globalization;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Export;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// A variable of a solution with its declared domain
+    /// </summary>
+    public class VariableRow
+    {
+        public string Name { get; init; } = "";
+        public double Value { get; init; }
+        public VariableType Type { get; init; } = VariableType.Float;
+        public double? LowerBound { get; init; }
+        public double? UpperBound { get; init; }
+
+        public bool IsDiscrete => Type is VariableType.Integer or VariableType.Boolean;
+    }
+
+    /// <summary>
+    /// A constraint of a solution with its slack
+    /// </summary>
+    public class ConstraintRow
+    {
+        public string Name { get; init; } = "";
+        public double Slack { get; init; }
+        public bool IsBinding { get; init; }
+    }
+
+    /// <summary>
+    /// Filtered view of a solve result. Filters compose: each call returns a new view that keeps
+    /// the filters of this one, so <c>view.NonzeroVariables().BindingConstraints()</c> shows the
+    /// nonzero variables and the binding constraints. Variable filters only narrow the variable
+    /// table and constraint filters the constraint table.
+    /// </summary>
+    public class SolutionView
+    {
+        private readonly List<VariableRow> allVariables;
+        private readonly List<ConstraintRow> allConstraints;
+        private readonly List<Func<VariableRow, bool>> variableFilters;
+        private readonly List<Func<ConstraintRow, bool>> constraintFilters;
+
+        /// <summary>
+        /// Creates an unfiltered view; the manager supplies variable types and bounds
+        /// (without it every variable is a free continuous one)
+        /// </summary>
+        public SolutionView(SolveResult result, ModelManager? manager = null, ComparisonTolerances? tolerances = null)
+        {
+            Tolerances = tolerances ?? ComparisonTolerances.Default;
+            allVariables = result.VariableValues
+                .OrderBy(v => v.Key, StringComparer.Ordinal)
+                .Select(v =>
+                {
+                    var declaration = manager == null ? null : IntegerModel.FindVariableInfo(manager, v.Key);
+                    var type = declaration?.Type ?? VariableType.Float;
+                    return new VariableRow
+                    {
+                        Name = v.Key,
+                        Value = v.Value,
+                        Type = type,
+                        LowerBound = type == VariableType.Boolean ? 0 : declaration?.GetBounds(v.Key).Lower,
+                        UpperBound = type == VariableType.Boolean ? 1 : declaration?.GetBounds(v.Key).Upper
+                    };
+                })
+                .ToList();
+            allConstraints = result.ConstraintSlacks
+                .OrderBy(c => c.Key, StringComparer.Ordinal)
+                .Select(c => new ConstraintRow { Name = c.Key, Slack = c.Value, IsBinding = Tolerances.IsBinding(c.Value) })
+                .ToList();
+            variableFilters = new List<Func<VariableRow, bool>>();
+            constraintFilters = new List<Func<ConstraintRow, bool>>();
+        }
+
+        private SolutionView(SolutionView source, Func<VariableRow, bool>? variableFilter, Func<ConstraintRow, bool>? constraintFilter)
+        {
+            Tolerances = source.Tolerances;
+            allVariables = source.allVariables;
+            allConstraints = source.allConstraints;
+            variableFilters = new List<Func<VariableRow, bool>>(source.variableFilters);
+            constraintFilters = new List<Func<ConstraintRow, bool>>(source.constraintFilters);
+            if (variableFilter != null)
+                variableFilters.Add(variableFilter);
+            if (constraintFilter != null)
+                constraintFilters.Add(constraintFilter);
+        }
+
+        public ComparisonTolerances Tolerances { get; }
+
+        public IReadOnlyList<VariableRow> Variables => allVariables.Where(v => variableFilters.All(f => f(v))).ToList();
+        public IReadOnlyList<ConstraintRow> Constraints => allConstraints.Where(c => constraintFilters.All(f => f(c))).ToList();
+
+        public SolutionView Where(Func<VariableRow, bool> filter) => new SolutionView(this, filter, null);
+        public SolutionView WhereConstraint(Func<ConstraintRow, bool> filter) => new SolutionView(this, null, filter);
+
+        public SolutionView NonzeroVariables() => Where(v => !Tolerances.IsZero(v.Value));
+
+        public SolutionView BindingConstraints() => WhereConstraint(c => c.IsBinding);
+
+        /// <summary>
+        /// Variables within epsilon (default: the absolute tolerance) of a finite bound
+        /// </summary>
+        public SolutionView AtBounds(double? epsilon = null)
+        {
+            double eps = epsilon ?? Tolerances.Absolute;
+            return Where(v => (v.LowerBound.HasValue && Math.Abs(v.Value - v.LowerBound.Value) <= eps) ||
+                              (v.UpperBound.HasValue && Math.Abs(v.Value - v.UpperBound.Value) <= eps));
+        }
+
+        /// <summary>
+        /// Integer and binary variables whose value is not integral, e.g. in the solution of an LP relaxation
+        /// </summary>
+        public SolutionView FractionalIntegers() => Where(v => v.IsDiscrete && !Tolerances.IsIntegral(v.Value));
+
+        /// <summary>
+        /// Variables and constraints whose name matches a wildcard pattern ('*' and '?')
+        /// </summary>
+        public SolutionView Matching(string pattern)
+        {
+            var regex = new Regex("^" + Regex.Escape(pattern).Replace("\\*", ".*").Replace("\\?", ".") + "$");
+            return new SolutionView(this, v => regex.IsMatch(v.Name), c => regex.IsMatch(c.Name));
+        }
+
+        /// <summary>
+        /// Aligned text tables of the variables and constraints in the view
+        /// </summary>
+        public string ToTable()
+        {
+            var sb = new StringBuilder();
+            var variables = Variables;
+            sb.AppendLine($"Variables ({variables.Count} of {allVariables.Count})");
+            AppendTable(sb, new[] { "Name", "Value", "Lower", "Upper", "Type" },
+                variables.Select(v => new[]
+                {
+                    v.Name, Format(v.Value), v.LowerBound.HasValue ? Format(v.LowerBound.Value) : "-∞",
+                    v.UpperBound.HasValue ? Format(v.UpperBound.Value) : "∞", v.Type.ToString().ToLowerInvariant()
+                }));
+
+            var constraints = Constraints;
+            sb.AppendLine();
+            sb.AppendLine($"Constraints ({constraints.Count} of {allConstraints.Count})");
+            AppendTable(sb, new[] { "Name", "Slack", "Status" },
+                constraints.Select(c => new[] { c.Name, Format(c.Slack), c.IsBinding ? "binding" : "" }));
+            return sb.ToString();
+        }
+
+        private static void AppendTable(StringBuilder sb, string[] header, IEnumerable<string[]> rows)
+        {
+            var all = new List<string[]> { header };
+            all.AddRange(rows);
+            var widths = header.Select((_, i) => all.Max(r => r[i].Length)).ToArray();
+
+            foreach (var row in all)
+            {
+                // Names left-aligned, numbers right-aligned
+                var cells = row.Select((cell, i) => i == 0 || i == row.Length - 1 ? cell.PadRight(widths[i]) : cell.PadLeft(widths[i]));
+                sb.AppendLine("  " + string.Join("  ", cells).TrimEnd());
+            }
+        }
+
+        private static string Format(double value) => value.ToString("G6", CultureInfo.InvariantCulture);
+    }
+}
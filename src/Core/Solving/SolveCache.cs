@@ -0,0 +1,200 @@
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using Core.Analysis;
+using Core.Export;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Remembers solve results by model fingerprint, solver and solver options, so solving an
+    /// unchanged model again (a validation pipeline rerun, a UI refresh) returns the stored
+    /// result instead of calling the solver. Holds up to Capacity results in memory; with a
+    /// spill directory, results pushed out of memory are written there as JSON and read back
+    /// on a later hit. Error results are not cached. Safe to share between threads.
+    /// </summary>
+    public class SolveCache
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            Converters = { new JsonStringEnumConverter() }
+        };
+
+        private readonly object sync = new object();
+        private readonly Dictionary<string, LinkedListNode<(string Key, SolveResult Result)>> entries = new();
+
+        // Most recently used first
+        private readonly LinkedList<(string Key, SolveResult Result)> order = new();
+
+        public SolveCache(int capacity = 64, string? spillDirectory = null)
+        {
+            if (capacity < 1)
+                throw new ArgumentOutOfRangeException(nameof(capacity), "Capacity must be at least 1");
+
+            Capacity = capacity;
+            SpillDirectory = spillDirectory;
+            if (spillDirectory != null)
+                Directory.CreateDirectory(spillDirectory);
+        }
+
+        public int Capacity { get; }
+
+        /// <summary>
+        /// Directory for results evicted from memory, null to drop them
+        /// </summary>
+        public string? SpillDirectory { get; }
+
+        public int Hits { get; private set; }
+        public int Misses { get; private set; }
+
+        /// <summary>
+        /// Results held in memory
+        /// </summary>
+        public int Count
+        {
+            get { lock (sync) return entries.Count; }
+        }
+
+        /// <summary>
+        /// Cache key of solving the expanded model with the driver as currently configured
+        /// (lowercase hex SHA-256 of the model hash, the solver name and its options)
+        /// </summary>
+        public static string KeyOf(ModelManager manager, ISolverDriver driver)
+        {
+            var sb = new StringBuilder();
+            sb.Append(ModelFingerprint.Compute(manager).ModelHash).Append('\n').Append(driver.Name);
+            foreach (var (name, value) in RunBundle.ReadSolverOptions(driver))
+                sb.Append('\n').Append(name).Append('=').Append(value);
+            return Convert.ToHexString(SHA256.HashData(Encoding.UTF8.GetBytes(sb.ToString()))).ToLowerInvariant();
+        }
+
+        public bool TryGet(string key, out SolveResult result)
+        {
+            lock (sync)
+            {
+                if (entries.TryGetValue(key, out var node))
+                {
+                    order.Remove(node);
+                    order.AddFirst(node);
+                    Hits++;
+                    result = node.Value.Result;
+                    return true;
+                }
+
+                var spilled = ReadSpilled(key);
+                if (spilled != null)
+                {
+                    Hits++;
+                    Store(key, spilled);
+                    result = spilled;
+                    return true;
+                }
+
+                Misses++;
+                result = null!;
+                return false;
+            }
+        }
+
+        /// <summary>
+        /// Stores a result; error results are ignored
+        /// </summary>
+        public void Put(string key, SolveResult result)
+        {
+            if (result.Status == SolveStatus.Error)
+                return;
+
+            lock (sync)
+            {
+                if (entries.TryGetValue(key, out var existing))
+                {
+                    order.Remove(existing);
+                    entries.Remove(key);
+                }
+                Store(key, result);
+            }
+        }
+
+        /// <summary>
+        /// Returns the cached result of solving the model with the driver, or solves and caches it
+        /// </summary>
+        public SolveResult GetOrSolve(ModelManager manager, ISolverDriver driver, CancellationToken cancellationToken = default)
+        {
+            string key = KeyOf(manager, driver);
+            if (TryGet(key, out var cached))
+                return cached;
+
+            var result = driver.Solve(manager, cancellationToken);
+            Put(key, result);
+            return result;
+        }
+
+        /// <summary>
+        /// Drops all results, including those spilled to disk
+        /// </summary>
+        public void Clear()
+        {
+            lock (sync)
+            {
+                entries.Clear();
+                order.Clear();
+                if (SpillDirectory != null && Directory.Exists(SpillDirectory))
+                {
+                    foreach (var file in Directory.EnumerateFiles(SpillDirectory, "*.json"))
+                        File.Delete(file);
+                }
+            }
+        }
+
+        private void Store(string key, SolveResult result)
+        {
+            entries[key] = order.AddFirst((key, result));
+            while (entries.Count > Capacity)
+            {
+                var evicted = order.Last!.Value;
+                order.RemoveLast();
+                entries.Remove(evicted.Key);
+                Spill(evicted.Key, evicted.Result);
+            }
+        }
+
+        private void Spill(string key, SolveResult result)
+        {
+            if (SpillDirectory == null)
+                return;
+
+            try
+            {
+                File.WriteAllBytes(SpillPath(key), JsonSerializer.SerializeToUtf8Bytes(result, jsonOptions));
+            }
+            catch (IOException)
+            {
+                // A full or read-only disk only costs a future solve
+            }
+        }
+
+        private SolveResult? ReadSpilled(string key)
+        {
+            if (SpillDirectory == null)
+                return null;
+
+            string path = SpillPath(key);
+            if (!File.Exists(path))
+                return null;
+
+            try
+            {
+                return JsonSerializer.Deserialize<SolveResult>(File.ReadAllBytes(path), jsonOptions);
+            }
+            catch (Exception ex) when (ex is IOException or JsonException)
+            {
+                return null;
+            }
+        }
+
+        private string SpillPath(string key) => Path.Combine(SpillDirectory!, key + ".json");
+    }
+}
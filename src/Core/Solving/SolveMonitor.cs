@@ -0,0 +1,216 @@
+using System.Globalization;
+
+namespace Core.Solving
+{
+    public enum SolverEventKind
+    {
+        /// <summary>A new best solution was found</summary>
+        Incumbent,
+
+        /// <summary>The proven bound on the objective improved</summary>
+        Bound,
+
+        /// <summary>Periodic count of explored branch-and-bound nodes</summary>
+        Nodes
+    }
+
+    /// <summary>
+    /// Event reported by a driver while it solves; only the fields of the event's kind are set
+    /// </summary>
+    public class SolverEvent
+    {
+        public SolverEventKind Kind { get; init; }
+        public TimeSpan Elapsed { get; init; }
+        public double? ObjectiveValue { get; init; }
+        public double? BestBound { get; init; }
+        public long? NodeCount { get; init; }
+
+        /// <summary>
+        /// Values of the new incumbent keyed by expanded variable name, when the backend reports them
+        /// </summary>
+        public IReadOnlyDictionary<string, double>? Values { get; init; }
+
+        public override string ToString()
+        {
+            string time = Elapsed.TotalSeconds.ToString("F1", CultureInfo.InvariantCulture);
+            return Kind switch
+            {
+                SolverEventKind.Incumbent => $"[{time}s] incumbent {Format(ObjectiveValue)}",
+                SolverEventKind.Bound => $"[{time}s] bound {Format(BestBound)}",
+                _ => $"[{time}s] {NodeCount} nodes"
+            };
+        }
+
+        private static string Format(double? value) => value?.ToString("G6", CultureInfo.InvariantCulture) ?? "?";
+    }
+
+    /// <summary>
+    /// Follows a running solve: receives the driver's events, keeps the current best incumbent
+    /// so it can be read as a SolveResult before the solve finishes, and lets the caller stop
+    /// the run once the incumbent is good enough. Safe to read from other threads.
+    /// </summary>
+    public class SolveMonitor
+    {
+        private readonly object sync = new object();
+        private readonly CancellationTokenSource stop = new CancellationTokenSource();
+        private SolveResult? incumbent;
+        private double? bestBound;
+        private long nodeCount;
+
+        /// <summary>
+        /// Raised on the driver's thread for every event
+        /// </summary>
+        public event Action<SolverEvent>? EventReported;
+
+        /// <summary>
+        /// Stop automatically once the relative gap between incumbent and bound is at most this
+        /// </summary>
+        public double? StopAtGap { get; init; }
+
+        /// <summary>
+        /// Best solution so far, null until the first incumbent. Its variable values are empty
+        /// when the backend reports only objective values while it runs.
+        /// </summary>
+        public SolveResult? Incumbent
+        {
+            get { lock (sync) return incumbent; }
+        }
+
+        public double? BestBound
+        {
+            get { lock (sync) return bestBound; }
+        }
+
+        public long NodeCount
+        {
+            get { lock (sync) return nodeCount; }
+        }
+
+        public double? MipGap
+        {
+            get { lock (sync) return Gap(incumbent?.ObjectiveValue, bestBound); }
+        }
+
+        public bool IsStopRequested => stop.IsCancellationRequested;
+
+        /// <summary>
+        /// Cancelled when Stop is called; drivers end their backend on it
+        /// </summary>
+        public CancellationToken StopToken => stop.Token;
+
+        /// <summary>
+        /// Asks the driver to end the solve and return the current incumbent
+        /// </summary>
+        public void Stop() => stop.Cancel();
+
+        public void Report(SolverEvent solverEvent)
+        {
+            double? gap;
+            lock (sync)
+            {
+                switch (solverEvent.Kind)
+                {
+                    case SolverEventKind.Incumbent:
+                        if (solverEvent.BestBound.HasValue)
+                            bestBound = solverEvent.BestBound;
+                        incumbent = new SolveResult
+                        {
+                            Status = SolveStatus.Feasible,
+                            ObjectiveValue = solverEvent.ObjectiveValue,
+                            VariableValues = solverEvent.Values?.ToDictionary(v => v.Key, v => v.Value) ?? new Dictionary<string, double>(),
+                            BestBound = bestBound,
+                            MipGap = Gap(solverEvent.ObjectiveValue, bestBound),
+                            SolveTime = solverEvent.Elapsed,
+                            StatusMessage = "Incumbent of a running solve"
+                        };
+                        break;
+                    case SolverEventKind.Bound:
+                        bestBound = solverEvent.BestBound;
+                        break;
+                    case SolverEventKind.Nodes:
+                        nodeCount = solverEvent.NodeCount ?? nodeCount;
+                        break;
+                }
+                gap = Gap(incumbent?.ObjectiveValue, bestBound);
+            }
+
+            EventReported?.Invoke(solverEvent);
+
+            if (StopAtGap.HasValue && gap.HasValue && gap.Value <= StopAtGap.Value)
+                Stop();
+        }
+
+        /// <summary>
+        /// Records the final result of a driver: a solved result becomes the incumbent (reported
+        /// as an event if the driver had not seen it yet). Returns the result.
+        /// </summary>
+        public SolveResult Complete(SolveResult result)
+        {
+            if (result.Status is not (SolveStatus.Optimal or SolveStatus.Feasible))
+                return result;
+
+            if (Incumbent?.ObjectiveValue != result.ObjectiveValue)
+            {
+                Report(new SolverEvent
+                {
+                    Kind = SolverEventKind.Incumbent,
+                    Elapsed = result.SolveTime,
+                    ObjectiveValue = result.ObjectiveValue,
+                    BestBound = result.BestBound,
+                    Values = result.VariableValues
+                });
+            }
+
+            lock (sync)
+            {
+                incumbent = result;
+                bestBound = result.BestBound ?? bestBound;
+            }
+            return result;
+        }
+
+        /// <summary>
+        /// Result of a solve ended by Stop: the incumbent, or an error if there was none yet
+        /// </summary>
+        public SolveResult StoppedResult(TimeSpan elapsed)
+        {
+            lock (sync)
+            {
+                if (incumbent == null)
+                {
+                    return new SolveResult
+                    {
+                        Status = SolveStatus.Error,
+                        StatusMessage = "Stopped before an incumbent was found",
+                        SolveTime = elapsed
+                    };
+                }
+
+                double? gap = Gap(incumbent.ObjectiveValue, bestBound);
+                return new SolveResult
+                {
+                    Status = SolveStatus.Feasible,
+                    ObjectiveValue = incumbent.ObjectiveValue,
+                    VariableValues = incumbent.VariableValues,
+                    ConstraintSlacks = incumbent.ConstraintSlacks,
+                    BestBound = bestBound,
+                    MipGap = gap,
+                    SolveTime = elapsed,
+                    StatusMessage = gap.HasValue
+                        ? $"Stopped with incumbent at gap {gap.Value.ToString("P2", CultureInfo.InvariantCulture)}"
+                        : "Stopped with incumbent"
+                };
+            }
+        }
+
+        /// <summary>
+        /// Relative gap |objective - bound| / |objective|, as CPLEX and CP-SAT report it
+        /// </summary>
+        public static double? Gap(double? objective, double? bound)
+        {
+            if (!objective.HasValue || !bound.HasValue)
+                return null;
+            return Math.Abs(objective.Value - bound.Value) / Math.Max(1e-10, Math.Abs(objective.Value));
+        }
+    }
+}
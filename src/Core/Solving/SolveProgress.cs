@@ -0,0 +1,26 @@
+namespace Core.Solving
+{
+    public enum SolvePhase
+    {
+        Parsing,
+        Solving,
+        Completed,
+        Failed
+    }
+
+    /// <summary>
+    /// Progress event reported while a solve runs; the objective fields are set once known
+    /// </summary>
+    public class SolveProgress
+    {
+        public SolvePhase Phase { get; init; }
+        public TimeSpan Elapsed { get; init; }
+        public string Message { get; init; } = "";
+        public SolveStatus? Status { get; init; }
+        public double? ObjectiveValue { get; init; }
+        public double? BestBound { get; init; }
+        public double? MipGap { get; init; }
+
+        public override string ToString() => $"[{Elapsed.TotalSeconds:F1}s] {Phase}: {Message}";
+    }
+}
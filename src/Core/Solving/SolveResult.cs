@@ -16,7 +16,27 @@ namespace Core.Solving
         public Dictionary<string, double> VariableValues { get; init; } = new();
         public Dictionary<string, double> ConstraintSlacks { get; init; } = new();
         public double? MipGap { get; init; }
+
+        /// <summary>
+        /// Best proven bound on the objective, when the solver reports one
+        /// </summary>
+        public double? BestBound { get; init; }
         public TimeSpan SolveTime { get; init; }
         public string? StatusMessage { get; init; }
+
+        /// <summary>
+        /// Values of the model's KPIs at this solution, by name in definition order (see ReportDefinition)
+        /// </summary>
+        public Dictionary<string, double> Kpis { get; init; } = new();
+
+        /// <summary>
+        /// Alert rules of the model that fired for this solution
+        /// </summary>
+        public List<ReportAlert> Alerts { get; init; } = new();
+
+        /// <summary>
+        /// Unfiltered view of the solution, for filtering and table output (see SolutionView)
+        /// </summary>
+        public SolutionView View(ModelManager? manager = null) => new SolutionView(this, manager);
     }
 }
@@ -0,0 +1,152 @@
+using System.Text.Json;
+using System.Text.Json.Serialization;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// One driver's outcome in one race
+    /// </summary>
+    public class SolverRun
+    {
+        public string Solver { get; init; } = "";
+
+        /// <summary>
+        /// Fingerprint of the raced model (ModelFingerprint.ModelHash)
+        /// </summary>
+        public string ModelHash { get; init; } = "";
+
+        public SolveStatus Status { get; init; }
+        public double Seconds { get; init; }
+        public bool Won { get; init; }
+        public DateTime Timestamp { get; init; }
+    }
+
+    /// <summary>
+    /// Summary of a driver's races
+    /// </summary>
+    public class SolverStatistics
+    {
+        public string Solver { get; init; } = "";
+        public int Races { get; init; }
+        public int Wins { get; init; }
+
+        /// <summary>
+        /// Median time of the races the driver won, null if it won none
+        /// </summary>
+        public double? MedianWinSeconds { get; init; }
+
+        public double WinRate => Races == 0 ? 0 : (double)Wins / Races;
+    }
+
+    /// <summary>
+    /// History of solver races, for choosing a driver without racing: which solver wins most
+    /// often, overall or on a given model. Saved as a JSON file next to the models.
+    /// </summary>
+    public class SolverPerformance
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            Converters = { new JsonStringEnumConverter() },
+            WriteIndented = true
+        };
+
+        private readonly object sync = new object();
+        private readonly List<SolverRun> runs = new List<SolverRun>();
+        private readonly Func<DateTime> clock;
+
+        public SolverPerformance(Func<DateTime>? clock = null)
+        {
+            this.clock = clock ?? (() => DateTime.UtcNow);
+        }
+
+        public IReadOnlyList<SolverRun> Runs
+        {
+            get { lock (sync) return runs.ToList(); }
+        }
+
+        public void Record(string modelHash, IEnumerable<RaceEntry> entries)
+        {
+            var now = clock();
+            lock (sync)
+            {
+                runs.AddRange(entries.Select(e => new SolverRun
+                {
+                    Solver = e.Solver,
+                    ModelHash = modelHash,
+                    Status = e.Status,
+                    Seconds = e.Elapsed.TotalSeconds,
+                    Won = e.Won,
+                    Timestamp = now
+                }));
+            }
+        }
+
+        /// <summary>
+        /// Per-driver statistics, best first (most wins relative to races, then fastest wins),
+        /// over all races or only those of one model
+        /// </summary>
+        public IReadOnlyList<SolverStatistics> Statistics(string? modelHash = null)
+        {
+            lock (sync)
+            {
+                return runs
+                    .Where(r => modelHash == null || r.ModelHash == modelHash)
+                    .GroupBy(r => r.Solver)
+                    .Select(g => new SolverStatistics
+                    {
+                        Solver = g.Key,
+                        Races = g.Count(),
+                        Wins = g.Count(r => r.Won),
+                        MedianWinSeconds = Median(g.Where(r => r.Won).Select(r => r.Seconds).ToList())
+                    })
+                    .OrderByDescending(s => s.WinRate)
+                    .ThenBy(s => s.MedianWinSeconds ?? double.MaxValue)
+                    .ThenBy(s => s.Solver, StringComparer.Ordinal)
+                    .ToList();
+            }
+        }
+
+        /// <summary>
+        /// The driver that has done best on the model, falling back to all races when the model
+        /// has not been raced; null when none of the drivers has a record
+        /// </summary>
+        public ISolverDriver? Choose(IEnumerable<ISolverDriver> drivers, string? modelHash = null)
+        {
+            var candidates = drivers.ToList();
+            var ranking = modelHash != null && Statistics(modelHash).Count > 0 ? Statistics(modelHash) : Statistics();
+            return ranking
+                .Select(s => candidates.FirstOrDefault(d => d.Name == s.Solver))
+                .FirstOrDefault(d => d != null);
+        }
+
+        public void Save(string path)
+        {
+            File.WriteAllText(path, JsonSerializer.Serialize(Runs, jsonOptions));
+        }
+
+        /// <summary>
+        /// Reads a saved history; a missing file gives an empty one
+        /// </summary>
+        public static SolverPerformance Load(string path)
+        {
+            var performance = new SolverPerformance();
+            if (File.Exists(path))
+            {
+                var saved = JsonSerializer.Deserialize<List<SolverRun>>(File.ReadAllText(path), jsonOptions)
+                            ?? throw new InvalidOperationException($"'{path}' is not a solver performance file");
+                performance.runs.AddRange(saved);
+            }
+            return performance;
+        }
+
+        private static double? Median(List<double> values)
+        {
+            if (values.Count == 0)
+                return null;
+            values.Sort();
+            int middle = values.Count / 2;
+            return values.Count % 2 == 1 ? values[middle] : (values[middle - 1] + values[middle]) / 2;
+        }
+    }
+}
@@ -0,0 +1,180 @@
+using System.Diagnostics;
+using Core.Analysis;
+using Core.Models;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// How one driver did in a race
+    /// </summary>
+    public class RaceEntry
+    {
+        public string Solver { get; init; } = "";
+        public SolveStatus Status { get; init; }
+        public double? ObjectiveValue { get; init; }
+        public TimeSpan Elapsed { get; init; }
+
+        /// <summary>
+        /// The driver was stopped because another one finished first or the time limit passed
+        /// </summary>
+        public bool Stopped { get; init; }
+
+        public bool Won { get; init; }
+        public string? StatusMessage { get; init; }
+    }
+
+    public class RaceResult
+    {
+        /// <summary>
+        /// Result of the winner, or an error result if no driver found a solution or proof
+        /// </summary>
+        public SolveResult Result { get; init; } = new SolveResult();
+
+        public string? Winner { get; init; }
+        public List<RaceEntry> Entries { get; init; } = new List<RaceEntry>();
+    }
+
+    /// <summary>
+    /// Solves one model with several drivers at once. The first driver to prove its answer
+    /// (optimal, infeasible or unbounded) wins and the others are stopped; when the time limit
+    /// passes first, every driver is stopped and the best incumbent wins. The drivers share the
+    /// manager, which they only read. Each run is recorded in Performance when it is set.
+    /// </summary>
+    public class SolverRace
+    {
+        private readonly List<ISolverDriver> drivers;
+
+        public SolverRace(IEnumerable<ISolverDriver> drivers)
+        {
+            this.drivers = drivers.ToList();
+            if (this.drivers.Count == 0)
+                throw new ArgumentException("A race needs at least one driver", nameof(drivers));
+            var duplicate = this.drivers.GroupBy(d => d.Name).FirstOrDefault(g => g.Count() > 1);
+            if (duplicate != null)
+                throw new ArgumentException($"Driver '{duplicate.Key}' is in the race twice", nameof(drivers));
+        }
+
+        public TimeSpan? TimeLimit { get; init; }
+
+        /// <summary>
+        /// How long stopped drivers get to return their incumbent before they are abandoned
+        /// </summary>
+        public TimeSpan StopGrace { get; init; } = TimeSpan.FromSeconds(5);
+
+        public SolverPerformance? Performance { get; init; }
+
+        /// <summary>
+        /// Cancellation stops every driver and throws OperationCanceledException
+        /// </summary>
+        public async Task<RaceResult> RunAsync(ModelManager manager, CancellationToken cancellationToken = default)
+        {
+            var sw = Stopwatch.StartNew();
+            var monitors = drivers.Select(_ => new SolveMonitor()).ToList();
+            var finishedAt = new TimeSpan[drivers.Count];
+            var tasks = drivers.Select((driver, i) => Task.Run(() =>
+            {
+                try
+                {
+                    return driver.Solve(manager, monitors[i], cancellationToken);
+                }
+                catch (OperationCanceledException) when (!cancellationToken.IsCancellationRequested)
+                {
+                    return monitors[i].StoppedResult(sw.Elapsed);
+                }
+                catch (Exception ex) when (ex is not OperationCanceledException)
+                {
+                    return new SolveResult { Status = SolveStatus.Error, StatusMessage = ex.Message };
+                }
+                finally
+                {
+                    finishedAt[i] = sw.Elapsed;
+                }
+            })).ToList();
+
+            int? winner = null;
+            var timeout = TimeLimit.HasValue ? Task.Delay(TimeLimit.Value, cancellationToken) : Task.Delay(Timeout.Infinite, cancellationToken);
+            var running = new List<Task<SolveResult>>(tasks);
+            while (running.Count > 0)
+            {
+                var finished = await Task.WhenAny(running.Cast<Task>().Append(timeout));
+                cancellationToken.ThrowIfCancellationRequested();
+                if (finished == timeout)
+                    break;
+
+                var task = (Task<SolveResult>)finished;
+                running.Remove(task);
+                if (IsProven(task.Result.Status))
+                {
+                    winner = tasks.IndexOf(task);
+                    break;
+                }
+            }
+
+            // Stopped drivers return their incumbent; those that cannot stop early are abandoned
+            // after the grace period with whatever incumbent they reported
+            bool[] stopped = tasks.Select(t => !t.IsCompleted).ToArray();
+            foreach (var monitor in monitors)
+                monitor.Stop();
+            await Task.WhenAny(Task.WhenAll(tasks), Task.Delay(StopGrace, cancellationToken));
+            cancellationToken.ThrowIfCancellationRequested();
+
+            var results = tasks.Select((t, i) =>
+            {
+                if (t.IsCompletedSuccessfully)
+                    return t.Result;
+                finishedAt[i] = sw.Elapsed;
+                return monitors[i].StoppedResult(sw.Elapsed);
+            }).ToList();
+            winner ??= Best(results, manager.Objective?.Sense ?? ObjectiveSense.Minimize);
+
+            var race = new RaceResult
+            {
+                Winner = winner.HasValue ? drivers[winner.Value].Name : null,
+                Result = winner.HasValue
+                    ? results[winner.Value]
+                    : new SolveResult
+                    {
+                        Status = SolveStatus.Error,
+                        StatusMessage = "No driver found a solution: " + string.Join("; ", results.Select((r, i) => $"{drivers[i].Name}: {r.StatusMessage ?? r.Status.ToString()}")),
+                        SolveTime = sw.Elapsed
+                    }
+            };
+            for (int i = 0; i < drivers.Count; i++)
+            {
+                race.Entries.Add(new RaceEntry
+                {
+                    Solver = drivers[i].Name,
+                    Status = results[i].Status,
+                    ObjectiveValue = results[i].ObjectiveValue,
+                    Elapsed = finishedAt[i],
+                    Stopped = stopped[i],
+                    Won = i == winner,
+                    StatusMessage = results[i].StatusMessage
+                });
+            }
+
+            Performance?.Record(ModelFingerprint.Compute(manager).ModelHash, race.Entries);
+            return race;
+        }
+
+        private static bool IsProven(SolveStatus status) => status is SolveStatus.Optimal or SolveStatus.Infeasible or SolveStatus.Unbounded;
+
+        /// <summary>
+        /// Index of the best solution among results that have one, null if none does
+        /// </summary>
+        private static int? Best(List<SolveResult> results, ObjectiveSense sense)
+        {
+            var solved = results
+                .Select((r, i) => (Result: r, Index: i))
+                .Where(r => r.Result.Status is SolveStatus.Optimal or SolveStatus.Feasible)
+                .ToList();
+            if (solved.Count == 0)
+                return null;
+
+            double Score(SolveResult r) => r.ObjectiveValue.HasValue
+                ? (sense == ObjectiveSense.Maximize ? -r.ObjectiveValue.Value : r.ObjectiveValue.Value)
+                : double.PositiveInfinity;
+            return solved.OrderBy(r => Score(r.Result)).ThenBy(r => r.Index).First().Index;
+        }
+    }
+}
@@ -0,0 +1,158 @@
+using System.Diagnostics;
+using System.Globalization;
+using System.Text;
+using System.Text.Json;
+
+namespace Core.Solving
+{
+    /// <summary>
+    /// Runs jobs on a machine reachable with the ssh client (keys and host settings come from
+    /// the user's ssh configuration). Each job gets a directory under RemoteDirectory holding
+    /// model.mof.json, log.txt, result.json and, once the solver exits, exit_code (RemoteDirectory
+    /// is quoted, so give it absolute or relative to the login directory, without '~'). The remote
+    /// SolverCommand is run as "&lt;command&gt; --solver &lt;solver&gt; [--option value ...] model.mof.json result.json"
+    /// and must write the SolveResult as JSON. ssh's exit code 255 (connection failure) is transient.
+    /// </summary>
+    public class SshSolveTransport : IRemoteSolveTransport
+    {
+        public SshSolveTransport(string host, string remoteDirectory, string solverCommand)
+        {
+            Host = host;
+            RemoteDirectory = remoteDirectory.TrimEnd('/');
+            SolverCommand = solverCommand;
+        }
+
+        public string Host { get; }
+        public string RemoteDirectory { get; }
+        public string SolverCommand { get; }
+
+        /// <summary>
+        /// Path to the ssh client
+        /// </summary>
+        public string SshPath { get; set; } = "ssh";
+
+        /// <summary>
+        /// Extra ssh arguments placed before the host, e.g. "-p", "2222" or "-i", "key"
+        /// </summary>
+        public List<string> SshArguments { get; } = new List<string> { "-o", "BatchMode=yes" };
+
+        public string Endpoint => $"ssh {Host}";
+
+        public async Task<long> GetUploadedLengthAsync(string jobId, CancellationToken cancellationToken)
+        {
+            string output = await RunAsync($"stat -c %s {Quote(JobPath(jobId, "model.mof.json"))} 2>/dev/null || echo 0", null, cancellationToken);
+            return long.TryParse(output.Trim(), NumberStyles.Integer, CultureInfo.InvariantCulture, out var length) ? length : 0;
+        }
+
+        public async Task UploadAsync(string jobId, long offset, long totalLength, ReadOnlyMemory<byte> chunk, CancellationToken cancellationToken)
+        {
+            // dd writes the chunk at its offset, so a retried chunk overwrites what arrived of it
+            string model = Quote(JobPath(jobId, "model.mof.json"));
+            await RunAsync($"mkdir -p {Quote(JobPath(jobId, null))} && dd of={model} bs=1M seek={offset} oflag=seek_bytes conv=notrunc status=none",
+                chunk, cancellationToken);
+        }
+
+        public async Task StartAsync(string jobId, string solver, IReadOnlyDictionary<string, string> options, CancellationToken cancellationToken)
+        {
+            var command = new StringBuilder(SolverCommand).Append(" --solver ").Append(Quote(solver));
+            foreach (var (name, value) in options.OrderBy(o => o.Key, StringComparer.Ordinal))
+                command.Append(" --").Append(Quote(name)).Append(' ').Append(Quote(value));
+            command.Append(" model.mof.json result.json");
+
+            string script = $"{command} > log.txt 2>&1; echo $? > exit_code";
+            await RunAsync($"cd {Quote(JobPath(jobId, null))} && rm -f exit_code result.json && (nohup sh -c {Quote(script)} > /dev/null 2>&1 &)",
+                null, cancellationToken);
+        }
+
+        public async Task<RemoteJobStatus> PollAsync(string jobId, long logOffset, CancellationToken cancellationToken)
+        {
+            // First line: the exit code, or "running"; then the log from the offset
+            string output = await RunAsync(
+                $"cd {Quote(JobPath(jobId, null))} && (cat exit_code 2>/dev/null || echo running) && tail -c +{logOffset + 1} log.txt 2>/dev/null",
+                null, cancellationToken);
+
+            int newline = output.IndexOf('\n');
+            string state = (newline >= 0 ? output.Substring(0, newline) : output).Trim();
+            string log = newline >= 0 ? output.Substring(newline + 1) : "";
+            return new RemoteJobStatus
+            {
+                State = state switch
+                {
+                    "running" => RemoteJobState.Running,
+                    "0" => RemoteJobState.Completed,
+                    _ => RemoteJobState.Failed
+                },
+                Log = log,
+                LogOffset = logOffset + Encoding.UTF8.GetByteCount(log),
+                Message = state is "running" or "0" ? null : $"{SolverCommand} exited with code {state}"
+            };
+        }
+
+        public async Task<SolveResult> GetResultAsync(string jobId, CancellationToken cancellationToken)
+        {
+            string json = await RunAsync($"cat {Quote(JobPath(jobId, "result.json"))}", null, cancellationToken);
+            try
+            {
+                return JsonSerializer.Deserialize<SolveResult>(json, RemoteSolverDriver.JsonOptions)
+                       ?? throw new RemoteSolveException($"{Endpoint} returned an empty result", false);
+            }
+            catch (JsonException ex)
+            {
+                throw new RemoteSolveException($"Result on {Endpoint} is not valid JSON: {ex.Message}", false, ex);
+            }
+        }
+
+        public async Task CancelAsync(string jobId, CancellationToken cancellationToken)
+        {
+            await RunAsync($"pkill -f {Quote(JobPath(jobId, null))} || true", null, cancellationToken);
+        }
+
+        /// <summary>
+        /// Runs a shell command on the host and returns its output
+        /// </summary>
+        protected virtual async Task<string> RunAsync(string remoteCommand, ReadOnlyMemory<byte>? input, CancellationToken cancellationToken)
+        {
+            var startInfo = new ProcessStartInfo(SshPath)
+            {
+                RedirectStandardInput = true,
+                RedirectStandardOutput = true,
+                RedirectStandardError = true,
+                UseShellExecute = false
+            };
+            foreach (string argument in SshArguments)
+                startInfo.ArgumentList.Add(argument);
+            startInfo.ArgumentList.Add(Host);
+            startInfo.ArgumentList.Add(remoteCommand);
+
+            using var process = Process.Start(startInfo)
+                ?? throw new RemoteSolveException($"Could not start '{SshPath}'", false);
+            var stdout = process.StandardOutput.ReadToEndAsync(cancellationToken);
+            var stderr = process.StandardError.ReadToEndAsync(cancellationToken);
+
+            if (input.HasValue)
+                await process.StandardInput.BaseStream.WriteAsync(input.Value, cancellationToken);
+            process.StandardInput.Close();
+
+            try
+            {
+                await process.WaitForExitAsync(cancellationToken);
+            }
+            catch (OperationCanceledException)
+            {
+                process.Kill(entireProcessTree: true);
+                throw;
+            }
+
+            if (process.ExitCode != 0)
+                throw new RemoteSolveException($"ssh {Host} failed with code {process.ExitCode}: {(await stderr).Trim()}", process.ExitCode == 255);
+            return await stdout;
+        }
+
+        private string JobPath(string jobId, string? file) => file == null ? $"{RemoteDirectory}/{jobId}" : $"{RemoteDirectory}/{jobId}/{file}";
+
+        /// <summary>
+        /// Single-quotes a word for the remote POSIX shell
+        /// </summary>
+        internal static string Quote(string word) => "'" + word.Replace("'", "'\\''") + "'";
+    }
+}
@@ -0,0 +1,99 @@
+namespace Core.Storage
+{
+    /// <summary>
+    /// Stores each model as a directory of version documents: &lt;root&gt;/&lt;id&gt;/00000003.json
+    /// </summary>
+    public class FileSystemModelStorage : IModelStorage
+    {
+        private readonly string rootDirectory;
+
+        public FileSystemModelStorage(string rootDirectory)
+        {
+            this.rootDirectory = rootDirectory;
+            Directory.CreateDirectory(rootDirectory);
+        }
+
+        public async Task<StoredModel?> OpenAsync(string id, int? version = null, CancellationToken cancellationToken = default)
+        {
+            var versions = GetVersionFiles(id);
+            if (versions.Count == 0)
+                return null;
+
+            string? path = version == null
+                ? versions[^1].Path
+                : versions.FirstOrDefault(v => v.Version == version.Value).Path;
+            if (path == null)
+                return null;
+
+            return StoredModelFormat.Deserialize(await File.ReadAllTextAsync(path, cancellationToken));
+        }
+
+        /// <summary>
+        /// Adds a version; a version that is already stored is never overwritten but refused
+        /// with a ModelVersionConflictException
+        /// </summary>
+        public async Task SaveAsync(StoredModel model, CancellationToken cancellationToken = default)
+        {
+            string directory = Path.Combine(rootDirectory, StoredModelFormat.ValidateId(model.Id));
+            Directory.CreateDirectory(directory);
+
+            // Write then rename, so a crash never leaves a truncated version behind
+            string path = Path.Combine(directory, StoredModelFormat.VersionName(model.Version));
+            string temporary = path + ".tmp";
+            await File.WriteAllTextAsync(temporary, StoredModelFormat.Serialize(model), cancellationToken);
+            try
+            {
+                File.Move(temporary, path, overwrite: false);
+            }
+            catch (IOException ex) when (File.Exists(path))
+            {
+                File.Delete(temporary);
+                throw new ModelVersionConflictException(model.Id, model.Version, ex);
+            }
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> ListAsync(CancellationToken cancellationToken = default)
+        {
+            var result = new List<StoredModelInfo>();
+            foreach (string directory in Directory.GetDirectories(rootDirectory).OrderBy(d => d, StringComparer.Ordinal))
+            {
+                var latest = await OpenAsync(Path.GetFileName(directory), null, cancellationToken);
+                if (latest != null)
+                    result.Add(StoredModelFormat.ToInfo(latest));
+            }
+            return result;
+        }
+
+        public Task<bool> DeleteAsync(string id, CancellationToken cancellationToken = default)
+        {
+            string directory = Path.Combine(rootDirectory, StoredModelFormat.ValidateId(id));
+            if (!Directory.Exists(directory))
+                return Task.FromResult(false);
+
+            Directory.Delete(directory, recursive: true);
+            return Task.FromResult(true);
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> VersionsAsync(string id, CancellationToken cancellationToken = default)
+        {
+            var result = new List<StoredModelInfo>();
+            foreach (var (_, path) in GetVersionFiles(id))
+                result.Add(StoredModelFormat.ToInfo(StoredModelFormat.Deserialize(await File.ReadAllTextAsync(path, cancellationToken))));
+            return result;
+        }
+
+        private List<(int Version, string Path)> GetVersionFiles(string id)
+        {
+            string directory = Path.Combine(rootDirectory, StoredModelFormat.ValidateId(id));
+            if (!Directory.Exists(directory))
+                return new List<(int, string)>();
+
+            return Directory.GetFiles(directory, "*.json")
+                .Select(path => (Version: StoredModelFormat.ParseVersionName(Path.GetFileName(path)), Path: path))
+                .Where(v => v.Version.HasValue)
+                .Select(v => (v.Version!.Value, v.Path))
+                .OrderBy(v => v.Item1)
+                .ToList();
+        }
+    }
+}
@@ -0,0 +1,97 @@
+namespace Core.Storage
+{
+    /// <summary>
+    /// Features of the native package format, each introduced by one format version
+    /// </summary>
+    [Flags]
+    public enum PackageFeatures
+    {
+        None = 0,
+
+        /// <summary>
+        /// Statement-aligned sections, small ones inline in the manifest, large ones in chunk files
+        /// </summary>
+        Sections = 1,
+
+        /// <summary>
+        /// Free-form document properties (author, description, ...) in the manifest
+        /// </summary>
+        Properties = 2,
+
+        /// <summary>
+        /// The manifest names the oldest reader able to open it, so readers open newer files
+        /// that use no features they lack
+        /// </summary>
+        ReaderVersion = 4
+    }
+
+    /// <summary>
+    /// One version of the native package format and what it can store. Readers accept every
+    /// version up to Current and upgrade older manifests in memory; writers can target an older
+    /// version for editors that have not been updated, dropping what that version cannot hold.
+    /// Published versions are frozen: a change to the format always adds a new version.
+    /// </summary>
+    public sealed class FormatVersion
+    {
+        private static readonly FormatVersion[] versions =
+        {
+            new FormatVersion(1, PackageFeatures.Sections, "chunked sections"),
+            new FormatVersion(2, PackageFeatures.Sections | PackageFeatures.Properties, "document properties"),
+            new FormatVersion(3, PackageFeatures.Sections | PackageFeatures.Properties | PackageFeatures.ReaderVersion, "minimum reader version")
+        };
+
+        private FormatVersion(int number, PackageFeatures features, string description)
+        {
+            Number = number;
+            Features = features;
+            Description = description;
+        }
+
+        public int Number { get; }
+
+        /// <summary>
+        /// Everything this version can store, including the features of older versions
+        /// </summary>
+        public PackageFeatures Features { get; }
+
+        /// <summary>
+        /// What the version added over its predecessor
+        /// </summary>
+        public string Description { get; }
+
+        /// <summary>
+        /// Features added by this version
+        /// </summary>
+        public PackageFeatures Added => Number == 1 ? Features : Features & ~Get(Number - 1).Features;
+
+        /// <summary>
+        /// The version written by default and the newest one this build reads
+        /// </summary>
+        public static FormatVersion Current => versions[^1];
+
+        public static FormatVersion Oldest => versions[0];
+
+        public static IReadOnlyList<FormatVersion> All => versions;
+
+        public static FormatVersion Get(int number)
+        {
+            if (number < Oldest.Number || number > Current.Number)
+            {
+                throw new InvalidOperationException(
+                    $"Unknown package format version {number}; this editor supports versions {Oldest.Number} to {Current.Number}");
+            }
+            return versions[number - Oldest.Number];
+        }
+
+        public bool Supports(PackageFeatures features) => (Features & features) == features;
+
+        /// <summary>
+        /// Oldest version that can store all of the given features
+        /// </summary>
+        public static FormatVersion Requiring(PackageFeatures features) =>
+            versions.FirstOrDefault(v => v.Supports(features))
+                ?? throw new InvalidOperationException($"No package format version supports {features}");
+
+        public override string ToString() => $"{Number} ({Description}: {Features})";
+    }
+}
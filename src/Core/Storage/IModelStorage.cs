@@ -0,0 +1,128 @@
+using System.Text.Json;
+using System.Text.RegularExpressions;
+
+namespace Core.Storage
+{
+    /// <summary>
+    /// Header of one saved version of a model
+    /// </summary>
+    public class StoredModelInfo
+    {
+        public string Id { get; init; } = "";
+        public string Name { get; init; } = "";
+        public int Version { get; init; }
+        public DateTime SavedAt { get; init; }
+
+        /// <summary>
+        /// Subject of the user who created the model, if known
+        /// </summary>
+        public string? Owner { get; init; }
+
+        public override string ToString() => $"{Id} v{Version} ({Name})";
+    }
+
+    /// <summary>
+    /// A saved model version: header plus model and data text
+    /// </summary>
+    public class StoredModel : StoredModelInfo
+    {
+        public string ModelText { get; init; } = "";
+        public string DataText { get; init; } = "";
+    }
+
+    /// <summary>
+    /// A save of a model version that is already stored; every backend keeps versions immutable
+    /// </summary>
+    public class ModelVersionConflictException : InvalidOperationException
+    {
+        public ModelVersionConflictException(string id, int version, Exception? innerException = null)
+            : base($"Version {version} of model '{id}' is already stored", innerException)
+        {
+            Id = id;
+            Version = version;
+        }
+
+        public string Id { get; }
+        public int Version { get; }
+    }
+
+    /// <summary>
+    /// Persistence backend for hosted models. Every save adds one version; a version that is
+    /// already stored is never replaced but refused with a ModelVersionConflictException.
+    /// Opening without a version returns the latest.
+    /// </summary>
+    public interface IModelStorage
+    {
+        /// <summary>
+        /// Loads a model version, or null if the model (or version) does not exist
+        /// </summary>
+        Task<StoredModel?> OpenAsync(string id, int? version = null, CancellationToken cancellationToken = default);
+
+        Task SaveAsync(StoredModel model, CancellationToken cancellationToken = default);
+
+        /// <summary>
+        /// Latest version of every stored model
+        /// </summary>
+        Task<IReadOnlyList<StoredModelInfo>> ListAsync(CancellationToken cancellationToken = default);
+
+        /// <summary>
+        /// Removes a model with all its versions
+        /// </summary>
+        Task<bool> DeleteAsync(string id, CancellationToken cancellationToken = default);
+
+        /// <summary>
+        /// All saved versions of a model, oldest first
+        /// </summary>
+        Task<IReadOnlyList<StoredModelInfo>> VersionsAsync(string id, CancellationToken cancellationToken = default);
+    }
+
+    /// <summary>
+    /// JSON document format and naming rules shared by the blob-style backends
+    /// </summary>
+    internal static class StoredModelFormat
+    {
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            WriteIndented = true
+        };
+
+        public static string Serialize(StoredModel model) => JsonSerializer.Serialize(model, jsonOptions);
+
+        public static StoredModel Deserialize(string json)
+        {
+            return JsonSerializer.Deserialize<StoredModel>(json, jsonOptions)
+                ?? throw new InvalidOperationException("Stored model document is empty");
+        }
+
+        public static StoredModelInfo ToInfo(StoredModel model) => new StoredModelInfo
+        {
+            Id = model.Id,
+            Name = model.Name,
+            Version = model.Version,
+            SavedAt = model.SavedAt,
+            Owner = model.Owner
+        };
+
+        /// <summary>
+        /// Versions are stored as zero-padded names so they sort lexically
+        /// </summary>
+        public static string VersionName(int version) => $"{version:D8}.json";
+
+        public static int? ParseVersionName(string name)
+        {
+            var match = Regex.Match(name, @"^(\d{8})\.json$");
+            return match.Success ? int.Parse(match.Groups[1].Value) : null;
+        }
+
+        /// <summary>
+        /// Model ids become path segments, so they are restricted to a safe alphabet
+        /// </summary>
+        public static string ValidateId(string id)
+        {
+            if (!Regex.IsMatch(id, @"^[A-Za-z0-9][A-Za-z0-9_\-]*$"))
+                throw new InvalidOperationException($"Invalid model id '{id}'");
+            return id;
+        }
+    }
+}
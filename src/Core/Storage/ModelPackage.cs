@@ -0,0 +1,518 @@
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+using Core.Services;
+
+namespace Core.Storage
+{
+    /// <summary>
+    /// One statement-aligned piece of the model or data text in a package manifest
+    /// </summary>
+    public class PackageSection
+    {
+        /// <summary>
+        /// "model" or "data"
+        /// </summary>
+        public string Stream { get; init; } = "";
+
+        /// <summary>
+        /// Entity key of the statement ("parameter:cost", "data:cost"), or null for other text
+        /// </summary>
+        public string? Key { get; init; }
+
+        /// <summary>
+        /// Length of the section in UTF-8 bytes
+        /// </summary>
+        public long Length { get; init; }
+
+        /// <summary>
+        /// SHA-256 of the section text
+        /// </summary>
+        public string Hash { get; init; } = "";
+
+        /// <summary>
+        /// Text of small sections, stored in the manifest itself
+        /// </summary>
+        public string? Text { get; init; }
+
+        /// <summary>
+        /// Content hashes of the chunks holding a large section, in order
+        /// </summary>
+        public List<string>? Chunks { get; init; }
+
+        public override string ToString() => $"{Stream}:{Key ?? "-"} ({Length} bytes)";
+    }
+
+    public class PackageManifest
+    {
+        public const string FormatName = "modeleditor-package";
+
+        public string Format { get; init; } = FormatName;
+        public int FormatVersion { get; init; } = Storage.FormatVersion.Current.Number;
+        public string Name { get; init; } = "";
+        public DateTime SavedAt { get; init; }
+
+        /// <summary>
+        /// Document properties (author, description, ...); format version 2 and later
+        /// </summary>
+        public Dictionary<string, string>? Properties { get; init; }
+
+        /// <summary>
+        /// Oldest format version a reader must support to open the package; format version 3 and later
+        /// </summary>
+        public int? MinimumReaderVersion { get; init; }
+
+        public List<PackageSection> Sections { get; init; } = new List<PackageSection>();
+
+        /// <summary>
+        /// Version the manifest has on disk. Older manifests are upgraded to the current version when read.
+        /// </summary>
+        [JsonIgnore]
+        public int StoredVersion { get; init; }
+    }
+
+    public class PackageSaveResult
+    {
+        public int ChunksWritten { get; init; }
+        public int ChunksReused { get; init; }
+
+        /// <summary>
+        /// Bytes written to disk, including the manifest
+        /// </summary>
+        public long BytesWritten { get; init; }
+
+        public PackageManifest Manifest { get; init; } = new PackageManifest();
+
+        /// <summary>
+        /// Content the target format version could not hold and that was left out of the package
+        /// </summary>
+        public List<string> Warnings { get; init; } = new List<string>();
+
+        public override string ToString() =>
+            $"{Manifest.Sections.Count} sections, {ChunksWritten} chunks written, {ChunksReused} reused, {BytesWritten} bytes written";
+    }
+
+    public class ModelPackageOptions
+    {
+        /// <summary>
+        /// Sections up to this size (in bytes) are stored inline in the manifest
+        /// </summary>
+        public int InlineLimit { get; set; } = 4 * 1024;
+
+        public int MinChunkSize { get; set; } = 16 * 1024;
+
+        /// <summary>
+        /// Expected chunk size; must be a power of two
+        /// </summary>
+        public int AverageChunkSize { get; set; } = 64 * 1024;
+
+        public int MaxChunkSize { get; set; } = 256 * 1024;
+
+        /// <summary>
+        /// Format version to write, for packages shared with older editors; null writes the current version
+        /// </summary>
+        public int? TargetVersion { get; set; }
+    }
+
+    /// <summary>
+    /// Native chunked model format: a directory with manifest.json and content-addressed chunk files
+    /// (chunks/ab/abcd...). The text is split at statement boundaries into sections; large sections
+    /// are cut into chunks at content-defined boundaries, so an edit only changes the chunks around
+    /// it. Saving writes only chunks that are not in the package yet, so the cost of a save is
+    /// proportional to the size of the change rather than the size of the model.
+    /// </summary>
+    public static class ModelPackage
+    {
+        public const string ManifestFileName = "manifest.json";
+        private const string ChunkDirectory = "chunks";
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+            DefaultIgnoreCondition = JsonIgnoreCondition.WhenWritingNull,
+            WriteIndented = true
+        };
+
+        private static readonly ulong[] gearTable = CreateGearTable();
+
+        public static PackageSaveResult Save(
+            string directory,
+            string name,
+            string modelText,
+            string dataText,
+            ModelPackageOptions? options = null,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var sections = SplitSections(modelText, dataText).ToList();
+            var result = Write(directory, name,
+                sections.Select(s => ((PackageSection?)null, s.Stream, s.Key, (string?)s.Text)).ToList(),
+                null, options, cancellationToken, progress);
+
+            // The text is at hand, so the reference index is always saved along
+            string indexPath = Path.Combine(directory, ReferenceIndex.FileName);
+            var references = ReferenceIndex.Load(indexPath);
+            references.Update(result.Manifest.Sections
+                .Select((s, i) => (s.Stream, s.Key, s.Hash, (Func<string>)(() => sections[i].Text)))
+                .ToList());
+            references.Save(indexPath);
+            return result;
+        }
+
+        /// <summary>
+        /// Opens a package lazily: only the manifest is read, section text is loaded on first access
+        /// </summary>
+        public static PackageDocument Open(string directory)
+        {
+            var manifest = ReadManifest(directory)
+                ?? throw new InvalidOperationException($"No model package at '{directory}'");
+            return new PackageDocument(directory, manifest);
+        }
+
+        /// <summary>
+        /// Writes a manifest for the given sections. A section passed as an existing manifest entry
+        /// (with null text) is kept as is; its chunks are already in the package. Progress is reported
+        /// per section. On cancellation the previous manifest stays in place; chunks written so far
+        /// are unreferenced until the next save or CollectGarbage. Null properties keep those of the
+        /// previous manifest.
+        /// </summary>
+        internal static PackageSaveResult Write(
+            string directory,
+            string name,
+            IReadOnlyList<(PackageSection? Existing, string Stream, string? Key, string? Text)> sections,
+            IReadOnlyDictionary<string, string>? properties,
+            ModelPackageOptions? options,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var tracker = new ProgressTracker("save", progress, cancellationToken);
+            options ??= new ModelPackageOptions();
+            if (options.AverageChunkSize <= 0 || (options.AverageChunkSize & (options.AverageChunkSize - 1)) != 0)
+                throw new InvalidOperationException("AverageChunkSize must be a power of two");
+            var target = options.TargetVersion.HasValue ? FormatVersion.Get(options.TargetVersion.Value) : FormatVersion.Current;
+
+            Directory.CreateDirectory(Path.Combine(directory, ChunkDirectory));
+
+            // Chunk lists of sections that are unchanged since the last save are reused without re-chunking
+            var previousManifest = ReadManifest(directory);
+            var previous = previousManifest?.Sections
+                .Where(s => s.Chunks != null)
+                .GroupBy(s => s.Hash)
+                .ToDictionary(g => g.Key, g => g.First().Chunks!) ?? new Dictionary<string, List<string>>();
+
+            properties ??= previousManifest?.Properties;
+            var warnings = new List<string>();
+            if (previousManifest != null && previousManifest.StoredVersion > FormatVersion.Current.Number)
+            {
+                warnings.Add($"Package was saved in format version {previousManifest.StoredVersion} by a newer editor; " +
+                    $"content this editor does not know is dropped");
+            }
+
+            var used = PackageFeatures.Sections;
+            if (properties?.Count > 0)
+            {
+                if (target.Supports(PackageFeatures.Properties))
+                    used |= PackageFeatures.Properties;
+                else
+                    warnings.Add($"Format version {target.Number} cannot store document properties; dropped {string.Join(", ", properties.Keys)}");
+            }
+
+            var manifest = new PackageManifest
+            {
+                FormatVersion = target.Number,
+                StoredVersion = target.Number,
+                Name = name,
+                SavedAt = DateTime.UtcNow,
+                Properties = used.HasFlag(PackageFeatures.Properties) ? new Dictionary<string, string>(properties!) : null,
+                MinimumReaderVersion = target.Supports(PackageFeatures.ReaderVersion)
+                    ? FormatVersion.Requiring(used | PackageFeatures.ReaderVersion).Number
+                    : null
+            };
+            int written = 0, reused = 0;
+            long bytes = 0;
+
+            tracker.Stage("sections", sections.Count);
+            for (int index = 0; index < sections.Count; index++)
+            {
+                tracker.Tick(index, sections.Count);
+                var (existing, stream, key, text) = sections[index];
+                if (existing != null)
+                {
+                    reused += existing.Chunks?.Count ?? 0;
+                    manifest.Sections.Add(existing);
+                    continue;
+                }
+
+                byte[] content = Encoding.UTF8.GetBytes(text ?? "");
+                string hash = Hash(content);
+
+                if (content.Length <= options.InlineLimit)
+                {
+                    manifest.Sections.Add(new PackageSection { Stream = stream, Key = key, Length = content.Length, Hash = hash, Text = text ?? "" });
+                    continue;
+                }
+
+                if (previous.TryGetValue(hash, out var chunks) && chunks.All(c => File.Exists(ChunkPath(directory, c))))
+                {
+                    reused += chunks.Count;
+                }
+                else
+                {
+                    chunks = new List<string>();
+                    foreach (var (offset, length) in Chunk(content, options))
+                    {
+                        var chunk = new ReadOnlySpan<byte>(content, offset, length);
+                        string chunkHash = Hash(chunk);
+                        string path = ChunkPath(directory, chunkHash);
+
+                        if (File.Exists(path))
+                        {
+                            reused++;
+                        }
+                        else
+                        {
+                            Directory.CreateDirectory(Path.GetDirectoryName(path)!);
+                            WriteAtomically(path, chunk.ToArray());
+                            written++;
+                            bytes += length;
+                        }
+                        chunks.Add(chunkHash);
+                    }
+                }
+
+                manifest.Sections.Add(new PackageSection { Stream = stream, Key = key, Length = content.Length, Hash = hash, Chunks = chunks });
+            }
+
+            tracker.Stage("manifest");
+            byte[] manifestBytes = JsonSerializer.SerializeToUtf8Bytes(manifest, jsonOptions);
+            WriteAtomically(Path.Combine(directory, ManifestFileName), manifestBytes);
+
+            return new PackageSaveResult
+            {
+                ChunksWritten = written,
+                ChunksReused = reused,
+                BytesWritten = bytes + manifestBytes.Length,
+                Manifest = manifest,
+                Warnings = warnings
+            };
+        }
+
+        /// <summary>
+        /// Reads the full model and data text of a package
+        /// </summary>
+        public static (string Name, string ModelText, string DataText) Load(string directory)
+        {
+            var manifest = ReadManifest(directory)
+                ?? throw new InvalidOperationException($"No model package at '{directory}'");
+
+            var model = new StringBuilder();
+            var data = new StringBuilder();
+            foreach (var section in manifest.Sections)
+                (section.Stream == "data" ? data : model).Append(ReadSection(directory, section));
+
+            return (manifest.Name, model.ToString(), data.ToString());
+        }
+
+        /// <summary>
+        /// Reads the manifest of a package and upgrades it to the current format version in memory.
+        /// A manifest of a newer version is read if it names a minimum reader version this editor
+        /// supports; the fields it does not know are ignored.
+        /// </summary>
+        public static PackageManifest? ReadManifest(string directory)
+        {
+            string path = Path.Combine(directory, ManifestFileName);
+            if (!File.Exists(path))
+                return null;
+
+            var manifest = JsonSerializer.Deserialize<PackageManifest>(File.ReadAllBytes(path), jsonOptions)
+                ?? throw new InvalidOperationException($"Empty package manifest '{path}'");
+            if (manifest.Format != PackageManifest.FormatName || manifest.FormatVersion < FormatVersion.Oldest.Number)
+                throw new InvalidOperationException($"Unsupported package format '{manifest.Format}' version {manifest.FormatVersion}");
+
+            if (manifest.FormatVersion > FormatVersion.Current.Number &&
+                !(manifest.MinimumReaderVersion <= FormatVersion.Current.Number))
+            {
+                throw new InvalidOperationException(
+                    $"Package '{directory}' was saved in format version {manifest.FormatVersion} by a newer editor; " +
+                    $"it needs a reader of version {manifest.MinimumReaderVersion ?? manifest.FormatVersion}, this editor reads up to {FormatVersion.Current.Number}");
+            }
+
+            return Upgrade(manifest);
+        }
+
+
+        /// <summary>
+        /// In-memory form of a manifest as the current version: fields added since its version get
+        /// their defaults. A newer manifest keeps its version number.
+        /// </summary>
+        private static PackageManifest Upgrade(PackageManifest manifest) => new PackageManifest
+        {
+            Format = manifest.Format,
+            FormatVersion = Math.Max(manifest.FormatVersion, FormatVersion.Current.Number),
+            StoredVersion = manifest.FormatVersion,
+            Name = manifest.Name,
+            SavedAt = manifest.SavedAt,
+            Properties = manifest.Properties ?? new Dictionary<string, string>(),
+            MinimumReaderVersion = manifest.MinimumReaderVersion,
+            Sections = manifest.Sections
+        };
+
+        /// <summary>
+        /// Text of one section, read from its chunks unless stored inline. The content hash is verified.
+        /// </summary>
+        public static string ReadSection(string directory, PackageSection section)
+        {
+            if (section.Text != null)
+                return section.Text;
+
+            var content = new byte[section.Length];
+            int offset = 0;
+            foreach (string chunk in section.Chunks ?? new List<string>())
+            {
+                byte[] bytes = File.ReadAllBytes(ChunkPath(directory, chunk));
+                if (offset + bytes.Length > content.Length)
+                    throw new InvalidOperationException($"Section {section} is longer than recorded");
+                bytes.CopyTo(content, offset);
+                offset += bytes.Length;
+            }
+
+            if (offset != content.Length || Hash(content) != section.Hash)
+                throw new InvalidOperationException($"Section {section} is corrupt: content does not match its hash");
+            return Encoding.UTF8.GetString(content);
+        }
+
+        /// <summary>
+        /// Deletes chunk files no longer referenced by the manifest; returns the number removed
+        /// </summary>
+        public static int CollectGarbage(string directory)
+        {
+            var manifest = ReadManifest(directory)
+                ?? throw new InvalidOperationException($"No model package at '{directory}'");
+            var referenced = manifest.Sections.SelectMany(s => s.Chunks ?? new List<string>()).ToHashSet(StringComparer.Ordinal);
+
+            int removed = 0;
+            string root = Path.Combine(directory, ChunkDirectory);
+            if (!Directory.Exists(root))
+                return 0;
+
+            foreach (string path in Directory.GetFiles(root, "*", SearchOption.AllDirectories))
+            {
+                if (!referenced.Contains(Path.GetFileName(path)))
+                {
+                    File.Delete(path);
+                    removed++;
+                }
+            }
+            return removed;
+        }
+
+        /// <summary>
+        /// Statement-aligned sections of both streams. Concatenating the sections of a stream
+        /// reproduces its text exactly.
+        /// </summary>
+        internal static IEnumerable<(string Stream, string? Key, string Text)> SplitSections(string modelText, string dataText)
+        {
+            foreach (var (stream, text) in new[] { ("model", modelText), ("data", dataText) })
+            {
+                var source = ModelSource.Parse(text);
+                int consumed = 0;
+
+                foreach (var statement in source.Statements)
+                {
+                    consumed += statement.Text.Length;
+                    yield return (stream, stream == "model" ? statement.Key : DataKey(statement.Code), statement.Text);
+                }
+
+                if (consumed < text.Length)
+                    yield return (stream, null, text.Substring(consumed));
+            }
+        }
+
+        /// <summary>
+        /// Key of a data statement ("cost = [...];" declares "data:cost"), or null
+        /// </summary>
+        internal static string? DataKey(string code)
+        {
+            var match = Regex.Match(code, @"^(\w+)\s*=");
+            return match.Success ? $"data:{match.Groups[1].Value}" : null;
+        }
+
+        /// <summary>
+        /// Content-defined chunking with a gear rolling hash: a boundary falls where the hash of the
+        /// preceding bytes matches a mask, so boundaries move with the content instead of with offsets
+        /// </summary>
+        private static IEnumerable<(int Offset, int Length)> Chunk(byte[] content, ModelPackageOptions options)
+        {
+            ulong mask = (ulong)(options.AverageChunkSize - 1) << (64 - BitLength(options.AverageChunkSize - 1));
+            int start = 0;
+
+            while (start < content.Length)
+            {
+                int remaining = content.Length - start;
+                if (remaining <= options.MinChunkSize)
+                {
+                    yield return (start, remaining);
+                    yield break;
+                }
+
+                int limit = Math.Min(remaining, options.MaxChunkSize);
+                int length = limit;
+                ulong hash = 0;
+
+                for (int i = options.MinChunkSize; i < limit; i++)
+                {
+                    hash = (hash << 1) + gearTable[content[start + i]];
+                    if ((hash & mask) == 0)
+                    {
+                        length = i + 1;
+                        break;
+                    }
+                }
+
+                yield return (start, length);
+                start += length;
+            }
+        }
+
+        private static int BitLength(int value)
+        {
+            int bits = 0;
+            while (value > 0)
+            {
+                bits++;
+                value >>= 1;
+            }
+            return bits;
+        }
+
+        private static ulong[] CreateGearTable()
+        {
+            // SplitMix64 with a fixed seed, so chunk boundaries are stable across versions
+            var table = new ulong[256];
+            ulong state = 0x9E3779B97F4A7C15;
+            for (int i = 0; i < table.Length; i++)
+            {
+                state += 0x9E3779B97F4A7C15;
+                ulong z = state;
+                z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9;
+                z = (z ^ (z >> 27)) * 0x94D049BB133111EB;
+                table[i] = z ^ (z >> 31);
+            }
+            return table;
+        }
+
+        internal static string Hash(ReadOnlySpan<byte> content) => Convert.ToHexString(SHA256.HashData(content)).ToLowerInvariant();
+
+        private static string ChunkPath(string directory, string hash) => Path.Combine(directory, ChunkDirectory, hash.Substring(0, 2), hash);
+
+        private static void WriteAtomically(string path, byte[] content)
+        {
+            string temporary = path + ".tmp";
+            File.WriteAllBytes(temporary, content);
+            File.Move(temporary, path, overwrite: true);
+        }
+    }
+}
@@ -0,0 +1,252 @@
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+using Core.Services;
+
+namespace Core.Storage
+{
+    /// <summary>
+    /// A model package opened lazily. The manifest gives the section headers (stream, key, size)
+    /// up front; the text of a chunked section is read from disk the first time it is accessed.
+    /// Edits replace whole sections, and saving writes only the edited sections, so a bound tweak
+    /// on a large model neither loads nor rewrites the data it does not touch.
+    /// </summary>
+    public class PackageDocument
+    {
+        private class Entry
+        {
+            public string Stream { get; init; } = "";
+            public string? Key { get; set; }
+
+            /// <summary>
+            /// Manifest entry of an unchanged section, null once edited
+            /// </summary>
+            public PackageSection? Stored { get; set; }
+
+            /// <summary>
+            /// Section text, null until loaded
+            /// </summary>
+            public string? Text { get; set; }
+        }
+
+        private readonly List<Entry> entries;
+        private Dictionary<string, string> storedProperties;
+        private ReferenceIndex? references;
+        private bool removed;
+
+        internal PackageDocument(string directory, PackageManifest manifest)
+        {
+            Directory = directory;
+            Name = manifest.Name;
+            StoredVersion = manifest.StoredVersion;
+            storedProperties = new Dictionary<string, string>(manifest.Properties ?? new Dictionary<string, string>());
+            Properties = new Dictionary<string, string>(storedProperties);
+            entries = manifest.Sections
+                .Select(s => new Entry { Stream = s.Stream, Key = s.Key, Stored = s, Text = s.Text })
+                .ToList();
+        }
+
+        public string Directory { get; }
+
+        public string Name { get; set; }
+
+        /// <summary>
+        /// Document properties; saved only by format version 2 and later
+        /// </summary>
+        public Dictionary<string, string> Properties { get; }
+
+        /// <summary>
+        /// Format version of the package on disk
+        /// </summary>
+        public int StoredVersion { get; private set; }
+
+        /// <summary>
+        /// Headers of the sections as last saved; edited sections are not included until saved
+        /// </summary>
+        public IReadOnlyList<PackageSection> Sections => entries.Where(e => e.Stored != null).Select(e => e.Stored!).ToList();
+
+        /// <summary>
+        /// Keys of all named sections of a stream, in order
+        /// </summary>
+        public IReadOnlyList<string> GetKeys(string stream = "model") =>
+            entries.Where(e => e.Stream == stream && e.Key != null).Select(e => e.Key!).ToList();
+
+        /// <summary>
+        /// Number of chunked sections whose text has been read from disk
+        /// </summary>
+        public int LoadedSections => entries.Count(e => e.Stored?.Chunks != null && e.Text != null);
+
+        public bool HasChanges =>
+            removed || entries.Any(e => e.Stored == null) ||
+            Properties.Count != storedProperties.Count || Properties.Any(p => storedProperties.GetValueOrDefault(p.Key) != p.Value);
+
+        /// <summary>
+        /// Reverse reference index of the current text. It starts from the index saved with the
+        /// package, so only sections edited since (or missing from it) are read and scanned.
+        /// </summary>
+        public ReferenceIndex References
+        {
+            get
+            {
+                references ??= ReferenceIndex.Load(Path.Combine(Directory, ReferenceIndex.FileName));
+                references.Update(entries
+                    .Select(e => (e.Stream, e.Key, e.Stored?.Hash ?? ModelPackage.Hash(Encoding.UTF8.GetBytes(e.Text!)), (Func<string>)(() => Load(e))))
+                    .ToList());
+                return references;
+            }
+        }
+
+        public bool IsLoaded(string key) => entries.Any(e => e.Key == key && e.Text != null);
+
+        /// <summary>
+        /// Text of the section declaring <paramref name="key"/> (with its leading trivia),
+        /// loading it if necessary; null if there is no such section
+        /// </summary>
+        public string? GetSection(string key)
+        {
+            var entry = entries.FirstOrDefault(e => e.Key == key);
+            return entry != null ? Load(entry) : null;
+        }
+
+        /// <summary>
+        /// Replaces the model statement declaring the same entity, or appends it to the model.
+        /// Returns true if an existing statement was replaced.
+        /// </summary>
+        public bool Upsert(string statement)
+        {
+            string code = Terminate(statement);
+            string key = ModelSource.GetKey(code)
+                ?? throw new InvalidOperationException($"Statement does not declare a named entity: {code}");
+            return Replace("model", key, code);
+        }
+
+        /// <summary>
+        /// Replaces the data statement assigning the same name ("cost = [...];"), or appends it.
+        /// Returns true if an existing statement was replaced.
+        /// </summary>
+        public bool UpsertData(string statement)
+        {
+            string code = Terminate(statement);
+            string key = ModelPackage.DataKey(code)
+                ?? throw new InvalidOperationException($"Statement does not assign a data item: {code}");
+            return Replace("data", key, code);
+        }
+
+        public bool Remove(string key)
+        {
+            int index = entries.FindIndex(e => e.Key == key);
+            if (index < 0)
+                return false;
+
+            entries.RemoveAt(index);
+            removed = true;
+            return true;
+        }
+
+        /// <summary>
+        /// Renames a symbol at every site it is written, including the keys of its declaration and
+        /// data statement. Only the sections that mention it are loaded. Returns the number of sites.
+        /// </summary>
+        public int Rename(string symbol, string newName)
+        {
+            if (!Regex.IsMatch(newName, @"^[A-Za-z_]\w*$"))
+                throw new InvalidOperationException($"'{newName}' is not a valid name");
+
+            var index = References;
+            if (index.FindReferences(newName).Count > 0)
+                throw new InvalidOperationException($"Cannot rename '{symbol}': '{newName}' is already used in the model");
+
+            var sites = index.FindReferences(symbol);
+            foreach (var section in sites.GroupBy(s => s.SectionIndex))
+            {
+                var entry = entries[section.Key];
+                var text = new StringBuilder(Load(entry));
+                foreach (var site in section.OrderByDescending(s => s.Offset))
+                    text.Remove(site.Offset, symbol.Length).Insert(site.Offset, newName);
+
+                entry.Text = text.ToString();
+                entry.Stored = null;
+                if (entry.Key != null && entry.Key.EndsWith(":" + symbol, StringComparison.Ordinal))
+                    entry.Key = entry.Key.Substring(0, entry.Key.Length - symbol.Length) + newName;
+            }
+            return sites.Count;
+        }
+
+        /// <summary>
+        /// Full model text; loads every model section
+        /// </summary>
+        public string GetModelText() => GetText("model");
+
+        /// <summary>
+        /// Full data text; loads every data section
+        /// </summary>
+        public string GetDataText() => GetText("data");
+
+        /// <summary>
+        /// Writes the edited sections and a new manifest; unchanged sections keep their chunks.
+        /// The reference index is updated with the edited sections if it is in use.
+        /// </summary>
+        public PackageSaveResult Save(
+            ModelPackageOptions? options = null,
+            CancellationToken cancellationToken = default,
+            IProgress<OperationProgress>? progress = null)
+        {
+            var result = ModelPackage.Write(Directory, Name,
+                entries.Select(e => (e.Stored, e.Stream, e.Key, e.Stored == null ? e.Text : null)).ToList(),
+                Properties, options, cancellationToken, progress);
+
+            for (int i = 0; i < entries.Count; i++)
+                entries[i].Stored = result.Manifest.Sections[i];
+            removed = false;
+            storedProperties = new Dictionary<string, string>(result.Manifest.Properties ?? new Dictionary<string, string>());
+            StoredVersion = result.Manifest.StoredVersion;
+
+            string indexPath = Path.Combine(Directory, ReferenceIndex.FileName);
+            if (references != null || File.Exists(indexPath))
+                References.Save(indexPath);
+            return result;
+        }
+
+        private bool Replace(string stream, string key, string code)
+        {
+            var existing = entries.FirstOrDefault(e => e.Key == key);
+            if (existing != null)
+            {
+                // Keep the comments and whitespace in front of the statement
+                string text = Load(existing);
+                existing.Text = text.Substring(0, ModelStatement.TriviaLength(text)) + code;
+                existing.Stored = null;
+                return true;
+            }
+
+            int last = entries.FindLastIndex(e => e.Stream == stream && e.Key != null);
+            int insertAt = last >= 0 ? last + 1 : (stream == "model" ? entries.FindIndex(e => e.Stream != "model") : -1);
+            if (insertAt < 0)
+                insertAt = entries.Count;
+
+            bool first = !entries.Any(e => e.Stream == stream);
+            entries.Insert(insertAt, new Entry { Stream = stream, Key = key, Text = (first ? "" : Environment.NewLine) + code });
+            return false;
+        }
+
+        private string GetText(string stream)
+        {
+            var sb = new StringBuilder();
+            foreach (var entry in entries.Where(e => e.Stream == stream))
+                sb.Append(Load(entry));
+            return sb.ToString();
+        }
+
+        private string Load(Entry entry)
+        {
+            entry.Text ??= ModelPackage.ReadSection(Directory, entry.Stored!);
+            return entry.Text;
+        }
+
+        private static string Terminate(string statement)
+        {
+            string code = statement.Trim();
+            return code.EndsWith(";") || code.EndsWith("}") ? code : code + ";";
+        }
+    }
+}
@@ -0,0 +1,322 @@
+using System.Text;
+using System.Text.Json;
+using System.Text.RegularExpressions;
+using Core.Parsing;
+
+namespace Core.Storage
+{
+    public enum ReferenceKind
+    {
+        /// <summary>
+        /// The declaration of the symbol (or the label of a constraint)
+        /// </summary>
+        Declaration,
+
+        /// <summary>
+        /// A use of the symbol in another statement
+        /// </summary>
+        Use,
+
+        /// <summary>
+        /// The data statement assigning the symbol ("cost = [...];")
+        /// </summary>
+        Data
+    }
+
+    /// <summary>
+    /// One place a symbol is written
+    /// </summary>
+    public class ReferenceSite
+    {
+        public string Symbol { get; init; } = "";
+        public ReferenceKind Kind { get; init; }
+
+        /// <summary>
+        /// "model" or "data"
+        /// </summary>
+        public string Stream { get; init; } = "";
+
+        /// <summary>
+        /// Entity key of the statement containing the site, or null for statements without one
+        /// </summary>
+        public string? Statement { get; init; }
+
+        /// <summary>
+        /// Position of the section in the document
+        /// </summary>
+        public int SectionIndex { get; init; }
+
+        /// <summary>
+        /// Character offset within the section text
+        /// </summary>
+        public int Offset { get; init; }
+
+        /// <summary>
+        /// 1-based line within the stream
+        /// </summary>
+        public int Line { get; init; }
+
+        public int Column { get; init; }
+
+        public override string ToString() => $"{Stream}:{Line}:{Column} {Kind} in {Statement ?? "-"}";
+    }
+
+    /// <summary>
+    /// Reverse index from symbol names to every place they are written, over the sections of a
+    /// document. Occurrences are cached per section content hash, so after an edit only the changed
+    /// sections are scanned again; the cache is saved next to the package manifest, so reopening a
+    /// large model does not re-scan it either. Symbols are matched by name: the model language has
+    /// one global namespace, so iterator names are indexed too but have no declaration.
+    /// </summary>
+    public class ReferenceIndex
+    {
+        public const string FileName = "references.json";
+
+        private const int CacheVersion = 1;
+
+        /// <summary>
+        /// Words of the model language that are never symbols
+        /// </summary>
+        private static readonly HashSet<string> keywords = new HashSet<string>(StringComparer.Ordinal)
+        {
+            "abs", "and", "boolean", "card", "ceil", "constraints", "dexpr", "diff", "dvar", "else", "execute",
+            "exp", "false", "first", "float", "floor", "forall", "if", "in", "infinity", "int", "inter", "item",
+            "last", "log", "main", "max", "maxint", "maximize", "min", "minimize", "next", "nextc", "not", "or", "ord",
+            "pow", "prev", "prevc", "prod", "range", "setof", "sqrt", "string", "subject", "sum", "timestamp", "to",
+            "true", "tuple", "union", "window", "with"
+        };
+
+        private static readonly Regex tuplePattern = new Regex(@"\Gtuple\b");
+
+        private static readonly JsonSerializerOptions jsonOptions = new JsonSerializerOptions
+        {
+            PropertyNamingPolicy = JsonNamingPolicy.CamelCase
+        };
+
+        /// <summary>
+        /// Occurrences of the symbols in one section text: name to (offset, line, column) triples,
+        /// with lines and columns relative to the section
+        /// </summary>
+        private class SectionOccurrences
+        {
+            public int Lines { get; init; }
+            public Dictionary<string, List<int[]>> Symbols { get; init; } = new Dictionary<string, List<int[]>>(StringComparer.Ordinal);
+        }
+
+        private class CacheFile
+        {
+            public int Version { get; init; }
+            public Dictionary<string, SectionOccurrences> Sections { get; init; } = new Dictionary<string, SectionOccurrences>();
+        }
+
+        private Dictionary<string, SectionOccurrences> cache = new Dictionary<string, SectionOccurrences>(StringComparer.Ordinal);
+        private List<(string Stream, string? Key, string Hash)> layout = new List<(string, string?, string)>();
+        private Dictionary<string, List<int>> sectionsBySymbol = new Dictionary<string, List<int>>(StringComparer.Ordinal);
+
+        /// <summary>
+        /// Number of sections scanned by the last Update
+        /// </summary>
+        public int LastScanned { get; private set; }
+
+        /// <summary>
+        /// Index of a whole model and data text
+        /// </summary>
+        public static ReferenceIndex Build(string modelText, string dataText)
+        {
+            var index = new ReferenceIndex();
+            index.Update(ModelPackage.SplitSections(modelText, dataText)
+                .Select(s => (s.Stream, s.Key, ModelPackage.Hash(Encoding.UTF8.GetBytes(s.Text)), (Func<string>)(() => s.Text)))
+                .ToList());
+            return index;
+        }
+
+        /// <summary>
+        /// Reads a cache saved by Save; a missing or outdated file gives an empty index
+        /// </summary>
+        public static ReferenceIndex Load(string path)
+        {
+            var index = new ReferenceIndex();
+            if (!File.Exists(path))
+                return index;
+
+            var file = JsonSerializer.Deserialize<CacheFile>(File.ReadAllBytes(path), jsonOptions);
+            if (file?.Version == CacheVersion)
+                index.cache = new Dictionary<string, SectionOccurrences>(file.Sections, StringComparer.Ordinal);
+            return index;
+        }
+
+        /// <summary>
+        /// Writes the occurrences of the current sections; entries of sections no longer in the document are dropped
+        /// </summary>
+        public void Save(string path)
+        {
+            var file = new CacheFile
+            {
+                Version = CacheVersion,
+                Sections = layout.Select(s => s.Hash).Distinct().ToDictionary(h => h, h => cache[h])
+            };
+            string temporary = path + ".tmp";
+            File.WriteAllBytes(temporary, JsonSerializer.SerializeToUtf8Bytes(file, jsonOptions));
+            File.Move(temporary, path, overwrite: true);
+        }
+
+        /// <summary>
+        /// Sets the sections of the document, in order. Text is requested only for sections whose
+        /// hash has not been scanned before.
+        /// </summary>
+        public void Update(IReadOnlyList<(string Stream, string? Key, string Hash, Func<string> Text)> sections)
+        {
+            LastScanned = 0;
+            foreach (var section in sections)
+            {
+                if (!cache.ContainsKey(section.Hash))
+                {
+                    cache[section.Hash] = Scan(section.Text());
+                    LastScanned++;
+                }
+            }
+
+            layout = sections.Select(s => (s.Stream, s.Key, s.Hash)).ToList();
+            sectionsBySymbol = new Dictionary<string, List<int>>(StringComparer.Ordinal);
+            for (int i = 0; i < layout.Count; i++)
+            {
+                foreach (string symbol in cache[layout[i].Hash].Symbols.Keys)
+                {
+                    if (!sectionsBySymbol.TryGetValue(symbol, out var list))
+                        sectionsBySymbol[symbol] = list = new List<int>();
+                    list.Add(i);
+                }
+            }
+        }
+
+        /// <summary>
+        /// Names declared by a model statement, in document order
+        /// </summary>
+        public IEnumerable<string> DeclaredSymbols =>
+            layout.Where(s => s.Stream == "model" && s.Key != null && s.Key.Contains(':'))
+                .Select(s => s.Key!.Substring(s.Key.IndexOf(':') + 1));
+
+        /// <summary>
+        /// Every site of the symbol in document order, its declaration included
+        /// </summary>
+        public IReadOnlyList<ReferenceSite> FindReferences(string symbol)
+        {
+            var sites = new List<ReferenceSite>();
+            if (!sectionsBySymbol.TryGetValue(symbol, out var indices))
+                return sites;
+
+            // Lines of a site are counted from the start of its stream
+            var firstLine = new int[layout.Count];
+            var lines = new Dictionary<string, int>(StringComparer.Ordinal);
+            for (int i = 0; i < layout.Count; i++)
+            {
+                int line = lines.GetValueOrDefault(layout[i].Stream);
+                firstLine[i] = line;
+                lines[layout[i].Stream] = line + cache[layout[i].Hash].Lines;
+            }
+
+            foreach (int i in indices)
+            {
+                var (stream, key, hash) = layout[i];
+                bool first = true;
+                foreach (var occurrence in cache[hash].Symbols[symbol])
+                {
+                    sites.Add(new ReferenceSite
+                    {
+                        Symbol = symbol,
+                        Kind = !first || key == null || !key.EndsWith(":" + symbol, StringComparison.Ordinal)
+                            ? ReferenceKind.Use
+                            : stream == "data" ? ReferenceKind.Data : ReferenceKind.Declaration,
+                        Stream = stream,
+                        Statement = key,
+                        SectionIndex = i,
+                        Offset = occurrence[0],
+                        Line = firstLine[i] + occurrence[1] + 1,
+                        Column = occurrence[2] + 1
+                    });
+                    first = false;
+                }
+            }
+            return sites;
+        }
+
+        /// <summary>
+        /// Uses that keep the symbol from being deleted: everything but its declaration and data
+        /// </summary>
+        public IReadOnlyList<ReferenceSite> FindDeletionBlockers(string symbol) =>
+            FindReferences(symbol).Where(s => s.Kind == ReferenceKind.Use).ToList();
+
+        /// <summary>
+        /// Identifiers outside comments and string literals; names after a single '.' are tuple
+        /// fields and are not symbols (the end of a range "1..n" is), and neither are the field
+        /// declarations in a tuple body
+        /// </summary>
+        private static SectionOccurrences Scan(string text)
+        {
+            var symbols = new Dictionary<string, List<int[]>>(StringComparer.Ordinal);
+            int line = 0, lineStart = 0, i = 0;
+
+            int stop = text.Length;
+            int code = ModelStatement.TriviaLength(text);
+            if (tuplePattern.IsMatch(text, code) && text.IndexOf('{', code) is int body and >= 0)
+                stop = body;
+
+            while (i < text.Length)
+            {
+                char c = text[i];
+                if (c == '\n')
+                {
+                    line++;
+                    lineStart = ++i;
+                }
+                else if (c == '/' && i + 1 < text.Length && text[i + 1] == '/')
+                {
+                    int end = text.IndexOf('\n', i);
+                    i = end < 0 ? text.Length : end;
+                }
+                else if (c == '/' && i + 1 < text.Length && text[i + 1] == '*')
+                {
+                    int end = text.IndexOf("*/", i + 2, StringComparison.Ordinal);
+                    end = end < 0 ? text.Length : end + 2;
+                    for (int j = i; j < end; j++)
+                    {
+                        if (text[j] == '\n')
+                        {
+                            line++;
+                            lineStart = j + 1;
+                        }
+                    }
+                    i = end;
+                }
+                else if (c == '"')
+                {
+                    i++;
+                    while (i < text.Length && text[i] != '"' && text[i] != '\n')
+                        i += text[i] == '\\' ? 2 : 1;
+                    i = Math.Min(i + 1, text.Length);
+                }
+                else if (char.IsLetterOrDigit(c) || c == '_')
+                {
+                    int start = i;
+                    while (i < text.Length && (char.IsLetterOrDigit(text[i]) || text[i] == '_'))
+                        i++;
+
+                    string word = text.Substring(start, i - start);
+                    bool field = start > 0 && text[start - 1] == '.' && !(start > 1 && text[start - 2] == '.');
+                    if ((char.IsLetter(c) || c == '_') && start < stop && !field && !keywords.Contains(word))
+                    {
+                        if (!symbols.TryGetValue(word, out var list))
+                            symbols[word] = list = new List<int[]>();
+                        list.Add(new[] { start, line, start - lineStart });
+                    }
+                }
+                else
+                {
+                    i++;
+                }
+            }
+
+            return new SectionOccurrences { Lines = line, Symbols = symbols };
+        }
+    }
+}
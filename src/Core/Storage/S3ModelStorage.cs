@@ -0,0 +1,313 @@
+using System.Globalization;
+using System.Net;
+using System.Security.Cryptography;
+using System.Text;
+using System.Xml.Linq;
+
+namespace Core.Storage
+{
+    public class S3StorageOptions
+    {
+        /// <summary>
+        /// Service endpoint, e.g. https://s3.eu-north-1.amazonaws.com or http://minio:9000
+        /// </summary>
+        public string Endpoint { get; set; } = "";
+        public string Bucket { get; set; } = "";
+        public string Region { get; set; } = "us-east-1";
+        public string AccessKey { get; set; } = "";
+        public string SecretKey { get; set; } = "";
+
+        /// <summary>
+        /// Key prefix for all objects, e.g. "modeleditor/"
+        /// </summary>
+        public string Prefix { get; set; } = "";
+    }
+
+    /// <summary>
+    /// Stores models in an S3-compatible bucket (AWS S3, MinIO, Ceph, ...) with path-style
+    /// addressing: one object per version under &lt;prefix&gt;&lt;id&gt;/00000003.json
+    /// </summary>
+    public class S3ModelStorage : IModelStorage
+    {
+        private readonly S3StorageOptions options;
+        private readonly HttpClient http;
+        private readonly Func<DateTime> clock;
+
+        public S3ModelStorage(S3StorageOptions options, HttpClient? http = null, Func<DateTime>? clock = null)
+        {
+            if (string.IsNullOrWhiteSpace(options.Endpoint) || string.IsNullOrWhiteSpace(options.Bucket))
+                throw new InvalidOperationException("S3 storage requires an endpoint and a bucket");
+
+            this.options = options;
+            this.http = http ?? new HttpClient();
+            this.clock = clock ?? (() => DateTime.UtcNow);
+        }
+
+        public async Task<StoredModel?> OpenAsync(string id, int? version = null, CancellationToken cancellationToken = default)
+        {
+            int? selected = version;
+            if (selected == null)
+            {
+                var versions = await ListVersionNumbersAsync(id, cancellationToken);
+                if (versions.Count == 0)
+                    return null;
+                selected = versions[^1];
+            }
+
+            using var response = await SendAsync(HttpMethod.Get, ObjectKey(id, selected.Value), null, null, cancellationToken);
+            if (response.StatusCode == HttpStatusCode.NotFound)
+                return null;
+
+            await EnsureSuccessAsync(response, cancellationToken);
+            return StoredModelFormat.Deserialize(await response.Content.ReadAsStringAsync(cancellationToken));
+        }
+
+        /// <summary>
+        /// Adds a version with a conditional write (If-None-Match: *); a version that is already
+        /// stored is refused with a ModelVersionConflictException. Services that ignore the
+        /// condition replace the object instead.
+        /// </summary>
+        public async Task SaveAsync(StoredModel model, CancellationToken cancellationToken = default)
+        {
+            byte[] body = Encoding.UTF8.GetBytes(StoredModelFormat.Serialize(model));
+            using var response = await SendAsync(HttpMethod.Put, ObjectKey(model.Id, model.Version), null, body, cancellationToken, createOnly: true);
+
+            // 409 is S3's answer to a conditional write racing another one for the same key
+            if (response.StatusCode is HttpStatusCode.PreconditionFailed or HttpStatusCode.Conflict)
+                throw new ModelVersionConflictException(model.Id, model.Version);
+            await EnsureSuccessAsync(response, cancellationToken);
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> ListAsync(CancellationToken cancellationToken = default)
+        {
+            var ids = (await ListAsync(options.Prefix, "/", cancellationToken)).Prefixes
+                .Select(p => p.Substring(options.Prefix.Length).TrimEnd('/'))
+                .OrderBy(p => p, StringComparer.Ordinal);
+
+            var result = new List<StoredModelInfo>();
+            foreach (string id in ids)
+            {
+                var latest = await OpenAsync(id, null, cancellationToken);
+                if (latest != null)
+                    result.Add(StoredModelFormat.ToInfo(latest));
+            }
+            return result;
+        }
+
+        public async Task<bool> DeleteAsync(string id, CancellationToken cancellationToken = default)
+        {
+            var versions = await ListVersionNumbersAsync(id, cancellationToken);
+            foreach (int version in versions)
+            {
+                using var response = await SendAsync(HttpMethod.Delete, ObjectKey(id, version), null, null, cancellationToken);
+                await EnsureSuccessAsync(response, cancellationToken);
+            }
+            return versions.Count > 0;
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> VersionsAsync(string id, CancellationToken cancellationToken = default)
+        {
+            var result = new List<StoredModelInfo>();
+            foreach (int version in await ListVersionNumbersAsync(id, cancellationToken))
+            {
+                var model = await OpenAsync(id, version, cancellationToken);
+                if (model != null)
+                    result.Add(StoredModelFormat.ToInfo(model));
+            }
+            return result;
+        }
+
+        private string ObjectKey(string id, int version) =>
+            $"{options.Prefix}{StoredModelFormat.ValidateId(id)}/{StoredModelFormat.VersionName(version)}";
+
+        private async Task<List<int>> ListVersionNumbersAsync(string id, CancellationToken cancellationToken)
+        {
+            string prefix = $"{options.Prefix}{StoredModelFormat.ValidateId(id)}/";
+            return (await ListAsync(prefix, null, cancellationToken)).Keys
+                .Select(k => StoredModelFormat.ParseVersionName(k.Substring(prefix.Length)))
+                .Where(v => v.HasValue)
+                .Select(v => v!.Value)
+                .OrderBy(v => v)
+                .ToList();
+        }
+
+        /// <summary>
+        /// ListObjectsV2 over all pages: object keys, and common prefixes when a delimiter is given
+        /// </summary>
+        private async Task<(List<string> Keys, List<string> Prefixes)> ListAsync(string prefix, string? delimiter, CancellationToken cancellationToken)
+        {
+            var keys = new List<string>();
+            var prefixes = new List<string>();
+            string? continuation = null;
+
+            do
+            {
+                var query = new SortedDictionary<string, string>(StringComparer.Ordinal) { ["list-type"] = "2", ["prefix"] = prefix };
+                if (delimiter != null)
+                    query["delimiter"] = delimiter;
+                if (continuation != null)
+                    query["continuation-token"] = continuation;
+
+                using var response = await SendAsync(HttpMethod.Get, null, query, null, cancellationToken);
+                await EnsureSuccessAsync(response, cancellationToken);
+
+                var document = XDocument.Parse(await response.Content.ReadAsStringAsync(cancellationToken));
+                var root = document.Root!;
+                XNamespace ns = root.Name.Namespace;
+
+                keys.AddRange(root.Elements(ns + "Contents").Select(c => (string)c.Element(ns + "Key")!));
+                prefixes.AddRange(root.Elements(ns + "CommonPrefixes").Select(c => (string)c.Element(ns + "Prefix")!));
+
+                continuation = (string?)root.Element(ns + "IsTruncated") == "true"
+                    ? (string?)root.Element(ns + "NextContinuationToken")
+                    : null;
+            }
+            while (continuation != null);
+
+            return (keys, prefixes);
+        }
+
+        private async Task<HttpResponseMessage> SendAsync(
+            HttpMethod method, string? key, IDictionary<string, string>? query, byte[]? body, CancellationToken cancellationToken,
+            bool createOnly = false)
+        {
+            string path = "/" + AwsSignatureV4.EncodePath(options.Bucket) + (key != null ? "/" + AwsSignatureV4.EncodePath(key) : "");
+            string queryString = query != null ? AwsSignatureV4.CanonicalQuery(query) : "";
+            var uri = new Uri(options.Endpoint.TrimEnd('/') + path + (queryString.Length > 0 ? "?" + queryString : ""));
+
+            var request = new HttpRequestMessage(method, uri);
+            if (body != null)
+                request.Content = new ByteArrayContent(body);
+            if (createOnly)
+                request.Headers.TryAddWithoutValidation("If-None-Match", "*");
+
+            AwsSignatureV4.Sign(request, body ?? Array.Empty<byte>(), options.Region, "s3", options.AccessKey, options.SecretKey, clock());
+            return await http.SendAsync(request, cancellationToken);
+        }
+
+        private static async Task EnsureSuccessAsync(HttpResponseMessage response, CancellationToken cancellationToken)
+        {
+            if (response.IsSuccessStatusCode)
+                return;
+
+            string detail = await response.Content.ReadAsStringAsync(cancellationToken);
+            throw new InvalidOperationException(
+                $"S3 request {response.RequestMessage?.Method} {response.RequestMessage?.RequestUri?.AbsolutePath} failed: " +
+                $"{(int)response.StatusCode} {response.ReasonPhrase} {detail}".TrimEnd());
+        }
+    }
+
+    /// <summary>
+    /// AWS Signature Version 4 request signing, as accepted by S3 and S3-compatible services
+    /// </summary>
+    public static class AwsSignatureV4
+    {
+        /// <summary>
+        /// Adds x-amz-date, x-amz-content-sha256 and Authorization headers. The host, those two
+        /// headers and any other header already on the request are signed.
+        /// </summary>
+        public static void Sign(HttpRequestMessage request, byte[] payload, string region, string service,
+                                string accessKey, string secretKey, DateTime utcNow)
+        {
+            Sign(request, Hex(SHA256.HashData(payload)), region, service, accessKey, secretKey, utcNow);
+        }
+
+        /// <summary>
+        /// Signs with a precomputed hex SHA-256 of the payload, for bodies streamed from disk
+        /// </summary>
+        public static void Sign(HttpRequestMessage request, string payloadHash, string region, string service,
+                                string accessKey, string secretKey, DateTime utcNow)
+        {
+            var uri = request.RequestUri ?? throw new InvalidOperationException("Request has no URI");
+            string amzDate = utcNow.ToString("yyyyMMdd'T'HHmmss'Z'", CultureInfo.InvariantCulture);
+            string date = amzDate.Substring(0, 8);
+
+            request.Headers.Remove("x-amz-date");
+            request.Headers.Remove("x-amz-content-sha256");
+            request.Headers.TryAddWithoutValidation("x-amz-date", amzDate);
+            request.Headers.TryAddWithoutValidation("x-amz-content-sha256", payloadHash);
+
+            var headers = new SortedDictionary<string, string>(StringComparer.Ordinal)
+            {
+                ["host"] = uri.IsDefaultPort ? uri.Host : uri.Authority
+            };
+            foreach (var header in request.Headers)
+                headers[header.Key.ToLowerInvariant()] = string.Join(",", header.Value).Trim();
+
+            string signedHeaders = string.Join(";", headers.Keys);
+            string canonicalRequest = string.Join("\n",
+                request.Method.Method,
+                uri.AbsolutePath,
+                uri.Query.TrimStart('?'),
+                string.Concat(headers.Select(h => $"{h.Key}:{h.Value}\n")),
+                signedHeaders,
+                payloadHash);
+
+            string scope = $"{date}/{region}/{service}/aws4_request";
+            string signature = Signature(canonicalRequest, amzDate, scope, secretKey);
+
+            request.Headers.TryAddWithoutValidation("Authorization",
+                $"AWS4-HMAC-SHA256 Credential={accessKey}/{scope}, SignedHeaders={signedHeaders}, Signature={signature}");
+        }
+
+        /// <summary>
+        /// Presigned URL that grants <paramref name="method"/> on the object to whoever holds it
+        /// until it expires, e.g. for a container without credentials. Only the host is signed
+        /// and the payload is not, as S3 expects for presigned requests.
+        /// </summary>
+        public static Uri Presign(HttpMethod method, Uri uri, string region, string service,
+                                  string accessKey, string secretKey, DateTime utcNow, TimeSpan expires)
+        {
+            string amzDate = utcNow.ToString("yyyyMMdd'T'HHmmss'Z'", CultureInfo.InvariantCulture);
+            string scope = $"{amzDate.Substring(0, 8)}/{region}/{service}/aws4_request";
+            var query = new SortedDictionary<string, string>(StringComparer.Ordinal)
+            {
+                ["X-Amz-Algorithm"] = "AWS4-HMAC-SHA256",
+                ["X-Amz-Credential"] = $"{accessKey}/{scope}",
+                ["X-Amz-Date"] = amzDate,
+                ["X-Amz-Expires"] = ((long)expires.TotalSeconds).ToString(CultureInfo.InvariantCulture),
+                ["X-Amz-SignedHeaders"] = "host"
+            };
+            string canonicalQuery = CanonicalQuery(query);
+
+            string canonicalRequest = string.Join("\n",
+                method.Method,
+                uri.AbsolutePath,
+                canonicalQuery,
+                $"host:{(uri.IsDefaultPort ? uri.Host : uri.Authority)}\n",
+                "host",
+                "UNSIGNED-PAYLOAD");
+
+            string signature = Signature(canonicalRequest, amzDate, scope, secretKey);
+            return new Uri($"{uri.GetLeftPart(UriPartial.Path)}?{canonicalQuery}&X-Amz-Signature={signature}");
+        }
+
+        /// <summary>
+        /// URI-encodes each segment of an object key, keeping the '/' separators
+        /// </summary>
+        public static string EncodePath(string path) => string.Join("/", path.Split('/').Select(Uri.EscapeDataString));
+
+        /// <summary>
+        /// Query string with encoded names and values sorted by name, as required for signing
+        /// </summary>
+        public static string CanonicalQuery(IDictionary<string, string> query)
+        {
+            return string.Join("&", query
+                .Select(q => (Name: Uri.EscapeDataString(q.Key), Value: Uri.EscapeDataString(q.Value)))
+                .OrderBy(q => q.Name, StringComparer.Ordinal)
+                .Select(q => $"{q.Name}={q.Value}"));
+        }
+
+        private static string Signature(string canonicalRequest, string amzDate, string scope, string secretKey)
+        {
+            string stringToSign = $"AWS4-HMAC-SHA256\n{amzDate}\n{scope}\n{Hex(SHA256.HashData(Encoding.UTF8.GetBytes(canonicalRequest)))}";
+
+            byte[] key = Encoding.UTF8.GetBytes("AWS4" + secretKey);
+            foreach (string part in scope.Split('/'))
+                key = HMACSHA256.HashData(key, Encoding.UTF8.GetBytes(part));
+            return Hex(HMACSHA256.HashData(key, Encoding.UTF8.GetBytes(stringToSign)));
+        }
+
+        internal static string Hex(byte[] bytes) => Convert.ToHexString(bytes).ToLowerInvariant();
+    }
+}
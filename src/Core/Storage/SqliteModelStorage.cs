@@ -0,0 +1,174 @@
+using System.Data.Common;
+using System.Globalization;
+
+namespace Core.Storage
+{
+    /// <summary>
+    /// Stores model versions as rows of a SQLite table. The connection comes from a factory
+    /// (e.g. () =&gt; new SqliteConnection("Data Source=models.db")) so Core does not depend
+    /// on a particular ADO.NET provider.
+    /// </summary>
+    public class SqliteModelStorage : IModelStorage
+    {
+        private const string Columns = "id, version, name, owner, saved_at, model_text, data_text";
+
+        private readonly Func<DbConnection> connectionFactory;
+        private readonly SemaphoreSlim schemaLock = new SemaphoreSlim(1, 1);
+        private bool schemaCreated;
+
+        public SqliteModelStorage(Func<DbConnection> connectionFactory)
+        {
+            this.connectionFactory = connectionFactory;
+        }
+
+        public async Task<StoredModel?> OpenAsync(string id, int? version = null, CancellationToken cancellationToken = default)
+        {
+            await using var connection = await OpenConnectionAsync(cancellationToken);
+            await using var command = CreateCommand(connection, version == null
+                ? $"SELECT {Columns} FROM model_versions WHERE id = @id ORDER BY version DESC LIMIT 1"
+                : $"SELECT {Columns} FROM model_versions WHERE id = @id AND version = @version",
+                ("@id", id), ("@version", version ?? 0));
+
+            await using var reader = await command.ExecuteReaderAsync(cancellationToken);
+            return await reader.ReadAsync(cancellationToken) ? ReadModel(reader) : null;
+        }
+
+        /// <summary>
+        /// Adds a version; a version that is already stored is never overwritten but refused
+        /// with a ModelVersionConflictException
+        /// </summary>
+        public async Task SaveAsync(StoredModel model, CancellationToken cancellationToken = default)
+        {
+            await using var connection = await OpenConnectionAsync(cancellationToken);
+            await using var command = CreateCommand(connection,
+                $"INSERT INTO model_versions ({Columns}) VALUES (@id, @version, @name, @owner, @saved_at, @model_text, @data_text)",
+                ("@id", model.Id),
+                ("@version", model.Version),
+                ("@name", model.Name),
+                ("@owner", (object?)model.Owner ?? DBNull.Value),
+                ("@saved_at", model.SavedAt.ToUniversalTime().ToString("O", CultureInfo.InvariantCulture)),
+                ("@model_text", model.ModelText),
+                ("@data_text", model.DataText));
+
+            try
+            {
+                await command.ExecuteNonQueryAsync(cancellationToken);
+            }
+            catch (DbException ex)
+            {
+                // Providers report constraint violations with their own codes; the stored row is what counts
+                if (!await ExistsAsync(connection, model.Id, model.Version, cancellationToken))
+                    throw;
+                throw new ModelVersionConflictException(model.Id, model.Version, ex);
+            }
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> ListAsync(CancellationToken cancellationToken = default)
+        {
+            await using var connection = await OpenConnectionAsync(cancellationToken);
+            await using var command = CreateCommand(connection,
+                "SELECT v.id, v.version, v.name, v.owner, v.saved_at FROM model_versions v " +
+                "JOIN (SELECT id, MAX(version) AS version FROM model_versions GROUP BY id) latest " +
+                "ON v.id = latest.id AND v.version = latest.version ORDER BY v.id");
+            return await ReadInfosAsync(command, cancellationToken);
+        }
+
+        public async Task<bool> DeleteAsync(string id, CancellationToken cancellationToken = default)
+        {
+            await using var connection = await OpenConnectionAsync(cancellationToken);
+            await using var command = CreateCommand(connection, "DELETE FROM model_versions WHERE id = @id", ("@id", id));
+            return await command.ExecuteNonQueryAsync(cancellationToken) > 0;
+        }
+
+        public async Task<IReadOnlyList<StoredModelInfo>> VersionsAsync(string id, CancellationToken cancellationToken = default)
+        {
+            await using var connection = await OpenConnectionAsync(cancellationToken);
+            await using var command = CreateCommand(connection,
+                "SELECT id, version, name, owner, saved_at FROM model_versions WHERE id = @id ORDER BY version", ("@id", id));
+            return await ReadInfosAsync(command, cancellationToken);
+        }
+
+        private static async Task<bool> ExistsAsync(DbConnection connection, string id, int version, CancellationToken cancellationToken)
+        {
+            await using var command = CreateCommand(connection,
+                "SELECT COUNT(*) FROM model_versions WHERE id = @id AND version = @version", ("@id", id), ("@version", version));
+            return Convert.ToInt64(await command.ExecuteScalarAsync(cancellationToken), CultureInfo.InvariantCulture) > 0;
+        }
+
+        private async Task<DbConnection> OpenConnectionAsync(CancellationToken cancellationToken)
+        {
+            var connection = connectionFactory();
+            await connection.OpenAsync(cancellationToken);
+
+            if (!schemaCreated)
+            {
+                await schemaLock.WaitAsync(cancellationToken);
+                try
+                {
+                    if (!schemaCreated)
+                    {
+                        await using var command = CreateCommand(connection,
+                            "CREATE TABLE IF NOT EXISTS model_versions (" +
+                            "id TEXT NOT NULL, version INTEGER NOT NULL, name TEXT NOT NULL, owner TEXT, " +
+                            "saved_at TEXT NOT NULL, model_text TEXT NOT NULL, data_text TEXT NOT NULL, " +
+                            "PRIMARY KEY (id, version))");
+                        await command.ExecuteNonQueryAsync(cancellationToken);
+                        schemaCreated = true;
+                    }
+                }
+                finally
+                {
+                    schemaLock.Release();
+                }
+            }
+
+            return connection;
+        }
+
+        private static DbCommand CreateCommand(DbConnection connection, string sql, params (string Name, object Value)[] parameters)
+        {
+            var command = connection.CreateCommand();
+            command.CommandText = sql;
+            foreach (var (name, value) in parameters)
+            {
+                var parameter = command.CreateParameter();
+                parameter.ParameterName = name;
+                parameter.Value = value;
+                command.Parameters.Add(parameter);
+            }
+            return command;
+        }
+
+        private static async Task<IReadOnlyList<StoredModelInfo>> ReadInfosAsync(DbCommand command, CancellationToken cancellationToken)
+        {
+            var result = new List<StoredModelInfo>();
+            await using var reader = await command.ExecuteReaderAsync(cancellationToken);
+            while (await reader.ReadAsync(cancellationToken))
+            {
+                result.Add(new StoredModelInfo
+                {
+                    Id = reader.GetString(0),
+                    Version = Convert.ToInt32(reader.GetValue(1), CultureInfo.InvariantCulture),
+                    Name = reader.GetString(2),
+                    Owner = reader.IsDBNull(3) ? null : reader.GetString(3),
+                    SavedAt = ParseTimestamp(reader.GetString(4))
+                });
+            }
+            return result;
+        }
+
+        private static StoredModel ReadModel(DbDataReader reader) => new StoredModel
+        {
+            Id = reader.GetString(0),
+            Version = Convert.ToInt32(reader.GetValue(1), CultureInfo.InvariantCulture),
+            Name = reader.GetString(2),
+            Owner = reader.IsDBNull(3) ? null : reader.GetString(3),
+            SavedAt = ParseTimestamp(reader.GetString(4)),
+            ModelText = reader.GetString(5),
+            DataText = reader.GetString(6)
+        };
+
+        private static DateTime ParseTimestamp(string text) =>
+            DateTime.Parse(text, CultureInfo.InvariantCulture, DateTimeStyles.AdjustToUniversal | DateTimeStyles.AssumeUniversal);
+    }
+}
@@ -0,0 +1,163 @@
+using Core.Models;
+using Core.Solving;
+
+namespace Core.Visualization
+{
+    /// <summary>
+    /// Sparse constraint matrix of an expanded model with rows and columns ordered by family,
+    /// so that constraint and variable families appear as contiguous blocks
+    /// </summary>
+    public class ConstraintMatrix
+    {
+        public List<string> RowNames { get; } = new List<string>();
+        public List<string> RowFamilies { get; } = new List<string>();
+        public List<string> ColumnNames { get; } = new List<string>();
+        public List<string> ColumnFamilies { get; } = new List<string>();
+
+        /// <summary>
+        /// Nonzero entries as (row, column, value)
+        /// </summary>
+        public List<(int Row, int Column, double Value)> Entries { get; } = new List<(int, int, double)>();
+
+        public int RowCount => RowNames.Count;
+        public int ColumnCount => ColumnNames.Count;
+
+        /// <summary>
+        /// Fraction of nonzero cells
+        /// </summary>
+        public double Density => RowCount == 0 || ColumnCount == 0 ? 0 : (double)Entries.Count / ((double)RowCount * ColumnCount);
+
+        public static ConstraintMatrix Build(ModelManager manager)
+        {
+            if (manager.IndexedEquationTemplates.Count > 0 || manager.ForallStatements.Count > 0)
+            {
+                throw new InvalidOperationException(
+                    "Cannot build constraint matrix: Model has unexpanded templates. " +
+                    "Call ExpandAllTemplates() after loading external data.");
+            }
+
+            var matrix = new ConstraintMatrix();
+
+            // Stable grouping by family in order of first appearance
+            var rows = manager.MaterializeRows()
+                .Select((equation, index) => (Equation: equation, Index: index, Family: SolutionComparison.GetFamily(equation)))
+                .ToList();
+            var rowFamilyOrder = FirstAppearance(rows.Select(r => r.Family));
+            rows = rows.OrderBy(r => rowFamilyOrder[r.Family]).ThenBy(r => r.Index).ToList();
+
+            var columnNames = new List<string>();
+            var seen = new HashSet<string>();
+            foreach (var (equation, _, _) in rows)
+            {
+                foreach (var name in equation.Coefficients.Keys)
+                {
+                    if (seen.Add(name))
+                        columnNames.Add(name);
+                }
+            }
+
+            var columnFamilyOrder = FirstAppearance(columnNames.Select(SolutionComparison.GetFamily));
+            columnNames = columnNames
+                .Select((name, index) => (Name: name, Index: index))
+                .OrderBy(c => columnFamilyOrder[SolutionComparison.GetFamily(c.Name)])
+                .ThenBy(c => c.Index)
+                .Select(c => c.Name)
+                .ToList();
+
+            var columnIndex = new Dictionary<string, int>();
+            foreach (var name in columnNames)
+            {
+                columnIndex[name] = matrix.ColumnNames.Count;
+                matrix.ColumnNames.Add(name);
+                matrix.ColumnFamilies.Add(SolutionComparison.GetFamily(name));
+            }
+
+            foreach (var (equation, index, family) in rows)
+            {
+                int row = matrix.RowNames.Count;
+                matrix.RowNames.Add(GetRowName(equation, index));
+                matrix.RowFamilies.Add(family);
+
+                foreach (var kvp in equation.Coefficients)
+                {
+                    double value = kvp.Value.Evaluate(manager);
+                    if (value != 0)
+                        matrix.Entries.Add((row, columnIndex[kvp.Key], value));
+                }
+            }
+
+            return matrix;
+        }
+
+        /// <summary>
+        /// Nonzero counts per (row family, column family) block, in matrix order
+        /// </summary>
+        public BlockStructure GetBlockStructure()
+        {
+            var rowFamilies = RowFamilies.Distinct().ToList();
+            var columnFamilies = ColumnFamilies.Distinct().ToList();
+            var rowLookup = rowFamilies.Select((f, i) => (f, i)).ToDictionary(x => x.f, x => x.i);
+            var columnLookup = columnFamilies.Select((f, i) => (f, i)).ToDictionary(x => x.f, x => x.i);
+
+            var counts = new int[rowFamilies.Count, columnFamilies.Count];
+            foreach (var entry in Entries)
+            {
+                counts[rowLookup[RowFamilies[entry.Row]], columnLookup[ColumnFamilies[entry.Column]]]++;
+            }
+
+            return new BlockStructure
+            {
+                RowFamilies = rowFamilies,
+                ColumnFamilies = columnFamilies,
+                RowSizes = rowFamilies.Select(f => RowFamilies.Count(r => r == f)).ToList(),
+                ColumnSizes = columnFamilies.Select(f => ColumnFamilies.Count(c => c == f)).ToList(),
+                NonZeros = counts
+            };
+        }
+
+        private static string GetRowName(LinearEquation equation, int index)
+        {
+            if (!string.IsNullOrEmpty(equation.Label))
+                return equation.Label;
+
+            string description = equation.GetDescription();
+            return string.IsNullOrEmpty(description) ? $"c{index + 1}" : description;
+        }
+
+        private static Dictionary<string, int> FirstAppearance(IEnumerable<string> families)
+        {
+            var order = new Dictionary<string, int>();
+            foreach (var family in families)
+            {
+                if (!order.ContainsKey(family))
+                    order[family] = order.Count;
+            }
+            return order;
+        }
+    }
+
+    /// <summary>
+    /// Constraint matrix aggregated to family blocks
+    /// </summary>
+    public class BlockStructure
+    {
+        public List<string> RowFamilies { get; init; } = new List<string>();
+        public List<string> ColumnFamilies { get; init; } = new List<string>();
+        public List<int> RowSizes { get; init; } = new List<int>();
+        public List<int> ColumnSizes { get; init; } = new List<int>();
+
+        /// <summary>
+        /// Nonzero count per [row family, column family]
+        /// </summary>
+        public int[,] NonZeros { get; init; } = new int[0, 0];
+
+        /// <summary>
+        /// Share of the block's cells that are nonzero
+        /// </summary>
+        public double GetDensity(int rowFamily, int columnFamily)
+        {
+            double cells = (double)RowSizes[rowFamily] * ColumnSizes[columnFamily];
+            return cells == 0 ? 0 : NonZeros[rowFamily, columnFamily] / cells;
+        }
+    }
+}
@@ -0,0 +1,291 @@
+using System.Globalization;
+using System.Security;
+using System.Text;
+
+namespace Core.Visualization
+{
+    public class MatrixRenderOptions
+    {
+        /// <summary>
+        /// Maximum width/height of the plot area in pixels; larger matrices are downsampled
+        /// so that one pixel covers several rows/columns
+        /// </summary>
+        public int MaxSize { get; set; } = 800;
+
+        /// <summary>
+        /// Pixel size of one block in heatmaps
+        /// </summary>
+        public int BlockSize { get; set; } = 48;
+
+        /// <summary>
+        /// Draw lines between row and column families
+        /// </summary>
+        public bool ShowFamilyBoundaries { get; set; } = true;
+    }
+
+    /// <summary>
+    /// Renders constraint matrix spy plots and family block heatmaps as SVG or PNG
+    /// </summary>
+    public class MatrixRenderer
+    {
+        private const int LabelMargin = 120;
+
+        private static readonly (byte R, byte G, byte B) Positive = (0x1F, 0x77, 0xB4);
+        private static readonly (byte R, byte G, byte B) Negative = (0xD6, 0x27, 0x28);
+        private static readonly (byte R, byte G, byte B) Mixed = (0x94, 0x67, 0xBD);
+        private static readonly (byte R, byte G, byte B) Boundary = (0xDD, 0xDD, 0xDD);
+
+        private readonly MatrixRenderOptions options;
+
+        public MatrixRenderer(MatrixRenderOptions? options = null)
+        {
+            this.options = options ?? new MatrixRenderOptions();
+        }
+
+        public string RenderSpySvg(ConstraintMatrix matrix)
+        {
+            var grid = SpyGrid.Create(matrix, options.MaxSize);
+            int width = grid.Columns * grid.Scale;
+            int height = grid.Rows * grid.Scale;
+
+            var sb = new StringBuilder();
+            AppendSvgHeader(sb, width + LabelMargin, height + LabelMargin);
+            sb.AppendLine($"<title>{Escape($"{matrix.RowCount} x {matrix.ColumnCount}, {matrix.Entries.Count} nonzeros")}</title>");
+            sb.AppendLine($"<g transform=\"translate({LabelMargin},{LabelMargin})\">");
+            sb.AppendLine($"<rect width=\"{width}\" height=\"{height}\" fill=\"white\" stroke=\"#999\"/>");
+
+            if (options.ShowFamilyBoundaries)
+                AppendFamilyBoundaries(sb, matrix, grid, width, height);
+
+            for (int r = 0; r < grid.Rows; r++)
+            {
+                for (int c = 0; c < grid.Columns; c++)
+                {
+                    var color = grid.GetColor(r, c);
+                    if (color == null)
+                        continue;
+
+                    sb.AppendLine($"<rect x=\"{c * grid.Scale}\" y=\"{r * grid.Scale}\" width=\"{grid.Scale}\" height=\"{grid.Scale}\" fill=\"{Hex(color.Value)}\"/>");
+                }
+            }
+
+            sb.AppendLine("</g>");
+            sb.AppendLine("</svg>");
+            return sb.ToString();
+        }
+
+        public byte[] RenderSpyPng(ConstraintMatrix matrix)
+        {
+            var grid = SpyGrid.Create(matrix, options.MaxSize);
+            int width = Math.Max(1, grid.Columns * grid.Scale);
+            int height = Math.Max(1, grid.Rows * grid.Scale);
+            var pixels = new byte[width * height * 3];
+            Array.Fill(pixels, (byte)0xFF);
+
+            if (options.ShowFamilyBoundaries)
+            {
+                foreach (int row in FamilyStarts(matrix.RowFamilies).Skip(1))
+                    FillRect(pixels, width, 0, row / grid.RowsPerPixel * grid.Scale, width, 1, Boundary);
+                foreach (int column in FamilyStarts(matrix.ColumnFamilies).Skip(1))
+                    FillRect(pixels, width, column / grid.ColumnsPerPixel * grid.Scale, 0, 1, height, Boundary);
+            }
+
+            for (int r = 0; r < grid.Rows; r++)
+            {
+                for (int c = 0; c < grid.Columns; c++)
+                {
+                    var color = grid.GetColor(r, c);
+                    if (color != null)
+                        FillRect(pixels, width, c * grid.Scale, r * grid.Scale, grid.Scale, grid.Scale, color.Value);
+                }
+            }
+
+            return PngEncoder.Encode(width, height, pixels);
+        }
+
+        public string RenderHeatmapSvg(BlockStructure blocks)
+        {
+            int size = options.BlockSize;
+            int width = blocks.ColumnFamilies.Count * size;
+            int height = blocks.RowFamilies.Count * size;
+
+            var sb = new StringBuilder();
+            AppendSvgHeader(sb, width + LabelMargin, height + LabelMargin);
+            sb.AppendLine($"<g transform=\"translate({LabelMargin},{LabelMargin})\" font-family=\"sans-serif\" font-size=\"11\">");
+
+            for (int r = 0; r < blocks.RowFamilies.Count; r++)
+            {
+                sb.AppendLine($"<text x=\"-6\" y=\"{r * size + size / 2 + 4}\" text-anchor=\"end\">{Escape(blocks.RowFamilies[r])} ({blocks.RowSizes[r]})</text>");
+
+                for (int c = 0; c < blocks.ColumnFamilies.Count; c++)
+                {
+                    double density = blocks.GetDensity(r, c);
+                    int count = blocks.NonZeros[r, c];
+                    var color = HeatColor(density, count);
+
+                    sb.Append($"<rect x=\"{c * size}\" y=\"{r * size}\" width=\"{size}\" height=\"{size}\" fill=\"{Hex(color)}\" stroke=\"#ccc\">");
+                    sb.Append($"<title>{Escape($"{blocks.RowFamilies[r]} x {blocks.ColumnFamilies[c]}: {count} nonzeros, density {density.ToString("P2", CultureInfo.InvariantCulture)}")}</title>");
+                    sb.AppendLine("</rect>");
+
+                    if (count > 0)
+                    {
+                        string textColor = density > 0.5 ? "white" : "black";
+                        sb.AppendLine($"<text x=\"{c * size + size / 2}\" y=\"{r * size + size / 2 + 4}\" text-anchor=\"middle\" fill=\"{textColor}\">{count}</text>");
+                    }
+                }
+            }
+
+            for (int c = 0; c < blocks.ColumnFamilies.Count; c++)
+            {
+                int x = c * size + size / 2;
+                sb.AppendLine($"<text x=\"{x}\" y=\"-6\" transform=\"rotate(-45 {x} -6)\">{Escape(blocks.ColumnFamilies[c])} ({blocks.ColumnSizes[c]})</text>");
+            }
+
+            sb.AppendLine("</g>");
+            sb.AppendLine("</svg>");
+            return sb.ToString();
+        }
+
+        public byte[] RenderHeatmapPng(BlockStructure blocks)
+        {
+            int size = options.BlockSize;
+            int width = Math.Max(1, blocks.ColumnFamilies.Count * size);
+            int height = Math.Max(1, blocks.RowFamilies.Count * size);
+            var pixels = new byte[width * height * 3];
+            Array.Fill(pixels, (byte)0xFF);
+
+            for (int r = 0; r < blocks.RowFamilies.Count; r++)
+            {
+                for (int c = 0; c < blocks.ColumnFamilies.Count; c++)
+                {
+                    FillRect(pixels, width, c * size, r * size, size, size, Boundary);
+                    FillRect(pixels, width, c * size + 1, r * size + 1, size - 1, size - 1, HeatColor(blocks.GetDensity(r, c), blocks.NonZeros[r, c]));
+                }
+            }
+
+            return PngEncoder.Encode(width, height, pixels);
+        }
+
+        /// <summary>
+        /// White for empty blocks, then light to dark blue with density (on a square-root scale
+        /// so that sparse linking blocks stay visible)
+        /// </summary>
+        private static (byte R, byte G, byte B) HeatColor(double density, int count)
+        {
+            if (count == 0)
+                return (0xFF, 0xFF, 0xFF);
+
+            double t = 0.15 + 0.85 * Math.Sqrt(Math.Clamp(density, 0, 1));
+            return (
+                (byte)(0xFF - t * (0xFF - 0x08)),
+                (byte)(0xFF - t * (0xFF - 0x30)),
+                (byte)(0xFF - t * (0xFF - 0x6B)));
+        }
+
+        private void AppendFamilyBoundaries(StringBuilder sb, ConstraintMatrix matrix, SpyGrid grid, int width, int height)
+        {
+            var rowStarts = FamilyStarts(matrix.RowFamilies).ToList();
+            var columnStarts = FamilyStarts(matrix.ColumnFamilies).ToList();
+
+            for (int i = 0; i < rowStarts.Count; i++)
+            {
+                int y = rowStarts[i] / grid.RowsPerPixel * grid.Scale;
+                if (i > 0)
+                    sb.AppendLine($"<line x1=\"0\" y1=\"{y}\" x2=\"{width}\" y2=\"{y}\" stroke=\"{Hex(Boundary)}\"/>");
+                sb.AppendLine($"<text x=\"-6\" y=\"{y + 10}\" text-anchor=\"end\" font-family=\"sans-serif\" font-size=\"10\">{Escape(matrix.RowFamilies[rowStarts[i]])}</text>");
+            }
+
+            for (int i = 0; i < columnStarts.Count; i++)
+            {
+                int x = columnStarts[i] / grid.ColumnsPerPixel * grid.Scale;
+                if (i > 0)
+                    sb.AppendLine($"<line x1=\"{x}\" y1=\"0\" x2=\"{x}\" y2=\"{height}\" stroke=\"{Hex(Boundary)}\"/>");
+                sb.AppendLine($"<text x=\"{x + 2}\" y=\"-6\" transform=\"rotate(-45 {x + 2} -6)\" font-family=\"sans-serif\" font-size=\"10\">{Escape(matrix.ColumnFamilies[columnStarts[i]])}</text>");
+            }
+        }
+
+        private static IEnumerable<int> FamilyStarts(List<string> families)
+        {
+            for (int i = 0; i < families.Count; i++)
+            {
+                if (i == 0 || families[i] != families[i - 1])
+                    yield return i;
+            }
+        }
+
+        private static void FillRect(byte[] pixels, int imageWidth, int x, int y, int w, int h, (byte R, byte G, byte B) color)
+        {
+            int imageHeight = pixels.Length / 3 / imageWidth;
+            for (int py = Math.Max(0, y); py < Math.Min(imageHeight, y + h); py++)
+            {
+                for (int px = Math.Max(0, x); px < Math.Min(imageWidth, x + w); px++)
+                {
+                    int offset = (py * imageWidth + px) * 3;
+                    pixels[offset] = color.R;
+                    pixels[offset + 1] = color.G;
+                    pixels[offset + 2] = color.B;
+                }
+            }
+        }
+
+        private static void AppendSvgHeader(StringBuilder sb, int width, int height)
+        {
+            sb.AppendLine($"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"{width}\" height=\"{height}\" viewBox=\"0 0 {width} {height}\">");
+        }
+
+        private static string Hex((byte R, byte G, byte B) color) => $"#{color.R:X2}{color.G:X2}{color.B:X2}";
+
+        private static string Escape(string text) => SecurityElement.Escape(text) ?? "";
+
+        /// <summary>
+        /// Matrix pattern reduced to at most MaxSize cells per axis. Each cell records the signs of
+        /// the nonzeros it covers (bit 1 = positive, bit 2 = negative).
+        /// </summary>
+        private class SpyGrid
+        {
+            public int Rows { get; private init; }
+            public int Columns { get; private init; }
+            public int RowsPerPixel { get; private init; }
+            public int ColumnsPerPixel { get; private init; }
+
+            /// <summary>
+            /// Pixels per cell when the matrix is smaller than MaxSize
+            /// </summary>
+            public int Scale { get; private init; }
+
+            private byte[,] signs = new byte[0, 0];
+
+            public static SpyGrid Create(ConstraintMatrix matrix, int maxSize)
+            {
+                int rowsPerPixel = Math.Max(1, (matrix.RowCount + maxSize - 1) / maxSize);
+                int columnsPerPixel = Math.Max(1, (matrix.ColumnCount + maxSize - 1) / maxSize);
+                int rows = (matrix.RowCount + rowsPerPixel - 1) / rowsPerPixel;
+                int columns = (matrix.ColumnCount + columnsPerPixel - 1) / columnsPerPixel;
+                int scale = Math.Max(1, Math.Min(16, maxSize / Math.Max(1, Math.Max(rows, columns))));
+
+                var grid = new SpyGrid
+                {
+                    Rows = rows,
+                    Columns = columns,
+                    RowsPerPixel = rowsPerPixel,
+                    ColumnsPerPixel = columnsPerPixel,
+                    Scale = scale,
+                    signs = new byte[rows, columns]
+                };
+
+                foreach (var (row, column, value) in matrix.Entries)
+                    grid.signs[row / rowsPerPixel, column / columnsPerPixel] |= (byte)(value > 0 ? 1 : 2);
+
+                return grid;
+            }
+
+            public (byte R, byte G, byte B)? GetColor(int row, int column) => signs[row, column] switch
+            {
+                1 => Positive,
+                2 => Negative,
+                3 => Mixed,
+                _ => null
+            };
+        }
+    }
+}
@@ -0,0 +1,320 @@
+using System.Globalization;
+using System.Security;
+using System.Text;
+using System.Text.RegularExpressions;
+using Core.Analysis;
+using Core.Parsing;
+
+namespace Core.Visualization
+{
+    /// <summary>
+    /// Writes model structure graphs in DOT (Graphviz) and GraphML
+    /// </summary>
+    public class ModelGraphExporter
+    {
+        private static readonly Regex ignoredText = new Regex(@"""(?:[^""\\]|\\.)*""|//[^\n]*|/\*.*?\*/", RegexOptions.Singleline);
+        private static readonly Regex identifierPattern = new Regex(@"(?<!\w)(?<!(?<!\.)\.)[A-Za-z_]\w*");
+
+        private readonly ConstraintMatrix matrix;
+
+        public ModelGraphExporter(ConstraintMatrix matrix)
+        {
+            this.matrix = matrix;
+        }
+
+        /// <summary>
+        /// Collapse constraints and variables to one node per family; edge weights are nonzero counts
+        /// </summary>
+        public bool AggregateFamilies { get; set; }
+
+        /// <summary>
+        /// Bipartite variable–constraint graph in DOT
+        /// </summary>
+        public string ExportVariableConstraintDot()
+        {
+            var (constraints, variables, edges) = BuildBipartiteGraph();
+            var sb = new StringBuilder();
+
+            sb.AppendLine("graph model {");
+            sb.AppendLine("  graph [layout=sfdp, overlap=false];");
+            sb.AppendLine("  node [fontname=\"sans-serif\", fontsize=10];");
+
+            foreach (var (id, label, size) in constraints)
+                sb.AppendLine($"  {Quote(id)} [label={Quote(NodeLabel(label, size))}, shape=box, color=\"#d62728\"];");
+
+            foreach (var (id, label, size) in variables)
+                sb.AppendLine($"  {Quote(id)} [label={Quote(NodeLabel(label, size))}, shape=ellipse, color=\"#1f77b4\"];");
+
+            foreach (var (from, to, weight) in edges)
+            {
+                string attributes = AggregateFamilies
+                    ? $" [weight={weight.ToString(CultureInfo.InvariantCulture)}, label=\"{weight.ToString(CultureInfo.InvariantCulture)}\"]"
+                    : "";
+                sb.AppendLine($"  {Quote(from)} -- {Quote(to)}{attributes};");
+            }
+
+            sb.AppendLine("}");
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Bipartite variable–constraint graph in GraphML
+        /// </summary>
+        public string ExportVariableConstraintGraphMl()
+        {
+            var (constraints, variables, edges) = BuildBipartiteGraph();
+            var sb = new StringBuilder();
+
+            sb.AppendLine("<?xml version=\"1.0\" encoding=\"UTF-8\"?>");
+            sb.AppendLine("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">");
+            sb.AppendLine("  <key id=\"kind\" for=\"node\" attr.name=\"kind\" attr.type=\"string\"/>");
+            sb.AppendLine("  <key id=\"label\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>");
+            sb.AppendLine("  <key id=\"size\" for=\"node\" attr.name=\"size\" attr.type=\"int\"/>");
+            sb.AppendLine("  <key id=\"weight\" for=\"edge\" attr.name=\"weight\" attr.type=\"double\"/>");
+            sb.AppendLine("  <graph id=\"model\" edgedefault=\"undirected\">");
+
+            foreach (var (kind, nodes) in new[] { ("constraint", constraints), ("variable", variables) })
+            {
+                foreach (var (id, label, size) in nodes)
+                {
+                    sb.AppendLine($"    <node id=\"{Escape(id)}\">");
+                    sb.AppendLine($"      <data key=\"kind\">{kind}</data>");
+                    sb.AppendLine($"      <data key=\"label\">{Escape(label)}</data>");
+                    sb.AppendLine($"      <data key=\"size\">{size}</data>");
+                    sb.AppendLine("    </node>");
+                }
+            }
+
+            int edgeId = 0;
+            foreach (var (from, to, weight) in edges)
+            {
+                sb.AppendLine($"    <edge id=\"e{edgeId++}\" source=\"{Escape(from)}\" target=\"{Escape(to)}\">");
+                sb.AppendLine($"      <data key=\"weight\">{weight.ToString("R", CultureInfo.InvariantCulture)}</data>");
+                sb.AppendLine("    </edge>");
+            }
+
+            sb.AppendLine("  </graph>");
+            sb.AppendLine("</graphml>");
+            return sb.ToString();
+        }
+
+        /// <summary>
+        /// Block hierarchy of the "// @block" annotations in DOT: the model contains its top-level
+        /// blocks and declarations, each block its nested blocks and declarations
+        /// </summary>
+        public static string ExportBlockHierarchyDot(string modelText)
+        {
+            return WriteDirectedDot("blocks", BuildBlockHierarchy(modelText));
+        }
+
+        /// <summary>
+        /// Block hierarchy of the "// @block" annotations in GraphML
+        /// </summary>
+        public static string ExportBlockHierarchyGraphMl(string modelText)
+        {
+            return WriteDirectedGraphMl("blocks", BuildBlockHierarchy(modelText));
+        }
+
+        /// <summary>
+        /// Set-dependency graph in DOT: an edge from every declaration to the sets it is indexed
+        /// over or iterates, and from every set to the sets and parameters it is defined with
+        /// </summary>
+        public static string ExportSetDependencyDot(string modelText)
+        {
+            return WriteDirectedDot("sets", BuildSetDependencies(modelText));
+        }
+
+        /// <summary>
+        /// Set-dependency graph in GraphML
+        /// </summary>
+        public static string ExportSetDependencyGraphMl(string modelText)
+        {
+            return WriteDirectedGraphMl("sets", BuildSetDependencies(modelText));
+        }
+
+        /// <summary>
+        /// Nodes as (id, label, size) and edges as (constraint id, variable id, weight). Without
+        /// aggregation the weight is the coefficient; with aggregation it is the nonzero count.
+        /// </summary>
+        private (List<(string Id, string Label, int Size)> Constraints,
+                 List<(string Id, string Label, int Size)> Variables,
+                 List<(string From, string To, double Weight)> Edges) BuildBipartiteGraph()
+        {
+            if (AggregateFamilies)
+            {
+                var blocks = matrix.GetBlockStructure();
+                var constraints = blocks.RowFamilies.Select((f, i) => ($"c:{f}", f, blocks.RowSizes[i])).ToList();
+                var variables = blocks.ColumnFamilies.Select((f, i) => ($"v:{f}", f, blocks.ColumnSizes[i])).ToList();
+                var edges = new List<(string, string, double)>();
+
+                for (int r = 0; r < blocks.RowFamilies.Count; r++)
+                {
+                    for (int c = 0; c < blocks.ColumnFamilies.Count; c++)
+                    {
+                        if (blocks.NonZeros[r, c] > 0)
+                            edges.Add(($"c:{blocks.RowFamilies[r]}", $"v:{blocks.ColumnFamilies[c]}", blocks.NonZeros[r, c]));
+                    }
+                }
+
+                return (constraints, variables, edges);
+            }
+
+            return (
+                matrix.RowNames.Select((n, i) => ($"c{i}", n, 1)).ToList(),
+                matrix.ColumnNames.Select((n, i) => ($"v{i}", n, 1)).ToList(),
+                matrix.Entries.Select(e => ($"c{e.Row}", $"v{e.Column}", e.Value)).ToList());
+        }
+
+        /// <summary>
+        /// Nodes are entity keys ("constraint:cap"), "block:" plus the block path and "model";
+        /// the size of a block is the number of declarations in it and its nested blocks
+        /// </summary>
+        private static (List<(string Id, string Label, string Kind, int Size)> Nodes,
+                        List<(string From, string To)> Edges) BuildBlockHierarchy(string modelText)
+        {
+            var tags = ModelTags.Parse(modelText);
+            var nodes = new List<(string, string, string, int)> { ("model", "model", "model", tags.Entities.Count) };
+            var edges = new List<(string, string)>();
+            var ids = new HashSet<string>(StringComparer.Ordinal) { "model" };
+
+            foreach (var entity in tags.Entities)
+            {
+                string parent = "model";
+                foreach (string path in entity.Block != null ? TagPath.Ancestors(entity.Block) : Enumerable.Empty<string>())
+                {
+                    string id = "block:" + path;
+                    if (ids.Add(id))
+                    {
+                        int size = tags.Entities.Count(e => e.Block != null && TagPath.Matches(e.Block, path));
+                        nodes.Add((id, path.Substring(path.LastIndexOf('/') + 1), "block", size));
+                        edges.Add((parent, id));
+                    }
+                    parent = id;
+                }
+
+                string key = ids.Add(entity.Key) ? entity.Key : $"{entity.Key}#{entity.LineNumber}";
+                ids.Add(key);
+                nodes.Add((key, NameOf(entity.Key), KindOf(entity.Key), 1));
+                edges.Add((parent, key));
+            }
+
+            return (nodes, edges);
+        }
+
+        /// <summary>
+        /// Nodes are entity keys in declaration order: every set, and every other declaration that
+        /// uses a set or is used by one; the size of a node is the number of declarations using it
+        /// </summary>
+        private static (List<(string Id, string Label, string Kind, int Size)> Nodes,
+                        List<(string From, string To)> Edges) BuildSetDependencies(string modelText)
+        {
+            var declarations = ModelSource.Parse(modelText).Statements
+                .Where(s => s.Key != null)
+                .GroupBy(s => s.Key!, StringComparer.Ordinal)
+                .Select(g => g.First())
+                .ToList();
+            var sets = declarations.Where(s => KindOf(s.Key!) == "set").Select(s => NameOf(s.Key!)).ToHashSet(StringComparer.Ordinal);
+            var parameters = declarations.Where(s => KindOf(s.Key!) == "parameter").Select(s => NameOf(s.Key!)).ToHashSet(StringComparer.Ordinal);
+
+            var edges = new List<(string, string)>();
+            foreach (var declaration in declarations)
+            {
+                string key = declaration.Key!;
+                bool isSet = KindOf(key) == "set";
+                string code = ignoredText.Replace(declaration.Code, " ");
+
+                foreach (string name in identifierPattern.Matches(code).Select(m => m.Value).Distinct(StringComparer.Ordinal))
+                {
+                    if (name == NameOf(key))
+                        continue;
+                    if (sets.Contains(name))
+                        edges.Add((key, $"set:{name}"));
+                    else if (isSet && parameters.Contains(name))
+                        edges.Add((key, $"parameter:{name}"));
+                }
+            }
+
+            var connected = edges.SelectMany(e => new[] { e.Item1, e.Item2 }).ToHashSet(StringComparer.Ordinal);
+            var nodes = declarations
+                .Where(s => KindOf(s.Key!) == "set" || connected.Contains(s.Key!))
+                .Select(s => (s.Key!, NameOf(s.Key!), KindOf(s.Key!), edges.Count(e => e.Item2 == s.Key)))
+                .ToList();
+
+            return (nodes, edges);
+        }
+
+        private static string WriteDirectedDot(string name, (List<(string Id, string Label, string Kind, int Size)> Nodes, List<(string From, string To)> Edges) graph)
+        {
+            var sb = new StringBuilder();
+
+            sb.AppendLine($"digraph {name} {{");
+            sb.AppendLine("  graph [rankdir=LR];");
+            sb.AppendLine("  node [fontname=\"sans-serif\", fontsize=10];");
+
+            foreach (var (id, label, kind, size) in graph.Nodes)
+            {
+                string text = kind is "model" or "block" ? $"{label} ({size})" : label;
+                sb.AppendLine($"  {Quote(id)} [label={Quote(text)}, {NodeStyle(kind)}];");
+            }
+
+            foreach (var (from, to) in graph.Edges)
+                sb.AppendLine($"  {Quote(from)} -> {Quote(to)};");
+
+            sb.AppendLine("}");
+            return sb.ToString();
+        }
+
+        private static string WriteDirectedGraphMl(string name, (List<(string Id, string Label, string Kind, int Size)> Nodes, List<(string From, string To)> Edges) graph)
+        {
+            var sb = new StringBuilder();
+
+            sb.AppendLine("<?xml version=\"1.0\" encoding=\"UTF-8\"?>");
+            sb.AppendLine("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">");
+            sb.AppendLine("  <key id=\"kind\" for=\"node\" attr.name=\"kind\" attr.type=\"string\"/>");
+            sb.AppendLine("  <key id=\"label\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>");
+            sb.AppendLine("  <key id=\"size\" for=\"node\" attr.name=\"size\" attr.type=\"int\"/>");
+            sb.AppendLine($"  <graph id=\"{name}\" edgedefault=\"directed\">");
+
+            foreach (var (id, label, kind, size) in graph.Nodes)
+            {
+                sb.AppendLine($"    <node id=\"{Escape(id)}\">");
+                sb.AppendLine($"      <data key=\"kind\">{kind}</data>");
+                sb.AppendLine($"      <data key=\"label\">{Escape(label)}</data>");
+                sb.AppendLine($"      <data key=\"size\">{size}</data>");
+                sb.AppendLine("    </node>");
+            }
+
+            int edgeId = 0;
+            foreach (var (from, to) in graph.Edges)
+                sb.AppendLine($"    <edge id=\"e{edgeId++}\" source=\"{Escape(from)}\" target=\"{Escape(to)}\"/>");
+
+            sb.AppendLine("  </graph>");
+            sb.AppendLine("</graphml>");
+            return sb.ToString();
+        }
+
+        private static string NodeStyle(string kind) => kind switch
+        {
+            "model" or "block" => "shape=folder, color=\"#7f7f7f\"",
+            "set" => "shape=hexagon, color=\"#2ca02c\"",
+            "parameter" => "shape=note, color=\"#8c564b\"",
+            "variable" => "shape=ellipse, color=\"#1f77b4\"",
+            "dexpr" => "shape=ellipse, style=dashed, color=\"#9467bd\"",
+            "constraint" => "shape=box, color=\"#d62728\"",
+            _ => "shape=doubleoctagon, color=\"#ff7f0e\""
+        };
+
+        private static string KindOf(string key) => key.Contains(':') ? key.Substring(0, key.IndexOf(':')) : key;
+
+        private static string NameOf(string key) => key.Substring(key.IndexOf(':') + 1);
+
+        private string NodeLabel(string label, int size)
+        {
+            return AggregateFamilies ? $"{label} ({size})" : label;
+        }
+
+        private static string Quote(string text) => "\"" + text.Replace("\\", "\\\\").Replace("\"", "\\\"") + "\"";
+
+        private static string Escape(string text) => SecurityElement.Escape(text) ?? "";
+    }
+}
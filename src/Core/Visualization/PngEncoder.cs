@@ -0,0 +1,92 @@
+using System.IO.Compression;
+using System.Text;
+
+namespace Core.Visualization
+{
+    /// <summary>
+    /// Minimal PNG writer for 8-bit RGB images (no external imaging dependency)
+    /// </summary>
+    internal static class PngEncoder
+    {
+        private static readonly uint[] CrcTable = BuildCrcTable();
+
+        /// <summary>
+        /// Encodes row-major RGB pixel data (3 bytes per pixel)
+        /// </summary>
+        public static byte[] Encode(int width, int height, byte[] rgb)
+        {
+            if (rgb.Length != width * height * 3)
+                throw new ArgumentException("Pixel buffer does not match image size", nameof(rgb));
+
+            using var output = new MemoryStream();
+            output.Write(new byte[] { 0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A });
+
+            var header = new byte[13];
+            WriteBigEndian(header, 0, (uint)width);
+            WriteBigEndian(header, 4, (uint)height);
+            header[8] = 8;  // bit depth
+            header[9] = 2;  // colour type: truecolour
+            WriteChunk(output, "IHDR", header);
+
+            using (var raw = new MemoryStream())
+            {
+                using (var zlib = new ZLibStream(raw, CompressionLevel.Optimal, leaveOpen: true))
+                {
+                    for (int y = 0; y < height; y++)
+                    {
+                        zlib.WriteByte(0);  // filter: none
+                        zlib.Write(rgb, y * width * 3, width * 3);
+                    }
+                }
+                WriteChunk(output, "IDAT", raw.ToArray());
+            }
+
+            WriteChunk(output, "IEND", Array.Empty<byte>());
+            return output.ToArray();
+        }
+
+        private static void WriteChunk(Stream output, string type, byte[] data)
+        {
+            var buffer = new byte[4];
+            WriteBigEndian(buffer, 0, (uint)data.Length);
+            output.Write(buffer);
+
+            byte[] typeBytes = Encoding.ASCII.GetBytes(type);
+            output.Write(typeBytes);
+            output.Write(data);
+
+            uint crc = UpdateCrc(0xFFFFFFFF, typeBytes);
+            crc = UpdateCrc(crc, data) ^ 0xFFFFFFFF;
+            WriteBigEndian(buffer, 0, crc);
+            output.Write(buffer);
+        }
+
+        private static void WriteBigEndian(byte[] buffer, int offset, uint value)
+        {
+            buffer[offset] = (byte)(value >> 24);
+            buffer[offset + 1] = (byte)(value >> 16);
+            buffer[offset + 2] = (byte)(value >> 8);
+            buffer[offset + 3] = (byte)value;
+        }
+
+        private static uint UpdateCrc(uint crc, byte[] data)
+        {
+            foreach (byte b in data)
+                crc = CrcTable[(crc ^ b) & 0xFF] ^ (crc >> 8);
+            return crc;
+        }
+
+        private static uint[] BuildCrcTable()
+        {
+            var table = new uint[256];
+            for (uint n = 0; n < 256; n++)
+            {
+                uint c = n;
+                for (int k = 0; k < 8; k++)
+                    c = (c & 1) != 0 ? 0xEDB88320 ^ (c >> 1) : c >> 1;
+                table[n] = c;
+            }
+            return table;
+        }
+    }
+}
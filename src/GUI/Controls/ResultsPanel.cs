@@ -307,9 +307,10 @@ namespace GUI.Controls
             }
             modelTreeView.Nodes.Add(varsNode);
 
-            // Equations
-            var equationsNode = new TreeNode($"Equations ({modelManager.Equations.Count})");
-            foreach (var equation in modelManager.Equations.OrderBy(e => e.Label ?? e.BaseName))
+            // Equations, including the rows of rules that are not expanded yet
+            var rows = modelManager.MaterializeRows();
+            var equationsNode = new TreeNode($"Equations ({rows.Count})");
+            foreach (var equation in rows.OrderBy(e => e.Label ?? e.BaseName))
             {
                 var eqText = equation.Label ?? equation.BaseName;
                 equationsNode.Nodes.Add(new TreeNode(eqText));
@@ -366,7 +367,7 @@ namespace GUI.Controls
             statisticsTextBox.AppendText($"  Ranges:              {modelManager.Ranges.Count}\n");
             statisticsTextBox.AppendText($"  Index Sets:          {modelManager.IndexSets.Count}\n");
             statisticsTextBox.AppendText($"  Variables:           {modelManager.IndexedVariables.Count}\n");
-            statisticsTextBox.AppendText($"  Equations:           {modelManager.Equations.Count}\n");
+            statisticsTextBox.AppendText($"  Equations:           {modelManager.MaterializeRows().Count}\n");
             statisticsTextBox.AppendText($"  Tuple Schemas:       {modelManager.TupleSchemas.Count}\n");
             statisticsTextBox.AppendText($"  Tuple Sets:          {modelManager.TupleSets.Count}\n");
             statisticsTextBox.AppendText($"  Primitive Sets:      {modelManager.PrimitiveSets.Count}\n");
@@ -348,6 +348,9 @@ namespace GUI
                 // Load data files
                 var dataTexts = config.DataFiles.Select(f => System.IO.File.ReadAllText(f)).ToList();
 
+                // Cases go on top of the data
+                service.Overlays.AddRange(config.OverlayFiles.Select(Core.CaseOverlay.Load));
+
                 // Parse
                 var result = service.ParseModel(modelTexts, dataTexts);
 
@@ -1,11 +1,11 @@
 ﻿using System.Runtime.InteropServices;
-using System.Text.RegularExpressions;
+using Core.Parsing;
 using ModelEditorApp.Extensions;
 
 namespace ModelEditorApp.Services
 {
     /// <summary>
-    /// Handles syntax highlighting for OPL model files
+    /// Handles syntax highlighting for OPL model files, coloring the tokens of ModelLexer
     /// </summary>
     public class SyntaxHighlighter
     {
@@ -26,20 +26,6 @@ namespace ModelEditorApp.Services
         private static readonly Color DirectiveColor = Color.FromArgb(155, 155, 155);
         private static readonly Color DefaultColor = Color.FromArgb(212, 212, 212);
 
-        private static readonly string[] Keywords =
-        [
-            "forall", "sum", "maximize", "minimize", "subject", "to",
-            "dvar", "dexpr", "constraint", "tuple", "key",
-            "range", "in", "execute",
-            "if", "else", "and", "or", "not",
-            "item", "ord", "first", "last", "next", "prev", "card"
-        ];
-
-        private static readonly string[] TypeKeywords =
-        [
-            "int", "float", "bool", "string"
-        ];
-
         // Windows API to prevent painting
         [DllImport("user32.dll")]
         private static extern int SendMessage(IntPtr hWnd, int wMsg, bool wParam, int lParam);
@@ -95,46 +81,16 @@ namespace ModelEditorApp.Services
                 richTextBox.SelectionColor = DefaultColor;
                 richTextBox.SelectionFont = new Font(richTextBox.Font, FontStyle.Regular);
 
-                string text = richTextBox.Text;
-                var highlighted = new HashSet<int>();
-
-                // 1. Block comments: /* ... */
-                HighlightPattern(text, @"/\*.*?\*/", CommentColor, RegexOptions.Singleline, highlighted, FontStyle.Italic);
-
-                // 2. Line comments: // ...
-                HighlightPattern(text, @"//.*$", CommentColor, RegexOptions.Multiline, highlighted, FontStyle.Italic);
-
-                // 3. Strings: "..."
-                HighlightPattern(text, @"""[^""]*""", StringColor, RegexOptions.None, highlighted);
-
-                // 4. External data marker
-                HighlightPattern(text, @"\.\.\.", DirectiveColor, RegexOptions.None, highlighted);
-
-                // 5. Range operator: ..
-                HighlightPattern(text, @"\.\.", OperatorColor, RegexOptions.None, highlighted, FontStyle.Bold);
-
-                // 6. Relational operators
-                HighlightPattern(text, @"(==|!=|<=|>=|&&|\|\||<|>)", OperatorColor, RegexOptions.None, highlighted);
-
-                // 7. Keywords (bold blue)
-                string keywordPattern = @"\b(" + string.Join("|", Keywords) + @")\b";
-                HighlightPattern(text, keywordPattern, KeywordColor, RegexOptions.None, highlighted, FontStyle.Bold);
-
-                // 8. Type keywords (teal, bold)
-                string typePattern = @"\b(" + string.Join("|", TypeKeywords) + @")\b";
-                HighlightPattern(text, typePattern, TypeColor, RegexOptions.None, highlighted, FontStyle.Bold);
-
-                // 9. Sign modifiers on types: float+, float-
-                HighlightPattern(text, @"\b(float|int)[+\-]", TypeColor, RegexOptions.None, highlighted, FontStyle.Bold);
-
-                // 10. Numbers
-                HighlightPattern(text, @"\b\d+(\.\d+)?([eE][+\-]?\d+)?\b", NumberColor, RegexOptions.None, highlighted);
-
-                // 11. Labels: Name[...]: or Name:  (before constraints)
-                HighlightLabelPattern(text, @"^\s*([a-zA-Z][a-zA-Z0-9_]*(?:\[[^\]]*\])*)\s*:", LabelColor, highlighted, FontStyle.Regular, RegexOptions.Multiline);
+                foreach (var token in ModelLexer.Classify(richTextBox.Text))
+                {
+                    var (color, fontStyle) = StyleOf(token);
+                    if (color == DefaultColor)
+                        continue;
 
-                // 12. Brackets and braces
-                HighlightPattern(text, @"[\[\]{}()<>]", BracketColor, RegexOptions.None, highlighted);
+                    richTextBox.Select(token.Offset, token.Length);
+                    richTextBox.SelectionColor = color;
+                    richTextBox.SelectionFont = new Font(richTextBox.Font.FontFamily, richTextBox.Font.Size, fontStyle);
+                }
 
                 // Restore
                 richTextBox.Select(selectionStart, selectionLength);
@@ -169,53 +125,25 @@ namespace ModelEditorApp.Services
             }
         }
 
-        private void HighlightPattern(string text, string pattern, Color color, RegexOptions options,
-            HashSet<int> highlighted, FontStyle fontStyle = FontStyle.Regular)
-        {
-            foreach (Match match in Regex.Matches(text, pattern, options))
-            {
-                bool skip = false;
-                for (int i = match.Index; i < match.Index + match.Length; i++)
-                {
-                    if (highlighted.Contains(i)) { skip = true; break; }
-                }
-                if (skip) continue;
-
-                richTextBox.Select(match.Index, match.Length);
-                richTextBox.SelectionColor = color;
-                richTextBox.SelectionFont = new Font(richTextBox.Font.FontFamily, richTextBox.Font.Size, fontStyle);
-
-                for (int i = match.Index; i < match.Index + match.Length; i++)
-                    highlighted.Add(i);
-            }
-        }
-
-        private void HighlightLabelPattern(string text, string pattern, Color color,
-            HashSet<int> highlighted, FontStyle fontStyle, RegexOptions options)
+        private static (Color Color, FontStyle Style) StyleOf(SyntaxToken token)
         {
-            foreach (Match match in Regex.Matches(text, pattern, options))
+            return token.Kind switch
             {
-                var group = match.Groups[1];
-                string label = group.Value.Split('[')[0].ToLower();
-
-                // Skip if label is a keyword or type
-                if (Keywords.Contains(label) || TypeKeywords.Contains(label))
-                    continue;
-
-                bool skip = false;
-                for (int i = group.Index; i < group.Index + group.Length; i++)
-                {
-                    if (highlighted.Contains(i)) { skip = true; break; }
-                }
-                if (skip) continue;
-
-                richTextBox.Select(group.Index, group.Length);
-                richTextBox.SelectionColor = color;
-                richTextBox.SelectionFont = new Font(richTextBox.Font.FontFamily, richTextBox.Font.Size, fontStyle);
-
-                for (int i = group.Index; i < group.Index + group.Length; i++)
-                    highlighted.Add(i);
-            }
+                SyntaxTokenKind.Comment or SyntaxTokenKind.Docstring => (CommentColor, FontStyle.Italic),
+                SyntaxTokenKind.String => (StringColor, FontStyle.Regular),
+                SyntaxTokenKind.Annotation => (DirectiveColor, FontStyle.Regular),
+                SyntaxTokenKind.Operator when token.Text == "..." => (DirectiveColor, FontStyle.Regular),
+                SyntaxTokenKind.Operator when token.Text == ".." => (OperatorColor, FontStyle.Bold),
+                SyntaxTokenKind.Operator => (OperatorColor, FontStyle.Regular),
+                SyntaxTokenKind.Keyword => (KeywordColor, FontStyle.Bold),
+                SyntaxTokenKind.Type => (TypeColor, FontStyle.Bold),
+                SyntaxTokenKind.Function => (FunctionColor, FontStyle.Regular),
+                SyntaxTokenKind.Number => (NumberColor, FontStyle.Regular),
+                SyntaxTokenKind.Identifier when token.Symbol?.StartsWith("constraint:", StringComparison.Ordinal) == true => (LabelColor, FontStyle.Regular),
+                SyntaxTokenKind.SetLiteral => (BracketColor, FontStyle.Regular),
+                SyntaxTokenKind.Punctuation when token.Text is "(" or ")" or "[" or "]" or "{" or "}" => (BracketColor, FontStyle.Regular),
+                _ => (DefaultColor, FontStyle.Regular)
+            };
         }
     }
 }
\ No newline at end of file
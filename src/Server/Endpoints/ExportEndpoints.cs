@@ -0,0 +1,70 @@
+using Core.Export;
+using Core.Server;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints for the export profiles a model declares with "// @export" annotations:
+    ///   GET /models/{id}/exports             the profiles, with warnings about invalid annotations
+    ///   GET /models/{id}/exports/{profile}   the model written with the profile, as a file download
+    /// A model that does not expand, or a profile needing a case file, is refused with 422.
+    /// Warnings of the export (e.g. rounded numbers) are sent in X-Export-Warning headers.
+    /// </summary>
+    public static class ExportEndpoints
+    {
+        public static IEndpointRouteBuilder MapExportEndpoints(this IEndpointRouteBuilder app)
+        {
+            var group = app.MapGroup("/models/{id}/exports");
+
+            group.MapGet("/", (string id, ModelHost host) =>
+            {
+                if (host.Find(id) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+                var profiles = host.GetExportProfiles(id);
+                return Results.Ok(new
+                {
+                    profiles = profiles.Profiles.Select(p => new
+                    {
+                        name = p.Name,
+                        format = p.Format.ToString().ToLowerInvariant(),
+                        naming = p.Naming.ToString().ToLowerInvariant(),
+                        precision = p.Precision,
+                        ordering = p.Ordering.ToString(),
+                        blocks = p.Blocks,
+                        @case = p.Case,
+                        workarounds = p.Workarounds,
+                        annotation = p.ToString()
+                    }),
+                    warnings = profiles.Warnings
+                });
+            });
+
+            group.MapGet("/{profile}", (string id, string profile, ModelHost host, HttpResponse response, CancellationToken cancellationToken) =>
+            {
+                if (host.Find(id) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+                if (host.GetExportProfiles(id).Find(profile) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' has no export profile '{profile}'" });
+
+                ExportProfileResult result;
+                try
+                {
+                    result = host.Export(id, profile, cancellationToken);
+                }
+                catch (InvalidOperationException ex) when (ex is not AccessDeniedException)
+                {
+                    return Results.UnprocessableEntity(new { error = ex.Message });
+                }
+
+                foreach (string warning in result.Warnings)
+                    response.Headers.Append("X-Export-Warning", warning);
+
+                string contentType = result.Extension.EndsWith(".json") ? "application/json" : "text/plain";
+                return Results.File(System.Text.Encoding.UTF8.GetBytes(result.Text), contentType, id + "-" + profile + result.Extension);
+            });
+
+            return app;
+        }
+    }
+}
@@ -0,0 +1,70 @@
+using Core.Server;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints for published models, the read-only versions production consumers use:
+    ///   POST /models/{id}/publications     publishes the current version (?expectedVersion=N)
+    ///   GET  /models/{id}/publications     the published versions of a model
+    ///   GET  /publications/{pid}           one published model with its model and data text
+    /// A version with errors is refused with 422. Published models never change, so they are
+    /// served with their id as ETag and as immutable; solve them by id through gRPC Solve.
+    /// </summary>
+    public static class PublicationEndpoints
+    {
+        public static IEndpointRouteBuilder MapPublicationEndpoints(this IEndpointRouteBuilder app)
+        {
+            app.MapPost("/models/{id}/publications", async (string id, int? expectedVersion, ModelHost host, CancellationToken cancellationToken) =>
+            {
+                var model = host.Find(id);
+                if (model == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+                if (expectedVersion != null && expectedVersion != model.Version)
+                    return Results.Conflict(new { error = $"Model is at version {model.Version}, expected {expectedVersion}" });
+
+                try
+                {
+                    var published = await host.PublishVersionAsync(id, expectedVersion, cancellationToken);
+                    return Results.Created($"/publications/{published.Id}", Describe(published));
+                }
+                catch (InvalidOperationException ex) when (ex is not AccessDeniedException)
+                {
+                    return Results.UnprocessableEntity(new { error = ex.Message });
+                }
+            });
+
+            app.MapGet("/models/{id}/publications", (string id, ModelHost host) =>
+            {
+                if (host.Find(id) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+                return Results.Ok(host.GetPublished(id).Select(Describe));
+            });
+
+            app.MapGet("/publications/{pid}", (string pid, ModelHost host, HttpResponse response) =>
+            {
+                if (host.Publications.Find(pid) == null)
+                    return Results.NotFound(new { error = $"Published model '{pid}' not found" });
+
+                var published = host.GetPublication(pid);
+                response.Headers.ETag = $"\"{published.Id}\"";
+                response.Headers.CacheControl = "private, max-age=31536000, immutable";
+                return Results.Ok(published);
+            });
+
+            return app;
+        }
+
+        private static object Describe(PublishedModel published) => new
+        {
+            id = published.Id,
+            modelId = published.ModelId,
+            name = published.Name,
+            version = published.Version,
+            publishedAt = published.PublishedAt,
+            publishedBy = published.PublishedBy,
+            fingerprint = published.Fingerprint
+        };
+    }
+}
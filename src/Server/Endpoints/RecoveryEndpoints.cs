@@ -0,0 +1,63 @@
+using Core.Server;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints offering the changes a crashed or drained server did not get to save:
+    ///   GET    /recovery                 recoverable checkpoints (model, versions, interrupted solves)
+    ///   GET    /recovery/{id}            one checkpoint with its model and data text
+    ///   POST   /recovery/{id}/restore    restores it as the model's newest version
+    ///   DELETE /recovery/{id}            discards it
+    /// </summary>
+    public static class RecoveryEndpoints
+    {
+        public static IEndpointRouteBuilder MapRecoveryEndpoints(this IEndpointRouteBuilder app)
+        {
+            var group = app.MapGroup("/recovery");
+
+            group.MapGet("/", async (ModelHost host, CancellationToken cancellationToken) =>
+            {
+                var offers = await host.RecoverAsync(cancellationToken);
+                return Results.Ok(offers.Select(c => new
+                {
+                    id = c.Id,
+                    name = c.Name,
+                    version = c.Version,
+                    savedVersion = c.SavedVersion,
+                    hostedVersion = host.Find(c.Id)?.Version,
+                    changedAt = c.SavedAt,
+                    shutdown = c.Shutdown,
+                    runningSolves = c.RunningSolves.Select(s => new { solver = s.Solver, elapsedSeconds = s.ElapsedSeconds, objectiveValue = s.ObjectiveValue, bestBound = s.BestBound })
+                }));
+            });
+
+            group.MapGet("/{id}", async (string id, ModelHost host, CancellationToken cancellationToken) =>
+            {
+                var checkpoint = (await host.RecoverAsync(cancellationToken)).FirstOrDefault(c => c.Id == id);
+                return checkpoint == null
+                    ? Results.NotFound(new { error = $"No recovered changes for model '{id}'" })
+                    : Results.Ok(checkpoint);
+            });
+
+            group.MapPost("/{id}/restore", async (string id, ModelHost host, CancellationToken cancellationToken) =>
+            {
+                if ((await host.RecoverAsync(cancellationToken)).All(c => c.Id != id))
+                    return Results.NotFound(new { error = $"No recovered changes for model '{id}'" });
+
+                var model = await host.RestoreAsync(id, cancellationToken);
+                return Results.Ok(new { id = model.Id, name = model.Name, version = model.Version, errors = model.Errors });
+            });
+
+            group.MapDelete("/{id}", async (string id, ModelHost host, CancellationToken cancellationToken) =>
+            {
+                if ((await host.RecoverAsync(cancellationToken)).All(c => c.Id != id))
+                    return Results.NotFound(new { error = $"No recovered changes for model '{id}'" });
+
+                await host.DiscardAsync(id, cancellationToken);
+                return Results.NoContent();
+            });
+
+            return app;
+        }
+    }
+}
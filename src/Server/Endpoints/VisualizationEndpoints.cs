@@ -0,0 +1,115 @@
+using Core;
+using Core.Decomposition;
+using Core.Server;
+using Core.Visualization;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints that render model structure for the frontend:
+    ///   GET /models/{id}/spy.svg|spy.png            constraint matrix spy plot
+    ///   GET /models/{id}/heatmap.svg|heatmap.png    family block heatmap
+    ///   GET /models/{id}/graph.dot|graph.graphml    variable–constraint graph (?aggregate=true for families)
+    ///   GET /models/{id}/blocks.dot|blocks.graphml  block hierarchy of the @block annotations
+    ///   GET /models/{id}/sets.dot|sets.graphml      set-dependency graph
+    ///   GET /models/{id}/structure                  detected block structure (block-angular, staircase, ...) as JSON
+    /// The spy plot accepts ?maxSize=N to bound the image size; the spy plot and heatmap accept
+    /// ?arrange=blocks to order rows and columns by the detected blocks.
+    /// </summary>
+    public static class VisualizationEndpoints
+    {
+        public static IEndpointRouteBuilder MapVisualizationEndpoints(this IEndpointRouteBuilder app)
+        {
+            var group = app.MapGroup("/models/{id}");
+
+            group.MapGet("/spy.svg", (string id, int? maxSize, string? arrange, ModelHost host) =>
+                Render(host, id, matrix => Results.Text(Renderer(maxSize).RenderSpySvg(Arrange(matrix, arrange)), "image/svg+xml")));
+
+            group.MapGet("/spy.png", (string id, int? maxSize, string? arrange, ModelHost host) =>
+                Render(host, id, matrix => Results.File(Renderer(maxSize).RenderSpyPng(Arrange(matrix, arrange)), "image/png")));
+
+            group.MapGet("/heatmap.svg", (string id, string? arrange, ModelHost host) =>
+                Render(host, id, matrix => Results.Text(Renderer(null).RenderHeatmapSvg(Arrange(matrix, arrange).GetBlockStructure()), "image/svg+xml")));
+
+            group.MapGet("/heatmap.png", (string id, string? arrange, ModelHost host) =>
+                Render(host, id, matrix => Results.File(Renderer(null).RenderHeatmapPng(Arrange(matrix, arrange).GetBlockStructure()), "image/png")));
+
+            group.MapGet("/structure", (string id, ModelHost host) =>
+                Render(host, id, matrix =>
+                {
+                    var analysis = new BlockStructureDetector().Detect(matrix);
+                    return Results.Ok(new
+                    {
+                        kind = analysis.Kind.ToString(),
+                        blocks = analysis.Blocks.Select(b => new { number = b.Number, rows = b.Rows, columns = b.Columns }),
+                        linkingRows = analysis.LinkingRows,
+                        linkingColumns = analysis.LinkingColumns,
+                        rowFamilies = analysis.RowFamilyBlocks,
+                        columnFamilies = analysis.ColumnFamilyBlocks
+                    });
+                }));
+
+            group.MapGet("/graph.dot", (string id, bool? aggregate, ModelHost host) =>
+                Render(host, id, matrix => Results.Text(
+                    new ModelGraphExporter(matrix) { AggregateFamilies = aggregate ?? false }.ExportVariableConstraintDot(),
+                    "text/vnd.graphviz")));
+
+            group.MapGet("/graph.graphml", (string id, bool? aggregate, ModelHost host) =>
+                Render(host, id, matrix => Results.Text(
+                    new ModelGraphExporter(matrix) { AggregateFamilies = aggregate ?? false }.ExportVariableConstraintGraphMl(),
+                    "application/graphml+xml")));
+
+            group.MapGet("/blocks.dot", (string id, ModelHost host) =>
+                RenderText(host, id, text => Results.Text(ModelGraphExporter.ExportBlockHierarchyDot(text), "text/vnd.graphviz")));
+
+            group.MapGet("/blocks.graphml", (string id, ModelHost host) =>
+                RenderText(host, id, text => Results.Text(ModelGraphExporter.ExportBlockHierarchyGraphMl(text), "application/graphml+xml")));
+
+            group.MapGet("/sets.dot", (string id, ModelHost host) =>
+                RenderText(host, id, text => Results.Text(ModelGraphExporter.ExportSetDependencyDot(text), "text/vnd.graphviz")));
+
+            group.MapGet("/sets.graphml", (string id, ModelHost host) =>
+                RenderText(host, id, text => Results.Text(ModelGraphExporter.ExportSetDependencyGraphMl(text), "application/graphml+xml")));
+
+            return app;
+        }
+
+        private static ConstraintMatrix Arrange(ConstraintMatrix matrix, string? arrange)
+        {
+            return arrange == "blocks" ? new BlockStructureDetector().Detect(matrix).Arrange(matrix) : matrix;
+        }
+
+        private static MatrixRenderer Renderer(int? maxSize)
+        {
+            var options = new MatrixRenderOptions();
+            if (maxSize is > 0)
+                options.MaxSize = Math.Min(maxSize.Value, 4000);
+            return new MatrixRenderer(options);
+        }
+
+        private static IResult Render(ModelHost host, string id, Func<ConstraintMatrix, IResult> render)
+        {
+            if (host.Find(id) == null)
+                return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+            var manager = host.Expand(id, out ParseResult parseResult);
+            if (parseResult.HasErrors)
+                return Results.UnprocessableEntity(new { errors = parseResult.Errors });
+
+            return render(ConstraintMatrix.Build(manager));
+        }
+
+        /// <summary>
+        /// Renders from the model text alone; the block and set graphs need neither data nor expansion
+        /// </summary>
+        private static IResult RenderText(ModelHost host, string id, Func<string, IResult> render)
+        {
+            var model = host.Find(id);
+            if (model == null)
+                return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+            host.Demand(id, Permission.Read);
+            return render(model.ModelText);
+        }
+    }
+}
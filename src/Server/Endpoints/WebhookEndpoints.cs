@@ -0,0 +1,130 @@
+using Core.Server;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints to manage solve webhooks:
+    ///   POST   /webhooks          register {"url", "events": ["solveCompleted", ...], "secret", "modelId"}
+    ///   GET    /webhooks          the registered webhooks (without their secrets)
+    ///   GET    /webhooks/deliveries  the most recent deliveries and their outcome
+    ///   DELETE /webhooks/{id}     unregister
+    /// Watching one model needs read access to it; watching every model ("*", the default) needs admin.
+    /// The endpoints answer 404 unless Webhooks:Enabled is set; URLs outside Webhooks:AllowedHosts
+    /// or at loopback, link-local and private addresses are rejected with 400.
+    /// </summary>
+    public static class WebhookEndpoints
+    {
+        public class WebhookRequest
+        {
+            public string Url { get; set; } = "";
+            public List<string> Events { get; set; } = new List<string>();
+            public string Secret { get; set; } = "";
+            public string ModelId { get; set; } = "*";
+        }
+
+        public static IEndpointRouteBuilder MapWebhookEndpoints(this IEndpointRouteBuilder app)
+        {
+            var group = app.MapGroup("/webhooks");
+
+            group.MapPost("/", (WebhookRequest request, ModelHost host) =>
+            {
+                var webhooks = host.Webhooks;
+                if (webhooks == null)
+                    return Results.NotFound(new { error = "Webhooks are not enabled on this server" });
+
+                host.Demand(request.ModelId, request.ModelId == "*" ? Permission.Admin : Permission.Read);
+                if (request.ModelId != "*" && host.Find(request.ModelId) == null)
+                    return Results.NotFound(new { error = $"Model '{request.ModelId}' not found" });
+
+                var events = WebhookEvents.None;
+                foreach (string name in request.Events.DefaultIfEmpty("all"))
+                {
+                    if (!Enum.TryParse<WebhookEvents>(name, ignoreCase: true, out var parsed))
+                        return Results.BadRequest(new { error = $"Unknown event '{name}'; use solveCompleted, solveFailed, milestone or all" });
+                    events |= parsed;
+                }
+
+                if (!Uri.TryCreate(request.Url, UriKind.Absolute, out var url))
+                    return Results.BadRequest(new { error = $"'{request.Url}' is not an absolute URL" });
+
+                try
+                {
+                    var subscription = webhooks.Register(new WebhookSubscription
+                    {
+                        Url = url,
+                        Events = events,
+                        Secret = request.Secret,
+                        ModelId = request.ModelId,
+                        Owner = AccessContext.Current?.Subject
+                    });
+                    return Results.Created($"/webhooks/{subscription.Id}", Describe(subscription));
+                }
+                catch (ArgumentException ex)
+                {
+                    return Results.BadRequest(new { error = ex.Message });
+                }
+            });
+
+            group.MapGet("/", (ModelHost host) =>
+                host.Webhooks == null
+                    ? Results.NotFound(new { error = "Webhooks are not enabled on this server" })
+                    : Results.Ok(host.Webhooks.Subscriptions.Where(s => CanSee(host, s)).Select(Describe)));
+
+            group.MapGet("/deliveries", (ModelHost host) =>
+            {
+                if (host.Webhooks == null)
+                    return Results.NotFound(new { error = "Webhooks are not enabled on this server" });
+
+                var visible = host.Webhooks.Subscriptions.Where(s => CanSee(host, s)).Select(s => s.Id).ToHashSet(StringComparer.Ordinal);
+                return Results.Ok(host.Webhooks.History.Where(d => visible.Contains(d.SubscriptionId)).Select(d => new
+                {
+                    webhook = d.SubscriptionId,
+                    @event = d.Event,
+                    delivery = d.Delivery,
+                    attempts = d.Attempts,
+                    status = (int?)d.StatusCode,
+                    delivered = d.Delivered,
+                    error = d.Error
+                }));
+            });
+
+            group.MapDelete("/{id}", (string id, ModelHost host) =>
+            {
+                var subscription = host.Webhooks?.Subscriptions.FirstOrDefault(s => s.Id == id);
+                if (subscription == null || !CanSee(host, subscription))
+                    return Results.NotFound(new { error = $"Webhook '{id}' not found" });
+
+                host.Demand(subscription.ModelId, subscription.ModelId == "*" ? Permission.Admin : Permission.Read);
+                host.Webhooks!.Unregister(id);
+                return Results.NoContent();
+            });
+
+            return app;
+        }
+
+        /// <summary>
+        /// Callers see the webhooks they registered; admins see all. Without an access policy every
+        /// webhook is visible; with one an unauthenticated caller sees none.
+        /// </summary>
+        private static bool CanSee(ModelHost host, WebhookSubscription subscription)
+        {
+            if (host.Policy == null)
+                return true;
+
+            var caller = AccessContext.Current;
+            if (caller == null)
+                return false;
+            return subscription.Owner == caller.Subject || host.Policy.IsAllowed(caller, "*", Permission.Admin);
+        }
+
+        private static object Describe(WebhookSubscription subscription) => new
+        {
+            id = subscription.Id,
+            url = subscription.Url.ToString(),
+            events = subscription.Events.ToString(),
+            modelId = subscription.ModelId,
+            signed = subscription.Secret.Length > 0,
+            owner = subscription.Owner
+        };
+    }
+}
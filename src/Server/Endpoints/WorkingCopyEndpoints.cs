@@ -0,0 +1,81 @@
+using Core.Server;
+
+namespace ModelEditorServer.Endpoints
+{
+    /// <summary>
+    /// HTTP endpoints for per-user working copies of shared models:
+    ///   POST /models/{id}/branch     the caller's working copy, created on first use
+    ///   GET  /models/{id}/copies     the working copies of a shared model the caller may see
+    ///   POST /models/{id}/publish    merges a working copy into its shared model (?overwrite=true lets
+    ///                                the copy win conflicts, ?expectedVersion=N guards the shared version)
+    ///   POST /models/{id}/update     merges the shared model's newer versions into a working copy
+    /// A refused publish answers 409 with the conflicting statements, or 422 with the new parse errors.
+    /// </summary>
+    public static class WorkingCopyEndpoints
+    {
+        public static IEndpointRouteBuilder MapWorkingCopyEndpoints(this IEndpointRouteBuilder app)
+        {
+            var group = app.MapGroup("/models/{id}");
+
+            group.MapPost("/branch", (string id, ModelHost host) =>
+            {
+                if (host.Find(id) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+                try
+                {
+                    return Results.Ok(Describe(host.Branch(id)));
+                }
+                catch (InvalidOperationException ex)
+                {
+                    return Results.BadRequest(new { error = ex.Message });
+                }
+            });
+
+            group.MapGet("/copies", (string id, ModelHost host) =>
+            {
+                if (host.Find(id) == null)
+                    return Results.NotFound(new { error = $"Model '{id}' not found" });
+
+                return Results.Ok(host.GetWorkingCopies(id).Select(Describe));
+            });
+
+            group.MapPost("/publish", (string id, bool? overwrite, int? expectedVersion, ModelHost host) =>
+                Merge(host, id, () => host.Publish(id, overwrite ?? false, expectedVersion)));
+
+            group.MapPost("/update", (string id, ModelHost host) =>
+                Merge(host, id, () => host.Update(id)));
+
+            return app;
+        }
+
+        private static IResult Merge(ModelHost host, string id, Func<WorkingCopyResult> merge)
+        {
+            var copy = host.Find(id);
+            if (copy == null)
+                return Results.NotFound(new { error = $"Model '{id}' not found" });
+            if (copy.BranchOf == null)
+                return Results.BadRequest(new { error = $"Model '{id}' is not a working copy" });
+            if (host.Find(copy.BranchOf) == null)
+                return Results.NotFound(new { error = $"Model '{copy.BranchOf}' not found" });
+
+            var result = merge();
+            var body = new { success = result.Success, version = result.Version, changed = result.Changed, conflicts = result.Conflicts, errors = result.Errors };
+            if (result.Success)
+                return Results.Ok(body);
+            if (result.Errors.Count > 0)
+                return Results.UnprocessableEntity(body);
+            return Results.Conflict(body);
+        }
+
+        private static object Describe(HostedModel copy) => new
+        {
+            id = copy.Id,
+            name = copy.Name,
+            owner = copy.Owner,
+            version = copy.Version,
+            branchOf = copy.BranchOf,
+            baseVersion = copy.BaseVersion
+        };
+    }
+}
@@ -0,0 +1,228 @@
+using System.Net;
+using System.Text.RegularExpressions;
+using Core.Models;
+using Core.Server;
+using Core.Services;
+using Core.Storage;
+using Microsoft.Data.Sqlite;
+using ModelEditorServer.Endpoints;
+using ModelEditorServer.Security;
+using ModelEditorServer.Services;
+using OpenTelemetry.Metrics;
+using OpenTelemetry.Resources;
+using OpenTelemetry.Trace;
+
+var builder = WebApplication.CreateBuilder(args);
+
+// Authentication is enabled when an OIDC authority or development tokens are configured.
+// Without either the server runs open (single-user/local mode), which is only allowed on
+// loopback addresses unless AllowAnonymous says otherwise.
+var authentication = builder.Configuration.GetSection("Authentication");
+var oidc = authentication.GetSection("Oidc").Get<OidcOptions>();
+var developmentTokens = authentication.GetSection("DevelopmentTokens").Get<List<DevelopmentToken>>() ?? new List<DevelopmentToken>();
+bool authenticationEnabled = !string.IsNullOrWhiteSpace(oidc?.Authority) || developmentTokens.Count > 0;
+if (!authenticationEnabled && !authentication.GetValue("AllowAnonymous", false))
+{
+    var exposed = ListenUrls(builder.Configuration).Where(url => !IsLoopback(url)).ToList();
+    if (exposed.Count > 0)
+    {
+        throw new InvalidOperationException(
+            $"Refusing to serve {string.Join(", ", exposed)} without authentication: configure Authentication:Oidc or " +
+            "Authentication:DevelopmentTokens, listen on localhost only, or set Authentication:AllowAnonymous to true");
+    }
+}
+bool requireRevisions = builder.Configuration.GetValue("Concurrency:RequireRevisions", true);
+var storage = CreateStorage(builder.Configuration.GetSection("Storage"));
+var limits = builder.Configuration.GetSection("Limits").Get<ModelLimits>() ?? ModelLimits.Shared;
+var webhookOptions = builder.Configuration.GetSection("Webhooks");
+bool metricsEnabled = builder.Configuration.GetValue("Metrics:Enabled", true);
+
+// Unsaved models are autosaved to the recovery path and checkpointed again on shutdown; an
+// empty path turns this off
+var recoveryOptions = builder.Configuration.GetSection("Recovery");
+string? recoveryPath = recoveryOptions["Path"];
+var recovery = string.IsNullOrWhiteSpace(recoveryPath) ? null : new ModelRecovery(recoveryPath);
+
+// Published models are write-once files under this path; without one they last until the server stops
+string? publicationsPath = builder.Configuration["Publications:Path"];
+var publications = string.IsNullOrWhiteSpace(publicationsPath) ? new PublishedModelStore() : new PublishedModelStore(publicationsPath);
+
+// Spans and metrics of parse/validate/export/solve are exported over OTLP when an endpoint is
+// configured. Independently of that, Metrics:Enabled serves the same metrics for Prometheus at /metrics.
+string? otlpEndpoint = builder.Configuration["Telemetry:OtlpEndpoint"];
+if (!string.IsNullOrWhiteSpace(otlpEndpoint))
+{
+    builder.Services.AddOpenTelemetry()
+        .ConfigureResource(resource => resource.AddService("modeleditor-server"))
+        .WithTracing(tracing => tracing
+            .AddSource(ModelTelemetry.Name)
+            .AddOtlpExporter(options => options.Endpoint = new Uri(otlpEndpoint)))
+        .WithMetrics(metrics => metrics
+            .AddMeter(ModelTelemetry.Name)
+            .AddOtlpExporter(options => options.Endpoint = new Uri(otlpEndpoint)));
+}
+
+if (authenticationEnabled)
+{
+    if (!string.IsNullOrWhiteSpace(oidc?.Authority))
+    {
+        builder.Services.AddSingleton<ITokenValidator>(new OidcTokenValidator(oidc));
+    }
+    else
+    {
+        var validator = new StaticTokenValidator();
+        foreach (var token in developmentTokens)
+            validator.Add(token.Token, new UserPrincipal { Subject = token.Subject, Roles = new HashSet<string>(token.Roles, StringComparer.OrdinalIgnoreCase) });
+        builder.Services.AddSingleton<ITokenValidator>(validator);
+    }
+
+    var policy = new AccessPolicy();
+    string adminRole = authentication["AdminRole"] ?? "modeleditor-admin";
+    policy.Grant(new AccessGrant { Subject = $"role:{adminRole}", ModelId = "*", Permissions = Permission.Admin });
+
+    // Entity restrictions on all models, e.g. { "Tag": "prices", "Role": "market" }
+    foreach (var restriction in authentication.GetSection("Restrictions").Get<List<EntityRestriction>>() ?? new List<EntityRestriction>())
+        policy.Restrict(restriction);
+
+    builder.Services.AddGrpc(options => options.Interceptors.Add<AuthInterceptor>());
+    builder.Services.AddSingleton(sp => new ModelHost
+    {
+        Policy = policy,
+        RequireRevisions = requireRevisions,
+        Storage = storage,
+        Limits = limits,
+        Logger = sp.GetRequiredService<ILogger<ModelHost>>(),
+        Webhooks = CreateWebhooks(webhookOptions, sp.GetRequiredService<ILogger<WebhookNotifier>>()),
+        Recovery = recovery,
+        Publications = publications
+    });
+}
+else
+{
+    builder.Services.AddGrpc();
+    builder.Services.AddSingleton(sp => new ModelHost
+    {
+        RequireRevisions = requireRevisions,
+        Storage = storage,
+        Limits = limits,
+        Logger = sp.GetRequiredService<ILogger<ModelHost>>(),
+        Webhooks = CreateWebhooks(webhookOptions, sp.GetRequiredService<ILogger<WebhookNotifier>>()),
+        Recovery = recovery,
+        Publications = publications
+    });
+}
+
+if (recovery != null)
+{
+    var autosaveInterval = recoveryOptions.GetValue("AutosaveInterval", TimeSpan.FromSeconds(30));
+    var shutdownGrace = recoveryOptions.GetValue("ShutdownGrace", TimeSpan.FromSeconds(20));
+    builder.Services.Configure<HostOptions>(options => options.ShutdownTimeout = shutdownGrace + TimeSpan.FromSeconds(10));
+    builder.Services.AddHostedService(sp => new RecoveryService(sp.GetRequiredService<ModelHost>(), autosaveInterval, shutdownGrace));
+}
+
+if (metricsEnabled)
+    builder.Services.AddSingleton(sp => new ServerMetrics(sp.GetRequiredService<ModelHost>()));
+
+var app = builder.Build();
+
+if (storage != null)
+{
+    var host = app.Services.GetRequiredService<ModelHost>();
+    await host.LoadAsync();
+    app.Lifetime.ApplicationStopping.Register(() => host.FlushAsync().GetAwaiter().GetResult());
+}
+
+// Deliveries still retrying get their chance before the process exits
+var webhooks = app.Services.GetRequiredService<ModelHost>().Webhooks;
+if (webhooks != null)
+    app.Lifetime.ApplicationStopping.Register(() => webhooks.FlushAsync().GetAwaiter().GetResult());
+
+// Routing runs first so AuthMiddleware can tell gRPC methods from the HTTP endpoints
+app.UseRouting();
+if (authenticationEnabled)
+    app.UseMiddleware<AuthMiddleware>();
+
+app.MapGrpcService<ModelEditorGrpcService>();
+app.MapVisualizationEndpoints();
+app.MapWebhookEndpoints();
+app.MapWorkingCopyEndpoints();
+app.MapPublicationEndpoints();
+app.MapExportEndpoints();
+if (recovery != null)
+    app.MapRecoveryEndpoints();
+if (metricsEnabled)
+{
+    // Resolved now so the listener counts requests and solves from the start, not from the first scrape
+    var metrics = app.Services.GetRequiredService<ServerMetrics>();
+    app.MapGet("/metrics", () => Results.Text(metrics.Scrape(), ServerMetrics.ContentType));
+}
+app.MapGet("/", () => "ModelEditor server. Connect with a gRPC client (see Protos/modeleditor.proto).");
+
+app.Run();
+
+// Addresses Kestrel listens on: its configured endpoints, or the "urls" setting
+// (ASPNETCORE_URLS), or the default of http://localhost:5000
+static List<string> ListenUrls(IConfiguration configuration)
+{
+    var urls = configuration.GetSection("Kestrel:Endpoints").GetChildren()
+        .Select(endpoint => endpoint["Url"])
+        .Where(url => !string.IsNullOrWhiteSpace(url))
+        .Select(url => url!)
+        .ToList();
+    if (urls.Count == 0)
+        urls.AddRange((configuration["urls"] ?? "http://localhost:5000").Split(';', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries));
+    return urls;
+}
+
+// Wildcard hosts ("*", "+", 0.0.0.0) listen on every interface
+static bool IsLoopback(string url)
+{
+    var match = Regex.Match(url, @"^\w+://(\[[^\]]*\]|[^:/]+)");
+    if (!match.Success)
+        return false;
+    string host = match.Groups[1].Value.Trim('[', ']');
+    return host.Equals("localhost", StringComparison.OrdinalIgnoreCase) ||
+        IPAddress.TryParse(host, out var address) && IPAddress.IsLoopback(address);
+}
+
+// "filesystem", "s3" or "sqlite"; none keeps models in memory only
+static IModelStorage? CreateStorage(IConfigurationSection section)
+{
+    string provider = section["Provider"] ?? "";
+    return provider.ToLowerInvariant() switch
+    {
+        "" or "memory" => null,
+        "filesystem" => new FileSystemModelStorage(section["Path"] ?? "models"),
+        "s3" => new S3ModelStorage(section.GetSection("S3").Get<S3StorageOptions>() ?? new S3StorageOptions()),
+        "sqlite" => new SqliteModelStorage(() => new SqliteConnection(section["ConnectionString"] ?? "Data Source=models.db")),
+        _ => throw new InvalidOperationException($"Unknown storage provider '{provider}'")
+    };
+}
+
+// Webhooks are off unless "Enabled" is true, since registering one makes the server post to a
+// URL of the caller's choice; registrations live in memory until the server stops
+static WebhookNotifier? CreateWebhooks(IConfigurationSection section, ILogger logger)
+{
+    if (!section.GetValue("Enabled", false))
+        return null;
+
+    var notifier = new WebhookNotifier
+    {
+        Logger = logger,
+        AllowedHosts = section.GetSection("AllowedHosts").Get<List<string>>() ?? new List<string>(),
+        AllowPrivateNetworks = section.GetValue("AllowPrivateNetworks", false)
+    };
+    notifier.MaxAttempts = section.GetValue("MaxAttempts", notifier.MaxAttempts);
+    notifier.RetryDelay = section.GetValue("RetryDelay", notifier.RetryDelay);
+    var milestones = section.GetSection("GapMilestones").Get<List<double>>();
+    if (milestones != null)
+        notifier.GapMilestones = milestones;
+    return notifier;
+}
+
+internal class DevelopmentToken
+{
+    public string Token { get; set; } = "";
+    public string Subject { get; set; } = "";
+    public List<string> Roles { get; set; } = new List<string>();
+}
@@ -0,0 +1,73 @@
+using Core.Server;
+using Grpc.Core;
+using Grpc.Core.Interceptors;
+
+namespace ModelEditorServer.Security
+{
+    /// <summary>
+    /// Authenticates every gRPC call from its "authorization: Bearer &lt;token&gt;" header, runs the
+    /// handler inside an AccessContext scope for the caller and maps access errors to gRPC status codes
+    /// </summary>
+    public class AuthInterceptor : Interceptor
+    {
+        private readonly ITokenValidator validator;
+
+        public AuthInterceptor(ITokenValidator validator)
+        {
+            this.validator = validator;
+        }
+
+        public override async Task<TResponse> UnaryServerHandler<TRequest, TResponse>(
+            TRequest request, ServerCallContext context, UnaryServerMethod<TRequest, TResponse> continuation)
+        {
+            using var scope = await AuthenticateAsync(context);
+            return await Guard(() => continuation(request, context));
+        }
+
+        public override async Task<TResponse> ClientStreamingServerHandler<TRequest, TResponse>(
+            IAsyncStreamReader<TRequest> requestStream, ServerCallContext context, ClientStreamingServerMethod<TRequest, TResponse> continuation)
+        {
+            using var scope = await AuthenticateAsync(context);
+            return await Guard(() => continuation(requestStream, context));
+        }
+
+        public override async Task ServerStreamingServerHandler<TRequest, TResponse>(
+            TRequest request, IServerStreamWriter<TResponse> responseStream, ServerCallContext context, ServerStreamingServerMethod<TRequest, TResponse> continuation)
+        {
+            using var scope = await AuthenticateAsync(context);
+            await Guard(async () => { await continuation(request, responseStream, context); return true; });
+        }
+
+        public override async Task DuplexStreamingServerHandler<TRequest, TResponse>(
+            IAsyncStreamReader<TRequest> requestStream, IServerStreamWriter<TResponse> responseStream, ServerCallContext context, DuplexStreamingServerMethod<TRequest, TResponse> continuation)
+        {
+            using var scope = await AuthenticateAsync(context);
+            await Guard(async () => { await continuation(requestStream, responseStream, context); return true; });
+        }
+
+        private async Task<IDisposable> AuthenticateAsync(ServerCallContext context)
+        {
+            string? header = context.RequestHeaders.GetValue("authorization");
+            if (header == null || !header.StartsWith("Bearer ", StringComparison.OrdinalIgnoreCase))
+                throw new RpcException(new Status(StatusCode.Unauthenticated, "Missing bearer token"));
+
+            var result = await validator.ValidateAsync(header.Substring("Bearer ".Length).Trim(), context.CancellationToken);
+            if (!result.Success)
+                throw new RpcException(new Status(StatusCode.Unauthenticated, result.Error ?? "Invalid token"));
+
+            return AccessContext.BeginScope(result.Principal!);
+        }
+
+        private static async Task<T> Guard<T>(Func<Task<T>> call)
+        {
+            try
+            {
+                return await call();
+            }
+            catch (AccessDeniedException ex)
+            {
+                throw new RpcException(new Status(StatusCode.PermissionDenied, ex.Message));
+            }
+        }
+    }
+}
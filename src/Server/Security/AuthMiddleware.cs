@@ -0,0 +1,61 @@
+using Core.Server;
+using Grpc.AspNetCore.Server;
+
+namespace ModelEditorServer.Security
+{
+    /// <summary>
+    /// Bearer-token authentication for the plain HTTP endpoints (gRPC calls use AuthInterceptor).
+    /// The banner and the Prometheus /metrics endpoint stay open so scrapers need no token. A call
+    /// is left to the interceptor only when routing matched it to a gRPC method, never on a header
+    /// the client chose, so the middleware must run after UseRouting.
+    /// </summary>
+    public class AuthMiddleware
+    {
+        private readonly RequestDelegate next;
+
+        public AuthMiddleware(RequestDelegate next)
+        {
+            this.next = next;
+        }
+
+        public async Task InvokeAsync(HttpContext context, ITokenValidator validator)
+        {
+            if (context.GetEndpoint()?.Metadata.GetMetadata<GrpcMethodMetadata>() != null ||
+                context.Request.Path == "/" || context.Request.Path == "/metrics")
+            {
+                await next(context);
+                return;
+            }
+
+            string? header = context.Request.Headers.Authorization;
+            if (header == null || !header.StartsWith("Bearer ", StringComparison.OrdinalIgnoreCase))
+            {
+                await Reject(context, StatusCodes.Status401Unauthorized, "Missing bearer token");
+                return;
+            }
+
+            var result = await validator.ValidateAsync(header.Substring("Bearer ".Length).Trim(), context.RequestAborted);
+            if (!result.Success)
+            {
+                await Reject(context, StatusCodes.Status401Unauthorized, result.Error ?? "Invalid token");
+                return;
+            }
+
+            using var scope = AccessContext.BeginScope(result.Principal!);
+            try
+            {
+                await next(context);
+            }
+            catch (AccessDeniedException ex)
+            {
+                await Reject(context, StatusCodes.Status403Forbidden, ex.Message);
+            }
+        }
+
+        private static Task Reject(HttpContext context, int statusCode, string error)
+        {
+            context.Response.StatusCode = statusCode;
+            return context.Response.WriteAsJsonAsync(new { error });
+        }
+    }
+}
@@ -0,0 +1,77 @@
+using Core.Server;
+using Microsoft.IdentityModel.JsonWebTokens;
+using Microsoft.IdentityModel.Protocols;
+using Microsoft.IdentityModel.Protocols.OpenIdConnect;
+
+namespace ModelEditorServer.Security
+{
+    public class OidcOptions
+    {
+        /// <summary>
+        /// Issuer URL; the discovery document is read from {Authority}/.well-known/openid-configuration
+        /// </summary>
+        public string Authority { get; set; } = "";
+
+        /// <summary>
+        /// Expected audience (client id), or null to skip the audience check
+        /// </summary>
+        public string? Audience { get; set; }
+
+        public string SubjectClaim { get; set; } = "sub";
+        public string NameClaim { get; set; } = "name";
+        public string RoleClaim { get; set; } = "roles";
+        public bool RequireHttps { get; set; } = true;
+    }
+
+    /// <summary>
+    /// Validates JWTs issued by an OpenID Connect provider against its published signing keys
+    /// </summary>
+    public class OidcTokenValidator : ITokenValidator
+    {
+        private readonly OidcOptions options;
+        private readonly ConfigurationManager<OpenIdConnectConfiguration> configurationManager;
+        private readonly JsonWebTokenHandler handler = new JsonWebTokenHandler();
+
+        public OidcTokenValidator(OidcOptions options)
+        {
+            if (string.IsNullOrWhiteSpace(options.Authority))
+                throw new InvalidOperationException("OIDC authority is not configured");
+
+            this.options = options;
+            configurationManager = new ConfigurationManager<OpenIdConnectConfiguration>(
+                $"{options.Authority.TrimEnd('/')}/.well-known/openid-configuration",
+                new OpenIdConnectConfigurationRetriever(),
+                new HttpDocumentRetriever { RequireHttps = options.RequireHttps });
+        }
+
+        public async Task<TokenValidationResult> ValidateAsync(string token, CancellationToken cancellationToken = default)
+        {
+            var configuration = await configurationManager.GetConfigurationAsync(cancellationToken);
+            var parameters = new Microsoft.IdentityModel.Tokens.TokenValidationParameters
+            {
+                ValidIssuer = configuration.Issuer,
+                ValidateAudience = options.Audience != null,
+                ValidAudience = options.Audience,
+                IssuerSigningKeys = configuration.SigningKeys,
+                NameClaimType = options.NameClaim,
+                RoleClaimType = options.RoleClaim
+            };
+
+            var result = await handler.ValidateTokenAsync(token, parameters);
+            if (!result.IsValid)
+                return TokenValidationResult.Invalid(result.Exception?.Message ?? "Invalid token");
+
+            var identity = result.ClaimsIdentity;
+            string? subject = identity.FindFirst(options.SubjectClaim)?.Value;
+            if (string.IsNullOrEmpty(subject))
+                return TokenValidationResult.Invalid($"Token has no '{options.SubjectClaim}' claim");
+
+            return TokenValidationResult.Valid(new UserPrincipal
+            {
+                Subject = subject,
+                DisplayName = identity.FindFirst(options.NameClaim)?.Value ?? subject,
+                Roles = new HashSet<string>(identity.FindAll(options.RoleClaim).Select(c => c.Value), StringComparer.OrdinalIgnoreCase)
+            });
+        }
+    }
+}
@@ -0,0 +1,329 @@
+using System.Threading.Channels;
+using Core.Server;
+using Core.Services;
+using Core.Solving;
+using Grpc.Core;
+using ModelEditorServer.Protos;
+
+namespace ModelEditorServer.Services
+{
+    /// <summary>
+    /// gRPC front end over ModelHost
+    /// </summary>
+    public class ModelEditorGrpcService : ModelEditor.ModelEditorBase
+    {
+        private readonly ModelHost host;
+        private readonly ILogger<ModelEditorGrpcService> logger;
+
+        public ModelEditorGrpcService(ModelHost host, ILogger<ModelEditorGrpcService> logger)
+        {
+            this.host = host;
+            this.logger = logger;
+        }
+
+        public override Task<ModelInfo> CreateModel(CreateModelRequest request, ServerCallContext context)
+        {
+            try
+            {
+                var model = host.Create(request.Name, request.ModelText, request.DataText);
+                return Task.FromResult(ProtoMapper.ToInfo(model));
+            }
+            catch (Core.Models.ModelLimitException ex)
+            {
+                throw new RpcException(new Status(StatusCode.ResourceExhausted, ex.Message));
+            }
+        }
+
+        public override Task<ListModelsResponse> ListModels(ListModelsRequest request, ServerCallContext context)
+        {
+            var response = new ListModelsResponse();
+            response.Models.AddRange(host.List().Select(ProtoMapper.ToInfo));
+            return Task.FromResult(response);
+        }
+
+        public override Task<ModelDocument> GetModel(ModelRef request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            host.Demand(model.Id, Permission.Read);
+            return Task.FromResult(new ModelDocument
+            {
+                Info = ProtoMapper.ToInfo(model),
+                ModelText = model.ModelText,
+                DataText = model.DataText,
+                Sequence = model.Sequence
+            });
+        }
+
+        public override Task<DeleteModelResponse> DeleteModel(ModelRef request, ServerCallContext context)
+        {
+            return Task.FromResult(new DeleteModelResponse { Deleted = host.Delete(request.ModelId) });
+        }
+
+        public override Task<ModelInfo> SetData(SetDataRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            try
+            {
+                host.SetData(model.Id, request.DataText);
+            }
+            catch (Core.Models.ModelLimitException ex)
+            {
+                throw new RpcException(new Status(StatusCode.ResourceExhausted, ex.Message));
+            }
+            return Task.FromResult(ProtoMapper.ToInfo(model));
+        }
+
+        public override Task<ListEntitiesResponse> ListEntities(ModelRef request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            var response = new ListEntitiesResponse();
+            response.Entities.AddRange(host.GetEntities(model.Id).Select(ProtoMapper.ToProto));
+            return Task.FromResult(response);
+        }
+
+        public override Task<EntityAck> UpsertEntity(UpsertEntityRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            var result = host.ApplyEntity(model.Id, ToDefinition(request.Entity), expectedRevision: request.HasExpectedRevision ? request.ExpectedRevision : null);
+            return Task.FromResult(ProtoMapper.ToAck(result));
+        }
+
+        public override Task<EntityAck> RemoveEntity(RemoveEntityRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            var result = host.RemoveEntity(model.Id, request.Key, request.HasExpectedRevision ? request.ExpectedRevision : null);
+            return Task.FromResult(ProtoMapper.ToAck(result));
+        }
+
+        public override async Task UploadEntities(
+            IAsyncStreamReader<UploadEntitiesRequest> requestStream,
+            IServerStreamWriter<UploadEntitiesResponse> responseStream,
+            ServerCallContext context)
+        {
+            HostedModel? model = null;
+            int count = 0;
+
+            await foreach (var request in requestStream.ReadAllAsync(context.CancellationToken))
+            {
+                model ??= GetModel(request.ModelId);
+                if (request.ModelId != model.Id)
+                    throw new RpcException(new Status(StatusCode.InvalidArgument, "All uploaded entities must target the same model"));
+
+                var definition = ToDefinition(request.Entity);
+                EntityUpdateResult result;
+                try
+                {
+                    result = host.ApplyEntity(model.Id, definition, validate: false,
+                        expectedRevision: request.HasExpectedRevision ? request.ExpectedRevision : null);
+                }
+                catch (AccessDeniedException ex)
+                {
+                    // Reject the entity but keep the stream open for the rest of the batch
+                    result = new EntityUpdateResult { Key = definition.Key, Version = model.Version, Errors = { ex.Message } };
+                }
+
+                await responseStream.WriteAsync(new UploadEntitiesResponse { Sequence = request.Sequence, Ack = ProtoMapper.ToAck(result) });
+                count++;
+            }
+
+            if (model == null)
+                return;
+
+            var errors = host.Validate(model.Id);
+            logger.LogEntitiesUploaded(model.Id, count, errors.Count);
+
+            var final = new EntityAck { Success = errors.Count == 0, Version = model.Version };
+            final.Errors.AddRange(errors);
+            await responseStream.WriteAsync(new UploadEntitiesResponse { Ack = final, Final = true });
+        }
+
+        public override async Task Solve(SolveRequest request, IServerStreamWriter<SolveProgressEvent> responseStream, ServerCallContext context)
+        {
+            string? publicationId = string.IsNullOrEmpty(request.PublicationId) ? null : request.PublicationId;
+            if (publicationId != null && host.Publications.Find(publicationId) == null)
+                throw new RpcException(new Status(StatusCode.NotFound, $"Published model '{publicationId}' not found"));
+            var model = publicationId == null ? GetModel(request.ModelId) : null;
+            var driver = CreateDriver(request);
+            var channel = Channel.CreateUnbounded<SolveProgress>();
+
+            var solveTask = Task.Run(async () =>
+            {
+                try
+                {
+                    var progress = new ChannelProgress(channel.Writer);
+                    return publicationId != null
+                        ? await host.SolvePublishedAsync(publicationId, driver, progress, cancellationToken: context.CancellationToken)
+                        : await host.SolveAsync(model!.Id, driver, progress, cancellationToken: context.CancellationToken);
+                }
+                finally
+                {
+                    channel.Writer.Complete();
+                }
+            });
+
+            SolveProgress? last = null;
+            await foreach (var progress in channel.Reader.ReadAllAsync(context.CancellationToken))
+            {
+                // The terminal event is sent after the result is available so it can carry the solution
+                if (progress.Phase is Core.Solving.SolvePhase.Completed or Core.Solving.SolvePhase.Failed)
+                {
+                    last = progress;
+                    continue;
+                }
+
+                await responseStream.WriteAsync(ProtoMapper.ToProto(progress));
+            }
+
+            var result = await solveTask;
+            var final = ProtoMapper.ToProto(last ?? new SolveProgress
+            {
+                Phase = Core.Solving.SolvePhase.Failed,
+                Status = result.Status,
+                Message = result.StatusMessage ?? ""
+            });
+
+            if (result.Status is SolveStatus.Optimal or SolveStatus.Feasible)
+                final.VariableValues.Add(result.VariableValues);
+
+            await responseStream.WriteAsync(final);
+        }
+
+        public override Task<SyncEntitiesResponse> SyncEntities(SyncEntitiesRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            SyncResult result;
+
+            try
+            {
+                result = host.Sync(model.Id, request.SinceSequence, request.Operations.Select(ProtoMapper.FromProto));
+            }
+            catch (InvalidOperationException ex) when (ex is not AccessDeniedException)
+            {
+                throw new RpcException(new Status(StatusCode.InvalidArgument, ex.Message));
+            }
+
+            var response = new SyncEntitiesResponse { Sequence = result.Sequence, Version = result.Version };
+            response.Operations.AddRange(result.Operations.Select(ProtoMapper.ToProto));
+            response.Errors.AddRange(result.Errors);
+            return Task.FromResult(response);
+        }
+
+        public override Task<GetCodeActionsResponse> GetCodeActions(ModelRef request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            // Read before computing, so a concurrent edit makes ApplyChangeSet report a conflict
+            var response = new GetCodeActionsResponse { Version = model.Version };
+            response.Actions.AddRange(host.GetCodeActions(model.Id).Select(ProtoMapper.ToProto));
+            return Task.FromResult(response);
+        }
+
+        public override Task<GetSemanticTokensResponse> GetSemanticTokens(ModelRef request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            var response = new GetSemanticTokensResponse { Version = model.Version };
+            response.Data.AddRange(Core.Parsing.SemanticTokens.Encode(host.GetTokens(model.Id)).Select(n => (uint)n));
+            response.TokenTypes.AddRange(Core.Parsing.SemanticTokens.TokenTypes);
+            response.TokenModifiers.AddRange(Core.Parsing.SemanticTokens.TokenModifiers);
+            return Task.FromResult(response);
+        }
+
+        public override Task<EntityAck> ApplyChangeSet(ApplyChangeSetRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            if (request.Changes == null || request.Changes.Edits.Count == 0)
+                throw new RpcException(new Status(StatusCode.InvalidArgument, "Change set has no edits"));
+
+            var result = host.ApplyChangeSet(model.Id, ProtoMapper.FromProto(request.Changes),
+                request.HasExpectedVersion ? request.ExpectedVersion : null);
+            return Task.FromResult(ProtoMapper.ToAck(result));
+        }
+
+        public override Task<SetPermissionResponse> SetPermission(SetPermissionRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            string block = string.IsNullOrEmpty(request.Block) ? "*" : request.Block;
+            var permissions = Permission.None;
+
+            if (!string.IsNullOrEmpty(request.Role) && !AccessPolicy.Roles.TryGetValue(request.Role, out permissions))
+                throw new RpcException(new Status(StatusCode.InvalidArgument, $"Unknown role '{request.Role}'"));
+
+            try
+            {
+                host.Grant(model.Id, request.Subject, permissions, block);
+            }
+            catch (InvalidOperationException ex) when (ex is not AccessDeniedException)
+            {
+                throw new RpcException(new Status(StatusCode.FailedPrecondition, ex.Message));
+            }
+
+            var response = new SetPermissionResponse();
+            response.Grants.AddRange(host.Policy!.GetGrants(model.Id).Select(g => g.ToString()));
+            return Task.FromResult(response);
+        }
+
+        public override Task<SetRestrictionResponse> SetRestriction(SetRestrictionRequest request, ServerCallContext context)
+        {
+            var model = GetModel(request.ModelId);
+            if (string.IsNullOrWhiteSpace(request.Role))
+                throw new RpcException(new Status(StatusCode.InvalidArgument, "Restriction has no role"));
+
+            try
+            {
+                host.Restrict(model.Id, request.Role,
+                    string.IsNullOrEmpty(request.Entities) ? "*" : request.Entities,
+                    string.IsNullOrEmpty(request.Tag) ? null : request.Tag,
+                    request.Remove ? Permission.None : Permission.Edit);
+            }
+            catch (InvalidOperationException ex) when (ex is not AccessDeniedException)
+            {
+                throw new RpcException(new Status(StatusCode.FailedPrecondition, ex.Message));
+            }
+
+            var response = new SetRestrictionResponse();
+            response.Restrictions.AddRange(host.Policy!.GetRestrictions(model.Id).Select(r => r.ToString()));
+            return Task.FromResult(response);
+        }
+
+        private HostedModel GetModel(string id)
+        {
+            return host.Find(id) ?? throw new RpcException(new Status(StatusCode.NotFound, $"Model '{id}' not found"));
+        }
+
+        private static Core.Server.EntityDefinition ToDefinition(Entity entity)
+        {
+            try
+            {
+                return ProtoMapper.FromProto(entity);
+            }
+            catch (InvalidOperationException ex)
+            {
+                throw new RpcException(new Status(StatusCode.InvalidArgument, ex.Message));
+            }
+        }
+
+        private static ISolverDriver CreateDriver(SolveRequest request)
+        {
+            TimeSpan? timeLimit = request.TimeLimitSeconds > 0 ? TimeSpan.FromSeconds(request.TimeLimitSeconds) : null;
+
+            return request.Solver.ToLowerInvariant() switch
+            {
+                "" or "cplex" => new ModelSolver(),
+                "cp-sat" => new CpSatDriver { TimeLimit = timeLimit },
+                "auto" => new AutoDriver(new ISolverDriver[] { new ModelSolver(), new CpSatDriver { TimeLimit = timeLimit } }),
+                _ => throw new RpcException(new Status(StatusCode.InvalidArgument, $"Unknown solver '{request.Solver}'"))
+            };
+        }
+
+        private class ChannelProgress : IProgress<SolveProgress>
+        {
+            private readonly ChannelWriter<SolveProgress> writer;
+
+            public ChannelProgress(ChannelWriter<SolveProgress> writer)
+            {
+                this.writer = writer;
+            }
+
+            public void Report(SolveProgress value) => writer.TryWrite(value);
+        }
+    }
+}
@@ -0,0 +1,263 @@
+using Core.Analysis;
+using Core.Server;
+using Core.Solving;
+using ModelEditorServer.Protos;
+
+namespace ModelEditorServer.Services
+{
+    /// <summary>
+    /// Conversions between protobuf messages and the Core server types
+    /// </summary>
+    internal static class ProtoMapper
+    {
+        public static ModelInfo ToInfo(HostedModel model)
+        {
+            var info = new ModelInfo
+            {
+                ModelId = model.Id,
+                Name = model.Name,
+                Version = model.Version,
+                LastModifiedUnixMs = new DateTimeOffset(model.LastModified, TimeSpan.Zero).ToUnixTimeMilliseconds()
+            };
+            info.Errors.AddRange(model.Errors);
+            return info;
+        }
+
+        public static EntityAck ToAck(EntityUpdateResult result)
+        {
+            var ack = new EntityAck
+            {
+                Key = result.Key,
+                Success = result.Success,
+                Replaced = result.Replaced,
+                Version = result.Version,
+                Revision = result.Revision,
+                Conflict = result.Conflict
+            };
+            ack.Errors.AddRange(result.Errors);
+            if (result.Current != null)
+                ack.Current = ToProto(result.Current);
+            return ack;
+        }
+
+        public static Entity ToProto(EntityDefinition definition)
+        {
+            var entity = ToProtoKind(definition);
+            entity.Revision = definition.Revision;
+            return entity;
+        }
+
+        private static Entity ToProtoKind(EntityDefinition definition)
+        {
+            switch (definition.Kind)
+            {
+                case EntityKind.Set:
+                    return new Entity { Set = new SetEntity { Name = definition.Name, Type = definition.Type, Value = definition.Value ?? "" } };
+
+                case EntityKind.Parameter:
+                    var parameter = new ParameterEntity { Name = definition.Name, Type = definition.Type };
+                    parameter.IndexSets.AddRange(definition.IndexSets);
+                    if (definition.Value != null)
+                        parameter.Value = definition.Value;
+                    return new Entity { Parameter = parameter };
+
+                case EntityKind.Variable:
+                    var variable = new VariableEntity { Name = definition.Name, Type = definition.Type };
+                    variable.IndexSets.AddRange(definition.IndexSets);
+                    if (definition.LowerBound != null)
+                        variable.LowerBound = definition.LowerBound;
+                    if (definition.UpperBound != null)
+                        variable.UpperBound = definition.UpperBound;
+                    return new Entity { Variable = variable };
+
+                case EntityKind.DecisionExpression:
+                    var dexpr = new DecisionExpressionEntity { Name = definition.Name, Type = definition.Type, Value = definition.Value ?? "" };
+                    dexpr.IndexSets.AddRange(definition.IndexSets);
+                    return new Entity { DecisionExpression = dexpr };
+
+                case EntityKind.Constraint:
+                    var constraint = new ConstraintEntity { Name = definition.Name, Body = definition.Body ?? "" };
+                    if (definition.Forall != null)
+                        constraint.Forall = definition.Forall;
+                    return new Entity { Constraint = constraint };
+
+                default:
+                    return new Entity
+                    {
+                        Objective = new ObjectiveEntity
+                        {
+                            Sense = definition.Sense == Core.Models.ObjectiveSense.Minimize
+                                ? Protos.ObjectiveSense.Minimize
+                                : Protos.ObjectiveSense.Maximize,
+                            Body = definition.Body ?? ""
+                        }
+                    };
+            }
+        }
+
+        public static EntityDefinition FromProto(Entity entity)
+        {
+            switch (entity.KindCase)
+            {
+                case Entity.KindOneofCase.Set:
+                    return new EntityDefinition { Kind = EntityKind.Set, Name = entity.Set.Name, Type = entity.Set.Type, Value = entity.Set.Value };
+
+                case Entity.KindOneofCase.Parameter:
+                    var p = entity.Parameter;
+                    return new EntityDefinition
+                    {
+                        Kind = EntityKind.Parameter,
+                        Name = p.Name,
+                        Type = p.Type,
+                        IndexSets = p.IndexSets.ToList(),
+                        Value = p.HasValue ? p.Value : null
+                    };
+
+                case Entity.KindOneofCase.Variable:
+                    var v = entity.Variable;
+                    return new EntityDefinition
+                    {
+                        Kind = EntityKind.Variable,
+                        Name = v.Name,
+                        Type = v.Type,
+                        IndexSets = v.IndexSets.ToList(),
+                        LowerBound = v.HasLowerBound ? v.LowerBound : null,
+                        UpperBound = v.HasUpperBound ? v.UpperBound : null
+                    };
+
+                case Entity.KindOneofCase.DecisionExpression:
+                    var d = entity.DecisionExpression;
+                    return new EntityDefinition
+                    {
+                        Kind = EntityKind.DecisionExpression,
+                        Name = d.Name,
+                        Type = d.Type,
+                        IndexSets = d.IndexSets.ToList(),
+                        Value = d.Value
+                    };
+
+                case Entity.KindOneofCase.Constraint:
+                    var c = entity.Constraint;
+                    return new EntityDefinition
+                    {
+                        Kind = EntityKind.Constraint,
+                        Name = c.Name,
+                        Forall = c.HasForall ? c.Forall : null,
+                        Body = c.Body
+                    };
+
+                case Entity.KindOneofCase.Objective:
+                    return new EntityDefinition
+                    {
+                        Kind = EntityKind.Objective,
+                        Sense = entity.Objective.Sense == Protos.ObjectiveSense.Minimize
+                            ? Core.Models.ObjectiveSense.Minimize
+                            : Core.Models.ObjectiveSense.Maximize,
+                        Body = entity.Objective.Body
+                    };
+
+                default:
+                    throw new InvalidOperationException("Entity has no kind set");
+            }
+        }
+
+        public static Protos.EntityOperation ToProto(Core.Server.EntityOperation operation)
+        {
+            var message = new Protos.EntityOperation
+            {
+                Key = operation.Key,
+                Timestamp = operation.Timestamp,
+                ReplicaId = operation.ReplicaId,
+                Sequence = operation.Sequence
+            };
+            if (operation.Statement != null)
+                message.Statement = operation.Statement;
+            return message;
+        }
+
+        public static Core.Server.EntityOperation FromProto(Protos.EntityOperation message)
+        {
+            return new Core.Server.EntityOperation
+            {
+                Key = message.Key,
+                Statement = message.HasStatement ? message.Statement : null,
+                Timestamp = message.Timestamp,
+                ReplicaId = message.ReplicaId
+            };
+        }
+
+        public static Protos.CodeAction ToProto(Core.Analysis.CodeAction action)
+        {
+            return new Protos.CodeAction
+            {
+                Title = action.Title,
+                Kind = action.Kind,
+                Diagnostic = action.Diagnostic,
+                RuleId = action.RuleId,
+                LineNumber = action.LineNumber,
+                IsPreferred = action.IsPreferred,
+                Edit = ToProto(action.Edit)
+            };
+        }
+
+        public static Protos.ChangeSet ToProto(Core.Parsing.ChangeSet changes)
+        {
+            var message = new Protos.ChangeSet { Title = changes.Title };
+            foreach (var edit in changes.Edits)
+            {
+                var proto = new Protos.ModelEdit();
+                if (edit.Key != null)
+                    proto.Key = edit.Key;
+                if (edit.Target != null)
+                    proto.Target = edit.Target;
+                if (edit.Statement != null)
+                    proto.Statement = edit.Statement;
+                if (edit.Annotation != null)
+                    proto.Annotation = edit.Annotation;
+                message.Edits.Add(proto);
+            }
+            return message;
+        }
+
+        public static Core.Parsing.ChangeSet FromProto(Protos.ChangeSet message)
+        {
+            return new Core.Parsing.ChangeSet
+            {
+                Title = message.Title,
+                Edits = message.Edits.Select(e => new Core.Parsing.ModelEdit
+                {
+                    Key = e.HasKey ? e.Key : null,
+                    Target = e.HasTarget ? e.Target : null,
+                    Statement = e.HasStatement ? e.Statement : null,
+                    Annotation = e.HasAnnotation ? e.Annotation : null
+                }).ToList()
+            };
+        }
+
+        public static SolveProgressEvent ToProto(SolveProgress progress)
+        {
+            var message = new SolveProgressEvent
+            {
+                Phase = progress.Phase switch
+                {
+                    Core.Solving.SolvePhase.Parsing => Protos.SolvePhase.Parsing,
+                    Core.Solving.SolvePhase.Solving => Protos.SolvePhase.Solving,
+                    Core.Solving.SolvePhase.Completed => Protos.SolvePhase.Completed,
+                    _ => Protos.SolvePhase.Failed
+                },
+                ElapsedSeconds = progress.Elapsed.TotalSeconds,
+                Message = progress.Message,
+                Status = progress.Status?.ToString() ?? ""
+            };
+
+            if (progress.ObjectiveValue.HasValue)
+                message.ObjectiveValue = progress.ObjectiveValue.Value;
+            if (progress.BestBound.HasValue)
+                message.BestBound = progress.BestBound.Value;
+            if (progress.MipGap.HasValue)
+                message.MipGap = progress.MipGap.Value;
+
+            return message;
+        }
+    }
+}
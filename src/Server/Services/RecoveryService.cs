@@ -0,0 +1,53 @@
+using Core.Server;
+using Core.Services;
+
+namespace ModelEditorServer.Services
+{
+    /// <summary>
+    /// Autosaves the host's unsaved models to its recovery location every AutosaveInterval and,
+    /// when the server stops, drains the host (see ModelHost.ShutdownAsync) so in-flight edits and
+    /// the state of running solves are checkpointed before the process exits
+    /// </summary>
+    public class RecoveryService : BackgroundService
+    {
+        private readonly ModelHost host;
+        private readonly TimeSpan autosaveInterval;
+        private readonly TimeSpan shutdownGrace;
+
+        public RecoveryService(ModelHost host, TimeSpan autosaveInterval, TimeSpan shutdownGrace)
+        {
+            this.host = host;
+            this.autosaveInterval = autosaveInterval;
+            this.shutdownGrace = shutdownGrace;
+        }
+
+        protected override async Task ExecuteAsync(CancellationToken stoppingToken)
+        {
+            using var timer = new PeriodicTimer(autosaveInterval);
+            try
+            {
+                while (await timer.WaitForNextTickAsync(stoppingToken))
+                {
+                    try
+                    {
+                        await host.CheckpointAsync(cancellationToken: stoppingToken);
+                    }
+                    catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+                    {
+                        // A full or unavailable disk must not stop the next attempt
+                        host.Logger.LogCheckpointFailed(host.Recovery!.Directory, ex);
+                    }
+                }
+            }
+            catch (OperationCanceledException) when (stoppingToken.IsCancellationRequested)
+            {
+            }
+        }
+
+        public override async Task StopAsync(CancellationToken cancellationToken)
+        {
+            await base.StopAsync(cancellationToken);
+            await host.ShutdownAsync(shutdownGrace, cancellationToken);
+        }
+    }
+}
@@ -0,0 +1,183 @@
+using Core.Analysis;
+using Core.Server;
+
+namespace Tests
+{
+    public class AccessControlTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+float price = 3;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) price * flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private static readonly UserPrincipal Owner = new UserPrincipal { Subject = "alice" };
+        private static readonly UserPrincipal Analyst = new UserPrincipal { Subject = "bob", Roles = { "analysts" } };
+
+        private static (ModelHost Host, HostedModel Model) CreateHostedModel()
+        {
+            var host = new ModelHost { Policy = new AccessPolicy() };
+            using (AccessContext.BeginScope(Owner))
+                return (host, host.Create("network", Model));
+        }
+
+        [Fact]
+        public void Policy_ShouldCombineRoleAndUserGrants()
+        {
+            var policy = new AccessPolicy();
+            policy.Grant(new AccessGrant { Subject = "role:analysts", ModelId = "m1", Permissions = AccessPolicy.Roles["viewer"] });
+            policy.Grant(new AccessGrant { Subject = "user:bob", ModelId = "*", Permissions = Permission.Solve });
+
+            Assert.Equal(Permission.Read | Permission.Solve, policy.GetPermissions(Analyst, "m1"));
+            Assert.Equal(Permission.Solve, policy.GetPermissions(Analyst, "m2"));
+            Assert.Equal(Permission.None, policy.GetPermissions(null, "m1"));
+            Assert.Equal(Permission.None, policy.GetPermissions(Owner, "m1"));
+        }
+
+        [Fact]
+        public void Policy_BlockGrant_ShouldOnlyApplyToMatchingEntities()
+        {
+            var policy = new AccessPolicy();
+            policy.Grant(new AccessGrant { Subject = "user:bob", ModelId = "m1", Block = "parameter:price*", Permissions = Permission.Edit });
+
+            Assert.True(policy.IsAllowed(Analyst, "m1", Permission.Edit, "parameter:price"));
+            Assert.False(policy.IsAllowed(Analyst, "m1", Permission.Edit, "parameter:capacity"));
+            Assert.False(policy.IsAllowed(Analyst, "m1", Permission.Edit));
+        }
+
+        [Fact]
+        public void Policy_GrantWithNone_ShouldRemoveGrant()
+        {
+            var policy = new AccessPolicy();
+            policy.Grant(new AccessGrant { Subject = "user:bob", ModelId = "m1", Permissions = Permission.All });
+            policy.Grant(new AccessGrant { Subject = "user:bob", ModelId = "m1", Permissions = Permission.None });
+
+            Assert.Empty(policy.GetGrants("m1"));
+        }
+
+        [Fact]
+        public void Create_ShouldMakeCreatorAdminAndRequireCaller()
+        {
+            var (host, model) = CreateHostedModel();
+
+            using (AccessContext.BeginScope(Owner))
+                Assert.True(host.Policy!.IsAllowed(Owner, model.Id, Permission.All));
+
+            Assert.Throws<AccessDeniedException>(() => host.GetEntities(model.Id));
+            Assert.ThrowsAny<InvalidOperationException>(() => host.Create("anonymous", Model));
+        }
+
+        [Fact]
+        public void ApplyEntity_ShouldHonorBlockGrant()
+        {
+            var (host, model) = CreateHostedModel();
+            using (AccessContext.BeginScope(Owner))
+                host.Grant(model.Id, "role:analysts", AccessPolicy.Roles["viewer"]);
+            using (AccessContext.BeginScope(Owner))
+                host.Grant(model.Id, "user:bob", Permission.Edit, "parameter:price");
+
+            using (AccessContext.BeginScope(Analyst))
+            {
+                var result = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Name = "price", Type = "float", Value = "4" });
+                Assert.True(result.Success);
+
+                var denied = Assert.Throws<AccessDeniedException>(() =>
+                    host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Name = "capacity", Type = "float", Value = "30" }));
+                Assert.Equal("parameter:capacity", denied.EntityKey);
+                Assert.Throws<AccessDeniedException>(() => host.SetData(model.Id, ""));
+            }
+
+            Assert.Contains("float price = 4;", model.ModelText);
+            Assert.Contains("float capacity = 25;", model.ModelText);
+        }
+
+        [Fact]
+        public void ListAndDelete_ShouldRespectPermissions()
+        {
+            var (host, model) = CreateHostedModel();
+            using (AccessContext.BeginScope(Owner))
+                host.Grant(model.Id, "user:bob", AccessPolicy.Roles["editor"]);
+
+            using (AccessContext.BeginScope(new UserPrincipal { Subject = "carol" }))
+                Assert.Empty(host.List());
+
+            using (AccessContext.BeginScope(Analyst))
+            {
+                Assert.Single(host.List());
+                Assert.Throws<AccessDeniedException>(() => host.Delete(model.Id));
+            }
+
+            using (AccessContext.BeginScope(Owner))
+                Assert.True(host.Delete(model.Id));
+
+            Assert.Empty(host.Policy!.GetGrants(model.Id));
+        }
+
+        [Fact]
+        public void Policy_Restriction_ShouldRequireRoleOnTaggedEntities()
+        {
+            var policy = new AccessPolicy();
+            policy.Grant(new AccessGrant { Subject = "*", ModelId = "m1", Permissions = AccessPolicy.Roles["editor"] });
+            policy.Restrict(new EntityRestriction { Tag = "prices", Role = "market" });
+            var trader = new UserPrincipal { Subject = "dave", Roles = { "market" } };
+
+            policy.Demand(Analyst, "m1", Permission.Edit, "parameter:capacity", new[] { "capacity" });
+            policy.Demand(Analyst, "m1", Permission.Read, "parameter:price", new[] { "prices/spot" });
+            policy.Demand(trader, "m1", Permission.Edit, "parameter:price", new[] { "prices/spot" });
+
+            var denied = Assert.Throws<AccessDeniedException>(() =>
+                policy.Demand(Analyst, "m1", Permission.Edit, "parameter:price", new[] { "prices/spot" }));
+            Assert.Equal(new[] { "market" }, denied.RequiredRoles);
+            Assert.Contains("requires role 'market'", denied.Message);
+
+            policy.Restrict(new EntityRestriction { Tag = "prices", Role = "market", Permissions = Permission.None });
+            Assert.Empty(policy.GetRestrictions("m1"));
+        }
+
+        [Fact]
+        public void ApplyEntity_ShouldHonorBlockRestriction()
+        {
+            var host = new ModelHost { Policy = new AccessPolicy() };
+            var trader = new UserPrincipal { Subject = "dave", Roles = { "market" } };
+            HostedModel model;
+            using (AccessContext.BeginScope(Owner))
+            {
+                model = host.Create("network", Model.Replace("float price = 3;", "// @block market\nfloat price = 3;\n// @endblock"));
+                host.Grant(model.Id, "*", AccessPolicy.Roles["editor"]);
+                host.Restrict(model.Id, "market", tag: "market");
+            }
+
+            using (AccessContext.BeginScope(Analyst))
+            {
+                Assert.True(host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Name = "capacity", Type = "float", Value = "30" }).Success);
+
+                var denied = Assert.Throws<AccessDeniedException>(() =>
+                    host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Name = "price", Type = "float", Value = "4" }));
+                Assert.Equal("parameter:price", denied.EntityKey);
+                Assert.Equal(new[] { "market" }, denied.RequiredRoles);
+            }
+
+            using (AccessContext.BeginScope(trader))
+                Assert.True(host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Name = "price", Type = "float", Value = "5" }).Success);
+
+            Assert.Contains("float capacity = 30;", model.ModelText);
+            Assert.Contains("float price = 5;", model.ModelText);
+        }
+
+        [Fact]
+        public async Task StaticTokenValidator_ShouldResolveKnownTokens()
+        {
+            var validator = new StaticTokenValidator().Add("secret", Analyst);
+
+            var valid = await validator.ValidateAsync("secret");
+            var invalid = await validator.ValidateAsync("other");
+
+            Assert.True(valid.Success);
+            Assert.Equal("bob", valid.Principal!.Subject);
+            Assert.False(invalid.Success);
+            Assert.NotNull(invalid.Error);
+        }
+    }
+}
@@ -0,0 +1,74 @@
+using Core;
+
+namespace Tests
+{
+    public class AggregationTests : TestBase
+    {
+        private const string Data =
+            "range T = 1..4;\n" +
+            "float demand[T] = [4, 9, 6, 3];\n";
+
+        [Fact]
+        public void Parse_FormulaParameters_ShouldEvaluateOverTheData()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Data +
+                "float peak = max(t in T) demand[t];\n" +
+                "float mean = avg(t in T) demand[t];\n" +
+                "int busy = count(t in T: demand[t] > 5) 1;\n");
+
+            AssertNoErrors(result);
+            Assert.Equal(9.0, manager.Parameters["peak"].Value);
+            Assert.Equal(5.5, manager.Parameters["mean"].Value);
+            Assert.Equal(2, manager.Parameters["busy"].Value);
+        }
+
+        [Fact]
+        public void Evaluate_ShouldApplyTheFilterAndRejectEmptyDomains()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            AssertNoErrors(parser.Parse(Data));
+
+            Assert.Equal(3, parser.ParseExpression("min(t in T: t > 1) demand[t]").Evaluate(manager));
+            Assert.Equal(216, parser.ParseExpression("prod(t in T: t <= 3) demand[t]").Evaluate(manager));
+            Assert.Equal(3, parser.ParseExpression("count(t in T) (demand[t] - 4)").Evaluate(manager));
+            Assert.Throws<InvalidOperationException>(() => parser.ParseExpression("max(t in T: t > 4) demand[t]").Evaluate(manager));
+        }
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldRewriteLinearAggregations()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Data +
+                "dvar float+ x[T];\n" +
+                "dvar bool on[T];\n" +
+                "mean: avg(t in T) x[t] <= max(t in T) demand[t];\n" +
+                "starts: count(t in T: t > 1) on[t] >= 2;\n");
+            AssertNoErrors(result);
+
+            parser.ExpandAllTemplates(result);
+
+            AssertNoErrors(result);
+            var equations = manager.Equations.Where(e => e.Label != null).ToDictionary(e => e.Label!);
+            Assert.Equal(0.25, equations["mean"].Coefficients["x1"].Evaluate(manager));
+            Assert.Equal(9, equations["mean"].Constant.Evaluate(manager));
+            Assert.Equal(new[] { "on2", "on3", "on4" }, equations["starts"].Coefficients.Keys.OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Parse_MaxOfVariablesPushedUp_ShouldNeedBoundsOrReportTheTerm()
+        {
+            var parser = CreateParser();
+            var result = parser.Parse(Data +
+                "dvar float+ x[T];\n" +
+                "floor: max(t in T) x[t] >= 2;\n");
+
+            parser.ExpandAllTemplates(result);
+
+            AssertHasError(result, "'max(t in T) x[t]' is not linear in the decision variables");
+        }
+    }
+}
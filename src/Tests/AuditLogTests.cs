@@ -0,0 +1,99 @@
+using Core;
+using Core.Models;
+using Core.Services;
+
+namespace Tests
+{
+    public class AuditLogTests : TestBase
+    {
+        [Fact]
+        public void ModelMutations_ShouldBeRecordedWithAuthor()
+        {
+            var manager = new ModelManager { AuditLog = new AuditLog() };
+
+            using (AuditContext.BeginScope("alice"))
+            {
+                manager.SetParameter("cost", 10.0);
+                manager.SetParameter("cost", 12.0);
+            }
+
+            using (AuditContext.BeginScope("bob"))
+            {
+                manager.AddIndexSet(new IndexSet("I", 1, 3));
+            }
+
+            var entries = manager.AuditLog.Entries;
+            Assert.Equal(3, entries.Count);
+            Assert.Equal(AuditOperation.Add, entries[0].Operation);
+            Assert.Equal(AuditOperation.Update, entries[1].Operation);
+            Assert.Equal("parameter:cost", Assert.Single(entries[1].Entities));
+            Assert.Equal(new long[] { 1, 2, 3 }, entries.Select(e => e.Sequence));
+
+            Assert.Equal(2, manager.AuditLog.Query(author: "alice").Count());
+            Assert.Equal("bob", Assert.Single(manager.AuditLog.GetHistory("set:I")).Author);
+        }
+
+        [Fact]
+        public void DirectCollectionEdits_ShouldNotBeRecorded()
+        {
+            var manager = new ModelManager { AuditLog = new AuditLog() };
+
+            manager.SetParameter("cost", 10.0);
+            manager.Parameters.Remove("cost");
+            manager.Equations.Add(new LinearEquation());
+
+            Assert.Equal("parameter:cost", Assert.Single(Assert.Single(manager.AuditLog.Entries).Entities));
+        }
+
+        [Fact]
+        public void ParsedForall_ShouldBeAuditedAsSingleExpansion()
+        {
+            var manager = CreateModelManager();
+            manager.AuditLog = new AuditLog();
+            var parser = CreateParser(manager);
+
+            var result = parser.Parse(@"
+                range I = 1..3;
+                dvar float+ x[I];
+                forall(i in I) limit: x[i] <= 5;
+            ");
+            AssertNoErrors(result);
+            manager.ExpandForallStatements();
+
+            var expand = Assert.Single(manager.AuditLog.Query(operation: AuditOperation.Expand));
+            Assert.Contains("3 constraints", expand.Details);
+            Assert.DoesNotContain(manager.AuditLog.Entries, e => e.Entities.Any(n => n.StartsWith("constraint:")));
+        }
+
+        [Fact]
+        public void FileBackedLog_ShouldAppendAndReload()
+        {
+            var modelPath = Path.Combine(Path.GetTempPath(), $"audit_{Guid.NewGuid():N}.mod");
+            var logPath = AuditLog.GetPathForModel(modelPath);
+
+            try
+            {
+                var log = AuditLog.ForModel(modelPath);
+                using (AuditContext.BeginScope("carol"))
+                {
+                    log.Record(AuditOperation.Add, "parameter:a");
+                    log.Record(AuditOperation.Remove, "parameter:a", "deleted in editor");
+                }
+
+                Assert.Equal(2, File.ReadAllLines(logPath).Length);
+
+                var reloaded = AuditLog.ForModel(modelPath);
+                Assert.Equal(2, reloaded.Entries.Count);
+                Assert.Equal("deleted in editor", reloaded.Entries[1].Details);
+
+                var next = reloaded.Record(AuditOperation.Add, "parameter:b");
+                Assert.Equal(3, next.Sequence);
+            }
+            finally
+            {
+                if (File.Exists(logPath))
+                    File.Delete(logPath);
+            }
+        }
+    }
+}
@@ -0,0 +1,124 @@
+using Core;
+using Core.Models;
+using Core.Solving;
+
+namespace Tests
+{
+    public class AutoDriverTests : TestBase
+    {
+        private class StubDriver : ISolverDriver
+        {
+            public StubDriver(string name, SolverCapabilities capabilities)
+            {
+                Name = name;
+                Capabilities = capabilities;
+            }
+
+            public string Name { get; }
+            public SolverCapabilities Capabilities { get; }
+            public int Calls { get; private set; }
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Calls++;
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 1, StatusMessage = "done" };
+            }
+        }
+
+        private readonly StubDriver mip = new StubDriver("MIP", SolverCapabilities.Continuous | SolverCapabilities.Integer);
+        private readonly StubDriver cp = new StubDriver("CP", SolverCapabilities.Integer | SolverCapabilities.Logical);
+
+        private const string IntegerModel = "dvar int a in 0..10;\ndvar int b in 0..10;\nminimize a + b;\nc1: a + b >= 3;\n";
+
+        [Fact]
+        public void Select_LinearProgram_ShouldSkipIntegerOnlySolver()
+        {
+            var selection = new AutoDriver(new[] { cp, mip }).Select(ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 1;\n"));
+
+            Assert.Same(mip, selection.Driver);
+            Assert.True(selection.Characteristics.IsLinearProgram);
+            Assert.Equal(new[]
+            {
+                "Model: linear program, 1 variables (0% integer), 1 constraints, 1 nonzeros, 0 logical constraints",
+                "CP: cannot solve continuous variables",
+                "Chose MIP: the only configured solver that can solve this model"
+            }, selection.Trace);
+        }
+
+        [Fact]
+        public void Select_PureIntegerModel_ShouldPreferConstraintProgrammingUpToSizeLimit()
+        {
+            var manager = ParseAndExpand(IntegerModel);
+            var auto = new AutoDriver(new[] { mip, cp });
+
+            var small = auto.Select(manager);
+            auto.MaxConstraintProgrammingVariables = 1;
+            var large = auto.Select(manager);
+
+            Assert.True(small.Characteristics.IsPureInteger);
+            Assert.Equal(1.0, small.Characteristics.IntegralityShare);
+            Assert.Same(cp, small.Driver);
+            Assert.Same(mip, large.Driver);
+            Assert.Contains("CP: more than 1 variables for constraint programming", large.Trace);
+            Assert.Equal("Chose MIP: mixed-integer program", large.Trace.Last());
+        }
+
+        [Fact]
+        public void Select_LogicalConstraints_ShouldRequireLogicalCapability()
+        {
+            var manager = ParseAndExpand("dvar int a in 0..10;\ndvar int b in 0..10;\nminimize a + b;\n(a >= 3) || (b >= 2);\n");
+
+            var selection = new AutoDriver(new[] { mip, cp }).Select(manager);
+
+            Assert.Same(cp, selection.Driver);
+            Assert.Contains("MIP: cannot solve logical constraints", selection.Trace);
+        }
+
+        [Fact]
+        public void Solve_QuadraticModel_WithoutCapableSolver_ShouldExplain()
+        {
+            var manager = ParseAndExpand("dvar float+ x;\ndvar float+ y;\nminimize x;\nc1: x >= 1;\n");
+            manager.Equations[0].Coefficients["x"] = new VariableExpression("y");
+
+            var result = new AutoDriver(new[] { mip, cp }).Solve(manager);
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Equal("No configured solver handles continuous variables, quadratic terms", result.StatusMessage);
+            Assert.Equal(0, mip.Calls);
+        }
+
+        [Fact]
+        public void Select_NonconvexSourceProducts_ShouldRequireGlobalSolver()
+        {
+            var manager = ParseAndExpand("dvar float x in 0..4;\ndvar float y in 0..4;\nminimize x + y;\nc1: x * y >= 2;\n");
+            var local = new StubDriver("Local", SolverCapabilities.Continuous | SolverCapabilities.Quadratic);
+            var global = new StubDriver("Global", SolverCapabilities.Continuous | SolverCapabilities.Quadratic | SolverCapabilities.Nonconvex);
+            var auto = new AutoDriver(new[] { mip, local, global });
+
+            var selection = auto.Select(manager);
+
+            Assert.Same(global, selection.Driver);
+            Assert.StartsWith("Model: QCP model (nonconvex), 2 variables", selection.Trace[0]);
+            Assert.Contains("MIP: cannot solve quadratic terms, nonconvex models", selection.Trace);
+            Assert.Contains("Local: cannot solve nonconvex models", selection.Trace);
+            Assert.Equal(new[] { "Global" }, auto.Classify(manager).Solvers);
+        }
+
+        [Fact]
+        public async Task Solve_ShouldPreferPastRaceWinnerAndNameChosenSolver()
+        {
+            var manager = ParseAndExpand(IntegerModel);
+            var performance = new SolverPerformance();
+            var fast = new StubDriver("Fast", SolverCapabilities.Continuous | SolverCapabilities.Integer);
+            await new SolverRace(new ISolverDriver[] { fast }) { Performance = performance }.RunAsync(manager);
+
+            var auto = new AutoDriver(new[] { cp, mip, fast }) { Performance = performance };
+            var result = auto.Solve(manager);
+
+            Assert.Equal(2, fast.Calls);
+            Assert.Equal(0, cp.Calls);
+            Assert.Equal("Fast: done", result.StatusMessage);
+            Assert.Equal("Chose Fast: won most races on this model", auto.Select(manager).Trace.Last());
+        }
+    }
+}
@@ -0,0 +1,120 @@
+using Core;
+using Core.Decomposition;
+using Core.Models;
+using Core.Solving;
+
+namespace Tests
+{
+    public class BendersDecompositionTests : TestBase
+    {
+        /// <summary>
+        /// Driver that replays a fixed sequence of results
+        /// </summary>
+        private class ScriptedDriver : ISolverDriver
+        {
+            private readonly Queue<SolveResult> results;
+            public ScriptedDriver(params SolveResult[] results) { this.results = new Queue<SolveResult>(results); }
+            public string Name => "Scripted";
+            public SolveResult Solve(ModelManager manager) => results.Dequeue();
+        }
+
+        private class FixedCostSubproblem : IBendersSubproblem
+        {
+            public string Name => "sub";
+            public string RecourseVariable => "theta";
+            public BendersSubproblemResult Solve(IReadOnlyDictionary<string, double> masterValues, ISolverDriver driver)
+            {
+                return new BendersSubproblemResult
+                {
+                    Status = SolveStatus.Optimal,
+                    Objective = 5,
+                    Cuts = { new BendersCut(BendersCutType.Optimality, new Dictionary<string, double> { ["x"] = -1 }, 5) }
+                };
+            }
+        }
+
+        private class ListProgress : IProgress<BendersIteration>
+        {
+            public List<BendersIteration> Reports { get; } = new();
+            public void Report(BendersIteration value) => Reports.Add(value);
+        }
+
+        private static ModelManager CreateMaster()
+        {
+            var master = new ModelManager();
+            master.SetObjective(new Objective(ObjectiveSense.Minimize,
+                new Dictionary<string, Expression> { ["x"] = new ConstantExpression(1), ["theta"] = new ConstantExpression(1) },
+                new ConstantExpression(0)));
+            return master;
+        }
+
+        private static SolveResult MasterResult(double objective, double x, double theta) => new SolveResult
+        {
+            Status = SolveStatus.Optimal,
+            ObjectiveValue = objective,
+            VariableValues = new Dictionary<string, double> { ["x"] = x, ["theta"] = theta }
+        };
+
+        [Fact]
+        public void Run_BoundsMeet_ShouldConvergeAndAddCuts()
+        {
+            var master = CreateMaster();
+            var driver = new ScriptedDriver(MasterResult(0, 0, 0), MasterResult(5, 0, 5));
+            var benders = new BendersDecomposition(master, new[] { new FixedCostSubproblem() }, driver);
+            var progress = new ListProgress();
+
+            var result = benders.Run(progress);
+
+            Assert.Equal(BendersStatus.Converged, result.Status);
+            Assert.Equal(2, result.Iterations.Count);
+            Assert.Equal(5, result.LowerBound, 6);
+            Assert.Equal(5, result.UpperBound, 6);
+            Assert.Equal(2, progress.Reports.Count);
+            Assert.Equal(1, progress.Reports[0].CutsAdded);
+
+            Assert.Empty(master.Equations);
+            var cut = Assert.Single(result.Cuts);
+            Assert.Equal("benders_cut_1", cut.Label);
+            Assert.Equal(RelationalOperator.GreaterThanOrEqual, cut.Operator);
+            Assert.True(cut.TryGetConstantCoefficient("theta", out var thetaCoeff));
+            Assert.Equal(1, thetaCoeff);
+            Assert.True(cut.TryGetConstantCoefficient("x", out var xCoeff));
+            Assert.Equal(1, xCoeff);
+        }
+
+        [Fact]
+        public void Run_MasterInfeasible_ShouldReportMasterFailed()
+        {
+            var master = CreateMaster();
+            var driver = new ScriptedDriver(new SolveResult { Status = SolveStatus.Infeasible });
+            var benders = new BendersDecomposition(master, new[] { new FixedCostSubproblem() }, driver);
+
+            var result = benders.Run();
+
+            Assert.Equal(BendersStatus.MasterFailed, result.Status);
+            Assert.Empty(result.Iterations);
+        }
+
+        [Fact]
+        public void Run_IterationLimit_ShouldStopAfterMaxIterations()
+        {
+            var master = CreateMaster();
+            var driver = new ScriptedDriver(MasterResult(0, 0, 0));
+            var benders = new BendersDecomposition(master, new[] { new FixedCostSubproblem() }, driver) { MaxIterations = 1 };
+
+            var result = benders.Run();
+
+            Assert.Equal(BendersStatus.IterationLimit, result.Status);
+            Assert.Equal(0, result.LowerBound, 6);
+            Assert.Equal(5, result.UpperBound, 6);
+        }
+
+        [Fact]
+        public void Cut_Evaluate_ShouldUseMasterValues()
+        {
+            var cut = new BendersCut(BendersCutType.Optimality, new Dictionary<string, double> { ["x"] = 2 }, 1);
+
+            Assert.Equal(7, cut.Evaluate(new Dictionary<string, double> { ["x"] = 3 }), 6);
+        }
+    }
+}
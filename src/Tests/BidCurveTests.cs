@@ -0,0 +1,83 @@
+using Core.Analysis;
+using Core.Generation;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class BidCurveTests : TestBase
+    {
+        private const string Data =
+            "range T = 1..2;\n" +
+            "float load[T] = [1, 2];\n" +
+            "minimize 0;\n";
+
+        private static BidCurve Demand()
+        {
+            return new BidCurve("d", CurveSide.Demand, CurveShape.Piecewise) { QuantityProfile = "load", Resolution = 2 }
+                .Add(0, 100).Add(10, 60).Add(20, 40);
+        }
+
+        private static BidCurve Supply()
+        {
+            return new BidCurve("s", CurveSide.Supply).Add(10, 20).Add(15, 20).Add(30, 50);
+        }
+
+        [Fact]
+        public void Segments_ShouldSplitPiecesAndMergeEqualSteps()
+        {
+            Assert.Equal(new[] { (5.0, 90.0), (5.0, 70.0), (5.0, 55.0), (5.0, 45.0) }, Demand().Segments());
+            Assert.Equal(new[] { (15.0, 20.0), (15.0, 50.0) }, Supply().Segments());
+        }
+
+        [Fact]
+        public void Generate_ShouldWriteSegmentsBoundToTheProfile()
+        {
+            var statements = Demand().Generate().Concat(Supply().Generate()).Select(e => e.Definition.ToStatement()).ToList();
+            Assert.Contains("float d_price[d_segments] = [90, 70, 55, 45];", statements);
+            Assert.Contains("forall(t in T, k in d_segments) d_segmentLimit: d_segment[t,k] <= d_width[k] * load[t];", statements);
+            Assert.Contains("dexpr float s_cost = sum(t in T) sum(k in s_segments) s_price[k] * s_segment[t,k];", statements);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Data + string.Join("\n", statements));
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            AssertNoErrors(result);
+
+            var limit = manager.GetEquationByLabel("d_segmentLimit_2_3")!;
+            Assert.Equal(new[] { "d_segment2_3" }, limit.Coefficients.Keys);
+            Assert.Equal(10, limit.Constant.Evaluate(manager));
+            Assert.Equal(new[] { "d_quantity1", "d_segment1_1", "d_segment1_2", "d_segment1_3", "d_segment1_4" },
+                manager.GetEquationByLabel("d_cleared_1")!.Coefficients.Keys.OrderBy(k => k, StringComparer.Ordinal));
+        }
+
+        [Fact]
+        public void Regenerate_ShouldWriteTaggedDocumentedEntitiesAndFollowTheCurve()
+        {
+            string model = Demand().Regenerate(ModelSource.Parse(Data)).Apply(Data);
+            model = Supply().Regenerate(ModelSource.Parse(model)).Apply(model);
+
+            var tags = ModelTags.Parse(model);
+            Assert.Equal(8, tags.Select("market/demand").Count);
+            Assert.Equal(8, tags.Select("market/supply/s").Count);
+            Assert.Equal("Quantity of each segment of d, scaled by load in every period", Docstrings.Extract(model)["parameter:d_width"]);
+
+            var flatter = new BidCurve("d", CurveSide.Demand).Add(10, 80).Add(20, 30);
+            model = flatter.Regenerate(ModelSource.Parse(model)).Apply(model);
+
+            Assert.Contains("range d_segments = 1..2;", model);
+            Assert.Contains("d_segment[t,k] <= d_width[k];", model);
+            Assert.Equal(16, ModelTags.Parse(model).Select("market").Count);
+        }
+
+        [Fact]
+        public void Validate_ShouldRejectCurvesOutOfMeritOrder()
+        {
+            var curve = new BidCurve("d", CurveSide.Demand).Add(10, 30).Add(20, 50).Add(15, 10);
+
+            Assert.Equal(new[] { "curve-quantities", "merit-order" }, curve.Validate().Select(f => f.RuleId));
+            Assert.Throws<InvalidOperationException>(() => curve.Generate());
+            Assert.Empty(Supply().Validate());
+        }
+    }
+}
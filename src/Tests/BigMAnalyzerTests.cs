@@ -0,0 +1,79 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class BigMAnalyzerTests : TestBase
+    {
+        private const string Model = @"
+            dvar float x in 0..50;
+            dvar float z in 0..20;
+            dvar float s;
+            dvar bool y;
+            dvar bool w;
+            dvar int k in 0..1;
+            minimize x + z + s + 10*y;
+            link: x - 1000*y <= 0;
+            small: z - 20*w <= 0;
+            demand: x + 1000000*k >= 10;
+            capped: x + 1000*w <= 1020;
+            open: s - 500*y <= 0;
+        ";
+
+        private ModelManager BuildModel() => ParseAndExpand(Model);
+
+        [Fact]
+        public void Analyze_ShouldFindBinaryBigMsAndImpliedValues()
+        {
+            var findings = new BigMAnalyzer(BuildModel()).Analyze();
+
+            Assert.Equal(new[] { "link", "demand", "capped", "open" }, findings.Select(f => f.Constraint));
+            Assert.DoesNotContain(findings, f => f.Constraint == "small");
+
+            var link = findings.Single(f => f.Constraint == "link");
+            Assert.Equal(1000, link.M);
+            Assert.Equal(50, link.ImpliedM);
+            Assert.True(link.CanTighten);
+            Assert.False(link.IsNumericallyRisky);
+
+            var demand = findings.Single(f => f.Constraint == "demand");
+            Assert.Equal(10, demand.ImpliedM);
+            Assert.True(demand.IsNumericallyRisky);
+
+            Assert.Equal(30, findings.Single(f => f.Constraint == "capped").ImpliedM);
+
+            var open = findings.Single(f => f.Constraint == "open");
+            Assert.Null(open.ImpliedM);
+            Assert.False(open.CanTighten);
+            Assert.Contains("unbounded", open.ToString());
+        }
+
+        [Fact]
+        public void Tighten_ShouldRewriteExpandedRows()
+        {
+            var manager = BuildModel();
+            var analyzer = new BigMAnalyzer(manager);
+
+            int changed = analyzer.Tighten(analyzer.Analyze());
+
+            Assert.Equal(3, changed);
+            var rows = manager.Equations.ToDictionary(e => e.Label!, e => e.Evaluate(manager));
+            Assert.Equal(-50, rows["link"].coefficients["y"]);
+            Assert.Equal(10, rows["demand"].coefficients["k"]);
+            Assert.Equal(10, rows["demand"].constant);
+            Assert.Equal(30, rows["capped"].coefficients["w"]);
+            Assert.Equal(50, rows["capped"].constant);
+
+            Assert.DoesNotContain(analyzer.Analyze(), f => f.CanTighten);
+        }
+
+        [Fact]
+        public void Analyze_UnexpandedModel_ShouldThrow()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse("range I = 1..2; dvar bool y[I]; dvar float+ x[I]; forall(i in I) c: x[i] - 1000*y[i] <= 0;"));
+
+            Assert.Throws<InvalidOperationException>(() => new BigMAnalyzer(manager).Analyze());
+        }
+    }
+}
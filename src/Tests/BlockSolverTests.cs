@@ -0,0 +1,119 @@
+using Core;
+using Core.Analysis;
+using Core.Solving;
+
+namespace Tests
+{
+    public class BlockSolverTests : TestBase
+    {
+        // Plant A after a what-if edit of its unit capacities (the reference was solved with 6 and 6)
+        private const string Model = @"
+// @block plantA
+dvar float+ a1;
+dvar float+ a2;
+dvar float+ a;
+unitsA: a1 + a2 - a == 0;
+cap1: a1 <= 5;
+cap2: a2 <= 7;
+// @endblock
+dvar float+ b;
+demand: a + b >= 12;
+minimize a1 + 2*a2 + 3*b;
+";
+
+        private static readonly SolveResult Reference = new SolveResult
+        {
+            Status = SolveStatus.Optimal,
+            ObjectiveValue = 18,
+            VariableValues = new Dictionary<string, double> { ["a1"] = 6, ["a2"] = 6, ["a"] = 12, ["b"] = 0 }
+        };
+
+        private class DelegateDriver : ISolverDriver
+        {
+            private readonly Func<ModelManager, SolveResult> solve;
+
+            public DelegateDriver(Func<ModelManager, SolveResult> solve)
+            {
+                this.solve = solve;
+            }
+
+            public string Name => "Delegate";
+
+            public SolveResult Solve(ModelManager manager) => solve(manager);
+        }
+
+        private ModelManager Expand() => ParseAndExpand(Model);
+
+        [Fact]
+        public void Solve_ShouldFixBoundaryAndOtherBlocksAtReference()
+        {
+            var manager = Expand();
+            int rows = manager.Equations.Count;
+            List<string>? solvedRows = null;
+            Dictionary<string, double>? fixedValues = null;
+
+            var driver = new DelegateDriver(m =>
+            {
+                solvedRows = m.Equations.Select(e => e.Label ?? "").ToList();
+                fixedValues = m.Equations
+                    .Where(e => e.Label!.StartsWith("fix_"))
+                    .ToDictionary(e => e.Coefficients.Keys.Single(), e => e.Constant.Evaluate(m));
+                return new SolveResult
+                {
+                    Status = SolveStatus.Optimal,
+                    ObjectiveValue = 19,
+                    VariableValues = new Dictionary<string, double> { ["a1"] = 5, ["a2"] = 7, ["a"] = 12, ["b"] = 0 }
+                };
+            });
+
+            var result = new BlockSolver(driver).Solve(manager, ModelTags.Parse(Model), "plantA", Reference);
+
+            Assert.Equal(new[] { "unitsA", "cap1", "cap2", "fix_a", "fix_b" }, solvedRows);
+            Assert.Equal(new Dictionary<string, double> { ["a"] = 12, ["b"] = 0 }, fixedValues);
+            Assert.Equal(rows, manager.Equations.Count);
+            Assert.Contains(manager.Equations, e => e.Label == "demand");
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(new[] { "a1", "a2" }, result.FreeVariables);
+            Assert.Equal(new[] { "a" }, result.BoundaryVariables);
+            Assert.Equal(12, result.Solution.VariableValues["a"]);
+            Assert.Equal("Block plantA: Optimal", result.Solution.StatusMessage);
+            Assert.Equal(
+                "Block plantA: Optimal, objective 19 (full model 18)" + Environment.NewLine +
+                "  3 rows, 2 free variables, 1 boundary variables fixed" + Environment.NewLine +
+                "  Changed:" + Environment.NewLine +
+                "    a1: 6 -> 5" + Environment.NewLine +
+                "    a2: 6 -> 7" + Environment.NewLine,
+                result.ToReport());
+        }
+
+        [Fact]
+        public void Solve_InfeasibleBlock_ShouldKeepNoSolution()
+        {
+            var manager = Expand();
+            var driver = new DelegateDriver(_ => new SolveResult { Status = SolveStatus.Infeasible, StatusMessage = "boundary a = 12 cannot be met" });
+
+            var result = new BlockSolver(driver).Solve(manager, "units", e => e.Label is "unitsA" or "cap1" or "cap2", Reference);
+
+            Assert.Equal(SolveStatus.Infeasible, result.Status);
+            Assert.Empty(result.Solution.VariableValues);
+            Assert.Empty(result.Changes);
+            Assert.Equal("Block units: boundary a = 12 cannot be met", result.Solution.StatusMessage);
+        }
+
+        [Fact]
+        public void Solve_ShouldUseLastSolutionAndRejectEmptyBlocks()
+        {
+            var manager = Expand();
+            var solver = new BlockSolver(new DelegateDriver(m => Reference));
+
+            var missing = Assert.Throws<InvalidOperationException>(() => solver.Solve(manager, ModelTags.Parse(Model), "plantA"));
+            Assert.Contains("solve the full model first", missing.Message);
+
+            manager.Solution = Reference;
+            Assert.Empty(solver.Solve(manager, ModelTags.Parse(Model), "plantA").Changes);
+            var empty = Assert.Throws<InvalidOperationException>(() => solver.Solve(manager, ModelTags.Parse(Model), "plantB"));
+            Assert.Equal("Block 'plantB' has no constraints", empty.Message);
+        }
+    }
+}
@@ -0,0 +1,102 @@
+using Core.Decomposition;
+using Core.Generation;
+using Core.Visualization;
+
+namespace Tests
+{
+    public class BlockStructureDetectorTests : TestBase
+    {
+        private ConstraintMatrix Generate(ModelStructure structure, int blocks, int seed)
+        {
+            var generated = new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = 40,
+                Columns = 30,
+                Density = 0.4,
+                Structure = structure,
+                Blocks = blocks,
+                Seed = seed
+            }).Generate();
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(generated.Model.ToModelText());
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            return ConstraintMatrix.Build(manager);
+        }
+
+        [Fact]
+        public void Detect_BlockAngularModel_ShouldFindTheLinkingRows()
+        {
+            var matrix = Generate(ModelStructure.BlockAngular, 3, 1);
+
+            var analysis = new BlockStructureDetector().Detect(matrix);
+
+            Assert.Equal(BlockStructureKind.BlockAngular, analysis.Kind);
+            Assert.Equal(Enumerable.Range(1, 10).Select(i => $"link{i}"), analysis.LinkingRows);
+            Assert.Empty(analysis.LinkingColumns);
+            Assert.Equal(3, analysis.Blocks.Count);
+            foreach (var block in analysis.Blocks)
+                Assert.All(block.Rows, row => Assert.StartsWith($"b{block.Number}_", row));
+            Assert.Contains("Suggested decomposition: Dantzig-Wolfe or Lagrangian relaxation of the linking rows", analysis.ToReport());
+
+            var arranged = analysis.Arrange(matrix);
+            Assert.Equal(matrix.Entries.Count, arranged.Entries.Count);
+            Assert.Equal(new[] { "block 1", "block 2", "block 3", "linking" }, arranged.RowFamilies.Distinct());
+            Assert.Equal(Enumerable.Repeat("linking", 10), arranged.RowFamilies.Skip(30));
+        }
+
+        [Fact]
+        public void Detect_StaircaseModel_ShouldOnlyCoupleConsecutiveBlocks()
+        {
+            var matrix = Generate(ModelStructure.Staircase, 5, 3);
+
+            var analysis = new BlockStructureDetector().Detect(matrix);
+
+            Assert.Equal(BlockStructureKind.Staircase, analysis.Kind);
+            Assert.True(analysis.Blocks.Count >= 3);
+            Assert.Empty(analysis.LinkingRows);
+            foreach (var (row, column, _) in matrix.Entries)
+            {
+                int rowBlock = analysis.GetRowBlock(matrix.RowNames[row])!.Value;
+                int columnBlock = analysis.GetColumnBlock(matrix.ColumnNames[column])!.Value;
+                Assert.Contains(rowBlock - columnBlock, new[] { 0, 1 });
+            }
+        }
+
+        [Fact]
+        public void Detect_SharedFirstStageColumn_ShouldProposeBendersWithTheColumnInTheMaster()
+        {
+            // Four scenarios that share only the capacity built in the first stage
+            var matrix = new ConstraintMatrix();
+            matrix.ColumnNames.Add("build");
+            matrix.ColumnFamilies.Add("build");
+            for (int s = 1; s <= 4; s++)
+            {
+                matrix.ColumnNames.AddRange(new[] { $"use[{s}]", $"buy[{s}]" });
+                matrix.ColumnFamilies.AddRange(new[] { "use", "buy" });
+                int use = matrix.ColumnCount - 2, buy = matrix.ColumnCount - 1;
+
+                matrix.RowNames.Add($"demand[{s}]");
+                matrix.RowFamilies.Add("demand");
+                matrix.Entries.Add((matrix.RowCount - 1, use, 1));
+                matrix.Entries.Add((matrix.RowCount - 1, buy, 1));
+
+                matrix.RowNames.Add($"capacity[{s}]");
+                matrix.RowFamilies.Add("capacity");
+                matrix.Entries.Add((matrix.RowCount - 1, use, 1));
+                matrix.Entries.Add((matrix.RowCount - 1, 0, -1));
+            }
+
+            var analysis = new BlockStructureDetector().Detect(matrix);
+
+            Assert.Equal(BlockStructureKind.DualBlockAngular, analysis.Kind);
+            Assert.Equal(new[] { "build" }, analysis.LinkingColumns);
+            Assert.Equal(4, analysis.Blocks.Count);
+            Assert.Equal(new[] { "demand[2]", "capacity[2]" }, analysis.Blocks[1].Rows);
+            Assert.Equal(new SortedSet<int> { 1, 2, 3, 4 }, analysis.RowFamilyBlocks["capacity"]);
+            Assert.Equal(new SortedSet<int> { 0 }, analysis.ColumnFamilyBlocks["build"]);
+        }
+    }
+}
@@ -0,0 +1,70 @@
+using Core;
+using Core.Export;
+
+namespace Tests
+{
+    public class BoundExpressionTests : TestBase
+    {
+        private const string Model = @"
+range P = 1..2;
+range T = 1..2;
+float capacity[P] = ...;
+float availability[P][T] = ...;
+float maxReserve = ...;
+dvar float+ x[p in P, t in T] in 0..capacity[p] * availability[p, t];
+dvar float+ reserve in 0..maxReserve;
+maximize sum(p in P, t in T) x[p,t] + reserve;
+";
+
+        private (ModelManager Manager, ParseSessionResult Result) Expand(string data)
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            AssertNoErrors(result);
+
+            var dataResult = new DataFileParser(manager).Parse(data);
+            Assert.False(dataResult.HasErrors, string.Join(", ", dataResult.GetErrorMessages()));
+
+            parser.ExpandAllTemplates(result);
+            return (manager, result);
+        }
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldEvaluateBoundsPerElement()
+        {
+            var (manager, result) = Expand("capacity = [100, 50];\navailability = [[1, 0.5], [0.8, 0]];\nmaxReserve = 12;");
+
+            AssertNoErrors(result);
+            var x = manager.IndexedVariables["x"];
+            Assert.Equal("capacity[p] * availability[p, t]", x.UpperBoundExpression);
+            Assert.Equal((0.0, 50.0), x.GetBounds("x1_2"));
+            Assert.Equal((0.0, 40.0), x.GetBounds("x2_1"));
+            Assert.Equal((0.0, 0.0), x.GetBounds("x2_2"));
+            Assert.Equal(12.0, manager.IndexedVariables["reserve"].UpperBound);
+        }
+
+        [Fact]
+        public void Export_ShouldFollowTheLoadedData()
+        {
+            var (first, _) = Expand("capacity = [100, 50];\navailability = [[1, 1], [1, 1]];\nmaxReserve = 12;");
+            var (second, _) = Expand("capacity = [100, 50];\navailability = [[1, 1], [0.25, 1]];\nmaxReserve = 3;");
+
+            Assert.Matches(@"UP BOUND1\s+x2_1\s+50\r?\n", new MPSExporter(first).Export());
+            string mps = new MPSExporter(second).Export();
+            Assert.Matches(@"UP BOUND1\s+x2_1\s+12\.5\r?\n", mps);
+            Assert.Matches(@"UP BOUND1\s+reserve\s+3\r?\n", mps);
+        }
+
+        [Fact]
+        public void Parse_BoundReferringToUndeclaredName_ShouldFail()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+
+            var result = parser.Parse("range P = 1..2;\ndvar float+ x[p in P] in 0..capacty[p];");
+
+            AssertHasError(result, "'capacty' is not a declared parameter");
+        }
+    }
+}
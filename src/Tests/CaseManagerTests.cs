@@ -0,0 +1,147 @@
+using Core;
+using Core.Services;
+using Core.Solving;
+
+namespace Tests
+{
+    public class CaseManagerTests : IDisposable
+    {
+        private const string Model =
+            "int nT = ...;\n" +
+            "range T = 1..nT;\n" +
+            "float demand[T] = ...;\n" +
+            "dvar float+ x[T];\n" +
+            "dvar float+ total;\n" +
+            "forall(t in T) x[t] >= demand[t];\n" +
+            "minimize total;\n";
+
+        /// <summary>
+        /// Sets each x to its demand, as the solver would, and counts the cases it runs at once
+        /// </summary>
+        private class DemandDriver : ISolverDriver
+        {
+            private int running;
+            public int Calls;
+            public int MaxRunning;
+
+            public string Name => "Demand";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                int now = Interlocked.Increment(ref running);
+                lock (this)
+                {
+                    Calls++;
+                    MaxRunning = Math.Max(MaxRunning, now);
+                }
+                Thread.Sleep(20);
+                Interlocked.Decrement(ref running);
+
+                var values = manager.Parameters["demand"].GetIndexedEntries()
+                    .ToDictionary(e => "x" + e.Key, e => Convert.ToDouble(e.Value));
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = values.Values.Sum(), VariableValues = values };
+            }
+        }
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "cases-" + Guid.NewGuid().ToString("N"));
+
+        public CaseManagerTests()
+        {
+            Directory.CreateDirectory(directory);
+            File.WriteAllText(Path.Combine(directory, "plan.mod"), Model);
+            File.WriteAllText(Path.Combine(directory, "plan.dat"), "nT = 2;\ndemand = [10, 30];\n");
+            File.WriteAllText(Path.Combine(directory, "high.case"), "demand = [15, 45];\n");
+            File.WriteAllText(Path.Combine(directory, "long.case"), "nT = 3;\ndemand = [10, 30, 20];\n");
+            File.WriteAllText(Path.Combine(directory, "typo.case"), "demnd = [1, 2];\n");
+        }
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private CaseManager CreateCases()
+        {
+            var cases = CaseManager.Open(directory);
+            cases.ModelFiles.Add("plan.mod");
+            cases.DataFiles.Add("plan.dat");
+            cases.Register("base");
+            cases.Register("high", "high.case", "Demand up 50%");
+            cases.Register("long", "long.case");
+            cases.Register("typo", "typo.case");
+            return cases;
+        }
+
+        [Fact]
+        public async Task RunAsync_ShouldSolveEachCaseInParallelAndArchiveIt()
+        {
+            var cases = CreateCases();
+            cases.MaxParallelism = 2;
+            var driver = new DemandDriver();
+
+            var runs = await cases.RunAsync(driver);
+
+            Assert.Equal(new[] { "base: Optimal 40", "high: Optimal 60", "long: Optimal 60", "typo: Error" }, runs.Select(r => r.ToString()));
+            Assert.Equal(3, driver.Calls);
+            Assert.InRange(driver.MaxRunning, 1, 2);
+            Assert.Equal("Case 'typo' line 1: 'demnd' is not a parameter of the model; did you mean 'demand'?", Assert.Single(runs[3].Errors));
+            Assert.All(runs, r => Assert.True(File.Exists(Path.Combine(directory, r.ArchivePath!))));
+
+            // The archive keeps every run
+            await cases.RunAsync(driver, new[] { "high" });
+            var history = cases.History("high");
+            Assert.Equal(2, history.Count);
+            Assert.Equal(45.0, history[1].Result.VariableValues["x2"]);
+            Assert.Equal("Demand", history[1].Solver);
+
+            Assert.Equal(
+                "Case  Status   Objective        Change  Time (s)   x\n" +
+                "base  Optimal         40                    0.00  40\n" +
+                "high  Optimal         60  +20 (+50.0%)      0.00  60\n" +
+                "long  Optimal         60  +20 (+50.0%)      0.00  60\n" +
+                "typo  Error                                 0.00\n",
+                cases.Compare().ToTable().Replace(Environment.NewLine, "\n"));
+        }
+
+        [Fact]
+        public async Task Compare_ShouldShowChosenVariablesAsCsv()
+        {
+            var cases = CreateCases();
+            await cases.RunAsync(new DemandDriver(), new[] { "long", "base" });
+
+            var comparison = cases.Compare(new[] { "base", "long", "high" });
+            comparison.Variables.AddRange(new[] { "x3", "x1" });
+
+            Assert.Equal(
+                "Case,Status,Objective,Change,Time (s),x3,x1\n" +
+                "base,Optimal,40,,0.00,,10\n" +
+                "long,Optimal,60,+20 (+50.0%),0.00,20,10\n",
+                comparison.ToCsv().Replace(Environment.NewLine, "\n"));
+        }
+
+        [Fact]
+        public async Task Registry_ShouldRoundTripAndRejectBadCases()
+        {
+            var cases = CreateCases();
+            cases.Save();
+
+            var reopened = CaseManager.Open(directory);
+            Assert.Equal(new[] { "plan.mod" }, reopened.ModelFiles);
+            Assert.Equal(new[] { "base (base)", "high (high.case)", "long (long.case)", "typo (typo.case)" }, reopened.Cases.Select(c => c.ToString()));
+            Assert.Equal("Demand up 50%", reopened.Find("high")!.Description);
+
+            Assert.Equal("Case 'high' is already registered",
+                Assert.Throws<InvalidOperationException>(() => reopened.Register("high")).Message);
+            Assert.Equal("Case name '../x' may only contain letters, digits, '_', '-' and '.'",
+                Assert.Throws<InvalidOperationException>(() => reopened.Register("../x")).Message);
+            Assert.Equal("Overlay file not found: low.case",
+                Assert.Throws<InvalidOperationException>(() => reopened.Register("low", "low.case")).Message);
+            Assert.Equal("Unknown case 'low'",
+                (await Assert.ThrowsAsync<InvalidOperationException>(() => reopened.RunAsync(new DemandDriver(), new[] { "low" }))).Message);
+
+            Assert.True(reopened.Remove("typo"));
+            Assert.Empty(reopened.History("typo"));
+        }
+    }
+}
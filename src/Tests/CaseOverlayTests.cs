@@ -0,0 +1,94 @@
+using Core;
+using Core.Models;
+
+namespace Tests
+{
+    public class CaseOverlayTests : TestBase
+    {
+        private const string Model =
+            "int nT = ...;\n" +
+            "range T = 1..nT;\n" +
+            "{string} Scenarios = ...;\n" +
+            "float demand[T] = ...;\n" +
+            "dvar float+ x[T] in 0..50;\n" +
+            "dvar float+ y;\n" +
+            "forall(t in T) x[t] >= demand[t];\n" +
+            "minimize y;\n";
+
+        private const string Data =
+            "nT = 3;\n" +
+            "Scenarios = {\"base\", \"high\", \"peak\"};\n" +
+            "demand = [10, 20, 30];\n";
+
+        private static (ModelManager Manager, ParseResult Result) Load(params CaseOverlay[] overlays)
+        {
+            var manager = new ModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            service.Overlays.AddRange(overlays);
+            return (manager, service.ParseModel(new List<string> { Model }, new List<string> { Data }));
+        }
+
+        [Fact]
+        public void Overlay_ShouldSetValuesBoundsAndSelectionsOverTheData()
+        {
+            var overlay = CaseOverlay.Parse(
+                "// high demand over four periods\n" +
+                "nT = 4;\n" +
+                "demand = [15, 25,\n" +
+                "          35, 45];\n" +
+                "x.ub = 40; y.lb = 1;\n" +
+                "select Scenarios = {\"peak\", \"high\"};\n",
+                "high");
+
+            Assert.Equal(new[] { "line 2: nT", "line 3: demand", "line 5: x.ub", "line 5: y.lb", "line 6: select Scenarios" },
+                overlay.Entries.Select(e => e.ToString()));
+
+            var (manager, result) = Load(overlay);
+
+            Assert.Empty(result.Errors);
+            Assert.Equal(4, Convert.ToInt32(manager.Parameters["nT"].Value));
+            Assert.Equal(45.0, Convert.ToDouble(manager.Parameters["demand"].GetIndexedValue(4)));
+            Assert.Equal(40.0, manager.IndexedVariables["x"].UpperBound);
+            Assert.Equal(0.0, manager.IndexedVariables["x"].LowerBound);
+            Assert.Equal(1.0, manager.IndexedVariables["y"].LowerBound);
+            Assert.Equal(new object[] { "high", "peak" }, manager.PrimitiveSets["Scenarios"].GetAllValues());
+            Assert.Equal(4, manager.MaterializeRows().Count);
+        }
+
+        [Fact]
+        public void Overlay_WithUnresolvedKeys_ShouldReportEveryKeyAndNotBeApplied()
+        {
+            var overlay = CaseOverlay.Parse(
+                "demnd = [1, 2, 3];\n" +
+                "z.ub = 5;\n" +
+                "x.lb = 60;\n" +
+                "Scenarios = {\"low\"};\n" +
+                "select Scenarios = {\"low\", \"high\"};\n" +
+                "nT = 5;\n" +
+                "nT = 6;\n" +
+                "minimize y;\n",
+                "broken");
+
+            var (manager, result) = Load(overlay);
+
+            Assert.Equal(new[]
+            {
+                "Case 'broken': Line 8: 'minimize y' is not a parameter value, bound or selection",
+                "Case 'broken' line 7: 'nT' is already set on line 6",
+                "Case 'broken' line 1: 'demnd' is not a parameter of the model; did you mean 'demand'?",
+                "Case 'broken' line 2: 'z' is not a variable of the model",
+                "Case 'broken' line 4: 'Scenarios' is not a parameter of the model; use 'select Scenarios = {...}' to choose members of a set",
+                "Case 'broken' line 5: 'low' is not a member of set 'Scenarios'",
+                "Case 'broken' line 3: bounds of 'x' are empty: 60..50"
+            }, result.Errors);
+
+            // Nothing of the case reached the model
+            Assert.Equal(new[] { 10.0, 20.0, 30.0 }, Enumerable.Range(1, 3).Select(i => Convert.ToDouble(manager.Parameters["demand"].GetIndexedValue(i))));
+            Assert.Equal(0.0, manager.IndexedVariables["x"].LowerBound);
+            Assert.Equal(3, manager.PrimitiveSets["Scenarios"].Count);
+        }
+    }
+}
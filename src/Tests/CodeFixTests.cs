@@ -0,0 +1,139 @@
+using Core;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Server;
+
+namespace Tests
+{
+    public class CodeFixTests : TestBase
+    {
+        [Fact]
+        public void ChangeSet_ShouldApplyAllEditsOrNone()
+        {
+            var source = ModelSource.Parse("float cost = 4;\ndvar float+ x;\nminimize cost * x;\n");
+
+            var stale = new ChangeSet("stale", ModelEdit.Upsert("float cost = 5;"), ModelEdit.Remove("variable:y"));
+            var ex = Assert.Throws<InvalidOperationException>(() => stale.Apply(source));
+            Assert.Contains("remove variable:y", ex.Message);
+            Assert.Equal("float cost = 4;\ndvar float+ x;\nminimize cost * x;\n", source.ToString());
+
+            var objective = source.Statements.Single(s => s.Key == "objective");
+            new ChangeSet("edit", ModelEdit.Upsert("float cost = 5;"), ModelEdit.Rewrite(objective, "minimize 2 * cost * x;")).Apply(source);
+            Assert.Equal("float cost = 5;\ndvar float+ x;\nminimize 2 * cost * x;\n", source.ToString());
+        }
+
+        [Fact]
+        public void Rewrite_UnkeyedStatement_ShouldNotApplyAfterItChanged()
+        {
+            var source = ModelSource.Parse("dvar float+ x;\nminimize x;\nx >= 1;\n");
+            var edit = ModelEdit.Rewrite(source.FindAt(3)!, "x >= 2;");
+
+            Assert.Null(edit.Key);
+            Assert.True(edit.CanApply(source));
+            Assert.Equal("dvar float+ x;\nminimize x;\nx >= 2;\n", new ChangeSet("tighten", edit).Apply(source.ToString()));
+            Assert.False(edit.CanApply(ModelSource.Parse("dvar float+ x;\nminimize x;\nx >= 3;\n")));
+        }
+
+        [Fact]
+        public void For_Suggestion_ShouldOfferReplacementsAndParameterDeclaration()
+        {
+            const string text = "dvar float+ cost;\ndvar float+ cats;\nminimize cots + cost;";
+            var result = CreateParser().Parse(text);
+            var source = ModelSource.Parse(text);
+
+            var actions = CodeFixes.For(Assert.Single(result.Suggestions), source);
+
+            Assert.Equal(new[] { "Replace 'cots' with 'cats'", "Replace 'cots' with 'cost'", "Declare parameter 'cots' = 0" },
+                actions.Select(a => a.Title));
+            Assert.True(actions[0].IsPreferred);
+            Assert.Equal(3, actions[0].LineNumber);
+            Assert.Equal(CodeFixes.UnresolvedReference, actions[0].RuleId);
+            Assert.Equal("dvar float+ cost;\ndvar float+ cats;\nminimize cost + cost;", actions[1].Edit.Apply(text));
+            Assert.Contains("float cots = 0;", actions[2].Edit.Apply(text));
+        }
+
+        [Fact]
+        public void Lint_LooseBound_ShouldOfferTightenedDeclaration()
+        {
+            const string text = "dvar float+ x;\ndvar int y in 0..100;\nminimize x + y;\nlimit: 2 * x <= 10;\nfloor: y >= 2.5;\ncap: y <= 40;";
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(text);
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+
+            var report = new ModelLinter(manager) { ModelTexts = new[] { text } }.Lint();
+            var findings = report.Findings.Where(f => f.RuleId == "loose-bound").ToList();
+
+            Assert.Equal(new[] { "x", "y" }, findings.Select(f => f.Subject));
+            Assert.Equal("Tighten bounds of 'x' to 0..5", Assert.Single(findings[0].Fixes).Title);
+            Assert.Contains("dvar int y in 3..40;", Assert.Single(findings[1].Fixes).Apply(text));
+
+            var actions = CodeFixes.Collect(ModelSource.Parse(text), result.Suggestions, report);
+            Assert.Equal(1, actions.Single(a => a.Title.Contains("'x'")).LineNumber);
+        }
+
+        [Fact]
+        public void Lint_UnusedEntities_ShouldOfferRemoval()
+        {
+            const string text = "range Spare = 1..2;\nfloat unused = 3;\ndvar float+ x;\ndvar float+ idle;\nminimize x;\nc: x >= 1;";
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(text);
+            parser.ExpandAllTemplates(result);
+
+            var report = new ModelLinter(manager) { ModelTexts = new[] { text } }.Lint();
+            var source = ModelSource.Parse(text);
+            var actions = CodeFixes.Collect(source, result.Suggestions, report);
+
+            Assert.Equal(new[] { "Remove unused set 'Spare'", "Remove unused parameter 'unused'", "Remove unused variable 'idle'" },
+                actions.Where(a => a.Title.StartsWith("Remove")).Select(a => a.Title));
+
+            foreach (var action in actions.Where(a => a.Title.StartsWith("Remove")))
+                action.Edit.Apply(source);
+            Assert.Equal("dvar float+ x;\nminimize x;\nc: x >= 1;", source.ToString().Trim());
+        }
+
+        [Fact]
+        public void ModelHost_ApplyChangeSet_ShouldApplyCodeActionAndLogIt()
+        {
+            var host = new ModelHost();
+            var model = host.Create("typo", "float cost = 4;\ndvar float+ x;\ndvar float+ idle;\nminimize cots * x;\n");
+            Assert.NotEmpty(model.Errors);
+
+            var actions = host.GetCodeActions(model.Id);
+            var rename = actions.First(a => a.IsPreferred && a.RuleId == CodeFixes.UnresolvedReference);
+            Assert.Equal("Replace 'cots' with 'cost'", rename.Title);
+
+            int version = model.Version;
+            int revision = model.GetRevision("objective");
+            var result = host.ApplyChangeSet(model.Id, rename.Edit, expectedVersion: version);
+
+            Assert.True(result.Success);
+            Assert.Empty(model.Errors);
+            Assert.Contains("minimize cost * x;", model.ModelText);
+            Assert.Equal(version + 1, result.Version);
+            Assert.Equal(revision + 1, model.GetRevision("objective"));
+
+            var stale = host.ApplyChangeSet(model.Id, rename.Edit, expectedVersion: version);
+            Assert.True(stale.Conflict);
+
+            var remove = host.GetCodeActions(model.Id).Single(a => a.RuleId == "unused-variable");
+            Assert.True(host.ApplyChangeSet(model.Id, remove.Edit).Success);
+            Assert.DoesNotContain("idle", model.ModelText);
+            Assert.False(host.ApplyChangeSet(model.Id, remove.Edit).Success);
+        }
+
+        [Fact]
+        public void ModelHost_ApplyChangeSet_ShouldRollBackNewErrors()
+        {
+            var host = new ModelHost();
+            var model = host.Create("flow", "dvar float+ x;\nminimize x;\n");
+
+            var result = host.ApplyChangeSet(model.Id, new ChangeSet("break", ModelEdit.Upsert("minimize x + y;")));
+
+            Assert.False(result.Success);
+            Assert.Equal("dvar float+ x;\nminimize x;\n", model.ModelText);
+        }
+    }
+}
@@ -0,0 +1,102 @@
+using Core.Server;
+
+namespace Tests
+{
+    public class CollaborationTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private static (ModelHost Host, HostedModel Model, EntityReplica A, EntityReplica B) CreateSession()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+            return (host, model,
+                new EntityReplica("alice", model.ModelText, model.Sequence),
+                new EntityReplica("bob", model.ModelText, model.Sequence));
+        }
+
+        private static void Sync(ModelHost host, HostedModel model, EntityReplica replica)
+        {
+            replica.Merge(host.Sync(model.Id, replica.Sequence, replica.Pending));
+        }
+
+        [Fact]
+        public void ConcurrentEditsOfDifferentEntities_ShouldBothSurvive()
+        {
+            var (host, model, a, b) = CreateSession();
+
+            a.Upsert("float capacity = 30;");
+            b.Upsert("float cost = 2;");
+            Sync(host, model, a);
+            Sync(host, model, b);
+            Sync(host, model, a);
+
+            Assert.Equal(a.ToText(), b.ToText());
+            Assert.Equal("float capacity = 30;", b.GetStatement("parameter:capacity"));
+            Assert.Equal("float cost = 2;", a.GetStatement("parameter:cost"));
+            Assert.Contains("float capacity = 30;", model.ModelText);
+            Assert.Contains("float cost = 2;", model.ModelText);
+        }
+
+        [Fact]
+        public void ConcurrentEditsOfSameEntity_ShouldConvergeRegardlessOfSyncOrder()
+        {
+            var (host, model, a, b) = CreateSession();
+
+            a.Upsert("float capacity = 30;");
+            b.Upsert("float capacity = 40;");
+            Sync(host, model, b);
+            Sync(host, model, a);
+            Sync(host, model, b);
+
+            // Equal timestamps: the greater replica id ("bob") wins on every replica
+            Assert.Equal("float capacity = 40;", a.GetStatement("parameter:capacity"));
+            Assert.Equal("float capacity = 40;", b.GetStatement("parameter:capacity"));
+            Assert.Contains("float capacity = 40;", model.ModelText);
+        }
+
+        [Fact]
+        public void RemoveAfterSeeingEdit_ShouldWin()
+        {
+            var (host, model, a, b) = CreateSession();
+
+            a.Upsert("float capacity = 30;");
+            Sync(host, model, a);
+            Sync(host, model, b);
+            b.Remove("parameter:capacity");
+            Sync(host, model, b);
+            Sync(host, model, a);
+
+            Assert.Null(a.GetStatement("parameter:capacity"));
+            Assert.DoesNotContain("capacity =", model.ModelText);
+            Assert.Equal(a.ToText(), b.ToText());
+        }
+
+        [Fact]
+        public void ServerEdits_ShouldReachReplicasThroughLog()
+        {
+            var (host, model, a, _) = CreateSession();
+
+            host.ApplyEntity(model.Id, new EntityDefinition { Kind = Core.Analysis.EntityKind.Parameter, Type = "float", Name = "capacity", Value = "50" });
+            Sync(host, model, a);
+
+            Assert.Equal("float capacity = 50;", a.GetStatement("parameter:capacity"));
+            Assert.Equal(1, a.Sequence);
+            Assert.Empty(host.Sync(model.Id, a.Sequence, a.Pending).Operations);
+        }
+
+        [Fact]
+        public void Sync_StatementForOtherKey_ShouldThrow()
+        {
+            var (host, model, _, _) = CreateSession();
+            var operation = new EntityOperation { Key = "parameter:cost", Statement = "float capacity = 1;", Timestamp = 1, ReplicaId = "x" };
+
+            Assert.Throws<InvalidOperationException>(() => host.Sync(model.Id, 0, new[] { operation }));
+        }
+    }
+}
@@ -0,0 +1,94 @@
+using Core;
+using Core.Analysis;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ConstraintExplainerTests : TestBase
+    {
+        private ModelManager BuildModel()
+        {
+            return ParseAndExpand(
+                "range I = 1..2;\n" +
+                "// @unit l/h\n" +
+                "float cap = 10;\n" +
+                "// @unit t\n" +
+                "dvar float+ x[I];\n" +
+                "// @unit h\n" +
+                "dvar float+ y;\n" +
+                "minimize y;\n" +
+                "forall(i in I) lo: x[i] >= 1;\n" +
+                "fuel: cap*y <= 20;\n" +
+                "mix: 3*x[1] + 2*y >= 1;\n");
+        }
+
+        [Fact]
+        public void Explain_ShouldSubstituteParametersAndEvaluateTerms()
+        {
+            var manager = BuildModel();
+            var values = new Dictionary<string, double> { ["x1"] = 1, ["x2"] = 1, ["y"] = 2 };
+
+            var explanation = new ConstraintExplainer(manager).Explain("fuel", values);
+
+            Assert.Equal("cap·y ≤ 20", explanation.Formula);
+            Assert.Equal("10·y ≤ 20", explanation.Substituted);
+            var term = Assert.Single(explanation.Terms);
+            Assert.Equal(10, term.Coefficient);
+            Assert.Equal(20, term.Contribution);
+            Assert.Equal("l/h", term.CoefficientUnit);
+            Assert.Equal("h", term.VariableUnit);
+            Assert.Equal(new[] { "variable:y", "parameter:cap" }, term.References);
+            Assert.Equal(ConstraintState.Binding, explanation.State);
+            Assert.Equal("fuel is binding: left side 20 ≤ 20; largest terms: y (20)", explanation.Summary);
+            Assert.Contains("y: cap = 10 l/h × 2 h = 20", explanation.ToText());
+        }
+
+        [Theory]
+        [InlineData(0.1, ConstraintState.Violated, "violated by 0.9")]
+        [InlineData(1.0, ConstraintState.Binding, "binding")]
+        [InlineData(3.0, ConstraintState.Satisfied, "satisfied with slack 2")]
+        public void Explain_ShouldClassifyRowAtSolution(double x, ConstraintState expected, string summary)
+        {
+            var manager = BuildModel();
+
+            var explanation = new ConstraintExplainer(manager).Explain("lo[1]", new Dictionary<string, double> { ["x1"] = x });
+
+            Assert.Equal("lo_1", explanation.Constraint);
+            Assert.Equal(expected, explanation.State);
+            Assert.Contains(summary, explanation.Summary);
+        }
+
+        [Fact]
+        public void Explain_OnModel_ShouldUseCurrentSolution()
+        {
+            var manager = BuildModel();
+
+            Assert.Equal(ConstraintState.Unknown, manager.Explain("mix").State);
+
+            manager.Solution = new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                VariableValues = new Dictionary<string, double> { ["x1"] = 1, ["x2"] = 1, ["y"] = 0 }
+            };
+            var explanation = manager.Explain("mix");
+
+            Assert.Equal(3, explanation.Activity);
+            Assert.Equal(ConstraintState.Satisfied, explanation.State);
+            Assert.Equal("3·x1 + 2·y ≥ 1", explanation.Substituted);
+            Assert.Equal(new[] { ("t", (string?)null), ("h", null) }, explanation.Terms.Select(t => (t.VariableUnit!, t.CoefficientUnit)));
+            Assert.Throws<InvalidOperationException>(() => manager.Explain("missing"));
+        }
+
+        [Fact]
+        public void ToMarkdown_ShouldLinkReferencedEntities()
+        {
+            var manager = BuildModel();
+
+            string markdown = new ConstraintExplainer(manager).Explain("fuel", new Dictionary<string, double> { ["y"] = 1 }).ToMarkdown();
+
+            Assert.Contains("### [fuel](#constraint:fuel)", markdown);
+            Assert.Contains("| [y](#variable:y) | cap = 10 l/h | 1 h | 10 | [cap](#parameter:cap) |", markdown);
+            Assert.Contains("satisfied with slack 10", markdown);
+        }
+    }
+}
@@ -0,0 +1,129 @@
+using Core;
+using Core.Models;
+
+namespace Tests
+{
+    public class ConstraintRuleTests : TestBase
+    {
+        private const string Model =
+            "int nT = ...;\n" +
+            "range T = 1..nT;\n" +
+            "float inflow[T] = ...;\n" +
+            "dvar float+ storage[T];\n" +
+            "dvar float+ release[T];\n" +
+            "dvar float+ total;\n" +
+            "forall(t in T) balance: storage[t] + release[t] == inflow[t];\n" +
+            "forall(t in T) release[t] <= 5;\n" +
+            "minimize total;\n";
+
+        private static (ModelManager Manager, EquationParser Parser) Load(bool defer)
+        {
+            var manager = new ModelManager { DeferRuleExpansion = defer };
+            var parser = new EquationParser(manager);
+            var service = new ModelParsingService(manager, parser, new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            var result = service.ParseModel(new List<string> { Model }, new List<string> { "nT = 4;\ninflow = [1, 2, 3, 4];\n" });
+            Assert.Empty(result.Errors);
+            return (manager, parser);
+        }
+
+        [Fact]
+        public void DeferredRules_ShouldBeStoredUnexpandedUntilMaterialized()
+        {
+            Assert.False(new ModelManager().DeferRuleExpansion);
+            var (manager, _) = Load(defer: true);
+
+            Assert.Equal(new[] { "balance", "forall2" }, manager.ConstraintRules.Select(manager.RuleName));
+            Assert.Equal(2, manager.PendingRules.Count);
+            Assert.Empty(manager.Equations);
+            Assert.Equal("forall(t in T) balance: storage[t] + release[t] == inflow[t]", manager.FindRule("balance")!.Source);
+
+            // Materializing generates a temporary row set and leaves the model as it was
+            var rows = manager.MaterializeRows();
+            Assert.Equal(8, rows.Count);
+            Assert.Same(manager.FindRule("balance"), rows.Single(e => e.Label == "balance_3").Rule);
+            Assert.Empty(manager.Equations);
+            Assert.Equal(2, manager.PendingRules.Count);
+            Assert.Null(manager.GetEquationByLabel("balance_3"));
+
+            using (manager.MaterializeInPlace())
+            {
+                Assert.Equal(8, manager.Equations.Count);
+                Assert.Empty(manager.PendingRules);
+                Assert.NotNull(manager.GetEquationByLabel("balance_3"));
+            }
+            Assert.Empty(manager.Equations);
+            Assert.Equal(2, manager.PendingRules.Count);
+            Assert.Null(manager.GetEquationByLabel("balance_3"));
+
+            // Explicit expansion keeps the rows; collapsing drops them again
+            manager.ExpandPendingRules();
+            Assert.Equal(8, manager.Equations.Count);
+            Assert.Empty(manager.PendingRules);
+            Assert.Equal(8, manager.CollapseRules());
+            Assert.Empty(manager.Equations);
+            Assert.Equal(8, manager.MaterializeRows().Count);
+        }
+
+        [Fact]
+        public void MaterializeRows_ShouldNameTheRuleThatFailsWithoutChangingTheModel()
+        {
+            var manager = new ModelManager { DeferRuleExpansion = true };
+            var parser = new EquationParser(manager);
+            AssertNoErrors(parser.Parse("range T = 1..3;\ndvar float x[T];\nforall(t in T) cap: x[t] <= 1;\nminimize 0;\n"));
+            parser.ExpandAllTemplates(new ParseSessionResult());
+
+            // The rule is only expanded when its rows are materialized, so that is where the limit bites
+            manager.ExpansionLimit = 2;
+
+            var error = Assert.Throws<InvalidOperationException>(() => manager.MaterializeRows());
+            Assert.StartsWith("Error expanding rule 'cap'", error.Message);
+            Assert.Throws<InvalidOperationException>(() => manager.MaterializeInPlace());
+            Assert.Empty(manager.Equations);
+            Assert.Single(manager.PendingRules);
+        }
+
+        [Fact]
+        public void ReplaceRule_ShouldRegenerateOnlyThatRulesConstraints()
+        {
+            var (manager, parser) = Load(defer: false);
+            Assert.Equal(8, manager.Equations.Count);
+            var limit = manager.FindRule("forall2")!;
+
+            var old = manager.GetEquationByLabel("balance_3");
+            parser.ReplaceRule("balance", "forall(t in T) balance: storage[t] == inflow[t];");
+
+            Assert.Equal(8, manager.Equations.Count);
+            Assert.DoesNotContain(old, manager.Equations);
+            Assert.Equal("balance_3: storage3 == 3", manager.GetEquationByLabel("balance_3")!.ToString());
+            Assert.Equal("forall(t in T) balance: storage[t] == inflow[t]", manager.FindRule("balance")!.Source);
+            Assert.Equal(4, manager.Equations.Count(e => e.Rule == limit));
+
+            Assert.Equal("Unknown constraint rule 'flow'",
+                Assert.Throws<InvalidOperationException>(() => parser.ReplaceRule("flow", "forall(t in T) release[t] <= 1")).Message);
+
+            Assert.True(manager.RemoveRule(limit));
+            Assert.Equal(4, manager.Equations.Count);
+        }
+
+        [Fact]
+        public void InstantiateRule_ShouldGenerateOnlyTheMatchingInstances()
+        {
+            var (manager, _) = Load(defer: true);
+
+            var instances = manager.InstantiateRule("balance", new Dictionary<string, string> { ["t"] = "3" });
+
+            Assert.Equal("balance_3: release3 + storage3 == 3", Assert.Single(instances).ToString());
+            Assert.Same(manager.FindRule("balance"), instances[0].Rule);
+            Assert.Empty(manager.Equations);
+            Assert.Equal(2, manager.PendingRules.Count);
+
+            Assert.Empty(manager.InstantiateRule("forall2", new Dictionary<string, string> { ["t"] = "9" }));
+            Assert.Equal(4, manager.InstantiateRule("forall2", new Dictionary<string, string>()).Count);
+            Assert.Equal("Rule 'balance' has no iterator 'p'; its iterators are t",
+                Assert.Throws<InvalidOperationException>(() => manager.InstantiateRule("balance", new Dictionary<string, string> { ["p"] = "a" })).Message);
+        }
+    }
+}
@@ -0,0 +1,114 @@
+using Core;
+using Core.Export;
+using Core.Models;
+
+namespace Tests
+{
+    public class ConstraintTableTests : TestBase
+    {
+        [Fact]
+        public void Rows_ShouldReadBackAsWritten()
+        {
+            var rule = new ForallStatement { Label = "cap" };
+            var capacity = new ParameterExpression("capacity");
+            var table = new ConstraintTable();
+            table.Add(new LinearEquation(
+                new Dictionary<string, Expression> { ["x1_2"] = new ConstantExpression(2), ["y"] = capacity },
+                new ConstantExpression(4),
+                RelationalOperator.LessThanOrEqual,
+                "cap_1_2") { BaseName = "cap", Index = 1, SecondIndex = 2, Rule = rule });
+            table.Add(new LinearEquation(new Dictionary<string, Expression> { ["y"] = new ConstantExpression(-1) }, capacity, RelationalOperator.Equal));
+
+            var cap = table[0];
+            Assert.Equal("cap_1_2: 2*x1_2 + (capacity)*y <= 4", cap.ToString());
+            Assert.Same(capacity, cap.Coefficients["y"]);
+            Assert.Equal(("cap", 1, 2), (cap.BaseName, cap.Index, cap.SecondIndex));
+            Assert.Same(rule, cap.Rule);
+
+            var balance = table[1];
+            Assert.Null(balance.Label);
+            Assert.Null(balance.Index);
+            Assert.Null(balance.Rule);
+            Assert.Same(capacity, balance.Constant);
+
+            // Copies are handed out; the table keeps its rows
+            cap.Coefficients.Clear();
+            Assert.Equal(2, table.GetTermCount(0));
+            Assert.Equal(2, table[0].VariableCount);
+        }
+
+        [Fact]
+        public void Terms_ShouldBeReadableWithoutDecodingTheRow()
+        {
+            var capacity = new ParameterExpression("capacity");
+            var manager = new ModelManager();
+            manager.AddParameter(new Parameter("capacity", ParameterType.Float, 5.0));
+            var table = new ConstraintTable();
+            table.Add(new LinearEquation(
+                new Dictionary<string, Expression> { ["x"] = new ConstantExpression(2), ["y"] = capacity },
+                new ConstantExpression(4),
+                RelationalOperator.LessThanOrEqual,
+                "cap"));
+
+            var ids = table.GetTermIds(0).ToArray();
+            Assert.Equal(new[] { "x", "y" }, ids.Select(table.GetName));
+            Assert.Equal(2, table.EvaluateCoefficient(0, 0, manager));
+            Assert.Equal(5, table.EvaluateCoefficient(0, 1, manager));
+            Assert.Same(capacity, table.GetCoefficient(0, 1));
+
+            var header = table.GetHeader(0);
+            Assert.Equal(("cap", RelationalOperator.LessThanOrEqual), (header.Label, header.Operator));
+            Assert.Empty(header.Coefficients);
+            Assert.Equal(new[] { "x", "y" }, Assert.Single(ConstraintTable.VariablesOf(table)));
+        }
+
+        [Fact]
+        public void MaterializeRows_ShouldStorePendingRulesColumnWise()
+        {
+            var manager = new ModelManager { DeferRuleExpansion = true };
+            var parser = CreateParser(manager);
+            AssertNoErrors(parser.Parse(
+                "range T = 1..1000;\n" +
+                "dvar float+ x[T];\n" +
+                "dvar float+ total;\n" +
+                "forall(t in T) cap: x[t] <= total;\n" +
+                "forall(t in T) floor: x[t] >= 1;\n" +
+                "minimize total;\n"));
+            parser.ExpandAllTemplates(new ParseSessionResult());
+
+            var rows = Assert.IsType<ConstraintTable>(manager.MaterializeRows());
+
+            Assert.Equal(2000, rows.Count);
+            // 1000 elements of x, total, and the labels of both families
+            Assert.Equal(1001 + 2000 + 2, rows.NameCount);
+            Assert.Equal("floor_7: x7 >= 1", rows[1006].ToString());
+            Assert.Same(manager.FindRule("floor"), rows[1006].Rule);
+            Assert.Empty(manager.Equations);
+        }
+
+        [Fact]
+        public void MpsExport_ShouldReadPendingRulesColumnWise()
+        {
+            const string model =
+                "range T = 1..3;\n" +
+                "float limit[T] = [4, 5, 6];\n" +
+                "dvar float+ x[T];\n" +
+                "dvar float+ total;\n" +
+                "forall(t in T) cap: x[t] - limit[t] * total <= 0;\n" +
+                "maximize sum(t in T) x[t];\n";
+
+            string Export(bool defer)
+            {
+                var manager = new ModelManager { DeferRuleExpansion = defer };
+                var parser = CreateParser(manager);
+                AssertNoErrors(parser.Parse(model));
+                parser.ExpandAllTemplates(new ParseSessionResult());
+                return new MPSExporter(manager).Export("TEST");
+            }
+
+            string deferred = Export(true);
+            Assert.Equal(Export(false), deferred);
+            Assert.Contains("cap_3", deferred);
+        }
+    }
+}
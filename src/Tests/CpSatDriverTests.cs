@@ -0,0 +1,108 @@
+using Core;
+using Core.Export;
+using Core.Solving;
+
+namespace Tests
+{
+    public class CpSatDriverTests : TestBase
+    {
+        private const string KnapsackModel = @"
+            dvar int x in 0..10;
+            dvar int y in 0..5;
+            maximize 3*x + 2*y;
+            cap: x + y <= 8;
+        ";
+
+        [Fact]
+        public void Build_ShouldWriteVariablesConstraintsObjectiveAndHints()
+        {
+            var builder = new CpSatModelBuilder(IntegerModel.FromModel(ParseAndExpand(KnapsackModel)));
+
+            string proto = builder.Build(new Dictionary<string, double> { ["y"] = 4, ["unknown"] = 1 });
+
+            Assert.Contains("variables { name: \"x\" domain: 0 domain: 10 }", proto);
+            Assert.Contains($"linear {{ vars: 0 vars: 1 coeffs: 1 coeffs: 1 domain: {long.MinValue} domain: 8 }}", proto);
+            Assert.Contains("objective { vars: 0 vars: 1 coeffs: -3 coeffs: -2 offset: 0 scaling_factor: -1 }", proto);
+            Assert.Contains("solution_hint { vars: 1 values: 4 }", proto);
+        }
+
+        [Fact]
+        public void Build_Disjunction_ShouldUseEnforcementLiterals()
+        {
+            var manager = ParseAndExpand(@"
+                dvar int a in 0..10;
+                dvar int b in 0..10;
+                minimize a + b;
+                (a >= 3) || (b >= 2);
+            ");
+            var builder = new CpSatModelBuilder(IntegerModel.FromModel(manager));
+
+            string proto = builder.Build();
+
+            Assert.Equal(2, builder.ModelVariableCount);
+            Assert.Contains("enforcement_literal: 2", proto);
+            Assert.Contains("enforcement_literal: 3", proto);
+            Assert.Contains("bool_or { literals: 3 literals: 2 }", proto);
+        }
+
+        [Fact]
+        public void ParseResponse_ShouldMapValuesAndBounds()
+        {
+            var builder = new CpSatModelBuilder(IntegerModel.FromModel(ParseAndExpand(KnapsackModel)));
+            builder.Build();
+
+            var result = CpSatDriver.ParseResponse(@"
+status: OPTIMAL
+solution: 8
+solution: 0
+objective_value: 24
+best_objective_bound: 24
+solution_info: ""default_lp""
+", builder);
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(8, result.VariableValues["x"]);
+            Assert.Equal(0, result.VariableValues["y"]);
+            Assert.Equal(24, result.ObjectiveValue);
+            Assert.Equal(24, result.BestBound);
+        }
+
+        [Theory]
+        [InlineData("#2       0.12s best:8     next:[5,7]      fixed_bools:0/3", SolverEventKind.Incumbent, 8.0, 5.0)]
+        [InlineData("#3       0.30s best:24    next:[25,30]    fixed_bools:0/3", SolverEventKind.Incumbent, 24.0, 30.0)]
+        [InlineData("#Bound   0.20s best:8     next:[6,7]      am_sat", SolverEventKind.Bound, null, 6.0)]
+        [InlineData("#4       0.41s best:8     next:[]         fixed_bools:3/3", SolverEventKind.Incumbent, 8.0, 8.0)]
+        public void ParseLogLine_ShouldMapSearchProgress(string line, SolverEventKind kind, double? objective, double bound)
+        {
+            var solverEvent = CpSatDriver.ParseLogLine(line);
+
+            Assert.NotNull(solverEvent);
+            Assert.Equal(kind, solverEvent!.Kind);
+            Assert.Equal(objective, solverEvent.ObjectiveValue);
+            Assert.Equal(bound, solverEvent.BestBound);
+        }
+
+        [Fact]
+        public void ParseLogLine_ShouldIgnoreOtherOutput()
+        {
+            Assert.Null(CpSatDriver.ParseLogLine("#Bound   0.01s best:inf   next:[0,13]     initial_domain"));
+            Assert.Null(CpSatDriver.ParseLogLine("#Done    0.41s best:8     next:[]         am_sat"));
+            Assert.Null(CpSatDriver.ParseLogLine("Starting CP-SAT solver v9.8"));
+        }
+
+        [Fact]
+        public void Solve_ContinuousModel_ShouldReturnError()
+        {
+            var manager = ParseAndExpand(@"
+                dvar float+ z;
+                minimize z;
+                c: z >= 1;
+            ");
+
+            var result = new CpSatDriver().Solve(manager);
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Contains("not a bounded-integer model", result.StatusMessage);
+        }
+    }
+}
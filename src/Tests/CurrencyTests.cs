@@ -0,0 +1,80 @@
+namespace Tests
+{
+    public class CurrencyTests : TestBase
+    {
+        private const string Model =
+            "range T = 1..2;\n" +
+            "// @money reporting EUR\n" +
+            "// @money USD\n" +
+            "float freight[t in T] = [10, 20];\n" +
+            "// @money EUR\n" +
+            "float storage = 3;\n" +
+            "// @rate USD EUR\n" +
+            "float usdEur[T] = [0.5, 0.8];\n" +
+            "dvar float+ ship[T];\n" +
+            "dvar float+ stock;\n";
+
+        [Fact]
+        public void Parse_Objective_ShouldBeConvertedToTheReportingCurrencyPerPeriod()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(Model +
+                "minimize sum(t in T) freight[t] * ship[t] + storage * stock;\n");
+
+            AssertNoErrors(result);
+            Assert.Contains("minimize sum(t in T) freight[t] * usdEur[t] * ship[t] + storage * stock;", manager.SourceTexts.Single());
+            Assert.Equal("USD", manager.Parameters["freight"].Currency);
+            Assert.Equal("EUR", manager.Currencies.ReportingCurrency);
+        }
+
+        [Fact]
+        public void Parse_DecisionExpressionInAnotherCurrency_ShouldBeConvertedInTheObjective()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(Model.Replace("float usdEur[T] = [0.5, 0.8];", "float usdEur = 0.5;") +
+                "dexpr float freightCost = sum(t in T) freight[t] * ship[t];\n" +
+                "minimize freightCost;\n");
+
+            AssertNoErrors(result);
+            Assert.Equal("USD", manager.Currencies.Money["freightCost"]);
+            Assert.Equal("EUR", manager.Currencies.Money["freightCost__EUR"]);
+            Assert.Contains("dexpr float freightCost__EUR = sum(t in T) freight[t] * usdEur * ship[t];", manager.SourceTexts.Single());
+            Assert.Contains("minimize freightCost__EUR;", manager.SourceTexts.Single());
+        }
+
+        [Fact]
+        public void Parse_MixedCurrenciesWithoutConversion_ShouldBeErrors()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(Model +
+                "subject to {\n" +
+                "  budget: sum(t in T) freight[t] * ship[t] <= storage;\n" +
+                "  converted: sum(t in T) freight[t] * usdEur[t] * ship[t] <= storage;\n" +
+                "}\n");
+
+            var error = Assert.Single(result.Errors);
+            Assert.Equal(12, error.LineNumber);
+            Assert.Contains("mixes amounts in EUR and USD without a conversion", error.Message);
+        }
+
+        [Fact]
+        public void Parse_ObjectiveWithoutRate_ShouldReportTheMissingRate()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "// @money reporting EUR\n" +
+                "// @money GBP\n" +
+                "float fee = 2;\n" +
+                "// @rate USD eur\n" +
+                "float usdEur = 0.9;\n" +
+                "dvar float+ x;\n" +
+                "minimize fee * x;\n");
+
+            Assert.Equal(new[]
+            {
+                (5, "Invalid currency code 'eur' (expected three capital letters such as EUR)"),
+                (7, "No exchange rate from GBP to EUR for 'fee' in the objective")
+            }, result.Errors.Select(e => (e.LineNumber, e.Message)));
+        }
+    }
+}
@@ -0,0 +1,95 @@
+using Core;
+using Core.Formatting;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class DataLocaleTests : TestBase
+    {
+        private const string Model =
+            "range I = 1..3;\n" +
+            "float rate = ...;\n" +
+            "float price[I] = ...;\n" +
+            "tuple Order { string id; string due; float amount; }\n" +
+            "{Order} orders = ...;\n";
+
+        private ModelManager Load()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+            return manager;
+        }
+
+        [Fact]
+        public void Parse_DecimalCommaLocale_ShouldReadCommaDecimalsAndDates()
+        {
+            var manager = Load();
+            var parser = new DataFileParser(manager) { Locale = DataLocale.Create("de-DE", "dd.MM.yyyy") };
+
+            var result = parser.Parse(
+                "rate = 3,14;\n" +
+                "price = [1,5, 2, 3,25];\n" +
+                "orders = { <\"A1\", 01.02.2024, 12,5>, <\"A2\" \"28.02.2024\" 7> };\n");
+
+            AssertNoErrors(result);
+            Assert.Equal(3.14, manager.Parameters["rate"].Value);
+            Assert.Equal(new object?[] { 1.5, 2.0, 3.25 }, new[] { 1, 2, 3 }.Select(i => manager.Parameters["price"].GetIndexedValue(i)));
+            var orders = manager.TupleSets["orders"].Instances;
+            Assert.Equal(new object?[] { "2024-02-01", "2024-02-28" }, orders.Select(o => o.GetValue("due")));
+            Assert.Equal(new object?[] { 12.5, 7.0 }, orders.Select(o => o.GetValue("amount")));
+        }
+
+        [Fact]
+        public void Parse_CommaDecimalInInvariantLocale_ShouldBeAnErrorNotAThousandsSeparator()
+        {
+            var manager = Load();
+
+            var result = new DataFileParser(manager).Parse("rate = 3,14;\n");
+            Assert.Contains(result.Errors, e => e.Message.Contains("Cannot parse '3,14' as float"));
+
+            var german = new DataFileParser(manager) { Locale = DataLocale.Create("de-DE") };
+            Assert.Contains(german.Parse("rate = 3.14;\n").Errors, e => e.Message.Contains("Cannot parse '3.14' as float (de-DE)"));
+        }
+
+        [Fact]
+        public void Parse_ColumnOverrides_ShouldApplyToTheirColumnOnly()
+        {
+            var manager = Load();
+            var locale = DataLocale.Parse(
+                "{ \"culture\": \"de-DE\", \"dateFormat\": \"dd.MM.yyyy\",\n" +
+                "  \"columns\": { \"rate\": { \"culture\": \"invariant\" }, \"orders.due\": { \"dateFormat\": \"MM/dd/yyyy\" } } }");
+
+            var result = new DataFileParser(manager) { Locale = locale }.Parse(
+                "rate = 0.5;\n" +
+                "price = [1,5 2 3];\n" +
+                "orders = { <\"A1\" \"02/01/2024\" 1,25> };\n");
+
+            AssertNoErrors(result);
+            Assert.Equal(0.5, manager.Parameters["rate"].Value);
+            Assert.Equal(1.5, manager.Parameters["price"].GetIndexedValue(1));
+            var order = Assert.Single(manager.TupleSets["orders"].Instances);
+            Assert.Equal("2024-02-01", order.GetValue("due"));
+            Assert.Equal(1.25, order.GetValue("amount"));
+            Assert.Equal("de-DE, dates MM/dd/yyyy", locale.For("orders.due").ToString());
+        }
+
+        [Fact]
+        public void Format_ShouldRoundTripValuesAndKeepDecimalCommasWhenFormatting()
+        {
+            var locale = DataLocale.Create("de-DE", "dd.MM.yyyy");
+            foreach (double value in new[] { 0.1, 3.14, 1.0 / 3, -2.5e-12, 123456789.125 })
+            {
+                string text = locale.Format(value);
+                Assert.True(locale.TryParseNumber(text, out double read));
+                Assert.Equal(value, read);
+            }
+            Assert.Equal("3,14", locale.Format(3.14));
+            Assert.Equal("\"01.02.2024\"", locale.Format("2024-02-01"));
+            Assert.Equal("2024-02-01", locale.ReadString("01.02.2024"));
+            Assert.Equal("\"2024-02-01\"", DataLocale.Invariant.Format("2024-02-01"));
+
+            var formatter = new ModelFormatter(new ModelFormatOptions { DecimalComma = true });
+            Assert.Equal("price = [1,5, 2 3,25];\n", formatter.FormatData("price=[1,5,  2 3,25];\n"));
+        }
+    }
+}
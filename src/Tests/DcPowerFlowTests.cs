@@ -0,0 +1,83 @@
+using Core.Networks;
+
+namespace Tests
+{
+    public class DcPowerFlowTests : TestBase
+    {
+        private static Network Triangle()
+        {
+            var network = new Network("grid");
+            network.AddNode("a", 10);
+            network.AddNode("b");
+            network.AddNode("c", -10);
+            network.AddArc("a", "b", capacity: 40).Reactance = 1;
+            network.AddArc("b", "c", capacity: 30).Reactance = 1;
+            network.AddArc("a", "c", capacity: 5).Reactance = 1;
+            return network;
+        }
+
+        [Fact]
+        public void Ptdf_ShouldSplitTheTransferOverTheParallelPaths()
+        {
+            var ptdf = DcPowerFlow.Ptdf(Triangle(), "c");
+
+            Assert.Equal(1.0 / 3, ptdf[0, 0], 9);
+            Assert.Equal(2.0 / 3, ptdf[2, 0], 9);
+            Assert.Equal(-1.0 / 3, ptdf[0, 1], 9);
+            Assert.Equal(2.0 / 3, ptdf[1, 1], 9);
+            Assert.Equal(0, ptdf[2, 2]);
+        }
+
+        [Fact]
+        public void Generate_AngleMethod_ShouldWriteAModelThatParses()
+        {
+            var result = DcPowerFlow.Generate(Triangle(), new DcPowerFlowOptions { SlackNode = "c" });
+
+            Assert.Contains("dc_slack: dc_angle_c == 0;", result.Statements);
+            Assert.Contains("dc_ohm_a_b: dc_flow_a_b == 1 * dc_angle_a - 1 * dc_angle_b;", result.Statements);
+            Assert.Contains("dc_balance_c: -dc_flow_b_c - dc_flow_a_c == -10;", result.Statements);
+            Assert.Contains("dc_limitReverse_a_c: dc_flow_a_c >= -5;", result.Statements);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var parsed = parser.Parse(string.Join("\n", result.Statements) + "\nminimize dc_flow_a_c;\n");
+            AssertNoErrors(parsed);
+            parser.ExpandAllTemplates(parsed);
+            AssertNoErrors(parsed);
+            Assert.Equal(new[] { "dc_angle_a", "dc_angle_b", "dc_flow_a_b" },
+                manager.GetEquationByLabel("dc_ohm_a_b")!.Coefficients.Keys.OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Generate_PtdfMethod_ShouldAddOutageLimitsAndSkipOutagesThatSplitTheGrid()
+        {
+            var network = Triangle();
+            network.AddNode("d");
+            network.AddArc("c", "d", capacity: 8).Reactance = 0.5;
+
+            var result = DcPowerFlow.Generate(network, new DcPowerFlowOptions
+            {
+                Method = DcPowerFlowMethod.Ptdf,
+                SlackNode = "c",
+                Injection = "gen[{node}] - load[{node}]",
+                Contingencies = { "a->c", "c -> d" }
+            });
+
+            Assert.Contains("dc_ptdf_a_c: dc_flow_a_c == 0.666666666667 * (gen[\"a\"] - load[\"a\"]) + 0.333333333333 * (gen[\"b\"] - load[\"b\"]);", result.Statements);
+            Assert.Contains("dc_outage_a_c_a_b: dc_flow_a_b + 1 * dc_flow_a_c <= 40;", result.Statements);
+            Assert.Contains("dc_outage_a_c_b_c: dc_flow_b_c + 1 * dc_flow_a_c <= 30;", result.Statements);
+            Assert.Contains("gen[\"d\"] - load[\"d\"]", result.Statements.Single(s => s.StartsWith("dc_balance:")));
+            Assert.Equal("The outage of c -> d splits the network; it is left out", Assert.Single(result.Warnings));
+        }
+
+        [Fact]
+        public void Generate_ShouldRejectLinesWithoutReactance()
+        {
+            var network = Triangle();
+            network.AddArc("c", "a");
+
+            var error = Assert.Throws<InvalidOperationException>(() => DcPowerFlow.Generate(network));
+            Assert.Contains("c -> a: a line needs a positive reactance", error.Message);
+        }
+    }
+}
@@ -0,0 +1,58 @@
+using Core;
+using Core.Analysis;
+using Core.Models;
+
+namespace Tests
+{
+    public class DefinitionCycleTests : TestBase
+    {
+        private static ModelManager CreateCycle()
+        {
+            var manager = new ModelManager();
+            manager.DecisionExpressions["total"] = new DecisionExpression("total", VariableType.Float,
+                new BinaryExpression(new DecisionExpressionExpression("cost"), BinaryOperator.Add, new ConstantExpression(1)));
+            manager.DecisionExpressions["cost"] = new DecisionExpression("cost", VariableType.Float,
+                new BinaryExpression(new ParameterExpression("rate"), BinaryOperator.Multiply, new ConstantExpression(2)));
+            manager.Parameters["rate"] = new Parameter("rate", ParameterType.Float, new List<string>(), new DecisionExpressionExpression("total"));
+            manager.DecisionExpressions["fine"] = new DecisionExpression("fine", VariableType.Float, new DecisionExpressionExpression("cost"));
+            return manager;
+        }
+
+        [Fact]
+        public void Find_ShouldReportTheCyclePathAcrossKinds()
+        {
+            var cycles = DefinitionCycles.Find(CreateCycle());
+
+            var cycle = Assert.Single(cycles);
+            Assert.Equal(new[] { "dexpr cost", "parameter rate", "dexpr total", "dexpr cost" }, cycle);
+            Assert.Empty(DefinitionCycles.Find(CreateModelManager()));
+        }
+
+        [Fact]
+        public void Evaluate_CircularDefinition_ShouldFailWithThePath()
+        {
+            var manager = new ModelManager();
+            manager.DecisionExpressions["a"] = new DecisionExpression("a", VariableType.Float, new DecisionExpressionExpression("b"));
+            manager.DecisionExpressions["b"] = new DecisionExpression("b", VariableType.Float,
+                new BinaryExpression(new DecisionExpressionExpression("a"), BinaryOperator.Add, new ConstantExpression(1)));
+
+            var ex = Assert.Throws<InvalidOperationException>(() => manager.DecisionExpressions["a"].Evaluate(manager));
+
+            Assert.Equal("Circular definition: dexpr a -> dexpr b -> dexpr a", ex.Message);
+            Assert.NotNull(new DecisionExpressionExpression("a").Simplify(manager));
+        }
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldStopAtCycles()
+        {
+            var manager = CreateCycle();
+            var result = new ParseSessionResult();
+
+            CreateParser(manager).ExpandAllTemplates(result);
+
+            AssertHasError(result, "Circular definition: dexpr cost -> parameter rate -> dexpr total -> dexpr cost");
+            Assert.False(manager.ValidateDexprDependencies(out string error));
+            Assert.Contains("dexpr cost", error);
+        }
+    }
+}
@@ -0,0 +1,93 @@
+using Core;
+using Core.Analysis;
+using Core.Models;
+
+namespace Tests
+{
+    public class DifferentiatorTests : TestBase
+    {
+        private const string Model =
+            "range I = 1..3;\n" +
+            "float a = 3;\n" +
+            "dvar float x;\n" +
+            "dvar float y;\n" +
+            "dvar float z[I];\n";
+
+        private (ModelManager Manager, EquationParser Parser) CreateModel()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            AssertNoErrors(parser.Parse(Model));
+            return (manager, parser);
+        }
+
+        [Fact]
+        public void Gradient_ShouldApplyTheChainAndProductRules()
+        {
+            var (manager, parser) = CreateModel();
+            var point = new Dictionary<string, double> { ["x"] = 2, ["y"] = 0.5 };
+
+            // a * x * y + sin(x * y)
+            var product = parser.ParseExpression("x * y");
+            var expression = new BinaryExpression(
+                new BinaryExpression(new ParameterExpression("a"), BinaryOperator.Multiply, product),
+                BinaryOperator.Add,
+                new MathFunctionExpression { Function = MathFunction.Sin, Arguments = new[] { product } });
+            var gradient = Differentiator.Gradient(expression, manager);
+
+            Assert.Equal(new[] { "x", "y" }, gradient.Keys);
+            Assert.Equal(3 * 0.5 + Math.Cos(1) * 0.5, Differentiator.Evaluate(gradient["x"], point), 12);
+            Assert.Equal(3 * 2 + Math.Cos(1) * 2, Differentiator.Evaluate(gradient["y"], point), 12);
+
+            var power = Differentiator.Differentiate(parser.ParseExpression("x ^ 3"), "x", manager);
+            Assert.Equal("(3 * (x ^ 2))", power.ToString());
+            Assert.Equal("0", Differentiator.Differentiate(parser.ParseExpression("a * y"), "x", manager).ToString());
+        }
+
+        [Fact]
+        public void Normalize_ShouldExpandSumsOverIndexedVariables()
+        {
+            var (manager, parser) = CreateModel();
+            var expression = parser.ParseExpression("sum(i in I) z[i] * z[i]");
+
+            var normalized = Differentiator.Normalize(expression, manager);
+            var gradient = Differentiator.Gradient(expression, manager);
+
+            Assert.Equal(new[] { "z1", "z2", "z3" }, Differentiator.Variables(normalized));
+            Assert.Equal(8.0, Differentiator.Evaluate(gradient["z2"], new Dictionary<string, double> { ["z2"] = 4 }));
+        }
+
+        [Fact]
+        public void Structure_ShouldListJacobianAndLowerHessianNonzeros()
+        {
+            var (manager, parser) = CreateModel();
+            var structure = DerivativeStructure.Analyze(
+                new BinaryExpression(parser.ParseExpression("x * x"), BinaryOperator.Add, new VariableExpression("y")),
+                new[] { parser.ParseExpression("x * y"), new BinaryExpression(parser.ParseExpression("z[1]"), BinaryOperator.Add, parser.ParseExpression("2 * y")) },
+                manager);
+
+            Assert.Equal(new[] { "x", "y", "z1" }, structure.Variables);
+            Assert.Equal(new[] { (0, "x"), (0, "y"), (1, "z1"), (1, "y") }, structure.Jacobian);
+            Assert.Equal(new[] { ("x", "x"), ("y", "x") }, structure.Hessian);
+            Assert.False(structure.IsLinear);
+        }
+
+        [Fact]
+        public void Linearize_ShouldGiveTheTangentConstraintAtAPoint()
+        {
+            var (manager, parser) = CreateModel();
+            var point = new Dictionary<string, double> { ["x"] = 2, ["y"] = 3 };
+
+            // x*y <= 10 around (2, 3): 6 + 3(x - 2) + 2(y - 3) <= 10, i.e. 3x + 2y <= 16
+            var tangent = Differentiator.Linearize(parser.ParseExpression("x * y"), RelationalOperator.LessThanOrEqual, 10, point, manager, "cut");
+
+            Assert.Equal(3.0, tangent.GetCoefficient("x"));
+            Assert.Equal(2.0, tangent.GetCoefficient("y"));
+            Assert.Equal(16.0, tangent.Constant.Evaluate(manager));
+            Assert.Equal("cut", tangent.Label);
+
+            var ex = Assert.Throws<InvalidOperationException>(() => Differentiator.Gradient(parser.ParseExpression("max(x, y)"), manager));
+            Assert.Contains("not differentiable", ex.Message);
+        }
+    }
+}
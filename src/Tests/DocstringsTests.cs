@@ -0,0 +1,74 @@
+using Core;
+using Core.Analysis;
+using Core.Parsing;
+using Core.Server;
+
+namespace Tests
+{
+    public class DocstringsTests : TestBase
+    {
+        private const string Model =
+            "\"\"\"Plants of the network,\n" +
+            "   one per site\"\"\"\n" +
+            "range Plants = 1..2;\n" +
+            "#: Capacity of each plant\n" +
+            "#: in tonnes per day\n" +
+            "float capacity[Plants] = [10, 20];\n" +
+            "dvar float+ ship[Plants]; #: Amount shipped\n" +
+            "// a plain comment\n" +
+            "#: Total shipped\n" +
+            "maximize sum(p in Plants) ship[p];\n" +
+            "#: Shipments stay within capacity\n" +
+            "forall(p in Plants) cap: ship[p] <= capacity[p];\n";
+
+        [Fact]
+        public void Parse_ShouldReadDocstringsIntoTheMetadata()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            Assert.Equal("Plants of the network,\none per site", manager.Ranges["Plants"].Description);
+            Assert.Equal("Capacity of each plant\nin tonnes per day", manager.Parameters["capacity"].Description);
+            Assert.Equal("Amount shipped", manager.IndexedVariables["ship"].Description);
+            Assert.Equal("Total shipped", manager.Documentation["objective"]);
+            Assert.Equal("Shipments stay within capacity", manager.Documentation["constraint:cap"]);
+            Assert.Equal(5, manager.Documentation.Count);
+
+            var catalog = EntityCatalog.Build(manager);
+            Assert.Contains("Shipments stay within capacity", catalog.Get("constraint:cap")!.Details);
+        }
+
+        [Fact]
+        public void Parse_ShouldReportStrayAndUnterminatedDocstrings()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "dvar float x in 0..4;\n" +
+                "#: Not attached to anything\n" +
+                "x >= 1;\n" +
+                "\"\"\"Never closed\n" +
+                "minimize x;\n");
+
+            Assert.Equal("Line 2: docstring does not document a named declaration", result.Warnings.Single());
+            Assert.Equal(("Unterminated docstring (missing closing \"\"\")", 4), result.Errors.Select(e => (e.Message, e.LineNumber)).Single());
+            Assert.Equal("dvar float x in 0..4;\n\nx >= 1;\n\n\n", Docstrings.Strip("dvar float x in 0..4;\n#: Not attached to anything\nx >= 1;\n\"\"\"Never closed\nminimize x;\n"));
+        }
+
+        [Fact]
+        public void Hover_ShouldShowTheDeclarationAndItsDocstring()
+        {
+            var host = new ModelHost();
+            var model = host.Create("plants", Model);
+
+            var hover = host.GetHover(model.Id, 12, 40)!;
+
+            Assert.Equal("parameter:capacity", hover.Key);
+            Assert.Equal((12, 37, 45), (hover.LineNumber, hover.StartColumn, hover.EndColumn));
+            Assert.Equal("```\nfloat capacity[Plants] = [10, 20]\n```\n\nCapacity of each plant\nin tonnes per day", hover.Contents);
+
+            Assert.Equal("objective: Total shipped", ModelHover.At(Model, 10, 3)!.ToString());
+            Assert.Equal("variable:ship: Amount shipped", ModelHover.At(Model, 12, 27)!.ToString());
+            Assert.Null(ModelHover.At(Model, 12, 2));
+        }
+    }
+}
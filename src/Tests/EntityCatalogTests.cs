@@ -0,0 +1,91 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class EntityCatalogTests : TestBase
+    {
+        private const string Model = @"
+            range Nodes = 1..3;
+            float capacity = 25;
+            float cost = 2;
+            dvar float+ flow[Nodes];
+            dvar float+ spill;
+            minimize sum(n in Nodes) cost * flow[n] + spill;
+            forall(n in Nodes) cap: flow[n] <= capacity;
+            balance: sum(n in Nodes) flow[n] + spill >= 10;
+        ";
+
+        private ModelManager ParseModel(string input, bool expand)
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(input);
+            AssertNoErrors(result);
+            if (expand)
+                parser.ExpandAllTemplates(result);
+            return manager;
+        }
+
+        [Fact]
+        public void Build_ShouldIndexEntitiesByKind()
+        {
+            var catalog = EntityCatalog.Build(ParseModel(Model, expand: false));
+
+            Assert.NotNull(catalog.Get("set:Nodes"));
+            Assert.NotNull(catalog.Get("parameter:capacity"));
+            Assert.NotNull(catalog.Get("variable:flow"));
+            Assert.NotNull(catalog.Get("constraint:cap"));
+            Assert.NotNull(catalog.Get("constraint:balance"));
+            Assert.NotNull(catalog.Get("objective"));
+
+            var groups = catalog.GetGroups().ToList();
+            Assert.Equal("Sets", groups[0]);
+            Assert.Equal("Parameters", groups[1]);
+            Assert.Equal("Variables", groups[2]);
+        }
+
+        [Fact]
+        public void Build_ShouldLinkReferencesInBothDirections()
+        {
+            var catalog = EntityCatalog.Build(ParseModel(Model, expand: false));
+
+            var cap = catalog.Get("constraint:cap")!;
+            Assert.Contains("variable:flow", cap.References);
+            Assert.Contains("parameter:capacity", cap.References);
+            Assert.Contains("set:Nodes", cap.References);
+            Assert.DoesNotContain("parameter:n", cap.References);
+
+            var capacity = catalog.Get("parameter:capacity")!;
+            Assert.Contains("constraint:cap", capacity.ReferencedBy);
+
+            var flow = catalog.Get("variable:flow")!;
+            Assert.Contains("constraint:balance", flow.ReferencedBy);
+            Assert.Contains("objective", flow.ReferencedBy);
+        }
+
+        [Fact]
+        public void Build_ExpandedModel_ShouldGroupConstraintsByFamily()
+        {
+            var catalog = EntityCatalog.Build(ParseModel(Model, expand: true));
+
+            var family = catalog.GetGroups().Single(g => g.StartsWith("Constraints/cap"));
+            Assert.Equal(3, catalog.GetGroup(family).Count());
+
+            var instance = catalog.GetGroup(family).First();
+            Assert.Contains("variable:flow", instance.References);
+            Assert.Contains(instance.Key, catalog.Get("variable:flow")!.ReferencedBy);
+        }
+
+        [Fact]
+        public void Search_ShouldMatchSubstringsAndWildcards()
+        {
+            var catalog = EntityCatalog.Build(ParseModel(Model, expand: false));
+
+            Assert.Equal(new[] { "capacity" }, catalog.Search("CAPA").Select(e => e.Name));
+            Assert.Equal(new[] { "capacity", "cost" }, catalog.Search("c*t*").Select(e => e.Name));
+            Assert.Equal(new[] { "cap" }, catalog.Search("c?p").Select(e => e.Name));
+            Assert.Empty(catalog.Search(" "));
+        }
+    }
+}
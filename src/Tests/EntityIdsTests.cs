@@ -0,0 +1,70 @@
+using Core.Analysis;
+using Core.Parsing;
+using Core.Storage;
+
+namespace Tests
+{
+    public class EntityIdsTests : IDisposable
+    {
+        private const string Model =
+            "// Plant model\n" +
+            "{string} Plants = {\"north\", \"south\"};\n" +
+            "float capacity[Plants] = [10, 20];\n" +
+            "dvar float+ production[Plants];\n" +
+            "forall(p in Plants) limit: production[p] <= capacity[p]; // capacity of a plant\n" +
+            "maximize sum(p in Plants) production[p];\n";
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "entityids-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        [Fact]
+        public void Assign_ShouldGiveEveryDeclarationAnIdOnce()
+        {
+            string model = EntityIds.Assign(Model);
+            var ids = EntityIds.Parse(model);
+
+            Assert.Equal(new[] { "set:Plants", "parameter:capacity", "variable:production", "constraint:limit", "objective" }, ids.Keys);
+            Assert.Equal(5, ids.Keys.Select(k => ids.Find(k)).Distinct().Count());
+            Assert.Equal("variable:production", ids.FindKey(ids.Find("variable:production")!));
+            Assert.Equal(model, EntityIds.Assign(model));
+        }
+
+        [Fact]
+        public void Ids_ShouldSurviveSaveLoadAndRename()
+        {
+            string model = EntityIds.Assign(Model);
+            string id = EntityIds.Parse(model).Find("parameter:capacity")!;
+
+            ModelPackage.Save(directory, "plants", model, "");
+            var source = ModelSource.Parse(ModelPackage.Load(directory).ModelText);
+            Assert.Equal(2, EntityIds.Rename(source, "parameter:capacity", "maxOutput"));
+
+            var renamed = source.ToString();
+            Assert.Contains("forall(p in Plants) limit: production[p] <= maxOutput[p]; // capacity of a plant", renamed);
+            Assert.Equal("parameter:maxOutput", EntityIds.Parse(renamed).FindKey(id));
+            Assert.Null(EntityIds.Parse(renamed).Find("parameter:capacity"));
+            Assert.Throws<InvalidOperationException>(() => EntityIds.Rename(source, "parameter:maxOutput", "production"));
+        }
+
+        [Fact]
+        public void Assign_ShouldGiveACopiedDeclarationANewId()
+        {
+            var source = ModelSource.Parse(EntityIds.Assign(Model));
+            string statement = source.Find("variable:production")!.Text.TrimStart('\n');
+            string model = source + statement.Replace("production", "reserve") + "\n";
+
+            Assert.Equal(new[] { "variable:reserve" }, EntityIds.Parse(model).Duplicates);
+
+            model = EntityIds.Assign(model);
+            var ids = EntityIds.Parse(model);
+            Assert.Empty(ids.Duplicates);
+            Assert.Equal(EntityIds.Parse(source.ToString()).Find("variable:production"), ids.Find("variable:production"));
+            Assert.NotEqual(ids.Find("variable:production"), ids.Find("variable:reserve"));
+        }
+    }
+}
@@ -53,6 +53,45 @@ namespace Tests
             Assert.Equal(5.3, equation.Constant.Evaluate(manager), 2);
         }
 
+        [Theory]
+        [InlineData("x - 1000*y + 25*x <= 3;", -1000)]
+        [InlineData("x + 12.5*y <= 3;", 12.5)]
+        [InlineData("10*x + 3 - 100*y <= 6;", -100)]
+        public void Parse_EquationWithMultiDigitCoefficients_ShouldNotAddConstant(string equation, double coefficient)
+        {
+            // Arrange
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+
+            // Act
+            var result = parser.Parse("var x; var y; " + equation);
+
+            // Assert
+            AssertNoErrors(result);
+            var parsed = manager.Equations[0];
+            Assert.Equal(3, parsed.Constant.Evaluate(manager));
+            Assert.Equal(coefficient, parsed.GetCoefficient("y"));
+        }
+
+        [Theory]
+        [InlineData("x + y <= -3;", -3)]
+        [InlineData("x - 2 + y >= 5;", 7)]
+        [InlineData("2*x - 1.5 == 4 - 6;", -0.5)]
+        [InlineData("-4 + x <= 0;", 4)]
+        public void Parse_NegativeConstants_ShouldKeepTheirSign(string equation, double expected)
+        {
+            // Arrange
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+
+            // Act
+            var result = parser.Parse("var x; var y; " + equation);
+
+            // Assert
+            AssertNoErrors(result);
+            Assert.Equal(expected, manager.Equations[0].Constant.Evaluate(manager));
+        }
+
         [Fact]
         public void Parse_LabeledEquation_ShouldStoreLabel()
         {
@@ -0,0 +1,72 @@
+using Core;
+
+namespace Tests
+{
+    public class ErrorRecoveryTests : TestBase
+    {
+        private const string Model =
+            "range I = 1..3;\n" +
+            "{string} S = {\"a\", \"b\"};\n" +
+            "float c[I] = [1, 2, 3];\n" +
+            "dvar float+ x[I];\n" +
+            "dvar float+ y;\n" +
+            "minimize sum(i in I) c[i] * x[i];\n" +
+            "subject to {\n" +
+            "  forall(i in I) lower[i]: x[i] >= 1;\n" +
+            "  total: sum(i in I) x[i] <= 10;\n" +
+            "  ylim: y <= 5;\n" +
+            "}\n";
+
+        [Fact]
+        public void Parse_MissingSemicolon_ShouldReportItAndKeepTheFollowingStatements()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(Model.Replace("[1, 2, 3];", "[1, 2, 3]").Replace("<= 10;", "<= 10"));
+
+            Assert.Equal(new[] { (3, "Missing ';' at end of line"), (9, "Missing ';' at end of line") },
+                result.Errors.Select(e => (e.LineNumber, e.Message)));
+            Assert.True(manager.Parameters.ContainsKey("c"));
+            Assert.True(manager.IndexedVariables.ContainsKey("x"));
+            Assert.Equal(new[] { "total", "ylim" }, manager.LabeledEquations.Keys.OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Parse_UnclosedBrace_ShouldSkipOnlyThatStatement()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(Model.Replace("\"b\"};", "\"b\";"));
+
+            var error = Assert.Single(result.Errors);
+            Assert.Equal(2, error.LineNumber);
+            Assert.StartsWith("Missing closing '}'", error.Message);
+            Assert.False(manager.PrimitiveSets.ContainsKey("S"));
+            Assert.Equal(2, manager.IndexedVariables.Count);
+            Assert.Equal(2, manager.LabeledEquations.Count);
+        }
+
+        [Fact]
+        public void Parse_SeveralErrors_ShouldReportEachOfThem()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse("execute { writeln(1);\n" +
+                Model.Replace("dvar float+ y;", "dvar flaot+ y;").Replace("ylim: y <= 5;\n}", "ylim: y <= 5;\n"));
+
+            Assert.Equal(new[] { 1, 6, 8 }, result.Errors.Select(e => e.LineNumber).OrderBy(l => l));
+            Assert.True(manager.IndexedVariables.ContainsKey("x"));
+            Assert.Equal(2, manager.LabeledEquations.Count);
+        }
+
+        [Fact]
+        public void ParseData_MissingSemicolon_ShouldReportItAndReadBothAssignments()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse("float a = ...;\nfloat b = ...;\nfloat d = ...;\n"));
+
+            var result = new DataFileParser(manager).Parse("a = 1\nb = 2;\nd = 3");
+
+            Assert.Equal(new[] { (1, "Missing ';' at end of line"), (3, "Missing ';' at end of line") },
+                result.Errors.Select(e => (e.LineNumber, e.Message)));
+            Assert.Equal(3, result.SuccessCount);
+        }
+    }
+}
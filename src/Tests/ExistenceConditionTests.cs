@@ -0,0 +1,76 @@
+using Core;
+
+namespace Tests
+{
+    public class ExistenceConditionTests : TestBase
+    {
+        private const string Model = @"
+range P = 1..3;
+int has_storage[P] = ...;
+float capacity[P] = ...;
+int useReserve = ...;
+dvar float+ output[P];
+dvar float+ storage[p in P] in 0..capacity[p] exists if has_storage[p];
+dvar float+ reserve;
+maximize sum(p in P) output[p] + reserve;
+forall(p in P) fill: output[p] <= storage[p] exists if has_storage[p] == 1;
+reserveLimit: reserve <= 10 exists if useReserve;
+";
+
+        private (ModelManager Manager, ParseSessionResult Result) Expand(string data)
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            AssertNoErrors(result);
+
+            var dataResult = new DataFileParser(manager).Parse(data);
+            Assert.False(dataResult.HasErrors, string.Join(", ", dataResult.GetErrorMessages()));
+
+            parser.ExpandAllTemplates(result);
+            return (manager, result);
+        }
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldOnlyCreateEntitiesWhoseConditionHolds()
+        {
+            var (manager, result) = Expand("has_storage = [1, 0, 1];\ncapacity = [5, 6, 7];\nuseReserve = 0;");
+
+            AssertNoErrors(result);
+            var storage = manager.IndexedVariables["storage"];
+            Assert.Equal("has_storage[p]", storage.ExistsCondition);
+            Assert.Equal(new[] { "storage2" }, storage.AbsentElements);
+            Assert.False(storage.Exists("storage2"));
+            Assert.Equal((0.0, 0.0), storage.GetBounds("storage2"));
+            Assert.Equal((0.0, 7.0), storage.GetBounds("storage3"));
+
+            var labels = manager.Equations.Select(e => e.Label).ToList();
+            Assert.Contains("fill_1", labels);
+            Assert.DoesNotContain("fill_2", labels);
+            Assert.Contains("fill_3", labels);
+            Assert.DoesNotContain("reserveLimit", labels);
+        }
+
+        [Fact]
+        public void ExpandAllTemplates_OtherData_ShouldChangeTheStructure()
+        {
+            var (manager, result) = Expand("has_storage = [0, 0, 1];\ncapacity = [5, 6, 7];\nuseReserve = 1;");
+
+            AssertNoErrors(result);
+            Assert.Equal(new[] { "storage1", "storage2" }, manager.IndexedVariables["storage"].AbsentElements.OrderBy(e => e));
+            var labels = manager.Equations.Select(e => e.Label).ToList();
+            Assert.Equal(new[] { "fill_3" }, labels.Where(l => l != null && l.StartsWith("fill")));
+            Assert.Contains("reserveLimit", labels);
+        }
+
+        [Fact]
+        public void Parse_ConditionOnNonConstraint_ShouldFail()
+        {
+            var parser = CreateParser();
+
+            var result = parser.Parse("int flag = 1;\ndvar float+ x;\nlimit: x exists if flag;");
+
+            AssertHasError(result, "No relational operator");
+        }
+    }
+}
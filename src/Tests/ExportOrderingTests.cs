@@ -0,0 +1,146 @@
+using System.Text.Json;
+using Core;
+using Core.Export;
+using Core.Import;
+
+namespace Tests
+{
+    public class ExportOrderingTests : TestBase
+    {
+        // The same model twice, with terms written in a different order
+        private const string Model = @"
+            dvar int+ zeta in 0..10;
+            dvar int+ alpha in 0..10;
+            dvar int+ mid in 0..10;
+            maximize 2*zeta + 3*alpha + mid;
+            second: zeta + alpha <= 8;
+            first: mid + alpha <= 6;
+        ";
+
+        private const string Reordered = @"
+            dvar int+ zeta in 0..10;
+            dvar int+ alpha in 0..10;
+            dvar int+ mid in 0..10;
+            maximize mid + 3*alpha + 2*zeta;
+            second: alpha + zeta <= 8;
+            first: alpha + mid <= 6;
+        ";
+
+        private static string Write(ModelManager manager, string format, ExportOrdering ordering) => format switch
+        {
+            "mps" => new MPSExporter(manager) { Ordering = ordering }.Export("TEST"),
+            "mof" => new MofExporter(manager) { Ordering = ordering }.Export("test"),
+            "fzn" => new FlatZincExporter(manager) { Ordering = ordering }.Export(),
+            _ => LinearModel.FromModel(manager, ordering).ToModelText()
+        };
+
+        [Theory]
+        [InlineData("mps", ExportOrdering.CreationOrder)]
+        [InlineData("mof", ExportOrdering.CreationOrder)]
+        [InlineData("fzn", ExportOrdering.CreationOrder)]
+        [InlineData("mod", ExportOrdering.CreationOrder)]
+        [InlineData("mps", ExportOrdering.Name)]
+        [InlineData("mof", ExportOrdering.Name)]
+        [InlineData("fzn", ExportOrdering.Name)]
+        [InlineData("mod", ExportOrdering.Name)]
+        [InlineData("mps", ExportOrdering.SortedColumns)]
+        [InlineData("mof", ExportOrdering.SortedColumns)]
+        [InlineData("fzn", ExportOrdering.SortedColumns)]
+        [InlineData("mod", ExportOrdering.SortedColumns)]
+        public void Export_ShouldNotDependOnTermOrder(string format, ExportOrdering ordering)
+        {
+            string first = Write(ParseAndExpand(Model), format, ordering);
+            string second = Write(ParseAndExpand(Reordered), format, ordering);
+
+            Assert.Equal(first, second);
+            Assert.Equal(first, Write(ParseAndExpand(Model), format, ordering));
+        }
+
+        [Fact]
+        public void CreationOrder_ShouldKeepRowsAndOrderColumnsByFirstUse()
+        {
+            var model = LinearModel.FromModel(ParseAndExpand(Model), ExportOrdering.CreationOrder);
+
+            Assert.Equal(new[] { "alpha", "mid", "zeta" }, model.Variables.Select(v => v.Name));
+            Assert.Equal(new[] { "second", "first" }, model.Constraints.Select(c => c.Name));
+            Assert.Equal(new[] { "alpha", "zeta" }, model.Constraints[0].Coefficients.Keys);
+        }
+
+        [Fact]
+        public void CreationOrder_ShouldPlaceObjectiveVariablesFirst()
+        {
+            var model = LinearModel.FromModel(ParseAndExpand(@"
+                dvar float+ b;
+                dvar float+ a;
+                dvar float+ c;
+                minimize c;
+                r1: b + a >= 1;
+            "), ExportOrdering.CreationOrder);
+
+            Assert.Equal(new[] { "c", "a", "b" }, model.Variables.Select(v => v.Name));
+        }
+
+        [Fact]
+        public void NameOrder_ShouldSortRowsAndColumns()
+        {
+            var model = LinearModel.FromModel(ParseAndExpand(Model), ExportOrdering.Name);
+
+            Assert.Equal(new[] { "alpha", "mid", "zeta" }, model.Variables.Select(v => v.Name));
+            Assert.Equal(new[] { "first", "second" }, model.Constraints.Select(c => c.Name));
+
+            string mps = new MPSExporter(ParseAndExpand(Model)) { Ordering = ExportOrdering.Name }.Export("TEST");
+            Assert.True(mps.IndexOf(" L  FIRST", StringComparison.Ordinal) < mps.IndexOf(" L  SECOND", StringComparison.Ordinal));
+
+            var integer = IntegerModel.FromModel(ParseAndExpand(Model), ExportOrdering.Name);
+            Assert.Equal(new[] { "first", "second" }, integer.Constraints.Select(c => c.Name));
+        }
+
+        [Fact]
+        public void Writers_ShouldDefaultToSortedColumns()
+        {
+            var manager = ParseAndExpand(Model);
+
+            Assert.Equal(Write(manager, "mps", ExportOrdering.SortedColumns), new MPSExporter(manager).Export("TEST"));
+            Assert.Equal(Write(manager, "mof", ExportOrdering.SortedColumns), new MofExporter(manager).Export("test"));
+            Assert.Equal(Write(manager, "fzn", ExportOrdering.SortedColumns), new FlatZincExporter(manager).Export());
+            Assert.Equal(new[] { "second", "first" }, LinearModel.FromModel(manager).Constraints.Select(c => c.Name));
+            Assert.Equal(new[] { "second", "first" }, IntegerModel.FromModel(manager).Constraints.Select(c => c.Name));
+            Assert.Equal(ExportOrdering.SortedColumns, new ExportProfile().Ordering);
+        }
+
+        [Fact]
+        public void DefaultMps_ShouldMatchTheLayoutBeforeOrderings()
+        {
+            // Rows as created, columns and their entries by name, whatever order the variables are first used in
+            string mps = new MPSExporter(ParseAndExpand(@"
+                dvar float+ b;
+                dvar float+ a;
+                dvar float+ c;
+                minimize c;
+                r2: b + a >= 1;
+                r1: c - a >= 0;
+            ")).Export("TEST");
+
+            var lines = mps.Split('\n').Select(l => l.TrimEnd('\r')).ToList();
+            string objective = lines[lines.IndexOf("ROWS") + 1].Substring(" N  ".Length);
+            Assert.Equal(new[] { " G  R2", " G  R1" }, lines.Where(l => l.StartsWith(" G ", StringComparison.Ordinal)));
+
+            var entries = lines.SkipWhile(l => l != "COLUMNS").Skip(1).TakeWhile(l => l != "RHS")
+                .Select(l => string.Join(" ", l.Split(' ', StringSplitOptions.RemoveEmptyEntries).Take(2)));
+            Assert.Equal(new[] { "A R2", "A R1", "B R2", $"C {objective}", "C R1" }, entries);
+        }
+
+        [Fact]
+        public void MofTerms_ShouldFollowVariableOrder()
+        {
+            string json = new MofExporter(ParseAndExpand(Reordered)).Export("test");
+
+            using var document = JsonDocument.Parse(json);
+            var terms = document.RootElement.GetProperty("objective").GetProperty("function").GetProperty("terms")
+                .EnumerateArray()
+                .Select(t => t.GetProperty("variable").GetString())
+                .ToList();
+            Assert.Equal(new[] { "alpha", "mid", "zeta" }, terms);
+        }
+    }
+}
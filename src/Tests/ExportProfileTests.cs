@@ -0,0 +1,68 @@
+using Core.Export;
+
+namespace Tests
+{
+    public class ExportProfileTests : TestBase
+    {
+        private const string Model = @"
+// @export cplex format=mps precision=4 workarounds=no-objective-constant
+// @export vendor format=mps names=anonymized blocks=hydro
+// @export broken format=xls workarounds=turbo
+range Units = 1..3;
+float capacity = 25.123456;
+float demand = 40;
+dvar float+ output[Units];
+dvar float+ reserve;
+minimize sum(u in Units) output[u] + reserve + 7;
+// @block hydro
+forall(u in Units) rampUp: output[u] <= capacity;
+// @endblock
+meet: sum(u in Units) output[u] + reserve == demand;
+";
+
+        [Fact]
+        public void Parse_ShouldReadProfilesAndReportInvalidSettings()
+        {
+            var profiles = ExportProfiles.Parse(Model);
+
+            Assert.Equal(new[] { "cplex", "vendor", "broken" }, profiles.Profiles.Select(p => p.Name));
+            var vendor = profiles.Find("VENDOR")!;
+            Assert.Equal(ExportNaming.Anonymized, vendor.Naming);
+            Assert.Equal(new[] { "hydro" }, vendor.Blocks);
+            Assert.Equal("// @export vendor format=mps names=anonymized blocks=hydro", vendor.ToString());
+            Assert.Equal(4, profiles.Find("cplex")!.Precision);
+
+            Assert.Equal(2, profiles.Warnings.Count);
+            Assert.Contains(profiles.Warnings, w => w.Contains("format=xls"));
+            Assert.Contains(profiles.Warnings, w => w.Contains("turbo"));
+        }
+
+        [Fact]
+        public void Export_Anonymized_ShouldHideNamesAndKeepOnlySelectedBlocks()
+        {
+            var result = ExportProfiles.Parse(Model).Find("vendor")!.Export(new[] { Model }, Array.Empty<string>());
+
+            Assert.Equal(".mps", result.Extension);
+            Assert.DoesNotContain("output", result.Text);
+            Assert.DoesNotContain("rampUp", result.Text);
+            Assert.Contains("x1", result.Text);
+            Assert.Equal("output[1]", result.Names["x1"]);
+
+            // Only the three hydro rows are exported; the balance row is left out
+            Assert.Equal(3, result.Names.Keys.Count(n => n.StartsWith("c")));
+            Assert.DoesNotContain(result.Names.Values, n => n.StartsWith("meet"));
+        }
+
+        [Fact]
+        public void Export_WithPrecisionAndWorkaround_ShouldWarnAboutChangedValues()
+        {
+            var result = ExportProfiles.Parse(Model).Find("cplex")!.Export(new[] { Model }, Array.Empty<string>());
+
+            Assert.Contains("25.12", result.Text);
+            Assert.DoesNotContain("25.123456", result.Text);
+            Assert.Contains(result.Warnings, w => w.Contains("25.123456") && w.Contains("25.12"));
+            Assert.Contains(result.Warnings, w => w.Contains(ExportProfile.NoObjectiveConstant));
+            Assert.Contains("meet", result.Text);
+        }
+    }
+}
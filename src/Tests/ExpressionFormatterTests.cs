@@ -0,0 +1,80 @@
+using Core;
+using Core.Formatting;
+using Core.Models;
+
+namespace Tests
+{
+    public class ExpressionFormatterTests : TestBase
+    {
+        private static Expression SampleSum() =>
+            new SummationExpression("i", "I",
+                new BinaryExpression(
+                    new IndexedParameterExpression("c", new List<Expression> { new ParameterExpression("i") }),
+                    BinaryOperator.Multiply,
+                    new IndexedVariableExpression("x", new ParameterExpression("i"))));
+
+        private static LinearEquation SampleEquation() => new LinearEquation
+        {
+            Label = "cap",
+            Coefficients = new Dictionary<string, Expression>
+            {
+                ["y2"] = new ConstantExpression(-1),
+                ["x10"] = new ConstantExpression(3),
+                ["x2"] = new ConstantExpression(1)
+            },
+            Operator = RelationalOperator.LessThanOrEqual,
+            Constant = new ConstantExpression(10)
+        };
+
+        [Theory]
+        [InlineData(ExpressionStyle.Ascii, "sum(i in I) c[i]*x[i]")]
+        [InlineData(ExpressionStyle.Opl, "sum(i in I) c[i]*x[i]")]
+        [InlineData(ExpressionStyle.Unicode, "∑(i∈I) c[i]·x[i]")]
+        [InlineData(ExpressionStyle.Latex, @"\sum_{i \in I} c_{i} \cdot x_{i}")]
+        public void Format_Summation_ShouldUseStyleSyntax(ExpressionStyle style, string expected)
+        {
+            Assert.Equal(expected, new ExpressionFormatter(style).Format(SampleSum()));
+        }
+
+        [Fact]
+        public void Format_ShouldParenthesizeByPrecedence()
+        {
+            var expr = new BinaryExpression(
+                new BinaryExpression(new ParameterExpression("a"), BinaryOperator.Add, new ParameterExpression("b")),
+                BinaryOperator.Multiply,
+                new BinaryExpression(new ParameterExpression("c"), BinaryOperator.Subtract,
+                    new BinaryExpression(new ParameterExpression("d"), BinaryOperator.Subtract, new ParameterExpression("e"))));
+
+            Assert.Equal("(a + b)*(c - (d - e))", new ExpressionFormatter(ExpressionStyle.Ascii).Format(expr));
+        }
+
+        [Fact]
+        public void Format_Equation_WithGroupingAndStyles()
+        {
+            var equation = SampleEquation();
+
+            var ascii = new ExpressionFormatter(new ExpressionFormatOptions { Grouping = TermGrouping.ByFamily });
+            Assert.Equal("cap: -y2 + x2 + 3*x10 <= 10", ascii.Format(equation));
+
+            var unicode = new ExpressionFormatter(new ExpressionFormatOptions { Style = ExpressionStyle.Unicode, Grouping = TermGrouping.Alphabetical });
+            Assert.Equal("cap: 3x10 + x2 − y2 ≤ 10", unicode.Format(equation));
+
+            var latex = new ExpressionFormatter(new ExpressionFormatOptions { Style = ExpressionStyle.Latex, Grouping = TermGrouping.ByFamily });
+            Assert.Equal(@"-y_{2} + x_{2} + 3 x_{10} \leq 10", latex.Format(equation, includeLabel: false));
+        }
+
+        [Fact]
+        public void Format_LongSum_ShouldWrapBeforeOperators()
+        {
+            var coefficients = Enumerable.Range(1, 8).ToDictionary(i => $"flow{i}", i => (Expression)new ConstantExpression(i));
+            var objective = new Objective(ObjectiveSense.Minimize, coefficients, new ConstantExpression(0));
+
+            var formatter = new ExpressionFormatter(new ExpressionFormatOptions { MaxLineWidth = 30, IndentWidth = 2 });
+            var lines = formatter.Format(objective).Split(Environment.NewLine);
+
+            Assert.True(lines.Length > 1);
+            Assert.All(lines, l => Assert.True(l.Length <= 30, l));
+            Assert.All(lines.Skip(1), l => Assert.StartsWith("  + ", l));
+        }
+    }
+}
@@ -0,0 +1,163 @@
+using Core;
+using Core.Analysis;
+using Core.Solving;
+
+namespace Tests
+{
+    public class FeasibilityRelaxationTests : TestBase
+    {
+        private const string Model = @"
+range Units = 1..2;
+dvar float output[Units] in 0..10;
+minimize sum(u in Units) output[u];
+// @tags demand
+need: sum(u in Units) output[u] >= 30;
+// @tags limits
+forall(u in Units) cap: output[u] <= 8;
+";
+
+        private class DelegateDriver : ISolverDriver
+        {
+            private readonly Func<ModelManager, SolveResult> solve;
+            public List<SolveResult> Results { get; } = new List<SolveResult>();
+
+            public DelegateDriver(Func<ModelManager, SolveResult> solve)
+            {
+                this.solve = solve;
+            }
+
+            public string Name => "Delegate";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                var result = solve(manager);
+                Results.Add(result);
+                return result;
+            }
+        }
+
+        private ModelManager Expand() => ParseAndExpand(Model);
+
+        private static bool IsRelaxed(ModelManager manager) => manager.Objective?.Name == "elastic_penalty";
+
+        [Fact]
+        public void Run_FeasibleModel_ShouldNotRelax()
+        {
+            var driver = new DelegateDriver(_ => new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 1 });
+
+            var result = new FeasibilityRelaxation(driver).Run(Expand());
+
+            Assert.True(result.WasFeasible);
+            Assert.Single(driver.Results);
+            Assert.Empty(result.Relaxations);
+        }
+
+        [Fact]
+        public void Run_ShouldElasticizeSelectedGroupsAndReportRelaxation()
+        {
+            var manager = Expand();
+            int variableCount = manager.IndexedVariables.Count;
+            var objective = manager.Objective;
+            List<string>? elasticNames = null;
+            double? weight = null;
+
+            var driver = new DelegateDriver(m =>
+            {
+                if (!IsRelaxed(m))
+                    return new SolveResult { Status = SolveStatus.Infeasible };
+
+                elasticNames = m.Objective!.Coefficients.Keys.ToList();
+                weight = m.Objective.Coefficients.Values.Single().Evaluate(m);
+                string down = elasticNames.Single();
+                var need = m.Equations.Single(e => e.Coefficients.ContainsKey(down));
+                Assert.Equal(1, need.Coefficients[down].Evaluate(m));
+
+                return new SolveResult
+                {
+                    Status = SolveStatus.Optimal,
+                    VariableValues = new Dictionary<string, double> { ["output1"] = 10, ["output2"] = 10, [down] = 10 }
+                };
+            });
+
+            var relaxation = new FeasibilityRelaxation(driver);
+            relaxation.AddTagGroup(ModelTags.Parse(Model), "demand", 5);
+            var result = relaxation.Run(manager);
+
+            Assert.False(result.WasFeasible);
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(5, weight);
+            Assert.Equal(50, result.TotalPenalty);
+
+            var relaxed = Assert.Single(result.Relaxations);
+            Assert.Equal("need", relaxed.Constraint);
+            Assert.Equal("demand", relaxed.Group);
+            Assert.Equal(-10, relaxed.Amount);
+            Assert.Contains("need: rhs -10 (group demand)", result.ToReport());
+            Assert.Equal(new[] { "output1", "output2" }, result.Solution!.VariableValues.Keys.OrderBy(k => k));
+
+            // The model is restored after the solves
+            Assert.Same(objective, manager.Objective);
+            Assert.Equal(variableCount, manager.IndexedVariables.Count);
+            Assert.DoesNotContain(manager.Equations, e => e.Coefficients.Keys.Any(elasticNames!.Contains));
+        }
+
+        [Fact]
+        public void Run_WithoutGroups_ShouldElasticizeEveryRow()
+        {
+            int elastics = 0;
+            var driver = new DelegateDriver(m =>
+            {
+                elastics = m.Objective!.Coefficients.Count;
+                return new SolveResult { Status = SolveStatus.Optimal };
+            });
+
+            var result = new FeasibilityRelaxation(driver) { CheckFeasibilityFirst = false }.Run(Expand());
+
+            Assert.Single(driver.Results);
+            Assert.Equal(3, elastics);
+            Assert.Equal(0, result.TotalPenalty);
+        }
+
+        [Fact]
+        public void Run_OptimizeOriginalObjective_ShouldCapViolationAndRestoreObjective()
+        {
+            var manager = Expand();
+            int equationCount = manager.Equations.Count;
+            bool capped = false;
+
+            var driver = new DelegateDriver(m =>
+            {
+                if (IsRelaxed(m))
+                {
+                    string up = m.Objective!.Coefficients.Keys.First();
+                    return new SolveResult { Status = SolveStatus.Optimal, VariableValues = new Dictionary<string, double> { [up] = 2 } };
+                }
+
+                capped = m.Equations.Any(e => e.Label == "elastic_penalty_cap");
+                return capped
+                    ? new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 20 }
+                    : new SolveResult { Status = SolveStatus.Infeasible };
+            });
+
+            var relaxation = new FeasibilityRelaxation(driver) { OptimizeOriginalObjective = true };
+            relaxation.AddGroup("limits", 1, "cap");
+            var result = relaxation.Run(manager);
+
+            Assert.True(capped);
+            Assert.Equal(3, driver.Results.Count);
+            Assert.Equal(20, result.Solution!.ObjectiveValue);
+            Assert.Equal(2, result.TotalPenalty);
+            Assert.Equal(equationCount, manager.Equations.Count);
+        }
+
+        [Fact]
+        public void Run_UnexpandedModel_ShouldThrow()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+            var driver = new DelegateDriver(_ => new SolveResult { Status = SolveStatus.Infeasible });
+
+            Assert.Throws<InvalidOperationException>(() => new FeasibilityRelaxation(driver).Run(manager));
+        }
+    }
+}
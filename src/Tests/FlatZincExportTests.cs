@@ -0,0 +1,70 @@
+using Core;
+using Core.Export;
+
+namespace Tests
+{
+    public class FlatZincExportTests : TestBase
+    {
+        [Fact]
+        public void Export_IntegerModel_ShouldWriteDomainsConstraintsAndObjective()
+        {
+            var manager = ParseAndExpand(@"
+                dvar int x in 0..10;
+                dvar int y in 0..5;
+                maximize 3*x + 2*y;
+                cap: x + y <= 8;
+                minX: x >= 2;
+            ");
+
+            string fzn = new FlatZincExporter(manager).Export();
+
+            Assert.Contains("var 0..10: x :: output_var;", fzn);
+            Assert.Contains("var 0..5: y :: output_var;", fzn);
+            Assert.Contains("constraint int_lin_le([1, 1], [x, y], 8); % cap", fzn);
+            Assert.Contains("constraint int_lin_le([-1], [x], -2); % minX", fzn);
+            Assert.Contains("int_lin_eq([3, 2, -1], [x, y, OBJECTIVE], 0)", fzn);
+            Assert.EndsWith("solve maximize OBJECTIVE;" + Environment.NewLine, fzn);
+        }
+
+        [Fact]
+        public void Export_MixedModel_ShouldSkipContinuousParts()
+        {
+            var manager = ParseAndExpand(@"
+                dvar int n in 0..4;
+                dvar float+ z;
+                minimize z;
+                both: n + z >= 1;
+                disc: n >= 1;
+            ");
+
+            var exporter = new FlatZincExporter(manager);
+            string fzn = exporter.Export();
+
+            Assert.Contains("% disc", fzn);
+            Assert.DoesNotContain("% both", fzn);
+            Assert.Contains(exporter.Warnings, w => w.Contains("both") && w.Contains("'z'"));
+            Assert.Contains("solve satisfy;", fzn);
+        }
+
+        [Fact]
+        public void Export_Disjunction_ShouldUseReifiedConstraints()
+        {
+            var manager = ParseAndExpand(@"
+                dvar int a in 0..10;
+                dvar int b in 0..10;
+                minimize a + b;
+                (a >= 3) || (b >= 2);
+            ");
+
+            string fzn = new FlatZincExporter(manager).Export();
+
+            Assert.Contains("int_lin_le_reif([-1], [a], -3, B_1)", fzn);
+            Assert.Contains("int_lin_le_reif([-1], [b], -2, B_2)", fzn);
+            Assert.Contains("bool_clause([B_1, B_2], [])", fzn);
+
+            int lastDeclaration = fzn.LastIndexOf("var ", StringComparison.Ordinal);
+            int firstConstraint = fzn.IndexOf("constraint ", StringComparison.Ordinal);
+            Assert.True(lastDeclaration < firstConstraint);
+        }
+    }
+}
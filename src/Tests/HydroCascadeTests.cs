@@ -0,0 +1,107 @@
+using Core;
+using Core.Analysis;
+using Core.Networks;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class HydroCascadeTests : TestBase
+    {
+        private const string Data =
+            "range T = 1..3;\n" +
+            "float inflowUp[T] = [10, 10, 10];\n" +
+            "float inflowDown[T] = [2, 2, 2];\n" +
+            "minimize 0;\n";
+
+        private static HydroCascade River()
+        {
+            var river = new HydroCascade("river");
+
+            var up = river.AddStation("Up");
+            up.Downstream = "Down";
+            up.Delay = 1;
+            up.SpillTo = "Down";
+            up.MaxVolume = 100;
+            up.InitialVolume = 50;
+            up.Inflow = "inflowUp";
+            up.Curves.Add(new HydroCurve { Volume = 0, Points = { (0, 0), (10, 8), (20, 12) } });
+            up.Curves.Add(new HydroCurve { Volume = 100, Points = { (0, 0), (10, 10), (20, 15) } });
+
+            var down = river.AddStation("Down");
+            down.MaxBypass = 5;
+            down.MaxVolume = 80;
+            down.InitialVolume = 20;
+            down.Inflow = "inflowDown";
+            down.Curves.Add(new HydroCurve { Volume = 0, Points = { (0, 0), (10, 6), (30, 12) } });
+
+            return river;
+        }
+
+        [Fact]
+        public void Generate_ShouldRouteUpstreamWaterAfterItsDelay()
+        {
+            var statements = River().Generate().Select(e => e.Statement).ToList();
+            Assert.Contains("dvar float Up_discharge[T] in 0..20;", statements);
+            Assert.Contains("dvar float Down_bypass[T] in 0..5;", statements);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Data + string.Join("\n", statements));
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            AssertNoErrors(result);
+
+            Assert.Equal(new[] { "Down_bypass2", "Down_discharge2", "Down_spill2", "Down_volume1", "Down_volume2", "Up_discharge1", "Up_spill2" },
+                manager.GetEquationByLabel("Down_balance_2")!.Coefficients.Keys.OrderBy(k => k, StringComparer.Ordinal));
+            Assert.Equal(new[] { "Down_bypass1", "Down_discharge1", "Down_spill1", "Down_volume1", "Up_spill1" },
+                manager.GetEquationByLabel("Down_balance_1")!.Coefficients.Keys.OrderBy(k => k, StringComparer.Ordinal));
+            Assert.Equal(new[] { "Up_discharge1", "Up_spill1", "Up_volume1" },
+                manager.GetEquationByLabel("Up_balance_1")!.Coefficients.Keys.OrderBy(k => k, StringComparer.Ordinal));
+        }
+
+        [Fact]
+        public void Generate_ShouldBoundProductionByPlanesThroughTheCurves()
+        {
+            var statements = River().Generate().Select(e => e.Statement).ToList();
+
+            Assert.Contains("forall(t in T) Up_production1: Up_power[t] <= 0.8 * Up_discharge[t] + 0.02 * Up_volume[t];", statements);
+            Assert.Contains("forall(t in T) Up_production2: Up_power[t] <= 1 * Up_discharge[t];", statements);
+            Assert.Contains("forall(t in T) Up_production3: Up_power[t] <= 0.4 * Up_discharge[t] + 0.03 * Up_volume[t] + 4;", statements);
+            Assert.Contains("forall(t in T) Up_production4: Up_power[t] <= 0.5 * Up_discharge[t] + 0.02 * Up_volume[t] + 3;", statements);
+            Assert.Contains("forall(t in T) Down_production2: Down_power[t] <= 0.3 * Down_discharge[t] + 3;", statements);
+            Assert.DoesNotContain(statements, s => s.Contains("Down_production3"));
+        }
+
+        [Fact]
+        public void Regenerate_ShouldWriteTaggedDocumentedEntities()
+        {
+            string model = River().Regenerate(ModelSource.Parse(Data)).Apply(Data);
+
+            Assert.Equal(17, ModelProvenance.Parse(model).Select("hydro:river").Count);
+            var tags = ModelTags.Parse(model);
+            Assert.Equal(new[] { "constraint:Up_balance", "constraint:Up_production1", "constraint:Up_production2", "constraint:Up_production3",
+                    "constraint:Up_production4", "variable:Up_discharge", "variable:Up_power", "variable:Up_spill", "variable:Up_volume" },
+                tags.Select("hydro/river/Up").Select(e => e.Key).OrderBy(k => k, StringComparer.Ordinal));
+            Assert.Equal(17, tags.Select("hydro/river").Count);
+            Assert.StartsWith("Volume of the reservoir of Down", Docstrings.Extract(model)["variable:Down_volume"]);
+
+            model = River().Regenerate(ModelSource.Parse(model)).Apply(model);
+            Assert.Equal(17, ModelTags.Parse(model).Select("hydro/river").Count);
+            Assert.Single(model.Split('\n'), line => line.StartsWith("#: Volume of the reservoir of Down"));
+        }
+
+        [Fact]
+        public void Validate_ShouldFindDanglingRoutesAndCycles()
+        {
+            var river = River();
+            river.FindStation("Down")!.SpillTo = "Up";
+            river.FindStation("Up")!.BypassTo = "Sea";
+            river.FindStation("Up")!.MaxBypass = 10;
+
+            var findings = river.Validate();
+
+            Assert.Equal(new[] { "dangling-route", "cycle", "cycle" }, findings.Select(f => f.RuleId));
+            Assert.Throws<InvalidOperationException>(() => river.Generate());
+        }
+    }
+}
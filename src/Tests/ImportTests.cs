@@ -0,0 +1,170 @@
+using Core;
+using Core.Import;
+using Core.Models;
+
+namespace Tests
+{
+    public class ImportTests : TestBase
+    {
+        private const string Mof = @"{
+  ""name"": ""knapsack"",
+  ""version"": { ""major"": 1, ""minor"": 7 },
+  ""variables"": [ { ""name"": ""x[1]"" }, { ""name"": ""x[2]"" }, { ""name"": ""y"" } ],
+  ""objective"": {
+    ""sense"": ""max"",
+    ""function"": {
+      ""type"": ""ScalarAffineFunction"",
+      ""terms"": [ { ""coefficient"": 5, ""variable"": ""x[1]"" }, { ""coefficient"": 4, ""variable"": ""x[2]"" }, { ""coefficient"": -1.5, ""variable"": ""y"" } ],
+      ""constant"": 2
+    }
+  },
+  ""constraints"": [
+    {
+      ""name"": ""weight"",
+      ""function"": { ""type"": ""ScalarAffineFunction"", ""terms"": [ { ""coefficient"": 2, ""variable"": ""x[1]"" }, { ""coefficient"": 3, ""variable"": ""x[2]"" } ], ""constant"": 0 },
+      ""set"": { ""type"": ""LessThan"", ""upper"": 10 }
+    },
+    {
+      ""name"": ""link"",
+      ""function"": { ""type"": ""ScalarAffineFunction"", ""terms"": [ { ""coefficient"": 1, ""variable"": ""x[1]"" }, { ""coefficient"": -1, ""variable"": ""y"" } ], ""constant"": 1 },
+      ""set"": { ""type"": ""Interval"", ""lower"": 0, ""upper"": 4 }
+    },
+    { ""function"": { ""type"": ""Variable"", ""name"": ""x[1]"" }, ""set"": { ""type"": ""ZeroOne"" } },
+    { ""function"": { ""type"": ""Variable"", ""name"": ""x[2]"" }, ""set"": { ""type"": ""Integer"" } },
+    { ""function"": { ""type"": ""Variable"", ""name"": ""x[2]"" }, ""set"": { ""type"": ""Interval"", ""lower"": 0, ""upper"": 3 } },
+    { ""function"": { ""type"": ""Variable"", ""name"": ""y"" }, ""set"": { ""type"": ""GreaterThan"", ""lower"": 0 } },
+    {
+      ""name"": ""cone"",
+      ""function"": { ""type"": ""VectorOfVariables"", ""variables"": [ ""y"" ] },
+      ""set"": { ""type"": ""SecondOrderCone"", ""dimension"": 1 }
+    }
+  ]
+}";
+
+        private const string PyomoLp = @"\* Source Pyomo model name=unknown *\
+
+max
+obj:
++3 x(1)
++2 x(2)
++10 ONE_VAR_CONSTANT
+
+s.t.
+
+c_u_capacity_:
++1 x(1)
++1 x(2)
+<= 4
+
+c_l_demand(a)_:
++1 x(1)
+-0.5 x(2)
+>= -1e-01
+
+c_e_ONE_VAR_CONSTANT:
+ONE_VAR_CONSTANT = 1.0
+
+bounds
+   0 <= x(1) <= 3
+   -inf <= x(2) <= +inf
+   0 <= n <= 5
+general
+  n
+end
+";
+
+        [Fact]
+        public void Mof_ShouldReadAffineConstraintsBoundsAndIntegrality()
+        {
+            var model = new MofImporter().Import(Mof);
+
+            Assert.Equal("knapsack", model.Name);
+            Assert.Equal(ObjectiveSense.Maximize, model.ObjectiveSense);
+            Assert.Equal(2, model.ObjectiveConstant);
+            Assert.Equal(VariableType.Boolean, model.FindVariable("x[1]")!.Type);
+            Assert.Equal(VariableType.Integer, model.FindVariable("x[2]")!.Type);
+            Assert.Equal(3, model.FindVariable("x[2]")!.UpperBound);
+
+            Assert.Equal(new[] { "weight", "link_lower", "link_upper" }, model.Constraints.Select(c => c.Name));
+            Assert.Equal(-1, model.Constraints[1].Rhs);
+            Assert.Equal(3, model.Constraints[2].Rhs);
+            Assert.Contains(model.Warnings, w => w.Contains("VectorOfVariables"));
+        }
+
+        [Fact]
+        public void Mof_ToModelText_ShouldParse()
+        {
+            string text = new MofImporter().Import(Mof).ToModelText();
+
+            Assert.Contains("//   x[1] -> x_1", text);
+            Assert.Contains("dvar bool x_1;", text);
+            Assert.Contains("dvar int x_2 in 0..3;", text);
+            Assert.Contains("maximize 5*x_1 + 4*x_2 - 1.5*y + 2;", text);
+
+            var manager = ParseAndExpand(text);
+            Assert.Equal(3, manager.Equations.Count);
+        }
+
+        [Fact]
+        public void Mof_InvalidJson_ShouldThrow()
+        {
+            Assert.Throws<InvalidOperationException>(() => new MofImporter().Import("{ not json"));
+            Assert.Throws<InvalidOperationException>(() => new MofImporter().Import("{}"));
+        }
+
+        [Fact]
+        public void Lp_ShouldUndoPyomoArtifacts()
+        {
+            var model = new LpImporter().Import(PyomoLp);
+
+            Assert.Equal(ObjectiveSense.Maximize, model.ObjectiveSense);
+            Assert.Equal(10, model.ObjectiveConstant);
+            Assert.Null(model.FindVariable("ONE_VAR_CONSTANT"));
+            Assert.Equal(new[] { "capacity", "demand(a)" }, model.Constraints.Select(c => c.Name));
+            Assert.Equal(-0.1, model.Constraints[1].Rhs, 10);
+            Assert.Equal(-0.5, model.Constraints[1].Coefficients["x(2)"]);
+
+            Assert.Equal(3, model.FindVariable("x(1)")!.UpperBound);
+            Assert.Null(model.FindVariable("x(2)")!.LowerBound);
+            Assert.Equal(VariableType.Integer, model.FindVariable("n")!.Type);
+        }
+
+        [Fact]
+        public void Lp_ToModelText_ShouldParse()
+        {
+            string text = new LpImporter().Import(PyomoLp).ToModelText();
+
+            Assert.Contains("dvar float x_2;", text);
+            Assert.Contains("demand_a: x_1 - 0.5*x_2 >= -0.1;", text);
+
+            var manager = ParseAndExpand(text);
+            Assert.Equal(2, manager.Equations.Count);
+            Assert.NotNull(manager.Objective);
+        }
+
+        [Fact]
+        public void Lp_GenericFile_ShouldReadInlineSections()
+        {
+            const string lp = @"\ plain CPLEX LP
+Minimize
+ cost: 2 x + 3 y - 4
+Subject To
+ c1: x + y >= 2
+ c2: x - y <= 1
+Bounds
+ x <= 10
+ y free
+Binaries
+ z
+End";
+            var model = new LpImporter().Import(lp);
+
+            Assert.Equal(-4, model.ObjectiveConstant);
+            Assert.Equal(new[] { "c1", "c2" }, model.Constraints.Select(c => c.Name));
+            Assert.Equal(0, model.FindVariable("x")!.LowerBound);
+            Assert.Equal(10, model.FindVariable("x")!.UpperBound);
+            Assert.Null(model.FindVariable("y")!.LowerBound);
+            Assert.Equal(VariableType.Boolean, model.FindVariable("z")!.Type);
+        }
+    }
+}
@@ -0,0 +1,86 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class IndexCheckerTests : TestBase
+    {
+        private const string Declarations =
+            "{string} Plants = {\"North\", \"South\"};\n" +
+            "range Periods = 1..3;\n" +
+            "float capacity[Plants] = [150, 200];\n" +
+            "dvar float+ x[Plants, Periods];\n";
+
+        private const string Valid = Declarations +
+            "forall(p in Plants, t in Periods) cap: x[p,t] <= capacity[p];\n" +
+            "limit: x[\"North\"][2] <= 10;\n";
+
+        private ModelManager Build(string text)
+        {
+            var manager = CreateModelManager();
+            CreateParser(manager).Parse(text);
+            return manager;
+        }
+
+        [Fact]
+        public void Check_MatchingReferences_ShouldReportNothing()
+        {
+            Assert.Empty(IndexChecker.Check(Build(Valid), Valid));
+        }
+
+        [Fact]
+        public void Check_WrongNumberOfIndices_ShouldPointAtTheReference()
+        {
+            string model = Declarations + "forall(p in Plants) total: x[p] <= capacity[p];\n";
+
+            var diagnostic = Assert.Single(IndexChecker.Check(Build(model), model));
+
+            Assert.Equal(5, diagnostic.LineNumber);
+            Assert.Equal(28, diagnostic.Column);
+            Assert.Equal("'x' is declared over Plants × Periods but x[p] has 1 index", diagnostic.Message);
+        }
+
+        [Fact]
+        public void Check_WrongIndexTypes_ShouldReportEachPosition()
+        {
+            string model = Declarations + "forall(t in Periods) swap: x[t,\"North\"] <= 5;\n";
+
+            var diagnostics = IndexChecker.Check(Build(model), model);
+
+            Assert.Equal(new[] { 30, 32 }, diagnostics.Select(d => d.Column));
+            Assert.Equal("index 1 of x[t,\"North\"] is int but 'x' is indexed over Plants (string)", diagnostics[0].Message);
+            Assert.Equal("index 2 of x[t,\"North\"] is string but 'x' is indexed over Periods (int)", diagnostics[1].Message);
+        }
+
+        [Fact]
+        public void ParseModel_WrongArity_ShouldFailWithTheLine()
+        {
+            var manager = CreateModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager));
+            string model = Declarations + "limit: capacity[\"North\", 1] <= 10;\n";
+
+            var result = service.ParseModel(new List<string> { model }, new List<string>());
+
+            Assert.False(result.Success);
+            Assert.Contains(result.Errors, e => e.Contains("Line 5, column 8: 'capacity' is declared over Plants but capacity[\"North\", 1] has 2 indices"));
+        }
+
+        [Fact]
+        public void Check_OperationsOnElements_ShouldFollowTheElementType()
+        {
+            string model = Declarations +
+                "{timestamp} Hours = {\"2024-01-01T06:00\", \"2024-01-01T07:00\"};\n" +
+                "forall(p in Plants: p != 1) shift: x[p-1,1] <= 5;\n" +
+                "forall(h in Hours: h >= \"2024-01-01T07:00\", t in Periods: t > 1) late: x[\"North\",t-1] <= 3;\n";
+
+            var diagnostics = IndexChecker.Check(Build(model), model);
+
+            Assert.Equal(new[]
+            {
+                "'p' is a string element of Plants and cannot be compared with 1 (int)",
+                "arithmetic on 'p' is not defined: it is a string element of Plants"
+            }, diagnostics.Select(d => d.Message));
+            Assert.All(diagnostics, d => Assert.Equal(6, d.LineNumber));
+        }
+    }
+}
@@ -0,0 +1,111 @@
+using Core;
+using Core.Services;
+using Core.Solving;
+
+namespace Tests
+{
+    public class KpiReportTests : TestBase
+    {
+        private const string Model =
+            "range T = 1..3;\n" +
+            "float price[T] = ...;\n" +
+            "float capacity = 5;\n" +
+            "dvar float+ gen[T];\n" +
+            "dvar float+ spill[T];\n" +
+            "dexpr float revenue = sum(t in T) price[t] * gen[t];\n" +
+            "// @kpi generation = sum(t in T) gen[t]\n" +
+            "// @kpi spillVolume = sum(t in 1..3) spill[t]\n" +
+            "// @kpi averagePrice = revenue / generation\n" +
+            "// @kpi peak = max(t in T) gen[t] / capacity\n" +
+            "maximize revenue;\n";
+
+        private ModelManager Load(string model = Model)
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(model));
+            AssertNoErrors(new DataFileParser(manager).Parse("price = [10 20 30];\n"));
+            return manager;
+        }
+
+        private static SolveResult Solution() => new SolveResult
+        {
+            Status = SolveStatus.Optimal,
+            VariableValues = new Dictionary<string, double>
+            {
+                ["gen1"] = 1, ["gen2"] = 2, ["gen3"] = 5, ["spill1"] = 0, ["spill2"] = 0.5, ["spill3"] = 1
+            }
+        };
+
+        [Fact]
+        public void Evaluate_ShouldComputeKpisFromSolutionValuesParametersAndDecisionExpressions()
+        {
+            var manager = Load();
+            var solution = Solution();
+
+            var report = manager.Report.Evaluate(manager, solution);
+
+            Assert.Equal(new[] { "generation", "spillVolume", "averagePrice", "peak" }, manager.Report.Kpis.Select(k => k.Name));
+            Assert.All(report.Values, v => Assert.Null(v.Error));
+            Assert.Equal(8.0, solution.Kpis["generation"]);
+            Assert.Equal(1.5, solution.Kpis["spillVolume"]);
+            Assert.Equal(200.0 / 8, solution.Kpis["averagePrice"]);
+            Assert.Equal(1.0, solution.Kpis["peak"]);
+            Assert.Equal(
+                "KPI           Value\n" +
+                "generation        8\n" +
+                "spillVolume     1.5\n" +
+                "averagePrice     25\n" +
+                "peak              1\n", report.ToTable().Replace("\r\n", "\n"));
+            Assert.Equal("KPI,Value\ngeneration,8\nspillVolume,1.5\naveragePrice,25\npeak,1\n", report.ToCsv().Replace("\r\n", "\n"));
+        }
+
+        [Fact]
+        public void Read_MalformedKpis_ShouldBeErrorsAtTheirLines()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "dvar float+ x;\n" +
+                "// @kpi total = x +\n" +
+                "// @kpi = x\n" +
+                "// @kpi twice = x\n" +
+                "// @kpi twice = 2 * x\n" +
+                "minimize x;\n");
+
+            Assert.Equal(new[]
+            {
+                (2, "KPI 'total': Unexpected end of expression"),
+                (3, "Expected '@kpi <name> = <expression>'"),
+                (5, "KPI 'twice' is already defined on line 4")
+            }, result.Errors.Select(e => (e.LineNumber, e.Message)));
+        }
+
+        [Fact]
+        public void Evaluate_MissingValue_ShouldReportTheKpiAndKeepTheOthers()
+        {
+            var manager = Load(Model + "// @kpi unknown = sum(t in T) flow[t]\n");
+            var solution = Solution();
+            solution.VariableValues.Remove("gen3");
+
+            var report = manager.Report.Evaluate(manager, solution);
+
+            Assert.Equal(new[]
+            {
+                "The solution has no value for 'gen3'", null, "The solution has no value for 'gen3'",
+                "The solution has no value for 'gen3'", "Unknown name 'flow'"
+            }, report.Values.Select(v => v.Error));
+            Assert.Equal(new[] { "spillVolume" }, solution.Kpis.Keys);
+        }
+
+        [Fact]
+        public void CaseComparison_ShouldShowTheKpisOfEachRun()
+        {
+            var solution = Solution();
+            solution.Kpis["generation"] = 8;
+            var comparison = new CaseComparison(new[] { new CaseRun { Case = "base", Result = solution } });
+
+            Assert.Equal(
+                "Case,Status,Objective,Change,Time (s),generation,gen,spill\n" +
+                "base,Optimal,,,0.00,8,8,1.5\n", comparison.ToCsv().Replace("\r\n", "\n"));
+        }
+    }
+}
@@ -0,0 +1,178 @@
+using System.Net;
+using System.Text;
+using System.Text.Json.Nodes;
+using Core;
+using Core.Solving;
+using Core.Storage;
+
+namespace Tests
+{
+    public class KubernetesSolveTransportTests : TestBase
+    {
+        /// <summary>
+        /// API server and object storage in one handler: the pod is unschedulable, then pending,
+        /// then runs with a growing log; on the fourth poll the job finishes and its result is
+        /// uploaded to the presigned RESULT_URL
+        /// </summary>
+        private class FakeCluster : HttpMessageHandler
+        {
+            private int polls;
+
+            public Dictionary<string, byte[]> Objects { get; } = new Dictionary<string, byte[]>(StringComparer.Ordinal);
+            public List<HttpRequestMessage> Requests { get; } = new List<HttpRequestMessage>();
+            public JsonNode? Manifest { get; private set; }
+            public bool FailJob { get; set; }
+            public bool Deleted { get; private set; }
+
+            protected override async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken cancellationToken)
+            {
+                Requests.Add(request);
+                string path = request.RequestUri!.AbsolutePath;
+
+                if (request.RequestUri.Host == "minio")
+                {
+                    string key = path.Substring("/solves/".Length);
+                    if (request.Method == HttpMethod.Put)
+                    {
+                        Objects[key] = await request.Content!.ReadAsByteArrayAsync(cancellationToken);
+                        return new HttpResponseMessage(HttpStatusCode.OK);
+                    }
+                    if (!Objects.TryGetValue(key, out var body))
+                        return new HttpResponseMessage(HttpStatusCode.NotFound);
+                    return new HttpResponseMessage(HttpStatusCode.OK) { Content = new ByteArrayContent(request.Method == HttpMethod.Head ? Array.Empty<byte>() : body) { Headers = { ContentLength = body.Length } } };
+                }
+
+                if (request.Method == HttpMethod.Post)
+                {
+                    Manifest = JsonNode.Parse(await request.Content!.ReadAsStringAsync(cancellationToken));
+                    return new HttpResponseMessage(HttpStatusCode.Created);
+                }
+                if (request.Method == HttpMethod.Delete)
+                {
+                    Deleted = true;
+                    return new HttpResponseMessage(HttpStatusCode.OK);
+                }
+                if (path.EndsWith("/log"))
+                    return Json(polls == 3 ? "Presolve done\n" : "Presolve done\nOptimal\n");
+                if (path.EndsWith("/pods"))
+                {
+                    string pod = polls switch
+                    {
+                        1 => "{\"phase\":\"Pending\",\"conditions\":[{\"type\":\"PodScheduled\",\"status\":\"False\",\"reason\":\"Unschedulable\",\"message\":\"0/3 nodes are available\"}]}",
+                        2 => "{\"phase\":\"Pending\"}",
+                        _ => "{\"phase\":\"Running\"}"
+                    };
+                    return Json($"{{\"items\":[{{\"metadata\":{{\"name\":\"solve-pod\"}},\"status\":{pod}}}]}}");
+                }
+
+                polls++;
+                if (polls < 4)
+                    return Json("{\"status\":{\"active\":1}}");
+                if (FailJob)
+                    return Json("{\"status\":{\"failed\":1,\"conditions\":[{\"type\":\"Failed\",\"status\":\"True\",\"message\":\"Job has reached the specified backoff limit\"}]}}");
+
+                var resultUrl = new Uri((string)Manifest!["spec"]!["template"]!["spec"]!["containers"]![0]!["env"]![0]!["value"]!);
+                Objects[resultUrl.AbsolutePath.Substring("/solves/".Length)] =
+                    Encoding.UTF8.GetBytes("{\"status\":\"Optimal\",\"objectiveValue\":5,\"variableValues\":{\"x\":5}}");
+                return Json("{\"status\":{\"succeeded\":1}}");
+            }
+
+            private static HttpResponseMessage Json(string body) =>
+                new HttpResponseMessage(HttpStatusCode.OK) { Content = new StringContent(body) };
+        }
+
+        private ModelManager Expand() => ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 5;\n");
+
+        private static (RemoteSolverDriver Driver, KubernetesSolveTransport Transport) CreateDriver(FakeCluster cluster)
+        {
+            var template = new KubernetesJobTemplate { Image = "registry/solver:1", CpuRequest = "16", MemoryLimit = "128Gi", VolumeSizeLimit = "50Gi" };
+            var transport = new KubernetesSolveTransport(new Uri("https://k8s:6443"), "solvers", template,
+                new S3StorageOptions { Endpoint = "http://minio:9000", Bucket = "solves", AccessKey = "AK", SecretKey = "SK" },
+                new HttpClient(cluster))
+            {
+                Token = "sa-token"
+            };
+            var driver = new RemoteSolverDriver(transport)
+            {
+                RetryDelay = TimeSpan.Zero,
+                PollInterval = TimeSpan.Zero,
+                TimeLimit = TimeSpan.FromMinutes(1)
+            };
+            return (driver, transport);
+        }
+
+        [Fact]
+        public async Task Solve_ShouldRunJobFromTemplateAndCollectResultFromStorage()
+        {
+            var cluster = new FakeCluster();
+            var manager = Expand();
+            var (driver, transport) = CreateDriver(cluster);
+            var statuses = new List<string>();
+            var lines = new List<string>();
+            transport.StatusChanged += statuses.Add;
+            driver.LogReceived += lines.Add;
+
+            var result = await driver.SolveAsync(manager);
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(5, result.VariableValues["x"]);
+            Assert.StartsWith("kubernetes k8s:6443/solvers: ", result.StatusMessage);
+
+            string jobId = SolveCache.KeyOf(manager, driver);
+            Assert.Equal(new Core.Export.MofExporter(manager).Export(), Encoding.UTF8.GetString(cluster.Objects[jobId + "/model.mof.json"]));
+            Assert.False(File.Exists(Path.Combine(Path.GetTempPath(), $"k8s_{jobId}.upload")));
+
+            var pod = cluster.Manifest!["spec"]!["template"]!["spec"]!;
+            var solver = pod["containers"]![0]!;
+            Assert.Equal("registry/solver:1", (string?)solver["image"]);
+            Assert.Equal("16", (string?)solver["resources"]!["requests"]!["cpu"]);
+            Assert.Equal("128Gi", (string?)solver["resources"]!["limits"]!["memory"]);
+            Assert.Equal("50Gi", (string?)pod["volumes"]![0]!["emptyDir"]!["sizeLimit"]);
+            Assert.Equal(
+                new[] { "modeledit-solve", "--solver", "cplex", "--timeLimitSeconds", "60" },
+                solver["command"]!.AsArray().Skip(4).Select(a => (string)a!));
+
+            string modelUrl = (string)pod["initContainers"]![0]!["env"]![0]!["value"]!;
+            string resultUrl = (string)solver["env"]![0]!["value"]!;
+            Assert.StartsWith($"http://minio:9000/solves/{jobId}/model.mof.json?X-Amz-Algorithm=AWS4-HMAC-SHA256", modelUrl);
+            Assert.StartsWith($"http://minio:9000/solves/{jobId}/result.json?", resultUrl);
+            Assert.Contains("X-Amz-Signature=", resultUrl);
+
+            Assert.Equal(new[] { "Presolve done", "Optimal" }, lines);
+            Assert.Equal(4, statuses.Count);
+            Assert.Matches("^Job solve-[0-9a-f]{12}-[0-9a-f]{11} created$", statuses[0]);
+            Assert.Equal(
+                new[] { "solve-pod: Pending (Unschedulable: 0/3 nodes are available)", "solve-pod: Pending", "solve-pod: Running" },
+                statuses.Skip(1));
+
+            Assert.All(cluster.Requests.Where(r => r.RequestUri!.Host == "k8s"), r => Assert.Equal("Bearer sa-token", r.Headers.Authorization!.ToString()));
+            Assert.All(cluster.Requests.Where(r => r.RequestUri!.Host == "minio"), r => Assert.StartsWith("AWS4-HMAC-SHA256 Credential=AK/", r.Headers.GetValues("Authorization").Single()));
+        }
+
+        [Fact]
+        public async Task Solve_FailedJob_ShouldReportJobCondition()
+        {
+            var cluster = new FakeCluster { FailJob = true };
+            var (driver, _) = CreateDriver(cluster);
+
+            var result = await driver.SolveAsync(Expand());
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Equal("Remote job failed on kubernetes k8s:6443/solvers: Job has reached the specified backoff limit", result.StatusMessage);
+        }
+
+        [Fact]
+        public async Task Cancel_ShouldDeleteJob()
+        {
+            var cluster = new FakeCluster();
+            var (driver, _) = CreateDriver(cluster);
+            using var cts = new CancellationTokenSource();
+            driver.LogReceived += _ => cts.Cancel();
+
+            await Assert.ThrowsAnyAsync<OperationCanceledException>(() => driver.SolveAsync(Expand(), cts.Token));
+
+            Assert.True(cluster.Deleted);
+            Assert.EndsWith("propagationPolicy=Background", cluster.Requests.Last().RequestUri!.Query);
+        }
+    }
+}
@@ -0,0 +1,77 @@
+using Core.Import;
+using Core.Models;
+
+namespace Tests
+{
+    public class LinearModelColumnsTests
+    {
+        [Fact]
+        public void Variables_ShouldWriteThroughViews()
+        {
+            var model = new LinearModel();
+            var x = model.GetOrAddVariable("x");
+            model.Variables.Add(new LinearVariable { Name = "y", Type = VariableType.Integer, UpperBound = 5 });
+
+            x.UpperBound = 10;
+            model.FindVariable("y")!.LowerBound = -2;
+            model.Variables[1].Type = VariableType.Boolean;
+
+            Assert.Equal(0, model.FindVariable("x")!.LowerBound);
+            Assert.Equal(10, model.Variables[0].UpperBound);
+            Assert.Equal(VariableType.Boolean, model.FindVariable("y")!.Type);
+            Assert.Equal(-2, model.Variables[1].LowerBound);
+            Assert.Equal(new[] { "x", "y" }, model.Variables.Select(v => v.Name));
+
+            Assert.True(model.Variables.Remove(x));
+            Assert.Null(model.FindVariable("x"));
+            Assert.Equal(0, model.Variables.IndexOf("y"));
+            Assert.Throws<InvalidOperationException>(() => x.UpperBound);
+        }
+
+        [Fact]
+        public void Constraints_ShouldStoreRowsAndDetectStaleViews()
+        {
+            var model = new LinearModel();
+            var coefficients = new Dictionary<string, double> { ["x"] = 2, ["y"] = -1 };
+            model.Constraints.Add(new LinearConstraint { Name = "cap", Coefficients = coefficients, Operator = RelationalOperator.LessThanOrEqual, Rhs = 4 });
+            model.Constraints.Add(new LinearConstraint { Name = "demand", Coefficients = new Dictionary<string, double> { ["x"] = 1 }, Operator = RelationalOperator.GreaterThanOrEqual, Rhs = 1 });
+
+            // The table keeps its own copy of the terms
+            coefficients["z"] = 3;
+
+            var cap = model.Constraints[0];
+            Assert.Equal("cap", cap.Name);
+            Assert.Equal(new Dictionary<string, double> { ["x"] = 2, ["y"] = -1 }, cap.Coefficients);
+            Assert.Equal(RelationalOperator.LessThanOrEqual, cap.Operator);
+            Assert.Equal(2, model.Constraints.GetTermCount(0));
+
+            model.Constraints[1] = new LinearConstraint { Name = "demand", Coefficients = new Dictionary<string, double> { ["y"] = 1 }, Rhs = 2 };
+            Assert.Equal(2, model.Constraints[1].Rhs);
+            Assert.Equal(RelationalOperator.Equal, model.Constraints[1].Operator);
+
+            Assert.True(model.Constraints.Remove(cap));
+            Assert.Equal(new[] { "demand" }, model.Constraints.Select(c => c.Name));
+            Assert.Throws<InvalidOperationException>(() => cap.Name);
+        }
+
+        [Fact]
+        public void Names_ShouldBeInternedOnce()
+        {
+            var model = new LinearModel();
+            for (int i = 0; i < 1000; i++)
+            {
+                model.GetOrAddVariable($"x{i}");
+                model.Constraints.Add(new LinearConstraint
+                {
+                    Name = $"c{i}",
+                    Coefficients = new Dictionary<string, double> { [$"x{i}"] = 1, [$"x{(i + 1) % 1000}"] = -1 }
+                });
+            }
+
+            Assert.Equal(2000, model.Names.Count);
+            Assert.Equal(500, model.Variables.IndexOf("x500"));
+            Assert.Equal(-1, model.Variables.IndexOf("c5"));
+            Assert.Null(model.FindVariable("c5"));
+        }
+    }
+}
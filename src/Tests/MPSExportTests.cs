@@ -318,5 +318,38 @@ namespace Tests
             // Cleanup
             File.Delete(tempFile);
         }
+
+        [Fact]
+        public void Export_WithPrecision_ShouldRoundValuesAndWarnOnce()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(@"
+                dvar float+ x;
+                dvar float+ y;
+                minimize 0.1*x + 0.333333333333333*y;
+                c1: x + y >= 1234567.891;
+            ");
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+
+            // By default every value is written exactly, and nothing is padded with digits
+            var exporter = new MPSExporter(manager);
+            string exact = exporter.Export();
+            Assert.Contains("0.333333333333333", exact);
+            Assert.Contains("1234567.891", exact);
+            Assert.DoesNotContain("0.10000", exact);
+            Assert.Empty(exporter.Warnings);
+
+            exporter.Precision = 4;
+            string rounded = exporter.Export();
+            Assert.Contains(" 0.3333", rounded);
+            Assert.DoesNotContain("0.33333", rounded);
+            Assert.Contains("1235000", rounded);
+            Assert.Contains(" 0.1", rounded);
+            var warning = Assert.Single(exporter.Warnings);
+            Assert.Contains("changed 2 of", warning);
+            Assert.Contains("1234567.891 written as 1235000", warning);
+        }
     }
-}
\ No newline at end of file
+}
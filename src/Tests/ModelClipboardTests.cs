@@ -0,0 +1,53 @@
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelClipboardTests
+    {
+        private const string Source =
+            "range T = 1..3;\n" +
+            "float cap = 100;\n" +
+            "float cost = 2;\n" +
+            "// @block hydro\n" +
+            "dvar float+ release[T] in 0..cap;\n" +
+            "forall(t in T) balance: release[t] <= cap;\n" +
+            "// @endblock\n" +
+            "minimize sum(t in T) cost * release[t];\n";
+
+        [Fact]
+        public void CopySelection_ShouldTakeTheBlockWithTheDeclarationsItReferences()
+        {
+            var fragment = ModelClipboard.CopySelection(Source, new[] { "block:hydro" }, "plant.mod");
+
+            Assert.Equal(new[] { "set:T", "parameter:cap", "variable:release", "constraint:balance" }, fragment.Keys);
+            Assert.Equal(new[] { true, true, false, false }, fragment.Entries.Select(e => e.Dependency));
+
+            var read = ModelFragment.FromJson(fragment.ToJson());
+            Assert.Equal("plant.mod", read.Source);
+            Assert.Equal(fragment.ToString(), read.ToString());
+            Assert.Throws<InvalidOperationException>(() => ModelClipboard.CopySelection(Source, new[] { "missing" }));
+        }
+
+        [Fact]
+        public void PasteSelection_ShouldReuseIdenticalDependenciesAndRenameCollisions()
+        {
+            const string target =
+                "range T = 1..3;\n" +
+                "float cap = 50;\n" +
+                "dvar float release[T];\n";
+
+            var fragment = ModelClipboard.CopySelection(Source, new[] { "balance" });
+            var result = ModelClipboard.PasteSelection(target, fragment);
+            var lines = result.ModelText.Split('\n');
+
+            Assert.Equal(new[] { "set:T" }, result.Reused);
+            Assert.Equal(new Dictionary<string, string> { ["cap"] = "cap_2", ["release"] = "release_2" }, result.Renamed);
+            Assert.Equal(new[] { "parameter:cap_2", "variable:release_2", "constraint:balance" }, result.Added);
+            Assert.Contains("float cap_2 = 100;", lines);
+            Assert.Contains("dvar float+ release_2[T] in 0..cap_2;", lines);
+            Assert.Contains("forall(t in T) balance: release_2[t] <= cap_2;", lines);
+            Assert.Contains("float cap = 50;", lines);
+            Assert.Equal(result.ModelText, result.Changes.Apply(target));
+        }
+    }
+}
@@ -0,0 +1,121 @@
+using Core;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelComposerTests : IDisposable
+    {
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "compose-" + Guid.NewGuid().ToString("N"));
+
+        public ModelComposerTests()
+        {
+            Directory.CreateDirectory(Path.Combine(directory, "lib"));
+            Write("lib/network.mod",
+                "range Nodes = 1..2;\n" +
+                "float capacity[Nodes] = [10, 20];\n");
+            Write("region.mod",
+                "import \"network.mod\" as net;\n" +
+                "export net.Nodes;\n" +
+                "dvar float+ supply[net.Nodes];\n" +
+                "forall(n in net.Nodes) cap: supply[n] <= net.capacity[n];\n");
+        }
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private string Write(string name, string text)
+        {
+            string path = Path.Combine(directory, name);
+            File.WriteAllText(path, text);
+            return path;
+        }
+
+        [Fact]
+        public void Compose_ShouldPrefixEachImportAndResolveReExports()
+        {
+            string plan = Write("plan.mod",
+                "import \"region.mod\" as north;\n" +
+                "import \"region.mod\" as south;\n" +
+                "dvar float+ total;\n" +
+                "forall(n in north.Nodes) link: north.supply[n] <= total;\n" +
+                "minimize total;\n");
+
+            var composer = ModelComposer.Compose(plan, new[] { Path.Combine(directory, "lib") });
+
+            Assert.Empty(composer.Errors);
+            Assert.Equal(new[] { "network.mod as north__net", "region.mod as north", "network.mod as south__net", "region.mod as south", "plan.mod" },
+                composer.Units.Select(u => u.ToString()));
+            Assert.Equal("north__net__Nodes", composer.Units[1].Exports["Nodes"]);
+            Assert.Equal("forall(n in north__net__Nodes) north__cap: north__supply[n] <= north__net__capacity[n];",
+                composer.Units[1].Text.Split('\n')[3]);
+
+            var manager = new ModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager)) { SolveAfterParse = false };
+            var result = service.ParseModel(composer.Units.Select(u => u.Text).ToList(), new List<string>());
+
+            Assert.Empty(result.Errors);
+            Assert.True(manager.IndexedVariables.ContainsKey("north__supply"));
+            Assert.True(manager.IndexedVariables.ContainsKey("south__supply"));
+            Assert.Contains("south__supply2", manager.GetEquationByLabel("south__cap_2")!.Coefficients.Keys);
+            Assert.Equal(2, manager.Equations.Count(e => e.BaseName == "link"));
+        }
+
+        [Fact]
+        public void Compose_ShouldReportCyclesMissingFilesAndPrivateNames()
+        {
+            Write("a.mod", "import \"b.mod\" as b;\nfloat x = 1;\n");
+            Write("b.mod", "import \"a.mod\" as a;\nimport \"c.mod\" as c;\nfloat y = 2;\n");
+            string plan = Write("plan.mod",
+                "import \"a.mod\" as a;\n" +
+                "import \"region.mod\" as r;\n" +
+                "float z = r.capacity[1];\n");
+
+            var composer = ModelComposer.Compose(plan, new[] { Path.Combine(directory, "lib") });
+
+            Assert.Equal(new[]
+            {
+                "Circular import: a.mod -> b.mod -> a.mod",
+                "b.mod line 2: imported file 'c.mod' not found",
+                "plan.mod line 3: 'capacity' is not exported by region.mod (imported as r)"
+            }, composer.Errors);
+        }
+
+        [Fact]
+        public void Compose_SelectiveImport_ShouldKeepNamesAndReuseTheFilesPrefix()
+        {
+            string plan = Write("plan.mod",
+                "import \"region.mod\" as north;\n" +
+                "import supply from \"region.mod\";\n" +
+                "import Nodes, capacity from \"network.mod\";\n" +
+                "float z = capacity[1];\n" +
+                "forall(n in Nodes) link: supply[n] <= z;\n");
+
+            var composer = ModelComposer.Compose(plan, new[] { Path.Combine(directory, "lib") });
+
+            Assert.Empty(composer.Errors);
+            Assert.Equal(new[] { "network.mod as north__net", "region.mod as north", "network.mod as network", "plan.mod" },
+                composer.Units.Select(u => u.ToString()));
+            Assert.Equal("forall(n in network__Nodes) link: north__supply[n] <= z;", composer.Units[3].Text.Split('\n')[4]);
+
+            var manager = new ModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager)) { SolveAfterParse = false };
+            var result = service.ParseModel(composer.Units.Select(u => u.Text).ToList(), new List<string>());
+
+            Assert.Empty(result.Errors);
+            Assert.Contains("north__supply1", manager.GetEquationByLabel("link_1")!.Coefficients.Keys);
+
+            string conflicting = Write("conflicting.mod",
+                "import Nodes, demand from \"network.mod\";\n" +
+                "float Nodes = 3;\n");
+
+            Assert.Equal(new[]
+            {
+                "conflicting.mod line 1: 'demand' is not exported by network.mod",
+                "conflicting.mod line 1: 'Nodes' is imported and also declared in this file"
+            }, ModelComposer.Compose(conflicting, new[] { Path.Combine(directory, "lib") }).Errors);
+        }
+    }
+}
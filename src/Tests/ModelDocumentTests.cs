@@ -0,0 +1,89 @@
+using System.Text;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelDocumentTests : TestBase
+    {
+        private const string Model =
+            "range I = 1..3;\n" +
+            "float c[I] = [1, 2, 3];\n" +
+            "dvar float+ x[I];\n" +
+            "execute { writeln(\"start\"); };\n" +
+            "minimize sum(i in I) c[i] * x[i];\n" +
+            "subject to {\n" +
+            "  forall(i in I) lower[i]: x[i] >= 1;\n" +
+            "  total: sum(i in I) x[i] <= 10;\n" +
+            "}\n" +
+            "// end\n";
+
+        [Fact]
+        public void Apply_ShouldMatchAFullParseAfterEachEdit()
+        {
+            var document = new ModelDocument(Model);
+            var random = new Random(7);
+            var pieces = new[] { ";", "}", "{", "(", "\n", "x", " ", "/*", "*/", "\"", "float y = 2;\n", "forall(i in I) " };
+
+            for (int n = 0; n < 300; n++)
+            {
+                int offset = random.Next(document.Text.Length + 1);
+                int length = random.Next(Math.Min(8, document.Text.Length - offset) + 1);
+                document.Apply(new TextChange { Offset = offset, Length = length, Text = pieces[random.Next(pieces.Length)] });
+
+                var expected = new ModelDocument(document.Text);
+                Assert.Equal(Describe(expected), Describe(document));
+                Assert.Equal(expected.ToSource().ToString(), document.Text);
+            }
+        }
+
+        [Fact]
+        public void Apply_ShouldReparseOnlyTheEditedRegionOfALargeModel()
+        {
+            var sb = new StringBuilder("range I = 1..10;\ndvar float+ x[I];\n");
+            for (int i = 0; i < 50000; i++)
+                sb.Append($"c{i}: x[{i % 10 + 1}] <= {i};\n\n");
+            var document = new ModelDocument(sb.ToString());
+
+            var result = document.Apply(25001, 15, 25001, 17, "==");
+
+            Assert.True(result.Removed <= 2 && result.Added <= 2, result.ToString());
+            var statement = document.Statements[12501];
+            Assert.Equal("c12499: x[10] == 12499;", statement.Code);
+            Assert.Equal(25001, statement.LineNumber);
+
+            document.Apply(3, 1, 3, 1, "// added\n// lines\n");
+            Assert.Equal(100003, document.Statements[^1].LineNumber);
+            Assert.Empty(document.Diagnostics);
+        }
+
+        [Fact]
+        public void Diagnostics_ShouldReportUnbalancedAndUnterminatedStatements()
+        {
+            var document = new ModelDocument(
+                "float a = (1 + 2];\n" +
+                "subject to {\n" +
+                "  c: a <= b\n" +
+                "}\n" +
+                "string s = \"open;\n");
+
+            Assert.Equal(new[]
+            {
+                "Line 1, column 17: Expected ')' but found ']'",
+                "Line 3, column 12: Missing ';' at end of statement",
+                "Line 5, column 12: Unterminated string"
+            }, document.Diagnostics.Select(d => d.ToString()));
+
+            document.Apply(1, 17, 1, 18, ")");
+            Assert.DoesNotContain(document.Diagnostics, d => d.LineNumber == 1);
+
+            Assert.Equal("Line 1, column 11: Unclosed '('", new ModelDocument("float d = (1 + 2;\n").Diagnostics.Single().ToString());
+        }
+
+        private static List<string> Describe(ModelDocument document)
+        {
+            return document.Statements.Select(s => $"{s.LineNumber} {s.Key} {s.Text}")
+                .Concat(document.Diagnostics.Select(d => d.ToString()))
+                .ToList();
+        }
+    }
+}
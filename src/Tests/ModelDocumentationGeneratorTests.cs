@@ -0,0 +1,109 @@
+using Core;
+using Core.Documentation;
+
+namespace Tests
+{
+    public class ModelDocumentationGeneratorTests : TestBase
+    {
+        private const string Model = @"
+            range Nodes = 1..3;
+            float capacity = 25;
+            dvar float+ flow[Nodes];
+            maximize sum(n in Nodes) flow[n];
+            forall(n in Nodes) cap: flow[n] <= capacity;
+        ";
+
+        private ModelManager ParseModel(string input)
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(input);
+            AssertNoErrors(result);
+            return manager;
+        }
+
+        [Fact]
+        public void Generate_Markdown_ShouldContainNomenclatureAndFormulations()
+        {
+            var manager = ParseModel(Model);
+            manager.Parameters["capacity"].Unit = "MW";
+            manager.Parameters["capacity"].Description = "Line capacity";
+
+            string doc = new ModelDocumentationGenerator(manager, new DocumentationOptions { Title = "Network" }).Generate();
+
+            Assert.StartsWith("# Network", doc);
+            Assert.Contains("## Sets", doc);
+            Assert.Contains(@"| $\mathit{Nodes}$ | $1..3$ | 3 |", doc);
+            Assert.Contains(@"| $\mathit{capacity}$ | float | MW | 25 | Line capacity |", doc);
+            Assert.Contains(@"| $\mathit{flow}_{\mathit{Nodes}}$ | float | $[0, \infty]$ |", doc);
+            Assert.Contains(@"\forall n \in \mathit{Nodes}", doc);
+            Assert.Contains(@"\text{cap:}\quad", doc);
+            Assert.Contains("| Constraint templates | 1 |", doc);
+        }
+
+        [Fact]
+        public void Generate_ShouldIncludeDocstrings()
+        {
+            var manager = ParseModel(
+                "#: Nodes of the network\n" +
+                "range Nodes = 1..3;\n" +
+                "\"\"\"Flow through a node,\n" +
+                "   in MW\"\"\"\n" +
+                "dvar float+ flow[Nodes];\n" +
+                "#: Largest total flow\n" +
+                "maximize sum(n in Nodes) flow[n];\n" +
+                "#: Flow | capacity limit\n" +
+                "forall(n in Nodes) cap: flow[n] <= 25;\n");
+
+            string doc = new ModelDocumentationGenerator(manager).Generate().Replace("\r\n", "\n");
+
+            Assert.Contains(@"| $\mathit{Nodes}$ | $1..3$ | 3 | Nodes of the network |", doc);
+            Assert.Contains(@"| $\mathit{flow}_{\mathit{Nodes}}$ | float | $[0, \infty]$ |  | Flow through a node, in MW |", doc);
+            Assert.Contains("$$\n\nLargest total flow\n", doc);
+            Assert.Contains("$$\n\nFlow | capacity limit\n", doc);
+        }
+
+        [Fact]
+        public void Generate_Latex_ShouldProduceStandaloneDocument()
+        {
+            var manager = ParseModel(Model);
+            manager.Parameters["capacity"].Description = "Capacity in % of rating";
+
+            string doc = new ModelDocumentationGenerator(manager, new DocumentationOptions { Format = DocumentationFormat.Latex }).Generate();
+
+            Assert.StartsWith(@"\documentclass{article}", doc);
+            Assert.Contains(@"\begin{longtable}", doc);
+            Assert.Contains(@"Capacity in \% of rating", doc);
+            Assert.Contains(@"\begin{equation*}", doc);
+            Assert.EndsWith(@"\end{document}" + Environment.NewLine, doc);
+        }
+
+        [Fact]
+        public void Generate_ExpandedModel_ShouldSummarizeConstraintFamilies()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            parser.ExpandAllTemplates(result);
+
+            string doc = new ModelDocumentationGenerator(manager).Generate();
+
+            Assert.Contains("3 instances of", doc);
+            Assert.Contains("| Constraints (expanded) | 3 |", doc);
+        }
+
+        [Fact]
+        public void Generate_DeferredRules_ShouldDocumentTheirRows()
+        {
+            var manager = new ModelManager { DeferRuleExpansion = true };
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            parser.ExpandAllTemplates(result);
+            Assert.Empty(manager.Equations);
+
+            string doc = new ModelDocumentationGenerator(manager).Generate();
+
+            Assert.Contains("3 instances of", doc);
+            Assert.Contains("| Constraints (expanded) | 3 |", doc);
+        }
+    }
+}
@@ -0,0 +1,110 @@
+using Core;
+using Core.Analysis;
+using Core.Export;
+
+namespace Tests
+{
+    public class ModelFingerprintTests : TestBase
+    {
+        [Fact]
+        public void Compute_DifferentFormattingAndOrder_ShouldProduceSameHash()
+        {
+            var a = ParseAndExpand(@"
+                dvar float+ x;
+                dvar float+ y;
+                maximize 3*x + 5*y;
+                c1: 2*x + y <= 10;
+                c2: x + 2*y <= 8;
+            ");
+            var b = ParseAndExpand(@"
+                dvar float+ y;   dvar float+ x;
+                maximize   5*y+3*x;
+                c2:   x+2*y<=8;
+                c1: y + 2*x <= 10;
+            ");
+
+            var fa = ModelFingerprint.Compute(a);
+            var fb = ModelFingerprint.Compute(b);
+
+            Assert.True(fa.Matches(fb));
+            Assert.Empty(fa.GetChangedEntities(fb));
+        }
+
+        [Fact]
+        public void Compute_ChangedCoefficient_ShouldReportChangedEntity()
+        {
+            var a = ParseAndExpand(@"
+                dvar float+ x;
+                maximize x;
+                c1: 2*x <= 10;
+                c2: x <= 8;
+            ");
+            var b = ParseAndExpand(@"
+                dvar float+ x;
+                maximize x;
+                c1: 2*x <= 11;
+                c2: x <= 8;
+            ");
+
+            var fa = ModelFingerprint.Compute(a);
+            var fb = ModelFingerprint.Compute(b);
+
+            Assert.False(fa.Matches(fb));
+            Assert.Equal(new[] { "constraint:c1" }, fa.GetChangedEntities(fb).ToArray());
+        }
+
+        [Fact]
+        public void Compute_PermutedRowsWithSameLabel_ShouldProduceSameHash()
+        {
+            var a = ParseAndExpand(@"
+                dvar float+ x;
+                dvar float+ y;
+                maximize x + y;
+                cap: x <= 4;
+                cap: y <= 6;
+                cap: x + y <= 9;
+            ");
+            var b = ParseAndExpand(@"
+                dvar float+ x;
+                dvar float+ y;
+                maximize x + y;
+                cap: x + y <= 9;
+                cap: x <= 4;
+                cap: y <= 6;
+            ");
+            var changed = ParseAndExpand(@"
+                dvar float+ x;
+                dvar float+ y;
+                maximize x + y;
+                cap: x + y <= 9;
+                cap: x <= 4;
+                cap: y <= 7;
+            ");
+
+            var fa = ModelFingerprint.Compute(a);
+
+            Assert.Equal(new[] { "constraint:cap", "constraint:cap#2", "constraint:cap#3" },
+                fa.EntityHashes.Keys.Where(k => k.StartsWith("constraint:")).OrderBy(k => k, StringComparer.Ordinal));
+            Assert.True(fa.Matches(ModelFingerprint.Compute(b)));
+            Assert.False(fa.Matches(ModelFingerprint.Compute(changed)));
+        }
+
+        [Fact]
+        public void Export_WithFingerprint_ShouldBeVerifiable()
+        {
+            var manager = ParseAndExpand(@"
+                dvar float+ x;
+                maximize x;
+                c1: x <= 8;
+            ");
+
+            string mps = new MPSExporter(manager) { IncludeFingerprint = true }.Export("TEST");
+            var fingerprint = ModelFingerprint.Compute(manager);
+
+            Assert.True(ModelFingerprint.TryReadStamp(mps, out var stamped));
+            Assert.Equal(fingerprint.ModelHash, stamped);
+            Assert.True(fingerprint.VerifyExport(mps));
+            Assert.False(fingerprint.VerifyExport(new MPSExporter(manager).Export("TEST")));
+        }
+    }
+}
@@ -0,0 +1,122 @@
+using Core.Formatting;
+
+namespace Tests
+{
+    public class ModelFormatterTests : TestBase
+    {
+        [Fact]
+        public void Format_ShouldNormalizeSpacingAndIndentation()
+        {
+            const string model =
+                "range I=1..3;\n" +
+                "float c[I]=[1,2,3];\n" +
+                "dvar float+ x[I];\n" +
+                "minimize sum(i in I)c[i]*x[i];\n" +
+                "subject to\n" +
+                "{\n" +
+                "forall(i in I)\n" +
+                "  lower[i]:   x[i]>=-1;\n" +
+                "}\n";
+
+            string formatted = ModelFormatter.FormatModel(model);
+
+            Assert.Equal(
+                "range I = 1..3;\n" +
+                "float c[I] = [1, 2, 3];\n" +
+                "\n" +
+                "dvar float+ x[I];\n" +
+                "\n" +
+                "minimize sum(i in I) c[i] * x[i];\n" +
+                "\n" +
+                "subject to {\n" +
+                "    forall(i in I)\n" +
+                "        lower[i]: x[i] >= -1;\n" +
+                "}\n",
+                formatted);
+            Assert.Equal(formatted, ModelFormatter.FormatModel(formatted));
+        }
+
+        [Fact]
+        public void Format_ShouldOrderSectionsAfterTheirDependencies()
+        {
+            const string model =
+                "range I = 1..3;\n" +
+                "dvar float+ x[I];\n" +
+                "// Costs\n" +
+                "float c[I] = ...;\n" +
+                "dexpr float cost = sum(i in I) c[i] * x[i];\n" +
+                "minimize cost;\n" +
+                "int n = card(I);\n";
+
+            Assert.Equal(
+                "range I = 1..3;\n" +
+                "// Costs\n" +
+                "float c[I] = ...;\n" +
+                "int n = card(I);\n" +
+                "\n" +
+                "dvar float+ x[I];\n" +
+                "\n" +
+                "dexpr float cost = sum(i in I) c[i] * x[i];\n" +
+                "\n" +
+                "minimize cost;\n",
+                ModelFormatter.FormatModel(model));
+
+            var unordered = new ModelFormatter(new ModelFormatOptions { OrderSections = false });
+            Assert.StartsWith("range I = 1..3;\ndvar float+ x[I];\n// Costs\n", unordered.Format(model));
+        }
+
+        [Fact]
+        public void Format_ShouldWrapLongSumsAndKeepTheModelEquivalent()
+        {
+            const string model =
+                "range T = 1..24;\n" +
+                "dvar float+ production[T];\n" +
+                "dvar float+ purchase[T];\n" +
+                "dvar float+ storage[T];\n" +
+                "dvar float+ shortage[T];\n" +
+                "dexpr float totalCost = sum(t in T) 12.5*production[t] + sum(t in T) 30*purchase[t] + sum(t in T) 0.4*storage[t] + sum(t in T) 1000*shortage[t];\n" +
+                "minimize totalCost;\n";
+            var formatter = new ModelFormatter();
+
+            string formatted = formatter.Format(model);
+
+            Assert.Contains(
+                "dexpr float totalCost = sum(t in T) 12.5 * production[t] + sum(t in T) 30 * purchase[t]\n" +
+                "    + sum(t in T) 0.4 * storage[t] + sum(t in T) 1000 * shortage[t];\n",
+                formatted);
+            Assert.Equal(formatted, formatter.Format(formatted));
+
+            var before = CreateParser(CreateModelManager()).Parse(model);
+            var after = CreateParser(CreateModelManager()).Parse(formatted);
+            AssertNoErrors(after);
+            Assert.Equal(before.SuccessCount, after.SuccessCount);
+        }
+
+        [Fact]
+        public void FormatData_ShouldAlignAssignments()
+        {
+            const string data =
+                "n=3;\n" +
+                "capacity = [10 -2  30];\n" +
+                "arcs={<1,2>,<2,3>};\n" +
+                "\n" +
+                "cost =[\n" +
+                "[1 2]\n" +
+                "[3 4]];\n";
+
+            var formatter = new ModelFormatter();
+            string formatted = formatter.FormatData(data);
+
+            Assert.Equal(
+                "n        = 3;\n" +
+                "capacity = [10 -2 30];\n" +
+                "arcs     = {<1, 2>, <2, 3>};\n" +
+                "\n" +
+                "cost = [\n" +
+                "    [1 2]\n" +
+                "    [3 4]];\n",
+                formatted);
+            Assert.Equal(formatted, formatter.FormatData(formatted));
+        }
+    }
+}
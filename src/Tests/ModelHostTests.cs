@@ -0,0 +1,226 @@
+using Core;
+using Core.Analysis;
+using Core.Models;
+using Core.Server;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ModelHostTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private class RecordingDriver : ISolverDriver
+        {
+            public ModelManager? Solved { get; private set; }
+            public string Name => "Recording";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Solved = manager;
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 75 };
+            }
+        }
+
+        private class SynchronousProgress : IProgress<SolveProgress>
+        {
+            public List<SolveProgress> Events { get; } = new List<SolveProgress>();
+            public void Report(SolveProgress value) => Events.Add(value);
+        }
+
+        [Fact]
+        public void GetEntities_ShouldReturnStructuredDeclarations()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var entities = host.GetEntities(model.Id);
+
+            Assert.Empty(model.Errors);
+            Assert.Equal(new[] { "set:Nodes", "parameter:capacity", "variable:flow", "objective", "constraint:cap" }, entities.Select(e => e.Key));
+
+            var flow = entities.Single(e => e.Name == "flow");
+            Assert.Equal("float+", flow.Type);
+            Assert.Equal(new[] { "Nodes" }, flow.IndexSets);
+
+            var cap = entities.Single(e => e.Name == "cap");
+            Assert.Equal("n in Nodes", cap.Forall);
+            Assert.Equal("flow[n] <= capacity", cap.Body);
+        }
+
+        [Fact]
+        public void EntityDefinition_ShouldRoundTripThroughStatement()
+        {
+            var definition = new EntityDefinition
+            {
+                Kind = EntityKind.Variable,
+                Name = "x",
+                Type = "int",
+                IndexSets = { "I", "J" },
+                LowerBound = "0",
+                UpperBound = "10"
+            };
+
+            string statement = definition.ToStatement();
+            var parsed = EntityDefinition.FromStatement(statement)!;
+
+            Assert.Equal("dvar int x[I,J] in 0..10;", statement);
+            Assert.Equal(definition.Key, parsed.Key);
+            Assert.Equal(definition.IndexSets, parsed.IndexSets);
+            Assert.Equal("10", parsed.UpperBound);
+        }
+
+        [Fact]
+        public void ApplyEntity_ShouldReplaceAndBumpVersion()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var result = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "capacity", Value = "30" });
+
+            Assert.True(result.Success);
+            Assert.True(result.Replaced);
+            Assert.Equal(2, result.Version);
+            Assert.Contains("float capacity = 30;", model.ModelText);
+            Assert.DoesNotContain("= 25", model.ModelText);
+        }
+
+        [Fact]
+        public void ApplyEntity_WithParseError_ShouldRollBack()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var result = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Constraint, Name = "bad", Body = "flow[n] <= " });
+
+            Assert.False(result.Success);
+            Assert.NotEmpty(result.Errors);
+            Assert.Equal(1, model.Version);
+            Assert.Equal(Model, model.ModelText);
+        }
+
+        [Fact]
+        public void ApplyEntity_MissingField_ShouldFailWithoutTouchingModel()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var result = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Set, Type = "range", Name = "Hours" });
+
+            Assert.False(result.Success);
+            Assert.Contains("no value", result.Errors.Single());
+        }
+
+        [Fact]
+        public void RemoveEntity_UnknownKey_ShouldFail()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            Assert.True(host.RemoveEntity(model.Id, "constraint:cap").Success);
+            Assert.False(host.RemoveEntity(model.Id, "constraint:cap").Success);
+        }
+
+        [Fact]
+        public void ApplyEntity_StaleRevision_ShouldConflictWithCurrentState()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+            var capacity = host.GetEntities(model.Id).Single(e => e.Key == "parameter:capacity");
+            Assert.Equal(1, capacity.Revision);
+
+            var first = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "capacity", Value = "30" }, expectedRevision: 1);
+            var second = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "capacity", Value = "40" }, expectedRevision: 1);
+
+            Assert.True(first.Success);
+            Assert.Equal(2, first.Revision);
+            Assert.False(second.Success);
+            Assert.True(second.Conflict);
+            Assert.Equal(2, second.Revision);
+            Assert.Equal("30", second.Current!.Value);
+            Assert.Contains("float capacity = 30;", model.ModelText);
+            Assert.Equal(1, model.GetRevision("constraint:cap"));
+        }
+
+        [Fact]
+        public void ApplyEntity_ExpectedRevisionZero_ShouldOnlyCreate()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var created = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "cost", Value = "2" }, expectedRevision: 0);
+            var duplicate = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "cost", Value = "3" }, expectedRevision: 0);
+
+            Assert.True(created.Success);
+            Assert.True(duplicate.Conflict);
+            Assert.Equal("2", duplicate.Current!.Value);
+        }
+
+        [Fact]
+        public void RemoveEntity_ShouldKeepRevisionCounterForReAdd()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            Assert.True(host.RemoveEntity(model.Id, "parameter:capacity", expectedRevision: 1).Success);
+            Assert.Equal(0, model.GetRevision("parameter:capacity"));
+
+            var stale = host.RemoveEntity(model.Id, "parameter:capacity", expectedRevision: 1);
+            Assert.True(stale.Conflict);
+            Assert.Null(stale.Current);
+
+            var readded = host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "capacity", Value = "5" }, expectedRevision: 0);
+            Assert.Equal(3, readded.Revision);
+        }
+
+        [Fact]
+        public void RequireRevisions_ShouldRejectUnconditionalEdits()
+        {
+            var host = new ModelHost { RequireRevisions = true };
+            var model = host.Create("network", Model);
+
+            var result = host.RemoveEntity(model.Id, "constraint:cap");
+
+            Assert.False(result.Success);
+            Assert.False(result.Conflict);
+            Assert.Equal(1, model.Version);
+        }
+
+        [Fact]
+        public async Task SolveAsync_ShouldExpandModelAndReportPhases()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+            var driver = new RecordingDriver();
+            var progress = new SynchronousProgress();
+
+            var result = await host.SolveAsync(model.Id, driver, progress);
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(3, driver.Solved!.Equations.Count);
+            Assert.Equal(SolvePhase.Parsing, progress.Events.First().Phase);
+            Assert.Contains(progress.Events, e => e.Phase == SolvePhase.Solving);
+            Assert.Equal(SolvePhase.Completed, progress.Events.Last().Phase);
+            Assert.Equal(75, progress.Events.Last().ObjectiveValue);
+        }
+
+        [Fact]
+        public async Task SolveAsync_MissingData_ShouldReportFailure()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model.Replace("float capacity = 25;", "float capacity = ...;"));
+            var progress = new SynchronousProgress();
+
+            var result = await host.SolveAsync(model.Id, new RecordingDriver(), progress);
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Contains("capacity", result.StatusMessage);
+            Assert.Equal(SolvePhase.Failed, progress.Events.Last().Phase);
+        }
+    }
+}
@@ -0,0 +1,88 @@
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelLexerTests : TestBase
+    {
+        private const string Model =
+            "#: Plants\n" +
+            "{string} Plants = {\"North\", \"South\"};\n" +
+            "float capacity[Plants] = [1.5e2, 200];\n" +
+            "dvar float+ ship[Plants];\n" +
+            "/* Objective\n" +
+            "   and limits */\n" +
+            "maximize sum(p in Plants) ship[p];\n" +
+            "subject to {\n" +
+            "  forall(p in Plants) cap: ship[p] <= capacity[p];\n" +
+            "}\n";
+
+        [Fact]
+        public void Tokenize_ShouldClassifyTokensWithPositions()
+        {
+            var tokens = ModelLexer.Tokenize(Model);
+
+            Assert.Equal(SyntaxTokenKind.Docstring, tokens[0].Kind);
+            Assert.Equal(new[] { SyntaxTokenKind.SetLiteral, SyntaxTokenKind.Type, SyntaxTokenKind.SetLiteral, SyntaxTokenKind.Identifier },
+                tokens.Skip(1).Take(4).Select(t => t.Kind));
+
+            var literal = tokens.Where(t => t.LineNumber == 2 && t.Kind == SyntaxTokenKind.SetLiteral).ToList();
+            Assert.Equal(4, literal.Count);
+            Assert.Equal(19, literal[2].Column);
+            Assert.Equal(SyntaxTokenKind.String, tokens.First(t => t.Text == "\"North\"").Kind);
+
+            var number = tokens.Single(t => t.Text == "1.5e2");
+            Assert.Equal(SyntaxTokenKind.Number, number.Kind);
+            Assert.Equal((3, 27), (number.LineNumber, number.Column));
+            Assert.Equal(Model.IndexOf("1.5e2", StringComparison.Ordinal), number.Offset);
+
+            Assert.Equal(SyntaxTokenKind.Type, tokens.Single(t => t.Text == "float+").Kind);
+            var comment = tokens.Single(t => t.Kind == SyntaxTokenKind.Comment);
+            Assert.Equal((5, "/* Objective\n   and limits */"), (comment.LineNumber, comment.Text));
+            Assert.Equal(7, tokens.Single(t => t.Text == "maximize").LineNumber);
+            Assert.Equal(SyntaxTokenKind.Function, tokens.Single(t => t.Text == "sum").Kind);
+
+            // The braces of subject to are a block, not a set
+            Assert.All(tokens.Where(t => t.LineNumber >= 8 && t.Text is "{" or "}"), t => Assert.Equal(SyntaxTokenKind.Punctuation, t.Kind));
+        }
+
+        [Fact]
+        public void Tokenize_ShouldKeepRangesAndTupleFieldsApart()
+        {
+            var tokens = ModelLexer.Tokenize("range R = 1..n; x = a.b + 2.5;");
+
+            Assert.Equal(new[] { "range", "R", "=", "1", "..", "n", ";", "x", "=", "a", ".", "b", "+", "2.5", ";" },
+                tokens.Select(t => t.Text));
+        }
+
+        [Fact]
+        public void Classify_ShouldResolveDeclaredNames()
+        {
+            var tokens = ModelLexer.Classify(Model);
+
+            var ship = tokens.Where(t => t.Text == "ship").ToList();
+            Assert.Equal(3, ship.Count);
+            Assert.All(ship, t => Assert.Equal("variable:ship", t.Symbol));
+            Assert.Equal(new[] { true, false, false }, ship.Select(t => t.IsDeclaration));
+
+            Assert.Equal("constraint:cap", tokens.Single(t => t.Text == "cap").Symbol);
+            Assert.Equal("set:Plants", tokens.Last(t => t.Text == "Plants").Symbol);
+            Assert.Null(tokens.Last(t => t.Text == "p").Symbol);
+        }
+
+        [Fact]
+        public void Encode_ShouldProduceRelativeLspTokens()
+        {
+            var data = SemanticTokens.Encode("dvar float x;\n  minimize x;");
+            var types = SemanticTokens.TokenTypes;
+
+            Assert.Equal(new[]
+            {
+                0, 0, 4, types.ToList().IndexOf("keyword"), 0,
+                0, 5, 5, types.ToList().IndexOf("type"), 0,
+                0, 6, 1, types.ToList().IndexOf("variable"), 1,
+                1, 2, 8, types.ToList().IndexOf("keyword"), 0,
+                0, 9, 1, types.ToList().IndexOf("variable"), 0
+            }, data);
+        }
+    }
+}
@@ -0,0 +1,113 @@
+using Core;
+using Core.Models;
+using Core.Server;
+
+namespace Tests
+{
+    public class ModelLimitsTests : TestBase
+    {
+        private static string Repeat(string text, int count) => string.Concat(Enumerable.Repeat(text, count));
+
+        [Fact]
+        public void Parse_DeeplyNestedExpression_ShouldReportErrorInsteadOfOverflowing()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "dvar float+ x;\nminimize x;\nc1: x + " + Repeat("(1 + ", 20000) + "x" + Repeat(")", 20000) + " <= 1;");
+
+            AssertHasError(result, "nests 20000 levels deep, more than the limit of 500");
+            Assert.Empty(manager.Equations);
+        }
+
+        [Fact]
+        public void Parse_LongChainOfOneVariable_ShouldReportCoefficientDepth()
+        {
+            var manager = CreateModelManager();
+            manager.Limits = new ModelLimits { MaxExpressionDepth = 50 };
+            var result = CreateParser(manager).Parse("dvar float+ x;\nminimize x;\nc1: " + Repeat("x + ", 100) + "x <= 1000;");
+
+            AssertHasError(result, "Coefficient of 'x' in c1 nests more than 50 levels deep");
+        }
+
+        [Fact]
+        public void Parse_MoreEntitiesThanLimit_ShouldReportError()
+        {
+            var manager = CreateModelManager();
+            manager.Limits = new ModelLimits { MaxEntities = 3 };
+            var result = CreateParser(manager).Parse(
+                "float a = 1;\nfloat b = 2;\nfloat c = 3;\nfloat d = 4;\n");
+
+            AssertHasError(result, "more than 3 entities");
+            Assert.Equal(3, manager.Parameters.Count);
+        }
+
+        [Fact]
+        public void AddEquation_BeyondEntityLimit_ShouldThrow()
+        {
+            var manager = CreateModelManager();
+            manager.Limits = new ModelLimits { MaxEntities = 1 };
+            manager.AddEquation(new LinearEquation());
+
+            var ex = Assert.Throws<ModelLimitException>(() => manager.AddEquation(new LinearEquation()));
+
+            Assert.Equal(nameof(ModelLimits.MaxEntities), ex.Limit);
+            Assert.Equal(1, ex.Maximum);
+            Assert.Equal(2, ex.Actual);
+        }
+
+        [Fact]
+        public void Parse_TextBeyondFileSize_ShouldNotBeParsed()
+        {
+            var manager = CreateModelManager();
+            manager.Limits = new ModelLimits { MaxFileSize = 1024 };
+
+            var result = CreateParser(manager).Parse("float a = 1;\n" + Repeat("// padding\n", 200));
+            var data = new DataFileParser(manager).Parse("n = 3;\n" + Repeat("// padding\n", 200));
+
+            AssertHasError(result, "Model text is 2.2 KB, more than the limit of 1 KB");
+            AssertHasError(data, "Data text is 2.2 KB");
+            Assert.Empty(manager.Parameters);
+        }
+
+        [Fact]
+        public void EstimateMemory_ShouldGrowWithTermsAndValues()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse("range I = 1..50;\nfloat c[I] = ...;\ndvar float+ x[I];\nforall(i in I) lo: x[i] >= 1;\n");
+            long declared = ModelLimits.EstimateMemory(manager);
+            new DataFileParser(manager).Parse("c = [" + string.Join(", ", Enumerable.Range(1, 50)) + "];");
+            long withData = ModelLimits.EstimateMemory(manager);
+
+            parser.ExpandAllTemplates(result);
+
+            Assert.True(withData > declared);
+            Assert.True(ModelLimits.EstimateMemory(manager) > withData);
+        }
+
+        [Fact]
+        public void Expand_BeyondMemoryLimit_ShouldReportError()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse("range I = 1..200;\ndvar float+ x[I];\nforall(i in I) lo: x[i] >= 1;\n");
+            AssertNoErrors(result);
+            manager.Limits = new ModelLimits { MaxMemoryBytes = ModelLimits.EstimateMemory(manager) + 1000 };
+
+            parser.ExpandAllTemplates(result);
+
+            AssertHasError(result, "more than the limit of");
+        }
+
+        [Fact]
+        public void ModelHost_ShouldRefuseTextBeyondLimitAndParseWithLimits()
+        {
+            var host = new ModelHost { Limits = new ModelLimits { MaxFileSize = 100, MaxEntities = 2 } };
+
+            Assert.Throws<ModelLimitException>(() => host.Create("big", Repeat("float a = 1;\n", 20)));
+
+            var model = host.Create("small", "float a = 1;\nfloat b = 2;\nfloat c = 3;\n");
+            Assert.Contains(model.Errors, e => e.Contains("more than 2 entities"));
+        }
+    }
+}
@@ -0,0 +1,138 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class ModelLinearizerTests : TestBase
+    {
+        [Fact]
+        public void Linearize_ShouldReplaceBinaryProductsWithMcCormickInequalities()
+        {
+            string model =
+                "range I = 1..2;\n" +
+                "float cap = 40;\n" +
+                "dvar bool open[I];\n" +
+                "dvar float+ flow[I] in 0..cap;\n" +
+                "forall(i in I) use: open[i] * flow[i] <= 30;\n" +
+                "minimize sum(i in I) flow[i];\n";
+
+            var result = new ModelLinearizer().Linearize(model);
+
+            Assert.Empty(result.Warnings);
+            Assert.Equal("constraint:use: open[i] * flow[i] -> use_prod (McCormick)", result.Terms.Single().ToString());
+            Assert.Contains("forall(i in I) use: use_prod[i] <= 30;", result.ModelText);
+            Assert.Contains("// @generated linearize from constraint:use\ndvar float use_prod[I];", result.ModelText.Replace("\r\n", "\n"));
+            Assert.Contains("forall(i in I) use_prod_def1: use_prod[i] <= cap * open[i];", result.ModelText);
+            Assert.Contains("forall(i in I) use_prod_def3: use_prod[i] <= flow[i];", result.ModelText);
+            Assert.Contains("forall(i in I) use_prod_def4: use_prod[i] >= flow[i] - cap + cap * open[i];", result.ModelText);
+
+            var provenance = ModelProvenance.Parse(result.ModelText);
+            Assert.Equal(5, provenance.Select(ModelLinearizer.Generator).Count);
+            Assert.Empty(CreateParser().Parse(result.ModelText).Errors);
+        }
+
+        [Fact]
+        public void Linearize_ShouldUseEpigraphsInConvexPositions()
+        {
+            string model =
+                "dvar float x;\n" +
+                "dvar float y;\n" +
+                "dvar float z;\n" +
+                "spread: max(x, y, 3) <= 10;\n" +
+                "floor: min(x, y) >= 2;\n" +
+                "minimize 2 * abs(x - y) + z;\n";
+
+            var result = new ModelLinearizer().Linearize(model);
+
+            Assert.Equal(new[] { "epigraph", "hypograph", "epigraph" }, result.Terms.Select(t => t.Formulation));
+            Assert.Contains("spread: spread_max <= 10;", result.ModelText);
+            Assert.Contains("spread_max_def3: spread_max >= 3;", result.ModelText);
+            Assert.Contains("floor_min_def2: floor_min <= y;", result.ModelText);
+            Assert.Contains("minimize 2 * objective_abs + z;", result.ModelText);
+            Assert.Contains("objective_abs_def1: objective_abs >= (x - y);", result.ModelText);
+            Assert.Contains("objective_abs_def2: objective_abs >= -(x - y);", result.ModelText);
+            Assert.Contains("dvar float+ objective_abs;", result.ModelText);
+        }
+
+        [Fact]
+        public void Linearize_ShouldNeedABigMOutsideConvexPositions()
+        {
+            string model =
+                "dvar float x in -5..5;\n" +
+                "dvar float y;\n" +
+                "dvar float+ u;\n" +
+                "dvar float+ v;\n" +
+                "least: abs(x) >= 1;\n" +
+                "bad: u * v <= 3;\n" +
+                "maximize y;\n";
+
+            var plain = new ModelLinearizer().Linearize(model);
+            Assert.Empty(plain.Terms);
+            Assert.Equal(new[]
+            {
+                "least: abs(x) left as is, it is not in a position where an epigraph is exact; give a big-M to linearize it with binaries",
+                "bad: u * v left as is, neither factor is binary"
+            }, plain.Warnings);
+            Assert.Equal(model, plain.ModelText);
+
+            var result = new ModelLinearizer { BigM = 100 }.Linearize(model, new[] { "constraint:least" });
+            Assert.Equal("big-M", result.Terms.Single().Formulation);
+            Assert.Contains("dvar bool least_abs_sel1;", result.ModelText);
+            Assert.Contains("least_abs_def1: least_abs_sel1 + least_abs_sel2 == 1;", result.ModelText);
+            Assert.Contains("least_abs_def3: least_abs <= x + 100 - 100 * least_abs_sel1;", result.ModelText);
+            Assert.Contains("least_abs_def5: least_abs <= -x + 100 - 100 * least_abs_sel2;", result.ModelText);
+            Assert.Contains("bad: u * v <= 3;", result.ModelText);
+        }
+
+        [Fact]
+        public void Linearize_ShouldIndexAggregationAuxiliariesByTheirDomain()
+        {
+            string model =
+                "range P = 1..2;\n" +
+                "range I = 1..3;\n" +
+                "dvar float x[P,I] in 0..10;\n" +
+                "dvar float+ cap[P];\n" +
+                "forall(p in P) peak: max(i in I) x[p,i] <= cap[p];\n" +
+                "forall(p in P) low: min(i in I: i > 1) x[p,i] <= 2;\n" +
+                "forall(p in P) few: count(i in I) x[p,i] <= 2;\n" +
+                "minimize sum(p in P) cap[p];\n";
+
+            var result = new ModelLinearizer().Linearize(model);
+
+            Assert.Empty(result.Warnings);
+            Assert.Equal(new[] { "epigraph", "big-M", "indicator" }, result.Terms.Select(t => t.Formulation));
+            Assert.Contains("forall(p in P) peak: peak_max[p] <= cap[p];", result.ModelText);
+            Assert.Contains("dvar float peak_max[P];", result.ModelText);
+            Assert.Contains("forall(p in P, i in I) peak_max_def1: peak_max[p] >= x[p,i];", result.ModelText);
+            Assert.Contains("dvar bool low_min_sel[P,I];", result.ModelText);
+            Assert.Contains("forall(p in P) low_min_def1: sum(i in I: i > 1) low_min_sel[p,i] == 1;", result.ModelText);
+            Assert.Contains("forall(p in P, i in I: i > 1) low_min_def3: low_min[p] >= x[p,i] - 10 + 10 * low_min_sel[p,i];", result.ModelText);
+            Assert.Contains("forall(p in P) few: sum(i in I) few_count[p,i] <= 2;", result.ModelText);
+            Assert.Contains("forall(p in P, i in I) few_count_def1: x[p,i] <= 10 * few_count[p,i];", result.ModelText);
+        }
+
+        [Fact]
+        public void ReformulateAggregations_ShouldKeepTheLinesOfTheModel()
+        {
+            string model =
+                "range I = 1..3;\n" +
+                "dvar float x[I] in 0..5;\n" +
+                "top: max(i in I)\n" +
+                "     x[i] <= 4;\n" +
+                "maximize sum(i in I) x[i];\n";
+
+            string text = ModelLinearizer.ReformulateAggregations(model);
+
+            var lines = text.Split('\n');
+            Assert.Equal("top: top_max <= 4;", lines[2]);
+            Assert.Equal("maximize sum(i in I) x[i];", lines[4]);
+            Assert.Contains("top_max_def1: top_max >= x[i];", text);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var parsed = parser.Parse(model);
+            parser.ExpandAllTemplates(parsed);
+            AssertNoErrors(parsed);
+        }
+    }
+}
@@ -0,0 +1,150 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class ModelLinterTests : TestBase
+    {
+        private const string Model = @"
+            range I = 1..3;
+            range Unused = 1..2;
+            float cost[I] = [4, 2, 7];
+            float limit = 5;
+            float spare = 1;
+            dvar float+ x[I];
+            dvar float free;
+            dvar float+ idle;
+            minimize sum(i in I) cost[i] * x[i] + free;
+            forall(i in I) cap: x[i] <= limit;
+            balance: free >= -3;
+        ";
+
+        private static IEnumerable<string> Subjects(LintReport report, string ruleId) =>
+            report.Findings.Where(f => f.RuleId == ruleId).Select(f => f.Subject);
+
+        [Fact]
+        public void Lint_Default_ShouldReportUnusedAndFreeEntities()
+        {
+            var report = new ModelLinter(ParseAndExpand(Model)) { ModelTexts = new[] { Model } }.Lint();
+
+            Assert.Equal(new[] { "idle" }, Subjects(report, "unused-variable"));
+            Assert.Equal(new[] { "spare" }, Subjects(report, "unused-parameter"));
+            Assert.Equal(new[] { "Unused" }, Subjects(report, "unused-set"));
+            Assert.Equal(new[] { "free" }, Subjects(report, "free-variable"));
+            Assert.Empty(Subjects(report, "missing-objective"));
+            Assert.Empty(Subjects(report, "missing-description"));
+            Assert.False(report.HasErrors);
+            Assert.Equal(LintSeverity.Warning, report.Findings[0].Severity);
+        }
+
+        [Fact]
+        public void Lint_WithoutSource_ShouldSkipUnusedParameterAndSetRules()
+        {
+            var report = new ModelLinter(ParseAndExpand(Model)).Lint();
+
+            Assert.Empty(Subjects(report, "unused-parameter"));
+            Assert.Empty(Subjects(report, "unused-set"));
+            Assert.Equal(new[] { "idle" }, Subjects(report, "unused-variable"));
+        }
+
+        [Fact]
+        public void Lint_Strict_ShouldEscalateSeverities()
+        {
+            var report = new ModelLinter(ParseAndExpand(Model)) { ModelTexts = new[] { Model } }.Lint(LintProfile.Strict);
+
+            Assert.True(report.HasErrors);
+            Assert.Equal(LintSeverity.Error, report.Findings.Single(f => f.RuleId == "unused-variable").Severity);
+            Assert.Equal(LintSeverity.Warning, report.Findings.Single(f => f.RuleId == "unused-set").Severity);
+            Assert.Contains("x", Subjects(report, "missing-description"));
+        }
+
+        [Fact]
+        public void Lint_NonlinearModel_ShouldReportClassAndNonconvexConstraints()
+        {
+            string model = "dvar float x in 0..4;\ndvar float y in 0..4;\nminimize x^2 + y^2;\nlink: x * y == 2;\n";
+            var report = new ModelLinter(ParseAndExpand(model)) { ModelTexts = new[] { model } }.Lint();
+
+            Assert.Equal("warning [nonconvex] link: nonconvex (nonlinear equality); local solvers may return a local optimum",
+                report.Findings.Single(f => f.RuleId == "nonconvex").ToString());
+            Assert.Equal("QCP, convex objective, nonconvex constraints", report.Findings.Single(f => f.RuleId == "problem-class").Message);
+            Assert.Empty(new ModelLinter(ParseAndExpand(Model)) { ModelTexts = new[] { Model } }.Lint().Findings.Where(f => f.RuleId is "nonconvex" or "problem-class"));
+        }
+
+        [Fact]
+        public void Lint_ParseErrors_ShouldBeErrorsByDefault()
+        {
+            var report = new ModelLinter(ParseAndExpand("dvar float+ x;\nminimize x;")).Lint(new[] { "Line 3: unexpected token" });
+
+            var finding = Assert.Single(report.Findings);
+            Assert.Equal("parse-error", finding.RuleId);
+            Assert.Equal("error [parse-error] Line 3: unexpected token", finding.ToString());
+            Assert.True(report.HasErrors);
+        }
+
+        [Fact]
+        public void Resolve_CustomProfile_ShouldOverrideExtendedProfile()
+        {
+            var configuration = LintConfiguration.Parse(@"{
+                // checked into the model repository
+                ""profiles"": {
+                    ""base"": { ""extends"": ""strict"", ""rules"": { ""unused-set"": ""off"" } },
+                    ""company"": { ""extends"": ""base"", ""rules"": { ""free-variable"": ""error"", ""missing-description"": ""info"" } }
+                }
+            }");
+
+            var profile = configuration.Resolve("company");
+
+            Assert.Equal(LintSeverity.Off, profile.SeverityOf("unused-set"));
+            Assert.Equal(LintSeverity.Error, profile.SeverityOf("free-variable"));
+            Assert.Equal(LintSeverity.Info, profile.SeverityOf("missing-description"));
+            Assert.Equal(LintSeverity.Error, profile.SeverityOf("unused-variable"));
+
+            var report = new ModelLinter(ParseAndExpand(Model)) { ModelTexts = new[] { Model } }.Lint(profile);
+            Assert.Equal("company", report.Profile);
+            Assert.Empty(Subjects(report, "unused-set"));
+            Assert.Equal(LintSeverity.Error, report.Findings.Single(f => f.RuleId == "free-variable").Severity);
+        }
+
+        [Fact]
+        public void Parse_UnknownRule_ShouldThrow()
+        {
+            var ex = Assert.Throws<InvalidOperationException>(() =>
+                LintConfiguration.Parse(@"{ ""profiles"": { ""company"": { ""rules"": { ""no-such-rule"": ""error"" } } } }"));
+            Assert.Contains("no-such-rule", ex.Message);
+        }
+
+        [Fact]
+        public void Resolve_UnknownOrCyclicProfile_ShouldThrow()
+        {
+            var configuration = LintConfiguration.Parse(@"{ ""profiles"": {
+                ""a"": { ""extends"": ""b"" },
+                ""b"": { ""extends"": ""a"" }
+            } }");
+
+            Assert.Throws<InvalidOperationException>(() => configuration.Resolve("a"));
+            var ex = Assert.Throws<InvalidOperationException>(() => configuration.Resolve("company"));
+            Assert.Contains("default, strict, a, b", ex.Message);
+        }
+
+        [Fact]
+        public void Find_ShouldUseNearestConfigurationFile()
+        {
+            string root = Path.Combine(Path.GetTempPath(), "modellint-" + Guid.NewGuid().ToString("N"));
+            string nested = Path.Combine(root, "models", "transport");
+            Directory.CreateDirectory(nested);
+            try
+            {
+                File.WriteAllText(Path.Combine(root, LintConfiguration.FileName), @"{ ""profiles"": { ""company"": { } } }");
+
+                var configuration = LintConfiguration.Find(nested);
+
+                Assert.Equal(Path.Combine(root, LintConfiguration.FileName), configuration.Path);
+                Assert.Contains("company", configuration.ProfileNames);
+            }
+            finally
+            {
+                Directory.Delete(root, recursive: true);
+            }
+        }
+    }
+}
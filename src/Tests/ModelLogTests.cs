@@ -0,0 +1,122 @@
+using Core;
+using Core.Server;
+using Core.Services;
+using Core.Solving;
+using Microsoft.Extensions.Logging;
+
+namespace Tests
+{
+    public class ModelLogTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= 10;
+";
+
+        private class LogEntry
+        {
+            public string EventName { get; init; } = "";
+            public LogLevel Level { get; init; }
+            public Dictionary<string, object?> Fields { get; init; } = new Dictionary<string, object?>();
+        }
+
+        private class CapturingLogger : ILogger
+        {
+            public List<LogEntry> Entries { get; } = new List<LogEntry>();
+
+            public IDisposable? BeginScope<TState>(TState state) where TState : notnull => null;
+
+            public bool IsEnabled(LogLevel logLevel) => true;
+
+            public void Log<TState>(LogLevel logLevel, EventId eventId, TState state, Exception? exception, Func<TState, Exception?, string> formatter)
+            {
+                var fields = state is IEnumerable<KeyValuePair<string, object?>> pairs
+                    ? pairs.ToDictionary(p => p.Key, p => p.Value)
+                    : new Dictionary<string, object?>();
+                lock (Entries)
+                {
+                    Entries.Add(new LogEntry { EventName = eventId.Name ?? "", Level = logLevel, Fields = fields });
+                }
+            }
+
+            public LogEntry Single(string eventName) => Entries.Single(e => e.EventName == eventName);
+        }
+
+        private class FixedDriver : ISolverDriver
+        {
+            public string Name => "Fixed";
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 30 };
+        }
+
+        private class CancellingDriver : ISolverDriver
+        {
+            public string Name => "Cancelling";
+
+            public SolveResult Solve(ModelManager manager) => throw new InvalidOperationException("Not used");
+
+            public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken) => throw new OperationCanceledException(cancellationToken);
+        }
+
+        [Fact]
+        public async Task ModelHost_ShouldLogLifecycleEventsWithStableFields()
+        {
+            var logger = new CapturingLogger();
+            var host = new ModelHost { Logger = logger };
+
+            var model = host.Create("network", Model);
+            host.Validate(model.Id);
+            await host.SolveAsync(model.Id, new FixedDriver());
+            host.Delete(model.Id);
+
+            Assert.Equal(new[] { "model_created", "model_parsed", "solve_started", "solve_completed", "model_deleted" },
+                logger.Entries.Select(e => e.EventName));
+
+            var created = logger.Single("model_created");
+            Assert.Equal(model.Id, created.Fields["model_id"]);
+            Assert.Equal("network", created.Fields["model_name"]);
+            Assert.Equal(4, created.Fields["entity_count"]);
+
+            var parsed = logger.Single("model_parsed");
+            Assert.Equal(0, parsed.Fields["error_count"]);
+            Assert.IsType<double>(parsed.Fields["duration_ms"]);
+
+            var completed = logger.Single("solve_completed");
+            Assert.Equal("Fixed", completed.Fields["solver"]);
+            Assert.Equal("Optimal", completed.Fields["status"]);
+            Assert.Equal(LogLevel.Information, completed.Level);
+        }
+
+        [Fact]
+        public async Task ModelHost_ShouldLogCancelledSolve()
+        {
+            var logger = new CapturingLogger();
+            var host = new ModelHost { Logger = logger };
+            var model = host.Create("network", Model);
+
+            await Assert.ThrowsAnyAsync<OperationCanceledException>(() => host.SolveAsync(model.Id, new CancellingDriver()));
+
+            var cancelled = logger.Single("solve_cancelled");
+            Assert.Equal(model.Id, cancelled.Fields["model_id"]);
+            Assert.Equal("Cancelling", cancelled.Fields["solver"]);
+            Assert.DoesNotContain(logger.Entries, e => e.EventName == "solve_completed");
+        }
+
+        [Fact]
+        public void RangeParser_ShouldLogInvertedRangeToManagerLogger()
+        {
+            var logger = new CapturingLogger();
+            var manager = new ModelManager { Logger = logger };
+
+            var result = new EquationParser(manager).Parse("int first = 5;\nrange Empty = first..2;");
+
+            Assert.False(result.HasErrors, string.Join("; ", result.GetErrorMessages()));
+            var warning = logger.Single("range_inverted");
+            Assert.Equal(LogLevel.Warning, warning.Level);
+            Assert.Equal("Empty", warning.Fields["range"]);
+            Assert.Equal(5, warning.Fields["start"]);
+            Assert.Equal(2, warning.Fields["end"]);
+        }
+    }
+}
@@ -0,0 +1,54 @@
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelMergeTests
+    {
+        private const string Base =
+            "float capacity = 25;\n" +
+            "float price = 3;\n" +
+            "subject to {\n" +
+            "    total: capacity <= 60;\n" +
+            "}\n";
+
+        [Fact]
+        public void Merge_ChangesOnDifferentStatements_ShouldKeepBoth()
+        {
+            string mine = Base
+                .Replace("float capacity = 25;", "float capacity = 30;")
+                .Replace("    total: capacity <= 60;\n", "    total: capacity <= 60;\n    floor: capacity >= 5;\n");
+            string theirs = Base.Replace("float price = 3;\n", "float price = 4;\nfloat cost = 2;\n");
+
+            var result = ModelMerge.Merge(Base, mine, theirs);
+
+            Assert.False(result.HasConflicts);
+            Assert.Equal(new[] { "parameter:capacity", "constraint:floor" }, result.Changed);
+            Assert.Equal(
+                "float capacity = 30;\n" +
+                "float price = 4;\n" +
+                "float cost = 2;\n" +
+                "subject to {\n" +
+                "    total: capacity <= 60;\n" +
+                "    floor: capacity >= 5;\n" +
+                "}\n", result.ModelText);
+        }
+
+        [Fact]
+        public void Merge_BothChangedSameStatement_ShouldReportConflict()
+        {
+            string mine = Base.Replace("float capacity = 25;", "float capacity = 30;");
+            string theirs = Base.Replace("float capacity = 25;", "float capacity = 40;");
+
+            var result = ModelMerge.Merge(Base, mine, theirs);
+            var preferMine = ModelMerge.Merge(Base, mine, theirs, preferMine: true);
+
+            Assert.Equal(new[] { "parameter:capacity" }, result.Conflicts);
+            Assert.Equal(theirs, result.ModelText);
+            Assert.Equal(new[] { "parameter:capacity" }, preferMine.Conflicts);
+            Assert.Equal(mine, preferMine.ModelText);
+
+            // The same edit on both sides is not a conflict
+            Assert.False(ModelMerge.Merge(Base, mine, mine).HasConflicts);
+        }
+    }
+}
@@ -0,0 +1,87 @@
+using Core.Parsing;
+using Core.Storage;
+
+namespace Tests
+{
+    public class ModelMigrationsTests : IDisposable
+    {
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "migrations-" + Guid.NewGuid().ToString("N"));
+
+        private const string Model =
+            "float cap = 100;\n" +
+            "// @tags hydro\n" +
+            "dvar float+ release in 0..cap;\n" +
+            "minimize release;\n";
+
+        public ModelMigrationsTests()
+        {
+            Directory.CreateDirectory(directory);
+        }
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private static MigrationRunner CreateRunner()
+        {
+            return new MigrationRunner()
+                .Register("0001-tag-taxonomy", "Move tags under energy/", text => text.Replace("// @tags hydro", "// @tags energy/hydro"))
+                .Register(new ModelMigration("0002-capacity", ModelPatch.Parse("# Rename capacity\nadd float capacity = 100;\nset release ub = capacity\nremove cap\n")));
+        }
+
+        [Fact]
+        public void Migrate_ShouldApplyPendingMigrationsOnceAndRecordThem()
+        {
+            var runner = CreateRunner();
+            var result = runner.Migrate(Model);
+
+            Assert.Null(result.Error);
+            Assert.Equal(new[] { "0001-tag-taxonomy", "0002-capacity" }, result.Applied);
+            Assert.StartsWith("// @migrations 0001-tag-taxonomy, 0002-capacity\n", result.Text);
+            Assert.Contains("// @tags energy/hydro\ndvar float+ release in 0..capacity;", result.Text);
+            Assert.Equal(new[] { "0001-tag-taxonomy", "0002-capacity" }, MigrationRunner.AppliedMigrations(result.Text));
+
+            var again = runner.Migrate(result.Text);
+            Assert.Empty(again.Applied);
+            Assert.Equal(result.Text, again.Text);
+
+            runner.Register("0003-reserve", "", text => ModelPatch.Parse("add float reserve = 5;").Apply(text, out _));
+            var third = runner.Migrate(result.Text);
+            Assert.Equal(new[] { "0003-reserve" }, third.Applied);
+            Assert.StartsWith("// @migrations 0001-tag-taxonomy, 0002-capacity, 0003-reserve\n", third.Text);
+            Assert.Single(third.Text.Split('\n'), l => l.Contains("@migrations"));
+        }
+
+        [Fact]
+        public void Migrate_ShouldLeaveTheDocumentUnchangedWhenAMigrationFails()
+        {
+            string path = Path.Combine(directory, "plant.mod");
+            File.WriteAllText(path, "dvar float+ release in 0..5;\nminimize release;\n");
+
+            var results = CreateRunner().MigrateFiles(new[] { path });
+
+            Assert.Equal("migration 0002-capacity: Patch 'Rename capacity' line 4: 'cap' is not declared", results[0].Error);
+            Assert.Equal(new[] { "0001-tag-taxonomy" }, results[0].Applied);
+            Assert.Equal("dvar float+ release in 0..5;\nminimize release;\n", File.ReadAllText(path));
+        }
+
+        [Fact]
+        public async Task MigrateStorage_ShouldSaveMigratedModelsAsNewVersions()
+        {
+            File.WriteAllText(Path.Combine(directory, "0001-reserve.patch"), "# Add reserve\nadd float reserve = 5;\n");
+            var runner = new MigrationRunner().RegisterDirectory(directory);
+            var storage = new FileSystemModelStorage(Path.Combine(directory, "store"));
+            await storage.SaveAsync(new StoredModel { Id = "plant", Name = "Plant", Version = 1, ModelText = Model });
+
+            var results = await runner.MigrateStorageAsync(storage);
+            var latest = await storage.OpenAsync("plant");
+
+            Assert.Equal("plant: applied 0001-reserve", results.Single().ToString());
+            Assert.Equal(2, latest!.Version);
+            Assert.Contains("float reserve = 5;", latest.ModelText);
+            Assert.Equal("plant: up to date", (await runner.MigrateStorageAsync(storage)).Single().ToString());
+        }
+    }
+}
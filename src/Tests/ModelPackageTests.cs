@@ -0,0 +1,236 @@
+using System.Text;
+using Core.Storage;
+
+namespace Tests
+{
+    public class ModelPackageTests : IDisposable
+    {
+        private const string ModelText =
+            "// transport\n" +
+            "int n = 20000;\n" +
+            "range I = 1..n;\n" +
+            "float cost[I] = ...;\n" +
+            "dvar float+ x[I];\n" +
+            "minimize sum(i in I) cost[i] * x[i];\n";
+
+        private static readonly ModelPackageOptions smallChunks = new ModelPackageOptions
+        {
+            InlineLimit = 256,
+            MinChunkSize = 1024,
+            AverageChunkSize = 4096,
+            MaxChunkSize = 16384
+        };
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "modelpackage-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private static string Data(int changedIndex = -1)
+        {
+            var random = new Random(7);
+            var sb = new StringBuilder("capacity = 40;\ncost = [");
+            for (int i = 0; i < 20000; i++)
+            {
+                int value = random.Next(1000, 9999);
+                sb.Append(i == changedIndex ? 1 : value).Append(i < 19999 ? ", " : "];\n");
+            }
+            return sb.ToString();
+        }
+
+        [Fact]
+        public void Save_ShouldRoundTripTextExactly()
+        {
+            string data = Data();
+            var result = ModelPackage.Save(directory, "transport", ModelText, data, smallChunks);
+
+            var (name, modelText, dataText) = ModelPackage.Load(directory);
+            Assert.Equal("transport", name);
+            Assert.Equal(ModelText, modelText);
+            Assert.Equal(data, dataText);
+
+            var cost = result.Manifest.Sections.Single(s => s.Key == "data:cost");
+            Assert.Null(cost.Text);
+            Assert.True(cost.Chunks!.Count > 10);
+            Assert.Equal("float cost[I] = ...;", result.Manifest.Sections.Single(s => s.Key == "parameter:cost").Text!.Trim());
+        }
+
+        [Fact]
+        public void Save_AfterSmallEdit_ShouldOnlyWriteChangedChunks()
+        {
+            var first = ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            var unchanged = ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            var edited = ModelPackage.Save(directory, "transport", ModelText.Replace("n = 20000", "n = 19999"), Data(changedIndex: 10000), smallChunks);
+
+            Assert.Equal(0, unchanged.ChunksWritten);
+            Assert.Equal(first.ChunksWritten, unchanged.ChunksReused);
+
+            // One value changed in the middle of a large array: only the chunk(s) around it are rewritten
+            Assert.InRange(edited.ChunksWritten, 1, 2);
+            Assert.True(edited.ChunksReused >= first.ChunksWritten - 2);
+            Assert.True(edited.BytesWritten < first.BytesWritten / 5);
+
+            Assert.Equal(Data(changedIndex: 10000), ModelPackage.Load(directory).DataText);
+            Assert.Equal(edited.ChunksWritten, ModelPackage.CollectGarbage(directory));
+            Assert.Equal(Data(changedIndex: 10000), ModelPackage.Load(directory).DataText);
+        }
+
+        [Fact]
+        public void Load_CorruptChunk_ShouldThrow()
+        {
+            var result = ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            string chunk = result.Manifest.Sections.Single(s => s.Key == "data:cost").Chunks![3];
+            File.WriteAllText(Path.Combine(directory, "chunks", chunk.Substring(0, 2), chunk), "cost = [];");
+
+            var ex = Assert.Throws<InvalidOperationException>(() => ModelPackage.Load(directory));
+            Assert.Contains("corrupt", ex.Message);
+            Assert.Throws<InvalidOperationException>(() => ModelPackage.Load(Path.Combine(directory, "missing")));
+        }
+
+        [Fact]
+        public void Open_ShouldLoadSectionsOnlyWhenAccessed()
+        {
+            var first = ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            var cost = first.Manifest.Sections.Single(s => s.Key == "data:cost");
+
+            // With a chunk of the large array missing, anything that reads it would fail
+            string chunk = cost.Chunks![0];
+            string chunkPath = Path.Combine(directory, "chunks", chunk.Substring(0, 2), chunk);
+            byte[] saved = File.ReadAllBytes(chunkPath);
+            File.Delete(chunkPath);
+
+            var document = ModelPackage.Open(directory);
+            Assert.Equal("transport", document.Name);
+            Assert.Equal(new[] { "parameter:n", "set:I", "parameter:cost", "variable:x", "objective" }, document.GetKeys());
+            Assert.Equal(new[] { "data:capacity", "data:cost" }, document.GetKeys("data"));
+            Assert.Equal(cost.Length, document.Sections.Single(s => s.Key == "data:cost").Length);
+
+            Assert.True(document.Upsert("dvar float+ x[I] in 0..10"));
+            Assert.True(document.UpsertData("capacity = 45;"));
+            Assert.False(document.Upsert("float budget = 100;"));
+            Assert.True(document.HasChanges);
+
+            var result = document.Save(smallChunks);
+            Assert.Equal(0, result.ChunksWritten);
+            Assert.Equal(cost.Chunks.Count, result.ChunksReused);
+            Assert.Equal(0, document.LoadedSections);
+            Assert.False(document.IsLoaded("data:cost"));
+            Assert.False(document.HasChanges);
+
+            File.WriteAllBytes(chunkPath, saved);
+            var (_, modelText, dataText) = ModelPackage.Load(directory);
+            Assert.Contains("\ndvar float+ x[I] in 0..10;\nminimize", modelText);
+            Assert.Contains("float budget = 100;", modelText);
+            Assert.Equal(Data().Replace("capacity = 40;", "capacity = 45;"), dataText);
+
+            var reopened = ModelPackage.Open(directory);
+            Assert.StartsWith("\ncost = [", reopened.GetSection("data:cost"));
+            Assert.Equal(1, reopened.LoadedSections);
+            Assert.Null(reopened.GetSection("data:missing"));
+        }
+    
+
+        private void WriteManifest(string json)
+        {
+            Directory.CreateDirectory(directory);
+            File.WriteAllText(Path.Combine(directory, ModelPackage.ManifestFileName), json);
+        }
+
+        [Fact]
+        public void Open_Version1Package_ShouldUpgradeInMemory()
+        {
+            // As written by an editor that only knows format version 1
+            WriteManifest(@"{
+  ""format"": ""modeleditor-package"",
+  ""formatVersion"": 1,
+  ""name"": ""legacy"",
+  ""savedAt"": ""2024-01-01T00:00:00Z"",
+  ""sections"": [ { ""stream"": ""model"", ""key"": ""variable:x"", ""length"": 15, ""hash"": ""-"", ""text"": ""dvar float+ x;\n"" } ]
+}");
+
+            var document = ModelPackage.Open(directory);
+            Assert.Equal(1, document.StoredVersion);
+            Assert.Empty(document.Properties);
+            Assert.Equal(FormatVersion.Current.Number, ModelPackage.ReadManifest(directory)!.FormatVersion);
+            Assert.Equal("dvar float+ x;\n", document.GetModelText());
+
+            document.Properties["author"] = "planning";
+            Assert.True(document.HasChanges);
+            var result = document.Save();
+
+            Assert.Empty(result.Warnings);
+            Assert.Equal(FormatVersion.Current.Number, document.StoredVersion);
+            var saved = ModelPackage.ReadManifest(directory)!;
+            Assert.Equal("planning", saved.Properties!["author"]);
+            Assert.Equal(3, saved.MinimumReaderVersion);
+        }
+
+        [Fact]
+        public void Save_TargetingOlderVersion_ShouldWarnAboutDroppedContent()
+        {
+            ModelPackage.Save(directory, "transport", ModelText, "", smallChunks);
+            var document = ModelPackage.Open(directory);
+            document.Properties["author"] = "planning";
+            document.Properties["description"] = "transport study";
+
+            var v2 = document.Save(new ModelPackageOptions { TargetVersion = 2 });
+            Assert.Empty(v2.Warnings);
+            string json = File.ReadAllText(Path.Combine(directory, ModelPackage.ManifestFileName));
+            Assert.Contains("\"formatVersion\": 2", json);
+            Assert.Contains("\"author\": \"planning\"", json);
+            Assert.DoesNotContain("minimumReaderVersion", json);
+
+            var v1 = document.Save(new ModelPackageOptions { TargetVersion = 1 });
+            Assert.Equal(new[] { "Format version 1 cannot store document properties; dropped author, description" }, v1.Warnings);
+            json = File.ReadAllText(Path.Combine(directory, ModelPackage.ManifestFileName));
+            Assert.Contains("\"formatVersion\": 1", json);
+            Assert.DoesNotContain("properties", json);
+            Assert.Equal(ModelText, ModelPackage.Load(directory).ModelText);
+            Assert.Equal(1, document.StoredVersion);
+
+            var unknown = Assert.Throws<InvalidOperationException>(() => document.Save(new ModelPackageOptions { TargetVersion = 9 }));
+            Assert.Equal("Unknown package format version 9; this editor supports versions 1 to 3", unknown.Message);
+        }
+
+        [Fact]
+        public void Open_NewerVersion_ShouldRespectMinimumReaderVersion()
+        {
+            const string Newer = @"{
+  ""format"": ""modeleditor-package"",
+  ""formatVersion"": 4,
+  ""minimumReaderVersion"": READER,
+  ""name"": ""future"",
+  ""savedAt"": ""2027-01-01T00:00:00Z"",
+  ""layers"": [ ""base"" ],
+  ""sections"": [ { ""stream"": ""model"", ""key"": ""variable:x"", ""length"": 15, ""hash"": ""-"", ""text"": ""dvar float+ x;\n"" } ]
+}";
+            WriteManifest(Newer.Replace("READER", "3"));
+            var document = ModelPackage.Open(directory);
+            Assert.Equal(4, document.StoredVersion);
+            Assert.Equal("dvar float+ x;\n", document.GetModelText());
+
+            var result = document.Save();
+            Assert.Equal(new[] { "Package was saved in format version 4 by a newer editor; content this editor does not know is dropped" }, result.Warnings);
+            Assert.Equal(3, ModelPackage.ReadManifest(directory)!.StoredVersion);
+
+            WriteManifest(Newer.Replace("READER", "4"));
+            var ex = Assert.Throws<InvalidOperationException>(() => ModelPackage.Open(directory));
+            Assert.EndsWith("was saved in format version 4 by a newer editor; it needs a reader of version 4, this editor reads up to 3", ex.Message);
+        }
+
+        [Fact]
+        public void FormatVersion_ShouldReportCapabilities()
+        {
+            Assert.Equal(new[] { 1, 2, 3 }, FormatVersion.All.Select(v => v.Number));
+            Assert.Same(FormatVersion.All[^1], FormatVersion.Current);
+            Assert.Equal(PackageFeatures.Properties, FormatVersion.Get(2).Added);
+            Assert.False(FormatVersion.Get(1).Supports(PackageFeatures.Properties));
+            Assert.True(FormatVersion.Current.Supports(PackageFeatures.Sections | PackageFeatures.ReaderVersion));
+            Assert.Equal(2, FormatVersion.Requiring(PackageFeatures.Properties).Number);
+            Assert.Equal("2 (document properties: Sections, Properties)", FormatVersion.Get(2).ToString());
+        }
+    }
+}
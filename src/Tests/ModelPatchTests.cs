@@ -0,0 +1,76 @@
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelPatchTests
+    {
+        private const string Model =
+            "range T = 1..3;\n" +
+            "float cap = 100;\n" +
+            "float legacy = 1;\n" +
+            "dvar float+ release[T] in 0..50;\n" +
+            "dvar float+ storage[T];\n" +
+            "forall(t in T) balance: storage[t] + 2*release[t] == 4;\n" +
+            "minimize sum(t in T) release[t];\n";
+
+        private const string Patch =
+            "# Raise capacity\n" +
+            "add float reserve = 10;\n" +
+            "set cap value = 120\n" +
+            "set variable:release ub = cap\n" +
+            "set storage lb = 5\n" +
+            "coef balance release = -1\n" +
+            "remove parameter:legacy\n";
+
+        [Fact]
+        public void Apply_ShouldEditDeclarationsAndInvertBackToTheOriginal()
+        {
+            var patch = ModelPatch.Parse(Patch);
+            string patched = patch.Apply(Model, out var inverse);
+            var lines = patched.Split('\n');
+
+            Assert.Equal("Raise capacity", patch.Title);
+            Assert.Equal(new[]
+            {
+                "range T = 1..3;",
+                "float cap = 120;",
+                "float reserve = 10;",
+                "dvar float+ release[T] in 0..cap;",
+                "dvar float+ storage[T] in 5..infinity;",
+                "forall(t in T) balance: storage[t] - release[t] == 4;"
+            }, lines.Take(6));
+
+            Assert.Equal("remove parameter:reserve", inverse.Operations[^1].ToString());
+            string restored = ModelPatch.Parse(inverse.ToString()).Apply(patched, out _);
+            var expected = ModelSource.Parse(Model).Statements.Select(s => s.Code).OrderBy(c => c);
+            Assert.Equal(expected, ModelSource.Parse(restored).Statements.Select(s => s.Code).OrderBy(c => c));
+        }
+
+        [Fact]
+        public void Json_ShouldRoundTripTheOperations()
+        {
+            var patch = ModelPatch.Parse(Patch);
+            var read = ModelPatch.FromJson(patch.ToJson());
+
+            Assert.Contains("\"op\": \"coefficient\"", patch.ToJson());
+            Assert.Equal(patch.ToString(), read.ToString());
+            Assert.Equal(ModelPatch.Parse(Patch).Apply(Model, out _), read.Apply(Model, out _));
+        }
+
+        [Fact]
+        public void Apply_ShouldRejectTheWholePatchWhenAnOperationFails()
+        {
+            var unknown = ModelPatch.Parse("# Tidy\nremove cap\nset missing value = 3\n");
+            var ex = Assert.Throws<InvalidOperationException>(() => unknown.Apply(Model, out _));
+            Assert.Equal("Patch 'Tidy' line 3: 'missing' is not declared", ex.Message);
+
+            var existing = ModelPatch.Parse("add float cap = 5;");
+            Assert.Contains("use replace", Assert.Throws<InvalidOperationException>(() => existing.Apply(Model, out _)).Message);
+
+            var broken = ModelPatch.Parse("# Break\nremove T\n");
+            Assert.StartsWith("Patch 'Break' would break the model:", Assert.Throws<InvalidOperationException>(() => broken.Apply(Model, out _)).Message);
+
+            Assert.Throws<InvalidOperationException>(() => ModelPatch.Parse("rename cap capacity"));
+        }
+    }
+}
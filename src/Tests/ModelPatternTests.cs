@@ -0,0 +1,69 @@
+using Core;
+using Core.Analysis;
+using Core.Generation;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelPatternTests : TestBase
+    {
+        private const string Model =
+            "range Units = 1..2;\n" +
+            "range T = 1..4;\n" +
+            "int minUp[Units] = [2, 3];\n" +
+            "int minDown[Units] = [1, 2];\n";
+
+        [Fact]
+        public void Apply_MinUpDown_ShouldGenerateConstraintsOverTheDataWindows()
+        {
+            var applied = ModelPatterns.Apply(Model, "min-up-down");
+
+            Assert.Empty(applied.Warnings);
+            Assert.Equal(new[] { "parameter:minUp", "parameter:minDown" }, applied.Reused);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(applied.ModelText);
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            AssertNoErrors(result);
+
+            var equations = manager.Equations.Where(e => e.Label != null).ToDictionary(e => e.Label!);
+            Assert.Equal(new[] { "on1_1", "start1_1" }, equations["on_minUp_1_1"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "on2_3", "start2_1", "start2_2", "start2_3" }, equations["on_minUp_2_3"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "on1_2", "stop1_2" }, equations["on_minDown_1_2"].Coefficients.Keys.OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Apply_ShouldDocumentAndAnnotateTheGeneratedEntities()
+        {
+            var applied = ModelPatterns.Apply("range Plants = 1..3;\nrange T = 1..24;\n", "ramping",
+                new Dictionary<string, string> { ["units"] = "Plants", ["output"] = "power" });
+
+            var documentation = Docstrings.Extract(applied.ModelText);
+            Assert.Equal("Output of the unit in the period", documentation["variable:power"]);
+            Assert.Contains("rampUp", documentation["constraint:power_rampUp"]);
+            Assert.Contains("float rampUp[Plants] = ...;", applied.ModelText);
+
+            var provenance = ModelProvenance.Parse(applied.ModelText);
+            Assert.Equal(6, provenance.Select("pattern:ramping").Count);
+
+            // Applying it again rewrites its own entities and adds nothing
+            var again = ModelPatterns.Apply(applied.ModelText, "ramping",
+                new Dictionary<string, string> { ["units"] = "Plants", ["output"] = "power" });
+            Assert.Empty(again.Warnings);
+            Assert.Equal(ModelSource.Parse(applied.ModelText).Statements.Count, ModelSource.Parse(again.ModelText).Statements.Count);
+        }
+
+        [Fact]
+        public void Apply_ShouldRejectUnknownArgumentsAndWarnAboutMissingSets()
+        {
+            Assert.Throws<ArgumentException>(() => ModelPatterns.Apply(Model, "unit-commitment"));
+            Assert.Throws<ArgumentException>(() => ModelPatterns.Apply(Model, "ramping", new Dictionary<string, string> { ["plants"] = "Units" }));
+            Assert.Throws<ArgumentException>(() => ModelPatterns.Apply(Model, "ramping", new Dictionary<string, string> { ["output"] = "gen[u]" }));
+
+            var applied = ModelPatterns.Apply(Model, "flow-conservation");
+            Assert.Contains(applied.Warnings, w => w.Contains("'Nodes'"));
+        }
+    }
+}
@@ -0,0 +1,106 @@
+using Core.Analysis;
+using Core.Import;
+using Core.Parsing;
+using Core.Server;
+
+namespace Tests
+{
+    public class ModelProvenanceTests
+    {
+        private static readonly DateTime GeneratedAt = new DateTime(2026, 10, 16, 9, 30, 0, DateTimeKind.Utc);
+
+        private const string Model =
+            "// Plant model\n" +
+            "float capacity = 10;\n" +
+            "// @generated import:plant.lp from plant.lp:3 at 2026-10-01T08:00:00Z\n" +
+            "dvar float+ x1;\n" +
+            "// @tags imported\n" +
+            "// @generated import:plant.lp from plant.lp:4\n" +
+            "dvar float+ x2;\n" +
+            "// @generated macro:ramp\n" +
+            "ramp: x1 <= capacity;\n";
+
+        [Fact]
+        public void Parse_ShouldReadGeneratorSourceAndTimestamp()
+        {
+            var provenance = ModelProvenance.Parse(Model);
+
+            Assert.Equal(new[] { "variable:x1", "variable:x2", "constraint:ramp" }, provenance.Entities.Select(e => e.Key));
+            Assert.Equal(new[] { "import:plant.lp", "macro:ramp" }, provenance.Generators);
+
+            var x1 = provenance.Find("variable:x1")!;
+            Assert.Equal("plant.lp:3", x1.Source);
+            Assert.Equal(new DateTime(2026, 10, 1, 8, 0, 0, DateTimeKind.Utc), x1.GeneratedAt);
+            Assert.Equal(4, x1.LineNumber);
+            Assert.Null(provenance.Find("variable:x2")!.GeneratedAt);
+            Assert.Null(provenance.Find("parameter:capacity"));
+            Assert.Equal("ramp: x1 <= capacity;", ModelSource.Parse(Model).Find("constraint:ramp")!.Code);
+        }
+
+        [Fact]
+        public void Regenerate_ShouldReplaceExactlyThePreviousOutput()
+        {
+            var source = ModelSource.Parse(Model);
+
+            var changes = ModelProvenance.Regenerate(source, "import:plant.lp",
+                new[] { "dvar float+ x1 in 0..5;", "dvar float+ x3;" }, "plant.lp", GeneratedAt);
+            changes.Apply(source);
+
+            var provenance = ModelProvenance.Parse(source);
+            Assert.Equal(new[] { "variable:x1", "variable:x3" }, provenance.Select("import:plant.lp").Select(e => e.Key));
+            Assert.All(provenance.Select("import:plant.lp"), e => Assert.Equal(GeneratedAt, e.GeneratedAt));
+            Assert.Null(source.Find("variable:x2"));
+            Assert.Equal("dvar float+ x1 in 0..5;", source.Find("variable:x1")!.Code);
+            Assert.NotNull(source.Find("parameter:capacity"));
+            Assert.Equal("macro:ramp", provenance.Find("constraint:ramp")!.Generator);
+
+            string text = source.ToString();
+            Assert.StartsWith("// Plant model\nfloat capacity = 10;\n// @generated import:plant.lp from plant.lp at 2026-10-16T09:30:00Z\ndvar float+ x1 in 0..5;\n", text);
+            Assert.Single(ModelSource.Parse(text).Statements, s => s.Text.Contains("@generated") && s.Key == "variable:x1");
+        }
+
+        [Fact]
+        public void Regenerate_ShouldKeepOtherAnnotationsOfReplacedDeclarations()
+        {
+            var source = ModelSource.Parse(Model);
+
+            ModelProvenance.Regenerate(source, "import:plant.lp", new[] { "dvar float+ x2;" }, generatedAt: GeneratedAt).Apply(source);
+
+            var x2 = source.Find("variable:x2")!;
+            Assert.Equal("\n// @tags imported\n// @generated import:plant.lp at 2026-10-16T09:30:00Z\n", x2.Text.Substring(0, x2.Text.Length - x2.Code.Length));
+        }
+
+        [Fact]
+        public void Regenerate_ShouldNotOverwriteHandWrittenOrForeignDeclarations()
+        {
+            var source = ModelSource.Parse(Model);
+
+            var handWritten = Assert.Throws<InvalidOperationException>(() =>
+                ModelProvenance.Regenerate(source, "import:plant.lp", new[] { "float capacity = 20;" }));
+            Assert.Contains("hand-written", handWritten.Message);
+
+            var foreign = Assert.Throws<InvalidOperationException>(() =>
+                ModelProvenance.Regenerate(source, "import:plant.lp", new[] { "ramp: x1 <= 5;" }));
+            Assert.Contains("generated by 'macro:ramp'", foreign.Message);
+        }
+
+        [Fact]
+        public void ModelHost_Regenerate_ShouldStampHostClockAndBeQueryable()
+        {
+            var host = new ModelHost(() => GeneratedAt);
+            var model = host.Create("plant", "dvar float+ z;\nminimize z;\n");
+            var imported = new LpImporter().Import("maximize\n obj: x\nsubject to\n c1: x <= 4\nend\n");
+
+            var result = host.Regenerate(model.Id, "import:plant.lp", imported.ToStatements().Where(s => !s.StartsWith("maximize")), "plant.lp");
+
+            Assert.True(result.Success, string.Join("; ", result.Errors));
+            var provenance = host.GetProvenance(model.Id);
+            Assert.Equal(new[] { "variable:x", "constraint:c1" }, provenance.Entities.Select(e => e.Key));
+            Assert.All(provenance.Entities, e => Assert.Equal(GeneratedAt, e.GeneratedAt));
+
+            var again = host.Regenerate(model.Id, "import:plant.lp", new[] { "minimize z + 1;" });
+            Assert.False(again.Success);
+            Assert.Contains("hand-written", again.Errors[0]);
+        }
+    }
+}
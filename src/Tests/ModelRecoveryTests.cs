@@ -0,0 +1,128 @@
+using Core;
+using Core.Server;
+using Core.Solving;
+using Core.Storage;
+
+namespace Tests
+{
+    public class ModelRecoveryTests : IDisposable
+    {
+        private const string ModelText = "range Nodes = 1..3;\ndvar float+ flow[Nodes];\nmaximize sum(n in Nodes) flow[n];\n";
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "modelrecovery-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        /// <summary>
+        /// Reports an incumbent, then runs until released
+        /// </summary>
+        private class BlockingDriver : ISolverDriver
+        {
+            public ManualResetEventSlim Started { get; } = new ManualResetEventSlim();
+            public ManualResetEventSlim Release { get; } = new ManualResetEventSlim();
+
+            public string Name => "Blocking";
+
+            public SolveResult Solve(ModelManager manager) => throw new NotSupportedException();
+
+            public SolveResult Solve(ModelManager manager, SolveMonitor monitor, CancellationToken cancellationToken)
+            {
+                monitor.Report(new SolverEvent
+                {
+                    Kind = SolverEventKind.Incumbent,
+                    ObjectiveValue = 60,
+                    BestBound = 75,
+                    Values = new Dictionary<string, double> { ["flow[1]"] = 20 }
+                });
+                Started.Set();
+                Release.Wait(cancellationToken);
+                return monitor.Complete(new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 75 });
+            }
+        }
+
+        [Fact]
+        public async Task RecoverAsync_AfterCrash_ShouldOfferAndRestoreAutosavedChanges()
+        {
+            var host = new ModelHost { Recovery = new ModelRecovery(directory) };
+            var model = host.Create("network", ModelText);
+            host.SetData(model.Id, "capacity = 25;");
+
+            Assert.Equal(1, await host.CheckpointAsync());
+            Assert.Equal(0, await host.CheckpointAsync());
+            Assert.Empty(await host.RecoverAsync());
+
+            // A new process finds the autosave of a model it never saved
+            var restarted = new ModelHost { Recovery = new ModelRecovery(directory) };
+            var offer = Assert.Single(await restarted.RecoverAsync());
+            Assert.Equal(model.Id, offer.Id);
+            Assert.Equal(model.Version, offer.Version);
+            Assert.False(offer.Shutdown);
+
+            var restored = await restarted.RestoreAsync(model.Id);
+            Assert.Equal(ModelText, restored.ModelText);
+            Assert.Equal("capacity = 25;", restored.DataText);
+            Assert.True(restored.HasUnsavedChanges);
+            Assert.Empty(await restarted.RecoverAsync());
+
+            Assert.True(restarted.Delete(model.Id));
+            Assert.Empty(await new ModelRecovery(directory).ReadAllAsync());
+        }
+
+        [Fact]
+        public async Task DiscardAsync_WithoutHostedModel_ShouldRequireOwnerOrAdmin()
+        {
+            var owner = new UserPrincipal { Subject = "alice" };
+            var host = new ModelHost { Policy = new AccessPolicy(), Recovery = new ModelRecovery(directory) };
+            HostedModel model;
+            using (AccessContext.BeginScope(owner))
+                model = host.Create("network", ModelText);
+            Assert.Equal(1, await host.CheckpointAsync());
+
+            var restarted = new ModelHost { Policy = new AccessPolicy(), Recovery = new ModelRecovery(directory) };
+            using (AccessContext.BeginScope(new UserPrincipal { Subject = "bob" }))
+                await Assert.ThrowsAsync<AccessDeniedException>(() => restarted.DiscardAsync(model.Id));
+            await Assert.ThrowsAsync<AccessDeniedException>(() => restarted.DiscardAsync(model.Id));
+            Assert.Single(await new ModelRecovery(directory).ReadAllAsync());
+
+            using (AccessContext.BeginScope(owner))
+                Assert.True(await restarted.DiscardAsync(model.Id));
+            Assert.Empty(await new ModelRecovery(directory).ReadAllAsync());
+        }
+
+        [Fact]
+        public async Task ShutdownAsync_ShouldSaveEditsAndCheckpointRunningSolves()
+        {
+            var storage = new FileSystemModelStorage(Path.Combine(directory, "models"));
+            var recovery = new ModelRecovery(Path.Combine(directory, "recovery"));
+            var host = new ModelHost { Storage = storage, Recovery = recovery };
+            var model = host.Create("network", ModelText);
+            var driver = new BlockingDriver();
+
+            var solve = host.SolveAsync(model.Id, driver);
+            Assert.True(driver.Started.Wait(TimeSpan.FromSeconds(10)));
+            await host.ShutdownAsync(TimeSpan.FromMilliseconds(50));
+            await Assert.ThrowsAsync<InvalidOperationException>(() => host.SolveAsync(model.Id, driver));
+
+            Assert.False(model.HasUnsavedChanges);
+            var checkpoint = (await recovery.ReadAsync(model.Id))!;
+            Assert.True(checkpoint.Shutdown);
+            Assert.Equal(model.Version, checkpoint.SavedVersion);
+            var interrupted = Assert.Single(checkpoint.RunningSolves);
+            Assert.Equal("Blocking", interrupted.Solver);
+            Assert.Equal(60, interrupted.ObjectiveValue);
+            Assert.Equal(20, interrupted.Values["flow[1]"]);
+
+            driver.Release.Set();
+            Assert.Equal(SolveStatus.Optimal, (await solve).Status);
+
+            // The saved text is loaded again; the interrupted solve is still offered
+            var restarted = new ModelHost { Storage = storage, Recovery = recovery };
+            Assert.Equal(1, await restarted.LoadAsync());
+            Assert.Single(Assert.Single(await restarted.RecoverAsync()).RunningSolves);
+        }
+    }
+}
@@ -0,0 +1,111 @@
+using Core.Parsing;
+
+namespace Tests
+{
+    public class ModelSourceTests
+    {
+        private const string Model =
+            "// Network model\n" +
+            "range Nodes = 1..3;\n" +
+            "float capacity = 25;\n" +
+            "dvar float+ flow[Nodes];\n" +
+            "tuple Arc { int from; int to; }\n" +
+            "maximize sum(n in Nodes) flow[n];\n" +
+            "subject to {\n" +
+            "    forall(n in Nodes) cap: flow[n] <= capacity; // per node\n" +
+            "    total: sum(n in Nodes) flow[n] <= 60;\n" +
+            "}\n";
+
+        [Fact]
+        public void Parse_ShouldRoundTripTextExactly()
+        {
+            var source = ModelSource.Parse(Model);
+
+            Assert.Equal(Model, source.ToString());
+        }
+
+        [Fact]
+        public void Parse_ShouldKeyDeclarations()
+        {
+            var source = ModelSource.Parse(Model);
+            var keys = source.Statements.Select(s => s.Key).ToList();
+
+            Assert.Equal(new string?[]
+            {
+                "set:Nodes", "parameter:capacity", "variable:flow", null, "objective",
+                null, "constraint:cap", "constraint:total", null
+            }, keys);
+            Assert.Equal(2, source.Find("set:Nodes")!.LineNumber);
+            Assert.Equal(8, source.Find("constraint:cap")!.LineNumber);
+        }
+
+        [Fact]
+        public void Upsert_ExistingEntity_ShouldReplaceOnlyThatStatement()
+        {
+            var source = ModelSource.Parse(Model);
+
+            bool replaced = source.Upsert("float capacity = 30");
+
+            Assert.True(replaced);
+            Assert.Equal(Model.Replace("float capacity = 25;", "float capacity = 30;"), source.ToString());
+        }
+
+        [Fact]
+        public void Upsert_NewEntities_ShouldInsertNextToSameKindAndInsideSubjectTo()
+        {
+            var source = ModelSource.Parse(Model);
+
+            Assert.False(source.Upsert("float cost = 2;"));
+            Assert.False(source.Upsert("floor: sum(n in Nodes) flow[n] >= 5;"));
+
+            string text = source.ToString();
+            Assert.Contains("float capacity = 25;\nfloat cost = 2;", text.Replace("\r\n", "\n"));
+            Assert.True(text.IndexOf("floor:") < text.LastIndexOf('}'));
+            Assert.Equal("constraint:floor", source.Statements[^2].Key);
+        }
+
+        [Fact]
+        public void Remove_ShouldDropStatement()
+        {
+            var source = ModelSource.Parse(Model);
+
+            Assert.True(source.Remove("constraint:total"));
+            Assert.False(source.Remove("constraint:total"));
+            Assert.DoesNotContain("total:", source.ToString());
+        }
+
+        [Fact]
+        public void Remove_ShouldKeepBlockAnnotationsOfRemainingDeclarations()
+        {
+            var source = ModelSource.Parse("float a = 1;\n// @block hydro\n// first\nfloat b = 2;\nfloat c = 3;\n// @endblock\nfloat d = 4;\n");
+
+            Assert.True(source.Remove("parameter:b"));
+            Assert.Equal("float a = 1;\n// @block hydro\nfloat c = 3;\n// @endblock\nfloat d = 4;\n", source.ToString());
+
+            Assert.True(source.Remove("parameter:c"));
+            Assert.Equal("float a = 1;\nfloat d = 4;\n", source.ToString());
+        }
+
+        [Fact]
+        public void Annotate_ShouldAddOrReplaceAnnotationKeepingIndentation()
+        {
+            var source = ModelSource.Parse(Model);
+
+            Assert.True(source.Annotate("constraint:total", "// @tags limits"));
+            Assert.Contains("// per node\n    // @tags limits\n    total:", source.ToString().Replace("\r\n", "\n"));
+
+            Assert.True(source.Annotate("constraint:total", "// @tags limits/total"));
+            Assert.Contains("    // @tags limits/total\n    total:", source.ToString().Replace("\r\n", "\n"));
+            Assert.DoesNotContain("@tags limits\n", source.ToString().Replace("\r\n", "\n"));
+            Assert.False(source.Annotate("constraint:missing", "// @tags limits"));
+        }
+
+        [Fact]
+        public void Upsert_StatementWithoutName_ShouldThrow()
+        {
+            var source = ModelSource.Parse(Model);
+
+            Assert.Throws<InvalidOperationException>(() => source.Upsert("x <= 3;"));
+        }
+    }
+}
@@ -0,0 +1,69 @@
+using Core.Parsing;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ModelTablesTests : TestBase
+    {
+        private const string Model =
+            "/* @table maintenance(unit: string key, start: date key, end: date, hours: float, note: string?)\n" +
+            "U1, 2026-03-01, 2026-03-14, 40, overhaul\n" +
+            "U2, 2026-05-10, 2026-05-12, 8,\n" +
+            "U2, 2026-07-01, 2026-07-02, 4.5, \"inspection, boiler\"\n" +
+            "*/\n" +
+            "dvar float+ x;\n" +
+            "// @kpi outageHours = sum(r in maintenance) maintenance.hours[r]\n" +
+            "// @kpi firstOutageDays = maintenance.end[1] - maintenance.start[1]\n" +
+            "minimize x;\n";
+
+        [Fact]
+        public void Read_ShouldValidateRowsAgainstTheSchemaAndAnswerQueries()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            var table = manager.Tables.Find("maintenance")!;
+            Assert.Equal(3, table.Rows.Count);
+            Assert.Equal(new DateOnly(2026, 3, 1), table[0, "start"]);
+            Assert.Null(table[1, "note"]);
+            Assert.Equal("inspection, boiler", table[2, "note"]);
+
+            var u2 = table.Select("unit == U2 and hours < 10");
+            Assert.Equal(new[] { 8.0, 4.5 }, u2.Select(r => (double)r["hours"]!));
+            Assert.Single(table.Select("start >= 2026-05-01 and note != \"\""));
+            Assert.Equal(40.0, (double)table.Find("U1", "2026-03-01")![3]!);
+            Assert.Contains("\"inspection, boiler\"", table.ToString());
+        }
+
+        [Fact]
+        public void Read_ShouldReportInvalidValuesAndDuplicateKeysAtTheirLine()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "/* @table calendar(day: date key, open: bool)\n" +
+                "2026-01-01, false\n" +
+                "2026-01-02, maybe\n" +
+                "2026-01-01, true\n" +
+                "*/\n" +
+                "dvar float+ x;\n" +
+                "minimize x;\n");
+
+            Assert.Contains(result.Errors, e => e.LineNumber == 3 && e.Message.Contains("'maybe' is not a valid bool"));
+            Assert.Contains(result.Errors, e => e.LineNumber == 4 && e.Message.Contains("duplicate key, first used on line 2"));
+            Assert.Single(manager.Tables.Find("calendar")!.Rows);
+        }
+
+        [Fact]
+        public void Kpis_ShouldAggregateTableColumns()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+            var solution = new SolveResult { Status = SolveStatus.Optimal, VariableValues = new Dictionary<string, double> { ["x"] = 0 } };
+
+            manager.Report.Evaluate(manager, solution);
+
+            Assert.Equal(52.5, solution.Kpis["outageHours"]);
+            Assert.Equal(13.0, solution.Kpis["firstOutageDays"]);
+        }
+    }
+}
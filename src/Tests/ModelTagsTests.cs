@@ -0,0 +1,129 @@
+using Core;
+using Core.Analysis;
+using Core.Import;
+
+namespace Tests
+{
+    public class ModelTagsTests : TestBase
+    {
+        private const string Model = @"
+range Units = 1..3;
+float capacity = 25;
+float demand = 40;
+dvar float+ output[Units];
+dvar float+ reserve;
+minimize sum(u in Units) output[u] + reserve;
+// @block hydro: water
+// @tags hydro/ramping/up
+forall(u in Units) rampUp: output[u] <= capacity;
+// @block units
+// @tags reserve
+spinning: reserve >= 5;
+// @endblock
+// @endblock
+// @tags balance
+meet: sum(u in Units) output[u] + reserve == demand;
+";
+
+        [Fact]
+        public void Parse_ShouldInheritBlockTags()
+        {
+            var tags = ModelTags.Parse(Model);
+
+            Assert.Empty(tags.Warnings);
+
+            var rampUp = tags.Find("constraint:rampUp")!;
+            Assert.Equal("hydro", rampUp.Block);
+            Assert.Equal(new[] { "hydro/ramping/up" }, rampUp.OwnTags);
+            Assert.Equal(new[] { "hydro", "hydro/ramping/up", "water" }, rampUp.Tags);
+
+            var spinning = tags.Find("constraint:spinning")!;
+            Assert.Equal("hydro/units", spinning.Block);
+            Assert.Equal(new[] { "hydro", "hydro/units", "reserve", "water" }, spinning.Tags);
+
+            Assert.Equal(new[] { "balance" }, tags.Find("constraint:meet")!.Tags);
+            Assert.Empty(tags.Find("variable:output")!.Tags);
+        }
+
+        [Fact]
+        public void Select_ShouldMatchHierarchically()
+        {
+            var tags = ModelTags.Parse(Model);
+
+            Assert.Equal(new[] { "constraint:rampUp", "constraint:spinning" }, tags.Select("hydro").Select(e => e.Key));
+            Assert.Equal(new[] { "constraint:rampUp" }, tags.Select("Hydro/Ramping").Select(e => e.Key));
+            Assert.Empty(tags.Select("hydro/ramp"));
+            Assert.True(TagPath.Matches("hydro/ramping/up", " hydro//ramping "));
+        }
+
+        [Fact]
+        public void Parse_UnbalancedBlocks_ShouldWarn()
+        {
+            var tags = ModelTags.Parse("// @endblock\ndvar float x;\n// @block open\nc: x <= 1;");
+
+            Assert.Equal(2, tags.Warnings.Count);
+            Assert.Equal("open", tags.Find("constraint:c")!.Block);
+        }
+
+        [Fact]
+        public void GetStatistics_ShouldCountEntitiesAndExpandedRows()
+        {
+            var tags = ModelTags.Parse(Model);
+
+            var statistics = tags.GetStatistics(ParseAndExpand(Model)).ToDictionary(s => s.Tag);
+
+            Assert.Contains("hydro/ramping", statistics.Keys);
+            Assert.Equal(2, statistics["hydro"].Total);
+            Assert.Equal(2, statistics["hydro"].Direct);
+            Assert.Equal(4, statistics["hydro"].Rows);
+            Assert.Equal(0, statistics["hydro/ramping"].Direct);
+            Assert.Equal(3, statistics["hydro/ramping"].Rows);
+            Assert.Equal(1, statistics["balance"].ByKind[EntityKind.Constraint]);
+        }
+
+        [Fact]
+        public void ExtractSubset_ShouldKeepOnlyTaggedConstraints()
+        {
+            string subset = ModelTags.Parse(Model).ExtractSubset("hydro/ramping");
+
+            Assert.Contains("rampUp:", subset);
+            Assert.DoesNotContain("spinning:", subset);
+            Assert.DoesNotContain("meet:", subset);
+
+            var manager = ParseAndExpand(subset);
+            Assert.Equal(3, manager.Equations.Count);
+        }
+
+        [Fact]
+        public void Relax_ShouldAddPenalizedSlacksForTaggedConstraints()
+        {
+            var result = ModelTags.Parse(Model).Relax("hydro", 500);
+
+            Assert.Empty(result.Warnings);
+            Assert.Equal(new[] { "rampUp_slack" }, result.Slacks["rampUp"]);
+            Assert.Contains("dvar float+ rampUp_slack[Units];", result.ModelText);
+            Assert.Contains("// @tags hydro/ramping/up", result.ModelText);
+
+            var model = LinearModel.FromModel(ParseAndExpand(result.ModelText));
+            var slackCosts = model.ObjectiveCoefficients.Where(c => c.Key.StartsWith("rampUp_slack")).ToList();
+            Assert.Equal(3, slackCosts.Count);
+            Assert.All(slackCosts, c => Assert.Equal(500, c.Value));
+            Assert.Equal(500, model.ObjectiveCoefficients.Single(c => c.Key.StartsWith("spinning_slack")).Value);
+
+            var rampRow = model.Constraints.First(c => c.Coefficients.Keys.Any(k => k.StartsWith("rampUp_slack")));
+            Assert.Equal(-1, rampRow.Coefficients.Single(c => c.Key.StartsWith("rampUp_slack")).Value);
+        }
+
+        [Fact]
+        public void Relax_Equality_ShouldUseTwoSlacks()
+        {
+            var result = ModelTags.Parse(Model).Relax("balance");
+
+            Assert.Equal(new[] { "meet_slack_pos", "meet_slack_neg" }, result.Slacks["meet"]);
+
+            var model = LinearModel.FromModel(ParseAndExpand(result.ModelText));
+            Assert.Equal(1000, model.ObjectiveCoefficients["meet_slack_pos"]);
+            Assert.Equal(1000, model.ObjectiveCoefficients["meet_slack_neg"]);
+        }
+    }
+}
@@ -0,0 +1,150 @@
+using System.Diagnostics;
+using System.Diagnostics.Metrics;
+using Core;
+using Core.Export;
+using Core.Server;
+using Core.Services;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ModelTelemetryTests : TestBase
+    {
+        private const string Model = @"dvar float+ x;
+dvar float+ y;
+maximize 3*x + 5*y;
+c1: 2*x + y <= 10;
+c2: x + 2*y <= 8;
+";
+
+        /// <summary>
+        /// Collects ModelEditor spans of one trace; other tests running in parallel use other traces
+        /// </summary>
+        private sealed class SpanCollector : IDisposable
+        {
+            private readonly ActivityListener listener;
+            private readonly Activity root;
+
+            public List<Activity> Spans { get; } = new List<Activity>();
+
+            public SpanCollector()
+            {
+                root = new Activity("test").Start();
+                var traceId = root.TraceId;
+                listener = new ActivityListener
+                {
+                    ShouldListenTo = source => source.Name == ModelTelemetry.Name,
+                    Sample = (ref ActivityCreationOptions<ActivityContext> options) => options.TraceId == traceId
+                        ? ActivitySamplingResult.AllDataAndRecorded
+                        : ActivitySamplingResult.None,
+                    ActivityStopped = activity =>
+                    {
+                        lock (Spans) Spans.Add(activity);
+                    }
+                };
+                ActivitySource.AddActivityListener(listener);
+            }
+
+            public void Dispose()
+            {
+                root.Stop();
+                listener.Dispose();
+            }
+        }
+
+        private class NamedDriver : ISolverDriver
+        {
+            public NamedDriver(string name) => Name = name;
+
+            public string Name { get; }
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 26 };
+        }
+
+        [Fact]
+        public void StartPhase_WithoutListener_ShouldNotCreateSpan()
+        {
+            using var phase = ModelTelemetry.StartPhase("export", ("format", "mps"));
+            phase.SetTag("modeleditor.model_id", "m1").Complete();
+
+            Assert.Null(phase.Activity);
+            Assert.Equal("ok", phase.Outcome);
+        }
+
+        [Fact]
+        public void Export_ShouldEmitSpanWithFormatAndOutcome()
+        {
+            var manager = CreateModelManager();
+            Assert.False(CreateParser(manager).Parse(Model).HasErrors);
+
+            using var collector = new SpanCollector();
+            new MPSExporter(manager).Export("TEST");
+
+            var span = Assert.Single(collector.Spans);
+            Assert.Equal("modeleditor.export", span.OperationName);
+            Assert.Equal("mps", span.GetTagItem("format"));
+            Assert.Equal("ok", span.GetTagItem("modeleditor.outcome"));
+            Assert.Equal(ActivityStatusCode.Ok, span.Status);
+        }
+
+        [Fact]
+        public void Export_Failure_ShouldMarkSpanAborted()
+        {
+            var manager = CreateModelManager();
+            Assert.False(CreateParser(manager).Parse("dvar float+ x;").HasErrors);
+
+            using var collector = new SpanCollector();
+            Assert.Throws<InvalidOperationException>(() => new MPSExporter(manager).Export("TEST"));
+
+            var span = Assert.Single(collector.Spans);
+            Assert.Equal("aborted", span.GetTagItem("modeleditor.outcome"));
+            Assert.Equal(ActivityStatusCode.Error, span.Status);
+        }
+
+        [Fact]
+        public async Task Solve_ShouldRecordSpansDurationsAndStatusCounter()
+        {
+            string solver = $"telemetry-{Guid.NewGuid():N}";
+            var durations = new List<(string Phase, string Outcome)>();
+            long solves = 0;
+
+            using var meters = new MeterListener();
+            meters.InstrumentPublished = (instrument, listener) =>
+            {
+                if (instrument.Meter.Name == ModelTelemetry.Name)
+                    listener.EnableMeasurementEvents(instrument);
+            };
+            meters.SetMeasurementEventCallback<double>((instrument, value, tags, state) =>
+            {
+                var tagMap = tags.ToArray().ToDictionary(t => t.Key, t => t.Value);
+                if (instrument.Name == "modeleditor.phase.duration" && Equals(tagMap.GetValueOrDefault("solver"), solver))
+                    lock (durations) durations.Add(((string)tagMap["phase"]!, (string)tagMap["outcome"]!));
+            });
+            meters.SetMeasurementEventCallback<long>((instrument, value, tags, state) =>
+            {
+                var tagMap = tags.ToArray().ToDictionary(t => t.Key, t => t.Value);
+                if (instrument.Name == "modeleditor.solve.count" && Equals(tagMap.GetValueOrDefault("solver"), solver))
+                {
+                    Assert.Equal("Optimal", tagMap["status"]);
+                    Interlocked.Add(ref solves, value);
+                }
+            });
+            meters.Start();
+
+            var host = new ModelHost();
+            var model = host.Create("telemetry", Model);
+
+            using var collector = new SpanCollector();
+            var result = await host.SolveAsync(model.Id, new NamedDriver(solver));
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(1, Interlocked.Read(ref solves));
+            Assert.Equal(new[] { ("solve", "ok") }, durations);
+
+            var solve = collector.Spans.Single(s => s.OperationName == "modeleditor.solve");
+            var parse = collector.Spans.Single(s => s.OperationName == "modeleditor.parse");
+            Assert.Equal(model.Id, solve.GetTagItem("modeleditor.model_id"));
+            Assert.Equal(solve.SpanId, parse.ParentSpanId);
+        }
+    }
+}
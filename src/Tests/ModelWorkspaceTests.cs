@@ -0,0 +1,125 @@
+using Core.Services;
+
+namespace Tests
+{
+    public class ModelWorkspaceTests : TestBase
+    {
+        private const string NetworkModel = @"
+            range Nodes = 1..3;
+            float capacity = 10;
+        ";
+
+        private const string DispatchModel = @"
+            import Nodes, capacity from network;
+            dvar float+ flow[Nodes];
+            forall(n in Nodes) cap: flow[n] <= capacity;
+            maximize sum(n in Nodes) flow[n];
+        ";
+
+        [Fact]
+        public void Parse_WithImport_ShouldResolveEntitiesFromOtherDocument()
+        {
+            var workspace = new ModelWorkspace();
+            workspace.AddDocument("network", NetworkModel);
+            var dispatch = workspace.AddDocument("dispatch", DispatchModel);
+
+            var result = workspace.Parse("dispatch");
+
+            AssertNoErrors(result);
+            Assert.Equal(3, dispatch.Manager.MaterializeRows().Count);
+            Assert.Equal(2, dispatch.Imports.Count);
+            Assert.False(workspace.IsStale("dispatch"));
+        }
+
+        [Fact]
+        public void Parse_WithComposerImportSyntax_ShouldImportCopies()
+        {
+            var workspace = new ModelWorkspace();
+            var network = workspace.AddDocument("network", NetworkModel);
+            var dispatch = workspace.AddDocument("dispatch", DispatchModel.Replace("from network;", "from \"network.mod\";"));
+
+            AssertNoErrors(workspace.Parse("dispatch"));
+            Assert.All(dispatch.Imports, l => Assert.Equal("network", l.SourceDocument));
+
+            var imported = dispatch.Manager.Parameters["capacity"];
+            Assert.NotSame(network.Manager.Parameters["capacity"], imported);
+            network.Manager.Parameters["capacity"].Value = 99.0;
+            Assert.Equal(10.0, Convert.ToDouble(imported.Value));
+
+            workspace.AddDocument("prefixed", "import \"network\" as net;\nfloat x = 1;\n");
+            var error = Assert.Single(workspace.Parse("prefixed").Errors);
+            Assert.Equal(1, error.LineNumber);
+            Assert.StartsWith("prefixed imports (as net) are only resolved when composing model files", error.Message);
+        }
+
+        [Fact]
+        public void IsStale_AfterSourceEntityChanges_ShouldReportStaleLink()
+        {
+            var workspace = new ModelWorkspace();
+            var network = workspace.AddDocument("network", NetworkModel);
+            workspace.AddDocument("dispatch", DispatchModel);
+            Assert.True(workspace.ValidateAll().IsValid);
+
+            network.SetText(NetworkModel.Replace("capacity = 10", "capacity = 25"));
+            Assert.True(workspace.IsStale("dispatch"));
+
+            workspace.Parse("network");
+            var stale = Assert.Single(workspace.GetStaleLinks());
+            Assert.Equal("capacity", stale.Link.EntityName);
+
+            workspace.Parse("dispatch");
+            Assert.False(workspace.IsStale("dispatch"));
+            Assert.Equal(new[] { "dispatch" }, workspace.GetDependents("network"));
+        }
+
+        [Fact]
+        public void ValidateAll_ShouldReportMissingEntitiesAndCycles()
+        {
+            var workspace = new ModelWorkspace();
+            workspace.AddDocument("network", NetworkModel);
+            workspace.AddDocument("a", "import Nodes, demand from network;\n");
+            workspace.AddDocument("b", "import x from c;\nfloat y = 1;\n");
+            workspace.AddDocument("c", "import y from b;\nfloat x = 1;\n");
+
+            var result = workspace.ValidateAll();
+
+            Assert.False(result.IsValid);
+            Assert.Contains(result.Errors["a"], e => e.Contains("'demand' is not declared in 'network'"));
+            Assert.Contains(result.Errors.Values.SelectMany(e => e), e => e.Contains("circular import"));
+            Assert.True(result.ParseOrder.IndexOf("network") < result.ParseOrder.IndexOf("a"));
+        }
+
+        [Fact]
+        public void Search_ShouldRankNamesAboveDocstringsAndAnnotationsWithPositions()
+        {
+            var workspace = new ModelWorkspace();
+            workspace.AddDocument("plants",
+                "range Units = 1..3;\n" +
+                "#: Ramp rate limit of each unit at StationA\n" +
+                "float rampLimit[Units] = ...;\n" +
+                "// @tags stationA\n" +
+                "float rampRate_StationA = 5;\n" +
+                "// @owner StationA operations\n" +
+                "// @unit MW per minute\n" +
+                "dvar float+ output[Units];\n");
+            workspace.AddDocument("grid", "float rate = 1; #: Exchange rate\n");
+
+            var results = workspace.Search("ramp rate StationA");
+
+            Assert.Equal(new[] { "parameter:rampRate_StationA", "parameter:rampLimit" }, results.Select(r => r.Key));
+            var best = results[0];
+            Assert.Equal(("plants", 5), (best.Document, best.LineNumber));
+            Assert.Contains(best.Matches, m => m.Field == SearchField.Name && m.Text == "StationA" && m.LineNumber == 5 && m.Column == 16);
+            Assert.Contains(best.Matches, m => m.Field == SearchField.Tag && m.Text == "stationA" && m.LineNumber == 4);
+            Assert.Contains(results[1].Matches, m => m.Field == SearchField.Docstring && m.Text == "Ramp" && m.Column == 4);
+
+            var annotated = Assert.Single(workspace.Search("minute oper"));
+            Assert.Equal("variable:output", annotated.Key);
+            Assert.Equal(new[] { "owner", "unit" }, annotated.Matches.Select(m => m.Annotation));
+
+            Assert.Equal("grid", Assert.Single(workspace.Search("exchange")).Document);
+            workspace.GetDocument("grid")!.SetText("float rate = 1; #: Conversion rate\n");
+            Assert.Empty(workspace.Search("exchange"));
+        }
+    }
+}
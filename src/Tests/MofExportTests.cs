@@ -0,0 +1,81 @@
+using System.Text.Json;
+using Core;
+using Core.Export;
+using Core.Import;
+
+namespace Tests
+{
+    public class MofExportTests : TestBase
+    {
+        private const string Model = @"
+            range Nodes = 1..3;
+            float capacity = 20;
+            dvar float+ flow[Nodes];
+            dvar int trucks in 0..4;
+            dvar bool open;
+            dvar float slack;
+            maximize sum(n in Nodes) 2 * flow[n] - 5 * trucks - slack + 1;
+            forall(n in Nodes) cap: flow[n] <= capacity;
+            fleet: sum(n in Nodes) flow[n] - 15 * trucks <= 0;
+            opening: trucks - 4 * open <= 0;
+            balance: flow[1] - slack == 2;
+        ";
+
+        private ModelManager BuildModel() => ParseAndExpand(Model);
+
+        [Fact]
+        public void Export_ShouldWriteAffineRowsAndVariableSets()
+        {
+            string json = new MofExporter(BuildModel()).Export("network");
+
+            using var document = JsonDocument.Parse(json);
+            var root = document.RootElement;
+            Assert.Equal("network", root.GetProperty("name").GetString());
+            Assert.Equal(1, root.GetProperty("version").GetProperty("major").GetInt32());
+            Assert.Equal(6, root.GetProperty("variables").GetArrayLength());
+            Assert.Equal("max", root.GetProperty("objective").GetProperty("sense").GetString());
+            Assert.Equal(1, root.GetProperty("objective").GetProperty("function").GetProperty("constant").GetDouble());
+
+            var constraints = root.GetProperty("constraints").EnumerateArray().ToList();
+            var rows = constraints.Where(c => c.GetProperty("function").GetProperty("type").GetString() == "ScalarAffineFunction").ToList();
+            Assert.Equal(6, rows.Count);
+            Assert.Equal(rows.Count, rows.Select(r => r.GetProperty("name").GetString()).Distinct().Count());
+
+            var sets = constraints
+                .Where(c => c.GetProperty("function").GetProperty("type").GetString() == "Variable")
+                .Select(c => $"{c.GetProperty("function").GetProperty("name").GetString()}:{c.GetProperty("set").GetProperty("type").GetString()}")
+                .ToList();
+            Assert.Contains("open:ZeroOne", sets);
+            Assert.Contains("trucks:Integer", sets);
+            Assert.Contains("trucks:Interval", sets);
+            Assert.DoesNotContain(sets, s => s.StartsWith("slack:"));
+        }
+
+        [Fact]
+        public void Export_ShouldRoundTripThroughImporter()
+        {
+            var original = BuildModel();
+            var imported = new MofImporter().Import(new MofExporter(original).Export());
+
+            Assert.Empty(imported.Warnings);
+            Assert.Equal(original.Equations.Count, imported.Constraints.Count);
+            Assert.Null(imported.FindVariable("slack")!.LowerBound);
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(imported.ToModelText());
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            Assert.Equal(original.Equations.Count, manager.Equations.Count);
+        }
+
+        [Fact]
+        public void Export_UnexpandedModel_ShouldThrow()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            Assert.Throws<InvalidOperationException>(() => new MofExporter(manager).Export());
+        }
+    }
+}
@@ -0,0 +1,134 @@
+using System.Net;
+using System.Text;
+using System.Xml.Linq;
+using Core;
+using Core.Solving;
+
+namespace Tests
+{
+    public class NeosSolveTransportTests : TestBase
+    {
+        /// <summary>
+        /// XML-RPC endpoint answering like NEOS: the job waits, runs with a log, then is done
+        /// </summary>
+        private class FakeNeos : HttpMessageHandler
+        {
+            private int statusCalls;
+
+            public List<string> Methods { get; } = new List<string>();
+            public XElement? Submitted { get; private set; }
+            public string? FaultOn { get; set; }
+            public string FinalOutput { get; set; } = "Optimal - objective value 5.00000000\n      0 X                      5                       1\n";
+
+            protected override async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken cancellationToken)
+            {
+                var call = XElement.Parse(await request.Content!.ReadAsStringAsync(cancellationToken));
+                string method = call.Element("methodName")!.Value;
+                var parameters = call.Element("params")!.Elements("param").Select(p => p.Element("value")!.Elements().First()).ToList();
+                Methods.Add(method);
+
+                string value = method switch
+                {
+                    "listSolversInCategory" => Array("Cbc:MPS", "CPLEX:GAMS", "HiGHS:MPS"),
+                    "submitJob" => Submit(parameters[0].Value),
+                    "getJobStatus" => $"<string>{(++statusCalls switch { 1 => "Waiting", 2 => "Running", _ => "Done" })}</string>",
+                    "getIntermediateResultsNonBlocking" =>
+                        $"<array><data><value><base64>{Convert.ToBase64String(Encoding.UTF8.GetBytes("Cbc0012I Integer solution of 5\n"))}</base64></value><value><int>31</int></value></data></array>",
+                    "getFinalResults" => $"<base64>{Convert.ToBase64String(Encoding.UTF8.GetBytes(FinalOutput))}</base64>",
+                    _ => throw new InvalidOperationException($"Unexpected call {method}")
+                };
+                string body = method == FaultOn
+                    ? "<methodResponse><fault><value><struct><member><name>faultCode</name><value><int>1</int></value></member>" +
+                      "<member><name>faultString</name><value><string>Input is too large</string></value></member></struct></value></fault></methodResponse>"
+                    : $"<?xml version=\"1.0\"?><methodResponse><params><param><value>{value}</value></param></params></methodResponse>";
+                return new HttpResponseMessage(HttpStatusCode.OK) { Content = new StringContent(body) };
+            }
+
+            private string Submit(string document)
+            {
+                Submitted = XElement.Parse(document);
+                return "<array><data><value><int>4711</int></value><value>secret</value></data></array>";
+            }
+
+            private static string Array(params string[] items) =>
+                "<array><data>" + string.Concat(items.Select(i => $"<value><string>{i}</string></value>")) + "</data></array>";
+        }
+
+        private static RemoteSolverDriver CreateDriver(FakeNeos neos, string solver) =>
+            new RemoteSolverDriver(new NeosSolveTransport("user@example.com", new Uri("https://neos.test:3333"), new HttpClient(neos)))
+            {
+                RemoteSolver = solver,
+                RetryDelay = TimeSpan.Zero,
+                PollInterval = TimeSpan.Zero
+            };
+
+        [Fact]
+        public async Task Solve_ShouldSubmitMpsToMatchingSolverAndMapColumnsBack()
+        {
+            var neos = new FakeNeos();
+            var driver = CreateDriver(neos, "cbc");
+            var lines = new List<string>();
+            driver.LogReceived += lines.Add;
+            var manager = ParseAndExpand("dvar int+ x;\nminimize x;\nc1: x >= 5;\n");
+
+            var result = await driver.SolveAsync(manager);
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(5, result.ObjectiveValue);
+            Assert.Equal(5, result.VariableValues["x"]);
+            Assert.Equal("NEOS neos.test: Optimal", result.StatusMessage);
+
+            Assert.Equal("milp", neos.Submitted!.Element("category")!.Value);
+            Assert.Equal("Cbc", neos.Submitted.Element("solver")!.Value);
+            Assert.Equal("MPS", neos.Submitted.Element("inputMethod")!.Value);
+            Assert.Equal("user@example.com", neos.Submitted.Element("email")!.Value);
+            Assert.Equal(new Core.Export.MPSExporter(manager).Export("PROBLEM"), neos.Submitted.Element("MPS")!.Value);
+
+            Assert.Equal(new[] { "Cbc0012I Integer solution of 5" }, lines);
+            Assert.Equal(
+                new[] { "listSolversInCategory", "submitJob", "getJobStatus", "getJobStatus", "getIntermediateResultsNonBlocking", "getJobStatus", "getFinalResults" },
+                neos.Methods);
+        }
+
+        [Fact]
+        public async Task Solve_UnknownSolver_ShouldListAvailableSolvers()
+        {
+            var neos = new FakeNeos();
+
+            var result = await CreateDriver(neos, "gurobi").SolveAsync(ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 5;\n"));
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Equal("NEOS has no MPS solver 'gurobi' for lp; available: Cbc, HiGHS", result.StatusMessage);
+            Assert.DoesNotContain("submitJob", neos.Methods);
+        }
+
+        [Fact]
+        public async Task Solve_Fault_ShouldFailWithFaultString()
+        {
+            var neos = new FakeNeos { FaultOn = "submitJob" };
+
+            var result = await CreateDriver(neos, "HiGHS").SolveAsync(ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 5;\n"));
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Equal("submitJob on NEOS neos.test failed: Input is too large", result.StatusMessage);
+        }
+
+        [Fact]
+        public void ParseCbcSolution_ShouldReadStatusAndSkipSolutionWhenInfeasible()
+        {
+            var stopped = NeosSolveTransport.ParseCbcSolution(
+                "Cbc0010I After 1000 nodes\nStopped on time - objective value 12.5\n      0 X  2.5  0\n**    1 Y  10  0\n\nDone\n");
+            var infeasible = NeosSolveTransport.ParseCbcSolution("Infeasible - objective value 0.00000000\n      0 X  1  0\n");
+            var failed = NeosSolveTransport.ParseCbcSolution("Reading MPS file\nError: bad row\n");
+
+            Assert.Equal(SolveStatus.Feasible, stopped.Status);
+            Assert.Equal(12.5, stopped.ObjectiveValue);
+            Assert.Equal(new Dictionary<string, double> { ["X"] = 2.5, ["Y"] = 10 }, stopped.VariableValues);
+            Assert.Equal(SolveStatus.Infeasible, infeasible.Status);
+            Assert.Empty(infeasible.VariableValues);
+            Assert.Null(infeasible.ObjectiveValue);
+            Assert.Equal(SolveStatus.Error, failed.Status);
+            Assert.Equal("No solution in NEOS output: Error: bad row", failed.StatusMessage);
+        }
+    }
+}
@@ -0,0 +1,82 @@
+using Core;
+using Core.Analysis;
+using Core.Networks;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class NetworkTests : TestBase
+    {
+        private const string Model =
+            "{string} Nodes = {\"a\", \"b\", \"c\"};\n" +
+            "float supply[Nodes] = [10, 0, -10];\n" +
+            "tuple Arc { string from; string to; float capacity; float cost; }\n" +
+            "{Arc} Arcs = {<\"a\",\"b\",40,2>, <\"b\",\"c\",30,1>, <\"a\",\"c\",5,6>};\n";
+
+        [Fact]
+        public void FromModel_ShouldGenerateFlowVariablesAndConservation()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            var network = Network.FromModel(manager, "Nodes", "Arcs", "supply");
+            Assert.Equal("Arcs: 3 nodes, 3 arcs", network.ToString());
+            Assert.Empty(network.Validate());
+
+            var statements = NetworkFormulation.Generate(network);
+            Assert.Contains("dvar float flow_a_b in 0..40;", statements);
+            Assert.Contains("flow_balance_c: -flow_b_c - flow_a_c == -10;", statements);
+            Assert.Contains("dexpr float flowCost = 2 * flow_a_b + 1 * flow_b_c + 6 * flow_a_c;", statements);
+
+            var generated = CreateModelManager();
+            var parser = CreateParser(generated);
+            var result = parser.Parse(string.Join("\n", statements) + "\nminimize flowCost;\n");
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            var balance = generated.GetEquationByLabel("flow_balance_a")!;
+            Assert.Equal(new[] { "flow_a_b", "flow_a_c" }, balance.Coefficients.Keys.OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Validate_ShouldReportDanglingArcsAndDisconnectedParts()
+        {
+            var network = new Network("grid");
+            foreach (var node in new[] { "n1", "n2", "n3", "n4", "n5" })
+                network.AddNode(node);
+            network.AddArc("n1", "n2");
+            network.AddArc("n3", "n4", capacity: -1);
+            network.AddArc("n2", "n9");
+
+            var findings = network.Validate();
+
+            Assert.Equal(new[] { "negative-capacity", "dangling-arc", "isolated-node", "disconnected" }, findings.Select(f => f.RuleId));
+            Assert.Equal("n2 -> n9", findings[1].Subject);
+            Assert.Contains("n1, n2; n3, n4", findings[3].Message);
+            Assert.Throws<InvalidOperationException>(() => NetworkFormulation.Generate(network));
+        }
+
+        [Fact]
+        public void Regenerate_ShouldRemoveTheDeclarationsOfRemovedArcs()
+        {
+            var network = new Network("grid");
+            network.AddNode("n1", 5);
+            network.AddNode("n2", -5);
+            network.AddNode("n3");
+            network.AddArc("n1", "n2");
+            network.AddArc("n1", "n3");
+            network.AddArc("n3", "n2");
+            string model = NetworkFormulation.Regenerate(ModelSource.Parse("minimize 0;\n"), network).Apply("minimize 0;\n");
+            Assert.Equal(6, ModelProvenance.Parse(model).Select("network:grid").Count);
+
+            var smaller = new Network("grid");
+            smaller.AddNode("n1", 5);
+            smaller.AddNode("n2", -5);
+            smaller.AddArc("n1", "n2");
+            model = NetworkFormulation.Regenerate(ModelSource.Parse(model), smaller).Apply(model);
+
+            Assert.Equal(3, ModelProvenance.Parse(model).Select("network:grid").Count);
+            Assert.DoesNotContain("flow_n1_n3", model);
+            Assert.DoesNotContain("flow_balance_n3", model);
+        }
+    }
+}
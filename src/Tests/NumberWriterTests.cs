@@ -0,0 +1,51 @@
+using Core.Export;
+using Core.Import;
+
+namespace Tests
+{
+    public class NumberWriterTests
+    {
+        [Theory]
+        [InlineData(0.1, "0.1")]
+        [InlineData(1e-30, "0.000000000000000000000000000001")]
+        [InlineData(-2.5e-7, "-0.00000025")]
+        [InlineData(1.5e20, "150000000000000000000")]
+        [InlineData(123.456, "123.456")]
+        public void Positional_ShouldWriteAllDigitsWithoutExponent(double value, string expected)
+        {
+            Assert.Equal(expected, NumberWriter.Positional(value));
+            Assert.Equal(value, double.Parse(expected, System.Globalization.CultureInfo.InvariantCulture));
+        }
+
+        [Fact]
+        public void ToModelText_ShouldKeepSmallCoefficients()
+        {
+            var model = new LinearModel();
+            model.Variables.Add(new LinearVariable { Name = "x", LowerBound = 0 });
+            model.Constraints.Add(new LinearConstraint
+            {
+                Name = "tiny",
+                Coefficients = new Dictionary<string, double> { ["x"] = 3e-12 },
+                Operator = Core.Models.RelationalOperator.LessThanOrEqual,
+                Rhs = 1
+            });
+
+            Assert.Contains("tiny: 0.000000000003*x <= 1;", model.ToModelText());
+        }
+
+        [Fact]
+        public void Round_ShouldOnlyReportValuesThePrecisionChanges()
+        {
+            var numbers = new NumberWriter(3);
+
+            Assert.Equal("2.5", numbers.Format(2.5, "a"));
+            Assert.Equal("3.14", numbers.Format(3.14159, "pi"));
+            Assert.Equal(2, numbers.Written);
+            Assert.Equal(1, numbers.Changed);
+            Assert.Contains("pi 3.14159 written as 3.14", numbers.Warning);
+
+            Assert.Null(new NumberWriter().Warning);
+            Assert.Throws<ArgumentOutOfRangeException>(() => new NumberWriter(18));
+        }
+    }
+}
@@ -0,0 +1,113 @@
+using Core;
+using Core.Export;
+using Core.Import;
+using Core.Models;
+
+namespace Tests
+{
+    public class NumericPrecisionTests : TestBase
+    {
+        [Theory]
+        [InlineData("0.1", "0.1")]
+        [InlineData("-1.5e-3", "-0.0015")]
+        [InlineData("1e2", "100")]
+        [InlineData("2/4", "0.5")]
+        [InlineData("2/6", "1/3")]
+        [InlineData("6/3", "2")]
+        public void ExactNumber_ParseAndToString_ShouldBeExact(string text, string expected)
+        {
+            Assert.Equal(expected, ExactNumber.Parse(text).ToString());
+        }
+
+        [Fact]
+        public void ExactNumber_FromDouble_ShouldUseShortestDecimal()
+        {
+            Assert.Equal(ExactNumber.Parse("0.1"), ExactNumber.FromDouble(0.1));
+            Assert.Equal(ExactNumber.Parse("0.3"), ExactNumber.FromDouble(0.1) + ExactNumber.FromDouble(0.2));
+            Assert.NotEqual(0.3, 0.1 + 0.2);
+            Assert.Throws<InvalidOperationException>(() => ExactNumber.FromDouble(double.NaN));
+        }
+
+        [Fact]
+        public void ExactNumber_RoundAndToDouble()
+        {
+            var third = ExactNumber.Parse("1/3");
+
+            Assert.Equal("0.3333", third.Round(4).ToString());
+            Assert.Equal("2", ExactNumber.Parse("2.5").Round(0).ToString());
+            Assert.Equal("0.12", ExactNumber.Parse("0.125").Round(2).ToString());
+            Assert.Equal(1.0 / 3, third.ToDouble());
+            Assert.False(third.IsExactDouble);
+            Assert.True(ExactNumber.Parse("0.5").IsExactDouble);
+            Assert.False(ExactNumber.Parse("0.10000000000000001").IsExactDouble);
+        }
+
+        [Fact]
+        public void Parse_ShouldReadNumericAnnotations()
+        {
+            var manager = ParseAndExpand(
+                "// @numeric default decimal(4)\n" +
+                "dvar float+ x;\n" +
+                "minimize x;\n" +
+                "// @numeric rational\n" +
+                "c1: x <= 1;\n");
+
+            Assert.Equal("decimal(4)", manager.NumericPrecision.ToString());
+            Assert.Equal(NumericType.Rational, manager.EntityPrecision["constraint:c1"].Type);
+            Assert.Equal(NumericType.Rational, NumericEvaluator.PrecisionOf(manager, manager.Equations.Single()).Type);
+            Assert.Equal(NumericType.Decimal, NumericEvaluator.PrecisionOfObjective(manager).Type);
+        }
+
+        [Fact]
+        public void Parse_UnknownNumericType_ShouldReportError()
+        {
+            var parser = CreateParser();
+            var result = parser.Parse("// @numeric decimal64\ndvar float+ x;\nminimize x;");
+
+            Assert.Contains(result.Errors, e => e.Message.Contains("Unknown numeric type 'decimal64'"));
+        }
+
+        [Theory]
+        [InlineData(NumericType.Rational, "1/3")]
+        [InlineData(NumericType.Decimal, "0.3333")]
+        public void Evaluate_Division_ShouldFollowPrecision(NumericType type, string expected)
+        {
+            var expression = new BinaryExpression(new ConstantExpression(1), BinaryOperator.Divide, new ConstantExpression(3));
+            var precision = new NumericPrecision { Type = type, Scale = 4 };
+
+            Assert.Equal(expected, NumericEvaluator.Evaluate(expression, CreateModelManager(), precision).ToString());
+        }
+
+        [Theory]
+        [InlineData("", 0.30000000000000004)]
+        [InlineData("// @numeric default decimal\n", 0.3)]
+        public void LinearModel_ShouldEvaluateCoefficientsInModelPrecision(string annotation, double expected)
+        {
+            var manager = ParseAndExpand(annotation + "dvar float+ x;\nminimize 0.1*x + 0.2*x;\nc1: x <= 1;");
+
+            var model = LinearModel.FromModel(manager);
+
+            Assert.Equal(expected, model.ObjectiveCoefficients["x"]);
+            Assert.Empty(model.Warnings);
+        }
+
+        [Fact]
+        public void Export_ValueBeyondDouble_ShouldBeExactInMpsAndWarnInMof()
+        {
+            var manager = ParseAndExpand(
+                "dvar float+ x;\n" +
+                "minimize x;\n" +
+                "// @numeric rational\n" +
+                "c1: 0.1*x + 0.00000000000000001*x <= 1;");
+
+            var mps = new MPSExporter(manager);
+            Assert.Contains("0.10000000000000001", mps.Export());
+            Assert.Empty(mps.Warnings);
+
+            var mof = new MofExporter(manager);
+            mof.Export();
+            var warning = Assert.Single(mof.Warnings);
+            Assert.Equal("Precision loss: coefficient of 'x' in 'c1' is 0.10000000000000001, written as 0.1", warning);
+        }
+    }
+}
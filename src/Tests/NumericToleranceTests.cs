@@ -0,0 +1,65 @@
+using Core.Models;
+
+namespace Tests
+{
+    public class NumericToleranceTests
+    {
+        [Theory]
+        [InlineData(1.0, 1.0000001, true)]
+        [InlineData(1.0, 1.00001, false)]
+        [InlineData(1e9, 1e9 + 0.5, true)]
+        [InlineData(0.1 + 0.2, 0.3, true)]
+        [InlineData(double.PositiveInfinity, double.PositiveInfinity, true)]
+        [InlineData(double.PositiveInfinity, 1e300, false)]
+        [InlineData(double.NaN, double.NaN, false)]
+        public void AreEqual_ShouldUseAbsoluteOrRelativeTolerance(double a, double b, bool expected)
+        {
+            Assert.Equal(expected, NumericTolerance.Default.AreEqual(a, b));
+        }
+
+        [Fact]
+        public void Compare_ShouldTreatValuesWithinToleranceAsEqual()
+        {
+            var tolerance = NumericTolerance.Default;
+
+            Assert.Equal(0, tolerance.Compare(2, 2 + 1e-8));
+            Assert.True(tolerance.IsLess(2, 2.1));
+            Assert.False(tolerance.IsLess(2 - 1e-8, 2));
+            Assert.True(tolerance.IsLessOrEqual(2 + 1e-8, 2));
+            Assert.True(tolerance.IsGreater(double.PositiveInfinity, 1e300));
+            Assert.False(tolerance.IsGreater(double.NegativeInfinity, double.NegativeInfinity));
+        }
+
+        [Fact]
+        public void IsIntegral_ShouldOnlyUseAbsoluteTolerance()
+        {
+            var tolerance = new NumericTolerance { Absolute = 1e-9, Relative = 1e-3 };
+
+            Assert.True(tolerance.IsIntegral(3.0000000001));
+            Assert.False(tolerance.IsIntegral(1e6 + 0.5));
+            Assert.Equal(3, tolerance.Ceiling(3.0000000001));
+            Assert.Equal(4, tolerance.Ceiling(3.01));
+            Assert.Equal(3, tolerance.Floor(2.9999999999));
+            Assert.Equal(2, tolerance.Floor(2.99));
+        }
+
+        [Fact]
+        public void Distinct_ShouldCollapseNearlyEqualValues()
+        {
+            var values = NumericTolerance.Default.Distinct(new[] { 0.3, 1.0, 0.1 + 0.2, double.NaN, 1.0000000001, 2.0 });
+
+            Assert.Equal(new[] { 0.3, 1.0, 2.0 }, values);
+        }
+
+        [Fact]
+        public void Evaluation_ShouldApplyToModelExpressions()
+        {
+            var comparison = new ComparisonExpression(
+                new BinaryExpression(new ConstantExpression(0.1), BinaryOperator.Add, new ConstantExpression(0.2)),
+                BinaryOperator.LessThanOrEqual,
+                new ConstantExpression(0.3));
+
+            Assert.Equal(1.0, comparison.Evaluate(new Core.ModelManager()));
+        }
+    }
+}
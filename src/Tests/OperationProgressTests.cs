@@ -0,0 +1,178 @@
+using Core;
+using Core.Analysis;
+using Core.Export;
+using Core.Import;
+using Core.Services;
+using Core.Solving;
+using Core.Storage;
+
+namespace Tests
+{
+    public class OperationProgressTests : TestBase
+    {
+        private const string Model = @"
+float capacity = ...;
+range Nodes = 1..3;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private class SynchronousProgress : IProgress<OperationProgress>
+        {
+            public List<OperationProgress> Events { get; } = new List<OperationProgress>();
+
+            /// <summary>
+            /// Called after each event is recorded, e.g. to cancel part-way through
+            /// </summary>
+            public Action<OperationProgress>? OnReport { get; init; }
+
+            public void Report(OperationProgress value)
+            {
+                Events.Add(value);
+                OnReport?.Invoke(value);
+            }
+        }
+
+        private class CountingDriver : ISolverDriver
+        {
+            public int Calls { get; private set; }
+            public string Name => "Counting";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Calls++;
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 0 };
+            }
+        }
+
+        private static ModelParsingService CreateService(ModelManager manager) =>
+            new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager)) { SolveAfterParse = false };
+
+        [Fact]
+        public void ParseModel_ShouldReportStages()
+        {
+            var manager = new ModelManager();
+            var progress = new SynchronousProgress();
+
+            var result = CreateService(manager).ParseModel(
+                new List<string> { Model }, new List<string> { "capacity = 5;" }, CancellationToken.None, progress);
+
+            Assert.True(result.Success, string.Join("; ", result.Errors));
+            Assert.Equal(3, manager.Equations.Count);
+
+            var stages = progress.Events.Select(e => $"{e.Operation}/{e.Stage}").Distinct().ToList();
+            Assert.Equal(new[] { "load/model", "load/data", "load/expand", "transform/templates", "transform/forall" }, stages);
+
+            var forall = progress.Events.Last(e => e.Stage == "forall");
+            Assert.Equal(1, forall.Fraction);
+            Assert.Equal("3 constraints", forall.Message);
+        }
+
+        [Fact]
+        public void ParseModel_Cancelled_ShouldThrow()
+        {
+            using var cancellation = new CancellationTokenSource();
+            cancellation.Cancel();
+
+            Assert.Throws<OperationCanceledException>(() => CreateService(new ModelManager()).ParseModel(
+                new List<string> { Model }, new List<string> { "capacity = 5;" }, cancellation.Token));
+        }
+
+        [Fact]
+        public void Export_ShouldReportRowsAndHonourCancellation()
+        {
+            var manager = new ModelManager();
+            CreateService(manager).ParseModel(new List<string> { Model }, new List<string> { "capacity = 5;" });
+
+            var progress = new SynchronousProgress();
+            var model = LinearModel.FromModel(manager, CancellationToken.None, progress);
+            Assert.Equal(3, model.Constraints.Count);
+            var last = progress.Events.Last();
+            Assert.Equal(("export", "constraints", 3L, (long?)3L), (last.Operation, last.Stage, last.Completed, last.Total));
+
+            using var cancellation = new CancellationTokenSource();
+            cancellation.Cancel();
+            Assert.Throws<OperationCanceledException>(() => new MPSExporter(manager).Export("P", cancellation.Token));
+            Assert.Throws<OperationCanceledException>(() => new MofExporter(manager).Export("P", cancellation.Token));
+        }
+
+        [Fact]
+        public void DefaultDriverCancellation_ShouldNotStartSolve()
+        {
+            ISolverDriver driver = new CountingDriver();
+            using var cancellation = new CancellationTokenSource();
+
+            Assert.Equal(SolveStatus.Optimal, driver.Solve(new ModelManager(), cancellation.Token).Status);
+            cancellation.Cancel();
+            Assert.Throws<OperationCanceledException>(() => driver.Solve(new ModelManager(), cancellation.Token));
+            Assert.Equal(1, ((CountingDriver)driver).Calls);
+        }
+
+        [Fact]
+        public void Linearizers_ShouldReportStatementsAndStopWhenCancelledMidway()
+        {
+            const string model =
+                "dvar float x in 0..4;\n" +
+                "dvar float+ y;\n" +
+                "minimize exp(x) + y;\n" +
+                "c: x * y <= 3;\n";
+
+            var progress = new SynchronousProgress();
+            new ModelLinearizer().Linearize(model, null, CancellationToken.None, progress);
+            var last = progress.Events.Last();
+            Assert.Equal(("linearize", "statements", 4L, (long?)4L), (last.Operation, last.Stage, last.Completed, last.Total));
+
+            // Cancelled from the progress callback once the named stage has started
+            using var piecewise = new CancellationTokenSource();
+            progress = new SynchronousProgress { OnReport = e => { if (e.Stage == "breakpoints") piecewise.Cancel(); } };
+            Assert.Throws<OperationCanceledException>(() => new PiecewiseLinearizer().Apply(model, "exp(x)", null, piecewise.Token, progress));
+            Assert.Equal("breakpoints", progress.Events.Last().Stage);
+
+            using var linearize = new CancellationTokenSource();
+            progress = new SynchronousProgress { OnReport = e => { if (e.Stage == "statements") linearize.Cancel(); } };
+            Assert.Throws<OperationCanceledException>(() => new ModelLinearizer().Linearize(model, null, linearize.Token, progress));
+            Assert.Equal(("statements", 0L), (progress.Events.Last().Stage, progress.Events.Last().Completed));
+        }
+
+        [Fact]
+        public void PackageSave_Cancelled_ShouldKeepPreviousManifest()
+        {
+            string directory = Path.Combine(Path.GetTempPath(), "progress-" + Guid.NewGuid().ToString("N"));
+            try
+            {
+                ModelPackage.Save(directory, "network", Model, "capacity = 5;");
+                using var cancellation = new CancellationTokenSource();
+                cancellation.Cancel();
+
+                Assert.Throws<OperationCanceledException>(() =>
+                    ModelPackage.Save(directory, "network", Model + "// edited\n", "capacity = 6;", cancellationToken: cancellation.Token));
+                Assert.Equal("capacity = 5;", ModelPackage.Load(directory).DataText);
+
+                var progress = new SynchronousProgress();
+                ModelPackage.Save(directory, "network", Model, "capacity = 6;", progress: progress);
+                Assert.Equal(new[] { "sections", "manifest" }, progress.Events.Select(e => e.Stage).Distinct());
+            }
+            finally
+            {
+                Directory.Delete(directory, recursive: true);
+            }
+        }
+
+        [Fact]
+        public void ValidateAll_ShouldReportDocuments()
+        {
+            var workspace = new ModelWorkspace();
+            workspace.AddDocument("a", "range I = 1..2;\ndvar float+ x[I];\n");
+            workspace.AddDocument("b", "dvar float+ y;\nminimize y;\n");
+            var progress = new SynchronousProgress();
+
+            var result = workspace.ValidateAll(CancellationToken.None, progress);
+
+            Assert.True(result.IsValid);
+            var documents = progress.Events.Where(e => e.Operation == "validate").ToList();
+            Assert.Equal(2L, documents.Last().Completed);
+            Assert.Equal(1, documents.Last().Fraction);
+        }
+    }
+}
@@ -0,0 +1,64 @@
+using Core;
+using Core.Models;
+
+namespace Tests
+{
+    public class OrderedSetTests : TestBase
+    {
+        private const string Model = @"
+{int} T = {1, 2, 5, 10};
+float level0 = 4;
+dvar float+ level[T];
+forall(t in T) balance: level[t] == level[prev(T, t, initial = level0)] + 1;
+forall(t in T) ramp: level[next(T, t)] - level[t] <= 5;
+forall(t in T) cycle: level[prevc(T, t)] <= level[t];
+start: level[first(T)] >= 1;
+";
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldShiftByPositionInTheSet()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            AssertNoErrors(result);
+
+            parser.ExpandAllTemplates(result);
+
+            AssertNoErrors(result);
+            var equations = manager.Equations.Where(e => e.Label != null).ToDictionary(e => e.Label!);
+            Assert.Equal(new[] { "level5", "level2" }, equations["balance_5"].Coefficients.Keys.OrderByDescending(k => k));
+            Assert.Equal(new[] { "level1" }, equations["balance_1"].Coefficients.Keys);
+            Assert.Equal(5, equations["balance_1"].Constant.Evaluate(manager));
+            Assert.Equal(new[] { "ramp_1", "ramp_2", "ramp_5" }, equations.Keys.Where(k => k.StartsWith("ramp")).OrderBy(k => k.Length).ThenBy(k => k));
+            Assert.Equal(new[] { "level10", "level5" }, equations["ramp_5"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Contains("level10", equations["cycle_1"].Coefficients.Keys);
+            Assert.Equal(new[] { "level1" }, equations["start"].Coefficients.Keys);
+        }
+
+        [Fact]
+        public void Evaluate_ShouldFollowTheBoundaryPolicy()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            AssertNoErrors(parser.Parse("range R = 1..4;\n{string} Hours = {\"a\", \"b\", \"c\"};"));
+
+            Assert.Equal(4, parser.ParseExpression("prevc(R, 1)").Evaluate(manager));
+            Assert.Equal(4, parser.ParseExpression("next(R, 4, clamp)").Evaluate(manager));
+            Assert.Equal(1, parser.ParseExpression("prev(R, 3, 2)").Evaluate(manager));
+            Assert.Equal(1, parser.ParseExpression("ord(Hours, \"b\")").Evaluate(manager));
+            Assert.Equal("c", ((OrderedSetExpression)parser.ParseExpression("last(Hours)")).Value(manager));
+            Assert.Throws<InvalidOperationException>(() => parser.ParseExpression("next(R, 4)").Evaluate(manager));
+        }
+
+        [Fact]
+        public void TryParse_ShouldKeepThePolicyAndRejectUnknownArguments()
+        {
+            var parser = CreateParser();
+
+            Assert.Equal("prev(T, t, 2, initial = level0[p])", parser.ParseExpression("prev(T,t,2,initial=level0[p])").ToString());
+            Assert.False(OrderedSets.TryParse("next(R, 1, sideways)", parser.ParseExpression, out _, out string error));
+            Assert.Contains("boundary policy", error);
+        }
+    }
+}
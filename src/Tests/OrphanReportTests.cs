@@ -0,0 +1,98 @@
+using Core.Analysis;
+
+namespace Tests
+{
+    public class OrphanReportTests : TestBase
+    {
+        private const string Model =
+            "int n = 3;\n" +
+            "range I = 1..n;\n" +
+            "{int} Idle = {};\n" +
+            "float spare = 7;\n" +
+            "float oldCap = 5;\n" +
+            "dvar float+ x[I];\n" +
+            "dvar float+ slack;\n" +
+            "dvar float+ y;\n" +
+            "dexpr float total = sum(i in I) x[i];\n" +
+            "// @block legacy: disabled\n" +
+            "float legacyLimit = 4;\n" +
+            "dvar float+ z;\n" +
+            "legacyCap: z <= legacyLimit;\n" +
+            "// @endblock\n" +
+            "// @tags disabled\n" +
+            "cap: y <= oldCap;\n" +
+            "demand: sum(i in I) x[i] >= n;\n" +
+            "minimize total;\n";
+
+        [Fact]
+        public void Analyze_ShouldReportOrphansAndDisabledBlocks()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            AssertNoErrors(result);
+
+            var report = OrphanReport.Analyze(Model, manager);
+
+            Assert.Equal(
+                "6 orphans:" + Environment.NewLine +
+                "  line 3: set 'Idle' has no members" + Environment.NewLine +
+                "  line 4: parameter 'spare' is never referenced" + Environment.NewLine +
+                "  line 5: parameter 'oldCap' is never referenced (used only by constraint:cap)" + Environment.NewLine +
+                "  line 7: variable 'slack' does not appear in any active constraint or the objective" + Environment.NewLine +
+                "  line 8: variable 'y' does not appear in any active constraint or the objective (used only by constraint:cap)" + Environment.NewLine +
+                "  line 11: block 'legacy' is entirely disabled (3 declarations)" + Environment.NewLine,
+                report.ToReport());
+
+            Assert.Equal(new[] { "parameter:spare" }, report.Findings.Single(f => f.Name == "spare").Keys);
+            Assert.False(report.Findings.Single(f => f.Name == "y").CanRemove);
+            Assert.False(report.Findings.Single(f => f.Kind == OrphanKind.EmptySet).CanRemove);
+            Assert.DoesNotContain(report.Findings, f => f.Name is "x" or "total" or "z" or "legacyLimit");
+        }
+
+        [Fact]
+        public void CreateCleanup_ShouldRemoveDeclarationsAndEmptiedBlocks()
+        {
+            var report = OrphanReport.Analyze(Model);
+            var cleanup = report.CreateCleanup();
+
+            Assert.Equal("Remove 5 orphaned declarations", cleanup.Title);
+            string cleaned = cleanup.Apply(Model);
+
+            Assert.Equal(
+                "int n = 3;\n" +
+                "range I = 1..n;\n" +
+                "{int} Idle = {};\n" +
+                "float oldCap = 5;\n" +
+                "dvar float+ x[I];\n" +
+                "dvar float+ y;\n" +
+                "dexpr float total = sum(i in I) x[i];\n" +
+                "// @tags disabled\n" +
+                "cap: y <= oldCap;\n" +
+                "demand: sum(i in I) x[i] >= n;\n" +
+                "minimize total;\n",
+                cleaned);
+            Assert.Empty(ModelTags.Parse(cleaned).Warnings);
+            Assert.DoesNotContain(OrphanReport.Analyze(cleaned).Findings, f => f.CanRemove);
+        }
+
+        [Fact]
+        public void DisabledBlock_StillUsedOutside_ShouldNotBeRemoved()
+        {
+            const string text =
+                "// @block spare\n" +
+                "// @tags disabled\n" +
+                "float limit = 4;\n" +
+                "// @endblock\n" +
+                "dvar float+ x;\n" +
+                "c1: x <= limit;\n" +
+                "minimize x;\n";
+
+            var finding = Assert.Single(OrphanReport.Analyze(text).Findings);
+
+            Assert.Equal("line 3: block 'spare' is entirely disabled (1 declaration); still used by constraint:c1", finding.ToString());
+            Assert.False(finding.CanRemove);
+            Assert.Empty(OrphanReport.Analyze(text).CreateCleanup().Edits);
+        }
+    }
+}
@@ -0,0 +1,107 @@
+using Core;
+using Core.Generation;
+using Core.Import;
+
+namespace Tests
+{
+    public class ParserFuzzTests : TestBase
+    {
+        /// <summary>
+        /// Default corpus plus the sample models and data files of the repository, when found
+        /// </summary>
+        private static List<string> Corpus(string target)
+        {
+            var corpus = ParserFuzzer.DefaultCorpus(target);
+            string extension = target switch { "model" => "*.mod", "data" => "*.dat", _ => "" };
+            if (extension == "")
+                return corpus;
+
+            for (var dir = new DirectoryInfo(AppContext.BaseDirectory); dir != null; dir = dir.Parent)
+            {
+                var data = new DirectoryInfo(Path.Combine(dir.FullName, "Data"));
+                if (data.Exists && data.GetFiles("*.mod").Length > 0)
+                {
+                    corpus.AddRange(data.GetFiles(extension).Select(f => File.ReadAllText(f.FullName)));
+                    break;
+                }
+            }
+            return corpus;
+        }
+
+        [Theory]
+        [InlineData("lp")]
+        [InlineData("mof")]
+        [InlineData("model")]
+        [InlineData("data")]
+        public void Fuzz_ShouldNotFindCrashesOrHangs(string target)
+        {
+            var fuzzer = new ParserFuzzer(seed: 1) { Iterations = 150 };
+
+            var findings = fuzzer.Run(target, Corpus(target));
+
+            Assert.True(findings.Count == 0, string.Join("\n", findings));
+        }
+
+        [Fact]
+        public void Run_SameSeed_ShouldGiveSameFindings()
+        {
+            var corpus = ParserFuzzer.DefaultCorpus("lp");
+
+            var first = new ParserFuzzer(seed: 3) { Iterations = 50 }.Run("lp", corpus);
+            var second = new ParserFuzzer(seed: 3) { Iterations = 50 }.Run("lp", corpus);
+
+            Assert.Equal(first.Select(f => f.Input), second.Select(f => f.Input));
+            Assert.Throws<InvalidOperationException>(() => new ParserFuzzer().Run("mps", corpus));
+        }
+
+        [Fact]
+        public void LpImport_UnexpectedToken_ShouldThrowInsteadOfLooping()
+        {
+            var ex = Assert.Throws<InvalidOperationException>(() =>
+                new LpImporter().Import("Minimize\n obj: x + * y\nSubject To\n c1: x >= 1\nEnd\n"));
+
+            Assert.Contains("unexpected '*'", ex.Message);
+        }
+
+        [Theory]
+        [InlineData("{\"variables\": [{\"name\": \"x\"}], \"constraints\": [{\"name\": \"c\"}]}", "missing 'function'")]
+        [InlineData("{\"variables\": [{\"name\": \"x\"}], \"objective\": {\"sense\": \"min\", \"function\": {\"type\": \"ScalarAffineFunction\", \"terms\": [], \"constant\": \"1\"}}}", "not a number")]
+        public void MofImport_MalformedDocument_ShouldThrowInvalidOperation(string json, string expected)
+        {
+            var ex = Assert.Throws<InvalidOperationException>(() => new MofImporter().Import(json));
+
+            Assert.Contains(expected, ex.Message);
+        }
+
+        [Fact]
+        public void Expand_ForallBeyondExpansionLimit_ShouldReportErrorWithoutExpanding()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(
+                "range I = 1..2000000000;\n" +
+                "dvar float+ x[I];\n" +
+                "minimize x[1];\n" +
+                "forall(i in I) lo: x[i] >= 1;\n");
+            AssertNoErrors(result);
+
+            parser.ExpandAllTemplates(result);
+
+            AssertHasError(result, "more than 1000000 constraints");
+            Assert.Empty(manager.Equations);
+        }
+
+        [Fact]
+        public void Parse_SumBeyondExpansionLimit_ShouldReportError()
+        {
+            var manager = CreateModelManager();
+            manager.ExpansionLimit = 10;
+            var result = CreateParser(manager).Parse(
+                "range I = 1..20;\n" +
+                "dvar float+ x[I];\n" +
+                "c1: sum(i in I) x[i] <= 5;\n");
+
+            AssertHasError(result, "more than 10 terms");
+        }
+    }
+}
@@ -0,0 +1,76 @@
+using Core;
+using Core.Analysis;
+
+namespace Tests
+{
+    public class PiecewiseLinearizerTests : TestBase
+    {
+        [Fact]
+        public void Approximate_ShouldReportTheMaximumErrorOfEachStrategy()
+        {
+            var uniform = new PiecewiseLinearizer { Segments = 4 }.Approximate("x ^ 2", "x", 0, 4);
+            Assert.Equal(new[] { 0.0, 1, 2, 3, 4 }, uniform.Breakpoints.Select(b => b.X));
+            Assert.Equal(0.25, uniform.MaxError, 6);
+            Assert.Equal(0.5, uniform.MaxErrorAt, 3);
+            Assert.Equal("x ^ 2 on [0, 4]: 4 segments (Uniform), max error 0.25 at x = 0.5", uniform.ToString());
+
+            var even = new PiecewiseLinearizer { Segments = 4 }.Approximate("exp(x)", "x", 0, 4);
+            var adaptive = new PiecewiseLinearizer { Segments = 4, Strategy = BreakpointStrategy.CurvatureAdaptive }.Approximate("exp(x)", "x", 0, 4);
+            Assert.Equal(4, adaptive.Segments);
+            Assert.True(adaptive.MaxError < even.MaxError / 2);
+            Assert.True(adaptive.Breakpoints[2].X > 2);
+        }
+
+        [Fact]
+        public void Approximate_ErrorBounded_ShouldStayWithinTheTolerance()
+        {
+            var linearizer = new PiecewiseLinearizer { Strategy = BreakpointStrategy.ErrorBounded, Tolerance = 0.05 };
+
+            var exp = linearizer.Approximate("exp(x)", "x", 0, 4);
+            Assert.True(exp.MaxError <= 0.05);
+            Assert.Equal(Math.Exp(2.5), exp.Value(2.5), 1);
+
+            var kink = linearizer.Approximate("abs(x - 1)", "x", 0, 4);
+            Assert.Equal(new[] { 0.0, 1, 4 }, kink.Breakpoints.Select(b => b.X));
+            Assert.Equal(0, kink.MaxError, 9);
+
+            var line = linearizer.Approximate(x => 2 * x + 1, 0, 4);
+            Assert.Equal(1, line.Segments);
+        }
+
+        [Fact]
+        public void Apply_ShouldReplaceTheFunctionWithALambdaFormulation()
+        {
+            string model =
+                "float cap = 4;\n" +
+                "dvar float x in 0..cap;\n" +
+                "dvar float+ y;\n" +
+                "minimize exp(x) + y;\n" +
+                "c: x + y >= 1;\n" +
+                "limit: exp( x ) <= 30;\n";
+
+            var result = new PiecewiseLinearizer { Segments = 3 }.Apply(model, "exp(x)");
+
+            Assert.Empty(result.Warnings);
+            Assert.Equal("exp_x", result.Auxiliary);
+            Assert.Equal(new[] { "objective", "constraint:limit" }, result.Replaced);
+            Assert.Contains("minimize exp_x + y;", result.ModelText);
+            Assert.Contains("limit: exp_x <= 30;", result.ModelText);
+            Assert.Contains("// @generated piecewise from exp(x)\ndvar bool exp_x_seg3;", result.ModelText.Replace("\r\n", "\n"));
+            Assert.Contains("exp_x_def1: exp_x_w1 + exp_x_w2 + exp_x_w3 + exp_x_w4 == 1;", result.ModelText);
+            Assert.Contains("exp_x_def2: x == 1.33333333333 * exp_x_w2 + 2.66666666667 * exp_x_w3 + 4 * exp_x_w4;", result.ModelText);
+            Assert.Contains("exp_x_def6: exp_x_w2 <= exp_x_seg1 + exp_x_seg2;", result.ModelText);
+            Assert.Empty(CreateParser().Parse(result.ModelText).Errors);
+        }
+
+        [Fact]
+        public void Apply_ShouldRequireABoundedArgument()
+        {
+            string model = "dvar float x;\nminimize log(x);\n";
+
+            var error = Assert.Throws<InvalidOperationException>(() => new PiecewiseLinearizer().Apply(model, "log(x)"));
+
+            Assert.Equal("'x' needs numeric bounds to approximate 'log(x)' (declare it 'in lo..hi')", error.Message);
+        }
+    }
+}
@@ -309,5 +309,42 @@ namespace Tests
             Assert.Contains("external", str);
             Assert.Contains("not loaded", str);
         }
+
+        [Fact]
+        public void Parse_TimestampSet_ShouldKeepChronologicalOrder()
+        {
+            // Arrange
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            string input = @"{timestamp} Hours = {""2024-01-01T08:00"", ""2024-01-01T06:00"", ""2024-01-01T07:00""};";
+
+            // Act
+            var result = parser.Parse(input);
+
+            // Assert
+            AssertNoErrors(result);
+            var set = manager.PrimitiveSets["Hours"];
+            Assert.Equal(PrimitiveSetType.Timestamp, set.Type);
+            Assert.Equal(new object[] { "2024-01-01T06:00:00", "2024-01-01T07:00:00", "2024-01-01T08:00:00" }, set.GetAllValues());
+            Assert.True(set.Contains("2024-01-01T07:00"));
+            AssertHasError(parser.Parse(@"{timestamp} Bad = {""tomorrow""};"), "Invalid timestamp value");
+        }
+
+        [Fact]
+        public void Shift_ShouldFollowTheOrderOfTheSet()
+        {
+            // Arrange
+            var set = new PrimitiveSet("periods", PrimitiveSetType.Int);
+            foreach (int period in new[] { 1, 2, 5, 10 })
+                set.Add(period);
+
+            // Act & Assert
+            Assert.Equal(3, set.Ord(5));
+            Assert.Equal(0, set.Ord(3));
+            Assert.Equal(2, set.Shift(5, -1));
+            Assert.Equal(10, set.Shift(5, 1));
+            Assert.Null(set.Shift(1, -1));
+            Assert.Null(set.Shift(3, 1));
+        }
     }
-}
\ No newline at end of file
+}
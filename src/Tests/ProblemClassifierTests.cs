@@ -0,0 +1,110 @@
+using Core;
+using Core.Analysis;
+using Core.Models;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ProblemClassifierTests : TestBase
+    {
+        private class StubDriver : ISolverDriver
+        {
+            public StubDriver(string name, SolverCapabilities capabilities)
+            {
+                Name = name;
+                Capabilities = capabilities;
+            }
+
+            public string Name { get; }
+            public SolverCapabilities Capabilities { get; }
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult { Status = SolveStatus.Optimal };
+        }
+
+        private static readonly ISolverDriver[] Solvers =
+        {
+            new StubDriver("MIP", SolverCapabilities.Continuous | SolverCapabilities.Integer),
+            new StubDriver("QP", SolverCapabilities.Continuous | SolverCapabilities.Integer | SolverCapabilities.Quadratic),
+            new StubDriver("Global", SolverCapabilities.Continuous | SolverCapabilities.Integer | SolverCapabilities.Quadratic |
+                                     SolverCapabilities.Nonlinear | SolverCapabilities.Nonconvex)
+        };
+
+        [Fact]
+        public void Classify_ShouldFindConvexQuadraticObjectiveFromTheSource()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(
+                "range I = 1..3;\n" +
+                "dvar float x in 0..10;\n" +
+                "dvar float y in 0..10;\n" +
+                "dvar float+ z[I];\n" +
+                "dvar bool open;\n" +
+                "minimize x^2 + 2*x*y + 3*y^2 + sum(i in I) z[i]*z[i] - x;\n" +
+                "cap: x + y <= 8;\n" +
+                "forall(i in I) link: z[i] <= 5 * open;\n"));
+
+            var classification = manager.Classify(Solvers);
+
+            Assert.Equal(ProblemClass.MIQP, classification.Class);
+            Assert.Equal(Convexity.Convex, classification.Convexity);
+            Assert.Equal("objective: quadratic, convex", classification.Entities.Single().ToString());
+            Assert.Equal(new[] { "QP", "Global" }, classification.Solvers);
+            Assert.Equal("MIQP, convex objective, convex constraints; solvers: QP, Global", classification.ToString());
+        }
+
+        [Fact]
+        public void Classify_ShouldDecideNonlinearConvexityByCompositionRules()
+        {
+            var classification = ProblemClassifier.Classify(
+                "dvar float x in 1..10;\n" +
+                "dvar float y in 1..10;\n" +
+                "maximize log(x) + sqrt(y) - 2 * abs(x - y);\n" +
+                "ball: x*x + y*y <= 25;\n" +
+                "curve: exp(x) - y <= 100;\n" +
+                "hyper: x * y >= 2;\n" +
+                "eq: x^2 == y;\n" +
+                "odd: sin(x) <= 0.5;\n" +
+                "x + y >= 3;\n", Solvers);
+
+            Assert.Equal(ProblemClass.NLP, classification.Class);
+            Assert.Equal(Convexity.Convex, classification.Objective);
+            Assert.Equal(Convexity.Nonconvex, classification.Constraints);
+            Assert.Equal(new[]
+            {
+                "objective: nonlinear, convex",
+                "constraint:ball: quadratic, convex",
+                "constraint:curve: nonlinear, convex",
+                "constraint:hyper: quadratic, nonconvex (indefinite quadratic)",
+                "constraint:eq: quadratic, nonconvex (nonlinear equality)",
+                "constraint:odd: nonlinear, unknown (sin)"
+            }, classification.Entities.Select(e => e.ToString()));
+            Assert.True(classification.Required.HasFlag(SolverCapabilities.Nonlinear | SolverCapabilities.Nonconvex));
+            Assert.Equal(new[] { "Global" }, classification.Solvers);
+        }
+
+        [Fact]
+        public void Classify_ShouldLeaveConvexityUnknownWhenItCannotDecide()
+        {
+            var classification = ProblemClassifier.Classify(
+                "range I = 1..3;\n" +
+                "float cost[I] = [1, 2, 3];\n" +
+                "dvar float x[I];\n" +
+                "dvar int n in 0..5;\n" +
+                "minimize sum(i in I) cost[i] * x[i]^2;\n" +
+                "forall(i in I, j in I) pair: x[i] * x[j] <= 4;\n" +
+                "spread: -x[1]^2 >= -9;\n" +
+                "concave: x[1] * x[1] >= n;\n");
+
+            Assert.Equal(ProblemClass.MIQCP, classification.Class);
+            Assert.Equal(Convexity.Nonconvex, classification.Convexity);
+            Assert.Equal(new[]
+            {
+                "objective: quadratic, unknown (coefficient of unknown sign)",
+                "constraint:pair: quadratic, unknown (curvature unknown)",
+                "constraint:spread: quadratic, convex",
+                "constraint:concave: quadratic, nonconvex (left side minus right side is convex)"
+            }, classification.Entities.Select(e => e.ToString()));
+            Assert.Null(classification.Solvers);
+        }
+    }
+}
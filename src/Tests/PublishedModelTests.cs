@@ -0,0 +1,87 @@
+using Core;
+using Core.Analysis;
+using Core.Server;
+using Core.Solving;
+
+namespace Tests
+{
+    public class PublishedModelTests : IDisposable
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "published-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private class RecordingDriver : ISolverDriver
+        {
+            public ModelManager? Solved { get; private set; }
+            public string Name => "Recording";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Solved = manager;
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 75 };
+            }
+        }
+
+        [Fact]
+        public async Task PublishVersionAsync_ShouldFreezeVersionIndependentOfLaterEdits()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model);
+
+            var published = await host.PublishVersionAsync(model.Id);
+            Assert.Equal(64, published.Id.Length);
+            Assert.Equal(model.Version, published.Version);
+            Assert.Same(published, await host.PublishVersionAsync(model.Id));
+
+            host.ApplyEntity(model.Id, new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = "capacity", Value = "30" });
+            var next = await host.PublishVersionAsync(model.Id);
+            Assert.NotEqual(published.Id, next.Id);
+            Assert.Equal(new[] { published.Id, next.Id }, host.GetPublished(model.Id).Select(p => p.Id));
+
+            var driver = new RecordingDriver();
+            var result = await host.SolvePublishedAsync(published.Id, driver);
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(25, Convert.ToDouble(driver.Solved!.Parameters["capacity"].Value));
+            Assert.Contains("float capacity = 30;", model.ModelText);
+        }
+
+        [Fact]
+        public async Task PublishVersionAsync_ShouldRefuseVersionsWithErrors()
+        {
+            var host = new ModelHost();
+            var model = host.Create("network", Model + "forall(n in Nodes) bad: flow[n] <= ;\n");
+
+            await Assert.ThrowsAsync<InvalidOperationException>(() => host.PublishVersionAsync(model.Id));
+            await Assert.ThrowsAsync<InvalidOperationException>(() => host.PublishVersionAsync(model.Id, expectedVersion: model.Version + 1));
+            Assert.Empty(host.GetPublished(model.Id));
+        }
+
+        [Fact]
+        public async Task Store_ShouldReloadArtifactsAndRejectTamperedOnes()
+        {
+            var host = new ModelHost { Publications = new PublishedModelStore(directory) };
+            var model = host.Create("network", Model);
+            var published = await host.PublishVersionAsync(model.Id);
+
+            var reloaded = new PublishedModelStore(directory).Find(published.Id)!;
+            Assert.Equal(published.ModelText, reloaded.ModelText);
+            Assert.Equal(published.Fingerprint, reloaded.Fingerprint);
+
+            string path = Path.Combine(directory, published.Id + ".json");
+            File.WriteAllText(path, File.ReadAllText(path).Replace("capacity = 25", "capacity = 99"));
+            Assert.Throws<InvalidOperationException>(() => new PublishedModelStore(directory));
+        }
+    }
+}
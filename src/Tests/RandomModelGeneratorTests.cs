@@ -0,0 +1,132 @@
+using Core.Export;
+using Core.Generation;
+using Core.Import;
+using Core.Models;
+
+namespace Tests
+{
+    public class RandomModelGeneratorTests : TestBase
+    {
+        private static bool IsSatisfied(LinearConstraint constraint, Dictionary<string, double> point)
+        {
+            double activity = constraint.Coefficients.Sum(c => c.Value * point[c.Key]);
+            return constraint.Operator switch
+            {
+                RelationalOperator.LessThanOrEqual => NumericTolerance.Default.IsLessOrEqual(activity, constraint.Rhs),
+                RelationalOperator.GreaterThanOrEqual => NumericTolerance.Default.IsGreaterOrEqual(activity, constraint.Rhs),
+                _ => NumericTolerance.Default.AreEqual(activity, constraint.Rhs)
+            };
+        }
+
+        [Fact]
+        public void Generate_SameSeed_ShouldGiveSameModel()
+        {
+            var options = new GeneratorOptions { Rows = 8, Columns = 12, Seed = 42, IntegerFraction = 0.25 };
+
+            string first = new RandomModelGenerator(options).Generate().Model.ToModelText();
+            string second = new RandomModelGenerator(options).Generate().Model.ToModelText();
+            string other = new RandomModelGenerator(new GeneratorOptions { Rows = 8, Columns = 12, Seed = 43, IntegerFraction = 0.25 })
+                .Generate().Model.ToModelText();
+
+            Assert.Equal(first, second);
+            Assert.NotEqual(first, other);
+        }
+
+        [Theory]
+        [InlineData(ModelStructure.Random)]
+        [InlineData(ModelStructure.BlockAngular)]
+        [InlineData(ModelStructure.Staircase)]
+        public void Generate_FeasiblePoint_ShouldSatisfyEveryRowAndBound(ModelStructure structure)
+        {
+            var generated = new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = 30, Columns = 40, Structure = structure, Blocks = 3, Seed = 7,
+                IntegerFraction = 0.2, BinaryFraction = 0.1, EqualityFraction = 0.2
+            }).Generate();
+            var model = generated.Model;
+
+            Assert.Equal(30, model.Constraints.Count);
+            Assert.Equal(40, model.Variables.Count);
+            Assert.Equal(8, model.Variables.Count(v => v.Type == VariableType.Integer));
+            Assert.Equal(4, model.Variables.Count(v => v.Type == VariableType.Boolean));
+            Assert.All(model.Constraints, c => Assert.True(IsSatisfied(c, generated.FeasiblePoint), c.Name));
+            Assert.All(model.Variables, v =>
+            {
+                double value = generated.FeasiblePoint[v.Name];
+                Assert.InRange(value, v.LowerBound!.Value, v.UpperBound!.Value);
+                if (v.Type != VariableType.Float)
+                    Assert.Equal(Math.Round(value), value);
+            });
+        }
+
+        [Fact]
+        public void Generate_BlockAngular_ShouldOnlyLinkBlocksThroughLinkingRows()
+        {
+            var model = new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = 12, Columns = 12, Structure = ModelStructure.BlockAngular, Blocks = 3, Density = 0.8, Seed = 1
+            }).Generate().Model;
+
+            int Block(string column) => (int.Parse(column.Substring(1)) - 1) / 4;
+
+            var blockRows = model.Constraints.Where(c => c.Name.StartsWith("b")).ToList();
+            Assert.Equal(3, model.Constraints.Count(c => c.Name.StartsWith("link")));
+            Assert.All(blockRows, c => Assert.Single(c.Coefficients.Keys.Select(Block).Distinct()));
+            Assert.All(blockRows, c => Assert.Equal(int.Parse(c.Name.Substring(1, 1)) - 1, Block(c.Coefficients.Keys.First())));
+        }
+
+        [Fact]
+        public void Generate_Staircase_ShouldUseCurrentAndNextBlock()
+        {
+            var model = new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = 9, Columns = 9, Structure = ModelStructure.Staircase, Blocks = 3, Density = 1, Seed = 3
+            }).Generate().Model;
+
+            var first = model.Constraints.First(c => c.Name.StartsWith("b1_"));
+            var last = model.Constraints.First(c => c.Name.StartsWith("b3_"));
+            Assert.Equal(new[] { "x1", "x2", "x3", "x4", "x5", "x6" }, first.Coefficients.Keys.OrderBy(k => k.Length).ThenBy(k => k));
+            Assert.Equal(new[] { "x7", "x8", "x9" }, last.Coefficients.Keys.OrderBy(k => k));
+        }
+
+        [Theory]
+        [InlineData(ModelStructure.Random, 1)]
+        [InlineData(ModelStructure.BlockAngular, 2)]
+        [InlineData(ModelStructure.Staircase, 3)]
+        public void Generate_ModelText_ShouldParseBackToTheSameRows(ModelStructure structure, int seed)
+        {
+            var generated = new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = 15, Columns = 20, Structure = structure, Blocks = 2, Seed = seed, IntegerFraction = 0.3
+            }).Generate().Model;
+
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(generated.ToModelText());
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            var parsed = LinearModel.FromModel(manager, ExportOrdering.CreationOrder);
+
+            Assert.Equal(generated.Variables.Count, parsed.Variables.Count);
+            Assert.Equal(generated.Constraints.Count, parsed.Constraints.Count);
+            for (int i = 0; i < generated.Constraints.Count; i++)
+            {
+                Assert.Equal(generated.Constraints[i].Name, parsed.Constraints[i].Name);
+                Assert.Equal(generated.Constraints[i].Rhs, parsed.Constraints[i].Rhs, 9);
+                Assert.Equal(generated.Constraints[i].Coefficients.OrderBy(c => c.Key), parsed.Constraints[i].Coefficients.OrderBy(c => c.Key));
+            }
+        }
+
+        [Theory]
+        [InlineData(0, 5, 0.5, 2)]
+        [InlineData(5, 5, 0, 2)]
+        [InlineData(5, 5, 0.5, 9)]
+        public void Constructor_InvalidOptions_ShouldThrow(int rows, int columns, double density, int blocks)
+        {
+            Assert.Throws<InvalidOperationException>(() => new RandomModelGenerator(new GeneratorOptions
+            {
+                Rows = rows, Columns = columns, Density = density, Structure = ModelStructure.Staircase, Blocks = blocks
+            }));
+        }
+    }
+}
@@ -0,0 +1,113 @@
+using System.Text;
+using Core.Storage;
+
+namespace Tests
+{
+    public class ReferenceIndexTests : IDisposable
+    {
+        private const string ModelText =
+            "// cost per unit, see cost sheet\n" +
+            "int n = 3;\n" +
+            "range I = 1..n;\n" +
+            "tuple Route { int cost; }\n" +
+            "float cost[I] = ...;\n" +
+            "Route routes[I] = ...;\n" +
+            "dvar float+ x[I];\n" +
+            "/* total\n   cost */\n" +
+            "minimize sum(i in I) (cost[i] + routes[i].cost) * x[i];\n";
+
+        private static readonly ModelPackageOptions smallChunks = new ModelPackageOptions
+        {
+            InlineLimit = 256,
+            MinChunkSize = 1024,
+            AverageChunkSize = 4096,
+            MaxChunkSize = 16384
+        };
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "referenceindex-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private static string Data()
+        {
+            var sb = new StringBuilder("cost = [");
+            for (int i = 0; i < 5000; i++)
+                sb.Append(1000 + i).Append(i < 4999 ? ", " : "];\n");
+            return "routes = [<1>, <2>, <3>];\n" + sb;
+        }
+
+        [Fact]
+        public void FindReferences_ShouldSkipCommentsAndTupleFields()
+        {
+            var index = ReferenceIndex.Build(ModelText, "cost = [1, 2, 3];\n");
+
+            var cost = index.FindReferences("cost");
+            Assert.Equal(
+                new[] { "model:5:7 Declaration in parameter:cost", "model:10:23 Use in objective", "data:1:1 Data in data:cost" },
+                cost.Select(s => s.ToString()));
+            Assert.Equal(new[] { "model:10:23 Use in objective" }, index.FindDeletionBlockers("cost").Select(s => s.ToString()));
+
+            // The end of a range is a use, the iterator has no declaration
+            Assert.Contains(index.FindReferences("n"), s => s.Statement == "set:I" && s.Kind == ReferenceKind.Use);
+            Assert.All(index.FindReferences("i"), s => Assert.Equal(ReferenceKind.Use, s.Kind));
+            Assert.Empty(index.FindDeletionBlockers("x").Where(s => s.Statement != "objective"));
+            Assert.Empty(index.FindReferences("sum"));
+            Assert.Equal(new[] { "n", "I", "cost", "x" }, index.DeclaredSymbols);
+        }
+
+        [Fact]
+        public void References_ShouldComeFromSavedIndexAndRescanOnlyEdits()
+        {
+            ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            Assert.True(File.Exists(Path.Combine(directory, ReferenceIndex.FileName)));
+
+            var document = ModelPackage.Open(directory);
+            var references = document.References;
+            Assert.Equal(0, references.LastScanned);
+            Assert.Equal(0, document.LoadedSections);
+            Assert.Equal(ReferenceKind.Data, references.FindReferences("cost").Last().Kind);
+
+            Assert.True(document.Upsert("dvar float+ x[I] in 0..n"));
+            var updated = document.References;
+            Assert.Equal(1, updated.LastScanned);
+            Assert.Contains(updated.FindReferences("n"), s => s.Statement == "variable:x");
+            Assert.Equal(0, document.LoadedSections);
+
+            document.Save(smallChunks);
+            Assert.Equal(0, ModelPackage.Open(directory).References.LastScanned);
+        }
+
+        [Fact]
+        public void Rename_ShouldRewriteOnlySectionsThatUseTheSymbol()
+        {
+            ModelPackage.Save(directory, "transport", ModelText, Data(), smallChunks);
+            var document = ModelPackage.Open(directory);
+
+            Assert.Equal(2, document.Rename("x", "flow"));
+            Assert.False(document.IsLoaded("data:cost"));
+            Assert.Contains("variable:flow", document.GetKeys());
+
+            // Only now is the large data section read
+            Assert.Equal(3, document.Rename("cost", "unitCost"));
+            Assert.True(document.IsLoaded("data:unitCost"));
+            Assert.Contains("parameter:unitCost", document.GetKeys());
+            Assert.Contains("data:unitCost", document.GetKeys("data"));
+
+            var taken = Assert.Throws<InvalidOperationException>(() => document.Rename("unitCost", "n"));
+            Assert.Equal("Cannot rename 'unitCost': 'n' is already used in the model", taken.Message);
+            Assert.Throws<InvalidOperationException>(() => document.Rename("unitCost", "2cost"));
+
+            document.Save(smallChunks);
+            var (_, modelText, dataText) = ModelPackage.Load(directory);
+            Assert.Equal(
+                ModelText.Replace("x[", "flow[").Replace("float cost[", "float unitCost[").Replace("(cost[", "(unitCost["),
+                modelText);
+            Assert.Equal(Data().Replace("cost = [", "unitCost = ["), dataText);
+            Assert.Empty(ModelPackage.Open(directory).References.FindReferences("cost"));
+        }
+    }
+}
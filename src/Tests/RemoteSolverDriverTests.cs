@@ -0,0 +1,188 @@
+using System.Net;
+using System.Text;
+using Core;
+using Core.Solving;
+
+namespace Tests
+{
+    public class RemoteSolverDriverTests : TestBase
+    {
+        /// <summary>
+        /// Minimal solve server: keeps uploads per job, fails the first chunk halfway once,
+        /// and replays a scripted log before reporting the job completed
+        /// </summary>
+        private class FakeSolveServer : HttpMessageHandler
+        {
+            private readonly Queue<string> logChunks = new Queue<string>(new[] { "Presolve done\nNode 1", "0 gap 0.5%\n", "Optimal\n" });
+            private readonly StringBuilder log = new StringBuilder();
+
+            public Dictionary<string, List<byte>> Uploads { get; } = new Dictionary<string, List<byte>>();
+            public List<HttpRequestMessage> Requests { get; } = new List<HttpRequestMessage>();
+            public bool FailFirstChunk { get; set; } = true;
+            public HttpStatusCode? Reject { get; set; }
+            public string? StartBody { get; private set; }
+
+            protected override async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken cancellationToken)
+            {
+                Requests.Add(request);
+                if (Reject.HasValue)
+                    return new HttpResponseMessage(Reject.Value) { Content = new StringContent("no token") };
+
+                var segments = request.RequestUri!.AbsolutePath.Trim('/').Split('/');
+                string job = segments[2];
+                string action = segments.Length > 3 ? segments[3] : "";
+
+                if (request.Method == HttpMethod.Head)
+                {
+                    if (!Uploads.TryGetValue(job, out var received))
+                        return new HttpResponseMessage(HttpStatusCode.NotFound);
+                    var response = new HttpResponseMessage(HttpStatusCode.OK);
+                    response.Headers.Add("Upload-Offset", received.Count.ToString());
+                    return response;
+                }
+                if (request.Method == HttpMethod.Patch)
+                {
+                    long offset = long.Parse(request.Headers.GetValues("Upload-Offset").Single());
+                    byte[] chunk = await request.Content!.ReadAsByteArrayAsync(cancellationToken);
+                    var received = Uploads.TryGetValue(job, out var existing) ? existing : Uploads[job] = new List<byte>();
+                    Assert.Equal(received.Count, offset);
+                    if (FailFirstChunk)
+                    {
+                        FailFirstChunk = false;
+                        received.AddRange(chunk.Take(chunk.Length / 2));
+                        return new HttpResponseMessage(HttpStatusCode.ServiceUnavailable);
+                    }
+                    received.AddRange(chunk);
+                    return new HttpResponseMessage(HttpStatusCode.NoContent);
+                }
+                if (request.Method == HttpMethod.Post && action == "start")
+                {
+                    StartBody = await request.Content!.ReadAsStringAsync(cancellationToken);
+                    return new HttpResponseMessage(HttpStatusCode.Accepted);
+                }
+                if (action == "log")
+                {
+                    long offset = long.Parse(request.RequestUri.Query.Split('=')[1]);
+                    if (logChunks.Count > 0)
+                        log.Append(logChunks.Dequeue());
+                    var response = new HttpResponseMessage(HttpStatusCode.OK) { Content = new StringContent(log.ToString().Substring((int)offset)) };
+                    response.Headers.Add("Job-State", logChunks.Count > 0 ? "running" : "completed");
+                    return response;
+                }
+                if (action == "result")
+                {
+                    return new HttpResponseMessage(HttpStatusCode.OK)
+                    {
+                        Content = new StringContent("{\"status\":\"Optimal\",\"objectiveValue\":5,\"variableValues\":{\"x\":5},\"statusMessage\":\"CPLEX status 101\"}")
+                    };
+                }
+                return new HttpResponseMessage(HttpStatusCode.NotFound);
+            }
+        }
+
+        private ModelManager Expand() => ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 5;\n");
+
+        private static RemoteSolverDriver CreateDriver(FakeSolveServer server) =>
+            new RemoteSolverDriver(new HttpSolveTransport(new Uri("http://solver:8080/api"), new HttpClient(server))
+            {
+                Authenticate = (request, _) =>
+                {
+                    request.Headers.TryAddWithoutValidation("Authorization", "Bearer secret");
+                    return Task.CompletedTask;
+                }
+            })
+            {
+                ChunkSize = 64,
+                RetryDelay = TimeSpan.Zero,
+                PollInterval = TimeSpan.Zero,
+                TimeLimit = TimeSpan.FromMinutes(5)
+            };
+
+        [Fact]
+        public async Task SolveAsync_ShouldResumeUploadStreamLogAndReturnSolution()
+        {
+            var server = new FakeSolveServer();
+            var driver = CreateDriver(server);
+            var lines = new List<string>();
+            driver.LogReceived += lines.Add;
+            var manager = Expand();
+
+            var result = await driver.SolveAsync(manager);
+
+            Assert.Equal(SolveStatus.Optimal, result.Status);
+            Assert.Equal(5, result.VariableValues["x"]);
+            Assert.Equal("http://solver:8080/api: CPLEX status 101", result.StatusMessage);
+
+            var (job, upload) = server.Uploads.Single();
+            Assert.Equal(SolveCache.KeyOf(manager, driver), job);
+            Assert.Equal(new Core.Export.MofExporter(manager).Export(), Encoding.UTF8.GetString(upload.ToArray()));
+            Assert.Contains("\"timeLimitSeconds\":\"300\"", server.StartBody);
+            Assert.Equal(new[] { "Presolve done", "Node 10 gap 0.5%", "Optimal" }, lines);
+            Assert.All(server.Requests, r => Assert.Equal("Bearer secret", r.Headers.Authorization!.ToString()));
+            Assert.All(server.Requests, r => Assert.StartsWith("/api/jobs/", r.RequestUri!.AbsolutePath));
+        }
+
+        [Fact]
+        public async Task SolveAsync_Unauthorized_ShouldFailWithoutRetrying()
+        {
+            var server = new FakeSolveServer { Reject = HttpStatusCode.Unauthorized };
+
+            var result = await CreateDriver(server).SolveAsync(Expand());
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.Contains("401 Unauthorized no token", result.StatusMessage);
+            Assert.Single(server.Requests);
+        }
+
+        [Fact]
+        public async Task SolveAsync_ServerDown_ShouldGiveUpAfterRetries()
+        {
+            var server = new FakeSolveServer { Reject = HttpStatusCode.BadGateway };
+            var driver = CreateDriver(server);
+            driver.MaxRetries = 2;
+
+            var result = await driver.SolveAsync(Expand());
+
+            Assert.Equal(SolveStatus.Error, result.Status);
+            Assert.StartsWith("http://solver:8080/api did not respond after 3 attempts", result.StatusMessage);
+            Assert.Equal(3, server.Requests.Count);
+        }
+
+        private class ScriptedSsh : SshSolveTransport
+        {
+            public ScriptedSsh() : base("big-box", "/scratch/jobs/", "modeledit-solve") { }
+
+            public List<string> Commands { get; } = new List<string>();
+            public string Output { get; set; } = "";
+
+            protected override Task<string> RunAsync(string remoteCommand, ReadOnlyMemory<byte>? input, CancellationToken cancellationToken)
+            {
+                Commands.Add(remoteCommand);
+                return Task.FromResult(Output);
+            }
+        }
+
+        [Fact]
+        public async Task SshTransport_ShouldQuoteCommandsAndReadJobState()
+        {
+            var ssh = new ScriptedSsh();
+
+            await ssh.StartAsync("job1", "cp-sat", new Dictionary<string, string> { ["timeLimitSeconds"] = "60" }, CancellationToken.None);
+            ssh.Output = "running\nline one\n";
+            var running = await ssh.PollAsync("job1", 10, CancellationToken.None);
+            ssh.Output = "3\n";
+            var failed = await ssh.PollAsync("job1", running.LogOffset, CancellationToken.None);
+
+            Assert.Equal(
+                "cd '/scratch/jobs/job1' && rm -f exit_code result.json && (nohup sh -c 'modeledit-solve --solver '\\''cp-sat'\\'' " +
+                "--'\\''timeLimitSeconds'\\'' '\\''60'\\'' model.mof.json result.json > log.txt 2>&1; echo $? > exit_code' > /dev/null 2>&1 &)",
+                ssh.Commands[0]);
+            Assert.Contains("tail -c +11 log.txt", ssh.Commands[1]);
+            Assert.Equal(RemoteJobState.Running, running.State);
+            Assert.Equal("line one\n", running.Log);
+            Assert.Equal(19, running.LogOffset);
+            Assert.Equal(RemoteJobState.Failed, failed.State);
+            Assert.Equal("modeledit-solve exited with code 3", failed.Message);
+        }
+    }
+}
@@ -0,0 +1,88 @@
+using Core.Analysis;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ReportAlertTests : TestBase
+    {
+        [Fact]
+        public void Evaluate_KpiAndUtilizationRules_ShouldRaiseAlertsAboveTheirThresholds()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(
+                "dvar float+ x;\n" +
+                "dvar float+ y;\n" +
+                "// @kpi total = x + y\n" +
+                "// @alert total > 8\n" +
+                "// @alert critical total < 5\n" +
+                "// @alert utilization(ca*) >= 95%\n" +
+                "maximize x + y;\n" +
+                "subject to {\n" +
+                "  cap: x + y <= 10;\n" +
+                "  limit: x <= 100;\n" +
+                "}\n"));
+            var solution = new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                VariableValues = new Dictionary<string, double> { ["x"] = 6, ["y"] = 4 }
+            };
+
+            var report = manager.Report.Evaluate(manager, solution);
+
+            Assert.Equal(new[]
+            {
+                "warning: KPI total = 10 is above 8",
+                "warning: Constraint cap is at 100% of its bound (at or above 95%)"
+            }, report.Alerts.Select(a => a.ToString()));
+            Assert.Equal(report.Alerts, solution.Alerts);
+            var utilization = report.Alerts[1];
+            Assert.Equal(("cap", 1.0, 0.95, 6), (utilization.Subject, utilization.Value, utilization.Threshold!.Value, utilization.LineNumber));
+        }
+
+        [Fact]
+        public void Evaluate_ViolatedSoftConstraintOfWeightClass_ShouldRaiseAnAlert()
+        {
+            string model =
+                "dvar float+ x;\n" +
+                "// @alert critical violated(hard-ish)\n" +
+                "minimize x;\n" +
+                "subject to {\n" +
+                "  // @tags hard-ish\n" +
+                "  demand: x >= 5;\n" +
+                "  // @tags soft\n" +
+                "  target: x >= 8;\n" +
+                "}\n";
+            var relaxed = new ConstraintRelaxer().Relax(model, new[] { "constraint:demand", "constraint:target" });
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(relaxed.ModelText));
+            var solution = new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                VariableValues = new Dictionary<string, double> { ["x"] = 3, ["demand_slack"] = 2, ["target_slack"] = 5 }
+            };
+
+            var alert = Assert.Single(manager.Report.Evaluate(manager, solution).Alerts);
+
+            Assert.Equal(AlertSeverity.Critical, alert.Severity);
+            Assert.Equal("Soft constraint demand (hard-ish) is violated by 2", alert.Message);
+            Assert.Null(alert.Threshold);
+        }
+
+        [Fact]
+        public void Read_MalformedRules_ShouldBeErrorsAtTheirLines()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "dvar float+ x;\n" +
+                "// @alert spill > lots\n" +
+                "// @alert spill > 5\n" +
+                "minimize x;\n");
+
+            Assert.Equal(new[]
+            {
+                (2, "Expected '@alert [critical] <kpi> > <threshold>', 'utilization(<rows>) > <percent>' or 'violated(<weight class>)'"),
+                (3, "Alert on unknown KPI 'spill'")
+            }, result.Errors.Select(e => (e.LineNumber, e.Message)));
+        }
+    }
+}
@@ -0,0 +1,169 @@
+using System.IO.Compression;
+using Core;
+using Core.Analysis;
+using Core.Export;
+using Core.Models;
+using Core.Solving;
+
+namespace Tests
+{
+    public class RunBundleTests : TestBase, IDisposable
+    {
+        private const string Model = @"float cost = ...;
+dvar float+ x;
+dvar float+ y;
+minimize cost * x + y;
+demand: x + y >= 2;
+";
+
+        private const string Data = "cost = 4;\n";
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "runbundle-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        private static RunBundle CreateBundle()
+        {
+            var bundle = new RunBundle { Name = "cost", Fingerprint = "abc" };
+            bundle.AddFile("model", "cost.mod", Model);
+            bundle.AddFile("data", "cost.dat", Data);
+            bundle.CaptureSolver(new CpSatDriver { TimeLimit = TimeSpan.FromSeconds(30), NumWorkers = 4 });
+            bundle.Result = RunBundleResult.From(new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                ObjectiveValue = 2,
+                SolveTime = TimeSpan.FromMilliseconds(12),
+                VariableValues = new Dictionary<string, double> { ["y"] = 2, ["x"] = 0 }
+            });
+            return bundle;
+        }
+
+        private ModelManager Load(string model, string data)
+        {
+            var manager = CreateModelManager();
+            var service = new ModelParsingService(manager, new EquationParser(manager), new DataFileParser(manager))
+            {
+                SolveAfterParse = false
+            };
+            var result = service.ParseModel(new List<string> { model }, new List<string> { data });
+            Assert.Empty(result.Errors);
+            return manager;
+        }
+
+        private static RunBundle RoundTrip(RunBundle bundle)
+        {
+            using var stream = new MemoryStream();
+            bundle.Export(stream);
+            stream.Position = 0;
+            return RunBundle.Import(stream);
+        }
+
+        [Fact]
+        public void ExportImport_ShouldRoundTripFilesOptionsAndResult()
+        {
+            var bundle = CreateBundle();
+
+            var imported = RoundTrip(bundle);
+
+            Assert.Equal("cost", imported.Name);
+            Assert.Equal(bundle.PackageVersion, imported.PackageVersion);
+            Assert.Equal(bundle.Environment.Runtime, imported.Environment.Runtime);
+            Assert.Equal("abc", imported.Fingerprint);
+            Assert.Equal(Model, imported.ModelFiles.Single().Text);
+            Assert.Equal(Data, imported.DataFiles.Single().Text);
+            Assert.Equal("CP-SAT", imported.Solver);
+            Assert.Equal("00:00:30", imported.SolverOptions["TimeLimit"]);
+            Assert.Equal("4", imported.SolverOptions["NumWorkers"]);
+            Assert.False(imported.SolverOptions.ContainsKey("Hints"));
+            Assert.Equal(SolveStatus.Optimal, imported.Result!.Status);
+            Assert.Equal(new[] { "x", "y" }, imported.Result.VariableValues.Keys);
+            Assert.Empty(imported.CompareEnvironment());
+        }
+
+        [Fact]
+        public void ApplySolverOptions_ShouldRestoreDriverSettings()
+        {
+            var imported = RoundTrip(CreateBundle());
+            var driver = new CpSatDriver();
+
+            var unknown = imported.ApplySolverOptions(driver);
+
+            Assert.Empty(unknown);
+            Assert.Equal(TimeSpan.FromSeconds(30), driver.TimeLimit);
+            Assert.Equal(4, driver.NumWorkers);
+        }
+
+        [Fact]
+        public void Import_TamperedFile_ShouldThrow()
+        {
+            using var stream = new MemoryStream();
+            CreateBundle().Export(stream);
+
+            using (var archive = new ZipArchive(stream, ZipArchiveMode.Update, leaveOpen: true))
+            {
+                archive.GetEntry("data/cost.dat")!.Delete();
+                using var writer = new StreamWriter(archive.CreateEntry("data/cost.dat").Open());
+                writer.Write("cost = 5;\n");
+            }
+
+            stream.Position = 0;
+            var ex = Assert.Throws<InvalidOperationException>(() => RunBundle.Import(stream));
+            Assert.Contains("data/cost.dat", ex.Message);
+        }
+
+        [Fact]
+        public void Export_ShouldBeByteIdenticalForSameBundle()
+        {
+            var bundle = CreateBundle();
+
+            using var first = new MemoryStream();
+            using var second = new MemoryStream();
+            bundle.Export(first);
+            bundle.Export(second);
+
+            Assert.Equal(first.ToArray(), second.ToArray());
+        }
+
+        [Fact]
+        public void Restore_ShouldWriteFilesAndReproduceFingerprint()
+        {
+            var bundle = CreateBundle();
+            bundle.Fingerprint = ModelFingerprint.Compute(Load(Model, Data)).ModelHash;
+
+            var configuration = RoundTrip(bundle).Restore(directory);
+
+            Assert.True(configuration.ValidateFiles(out _));
+            Assert.Equal(Model, File.ReadAllText(configuration.ModelFiles.Single()));
+            Assert.Equal(Data, File.ReadAllText(configuration.DataFiles.Single()));
+            Assert.Equal("CP-SAT", configuration.Metadata["solver"]);
+            Assert.Equal("4", configuration.Metadata["solver.NumWorkers"]);
+
+            var restored = Load(File.ReadAllText(configuration.ModelFiles[0]), File.ReadAllText(configuration.DataFiles[0]));
+            Assert.True(bundle.VerifyFingerprint(restored));
+            Assert.False(bundle.VerifyFingerprint(Load(Model, "cost = 5;\n")));
+        }
+
+        [Fact]
+        public void FromConfiguration_MissingFile_ShouldThrow()
+        {
+            var configuration = new RunConfiguration { ModelFiles = { Path.Combine(directory, "missing.mod") } };
+
+            Assert.Throws<InvalidOperationException>(() => RunBundle.FromConfiguration(configuration));
+        }
+
+        [Fact]
+        public void AddFile_DuplicateOrPathName_ShouldThrow()
+        {
+            var bundle = new RunBundle();
+            bundle.AddFile("model", "a.mod", "");
+
+            Assert.Throws<InvalidOperationException>(() => bundle.AddFile("model", "a.mod", ""));
+            Assert.Throws<ArgumentException>(() => bundle.AddFile("model", "../a.mod", ""));
+            Assert.Throws<ArgumentException>(() => bundle.AddFile("results", "a.txt", ""));
+        }
+    }
+}
@@ -0,0 +1,60 @@
+using Core;
+using Core.Server;
+using Core.Solving;
+
+namespace Tests
+{
+    public class ServerMetricsTests
+    {
+        private const string Model = @"dvar float+ x;
+dvar float+ y;
+maximize 3*x + 5*y;
+c1: 2*x + y <= 10;
+c2: x + 2*y <= 8;
+";
+
+        private class NamedDriver : ISolverDriver
+        {
+            private readonly SolveStatus status;
+
+            public NamedDriver(string name, SolveStatus status)
+            {
+                Name = name;
+                this.status = status;
+            }
+
+            public string Name { get; }
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult { Status = status, ObjectiveValue = 26 };
+        }
+
+        [Fact]
+        public async Task Scrape_ShouldExposeModelsSolvesAndFailuresInPrometheusFormat()
+        {
+            string solver = $"metrics-{Guid.NewGuid():N}";
+            var host = new ModelHost();
+            using var metrics = new ServerMetrics(host);
+            var model = host.Create("metrics", Model);
+            host.Create("empty", "");
+
+            await host.SolveAsync(model.Id, new NamedDriver(solver, SolveStatus.Optimal));
+            await host.SolveAsync(model.Id, new NamedDriver(solver, SolveStatus.Infeasible));
+            var lines = metrics.Scrape().Split('\n');
+
+            Assert.Contains("# TYPE modeleditor_models_open gauge", lines);
+            Assert.Contains("modeleditor_models_open 2", lines);
+            Assert.Contains($"modeleditor_model_memory_bytes{{model_id=\"{model.Id}\"}} {Model.Length * 2}", lines);
+            Assert.Contains(lines, l => l.StartsWith($"modeleditor_model_expanded_bytes{{model_id=\"{model.Id}\"}} ", StringComparison.Ordinal));
+
+            Assert.Contains("# TYPE modeleditor_solve_total counter", lines);
+            Assert.Contains($"modeleditor_solve_total{{solver=\"{solver}\",status=\"Optimal\"}} 1", lines);
+            Assert.Contains($"modeleditor_solve_total{{solver=\"{solver}\",status=\"Infeasible\"}} 1", lines);
+            Assert.Contains($"modeleditor_solve_active{{solver=\"{solver}\"}} 0", lines);
+            Assert.Contains("modeleditor_failure_total{operation=\"solve\",reason=\"Infeasible\"}", lines.Select(l => l.Split(' ')[0]));
+
+            Assert.Contains("# TYPE modeleditor_phase_duration_seconds histogram", lines);
+            Assert.Contains($"modeleditor_phase_duration_seconds_count{{outcome=\"ok\",phase=\"solve\",solver=\"{solver}\"}} 1", lines);
+            Assert.Contains($"modeleditor_phase_duration_seconds_bucket{{outcome=\"error\",phase=\"solve\",solver=\"{solver}\",le=\"+Inf\"}} 1", lines);
+        }
+    }
+}
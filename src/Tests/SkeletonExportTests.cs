@@ -0,0 +1,56 @@
+using Core.Export;
+
+namespace Tests
+{
+    public class SkeletonExportTests : TestBase
+    {
+        private const string Model =
+            "// capacities are confidential\n" +
+            "int n = 3;\n" +
+            "range I = 1..n;\n" +
+            "{string} Products = {\"bolt\", \"nut\"};\n" +
+            "float cost[I] = [1.5, 2e3, -4];\n" +
+            "float demand[I] = ...;\n" +
+            "float total = sum(i in I) cost[i];\n" +
+            "bool strict = true;\n" +
+            "dvar float+ x[I] in 0..100;\n" +
+            "forall(i in I) meet: x[i] >= demand[i];\n" +
+            "minimize sum(i in I) cost[i] * x[i];\n";
+
+        [Fact]
+        public void Export_ShouldReplaceInlineDataAndKeepFormulation()
+        {
+            var exporter = new SkeletonExporter();
+
+            string skeleton = exporter.Export(Model);
+
+            Assert.Equal(
+                "// Model structure; inline data of n, Products, cost, strict omitted" + Environment.NewLine +
+                "// capacities are confidential\n" +
+                "int n = ...;\n" +
+                "range I = 1..n;\n" +
+                "{string} Products = ...;\n" +
+                "float cost[I] = ...;\n" +
+                "float demand[I] = ...;\n" +
+                "float total = sum(i in I) cost[i];\n" +
+                "bool strict = ...;\n" +
+                "dvar float+ x[I] in 0..100;\n" +
+                "forall(i in I) meet: x[i] >= demand[i];\n" +
+                "minimize sum(i in I) cost[i] * x[i];\n",
+                skeleton);
+            Assert.Equal(new[] { "n", "Products", "cost", "strict" }, exporter.Omitted);
+            Assert.Empty(exporter.Warnings);
+        }
+
+        [Fact]
+        public void Export_ShouldWarnAboutScriptBlocks()
+        {
+            var exporter = new SkeletonExporter();
+
+            string skeleton = exporter.Export("float f = ...;\nexecute { f = 2; }\n");
+
+            Assert.StartsWith("// Model structure; it has no inline data", skeleton);
+            Assert.Equal(new[] { "Line 2: script block kept as is; check it for data" }, exporter.Warnings);
+        }
+    }
+}
@@ -0,0 +1,132 @@
+using Core.Solving;
+
+namespace Tests
+{
+    public class SolutionComparisonTests : TestBase
+    {
+        private static SolveResult Solution(double objective, Dictionary<string, double> values, Dictionary<string, double> slacks)
+        {
+            return new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                ObjectiveValue = objective,
+                VariableValues = values,
+                ConstraintSlacks = slacks
+            };
+        }
+
+        [Fact]
+        public void Compare_ShouldReportChangedVariablesAndBindingStatus()
+        {
+            var before = Solution(100,
+                new Dictionary<string, double> { ["x1"] = 5, ["x2"] = 3, ["y"] = 1 },
+                new Dictionary<string, double> { ["cap_1"] = 0, ["cap_2"] = 4 });
+            var after = Solution(90,
+                new Dictionary<string, double> { ["x1"] = 5.0000000001, ["x2"] = 7, ["y"] = 1 },
+                new Dictionary<string, double> { ["cap_1"] = 2, ["cap_2"] = 4 });
+
+            var delta = SolutionComparison.Compare(before, after);
+
+            var change = Assert.Single(delta.VariableChanges);
+            Assert.Equal("x2", change.Name);
+            Assert.Equal(4, change.Delta, 6);
+
+            var constraint = Assert.Single(delta.ConstraintChanges);
+            Assert.Equal("cap_1", constraint.Name);
+            Assert.True(constraint.WasBinding);
+            Assert.False(constraint.IsBinding);
+
+            Assert.Equal(-10, delta.ObjectiveDelta!.Value, 6);
+            Assert.Contains("x2", delta.ToReport());
+        }
+
+        [Fact]
+        public void Compare_ObjectiveWithinTolerance_ShouldNotCountAsChange()
+        {
+            var values = new Dictionary<string, double> { ["x"] = 1 };
+            var slacks = new Dictionary<string, double>();
+
+            var delta = SolutionComparison.Compare(Solution(1e6, values, slacks), Solution(1e6 + 1e-4, values, slacks));
+            Assert.False(delta.HasChanges);
+
+            var strict = new ComparisonTolerances { Absolute = 1e-9, Relative = 0 };
+            Assert.True(SolutionComparison.Compare(Solution(1e6, values, slacks), Solution(1e6 + 1e-4, values, slacks), strict).HasChanges);
+        }
+
+        [Fact]
+        public void Compare_WithFamilyFilter_ShouldOnlyIncludeMatchingNames()
+        {
+            var before = Solution(0,
+                new Dictionary<string, double> { ["x1"] = 1, ["y1"] = 1 },
+                new Dictionary<string, double>());
+            var after = Solution(0,
+                new Dictionary<string, double> { ["x1"] = 2, ["y1"] = 2 },
+                new Dictionary<string, double>());
+
+            var delta = SolutionComparison.Compare(before, after, null, new[] { "y" });
+
+            Assert.Equal("y1", Assert.Single(delta.VariableChanges).Name);
+        }
+
+        [Fact]
+        public void Compare_VariableMissingInOneSolution_ShouldBeReported()
+        {
+            var before = Solution(0, new Dictionary<string, double> { ["x1"] = 1 }, new Dictionary<string, double>());
+            var after = Solution(0, new Dictionary<string, double>(), new Dictionary<string, double>());
+
+            var change = Assert.Single(SolutionComparison.Compare(before, after).VariableChanges);
+
+            Assert.Null(change.After);
+            Assert.Equal(-1, change.Delta, 6);
+        }
+
+        [Fact]
+        public void Compare_WithScope_ShouldSelectByDeclarationTagAndBlock()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(
+                "range I = 1..2;\n" +
+                "// @block hydro: water\n" +
+                "dvar float+ flow[I];\n" +
+                "// @tags ramping/up\n" +
+                "forall(i in I) stage2: flow[i] <= 5;\n" +
+                "// @endblock\n" +
+                "// @tags thermal\n" +
+                "dvar float+ gen2;\n" +
+                "balance: gen2 + sum(i in I) flow[i] >= 3;\n" +
+                "minimize gen2;\n"));
+            var before = Solution(1,
+                new Dictionary<string, double> { ["flow1"] = 1, ["flow2"] = 1, ["gen2"] = 1 },
+                new Dictionary<string, double> { ["stage2_1"] = 0, ["stage2_2"] = 1, ["balance"] = 0 });
+            var after = Solution(2,
+                new Dictionary<string, double> { ["flow1"] = 2, ["flow2"] = 2, ["gen2"] = 2 },
+                new Dictionary<string, double> { ["stage2_1"] = 1, ["stage2_2"] = 0, ["balance"] = 1 });
+
+            // Changed variables | changed constraints
+            string Compared(ComparisonScope scope)
+            {
+                var delta = SolutionComparison.Compare(before, after, manager, scope);
+                return string.Join(" ", delta.VariableChanges.Select(c => c.Name)) + " | " + string.Join(" ", delta.ConstraintChanges.Select(c => c.Name));
+            }
+
+            Assert.Equal("flow1 flow2 | stage2_1 stage2_2", Compared(new ComparisonScope { Blocks = new[] { "hydro" } }));
+            Assert.Equal(" | stage2_1 stage2_2", Compared(new ComparisonScope { Tags = new[] { "ramping" } }));
+            Assert.Equal("gen2 | ", Compared(new ComparisonScope { Tags = new[] { "thermal" } }));
+
+            // Families are the declared names, so neither 'stage2' nor 'gen2' is cut down by its trailing digit
+            Assert.Equal("gen2 | stage2_1 stage2_2", Compared(new ComparisonScope { Families = new[] { "stage2", "gen2" } }));
+            Assert.Equal(" | ", Compared(new ComparisonScope { Families = new[] { "stage", "gen" } }));
+            Assert.Equal("flow1 flow2 | ", Compared(new ComparisonScope { Families = new[] { "flow", "balance" }, Blocks = new[] { "hydro" } }));
+        }
+
+        [Theory]
+        [InlineData("x12", "x")]
+        [InlineData("flow1_2", "flow")]
+        [InlineData("limit_3", "limit")]
+        [InlineData("obj", "obj")]
+        public void GetFamily_ShouldStripIndexSuffix(string name, string expected)
+        {
+            Assert.Equal(expected, SolutionComparison.GetFamily(name));
+        }
+    }
+}
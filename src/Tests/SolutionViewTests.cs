@@ -0,0 +1,67 @@
+using Core;
+using Core.Solving;
+
+namespace Tests
+{
+    public class SolutionViewTests : TestBase
+    {
+        private ModelManager BuildModel()
+        {
+            return ParseAndExpand(
+                "range I = 1..3;\n" +
+                "dvar float x[I] in 0..10;\n" +
+                "dvar int n in 0..5;\n" +
+                "dvar bool b;\n" +
+                "minimize n;\n" +
+                "c1: x[1] + n >= 1;\n");
+        }
+
+        private static SolveResult Relaxation() => new SolveResult
+        {
+            Status = SolveStatus.Optimal,
+            VariableValues = new Dictionary<string, double> { ["x1"] = 0, ["x2"] = 4.5, ["x3"] = 10, ["n"] = 2.5, ["b"] = 1 },
+            ConstraintSlacks = new Dictionary<string, double> { ["c1"] = 0, ["c2"] = 3, ["c3"] = 1e-9 }
+        };
+
+        [Fact]
+        public void Filters_ShouldSelectRowsByActivity()
+        {
+            var view = Relaxation().View(BuildModel());
+
+            Assert.Equal(new[] { "b", "n", "x2", "x3" }, view.NonzeroVariables().Variables.Select(v => v.Name));
+            Assert.Equal(new[] { "b", "x1", "x3" }, view.AtBounds().Variables.Select(v => v.Name));
+            Assert.Equal(new[] { "n" }, view.FractionalIntegers().Variables.Select(v => v.Name));
+            Assert.Equal(new[] { "c1", "c3" }, view.BindingConstraints().Constraints.Select(c => c.Name));
+        }
+
+        [Fact]
+        public void Filters_ShouldCompose()
+        {
+            var view = Relaxation().View(BuildModel());
+
+            var composed = view.NonzeroVariables().AtBounds().Matching("x*").BindingConstraints();
+
+            Assert.Equal(new[] { "x3" }, composed.Variables.Select(v => v.Name));
+            Assert.Empty(composed.Constraints);
+            Assert.Equal(5, view.Variables.Count);
+            Assert.Equal(new[] { "b", "n", "x1", "x2", "x3" }, view.AtBounds(epsilon: 6).Variables.Select(v => v.Name));
+        }
+
+        [Fact]
+        public void ToTable_ShouldAlignColumns()
+        {
+            var table = Relaxation().View(BuildModel()).FractionalIntegers().BindingConstraints().ToTable();
+
+            Assert.Equal(
+                "Variables (1 of 5)\n" +
+                "  Name  Value  Lower  Upper  Type\n" +
+                "  n       2.5      0      5  integer\n" +
+                "\n" +
+                "Constraints (2 of 3)\n" +
+                "  Name  Slack  Status\n" +
+                "  c1        0  binding\n" +
+                "  c3    1E-09  binding\n",
+                table.Replace("\r\n", "\n"));
+        }
+    }
+}
@@ -0,0 +1,134 @@
+using Core;
+using Core.Server;
+using Core.Solving;
+
+namespace Tests
+{
+    public class SolveCacheTests : TestBase, IDisposable
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private class CountingDriver : ISolverDriver
+        {
+            public int Calls { get; private set; }
+            public string Name => "Counting";
+            public TimeSpan? TimeLimit { get; set; }
+            public SolveStatus Status { get; set; } = SolveStatus.Optimal;
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Calls++;
+                return new SolveResult
+                {
+                    Status = Status,
+                    ObjectiveValue = manager.Equations.Count * 25,
+                    VariableValues = new Dictionary<string, double> { ["flow1"] = 25 }
+                };
+            }
+        }
+
+        private readonly string directory = Path.Combine(Path.GetTempPath(), "solvecache-" + Guid.NewGuid().ToString("N"));
+
+        public void Dispose()
+        {
+            if (Directory.Exists(directory))
+                Directory.Delete(directory, recursive: true);
+        }
+
+        [Fact]
+        public void GetOrSolve_UnchangedModel_ShouldSolveOnce()
+        {
+            var cache = new SolveCache();
+            var driver = new CountingDriver();
+
+            var first = cache.GetOrSolve(ParseAndExpand(Model), driver);
+            var second = cache.GetOrSolve(ParseAndExpand(Model), driver);
+
+            Assert.Equal(1, driver.Calls);
+            Assert.Same(first, second);
+            Assert.Equal(1, cache.Hits);
+            Assert.Equal(1, cache.Misses);
+        }
+
+        [Fact]
+        public void KeyOf_ShouldChangeWithDataAndSolverOptions()
+        {
+            var driver = new CountingDriver();
+            string key = SolveCache.KeyOf(ParseAndExpand(Model), driver);
+
+            Assert.Equal(key, SolveCache.KeyOf(ParseAndExpand(Model), driver));
+            Assert.NotEqual(key, SolveCache.KeyOf(ParseAndExpand(Model.Replace("capacity = 25", "capacity = 30")), driver));
+
+            driver.TimeLimit = TimeSpan.FromSeconds(10);
+            Assert.NotEqual(key, SolveCache.KeyOf(ParseAndExpand(Model), driver));
+        }
+
+        [Fact]
+        public void GetOrSolve_ErrorResult_ShouldNotBeCached()
+        {
+            var cache = new SolveCache();
+            var driver = new CountingDriver { Status = SolveStatus.Error };
+
+            cache.GetOrSolve(ParseAndExpand(Model), driver);
+            cache.GetOrSolve(ParseAndExpand(Model), driver);
+
+            Assert.Equal(2, driver.Calls);
+            Assert.Equal(0, cache.Count);
+        }
+
+        [Fact]
+        public void Evicted_ShouldBeReadBackFromSpillDirectory()
+        {
+            var cache = new SolveCache(capacity: 1, spillDirectory: directory);
+            var driver = new CountingDriver();
+            var first = ParseAndExpand(Model);
+
+            cache.GetOrSolve(first, driver);
+            cache.GetOrSolve(ParseAndExpand(Model.Replace("1..3", "1..4")), driver);
+            Assert.Single(Directory.GetFiles(directory, "*.json"));
+
+            var spilled = cache.GetOrSolve(first, driver);
+
+            Assert.Equal(2, driver.Calls);
+            Assert.Equal(SolveStatus.Optimal, spilled.Status);
+            Assert.Equal(75, spilled.ObjectiveValue);
+            Assert.Equal(25, spilled.VariableValues["flow1"]);
+
+            cache.Clear();
+            Assert.Empty(Directory.GetFiles(directory, "*.json"));
+        }
+
+        [Fact]
+        public async Task ModelHost_ShouldReturnCachedResultWithoutSolving()
+        {
+            var host = new ModelHost { Cache = new SolveCache() };
+            var model = host.Create("network", Model);
+            var driver = new CountingDriver();
+
+            await host.SolveAsync(model.Id, driver);
+            var events = new List<SolveProgress>();
+            var result = await host.SolveAsync(model.Id, driver, new Progress(events));
+
+            Assert.Equal(1, driver.Calls);
+            Assert.Equal(75, result.ObjectiveValue);
+            Assert.EndsWith("(cached)", events.Last().Message);
+        }
+
+        private class Progress : IProgress<SolveProgress>
+        {
+            private readonly List<SolveProgress> events;
+
+            public Progress(List<SolveProgress> events)
+            {
+                this.events = events;
+            }
+
+            public void Report(SolveProgress value) => events.Add(value);
+        }
+    }
+}
@@ -0,0 +1,129 @@
+using Core;
+using Core.Solving;
+
+namespace Tests
+{
+    public class SolveMonitorTests : TestBase
+    {
+        private class FixedDriver : ISolverDriver
+        {
+            public string Name => "Fixed";
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult
+            {
+                Status = SolveStatus.Optimal,
+                ObjectiveValue = 7,
+                BestBound = 7,
+                VariableValues = new Dictionary<string, double> { ["x"] = 2 }
+            };
+        }
+
+        /// <summary>
+        /// Reports an incumbent, then runs until cancelled
+        /// </summary>
+        private class RunningDriver : ISolverDriver
+        {
+            private readonly SolveMonitor monitor;
+
+            public RunningDriver(SolveMonitor monitor)
+            {
+                this.monitor = monitor;
+            }
+
+            public string Name => "Running";
+
+            public SolveResult Solve(ModelManager manager) => Solve(manager, CancellationToken.None);
+
+            public SolveResult Solve(ModelManager manager, CancellationToken cancellationToken)
+            {
+                monitor.Report(new SolverEvent { Kind = SolverEventKind.Bound, BestBound = 10 });
+                monitor.Report(new SolverEvent
+                {
+                    Kind = SolverEventKind.Incumbent,
+                    ObjectiveValue = 12,
+                    Values = new Dictionary<string, double> { ["x"] = 1, ["y"] = 3 }
+                });
+                cancellationToken.WaitHandle.WaitOne(TimeSpan.FromSeconds(30));
+                cancellationToken.ThrowIfCancellationRequested();
+                return new SolveResult { Status = SolveStatus.Error, StatusMessage = "not stopped" };
+            }
+        }
+
+        [Fact]
+        public void Report_ShouldTrackIncumbentBoundAndStopAtGap()
+        {
+            var monitor = new SolveMonitor { StopAtGap = 0.1 };
+            var events = new List<SolverEvent>();
+            monitor.EventReported += events.Add;
+
+            monitor.Report(new SolverEvent { Kind = SolverEventKind.Incumbent, ObjectiveValue = 20, BestBound = 10 });
+            Assert.Equal(0.5, monitor.MipGap);
+            Assert.False(monitor.IsStopRequested);
+
+            monitor.Report(new SolverEvent { Kind = SolverEventKind.Nodes, NodeCount = 150 });
+            monitor.Report(new SolverEvent { Kind = SolverEventKind.Incumbent, ObjectiveValue = 11 });
+            monitor.Report(new SolverEvent { Kind = SolverEventKind.Bound, BestBound = 10.5 });
+
+            Assert.Equal(4, events.Count);
+            Assert.Equal(150, monitor.NodeCount);
+            Assert.Equal(11, monitor.Incumbent!.ObjectiveValue);
+            Assert.Equal(SolveStatus.Feasible, monitor.Incumbent.Status);
+            Assert.True(monitor.IsStopRequested);
+        }
+
+        [Fact]
+        public void Solve_DriverWithoutEvents_ShouldReportFinalSolution()
+        {
+            var monitor = new SolveMonitor();
+            var events = new List<SolverEvent>();
+            monitor.EventReported += events.Add;
+            ISolverDriver driver = new FixedDriver();
+
+            var result = driver.Solve(new ModelManager(), monitor, CancellationToken.None);
+
+            Assert.Same(result, monitor.Incumbent);
+            var incumbent = Assert.Single(events);
+            Assert.Equal(SolverEventKind.Incumbent, incumbent.Kind);
+            Assert.Equal(7, incumbent.ObjectiveValue);
+            Assert.Equal(0, monitor.MipGap);
+        }
+
+        [Fact]
+        public async Task Stop_ShouldEndSolveWithLiveIncumbent()
+        {
+            var monitor = new SolveMonitor();
+            ISolverDriver driver = new RunningDriver(monitor);
+
+            var solve = Task.Run(() => driver.Solve(new ModelManager(), monitor, CancellationToken.None));
+            while (monitor.Incumbent == null)
+                await Task.Delay(10);
+
+            Assert.Equal(3, monitor.Incumbent.VariableValues["y"]);
+            Assert.False(solve.IsCompleted);
+
+            monitor.Stop();
+            var result = await solve;
+
+            Assert.Equal(SolveStatus.Feasible, result.Status);
+            Assert.Equal(12, result.ObjectiveValue);
+            Assert.Equal(1, result.VariableValues["x"]);
+            Assert.Equal(10, result.BestBound);
+            Assert.Equal("Stopped with incumbent at gap 16.67 %", result.StatusMessage);
+        }
+
+        [Fact]
+        public async Task Stop_UserCancellation_ShouldStillThrow()
+        {
+            var monitor = new SolveMonitor();
+            ISolverDriver driver = new RunningDriver(monitor);
+            using var cancellation = new CancellationTokenSource();
+
+            var solve = Task.Run(() => driver.Solve(new ModelManager(), monitor, cancellation.Token));
+            while (monitor.Incumbent == null)
+                await Task.Delay(10);
+            cancellation.Cancel();
+
+            await Assert.ThrowsAnyAsync<OperationCanceledException>(() => solve);
+        }
+    }
+}
@@ -0,0 +1,141 @@
+using Core;
+using Core.Solving;
+
+namespace Tests
+{
+    public class SolverRaceTests : TestBase, IDisposable
+    {
+        private readonly string path = Path.Combine(Path.GetTempPath(), $"performance_{Guid.NewGuid():N}.json");
+
+        public void Dispose()
+        {
+            if (File.Exists(path))
+                File.Delete(path);
+        }
+
+        private class FixedDriver : ISolverDriver
+        {
+            private readonly SolveStatus status;
+            private readonly double objective;
+
+            public FixedDriver(string name, SolveStatus status, double objective = 5)
+            {
+                Name = name;
+                this.status = status;
+                this.objective = objective;
+            }
+
+            public string Name { get; }
+
+            public SolveResult Solve(ModelManager manager) => new SolveResult { Status = status, ObjectiveValue = objective };
+        }
+
+        /// <summary>
+        /// Reports one incumbent and runs until stopped
+        /// </summary>
+        private class SearchingDriver : ISolverDriver
+        {
+            private readonly double incumbent;
+
+            public SearchingDriver(string name, double incumbent)
+            {
+                Name = name;
+                this.incumbent = incumbent;
+            }
+
+            public string Name { get; }
+
+            public SolveResult Solve(ModelManager manager) => throw new NotSupportedException();
+
+            public SolveResult Solve(ModelManager manager, SolveMonitor monitor, CancellationToken cancellationToken)
+            {
+                monitor.Report(new SolverEvent { Kind = SolverEventKind.Incumbent, ObjectiveValue = incumbent });
+                monitor.StopToken.WaitHandle.WaitOne(TimeSpan.FromSeconds(30));
+                return monitor.StoppedResult(TimeSpan.Zero);
+            }
+        }
+
+        /// <summary>
+        /// Ignores stop requests until released
+        /// </summary>
+        private class StuckDriver : ISolverDriver
+        {
+            public ManualResetEventSlim Release { get; } = new ManualResetEventSlim();
+            public string Name => "Stuck";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                Release.Wait(TimeSpan.FromSeconds(30));
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 1 };
+            }
+        }
+
+        private ModelManager Expand() => ParseAndExpand("dvar float+ x;\nminimize x;\nc1: x >= 1;\n");
+
+        [Fact]
+        public async Task RunAsync_FirstProvenResult_ShouldWinAndStopOthers()
+        {
+            var stuck = new StuckDriver();
+            var performance = new SolverPerformance();
+            var race = new SolverRace(new ISolverDriver[] { new SearchingDriver("Searching", 9), stuck, new FixedDriver("Fast", SolveStatus.Optimal) })
+            {
+                StopGrace = TimeSpan.FromMilliseconds(100),
+                Performance = performance
+            };
+
+            var result = await race.RunAsync(Expand());
+            stuck.Release.Set();
+
+            Assert.Equal("Fast", result.Winner);
+            Assert.Equal(5, result.Result.ObjectiveValue);
+            var searching = result.Entries.Single(e => e.Solver == "Searching");
+            Assert.True(searching.Stopped);
+            Assert.Equal(SolveStatus.Feasible, searching.Status);
+            Assert.Equal(9, searching.ObjectiveValue);
+            var abandoned = result.Entries.Single(e => e.Solver == "Stuck");
+            Assert.True(abandoned.Stopped);
+            Assert.Equal(SolveStatus.Error, abandoned.Status);
+            Assert.Equal(3, performance.Runs.Count);
+        }
+
+        [Fact]
+        public async Task RunAsync_TimeLimit_ShouldReturnBestIncumbent()
+        {
+            var race = new SolverRace(new ISolverDriver[]
+            {
+                new SearchingDriver("A", 12), new SearchingDriver("B", 8), new FixedDriver("Broken", SolveStatus.Error)
+            })
+            {
+                TimeLimit = TimeSpan.FromMilliseconds(100)
+            };
+
+            var result = await race.RunAsync(Expand());
+
+            Assert.Equal("B", result.Winner);
+            Assert.Equal(SolveStatus.Feasible, result.Result.Status);
+            Assert.Equal(8, result.Result.ObjectiveValue);
+            Assert.All(result.Entries.Where(e => e.Solver != "Broken"), e => Assert.True(e.Stopped));
+        }
+
+        [Fact]
+        public async Task Performance_ShouldRankWinnersAndRoundTrip()
+        {
+            var performance = new SolverPerformance();
+            var drivers = new ISolverDriver[] { new FixedDriver("Slow", SolveStatus.Feasible), new FixedDriver("Exact", SolveStatus.Optimal) };
+            var manager = Expand();
+
+            for (int i = 0; i < 3; i++)
+                await new SolverRace(drivers) { Performance = performance }.RunAsync(manager);
+            performance.Save(path);
+            var loaded = SolverPerformance.Load(path);
+
+            var statistics = loaded.Statistics();
+            Assert.Equal(new[] { "Exact", "Slow" }, statistics.Select(s => s.Solver));
+            Assert.Equal(3, statistics[0].Wins);
+            Assert.Equal(1.0, statistics[0].WinRate);
+            Assert.Equal("Exact", loaded.Choose(drivers)!.Name);
+            Assert.Null(loaded.Choose(new ISolverDriver[] { new FixedDriver("Unknown", SolveStatus.Optimal) }));
+            Assert.Empty(SolverPerformance.Load(path + ".missing").Runs);
+        }
+    }
+}
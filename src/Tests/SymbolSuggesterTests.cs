@@ -0,0 +1,101 @@
+using Core;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class SymbolSuggesterTests : TestBase
+    {
+        [Theory]
+        [InlineData("cost", "cost", 0)]
+        [InlineData("cost", "COST", 0)]
+        [InlineData("cots", "cost", 1)]
+        [InlineData("cst", "cost", 1)]
+        [InlineData("demnad", "demand", 1)]
+        [InlineData("flow", "capacity", 8)]
+        public void Distance_ShouldCountTranspositionAsOneEdit(string a, string b, int expected)
+        {
+            Assert.Equal(expected, SymbolSuggester.Distance(a, b));
+        }
+
+        [Fact]
+        public void Rank_ShouldPreferMatchingArityThenCaseOnlyDifferences()
+        {
+            var declared = new (string, int?)[] { ("coast", 0), ("costs", 1), ("Cost", 1), ("flow", 0) };
+
+            Assert.Equal(new[] { "Cost", "costs", "coast" }, SymbolSuggester.Rank("cost", declared, arity: 1));
+            Assert.Equal(new[] { "coast", "Cost", "costs" }, SymbolSuggester.Rank("cost", declared, arity: 0));
+            Assert.Equal(new[] { "Cost", "coast", "costs" }, SymbolSuggester.Rank("cost", declared));
+        }
+
+        [Fact]
+        public void Rank_ShortNames_ShouldOnlyMatchByCase()
+        {
+            var declared = new (string, int?)[] { ("x", 0), ("y", 0), ("X", 0) };
+
+            Assert.Empty(SymbolSuggester.Rank("z", declared));
+            Assert.Equal(new[] { "X" }, SymbolSuggester.Rank("x", declared));
+        }
+
+        [Fact]
+        public void Parse_UndeclaredVariable_ShouldSuggestClosestName()
+        {
+            var parser = CreateParser();
+
+            var result = parser.Parse("dvar float+ cost;\ndvar float+ flow;\nminimize cots;");
+
+            AssertHasError(result, "did you mean 'cost'?");
+            var suggestion = Assert.Single(result.Suggestions);
+            Assert.Equal("cots", suggestion.Name);
+            Assert.Equal(new[] { "cost" }, suggestion.Candidates);
+            Assert.Equal(3, suggestion.LineNumber);
+            Assert.Equal(suggestion, Assert.Single(result.GetSuggestionsForLine(3)));
+        }
+
+        [Fact]
+        public void Parse_IndexedReference_ShouldPreferNamesWithSameArity()
+        {
+            var parser = CreateParser();
+
+            var result = parser.Parse("range N = 1..3;\ndvar float+ coast;\ndvar float+ costs[N];\nminimize cost[1];");
+
+            var suggestion = Assert.Single(result.Suggestions);
+            Assert.Equal(1, suggestion.Arity);
+            Assert.Equal(new[] { "costs", "coast" }, suggestion.Candidates);
+        }
+
+        [Fact]
+        public void Parse_UnknownSet_ShouldSuggestSetNames()
+        {
+            var parser = CreateParser();
+
+            var result = parser.Parse("range Nodes = 1..3;\ndvar float+ x[Nodes];\nminimize sum(i in Node) x[i];");
+
+            AssertHasError(result, "Set or range 'Node' not found; did you mean 'Nodes'?");
+            Assert.Equal(SymbolKind.Set, Assert.Single(result.Suggestions).Kind);
+        }
+
+        [Fact]
+        public void ParseData_UndeclaredParameter_ShouldSuggestDeclaredParameter()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse("float demand = ...;"));
+
+            var result = new DataFileParser(manager).Parse("demnad = 3;");
+
+            AssertHasError(result, "Parameter 'demnad' is not declared; did you mean 'demand'?");
+            Assert.Equal("demand", Assert.Single(result.Suggestions).Candidates[0]);
+        }
+
+        [Fact]
+        public void Parse_NoCloseName_ShouldNotSuggest()
+        {
+            var parser = CreateParser();
+
+            var result = parser.Parse("dvar float+ flow;\nminimize capacity;");
+
+            Assert.True(result.HasErrors);
+            Assert.DoesNotContain("did you mean", result.Errors[0].Message);
+            Assert.Empty(result.Suggestions);
+        }
+    }
+}
@@ -0,0 +1,97 @@
+using Core;
+using Core.Parsing;
+
+namespace Tests
+{
+    public class SymbolTableTests : TestBase
+    {
+        private const string Model =
+            "// @scoped\n" +
+            "range T = 1..2;\n" +
+            "// @block hydro\n" +
+            "// @export hydroOut\n" +
+            "float cap = 5;\n" +
+            "dvar float+ hydroOut[T];\n" +
+            "forall(t in T) limit: hydroOut[t] <= cap;\n" +
+            "// @endblock\n" +
+            "// @block thermal\n" +
+            "// @export thermalOut\n" +
+            "float cap = 8;\n" +
+            "dvar float+ thermalOut[T];\n" +
+            "forall(t in T) limit: thermalOut[t] <= cap;\n" +
+            "// @endblock\n" +
+            "dvar float+ total;\n" +
+            "minimize total;\n";
+
+        [Fact]
+        public void Blocks_ShouldDeclareTheSameNamesWithoutCollisions()
+        {
+            var manager = new ModelManager();
+            var result = new EquationParser(manager).Parse(Model);
+            var expansion = new ParseSessionResult();
+            new EquationParser(manager).ExpandAllTemplates(expansion);
+
+            Assert.Empty(result.Errors);
+            Assert.Equal(5.0, Convert.ToDouble(manager.Parameters["hydro__cap"].Value));
+            Assert.Equal(8.0, Convert.ToDouble(manager.Parameters["thermal__cap"].Value));
+            Assert.False(manager.Parameters.ContainsKey("cap"));
+            Assert.True(manager.IndexedVariables.ContainsKey("hydroOut"));
+            Assert.Equal("thermal__limit_2: thermalOut2 <= 8", manager.GetEquationByLabel("thermal__limit_2")!.ToString());
+        }
+
+        [Fact]
+        public void Lookups_ShouldResolveThroughParentScopes()
+        {
+            var table = SymbolTable.Build(
+                "// @scoped\n" +
+                "float cap = 1;\n" +
+                "// @block hydro\n" +
+                "float head = cap;\n" +
+                "// @block units\n" +
+                "// @export flow\n" +
+                "float cap = 2;\n" +
+                "float flow = cap * head;\n" +
+                "// @endblock\n" +
+                "float total = flow;\n" +
+                "// @endblock\n" +
+                "float all = cap;\n");
+
+            Assert.Empty(table.Errors);
+            Assert.Equal("cap", table.Resolve("cap", 4)!.QualifiedName);
+            Assert.Equal("hydro__units__cap", table.Resolve("cap", 8)!.QualifiedName);
+            Assert.Equal("hydro__head", table.Resolve("head", 8)!.QualifiedName);
+            Assert.Equal("hydro__flow", table.Resolve("flow", 10)!.QualifiedName);
+            Assert.Null(table.Resolve("flow", 12));
+            Assert.Null(table.Resolve("head", 12));
+
+            var rewritten = table.Rewrite().Split('\n');
+            Assert.Equal("float hydro__flow = hydro__units__cap * hydro__head;", rewritten[7]);
+            Assert.Equal("float hydro__total = hydro__flow;", rewritten[9]);
+            Assert.Equal("float all = cap;", rewritten[11]);
+        }
+
+        [Fact]
+        public void Build_ShouldReportCollisionsWithinAScopeAndBadExports()
+        {
+            var table = SymbolTable.Build(
+                "// @scoped\n" +
+                "float out = 0;\n" +
+                "// @block hydro\n" +
+                "// @export out, missing\n" +
+                "float cap = 1;\n" +
+                "float cap = 2;\n" +
+                "float out = cap;\n" +
+                "// @endblock\n" +
+                "// @block thermal\n" +
+                "float cap = 3;\n");
+
+            Assert.Equal(new[]
+            {
+                ("Block 'hydro' exports 'missing', which it does not declare", 5),
+                ("'cap' is already declared in block 'hydro' on line 5", 6),
+                ("'out' is already declared at top level on line 2", 7)
+            }, table.Errors);
+            Assert.Equal(new[] { "Block 'thermal' is not closed with @endblock" }, table.Warnings);
+        }
+    }
+}
@@ -18,6 +18,19 @@ namespace Tests
             return new EquationParser(manager);
         }
 
+        /// <summary>
+        /// Parses a model text without errors and expands its templates
+        /// </summary>
+        protected ModelManager ParseAndExpand(string modelText)
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(modelText);
+            AssertNoErrors(result);
+            parser.ExpandAllTemplates(result);
+            return manager;
+        }
+
         protected void AssertNoErrors(ParseSessionResult result)
         {
             
@@ -0,0 +1,55 @@
+using Core;
+using Core.Analysis;
+using Core.Documentation;
+
+namespace Tests
+{
+    public class UnitAnnotationsTests : TestBase
+    {
+        private const string Model =
+            "range Nodes = 1..3;\n" +
+            "// @unit MW\n" +
+            "float capacity = 25;\n" +
+            "// @unit MWh\n" +
+            "dvar float+ flow[Nodes];\n" +
+            "maximize sum(n in Nodes) flow[n];\n" +
+            "forall(n in Nodes) cap: flow[n] <= capacity;\n";
+
+        [Fact]
+        public void Parse_ShouldReadUnitsIntoTheMetadata()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            Assert.Equal("MW", manager.Parameters["capacity"].Unit);
+            Assert.Equal("MWh", manager.IndexedVariables["flow"].Unit);
+            Assert.Equal(2, manager.Units.Count);
+
+            var catalog = EntityCatalog.Build(manager);
+            Assert.Contains("Unit: MW", catalog.Get("parameter:capacity")!.Details);
+            Assert.Contains("Unit: MWh", catalog.Get("variable:flow")!.Details);
+
+            string doc = new ModelDocumentationGenerator(manager).Generate();
+            Assert.Contains(@"| $\mathit{capacity}$ | float | MW | 25 |", doc);
+            Assert.Contains(@"| $\mathit{flow}_{\mathit{Nodes}}$ | float | $[0, \infty]$ | MWh |", doc);
+        }
+
+        [Fact]
+        public void Parse_MisplacedOrEmptyUnit_ShouldReportError()
+        {
+            var manager = CreateModelManager();
+            var result = CreateParser(manager).Parse(
+                "dvar float x in 0..4;\n" +
+                "// @unit MW\n" +
+                "c1: x >= 1;\n" +
+                "// @unit\n" +
+                "float cost = 2;\n" +
+                "minimize cost * x;\n");
+
+            Assert.Equal(
+                new[] { ("@unit must precede a parameter or variable declaration", 3), ("@unit needs a unit of measure, e.g. // @unit MW", 5) },
+                result.Errors.Select(e => (e.Message, e.LineNumber)).OrderBy(e => e.LineNumber));
+            Assert.Empty(manager.Units);
+        }
+    }
+}
@@ -93,6 +93,26 @@ namespace Tests
             Assert.Equal(VariableType.Float, variable.Type);
         }
 
+        [Fact]
+        public void Parse_IndexedVariableWithUnderscoreInName_ShouldBeUsableInObjective()
+        {
+            // Arrange
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            string input = @"
+                range I = 1..3;
+                dvar float+ cap_slack[I];
+                minimize sum(i in I) cap_slack[i];
+            ";
+
+            // Act
+            var result = parser.Parse(input);
+
+            // Assert
+            AssertNoErrors(result);
+            Assert.Equal(new[] { "cap_slack1", "cap_slack2", "cap_slack3" }, manager.Objective!.Coefficients.Keys.OrderBy(k => k));
+        }
+
         [Fact]
         public void Parse_IndexedVariableWithoutType_DefaultsToFloat()
         {
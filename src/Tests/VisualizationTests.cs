@@ -0,0 +1,152 @@
+using Core;
+using Core.Visualization;
+
+namespace Tests
+{
+    public class VisualizationTests : TestBase
+    {
+        private const string Model = @"
+            range Nodes = 1..3;
+            float capacity = 25;
+            dvar float+ flow[Nodes];
+            dvar float+ spill;
+            maximize sum(n in Nodes) flow[n] - spill;
+            forall(n in Nodes) cap: flow[n] <= capacity;
+            balance: sum(n in Nodes) flow[n] - spill == 10;
+        ";
+
+        private ConstraintMatrix BuildMatrix() => ConstraintMatrix.Build(ParseAndExpand(Model));
+
+        [Fact]
+        public void Build_ShouldGroupRowsAndColumnsByFamily()
+        {
+            var matrix = BuildMatrix();
+
+            Assert.Equal(4, matrix.RowCount);
+            Assert.Equal(4, matrix.ColumnCount);
+            Assert.Equal(7, matrix.Entries.Count);
+            Assert.Contains(matrix.Entries, e => matrix.ColumnNames[e.Column] == "spill" && e.Value == -1);
+
+            var blocks = matrix.GetBlockStructure();
+            Assert.Equal(2, blocks.RowFamilies.Count);
+            Assert.Equal(new[] { "flow", "spill" }, blocks.ColumnFamilies);
+            Assert.Equal(new[] { 3, 1 }, blocks.ColumnSizes);
+            Assert.Equal(1.0 / 3.0, blocks.GetDensity(blocks.RowFamilies.IndexOf("cap"), 0), 6);
+        }
+
+        [Fact]
+        public void Build_UnexpandedModel_ShouldThrow()
+        {
+            var manager = CreateModelManager();
+            AssertNoErrors(CreateParser(manager).Parse(Model));
+
+            Assert.Throws<InvalidOperationException>(() => ConstraintMatrix.Build(manager));
+        }
+
+        [Fact]
+        public void RenderSpy_ShouldProduceSvgCellsAndValidPng()
+        {
+            var matrix = BuildMatrix();
+            var renderer = new MatrixRenderer(new MatrixRenderOptions { MaxSize = 40 });
+
+            string svg = renderer.RenderSpySvg(matrix);
+            byte[] png = renderer.RenderSpyPng(matrix);
+
+            Assert.StartsWith("<svg", svg);
+            Assert.Contains("fill=\"#D62728\"", svg);
+            Assert.Equal(new byte[] { 0x89, 0x50, 0x4E, 0x47 }, png.Take(4).ToArray());
+            Assert.Equal("IHDR", System.Text.Encoding.ASCII.GetString(png, 12, 4));
+            Assert.Equal(40, (png[16] << 24) | (png[17] << 16) | (png[18] << 8) | png[19]);
+        }
+
+        [Fact]
+        public void RenderSpy_LargeMatrix_ShouldDownsample()
+        {
+            var matrix = new ConstraintMatrix();
+            for (int i = 0; i < 1000; i++)
+            {
+                matrix.RowNames.Add($"r{i}");
+                matrix.RowFamilies.Add("r");
+                matrix.ColumnNames.Add($"x{i}");
+                matrix.ColumnFamilies.Add("x");
+                matrix.Entries.Add((i, i, 1.0));
+            }
+
+            byte[] png = new MatrixRenderer(new MatrixRenderOptions { MaxSize = 100 }).RenderSpyPng(matrix);
+
+            Assert.Equal(100, (png[16] << 24) | (png[17] << 16) | (png[18] << 8) | png[19]);
+        }
+
+        [Fact]
+        public void RenderHeatmap_ShouldLabelFamiliesAndCounts()
+        {
+            var blocks = BuildMatrix().GetBlockStructure();
+
+            string svg = new MatrixRenderer().RenderHeatmapSvg(blocks);
+
+            Assert.Contains(">spill (1)</text>", svg);
+            Assert.Contains("3 nonzeros", svg);
+        }
+
+        [Fact]
+        public void GraphExport_ShouldWriteBipartiteDotAndGraphMl()
+        {
+            var matrix = BuildMatrix();
+            var exporter = new ModelGraphExporter(matrix);
+
+            string dot = exporter.ExportVariableConstraintDot();
+            string graphMl = exporter.ExportVariableConstraintGraphMl();
+
+            Assert.StartsWith("graph model {", dot);
+            Assert.Equal(7, dot.Split('\n').Count(l => l.Contains(" -- ")));
+            Assert.Contains("<graphml", graphMl);
+            Assert.Equal(8, graphMl.Split("<node ").Length - 1);
+
+            exporter.AggregateFamilies = true;
+            string aggregated = exporter.ExportVariableConstraintDot();
+            Assert.Contains("\"v:flow\" [label=\"flow (3)\"", aggregated);
+            Assert.Contains("\"c:balance\" -- \"v:flow\" [weight=3", aggregated);
+        }
+
+        [Fact]
+        public void GraphExport_ShouldWriteBlockHierarchyAndSetDependencies()
+        {
+            const string model =
+                "int n = 3;\n" +
+                "range Nodes = 1..n;\n" +
+                "{int} Hubs = {1, 3};\n" +
+                "// @block network\n" +
+                "float capacity[Nodes] = ...;\n" +
+                "dvar float+ flow[Nodes];\n" +
+                "// @block hubs\n" +
+                "forall(h in Hubs) hub: flow[h] <= capacity[h]; // not Nodes\n" +
+                "// @endblock\n" +
+                "// @endblock\n" +
+                "dvar float+ spill;\n" +
+                "maximize sum(i in Nodes) flow[i] - spill;\n";
+
+            string blocks = ModelGraphExporter.ExportBlockHierarchyDot(model);
+            Assert.StartsWith("digraph blocks {", blocks);
+            Assert.Contains("\"block:network\" [label=\"network (3)\", shape=folder", blocks);
+            Assert.Contains("\"block:network\" -> \"block:network/hubs\";", blocks);
+            Assert.Contains("\"block:network/hubs\" -> \"constraint:hub\";", blocks);
+            Assert.Contains("\"model\" -> \"variable:spill\";", blocks);
+
+            string sets = ModelGraphExporter.ExportSetDependencyDot(model);
+            Assert.Equal(new[]
+            {
+                "\"set:Nodes\" -> \"parameter:n\";",
+                "\"parameter:capacity\" -> \"set:Nodes\";",
+                "\"variable:flow\" -> \"set:Nodes\";",
+                "\"constraint:hub\" -> \"set:Hubs\";",
+                "\"objective\" -> \"set:Nodes\";"
+            }, sets.Split('\n').Where(l => l.Contains(" -> ")).Select(l => l.Trim()));
+            Assert.DoesNotContain("variable:spill", sets);
+
+            string graphMl = ModelGraphExporter.ExportSetDependencyGraphMl(model);
+            Assert.Contains("<graph id=\"sets\" edgedefault=\"directed\">", graphMl);
+            Assert.Equal(7, graphMl.Split("<node ").Length - 1);
+            Assert.Contains("<edge id=\"e0\" source=\"set:Nodes\" target=\"parameter:n\"/>", graphMl);
+        }
+    }
+}
@@ -0,0 +1,167 @@
+using System.Net;
+using System.Text.Json;
+using Core;
+using Core.Server;
+using Core.Solving;
+
+namespace Tests
+{
+    public class WebhookTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        /// <summary>
+        /// Receiver that answers with the scripted status codes in turn, then 200
+        /// </summary>
+        private class FakeReceiver : HttpMessageHandler
+        {
+            private readonly Queue<HttpStatusCode> script;
+
+            public FakeReceiver(params HttpStatusCode[] script)
+            {
+                this.script = new Queue<HttpStatusCode>(script);
+            }
+
+            public List<(string Url, string Body, Dictionary<string, string> Headers)> Received { get; } =
+                new List<(string Url, string Body, Dictionary<string, string> Headers)>();
+
+            protected override async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken cancellationToken)
+            {
+                string body = await request.Content!.ReadAsStringAsync(cancellationToken);
+                lock (Received)
+                {
+                    Received.Add((request.RequestUri!.ToString(), body,
+                        request.Headers.ToDictionary(h => h.Key, h => h.Value.Single())));
+                    return new HttpResponseMessage(script.Count > 0 ? script.Dequeue() : HttpStatusCode.OK);
+                }
+            }
+        }
+
+        /// <summary>
+        /// Reports an incumbent at 25% gap and then one at 0.4%, before finishing optimal
+        /// </summary>
+        private class ImprovingDriver : ISolverDriver
+        {
+            private readonly SolveMonitor monitor;
+
+            public ImprovingDriver(SolveMonitor monitor)
+            {
+                this.monitor = monitor;
+            }
+
+            public string Name => "Improving";
+
+            public SolveResult Solve(ModelManager manager)
+            {
+                monitor.Report(new SolverEvent { Kind = SolverEventKind.Incumbent, ObjectiveValue = 60, BestBound = 75 });
+                monitor.Report(new SolverEvent { Kind = SolverEventKind.Incumbent, ObjectiveValue = 74.7, BestBound = 75 });
+                return new SolveResult { Status = SolveStatus.Optimal, ObjectiveValue = 75, BestBound = 75 };
+            }
+        }
+
+        [Fact]
+        public async Task SolveAsync_ShouldPostSignedEventsToMatchingWebhooks()
+        {
+            var receiver = new FakeReceiver();
+            var webhooks = new WebhookNotifier(new HttpClient(receiver), () => new DateTime(2026, 10, 16, 12, 0, 0, DateTimeKind.Utc));
+            var host = new ModelHost { Webhooks = webhooks };
+            var model = host.Create("network", Model);
+            webhooks.Register(new WebhookSubscription { Url = new Uri("https://hooks.example.com/all"), Secret = "s3cret", ModelId = model.Id });
+            webhooks.Register(new WebhookSubscription { Url = new Uri("https://hooks.example.com/failures"), Events = WebhookEvents.SolveFailed });
+            webhooks.Register(new WebhookSubscription { Url = new Uri("https://hooks.example.com/other"), ModelId = "another-model" });
+
+            var monitor = new SolveMonitor();
+            await host.SolveAsync(model.Id, new ImprovingDriver(monitor), monitor: monitor);
+            await webhooks.FlushAsync();
+
+            Assert.All(receiver.Received, r => Assert.Equal("https://hooks.example.com/all", r.Url));
+            Assert.Equal(3, receiver.Received.Count);
+            foreach (var (_, body, headers) in receiver.Received)
+            {
+                Assert.Equal(WebhookNotifier.Sign("s3cret", body), headers[WebhookNotifier.SignatureHeader]);
+                Assert.Equal(32, headers[WebhookNotifier.DeliveryHeader].Length);
+            }
+
+            var payloads = receiver.Received.Select(r => JsonDocument.Parse(r.Body).RootElement).ToList();
+            var milestones = payloads.Where(p => p.GetProperty("event").GetString() == "solve.milestone")
+                .Select(p => p.GetProperty("milestone").GetString()).OrderBy(m => m);
+            Assert.Equal(new[] { "first-incumbent", "gap:0.01" }, milestones);
+
+            var completed = payloads.Single(p => p.GetProperty("event").GetString() == "solve.completed");
+            Assert.Equal("Optimal", completed.GetProperty("status").GetString());
+            Assert.Equal(75, completed.GetProperty("objectiveValue").GetDouble());
+            Assert.Equal("network", completed.GetProperty("modelName").GetString());
+        }
+
+        [Fact]
+        public async Task Delivery_ShouldRetryTransientFailuresOnly()
+        {
+            var receiver = new FakeReceiver(HttpStatusCode.ServiceUnavailable, HttpStatusCode.TooManyRequests, HttpStatusCode.BadRequest);
+            var webhooks = new WebhookNotifier(new HttpClient(receiver)) { RetryDelay = TimeSpan.FromMilliseconds(1) };
+            var host = new ModelHost { Webhooks = webhooks };
+            var model = host.Create("network", Model);
+            var subscription = webhooks.Register(new WebhookSubscription
+            {
+                Url = new Uri("https://hooks.example.com/"),
+                Events = WebhookEvents.SolveCompleted | WebhookEvents.SolveFailed
+            });
+
+            await host.SolveAsync(model.Id, new ImprovingDriver(new SolveMonitor()));
+            await webhooks.FlushAsync();
+
+            var delivery = Assert.Single(webhooks.History);
+            Assert.True(delivery.Delivered);
+            Assert.Equal(3, delivery.Attempts);
+            Assert.Equal("solve.completed", delivery.Event);
+
+            await host.SolveAsync(model.Id, new ImprovingDriver(new SolveMonitor()));
+            await webhooks.FlushAsync();
+
+            var rejected = webhooks.History.First();
+            Assert.False(rejected.Delivered);
+            Assert.Equal(1, rejected.Attempts);
+            Assert.Equal(HttpStatusCode.BadRequest, rejected.StatusCode);
+
+            Assert.True(webhooks.Unregister(subscription.Id));
+            Assert.Throws<ArgumentException>(() => webhooks.Register(new WebhookSubscription { Url = new Uri("ftp://hooks.example.com/") }));
+        }
+
+        [Theory]
+        [InlineData("http://127.0.0.1:8080/")]
+        [InlineData("http://localhost/")]
+        [InlineData("http://169.254.169.254/latest/meta-data/")]
+        [InlineData("http://10.1.2.3/")]
+        [InlineData("http://172.20.0.1/")]
+        [InlineData("http://192.168.1.10/")]
+        [InlineData("http://[::1]/")]
+        [InlineData("http://[fd00::1]/")]
+        [InlineData("http://[::ffff:127.0.0.1]/")]
+        public void Register_ShouldRejectInternalAddresses(string url)
+        {
+            var webhooks = new WebhookNotifier(new HttpClient(new FakeReceiver()));
+
+            Assert.Throws<ArgumentException>(() => webhooks.Register(new WebhookSubscription { Url = new Uri(url) }));
+
+            webhooks.AllowPrivateNetworks = true;
+            webhooks.Register(new WebhookSubscription { Url = new Uri(url) });
+        }
+
+        [Fact]
+        public void Register_ShouldOnlyAcceptAllowedHosts()
+        {
+            var webhooks = new WebhookNotifier(new HttpClient(new FakeReceiver())) { AllowedHosts = { "hooks.example.com", "*.ci.example.org" } };
+
+            webhooks.Register(new WebhookSubscription { Url = new Uri("https://hooks.example.com/solves") });
+            webhooks.Register(new WebhookSubscription { Url = new Uri("https://build.ci.example.org/") });
+            Assert.Throws<ArgumentException>(() => webhooks.Register(new WebhookSubscription { Url = new Uri("https://example.com/") }));
+            Assert.Throws<ArgumentException>(() => webhooks.Register(new WebhookSubscription { Url = new Uri("https://evil-hooks.example.com.attacker.net/") }));
+            Assert.True(WebhookNotifier.IsPublic(IPAddress.Parse("93.184.216.34")));
+            Assert.False(WebhookNotifier.IsPublic(IPAddress.Parse("100.64.0.1")));
+        }
+    }
+}
@@ -0,0 +1,64 @@
+using Core;
+using Core.Models;
+
+namespace Tests
+{
+    public class WindowTests : TestBase
+    {
+        private const string Model = @"
+range T = 1..5;
+dvar bool on[T];
+dvar bool start[T];
+dvar float+ x[T];
+forall(t in T) minUp: sum(s in window(T, t, 3)) start[s] <= on[t];
+forall(t in T) down: on[t] + sum(s in window(T, t, -2..-1)) start[s] <= 1;
+forall(t in T) envelope: sum(s in window(T, t, 0..1, wrap)) x[s] <= 10;
+forall(t in T) full: sum(s in window(T, t, 2, skip)) x[s] >= 1;
+";
+
+        [Fact]
+        public void ExpandAllTemplates_ShouldKeepTheWindowInsideTheSet()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            var result = parser.Parse(Model);
+            AssertNoErrors(result);
+
+            parser.ExpandAllTemplates(result);
+
+            AssertNoErrors(result);
+            var equations = manager.Equations.Where(e => e.Label != null).ToDictionary(e => e.Label!);
+            Assert.Equal(new[] { "on1", "start1" }, equations["minUp_1"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "on4", "start2", "start3", "start4" }, equations["minUp_4"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "on1" }, equations["down_1"].Coefficients.Keys);
+            Assert.Equal(new[] { "on3", "start1", "start2" }, equations["down_3"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "x1", "x5" }, equations["envelope_5"].Coefficients.Keys.OrderBy(k => k));
+            Assert.Equal(new[] { "full_2", "full_3", "full_4", "full_5" }, equations.Keys.Where(k => k.StartsWith("full")).OrderBy(k => k));
+        }
+
+        [Fact]
+        public void Evaluate_ShouldAggregateOverTheWindow()
+        {
+            var manager = CreateModelManager();
+            var parser = CreateParser(manager);
+            AssertNoErrors(parser.Parse("range T = 1..4;\nfloat demand[T] = [4, 9, 6, 3];"));
+
+            Assert.Equal(9, parser.ParseExpression("max(s in window(T, 4, 3)) demand[s]").Evaluate(manager));
+            Assert.Equal(3.5, parser.ParseExpression("avg(s in window(T, 1, -1..0, wrap)) demand[s]").Evaluate(manager));
+            Assert.Equal(0, parser.ParseExpression("count(s in window(T, 1, 2, skip)) 1").Evaluate(manager));
+        }
+
+        [Fact]
+        public void TryParse_ShouldReadTheSpanAndRejectUnknownPolicies()
+        {
+            Assert.True(Windows.TryParse("window(T, t, 4)", out var window, out _));
+            Assert.Equal((-3, 0, BoundaryPolicy.Clamp), (window!.From, window.To, window.Policy));
+            Assert.True(Windows.TryParse("window(T, t, 1..2, wrap)", out window, out _));
+            Assert.Equal((1, 2, BoundaryPolicy.Wrap), (window!.From, window.To, window.Policy));
+            Assert.True(Windows.TryParse("window(T, t, 3, sideways)", out window, out string error));
+            Assert.Null(window);
+            Assert.Contains("boundary policy", error);
+            Assert.Equal("T", Windows.SetName("window(T, t, 4)"));
+        }
+    }
+}
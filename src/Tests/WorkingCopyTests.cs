@@ -0,0 +1,72 @@
+using Core.Analysis;
+using Core.Server;
+
+namespace Tests
+{
+    public class WorkingCopyTests
+    {
+        private const string Model = @"range Nodes = 1..3;
+float capacity = 25;
+float price = 3;
+dvar float+ flow[Nodes];
+maximize sum(n in Nodes) price * flow[n];
+forall(n in Nodes) cap: flow[n] <= capacity;
+";
+
+        private static EntityDefinition Parameter(string name, string value) =>
+            new EntityDefinition { Kind = EntityKind.Parameter, Type = "float", Name = name, Value = value };
+
+        [Fact]
+        public void Publish_ShouldMergeCopyIntoSharedModelOnlyWhenPublished()
+        {
+            var host = new ModelHost();
+            var shared = host.Create("network", Model);
+            var copy = host.Branch(shared.Id);
+            Assert.Same(copy, host.Branch(shared.Id));
+            Assert.Equal(shared.Id, Assert.Single(host.GetWorkingCopies(shared.Id)).BranchOf);
+
+            Assert.True(host.ApplyEntity(copy.Id, Parameter("capacity", "30")).Success);
+            Assert.Contains("float capacity = 25;", shared.ModelText);
+
+            // Someone else edits the shared model meanwhile
+            Assert.True(host.ApplyEntity(shared.Id, Parameter("price", "4")).Success);
+
+            var result = host.Publish(copy.Id);
+
+            Assert.True(result.Success);
+            Assert.Equal(new[] { "parameter:capacity" }, result.Changed);
+            Assert.Equal(shared.Version, result.Version);
+            Assert.Contains("float capacity = 30;", shared.ModelText);
+            Assert.Contains("float price = 4;", shared.ModelText);
+            Assert.Equal(shared.ModelText, copy.ModelText);
+            Assert.Equal(shared.Version, copy.BaseVersion);
+        }
+
+        [Fact]
+        public void Publish_Conflict_ShouldBeRefusedUntilUpdatedOrOverwritten()
+        {
+            var host = new ModelHost();
+            var shared = host.Create("network", Model);
+            var copy = host.Branch(shared.Id);
+            host.ApplyEntity(copy.Id, Parameter("capacity", "30"));
+            host.ApplyEntity(shared.Id, Parameter("capacity", "40"));
+            int version = shared.Version;
+
+            var refused = host.Publish(copy.Id);
+
+            Assert.False(refused.Success);
+            Assert.Equal(new[] { "parameter:capacity" }, refused.Conflicts);
+            Assert.Equal(version, shared.Version);
+
+            // Updating keeps the copy's edit and reports it; publishing then overwrites theirs
+            var updated = host.Update(copy.Id);
+            Assert.Equal(new[] { "parameter:capacity" }, updated.Conflicts);
+            Assert.Contains("float capacity = 30;", copy.ModelText);
+            Assert.Equal(version, copy.BaseVersion);
+
+            Assert.False(host.Publish(copy.Id, expectedVersion: version - 1).Success);
+            Assert.True(host.Publish(copy.Id, expectedVersion: version).Success);
+            Assert.Contains("float capacity = 30;", shared.ModelText);
+        }
+    }
+}